@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newBackupCommands builds the backup commands:
+//
+//	backup          back up the database and prune old backups
+//	list-backups    list stored backups, newest first
+//	restore <name>  replace the database with a stored backup
+//	export <file>   write a backup archive to a file, or - for standard output
+//	import <file>   replace the database with a backup archive from a file
+func newBackupCommands() []*cobra.Command {
+	return []*cobra.Command{
+		{
+			Use:   "backup",
+			Short: "Back up the database and prune old backups",
+			Args:  cobra.NoArgs,
+			RunE: withApp(func(a *app, cmd *cobra.Command, _ []string) error {
+				backups, err := a.backupService()
+				if err != nil {
+					return err
+				}
+				backup, err := backups.Create(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("backup failed: %w", err)
+				}
+				log.Printf("Created backup %s (%d bytes)", backup.Name, backup.SizeBytes)
+				removed, err := backups.Prune(cmd.Context(), time.Now())
+				if err != nil {
+					return fmt.Errorf("pruning backups failed: %w", err)
+				}
+				for _, name := range removed {
+					log.Printf("Removed backup %s", name)
+				}
+				return nil
+			}),
+		},
+		{
+			Use:   "list-backups",
+			Short: "List stored backups, newest first",
+			Args:  cobra.NoArgs,
+			RunE: withApp(func(a *app, cmd *cobra.Command, _ []string) error {
+				backups, err := a.backupService()
+				if err != nil {
+					return err
+				}
+				list, err := backups.List(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to list backups: %w", err)
+				}
+				for _, backup := range list {
+					log.Printf("%s  %s  %d bytes", backup.Name, backup.CreatedAt.Format(time.RFC3339), backup.SizeBytes)
+				}
+				log.Printf("%d backups", len(list))
+				return nil
+			}),
+		},
+		{
+			Use:   "restore <name>",
+			Short: "Replace the database with a stored backup",
+			Args:  cobra.ExactArgs(1),
+			RunE: withApp(func(a *app, cmd *cobra.Command, args []string) error {
+				backups, err := a.backupService()
+				if err != nil {
+					return err
+				}
+				safety, err := backups.Restore(cmd.Context(), args[0])
+				if safety != nil {
+					log.Printf("Backed up the database to %s before restoring", safety.Name)
+				}
+				if err != nil {
+					return fmt.Errorf("restore failed: %w", err)
+				}
+				log.Printf("Restored the database from %s", args[0])
+				return nil
+			}),
+		},
+		{
+			Use:   "export <file>",
+			Short: "Write a backup archive to a file, or - for standard output",
+			Args:  cobra.ExactArgs(1),
+			RunE: withApp(func(a *app, cmd *cobra.Command, args []string) error {
+				backups, err := a.backupService()
+				if err != nil {
+					return err
+				}
+				var w io.Writer = cmd.OutOrStdout()
+				if args[0] != "-" {
+					file, err := os.Create(args[0])
+					if err != nil {
+						return fmt.Errorf("failed to create %s: %w", args[0], err)
+					}
+					defer file.Close()
+					w = file
+				}
+				size, err := backups.Export(cmd.Context(), w)
+				if err != nil {
+					return fmt.Errorf("export failed: %w", err)
+				}
+				log.Printf("Exported the database (%d bytes)", size)
+				return nil
+			}),
+		},
+		{
+			Use:   "import <file>",
+			Short: "Replace the database with a backup archive from a file",
+			Args:  cobra.ExactArgs(1),
+			RunE: withApp(func(a *app, cmd *cobra.Command, args []string) error {
+				backups, err := a.backupService()
+				if err != nil {
+					return err
+				}
+				file, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open %s: %w", args[0], err)
+				}
+				defer file.Close()
+				safety, err := backups.Import(cmd.Context(), file, args[0])
+				if safety != nil {
+					log.Printf("Backed up the database to %s before importing", safety.Name)
+				}
+				if err != nil {
+					return fmt.Errorf("import failed: %w", err)
+				}
+				log.Printf("Imported the database from %s", args[0])
+				return nil
+			}),
+		},
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/api"
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/credentials"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/encryption"
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+	"networth-dashboard/internal/storage"
+	"networth-dashboard/internal/telemetry"
+
+	"github.com/spf13/cobra"
+)
+
+// app is what every command shares: the configuration, a migrated database
+// and the field encryptor
+type app struct {
+	cfg             *config.Config
+	db              *database.DB
+	fieldEncryptor  *encryption.FieldEncryptor
+	shutdownTracing func(context.Context) error
+}
+
+// openApp loads the configuration, starts tracing and opens the database,
+// running its migrations
+func openApp() (*app, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Initialize tracing before the database so SQL spans are exported
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
+	db, err := database.Initialize(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	if db.Timescale {
+		log.Printf("INFO: Storing price and snapshot history in TimescaleDB")
+	}
+
+	fieldEncryptor, err := encryption.NewFieldEncryptor(cfg.Security.FieldEncryptionKey)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize field encryption: %w", err)
+	}
+
+	return &app{cfg: cfg, db: db, fieldEncryptor: fieldEncryptor, shutdownTracing: shutdownTracing}, nil
+}
+
+// Close closes the database and flushes traces
+func (a *app) Close() {
+	a.db.Close()
+	if err := a.shutdownTracing(context.Background()); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+}
+
+// store opens the document store holding attachments and backups
+func (a *app) store() (storage.Store, error) {
+	store, err := storage.New(a.cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+// backupService creates the backup service over the document store
+func (a *app) backupService() (*services.BackupService, error) {
+	store, err := a.store()
+	if err != nil {
+		return nil, err
+	}
+	return services.NewBackupService(store, a.cfg.Database, a.cfg.Backup, services.NewNotificationService(a.db.DB)), nil
+}
+
+// server creates the API server, whose services the commands reuse
+func (a *app) server() (*api.Server, error) {
+	store, err := a.store()
+	if err != nil {
+		return nil, err
+	}
+	pluginManager := plugins.NewManager(a.db.DB)
+	pluginManager.SetFieldEncryptor(a.fieldEncryptor)
+	return api.NewServer(a.cfg, a.db.DB, pluginManager, a.fieldEncryptor, store), nil
+}
+
+// withApp wraps a command body with opening and closing the app
+func withApp(run func(a *app, cmd *cobra.Command, args []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		return run(a, cmd, args)
+	}
+}
+
+// newRootCommand builds the command line. Without a subcommand the API
+// server runs, as it always has.
+func newRootCommand() *cobra.Command {
+	var configFile string
+	root := &cobra.Command{
+		Use:           "networth-dashboard",
+		Short:         "Net worth dashboard API server and administration commands",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          withApp(serve),
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// --config is shorthand for CONFIG_FILE, so reloads read the same file
+			if configFile != "" {
+				return os.Setenv("CONFIG_FILE", configFile)
+			}
+			return nil
+		},
+	}
+	root.PersistentFlags().StringVar(&configFile, "config", "", "YAML or TOML configuration file (default $CONFIG_FILE)")
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Run the API server (the default)",
+			Args:  cobra.NoArgs,
+			RunE:  withApp(serve),
+		},
+		&cobra.Command{
+			Use:   "migrate",
+			Short: "Bring the database schema up to date and exit",
+			Args:  cobra.NoArgs,
+			RunE: withApp(func(a *app, _ *cobra.Command, _ []string) error {
+				log.Printf("Database schema is up to date")
+				return nil
+			}),
+		},
+		newRefreshPricesCommand(),
+		newSnapshotNowCommand(),
+		newCreateUserCommand(),
+		newRotateKeysCommand(),
+		&cobra.Command{
+			Use:   "encrypt-fields",
+			Short: "Encrypt plaintext left in sensitive columns",
+			Args:  cobra.NoArgs,
+			RunE: withApp(func(a *app, _ *cobra.Command, _ []string) error {
+				counts, err := encryption.EncryptExistingData(a.db.DB, a.fieldEncryptor)
+				if err != nil {
+					return fmt.Errorf("failed to encrypt existing data: %w", err)
+				}
+				for column, count := range counts {
+					log.Printf("Encrypted %d values in %s", count, column)
+				}
+				return nil
+			}),
+		},
+	)
+	root.AddCommand(newDemoCommands()...)
+	root.AddCommand(newBackupCommands()...)
+	return root
+}
+
+// newRefreshPricesCommand refreshes stock and crypto prices once, as the
+// refresh endpoints do
+func newRefreshPricesCommand() *cobra.Command {
+	var all, force bool
+	cmd := &cobra.Command{
+		Use:   "refresh-prices",
+		Short: "Refresh stale stock prices and all crypto prices",
+		Args:  cobra.NoArgs,
+		RunE: withApp(func(a *app, cmd *cobra.Command, _ []string) error {
+			server, err := a.server()
+			if err != nil {
+				return err
+			}
+
+			stocks := server.RefreshStockPrices(cmd.Context(), !all && !force, force)
+			for _, result := range stocks.Results {
+				if !result.Updated {
+					log.Printf("%s failed: %s", result.Symbol, result.Error)
+				}
+			}
+			log.Printf("Stocks: %d updated, %d failed, %d skipped as fresh, %d paused, %d deferred by the rate limit",
+				stocks.UpdatedSymbols, stocks.FailedSymbols, len(stocks.SkippedSymbols), len(stocks.PausedSymbols), len(stocks.DeferredSymbols))
+
+			crypto, err := server.RefreshCryptoPrices()
+			if err != nil {
+				return fmt.Errorf("failed to refresh crypto prices: %w", err)
+			}
+			log.Printf("Crypto: %d updated, %d failed, %d paused", crypto.UpdatedSymbols, crypto.FailedSymbols, len(crypto.PausedSymbols))
+
+			if stocks.TotalSymbols > 0 && stocks.UpdatedSymbols == 0 {
+				return fmt.Errorf("no stock prices could be refreshed")
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "refresh every stock symbol, not only those with stale prices")
+	cmd.Flags().BoolVar(&force, "force", false, "refresh every stock symbol, bypassing the price cache")
+	return cmd
+}
+
+// newSnapshotNowCommand records today's net worth snapshot
+func newSnapshotNowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "snapshot-now",
+		Short: "Record today's net worth snapshot from current values",
+		Args:  cobra.NoArgs,
+		RunE: withApp(func(a *app, _ *cobra.Command, _ []string) error {
+			breakdown, err := repository.New(a.db.DB, a.fieldEncryptor).NetWorth.Breakdown()
+			if err != nil {
+				return fmt.Errorf("failed to calculate net worth: %w", err)
+			}
+			if err := services.NewNetWorthHistoryService(a.db.DB).RecordSnapshot(breakdown, time.Now()); err != nil {
+				return err
+			}
+			log.Printf("Recorded net worth of %s", breakdown.NetWorth().StringFixed(2))
+			return nil
+		}),
+	}
+}
+
+// newCreateUserCommand adds a user. The password is read from standard
+// input unless --password is given, so it stays out of shell history.
+func newCreateUserCommand() *cobra.Command {
+	var input services.UserInput
+	cmd := &cobra.Command{
+		Use:   "create-user <username>",
+		Short: "Add a user who can sign in to the dashboard",
+		Args:  cobra.ExactArgs(1),
+		RunE: withApp(func(a *app, cmd *cobra.Command, args []string) error {
+			input.Username = args[0]
+			if input.Password == "" {
+				password, err := readLine(cmd.InOrStdin(), "Password: ")
+				if err != nil {
+					return fmt.Errorf("failed to read password: %w", err)
+				}
+				input.Password = password
+			}
+			user, err := services.NewUserService(a.db.DB, a.cfg.Auth.SessionTTL).Create(input)
+			if err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			log.Printf("Created %s %s (id %d)", user.Role, user.Username, user.ID)
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&input.Role, "role", services.RoleViewer, "admin, editor or viewer")
+	cmd.Flags().StringVar(&input.DisplayName, "display-name", "", "name shown in the dashboard")
+	cmd.Flags().StringVar(&input.Password, "password", "", "password (read from standard input when not given)")
+	return cmd
+}
+
+// newRotateKeysCommand re-encrypts stored secrets under new keys. The current
+// keys come from the configuration; once it succeeds, the configuration must
+// be switched to the new keys before the server starts again.
+func newRotateKeysCommand() *cobra.Command {
+	var fieldKey, credentialKey string
+	cmd := &cobra.Command{
+		Use:   "rotate-keys",
+		Short: "Re-encrypt sensitive columns and stored credentials under new keys",
+		Args:  cobra.NoArgs,
+		RunE: withApp(func(a *app, _ *cobra.Command, _ []string) error {
+			if fieldKey == "" && credentialKey == "" {
+				return fmt.Errorf("give --field-key, --credential-key or both")
+			}
+
+			if fieldKey != "" {
+				newEncryptor, err := encryption.NewFieldEncryptor(fieldKey)
+				if err != nil {
+					return fmt.Errorf("invalid new field encryption key: %w", err)
+				}
+				counts, err := encryption.RotateKey(a.db.DB, a.fieldEncryptor, newEncryptor)
+				for column, count := range counts {
+					log.Printf("Re-encrypted %d values in %s", count, column)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to rotate the field encryption key: %w", err)
+				}
+				log.Printf("Set FIELD_ENCRYPTION_KEY to the new key before starting the server")
+			}
+
+			if credentialKey != "" {
+				count, err := credentials.RotateKey(a.db.DB, a.cfg.Security.CredentialKey, credentialKey)
+				if err != nil {
+					return fmt.Errorf("failed to rotate the credential key: %w", err)
+				}
+				log.Printf("Re-encrypted %d stored credentials", count)
+				log.Printf("Set CREDENTIAL_KEY to the new key before starting the server")
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&fieldKey, "field-key", "", "new FIELD_ENCRYPTION_KEY for wallet addresses and account numbers")
+	cmd.Flags().StringVar(&credentialKey, "credential-key", "", "new CREDENTIAL_KEY for stored provider credentials")
+	return cmd
+}
+
+// readLine prompts on standard error and reads one line from r
+func readLine(r io.Reader, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/spf13/cobra"
+)
+
+// newDemoCommands builds the demo data commands:
+//
+//	seed-demo  load the demo portfolio into a database without holdings
+//	wipe-demo  remove the demo portfolio and its history
+func newDemoCommands() []*cobra.Command {
+	return []*cobra.Command{
+		{
+			Use:     "seed-demo",
+			Aliases: []string{"seed-demo-data"},
+			Short:   "Load the demo portfolio into a database without holdings",
+			Args:    cobra.NoArgs,
+			RunE: withApp(func(a *app, _ *cobra.Command, _ []string) error {
+				status, err := services.NewDemoDataService(a.db.DB).Seed(time.Now())
+				if err != nil {
+					return fmt.Errorf("failed to load demo data: %w", err)
+				}
+				for table, count := range status.Records {
+					log.Printf("Added %d rows to %s", count, table)
+				}
+				return nil
+			}),
+		},
+		{
+			Use:     "wipe-demo",
+			Aliases: []string{"wipe-demo-data"},
+			Short:   "Remove the demo portfolio and its history",
+			Args:    cobra.NoArgs,
+			RunE: withApp(func(a *app, _ *cobra.Command, _ []string) error {
+				removed, err := services.NewDemoDataService(a.db.DB).Wipe()
+				if errors.Is(err, services.ErrNoDemoData) {
+					log.Printf("No demo data is loaded")
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("failed to remove demo data: %w", err)
+				}
+				for table, count := range removed {
+					log.Printf("Removed %d rows from %s", count, table)
+				}
+				return nil
+			}),
+		},
+	}
+}
+
+// seedDemoMode loads demo data at startup when DEMO_MODE is set, skipping
+// databases that already hold demo or real data
+func seedDemoMode(demo *services.DemoDataService) {
+	_, err := demo.Seed(time.Now())
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrDemoDataLoaded):
+	case errors.Is(err, services.ErrDatabaseNotEmpty):
+		log.Printf("Demo mode is on but the database has holdings; not loading demo data")
+	default:
+		log.Printf("Failed to load demo data: %v", err)
+	}
+}
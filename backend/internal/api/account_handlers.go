@@ -0,0 +1,343 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// maxLinkedHoldings caps how many holdings one link request may list
+const maxLinkedHoldings = 500
+
+// ownerNetWorth is the net worth of the holdings in one owner's accounts
+type ownerNetWorth struct {
+	Owner               string                     `json:"owner"`
+	Custodial           bool                       `json:"custodial"`
+	NetWorth            decimal.Decimal            `json:"net_worth"`
+	TotalAssets         decimal.Decimal            `json:"total_assets"`
+	UnvestedEquityValue decimal.Decimal            `json:"unvested_equity_value"`
+	Components          []models.NetWorthComponent `json:"components"`
+}
+
+// excludeCustodial reports whether a net worth request leaves out custodial
+// accounts with include_custodial=false
+func excludeCustodial(c *gin.Context) bool {
+	return c.Query("include_custodial") == "false"
+}
+
+// respondAccountError maps account repository errors to HTTP responses
+func (s *Server) respondAccountError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidHoldingType), errors.Is(err, repository.ErrUnknownHolding),
+		errors.Is(err, repository.ErrInstitutionMismatch), errors.Is(err, repository.ErrUnknownMember):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrAccountInUse), errors.Is(err, repository.ErrDuplicateInAccount):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.respondRepositoryError(c, err, notFoundMsg, failureMsg)
+	}
+}
+
+// bindAccount binds and validates an account body
+func bindAccount(c *gin.Context) (*models.AccountInput, bool) {
+	var input models.AccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, false
+	}
+
+	input.AccountName = strings.TrimSpace(input.AccountName)
+	input.AccountType = strings.TrimSpace(input.AccountType)
+	input.Institution = strings.TrimSpace(input.Institution)
+	if input.AccountName == "" || input.AccountType == "" || input.Institution == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_name, account_type and institution are required"})
+		return nil, false
+	}
+	return &input, true
+}
+
+// @Summary Get all accounts
+// @Description List accounts with the number of holdings filed under each and their value toward total assets. Manual entries are each filed under a "manual" account of their own until linked to an "institution" account. owner is self, spouse or child, with owner_member_id naming the child's household member; custodial accounts can be left out of net worth.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "List of accounts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /accounts [get]
+func (s *Server) getAccounts(c *gin.Context) {
+	accounts, err := s.repos.Accounts.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"count":    len(accounts),
+	})
+}
+
+// @Summary Get account by ID
+// @Description Get an account with the holdings filed under it
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} map[string]interface{} "Account details"
+// @Failure 400 {object} map[string]interface{} "Invalid account ID"
+// @Failure 404 {object} map[string]interface{} "Account not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /accounts/{id} [get]
+func (s *Server) getAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	account, err := s.repos.Accounts.Get(id)
+	if err != nil {
+		s.respondAccountError(c, err, "Account not found", "Failed to fetch account")
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// @Summary Create new account
+// @Description Create an institution account, such as a brokerage or bank account, for holdings to be linked to
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param account body map[string]interface{} true "Account (account_name, account_type, institution, external_account_id, owner: self, spouse or child, owner_member_id: the child's household member, custodial: defaults to true for a child)"
+// @Success 201 {object} map[string]interface{} "Account created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /accounts [post]
+func (s *Server) createAccount(c *gin.Context) {
+	input, ok := bindAccount(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.Accounts.Create(*input)
+	if err != nil {
+		s.respondAccountError(c, err, "Account not found", "Failed to create account")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Account created successfully",
+	})
+}
+
+// @Summary Update account
+// @Description Rename an account or change its type, institution, external ID or owner. A blank owner is self.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param account body map[string]interface{} true "Account (account_name, account_type, institution, external_account_id, owner, owner_member_id, custodial)"
+// @Success 200 {object} map[string]interface{} "Account updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Account not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /accounts/{id} [put]
+func (s *Server) updateAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	input, ok := bindAccount(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.Accounts.Update(id, *input); err != nil {
+		s.respondAccountError(c, err, "Account not found", "Failed to update account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account updated successfully",
+	})
+}
+
+// @Summary Delete account
+// @Description Delete an account with no holdings, balances or manual entries filed under it
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} map[string]interface{} "Account deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid account ID"
+// @Failure 404 {object} map[string]interface{} "Account not found"
+// @Failure 409 {object} map[string]interface{} "Account still has holdings"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /accounts/{id} [delete]
+func (s *Server) deleteAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	if err := s.repos.Accounts.Delete(id); err != nil {
+		s.respondAccountError(c, err, "Account not found", "Failed to delete account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deleted successfully",
+	})
+}
+
+// @Summary Link holdings to account
+// @Description File holdings under an account, so the institution summary and accounts reflect where they are held. Stock, cash and crypto holdings must be at the account's institution. The per-entry accounts the holdings leave are deleted once empty, and each move is audited as an update of the holding.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param request body map[string]interface{} true "Holdings to link: {\"holdings\": [{\"holding_type\": \"stock_holding\", \"holding_id\": 1}]}"
+// @Success 200 {object} map[string]interface{} "Holdings linked successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request, unknown holding or another institution"
+// @Failure 404 {object} map[string]interface{} "Account not found"
+// @Failure 409 {object} map[string]interface{} "Account already holds the same holding"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /accounts/{id}/holdings [post]
+func (s *Server) linkAccountHoldings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var request struct {
+		Holdings []models.HoldingRef `json:"holdings" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if len(request.Holdings) == 0 || len(request.Holdings) > maxLinkedHoldings {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("holdings must list between 1 and %d holdings", maxLinkedHoldings)})
+		return
+	}
+
+	before := make([]map[string]interface{}, len(request.Holdings))
+	for i, ref := range request.Holdings {
+		before[i] = s.auditService.Snapshot(ref.HoldingType, ref.HoldingID)
+	}
+	removed, err := s.repos.Accounts.Link(id, request.Holdings)
+	if err != nil {
+		s.respondAccountError(c, err, "Account not found", "Failed to link holdings")
+		return
+	}
+
+	actor := requestActor(c)
+	for i, ref := range request.Holdings {
+		ref := ref
+		after := s.auditService.Snapshot(ref.HoldingType, ref.HoldingID)
+		s.recordAudit(services.AuditActionUpdate, ref.HoldingType, &ref.HoldingID, actor, c.ClientIP(), before[i], after)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Holdings linked successfully",
+		"count":            len(request.Holdings),
+		"removed_accounts": removed,
+	})
+}
+
+// @Summary Get net worth by owner
+// @Description Split net worth by the owner of the account each holding is filed under: self, spouse and each child by household member name, self first. An owner's custodial accounts, such as UTMA and 529 accounts, are listed apart from the rest; personal totals leave them out, as GET /net-worth?include_custodial=false does. Holdings without an account belong to self.
+// @Tags net-worth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Net worth of each owner with personal and custodial totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /net-worth/owners [get]
+func (s *Server) getOwnerNetWorth(c *gin.Context) {
+	breakdowns, err := s.repos.NetWorth.OwnerBreakdowns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split net worth between owners"})
+		return
+	}
+
+	var personal, custodial models.NetWorthBreakdown
+	owners := make([]ownerNetWorth, 0, len(breakdowns))
+	for _, owner := range breakdowns {
+		for _, component := range owner.Breakdown.Components() {
+			if owner.Custodial {
+				custodial.AddComponent(component.Key, component.Value)
+			} else {
+				personal.AddComponent(component.Key, component.Value)
+			}
+		}
+		owners = append(owners, ownerNetWorth{
+			Owner:               owner.Owner,
+			Custodial:           owner.Custodial,
+			NetWorth:            owner.Breakdown.NetWorth(),
+			TotalAssets:         owner.Breakdown.TotalAssets(),
+			UnvestedEquityValue: owner.Breakdown.UnvestedEquityValue,
+			Components:          owner.Breakdown.Components(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"owners": owners,
+		"personal": gin.H{
+			"net_worth":    personal.NetWorth(),
+			"total_assets": personal.TotalAssets(),
+		},
+		"custodial": gin.H{
+			"net_worth":    custodial.NetWorth(),
+			"total_assets": custodial.TotalAssets(),
+		},
+	})
+}
+
+// respondPersonalNetWorth answers a net worth request that leaves out
+// custodial accounts. It is computed fresh rather than cached.
+func (s *Server) respondPersonalNetWorth(c *gin.Context) {
+	breakdown, err := s.repos.NetWorth.PersonalBreakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+	summary, err := s.summarizeNetWorth(breakdown)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// respondPersonalBreakdown answers a net worth breakdown request that leaves
+// out custodial accounts
+func (s *Server) respondPersonalBreakdown(c *gin.Context) {
+	breakdown, err := s.repos.NetWorth.PersonalBreakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth breakdown"})
+		return
+	}
+	tree, err := s.repos.NetWorth.PersonalTree(s.config.Risk.StablecoinsAsCash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth breakdown"})
+		return
+	}
+	if s.config.Risk.StablecoinsAsCash {
+		breakdown = breakdown.StablecoinsAsCash()
+	}
+	c.JSON(http.StatusOK, s.breakdownResponse(breakdown, tree))
+}
@@ -0,0 +1,458 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/cache"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultGainsHistoryDays is the range of a gains history request without from
+	defaultGainsHistoryDays = 90
+	// defaultBenchmarkDays is the range of a benchmark comparison without from
+	defaultBenchmarkDays = 365
+	// defaultBenchmarks are compared when none are requested
+	defaultBenchmarks = "SPY,QQQ," + services.BenchmarkSixtyForty
+	// maxBenchmarks bounds a single comparison
+	maxBenchmarks = 10
+	// maxWhatIfActions bounds a single what-if scenario
+	maxWhatIfActions = 20
+	// fireCashFlowMonths is how many full months of tracked income and
+	// expenses FIRE metrics default to
+	fireCashFlowMonths = 12
+)
+
+// parseDateRange reads optional from and to query dates (YYYY-MM-DD), defaulting
+// to the defaultDays before today and today
+func parseDateRange(c *gin.Context, defaultDays int) (time.Time, time.Time, bool) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -defaultDays)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// @Summary Get unrealized gains history
+// @Description Unrealized gains over time for the whole portfolio and per symbol, from daily position snapshots valued with the stock price history. Each point splits the change in market value since the previous point into contributions (shares bought or sold) and market change (price movement on the shares already held).
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 90 days before to)"
+// @Param to query string false "End date (YYYY-MM-DD, default today)"
+// @Param interval query string false "Sampling interval: day, week or month (default day)"
+// @Param symbol query string false "Only return the series for this symbol"
+// @Param tag query string false "Only symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Success 200 {object} map[string]interface{} "Gains history"
+// @Failure 400 {object} map[string]interface{} "Invalid parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/gains-history [get]
+func (s *Server) getGainsHistory(c *gin.Context) {
+	from, to, ok := parseDateRange(c, defaultGainsHistoryDays)
+	if !ok {
+		return
+	}
+
+	interval := c.DefaultQuery("interval", services.GainsIntervalDay)
+	symbol := strings.ToUpper(strings.TrimSpace(c.Query("symbol")))
+
+	matcher, ok := s.symbolTagMatcher(c)
+	if !ok {
+		return
+	}
+
+	history, err := s.gainsHistoryService.History(from, to, interval, symbol, symbolFilter(matcher))
+	if errors.Is(err, services.ErrInvalidGainsInterval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate gains history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary Compare performance with benchmarks
+// @Description Compare the portfolio's time-weighted return (price movement only, excluding contributions) with benchmark ETFs over the same snapshot dates. A benchmark is a symbol (SPY), the 60/40 preset (60% SPY, 40% AGG) or a blend such as VTI:70+BND:30. Benchmarks use the recorded stock price history; one whose prices start after the range start is returned with available=false.
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param benchmarks query string false "Comma-separated benchmarks (default SPY,QQQ,60/40)"
+// @Param from query string false "Start date (YYYY-MM-DD, default 365 days before to)"
+// @Param to query string false "End date (YYYY-MM-DD, default today)"
+// @Param interval query string false "Sampling interval: day, week or month (default week)"
+// @Param tag query string false "Only symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Success 200 {object} map[string]interface{} "Benchmark comparison"
+// @Failure 400 {object} map[string]interface{} "Invalid parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/benchmark [get]
+func (s *Server) getBenchmarkComparison(c *gin.Context) {
+	from, to, ok := parseDateRange(c, defaultBenchmarkDays)
+	if !ok {
+		return
+	}
+
+	specs := strings.Split(c.DefaultQuery("benchmarks", defaultBenchmarks), ",")
+	if len(specs) > maxBenchmarks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d benchmarks can be compared at once", maxBenchmarks)})
+		return
+	}
+	benchmarks := make([]services.Benchmark, 0, len(specs))
+	for _, spec := range specs {
+		benchmark, err := services.ParseBenchmark(spec)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		benchmarks = append(benchmarks, benchmark)
+	}
+
+	matcher, ok := s.symbolTagMatcher(c)
+	if !ok {
+		return
+	}
+
+	interval := c.DefaultQuery("interval", services.GainsIntervalWeek)
+	comparison, err := s.benchmarkService.Compare(from, to, interval, benchmarks, symbolFilter(matcher))
+	if errors.Is(err, services.ErrInvalidGainsInterval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare with benchmarks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+// @Summary Get portfolio exposure
+// @Description Break down stock holdings (including vested equity) by sector, country and region, with each position's share of the stock portfolio and of total assets, and concentration measures
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param tag query string false "Only symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Param look_through query bool false "Split ETFs and mutual funds, including target-date funds, across the sectors and countries they invest in"
+// @Success 200 {object} map[string]interface{} "Exposure breakdown"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/exposure [get]
+func (s *Server) getExposure(c *gin.Context) {
+	stocks, _, err := cache.GetOrLoad(s.cache, cache.KeyConsolidatedStocks, s.config.Cache.TTL, s.repos.Stocks.ListConsolidated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock holdings"})
+		return
+	}
+
+	breakdown, _, err := cache.GetOrLoad(s.cache, cache.KeyNetWorthBreakdown, s.config.Cache.TTL, s.repos.NetWorth.Breakdown)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate total assets"})
+		return
+	}
+
+	matcher, ok := s.symbolTagMatcher(c)
+	if !ok {
+		return
+	}
+	stocks = filterStocksByTags(stocks, matcher)
+
+	symbols := make([]string, 0, len(stocks))
+	for _, stock := range stocks {
+		symbols = append(symbols, strings.ToUpper(stock.Symbol))
+	}
+	metadata, err := s.securityMetadataService.Lookup(symbols)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security metadata"})
+		return
+	}
+
+	var lookThrough map[string]services.FundExposure
+	if c.Query("look_through") == "true" {
+		lookThrough, err = s.securityMetadataService.LookThrough(symbols)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fund compositions"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, services.BuildExposure(stocks, metadata, lookThrough, breakdown.TotalAssets().InexactFloat64()))
+}
+
+// @Summary Model a what-if scenario
+// @Description Apply hypothetical actions to current holdings and return projected net worth, allocation and estimated tax without saving anything. Actions run in order: sell_stock (symbol, shares, optional price per share) sells the oldest lots first and adds the after-tax proceeds to cash; pay_off_mortgage (property_id) pays the owner's share of the mortgage from cash; buy_property (price, down_payment, optional closing_costs) pays the down payment and closing costs from cash and finances the rest. sell_property (property_id, optional price and closing_costs for the whole property) sells the owner's share, pays off the mortgage and taxes the gain over the adjusted basis, with depreciation taken taxed at the recapture rate. exercise_options (grant_id, shares, optional price per share and sell) exercises ISO or NSO options, paying the strike price from cash: an NSO spread is ordinary income taxed at the short-term rate; an ISO spread is an AMT preference when the shares are held, or ordinary income when sold the same day. Unvested options can only be exercised on a grant with an 83(b) election, and expired options not at all.
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param scenario body map[string]interface{} true "Scenario: {\"actions\": [{\"type\": \"sell_stock\", \"symbol\": \"AAPL\", \"shares\": 10}]}"
+// @Success 200 {object} map[string]interface{} "Current and projected net worth with per-action effects"
+// @Failure 400 {object} map[string]interface{} "Invalid scenario"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/what-if [post]
+func (s *Server) postWhatIf(c *gin.Context) {
+	var request struct {
+		Actions []services.WhatIfAction `json:"actions" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if len(request.Actions) == 0 || len(request.Actions) > maxWhatIfActions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("actions must list between 1 and %d actions", maxWhatIfActions)})
+		return
+	}
+
+	breakdown, err := s.repos.NetWorth.Breakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+	stocks, err := s.repos.Stocks.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock holdings"})
+		return
+	}
+	properties, err := s.repos.RealEstate.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch real estate properties"})
+		return
+	}
+
+	grants, err := s.repos.Equity.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch equity grants"})
+		return
+	}
+
+	basisAdditions, err := s.repos.PropertyLedger.BasisAdditions(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch property basis additions"})
+		return
+	}
+
+	scenario := services.NewWhatIfScenario(breakdown, stocks, properties,
+		s.config.Tax.Current().ShortTermCapitalGainsPercent, s.config.Tax.Current().LongTermCapitalGainsPercent, time.Now()).
+		WithDepreciation(basisAdditions, s.config.Tax.Current().DepreciationRecapturePercent).
+		WithGrants(grants)
+	result, err := scenario.Run(request.Actions)
+	if errors.Is(err, services.ErrInvalidWhatIf) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to model scenario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Get FIRE metrics
+// @Description Measure progress toward financial independence. The FI number is annual expenses divided by the safe withdrawal rate, and progress is invested assets (stocks, vested equity, cash, crypto, private investments and fixed income) as a percentage of it. Annual income and expenses default to the last 12 full months of cash-flow tracking, and the savings rate is the share of income not spent. The projected FI date is the first month projected invested assets reach the FI number: cash holdings follow the balance projection, with interest, recurring contributions and employer match, and the rest of the invested assets grow at return_rate.
+// @Tags analytics
+// @Produce json
+// @Param swr query number false "Safe withdrawal rate in percent a year (default 4)"
+// @Param annual_expenses query number false "Annual expenses in retirement (default: the last 12 months of tracked expenses)"
+// @Param annual_income query number false "Annual income for the savings rate (default: the last 12 months of tracked income)"
+// @Param return_rate query number false "Annual return on invested assets other than cash, in percent (default 5)"
+// @Param months query int false "Months to project (default 600, max 600)"
+// @Param include_cash_flow query bool false "Add average monthly net savings from cash-flow tracking to the projection"
+// @Param include_custodial query bool false "false to leave out accounts owned by a child (default true)"
+// @Param compounding query string false "How cash interest rates compound: daily, monthly (default), quarterly or annually"
+// @Success 200 {object} map[string]interface{} "FI number, progress, savings rate and projected FI date"
+// @Failure 400 {object} map[string]interface{} "Invalid parameter, or no expenses tracked or given"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/fire [get]
+func (s *Server) getFIRE(c *gin.Context) {
+	months := maxProjectionMonths
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > maxProjectionMonths {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("months must be between 1 and %d", maxProjectionMonths)})
+			return
+		}
+		months = parsed
+	}
+	withdrawalRate, ok := parsePercentQuery(c, "swr", services.DefaultWithdrawalRate)
+	if !ok {
+		return
+	}
+	if withdrawalRate <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "swr must be more than 0"})
+		return
+	}
+	returnRate, ok := parsePercentQuery(c, "return_rate", services.DefaultFIREReturnRate)
+	if !ok {
+		return
+	}
+	annualExpenses, ok := parseAmountQuery(c, "annual_expenses")
+	if !ok {
+		return
+	}
+	annualIncome, ok := parseAmountQuery(c, "annual_income")
+	if !ok {
+		return
+	}
+	compounding, ok := parseCompounding(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if annualExpenses == nil || annualIncome == nil {
+		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		summary, err := s.repos.CashFlow.Summary(thisMonth.AddDate(0, -fireCashFlowMonths, 0), thisMonth)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize cash flow"})
+			return
+		}
+		if annualExpenses == nil && summary.TotalExpenses.IsPositive() {
+			expenses := summary.TotalExpenses.InexactFloat64()
+			annualExpenses = &expenses
+		}
+		if annualIncome == nil && summary.TotalIncome.IsPositive() {
+			income := summary.TotalIncome.InexactFloat64()
+			annualIncome = &income
+		}
+	}
+	if annualExpenses == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "annual_expenses is required when no expenses are tracked"})
+		return
+	}
+
+	var cashFlowSavings float64
+	if c.Query("include_cash_flow") == "true" {
+		savings, err := s.recentMonthlyNetSavings(now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize cash flow"})
+			return
+		}
+		cashFlowSavings = savings
+	}
+
+	loadBreakdown := s.repos.NetWorth.Breakdown
+	if excludeCustodial(c) {
+		loadBreakdown = s.repos.NetWorth.PersonalBreakdown
+	}
+	breakdown, err := loadBreakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+	projection, err := s.contributionService.Project(months, now, cashFlowSavings, compounding)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to project balances"})
+		return
+	}
+
+	input := services.FIREInput{
+		WithdrawalRate: withdrawalRate,
+		ReturnRate:     returnRate,
+		AnnualExpenses: *annualExpenses,
+		AnnualIncome:   annualIncome,
+	}
+	c.JSON(http.StatusOK, services.ProjectFIRE(input, services.InvestedAssets(breakdown), projection))
+}
+
+// @Summary Simulate retirement withdrawal strategies
+// @Description Run a Monte Carlo simulation of retiring on today's invested assets (stocks, vested equity, cash, crypto, private investments and fixed income) in their current allocation. Each simulated year draws an inflation-adjusted return for every asset class, moving with a shared stock market draw, and every strategy is run on the same draws. fixed withdraws the same amount every year; guardrails cuts spending 10% when the withdrawal rate rises 20% above the starting rate and raises it 10% when it falls 20% below; bucket spends from cash, refilling two years of spending from stocks after up years and from bonds after down years. Spending is annual_spending, otherwise withdrawal_rate percent of invested assets, otherwise the last 12 months of tracked expenses, otherwise 4%. Each strategy reports the percentage of simulations that ran out of money and the median and 10th and 90th percentile ending balances in today's dollars.
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param simulation body map[string]interface{} false "Simulation: {\"strategies\": [\"fixed\", \"guardrails\", \"bucket\"], \"years\": 30, \"simulations\": 1000, \"annual_spending\": 60000, \"seed\": 42}"
+// @Param include_custodial query bool false "false to leave out accounts owned by a child (default true)"
+// @Success 200 {object} map[string]interface{} "Allocation, assumptions and results per strategy"
+// @Failure 400 {object} map[string]interface{} "Invalid simulation, or no invested assets"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/withdrawal-simulation [post]
+func (s *Server) postWithdrawalSimulation(c *gin.Context) {
+	var input services.WithdrawalSimulationInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+
+	if input.AnnualSpending == nil && input.WithdrawalRate == 0 {
+		now := time.Now()
+		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		summary, err := s.repos.CashFlow.Summary(thisMonth.AddDate(0, -fireCashFlowMonths, 0), thisMonth)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize cash flow"})
+			return
+		}
+		if summary.TotalExpenses.IsPositive() {
+			spending := summary.TotalExpenses.InexactFloat64()
+			input.AnnualSpending = &spending
+		}
+	}
+
+	loadBreakdown := s.repos.NetWorth.Breakdown
+	if excludeCustodial(c) {
+		loadBreakdown = s.repos.NetWorth.PersonalBreakdown
+	}
+	breakdown, err := loadBreakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+
+	simulation, err := services.SimulateWithdrawals(breakdown, input)
+	if errors.Is(err, services.ErrInvalidWithdrawalSimulation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate withdrawals"})
+		return
+	}
+	c.JSON(http.StatusOK, simulation)
+}
+
+// parseAmountQuery reads an optional non-negative amount from the named query
+// parameter, nil when it is absent
+func parseAmountQuery(c *gin.Context, name string) (*float64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a non-negative amount", name)})
+		return nil, false
+	}
+	return &amount, true
+}
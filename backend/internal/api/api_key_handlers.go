@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List API keys
+// @Description Every API key with its scope, asset classes, expiry, last use and revocation, newest first (admin only). Keys themselves are only shown when created. asset_classes lists the classes a key can be limited to.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} map[string]interface{} "API keys"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api-keys [get]
+func (s *Server) getAPIKeys(c *gin.Context) {
+	keys, err := s.apiKeyService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys":      keys,
+		"count":         len(keys),
+		"asset_classes": services.APIKeyAssetClasses,
+	})
+}
+
+// @Summary Create API key
+// @Description Create a key external tools send as "X-API-Key: <key>" or "Authorization: Bearer <key>" instead of signing in (admin only). read keys act as viewers and write keys as editors; neither reaches admin endpoints. asset_classes limits a key to those classes' endpoints. The key is only returned here.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "API key: {\"name\": \"Advisor\", \"scope\": \"read\", \"asset_classes\": [\"stocks\"], \"expires_in_days\": 90}"
+// @Success 201 {object} map[string]interface{} "Created API key"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api-keys [post]
+func (s *Server) createAPIKey(c *gin.Context) {
+	var input services.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	key, err := s.apiKeyService.Create(input, requestActor(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	setAuditEntityID(c, key.ID)
+	c.JSON(http.StatusCreated, key)
+}
+
+// @Summary Revoke API key
+// @Description Stop an API key from working (admin only). The key stays listed with its revocation time.
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]interface{} "API key revoked"
+// @Failure 400 {object} map[string]interface{} "Invalid API key ID"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api-keys/{id} [delete]
+func (s *Server) revokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	err = s.apiKeyService.Revoke(id, time.Now())
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
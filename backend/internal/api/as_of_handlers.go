@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// netWorthAsOf is net worth at the end of a past date, from the last snapshot
+// taken by then
+type netWorthAsOf struct {
+	AsOf                string                     `json:"as_of"`
+	SnapshotAt          time.Time                  `json:"snapshot_at"`
+	NetWorth            decimal.Decimal            `json:"net_worth"`
+	TotalAssets         decimal.Decimal            `json:"total_assets"`
+	TotalLiabilities    decimal.Decimal            `json:"total_liabilities"`
+	UnvestedEquityValue decimal.Decimal            `json:"unvested_equity_value"`
+	Components          []models.NetWorthComponent `json:"components"`
+}
+
+// parseAsOf reads the as_of query parameter (YYYY-MM-DD). It returns nil when
+// the parameter is absent and responds with 400 when it is invalid or in the
+// future.
+func parseAsOf(c *gin.Context) (*time.Time, bool) {
+	value := c.Query("as_of")
+	if value == "" {
+		return nil, true
+	}
+
+	asOf, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be YYYY-MM-DD"})
+		return nil, false
+	}
+	if asOf.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must not be in the future"})
+		return nil, false
+	}
+	return &asOf, true
+}
+
+// respondNetWorthAsOf answers /net-worth?as_of= from the last net worth
+// snapshot taken by the end of asOf
+func (s *Server) respondNetWorthAsOf(c *gin.Context, asOf time.Time) {
+	snapshot, err := s.netWorthHistoryService.LatestBefore(asOf.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch net worth history"})
+		return
+	}
+	if snapshot == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No net worth snapshot on or before " + asOf.Format("2006-01-02")})
+		return
+	}
+
+	c.JSON(http.StatusOK, netWorthAsOf{
+		AsOf:                asOf.Format("2006-01-02"),
+		SnapshotAt:          snapshot.Timestamp,
+		NetWorth:            snapshot.NetWorth,
+		TotalAssets:         snapshot.TotalAssets,
+		TotalLiabilities:    snapshot.TotalLiabilities,
+		UnvestedEquityValue: snapshot.UnvestedEquityValue,
+		Components:          snapshot.NetWorthBreakdown.Components(),
+	})
+}
+
+// holdingsAsOf returns load, or when as_of is given a loader for the
+// holdings at the end of that date. The as_of date, if any, is returned for
+// the response.
+func holdingsAsOf[T any](c *gin.Context, load func() ([]T, error), loadAsOf func(time.Time) ([]T, error)) (func() ([]T, error), *string, bool) {
+	asOf, ok := parseAsOf(c)
+	if !ok || asOf == nil {
+		return load, nil, ok
+	}
+	date := asOf.Format("2006-01-02")
+	return func() ([]T, error) { return loadAsOf(*asOf) }, &date, true
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Validate an asset category custom schema
+// @Description Check a custom_schema as category create and update do, without saving it, so an editor can show problems while the schema is written. Field names must be unique snake_case, types text, textarea, number, date or select, select fields need options with unique values, and validation rules must suit the field type.
+// @Tags asset-categories
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Object with the custom_schema to check"
+// @Success 200 {object} map[string]interface{} "Whether the schema is valid, with each problem"
+// @Failure 400 {object} map[string]interface{} "Invalid JSON data"
+// @Router /asset-categories/validate-schema [post]
+func (s *Server) validateAssetCategorySchema(c *gin.Context) {
+	var req struct {
+		CustomSchema interface{} `json:"custom_schema"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	errs := plugins.ValidateCustomSchema(req.CustomSchema)
+	if errs == nil {
+		errs = []plugins.ValidationError{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	})
+}
+
+// categoryMigrationSchema returns the custom schema fields and assets of the
+// category named by the id path parameter
+func (s *Server) categoryMigrationSchema(c *gin.Context) (int, []plugins.FieldSpec, []models.MiscellaneousAsset, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return 0, nil, nil, false
+	}
+
+	schemaJSON, err := s.repos.OtherAssets.CategorySchema(id)
+	if err != nil {
+		s.respondRepositoryError(c, err, "Asset category not found", "Failed to fetch asset category")
+		return 0, nil, nil, false
+	}
+	fields, err := plugins.ParseCustomSchemaFields(schemaJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse category custom schema"})
+		return 0, nil, nil, false
+	}
+
+	assets, err := s.repos.OtherAssets.List(&id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch other assets"})
+		return 0, nil, nil, false
+	}
+	return id, fields, assets, true
+}
+
+// customFieldDriftReport describes which of a category's assets have custom
+// fields missing or extra relative to its schema, with how many assets lack or
+// keep each field
+func customFieldDriftReport(categoryID int, fields []plugins.FieldSpec, values map[int]map[string]interface{}, assets []models.MiscellaneousAsset) gin.H {
+	fieldNames := make([]string, 0, len(fields))
+	for _, field := range fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+
+	drifted := make([]gin.H, 0)
+	missing := make(map[string]int)
+	extra := make(map[string]int)
+	for _, asset := range assets {
+		drift := plugins.CompareCustomFields(fields, values[asset.ID])
+		if !drift.HasDrift() {
+			continue
+		}
+		for _, name := range drift.Missing {
+			missing[name]++
+		}
+		for _, name := range drift.Extra {
+			extra[name]++
+		}
+		drifted = append(drifted, gin.H{
+			"asset_id":         asset.ID,
+			"asset_name":       asset.AssetName,
+			"missing":          drift.Missing,
+			"missing_required": drift.MissingRequired,
+			"extra":            drift.Extra,
+		})
+	}
+
+	return gin.H{
+		"category_id":    categoryID,
+		"schema_fields":  fieldNames,
+		"asset_count":    len(assets),
+		"drifted_count":  len(drifted),
+		"missing_fields": missing,
+		"extra_fields":   extra,
+		"assets":         drifted,
+	}
+}
+
+// @Summary Report custom field drift in an asset category
+// @Description Which of the category's assets have custom_fields missing or extra relative to its current custom_schema, typically after the schema was edited, with how many assets lack or keep each field
+// @Tags asset-categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} map[string]interface{} "Drift report"
+// @Failure 400 {object} map[string]interface{} "Invalid category ID"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /asset-categories/{id}/migration [get]
+func (s *Server) getAssetCategoryMigration(c *gin.Context) {
+	id, fields, assets, ok := s.categoryMigrationSchema(c)
+	if !ok {
+		return
+	}
+
+	values := make(map[int]map[string]interface{}, len(assets))
+	for _, asset := range assets {
+		values[asset.ID] = asset.CustomFields
+	}
+	c.JSON(http.StatusOK, customFieldDriftReport(id, fields, values, assets))
+}
+
+// @Summary Migrate an asset category's custom fields
+// @Description Bring the custom_fields of every asset in the category in line with its custom_schema: renames move values from old field names to schema fields that have no value yet, defaults fill schema fields that have no value, and remove_extra drops values for fields no longer in the schema. Returns how many assets changed and the drift that remains. With dry_run nothing is saved.
+// @Tags asset-categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param request body map[string]interface{} true "renames (old name to schema field), defaults (schema field to value), remove_extra and dry_run"
+// @Success 200 {object} map[string]interface{} "Migration result with the remaining drift"
+// @Failure 400 {object} map[string]interface{} "Invalid category ID or migration"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /asset-categories/{id}/migration [post]
+func (s *Server) migrateAssetCategory(c *gin.Context) {
+	var req struct {
+		plugins.CustomFieldMigration
+		DryRun bool `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if len(req.Renames) == 0 && len(req.Defaults) == 0 && !req.RemoveExtra {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give renames, defaults or remove_extra"})
+		return
+	}
+
+	id, fields, assets, ok := s.categoryMigrationSchema(c)
+	if !ok {
+		return
+	}
+	if errs := req.Validate(fields); len(errs) > 0 {
+		respondValidationErrors(c, "Invalid category migration", errs)
+		return
+	}
+
+	values := make(map[int]map[string]interface{}, len(assets))
+	changed := make(map[int]map[string]interface{})
+	for _, asset := range assets {
+		migrated, didChange := req.Apply(fields, asset.CustomFields)
+		values[asset.ID] = migrated
+		if didChange {
+			changed[asset.ID] = migrated
+		}
+	}
+
+	if !req.DryRun && len(changed) > 0 {
+		if err := s.repos.OtherAssets.ReplaceCustomFields(changed); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to migrate custom fields"})
+			return
+		}
+	}
+
+	message := "Custom fields migrated successfully"
+	if req.DryRun {
+		message = "Dry run; no custom fields were changed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       message,
+		"dry_run":       req.DryRun,
+		"updated_count": len(changed),
+		"remaining":     customFieldDriftReport(id, fields, values, assets),
+	})
+}
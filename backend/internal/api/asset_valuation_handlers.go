@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// defaultAssetHistoryDays is the asset valuation history window when from is not given
+const defaultAssetHistoryDays = 365
+
+// assetValueTrend summarizes how an asset's value moved over a history window
+type assetValueTrend struct {
+	StartValue    *decimal.Decimal `json:"start_value"` // value in effect at the start of the window
+	EndValue      decimal.Decimal  `json:"end_value"`
+	Change        *decimal.Decimal `json:"change"`
+	ChangePercent *float64         `json:"change_percent"`
+}
+
+// otherAssetFromPath returns the asset named by the id path parameter
+func (s *Server) otherAssetFromPath(c *gin.Context) (*models.MiscellaneousAsset, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset ID"})
+		return nil, false
+	}
+
+	asset, err := s.repos.OtherAssets.Get(id)
+	if err != nil {
+		s.respondRepositoryError(c, err, "Asset not found", "Failed to fetch asset")
+		return nil, false
+	}
+	return asset, true
+}
+
+// @Summary List asset valuation providers
+// @Description Providers a category's valuation_api_config can name in "provider", and whether each is configured
+// @Tags other-assets
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Asset valuation providers"
+// @Router /other-assets/valuation-providers [get]
+func (s *Server) getAssetValuationProviders(c *gin.Context) {
+	providers := make([]gin.H, 0)
+	for _, provider := range s.assetValuationService.Providers() {
+		providers = append(providers, gin.H{
+			"name":         provider.Name(),
+			"display_name": provider.GetProviderName(),
+			"available":    provider.IsAvailable(),
+			"description":  provider.Description(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// @Summary Refresh an other asset's value
+// @Description Value an asset with the provider named in its category's valuation_api_config and store the result as its current_value and in its valuation history. The asset's valuation_method becomes api, so it is revalued automatically each refresh_interval_days (default 30) until its value is edited by hand. Providers that require confirmation (ebay_sold) store the value as a pending suggestion instead, returned with pending set.
+// @Tags other-assets
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {object} map[string]interface{} "New value with the previous one"
+// @Failure 400 {object} map[string]interface{} "Invalid asset ID, no valuation config or asset fields the provider needs are missing"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Failure 503 {object} map[string]interface{} "Provider not configured"
+// @Router /other-assets/{id}/valuation/refresh [post]
+func (s *Server) refreshOtherAssetValuation(c *gin.Context) {
+	asset, ok := s.otherAssetFromPath(c)
+	if !ok {
+		return
+	}
+
+	valuation, err := s.assetValuationService.Refresh(*asset)
+	switch {
+	case errors.Is(err, services.ErrAssetValuationUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to value asset: %v", err)})
+		return
+	}
+
+	message := "Asset value refreshed successfully"
+	if valuation.Pending {
+		message = "Asset value suggested; confirm it to apply"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"valuation": valuation,
+	})
+}
+
+// respondSuggestionError maps asset valuation suggestion errors to responses
+func respondSuggestionError(c *gin.Context, err error, failure string) {
+	if errors.Is(err, services.ErrNoAssetValuationSuggestion) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending valuation suggestion for this asset"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+}
+
+// @Summary Get an other asset's valuation suggestion
+// @Description The value suggested for an asset by a provider that requires confirmation, with the current value it would replace
+// @Tags other-assets
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {object} map[string]interface{} "Pending suggestion"
+// @Failure 404 {object} map[string]interface{} "Asset not found or no pending suggestion"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /other-assets/{id}/valuation/suggestion [get]
+func (s *Server) getOtherAssetValuationSuggestion(c *gin.Context) {
+	asset, ok := s.otherAssetFromPath(c)
+	if !ok {
+		return
+	}
+
+	suggestion, err := s.assetValuationService.Suggestion(asset.ID)
+	if err != nil {
+		respondSuggestionError(c, err, "Failed to fetch valuation suggestion")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"suggestion": suggestion})
+}
+
+// @Summary Confirm an other asset's valuation suggestion
+// @Description Apply the pending suggestion as the asset's current_value, record it in the valuation history and keep the asset API-valued, so new suggestions are made each refresh_interval_days
+// @Tags other-assets
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {object} map[string]interface{} "Suggestion applied"
+// @Failure 404 {object} map[string]interface{} "Asset not found or no pending suggestion"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /other-assets/{id}/valuation/suggestion/confirm [post]
+func (s *Server) confirmOtherAssetValuationSuggestion(c *gin.Context) {
+	asset, ok := s.otherAssetFromPath(c)
+	if !ok {
+		return
+	}
+
+	valuation, err := s.assetValuationService.ConfirmSuggestion(asset.ID)
+	if err != nil {
+		respondSuggestionError(c, err, "Failed to apply valuation suggestion")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Suggested value applied successfully",
+		"valuation": valuation,
+	})
+}
+
+// @Summary Dismiss an other asset's valuation suggestion
+// @Description Discard the pending suggestion without changing the asset's value
+// @Tags other-assets
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {object} map[string]interface{} "Suggestion dismissed"
+// @Failure 404 {object} map[string]interface{} "Asset not found or no pending suggestion"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /other-assets/{id}/valuation/suggestion [delete]
+func (s *Server) dismissOtherAssetValuationSuggestion(c *gin.Context) {
+	asset, ok := s.otherAssetFromPath(c)
+	if !ok {
+		return
+	}
+
+	if err := s.assetValuationService.DismissSuggestion(asset.ID); err != nil {
+		respondSuggestionError(c, err, "Failed to dismiss valuation suggestion")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Valuation suggestion dismissed"})
+}
+
+// @Summary Get an other asset's valuation history
+// @Description Values recorded for an asset, oldest first, for charting its value over time. A value is recorded on every change, whether entered by hand (source manual) or by a valuation provider. The trend compares the value in effect at from, which may have been recorded earlier, with the latest value in the window.
+// @Tags other-assets
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param from query string false "Start date (YYYY-MM-DD, default 365 days before to)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Success 200 {object} map[string]interface{} "Valuation history with trend"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /other-assets/{id}/history [get]
+func (s *Server) getOtherAssetHistory(c *gin.Context) {
+	asset, ok := s.otherAssetFromPath(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c, defaultAssetHistoryDays)
+	if !ok {
+		return
+	}
+
+	history, err := s.repos.OtherAssets.ValuationHistory(asset.ID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch asset valuation history"})
+		return
+	}
+	opening, err := s.repos.OtherAssets.ValuationBefore(asset.ID, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch asset valuation history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"asset_id": asset.ID,
+		"history":  history,
+		"count":    len(history),
+		"trend":    valueTrend(opening, history, asset.CurrentValue),
+		"from":     from.Format("2006-01-02"),
+		"to":       to.Format("2006-01-02"),
+	})
+}
+
+// valueTrend compares the value in effect at the start of a history window
+// with the last one recorded in it. Without a value before the window the
+// first one in it is the start; without any value the current value is the end.
+func valueTrend(opening *models.AssetValuationRecord, history []models.AssetValuationRecord, current decimal.Decimal) assetValueTrend {
+	trend := assetValueTrend{EndValue: current}
+	if len(history) > 0 {
+		trend.EndValue = history[len(history)-1].Value
+	}
+
+	switch {
+	case opening != nil:
+		trend.StartValue = &opening.Value
+	case len(history) > 0:
+		trend.StartValue = &history[0].Value
+	default:
+		return trend
+	}
+
+	change := trend.EndValue.Sub(*trend.StartValue)
+	trend.Change = &change
+	if !trend.StartValue.IsZero() {
+		percent := change.Div(*trend.StartValue).Mul(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+		trend.ChangePercent = &percent
+	}
+	return trend
+}
@@ -0,0 +1,405 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+	"networth-dashboard/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead allows for the form fields and boundaries around an
+// uploaded file when bounding the request body
+const multipartOverhead = 1 << 20
+
+// attachmentHolding checks that the holding an attachment names exists,
+// responding 400 for unknown types and 404 for missing holdings
+func (s *Server) attachmentHolding(c *gin.Context, holdingType string, holdingID int) bool {
+	exists, err := s.repos.Attachments.HoldingExists(holdingType, holdingID)
+	switch {
+	case errors.Is(err, repository.ErrInvalidHoldingType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be stock_holding, equity_grant, real_estate, cash_holding, crypto_holding or other_asset"})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check holding"})
+		return false
+	case !exists:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holding not found"})
+		return false
+	}
+	return true
+}
+
+// attachmentFromPath returns the attachment named by the id path parameter
+func (s *Server) attachmentFromPath(c *gin.Context) (*models.Attachment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return nil, false
+	}
+
+	attachment, err := s.repos.Attachments.Get(id)
+	if err != nil {
+		s.respondRepositoryError(c, err, "Attachment not found", "Failed to fetch attachment")
+		return nil, false
+	}
+	return attachment, true
+}
+
+// respondAttachmentTooLarge rejects an upload over the size limit
+func respondAttachmentTooLarge(c *gin.Context, maxSize int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Files are limited to %d MB", maxSize>>20)})
+}
+
+// optionalNote returns a trimmed note, or nil when it is blank
+func optionalNote(note string) *string {
+	if note = strings.TrimSpace(note); note == "" {
+		return nil
+	}
+	return &note
+}
+
+// @Summary List a holding's attachments
+// @Description Notes and files attached to a holding, newest first. Files are fetched from their download endpoint.
+// @Tags attachments
+// @Produce json
+// @Param type query string true "Holding type: stock_holding, equity_grant, real_estate, cash_holding, crypto_holding or other_asset"
+// @Param holding_id query int true "Holding ID"
+// @Success 200 {object} map[string]interface{} "Attachments"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Holding not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments [get]
+func (s *Server) getAttachments(c *gin.Context) {
+	holdingType := c.Query("type")
+	holdingID, err := strconv.Atoi(c.Query("holding_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "holding_id is required"})
+		return
+	}
+	if !s.attachmentHolding(c, holdingType, holdingID) {
+		return
+	}
+
+	attachments, err := s.repos.Attachments.List(holdingType, holdingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": attachments,
+		"count":       len(attachments),
+	})
+}
+
+// @Summary Upload an attachment
+// @Description Attach a file, such as an appraisal PDF, grant letter or photo, to a holding as multipart form data, with an optional note. Files are limited to ATTACHMENT_MAX_SIZE_MB.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param type formData string true "Holding type"
+// @Param holding_id formData int true "Holding ID"
+// @Param file formData file true "File to attach"
+// @Param note formData string false "Note about the file"
+// @Success 201 {object} map[string]interface{} "Attachment created"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Holding not found"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments [post]
+func (s *Server) uploadAttachment(c *gin.Context) {
+	maxSize := s.attachmentService.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	holdingType := c.PostForm("type")
+	holdingID, err := strconv.Atoi(c.PostForm("holding_id"))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondAttachmentTooLarge(c, maxSize)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "holding_id is required"})
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondAttachmentTooLarge(c, maxSize)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+	defer file.Close()
+
+	if !s.attachmentHolding(c, holdingType, holdingID) {
+		return
+	}
+
+	fileName := filepath.Base(header.Filename)
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	size := header.Size
+
+	key, err := s.attachmentService.Save(c.Request.Context(), file, size, contentType)
+	if errors.Is(err, services.ErrAttachmentTooLarge) {
+		respondAttachmentTooLarge(c, maxSize)
+		return
+	}
+	if err != nil {
+		fmt.Printf("WARNING: Failed to store attachment file: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	attachment := models.Attachment{
+		EntityType:  holdingType,
+		EntityID:    holdingID,
+		Kind:        models.AttachmentKindFile,
+		Note:        optionalNote(c.PostForm("note")),
+		FileName:    &fileName,
+		ContentType: &contentType,
+		SizeBytes:   &size,
+		StorageKey:  &key,
+		CreatedBy:   requestActor(c),
+	}
+	id, err := s.repos.Attachments.Create(attachment)
+	if err != nil {
+		s.attachmentService.Remove(c.Request.Context(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+
+	// Audited here rather than by middleware, which would buffer the whole upload
+	s.recordAudit(services.AuditActionCreate, "attachment", &id, requestActor(c), c.ClientIP(), nil, s.auditService.Snapshot("attachment", id))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "File attached successfully",
+		"attachment_id": id,
+	})
+}
+
+// @Summary Add a note to a holding
+// @Description Attach a text note to a holding
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "type, holding_id and note"
+// @Success 201 {object} map[string]interface{} "Note created"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Holding not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments/notes [post]
+func (s *Server) createAttachmentNote(c *gin.Context) {
+	var req struct {
+		Type      string `json:"type" binding:"required"`
+		HoldingID int    `json:"holding_id" binding:"required"`
+		Note      string `json:"note" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	note := optionalNote(req.Note)
+	if note == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "note must not be blank"})
+		return
+	}
+	if !s.attachmentHolding(c, req.Type, req.HoldingID) {
+		return
+	}
+
+	id, err := s.repos.Attachments.Create(models.Attachment{
+		EntityType: req.Type,
+		EntityID:   req.HoldingID,
+		Kind:       models.AttachmentKindNote,
+		Note:       note,
+		CreatedBy:  requestActor(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save note"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Note added successfully",
+		"attachment_id": id,
+	})
+}
+
+// fileAttachmentFromPath returns the file attachment named by the id path
+// parameter, rejecting notes without a file
+func (s *Server) fileAttachmentFromPath(c *gin.Context) (*models.Attachment, bool) {
+	attachment, ok := s.attachmentFromPath(c)
+	if !ok {
+		return nil, false
+	}
+	if attachment.StorageKey == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attachment is a note without a file"})
+		return nil, false
+	}
+	return attachment, true
+}
+
+// @Summary Download an attachment
+// @Description The attached file, with its original name and content type, read from the storage backend
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Success 200 {file} file "Attached file"
+// @Failure 400 {object} map[string]interface{} "Invalid attachment ID or a note without a file"
+// @Failure 404 {object} map[string]interface{} "Attachment or its file not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments/{id}/download [get]
+func (s *Server) downloadAttachment(c *gin.Context) {
+	attachment, ok := s.fileAttachmentFromPath(c)
+	if !ok {
+		return
+	}
+
+	file, err := s.attachmentService.Open(c.Request.Context(), *attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment file not found"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("WARNING: Failed to read attachment %d: %v\n", attachment.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment file"})
+		return
+	}
+	defer file.Close()
+
+	var size int64 = -1
+	if attachment.SizeBytes != nil {
+		size = *attachment.SizeBytes
+	}
+	contentType := "application/octet-stream"
+	if attachment.ContentType != nil {
+		contentType = *attachment.ContentType
+	}
+	fileName := "attachment"
+	if attachment.FileName != nil {
+		fileName = *attachment.FileName
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": fileName}),
+	})
+}
+
+// @Summary Get a download link for an attachment
+// @Description A URL that downloads the attached file. With the s3 storage backend it is a presigned URL served by the bucket without credentials until expires_at (STORAGE_SIGNED_URL_TTL_MINUTES); with local storage it is the API download endpoint and signed is false.
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Success 200 {object} map[string]interface{} "Download URL"
+// @Failure 400 {object} map[string]interface{} "Invalid attachment ID or a note without a file"
+// @Failure 404 {object} map[string]interface{} "Attachment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments/{id}/url [get]
+func (s *Server) getAttachmentURL(c *gin.Context) {
+	attachment, ok := s.fileAttachmentFromPath(c)
+	if !ok {
+		return
+	}
+
+	fileName := "attachment"
+	if attachment.FileName != nil {
+		fileName = *attachment.FileName
+	}
+	url, expiresAt, err := s.attachmentService.SignedURL(*attachment.StorageKey, fileName)
+	if errors.Is(err, storage.ErrSignedURLsUnsupported) {
+		c.JSON(http.StatusOK, gin.H{
+			"url":    fmt.Sprintf("/api/v1/attachments/%d/download", attachment.ID),
+			"signed": false,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign attachment URL"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"url":        url,
+		"signed":     true,
+		"expires_at": expiresAt,
+	})
+}
+
+// @Summary Update an attachment's note
+// @Description Replace the note of a note or file attachment; a blank note is cleared on files
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Param request body map[string]interface{} true "note"
+// @Success 200 {object} map[string]interface{} "Note updated"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Attachment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments/{id} [put]
+func (s *Server) updateAttachmentNote(c *gin.Context) {
+	attachment, ok := s.attachmentFromPath(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	note := optionalNote(req.Note)
+	if note == nil && attachment.Kind == models.AttachmentKindNote {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "note must not be blank"})
+		return
+	}
+
+	if err := s.repos.Attachments.UpdateNote(attachment.ID, note); err != nil {
+		s.respondRepositoryError(c, err, "Attachment not found", "Failed to update attachment")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Note updated successfully"})
+}
+
+// @Summary Delete an attachment
+// @Description Delete a note, or a file attachment together with its stored file
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Success 200 {object} map[string]interface{} "Attachment deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid attachment ID"
+// @Failure 404 {object} map[string]interface{} "Attachment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /attachments/{id} [delete]
+func (s *Server) deleteAttachment(c *gin.Context) {
+	attachment, ok := s.attachmentFromPath(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.Attachments.Delete(attachment.ID); err != nil {
+		s.respondRepositoryError(c, err, "Attachment not found", "Failed to delete attachment")
+		return
+	}
+	if attachment.StorageKey != nil {
+		if err := s.attachmentService.Remove(c.Request.Context(), *attachment.StorageKey); err != nil {
+			fmt.Printf("WARNING: Attachment %d deleted but its file was not: %v\n", attachment.ID, err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys handlers use to tell the audit middleware what they did
+const (
+	auditEntityIDKey = "audit_entity_id"
+	auditActionKey   = "audit_action"
+	auditPreviousKey = "audit_previous"
+	auditBulkKey     = "audit_bulk"
+)
+
+// auditActorHeader identifies who made a change when authentication is disabled
+const auditActorHeader = "X-User"
+
+// pluginEntityTypes maps manual entry plugin names to audited entity types
+var pluginEntityTypes = map[string]string{
+	"stock_holding":       "stock_holding",
+	"morgan_stanley":      "equity_grant",
+	"real_estate":         "real_estate",
+	"cash_holdings":       "cash_holding",
+	"crypto_holdings":     "crypto_holding",
+	"other_assets":        "other_asset",
+	"private_investments": "private_investment",
+	"bonds":               "bond",
+	"i_bonds":             "i_bond",
+	"pensions":            "pension",
+	"insurance":           "insurance_policy",
+}
+
+// setAuditEntityID records the ID of a created entity for the audit middleware
+func setAuditEntityID(c *gin.Context, id int) {
+	c.Set(auditEntityIDKey, id)
+}
+
+// setAuditBulkChanges records the entities touched by a bulk create or delete,
+// keyed by ID with their state before the change (nil for creates)
+func setAuditBulkChanges(c *gin.Context, changes map[int]map[string]interface{}) {
+	c.Set(auditBulkKey, changes)
+}
+
+// setAuditOutcome records what a manual entry upsert did for the audit middleware
+func setAuditOutcome(c *gin.Context, outcome *plugins.ManualEntryOutcome) {
+	switch outcome.Action {
+	case plugins.ManualEntryUpdated:
+		c.Set(auditActionKey, services.AuditActionUpdate)
+		c.Set(auditPreviousKey, outcome.Previous)
+	case plugins.ManualEntrySkipped:
+		c.Set(auditActionKey, "")
+	}
+	if outcome.ID != 0 {
+		setAuditEntityID(c, outcome.ID)
+	}
+}
+
+// audited returns middleware that records a successful mutation of entityType to
+// the audit log, capturing the entity before and after the handler runs
+func (s *Server) audited(action, entityType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.auditRequest(c, action, entityType)
+	}
+}
+
+// auditedPlugin is like audited but resolves the entity type from the plugin
+// named by the :name path parameter or the type query parameter
+func (s *Server) auditedPlugin(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pluginName := c.Param("name")
+		if pluginName == "" {
+			pluginName = c.Query("type")
+		}
+
+		entityType, ok := pluginEntityTypes[pluginName]
+		if !ok {
+			c.Next()
+			return
+		}
+		s.auditRequest(c, action, entityType)
+	}
+}
+
+// requestActor names who made a request: the API key it used, else the
+// signed-in user, else the X-User header, else "anonymous"
+func requestActor(c *gin.Context) string {
+	if key := currentAPIKey(c); key != nil {
+		return "api-key:" + key.Name
+	}
+	if user := currentUser(c); user != nil {
+		return user.Username
+	}
+	if actor := c.GetHeader(auditActorHeader); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+func (s *Server) auditRequest(c *gin.Context, action, entityType string) {
+	body := readRequestBody(c)
+
+	var entityID *int
+	if id, err := strconv.Atoi(c.Param("id")); err == nil {
+		entityID = &id
+	}
+
+	// Capture the state before the change
+	var before map[string]interface{}
+	var bulkBefore map[int]map[string]interface{}
+	switch {
+	case action == services.AuditActionBulkUpdate:
+		bulkBefore = make(map[int]map[string]interface{})
+		for _, id := range bulkUpdateIDs(body) {
+			bulkBefore[id] = s.auditService.Snapshot(entityType, id)
+		}
+	case entityID != nil:
+		before = s.auditService.Snapshot(entityType, *entityID)
+	}
+
+	c.Next()
+
+	if status := c.Writer.Status(); status < 200 || status >= 300 {
+		return
+	}
+
+	actor := requestActor(c)
+
+	if action == services.AuditActionBulkUpdate {
+		for id, old := range bulkBefore {
+			id := id
+			after := s.auditService.Snapshot(entityType, id)
+			s.recordAudit(action, entityType, &id, actor, c.ClientIP(), old, after)
+		}
+		return
+	}
+
+	if value, exists := c.Get(auditBulkKey); exists {
+		for id, old := range value.(map[int]map[string]interface{}) {
+			id := id
+			var after map[string]interface{}
+			if action != services.AuditActionBulkDelete {
+				after = s.auditService.Snapshot(entityType, id)
+			}
+			s.recordAudit(action, entityType, &id, actor, c.ClientIP(), old, after)
+		}
+		return
+	}
+
+	// Creates may turn out to be updates or no-ops when an existing entry matched
+	if override, exists := c.Get(auditActionKey); exists {
+		action = override.(string)
+		if action == "" {
+			return
+		}
+	}
+	if id, exists := c.Get(auditEntityIDKey); exists && entityID == nil {
+		createdID := id.(int)
+		entityID = &createdID
+	}
+	if previous, exists := c.Get(auditPreviousKey); exists {
+		before, _ = previous.(map[string]interface{})
+	}
+
+	var after map[string]interface{}
+	if action != services.AuditActionDelete {
+		if entityID != nil {
+			after = s.auditService.Snapshot(entityType, *entityID)
+		}
+		if after == nil {
+			// No stored row to snapshot, fall back to what was submitted
+			json.Unmarshal(body, &after)
+		}
+	}
+
+	s.recordAudit(action, entityType, entityID, actor, c.ClientIP(), before, after)
+}
+
+func (s *Server) recordAudit(action, entityType string, entityID *int, actor, ipAddress string, before, after map[string]interface{}) {
+	if err := s.auditService.Record(action, entityType, entityID, actor, ipAddress, before, after); err != nil {
+		fmt.Printf("WARNING: Failed to audit %s of %s: %v\n", action, entityType, err)
+	}
+}
+
+// readRequestBody reads the request body and restores it for the handler
+func readRequestBody(c *gin.Context) []byte {
+	if c.Request.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// bulkUpdateIDs extracts the IDs from a bulk update request body
+func bulkUpdateIDs(body []byte) []int {
+	var request struct {
+		Updates []struct {
+			ID int `json:"id"`
+		} `json:"updates"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil
+	}
+
+	ids := make([]int, 0, len(request.Updates))
+	for _, update := range request.Updates {
+		ids = append(ids, update.ID)
+	}
+	return ids
+}
+
+// parseAuditTime parses an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// @Summary Get audit log
+// @Description List recorded create, update, delete and bulk create, update and delete operations with old and new values, newest first
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param entity_type query string false "Entity type (stock_holding, equity_grant, real_estate, cash_holding, crypto_holding, other_asset, private_investment, bond, i_bond, pension, insurance_policy, asset_category)"
+// @Param entity_type query string false "Entity type (stock_holding, equity_grant, real_estate, cash_holding, crypto_holding, other_asset, private_investment, bond, liability, asset_category)"
+// @Param entity_id query int false "Entity ID"
+// @Param from query string false "Start of date range (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "End of date range, exclusive for timestamps and inclusive for dates (YYYY-MM-DD or RFC 3339)"
+// @Param limit query int false "Maximum number of entries (default 100, max 1000)"
+// @Success 200 {object} map[string]interface{} "Audit log entries"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /audit [get]
+func (s *Server) getAuditLog(c *gin.Context) {
+	filter := services.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		Limit:      100,
+	}
+
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 1000 {
+		filter.Limit = l
+	}
+
+	if idParam := c.Query("entity_id"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid entity_id",
+			})
+			return
+		}
+		filter.EntityID = &id
+	}
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err := parseAuditTime(fromParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid from date (expected YYYY-MM-DD or RFC 3339)",
+			})
+			return
+		}
+		filter.From = &from
+	}
+
+	if toParam := c.Query("to"); toParam != "" {
+		to, err := parseAuditTime(toParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid to date (expected YYYY-MM-DD or RFC 3339)",
+			})
+			return
+		}
+		if len(toParam) == len("2006-01-02") {
+			// Include the whole day
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	entries, err := s.auditService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get audit log: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
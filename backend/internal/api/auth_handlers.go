@@ -0,0 +1,425 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserKey holds the signed-in *services.User on the request context
+const currentUserKey = "current_user"
+
+// currentAPIKeyKey holds the *services.APIKey a request authenticated with
+const currentAPIKeyKey = "current_api_key"
+
+// apiKeyHeader is an alternative to sending an API key as a bearer token
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyRoutes are routes any API key may call regardless of asset classes
+var apiKeyRoutes = map[string]bool{
+	"/auth/me": true,
+}
+
+// assetClassRoutes maps the first segment of a route to the asset class its
+// data belongs to. API keys limited to asset classes may only call these.
+var assetClassRoutes = map[string]string{
+	"stocks":              "stocks",
+	"securities":          "stocks",
+	"equity":              "equity",
+	"real-estate":         "real_estate",
+	"property-valuation":  "real_estate",
+	"cash-holdings":       "cash",
+	"crypto-holdings":     "crypto",
+	"crypto":              "crypto",
+	"other-assets":        "other_assets",
+	"asset-categories":    "other_assets",
+	"metals":              "other_assets",
+	"private-investments": "private_investments",
+	"bonds":               "fixed_income",
+	"i-bonds":             "fixed_income",
+	"pensions":            "pensions",
+	"insurance":           "insurance",
+	"liabilities":         "liabilities",
+}
+
+// publicRoutes can be called without a session
+var publicRoutes = map[string]bool{
+	"/auth/login":   true,
+	"/share/:token": true,
+}
+
+// viewerWriteRoutes are writes viewers may make: POST routes that change
+// nothing, and each user's own settings
+var viewerWriteRoutes = map[string]bool{
+	"POST /auth/logout":                     true,
+	"POST /analytics/what-if":               true,
+	"POST /analytics/withdrawal-simulation": true,
+	"PUT /dashboard/config":                 true,
+	"DELETE /dashboard/config":              true,
+}
+
+// routePath returns the matched route without its version prefix, e.g. /stocks/:id
+func routePath(c *gin.Context, prefix string) string {
+	return strings.TrimPrefix(c.FullPath(), prefix)
+}
+
+// bearerToken returns the session token from the Authorization header
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// currentUser returns the signed-in user, or nil when authentication is off
+func currentUser(c *gin.Context) *services.User {
+	if value, exists := c.Get(currentUserKey); exists {
+		return value.(*services.User)
+	}
+	return nil
+}
+
+// currentAPIKey returns the API key a request authenticated with, if any
+func currentAPIKey(c *gin.Context) *services.APIKey {
+	if value, exists := c.Get(currentAPIKeyKey); exists {
+		return value.(*services.APIKey)
+	}
+	return nil
+}
+
+// requestAPIKey returns the API key sent in the X-API-Key header or as a
+// bearer token, or "" when the request carries none
+func requestAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader(apiKeyHeader)); key != "" {
+		return key
+	}
+	if token := bearerToken(c); strings.HasPrefix(token, services.APIKeyPrefix) {
+		return token
+	}
+	return ""
+}
+
+// authenticate resolves the session token of requests under prefix to a user.
+// With authentication disabled every request passes as before.
+func (s *Server) authenticate(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.Auth.Enabled || publicRoutes[routePath(c, prefix)] {
+			c.Next()
+			return
+		}
+
+		if secret := requestAPIKey(c); secret != "" {
+			key, err := s.apiKeyService.Authenticate(secret, time.Now())
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, revoked or expired API key"})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+				return
+			}
+			c.Set(currentAPIKeyKey, key)
+			c.Next()
+			return
+		}
+
+		user, err := s.userService.Authenticate(bearerToken(c))
+		if errors.Is(err, services.ErrInvalidSession) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in required"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+			return
+		}
+
+		c.Set(currentUserKey, user)
+		c.Next()
+	}
+}
+
+// authorizeByMethod lets viewers read and editors change data. Routes that
+// need more add requireRole. API keys act with their scope's role and, when
+// limited to asset classes, only reach those classes' routes.
+func (s *Server) authorizeByMethod(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := currentUser(c)
+		key := currentAPIKey(c)
+		if user == nil && key == nil {
+			c.Next()
+			return
+		}
+
+		path := routePath(c, prefix)
+		required := services.RoleEditor
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			required = services.RoleViewer
+		default:
+			if viewerWriteRoutes[c.Request.Method+" "+path] {
+				required = services.RoleViewer
+			}
+		}
+
+		if key != nil {
+			if !apiKeyRoutes[path] && !key.AllowsAssetClass(routeAssetClass(path)) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": fmt.Sprintf("This API key is limited to %s", strings.Join(key.AssetClasses, ", ")),
+				})
+				return
+			}
+			s.enforceAPIKey(c, key, required)
+			return
+		}
+		s.enforceRole(c, user, required)
+	}
+}
+
+// routeAssetClass returns the asset class a route belongs to, or "" for
+// routes spanning asset classes
+func routeAssetClass(path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	return assetClassRoutes[segment]
+}
+
+// requireRole restricts a route to users with at least role
+func (s *Server) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := currentAPIKey(c); key != nil {
+			s.enforceAPIKey(c, key, role)
+			return
+		}
+		user := currentUser(c)
+		if user == nil {
+			c.Next()
+			return
+		}
+		s.enforceRole(c, user, role)
+	}
+}
+
+func (s *Server) enforceAPIKey(c *gin.Context, key *services.APIKey, required string) {
+	if !services.RoleAllows(key.Role(), required) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("This action requires the %s role; %s API keys act as %s", required, key.Scope, key.Role()),
+		})
+		return
+	}
+	c.Next()
+}
+
+func (s *Server) enforceRole(c *gin.Context, user *services.User, required string) {
+	if !services.RoleAllows(user.Role, required) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("This action requires the %s role", required),
+		})
+		return
+	}
+	c.Next()
+}
+
+// @Summary Sign in
+// @Description Check a username and password and start a session. Send the returned token as "Authorization: Bearer <token>".
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Credentials: {\"username\": \"admin\", \"password\": \"...\"}"
+// @Success 200 {object} map[string]interface{} "Session token, expiry and user"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Invalid username or password"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/login [post]
+func (s *Server) login(c *gin.Context) {
+	var request struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	session, err := s.userService.Login(request.Username, request.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// @Summary Sign out
+// @Description End the session of the bearer token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Signed out"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/logout [post]
+func (s *Server) logout(c *gin.Context) {
+	if token := bearerToken(c); token != "" {
+		if err := s.userService.Logout(token); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign out"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Signed out"})
+}
+
+// @Summary Current user
+// @Description Return the signed-in user, or the API key a request used, and whether authentication is enabled. With authentication off there is no user and every caller has admin access.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Current user"
+// @Router /auth/me [get]
+func (s *Server) getCurrentUser(c *gin.Context) {
+	role := services.RoleAdmin
+	user := currentUser(c)
+	key := currentAPIKey(c)
+	switch {
+	case key != nil:
+		role = key.Role()
+	case user != nil:
+		role = user.Role
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"auth_enabled": s.config.Auth.Enabled,
+		"user":         user,
+		"api_key":      key,
+		"role":         role,
+	})
+}
+
+// @Summary List users
+// @Description List everyone who can sign in, with their roles (admin only)
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Users"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users [get]
+func (s *Server) getUsers(c *gin.Context) {
+	users, err := s.userService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "count": len(users)})
+}
+
+// @Summary Create user
+// @Description Add a user with the admin, editor or viewer role (admin only). Viewers can read everything but change nothing; editors can change data; admins also manage plugins, credentials and users.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "User: {\"username\": \"sam\", \"display_name\": \"Sam\", \"role\": \"viewer\", \"password\": \"at least 8 characters\"}"
+// @Success 201 {object} map[string]interface{} "Created user"
+// @Failure 400 {object} map[string]interface{} "Invalid user"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "Username already taken"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users [post]
+func (s *Server) createUser(c *gin.Context) {
+	var input services.UserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	user, err := s.userService.Create(input)
+	if err != nil {
+		respondUserError(c, err, "Failed to create user")
+		return
+	}
+
+	setAuditEntityID(c, user.ID)
+	c.JSON(http.StatusCreated, user)
+}
+
+// @Summary Update user
+// @Description Change a user's display name, role or password (admin only). A new password signs the user out everywhere. The last admin cannot be demoted.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body map[string]interface{} true "Fields to change: display_name, role, password"
+// @Success 200 {object} map[string]interface{} "Updated user"
+// @Failure 400 {object} map[string]interface{} "Invalid user"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Would leave no admin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id} [put]
+func (s *Server) updateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var update services.UserUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	user, err := s.userService.Update(id, update)
+	if err != nil {
+		respondUserError(c, err, "Failed to update user")
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Delete user
+// @Description Remove a user and end their sessions (admin only). The last admin cannot be deleted.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Would leave no admin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id} [delete]
+func (s *Server) deleteUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := s.userService.Delete(id); err != nil {
+		respondUserError(c, err, "Failed to delete user")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// respondUserError maps user service errors to responses
+func respondUserError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+	case errors.Is(err, services.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": "At least one admin is required"})
+	case errors.Is(err, services.ErrInvalidUser):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required, role must be admin, editor or viewer, and passwords need at least 8 characters"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondBackupError maps backup service errors to responses
+func respondBackupError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, services.ErrBackupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Backup not found"})
+	case errors.Is(err, services.ErrBackupInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": "A backup or restore is already running"})
+	default:
+		fmt.Printf("WARNING: %s: %v\n", failure, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
+
+// @Summary List database backups
+// @Description Database backups in the storage backend, newest first, with the retention policy applied after each scheduled backup
+// @Tags backups
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Backups"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /backups [get]
+func (s *Server) getBackups(c *gin.Context) {
+	backups, err := s.backupService.List(c.Request.Context())
+	if err != nil {
+		respondBackupError(c, err, "Failed to list backups")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backups": backups,
+		"count":   len(backups),
+		"schedule": gin.H{
+			"enabled":        s.config.Backup.ScheduleEnabled,
+			"interval_hours": int(s.config.Backup.Interval.Hours()),
+		},
+		"retention": gin.H{
+			"count": s.config.Backup.RetentionCount,
+			"days":  s.config.Backup.RetentionDays,
+		},
+	})
+}
+
+// @Summary Back up the database
+// @Description Dump the database with pg_dump, or copy it under SQLite, and store the archive in the storage backend. Backups beyond the retention policy are then removed.
+// @Tags backups
+// @Produce json
+// @Success 201 {object} map[string]interface{} "Backup created"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "A backup or restore is already running"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /backups [post]
+func (s *Server) createBackup(c *gin.Context) {
+	backup, err := s.backupService.Create(c.Request.Context())
+	if err != nil {
+		respondBackupError(c, err, "Failed to back up the database")
+		return
+	}
+
+	removed, err := s.backupService.Prune(c.Request.Context(), backup.CreatedAt)
+	if err != nil {
+		fmt.Printf("WARNING: Pruning backups failed: %v\n", err)
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Database backed up successfully",
+		"backup":  backup,
+		"pruned":  removed,
+	})
+}
+
+// @Summary Download a database backup
+// @Description The pg_dump archive of a backup, in custom format for pg_restore, or the copy of a SQLite database
+// @Tags backups
+// @Produce octet-stream
+// @Param name path string true "Backup name"
+// @Success 200 {file} file "Backup archive"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Backup not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /backups/{name}/download [get]
+func (s *Server) downloadBackup(c *gin.Context) {
+	name := c.Param("name")
+	archive, err := s.backupService.Open(c.Request.Context(), name)
+	if err != nil {
+		respondBackupError(c, err, "Failed to read backup")
+		return
+	}
+	defer archive.Close()
+
+	c.DataFromReader(http.StatusOK, -1, "application/octet-stream", archive, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": name}),
+	})
+}
+
+// @Summary Restore a database backup
+// @Description Replace the database's contents with a backup. confirm must repeat the backup name. The current database is backed up first and that backup is returned, so the restore can be undone. The restore runs in one transaction and leaves the database unchanged if it fails.
+// @Tags backups
+// @Accept json
+// @Produce json
+// @Param name path string true "Backup name"
+// @Param request body map[string]interface{} true "confirm"
+// @Success 200 {object} map[string]interface{} "Database restored"
+// @Failure 400 {object} map[string]interface{} "confirm does not match the backup name"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Backup not found"
+// @Failure 409 {object} map[string]interface{} "A backup or restore is already running"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /backups/{name}/restore [post]
+func (s *Server) restoreBackup(c *gin.Context) {
+	name := c.Param("name")
+	var req struct {
+		Confirm string `json:"confirm" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if req.Confirm != name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "confirm must repeat the backup name"})
+		return
+	}
+
+	safety, err := s.backupService.Restore(c.Request.Context(), name)
+	if err != nil {
+		respondBackupError(c, err, "Failed to restore the database")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       fmt.Sprintf("Database restored from %s", name),
+		"previous_data": safety,
+	})
+}
+
+// @Summary Delete a database backup
+// @Description Remove a backup from the storage backend
+// @Tags backups
+// @Produce json
+// @Param name path string true "Backup name"
+// @Success 200 {object} map[string]interface{} "Backup deleted"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Backup not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /backups/{name} [delete]
+func (s *Server) deleteBackup(c *gin.Context) {
+	if err := s.backupService.Delete(c.Request.Context(), c.Param("name")); err != nil {
+		respondBackupError(c, err, "Failed to delete backup")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Backup deleted successfully"})
+}
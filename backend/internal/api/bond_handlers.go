@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondBondError maps bond service errors to HTTP responses
+func respondBondError(c *gin.Context, err error, failureMsg string) {
+	if errors.Is(err, services.ErrBondNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bond not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+}
+
+// @Summary Get bonds
+// @Description List bonds, treasuries and CDs, soonest maturity first, with their totals. Each is valued at its current price, or its purchase price until one is entered, plus the interest accrued since its last coupon; a matured bond counts at face value.
+// @Tags bonds
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Bonds with totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /bonds [get]
+func (s *Server) getBonds(c *gin.Context) {
+	portfolio, err := s.bondService.List(time.Now())
+	if err != nil {
+		respondBondError(c, err, "Failed to fetch bonds")
+		return
+	}
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// @Summary Get bond ladder
+// @Description Bonds grouped by the year they mature, with every year from the first maturity to the last so gaps show, and the face-weighted average years to maturity and coupon rate of those outstanding
+// @Tags bonds
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Bond ladder"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /bonds/ladder [get]
+func (s *Server) getBondLadder(c *gin.Context) {
+	ladder, err := s.bondService.Ladder(time.Now())
+	if err != nil {
+		respondBondError(c, err, "Failed to build bond ladder")
+		return
+	}
+	c.JSON(http.StatusOK, ladder)
+}
+
+// @Summary Get bond
+// @Description A bond with its market value, accrued interest and current value
+// @Tags bonds
+// @Produce json
+// @Param id path int true "Bond ID"
+// @Success 200 {object} map[string]interface{} "Bond"
+// @Failure 400 {object} map[string]interface{} "Invalid bond ID"
+// @Failure 404 {object} map[string]interface{} "Bond not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /bonds/{id} [get]
+func (s *Server) getBond(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bond ID"})
+		return
+	}
+
+	bond, err := s.bondService.Get(id, time.Now())
+	if err != nil {
+		respondBondError(c, err, "Failed to fetch bond")
+		return
+	}
+	c.JSON(http.StatusOK, bond)
+}
+
+// @Summary Create bond
+// @Description Add a bond, treasury or CD using the bonds plugin. Prices are percentages of face value, and a CUSIP must have a valid check digit.
+// @Tags bonds
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Bond: {\"institution_name\": \"Fidelity\", \"bond_name\": \"US Treasury 4.25% 2034\", \"bond_type\": \"treasury\", \"cusip\": \"91282CJZ5\", \"face_value\": 10000, \"coupon_rate\": 4.25, \"coupon_frequency\": \"semiannual\", \"maturity_date\": \"2034-02-15\", \"purchase_price\": 98.75}"
+// @Success 201 {object} map[string]interface{} "Bond created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /bonds [post]
+func (s *Server) createBond(c *gin.Context) {
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("bonds")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Bonds plugin not found"})
+		return
+	}
+	txPlugin, ok := plugin.(plugins.TxManualEntryPlugin)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Plugin does not support manual entry"})
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bond"})
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := txPlugin.CreateManualEntryTx(tx, requestData)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to create bond: %v", err)})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bond"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Bond created successfully",
+	})
+}
+
+// @Summary Update bond
+// @Description Update a bond using the bonds plugin, for example to enter its current price
+// @Tags bonds
+// @Accept json
+// @Produce json
+// @Param id path int true "Bond ID"
+// @Param request body map[string]interface{} true "Updated bond details"
+// @Success 200 {object} map[string]interface{} "Bond updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 404 {object} map[string]interface{} "Bond not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /bonds/{id} [put]
+func (s *Server) updateBond(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bond ID"})
+		return
+	}
+
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("bonds")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Bonds plugin not found"})
+		return
+	}
+
+	if err := plugin.UpdateManualEntry(id, requestData); err != nil {
+		if strings.Contains(err.Error(), "no bond found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bond not found"})
+		} else {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to update bond: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bond updated successfully"})
+}
+
+// @Summary Delete bond
+// @Description Delete a bond, for example once it has matured and been repaid
+// @Tags bonds
+// @Produce json
+// @Param id path int true "Bond ID"
+// @Success 200 {object} map[string]interface{} "Bond deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid bond ID"
+// @Failure 404 {object} map[string]interface{} "Bond not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /bonds/{id} [delete]
+func (s *Server) deleteBond(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bond ID"})
+		return
+	}
+
+	if err := s.bondService.Delete(id); err != nil {
+		respondBondError(c, err, "Failed to delete bond")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bond deleted successfully"})
+}
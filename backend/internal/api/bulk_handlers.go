@@ -0,0 +1,244 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkItems caps how many entries a single bulk request may create or delete by ID
+const maxBulkItems = 500
+
+// bulkCreateRequest is the body of a bulk create. Atomic defaults to true.
+type bulkCreateRequest struct {
+	Items  []map[string]interface{} `json:"items" binding:"required"`
+	Atomic *bool                    `json:"atomic"`
+}
+
+// bulkDeleteRequest is the body of a bulk delete: either explicit IDs or
+// filter criteria, optionally narrowed by creation date
+type bulkDeleteRequest struct {
+	IDs           []int                  `json:"ids" binding:"dive,gt=0"`
+	Filter        map[string]interface{} `json:"filter"`
+	CreatedAfter  string                 `json:"created_after"`
+	CreatedBefore string                 `json:"created_before"`
+}
+
+// bulkDeleter is implemented by repositories that support bulk deletes
+type bulkDeleter interface {
+	BulkDelete(filter repository.BulkDeleteFilter) (*repository.BulkDeleteResult, error)
+}
+
+// @Summary Bulk create stock holdings
+// @Description Create many stock holdings in one transaction. With atomic (the default) any invalid item rolls back the whole batch; otherwise valid items are kept and failures reported per item.
+// @Tags stocks
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "items array of stock holdings and optional atomic flag"
+// @Success 201 {object} map[string]interface{} "All items created"
+// @Success 206 {object} map[string]interface{} "Some items failed (non-atomic)"
+// @Failure 400 {object} map[string]interface{} "Invalid request, or an item failed and the batch was rolled back"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /stocks/bulk [post]
+func (s *Server) bulkCreateStockHoldings(c *gin.Context) {
+	s.bulkCreate(c, "stock_holding")
+}
+
+// @Summary Bulk create crypto holdings
+// @Description Create many crypto holdings in one transaction. With atomic (the default) any invalid item rolls back the whole batch; otherwise valid items are kept and failures reported per item.
+// @Tags crypto
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "items array of crypto holdings and optional atomic flag"
+// @Success 201 {object} map[string]interface{} "All items created"
+// @Success 206 {object} map[string]interface{} "Some items failed (non-atomic)"
+// @Failure 400 {object} map[string]interface{} "Invalid request, or an item failed and the batch was rolled back"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto-holdings/bulk [post]
+func (s *Server) bulkCreateCryptoHoldings(c *gin.Context) {
+	s.bulkCreate(c, "crypto_holdings")
+}
+
+// @Summary Bulk create cash holdings
+// @Description Create many cash holdings in one transaction. With atomic (the default) any invalid item rolls back the whole batch; otherwise valid items are kept and failures reported per item.
+// @Tags cash-holdings
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "items array of cash holdings and optional atomic flag"
+// @Success 201 {object} map[string]interface{} "All items created"
+// @Success 206 {object} map[string]interface{} "Some items failed (non-atomic)"
+// @Failure 400 {object} map[string]interface{} "Invalid request, or an item failed and the batch was rolled back"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-holdings/bulk [post]
+func (s *Server) bulkCreateCashHoldings(c *gin.Context) {
+	s.bulkCreate(c, "cash_holdings")
+}
+
+// @Summary Bulk create other assets
+// @Description Create many other assets in one transaction. With atomic (the default) any invalid item rolls back the whole batch; otherwise valid items are kept and failures reported per item.
+// @Tags other-assets
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "items array of other assets and optional atomic flag"
+// @Success 201 {object} map[string]interface{} "All items created"
+// @Success 206 {object} map[string]interface{} "Some items failed (non-atomic)"
+// @Failure 400 {object} map[string]interface{} "Invalid request, or an item failed and the batch was rolled back"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /other-assets/bulk [post]
+func (s *Server) bulkCreateOtherAssets(c *gin.Context) {
+	s.bulkCreate(c, "other_assets")
+}
+
+// bulkCreate creates the request's items through the named manual entry plugin
+func (s *Server) bulkCreate(c *gin.Context, pluginName string) {
+	var request bulkCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if len(request.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No items provided"})
+		return
+	}
+	if len(request.Items) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d items can be created at once", maxBulkItems)})
+		return
+	}
+
+	atomic := true
+	if request.Atomic != nil {
+		atomic = *request.Atomic
+	}
+
+	result, err := s.pluginManager.BulkCreateManualEntries(pluginName, request.Items, atomic)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Bulk create failed: %v", err)})
+		return
+	}
+
+	if !result.Committed {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	created := make(map[int]map[string]interface{}, result.CreatedCount)
+	for _, item := range result.Results {
+		if item.ID != 0 {
+			created[item.ID] = nil
+		}
+	}
+	setAuditBulkChanges(c, created)
+
+	status := http.StatusCreated
+	if result.FailedCount > 0 {
+		status = http.StatusPartialContent
+	}
+	c.JSON(status, result)
+}
+
+// @Summary Bulk delete stock holdings
+// @Description Delete stock holdings by ID list or by filter (symbol, institution_name, data_source) and creation date range, in one transaction
+// @Tags stocks
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "ids array, or filter object with optional created_after/created_before"
+// @Success 200 {object} map[string]interface{} "Per-ID delete results"
+// @Failure 400 {object} map[string]interface{} "Invalid or empty filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /stocks/bulk-delete [post]
+func (s *Server) bulkDeleteStockHoldings(c *gin.Context) {
+	s.bulkDelete(c, s.repos.Stocks)
+}
+
+// @Summary Bulk delete crypto holdings
+// @Description Delete crypto holdings by ID list or by filter (crypto_symbol, institution_name) and creation date range, in one transaction
+// @Tags crypto
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "ids array, or filter object with optional created_after/created_before"
+// @Success 200 {object} map[string]interface{} "Per-ID delete results"
+// @Failure 400 {object} map[string]interface{} "Invalid or empty filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto-holdings/bulk-delete [post]
+func (s *Server) bulkDeleteCryptoHoldings(c *gin.Context) {
+	s.bulkDelete(c, s.repos.Crypto)
+}
+
+// @Summary Bulk delete cash holdings
+// @Description Delete cash holdings by ID list or by filter (institution_name, account_name, account_type) and creation date range, in one transaction
+// @Tags cash-holdings
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "ids array, or filter object with optional created_after/created_before"
+// @Success 200 {object} map[string]interface{} "Per-ID delete results"
+// @Failure 400 {object} map[string]interface{} "Invalid or empty filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-holdings/bulk-delete [post]
+func (s *Server) bulkDeleteCashHoldings(c *gin.Context) {
+	s.bulkDelete(c, s.repos.Cash)
+}
+
+// @Summary Bulk delete other assets
+// @Description Delete other assets by ID list or by filter (asset_category_id, asset_name) and creation date range, in one transaction
+// @Tags other-assets
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "ids array, or filter object with optional created_after/created_before"
+// @Success 200 {object} map[string]interface{} "Per-ID delete results"
+// @Failure 400 {object} map[string]interface{} "Invalid or empty filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /other-assets/bulk-delete [post]
+func (s *Server) bulkDeleteOtherAssets(c *gin.Context) {
+	s.bulkDelete(c, s.repos.OtherAssets)
+}
+
+// bulkDelete deletes the rows selected by the request body from repo
+func (s *Server) bulkDelete(c *gin.Context, repo bulkDeleter) {
+	var request bulkDeleteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if len(request.IDs) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d IDs can be deleted at once", maxBulkItems)})
+		return
+	}
+
+	filter := repository.BulkDeleteFilter{IDs: request.IDs, Fields: make(map[string]string, len(request.Filter))}
+	for name, value := range request.Filter {
+		filter.Fields[name] = fmt.Sprint(value)
+	}
+	if request.CreatedAfter != "" {
+		t, err := parseAuditTime(request.CreatedAfter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_after, use YYYY-MM-DD or RFC 3339"})
+			return
+		}
+		filter.CreatedAfter = &t
+	}
+	if request.CreatedBefore != "" {
+		t, err := parseAuditTime(request.CreatedBefore)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_before, use YYYY-MM-DD or RFC 3339"})
+			return
+		}
+		filter.CreatedBefore = &t
+	}
+
+	result, err := repo.BulkDelete(filter)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Bulk delete failed: %v", err)})
+		return
+	}
+
+	setAuditBulkChanges(c, result.Deleted)
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheStatusHeader reports whether a response was served from the read-through cache
+const cacheStatusHeader = "X-Cache"
+
+// setCacheStatus marks a response as a cache hit or miss
+func setCacheStatus(c *gin.Context, hit bool) {
+	if hit {
+		c.Header(cacheStatusHeader, "HIT")
+	} else {
+		c.Header(cacheStatusHeader, "MISS")
+	}
+}
+
+// invalidateCache drops every cached aggregate so the next read recomputes it
+func (s *Server) invalidateCache() {
+	s.writeGeneration.Add(1)
+	if err := s.cache.Invalidate(); err != nil {
+		log.Printf("WARNING: Failed to invalidate response cache: %v", err)
+	}
+}
+
+// invalidateCacheOnWrite clears cached aggregates after any successful mutation.
+// Every write can affect net worth, so invalidation is deliberately coarse.
+func (s *Server) invalidateCacheOnWrite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if c.Writer.Status() < http.StatusBadRequest {
+			s.invalidateCache()
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxCashFlowImportBytes bounds the size of an uploaded statement
+	maxCashFlowImportBytes = 5 << 20
+	// maxCashFlowTransactions bounds a transaction listing
+	maxCashFlowTransactions = 1000
+	// maxCashFlowSummaryMonths bounds the range of a cash-flow summary
+	maxCashFlowSummaryMonths = 120
+	// cashFlowProjectionMonths is how many full months of net savings feed
+	// balance projections
+	cashFlowProjectionMonths = 3
+)
+
+// respondCashFlowError maps cash-flow repository errors to HTTP responses
+func (s *Server) respondCashFlowError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidReference):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrDuplicateCategory), errors.Is(err, repository.ErrCategoryInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.respondRepositoryError(c, err, notFoundMsg, failureMsg)
+	}
+}
+
+// bindCashFlowCategory binds and validates a category body
+func bindCashFlowCategory(c *gin.Context) (*models.CashFlowCategoryInput, bool) {
+	var input models.CashFlowCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, false
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return nil, false
+	}
+
+	return &input, true
+}
+
+// bindCashFlowTransaction binds and validates a transaction body, returning its
+// parsed date
+func bindCashFlowTransaction(c *gin.Context) (*models.CashFlowTransactionInput, time.Time, bool) {
+	var input models.CashFlowTransactionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, time.Time{}, false
+	}
+
+	date, err := time.Parse("2006-01-02", input.TransactionDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transaction_date must be YYYY-MM-DD"})
+		return nil, time.Time{}, false
+	}
+
+	return &input, date, true
+}
+
+// recentMonthlyNetSavings averages net savings over the last full months
+func (s *Server) recentMonthlyNetSavings(now time.Time) (float64, error) {
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	summary, err := s.repos.CashFlow.Summary(thisMonth.AddDate(0, -cashFlowProjectionMonths, 0), thisMonth)
+	if err != nil {
+		return 0, err
+	}
+	return summary.AverageMonthlyNetSavings.InexactFloat64(), nil
+}
+
+// @Summary Get cash-flow categories
+// @Description List income and expense categories
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Categories"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/categories [get]
+func (s *Server) getCashFlowCategories(c *gin.Context) {
+	categories, err := s.repos.CashFlow.ListCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+		"count":      len(categories),
+	})
+}
+
+// @Summary Create cash-flow category
+// @Description Create an income or expense category
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param category body map[string]interface{} true "Category (name, kind: income|expense, color)"
+// @Success 201 {object} map[string]interface{} "Category created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Category already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/categories [post]
+func (s *Server) createCashFlowCategory(c *gin.Context) {
+	input, ok := bindCashFlowCategory(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.CashFlow.CreateCategory(*input)
+	if err != nil {
+		s.respondCashFlowError(c, err, "Category not found", "Failed to create category")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Category created successfully",
+	})
+}
+
+// @Summary Update cash-flow category
+// @Description Rename or recolor a category
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param category body map[string]interface{} true "Category (name, kind: income|expense, color)"
+// @Success 200 {object} map[string]interface{} "Category updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Category already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/categories/{id} [put]
+func (s *Server) updateCashFlowCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	input, ok := bindCashFlowCategory(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.CashFlow.UpdateCategory(id, *input); err != nil {
+		s.respondCashFlowError(c, err, "Category not found", "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category updated successfully",
+	})
+}
+
+// @Summary Delete cash-flow category
+// @Description Delete a category that has no transactions
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} map[string]interface{} "Category deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid category ID"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Category has transactions"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/categories/{id} [delete]
+func (s *Server) deleteCashFlowCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	if err := s.repos.CashFlow.DeleteCategory(id); err != nil {
+		s.respondCashFlowError(c, err, "Category not found", "Failed to delete category")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category deleted successfully",
+	})
+}
+
+// @Summary Get cash-flow transactions
+// @Description List income and expense transactions, newest first
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param from query string false "Earliest transaction date (YYYY-MM-DD)"
+// @Param to query string false "Latest transaction date (YYYY-MM-DD)"
+// @Param category_id query int false "Filter by category"
+// @Param kind query string false "Filter by kind (income or expense)"
+// @Param limit query int false "Maximum transactions to return (default 100, max 1000)"
+// @Success 200 {object} map[string]interface{} "Transactions"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/transactions [get]
+func (s *Server) getCashFlowTransactions(c *gin.Context) {
+	filter := repository.CashFlowFilter{Limit: 100, Kind: c.Query("kind")}
+	if filter.Kind != "" && filter.Kind != models.CashFlowIncome && filter.Kind != models.CashFlowExpense {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be income or expense"})
+		return
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		filter.From = &from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		filter.To = &to
+	}
+
+	if value := c.Query("category_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category_id"})
+			return
+		}
+		filter.CategoryID = id
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxCashFlowTransactions {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxCashFlowTransactions)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	transactions, err := s.repos.CashFlow.ListTransactions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"count":        len(transactions),
+	})
+}
+
+// @Summary Create cash-flow transaction
+// @Description Record an income or expense. The amount is positive; the category's kind gives the direction.
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param transaction body map[string]interface{} true "Transaction (category_id, amount, transaction_date, description, cash_holding_id)"
+// @Success 201 {object} map[string]interface{} "Transaction created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/transactions [post]
+func (s *Server) createCashFlowTransaction(c *gin.Context) {
+	input, date, ok := bindCashFlowTransaction(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.CashFlow.CreateTransaction(*input, date)
+	if err != nil {
+		s.respondCashFlowError(c, err, "Transaction not found", "Failed to create transaction")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Transaction created successfully",
+	})
+}
+
+// @Summary Update cash-flow transaction
+// @Description Replace a transaction's fields
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param transaction body map[string]interface{} true "Transaction (category_id, amount, transaction_date, description, cash_holding_id)"
+// @Success 200 {object} map[string]interface{} "Transaction updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/transactions/{id} [put]
+func (s *Server) updateCashFlowTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	input, date, ok := bindCashFlowTransaction(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.CashFlow.UpdateTransaction(id, *input, date); err != nil {
+		s.respondCashFlowError(c, err, "Transaction not found", "Failed to update transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Transaction updated successfully",
+	})
+}
+
+// @Summary Delete cash-flow transaction
+// @Description Delete an income or expense transaction
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} map[string]interface{} "Transaction deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid transaction ID"
+// @Failure 404 {object} map[string]interface{} "Transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/transactions/{id} [delete]
+func (s *Server) deleteCashFlowTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	if err := s.repos.CashFlow.DeleteTransaction(id); err != nil {
+		s.respondCashFlowError(c, err, "Transaction not found", "Failed to delete transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Transaction deleted successfully",
+	})
+}
+
+// @Summary Import cash-flow transactions
+// @Description Import a CSV bank statement as a multipart "file" field or a raw text/csv body. Columns: date, amount (negative for expenses) or debit/credit, and optional description, category and type. Rows already imported are skipped.
+// @Tags cash-flow
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Param file formData file false "CSV statement"
+// @Success 201 {object} map[string]interface{} "Import result"
+// @Failure 400 {object} map[string]interface{} "Invalid CSV"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/transactions/import [post]
+func (s *Server) importCashFlowTransactions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCashFlowImportBytes)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing CSV file"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	rows, err := services.ParseCashFlowCSV(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid CSV: %v", err)})
+		return
+	}
+
+	result, err := s.repos.CashFlow.Import(rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import transactions"})
+		return
+	}
+
+	created := make(map[int]map[string]interface{}, len(result.IDs))
+	for _, id := range result.IDs {
+		created[id] = nil
+	}
+	setAuditBulkChanges(c, created)
+	c.JSON(http.StatusCreated, result)
+}
+
+// @Summary Get cash-flow summary
+// @Description Monthly income, expenses, net savings and savings rate with per-category totals, ending with the current month
+// @Tags cash-flow
+// @Accept json
+// @Produce json
+// @Param months query int false "Months to summarize (default 12, max 120)"
+// @Success 200 {object} map[string]interface{} "Cash-flow summary"
+// @Failure 400 {object} map[string]interface{} "Invalid months"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cash-flow/summary [get]
+func (s *Server) getCashFlowSummary(c *gin.Context) {
+	months := 12
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > maxCashFlowSummaryMonths {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("months must be between 1 and %d", maxCashFlowSummaryMonths),
+			})
+			return
+		}
+		months = parsed
+	}
+
+	now := time.Now()
+	nextMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	summary, err := s.repos.CashFlow.Summary(nextMonth.AddDate(0, -months, 0), nextMonth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize cash flow"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,396 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxProjectionMonths bounds how far ahead balances are projected
+const maxProjectionMonths = 600
+
+// parseCompounding reads how cash interest rates compound from the compounding
+// query parameter, monthly by default
+func parseCompounding(c *gin.Context) (string, bool) {
+	compounding := c.DefaultQuery("compounding", services.CompoundingMonthly)
+	if !services.IsCompounding(compounding) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "compounding must be daily, monthly, quarterly or annually"})
+		return "", false
+	}
+	return compounding, true
+}
+
+// respondContributionError maps contribution service errors to HTTP responses
+func respondContributionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrContributionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contribution not found"})
+	case errors.Is(err, services.ErrContributionNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "Contribution is not pending"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Summary Get recurring contributions
+// @Description List the monthly contribution schedule of every cash and brokerage account with a monthly contribution
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Contribution schedules"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions [get]
+func (s *Server) getRecurringContributions(c *gin.Context) {
+	schedules, err := s.contributionService.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get contribution schedules: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contributions": schedules,
+		"count":         len(schedules),
+	})
+}
+
+// @Summary Update recurring contribution
+// @Description Change the day of month (1-28), confirmation requirement or active state of a contribution schedule. The amount is the account's monthly_contribution.
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body map[string]interface{} true "day_of_month, requires_confirmation and/or active"
+// @Success 200 {object} map[string]interface{} "Updated schedule"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 404 {object} map[string]interface{} "Schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/{id} [put]
+func (s *Server) updateRecurringContribution(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	var update services.RecurringContributionUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	schedule, err := s.contributionService.UpdateSchedule(id, update)
+	if err != nil {
+		respondContributionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// @Summary Get contribution transactions
+// @Description List scheduled contributions and whether they were applied, skipped or are awaiting confirmation, newest first
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param status query string false "Filter by status (pending, applied, skipped)"
+// @Param cash_holding_id query int false "Filter by cash holding"
+// @Param limit query int false "Maximum number of transactions (default 100)"
+// @Success 200 {object} map[string]interface{} "Contribution transactions"
+// @Failure 400 {object} map[string]interface{} "Invalid status"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/transactions [get]
+func (s *Server) getContributionTransactions(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", services.ContributionPending, services.ContributionApplied, services.ContributionSkipped:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, applied or skipped"})
+		return
+	}
+
+	cashHoldingID, _ := strconv.Atoi(c.Query("cash_holding_id"))
+
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 1000 {
+		limit = l
+	}
+
+	transactions, err := s.contributionService.ListTransactions(status, cashHoldingID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get contribution transactions: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"count":        len(transactions),
+	})
+}
+
+// @Summary Confirm contribution
+// @Description Apply a pending contribution to its account balance
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param id path int true "Contribution transaction ID"
+// @Success 200 {object} map[string]interface{} "Applied contribution"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Contribution not found"
+// @Failure 409 {object} map[string]interface{} "Contribution is not pending"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/transactions/{id}/confirm [post]
+func (s *Server) confirmContribution(c *gin.Context) {
+	s.resolveContribution(c, s.contributionService.Confirm)
+}
+
+// @Summary Skip contribution
+// @Description Mark a pending contribution as not made, leaving the balance unchanged
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param id path int true "Contribution transaction ID"
+// @Success 200 {object} map[string]interface{} "Skipped contribution"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Contribution not found"
+// @Failure 409 {object} map[string]interface{} "Contribution is not pending"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/transactions/{id}/skip [post]
+func (s *Server) skipContribution(c *gin.Context) {
+	s.resolveContribution(c, s.contributionService.Skip)
+}
+
+func (s *Server) resolveContribution(c *gin.Context, resolve func(id int) (*services.ContributionTransaction, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+		return
+	}
+
+	transaction, err := resolve(id)
+	if err != nil {
+		respondContributionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, transaction)
+}
+
+// @Summary Apply due contributions
+// @Description Schedule every contribution due today or earlier now instead of waiting for the background job
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Counts of applied and pending contributions"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/run [post]
+func (s *Server) runContributions(c *gin.Context) {
+	result, err := s.contributionService.ProcessDue(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to apply contributions: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Project cash balances
+// @Description Project cash and brokerage balances forward with each account's interest rate and active monthly contribution. With include_cash_flow=true the average monthly net savings of the last three full months of tracked income and expenses is added each month; leave it off when those savings already fund the recurring contributions. The interest field gives the interest current balances earn over a year and the yield on all cash. Accounts with an employer match rule also receive the match their contributions earn each month; employer_match breaks it down by account with any match left unclaimed. Also served at /analytics/projection.
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param months query int false "Months to project (default 12, max 600)"
+// @Param include_cash_flow query bool false "Add average monthly net savings from cash-flow tracking"
+// @Param compounding query string false "How interest rates compound: daily, monthly (default), quarterly or annually (the rate is already an APY)"
+// @Success 200 {object} map[string]interface{} "Monthly projection"
+// @Failure 400 {object} map[string]interface{} "Invalid months or compounding"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/projection [get]
+func (s *Server) getContributionProjection(c *gin.Context) {
+	months := 12
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > maxProjectionMonths {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("months must be between 1 and %d", maxProjectionMonths),
+			})
+			return
+		}
+		months = parsed
+	}
+	compounding, ok := parseCompounding(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var cashFlowSavings float64
+	if c.Query("include_cash_flow") == "true" {
+		savings, err := s.recentMonthlyNetSavings(now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize cash flow"})
+			return
+		}
+		cashFlowSavings = savings
+	}
+
+	projection, err := s.contributionService.Project(months, now, cashFlowSavings, compounding)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to project balances: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, projection)
+}
+
+// respondEmployerMatchError maps employer match service errors to HTTP responses
+func respondEmployerMatchError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEmployerMatchRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmployerMatchRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employer match rule not found"})
+	case errors.Is(err, services.ErrMatchCashHoldingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cash holding not found"})
+	case errors.Is(err, services.ErrEmployerMatchRuleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cash holding already has an employer match rule"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+	}
+}
+
+// checkEmployerMatch warns about unclaimed match as soon as a rule changes
+// rather than at the next daily check
+func (s *Server) checkEmployerMatch() {
+	if err := s.employerMatchService.Check(); err != nil {
+		fmt.Printf("WARNING: Employer match check failed: %v\n", err)
+	}
+}
+
+// @Summary Get employer match
+// @Description List the employer match rules of retirement accounts with the match each account's active monthly contribution earns in a year. For a limited match, max_annual_match is the most the employer pays, contribution_for_full_match the yearly contribution that earns it and missed_annual_match what the current contribution leaves unclaimed, with a warning.
+// @Tags contributions
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Employer match by account"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/employer-match [get]
+func (s *Server) getEmployerMatch(c *gin.Context) {
+	projections, err := s.employerMatchService.Projections()
+	if err != nil {
+		respondEmployerMatchError(c, err, "Failed to fetch employer match")
+		return
+	}
+
+	var projected, missed float64
+	for _, p := range projections {
+		projected += p.ProjectedAnnualMatch
+		missed += p.MissedAnnualMatch
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"employer_match":         projections,
+		"count":                  len(projections),
+		"projected_annual_match": math.Round(projected*100) / 100,
+		"missed_annual_match":    math.Round(missed*100) / 100,
+	})
+}
+
+// @Summary Create employer match rule
+// @Description Add the employer match of a 401(k), 403(b), 457(b) or HSA cash holding. The employer adds match_percent of contributions, counting contributions up to salary_limit_percent of annual_salary when given, and pays at most annual_match_cap a year when given.
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param rule body map[string]interface{} true "Rule: {\"cash_holding_id\": 3, \"plan_type\": \"401k\", \"match_percent\": 50, \"salary_limit_percent\": 6, \"annual_salary\": 120000}"
+// @Success 201 {object} map[string]interface{} "Employer match rule created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Cash holding not found"
+// @Failure 409 {object} map[string]interface{} "Cash holding already has an employer match rule"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/employer-match [post]
+func (s *Server) createEmployerMatchRule(c *gin.Context) {
+	var input services.EmployerMatchRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	id, err := s.employerMatchService.CreateRule(input)
+	if err != nil {
+		respondEmployerMatchError(c, err, "Failed to create employer match rule")
+		return
+	}
+	s.checkEmployerMatch()
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Employer match rule created successfully",
+	})
+}
+
+// @Summary Update employer match rule
+// @Description Replace the terms of an employer match rule. Omitted limits are cleared.
+// @Tags contributions
+// @Accept json
+// @Produce json
+// @Param id path int true "Employer match rule ID"
+// @Param rule body map[string]interface{} true "Rule: {\"plan_type\": \"401k\", \"match_percent\": 100, \"annual_match_cap\": 4000}"
+// @Success 200 {object} map[string]interface{} "Employer match rule updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Employer match rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/employer-match/{id} [put]
+func (s *Server) updateEmployerMatchRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employer match rule ID"})
+		return
+	}
+
+	var input services.EmployerMatchRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.employerMatchService.UpdateRule(id, input); err != nil {
+		respondEmployerMatchError(c, err, "Failed to update employer match rule")
+		return
+	}
+	s.checkEmployerMatch()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Employer match rule updated successfully"})
+}
+
+// @Summary Delete employer match rule
+// @Description Delete an employer match rule
+// @Tags contributions
+// @Produce json
+// @Param id path int true "Employer match rule ID"
+// @Success 200 {object} map[string]interface{} "Employer match rule deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid employer match rule ID"
+// @Failure 404 {object} map[string]interface{} "Employer match rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /contributions/employer-match/{id} [delete]
+func (s *Server) deleteEmployerMatchRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employer match rule ID"})
+		return
+	}
+
+	if err := s.employerMatchService.DeleteRule(id); err != nil {
+		respondEmployerMatchError(c, err, "Failed to delete employer match rule")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Employer match rule deleted successfully"})
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCryptoIncomeMonths caps the months of staking income returned at once
+const maxCryptoIncomeMonths = 120
+
+// @Summary Get crypto staking positions
+// @Description Each staked holding's staked and liquid balance, APR, the rewards recorded so far valued when received, rewards accrued since the last accrual, and projected annual rewards at the current price
+// @Tags crypto-holdings
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Staking positions"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto/staking [get]
+func (s *Server) getCryptoStaking(c *gin.Context) {
+	positions, err := s.cryptoStakingService.Positions(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch staking positions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"positions": positions, "count": len(positions)})
+}
+
+// @Summary Get crypto income
+// @Description Staking reward income by month and coin, valued at the price when each reward was received
+// @Tags analytics
+// @Produce json
+// @Param months query int false "Months to include, ending with the current one (default 12)"
+// @Success 200 {object} map[string]interface{} "Crypto income by month"
+// @Failure 400 {object} map[string]interface{} "Invalid months"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/crypto-income [get]
+func (s *Server) getCryptoIncome(c *gin.Context) {
+	months := 12
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > maxCryptoIncomeMonths {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "months must be between 1 and " + strconv.Itoa(maxCryptoIncomeMonths)})
+			return
+		}
+		months = parsed
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-months, 0)
+	income, err := s.cryptoStakingService.Income(from, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crypto income"})
+		return
+	}
+	c.JSON(http.StatusOK, income)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondCryptoTransactionError maps crypto lot service errors to HTTP responses
+func respondCryptoTransactionError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCryptoTransaction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCryptoHoldingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Crypto holding not found"})
+	case errors.Is(err, services.ErrCryptoTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Crypto transaction not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+	}
+}
+
+// @Summary Get crypto transactions
+// @Description List a crypto holding's purchases and sales by date
+// @Tags crypto-holdings
+// @Produce json
+// @Param id path int true "Crypto holding ID"
+// @Success 200 {object} map[string]interface{} "Crypto transactions"
+// @Failure 400 {object} map[string]interface{} "Invalid crypto holding ID"
+// @Failure 404 {object} map[string]interface{} "Crypto holding not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto-holdings/{id}/transactions [get]
+func (s *Server) getCryptoTransactions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crypto holding ID"})
+		return
+	}
+
+	transactions, err := s.cryptoLotService.Transactions(id)
+	if err != nil {
+		respondCryptoTransactionError(c, err, "Failed to fetch crypto transactions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions, "count": len(transactions)})
+}
+
+// @Summary Create crypto transaction
+// @Description Record a purchase or sale of tokens, moving the holding's balance by the quantity. The balance held before a holding's first transaction is kept as an opening lot at its purchase price. A sale of more tokens than were held on its date is rejected.
+// @Tags crypto-holdings
+// @Accept json
+// @Produce json
+// @Param transaction body map[string]interface{} true "Transaction: {\"crypto_holding_id\": 1, \"transaction_type\": \"sell\", \"quantity\": 0.25, \"price_usd\": 64000, \"fee_usd\": 12.5, \"transaction_date\": \"2025-03-14\"}"
+// @Success 201 {object} map[string]interface{} "Crypto transaction created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Crypto holding not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto-holdings/transactions [post]
+func (s *Server) createCryptoTransaction(c *gin.Context) {
+	var input services.CryptoTransactionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	id, err := s.cryptoLotService.CreateTransaction(input)
+	if err != nil {
+		respondCryptoTransactionError(c, err, "Failed to create crypto transaction")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Crypto transaction created successfully",
+	})
+}
+
+// @Summary Delete crypto transaction
+// @Description Delete a purchase or sale and undo its change to the holding's balance. A purchase later sales were matched to can't be deleted while they'd be left without tokens.
+// @Tags crypto-holdings
+// @Produce json
+// @Param id path int true "Crypto transaction ID"
+// @Success 200 {object} map[string]interface{} "Crypto transaction deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Crypto transaction not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto-holdings/transactions/{id} [delete]
+func (s *Server) deleteCryptoTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid crypto transaction ID"})
+		return
+	}
+
+	if err := s.cryptoLotService.DeleteTransaction(id); err != nil {
+		respondCryptoTransactionError(c, err, "Failed to delete crypto transaction")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Crypto transaction deleted successfully"})
+}
+
+// @Summary Get crypto cost basis
+// @Description FIFO cost basis, open lots and unrealized and realized gains of each coin across its holdings. Unrealized gains use the latest cached price.
+// @Tags crypto-holdings
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Cost basis by coin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /crypto/cost-basis [get]
+func (s *Server) getCryptoCostBasis(c *gin.Context) {
+	costBases, err := s.cryptoLotService.CostBasis()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate crypto cost basis"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"coins": costBases, "count": len(costBases)})
+}
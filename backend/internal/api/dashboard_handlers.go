@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dashboardOwner returns whose layout a request works on: the signed-in
+// user's, or the shared layout (nil) while authentication is off
+func dashboardOwner(c *gin.Context) *int {
+	if user := currentUser(c); user != nil {
+		return &user.ID
+	}
+	return nil
+}
+
+// validateDashboardConfig checks what binding tags cannot: the schema
+// version, known widget types, unique widget IDs and ranges only on charts
+func validateDashboardConfig(config models.DashboardConfig) []plugins.ValidationError {
+	var fields []plugins.ValidationError
+	if config.Version != models.DashboardConfigVersion {
+		fields = append(fields, plugins.ValidationError{
+			Field:   "version",
+			Message: fmt.Sprintf("version must be %d", models.DashboardConfigVersion),
+			Code:    "unsupported",
+		})
+	}
+
+	seen := make(map[string]bool, len(config.Widgets))
+	for i, widget := range config.Widgets {
+		ranged, known := models.DashboardWidgetTypes[widget.Type]
+		switch {
+		case !known:
+			fields = append(fields, plugins.ValidationError{
+				Field:   fmt.Sprintf("widgets[%d].type", i),
+				Message: fmt.Sprintf("unknown widget type %q", widget.Type),
+				Code:    "oneof",
+			})
+		case widget.Range != "" && !ranged:
+			fields = append(fields, plugins.ValidationError{
+				Field:   fmt.Sprintf("widgets[%d].range", i),
+				Message: fmt.Sprintf("%s widgets do not take a range", widget.Type),
+				Code:    "not_allowed",
+			})
+		}
+		if seen[widget.ID] {
+			fields = append(fields, plugins.ValidationError{
+				Field:   fmt.Sprintf("widgets[%d].id", i),
+				Message: fmt.Sprintf("widget id %q is used more than once", widget.ID),
+				Code:    "unique",
+			})
+		}
+		seen[widget.ID] = true
+	}
+	return fields
+}
+
+// @Summary Get dashboard layout
+// @Description Return the saved dashboard layout: which widgets to show, in order, with their size and chart range. Each user has their own layout; while authentication is off there is one shared layout. default is true when nothing has been saved yet. widget_types lists the available widgets and whether each takes a range.
+// @Tags dashboard
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Dashboard layout"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /dashboard/config [get]
+func (s *Server) getDashboardConfig(c *gin.Context) {
+	saved, err := s.repos.Dashboard.Get(dashboardOwner(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dashboard config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":       saved.Config,
+		"default":      saved.Default,
+		"updated_at":   saved.UpdatedAt,
+		"widget_types": models.DashboardWidgetTypes,
+	})
+}
+
+// @Summary Save dashboard layout
+// @Description Replace the dashboard layout. Widgets are shown in list order. version must be 1, widget ids must be unique, type must be one of widget_types, size is small, medium or large, and range (1M, 3M, 6M, 1Y, YTD or ALL) is only allowed on chart widgets. Unknown fields are dropped.
+// @Tags dashboard
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Layout: {\"version\": 1, \"columns\": 4, \"widgets\": [{\"id\": \"trend\", \"type\": \"net_worth_trend\", \"size\": \"large\", \"range\": \"6M\"}]}"
+// @Success 200 {object} map[string]interface{} "Saved layout"
+// @Failure 400 {object} map[string]interface{} "Invalid layout"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /dashboard/config [put]
+func (s *Server) updateDashboardConfig(c *gin.Context) {
+	var config models.DashboardConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if fields := validateDashboardConfig(config); len(fields) > 0 {
+		respondValidationErrors(c, "Invalid dashboard config", fields)
+		return
+	}
+
+	saved, err := s.repos.Dashboard.Save(dashboardOwner(c), config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save dashboard config"})
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// @Summary Reset dashboard layout
+// @Description Delete the saved dashboard layout so the default layout applies again
+// @Tags dashboard
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Default layout"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /dashboard/config [delete]
+func (s *Server) resetDashboardConfig(c *gin.Context) {
+	if err := s.repos.Dashboard.Reset(dashboardOwner(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset dashboard config"})
+		return
+	}
+	c.JSON(http.StatusOK, models.SavedDashboardConfig{Config: models.DefaultDashboardConfig(), Default: true})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/cache"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// dashboardTrendDays is how far back the summary's net worth trend goes
+	dashboardTrendDays = 30
+	// dashboardTopHoldings is how many of the largest holdings the summary lists
+	dashboardTopHoldings = 5
+	// dashboardUpcomingVests is how many upcoming vests the summary lists
+	dashboardUpcomingVests = 5
+)
+
+// dashboardSummary is everything the landing page shows on first paint
+type dashboardSummary struct {
+	NetWorth            decimal.Decimal            `json:"net_worth"`
+	TotalAssets         decimal.Decimal            `json:"total_assets"`
+	TotalLiabilities    decimal.Decimal            `json:"total_liabilities"`
+	UnvestedEquityValue decimal.Decimal            `json:"unvested_equity_value"`
+	Components          []models.NetWorthComponent `json:"components"`
+	Trend               dashboardTrend             `json:"trend"`
+	TopHoldings         []models.HoldingValue      `json:"top_holdings"`
+	UpcomingVesting     []models.UpcomingVest      `json:"upcoming_vesting"`
+	Prices              dashboardPriceWarning      `json:"prices"`
+}
+
+// dashboardTrend is net worth at each daily snapshot of the trend window and
+// the change from the first of them to now
+type dashboardTrend struct {
+	Days          int                   `json:"days"`
+	Change        decimal.Decimal       `json:"change"`
+	ChangePercent *float64              `json:"change_percent"`
+	Points        []dashboardTrendPoint `json:"points"`
+}
+
+// dashboardTrendPoint is net worth at one daily snapshot
+type dashboardTrendPoint struct {
+	Date     string          `json:"date"`
+	NetWorth decimal.Decimal `json:"net_worth"`
+}
+
+// dashboardPriceWarning flags prices or valuations that need refreshing.
+// StaleCount adds up the stale items of every asset class.
+type dashboardPriceWarning struct {
+	Stale           bool     `json:"stale"`
+	StaleCount      int      `json:"stale_count"`
+	LastPriceUpdate string   `json:"last_price_update,omitempty"`
+	Recommendations []string `json:"recommendations"`
+}
+
+// @Summary Get dashboard summary
+// @Description Everything the landing page needs in one response: net worth with its asset classes, the trend over the last 30 days of daily snapshots, the 5 largest holdings, the next 5 vests and whether prices need refreshing. The response has an ETag; send it back in If-None-Match to get 304 Not Modified while nothing has changed.
+// @Tags dashboard
+// @Produce json
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} map[string]interface{} "Dashboard summary"
+// @Success 304 "Not modified"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /dashboard/summary [get]
+func (s *Server) getDashboardSummary(c *gin.Context) {
+	summary, hit, err := cache.GetOrLoad(s.cache, cache.KeyDashboardSummary, s.config.Cache.TTL, s.buildDashboardSummary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build dashboard summary"})
+		return
+	}
+
+	setCacheStatus(c, hit)
+	respondWithETag(c, summary)
+}
+
+// buildDashboardSummary gathers the summary from the cached net worth and
+// price status where available
+func (s *Server) buildDashboardSummary() (dashboardSummary, error) {
+	breakdown, _, err := cache.GetOrLoad(s.cache, cache.KeyNetWorthBreakdown, s.config.Cache.TTL, s.repos.NetWorth.Breakdown)
+	if err != nil {
+		return dashboardSummary{}, err
+	}
+	if s.config.Risk.StablecoinsAsCash {
+		breakdown = breakdown.StablecoinsAsCash()
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	summary := dashboardSummary{
+		NetWorth:            breakdown.NetWorth(),
+		TotalAssets:         breakdown.TotalAssets(),
+		TotalLiabilities:    breakdown.TotalLiabilities,
+		UnvestedEquityValue: breakdown.UnvestedEquityValue,
+		Components:          breakdown.Components(),
+		Trend:               dashboardTrend{Days: dashboardTrendDays, Points: []dashboardTrendPoint{}},
+	}
+
+	snapshots, err := s.netWorthHistoryService.List(today.AddDate(0, 0, -dashboardTrendDays), today.AddDate(0, 0, 1), services.SnapshotTriggerScheduled)
+	if err != nil {
+		return dashboardSummary{}, err
+	}
+	for _, snapshot := range snapshots {
+		summary.Trend.Points = append(summary.Trend.Points, dashboardTrendPoint{
+			Date:     snapshot.Timestamp.Format("2006-01-02"),
+			NetWorth: snapshot.NetWorth,
+		})
+	}
+	if len(snapshots) > 0 {
+		first := snapshots[0].NetWorth
+		summary.Trend.Change = summary.NetWorth.Sub(first)
+		if !first.IsZero() {
+			percent := summary.Trend.Change.Div(first.Abs()).Mul(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+			summary.Trend.ChangePercent = &percent
+		}
+	}
+
+	if summary.TopHoldings, err = s.repos.NetWorth.TopHoldings(dashboardTopHoldings); err != nil {
+		return dashboardSummary{}, err
+	}
+	if summary.UpcomingVesting, err = s.repos.Equity.UpcomingVests(today, dashboardUpcomingVests); err != nil {
+		return dashboardSummary{}, err
+	}
+
+	priceStatus, _, _ := cache.GetOrLoad(s.cache, cache.KeyPricesStatus, s.config.Cache.TTL, func() (PriceStatus, error) {
+		return s.getPriceStatus(), nil
+	})
+	summary.Prices = dashboardPriceWarning{
+		Stale:           priceStatus.CacheStale || len(priceStatus.Recommendations) > 0,
+		LastPriceUpdate: priceStatus.LastCacheUpdate,
+		Recommendations: priceStatus.Recommendations,
+	}
+	for _, class := range priceStatus.AssetClasses {
+		summary.Prices.StaleCount += class.StaleCount
+	}
+	if summary.Prices.Recommendations == nil {
+		summary.Prices.Recommendations = []string{}
+	}
+	return summary, nil
+}
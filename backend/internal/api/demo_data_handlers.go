@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondDemoDataError maps demo data service errors to responses
+func respondDemoDataError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, services.ErrDemoDataLoaded), errors.Is(err, services.ErrDatabaseNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoDemoData):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		fmt.Printf("WARNING: %s: %v\n", failure, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
+
+// @Summary Get demo data status
+// @Description Whether demo data is loaded, when, and how many rows of each table it added
+// @Tags demo-data
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Demo data status"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /demo-data [get]
+func (s *Server) getDemoData(c *gin.Context) {
+	status, err := s.demoDataService.Status()
+	if err != nil {
+		respondDemoDataError(c, err, "Failed to get demo data status")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    status,
+		"demo_mode": s.config.Demo.Enabled,
+	})
+}
+
+// @Summary Load demo data
+// @Description Load a sample portfolio of stocks, equity grants, properties, cash, crypto and other assets with a year of price and net worth history. Only allowed while the database has no holdings.
+// @Tags demo-data
+// @Produce json
+// @Success 201 {object} map[string]interface{} "Demo data loaded"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "Demo data is loaded or the database has holdings"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /demo-data [post]
+func (s *Server) seedDemoData(c *gin.Context) {
+	status, err := s.demoDataService.Seed(time.Now())
+	if err != nil {
+		respondDemoDataError(c, err, "Failed to load demo data")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Demo data loaded successfully",
+		"status":  status,
+	})
+}
+
+// @Summary Remove demo data
+// @Description Remove every row demo data added, along with the net worth and holding snapshots recorded since it was loaded
+// @Tags demo-data
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Demo data removed"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "No demo data is loaded"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /demo-data [delete]
+func (s *Server) wipeDemoData(c *gin.Context) {
+	removed, err := s.demoDataService.Wipe()
+	if err != nil {
+		respondDemoDataError(c, err, "Failed to remove demo data")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Demo data removed successfully",
+		"removed": removed,
+	})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// depreciationAsOf reads the optional as_of date, defaulting to today. The
+// schedule covers later years, so as_of need not be in the future.
+func depreciationAsOf(c *gin.Context) (time.Time, bool) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return time.Time{}, false
+	}
+	if asOf == nil {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), true
+	}
+	return *asOf, true
+}
+
+// @Summary Get depreciation for all properties
+// @Description Straight-line depreciation of every property as of a date: annual and accumulated depreciation and adjusted basis. Investment properties depreciate their improvement_value over 27.5 years and commercial properties over 39, under the mid-month convention; other property types are listed with the reason they are not depreciated.
+// @Tags real-estate
+// @Produce json
+// @Param as_of query string false "Date to depreciate to (YYYY-MM-DD, not in the future, default today)"
+// @Success 200 {object} map[string]interface{} "Depreciation per property and totals"
+// @Failure 400 {object} map[string]interface{} "Invalid date"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/depreciation [get]
+func (s *Server) getRealEstateDepreciation(c *gin.Context) {
+	asOf, ok := depreciationAsOf(c)
+	if !ok {
+		return
+	}
+
+	properties, err := s.repos.RealEstate.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch real estate properties"})
+		return
+	}
+	additions, err := s.repos.PropertyLedger.BasisAdditions(asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch property basis additions"})
+		return
+	}
+
+	schedules := make([]services.PropertyDepreciation, 0, len(properties))
+	var annual, accumulated float64
+	for _, property := range properties {
+		d := services.DepreciateProperty(property, additions[property.ID], asOf)
+		if d.Depreciable {
+			annual += d.AnnualDepreciation
+			accumulated += d.AccumulatedDepreciation
+		}
+		schedules = append(schedules, d)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"as_of":                          asOf.Format("2006-01-02"),
+		"properties":                     schedules,
+		"total_annual_depreciation":      annual,
+		"total_accumulated_depreciation": accumulated,
+	})
+}
+
+// @Summary Get property depreciation
+// @Description One property's depreciation as of a date, with the year-by-year schedule until the building is fully depreciated. Cost basis adds closing_costs and capital_improvement ledger entries to the purchase price.
+// @Tags real-estate
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param as_of query string false "Date to depreciate to (YYYY-MM-DD, not in the future, default today)"
+// @Success 200 {object} map[string]interface{} "Property depreciation"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Property not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/{id}/depreciation [get]
+func (s *Server) getPropertyDepreciation(c *gin.Context) {
+	property, ok := s.propertyFromPath(c)
+	if !ok {
+		return
+	}
+	asOf, ok := depreciationAsOf(c)
+	if !ok {
+		return
+	}
+
+	additions, err := s.repos.PropertyLedger.BasisAdditions(asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch property basis additions"})
+		return
+	}
+	c.JSON(http.StatusOK, services.DepreciateProperty(*property, additions[property.ID], asOf))
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"networth-dashboard/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// Error codes of the error envelope. Most follow the status; handlers may set
+// a more specific "code" themselves.
+const (
+	errorCodeBadRequest       = "bad_request"
+	errorCodeValidationFailed = "validation_failed"
+	errorCodeUnauthorized     = "unauthorized"
+	errorCodeForbidden        = "forbidden"
+	errorCodeNotFound         = "not_found"
+	errorCodeConflict         = "conflict"
+	errorCodeRateLimited      = "rate_limited"
+	errorCodeInternal         = "internal_error"
+	errorCodeUpstream         = "upstream_error"
+	errorCodeUnavailable      = "service_unavailable"
+)
+
+// errorCodesByStatus is the default code for an error status
+var errorCodesByStatus = map[int]string{
+	http.StatusBadRequest:          errorCodeBadRequest,
+	http.StatusUnauthorized:        errorCodeUnauthorized,
+	http.StatusForbidden:           errorCodeForbidden,
+	http.StatusNotFound:            errorCodeNotFound,
+	http.StatusConflict:            errorCodeConflict,
+	http.StatusUnprocessableEntity: errorCodeValidationFailed,
+	http.StatusTooManyRequests:     errorCodeRateLimited,
+	http.StatusInternalServerError: errorCodeInternal,
+	http.StatusBadGateway:          errorCodeUpstream,
+	http.StatusServiceUnavailable:  errorCodeUnavailable,
+}
+
+// errorCodeForStatus returns the default code for an error status
+func errorCodeForStatus(status int) string {
+	if code, ok := errorCodesByStatus[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return errorCodeInternal
+	}
+	return errorCodeBadRequest
+}
+
+// configureValidator makes binding errors name fields by their JSON keys
+// rather than Go field names, and validates decimal amounts as numbers so
+// tags such as gte=0 apply to them as they do to float64 fields
+func configureValidator() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(decimal.Decimal).InexactFloat64()
+	}, decimal.Decimal{})
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// errorEnvelope gives every JSON error response of the routes it wraps the
+// same shape:
+//
+//	{"error": "message", "code": "not_found", "fields": [{"field": ..., "message": ..., "code": ...}]}
+//
+// "error" is the message handlers already write. "code" defaults from the
+// status, and "fields" comes from validation errors attached with c.Error,
+// such as those from respondBindingError and respondValidationErrors.
+func errorEnvelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.body == nil {
+			return
+		}
+		writer.ResponseWriter.Write(envelopeBody(c, writer.Status(), writer.body.Bytes()))
+	}
+}
+
+// envelopeWriter holds back JSON error bodies so errorEnvelope can rewrite them
+type envelopeWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *envelopeWriter) capturing() bool {
+	if w.body != nil {
+		return true
+	}
+	if w.Status() < 400 || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return false
+	}
+	w.body = &bytes.Buffer{}
+	return true
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	if w.capturing() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	if w.capturing() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// envelopeBody adds the code and field errors to an error body. Bodies that
+// are not an object with a string "error" are passed through unchanged.
+func envelopeBody(c *gin.Context, status int, body []byte) []byte {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body
+	}
+	if _, ok := response["error"].(string); !ok {
+		return body
+	}
+
+	fields := fieldErrors(c)
+	if len(fields) > 0 {
+		response["fields"] = fields
+	}
+	if _, ok := response["code"]; !ok {
+		if len(fields) > 0 {
+			response["code"] = errorCodeValidationFailed
+		} else {
+			response["code"] = errorCodeForStatus(status)
+		}
+	}
+
+	rewritten, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+// fieldErrors collects the field errors of validation errors attached to the request
+func fieldErrors(c *gin.Context) []plugins.ValidationError {
+	var fields []plugins.ValidationError
+	for _, ginErr := range c.Errors {
+		var validationErrors plugins.ValidationErrors
+		var bindingErrors validator.ValidationErrors
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(ginErr.Err, &validationErrors):
+			fields = append(fields, validationErrors...)
+		case errors.As(ginErr.Err, &bindingErrors):
+			for _, fieldErr := range bindingErrors {
+				fields = append(fields, bindingFieldError(fieldErr))
+			}
+		case errors.As(ginErr.Err, &typeErr) && typeErr.Field != "":
+			fields = append(fields, plugins.ValidationError{
+				Field:   typeErr.Field,
+				Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, jsonTypeName(typeErr.Type)),
+				Code:    "invalid_type",
+			})
+		}
+	}
+	return fields
+}
+
+// bindingFieldError describes a failed validator tag
+func bindingFieldError(fieldErr validator.FieldError) plugins.ValidationError {
+	// Name nested fields by their path below the request, e.g. updates[0].id
+	field := fieldErr.Namespace()
+	if dot := strings.Index(field, "."); dot >= 0 {
+		field = field[dot+1:]
+	}
+
+	var message string
+	switch fieldErr.Tag() {
+	case "required":
+		message = fmt.Sprintf("%s is required", field)
+	case "oneof":
+		message = fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+	case "gt":
+		message = fmt.Sprintf("%s must be greater than %s", field, fieldErr.Param())
+	case "gte", "min":
+		if fieldErr.Kind() == reflect.String || fieldErr.Kind() == reflect.Slice {
+			message = fmt.Sprintf("%s must have at least %s items or characters", field, fieldErr.Param())
+		} else {
+			message = fmt.Sprintf("%s must be at least %s", field, fieldErr.Param())
+		}
+	case "lt":
+		message = fmt.Sprintf("%s must be less than %s", field, fieldErr.Param())
+	case "lte", "max":
+		if fieldErr.Kind() == reflect.String || fieldErr.Kind() == reflect.Slice {
+			message = fmt.Sprintf("%s must have at most %s items or characters", field, fieldErr.Param())
+		} else {
+			message = fmt.Sprintf("%s must be at most %s", field, fieldErr.Param())
+		}
+	case "datetime":
+		message = fmt.Sprintf("%s must be a date (YYYY-MM-DD)", field)
+	case "email":
+		message = fmt.Sprintf("%s must be an email address", field)
+	default:
+		message = fmt.Sprintf("%s failed the %s check", field, fieldErr.Tag())
+	}
+
+	return plugins.ValidationError{Field: field, Message: message, Code: fieldErr.Tag()}
+}
+
+// jsonTypeName names a Go type the way a JSON client sees it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// respondBindingError reports a request body that could not be bound, with a
+// field error for each failed validator tag or mistyped field
+func respondBindingError(c *gin.Context, err error) {
+	c.Error(err)
+
+	var bindingErrors validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	message := "Invalid JSON data"
+	switch {
+	case errors.As(err, &bindingErrors):
+		message = "Validation failed"
+	case errors.As(err, &typeErr):
+		message = "Invalid JSON data: wrong value type"
+	case errors.Is(err, io.EOF):
+		message = "Request body is required"
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
+
+// respondValidationErrors reports data a plugin or service rejected
+func respondValidationErrors(c *gin.Context, message string, fields []plugins.ValidationError) {
+	c.Error(plugins.ValidationErrors(fields))
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
+
+// respondRouteNotFound answers unknown routes in the error envelope shape
+func respondRouteNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": fmt.Sprintf("No route for %s %s", c.Request.Method, c.Request.URL.Path),
+		"code":  errorCodeNotFound,
+	})
+}
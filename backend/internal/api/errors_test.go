@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDecimalBinding(t *testing.T) {
+	configureValidator()
+	gin.SetMode(gin.TestMode)
+
+	liability := func() interface{} { return &models.LiabilityInput{} }
+	goal := func() interface{} { return &models.GoalInput{} }
+	action := func() interface{} { return &services.WhatIfAction{} }
+	tests := []struct {
+		name  string
+		input func() interface{}
+		body  string
+		ok    bool
+	}{
+		{"whole amounts", liability, `{"institution_name": "Chase", "liability_name": "Sapphire", "liability_type": "credit_card", "current_balance": 1200.5}`, true},
+		{"quoted amounts", liability, `{"institution_name": "Chase", "liability_name": "Sapphire", "liability_type": "credit_card", "current_balance": "1200.50", "minimum_payment": "35"}`, true},
+		{"negative balance", liability, `{"institution_name": "Chase", "liability_name": "Sapphire", "liability_type": "credit_card", "current_balance": -1}`, false},
+		{"negative minimum payment", liability, `{"institution_name": "Chase", "liability_name": "Sapphire", "liability_type": "credit_card", "current_balance": 0, "minimum_payment": -0.01}`, false},
+		{"goal target", goal, `{"name": "House", "target_amount": 50000}`, true},
+		{"zero goal target", goal, `{"name": "House", "target_amount": 0}`, false},
+		{"what-if price", action, `{"type": "buy_property", "price": 400000, "down_payment": 80000}`, true},
+		{"negative what-if price", action, `{"type": "sell_stock", "symbol": "AAPL", "shares": 1, "price": -5}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if err := c.ShouldBindJSON(tt.input()); (err == nil) != tt.ok {
+				t.Errorf("ShouldBindJSON = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
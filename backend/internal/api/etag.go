@@ -0,0 +1,140 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionSource is a table a response is built from and the column stamped
+// when one of its rows changes. The column is indexed (see createIndices in
+// migrations.go), so its latest value is one index lookup.
+type versionSource struct {
+	table   string
+	changed string
+}
+
+var (
+	netWorthVersionSources = []versionSource{
+		{"stock_holdings", "last_updated"},
+		{"equity_grants", "last_updated"},
+		{"cash_holdings", "updated_at"},
+		{"crypto_holdings", "updated_at"},
+		{"real_estate_properties", "last_updated"},
+		{"miscellaneous_assets", "last_updated"},
+		{"private_investments", "last_updated"},
+		{"bonds", "last_updated"},
+		{"i_bonds", "last_updated"},
+		{"i_bond_rates", "updated_at"},
+		{"pensions", "last_updated"},
+		{"insurance_policies", "last_updated"},
+		{"liabilities", "last_updated"},
+		{"stock_prices", "timestamp"},
+		{"crypto_prices", "last_updated"},
+	}
+	consolidatedStocksVersionSources = []versionSource{
+		{"stock_holdings", "last_updated"},
+		{"equity_grants", "last_updated"},
+		{"stock_prices", "timestamp"},
+		{"symbol_lookups", "looked_up_at"},
+		{"tags", "created_at"},
+		{"holding_tags", "created_at"},
+		{"saved_views", "updated_at"},
+	}
+	realEstateVersionSources = []versionSource{
+		{"real_estate_properties", "last_updated"},
+		{"property_valuations", "valued_at"},
+		{"tags", "created_at"},
+		{"holding_tags", "created_at"},
+		{"saved_views", "updated_at"},
+	}
+)
+
+// respondWithETag writes value as JSON with an ETag of its encoding, or 304
+// Not Modified when the request's If-None-Match already names that ETag.
+// no-cache makes browsers revalidate rather than reuse the response blindly.
+func respondWithETag(c *gin.Context, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// notModified sets an ETag derived from the latest change time of each source
+// table, and answers 304 Not Modified when the request's If-None-Match already
+// names it, without building the response. It returns true when the response
+// has been written. Writes through this server bump the write generation,
+// which covers deletes and updates that leave the timestamps alone; the start
+// time keeps ETags from a previous run from matching. Bonds, I bonds and
+// pensions are valued as of the current date, so the server's date and the
+// database's are part of the ETag too and a new day is never answered with
+// the previous day's values.
+func (s *Server) notModified(c *gin.Context, sources []versionSource) bool {
+	version, err := s.dataVersion(sources)
+	if err != nil {
+		log.Printf("WARNING: Failed to compute ETag for %s: %v", c.Request.URL.Path, err)
+		return false
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%s|%s|%s",
+		s.startedAt.UnixNano(), s.writeGeneration.Load(), now().Format("2006-01-02"), c.Request.URL.RawQuery, version)))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// dataVersion fingerprints the source tables by their latest change time,
+// and the database's date, in a single query. Counting rows as well would
+// catch deletes made outside this server, but scans every table each request.
+func (s *Server) dataVersion(sources []versionSource) (string, error) {
+	parts := make([]string, len(sources))
+	for i, source := range sources {
+		parts[i] = fmt.Sprintf("(SELECT COALESCE(CAST(MAX(%s) AS TEXT), '') FROM %s)",
+			sqlbuilder.Ident(source.changed), sqlbuilder.Ident(source.table))
+	}
+
+	var version string
+	err := s.db.QueryRow("SELECT CAST(CURRENT_DATE AS TEXT) || ';' || " + strings.Join(parts, " || ';' || ")).Scan(&version)
+	return version, err
+}
+
+// etagMatches reports whether an If-None-Match header names etag, comparing
+// weakly as RFC 9110 requires
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
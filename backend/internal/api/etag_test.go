@@ -0,0 +1,124 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotModifiedExpiresAtMidnight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 10, 16, 23, 59, 0, 0, time.Local)
+	s := &Server{db: db, startedAt: now.Add(-time.Hour), now: func() time.Time { return now }}
+
+	r := gin.New()
+	r.GET("/net-worth", func(c *gin.Context) {
+		if s.notModified(c, netWorthVersionSources) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"net_worth": 1})
+	})
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		// The tables are unchanged between requests
+		mock.ExpectQuery(`SELECT CAST\(CURRENT_DATE AS TEXT\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("2026-10-16;2026-10-01;2026-09-30"))
+		req := httptest.NewRequest(http.MethodGet, "/net-worth", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request = %d with ETag %q, want 200 with an ETag", first.Code, etag)
+	}
+	if again := get(etag); again.Code != http.StatusNotModified {
+		t.Errorf("same day revalidation = %d, want 304", again.Code)
+	}
+
+	now = now.Add(2 * time.Minute)
+	next := get(etag)
+	if next.Code != http.StatusOK {
+		t.Errorf("next day revalidation = %d, want a fresh 200", next.Code)
+	}
+	if next.Header().Get("ETag") == etag {
+		t.Errorf("next day kept ETag %s", etag)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// indexSearch matches a query plan step that searches an index, capturing its name
+var indexSearch = regexp.MustCompile(`^SEARCH \S+ USING (?:COVERING )?INDEX (\S+)`)
+
+func TestVersionSourcesAreIndexed(t *testing.T) {
+	db, err := database.Initialize(config.DatabaseConfig{
+		Driver:       config.DriverSQLite,
+		Path:         filepath.Join(t.TempDir(), "networth.db"),
+		MaxOpenConns: 1,
+	})
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	defer db.Close()
+
+	for _, sources := range [][]versionSource{netWorthVersionSources, consolidatedStocksVersionSources, realEstateVersionSources} {
+		for _, source := range sources {
+			// Each latest change time is a search of an index led by the
+			// change column, not a scan
+			rows, err := db.Query("EXPLAIN QUERY PLAN SELECT MAX(" + sqlbuilder.Ident(source.changed) + ") FROM " + sqlbuilder.Ident(source.table))
+			if err != nil {
+				t.Fatalf("%s: %v", source.table, err)
+			}
+			var plan []string
+			for rows.Next() {
+				var id, parent, notUsed int
+				var detail string
+				if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+					t.Fatal(err)
+				}
+				plan = append(plan, detail)
+			}
+			rows.Close()
+			match := indexSearch.FindStringSubmatch(strings.Join(plan, "; "))
+			if len(plan) != 1 || match == nil {
+				t.Errorf("MAX(%s) of %s is not an index search: %v", source.changed, source.table, plan)
+				continue
+			}
+			var leading string
+			if err := db.QueryRow("SELECT name FROM pragma_index_info($1) WHERE seqno = 0", match[1]).Scan(&leading); err != nil {
+				t.Fatalf("%s: %v", match[1], err)
+			}
+			if leading != source.changed {
+				t.Errorf("MAX(%s) of %s searches %s, which leads with %s", source.changed, source.table, match[1], leading)
+			}
+		}
+
+		s := &Server{db: db.DB}
+		if _, err := s.dataVersion(sources); err != nil {
+			t.Errorf("dataVersion: %v", err)
+		}
+	}
+}
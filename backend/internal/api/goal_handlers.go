@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindGoalInput binds and validates a goal body, returning its parsed target
+// date. A goal without links tracks total net worth.
+func bindGoalInput(c *gin.Context) (*models.GoalInput, *time.Time, bool) {
+	var input models.GoalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, nil, false
+	}
+
+	var targetDate *time.Time
+	if input.TargetDate != nil && *input.TargetDate != "" {
+		parsed, err := time.Parse("2006-01-02", *input.TargetDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_date must be YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		targetDate = &parsed
+	}
+
+	for _, class := range input.AssetClasses {
+		if !models.ValidGoalAssetClass(class) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown asset class %q", class)})
+			return nil, nil, false
+		}
+	}
+	if input.AssetClasses == nil {
+		input.AssetClasses = []string{}
+	}
+	if len(input.AssetClasses) == 0 && len(input.CashHoldingIDs) == 0 {
+		input.AssetClasses = []string{models.GoalAssetClassNetWorth}
+	}
+
+	return &input, targetDate, true
+}
+
+// respondGoalError maps goal repository errors to HTTP responses
+func (s *Server) respondGoalError(c *gin.Context, err error, failureMsg string) {
+	if errors.Is(err, repository.ErrUnknownCashHolding) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.respondRepositoryError(c, err, "Goal not found", failureMsg)
+}
+
+// @Summary Get goals
+// @Description List savings goals with progress from current balances and recurring contributions
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Goals with progress"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /goals [get]
+func (s *Server) getGoals(c *gin.Context) {
+	goals, err := s.repos.Goals.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
+		return
+	}
+
+	if err := s.repos.Goals.AttachProgress(goals, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate goal progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goals": goals,
+		"count": len(goals),
+	})
+}
+
+// @Summary Get goal
+// @Description Get a savings goal with its progress
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Success 200 {object} map[string]interface{} "Goal with progress"
+// @Failure 400 {object} map[string]interface{} "Invalid goal ID"
+// @Failure 404 {object} map[string]interface{} "Goal not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /goals/{id} [get]
+func (s *Server) getGoal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID"})
+		return
+	}
+
+	goal, err := s.repos.Goals.Get(id)
+	if err != nil {
+		s.respondGoalError(c, err, "Failed to fetch goal")
+		return
+	}
+
+	goals := []models.Goal{*goal}
+	if err := s.repos.Goals.AttachProgress(goals, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate goal progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, goals[0])
+}
+
+// @Summary Create goal
+// @Description Create a savings goal linked to asset classes (stock_holdings, vested_equity, real_estate, cash_holdings, crypto_holdings, other_assets, private_investments, fixed_income, pensions, insurance, net_worth) and/or cash holding IDs. With no links the goal tracks net worth.
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param goal body map[string]interface{} true "Goal (name, target_amount, target_date, asset_classes, cash_holding_ids)"
+// @Success 201 {object} map[string]interface{} "Goal created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /goals [post]
+func (s *Server) createGoal(c *gin.Context) {
+	input, targetDate, ok := bindGoalInput(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.Goals.Create(*input, targetDate)
+	if err != nil {
+		s.respondGoalError(c, err, "Failed to create goal")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Goal created successfully",
+	})
+}
+
+// @Summary Update goal
+// @Description Replace a savings goal's fields and links
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Param goal body map[string]interface{} true "Goal (name, target_amount, target_date, asset_classes, cash_holding_ids)"
+// @Success 200 {object} map[string]interface{} "Goal updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Goal not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /goals/{id} [put]
+func (s *Server) updateGoal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID"})
+		return
+	}
+
+	input, targetDate, ok := bindGoalInput(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.Goals.Update(id, *input, targetDate); err != nil {
+		s.respondGoalError(c, err, "Failed to update goal")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Goal updated successfully",
+	})
+}
+
+// @Summary Delete goal
+// @Description Delete a savings goal
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Success 200 {object} map[string]interface{} "Goal deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid goal ID"
+// @Failure 404 {object} map[string]interface{} "Goal not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /goals/{id} [delete]
+func (s *Server) deleteGoal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID"})
+		return
+	}
+
+	if err := s.repos.Goals.Delete(id); err != nil {
+		s.respondGoalError(c, err, "Failed to delete goal")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Goal deleted successfully",
+	})
+}
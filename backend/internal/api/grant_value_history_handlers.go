@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get equity grant value history
+// @Description How an equity grant's vested and unvested value evolved, from its vesting schedule and the recorded stock price history. The grant is valued at the end of each interval and on each vest date, from the grant date unless from is given. Options are valued at their spread over the strike price (spread_per_share), never below zero. Dates before the first recorded price are left out.
+// @Tags equity
+// @Produce json
+// @Param id path int true "Equity Grant ID"
+// @Param from query string false "Start date (YYYY-MM-DD, default the grant date)"
+// @Param to query string false "End date (YYYY-MM-DD, default today)"
+// @Param interval query string false "Sampling interval: day, week or month (default week)"
+// @Success 200 {object} map[string]interface{} "Grant value history"
+// @Failure 400 {object} map[string]interface{} "Invalid parameters"
+// @Failure 404 {object} map[string]interface{} "Equity grant not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/{id}/value-history [get]
+func (s *Server) getGrantValueHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid equity grant ID"})
+		return
+	}
+
+	from, to, ok := parseDateRange(c, 0)
+	if !ok {
+		return
+	}
+	if c.Query("from") == "" {
+		// Start at the grant date
+		from = time.Time{}
+	}
+	interval := c.DefaultQuery("interval", services.GainsIntervalWeek)
+
+	history, err := services.NewGrantValueHistory(s.db, id, from, to, interval)
+	switch {
+	case errors.Is(err, services.ErrInvalidGainsInterval):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEquityGrantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Equity grant not found"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate grant value history"})
+	default:
+		c.JSON(http.StatusOK, history)
+	}
+}
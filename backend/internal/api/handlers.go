@@ -1,18 +1,24 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"networth-dashboard/internal/cache"
+	"networth-dashboard/internal/models"
 	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/repository"
 	"networth-dashboard/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 // Placeholder handlers - will be implemented in future phases
@@ -20,208 +26,189 @@ import (
 // Net worth handlers
 
 // @Summary Get current net worth
-// @Description Calculate and return current net worth including all assets (stocks, equity, real estate, cash, crypto, other assets) minus liabilities
+// @Description Calculate and return current net worth including all assets (stocks, equity, real estate, cash, crypto, other assets) minus liabilities. concentration_risks lists symbols (holdings plus vested and unvested grants) above CONCENTRATION_THRESHOLD_PERCENT of total assets.
 // @Tags net-worth
 // @Accept json
 // @Produce json
+// @Param member query string false "Household member ID, or me for the signed-in user's member, to return only their share"
+// @Param as_of query string false "Past date (YYYY-MM-DD): return net worth from the last snapshot taken by the end of that day"
+// @Param include_custodial query bool false "false to leave out accounts owned by a child, such as UTMA and 529 accounts (default true)"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {object} map[string]interface{} "Net worth data including breakdown by asset type"
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]interface{} "Invalid as_of, or as_of, member and include_custodial=false combined"
+// @Failure 404 {object} map[string]interface{} "Household member not found, or no snapshot by as_of"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /net-worth [get]
 func (s *Server) getNetWorth(c *gin.Context) {
-	// Calculate stock holdings value
-	stockValue := s.calculateStockHoldingsValue()
-
-	// Calculate vested equity value (only vested shares count toward net worth)
-	vestedEquityValue := s.calculateVestedEquityValue()
-
-	// Calculate unvested equity value (future value, shown separately)
-	unvestedEquityValue := s.calculateUnvestedEquityValue()
-
-	// Calculate real estate equity
-	realEstateEquity := s.calculateRealEstateEquity()
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+	if asOf != nil {
+		if c.Query("member") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of cannot be combined with member"})
+			return
+		}
+		if excludeCustodial(c) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of cannot be combined with include_custodial=false"})
+			return
+		}
+		s.respondNetWorthAsOf(c, *asOf)
+		return
+	}
 
-	// Calculate cash holdings value
-	cashHoldingsValue := s.calculateCashHoldingsValue()
+	if member := c.Query("member"); member != "" {
+		if excludeCustodial(c) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "member cannot be combined with include_custodial=false"})
+			return
+		}
+		s.respondMemberNetWorth(c, member)
+		return
+	}
 
-	// Calculate crypto holdings value
-	cryptoHoldingsValue := s.calculateCryptoHoldingsValue()
+	if excludeCustodial(c) {
+		s.respondPersonalNetWorth(c)
+		return
+	}
 
-	// Calculate other assets value (equity = value - amount owed)
-	otherAssetsValue := s.calculateOtherAssetsValue()
+	if s.notModified(c, netWorthVersionSources) {
+		return
+	}
 
-	// Calculate liabilities
-	totalLiabilities := s.calculateTotalLiabilities()
+	data, hit, err := cache.GetOrLoad(s.cache, cache.KeyNetWorth, s.config.Cache.TTL, s.calculateNetWorth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to calculate net worth",
+		})
+		return
+	}
 
-	// Net worth = only vested/liquid assets - liabilities
-	totalAssets := stockValue + vestedEquityValue + realEstateEquity + cashHoldingsValue + cryptoHoldingsValue + otherAssetsValue
-	netWorth := totalAssets - totalLiabilities
+	setCacheStatus(c, hit)
+	c.JSON(http.StatusOK, data)
+}
 
+// netWorthSummary is the net worth response shared by every API version
+type netWorthSummary struct {
+	NetWorth                decimal.Decimal              `json:"net_worth"`
+	TotalAssets             decimal.Decimal              `json:"total_assets"`
+	TotalLiabilities        decimal.Decimal              `json:"total_liabilities"`
+	VestedEquityValue       decimal.Decimal              `json:"vested_equity_value"`
+	UnvestedEquityValue     decimal.Decimal              `json:"unvested_equity_value"` // Shown separately as future value
+	StockHoldingsValue      decimal.Decimal              `json:"stock_holdings_value"`
+	RealEstateEquity        decimal.Decimal              `json:"real_estate_equity"`
+	CashHoldingsValue       decimal.Decimal              `json:"cash_holdings_value"`
+	CryptoHoldingsValue     decimal.Decimal              `json:"crypto_holdings_value"`
+	OtherAssetsValue        decimal.Decimal              `json:"other_assets_value"`
+	PrivateInvestmentsValue decimal.Decimal              `json:"private_investments_value"`
+	FixedIncomeValue        decimal.Decimal              `json:"fixed_income_value"`
+	PensionsValue           decimal.Decimal              `json:"pensions_value"`
+	InsuranceCashValue      decimal.Decimal              `json:"insurance_cash_value"`
+	PriceLastUpdated        string                       `json:"price_last_updated"`
+	StalePriceCount         int                          `json:"stale_price_count"`
+	ProviderName            string                       `json:"provider_name"`
+	ConcentrationRisks      []services.ConcentrationRisk `json:"concentration_risks"`
+	LastUpdated             string                       `json:"last_updated"`
+}
+
+// calculateNetWorth builds the net worth summary from the aggregated breakdown
+func (s *Server) calculateNetWorth() (netWorthSummary, error) {
+	breakdown, err := s.repos.NetWorth.Breakdown()
+	if err != nil {
+		return netWorthSummary{}, err
+	}
+	return s.summarizeNetWorth(breakdown)
+}
+
+// summarizeNetWorth builds a net worth summary from a breakdown, the whole
+// household's or one member's share of it
+func (s *Server) summarizeNetWorth(breakdown models.NetWorthBreakdown) (netWorthSummary, error) {
 	// Get price status information
 	priceStatus := s.getPriceStatus()
 
-	data := gin.H{
-		"net_worth":              netWorth,
-		"total_assets":           totalAssets,
-		"total_liabilities":      totalLiabilities,
-		"vested_equity_value":    vestedEquityValue,
-		"unvested_equity_value":  unvestedEquityValue, // Shown separately as future value
-		"stock_holdings_value":   stockValue,
-		"real_estate_equity":     realEstateEquity,
-		"cash_holdings_value":    cashHoldingsValue,
-		"crypto_holdings_value":  cryptoHoldingsValue,
-		"other_assets_value":     otherAssetsValue,
-		"price_last_updated":     priceStatus.LastUpdated,
-		"stale_price_count":      priceStatus.StaleCount,
-		"provider_name":          priceStatus.ProviderName,
-		"last_updated":           time.Now().Format(time.RFC3339),
-	}
-	c.JSON(http.StatusOK, data)
-}
-
-// Helper functions for net worth calculation
-func (s *Server) calculateStockHoldingsValue() float64 {
-	var stockValue float64
-	query := `
-		SELECT COALESCE(SUM(shares_owned * COALESCE(current_price, 0)), 0) 
-		FROM stock_holdings
-		WHERE current_price > 0 AND COALESCE(is_vested_equity, false) = false
-	`
-	err := s.db.QueryRow(query).Scan(&stockValue)
+	concentrationRisks, err := s.concentrationRiskService.Assess(breakdown)
 	if err != nil {
-		stockValue = 0.0
+		return netWorthSummary{}, err
 	}
-	
-	// Add brokerage account values from cash_holdings
-	var brokerageValue float64
-	brokerageQuery := `
-		SELECT COALESCE(SUM(current_balance), 0) 
-		FROM cash_holdings
-		WHERE account_type = 'brokerage'
-	`
-	err = s.db.QueryRow(brokerageQuery).Scan(&brokerageValue)
-	if err != nil {
-		brokerageValue = 0.0
-	}
-	
-	return stockValue + brokerageValue
-}
 
-func (s *Server) calculateVestedEquityValue() float64 {
-	// Calculate value from equity grants (traditional vested shares)
-	var equityGrantsValue float64
-	query := `
-		SELECT COALESCE(SUM(vested_shares * COALESCE(current_price, 0)), 0) 
-		FROM equity_grants 
-		WHERE current_price > 0 AND vested_shares > 0
-	`
-	err := s.db.QueryRow(query).Scan(&equityGrantsValue)
-	if err != nil {
-		equityGrantsValue = 0.0
-	}
-	
-	// Calculate value from stock holdings marked as vested equity
-	var vestedStockValue float64
-	vestedStockQuery := `
-		SELECT COALESCE(SUM(shares_owned * COALESCE(current_price, 0)), 0) 
-		FROM stock_holdings 
-		WHERE current_price > 0 AND COALESCE(is_vested_equity, false) = true
-	`
-	err = s.db.QueryRow(vestedStockQuery).Scan(&vestedStockValue)
-	if err != nil {
-		vestedStockValue = 0.0
+	// Net worth = only vested/liquid assets - liabilities
+	return netWorthSummary{
+		NetWorth:                breakdown.NetWorth(),
+		TotalAssets:             breakdown.TotalAssets(),
+		TotalLiabilities:        breakdown.TotalLiabilities,
+		VestedEquityValue:       breakdown.VestedEquityValue,
+		UnvestedEquityValue:     breakdown.UnvestedEquityValue,
+		StockHoldingsValue:      breakdown.StockHoldingsValue,
+		RealEstateEquity:        breakdown.RealEstateEquity,
+		CashHoldingsValue:       breakdown.CashHoldingsValue,
+		CryptoHoldingsValue:     breakdown.CryptoHoldingsValue,
+		OtherAssetsValue:        breakdown.OtherAssetsValue,
+		PrivateInvestmentsValue: breakdown.PrivateInvestmentsValue,
+		FixedIncomeValue:        breakdown.FixedIncomeValue,
+		PensionsValue:           breakdown.PensionsValue,
+		InsuranceCashValue:      breakdown.InsuranceCashValue,
+		PriceLastUpdated:        priceStatus.LastUpdated,
+		StalePriceCount:         priceStatus.StaleCount,
+		ProviderName:            priceStatus.ProviderName,
+		ConcentrationRisks:      concentrationRisks,
+		LastUpdated:             time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// @Summary Get net worth breakdown
+// @Description Return each asset class with its value and share of total assets, computed from the same aggregate as the net worth summary. The tree field drills each asset class down by institution, account and holding, each node with its value and share of total assets. Stablecoins are reported as cash rather than crypto when STABLECOINS_AS_CASH is on; stablecoin_value is their value either way.
+// @Tags net-worth
+// @Accept json
+// @Produce json
+// @Param include_custodial query bool false "false to leave out accounts owned by a child, such as UTMA and 529 accounts (default true)"
+// @Success 200 {object} map[string]interface{} "Asset class breakdown with totals and drill-down tree"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /net-worth/breakdown [get]
+func (s *Server) getNetWorthBreakdown(c *gin.Context) {
+	if excludeCustodial(c) {
+		s.respondPersonalBreakdown(c)
+		return
 	}
-	
-	return equityGrantsValue + vestedStockValue
-}
 
-func (s *Server) calculateUnvestedEquityValue() float64 {
-	var value float64
-	query := `
-		SELECT COALESCE(SUM(unvested_shares * COALESCE(current_price, 0)), 0) 
-		FROM equity_grants 
-		WHERE current_price > 0 AND unvested_shares > 0
-	`
-	err := s.db.QueryRow(query).Scan(&value)
+	breakdown, hit, err := cache.GetOrLoad(s.cache, cache.KeyNetWorthBreakdown, s.config.Cache.TTL, s.repos.NetWorth.Breakdown)
 	if err != nil {
-		return 0.0
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to calculate net worth breakdown",
+		})
+		return
 	}
-	return value
-}
 
-func (s *Server) calculateRealEstateEquity() float64 {
-	var value float64
-	query := `
-		SELECT COALESCE(SUM(equity), 0) 
-		FROM real_estate_properties
-	`
-	err := s.db.QueryRow(query).Scan(&value)
-	if err != nil {
-		return 0.0
+	loadTree := s.repos.NetWorth.Tree
+	if s.config.Risk.StablecoinsAsCash {
+		breakdown = breakdown.StablecoinsAsCash()
+		loadTree = s.repos.NetWorth.CashEquivalentTree
 	}
-	return value
-}
 
-func (s *Server) calculateCashHoldingsValue() float64 {
-	var value float64
-	query := `
-		SELECT COALESCE(SUM(current_balance), 0) 
-		FROM cash_holdings
-		WHERE account_type != 'brokerage'
-	`
-	err := s.db.QueryRow(query).Scan(&value)
+	tree, treeHit, err := cache.GetOrLoad(s.cache, cache.KeyNetWorthTree, s.config.Cache.TTL, loadTree)
 	if err != nil {
-		return 0.0
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to calculate net worth breakdown",
+		})
+		return
 	}
-	return value
-}
 
-func (s *Server) calculateCryptoHoldingsValue() float64 {
-	var value float64
-	query := `
-		SELECT COALESCE(SUM(ch.balance_tokens * COALESCE(cp.price_usd, 0)), 0)
-		FROM crypto_holdings ch
-		LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
-		AND cp.last_updated = (
-			SELECT MAX(last_updated)
-			FROM crypto_prices cp2
-			WHERE cp2.symbol = ch.crypto_symbol
-		)
-	`
-	err := s.db.QueryRow(query).Scan(&value)
-	if err != nil {
-		return 0.0
-	}
-	return value
+	setCacheStatus(c, hit && treeHit)
+	c.JSON(http.StatusOK, s.breakdownResponse(breakdown, tree))
 }
 
-func (s *Server) calculateOtherAssetsValue() float64 {
-	var value float64
-	query := `
-		SELECT COALESCE(SUM(current_value - COALESCE(amount_owed, 0)), 0)
-		FROM miscellaneous_assets
-	`
-	err := s.db.QueryRow(query).Scan(&value)
-	if err != nil {
-		return 0.0
+// breakdownResponse is the net worth breakdown body, with breakdown already
+// moved to cash-equivalent stablecoins when STABLECOINS_AS_CASH is on
+func (s *Server) breakdownResponse(breakdown models.NetWorthBreakdown, tree []models.BreakdownAssetClass) gin.H {
+	return gin.H{
+		"components":            breakdown.Components(),
+		"tree":                  tree,
+		"unvested_equity_value": breakdown.UnvestedEquityValue,
+		"stablecoin_value":      breakdown.StablecoinValue,
+		"stablecoins_as_cash":   s.config.Risk.StablecoinsAsCash,
+		"total_assets":          breakdown.TotalAssets(),
+		"total_liabilities":     breakdown.TotalLiabilities,
+		"net_worth":             breakdown.NetWorth(),
 	}
-	return value
-}
-
-func (s *Server) calculateTotalLiabilities() float64 {
-	// Note: Real estate mortgages are NOT included here because 
-	// real estate equity is already calculated net of mortgages
-	// (equity = current_value - outstanding_mortgage)
-	// 
-	// This function should include other types of liabilities like:
-	// - Credit card debt
-	// - Personal loans  
-	// - Student loans
-	// - Other debts not secured by assets already counted as equity
-	//
-	// For now, returning 0 since we don't have other liability types implemented
-	// and real estate mortgages are already accounted for in the equity calculation
-	
-	return 0.0
 }
 
 // @Summary Get passive income breakdown
@@ -233,19 +220,25 @@ func (s *Server) calculateTotalLiabilities() float64 {
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /passive-income [get]
 func (s *Server) getPassiveIncome(c *gin.Context) {
-	// Calculate passive income from different sources
-	
-	// 1. Cash holdings interest (monthly)
-	cashInterestMonthly := s.calculateCashInterestMonthly()
-	
-	// 2. Stock dividends (monthly average from quarterly)
-	stockDividendsMonthly := s.calculateStockDividendsMonthly()
-	
-	// 3. Real estate rental income (already monthly)
-	realEstateIncomeMonthly := s.calculateRealEstateIncomeMonthly()
+	// Monthly passive income from cash interest, dividends, rent and staking
+	income, err := s.repos.NetWorth.PassiveIncome()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to calculate passive income",
+		})
+		return
+	}
+	cashInterestMonthly := income.CashInterest
+	stockDividendsMonthly := income.StockDividends
+	realEstateIncomeMonthly := income.RentalIncome
+	cryptoStakingMonthly := income.CryptoStaking
 	
-	// 4. Crypto staking income (monthly)
-	cryptoStakingMonthly := s.calculateCryptoStakingMonthly()
+	// Staking rewards actually received over the last year
+	var cryptoRewardsLastYear float64
+	now := time.Now()
+	if income, err := s.cryptoStakingService.Income(now.AddDate(-1, 0, 1), now); err == nil {
+		cryptoRewardsLastYear = income.TotalUSD
+	}
 	
 	// Calculate total monthly passive income
 	totalMonthly := cashInterestMonthly + stockDividendsMonthly + realEstateIncomeMonthly + cryptoStakingMonthly
@@ -298,6 +291,7 @@ func (s *Server) getPassiveIncome(c *gin.Context) {
 			"stock_dividends_monthly": stockDividendsMonthly,
 			"real_estate_income_monthly": realEstateIncomeMonthly,
 			"crypto_staking_monthly": cryptoStakingMonthly,
+			"crypto_rewards_last_12_months": cryptoRewardsLastYear,
 		},
 		"last_updated": time.Now().Format(time.RFC3339),
 	}
@@ -305,108 +299,6 @@ func (s *Server) getPassiveIncome(c *gin.Context) {
 	c.JSON(http.StatusOK, data)
 }
 
-// Helper functions for passive income calculation
-func (s *Server) calculateCashInterestMonthly() float64 {
-	var totalInterest float64
-	query := `
-		SELECT COALESCE(SUM(current_balance * COALESCE(interest_rate, 0) / 100 / 12), 0)
-		FROM cash_holdings
-		WHERE account_type != 'brokerage' AND interest_rate > 0
-	`
-	err := s.db.QueryRow(query).Scan(&totalInterest)
-	if err != nil {
-		return 0.0
-	}
-	return totalInterest
-}
-
-func (s *Server) calculateStockDividendsMonthly() float64 {
-	var totalDividends float64
-	query := `
-		SELECT COALESCE(SUM(shares_owned * COALESCE(estimated_quarterly_dividend, 0) / 3), 0)
-		FROM stock_holdings
-		WHERE estimated_quarterly_dividend > 0
-	`
-	err := s.db.QueryRow(query).Scan(&totalDividends)
-	if err != nil {
-		return 0.0
-	}
-	return totalDividends
-}
-
-func (s *Server) calculateRealEstateIncomeMonthly() float64 {
-	var totalRentalIncome float64
-	query := `
-		SELECT COALESCE(SUM(rental_income_monthly), 0)
-		FROM real_estate_properties
-		WHERE rental_income_monthly > 0
-	`
-	err := s.db.QueryRow(query).Scan(&totalRentalIncome)
-	if err != nil {
-		return 0.0
-	}
-	return totalRentalIncome
-}
-
-func (s *Server) calculateCryptoStakingMonthly() float64 {
-	var totalStakingIncome float64
-	
-	// Calculation: (balance_tokens * price_usd * staking_annual_percentage / 100 / 12)
-	// Example: 10 ETH * $3,400 * 3.43% / 12 = $34,000 * 0.0343 / 12 = $97.27/month
-	
-	// Debug query to show individual calculations
-	debugQuery := `
-		SELECT ch.crypto_symbol, ch.balance_tokens, COALESCE(cp.price_usd, 0) as price_usd, 
-		       ch.staking_annual_percentage,
-		       (ch.balance_tokens * COALESCE(cp.price_usd, 0) * ch.staking_annual_percentage / 100 / 12) as monthly_income
-		FROM crypto_holdings ch
-		LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
-		AND cp.last_updated = (
-			SELECT MAX(last_updated)
-			FROM crypto_prices cp2
-			WHERE cp2.symbol = ch.crypto_symbol
-		)
-		WHERE ch.staking_annual_percentage > 0
-	`
-	
-	// Log debug information
-	rows, err := s.db.Query(debugQuery)
-	if err == nil {
-		defer rows.Close()
-		fmt.Printf("DEBUG: Crypto staking calculations:\n")
-		for rows.Next() {
-			var symbol string
-			var tokens, price, percentage, monthlyIncome float64
-			if err := rows.Scan(&symbol, &tokens, &price, &percentage, &monthlyIncome); err == nil {
-				fmt.Printf("  %s: %.6f tokens * $%.2f * %.2f%% / 12 = $%.2f/month\n", 
-					symbol, tokens, price, percentage, monthlyIncome)
-			}
-		}
-	}
-	
-	// Main calculation query
-	query := `
-		SELECT COALESCE(SUM(
-			ch.balance_tokens * COALESCE(cp.price_usd, 0) * ch.staking_annual_percentage / 100 / 12
-		), 0)
-		FROM crypto_holdings ch
-		LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
-		AND cp.last_updated = (
-			SELECT MAX(last_updated)
-			FROM crypto_prices cp2
-			WHERE cp2.symbol = ch.crypto_symbol
-		)
-		WHERE ch.staking_annual_percentage > 0
-	`
-	err = s.db.QueryRow(query).Scan(&totalStakingIncome)
-	if err != nil {
-		return 0.0
-	}
-	
-	fmt.Printf("DEBUG: Total crypto staking monthly income: $%.2f\n", totalStakingIncome)
-	return totalStakingIncome
-}
-
 // PriceStatus represents the current status of price data
 type PriceStatus struct {
 	LastUpdated       string `json:"last_updated"`
@@ -418,6 +310,9 @@ type PriceStatus struct {
 	LastCacheUpdate   string `json:"last_cache_update,omitempty"`
 	CacheAgeMinutes   int    `json:"cache_age_minutes"`
 	MarketOpen        bool   `json:"market_open"`
+	// Freshness of every asset class, stocks first, and what to refresh
+	AssetClasses    []AssetClassPriceStatus `json:"asset_classes"`
+	Recommendations []string                `json:"recommendations"`
 }
 
 func (s *Server) getPriceStatus() PriceStatus {
@@ -425,41 +320,14 @@ func (s *Server) getPriceStatus() PriceStatus {
 	marketService := s.marketService
 	now := time.Now()
 
-	// Count total symbols and stale prices (null/zero prices)
-	var totalCount, staleCount int
-	staleQuery := `
-		SELECT COUNT(DISTINCT symbol) as stale_count,
-		       (SELECT COUNT(DISTINCT symbol) FROM (
-		           SELECT symbol FROM stock_holdings 
-		           UNION 
-		           SELECT company_symbol as symbol FROM equity_grants
-		       ) as all_symbols) as total_count
-		FROM (
-		    SELECT symbol FROM stock_holdings 
-		    WHERE current_price = 0 OR current_price IS NULL
-		    UNION
-		    SELECT company_symbol as symbol FROM equity_grants 
-		    WHERE current_price = 0 OR current_price IS NULL
-		) as stale_symbols
-	`
-
-	err := s.db.QueryRow(staleQuery).Scan(&staleCount, &totalCount)
+	// Count total symbols and stale prices (null/zero prices), and find the
+	// most recent cache update across all symbols
+	coverage, err := s.repos.Stocks.PriceCoverage()
 	if err != nil {
-		staleCount = 0
-		totalCount = 0
-	}
-
-	// Get most recent cache update time across all symbols
-	var lastCacheUpdate time.Time
-	cacheQuery := `
-		SELECT COALESCE(MAX(timestamp), '1970-01-01'::timestamp) as last_update
-		FROM stock_prices
-	`
-	
-	err = s.db.QueryRow(cacheQuery).Scan(&lastCacheUpdate)
-	if err != nil {
-		lastCacheUpdate = time.Time{} // Zero time if error
+		fmt.Printf("WARNING: %v\n", err)
 	}
+	totalCount, staleCount := coverage.Total, coverage.Stale
+	lastCacheUpdate := coverage.LastCachedAt
 
 	// Calculate cache age
 	var cacheAgeMinutes int
@@ -477,13 +345,13 @@ func (s *Server) getPriceStatus() PriceStatus {
 	
 	if !lastCacheUpdate.IsZero() {
 		// Use the same logic as the market service for consistency
-		shouldRefresh := marketService.ShouldRefreshPricesWithForce(lastCacheUpdate, s.config.API.CacheRefreshInterval, false)
+		shouldRefresh := marketService.ShouldRefreshPricesWithForce(lastCacheUpdate, s.config.API.Current().CacheRefreshInterval, false)
 		cacheStale = shouldRefresh
 		
 		// Force refresh needed if cache is significantly stale
 		if isMarketOpen && cacheAgeMinutes > 30 { // More than 30 min during market hours
 			forceRefreshNeeded = true
-		} else if !isMarketOpen && cacheAgeMinutes > 720 { // More than 12 hours when market closed
+		} else if !isMarketOpen && cacheStale { // Missed the last session's close
 			forceRefreshNeeded = true
 		}
 	} else {
@@ -492,6 +360,41 @@ func (s *Server) getPriceStatus() PriceStatus {
 		forceRefreshNeeded = true
 	}
 
+	stocks := AssetClassPriceStatus{
+		AssetClass:      "stocks",
+		TotalCount:      totalCount,
+		StaleCount:      staleCount,
+		LastUpdate:      lastCacheUpdateStr,
+		OldestUpdate:    lastCacheUpdateStr,
+		AgeMinutes:      cacheAgeMinutes,
+		MaxAgeMinutes:   int(s.config.API.Current().CacheRefreshInterval.Minutes()),
+		RefreshEndpoint: "POST /api/v1/prices/refresh",
+	}
+	switch {
+	case totalCount == 0:
+	case lastCacheUpdate.IsZero():
+		stocks.Recommendation = fmt.Sprintf("No stock prices have been fetched; refresh with %s", stocks.RefreshEndpoint)
+	case forceRefreshNeeded:
+		stocks.Recommendation = fmt.Sprintf("Stock prices are %d minutes old; refresh with %s", cacheAgeMinutes, stocks.RefreshEndpoint)
+	case staleCount > 0:
+		stocks.Recommendation = fmt.Sprintf("%d of %d stock symbols have no price; refresh with %s", staleCount, totalCount, stocks.RefreshEndpoint)
+	}
+
+	assetClasses := []AssetClassPriceStatus{stocks}
+	for _, check := range s.assetClassFreshness() {
+		status, err := s.freshness(check, now)
+		if err != nil {
+			fmt.Printf("WARNING: %v\n", err)
+		}
+		assetClasses = append(assetClasses, status)
+	}
+	recommendations := []string{}
+	for _, class := range assetClasses {
+		if class.Recommendation != "" {
+			recommendations = append(recommendations, class.Recommendation)
+		}
+	}
+
 	return PriceStatus{
 		LastUpdated:       now.Format(time.RFC3339),
 		StaleCount:        staleCount,
@@ -502,110 +405,11 @@ func (s *Server) getPriceStatus() PriceStatus {
 		LastCacheUpdate:   lastCacheUpdateStr,
 		CacheAgeMinutes:   cacheAgeMinutes,
 		MarketOpen:        isMarketOpen,
+		AssetClasses:      assetClasses,
+		Recommendations:   recommendations,
 	}
 }
 
-// @Summary Get net worth history
-// @Description Get historical net worth data over time (placeholder - to be implemented)
-// @Tags net-worth
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]interface{} "Net worth history data"
-// @Router /net-worth/history [get]
-func (s *Server) getNetWorthHistory(c *gin.Context) {
-	// TODO: Implement net worth history
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Net worth history endpoint - to be implemented",
-	})
-}
-
-// Account handlers
-
-// @Summary Get all accounts
-// @Description Retrieve all financial accounts (placeholder - to be implemented)
-// @Tags accounts
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]interface{} "List of accounts"
-// @Router /accounts [get]
-func (s *Server) getAccounts(c *gin.Context) {
-	// TODO: Implement account retrieval
-	c.JSON(http.StatusOK, gin.H{
-		"accounts": []gin.H{},
-		"message":  "Accounts endpoint - to be implemented",
-	})
-}
-
-// @Summary Get account by ID
-// @Description Retrieve a specific financial account by ID (placeholder - to be implemented)
-// @Tags accounts
-// @Accept json
-// @Produce json
-// @Param id path string true "Account ID"
-// @Success 200 {object} map[string]interface{} "Account details"
-// @Failure 404 {object} map[string]interface{} "Account not found"
-// @Router /accounts/{id} [get]
-func (s *Server) getAccount(c *gin.Context) {
-	id := c.Param("id")
-	// TODO: Implement single account retrieval
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": id,
-		"message":    "Single account endpoint - to be implemented",
-	})
-}
-
-// @Summary Create new account
-// @Description Create a new financial account (placeholder - to be implemented)
-// @Tags accounts
-// @Accept json
-// @Produce json
-// @Success 201 {object} map[string]interface{} "Account created successfully"
-// @Failure 400 {object} map[string]interface{} "Bad request"
-// @Router /accounts [post]
-func (s *Server) createAccount(c *gin.Context) {
-	// TODO: Implement account creation
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Create account endpoint - to be implemented",
-	})
-}
-
-// @Summary Update account
-// @Description Update an existing financial account (placeholder - to be implemented)
-// @Tags accounts
-// @Accept json
-// @Produce json
-// @Param id path string true "Account ID"
-// @Success 200 {object} map[string]interface{} "Account updated successfully"
-// @Failure 400 {object} map[string]interface{} "Bad request"
-// @Failure 404 {object} map[string]interface{} "Account not found"
-// @Router /accounts/{id} [put]
-func (s *Server) updateAccount(c *gin.Context) {
-	id := c.Param("id")
-	// TODO: Implement account update
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": id,
-		"message":    "Update account endpoint - to be implemented",
-	})
-}
-
-// @Summary Delete account
-// @Description Delete a financial account (placeholder - to be implemented)
-// @Tags accounts
-// @Accept json
-// @Produce json
-// @Param id path string true "Account ID"
-// @Success 200 {object} map[string]interface{} "Account deleted successfully"
-// @Failure 404 {object} map[string]interface{} "Account not found"
-// @Router /accounts/{id} [delete]
-func (s *Server) deleteAccount(c *gin.Context) {
-	id := c.Param("id")
-	// TODO: Implement account deletion
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": id,
-		"message":    "Delete account endpoint - to be implemented",
-	})
-}
-
 // Balance handlers
 
 // @Summary Get all balances
@@ -642,6 +446,20 @@ func (s *Server) getAccountBalances(c *gin.Context) {
 	})
 }
 
+// respondRepositoryError writes a 404 for repository.ErrNotFound and a 500 otherwise
+func (s *Server) respondRepositoryError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": notFoundMsg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": failureMsg,
+	})
+}
+
 // Stock holdings handlers
 
 // @Summary Get all stock holdings
@@ -649,87 +467,40 @@ func (s *Server) getAccountBalances(c *gin.Context) {
 // @Tags stocks
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only holdings with any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out holdings with any of these comma-separated tags"
+// @Param view query int false "Only holdings passing this saved view, which must be over the same holding list"
+// @Param as_of query string false "Past date (YYYY-MM-DD): holdings as they stood at the end of that day, with shares rolled back through the audit log and prices from the price history"
 // @Success 200 {array} map[string]interface{} "List of stock holdings"
+// @Failure 400 {object} map[string]interface{} "Invalid as_of"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /stocks [get]
 func (s *Server) getStockHoldings(c *gin.Context) {
-	query := `
-		SELECT h.id, h.account_id, h.symbol, h.company_name, h.shares_owned, 
-		       h.cost_basis, h.current_price, h.institution_name, h.data_source, h.created_at,
-		       COALESCE(h.shares_owned * h.current_price, 0) as market_value,
-		       h.estimated_quarterly_dividend, h.purchase_date, h.drip_enabled, h.last_manual_update,
-		       COALESCE(h.is_vested_equity, false) as is_vested_equity
-		FROM stock_holdings h
-		ORDER BY h.institution_name, h.symbol
-	`
-
-	rows, err := s.db.Query(query)
+	load, asOf, ok := holdingsAsOf(c, s.repos.Stocks.List, s.repos.Stocks.ListAsOf)
+	if !ok {
+		return
+	}
+
+	holdings, err := load()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch stock holdings",
 		})
 		return
 	}
-	defer rows.Close()
-
-	holdings := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var holding struct {
-			ID                        int      `json:"id"`
-			AccountID                 int      `json:"account_id"`
-			Symbol                    string   `json:"symbol"`
-			CompanyName               *string  `json:"company_name"`
-			SharesOwned               float64  `json:"shares_owned"`
-			CostBasis                 *float64 `json:"cost_basis"`
-			CurrentPrice              *float64 `json:"current_price"`
-			InstitutionName           string   `json:"institution_name"`
-			MarketValue               float64  `json:"market_value"`
-			DataSource                string   `json:"data_source"`
-			CreatedAt                 string   `json:"created_at"`
-			EstimatedQuarterlyDividend *float64 `json:"estimated_quarterly_dividend"`
-			PurchaseDate              *string  `json:"purchase_date"`
-			DripEnabled               *string  `json:"drip_enabled"`
-			LastManualUpdate          *string  `json:"last_manual_update"`
-			IsVestedEquity            bool     `json:"is_vested_equity"`
-		}
 
-		err := rows.Scan(
-			&holding.ID, &holding.AccountID, &holding.Symbol, &holding.CompanyName,
-			&holding.SharesOwned, &holding.CostBasis, &holding.CurrentPrice,
-			&holding.InstitutionName, &holding.DataSource, &holding.CreatedAt, &holding.MarketValue,
-			&holding.EstimatedQuarterlyDividend, &holding.PurchaseDate, &holding.DripEnabled, &holding.LastManualUpdate,
-			&holding.IsVestedEquity,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan stock holding",
-			})
-			return
-		}
-
-		holdingMap := map[string]interface{}{
-			"id":                          holding.ID,
-			"account_id":                  holding.AccountID,
-			"symbol":                      holding.Symbol,
-			"company_name":                holding.CompanyName,
-			"shares_owned":                holding.SharesOwned,
-			"cost_basis":                  holding.CostBasis,
-			"current_price":               holding.CurrentPrice,
-			"institution_name":            holding.InstitutionName,
-			"market_value":                holding.MarketValue,
-			"data_source":                 holding.DataSource,
-			"created_at":                  holding.CreatedAt,
-			"estimated_quarterly_dividend": holding.EstimatedQuarterlyDividend,
-			"purchase_date":               holding.PurchaseDate,
-			"drip_enabled":                holding.DripEnabled,
-			"last_manual_update":          holding.LastManualUpdate,
-		}
-		holdings = append(holdings, holdingMap)
+	holdings, ok = filterHoldings(s, c, models.HoldingTypeStock, holdings, func(h models.StockHolding) int { return h.ID })
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"stocks": holdings,
-	})
+	}
+	if asOf != nil {
+		response["as_of"] = *asOf
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Get consolidated stock holdings
@@ -737,221 +508,81 @@ func (s *Server) getStockHoldings(c *gin.Context) {
 // @Tags stocks
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out symbols with a stock holding or equity grant carrying any of these comma-separated tags"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {array} map[string]interface{} "Consolidated stock holdings with sources"
+// @Success 304 "Not modified"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /stocks/consolidated [get]
 func (s *Server) getConsolidatedStocks(c *gin.Context) {
-	query := `
-		WITH combined_holdings AS (
-			-- Direct stock holdings
-			SELECT symbol, 
-			       company_name,
-			       shares_owned, 
-			       cost_basis, 
-			       current_price, 
-			       'direct_stock' as source_type,
-			       data_source
-			FROM stock_holdings 
-			WHERE shares_owned > 0
-			
-			UNION ALL
-			
-			-- Vested equity compensation
-			SELECT company_symbol as symbol,
-			       company_symbol as company_name,  -- Use symbol as fallback company name
-			       vested_shares as shares_owned,
-			       CASE 
-			           WHEN grant_type = 'stock_option' THEN COALESCE(strike_price, 0)
-			           ELSE COALESCE(current_price, 0) -- For RSUs/ESPP, cost basis is current price at vest
-			       END as cost_basis,
-			       current_price,
-			       CONCAT('equity_', grant_type) as source_type,
-			       data_source
-			FROM equity_grants 
-			WHERE vested_shares > 0
-		)
-		SELECT symbol, 
-		       COALESCE(MAX(company_name), symbol) as company_name,
-		       SUM(shares_owned) as total_shares,
-		       COALESCE(AVG(NULLIF(current_price, 0)), 0) as current_price,
-		       SUM(shares_owned * COALESCE(current_price, 0)) as total_value,
-		       COALESCE(
-		           SUM(shares_owned * COALESCE(current_price, 0)) - 
-		           SUM(shares_owned * COALESCE(cost_basis, 0)), 
-		           0
-		       ) as unrealized_gains
-		FROM combined_holdings
-		GROUP BY symbol
-		ORDER BY total_value DESC
-	`
-
-	rows, err := s.db.Query(query)
+	if s.notModified(c, consolidatedStocksVersionSources) {
+		return
+	}
+
+	consolidatedStocks, hit, err := cache.GetOrLoad(s.cache, cache.KeyConsolidatedStocks, s.config.Cache.TTL, s.repos.Stocks.ListConsolidated)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch consolidated stocks",
 		})
 		return
 	}
-	defer rows.Close()
-
-	consolidatedStocks := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var stock struct {
-			Symbol          string  `json:"symbol"`
-			CompanyName     string  `json:"company_name"`
-			TotalShares     float64 `json:"total_shares"`
-			CurrentPrice    float64 `json:"current_price"`
-			TotalValue      float64 `json:"total_value"`
-			UnrealizedGains float64 `json:"unrealized_gains"`
-		}
 
-		err := rows.Scan(
-			&stock.Symbol, &stock.CompanyName, &stock.TotalShares,
-			&stock.CurrentPrice, &stock.TotalValue, &stock.UnrealizedGains,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan consolidated stock",
-			})
-			return
-		}
-
-		// Get sources for this symbol (both stock holdings and equity grants)
-		sourcesQuery := `
-			SELECT id, account_id, shares_owned, cost_basis, data_source, created_at, 'direct_stock' as source_type, NULL as grant_type
-			FROM stock_holdings 
-			WHERE symbol = $1 AND shares_owned > 0
-			
-			UNION ALL
-			
-			SELECT id, account_id, vested_shares as shares_owned, 
-			       CASE 
-			           WHEN grant_type = 'stock_option' THEN COALESCE(strike_price, 0)
-			           ELSE COALESCE(current_price, 0) 
-			       END as cost_basis,
-			       data_source, created_at, 'equity_compensation' as source_type, grant_type
-			FROM equity_grants 
-			WHERE company_symbol = $1 AND vested_shares > 0
-			
-			ORDER BY data_source, source_type
-		`
-
-		sourceRows, err := s.db.Query(sourcesQuery, stock.Symbol)
-		if err != nil {
-			continue // Skip if can't get sources, but continue with consolidated data
-		}
-
-		sources := make([]map[string]interface{}, 0)
-		for sourceRows.Next() {
-			var source struct {
-				ID          int      `json:"id"`
-				AccountID   int      `json:"account_id"`
-				SharesOwned float64  `json:"shares_owned"`
-				CostBasis   *float64 `json:"cost_basis"`
-				DataSource  string   `json:"data_source"`
-				CreatedAt   string   `json:"created_at"`
-				SourceType  string   `json:"source_type"`
-				GrantType   *string  `json:"grant_type"`
-			}
-
-			err := sourceRows.Scan(
-				&source.ID, &source.AccountID, &source.SharesOwned,
-				&source.CostBasis, &source.DataSource, &source.CreatedAt,
-				&source.SourceType, &source.GrantType,
-			)
-			if err != nil {
-				continue
-			}
-
-			// Build source display name
-			sourceName := source.DataSource
-			if source.SourceType == "equity_compensation" && source.GrantType != nil {
-				sourceName = fmt.Sprintf("%s (%s)", source.DataSource, *source.GrantType)
-			}
-
-			sourceMap := map[string]interface{}{
-				"id":            source.ID,
-				"account_id":    source.AccountID,
-				"symbol":        stock.Symbol,
-				"company_name":  stock.CompanyName,
-				"shares_owned":  source.SharesOwned,
-				"cost_basis":    source.CostBasis,
-				"current_price": stock.CurrentPrice,
-				"market_value":  source.SharesOwned * stock.CurrentPrice,
-				"data_source":   sourceName,
-				"source_type":   source.SourceType,
-				"grant_type":    source.GrantType,
-				"created_at":    source.CreatedAt,
-			}
-			sources = append(sources, sourceMap)
-		}
-		sourceRows.Close()
-
-		stockMap := map[string]interface{}{
-			"symbol":           stock.Symbol,
-			"company_name":     stock.CompanyName,
-			"total_shares":     stock.TotalShares,
-			"total_value":      stock.TotalValue,
-			"current_price":    stock.CurrentPrice,
-			"unrealized_gains": stock.UnrealizedGains,
-			"sources":          sources,
-		}
-		consolidatedStocks = append(consolidatedStocks, stockMap)
+	matcher, ok := s.symbolTagMatcher(c)
+	if !ok {
+		return
 	}
+	consolidatedStocks = filterStocksByTags(consolidatedStocks, matcher)
 
+	setCacheStatus(c, hit)
 	c.JSON(http.StatusOK, gin.H{
 		"consolidated_stocks": consolidatedStocks,
 	})
 }
 
 // @Summary Create stock holding
-// @Description Create a new stock holding using the stock holdings plugin
+// @Description Create a new stock holding using the stock holdings plugin. A holding of the same symbol at the same institution, ignoring case, is refused with 409 and returned, unless conflict_policy says otherwise. The symbol is checked against the price provider's symbol search, which also fills in the company name, exchange and security type.
 // @Tags stocks
 // @Accept json
 // @Produce json
+// @Param request body map[string]interface{} true "Stock holding details"
+// @Param conflict_policy query string false "What to do when the holding already exists: reject (default), update, skip or duplicate"
 // @Success 201 {object} map[string]interface{} "Stock holding created successfully"
+// @Success 200 {object} map[string]interface{} "Existing stock holding updated or skipped"
 // @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 409 {object} map[string]interface{} "The holding already exists; existing holds it"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /stocks [post]
 func (s *Server) createStockHolding(c *gin.Context) {
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
 	// Get the stock holdings plugin
-	plugin, err := s.pluginManager.GetPlugin("stock_holding")
-	if err != nil || plugin == nil {
+	if _, err := s.pluginManager.GetPlugin("stock_holding"); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Stock holdings plugin not found",
 		})
 		return
 	}
 
-	manualPlugin, ok := plugin.(interface {
-		ProcessManualEntry(data map[string]interface{}) error
-	})
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Plugin does not support manual entry",
-		})
+	// Check the symbol and fill in its company name, exchange and security type
+	if !s.enrichStockSymbol(c, requestData) {
 		return
 	}
 
 	// Process the manual entry
-	err = manualPlugin.ProcessManualEntry(requestData)
+	outcome, err := s.createHoldingEntry(c, "stock_holding", requestData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to create stock holding: %v", err),
-		})
+		respondManualEntryError(c, err, fmt.Sprintf("Failed to create stock holding: %v", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Stock holding created successfully",
+	c.JSON(manualEntryStatus(outcome), gin.H{
+		"message": fmt.Sprintf("Stock holding %s successfully", outcome.Action),
+		"outcome": outcome,
 	})
 }
 
@@ -963,7 +594,7 @@ func (s *Server) createStockHolding(c *gin.Context) {
 // @Param id path string true "Stock Holding ID"
 // @Success 200 {object} map[string]interface{} "Stock holding updated successfully"
 // @Summary Update stock holding
-// @Description Update an existing stock holding record
+// @Description Update an existing stock holding record. The symbol is checked and its details filled in as on create.
 // @Tags stocks
 // @Accept json
 // @Produce json
@@ -984,7 +615,7 @@ func (s *Server) updateStockHolding(c *gin.Context) {
 
 	var updateData map[string]interface{}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON data"})
+		respondBindingError(c, err)
 		return
 	}
 
@@ -1003,11 +634,11 @@ func (s *Server) updateStockHolding(c *gin.Context) {
 
 	// Validate the data
 	validation := stockPlugin.ValidateManualEntry(updateData)
+	if validation.Valid && !s.enrichStockSymbol(c, validation.Data) {
+		return
+	}
 	if !validation.Valid {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Validation failed",
-			"validation_errors": validation.Errors,
-		})
+		respondValidationErrors(c, "Validation failed", validation.Errors)
 		return
 	}
 
@@ -1037,36 +668,16 @@ func (s *Server) updateStockHolding(c *gin.Context) {
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /stocks/{id} [delete]
 func (s *Server) deleteStockHolding(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Stock holding ID is required",
-		})
-		return
-	}
-
-	// Delete the stock holding record
-	query := `DELETE FROM stock_holdings WHERE id = $1`
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete stock holding",
-		})
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check deletion result",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid stock holding ID",
 		})
 		return
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Stock holding not found",
-		})
+	if err := s.repos.Stocks.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Stock holding not found", "Failed to delete stock holding")
 		return
 	}
 
@@ -1078,81 +689,45 @@ func (s *Server) deleteStockHolding(c *gin.Context) {
 // Equity compensation handlers
 
 // @Summary Get equity grants
-// @Description Retrieve all equity compensation grants including stock options and RSUs
+// @Description Retrieve all equity compensation grants including stock options and RSUs. trading_status says, per company symbol, whether its stock can be traded today under the configured trading windows and when that next changes.
 // @Tags equity
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only holdings with any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out holdings with any of these comma-separated tags"
+// @Param view query int false "Only holdings passing this saved view, which must be over the same holding list"
 // @Success 200 {array} map[string]interface{} "List of equity grants"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /equity [get]
 func (s *Server) getEquityGrants(c *gin.Context) {
-	query := `
-		SELECT id, account_id, grant_type, company_symbol, total_shares, 
-		       vested_shares, unvested_shares, strike_price, grant_date, 
-		       vest_start_date, current_price, data_source, created_at
-		FROM equity_grants
-		ORDER BY grant_date DESC
-	`
-
-	rows, err := s.db.Query(query)
+	grants, err := s.repos.Equity.List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch equity grants",
 		})
 		return
 	}
-	defer rows.Close()
-
-	grants := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var grant struct {
-			ID             int      `json:"id"`
-			AccountID      int      `json:"account_id"`
-			GrantType      string   `json:"grant_type"`
-			CompanySymbol  string   `json:"company_symbol"`
-			TotalShares    float64  `json:"total_shares"`
-			VestedShares   float64  `json:"vested_shares"`
-			UnvestedShares float64  `json:"unvested_shares"`
-			StrikePrice    *float64 `json:"strike_price"`
-			GrantDate      string   `json:"grant_date"`
-			VestStartDate  string   `json:"vest_start_date"`
-			CurrentPrice   *float64 `json:"current_price"`
-			DataSource     string   `json:"data_source"`
-			CreatedAt      string   `json:"created_at"`
-		}
 
-		err := rows.Scan(
-			&grant.ID, &grant.AccountID, &grant.GrantType, &grant.CompanySymbol,
-			&grant.TotalShares, &grant.VestedShares, &grant.UnvestedShares,
-			&grant.StrikePrice, &grant.GrantDate, &grant.VestStartDate, &grant.CurrentPrice, &grant.DataSource, &grant.CreatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan equity grant",
-			})
-			return
-		}
+	grants, ok := filterHoldings(s, c, models.HoldingTypeEquityGrant, grants, func(h models.EquityGrant) int { return h.ID })
+	if !ok {
+		return
+	}
 
-		grantMap := map[string]interface{}{
-			"id":              grant.ID,
-			"account_id":      grant.AccountID,
-			"grant_type":      grant.GrantType,
-			"company_symbol":  grant.CompanySymbol,
-			"total_shares":    grant.TotalShares,
-			"vested_shares":   grant.VestedShares,
-			"unvested_shares": grant.UnvestedShares,
-			"strike_price":    grant.StrikePrice,
-			"grant_date":      grant.GrantDate,
-			"vest_start_date": grant.VestStartDate,
-			"current_price":   grant.CurrentPrice,
-			"data_source":     grant.DataSource,
-			"created_at":      grant.CreatedAt,
-		}
-		grants = append(grants, grantMap)
+	symbols := make([]string, 0, len(grants))
+	for _, grant := range grants {
+		symbols = append(symbols, grant.CompanySymbol)
+	}
+	tradingStatus, err := s.tradingWindowService.Statuses(symbols, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch trading status",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"equity_grants": grants,
+		"equity_grants":  grants,
+		"trading_status": tradingStatus,
 	})
 }
 
@@ -1177,63 +752,37 @@ func (s *Server) getVestingSchedule(c *gin.Context) {
 }
 
 // @Summary Create equity grant
-// @Description Create a new equity compensation grant (placeholder - to be implemented)
+// @Description Create a new equity compensation grant. grant_type is iso, nso, rsu, espp or rsa. Options need strike_price and may have expiration_date (within 10 years of grant_date for an ISO); election_83b_filed_date applies to RSAs (within 30 days of grant_date) and early-exercised options; amt_basis_per_share applies to ISOs.
 // @Tags equity
 // @Accept json
 // @Produce json
+// @Param grant body map[string]interface{} true "Grant: {\"account_id\": 1, \"grant_type\": \"iso\", \"company_symbol\": \"ACME\", \"total_shares\": 1000, \"vested_shares\": 250, \"strike_price\": 12.5, \"grant_date\": \"2023-01-15\", \"vest_start_date\": \"2023-01-15\", \"expiration_date\": \"2033-01-15\"}"
 // @Success 201 {object} map[string]interface{} "Equity grant created successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /equity [post]
 func (s *Server) createEquityGrant(c *gin.Context) {
-	var request struct {
-		AccountID     int     `json:"account_id" binding:"required"`
-		GrantType     string  `json:"grant_type" binding:"required"`
-		CompanySymbol string  `json:"company_symbol" binding:"required"`
-		TotalShares   float64 `json:"total_shares" binding:"required"`
-		VestedShares  float64 `json:"vested_shares"`
-		StrikePrice   float64 `json:"strike_price"`
-		GrantDate     string  `json:"grant_date" binding:"required"`
-		VestStartDate string  `json:"vest_start_date" binding:"required"`
-	}
-
+	var request models.EquityGrantInput
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondBindingError(c, err)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Calculate unvested shares
-	unvestedShares := request.TotalShares - request.VestedShares
 
 	// Get current market price
-	currentPrice, priceErr := s.priceService.GetCurrentPrice(request.CompanySymbol)
+	var currentPrice decimal.Decimal
+	quote, priceErr := s.priceService.GetCurrentPrice(request.CompanySymbol)
 	if priceErr != nil {
 		// Log error but continue with 0 price
 		fmt.Printf("Warning: Could not fetch price for %s: %v\n", request.CompanySymbol, priceErr)
-		currentPrice = 0
-	}
-
-	// Insert equity grant
-	query := `
-		INSERT INTO equity_grants (
-			account_id, grant_type, company_symbol, total_shares, vested_shares, 
-			unvested_shares, strike_price, grant_date, vest_start_date, 
-			current_price, data_source, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING id
-	`
-
-	var grantID int
-	err := s.db.QueryRow(
-		query,
-		request.AccountID, request.GrantType, request.CompanySymbol,
-		request.TotalShares, request.VestedShares, unvestedShares,
-		request.StrikePrice, request.GrantDate, request.VestStartDate,
-		currentPrice, "manual", time.Now(),
-	).Scan(&grantID)
+	} else {
+		currentPrice = decimal.NewFromFloat(quote)
+	}
 
+	grantID, err := s.repos.Equity.Create(request, currentPrice)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create equity grant",
@@ -1241,6 +790,7 @@ func (s *Server) createEquityGrant(c *gin.Context) {
 		return
 	}
 
+	setAuditEntityID(c, grantID)
 	c.JSON(http.StatusCreated, gin.H{
 		"id":      grantID,
 		"message": "Equity grant created successfully",
@@ -1248,94 +798,47 @@ func (s *Server) createEquityGrant(c *gin.Context) {
 }
 
 // @Summary Update equity grant
-// @Description Update an existing equity compensation grant (placeholder - to be implemented)
+// @Description Update an existing equity compensation grant. The type-specific fields are validated as on create.
 // @Tags equity
 // @Accept json
 // @Produce json
-// @Param id path string true "Equity Grant ID"
+// @Param id path int true "Equity Grant ID"
+// @Param grant body map[string]interface{} true "Grant, as on create"
 // @Success 200 {object} map[string]interface{} "Equity grant updated successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 404 {object} map[string]interface{} "Equity grant not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /equity/{id} [put]
 func (s *Server) updateEquityGrant(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Equity grant ID is required",
+			"error": "Invalid equity grant ID",
 		})
 		return
 	}
 
-	var request struct {
-		AccountID     int     `json:"account_id" binding:"required"`
-		GrantType     string  `json:"grant_type" binding:"required"`
-		CompanySymbol string  `json:"company_symbol" binding:"required"`
-		TotalShares   float64 `json:"total_shares" binding:"required"`
-		VestedShares  float64 `json:"vested_shares"`
-		StrikePrice   float64 `json:"strike_price"`
-		GrantDate     string  `json:"grant_date" binding:"required"`
-		VestStartDate string  `json:"vest_start_date" binding:"required"`
-	}
-
+	var request models.EquityGrantInput
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondBindingError(c, err)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Calculate unvested shares
-	unvestedShares := request.TotalShares - request.VestedShares
 
 	// Get current market price
-	currentPrice, priceErr := s.priceService.GetCurrentPrice(request.CompanySymbol)
+	quote, priceErr := s.priceService.GetCurrentPrice(request.CompanySymbol)
+	currentPrice := decimal.NewFromFloat(quote)
 	if priceErr != nil {
 		// Log error but continue with existing price
 		fmt.Printf("Warning: Could not fetch price for %s: %v\n", request.CompanySymbol, priceErr)
-		// Get existing price from database
-		var existingPrice float64
-		priceQuery := "SELECT COALESCE(current_price, 0) FROM equity_grants WHERE id = $1"
-		s.db.QueryRow(priceQuery, id).Scan(&existingPrice)
-		currentPrice = existingPrice
-	}
-
-	// Update equity grant
-	query := `
-		UPDATE equity_grants 
-		SET account_id = $1, grant_type = $2, company_symbol = $3, total_shares = $4, 
-		    vested_shares = $5, unvested_shares = $6, strike_price = $7, current_price = $8, 
-		    grant_date = $9, vest_start_date = $10, updated_at = $11
-		WHERE id = $12
-	`
-
-	result, err := s.db.Exec(
-		query,
-		request.AccountID, request.GrantType, request.CompanySymbol,
-		request.TotalShares, request.VestedShares, unvestedShares,
-		request.StrikePrice, currentPrice, request.GrantDate, request.VestStartDate,
-		time.Now(), id,
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update equity grant",
-		})
-		return
+		currentPrice = s.repos.Equity.GetCurrentPrice(id)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check update result",
-		})
-		return
-	}
-
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Equity grant not found",
-		})
+	if err := s.repos.Equity.Update(id, request, currentPrice); err != nil {
+		s.respondRepositoryError(c, err, "Equity grant not found", "Failed to update equity grant")
 		return
 	}
 
@@ -1346,52 +849,33 @@ func (s *Server) updateEquityGrant(c *gin.Context) {
 }
 
 // @Summary Delete equity grant
-// @Description Delete an equity compensation grant (placeholder - to be implemented)
+// @Description Delete an equity compensation grant
 // @Tags equity
 // @Accept json
 // @Produce json
-// @Param id path string true "Equity Grant ID"
+// @Param id path int true "Equity Grant ID"
 // @Success 200 {object} map[string]interface{} "Equity grant deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid equity grant ID"
 // @Failure 404 {object} map[string]interface{} "Equity grant not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /equity/{id} [delete]
 func (s *Server) deleteEquityGrant(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Equity grant ID is required",
-		})
-		return
-	}
-
-	// Delete the equity grant record
-	query := `DELETE FROM equity_grants WHERE id = $1`
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete equity grant",
-		})
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check delete result",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid equity grant ID",
 		})
 		return
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Equity grant not found",
-		})
+	if err := s.repos.Equity.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Equity grant not found", "Failed to delete equity grant")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"grant_id": id,
-		"message":  "Equity grant deleted successfully",
+		"message": "Equity grant deleted successfully",
 	})
 }
 
@@ -1402,104 +886,30 @@ func (s *Server) deleteEquityGrant(c *gin.Context) {
 // @Tags real-estate
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only holdings with any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out holdings with any of these comma-separated tags"
+// @Param view query int false "Only holdings passing this saved view, which must be over the same holding list"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {array} map[string]interface{} "List of real estate properties"
+// @Success 304 "Not modified"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /real-estate [get]
 func (s *Server) getRealEstate(c *gin.Context) {
-	query := `
-		SELECT id, account_id, property_type, property_name, purchase_price, 
-		       current_value, outstanding_mortgage, equity, 
-		       TO_CHAR(purchase_date, 'YYYY-MM-DD') as purchase_date, 
-		       property_size_sqft, lot_size_acres, rental_income_monthly, 
-		       property_tax_annual, notes, street_address, city, state, zip_code,
-		       latitude, longitude, api_estimated_value, api_estimate_date, 
-		       api_provider, created_at
-		FROM real_estate_properties
-		ORDER BY property_name
-	`
-
-	rows, err := s.db.Query(query)
+	if s.notModified(c, realEstateVersionSources) {
+		return
+	}
+
+	properties, err := s.repos.RealEstate.List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch real estate properties",
 		})
 		return
 	}
-	defer rows.Close()
-
-	properties := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var property struct {
-			ID                  int      `json:"id"`
-			AccountID           int      `json:"account_id"`
-			PropertyType        string   `json:"property_type"`
-			PropertyName        string   `json:"property_name"`
-			PurchasePrice       float64  `json:"purchase_price"`
-			CurrentValue        float64  `json:"current_value"`
-			OutstandingMortgage float64  `json:"outstanding_mortgage"`
-			Equity              float64  `json:"equity"`
-			PurchaseDate        string   `json:"purchase_date"`
-			PropertySizeSqft    *float64 `json:"property_size_sqft"`
-			LotSizeAcres        *float64 `json:"lot_size_acres"`
-			RentalIncomeMonthly *float64 `json:"rental_income_monthly"`
-			PropertyTaxAnnual   *float64 `json:"property_tax_annual"`
-			Notes               *string  `json:"notes"`
-			StreetAddress       *string  `json:"street_address"`
-			City                *string  `json:"city"`
-			State               *string  `json:"state"`
-			ZipCode             *string  `json:"zip_code"`
-			Latitude            *float64 `json:"latitude"`
-			Longitude           *float64 `json:"longitude"`
-			APIEstimatedValue   *float64 `json:"api_estimated_value"`
-			APIEstimateDate     *string  `json:"api_estimate_date"`
-			APIProvider         *string  `json:"api_provider"`
-			CreatedAt           string   `json:"created_at"`
-		}
-
-		err := rows.Scan(
-			&property.ID, &property.AccountID, &property.PropertyType, &property.PropertyName,
-			&property.PurchasePrice, &property.CurrentValue, &property.OutstandingMortgage,
-			&property.Equity, &property.PurchaseDate, &property.PropertySizeSqft,
-			&property.LotSizeAcres, &property.RentalIncomeMonthly, &property.PropertyTaxAnnual,
-			&property.Notes, &property.StreetAddress, &property.City, &property.State, 
-			&property.ZipCode, &property.Latitude, &property.Longitude, 
-			&property.APIEstimatedValue, &property.APIEstimateDate, &property.APIProvider,
-			&property.CreatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan real estate property",
-			})
-			return
-		}
 
-		propertyMap := map[string]interface{}{
-			"id":                    property.ID,
-			"account_id":            property.AccountID,
-			"property_type":         property.PropertyType,
-			"property_name":         property.PropertyName,
-			"purchase_price":        property.PurchasePrice,
-			"current_value":         property.CurrentValue,
-			"outstanding_mortgage":  property.OutstandingMortgage,
-			"equity":                property.Equity,
-			"purchase_date":         property.PurchaseDate,
-			"property_size_sqft":    property.PropertySizeSqft,
-			"lot_size_acres":        property.LotSizeAcres,
-			"rental_income_monthly": property.RentalIncomeMonthly,
-			"property_tax_annual":   property.PropertyTaxAnnual,
-			"notes":                 property.Notes,
-			"street_address":        property.StreetAddress,
-			"city":                  property.City,
-			"state":                 property.State,
-			"zip_code":              property.ZipCode,
-			"latitude":              property.Latitude,
-			"longitude":             property.Longitude,
-			"api_estimated_value":   property.APIEstimatedValue,
-			"api_estimate_date":     property.APIEstimateDate,
-			"api_provider":          property.APIProvider,
-			"created_at":            property.CreatedAt,
-		}
-		properties = append(properties, propertyMap)
+	properties, ok := filterHoldings(s, c, models.HoldingTypeRealEstate, properties, func(h models.RealEstate) int { return h.ID })
+	if !ok {
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -1512,130 +922,87 @@ func (s *Server) getRealEstate(c *gin.Context) {
 // @Tags cash
 // @Accept json
 // @Produce json
-// @Success 200 {array} map[string]interface{} "List of cash holdings"
+// @Param tag query string false "Only holdings with any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out holdings with any of these comma-separated tags"
+// @Param view query int false "Only holdings passing this saved view, which must be over the same holding list"
+// @Param as_of query string false "Past date (YYYY-MM-DD): balances as they stood at the end of that day, rolled back through the audit log and applied contributions"
+// @Param compounding query string false "How interest rates compound for apy and projected_annual_interest: daily, monthly (default), quarterly or annually (the rate is already an APY)"
+// @Success 200 {array} map[string]interface{} "List of cash holdings with projected interest, and the interest totals"
+// @Failure 400 {object} map[string]interface{} "Invalid as_of or compounding"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cash-holdings [get]
 func (s *Server) getCashHoldings(c *gin.Context) {
-	query := `
-		SELECT id, account_id, institution_name, account_name, account_type, 
-		       current_balance, interest_rate, monthly_contribution, 
-		       account_number_last4, currency, notes, created_at, updated_at
-		FROM cash_holdings
-		ORDER BY institution_name, account_name
-	`
-
-	rows, err := s.db.Query(query)
+	load, asOf, ok := holdingsAsOf(c, s.repos.Cash.List, s.repos.Cash.ListAsOf)
+	if !ok {
+		return
+	}
+	compounding, ok := parseCompounding(c)
+	if !ok {
+		return
+	}
+
+	holdings, err := load()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch cash holdings",
 		})
 		return
 	}
-	defer rows.Close()
-
-	holdings := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var holding struct {
-			ID                  int      `json:"id"`
-			AccountID           int      `json:"account_id"`
-			InstitutionName     string   `json:"institution_name"`
-			AccountName         string   `json:"account_name"`
-			AccountType         string   `json:"account_type"`
-			CurrentBalance      float64  `json:"current_balance"`
-			InterestRate        *float64 `json:"interest_rate"`
-			MonthlyContribution *float64 `json:"monthly_contribution"`
-			AccountNumberLast4  *string  `json:"account_number_last4"`
-			Currency            string   `json:"currency"`
-			Notes               *string  `json:"notes"`
-			CreatedAt           string   `json:"created_at"`
-			UpdatedAt           string   `json:"updated_at"`
-		}
-
-		err := rows.Scan(
-			&holding.ID, &holding.AccountID, &holding.InstitutionName, &holding.AccountName,
-			&holding.AccountType, &holding.CurrentBalance, &holding.InterestRate,
-			&holding.MonthlyContribution, &holding.AccountNumberLast4, &holding.Currency,
-			&holding.Notes, &holding.CreatedAt, &holding.UpdatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan cash holding",
-			})
-			return
-		}
 
-		holdingMap := map[string]interface{}{
-			"id":                   holding.ID,
-			"account_id":           holding.AccountID,
-			"institution_name":     holding.InstitutionName,
-			"account_name":         holding.AccountName,
-			"account_type":         holding.AccountType,
-			"current_balance":      holding.CurrentBalance,
-			"interest_rate":        holding.InterestRate,
-			"monthly_contribution": holding.MonthlyContribution,
-			"account_number_last4": holding.AccountNumberLast4,
-			"currency":             holding.Currency,
-			"notes":                holding.Notes,
-			"created_at":           holding.CreatedAt,
-			"updated_at":           holding.UpdatedAt,
-		}
-		holdings = append(holdings, holdingMap)
+	holdings, ok = filterHoldings(s, c, models.HoldingTypeCash, holdings, func(h models.CashHolding) int { return h.ID })
+	if !ok {
+		return
 	}
+	holdings, interest := services.ProjectCashInterest(holdings, compounding)
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"cash_holdings": holdings,
-	})
+		"interest":      interest,
+	}
+	if asOf != nil {
+		response["as_of"] = *asOf
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Create cash holding
-// @Description Create a new cash holding using the cash holdings plugin
+// @Description Create a new cash holding using the cash holdings plugin. A holding with the same institution and account name is refused with 409 and returned, unless conflict_policy says otherwise.
 // @Tags cash-holdings
 // @Accept json
 // @Produce json
 // @Param request body map[string]interface{} true "Cash holding details"
+// @Param conflict_policy query string false "What to do when the holding already exists: reject (default), update, skip or duplicate"
 // @Success 201 {object} map[string]interface{} "Cash holding created successfully"
+// @Success 200 {object} map[string]interface{} "Existing cash holding updated or skipped"
 // @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 409 {object} map[string]interface{} "The holding already exists; existing holds it"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cash-holdings [post]
 func (s *Server) createCashHolding(c *gin.Context) {
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
 	// Get the cash holdings plugin
-	plugin, err := s.pluginManager.GetPlugin("cash_holdings")
-	if err != nil || plugin == nil {
+	if _, err := s.pluginManager.GetPlugin("cash_holdings"); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Cash holdings plugin not found",
 		})
 		return
 	}
 
-	manualPlugin, ok := plugin.(interface {
-		ProcessManualEntry(data map[string]interface{}) error
-	})
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Plugin does not support manual entry",
-		})
-		return
-	}
-
 	// Process the manual entry
-	err = manualPlugin.ProcessManualEntry(requestData)
+	outcome, err := s.createHoldingEntry(c, "cash_holdings", requestData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to create cash holding: %v", err),
-		})
+		respondManualEntryError(c, err, fmt.Sprintf("Failed to create cash holding: %v", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Cash holding created successfully",
+	c.JSON(manualEntryStatus(outcome), gin.H{
+		"message": fmt.Sprintf("Cash holding %s successfully", outcome.Action),
+		"outcome": outcome,
 	})
 }
 
@@ -1662,9 +1029,7 @@ func (s *Server) updateCashHolding(c *gin.Context) {
 
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
@@ -1695,6 +1060,7 @@ func (s *Server) updateCashHolding(c *gin.Context) {
 				"error": "Cash holding not found",
 			})
 		} else {
+			c.Error(err)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": fmt.Sprintf("Failed to update cash holding: %v", err),
 			})
@@ -1720,15 +1086,13 @@ func (s *Server) updateCashHolding(c *gin.Context) {
 func (s *Server) bulkUpdateCashHoldings(c *gin.Context) {
 	var requestData struct {
 		Updates []struct {
-			ID      int                    `json:"id"`
-			Changes map[string]interface{} `json:"changes"`
-		} `json:"updates"`
+			ID      int                    `json:"id" binding:"required,gt=0"`
+			Changes map[string]interface{} `json:"changes" binding:"required"`
+		} `json:"updates" binding:"required,dive"`
 	}
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
@@ -1816,28 +1180,8 @@ func (s *Server) deleteCashHolding(c *gin.Context) {
 		return
 	}
 
-	// Delete the cash holding record
-	query := `DELETE FROM cash_holdings WHERE id = $1`
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete cash holding",
-		})
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check deletion result",
-		})
-		return
-	}
-
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Cash holding not found",
-		})
+	if err := s.repos.Cash.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Cash holding not found", "Failed to delete cash holding")
 		return
 	}
 
@@ -1851,95 +1195,24 @@ func (s *Server) deleteCashHolding(c *gin.Context) {
 // @Tags crypto
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only holdings with any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out holdings with any of these comma-separated tags"
+// @Param view query int false "Only holdings passing this saved view, which must be over the same holding list"
 // @Success 200 {array} map[string]interface{} "List of cryptocurrency holdings"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /crypto-holdings [get]
 func (s *Server) getCryptoHoldings(c *gin.Context) {
-	query := `
-		SELECT ch.id, ch.account_id, ch.institution_name, ch.crypto_symbol, 
-		       ch.balance_tokens, ch.purchase_price_usd, ch.purchase_date,
-		       ch.wallet_address, ch.notes, ch.staking_annual_percentage, ch.created_at, ch.updated_at,
-		       cp.price_usd, cp.price_btc, cp.price_change_24h, cp.last_updated
-		FROM crypto_holdings ch
-		LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
-		AND cp.last_updated = (
-			SELECT MAX(last_updated)
-			FROM crypto_prices cp2
-			WHERE cp2.symbol = ch.crypto_symbol
-		)
-		ORDER BY ch.institution_name, ch.crypto_symbol
-	`
-
-	rows, err := s.db.Query(query)
+	holdings, err := s.repos.Crypto.List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch crypto holdings",
 		})
 		return
 	}
-	defer rows.Close()
-
-	holdings := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var holding struct {
-			ID                      int      `json:"id"`
-			AccountID               int      `json:"account_id"`
-			InstitutionName         string   `json:"institution_name"`
-			CryptoSymbol            string   `json:"crypto_symbol"`
-			BalanceTokens           float64  `json:"balance_tokens"`
-			PurchasePriceUSD        *float64 `json:"purchase_price_usd"`
-			PurchaseDate            *string  `json:"purchase_date"`
-			WalletAddress           *string  `json:"wallet_address"`
-			Notes                   *string  `json:"notes"`
-			StakingAnnualPercentage *float64 `json:"staking_annual_percentage"`
-			CreatedAt               string   `json:"created_at"`
-			UpdatedAt               string   `json:"updated_at"`
-			PriceUSD                *float64 `json:"current_price_usd"`
-			PriceBTC                *float64 `json:"current_price_btc"`
-			PriceChange24h          *float64 `json:"price_change_24h"`
-			PriceLastUpdated        *string  `json:"price_last_updated"`
-		}
-
-		err := rows.Scan(
-			&holding.ID, &holding.AccountID, &holding.InstitutionName, &holding.CryptoSymbol,
-			&holding.BalanceTokens, &holding.PurchasePriceUSD, &holding.PurchaseDate,
-			&holding.WalletAddress, &holding.Notes, &holding.StakingAnnualPercentage, &holding.CreatedAt, &holding.UpdatedAt,
-			&holding.PriceUSD, &holding.PriceBTC, &holding.PriceChange24h, &holding.PriceLastUpdated,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan crypto holding",
-			})
-			return
-		}
 
-		// Calculate current value in USD
-		var currentValueUSD *float64
-		if holding.PriceUSD != nil {
-			value := holding.BalanceTokens * *holding.PriceUSD
-			currentValueUSD = &value
-		}
-
-		holdingMap := map[string]interface{}{
-			"id":                        holding.ID,
-			"account_id":                holding.AccountID,
-			"institution_name":          holding.InstitutionName,
-			"crypto_symbol":             holding.CryptoSymbol,
-			"balance_tokens":            holding.BalanceTokens,
-			"purchase_price_usd":        holding.PurchasePriceUSD,
-			"purchase_date":             holding.PurchaseDate,
-			"wallet_address":            holding.WalletAddress,
-			"notes":                     holding.Notes,
-			"staking_annual_percentage": holding.StakingAnnualPercentage,
-			"created_at":                holding.CreatedAt,
-			"updated_at":                holding.UpdatedAt,
-			"current_price_usd":         holding.PriceUSD,
-			"current_price_btc":         holding.PriceBTC,
-			"current_value_usd":         currentValueUSD,
-			"price_change_24h":          holding.PriceChange24h,
-			"price_last_updated":        holding.PriceLastUpdated,
-		}
-		holdings = append(holdings, holdingMap)
+	holdings, ok := filterHoldings(s, c, models.HoldingTypeCrypto, holdings, func(h models.CryptoHolding) int { return h.ID })
+	if !ok {
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -1948,54 +1221,43 @@ func (s *Server) getCryptoHoldings(c *gin.Context) {
 }
 
 // @Summary Create new crypto holding
-// @Description Create a new cryptocurrency holding using the crypto holdings plugin
+// @Description Create a new cryptocurrency holding using the crypto holdings plugin. A holding of the same coin in the same wallet (the same wallet address, or the same institution when neither has one) is refused with 409 and returned, unless conflict_policy says otherwise.
 // @Tags crypto-holdings
 // @Accept json
 // @Produce json
 // @Param request body map[string]interface{} true "Crypto holding details"
+// @Param conflict_policy query string false "What to do when the holding already exists: reject (default), update, skip or duplicate"
 // @Success 201 {object} map[string]interface{} "Crypto holding created successfully"
+// @Success 200 {object} map[string]interface{} "Existing crypto holding updated or skipped"
 // @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 409 {object} map[string]interface{} "The holding already exists; existing holds it"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /crypto-holdings [post]
 func (s *Server) createCryptoHolding(c *gin.Context) {
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
 	// Get the crypto holdings plugin
-	plugin, err := s.pluginManager.GetPlugin("crypto_holdings")
-	if err != nil || plugin == nil {
+	if _, err := s.pluginManager.GetPlugin("crypto_holdings"); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Crypto holdings plugin not found",
 		})
 		return
 	}
 
-	manualPlugin, ok := plugin.(interface {
-		ProcessManualEntry(data map[string]interface{}) error
-	})
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Plugin does not support manual entry",
-		})
-		return
-	}
-
 	// Process the manual entry
-	err = manualPlugin.ProcessManualEntry(requestData)
+	outcome, err := s.createHoldingEntry(c, "crypto_holdings", requestData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to create crypto holding: %v", err),
-		})
+		respondManualEntryError(c, err, fmt.Sprintf("Failed to create crypto holding: %v", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Crypto holding created successfully",
+	c.JSON(manualEntryStatus(outcome), gin.H{
+		"message": fmt.Sprintf("Crypto holding %s successfully", outcome.Action),
+		"outcome": outcome,
 	})
 }
 
@@ -2022,9 +1284,7 @@ func (s *Server) updateCryptoHolding(c *gin.Context) {
 
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
@@ -2055,6 +1315,7 @@ func (s *Server) updateCryptoHolding(c *gin.Context) {
 				"error": "Crypto holding not found",
 			})
 		} else {
+			c.Error(err)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": fmt.Sprintf("Failed to update crypto holding: %v", err),
 			})
@@ -2087,28 +1348,8 @@ func (s *Server) deleteCryptoHolding(c *gin.Context) {
 		return
 	}
 
-	// Delete the crypto holding record
-	query := `DELETE FROM crypto_holdings WHERE id = $1`
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete crypto holding",
-		})
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check deletion result",
-		})
-		return
-	}
-
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Crypto holding not found",
-		})
+	if err := s.repos.Crypto.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Crypto holding not found", "Failed to delete crypto holding")
 		return
 	}
 
@@ -2118,19 +1359,41 @@ func (s *Server) deleteCryptoHolding(c *gin.Context) {
 }
 
 // @Summary Create new real estate property
-// @Description Create a new real estate property record (placeholder - to be implemented)
+// @Description Create a new real estate property using the real estate plugin. A property at the same address is refused with 409 and returned, unless conflict_policy says otherwise.
 // @Tags real-estate
 // @Accept json
 // @Produce json
 // @Param request body map[string]interface{} true "Property details including address, value, and mortgage info"
+// @Param conflict_policy query string false "What to do when the property already exists: reject (default), update, skip or duplicate"
 // @Success 201 {object} map[string]interface{} "Property created successfully"
+// @Success 200 {object} map[string]interface{} "Existing property updated or skipped"
 // @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 409 {object} map[string]interface{} "The property already exists; existing holds it"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /real-estate [post]
 func (s *Server) createRealEstate(c *gin.Context) {
-	// TODO: Implement real estate creation
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Create real estate endpoint - to be implemented",
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if _, err := s.pluginManager.GetPlugin("real_estate"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Real estate plugin not found",
+		})
+		return
+	}
+
+	outcome, err := s.createHoldingEntry(c, "real_estate", requestData)
+	if err != nil {
+		respondManualEntryError(c, err, fmt.Sprintf("Failed to create property: %v", err))
+		return
+	}
+
+	c.JSON(manualEntryStatus(outcome), gin.H{
+		"message": fmt.Sprintf("Property %s successfully", outcome.Action),
+		"outcome": outcome,
 	})
 }
 
@@ -2156,9 +1419,7 @@ func (s *Server) updateRealEstate(c *gin.Context) {
 
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
@@ -2180,6 +1441,7 @@ func (s *Server) updateRealEstate(c *gin.Context) {
 
 	// Update the property using the plugin
 	if err := plugin.UpdateManualEntry(id, data); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -2192,21 +1454,33 @@ func (s *Server) updateRealEstate(c *gin.Context) {
 }
 
 // @Summary Delete real estate property
-// @Description Delete a real estate property record (placeholder - to be implemented)
+// @Description Delete a real estate property record
 // @Tags real-estate
 // @Accept json
 // @Produce json
-// @Param id path string true "Property ID"
+// @Param id path int true "Property ID"
 // @Success 200 {object} map[string]interface{} "Property deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid property ID"
 // @Failure 404 {object} map[string]interface{} "Property not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /real-estate/{id} [delete]
 func (s *Server) deleteRealEstate(c *gin.Context) {
-	id := c.Param("id")
-	// TODO: Implement real estate deletion
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid property ID",
+		})
+		return
+	}
+
+	if err := s.repos.RealEstate.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Property not found", "Failed to delete property")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"property_id": id,
-		"message":     "Delete real estate endpoint - to be implemented",
+		"message": "Property deleted successfully",
 	})
 }
 
@@ -2327,8 +1601,10 @@ func (s *Server) getPluginSchemaForCategory(c *gin.Context) {
 // @Produce json
 // @Param name path string true "Plugin Name"
 // @Param request body map[string]interface{} true "Manual entry data matching plugin schema"
+// @Param conflict_policy query string false "What to do when the entry already exists: update (default), skip, duplicate or reject"
 // @Success 200 {object} map[string]interface{} "Manual entry processed successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid data or plugin does not support manual entry"
+// @Failure 409 {object} map[string]interface{} "The entry already exists and conflict_policy is reject; existing holds it"
 // @Failure 404 {object} map[string]interface{} "Plugin not found"
 // @Router /plugins/{name}/manual-entry [post]
 func (s *Server) processManualEntry(c *gin.Context) {
@@ -2336,24 +1612,73 @@ func (s *Server) processManualEntry(c *gin.Context) {
 
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
-	if err := s.pluginManager.ProcessManualEntry(pluginName, data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	outcome, err := s.upsertManualEntry(c, pluginName, data)
+	if err != nil {
+		respondManualEntryError(c, err, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Manual entry processed successfully",
+		"outcome": outcome,
 	})
 }
 
+// upsertManualEntry processes a manual entry, letting a conflict_policy query
+// parameter override any conflict_policy in the request body
+func (s *Server) upsertManualEntry(c *gin.Context, pluginName string, data map[string]interface{}) (*plugins.ManualEntryOutcome, error) {
+	if policy := c.Query(plugins.ConflictPolicyField); policy != "" {
+		data[plugins.ConflictPolicyField] = policy
+	}
+
+	outcome, err := s.pluginManager.UpsertManualEntry(pluginName, data)
+	if err != nil {
+		// Lets the error envelope report validation failures per field
+		c.Error(err)
+		return nil, err
+	}
+
+	setAuditOutcome(c, outcome)
+	return outcome, nil
+}
+
+// createHoldingEntry is upsertManualEntry for the create endpoints, which
+// refuse an entry matching an existing one unless conflict_policy is given
+func (s *Server) createHoldingEntry(c *gin.Context, pluginName string, data map[string]interface{}) (*plugins.ManualEntryOutcome, error) {
+	if _, given := data[plugins.ConflictPolicyField]; !given {
+		data[plugins.ConflictPolicyField] = string(plugins.ConflictPolicyReject)
+	}
+	return s.upsertManualEntry(c, pluginName, data)
+}
+
+// respondManualEntryError reports a failed manual entry: 409 with the existing
+// entry when it matched one, 400 otherwise
+func respondManualEntryError(c *gin.Context, err error, message string) {
+	var duplicate *plugins.DuplicateEntryError
+	if errors.As(err, &duplicate) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       message,
+			"code":        "duplicate_entry",
+			"existing_id": duplicate.ID,
+			"existing":    duplicate.Existing,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
+
+// manualEntryStatus returns 201 when a manual entry created a record and 200 otherwise
+func manualEntryStatus(outcome *plugins.ManualEntryOutcome) int {
+	if outcome.Action == plugins.ManualEntryCreated {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
 // @Summary Refresh all plugin data
 // @Description Trigger data refresh for all enabled plugins from their external sources
 // @Tags plugins
@@ -2422,188 +1747,7 @@ func (s *Server) getPluginHealth(c *gin.Context) {
 func (s *Server) getManualEntries(c *gin.Context) {
 	entryType := c.Query("type") // Optional filter by entry type
 
-	// Build unified query to get manual entries from all relevant tables
-	query := `
-		SELECT 'computershare' as entry_type, 
-		       sh.id, sh.account_id, sh.created_at, sh.created_at as updated_at,
-		       json_build_object(
-		           'symbol', sh.symbol,
-		           'company_name', sh.company_name,
-		           'shares_owned', sh.shares_owned,
-		           'cost_basis', sh.cost_basis,
-		           'current_price', sh.current_price
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM stock_holdings sh
-		LEFT JOIN accounts a ON sh.account_id = a.id
-		WHERE sh.data_source = 'computershare'
-		
-		UNION ALL
-		
-		SELECT 'stock_holding' as entry_type, 
-		       sh.id, sh.account_id, sh.created_at, sh.created_at as updated_at,
-		       json_build_object(
-		           'symbol', sh.symbol,
-		           'company_name', sh.company_name,
-		           'shares_owned', sh.shares_owned,
-		           'cost_basis', sh.cost_basis,
-		           'current_price', sh.current_price,
-		           'institution_name', sh.institution_name
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM stock_holdings sh
-		LEFT JOIN accounts a ON sh.account_id = a.id
-		WHERE sh.data_source IN ('manual', 'stock_holding') OR (sh.data_source IS NULL AND sh.created_at IS NOT NULL)
-		
-		UNION ALL
-		
-		SELECT 'morgan_stanley' as entry_type,
-		       eg.id, eg.account_id, eg.created_at, eg.created_at as updated_at,
-		       json_build_object(
-		           'grant_type', eg.grant_type,
-		           'company_symbol', eg.company_symbol,
-		           'total_shares', eg.total_shares,
-		           'vested_shares', eg.vested_shares,
-		           'unvested_shares', eg.unvested_shares,
-		           'strike_price', eg.strike_price,
-		           'grant_date', eg.grant_date,
-		           'vest_start_date', eg.vest_start_date,
-		           'current_price', eg.current_price
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM equity_grants eg
-		LEFT JOIN accounts a ON eg.account_id = a.id
-		WHERE eg.created_at IS NOT NULL
-		
-		UNION ALL
-		
-		SELECT 'real_estate' as entry_type,
-		       re.id, re.account_id, re.created_at, re.created_at as updated_at,
-		       json_build_object(
-		           'property_type', re.property_type,
-		           'property_name', re.property_name,
-		           'street_address', re.street_address,
-		           'city', re.city,
-		           'state', re.state,
-		           'zip_code', re.zip_code,
-		           'purchase_price', re.purchase_price,
-		           'current_value', re.current_value,
-		           'outstanding_mortgage', re.outstanding_mortgage,
-		           'equity', re.equity,
-		           'purchase_date', TO_CHAR(re.purchase_date, 'YYYY-MM-DD'),
-		           'property_size_sqft', re.property_size_sqft,
-		           'lot_size_acres', re.lot_size_acres,
-		           'rental_income_monthly', re.rental_income_monthly,
-		           'property_tax_annual', re.property_tax_annual,
-		           'notes', re.notes
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM real_estate_properties re
-		LEFT JOIN accounts a ON re.account_id = a.id
-		WHERE re.created_at IS NOT NULL
-		
-		UNION ALL
-		
-		SELECT 'cash_holdings' as entry_type,
-		       ch.id, ch.account_id, ch.created_at, ch.updated_at,
-		       json_build_object(
-		           'institution_name', ch.institution_name,
-		           'account_name', ch.account_name,
-		           'account_type', ch.account_type,
-		           'current_balance', ch.current_balance,
-		           'interest_rate', ch.interest_rate,
-		           'monthly_contribution', ch.monthly_contribution,
-		           'account_number_last4', ch.account_number_last4,
-		           'currency', ch.currency,
-		           'notes', ch.notes
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM cash_holdings ch
-		LEFT JOIN accounts a ON ch.account_id = a.id
-		WHERE ch.created_at IS NOT NULL
-		
-		UNION ALL
-		
-		SELECT 'crypto_holdings' as entry_type,
-		       cry.id, cry.account_id, cry.created_at, cry.updated_at,
-		       json_build_object(
-		           'institution_name', cry.institution_name,
-		           'crypto_symbol', cry.crypto_symbol,
-		           'balance_tokens', cry.balance_tokens,
-		           'purchase_price_usd', cry.purchase_price_usd,
-		           'purchase_date', cry.purchase_date,
-		           'wallet_address', cry.wallet_address,
-		           'notes', cry.notes
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM crypto_holdings cry
-		LEFT JOIN accounts a ON cry.account_id = a.id
-		WHERE cry.created_at IS NOT NULL
-		
-		UNION ALL
-		
-		SELECT 'other_assets' as entry_type,
-		       ma.id, ma.account_id, ma.created_at, ma.last_updated as updated_at,
-		       json_build_object(
-		           'asset_category_id', ma.asset_category_id,
-		           'asset_name', ma.asset_name,
-		           'current_value', ma.current_value,
-		           'purchase_price', ma.purchase_price,
-		           'amount_owed', ma.amount_owed,
-		           'purchase_date', ma.purchase_date,
-		           'description', ma.description,
-		           'custom_fields', ma.custom_fields,
-		           'valuation_method', ma.valuation_method,
-		           'last_valuation_date', ma.last_valuation_date,
-		           'notes', ma.notes,
-		           'category_name', ac.name,
-		           'category_description', ac.description,
-		           'category_icon', ac.icon,
-		           'category_color', ac.color
-		       ) as data_json,
-		       a.account_name, a.institution
-		FROM miscellaneous_assets ma
-		LEFT JOIN accounts a ON ma.account_id = a.id
-		LEFT JOIN asset_categories ac ON ma.asset_category_id = ac.id
-		WHERE ma.created_at IS NOT NULL
-	`
-
-	args := []interface{}{}
-
-	// Add filter if entry type is specified
-	if entryType != "" {
-		query = `
-			SELECT * FROM (` + query + `) as all_entries 
-			WHERE entry_type = $1
-			ORDER BY created_at DESC
-		`
-		args = append(args, entryType)
-	} else {
-		query += " ORDER BY created_at DESC"
-	}
-
-	// Debug: Check what's actually in the individual tables
-	var stockCount, equityCount, realEstateCount, cashCount, cryptoCount int
-	s.db.QueryRow("SELECT COUNT(*) FROM stock_holdings").Scan(&stockCount)
-	s.db.QueryRow("SELECT COUNT(*) FROM equity_grants").Scan(&equityCount)
-	s.db.QueryRow("SELECT COUNT(*) FROM real_estate_properties").Scan(&realEstateCount)
-	s.db.QueryRow("SELECT COUNT(*) FROM cash_holdings").Scan(&cashCount)
-	s.db.QueryRow("SELECT COUNT(*) FROM crypto_holdings").Scan(&cryptoCount)
-	fmt.Printf("DEBUG: Table counts - stock: %d, equity: %d, real_estate: %d, cash: %d, crypto: %d\n", 
-		stockCount, equityCount, realEstateCount, cashCount, cryptoCount)
-	
-	// Debug: Check accounts that exist
-	accountRows, _ := s.db.Query("SELECT id, account_name, institution FROM accounts ORDER BY created_at DESC LIMIT 10")
-	fmt.Printf("DEBUG: Recent accounts:\n")
-	for accountRows.Next() {
-		var id int
-		var name, institution string
-		accountRows.Scan(&id, &name, &institution)
-		fmt.Printf("  Account %d: %s at %s\n", id, name, institution)
-	}
-	accountRows.Close()
-
-	rows, err := s.db.Query(query, args...)
+	records, err := s.repos.ManualEntries.List(entryType)
 	if err != nil {
 		fmt.Printf("Query Error: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -2611,41 +1755,14 @@ func (s *Server) getManualEntries(c *gin.Context) {
 		})
 		return
 	}
-	defer rows.Close()
-
-	entries := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var entry struct {
-			EntryType   string  `json:"entry_type"`
-			ID          int     `json:"id"`
-			AccountID   int     `json:"account_id"`
-			CreatedAt   string  `json:"created_at"`
-			UpdatedAt   string  `json:"updated_at"`
-			DataJSON    string  `json:"data_json"`
-			AccountName *string `json:"account_name"`
-			Institution *string `json:"institution"`
-		}
-
-		err := rows.Scan(
-			&entry.EntryType, &entry.ID, &entry.AccountID, &entry.CreatedAt, &entry.UpdatedAt,
-			&entry.DataJSON, &entry.AccountName, &entry.Institution,
-		)
-		if err != nil {
-			fmt.Printf("Scan Error: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan manual entry",
-			})
-			return
-		}
-
-		fmt.Printf("DEBUG: Found entry - Type: %s, ID: %d, AccountID: %d, AccountName: %v\n", 
-			entry.EntryType, entry.ID, entry.AccountID, entry.AccountName)
 
+	entries := make([]map[string]interface{}, 0, len(records))
+	for _, entry := range records {
 		entryMap := map[string]interface{}{
 			"id":           entry.ID,
 			"account_id":   entry.AccountID,
 			"entry_type":   entry.EntryType,
-			"data_json":    entry.DataJSON,
+			"data_json":    s.decryptManualEntryJSON(entry.EntryType, entry.DataJSON),
 			"created_at":   entry.CreatedAt,
 			"updated_at":   entry.UpdatedAt,
 			"account_name": entry.AccountName,
@@ -2661,6 +1778,44 @@ func (s *Server) getManualEntries(c *gin.Context) {
 	})
 }
 
+// encryptedManualEntryFields lists the encrypted fields in each manual entry type's data_json
+var encryptedManualEntryFields = map[string]string{
+	"cash_holdings":   "account_number_last4",
+	"crypto_holdings": "wallet_address",
+}
+
+// decryptManualEntryJSON decrypts the sensitive field in a manual entry's data_json
+func (s *Server) decryptManualEntryJSON(entryType, dataJSON string) string {
+	field, ok := encryptedManualEntryFields[entryType]
+	if !ok {
+		return dataJSON
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return dataJSON
+	}
+
+	value, ok := data[field].(string)
+	if !ok {
+		return dataJSON
+	}
+
+	plaintext, err := s.fieldEncryptor.Decrypt(value)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to decrypt %s for %s entry: %v\n", field, entryType, err)
+		data[field] = nil
+	} else {
+		data[field] = plaintext
+	}
+
+	decrypted, err := json.Marshal(data)
+	if err != nil {
+		return dataJSON
+	}
+	return string(decrypted)
+}
+
 // @Summary Create new manual entry
 // @Description Create a new manual data entry using the appropriate plugin system
 // @Tags manual-entries
@@ -2710,9 +1865,7 @@ func (s *Server) updateManualEntry(c *gin.Context) {
 
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 
@@ -2734,6 +1887,7 @@ func (s *Server) updateManualEntry(c *gin.Context) {
 
 	// Update the entry using the plugin
 	if err := plugin.UpdateManualEntry(id, data); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -2774,45 +1928,14 @@ func (s *Server) deleteManualEntry(c *gin.Context) {
 		return
 	}
 
-	var query string
-	switch entryType {
-	case "stock_holding":
-		query = "DELETE FROM stock_holdings WHERE id = $1 AND data_source = 'stock_holding'"
-	case "morgan_stanley":
-		query = "DELETE FROM equity_grants WHERE id = $1"
-	case "real_estate":
-		query = "DELETE FROM real_estate_properties WHERE id = $1"
-	case "cash_holdings":
-		query = "DELETE FROM cash_holdings WHERE id = $1"
-	case "crypto_holdings":
-		query = "DELETE FROM crypto_holdings WHERE id = $1"
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid entry type",
-		})
-		return
-	}
-
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete entry",
-		})
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check deletion result",
-		})
-		return
-	}
-
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Entry not found",
-		})
+	if err := s.repos.ManualEntries.Delete(entryType, id); err != nil {
+		if errors.Is(err, repository.ErrInvalidEntryType) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid entry type",
+			})
+			return
+		}
+		s.respondRepositoryError(c, err, "Entry not found", "Failed to delete entry")
 		return
 	}
 
@@ -2839,17 +1962,17 @@ func (s *Server) getManualEntrySchemas(c *gin.Context) {
 // Price refresh handlers
 
 // @Summary Refresh all stock prices
-// @Description Trigger price refresh for all stock symbols from configured price provider
+// @Description Trigger price refresh for stock symbols from configured price provider. By default only symbols whose cached price is stale for the market hours are refreshed, and the rest are listed in the summary's skipped_symbols; mode=all or force=true refreshes every symbol. With PRICE_REFRESH_PRIORITIZE on, symbols are refreshed largest position first and those left when the provider rate limits are listed in deferred_symbols.
 // @Tags prices
 // @Accept json
 // @Produce json
 // @Param force query boolean false "Force refresh even if cache is recent"
+// @Param mode query string false "stale (default) to refresh only stale symbols, or all"
 // @Success 200 {object} map[string]interface{} "Price refresh completed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid mode"
 // @Failure 500 {object} map[string]interface{} "Internal server error during refresh"
 // @Router /prices/refresh [post]
 func (s *Server) refreshPrices(c *gin.Context) {
-	startTime := time.Now()
-
 	// Enhanced debugging - log full request details
 	fmt.Printf("DEBUG: refreshPrices called - Method: %s, URL: %s, FullPath: %s\n", c.Request.Method, c.Request.URL.String(), c.FullPath())
 	fmt.Printf("DEBUG: Query parameters: %v\n", c.Request.URL.Query())
@@ -2858,63 +1981,34 @@ func (s *Server) refreshPrices(c *gin.Context) {
 	forceRefresh := c.Query("force") == "true"
 	fmt.Printf("DEBUG: force query param: '%s', forceRefresh: %t\n", c.Query("force"), forceRefresh)
 
-	// Get all unique symbols that need price updates
-	symbols := s.getAllActiveSymbols()
-	if len(symbols) == 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "No symbols found to update",
-			"summary": services.PriceRefreshSummary{
-				TotalSymbols:   0,
-				UpdatedSymbols: 0,
-				FailedSymbols:  0,
-				Timestamp:      time.Now(),
-				DurationMs:     time.Since(startTime).Milliseconds(),
-			},
-		})
+	mode := c.DefaultQuery("mode", priceRefreshModeStale)
+	if mode != priceRefreshModeStale && mode != priceRefreshModeAll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be stale or all"})
 		return
 	}
 
-	// Initialize price service
-	priceService := s.priceService
-
-	// Track results
-	var results []services.PriceUpdateResult
-	updatedCount := 0
-	failedCount := 0
-
-	for _, symbol := range symbols {
-		result := s.updateSymbolPrice(symbol, priceService, forceRefresh)
-		results = append(results, result)
-
-		if result.Updated {
-			updatedCount++
-		} else {
-			failedCount++
+	summary := s.RefreshStockPrices(c.Request.Context(), mode == priceRefreshModeStale && !forceRefresh, forceRefresh)
+	if summary.TotalSymbols == 0 {
+		message := "No symbols found to update"
+		if len(summary.SkippedSymbols) > 0 {
+			message = fmt.Sprintf("All %d symbols have fresh prices", len(summary.SkippedSymbols))
 		}
-	}
-
-	// Determine the actual provider name based on results
-	actualProviderName := s.determineActualProviderName(results, priceService.GetProviderName())
-
-	summary := services.PriceRefreshSummary{
-		TotalSymbols:   len(symbols),
-		UpdatedSymbols: updatedCount,
-		FailedSymbols:  failedCount,
-		Results:        results,
-		ProviderName:   actualProviderName,
-		Timestamp:      time.Now(),
-		DurationMs:     time.Since(startTime).Milliseconds(),
+		c.JSON(http.StatusOK, gin.H{
+			"message": message,
+			"summary": summary,
+		})
+		return
 	}
 
 	status := http.StatusOK
-	if failedCount == len(symbols) {
+	if summary.UpdatedSymbols == 0 {
 		status = http.StatusInternalServerError
-	} else if failedCount > 0 {
+	} else if summary.FailedSymbols > 0 || len(summary.DeferredSymbols) > 0 {
 		status = http.StatusPartialContent
 	}
 
 	c.JSON(status, gin.H{
-		"message": fmt.Sprintf("Price refresh completed: %d/%d symbols updated", updatedCount, len(symbols)),
+		"message": fmt.Sprintf("Price refresh completed: %d/%d symbols updated", summary.UpdatedSymbols, summary.TotalSymbols+len(summary.DeferredSymbols)),
 		"summary": summary,
 	})
 }
@@ -2943,7 +2037,8 @@ func (s *Server) refreshSymbolPrice(c *gin.Context) {
 	forceRefresh := c.Query("force") == "true"
 
 	priceService := s.priceService
-	result := s.updateSymbolPrice(symbol, priceService, forceRefresh)
+	result := s.updateSymbolPrice(c.Request.Context(), symbol, priceService, forceRefresh)
+	s.symbolHealthService.RecordResult(services.SymbolAssetTypeStock, symbol, result.Updated, result.ErrorType, result.Error)
 
 	status := http.StatusOK
 	if !result.Updated {
@@ -2957,14 +2052,17 @@ func (s *Server) refreshSymbolPrice(c *gin.Context) {
 }
 
 // @Summary Get current price status
-// @Description Retrieve current price cache status including stale count, last update time, and refresh recommendations
+// @Description Retrieve current price cache status including stale count, last update time, and refresh recommendations. asset_classes reports the freshness of stock and crypto prices and of property and other asset valuations, each with its stale count and refresh endpoint; recommendations lists what to refresh.
 // @Tags prices
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{} "Current price status and cache information"
 // @Router /prices/status [get]
 func (s *Server) getPricesStatus(c *gin.Context) {
-	status := s.getPriceStatus()
+	status, hit, _ := cache.GetOrLoad(s.cache, cache.KeyPricesStatus, s.config.Cache.TTL, func() (PriceStatus, error) {
+		return s.getPriceStatus(), nil
+	})
+	setCacheStatus(c, hit)
 	c.JSON(http.StatusOK, status)
 }
 
@@ -2982,51 +2080,70 @@ func (s *Server) getMarketStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
-// Helper functions for price refresh
+// getAllActiveSymbols returns every symbol held in stock holdings or equity grants
 func (s *Server) getAllActiveSymbols() []string {
-	var symbols []string
-
-	// Get symbols from stock_holdings
-	stockQuery := `SELECT DISTINCT symbol FROM stock_holdings WHERE symbol IS NOT NULL AND symbol != ''`
-	rows, err := s.db.Query(stockQuery)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var symbol string
-			if rows.Scan(&symbol) == nil && symbol != "" {
-				symbols = append(symbols, strings.ToUpper(strings.TrimSpace(symbol)))
-			}
+	symbols, err := s.repos.Stocks.ActiveSymbols()
+	if err != nil {
+		fmt.Printf("WARNING: %v\n", err)
+	}
+	return symbols
+}
+
+// Price refresh modes: only symbols with stale cached prices, or every symbol
+const (
+	priceRefreshModeStale = "stale"
+	priceRefreshModeAll   = "all"
+)
+
+// prioritizeByPositionValue orders symbols by the market value held in them,
+// stock holdings plus vested and unvested grants, largest first. Symbols
+// without a price yet come first, since their value is missing altogether.
+func (s *Server) prioritizeByPositionValue(ctx context.Context, symbols []string) []string {
+	positions, err := s.repos.Stocks.Positions(ctx, symbols)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to value positions, refreshing in the usual order: %v\n", err)
+		return symbols
+	}
+
+	ordered := append([]string(nil), symbols...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := positions[ordered[i]], positions[ordered[j]]
+		if a.Priced != b.Priced {
+			return !a.Priced
 		}
+		return a.Value > b.Value
+	})
+	return ordered
+}
+
+// staleStockSymbols splits symbols into those whose latest cached price is
+// stale for the market hours, or missing, and those still fresh
+func (s *Server) staleStockSymbols(ctx context.Context, symbols []string) (stale, fresh []string) {
+	cachedAt, err := s.repos.Stocks.LatestPriceTimes(ctx, symbols)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to check cached prices, refreshing every symbol: %v\n", err)
+		return symbols, nil
 	}
 
-	// Get symbols from equity_grants
-	equityQuery := `SELECT DISTINCT company_symbol FROM equity_grants WHERE company_symbol IS NOT NULL AND company_symbol != ''`
-	rows, err = s.db.Query(equityQuery)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var symbol string
-			if rows.Scan(&symbol) == nil && symbol != "" {
-				symbol = strings.ToUpper(strings.TrimSpace(symbol))
-				// Avoid duplicates
-				found := false
-				for _, existing := range symbols {
-					if existing == symbol {
-						found = true
-						break
-					}
-				}
-				if !found {
-					symbols = append(symbols, symbol)
-				}
-			}
+	for _, symbol := range symbols {
+		if s.marketService.ShouldRefreshPrices(cachedAt[symbol], s.config.API.Current().CacheRefreshInterval) {
+			stale = append(stale, symbol)
+		} else {
+			fresh = append(fresh, symbol)
 		}
 	}
+	return stale, fresh
+}
 
-	return symbols
+func (s *Server) updateSymbolPrice(ctx context.Context, symbol string, priceService *services.PriceService, forceRefresh bool) services.PriceUpdateResult {
+	return s.applySymbolPrice(ctx, symbol, forceRefresh, func() (float64, error) {
+		return priceService.GetCurrentPriceWithForceContext(ctx, symbol, forceRefresh)
+	})
 }
 
-func (s *Server) updateSymbolPrice(symbol string, priceService *services.PriceService, forceRefresh bool) services.PriceUpdateResult {
+// applySymbolPrice records the price fetch returns for symbol on its holdings and grants,
+// comparing it with the previous price
+func (s *Server) applySymbolPrice(ctx context.Context, symbol string, forceRefresh bool, fetch func() (float64, error)) services.PriceUpdateResult {
 	result := services.PriceUpdateResult{
 		Symbol:    symbol,
 		Updated:   false,
@@ -3036,38 +2153,24 @@ func (s *Server) updateSymbolPrice(symbol string, priceService *services.PriceSe
 	// Get old price and cache info for comparison and analysis
 	var oldPrice float64
 	var lastCacheUpdate time.Time
-	var stockHoldingsPrice sql.NullFloat64
-	var stockPricesTimestamp sql.NullTime
-	
-	priceQuery := `
-		SELECT COALESCE(h.current_price, 0), h.current_price, sp.timestamp
-		FROM stock_holdings h
-		LEFT JOIN (
-			SELECT symbol, timestamp 
-			FROM stock_prices 
-			WHERE symbol = $1 
-			ORDER BY timestamp DESC 
-			LIMIT 1
-		) sp ON sp.symbol = h.symbol
-		WHERE h.symbol = $1 
-		LIMIT 1
-	`
-	err := s.db.QueryRow(priceQuery, symbol).Scan(&oldPrice, &stockHoldingsPrice, &stockPricesTimestamp)
-	if err != nil && err != sql.ErrNoRows {
+	snapshot, err := s.repos.Stocks.PriceSnapshot(ctx, symbol)
+	if err != nil {
 		fmt.Printf("ERROR: Failed to get old price for %s: %v\n", symbol, err)
 	}
-	
+	if snapshot.HoldingPrice != nil {
+		oldPrice = *snapshot.HoldingPrice
+	}
+
 	// Determine cache source and age
-	if stockPricesTimestamp.Valid {
-		lastCacheUpdate = stockPricesTimestamp.Time
+	if snapshot.CachedAt != nil {
+		lastCacheUpdate = *snapshot.CachedAt
 		fmt.Printf("DEBUG: Old price %.2f for %s from stock_prices table (timestamp: %v)\n", oldPrice, symbol, lastCacheUpdate)
-	} else if stockHoldingsPrice.Valid {
+	} else if snapshot.HoldingPrice != nil {
 		fmt.Printf("DEBUG: Old price %.2f for %s from stock_holdings.current_price (no stock_prices entry)\n", oldPrice, symbol)
 		// For stock holdings price, we don't have a reliable timestamp, so use a very old date to force refresh
 		lastCacheUpdate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
 	} else {
 		fmt.Printf("DEBUG: No old price found for %s in any cache location\n", symbol)
-		oldPrice = 0
 		lastCacheUpdate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
 	}
 
@@ -3086,7 +2189,7 @@ func (s *Server) updateSymbolPrice(symbol string, priceService *services.PriceSe
 	result.OldPrice = oldPrice
 
 	// Get current price from service
-	newPrice, err := priceService.GetCurrentPriceWithForce(symbol, forceRefresh)
+	newPrice, err := fetch()
 	if err != nil {
 		result.Error = err.Error()
 		
@@ -3122,60 +2225,18 @@ func (s *Server) updateSymbolPrice(symbol string, priceService *services.PriceSe
 		result.Source = "cache"
 	}
 
-	// Update stock_holdings with transaction for consistency
-	fmt.Printf("INFO: Starting database transaction to update prices for %s (new price: %.2f)\n", symbol, newPrice)
-	tx, err := s.db.Begin()
-	if err != nil {
-		result.Error = fmt.Sprintf("Failed to start transaction: %v", err)
-		result.ErrorType = "database_error"
-		fmt.Printf("ERROR: Failed to start transaction for %s: %v\n", symbol, err)
-		return result
-	}
-	defer tx.Rollback()
-
-	stockUpdate := `
-		UPDATE stock_holdings 
-		SET current_price = $1, last_updated = $2 
-		WHERE symbol = $3
-	`
-	fmt.Printf("INFO: Updating stock_holdings for %s with price %.2f\n", symbol, newPrice)
-	stockResult, err := tx.Exec(stockUpdate, newPrice, time.Now(), symbol)
-
-	// Update equity_grants
-	equityUpdate := `
-		UPDATE equity_grants 
-		SET current_price = $1, last_updated = $2 
-		WHERE company_symbol = $3
-	`
-	fmt.Printf("INFO: Updating equity_grants for %s with price %.2f\n", symbol, newPrice)
-	equityResult, err2 := tx.Exec(equityUpdate, newPrice, time.Now(), symbol)
-
-	// Check if any rows were updated
-	stockRows, stockErr := stockResult.RowsAffected()
-	equityRows, equityErr := equityResult.RowsAffected()
-
-	fmt.Printf("INFO: Database update results for %s - stock_holdings: %d rows, equity_grants: %d rows\n", symbol, stockRows, equityRows)
-
-	// Handle database errors comprehensively
-	if err != nil && err2 != nil {
-		result.Error = fmt.Sprintf("Update failed: stock_holdings: %v, equity_grants: %v", err, err2)
-		result.ErrorType = "database_error"
-		fmt.Printf("ERROR: Both updates failed for %s - stock: %v, equity: %v\n", symbol, err, err2)
-	} else if stockErr != nil || equityErr != nil {
-		result.Error = fmt.Sprintf("Failed to check affected rows: %v, %v", stockErr, equityErr)
+	// Update stock_holdings and equity_grants in one transaction for consistency
+	fmt.Printf("INFO: Updating prices for %s (new price: %.2f)\n", symbol, newPrice)
+	updated, err := s.repos.Stocks.ApplyPrice(symbol, newPrice)
+	switch {
+	case err != nil:
+		result.Error = err.Error()
 		result.ErrorType = "database_error"
-		fmt.Printf("ERROR: Failed to check affected rows for %s - stock: %v, equity: %v\n", symbol, stockErr, equityErr)
-	} else if stockRows > 0 || equityRows > 0 {
-		// Commit the transaction only if updates were successful
-		if commitErr := tx.Commit(); commitErr != nil {
-			result.Error = fmt.Sprintf("Failed to commit transaction: %v", commitErr)
-			result.ErrorType = "database_error"
-			fmt.Printf("ERROR: Failed to commit transaction for %s: %v\n", symbol, commitErr)
-		} else {
-			result.Updated = true
-			fmt.Printf("SUCCESS: Price update committed for %s - stock_holdings: %d rows, equity_grants: %d rows\n", symbol, stockRows, equityRows)
-		}
-	} else {
+		fmt.Printf("ERROR: Price update failed for %s: %v\n", symbol, err)
+	case updated > 0:
+		result.Updated = true
+		fmt.Printf("SUCCESS: Price update committed for %s - %d holdings and grants\n", symbol, updated)
+	default:
 		result.Error = "No records found to update for this symbol"
 		result.ErrorType = "invalid_symbol"
 		fmt.Printf("WARNING: No records found to update for symbol %s - may not exist in stock_holdings or equity_grants\n", symbol)
@@ -3263,7 +2324,7 @@ func (s *Server) refreshCryptoPrice(c *gin.Context) {
 		return
 	}
 
-	price, err := s.cryptoService.GetPrice(symbol)
+	price, err := s.cryptoService.GetPriceWithForce(symbol, true)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to refresh price for %s: %v", symbol, err),
@@ -3307,45 +2368,24 @@ func (s *Server) getCryptoPriceHistory(c *gin.Context) {
 	// Calculate start date
 	startDate := time.Now().AddDate(0, 0, -days)
 
-	query := `
-		SELECT symbol, price_usd, price_btc, last_updated
-		FROM crypto_prices 
-		WHERE last_updated >= $1
-		ORDER BY symbol, last_updated
-	`
-
-	rows, err := s.db.Query(query, startDate)
+	points, err := s.repos.Crypto.PriceHistory(startDate)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch crypto price history",
 		})
 		return
 	}
-	defer rows.Close()
 
 	// Group data by symbol
 	historyMap := make(map[string][]map[string]interface{})
-	
-	for rows.Next() {
-		var symbol string
-		var priceUSD, priceBTC float64
-		var lastUpdated time.Time
-
-		err := rows.Scan(&symbol, &priceUSD, &priceBTC, &lastUpdated)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to scan price history data",
-			})
-			return
-		}
-
+	for _, point := range points {
 		dataPoint := map[string]interface{}{
-			"timestamp":  lastUpdated.Format(time.RFC3339),
-			"price_usd":  priceUSD,
-			"price_btc":  priceBTC,
+			"timestamp":  point.LastUpdated.Format(time.RFC3339),
+			"price_usd":  point.PriceUSD,
+			"price_btc":  point.PriceBTC,
 		}
 
-		historyMap[symbol] = append(historyMap[symbol], dataPoint)
+		historyMap[point.Symbol] = append(historyMap[point.Symbol], dataPoint)
 	}
 
 	// Convert to array format
@@ -3369,7 +2409,7 @@ func (s *Server) getCryptoPriceHistory(c *gin.Context) {
 // Property valuation handlers
 
 // @Summary Get property valuation
-// @Description Retrieve current property valuation estimate by address components
+// @Description Retrieve current property valuation estimate by address components. Every configured provider is asked; with more than one answering, the estimate is their confidence-weighted consensus and providers lists each provider's value and confidence.
 // @Tags property-valuation
 // @Accept json
 // @Produce json
@@ -3468,7 +2508,7 @@ func (s *Server) refreshPropertyValuation(c *gin.Context) {
 }
 
 // @Summary Get property valuation providers
-// @Description Retrieve list of available property valuation providers and their status
+// @Description Retrieve list of property valuation providers (ATTOM Data, Rentcast, HouseCanary) and whether each is configured. Valuations use every available provider.
 // @Tags property-valuation
 // @Accept json
 // @Produce json
@@ -3500,17 +2540,11 @@ func (s *Server) getPropertyValuationProviders(c *gin.Context) {
 		},
 	}
 	
-	if s.propertyValuationService.IsAttomDataAvailable() {
-		providers = append(providers, gin.H{
-			"name": "ATTOM Data API",
-			"available": true,
-			"description": "Professional property data and valuation from ATTOM Data",
-		})
-	} else {
+	for _, provider := range s.propertyValuationService.Providers() {
 		providers = append(providers, gin.H{
-			"name": "ATTOM Data API",
-			"available": false,
-			"description": "Professional property data and valuation from ATTOM Data (API key required or feature disabled)",
+			"name": provider.GetProviderName(),
+			"available": provider.IsAvailable(),
+			"description": provider.Description(),
 		})
 	}
 	
@@ -3529,160 +2563,50 @@ func (s *Server) getPropertyValuationProviders(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param category query int false "Filter by asset category ID"
+// @Param tag query string false "Only holdings with any of these comma-separated tags"
+// @Param exclude_tag query string false "Leave out holdings with any of these comma-separated tags"
+// @Param view query int false "Only holdings passing this saved view, which must be over the same holding list"
 // @Success 200 {object} map[string]interface{} "List of other assets"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /other-assets [get]
 func (s *Server) getOtherAssets(c *gin.Context) {
-	categoryFilter := c.Query("category")
-	
-	query := `
-		SELECT ma.id, ma.asset_name, ma.current_value, ma.purchase_price, 
-		       ma.amount_owed, ma.purchase_date, ma.description, ma.custom_fields,
-		       ma.valuation_method, ma.last_valuation_date, ma.api_provider,
-		       ma.notes, ma.created_at, ma.last_updated,
-		       ac.name as category_name, ac.description as category_description,
-		       ac.icon as category_icon, ac.color as category_color,
-		       ma.asset_category_id
-		FROM miscellaneous_assets ma
-		LEFT JOIN asset_categories ac ON ma.asset_category_id = ac.id
-	`
-	
-	args := []interface{}{}
-	if categoryFilter != "" {
-		query += " WHERE ma.asset_category_id = $1"
-		categoryID, err := strconv.Atoi(categoryFilter)
+	var categoryID *int
+	if categoryFilter := c.Query("category"); categoryFilter != "" {
+		id, err := strconv.Atoi(categoryFilter)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Invalid category ID",
 			})
 			return
 		}
-		args = append(args, categoryID)
+		categoryID = &id
 	}
-	
-	query += " ORDER BY ma.last_updated DESC"
-	
-	rows, err := s.db.Query(query, args...)
+
+	assets, err := s.repos.OtherAssets.List(categoryID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch other assets",
 		})
 		return
 	}
-	defer rows.Close()
-	
-	var assets []map[string]interface{}
-	for rows.Next() {
-		var asset struct {
-			ID                    int             `json:"id"`
-			AssetName            string          `json:"asset_name"`
-			CurrentValue         float64         `json:"current_value"`
-			PurchasePrice        sql.NullFloat64 `json:"purchase_price"`
-			AmountOwed           sql.NullFloat64 `json:"amount_owed"`
-			PurchaseDate         sql.NullTime    `json:"purchase_date"`
-			Description          sql.NullString  `json:"description"`
-			CustomFields         sql.NullString  `json:"custom_fields"`
-			ValuationMethod      string          `json:"valuation_method"`
-			LastValuationDate    sql.NullTime    `json:"last_valuation_date"`
-			APIProvider          sql.NullString  `json:"api_provider"`
-			Notes                sql.NullString  `json:"notes"`
-			CreatedAt            time.Time       `json:"created_at"`
-			LastUpdated          time.Time       `json:"last_updated"`
-			CategoryName         sql.NullString  `json:"category_name"`
-			CategoryDescription  sql.NullString  `json:"category_description"`
-			CategoryIcon         sql.NullString  `json:"category_icon"`
-			CategoryColor        sql.NullString  `json:"category_color"`
-			AssetCategoryID      sql.NullInt64   `json:"asset_category_id"`
-		}
-		
-		err := rows.Scan(
-			&asset.ID, &asset.AssetName, &asset.CurrentValue, &asset.PurchasePrice,
-			&asset.AmountOwed, &asset.PurchaseDate, &asset.Description, &asset.CustomFields,
-			&asset.ValuationMethod, &asset.LastValuationDate, &asset.APIProvider,
-			&asset.Notes, &asset.CreatedAt, &asset.LastUpdated,
-			&asset.CategoryName, &asset.CategoryDescription, &asset.CategoryIcon,
-			&asset.CategoryColor, &asset.AssetCategoryID,
-		)
-		if err != nil {
-			continue
-		}
-		
-		// Calculate equity (value - amount owed)
-		var equity float64
-		if asset.AmountOwed.Valid {
-			equity = asset.CurrentValue - asset.AmountOwed.Float64
-		} else {
-			equity = asset.CurrentValue
-		}
-		
-		// Parse custom fields JSON
-		var customFields map[string]interface{}
-		if asset.CustomFields.Valid && asset.CustomFields.String != "" {
-			json.Unmarshal([]byte(asset.CustomFields.String), &customFields)
-		}
-		
-		assetMap := map[string]interface{}{
-			"id":                     asset.ID,
-			"asset_name":            asset.AssetName,
-			"current_value":         asset.CurrentValue,
-			"equity":                equity,
-			"valuation_method":      asset.ValuationMethod,
-			"created_at":            asset.CreatedAt,
-			"last_updated":          asset.LastUpdated,
-			"asset_category_id":     asset.AssetCategoryID.Int64,
-		}
-		
-		// Add optional fields
-		if asset.PurchasePrice.Valid {
-			assetMap["purchase_price"] = asset.PurchasePrice.Float64
-		}
-		if asset.AmountOwed.Valid {
-			assetMap["amount_owed"] = asset.AmountOwed.Float64
-		}
-		if asset.PurchaseDate.Valid {
-			assetMap["purchase_date"] = asset.PurchaseDate.Time.Format("2006-01-02")
-		}
-		if asset.Description.Valid {
-			assetMap["description"] = asset.Description.String
-		}
-		if asset.Notes.Valid {
-			assetMap["notes"] = asset.Notes.String
-		}
-		if asset.LastValuationDate.Valid {
-			assetMap["last_valuation_date"] = asset.LastValuationDate.Time
-		}
-		if asset.APIProvider.Valid {
-			assetMap["api_provider"] = asset.APIProvider.String
-		}
-		if customFields != nil {
-			assetMap["custom_fields"] = customFields
-		}
-		
-		// Add category information
-		if asset.CategoryName.Valid {
-			assetMap["category"] = map[string]interface{}{
-				"name":        asset.CategoryName.String,
-				"description": asset.CategoryDescription.String,
-				"icon":        asset.CategoryIcon.String,
-				"color":       asset.CategoryColor.String,
-			}
-		}
-		
-		assets = append(assets, assetMap)
+
+	assets, ok := filterHoldings(s, c, models.HoldingTypeOtherAsset, assets, func(h models.MiscellaneousAsset) int { return h.ID })
+	if !ok {
+		return
 	}
-	
+
 	// Calculate total value and equity
-	var totalValue, totalEquity float64
+	var totalValue, totalEquity decimal.Decimal
 	for _, asset := range assets {
-		totalValue += asset["current_value"].(float64)
-		totalEquity += asset["equity"].(float64)
+		totalValue = totalValue.Add(asset.CurrentValue)
+		totalEquity = totalEquity.Add(asset.Equity)
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"other_assets": assets,
 		"summary": gin.H{
-			"total_count": len(assets),
-			"total_value": totalValue,
+			"total_count":  len(assets),
+			"total_value":  totalValue,
 			"total_equity": totalEquity,
 		},
 	})
@@ -3701,15 +2625,14 @@ func (s *Server) getOtherAssets(c *gin.Context) {
 func (s *Server) createOtherAsset(c *gin.Context) {
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 	
 	// Use the other_assets plugin to process the entry
 	err := s.pluginManager.ProcessManualEntry("other_assets", data)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -3744,9 +2667,7 @@ func (s *Server) updateOtherAsset(c *gin.Context) {
 	
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
 	
@@ -3767,6 +2688,7 @@ func (s *Server) updateOtherAsset(c *gin.Context) {
 				"error": "Asset not found",
 			})
 		} else {
+			c.Error(err)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": err.Error(),
 			})
@@ -3798,31 +2720,12 @@ func (s *Server) deleteOtherAsset(c *gin.Context) {
 		})
 		return
 	}
-	
-	query := "DELETE FROM miscellaneous_assets WHERE id = $1"
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete asset",
-		})
-		return
-	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check deletion result",
-		})
-		return
-	}
-	
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Asset not found",
-		})
+
+	if err := s.repos.OtherAssets.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Asset not found", "Failed to delete asset")
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Other asset deleted successfully",
 	})
@@ -3840,56 +2743,16 @@ func (s *Server) deleteOtherAsset(c *gin.Context) {
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /asset-categories [get]
 func (s *Server) getAssetCategories(c *gin.Context) {
-	activeFilter := c.Query("active")
-	
-	query := `
-		SELECT id, name, description, icon, color, custom_schema, 
-		       valuation_api_config, is_active, sort_order, 
-		       created_at, updated_at
-		FROM asset_categories
-	`
-	
-	args := []interface{}{}
-	if activeFilter == "true" {
-		query += " WHERE is_active = true"
-	}
-	
-	query += " ORDER BY sort_order, name"
-	
-	rows, err := s.db.Query(query, args...)
+	records, err := s.repos.AssetCategories.List(c.Query("active") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch asset categories",
 		})
 		return
 	}
-	defer rows.Close()
-	
+
 	var categories []map[string]interface{}
-	for rows.Next() {
-		var category struct {
-			ID                   int            `json:"id"`
-			Name                 string         `json:"name"`
-			Description          sql.NullString `json:"description"`
-			Icon                 sql.NullString `json:"icon"`
-			Color                sql.NullString `json:"color"`
-			CustomSchema         sql.NullString `json:"custom_schema"`
-			ValuationAPIConfig   sql.NullString `json:"valuation_api_config"`
-			IsActive             bool           `json:"is_active"`
-			SortOrder            int            `json:"sort_order"`
-			CreatedAt            time.Time      `json:"created_at"`
-			UpdatedAt            time.Time      `json:"updated_at"`
-		}
-		
-		err := rows.Scan(
-			&category.ID, &category.Name, &category.Description, &category.Icon,
-			&category.Color, &category.CustomSchema, &category.ValuationAPIConfig,
-			&category.IsActive, &category.SortOrder, &category.CreatedAt, &category.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		
+	for _, category := range records {
 		categoryMap := map[string]interface{}{
 			"id":         category.ID,
 			"name":       category.Name,
@@ -3898,37 +2761,37 @@ func (s *Server) getAssetCategories(c *gin.Context) {
 			"created_at": category.CreatedAt,
 			"updated_at": category.UpdatedAt,
 		}
-		
+
 		// Add optional fields
-		if category.Description.Valid {
-			categoryMap["description"] = category.Description.String
+		if category.Description != nil {
+			categoryMap["description"] = *category.Description
 		}
-		if category.Icon.Valid {
-			categoryMap["icon"] = category.Icon.String
+		if category.Icon != nil {
+			categoryMap["icon"] = *category.Icon
 		}
-		if category.Color.Valid {
-			categoryMap["color"] = category.Color.String
+		if category.Color != nil {
+			categoryMap["color"] = *category.Color
 		}
-		
+
 		// Parse custom schema
-		if category.CustomSchema.Valid && category.CustomSchema.String != "" {
+		if category.CustomSchema != nil && *category.CustomSchema != "" {
 			var schema map[string]interface{}
-			if err := json.Unmarshal([]byte(category.CustomSchema.String), &schema); err == nil {
+			if err := json.Unmarshal([]byte(*category.CustomSchema), &schema); err == nil {
 				categoryMap["custom_schema"] = schema
 			}
 		}
-		
+
 		// Parse valuation API config
-		if category.ValuationAPIConfig.Valid && category.ValuationAPIConfig.String != "" {
+		if category.ValuationAPIConfig != nil && *category.ValuationAPIConfig != "" {
 			var config map[string]interface{}
-			if err := json.Unmarshal([]byte(category.ValuationAPIConfig.String), &config); err == nil {
+			if err := json.Unmarshal([]byte(*category.ValuationAPIConfig), &config); err == nil {
 				categoryMap["valuation_api_config"] = config
 			}
 		}
-		
+
 		categories = append(categories, categoryMap)
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"asset_categories": categories,
 		"total_count":      len(categories),
@@ -3948,12 +2811,10 @@ func (s *Server) getAssetCategories(c *gin.Context) {
 func (s *Server) createAssetCategory(c *gin.Context) {
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
+		respondBindingError(c, err)
 		return
 	}
-	
+
 	// Validate required fields
 	name, ok := data["name"].(string)
 	if !ok || strings.TrimSpace(name) == "" {
@@ -3962,69 +2823,74 @@ func (s *Server) createAssetCategory(c *gin.Context) {
 		})
 		return
 	}
-	
-	// Prepare optional fields
-	var description, icon, color sql.NullString
-	var customSchema, valuationAPIConfig sql.NullString
-	var isActive = true
-	var sortOrder = 0
-	
+
+	input, ok := s.bindAssetCategoryInput(c, data)
+	if !ok {
+		return
+	}
+	input.Name = &name
+
+	categoryID, err := s.repos.AssetCategories.Create(input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create asset category",
+		})
+		return
+	}
+
+	setAuditEntityID(c, categoryID)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Asset category created successfully",
+		"category_id": categoryID,
+	})
+}
+
+// bindAssetCategoryInput reads the optional fields of an asset category from
+// request data, validating the custom schema and valuation config. It
+// responds with 400 and returns false when either is invalid.
+func (s *Server) bindAssetCategoryInput(c *gin.Context, data map[string]interface{}) (models.AssetCategoryInput, bool) {
+	var input models.AssetCategoryInput
 	if desc, ok := data["description"].(string); ok {
-		description.String = desc
-		description.Valid = true
+		input.Description = &desc
 	}
-	if ic, ok := data["icon"].(string); ok {
-		icon.String = ic
-		icon.Valid = true
+	if icon, ok := data["icon"].(string); ok {
+		input.Icon = &icon
 	}
-	if col, ok := data["color"].(string); ok {
-		color.String = col
-		color.Valid = true
+	if color, ok := data["color"].(string); ok {
+		input.Color = &color
 	}
 	if active, ok := data["is_active"].(bool); ok {
-		isActive = active
+		input.IsActive = &active
 	}
 	if order, ok := data["sort_order"].(float64); ok {
-		sortOrder = int(order)
+		sortOrder := int(order)
+		input.SortOrder = &sortOrder
 	}
-	
-	// Handle custom schema
+
 	if schema, ok := data["custom_schema"]; ok {
+		if errs := plugins.ValidateCustomSchema(schema); len(errs) > 0 {
+			respondValidationErrors(c, "Invalid custom schema", errs)
+			return input, false
+		}
 		if schemaJSON, err := json.Marshal(schema); err == nil {
-			customSchema.String = string(schemaJSON)
-			customSchema.Valid = true
+			customSchema := string(schemaJSON)
+			input.CustomSchema = &customSchema
 		}
 	}
-	
-	// Handle valuation API config
+
 	if config, ok := data["valuation_api_config"]; ok {
+		if err := s.assetValuationService.ValidateConfig(config); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return input, false
+		}
 		if configJSON, err := json.Marshal(config); err == nil {
-			valuationAPIConfig.String = string(configJSON)
-			valuationAPIConfig.Valid = true
+			valuationAPIConfig := string(configJSON)
+			input.ValuationAPIConfig = &valuationAPIConfig
 		}
 	}
-	
-	query := `
-		INSERT INTO asset_categories (name, description, icon, color, custom_schema, 
-		                            valuation_api_config, is_active, sort_order)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id
-	`
-	
-	var categoryID int
-	err := s.db.QueryRow(query, name, description, icon, color, customSchema, 
-		valuationAPIConfig, isActive, sortOrder).Scan(&categoryID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create asset category",
-		})
-		return
-	}
-	
-	c.JSON(http.StatusCreated, gin.H{
-		"message":     "Asset category created successfully",
-		"category_id": categoryID,
-	})
+	return input, true
 }
 
 // @Summary Update asset category
@@ -4047,113 +2913,33 @@ func (s *Server) updateAssetCategory(c *gin.Context) {
 		})
 		return
 	}
-	
+
 	var data map[string]interface{}
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON data",
-		})
-		return
-	}
-	
-	// Build dynamic update query
-	var setParts []string
-	var args []interface{}
-	argIndex := 1
-	
-	if name, ok := data["name"].(string); ok && strings.TrimSpace(name) != "" {
-		setParts = append(setParts, fmt.Sprintf("name = $%d", argIndex))
-		args = append(args, strings.TrimSpace(name))
-		argIndex++
-	}
-	
-	if desc, ok := data["description"].(string); ok {
-		setParts = append(setParts, fmt.Sprintf("description = $%d", argIndex))
-		args = append(args, desc)
-		argIndex++
-	}
-	
-	if icon, ok := data["icon"].(string); ok {
-		setParts = append(setParts, fmt.Sprintf("icon = $%d", argIndex))
-		args = append(args, icon)
-		argIndex++
-	}
-	
-	if color, ok := data["color"].(string); ok {
-		setParts = append(setParts, fmt.Sprintf("color = $%d", argIndex))
-		args = append(args, color)
-		argIndex++
-	}
-	
-	if active, ok := data["is_active"].(bool); ok {
-		setParts = append(setParts, fmt.Sprintf("is_active = $%d", argIndex))
-		args = append(args, active)
-		argIndex++
-	}
-	
-	if order, ok := data["sort_order"].(float64); ok {
-		setParts = append(setParts, fmt.Sprintf("sort_order = $%d", argIndex))
-		args = append(args, int(order))
-		argIndex++
-	}
-	
-	if schema, ok := data["custom_schema"]; ok {
-		if schemaJSON, err := json.Marshal(schema); err == nil {
-			setParts = append(setParts, fmt.Sprintf("custom_schema = $%d", argIndex))
-			args = append(args, string(schemaJSON))
-			argIndex++
-		}
-	}
-	
-	if config, ok := data["valuation_api_config"]; ok {
-		if configJSON, err := json.Marshal(config); err == nil {
-			setParts = append(setParts, fmt.Sprintf("valuation_api_config = $%d", argIndex))
-			args = append(args, string(configJSON))
-			argIndex++
-		}
-	}
-	
-	if len(setParts) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No valid fields to update",
-		})
+		respondBindingError(c, err)
 		return
 	}
-	
-	// Add updated_at
-	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argIndex))
-	args = append(args, time.Now())
-	argIndex++
-	
-	// Add WHERE condition
-	args = append(args, id)
-	
-	query := fmt.Sprintf("UPDATE asset_categories SET %s WHERE id = $%d", 
-		strings.Join(setParts, ", "), argIndex)
-	
-	result, err := s.db.Exec(query, args...)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update asset category",
-		})
+
+	input, ok := s.bindAssetCategoryInput(c, data)
+	if !ok {
 		return
 	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check update result",
-		})
-		return
+	if name, ok := data["name"].(string); ok && strings.TrimSpace(name) != "" {
+		name = strings.TrimSpace(name)
+		input.Name = &name
 	}
-	
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Asset category not found",
-		})
+
+	if err := s.repos.AssetCategories.Update(id, input); err != nil {
+		if errors.Is(err, repository.ErrNoFieldsToUpdate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "No valid fields to update",
+			})
+			return
+		}
+		s.respondRepositoryError(c, err, "Asset category not found", "Failed to update asset category")
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Asset category updated successfully",
 	})
@@ -4178,50 +2964,20 @@ func (s *Server) deleteAssetCategory(c *gin.Context) {
 		})
 		return
 	}
-	
-	// Check if category is in use
-	var count int
-	countQuery := "SELECT COUNT(*) FROM miscellaneous_assets WHERE asset_category_id = $1"
-	err = s.db.QueryRow(countQuery, id).Scan(&count)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check category usage",
-		})
-		return
-	}
-	
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Cannot delete category: %d assets are using this category", count),
-		})
-		return
-	}
-	
-	// Delete category
-	query := "DELETE FROM asset_categories WHERE id = $1"
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete asset category",
-		})
-		return
-	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check deletion result",
-		})
-		return
-	}
-	
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Asset category not found",
-		})
+
+	// Categories still in use are kept
+	if err := s.repos.AssetCategories.Delete(id); err != nil {
+		var inUse *repository.CategoryInUseError
+		if errors.As(err, &inUse) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Cannot delete category: %v", inUse),
+			})
+			return
+		}
+		s.respondRepositoryError(c, err, "Asset category not found", "Failed to delete asset category")
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Asset category deleted successfully",
 	})
@@ -4245,41 +3001,29 @@ func (s *Server) getAssetCategorySchema(c *gin.Context) {
 		})
 		return
 	}
-	
-	var name, description sql.NullString
-	var customSchema sql.NullString
-	
-	query := "SELECT name, description, custom_schema FROM asset_categories WHERE id = $1"
-	err = s.db.QueryRow(query, id).Scan(&name, &description, &customSchema)
+
+	category, err := s.repos.AssetCategories.Get(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Asset category not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to fetch category schema",
-			})
-		}
+		s.respondRepositoryError(c, err, "Asset category not found", "Failed to fetch category schema")
 		return
 	}
-	
+
 	result := map[string]interface{}{
 		"category_id": id,
-		"name":        name.String,
+		"name":        category.Name,
 	}
-	
-	if description.Valid {
-		result["description"] = description.String
+
+	if category.Description != nil {
+		result["description"] = *category.Description
 	}
-	
-	if customSchema.Valid && customSchema.String != "" {
+
+	if category.CustomSchema != nil && *category.CustomSchema != "" {
 		var schema map[string]interface{}
-		if err := json.Unmarshal([]byte(customSchema.String), &schema); err == nil {
+		if err := json.Unmarshal([]byte(*category.CustomSchema), &schema); err == nil {
 			result["schema"] = schema
 		}
 	}
-	
+
 	c.JSON(http.StatusOK, result)
 }
 
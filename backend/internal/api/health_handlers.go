@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessDBTimeout bounds the database ping so a hung connection fails the probe
+const readinessDBTimeout = 2 * time.Second
+
+// @Summary Liveness probe
+// @Description Report that the process is running. Does not check dependencies, so a database outage does not restart the pod.
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Process is alive"
+// @Router /healthz [get]
+func (s *Server) livenessCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Readiness probe
+// @Description Report whether the server can take traffic: the database is reachable, plugins are initialized and the server is not shutting down
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Ready to serve traffic"
+// @Failure 503 {object} map[string]interface{} "Not ready, with the failing checks"
+// @Router /readyz [get]
+func (s *Server) readinessCheck(c *gin.Context) {
+	checks := gin.H{}
+	ready := true
+
+	if s.shuttingDown.Load() {
+		checks["server"] = "shutting down"
+		ready = false
+	} else {
+		checks["server"] = "ok"
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessDBTimeout)
+	defer cancel()
+	if err := s.db.PingContext(ctx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if s.pluginManager.Initialized() {
+		checks["plugins"] = "ok"
+	} else {
+		checks["plugins"] = "not initialized"
+		ready = false
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
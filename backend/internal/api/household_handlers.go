@@ -0,0 +1,346 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// memberNetWorth is one member's share of the household net worth
+type memberNetWorth struct {
+	Member              models.HouseholdMember     `json:"member"`
+	NetWorth            decimal.Decimal            `json:"net_worth"`
+	TotalAssets         decimal.Decimal            `json:"total_assets"`
+	UnvestedEquityValue decimal.Decimal            `json:"unvested_equity_value"`
+	Components          []models.NetWorthComponent `json:"components"`
+}
+
+// @Summary Get household
+// @Description Return the household name and its members
+// @Tags household
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Household with members"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household [get]
+func (s *Server) getHousehold(c *gin.Context) {
+	household, err := s.repos.Household.Get()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch household"})
+		return
+	}
+	c.JSON(http.StatusOK, household)
+}
+
+// @Summary Rename household
+// @Description Change the household name
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Household name: {\"name\": \"Smith Household\"}"
+// @Success 200 {object} map[string]interface{} "Household with members"
+// @Failure 400 {object} map[string]interface{} "Invalid name"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household [put]
+func (s *Server) updateHousehold(c *gin.Context) {
+	var request struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if err := s.repos.Household.Rename(name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename household"})
+		return
+	}
+	s.getHousehold(c)
+}
+
+// @Summary Add household member
+// @Description Add a person holdings can be attributed to, such as you or a spouse. Linking a user lets them ask for their own share with member=me.
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Member: {\"name\": \"Alex\", \"user_id\": 2}"
+// @Success 201 {object} map[string]interface{} "Created member"
+// @Failure 400 {object} map[string]interface{} "Invalid member or unknown user"
+// @Failure 409 {object} map[string]interface{} "Name or user already used"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household/members [post]
+func (s *Server) createHouseholdMember(c *gin.Context) {
+	input, ok := bindHouseholdMember(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.Household.CreateMember(*input)
+	if err != nil {
+		s.respondHouseholdError(c, err, "Failed to create household member")
+		return
+	}
+	setAuditEntityID(c, id)
+
+	member, err := s.repos.Household.GetMember(id)
+	if err != nil {
+		s.respondHouseholdError(c, err, "Failed to fetch household member")
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// @Summary Update household member
+// @Description Rename a member or change the user linked to them
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param id path int true "Member ID"
+// @Param request body map[string]interface{} true "Member: {\"name\": \"Alex\", \"user_id\": 2}"
+// @Success 200 {object} map[string]interface{} "Updated member"
+// @Failure 400 {object} map[string]interface{} "Invalid member or unknown user"
+// @Failure 404 {object} map[string]interface{} "Member not found"
+// @Failure 409 {object} map[string]interface{} "Name or user already used"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household/members/{id} [put]
+func (s *Server) updateHouseholdMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return
+	}
+	input, ok := bindHouseholdMember(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.Household.UpdateMember(id, *input); err != nil {
+		s.respondHouseholdError(c, err, "Failed to update household member")
+		return
+	}
+
+	member, err := s.repos.Household.GetMember(id)
+	if err != nil {
+		s.respondHouseholdError(c, err, "Failed to fetch household member")
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// @Summary Delete household member
+// @Description Remove a member. Holdings they owned a share of are split between the remaining members.
+// @Tags household
+// @Produce json
+// @Param id path int true "Member ID"
+// @Success 200 {object} map[string]interface{} "Member deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid member ID"
+// @Failure 404 {object} map[string]interface{} "Member not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household/members/{id} [delete]
+func (s *Server) deleteHouseholdMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return
+	}
+
+	if err := s.repos.Household.DeleteMember(id); err != nil {
+		s.respondHouseholdError(c, err, "Failed to delete household member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Household member deleted"})
+}
+
+// @Summary Get holding ownership
+// @Description Return the ownership percentages set for a holding and each member's effective share. Whatever is not assigned is split equally between all members, so a holding with no shares set is joint.
+// @Tags household
+// @Produce json
+// @Param type path string true "Holding type: stock_holding, equity_grant, real_estate, cash_holding, crypto_holding or other_asset"
+// @Param holding_id path int true "Holding ID"
+// @Success 200 {object} map[string]interface{} "Set and effective shares"
+// @Failure 400 {object} map[string]interface{} "Invalid holding"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household/ownership/{type}/{holding_id} [get]
+func (s *Server) getHoldingOwnership(c *gin.Context) {
+	ref, ok := holdingRefParam(c)
+	if !ok {
+		return
+	}
+
+	ownership, err := s.repos.Household.Ownership(ref)
+	if err != nil {
+		s.respondHouseholdError(c, err, "Failed to fetch ownership")
+		return
+	}
+	c.JSON(http.StatusOK, ownership)
+}
+
+// @Summary Set holding ownership
+// @Description Replace the ownership percentages of a holding, e.g. 100 for one member, or 50/50 for a joint account. Percentages may add up to less than 100; the rest is split equally between all members. An empty list makes the holding joint.
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param type path string true "Holding type: stock_holding, equity_grant, real_estate, cash_holding, crypto_holding or other_asset"
+// @Param holding_id path int true "Holding ID"
+// @Param request body map[string]interface{} true "Shares: {\"shares\": [{\"member_id\": 1, \"percentage\": 60}, {\"member_id\": 2, \"percentage\": 40}]}"
+// @Success 200 {object} map[string]interface{} "Set and effective shares"
+// @Failure 400 {object} map[string]interface{} "Invalid holding, unknown member or more than 100%"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /household/ownership/{type}/{holding_id} [put]
+func (s *Server) setHoldingOwnership(c *gin.Context) {
+	ref, ok := holdingRefParam(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Shares []models.OwnershipShare `json:"shares" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.repos.Household.SetOwnership(ref, request.Shares); err != nil {
+		s.respondHouseholdError(c, err, "Failed to save ownership")
+		return
+	}
+	s.getHoldingOwnership(c)
+}
+
+// @Summary Get household net worth
+// @Description Return the combined household net worth and each member's share of it, from their effective ownership of every holding
+// @Tags net-worth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Combined and per-member net worth"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /net-worth/household [get]
+func (s *Server) getHouseholdNetWorth(c *gin.Context) {
+	household, err := s.repos.Household.Get()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch household"})
+		return
+	}
+	combined, err := s.repos.NetWorth.Breakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+	breakdowns, err := s.repos.Household.MemberBreakdowns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split net worth between members"})
+		return
+	}
+
+	members := make([]memberNetWorth, 0, len(household.Members))
+	for _, member := range household.Members {
+		breakdown := breakdowns[member.ID]
+		members = append(members, memberNetWorth{
+			Member:              member,
+			NetWorth:            breakdown.NetWorth(),
+			TotalAssets:         breakdown.TotalAssets(),
+			UnvestedEquityValue: breakdown.UnvestedEquityValue,
+			Components:          breakdown.Components(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"household": household.Name,
+		"combined": gin.H{
+			"net_worth":             combined.NetWorth(),
+			"total_assets":          combined.TotalAssets(),
+			"unvested_equity_value": combined.UnvestedEquityValue,
+			"components":            combined.Components(),
+		},
+		"members": members,
+	})
+}
+
+// respondMemberNetWorth answers a net worth request for one member's share.
+// member is a member ID, or "me" for the member linked to the signed-in user.
+func (s *Server) respondMemberNetWorth(c *gin.Context, member string) {
+	var resolved *models.HouseholdMember
+	var err error
+	if member == "me" {
+		user := currentUser(c)
+		if user == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "member=me needs a signed-in user"})
+			return
+		}
+		resolved, err = s.repos.Household.MemberForUser(user.ID)
+	} else {
+		id, convErr := strconv.Atoi(member)
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "member must be a member ID or me"})
+			return
+		}
+		resolved, err = s.repos.Household.GetMember(id)
+	}
+	if err != nil {
+		s.respondHouseholdError(c, err, "Failed to fetch household member")
+		return
+	}
+
+	breakdowns, err := s.repos.Household.MemberBreakdowns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split net worth between members"})
+		return
+	}
+	summary, err := s.summarizeNetWorth(breakdowns[resolved.ID])
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+
+	c.Header("X-Household-Member", strconv.Itoa(resolved.ID))
+	c.JSON(http.StatusOK, summary)
+}
+
+// bindHouseholdMember binds and validates a member body
+func bindHouseholdMember(c *gin.Context) (*models.HouseholdMemberInput, bool) {
+	var input models.HouseholdMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, false
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return nil, false
+	}
+	return &input, true
+}
+
+// holdingRefParam reads the :type and :holding_id path parameters
+func holdingRefParam(c *gin.Context) (models.HoldingRef, bool) {
+	id, err := strconv.Atoi(c.Param("holding_id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holding ID"})
+		return models.HoldingRef{}, false
+	}
+	return models.HoldingRef{HoldingType: c.Param("type"), HoldingID: id}, true
+}
+
+// respondHouseholdError maps household repository errors to responses
+func (s *Server) respondHouseholdError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidHoldingType), errors.Is(err, repository.ErrUnknownHolding),
+		errors.Is(err, repository.ErrUnknownMember), errors.Is(err, repository.ErrUnknownUser),
+		errors.Is(err, repository.ErrOwnershipOverAllocated):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrDuplicateMember), errors.Is(err, repository.ErrUserAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.respondRepositoryError(c, err, "Household member not found", failureMsg)
+	}
+}
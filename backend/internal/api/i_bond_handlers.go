@@ -0,0 +1,256 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondIBondError maps I bond service errors to HTTP responses
+func respondIBondError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, services.ErrIBondNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "I bond not found"})
+	case errors.Is(err, services.ErrIBondRateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "I bond rate not found"})
+	case errors.Is(err, services.ErrInvalidIBondRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+	}
+}
+
+// @Summary Get I bonds
+// @Description List Series I savings bonds, oldest first, with their composite rate, current value and redemption value, plus totals. Redeeming in the first five years forfeits the last three months of interest, and bonds can't be redeemed in their first year.
+// @Tags i-bonds
+// @Produce json
+// @Success 200 {object} map[string]interface{} "I bonds with totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds [get]
+func (s *Server) getIBonds(c *gin.Context) {
+	portfolio, err := s.iBondService.List(time.Now())
+	if err != nil {
+		respondIBondError(c, err, "Failed to fetch I bonds")
+		return
+	}
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// @Summary Get I bond
+// @Description An I bond with its composite rate, next rate change, current value and redemption value
+// @Tags i-bonds
+// @Produce json
+// @Param id path int true "I bond ID"
+// @Success 200 {object} map[string]interface{} "I bond"
+// @Failure 400 {object} map[string]interface{} "Invalid I bond ID"
+// @Failure 404 {object} map[string]interface{} "I bond not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds/{id} [get]
+func (s *Server) getIBond(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid I bond ID"})
+		return
+	}
+
+	bond, err := s.iBondService.Get(id, time.Now())
+	if err != nil {
+		respondIBondError(c, err, "Failed to fetch I bond")
+		return
+	}
+	c.JSON(http.StatusOK, bond)
+}
+
+// @Summary Create I bond
+// @Description Add a Series I savings bond using the I bonds plugin. The issue date moves to the first of its month, and the fixed rate defaults to the one in the rate schedule for that date.
+// @Tags i-bonds
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "I bond: {\"institution_name\": \"TreasuryDirect\", \"issue_date\": \"2024-01-01\", \"purchase_amount\": 10000}"
+// @Success 201 {object} map[string]interface{} "I bond created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds [post]
+func (s *Server) createIBond(c *gin.Context) {
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("i_bonds")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "I bonds plugin not found"})
+		return
+	}
+	txPlugin, ok := plugin.(plugins.TxManualEntryPlugin)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Plugin does not support manual entry"})
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create I bond"})
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := txPlugin.CreateManualEntryTx(tx, requestData)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to create I bond: %v", err)})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create I bond"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "I bond created successfully",
+	})
+}
+
+// @Summary Update I bond
+// @Description Update an I bond using the I bonds plugin
+// @Tags i-bonds
+// @Accept json
+// @Produce json
+// @Param id path int true "I bond ID"
+// @Param request body map[string]interface{} true "Updated I bond details"
+// @Success 200 {object} map[string]interface{} "I bond updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 404 {object} map[string]interface{} "I bond not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds/{id} [put]
+func (s *Server) updateIBond(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid I bond ID"})
+		return
+	}
+
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("i_bonds")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "I bonds plugin not found"})
+		return
+	}
+
+	if err := plugin.UpdateManualEntry(id, requestData); err != nil {
+		if strings.Contains(err.Error(), "no I bond found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "I bond not found"})
+		} else {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to update I bond: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "I bond updated successfully"})
+}
+
+// @Summary Delete I bond
+// @Description Delete an I bond, for example once it has been cashed in
+// @Tags i-bonds
+// @Produce json
+// @Param id path int true "I bond ID"
+// @Success 200 {object} map[string]interface{} "I bond deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid I bond ID"
+// @Failure 404 {object} map[string]interface{} "I bond not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds/{id} [delete]
+func (s *Server) deleteIBond(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid I bond ID"})
+		return
+	}
+
+	if err := s.iBondService.Delete(id); err != nil {
+		respondIBondError(c, err, "Failed to delete I bond")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "I bond deleted successfully"})
+}
+
+// @Summary Get I bond rates
+// @Description The I bond rate schedule, newest first: the fixed rate for bonds issued in the six months from each May 1 and November 1, the semiannual inflation rate every bond earns for its periods starting then, and their composite rate
+// @Tags i-bonds
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Rate schedule"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds/rates [get]
+func (s *Server) getIBondRates(c *gin.Context) {
+	rates, err := s.iBondService.Rates()
+	if err != nil {
+		respondIBondError(c, err, "Failed to fetch I bond rates")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rates": rates,
+		"count": len(rates),
+	})
+}
+
+// @Summary Set I bond rate
+// @Description Record the rates Treasury announced for a May 1 or November 1, replacing any recorded for that date. Rates are percentages; the semiannual inflation rate can be negative.
+// @Tags i-bonds
+// @Accept json
+// @Produce json
+// @Param date path string true "Announcement date (YYYY-05-01 or YYYY-11-01)"
+// @Param request body map[string]interface{} true "Rates: {\"fixed_rate\": 1.1, \"inflation_rate\": 1.43}"
+// @Success 200 {object} map[string]interface{} "Rate with its composite rate"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid date"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds/rates/{date} [put]
+func (s *Server) setIBondRate(c *gin.Context) {
+	var input services.IBondRateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	rate, err := s.iBondService.SetRate(c.Param("date"), input)
+	if err != nil {
+		respondIBondError(c, err, "Failed to save I bond rate")
+		return
+	}
+	c.JSON(http.StatusOK, rate)
+}
+
+// @Summary Delete I bond rate
+// @Description Remove the rates recorded for a May 1 or November 1
+// @Tags i-bonds
+// @Produce json
+// @Param date path string true "Announcement date (YYYY-05-01 or YYYY-11-01)"
+// @Success 200 {object} map[string]interface{} "I bond rate deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid date"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "I bond rate not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /i-bonds/rates/{date} [delete]
+func (s *Server) deleteIBondRate(c *gin.Context) {
+	if err := s.iBondService.DeleteRate(c.Param("date")); err != nil {
+		respondIBondError(c, err, "Failed to delete I bond rate")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "I bond rate deleted successfully"})
+}
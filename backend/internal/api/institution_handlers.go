@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// @Summary Get institution summary
+// @Description Return each institution holding stocks, cash or crypto with its accounts, the value held there by asset type and when its data last changed, largest first. Holdings without an institution are grouped under "Unspecified".
+// @Tags institutions
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Institutions with accounts and totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /institutions [get]
+func (s *Server) getInstitutions(c *gin.Context) {
+	institutions, err := s.repos.NetWorth.Institutions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize institutions"})
+		return
+	}
+
+	var total decimal.Decimal
+	for _, institution := range institutions {
+		total = total.Add(institution.TotalValue)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"institutions": institutions,
+		"count":        len(institutions),
+		"total_value":  total,
+	})
+}
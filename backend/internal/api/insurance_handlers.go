@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondInsuranceError maps insurance service errors to HTTP responses
+func respondInsuranceError(c *gin.Context, err error, failureMsg string) {
+	if errors.Is(err, services.ErrInsurancePolicyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Insurance policy not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+}
+
+// @Summary Get insurance policies
+// @Description List insurance policies, soonest renewal first, with their annual premium, net cash value and days until renewal, plus coverage by policy type, total annual premiums, the net cash value counted in net worth and how many policies renew within their notice period
+// @Tags insurance
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Insurance policies with totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /insurance [get]
+func (s *Server) getInsurancePolicies(c *gin.Context) {
+	portfolio, err := s.insuranceService.List(time.Now())
+	if err != nil {
+		respondInsuranceError(c, err, "Failed to fetch insurance policies")
+		return
+	}
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// @Summary Get insurance policy
+// @Description An insurance policy with its annual premium, net cash value and days until renewal
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Insurance policy ID"
+// @Success 200 {object} map[string]interface{} "Insurance policy"
+// @Failure 400 {object} map[string]interface{} "Invalid insurance policy ID"
+// @Failure 404 {object} map[string]interface{} "Insurance policy not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /insurance/{id} [get]
+func (s *Server) getInsurancePolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid insurance policy ID"})
+		return
+	}
+
+	policy, err := s.insuranceService.Get(id, time.Now())
+	if err != nil {
+		respondInsuranceError(c, err, "Failed to fetch insurance policy")
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// @Summary Create insurance policy
+// @Description Add an insurance policy using the insurance plugin. Only whole, universal and variable life policies can have a cash value or policy loan; their cash value less loans counts toward net worth. premium_frequency is monthly, quarterly, semiannual or annual.
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Policy: {\"institution_name\": \"Northwestern Mutual\", \"policy_name\": \"Whole Life 65\", \"policy_type\": \"whole_life\", \"coverage_amount\": 500000, \"premium_amount\": 450, \"premium_frequency\": \"monthly\", \"cash_value\": 42000, \"renewal_date\": \"2026-03-01\", \"term_months\": 12}"
+// @Success 201 {object} map[string]interface{} "Insurance policy created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /insurance [post]
+func (s *Server) createInsurancePolicy(c *gin.Context) {
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("insurance")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Insurance plugin not found"})
+		return
+	}
+	txPlugin, ok := plugin.(plugins.TxManualEntryPlugin)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Plugin does not support manual entry"})
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create insurance policy"})
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := txPlugin.CreateManualEntryTx(tx, requestData)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to create insurance policy: %v", err)})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create insurance policy"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Insurance policy created successfully",
+	})
+}
+
+// @Summary Update insurance policy
+// @Description Update an insurance policy using the insurance plugin
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path int true "Insurance policy ID"
+// @Param request body map[string]interface{} true "Updated policy details"
+// @Success 200 {object} map[string]interface{} "Insurance policy updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 404 {object} map[string]interface{} "Insurance policy not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /insurance/{id} [put]
+func (s *Server) updateInsurancePolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid insurance policy ID"})
+		return
+	}
+
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("insurance")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Insurance plugin not found"})
+		return
+	}
+
+	if err := plugin.UpdateManualEntry(id, requestData); err != nil {
+		if strings.Contains(err.Error(), "no insurance policy found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Insurance policy not found"})
+		} else {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to update insurance policy: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Insurance policy updated successfully"})
+}
+
+// @Summary Delete insurance policy
+// @Description Delete an insurance policy, for example once it has lapsed or been surrendered
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Insurance policy ID"
+// @Success 200 {object} map[string]interface{} "Insurance policy deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid insurance policy ID"
+// @Failure 404 {object} map[string]interface{} "Insurance policy not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /insurance/{id} [delete]
+func (s *Server) deleteInsurancePolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid insurance policy ID"})
+		return
+	}
+
+	if err := s.insuranceService.Delete(id); err != nil {
+		respondInsuranceError(c, err, "Failed to delete insurance policy")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Insurance policy deleted successfully"})
+}
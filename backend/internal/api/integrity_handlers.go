@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get data integrity report
+// @Description The last data integrity check: equity grants with vested shares outside the grant, holdings without an account and negative balances. Runs a check first when none has run since the server started.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Integrity report"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/integrity [get]
+func (s *Server) getIntegrityReport(c *gin.Context) {
+	report := s.integrityService.LastReport()
+	if report == nil {
+		var err error
+		if report, err = s.integrityService.Check(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check data integrity"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Run data integrity check
+// @Description Check data integrity now rather than waiting for the nightly check
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Integrity report"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/integrity/check [post]
+func (s *Server) checkIntegrity(c *gin.Context) {
+	report, err := s.integrityService.Check(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check data integrity"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get intraday prices
+// @Description A symbol's 5-minute bars for the current or latest trading day, for sparkline charts. Bars are cached and fetched from the price provider at most once every 5 minutes per symbol while the market is open, counting against its rate limits. stale is set when the cache is older but the rate limit is spent.
+// @Tags stocks
+// @Produce json
+// @Param symbol path string true "Stock symbol"
+// @Success 200 {object} map[string]interface{} "Intraday bars with the day's open, last price and change"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded and no cached bars"
+// @Failure 503 {object} map[string]interface{} "Price provider has no intraday data"
+// @Failure 502 {object} map[string]interface{} "Price provider error"
+// @Router /stocks/{symbol}/intraday [get]
+func (s *Server) getIntradayPrices(c *gin.Context) {
+	series, err := s.intradayService.Series(c.Param("symbol"))
+	switch {
+	case errors.Is(err, services.ErrIntradayUnsupported):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIntradayRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch intraday prices: " + err.Error()})
+	default:
+		c.JSON(http.StatusOK, series)
+	}
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// respondLiabilityError maps liability repository errors to HTTP responses
+func (s *Server) respondLiabilityError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidReference):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrStatementReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.respondRepositoryError(c, err, notFoundMsg, failureMsg)
+	}
+}
+
+// bindLiability binds and validates a liability body
+func bindLiability(c *gin.Context) (*models.LiabilityInput, bool) {
+	var input models.LiabilityInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, false
+	}
+	input.InstitutionName = strings.TrimSpace(input.InstitutionName)
+	input.LiabilityName = strings.TrimSpace(input.LiabilityName)
+	return &input, true
+}
+
+// liabilityFromPath returns the liability named by the id path parameter
+func (s *Server) liabilityFromPath(c *gin.Context) (*models.Liability, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid liability ID"})
+		return nil, false
+	}
+	liability, err := s.repos.Liabilities.Get(id)
+	if err != nil {
+		s.respondLiabilityError(c, err, "Liability not found", "Failed to fetch liability")
+		return nil, false
+	}
+	return liability, true
+}
+
+// @Summary List liabilities
+// @Description Credit cards and loans, soonest due first, with their total balance and minimum payments. The total balance is total_liabilities in net worth; mortgages are netted into real estate equity instead.
+// @Tags liabilities
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Liabilities with totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities [get]
+func (s *Server) getLiabilities(c *gin.Context) {
+	liabilities, err := s.repos.Liabilities.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch liabilities"})
+		return
+	}
+
+	var totalBalance, totalMinimum decimal.Decimal
+	for _, l := range liabilities {
+		totalBalance = totalBalance.Add(l.CurrentBalance)
+		if l.MinimumPayment != nil {
+			totalMinimum = totalMinimum.Add(*l.MinimumPayment)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"liabilities":           liabilities,
+		"total_balance":         totalBalance,
+		"total_minimum_payment": totalMinimum,
+		"count":                 len(liabilities),
+	})
+}
+
+// @Summary Get liability
+// @Description Return a credit card or loan
+// @Tags liabilities
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Success 200 {object} models.Liability
+// @Failure 400 {object} map[string]interface{} "Invalid liability ID"
+// @Failure 404 {object} map[string]interface{} "Liability not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id} [get]
+func (s *Server) getLiability(c *gin.Context) {
+	liability, ok := s.liabilityFromPath(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, liability)
+}
+
+// @Summary Create liability
+// @Description Add a credit card or loan. Dates are YYYY-MM-DD and apr is an annual percentage.
+// @Tags liabilities
+// @Accept json
+// @Produce json
+// @Param request body models.LiabilityInput true "Liability"
+// @Success 201 {object} models.Liability
+// @Failure 400 {object} map[string]interface{} "Invalid liability or unknown account"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities [post]
+func (s *Server) createLiability(c *gin.Context) {
+	input, ok := bindLiability(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.Liabilities.Create(*input)
+	if err != nil {
+		s.respondLiabilityError(c, err, "Liability not found", "Failed to create liability")
+		return
+	}
+	setAuditEntityID(c, id)
+
+	liability, err := s.repos.Liabilities.Get(id)
+	if err != nil {
+		s.respondLiabilityError(c, err, "Liability not found", "Failed to fetch liability")
+		return
+	}
+	c.JSON(http.StatusCreated, liability)
+}
+
+// @Summary Update liability
+// @Description Replace the fields of a credit card or loan
+// @Tags liabilities
+// @Accept json
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Param request body models.LiabilityInput true "Liability"
+// @Success 200 {object} models.Liability
+// @Failure 400 {object} map[string]interface{} "Invalid liability or unknown account"
+// @Failure 404 {object} map[string]interface{} "Liability not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id} [put]
+func (s *Server) updateLiability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid liability ID"})
+		return
+	}
+	input, ok := bindLiability(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.Liabilities.Update(id, *input); err != nil {
+		s.respondLiabilityError(c, err, "Liability not found", "Failed to update liability")
+		return
+	}
+	s.getLiability(c)
+}
+
+// @Summary Delete liability
+// @Description Remove a credit card or loan with its imported statements
+// @Tags liabilities
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Success 200 {object} map[string]interface{} "Liability deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid liability ID"
+// @Failure 404 {object} map[string]interface{} "Liability not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id} [delete]
+func (s *Server) deleteLiability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid liability ID"})
+		return
+	}
+
+	if err := s.repos.Liabilities.Delete(id); err != nil {
+		s.respondLiabilityError(c, err, "Liability not found", "Failed to delete liability")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Liability deleted successfully"})
+}
+
+// @Summary List a liability's statements
+// @Description Statements imported for a credit card or loan, newest first, with the fields read from each and whether they were applied
+// @Tags liabilities
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Success 200 {object} map[string]interface{} "Statements"
+// @Failure 400 {object} map[string]interface{} "Invalid liability ID"
+// @Failure 404 {object} map[string]interface{} "Liability not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id}/statements [get]
+func (s *Server) getLiabilityStatements(c *gin.Context) {
+	liability, ok := s.liabilityFromPath(c)
+	if !ok {
+		return
+	}
+
+	statements, err := s.repos.Liabilities.ListStatements(liability.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statements"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"statements": statements,
+		"count":      len(statements),
+	})
+}
+
+// @Summary Import a liability statement
+// @Description Upload a credit card or loan statement, as a PDF or text file, to read its balance, APR, minimum payment, due date and statement date. The file is attached to the liability and the fields found are proposed for review; nothing changes until the statement is applied. document_type defaults to the liability's kind of statement.
+// @Tags liabilities
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Param file formData file true "Statement"
+// @Param document_type formData string false "credit_card_statement or loan_statement"
+// @Success 201 {object} models.LiabilityStatement
+// @Failure 400 {object} map[string]interface{} "Missing file, unsupported file or unknown document type"
+// @Failure 404 {object} map[string]interface{} "Liability not found"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 422 {object} map[string]interface{} "Nothing found in the statement"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id}/statements [post]
+func (s *Server) importLiabilityStatement(c *gin.Context) {
+	maxSize := s.attachmentService.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	liability, ok := s.liabilityFromPath(c)
+	if !ok {
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondAttachmentTooLarge(c, maxSize)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxSize {
+		respondAttachmentTooLarge(c, maxSize)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	documentType := c.PostForm("document_type")
+	if documentType == "" {
+		documentType = services.DocumentTypeFor(liability.LiabilityType)
+	}
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	fields, err := s.statementImportService.Read(documentType, contentType, data)
+	switch {
+	case errors.Is(err, services.ErrNothingExtracted):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The statement is kept as an attachment of the liability
+	size := int64(len(data))
+	key, err := s.attachmentService.Save(c.Request.Context(), bytes.NewReader(data), size, contentType)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to store statement file: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	fileName := filepath.Base(header.Filename)
+	attachmentID, err := s.repos.Attachments.Create(models.Attachment{
+		EntityType:  models.HoldingTypeLiability,
+		EntityID:    liability.ID,
+		Kind:        models.AttachmentKindFile,
+		FileName:    &fileName,
+		ContentType: &contentType,
+		SizeBytes:   &size,
+		StorageKey:  &key,
+		CreatedBy:   requestActor(c),
+	})
+	if err != nil {
+		s.attachmentService.Remove(c.Request.Context(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+
+	id, err := s.repos.Liabilities.CreateStatement(models.LiabilityStatement{
+		LiabilityID:    liability.ID,
+		AttachmentID:   &attachmentID,
+		DocumentType:   documentType,
+		Balance:        fields.Balance,
+		APR:            fields.APR,
+		MinimumPayment: fields.MinimumPayment,
+		DueDate:        fields.DueDate,
+		StatementDate:  fields.StatementDate,
+	})
+	if err != nil {
+		s.respondLiabilityError(c, err, "Liability not found", "Failed to record statement")
+		return
+	}
+
+	// Audited here rather than by middleware, which would buffer the whole upload
+	s.recordAudit(services.AuditActionCreate, "attachment", &attachmentID, requestActor(c), c.ClientIP(), nil, s.auditService.Snapshot("attachment", attachmentID))
+	statement, err := s.repos.Liabilities.GetStatement(id)
+	if err != nil {
+		s.respondLiabilityError(c, err, "Statement not found", "Failed to fetch statement")
+		return
+	}
+	c.JSON(http.StatusCreated, statement)
+}
+
+// @Summary Apply a liability statement
+// @Description Copy the fields read from a pending statement onto its liability. Fields the statement didn't show keep their current values.
+// @Tags liabilities
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Param statement_id path int true "Statement ID"
+// @Success 200 {object} models.Liability
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Statement not found"
+// @Failure 409 {object} map[string]interface{} "Statement already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id}/statements/{statement_id}/apply [post]
+func (s *Server) applyLiabilityStatement(c *gin.Context) {
+	statementID, ok := s.liabilityStatementFromPath(c)
+	if !ok {
+		return
+	}
+	if err := s.repos.Liabilities.ApplyStatement(statementID); err != nil {
+		s.respondLiabilityError(c, err, "Statement not found", "Failed to apply statement")
+		return
+	}
+	s.getLiability(c)
+}
+
+// @Summary Reject a liability statement
+// @Description Mark a pending statement rejected, leaving its liability unchanged
+// @Tags liabilities
+// @Produce json
+// @Param id path int true "Liability ID"
+// @Param statement_id path int true "Statement ID"
+// @Success 200 {object} map[string]interface{} "Statement rejected"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Statement not found"
+// @Failure 409 {object} map[string]interface{} "Statement already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /liabilities/{id}/statements/{statement_id}/reject [post]
+func (s *Server) rejectLiabilityStatement(c *gin.Context) {
+	statementID, ok := s.liabilityStatementFromPath(c)
+	if !ok {
+		return
+	}
+	if err := s.repos.Liabilities.RejectStatement(statementID); err != nil {
+		s.respondLiabilityError(c, err, "Statement not found", "Failed to reject statement")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Statement rejected"})
+}
+
+// liabilityStatementFromPath returns the statement_id path parameter,
+// responding 404 unless it names a statement of the liability named by id
+func (s *Server) liabilityStatementFromPath(c *gin.Context) (int, bool) {
+	liabilityID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid liability ID"})
+		return 0, false
+	}
+	statementID, err := strconv.Atoi(c.Param("statement_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement ID"})
+		return 0, false
+	}
+
+	statement, err := s.repos.Liabilities.GetStatement(statementID)
+	if err == nil && statement.LiabilityID != liabilityID {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		s.respondLiabilityError(c, err, "Statement not found", "Failed to fetch statement")
+		return 0, false
+	}
+	return statementID, true
+}
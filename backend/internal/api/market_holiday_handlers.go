@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get market holidays
+// @Description List the weekdays the stock market is closed, by date. The standard exchange calendar is filled in for this year and next; closures added by hand have source manual.
+// @Tags market
+// @Produce json
+// @Param year query int false "Only this year's holidays; defaults to the current year, 0 for every year"
+// @Success 200 {object} map[string]interface{} "Market holidays"
+// @Failure 400 {object} map[string]interface{} "Invalid year"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /market/holidays [get]
+func (s *Server) getMarketHolidays(c *gin.Context) {
+	year := time.Now().Year()
+	if param := c.Query("year"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := s.marketHolidayService.List(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch market holidays"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"holidays": holidays, "count": len(holidays)})
+}
+
+// @Summary Create market holiday
+// @Description Add a day the market is closed, such as an unscheduled closure, replacing any holiday on that date
+// @Tags market
+// @Accept json
+// @Produce json
+// @Param holiday body map[string]interface{} true "Holiday: {\"date\": \"2025-01-09\", \"name\": \"National Day of Mourning\"}"
+// @Success 201 {object} map[string]interface{} "Market holiday created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /market/holidays [post]
+func (s *Server) createMarketHoliday(c *gin.Context) {
+	var input services.MarketHolidayInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	id, err := s.marketHolidayService.Create(input)
+	if errors.Is(err, services.ErrInvalidMarketHoliday) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create market holiday"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Market holiday created successfully",
+	})
+}
+
+// @Summary Delete market holiday
+// @Description Mark a day as a trading day. A standard calendar holiday deleted this way is not added back.
+// @Tags market
+// @Produce json
+// @Param id path int true "Market holiday ID"
+// @Success 200 {object} map[string]interface{} "Market holiday deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid market holiday ID"
+// @Failure 404 {object} map[string]interface{} "Market holiday not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /market/holidays/{id} [delete]
+func (s *Server) deleteMarketHoliday(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid market holiday ID"})
+		return
+	}
+
+	err = s.marketHolidayService.Delete(id)
+	if errors.Is(err, services.ErrMarketHolidayNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Market holiday not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete market holiday"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Market holiday deleted successfully"})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondMergeError maps merge repository errors to HTTP responses
+func (s *Server) respondMergeError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidMerge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrMergeConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.respondRepositoryError(c, err, notFoundMsg, failureMsg)
+	}
+}
+
+// @Summary Merge duplicate holdings
+// @Description Fold a duplicate stock, cash or crypto holding into another of the same symbol (currency for cash) and delete it. Shares, balances and tokens are summed, cost basis and purchase price averaged by quantity, and the earliest purchase date kept. The duplicate's transactions, contributions, goals, tags and attachments move to the target, and both are recorded in the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Holdings to merge: {\"holding_type\": \"stock_holding\", \"target_id\": 1, \"source_id\": 2}"
+// @Success 200 {object} map[string]interface{} "Holdings merged successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or holdings cannot be merged"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Holding not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/merge/holdings [post]
+func (s *Server) mergeHoldings(c *gin.Context) {
+	var input models.MergeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if !repository.IsMergeableHoldingType(input.HoldingType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "holding_type must be stock_holding, cash_holding or crypto_holding"})
+		return
+	}
+
+	targetBefore := s.auditService.Snapshot(input.HoldingType, input.TargetID)
+	sourceBefore := s.auditService.Snapshot(input.HoldingType, input.SourceID)
+	if err := s.repos.Merges.MergeHoldings(input.HoldingType, input.TargetID, input.SourceID); err != nil {
+		s.respondMergeError(c, err, "Holding not found", "Failed to merge holdings")
+		return
+	}
+
+	// The target's changes are an update, and the source's last values a
+	// merge into the target
+	actor := requestActor(c)
+	targetAfter := s.auditService.Snapshot(input.HoldingType, input.TargetID)
+	s.recordAudit(services.AuditActionUpdate, input.HoldingType, &input.TargetID, actor, c.ClientIP(), targetBefore, targetAfter)
+	s.recordAudit(services.AuditActionMerge, input.HoldingType, &input.SourceID, actor, c.ClientIP(), sourceBefore,
+		map[string]interface{}{"merged_into": input.TargetID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Holdings merged successfully",
+		"target_id": input.TargetID,
+		"source_id": input.SourceID,
+	})
+}
+
+// @Summary Merge duplicate accounts
+// @Description Move every holding, balance and manual entry of a duplicate account to another account and delete it. Fails with 409 when both accounts hold the same holding; merge those holdings first.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Accounts to merge: {\"target_id\": 1, \"source_id\": 2}"
+// @Success 200 {object} map[string]interface{} "Accounts merged successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Account not found"
+// @Failure 409 {object} map[string]interface{} "Both accounts hold the same holding"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/merge/accounts [post]
+func (s *Server) mergeAccounts(c *gin.Context) {
+	var input models.MergeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	sourceBefore := s.auditService.Snapshot("account", input.SourceID)
+	if err := s.repos.Merges.MergeAccounts(input.TargetID, input.SourceID); err != nil {
+		s.respondMergeError(c, err, "Account not found", "Failed to merge accounts")
+		return
+	}
+	s.recordAudit(services.AuditActionMerge, "account", &input.SourceID, requestActor(c), c.ClientIP(), sourceBefore,
+		map[string]interface{}{"merged_into": input.TargetID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Accounts merged successfully",
+		"target_id": input.TargetID,
+		"source_id": input.SourceID,
+	})
+}
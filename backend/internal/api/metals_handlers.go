@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get precious metal spot prices
+// @Description Spot prices of gold, silver, platinum and palladium in USD per troy ounce. Stored prices are refetched once older than CRYPTO_CACHE_REFRESH_MINUTES; a stored price is returned with stale set when the provider fails.
+// @Tags metals
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Spot prices"
+// @Router /metals/prices [get]
+func (s *Server) getMetalPrices(c *gin.Context) {
+	prices, failures := s.metalsPriceService.GetSpotPrices(false)
+	response := gin.H{
+		"prices":   prices,
+		"provider": s.metalsPriceService.GetProviderName(),
+	}
+	if len(failures) > 0 {
+		response["errors"] = failures
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get a precious metal spot price
+// @Description Spot price of one metal in USD per troy ounce
+// @Tags metals
+// @Produce json
+// @Param metal path string true "Metal (gold, silver, platinum or palladium)"
+// @Success 200 {object} map[string]interface{} "Spot price"
+// @Failure 400 {object} map[string]interface{} "Unknown metal"
+// @Failure 502 {object} map[string]interface{} "Provider failed and no price is stored"
+// @Router /metals/prices/{metal} [get]
+func (s *Server) getMetalPrice(c *gin.Context) {
+	price, err := s.metalsPriceService.GetSpotPrice(c.Param("metal"), false)
+	switch {
+	case errors.Is(err, services.ErrUnknownMetal):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+// @Summary Refresh precious metal spot prices
+// @Description Fetch every metal's spot price now and revalue the API-valued other assets whose category uses the metal_spot provider
+// @Tags metals
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Refreshed prices and the number of assets revalued"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /metals/prices/refresh [post]
+func (s *Server) refreshMetalPrices(c *gin.Context) {
+	prices, failures := s.metalsPriceService.GetSpotPrices(true)
+
+	revalued, err := s.assetValuationService.RefreshProvider(c.Request.Context(), services.MetalSpotProvider)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revalue metal holdings"})
+		return
+	}
+
+	response := gin.H{
+		"message":         "Metal prices refreshed",
+		"prices":          prices,
+		"assets_revalued": revalued,
+	}
+	if len(failures) > 0 {
+		response["errors"] = failures
+	}
+	c.JSON(http.StatusOK, response)
+}
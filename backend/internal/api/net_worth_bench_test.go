@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/cache"
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/dbtest"
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// benchHoldings is how many holdings the breakdown benchmark values
+const benchHoldings = 200
+
+// breakdownBenchResult answers the holding values query with benchHoldings
+// holdings across the asset classes, and the pensions, bonds and I bonds
+// valued in Go with none
+func breakdownBenchResult(query string) dbtest.Result {
+	if !strings.Contains(query, "UNION ALL") {
+		return dbtest.Result{Columns: []string{"id"}}
+	}
+
+	components := []string{"stock_holdings", "vested_equity", "cash_holdings", "crypto_holdings", "real_estate", "other_assets"}
+	result := dbtest.Result{Columns: []string{"holding_type", "id", "component", "value",
+		"institution", "account_id", "account_name", "owner", "custodial", "name", "updated_at"}}
+	updated := time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC)
+	for i := range benchHoldings {
+		result.Rows = append(result.Rows, []driver.Value{
+			"stock_holding", int64(i + 1), components[i%len(components)], fmt.Sprintf("%d.25", 1000+i),
+			fmt.Sprintf("Institution %d", i%5), int64(i%10 + 1), fmt.Sprintf("Account %d", i%10), "self", false,
+			fmt.Sprintf("HOLD%d", i), updated,
+		})
+	}
+	return result
+}
+
+// BenchmarkNetWorthBreakdownEndpoint times GET /net-worth/breakdown with a
+// cold cache: the holding values behind the breakdown and the tree, and the
+// pensions, bonds and I bonds valued in Go, each a 200µs round trip
+func BenchmarkNetWorthBreakdownEndpoint(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(200*time.Microsecond, breakdownBenchResult)
+	defer db.Close()
+
+	s := &Server{
+		db:     db,
+		config: &config.Config{},
+		repos:  repository.New(db, nil),
+		cache:  cache.New(config.CacheConfig{}),
+	}
+	r := gin.New()
+	r.GET("/net-worth/breakdown", s.getNetWorthBreakdown)
+
+	b.ReportAllocs()
+	for range b.N {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/net-worth/breakdown", nil))
+		if w.Code != http.StatusOK {
+			b.Fatalf("breakdown = %d: %s", w.Code, w.Body)
+		}
+	}
+}
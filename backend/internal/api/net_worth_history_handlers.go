@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultNetWorthHistoryDays is the net worth history window when from is not given
+const defaultNetWorthHistoryDays = 365
+
+// @Summary Get net worth history
+// @Description Net worth snapshots over time, oldest first. Besides the scheduled daily snapshot, one is recorded whenever a change moves net worth by more than NET_WORTH_CHANGE_SNAPSHOT_THRESHOLD since the last snapshot; those have trigger "change" and name the request that caused them in trigger_event. With interval, each day or week (from Monday) is rolled up into its last net worth with the low and high.
+// @Tags net-worth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD, default 365 days before to)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, default today)"
+// @Param trigger query string false "Only snapshots recorded by this trigger: scheduled or change"
+// @Param interval query string false "Return daily or weekly rollups instead of snapshots: day or week"
+// @Success 200 {object} map[string]interface{} "Net worth snapshots, or rollups with interval"
+// @Failure 400 {object} map[string]interface{} "Invalid parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /net-worth/history [get]
+func (s *Server) getNetWorthHistory(c *gin.Context) {
+	from, to, ok := parseDateRange(c, defaultNetWorthHistoryDays)
+	if !ok {
+		return
+	}
+
+	trigger := c.Query("trigger")
+	if trigger != "" && trigger != services.SnapshotTriggerScheduled && trigger != services.SnapshotTriggerChange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "trigger must be scheduled or change"})
+		return
+	}
+
+	if interval := c.Query("interval"); interval != "" {
+		if trigger != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "trigger cannot be combined with interval"})
+			return
+		}
+		rollups, err := s.netWorthHistoryService.Rollups(from, to.AddDate(0, 0, 1), interval)
+		if errors.Is(err, services.ErrInvalidRollupInterval) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch net worth history"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"rollups":  rollups,
+			"count":    len(rollups),
+			"interval": interval,
+			"from":     from.Format("2006-01-02"),
+			"to":       to.Format("2006-01-02"),
+		})
+		return
+	}
+
+	snapshots, err := s.netWorthHistoryService.List(from, to.AddDate(0, 0, 1), trigger)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch net worth history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+	})
+}
+
+// snapshotOnChange records a net worth snapshot after a successful write that
+// moves net worth past the configured threshold. The snapshot is taken in the
+// background so the write's response is not held up.
+func (s *Server) snapshotOnChange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		threshold := s.config.History.Current().ChangeSnapshotThreshold
+		if threshold <= 0 {
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if status := c.Writer.Status(); status < 200 || status >= 300 {
+			return
+		}
+
+		event := fmt.Sprintf("%s %s by %s", c.Request.Method, c.Request.URL.Path, requestActor(c))
+		at := time.Now()
+		go func() {
+			breakdown, err := s.repos.NetWorth.Breakdown()
+			if err == nil {
+				_, err = s.netWorthHistoryService.RecordChange(breakdown, event, at, threshold)
+			}
+			if err != nil {
+				fmt.Printf("WARNING: Net worth change snapshot failed: %v\n", err)
+			}
+		}()
+	}
+}
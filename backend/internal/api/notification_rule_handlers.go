@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondNotificationRuleError maps notification rule errors to responses
+func respondNotificationRuleError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidNotificationRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDuplicateNotificationRule):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotificationRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification rule not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+	}
+}
+
+// @Summary Get notification channels
+// @Description List the configured delivery channels (email, telegram, discord) with the lowest severity each receives by default, and the notification types the backend creates
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Configured channels and notification types"
+// @Router /notifications/channels [get]
+func (s *Server) getNotificationChannels(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"channels":           s.notificationService.Channels(),
+		"notification_types": services.NotificationTypes,
+	})
+}
+
+// @Summary Get notification rules
+// @Description List per-type routing rules. A notification whose type has a rule goes to the rule's channels when it meets the rule's min_severity; other notifications go to every channel whose default threshold they meet.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "List of notification rules"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/rules [get]
+func (s *Server) getNotificationRules(c *gin.Context) {
+	rules, err := s.notificationService.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// @Summary Create notification rule
+// @Description Route one notification type to chosen channels. An empty channel list keeps the type in-app only.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param rule body map[string]interface{} true "Rule: {\"notification_type\": \"symbol_paused\", \"channels\": [\"telegram\"], \"min_severity\": \"info\"}"
+// @Success 201 {object} map[string]interface{} "Notification rule created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Notification type already has a rule"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/rules [post]
+func (s *Server) createNotificationRule(c *gin.Context) {
+	var input services.NotificationRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	id, err := s.notificationService.CreateRule(input)
+	if err != nil {
+		respondNotificationRuleError(c, err, "Failed to create notification rule")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Notification rule created successfully",
+	})
+}
+
+// @Summary Update notification rule
+// @Description Replace a rule's notification type, channels and minimum severity
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path int true "Notification rule ID"
+// @Param rule body map[string]interface{} true "Rule (notification_type, channels, min_severity)"
+// @Success 200 {object} map[string]interface{} "Notification rule updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Notification rule not found"
+// @Failure 409 {object} map[string]interface{} "Notification type already has a rule"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/rules/{id} [put]
+func (s *Server) updateNotificationRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification rule ID"})
+		return
+	}
+
+	var input services.NotificationRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.notificationService.UpdateRule(id, input); err != nil {
+		respondNotificationRuleError(c, err, "Failed to update notification rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification rule updated successfully",
+	})
+}
+
+// @Summary Delete notification rule
+// @Description Delete a rule, returning its notification type to the channels' default thresholds
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path int true "Notification rule ID"
+// @Success 200 {object} map[string]interface{} "Notification rule deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid notification rule ID"
+// @Failure 404 {object} map[string]interface{} "Notification rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/rules/{id} [delete]
+func (s *Server) deleteNotificationRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification rule ID"})
+		return
+	}
+
+	if err := s.notificationService.DeleteRule(id); err != nil {
+		respondNotificationRuleError(c, err, "Failed to delete notification rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification rule deleted successfully",
+	})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"strconv"
+
+	"networth-dashboard/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// List page size limits
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// pageRequest is the limit and offset a client asked for
+type pageRequest struct {
+	Limit  int
+	Offset int
+}
+
+// PageInfo describes where a page sits in the full list. NextOffset is set
+// while more items follow.
+type PageInfo struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	Total      int  `json:"total"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+// Page is a typed, paginated list response
+type Page[T any] struct {
+	Items      []T      `json:"items"`
+	Pagination PageInfo `json:"pagination"`
+}
+
+// parsePageRequest reads the limit and offset query parameters, reporting
+// invalid values as field errors
+func parsePageRequest(c *gin.Context) (pageRequest, bool) {
+	request := pageRequest{Limit: defaultPageLimit}
+	var fields []plugins.ValidationError
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			fields = append(fields, plugins.ValidationError{
+				Field:   "limit",
+				Message: "limit must be a whole number from 1 to " + strconv.Itoa(maxPageLimit),
+				Code:    "range",
+			})
+		}
+		request.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			fields = append(fields, plugins.ValidationError{
+				Field:   "offset",
+				Message: "offset must be a whole number of at least 0",
+				Code:    "range",
+			})
+		}
+		request.Offset = offset
+	}
+
+	if len(fields) > 0 {
+		respondValidationErrors(c, "Invalid pagination parameters", fields)
+		return pageRequest{}, false
+	}
+	return request, true
+}
+
+// paginate returns one page of a fully loaded list
+func paginate[T any](items []T, request pageRequest) Page[T] {
+	total := len(items)
+	start := min(request.Offset, total)
+	end := min(start+request.Limit, total)
+
+	page := Page[T]{
+		Items:      items[start:end],
+		Pagination: PageInfo{Limit: request.Limit, Offset: request.Offset, Total: total},
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if end < total {
+		page.Pagination.NextOffset = &end
+	}
+	return page
+}
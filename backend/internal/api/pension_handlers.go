@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondPensionError maps pension service errors to HTTP responses
+func respondPensionError(c *gin.Context, err error, failureMsg string) {
+	if errors.Is(err, services.ErrPensionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pension not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+}
+
+// @Summary Get pensions
+// @Description List defined-benefit pensions and annuities, soonest start first, with the present value of their remaining payments, plus the total present value, the part included in net worth and the monthly income of those being paid now
+// @Tags pensions
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Pensions with totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /pensions [get]
+func (s *Server) getPensions(c *gin.Context) {
+	portfolio, err := s.pensionService.List(time.Now())
+	if err != nil {
+		respondPensionError(c, err, "Failed to fetch pensions")
+		return
+	}
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// @Summary Get pension
+// @Description A pension or annuity with its start and end dates, current monthly benefit and present value
+// @Tags pensions
+// @Produce json
+// @Param id path int true "Pension ID"
+// @Success 200 {object} map[string]interface{} "Pension"
+// @Failure 400 {object} map[string]interface{} "Invalid pension ID"
+// @Failure 404 {object} map[string]interface{} "Pension not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /pensions/{id} [get]
+func (s *Server) getPension(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pension ID"})
+		return
+	}
+
+	pension, err := s.pensionService.Get(id, time.Now())
+	if err != nil {
+		respondPensionError(c, err, "Failed to fetch pension")
+		return
+	}
+	c.JSON(http.StatusOK, pension)
+}
+
+// @Summary Create pension
+// @Description Add a defined-benefit pension or annuity using the pensions plugin. It is left out of net worth unless include_in_net_worth is true.
+// @Tags pensions
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Pension: {\"institution_name\": \"State Teachers' Retirement System\", \"plan_name\": \"Defined Benefit Plan\", \"birth_date\": \"1975-06-15\", \"monthly_benefit\": 2500, \"start_age\": 65, \"end_age\": 90, \"cola_rate\": 2, \"discount_rate\": 4}"
+// @Success 201 {object} map[string]interface{} "Pension created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /pensions [post]
+func (s *Server) createPension(c *gin.Context) {
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("pensions")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Pensions plugin not found"})
+		return
+	}
+	txPlugin, ok := plugin.(plugins.TxManualEntryPlugin)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Plugin does not support manual entry"})
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pension"})
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := txPlugin.CreateManualEntryTx(tx, requestData)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to create pension: %v", err)})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pension"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Pension created successfully",
+	})
+}
+
+// @Summary Update pension
+// @Description Update a pension or annuity using the pensions plugin
+// @Tags pensions
+// @Accept json
+// @Produce json
+// @Param id path int true "Pension ID"
+// @Param request body map[string]interface{} true "Updated pension details"
+// @Success 200 {object} map[string]interface{} "Pension updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 404 {object} map[string]interface{} "Pension not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /pensions/{id} [put]
+func (s *Server) updatePension(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pension ID"})
+		return
+	}
+
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("pensions")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Pensions plugin not found"})
+		return
+	}
+
+	if err := plugin.UpdateManualEntry(id, requestData); err != nil {
+		if strings.Contains(err.Error(), "no pension found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pension not found"})
+		} else {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to update pension: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pension updated successfully"})
+}
+
+// @Summary Include pension in net worth
+// @Description Turn counting a pension's present value toward net worth on or off
+// @Tags pensions
+// @Accept json
+// @Produce json
+// @Param id path int true "Pension ID"
+// @Param request body map[string]interface{} true "{\"include_in_net_worth\": true}"
+// @Success 200 {object} map[string]interface{} "Pension updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Pension not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /pensions/{id}/net-worth [put]
+func (s *Server) setPensionIncluded(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pension ID"})
+		return
+	}
+
+	var request struct {
+		IncludeInNetWorth *bool `json:"include_in_net_worth" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.pensionService.SetIncluded(id, *request.IncludeInNetWorth); err != nil {
+		respondPensionError(c, err, "Failed to update pension")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Pension updated successfully",
+		"include_in_net_worth": *request.IncludeInNetWorth,
+	})
+}
+
+// @Summary Delete pension
+// @Description Delete a pension or annuity
+// @Tags pensions
+// @Produce json
+// @Param id path int true "Pension ID"
+// @Success 200 {object} map[string]interface{} "Pension deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid pension ID"
+// @Failure 404 {object} map[string]interface{} "Pension not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /pensions/{id} [delete]
+func (s *Server) deletePension(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pension ID"})
+		return
+	}
+
+	if err := s.pensionService.Delete(id); err != nil {
+		respondPensionError(c, err, "Failed to delete pension")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pension deleted successfully"})
+}
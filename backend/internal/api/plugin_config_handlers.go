@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"networth-dashboard/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pluginConfigRequest is the body of PUT /plugins/:name/config
+type pluginConfigRequest struct {
+	// Enabled and Schedule keep their current values when omitted
+	Enabled  *bool                  `json:"enabled"`
+	Settings map[string]interface{} `json:"settings"`
+	Schedule *string                `json:"schedule" binding:"omitempty,max=100"`
+}
+
+// @Summary Get plugin configuration
+// @Description Get a plugin's current configuration and the schema its settings are validated against
+// @Tags plugins
+// @Accept json
+// @Produce json
+// @Param name path string true "Plugin Name"
+// @Success 200 {object} map[string]interface{} "Plugin configuration and settings schema"
+// @Failure 404 {object} map[string]interface{} "Plugin not found"
+// @Router /plugins/{name}/config [get]
+func (s *Server) getPluginConfig(c *gin.Context) {
+	name := c.Param("name")
+
+	schema, err := s.pluginManager.GetConfigSchema(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Plugin not found",
+		})
+		return
+	}
+
+	config, err := s.pluginManager.GetPluginConfig(name)
+	if err != nil {
+		config = plugins.PluginConfig{Settings: map[string]interface{}{}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plugin":   name,
+		"enabled":  config.Enabled,
+		"settings": config.Settings,
+		"schedule": config.Schedule,
+		"schema":   schema,
+	})
+}
+
+// @Summary Update plugin configuration
+// @Description Validate, save and apply a plugin's configuration. Settings replace the current settings, with omitted ones reset to their defaults; the change takes effect immediately and is restored on restart.
+// @Tags plugins
+// @Accept json
+// @Produce json
+// @Param name path string true "Plugin Name"
+// @Param config body map[string]interface{} true "Configuration: settings, plus enabled and a cron schedule (both optional)"
+// @Success 200 {object} map[string]interface{} "Applied plugin configuration"
+// @Failure 400 {object} map[string]interface{} "Invalid settings"
+// @Failure 404 {object} map[string]interface{} "Plugin not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /plugins/{name}/config [put]
+func (s *Server) updatePluginConfig(c *gin.Context) {
+	name := c.Param("name")
+
+	current, err := s.pluginManager.GetPluginConfig(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Plugin not found",
+		})
+		return
+	}
+
+	var req pluginConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	config := plugins.PluginConfig{
+		Enabled:  current.Enabled,
+		Settings: req.Settings,
+		Schedule: current.Schedule,
+	}
+	if req.Enabled != nil {
+		config.Enabled = *req.Enabled
+	}
+	if req.Schedule != nil {
+		config.Schedule = *req.Schedule
+	}
+
+	result, err := s.pluginManager.SaveConfig(name, config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to save plugin config: %v", err),
+		})
+		return
+	}
+	if !result.Valid {
+		respondValidationErrors(c, "Invalid plugin settings", result.Errors)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Plugin configuration saved",
+		"plugin":   name,
+		"enabled":  config.Enabled,
+		"settings": result.Data,
+		"schedule": strings.TrimSpace(config.Schedule),
+	})
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/credentials"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Credential sources reported for a provider plugin
+const (
+	credentialSourceStore = "store"
+	credentialSourceEnv   = "env"
+	credentialSourceNone  = "none"
+)
+
+// providerPlugin is a data provider whose API key can be managed through the credential store
+type providerPlugin struct {
+	serviceType credentials.ServiceType
+	displayName string
+	envVar      string
+	apiKey      func(cfg *config.ApiConfig) *string
+}
+
+// providerPlugins lists the provider plugins with manageable API keys, by plugin name
+var providerPlugins = map[string]providerPlugin{
+	"twelvedata": {
+		serviceType: credentials.ServiceTypeTwelveData,
+		displayName: "Twelve Data",
+		envVar:      "TWELVE_DATA_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.TwelveDataAPIKey },
+	},
+	"alphavantage": {
+		serviceType: credentials.ServiceTypeAlphaVantage,
+		displayName: "Alpha Vantage",
+		envVar:      "ALPHA_VANTAGE_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.AlphaVantageAPIKey },
+	},
+	"coingecko": {
+		serviceType: credentials.ServiceTypeCoinGecko,
+		displayName: "CoinGecko",
+		envVar:      "COINGECKO_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.CoinGeckoAPIKey },
+	},
+	"coinmarketcap": {
+		serviceType: credentials.ServiceTypeCoinMarketCap,
+		displayName: "CoinMarketCap",
+		envVar:      "COINMARKETCAP_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.CoinMarketCapAPIKey },
+	},
+	"attomdata": {
+		serviceType: credentials.ServiceTypeAttomData,
+		displayName: "ATTOM Data",
+		envVar:      "ATTOM_DATA_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.AttomDataAPIKey },
+	},
+	"rentcast": {
+		serviceType: credentials.ServiceTypeRentcast,
+		displayName: "Rentcast",
+		envVar:      "RENTCAST_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.RentcastAPIKey },
+	},
+	"marketcheck": {
+		serviceType: credentials.ServiceTypeMarketCheck,
+		displayName: "MarketCheck",
+		envVar:      "MARKETCHECK_API_KEY",
+		apiKey:      func(cfg *config.ApiConfig) *string { return &cfg.MarketCheckAPIKey },
+	},
+}
+
+// errProviderTestUnsupported is returned for providers without a cheap live check
+var errProviderTestUnsupported = errors.New("live test not supported for this provider")
+
+// captureEnvProviderKeys records the API keys configured through the environment so
+// they can be restored when a stored credential is removed
+func captureEnvProviderKeys(cfg *config.ApiConfig) map[string]string {
+	keys := make(map[string]string, len(providerPlugins))
+	for name, plugin := range providerPlugins {
+		keys[name] = *plugin.apiKey(cfg)
+	}
+	return keys
+}
+
+// applyProviderCredentials sets each provider's API key in cfg, preferring the
+// credential store over the environment
+func applyProviderCredentials(manager *credentials.Manager, cfg *config.ApiConfig, envKeys map[string]string) {
+	for name, plugin := range providerPlugins {
+		key := envKeys[name]
+
+		stored, err := manager.GetAPIKey(plugin.serviceType)
+		switch {
+		case err == nil:
+			key = stored.Key
+		case !errors.Is(err, credentials.ErrCredentialNotFound):
+			log.Printf("WARNING: Failed to load stored %s credentials, using environment: %v", plugin.displayName, err)
+		}
+
+		*plugin.apiKey(cfg) = key
+	}
+}
+
+// reloadProviderCredentials re-applies stored credentials and rebuilds the providers that use them
+func (s *Server) reloadProviderCredentials() {
+	applyProviderCredentials(s.credentialManager, &s.config.API, s.envProviderKeys)
+
+	s.priceService.ReloadProviders(s.db, s.marketService, &s.config.API)
+	s.cryptoService.ReloadProvider()
+	s.propertyValuationService.SetAttomAPIKey(s.config.API.AttomDataAPIKey)
+	s.propertyValuationService.SetRentcastAPIKey(s.config.API.RentcastAPIKey)
+	s.assetValuationService.SetMarketCheckAPIKey(s.config.API.MarketCheckAPIKey)
+
+	log.Printf("INFO: Provider credentials reloaded (price: %s, crypto: %s)",
+		s.priceService.GetProviderName(), s.cryptoService.GetProviderName())
+}
+
+// testProviderKey makes a minimal live request to a provider with the given key
+func (s *Server) testProviderKey(name, key string) error {
+	switch name {
+	case "twelvedata":
+		_, err := services.NewTwelveDataPriceProvider(key, s.db, s.marketService, &s.config.API).GetCurrentPriceWithForce("AAPL", true)
+		return err
+	case "alphavantage":
+		_, err := services.NewAlphaVantagePriceProvider(key, s.db, s.marketService, &s.config.API).GetCurrentPriceWithForce("AAPL", true)
+		return err
+	case "coingecko", "coinmarketcap":
+		var provider services.CryptoPriceProvider
+		if name == "coingecko" {
+			provider = services.NewCoinGeckoPriceProvider(key, &s.config.API)
+		} else {
+			provider = services.NewCoinMarketCapPriceProvider(key, &s.config.API)
+		}
+		prices, err := provider.GetMultiplePrices([]string{"BTC"})
+		if err != nil {
+			return err
+		}
+		if _, ok := prices["BTC"]; !ok {
+			return fmt.Errorf("%s returned no price for BTC", provider.GetProviderName())
+		}
+		return nil
+	default:
+		return errProviderTestUnsupported
+	}
+}
+
+// lookupProviderPlugin resolves the :name path parameter, responding 404 if unknown
+func lookupProviderPlugin(c *gin.Context) (string, providerPlugin, bool) {
+	name := c.Param("name")
+	plugin, ok := providerPlugins[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("Plugin %s has no manageable credentials", name),
+		})
+	}
+	return name, plugin, ok
+}
+
+// maskKey returns a hint of a key showing only its last 4 characters
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
+
+// @Summary Get plugin credentials
+// @Description Show where a provider plugin's API key comes from (credential store, environment or none) without revealing it
+// @Tags plugins
+// @Accept json
+// @Produce json
+// @Param name path string true "Provider plugin name (twelvedata, alphavantage, coingecko, coinmarketcap, attomdata, rentcast, marketcheck)"
+// @Success 200 {object} map[string]interface{} "Credential status"
+// @Failure 404 {object} map[string]interface{} "Plugin has no manageable credentials"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /plugins/{name}/credentials [get]
+func (s *Server) getPluginCredentials(c *gin.Context) {
+	name, plugin, ok := lookupProviderPlugin(c)
+	if !ok {
+		return
+	}
+
+	response := gin.H{
+		"plugin":       name,
+		"display_name": plugin.displayName,
+		"env_var":      plugin.envVar,
+		"source":       credentialSourceNone,
+	}
+
+	cred, err := s.credentialManager.GetCredentialInfo(plugin.serviceType)
+	switch {
+	case err == nil:
+		response["source"] = credentialSourceStore
+		response["credential"] = cred
+	case !errors.Is(err, credentials.ErrCredentialNotFound):
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get credentials: %v", err),
+		})
+		return
+	case s.envProviderKeys[name] != "":
+		response["source"] = credentialSourceEnv
+	}
+
+	if key := *plugin.apiKey(&s.config.API); key != "" {
+		response["key_hint"] = maskKey(key)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Set or rotate plugin credentials
+// @Description Store a provider plugin's API key encrypted in the credential store, replacing any existing key. Takes effect immediately and overrides the environment variable.
+// @Tags plugins
+// @Accept json
+// @Produce json
+// @Param name path string true "Provider plugin name"
+// @Param request body map[string]interface{} true "API key: {\"key\": \"...\", \"secret\": \"...\", \"environment\": \"...\"}"
+// @Success 200 {object} map[string]interface{} "Credentials stored"
+// @Failure 400 {object} map[string]interface{} "Missing key"
+// @Failure 404 {object} map[string]interface{} "Plugin has no manageable credentials"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /plugins/{name}/credentials [put]
+func (s *Server) setPluginCredentials(c *gin.Context) {
+	name, plugin, ok := lookupProviderPlugin(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Key         string `json:"key" binding:"required"`
+		Secret      string `json:"secret"`
+		Environment string `json:"environment"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	cred, rotated, err := s.credentialManager.SetAPIKey(plugin.serviceType, plugin.displayName+" API key",
+		request.Key, request.Secret, request.Environment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to store credentials: %v", err),
+		})
+		return
+	}
+
+	s.reloadProviderCredentials()
+
+	message := fmt.Sprintf("%s API key stored", plugin.displayName)
+	if rotated {
+		message = fmt.Sprintf("%s API key rotated", plugin.displayName)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"plugin":     name,
+		"rotated":    rotated,
+		"credential": cred,
+		"key_hint":   maskKey(request.Key),
+	})
+}
+
+// @Summary Delete plugin credentials
+// @Description Remove a provider plugin's stored API key and fall back to its environment variable
+// @Tags plugins
+// @Accept json
+// @Produce json
+// @Param name path string true "Provider plugin name"
+// @Success 200 {object} map[string]interface{} "Credentials removed"
+// @Failure 404 {object} map[string]interface{} "Plugin has no manageable credentials or no stored key"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /plugins/{name}/credentials [delete]
+func (s *Server) deletePluginCredentials(c *gin.Context) {
+	name, plugin, ok := lookupProviderPlugin(c)
+	if !ok {
+		return
+	}
+
+	if _, err := s.credentialManager.GetCredentialInfo(plugin.serviceType); err != nil {
+		if errors.Is(err, credentials.ErrCredentialNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("No stored credentials for %s", name),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := s.credentialManager.DeleteCredential(plugin.serviceType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to delete credentials: %v", err),
+		})
+		return
+	}
+
+	s.reloadProviderCredentials()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%s stored API key removed", plugin.displayName),
+		"plugin":  name,
+	})
+}
+
+// @Summary Test plugin credentials
+// @Description Make a minimal live request to the provider with its current API key, or with a candidate key from the request body before storing it
+// @Tags plugins
+// @Accept json
+// @Produce json
+// @Param name path string true "Provider plugin name"
+// @Param request body map[string]interface{} false "Optional candidate key: {\"key\": \"...\"}"
+// @Success 200 {object} map[string]interface{} "Test result"
+// @Failure 400 {object} map[string]interface{} "No key configured"
+// @Failure 404 {object} map[string]interface{} "Plugin has no manageable credentials"
+// @Router /plugins/{name}/credentials/test [post]
+func (s *Server) testPluginCredentials(c *gin.Context) {
+	name, plugin, ok := lookupProviderPlugin(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Key string `json:"key"`
+	}
+	c.ShouldBindJSON(&request)
+
+	key := request.Key
+	if key == "" {
+		key = *plugin.apiKey(&s.config.API)
+	}
+	if key == "" && name != "coingecko" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("No API key configured for %s", plugin.displayName),
+		})
+		return
+	}
+
+	err := s.testProviderKey(name, key)
+	if errors.Is(err, errProviderTestUnsupported) {
+		c.JSON(http.StatusOK, gin.H{
+			"plugin":  name,
+			"success": true,
+			"tested":  false,
+			"message": fmt.Sprintf("%s key is configured; live test skipped to preserve API quota", plugin.displayName),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"plugin":  name,
+			"success": false,
+			"tested":  true,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plugin":  name,
+		"success": true,
+		"tested":  true,
+		"message": fmt.Sprintf("%s API key works", plugin.displayName),
+	})
+}
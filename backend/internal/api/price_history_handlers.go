@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPriceBackfillDays caps how far back a price history backfill reaches
+const maxPriceBackfillDays = 3650
+
+// @Summary Backfill stock price history
+// @Description Fetches daily closing prices from the price provider for the given symbols, or every held symbol, and records them in one batched write. Each symbol costs one provider call against its rate limits; symbols left when the limit runs out are returned as deferred. Days already recorded are skipped.
+// @Tags prices
+// @Produce json
+// @Param days query int false "Days of history to fetch (default 365, max 3650)"
+// @Param symbols query string false "Comma-separated symbols; defaults to every held symbol"
+// @Success 200 {object} map[string]interface{} "Closes fetched and inserted, with failed and deferred symbols"
+// @Failure 400 {object} map[string]interface{} "Invalid days"
+// @Failure 503 {object} map[string]interface{} "Price provider has no daily history"
+// @Failure 500 {object} map[string]interface{} "Failed to record prices"
+// @Router /prices/history/backfill [post]
+func (s *Server) backfillPriceHistory(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "365"))
+	if err != nil || days < 1 || days > maxPriceBackfillDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 3650"})
+		return
+	}
+
+	var symbols []string
+	for _, symbol := range strings.Split(c.Query("symbols"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		symbols = s.getAllActiveSymbols()
+	}
+
+	from := time.Now().AddDate(0, 0, -days)
+	result, err := s.priceHistoryService.Backfill(c.Request.Context(), symbols, from)
+	switch {
+	case errors.Is(err, services.ErrDailyHistoryUnsupported):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill price history"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"networth-dashboard/internal/services"
+)
+
+// RefreshStockPrices refreshes the price of every active stock symbol,
+// skipping symbols paused after repeated failures. With staleOnly, symbols
+// whose cached price is still fresh for the market hours are skipped too;
+// force bypasses the provider cache. Providers with batch quotes are asked
+// for every symbol in one request. With PRICE_REFRESH_PRIORITIZE on, symbols
+// go largest position first and those left when the provider rate limits are
+// deferred.
+func (s *Server) RefreshStockPrices(ctx context.Context, staleOnly, force bool) services.PriceRefreshSummary {
+	startTime := time.Now()
+
+	var pausedSymbols []string
+	paused := s.symbolHealthService.GetPausedSymbols(services.SymbolAssetTypeStock)
+	var symbols []string
+	for _, symbol := range s.getAllActiveSymbols() {
+		if paused[symbol] {
+			pausedSymbols = append(pausedSymbols, symbol)
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	// Quota goes to symbols whose cached price is stale unless every symbol is asked for
+	var skippedSymbols []string
+	if staleOnly {
+		symbols, skippedSymbols = s.staleStockSymbols(ctx, symbols)
+	}
+
+	summary := services.PriceRefreshSummary{
+		PausedSymbols:  pausedSymbols,
+		SkippedSymbols: skippedSymbols,
+	}
+	if len(symbols) == 0 {
+		summary.Timestamp = time.Now()
+		summary.DurationMs = time.Since(startTime).Milliseconds()
+		return summary
+	}
+
+	// When calls run short, the largest positions get them
+	prioritize := s.config.API.Current().PrioritizeRefreshByValue
+	if prioritize {
+		symbols = s.prioritizeByPositionValue(ctx, symbols)
+	}
+
+	// Providers with batch quotes price every symbol up front; the rest go one at a time
+	quotes, batched := s.priceService.GetBatchPricesContext(ctx, symbols, force)
+
+	for i, symbol := range symbols {
+		var result services.PriceUpdateResult
+		if batched {
+			quote := quotes[symbol]
+			result = s.applySymbolPrice(ctx, symbol, force, func() (float64, error) { return quote.Price, quote.Err })
+		} else {
+			result = s.updateSymbolPrice(ctx, symbol, s.priceService, force)
+		}
+		s.symbolHealthService.RecordResult(services.SymbolAssetTypeStock, symbol, result.Updated, result.ErrorType, result.Error)
+		summary.Results = append(summary.Results, result)
+
+		if result.Updated {
+			summary.UpdatedSymbols++
+		} else {
+			summary.FailedSymbols++
+		}
+
+		// The remaining, smaller positions wait for the next refresh rather than spending more calls
+		if prioritize && result.ErrorType == "rate_limited" {
+			summary.DeferredSymbols = symbols[i+1:]
+			break
+		}
+	}
+
+	// Refreshed prices change every cached aggregate; the GET route bypasses invalidateCache
+	if summary.UpdatedSymbols > 0 {
+		s.invalidateCache()
+	}
+
+	summary.TotalSymbols = len(summary.Results)
+	summary.ProviderName = s.determineActualProviderName(summary.Results, s.priceService.GetProviderName())
+	summary.Timestamp = time.Now()
+	summary.DurationMs = time.Since(startTime).Milliseconds()
+	return summary
+}
+
+// RefreshCryptoPrices refreshes the price of every held coin
+func (s *Server) RefreshCryptoPrices() (*services.CryptoPriceRefreshSummary, error) {
+	return s.cryptoService.RefreshAllCryptoPrices()
+}
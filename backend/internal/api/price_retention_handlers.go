@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get price retention status
+// @Description The raw price retention policy, the size and age of the raw and daily stock and crypto price tables, how many raw prices the next pruning run will fold into daily prices, and the last run since the server started
+// @Tags prices
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Retention policy, price tables and last run"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/retention [get]
+func (s *Server) getPriceRetention(c *gin.Context) {
+	status, err := s.priceRetentionService.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check price retention"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// AssetClassPriceStatus is how fresh the prices or valuations of one asset
+// class are. Ages are of the oldest item, so one stale holding shows.
+type AssetClassPriceStatus struct {
+	AssetClass      string `json:"asset_class"`
+	TotalCount      int    `json:"total_count"`
+	StaleCount      int    `json:"stale_count"`
+	LastUpdate      string `json:"last_update,omitempty"`
+	OldestUpdate    string `json:"oldest_update,omitempty"`
+	AgeMinutes      int    `json:"age_minutes"`
+	MaxAgeMinutes   int    `json:"max_age_minutes"`
+	RefreshEndpoint string `json:"refresh_endpoint"`
+	Recommendation  string `json:"recommendation,omitempty"`
+}
+
+// freshnessCheck is how to tell whether the items of an asset class are
+// fresh: when each was last priced or valued, nil when never, and how old that
+// can be
+type freshnessCheck struct {
+	assetClass      string
+	updateTimes     func() ([]*time.Time, error)
+	maxAge          time.Duration
+	refreshEndpoint string
+	noun            string
+}
+
+// assetClassFreshness lists the freshness checks of crypto, real estate and
+// other assets
+func (s *Server) assetClassFreshness() []freshnessCheck {
+	return []freshnessCheck{
+		{
+			assetClass:      "crypto",
+			updateTimes:     s.repos.Crypto.PriceUpdateTimes,
+			maxAge:          s.config.API.Current().CryptoCacheRefreshInterval,
+			refreshEndpoint: "POST /api/v1/crypto/prices/refresh",
+			noun:            "crypto symbols",
+		},
+		{
+			assetClass:      "real_estate",
+			updateTimes:     s.repos.RealEstate.ValuationUpdateTimes,
+			maxAge:          s.config.API.Current().PropertyValuationMaxAge,
+			refreshEndpoint: "POST /api/v1/real-estate/{id}/valuation/refresh",
+			noun:            "properties",
+		},
+		{
+			assetClass:      "other_assets",
+			updateTimes:     s.repos.OtherAssets.ValuationUpdateTimes,
+			maxAge:          s.config.API.Current().OtherAssetValuationMaxAge,
+			refreshEndpoint: "POST /api/v1/other-assets/{id}/valuation/refresh",
+			noun:            "other assets",
+		},
+	}
+}
+
+// freshness counts an asset class's items and those not updated within its
+// maximum age or never
+func (s *Server) freshness(check freshnessCheck, now time.Time) (AssetClassPriceStatus, error) {
+	status := AssetClassPriceStatus{
+		AssetClass:      check.assetClass,
+		MaxAgeMinutes:   int(check.maxAge.Minutes()),
+		RefreshEndpoint: check.refreshEndpoint,
+	}
+
+	updateTimes, err := check.updateTimes()
+	if err != nil {
+		return status, fmt.Errorf("failed to check %s freshness: %w", check.assetClass, err)
+	}
+
+	var last, oldest time.Time
+	for _, updated := range updateTimes {
+		status.TotalCount++
+		if updated == nil {
+			status.StaleCount++
+			continue
+		}
+		if now.Sub(*updated) > check.maxAge {
+			status.StaleCount++
+		}
+		if updated.After(last) {
+			last = *updated
+		}
+		if oldest.IsZero() || updated.Before(oldest) {
+			oldest = *updated
+		}
+	}
+
+	if !last.IsZero() {
+		status.LastUpdate = last.Format(time.RFC3339)
+		status.OldestUpdate = oldest.Format(time.RFC3339)
+		status.AgeMinutes = int(now.Sub(oldest).Minutes())
+	}
+	if status.StaleCount > 0 {
+		status.Recommendation = fmt.Sprintf("%d of %d %s not updated in %s; refresh with %s",
+			status.StaleCount, status.TotalCount, check.noun, formatMaxAge(check.maxAge), check.refreshEndpoint)
+	}
+	return status, nil
+}
+
+// formatMaxAge renders a maximum age in days once it is at least a day
+func formatMaxAge(age time.Duration) string {
+	if age >= 24*time.Hour {
+		return fmt.Sprintf("%d days", int(age.Hours()/24))
+	}
+	return age.String()
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondPrivateInvestmentError maps private investment service errors to HTTP responses
+func respondPrivateInvestmentError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPrivateInvestmentFlow), errors.Is(err, services.ErrInvalidNAVUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPrivateInvestmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Private investment not found"})
+	case errors.Is(err, services.ErrPrivateInvestmentFlowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Private investment flow not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+	}
+}
+
+// @Summary Get private investments
+// @Description List private investments, largest current value first, each with committed, called and uncalled capital, distributions, DPI, TVPI and IRR, and the portfolio totals. Current value is the last reported NAV plus capital called and less distributions paid since its date. IRR is annualized and treats the current value as received today; it is null until money has both been called and returned or valued.
+// @Tags private-investments
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Private investments with portfolio totals"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments [get]
+func (s *Server) getPrivateInvestments(c *gin.Context) {
+	portfolio, err := s.privateInvestmentService.List(time.Now())
+	if err != nil {
+		respondPrivateInvestmentError(c, err, "Failed to fetch private investments")
+		return
+	}
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// @Summary Get private investment
+// @Description A private investment with its returns, capital calls and distributions by date, and the NAVs reported for it, latest first
+// @Tags private-investments
+// @Produce json
+// @Param id path int true "Private investment ID"
+// @Success 200 {object} map[string]interface{} "Private investment"
+// @Failure 400 {object} map[string]interface{} "Invalid private investment ID"
+// @Failure 404 {object} map[string]interface{} "Private investment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments/{id} [get]
+func (s *Server) getPrivateInvestment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private investment ID"})
+		return
+	}
+
+	detail, err := s.privateInvestmentService.Get(id, time.Now())
+	if err != nil {
+		respondPrivateInvestmentError(c, err, "Failed to fetch private investment")
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// @Summary Create private investment
+// @Description Add an angel investment or fund commitment using the private investments plugin. The NAV given is recorded as its first reported NAV.
+// @Tags private-investments
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "Private investment: {\"sponsor\": \"Acme Ventures\", \"investment_name\": \"Fund III\", \"investment_type\": \"venture_capital\", \"committed_capital\": 250000, \"current_nav\": 0, \"nav_date\": \"2025-01-15\", \"vintage_year\": 2025}"
+// @Success 201 {object} map[string]interface{} "Private investment created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments [post]
+func (s *Server) createPrivateInvestment(c *gin.Context) {
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("private_investments")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Private investments plugin not found"})
+		return
+	}
+	txPlugin, ok := plugin.(plugins.TxManualEntryPlugin)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Plugin does not support manual entry"})
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create private investment"})
+		return
+	}
+	defer tx.Rollback()
+
+	id, err := txPlugin.CreateManualEntryTx(tx, requestData)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to create private investment: %v", err)})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create private investment"})
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Private investment created successfully",
+	})
+}
+
+// @Summary Update private investment
+// @Description Update a private investment using the private investments plugin. A changed NAV or NAV date is added to its NAV history.
+// @Tags private-investments
+// @Accept json
+// @Produce json
+// @Param id path int true "Private investment ID"
+// @Param request body map[string]interface{} true "Updated private investment details"
+// @Success 200 {object} map[string]interface{} "Private investment updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or invalid data"
+// @Failure 404 {object} map[string]interface{} "Private investment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments/{id} [put]
+func (s *Server) updatePrivateInvestment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private investment ID"})
+		return
+	}
+
+	var requestData map[string]interface{}
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	plugin, err := s.pluginManager.GetPlugin("private_investments")
+	if err != nil || plugin == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Private investments plugin not found"})
+		return
+	}
+
+	if err := plugin.UpdateManualEntry(id, requestData); err != nil {
+		if strings.Contains(err.Error(), "no private investment found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Private investment not found"})
+		} else {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to update private investment: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Private investment updated successfully"})
+}
+
+// @Summary Delete private investment
+// @Description Delete a private investment with its capital calls, distributions and NAV history
+// @Tags private-investments
+// @Produce json
+// @Param id path int true "Private investment ID"
+// @Success 200 {object} map[string]interface{} "Private investment deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid private investment ID"
+// @Failure 404 {object} map[string]interface{} "Private investment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments/{id} [delete]
+func (s *Server) deletePrivateInvestment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private investment ID"})
+		return
+	}
+
+	if err := s.privateInvestmentService.Delete(id); err != nil {
+		respondPrivateInvestmentError(c, err, "Failed to delete private investment")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Private investment deleted successfully"})
+}
+
+// @Summary Update private investment NAV
+// @Description Record a NAV reported by the sponsor, dated today unless nav_date is given. It becomes the current NAV unless a later one is already recorded, in which case it is only added to the history. Capital calls and distributions after the NAV date move the current value from it.
+// @Tags private-investments
+// @Accept json
+// @Produce json
+// @Param id path int true "Private investment ID"
+// @Param request body map[string]interface{} true "NAV: {\"nav\": 212500, \"nav_date\": \"2025-06-30\"}"
+// @Success 200 {object} map[string]interface{} "NAV recorded successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Private investment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments/{id}/nav [post]
+func (s *Server) updatePrivateInvestmentNAV(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private investment ID"})
+		return
+	}
+
+	var update services.NAVUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.privateInvestmentService.UpdateNAV(id, update, time.Now()); err != nil {
+		respondPrivateInvestmentError(c, err, "Failed to record NAV")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "NAV recorded successfully"})
+}
+
+// @Summary Create private investment flow
+// @Description Record a capital call paid in or a distribution received. Flows dated after the current NAV move the investment's current value; earlier ones are assumed to be reflected in the NAV.
+// @Tags private-investments
+// @Accept json
+// @Produce json
+// @Param flow body map[string]interface{} true "Flow: {\"private_investment_id\": 1, \"flow_type\": \"capital_call\", \"amount\": 25000, \"flow_date\": \"2025-03-14\"}"
+// @Success 201 {object} map[string]interface{} "Private investment flow created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Private investment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments/flows [post]
+func (s *Server) createPrivateInvestmentFlow(c *gin.Context) {
+	var input services.PrivateInvestmentFlowInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	id, err := s.privateInvestmentService.CreateFlow(input, time.Now())
+	if err != nil {
+		respondPrivateInvestmentError(c, err, "Failed to create private investment flow")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Private investment flow created successfully",
+	})
+}
+
+// @Summary Delete private investment flow
+// @Description Delete a capital call or distribution
+// @Tags private-investments
+// @Produce json
+// @Param id path int true "Private investment flow ID"
+// @Success 200 {object} map[string]interface{} "Private investment flow deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid private investment flow ID"
+// @Failure 404 {object} map[string]interface{} "Private investment flow not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /private-investments/flows/{id} [delete]
+func (s *Server) deletePrivateInvestmentFlow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private investment flow ID"})
+		return
+	}
+
+	if err := s.privateInvestmentService.DeleteFlow(id); err != nil {
+		respondPrivateInvestmentError(c, err, "Failed to delete private investment flow")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Private investment flow deleted successfully"})
+}
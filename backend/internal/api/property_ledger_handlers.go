@@ -0,0 +1,220 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindPropertyLedgerEntry binds and validates a ledger entry body, returning
+// its parsed date
+func bindPropertyLedgerEntry(c *gin.Context) (*models.PropertyLedgerEntryInput, time.Time, bool) {
+	var input models.PropertyLedgerEntryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, time.Time{}, false
+	}
+
+	input.Category = strings.ToLower(strings.TrimSpace(input.Category))
+	if _, ok := models.PropertyLedgerCategories[input.Category]; !ok {
+		categories := make([]string, 0, len(models.PropertyLedgerCategories))
+		for category := range models.PropertyLedgerCategories {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+		respondValidationErrors(c, "Validation failed", []plugins.ValidationError{{
+			Field:   "category",
+			Message: "category must be one of " + strings.Join(categories, ", "),
+			Code:    "oneof",
+		}})
+		return nil, time.Time{}, false
+	}
+
+	date, err := time.Parse("2006-01-02", input.EntryDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entry_date must be YYYY-MM-DD"})
+		return nil, time.Time{}, false
+	}
+
+	return &input, date, true
+}
+
+// propertyFromPath returns the property named by the id path parameter
+func (s *Server) propertyFromPath(c *gin.Context) (*models.RealEstate, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID"})
+		return nil, false
+	}
+
+	property, err := s.repos.RealEstate.Get(id)
+	if err != nil {
+		s.respondRepositoryError(c, err, "Property not found", "Failed to fetch property")
+		return nil, false
+	}
+	return property, true
+}
+
+// @Summary Get property ledger
+// @Description List a property's income and expense entries, newest first
+// @Tags real-estate
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param from query string false "Earliest entry date (YYYY-MM-DD, default 365 days before to)"
+// @Param to query string false "Latest entry date (YYYY-MM-DD, default today)"
+// @Success 200 {object} map[string]interface{} "Ledger entries"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Property not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/{id}/ledger [get]
+func (s *Server) getPropertyLedger(c *gin.Context) {
+	property, ok := s.propertyFromPath(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	entries, err := s.repos.PropertyLedger.List(property.ID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"entries":     entries,
+		"count":       len(entries),
+	})
+}
+
+// @Summary Create property ledger entry
+// @Description Record rent received or money spent on a property. Amounts are for the whole property. Categories: rent and other_income are income; repairs, maintenance, insurance, hoa, property_tax, utilities, management and other_expense are operating expenses; mortgage_payment is debt service; down_payment, closing_costs and capital_improvement are cash invested.
+// @Tags real-estate
+// @Accept json
+// @Produce json
+// @Param entry body map[string]interface{} true "Entry: {\"property_id\": 1, \"category\": \"rent\", \"amount\": 2400, \"entry_date\": \"2026-01-01\", \"description\": \"January rent\"}"
+// @Success 201 {object} map[string]interface{} "Entry created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or unknown property"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/ledger [post]
+func (s *Server) createPropertyLedgerEntry(c *gin.Context) {
+	input, date, ok := bindPropertyLedgerEntry(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.PropertyLedger.Create(*input, date)
+	if err != nil {
+		s.respondCashFlowError(c, err, "Ledger entry not found", "Failed to create ledger entry")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Ledger entry created successfully",
+	})
+}
+
+// @Summary Update property ledger entry
+// @Description Replace a ledger entry's fields
+// @Tags real-estate
+// @Accept json
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Param entry body map[string]interface{} true "Entry (property_id, category, amount, entry_date, description)"
+// @Success 200 {object} map[string]interface{} "Entry updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or unknown property"
+// @Failure 404 {object} map[string]interface{} "Ledger entry not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/ledger/{id} [put]
+func (s *Server) updatePropertyLedgerEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		return
+	}
+
+	input, date, ok := bindPropertyLedgerEntry(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.PropertyLedger.Update(id, *input, date); err != nil {
+		s.respondCashFlowError(c, err, "Ledger entry not found", "Failed to update ledger entry")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "Ledger entry updated successfully",
+	})
+}
+
+// @Summary Delete property ledger entry
+// @Description Delete a ledger entry
+// @Tags real-estate
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Success 200 {object} map[string]interface{} "Entry deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid entry ID"
+// @Failure 404 {object} map[string]interface{} "Ledger entry not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/ledger/{id} [delete]
+func (s *Server) deletePropertyLedgerEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		return
+	}
+
+	if err := s.repos.PropertyLedger.Delete(id); err != nil {
+		s.respondRepositoryError(c, err, "Ledger entry not found", "Failed to delete ledger entry")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "Ledger entry deleted successfully",
+	})
+}
+
+// @Summary Get property cash flow
+// @Description Summarize a property's ledger over a date range: income and operating expenses by category, net operating income (NOI), debt service and cash flow, per month and in total. Cap rate is annualized NOI over the current value. Cash-on-cash return is annualized cash flow over the cash invested (down payment, closing cost and capital improvement entries up to the end of the range); without those entries purchase price less outstanding mortgage is used and cash_invested_estimated is set. Amounts are for the whole property, and the owned_ totals are the owner's share of them by ownership_percentage.
+// @Tags real-estate
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param from query string false "Start date (YYYY-MM-DD, default 365 days before to)"
+// @Param to query string false "End date (YYYY-MM-DD, default today)"
+// @Success 200 {object} map[string]interface{} "Property cash flow"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Property not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/{id}/cashflow [get]
+func (s *Server) getPropertyCashFlow(c *gin.Context) {
+	property, ok := s.propertyFromPath(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	summary, err := s.repos.PropertyLedger.CashFlow(*property, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize property cash flow"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPropertyValuations bounds a property's valuation history listing
+const maxPropertyValuations = 500
+
+// stringValue returns the string a pointer points to, or "" for nil
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// @Summary Refresh a property's valuation
+// @Description Value a property at its address with every configured provider (ATTOM Data, Rentcast, HouseCanary) and store the consensus as its api_estimated_value, with each provider's value and confidence kept in its valuation history. The manually entered current_value is not changed.
+// @Tags real-estate
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {object} map[string]interface{} "Consensus valuation with per-provider values"
+// @Failure 400 {object} map[string]interface{} "Invalid property ID or no address"
+// @Failure 404 {object} map[string]interface{} "Property not found"
+// @Failure 502 {object} map[string]interface{} "Every provider failed"
+// @Failure 503 {object} map[string]interface{} "Property valuation disabled or no provider configured"
+// @Router /real-estate/{id}/valuation/refresh [post]
+func (s *Server) refreshRealEstateValuation(c *gin.Context) {
+	if !s.propertyValuationService.IsPropertyValuationEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Property valuation feature is currently disabled"})
+		return
+	}
+	if !s.propertyValuationService.HasAvailableProvider() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No property valuation provider is configured"})
+		return
+	}
+
+	property, ok := s.propertyFromPath(c)
+	if !ok {
+		return
+	}
+	if stringValue(property.StreetAddress) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Property has no street address to value"})
+		return
+	}
+
+	valuation, err := s.propertyValuationService.GetPropertyValuation(stringValue(property.StreetAddress),
+		stringValue(property.City), stringValue(property.State), stringValue(property.ZipCode))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to value property: %v", err)})
+		return
+	}
+
+	var providers []models.PropertyValuationRecord
+	for _, p := range valuation.Providers {
+		if p.EstimatedValue != nil {
+			providers = append(providers, models.PropertyValuationRecord{
+				Provider:        p.Provider,
+				EstimatedValue:  *p.EstimatedValue,
+				ConfidenceScore: p.ConfidenceScore,
+			})
+		}
+	}
+	consensus := models.PropertyValuationRecord{EstimatedValue: valuation.EstimatedValue, ConfidenceScore: valuation.ConfidenceScore}
+	if err := s.repos.RealEstate.RecordValuation(property.ID, valuation.Source, consensus, providers); err != nil {
+		s.respondRepositoryError(c, err, "Property not found", "Failed to store property valuation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Property valuation refreshed successfully",
+		"property_id": property.ID,
+		"valuation":   valuation,
+	})
+}
+
+// @Summary Get a property's valuation history
+// @Description Stored valuations of a property, newest first: one row per provider per refresh, plus the consensus (provider "consensus")
+// @Tags real-estate
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param limit query int false "Maximum valuations to return (default 100, max 500)"
+// @Success 200 {object} map[string]interface{} "Valuations"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Property not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /real-estate/{id}/valuations [get]
+func (s *Server) getRealEstateValuations(c *gin.Context) {
+	property, ok := s.propertyFromPath(c)
+	if !ok {
+		return
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPropertyValuations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPropertyValuations)})
+			return
+		}
+		limit = parsed
+	}
+
+	valuations, err := s.repos.RealEstate.ListValuations(property.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch property valuations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": property.ID,
+		"valuations":  valuations,
+		"count":       len(valuations),
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get a monthly report
+// @Description Monthly statement with the change in net worth, the symbols with the largest gains and losses, equity vesting events and allocation drift between the start and end of the month. Net worth comes from the daily net worth snapshots; a month in progress closes with current values.
+// @Tags reports
+// @Produce html,json,application/pdf
+// @Param month path string true "Month (YYYY-MM)"
+// @Param format query string false "html, pdf or json (default html)"
+// @Success 200 {object} map[string]interface{} "Monthly report"
+// @Failure 400 {object} map[string]interface{} "Invalid month or format"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /reports/monthly/{month} [get]
+func (s *Server) getMonthlyReport(c *gin.Context) {
+	now := time.Now()
+	month, err := services.ParseReportMonth(c.Param("month"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "pdf" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html, pdf or json"})
+		return
+	}
+
+	report, err := s.reportService.Monthly(month, now, s.repos.NetWorth.Breakdown)
+	if errors.Is(err, services.ErrInvalidReportMonth) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report"})
+		return
+	}
+
+	switch format {
+	case "json":
+		c.JSON(http.StatusOK, report)
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="networth-report-%s.pdf"`, report.Month))
+		c.Data(http.StatusOK, "application/pdf", services.RenderMonthlyReportPDF(report))
+	default:
+		page, err := services.RenderMonthlyReportHTML(report)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render report"})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
+
+// @Summary Get a capital gains report
+// @Description Realized gains of a tax year, sale by sale, split into short-term and long-term (held more than a year) with the tax they'd owe at the configured capital gains rates. Crypto sales are matched to purchases first in, first out.
+// @Tags reports
+// @Produce json
+// @Param year query int false "Tax year (YYYY); defaults to the current year"
+// @Success 200 {object} map[string]interface{} "Capital gains report"
+// @Failure 400 {object} map[string]interface{} "Invalid year"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /reports/capital-gains [get]
+func (s *Server) getCapitalGainsReport(c *gin.Context) {
+	year, err := services.ParseTaxYear(c.Query("year"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to := services.TaxYearBounds(year)
+	sales, warnings, err := s.cryptoLotService.RealizedGains(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate realized gains"})
+		return
+	}
+	c.JSON(http.StatusOK, services.NewCapitalGainsReport(year, sales, warnings,
+		s.config.Tax.Current().ShortTermCapitalGainsPercent, s.config.Tax.Current().LongTermCapitalGainsPercent))
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiVersionHeader names the API version that served a response
+const apiVersionHeader = "X-API-Version"
+
+// API version statuses
+const (
+	apiStatusStable  = "stable"
+	apiStatusPreview = "preview"
+)
+
+// apiVersion is one API surface, mounted at /api/<name>. Versions share the
+// error envelope, access control and cache invalidation but register their
+// own routes, so a new version can be filled in endpoint by endpoint while
+// older ones stay as they are.
+type apiVersion struct {
+	name     string
+	status   string
+	register func(api *gin.RouterGroup)
+}
+
+// apiVersions lists the mounted API versions, oldest first
+func (s *Server) apiVersions() []apiVersion {
+	return []apiVersion{
+		{name: "v1", status: apiStatusStable, register: s.registerV1Routes},
+		{name: "v2", status: apiStatusPreview, register: s.registerV2Routes},
+	}
+}
+
+// mountAPIVersions registers every API version and the /api index listing them
+func (s *Server) mountAPIVersions() {
+	versions := s.apiVersions()
+	for _, version := range versions {
+		prefix := "/api/" + version.name
+		group := s.router.Group(prefix)
+		group.Use(versionHeader(version.name))
+		group.Use(errorEnvelope())
+		group.Use(s.authenticate(prefix))
+		group.Use(s.authorizeByMethod(prefix))
+		group.Use(s.invalidateCacheOnWrite())
+		group.Use(s.snapshotOnChange())
+		version.register(group)
+	}
+
+	s.router.GET("/api", func(c *gin.Context) {
+		index := make([]gin.H, 0, len(versions))
+		for _, version := range versions {
+			index = append(index, gin.H{
+				"version": version.name,
+				"status":  version.status,
+				"base":    "/api/" + version.name,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"versions": index})
+	})
+}
+
+// versionHeader tags responses with the API version that served them
+func versionHeader(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(apiVersionHeader, name)
+		c.Next()
+	}
+}
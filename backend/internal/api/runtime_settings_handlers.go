@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondRuntimeSettingError maps runtime settings errors to responses
+func respondRuntimeSettingError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, services.ErrNotRuntimeSetting):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Setting not found or cannot be changed at runtime"})
+	case errors.Is(err, services.ErrInvalidSettingValue):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		fmt.Printf("WARNING: %s: %v\n", failure, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
+
+// settingValue formats a JSON value as a setting's text form, so numbers and
+// booleans may be sent unquoted
+func settingValue(value interface{}) string {
+	switch value := value.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = settingValue(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// @Summary List runtime settings
+// @Description Settings that can change without a restart, such as price cache refresh intervals, provider daily and per-minute limits and how long valuations count as current, with each one's current value and whether it comes from the settings API, the environment, the configuration file or the default
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Runtime settings"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/settings [get]
+func (s *Server) getRuntimeSettings(c *gin.Context) {
+	settings, err := s.runtimeSettingsService.List()
+	if err != nil {
+		respondRuntimeSettingError(c, err, "Failed to list runtime settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings, "count": len(settings)})
+}
+
+// @Summary Change a runtime setting
+// @Description Store a new value for a runtime setting and apply it immediately. The value is validated as it would be in the environment and overrides the environment and configuration file until reset.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Setting name, e.g. CACHE_REFRESH_MINUTES"
+// @Param setting body map[string]interface{} true "New value: {\"value\": 30}"
+// @Success 200 {object} map[string]interface{} "Setting with its new value"
+// @Failure 400 {object} map[string]interface{} "Invalid value"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Not a runtime setting"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/settings/{name} [put]
+func (s *Server) updateRuntimeSetting(c *gin.Context) {
+	var request struct {
+		Value interface{} `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	setting, err := s.runtimeSettingsService.Set(c.Param("name"), settingValue(request.Value), requestActor(c))
+	if err != nil {
+		respondRuntimeSettingError(c, err, "Failed to update runtime setting")
+		return
+	}
+	s.invalidateCache()
+	c.JSON(http.StatusOK, setting)
+}
+
+// @Summary Reset a runtime setting
+// @Description Remove a runtime setting's stored value, so the environment, configuration file or default applies again
+// @Tags admin
+// @Produce json
+// @Param name path string true "Setting name"
+// @Success 200 {object} map[string]interface{} "Setting with the value now in effect"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Not a runtime setting"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/settings/{name} [delete]
+func (s *Server) resetRuntimeSetting(c *gin.Context) {
+	setting, err := s.runtimeSettingsService.Reset(c.Param("name"))
+	if err != nil {
+		respondRuntimeSettingError(c, err, "Failed to reset runtime setting")
+		return
+	}
+	s.invalidateCache()
+	c.JSON(http.StatusOK, setting)
+}
+
+// ReloadConfig re-reads the environment, configuration file and runtime
+// settings, applying the settings that can change while the server runs and
+// keeping the current configuration if the new one is invalid
+func (s *Server) ReloadConfig() {
+	applied, restartRequired, err := s.runtimeSettingsService.Reload()
+	if err != nil {
+		log.Printf("WARNING: Not reloading configuration: %v", err)
+		return
+	}
+	if len(applied) > 0 {
+		log.Printf("INFO: Reloaded configuration: %s", strings.Join(applied, ", "))
+	} else {
+		log.Printf("INFO: Reloaded configuration, no changes to apply")
+	}
+	if len(restartRequired) > 0 {
+		log.Printf("WARNING: These changed settings take effect after a restart: %s", strings.Join(restartRequired, ", "))
+	}
+}
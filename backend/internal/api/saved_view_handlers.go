@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Holding types whose views may filter by symbol or by sector
+var (
+	symbolViewTypes = map[string]bool{
+		models.HoldingTypeStock:       true,
+		models.HoldingTypeEquityGrant: true,
+		models.HoldingTypeCrypto:      true,
+	}
+	sectorViewTypes = map[string]bool{
+		models.HoldingTypeStock:       true,
+		models.HoldingTypeEquityGrant: true,
+	}
+)
+
+// savedViewMatches returns the IDs of the holdings passing the saved view
+// named by the view query parameter, or nil when there is none. It responds
+// with an error when the view does not exist or is over another holding list.
+func (s *Server) savedViewMatches(c *gin.Context, holdingType string) (map[int]bool, bool) {
+	value := c.Query("view")
+	if value == "" {
+		return nil, true
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be a saved view ID"})
+		return nil, false
+	}
+	view, err := s.repos.SavedViews.Get(id)
+	if err != nil {
+		s.respondRepositoryError(c, err, "Saved view not found", "Failed to fetch saved view")
+		return nil, false
+	}
+	if view.HoldingType != holdingType {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Saved view %d filters %s, not %s", id, view.HoldingType, holdingType),
+		})
+		return nil, false
+	}
+
+	ids, err := s.repos.SavedViews.MatchingIDs(*view)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply saved view"})
+		return nil, false
+	}
+	return ids, true
+}
+
+// validateSavedView checks the holding type and that each filter applies to it
+func validateSavedView(input models.SavedViewInput) []plugins.ValidationError {
+	var fields []plugins.ValidationError
+	if !repository.IsHoldingType(input.HoldingType) {
+		return append(fields, plugins.ValidationError{
+			Field:   "holding_type",
+			Message: "holding_type must be one of stock_holding, equity_grant, real_estate, cash_holding, crypto_holding, other_asset",
+			Code:    "oneof",
+		})
+	}
+
+	f := input.Filters
+	if len(f.Symbols) > 0 && !symbolViewTypes[input.HoldingType] {
+		fields = append(fields, plugins.ValidationError{
+			Field:   "filters.symbols",
+			Message: "symbols only filter stock_holding, equity_grant and crypto_holding views",
+			Code:    "not_allowed",
+		})
+	}
+	if len(f.Sectors) > 0 && !sectorViewTypes[input.HoldingType] {
+		fields = append(fields, plugins.ValidationError{
+			Field:   "filters.sectors",
+			Message: "sectors only filter stock_holding and equity_grant views",
+			Code:    "not_allowed",
+		})
+	}
+	if f.MinValue != nil && f.MaxValue != nil && *f.MinValue > *f.MaxValue {
+		fields = append(fields, plugins.ValidationError{
+			Field:   "filters.max_value",
+			Message: "max_value must not be less than min_value",
+			Code:    "gtefield",
+		})
+	}
+	return fields
+}
+
+// bindSavedView binds and validates a saved view from the request body
+func bindSavedView(c *gin.Context) (*models.SavedViewInput, bool) {
+	var input models.SavedViewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, false
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if fields := validateSavedView(input); len(fields) > 0 {
+		respondValidationErrors(c, "Invalid saved view", fields)
+		return nil, false
+	}
+	return &input, true
+}
+
+// respondSavedViewError maps saved view repository errors to responses
+func (s *Server) respondSavedViewError(c *gin.Context, err error, failureMsg string) {
+	if errors.Is(err, repository.ErrDuplicateView) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A saved view with this name already exists for that holding list"})
+		return
+	}
+	s.respondRepositoryError(c, err, "Saved view not found", failureMsg)
+}
+
+// @Summary List saved views
+// @Description List saved views, optionally only those over one holding list
+// @Tags views
+// @Produce json
+// @Param holding_type query string false "Only views over this holding type"
+// @Success 200 {object} map[string]interface{} "Saved views"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /views [get]
+func (s *Server) getSavedViews(c *gin.Context) {
+	views, err := s.repos.SavedViews.List(c.Query("holding_type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved views"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"views": views, "count": len(views)})
+}
+
+// @Summary Get saved view
+// @Description Get one saved view
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {object} map[string]interface{} "Saved view"
+// @Failure 400 {object} map[string]interface{} "Invalid view ID"
+// @Failure 404 {object} map[string]interface{} "Saved view not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /views/{id} [get]
+func (s *Server) getSavedView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	view, err := s.repos.SavedViews.Get(id)
+	if err != nil {
+		s.respondSavedViewError(c, err, "Failed to fetch saved view")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// @Summary Create saved view
+// @Description Save a named filter over one holding list, then apply it with ?view={id} on that list. Filters: institution, symbols (stocks, equity grants, crypto), sectors (stocks, equity grants), min_value, max_value, tags and exclude_tags. Every filter given must match.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} true "View: {\"name\": \"Tech stocks > $10k\", \"holding_type\": \"stock_holding\", \"filters\": {\"sectors\": [\"Technology\"], \"min_value\": 10000}}"
+// @Success 201 {object} map[string]interface{} "Created view"
+// @Failure 400 {object} map[string]interface{} "Invalid view"
+// @Failure 409 {object} map[string]interface{} "Name already used for that holding list"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /views [post]
+func (s *Server) createSavedView(c *gin.Context) {
+	input, ok := bindSavedView(c)
+	if !ok {
+		return
+	}
+
+	view, err := s.repos.SavedViews.Create(*input)
+	if err != nil {
+		s.respondSavedViewError(c, err, "Failed to create saved view")
+		return
+	}
+
+	setAuditEntityID(c, view.ID)
+	c.JSON(http.StatusCreated, view)
+}
+
+// @Summary Update saved view
+// @Description Replace a saved view's name, holding type and filters
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Param request body map[string]interface{} true "View (name, holding_type, filters)"
+// @Success 200 {object} map[string]interface{} "Updated view"
+// @Failure 400 {object} map[string]interface{} "Invalid view"
+// @Failure 404 {object} map[string]interface{} "Saved view not found"
+// @Failure 409 {object} map[string]interface{} "Name already used for that holding list"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /views/{id} [put]
+func (s *Server) updateSavedView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	input, ok := bindSavedView(c)
+	if !ok {
+		return
+	}
+
+	view, err := s.repos.SavedViews.Update(id, *input)
+	if err != nil {
+		s.respondSavedViewError(c, err, "Failed to update saved view")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// @Summary Delete saved view
+// @Description Delete a saved view
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {object} map[string]interface{} "Saved view deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid view ID"
+// @Failure 404 {object} map[string]interface{} "Saved view not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /views/{id} [delete]
+func (s *Server) deleteSavedView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	if err := s.repos.SavedViews.Delete(id); err != nil {
+		s.respondSavedViewError(c, err, "Failed to delete saved view")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Saved view deleted"})
+}
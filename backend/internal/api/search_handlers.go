@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search result limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// @Summary Search holdings
+// @Description Full-text search across stock holdings (symbol, company), equity grants, properties (name, address, notes), cash accounts, crypto holdings and other assets (name, description, notes). Every word of q must match, as a word prefix, so partial words find results while typing. Results are typed, best matches first, and link to the page showing the holding.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search text"
+// @Param type query string false "Only these comma-separated types: stock_holding, equity_grant, real_estate, cash_holding, crypto_holding, other_asset"
+// @Param limit query int false "Maximum results (default 20, max 100)"
+// @Success 200 {object} map[string]interface{} "Search results"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /search [get]
+func (s *Server) search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	var types []string
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !repository.IsSearchType(t) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown search type %q", t)})
+			return
+		}
+		types = append(types, t)
+	}
+
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := s.repos.Search.Search(q, types, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search holdings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   q,
+		"results": results,
+		"count":   len(results),
+	})
+}
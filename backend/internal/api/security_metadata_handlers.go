@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get security metadata
+// @Description List the sector, industry and country of every held symbol and where each came from (manual, static dataset, provider or unknown)
+// @Tags securities
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Security metadata"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /securities/metadata [get]
+func (s *Server) getSecurityMetadata(c *gin.Context) {
+	symbols, err := s.securityMetadataService.HeldSymbols()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch held symbols"})
+		return
+	}
+
+	metadata, err := s.securityMetadataService.Lookup(symbols)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security metadata"})
+		return
+	}
+
+	securities := make([]models.SecurityMetadata, 0, len(symbols))
+	for _, symbol := range symbols {
+		securities = append(securities, metadata[symbol])
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"securities": securities,
+		"count":      len(securities),
+	})
+}
+
+// @Summary Set security metadata
+// @Description Override the sector, industry, country, name or asset type of a symbol. Overrides are never replaced by provider lookups.
+// @Tags securities
+// @Accept json
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Param metadata body map[string]interface{} true "Metadata (name, sector, industry, country, asset_type)"
+// @Success 200 {object} map[string]interface{} "Metadata saved"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /securities/{symbol}/metadata [put]
+func (s *Server) setSecurityMetadata(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+	if symbol == "" || len(symbol) > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid symbol"})
+		return
+	}
+
+	var input models.SecurityMetadataInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.securityMetadataService.SetOverride(symbol, input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save security metadata"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Security metadata saved",
+		"symbol":  symbol,
+	})
+}
+
+// @Summary Delete security metadata
+// @Description Remove stored metadata for a symbol so it falls back to the built-in dataset or is looked up again
+// @Tags securities
+// @Accept json
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Success 200 {object} map[string]interface{} "Metadata deleted"
+// @Failure 404 {object} map[string]interface{} "No stored metadata"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /securities/{symbol}/metadata [delete]
+func (s *Server) deleteSecurityMetadata(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+
+	err := s.securityMetadataService.DeleteStored(symbol)
+	if errors.Is(err, services.ErrSecurityMetadataNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete security metadata"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Security metadata deleted",
+	})
+}
+
+// @Summary Get fund composition
+// @Description Get the sector and country breakdown, underlying funds and largest holdings of an ETF or mutual fund, from the provider or the built-in dataset
+// @Tags securities
+// @Accept json
+// @Produce json
+// @Param symbol path string true "Fund symbol"
+// @Success 200 {object} map[string]interface{} "Fund composition"
+// @Failure 404 {object} map[string]interface{} "No composition for symbol"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /securities/{symbol}/composition [get]
+func (s *Server) getFundComposition(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+
+	compositions, err := s.securityMetadataService.Compositions([]string{symbol})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fund composition"})
+		return
+	}
+	composition, ok := compositions[symbol]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No composition for symbol"})
+		return
+	}
+	c.JSON(http.StatusOK, composition)
+}
+
+// @Summary Refresh security metadata
+// @Description Look up unclassified held symbols with the Alpha Vantage overview API, then held funds without a recent breakdown with the ETF profile API (a few per call, requires an Alpha Vantage key)
+// @Tags securities
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Number of symbols stored"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /securities/metadata/refresh [post]
+func (s *Server) refreshSecurityMetadata(c *gin.Context) {
+	stored, err := s.securityMetadataService.Refresh(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh security metadata"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stored": stored,
+	})
+}
@@ -5,13 +5,21 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"networth-dashboard/internal/cache"
 	"networth-dashboard/internal/config"
 	"networth-dashboard/internal/credentials"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/encryption"
 	"networth-dashboard/internal/handlers"
+	"networth-dashboard/internal/httpclient"
+	"networth-dashboard/internal/models"
 	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/repository"
 	"networth-dashboard/internal/services"
+	"networth-dashboard/internal/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -23,24 +31,92 @@ type Server struct {
 	config                   *config.Config
 	router                   *gin.Engine
 	db                       *sql.DB
+	repos                    *repository.Repositories
 	pluginManager            *plugins.Manager
 	credentialManager        *credentials.Manager
 	cryptoService            *services.CryptoService
 	priceService             *services.PriceService
 	marketService            *services.MarketHoursService
 	propertyValuationService *services.PropertyValuationService
+	assetValuationService    *services.AssetValuationService
+	metalsPriceService       *services.MetalsPriceService
+	setupService             *services.SetupService
+	notificationService      *services.NotificationService
+	symbolHealthService      *services.SymbolHealthService
+	auditService             *services.AuditService
+	contributionService      *services.ContributionService
+	gainsHistoryService      *services.GainsHistoryService
+	benchmarkService         *services.BenchmarkService
+	securityMetadataService  *services.SecurityMetadataService
+	symbolLookupService      *services.SymbolLookupService
+	concentrationRiskService *services.ConcentrationRiskService
+	netWorthHistoryService   *services.NetWorthHistoryService
+	reportService            *services.ReportService
+	userService              *services.UserService
+	attachmentService        *services.AttachmentService
+	backupService            *services.BackupService
+	demoDataService          *services.DemoDataService
+	shareService             *services.ShareService
+	apiKeyService            *services.APIKeyService
+	tradingWindowService     *services.TradingWindowService
+	marketHolidayService     *services.MarketHolidayService
+	intradayService          *services.IntradayService
+	priceHistoryService      *services.PriceHistoryService
+	priceRetentionService    *services.PriceRetentionService
+	runtimeSettingsService   *services.RuntimeSettingsService
+	integrityService         *services.IntegrityService
+	cryptoLotService         *services.CryptoLotService
+	cryptoStakingService     *services.CryptoStakingService
+	privateInvestmentService *services.PrivateInvestmentService
+	bondService              *services.BondService
+	iBondService             *services.IBondService
+	pensionService           *services.PensionService
+	insuranceService         *services.InsuranceService
+	employerMatchService     *services.EmployerMatchService
+	statementImportService   *services.StatementImportService
+	mailer                   *services.Mailer
+	fieldEncryptor           *encryption.FieldEncryptor
+	envProviderKeys          map[string]string
+	cache                    cache.Cache
 	httpServer               *http.Server
+	shuttingDown             atomic.Bool
+	startedAt                time.Time
+	writeGeneration          atomic.Int64
+	// now is the clock ETags take the date from; nil means time.Now
+	now func() time.Time
 }
 
-func NewServer(cfg *config.Config, db *sql.DB, pluginManager *plugins.Manager) *Server {
+func NewServer(cfg *config.Config, db *sql.DB, pluginManager *plugins.Manager, fieldEncryptor *encryption.FieldEncryptor, store storage.Store) *Server {
 	// Initialize credential manager
 	credentialManager, err := credentials.NewManager(db, cfg.Security.CredentialKey)
 	if err != nil {
 		log.Fatal("Failed to initialize credential manager:", err)
 	}
 
-	// Initialize crypto service
-	cryptoService := services.NewCryptoService(db)
+	// Provider API keys in the credential store take precedence over the environment
+	envProviderKeys := captureEnvProviderKeys(&cfg.API)
+	applyProviderCredentials(credentialManager, &cfg.API, envProviderKeys)
+
+	// Initialize notifications and per-symbol refresh health tracking
+	notificationService := services.NewNotificationService(db)
+	mailer := services.NewMailer(db, &cfg.SMTP)
+	if mailer.Configured() {
+		notificationService.AddChannel(services.NewEmailChannel(mailer), cfg.SMTP.MinSeverity)
+	}
+	if cfg.Notifications.TelegramBotToken != "" && cfg.Notifications.TelegramChatID != "" {
+		notificationService.AddChannel(
+			services.NewTelegramChannel(cfg.Notifications.TelegramBotToken, cfg.Notifications.TelegramChatID),
+			cfg.Notifications.TelegramMinSeverity)
+	}
+	if cfg.Notifications.DiscordWebhookURL != "" {
+		notificationService.AddChannel(services.NewDiscordChannel(cfg.Notifications.DiscordWebhookURL), cfg.Notifications.DiscordMinSeverity)
+	}
+	symbolHealthService := services.NewSymbolHealthService(db, cfg.API.SymbolFailureThreshold, notificationService)
+
+	// Initialize crypto service with configured price provider
+	cryptoService := services.NewCryptoService(db, &cfg.API)
+	cryptoService.SetSymbolHealthService(symbolHealthService)
+	log.Printf("INFO: Crypto service initialized with provider: %s", cryptoService.GetProviderName())
 
 	// Initialize market hours service
 	marketService, err := services.NewMarketHoursService(&cfg.Market)
@@ -60,15 +136,86 @@ func NewServer(cfg *config.Config, db *sql.DB, pluginManager *plugins.Manager) *
 	propertyValuationService := services.NewPropertyValuationService(&cfg.API)
 	log.Printf("INFO: Property valuation service initialized with provider: %s", propertyValuationService.GetProviderName())
 
+	metalsPriceService := services.NewMetalsPriceService(db, &cfg.API)
+
+	gainsHistoryService := services.NewGainsHistoryService(db)
+	netWorthHistoryService := services.NewNetWorthHistoryService(db)
+
+	// Stablecoins counted as cash are left out of concentration risk as well
+	// as moved to cash in the allocation breakdown
+	coins := models.NewCoinClassification(cfg.Risk.StablecoinSymbols)
+	var cashEquivalentCoins []string
+	if cfg.Risk.StablecoinsAsCash {
+		cashEquivalentCoins = coins.Stablecoins()
+	}
+
+	userService := services.NewUserService(db, cfg.Auth.SessionTTL)
+	if cfg.Auth.Enabled {
+		if err := userService.EnsureAdmin(cfg.Auth.BootstrapUsername, cfg.Auth.BootstrapPassword); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+
 	server := &Server{
 		config:                   cfg,
 		db:                       db,
+		repos:                    repository.New(db, fieldEncryptor),
 		pluginManager:            pluginManager,
 		credentialManager:        credentialManager,
 		cryptoService:            cryptoService,
 		priceService:             priceService,
 		marketService:            marketService,
 		propertyValuationService: propertyValuationService,
+		assetValuationService:    services.NewAssetValuationService(db, &cfg.API, metalsPriceService),
+		metalsPriceService:       metalsPriceService,
+		setupService:             services.NewSetupService(db, &cfg.API),
+		notificationService:      notificationService,
+		symbolHealthService:      symbolHealthService,
+		auditService:             services.NewAuditService(db),
+		contributionService:      services.NewContributionService(db, notificationService, cfg.Contributions.RequireConfirmation),
+		gainsHistoryService:      gainsHistoryService,
+		benchmarkService:         services.NewBenchmarkService(db, priceService, gainsHistoryService),
+		securityMetadataService:  services.NewSecurityMetadataService(db, &cfg.API),
+		symbolLookupService:      services.NewSymbolLookupService(db, &cfg.API),
+		concentrationRiskService: services.NewConcentrationRiskService(db, cfg.Risk.ConcentrationThresholdPercent, cashEquivalentCoins, notificationService),
+		netWorthHistoryService:   netWorthHistoryService,
+		reportService:            services.NewReportService(db, netWorthHistoryService, gainsHistoryService, notificationService, mailer),
+		userService:              userService,
+		attachmentService:        services.NewAttachmentService(store, cfg.Attachments, cfg.Storage.SignedURLTTL),
+		backupService:            services.NewBackupService(store, cfg.Database, cfg.Backup, notificationService),
+		demoDataService:          services.NewDemoDataService(db),
+		shareService:             services.NewShareService(db, netWorthHistoryService),
+		apiKeyService:            services.NewAPIKeyService(db),
+		tradingWindowService:     services.NewTradingWindowService(db, notificationService),
+		marketHolidayService:     services.NewMarketHolidayService(db, marketService),
+		intradayService:          services.NewIntradayService(db, priceService, marketService),
+		priceHistoryService:      services.NewPriceHistoryService(db, priceService),
+		priceRetentionService:    services.NewPriceRetentionService(db, cfg.History.PriceRetentionDays),
+		runtimeSettingsService:   services.NewRuntimeSettingsService(db, cfg),
+		integrityService:         services.NewIntegrityService(db),
+		cryptoLotService:         services.NewCryptoLotService(db),
+		cryptoStakingService:     services.NewCryptoStakingService(db),
+		privateInvestmentService: services.NewPrivateInvestmentService(db),
+		bondService:              services.NewBondService(db),
+		iBondService:             services.NewIBondService(db, notificationService),
+		pensionService:           services.NewPensionService(db),
+		insuranceService:         services.NewInsuranceService(db, notificationService),
+		employerMatchService:     services.NewEmployerMatchService(db, notificationService),
+		statementImportService:   services.NewStatementImportService(),
+		mailer:                   mailer,
+		fieldEncryptor:           fieldEncryptor,
+		envProviderKeys:          envProviderKeys,
+		startedAt:                time.Now(),
+		cache:                    cache.New(cfg.Cache),
+	}
+
+	server.repos.NetWorth.SetCoinClassification(coins)
+
+	// Settings changed through /admin/settings override the configuration
+	if count, err := server.runtimeSettingsService.Apply(); err != nil {
+		log.Printf("WARNING: Failed to apply runtime settings: %v", err)
+	} else if count > 0 {
+		log.Printf("INFO: Applied %d runtime settings", count)
 	}
 
 	server.setupRouter()
@@ -81,138 +228,436 @@ func (s *Server) setupRouter() {
 	}
 
 	s.router = gin.Default()
+	s.router.Use(tracingMiddleware())
+	s.router.NoRoute(respondRouteNotFound)
+	configureValidator()
 
 	// CORS configuration
 	if s.config.Server.CORSEnabled {
 		config := cors.DefaultConfig()
 		config.AllowOrigins = s.config.Server.CORSOrigins
 		config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-		config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+		config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", auditActorHeader, apiKeyHeader}
 		s.router.Use(cors.New(config))
 	}
 
 	// Health check endpoint
 	s.router.GET("/health", s.healthCheck)
 
+	// Kubernetes liveness and readiness probes
+	s.router.GET("/healthz", s.livenessCheck)
+	s.router.GET("/readyz", s.readinessCheck)
+
 	// Swagger documentation
 	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// API routes
-	api := s.router.Group("/api/v1")
-	{
-		// Net worth endpoints
-		api.GET("/net-worth", s.getNetWorth)
-		api.GET("/net-worth/history", s.getNetWorthHistory)
-		api.GET("/passive-income", s.getPassiveIncome)
-
-		// Account endpoints
-		api.GET("/accounts", s.getAccounts)
-		api.GET("/accounts/:id", s.getAccount)
-		api.POST("/accounts", s.createAccount)
-		api.PUT("/accounts/:id", s.updateAccount)
-		api.DELETE("/accounts/:id", s.deleteAccount)
-
-		// Balance endpoints
-		api.GET("/balances", s.getBalances)
-		api.GET("/accounts/:id/balances", s.getAccountBalances)
-
-		// Stock holdings endpoints
-		api.GET("/stocks", s.getStockHoldings)
-		api.GET("/stocks/consolidated", s.getConsolidatedStocks)
-		api.POST("/stocks", s.createStockHolding)
-		api.PUT("/stocks/:id", s.updateStockHolding)
-		api.DELETE("/stocks/:id", s.deleteStockHolding)
-
-		// Equity compensation endpoints
-		api.GET("/equity", s.getEquityGrants)
-		api.GET("/equity/:id/vesting", s.getVestingSchedule)
-		api.POST("/equity", s.createEquityGrant)
-		api.PUT("/equity/:id", s.updateEquityGrant)
-		api.DELETE("/equity/:id", s.deleteEquityGrant)
-
-		// Real estate endpoints
-		api.GET("/real-estate", s.getRealEstate)
-		api.POST("/real-estate", s.createRealEstate)
-		api.PUT("/real-estate/:id", s.updateRealEstate)
-		api.DELETE("/real-estate/:id", s.deleteRealEstate)
-
-		// Cash holdings endpoints
-		api.GET("/cash-holdings", s.getCashHoldings)
-		api.POST("/cash-holdings", s.createCashHolding)
-		api.PUT("/cash-holdings/bulk", s.bulkUpdateCashHoldings)
-		api.PUT("/cash-holdings/:id", s.updateCashHolding)
-		api.DELETE("/cash-holdings/:id", s.deleteCashHolding)
-
-		// Crypto holdings endpoints
-		api.GET("/crypto-holdings", s.getCryptoHoldings)
-		api.POST("/crypto-holdings", s.createCryptoHolding)
-		api.PUT("/crypto-holdings/:id", s.updateCryptoHolding)
-		api.DELETE("/crypto-holdings/:id", s.deleteCryptoHolding)
-
-		// Other assets endpoints
-		api.GET("/other-assets", s.getOtherAssets)
-		api.POST("/other-assets", s.createOtherAsset)
-		api.PUT("/other-assets/:id", s.updateOtherAsset)
-		api.DELETE("/other-assets/:id", s.deleteOtherAsset)
-
-		// Asset categories endpoints
-		api.GET("/asset-categories", s.getAssetCategories)
-		api.POST("/asset-categories", s.createAssetCategory)
-		api.PUT("/asset-categories/:id", s.updateAssetCategory)
-		api.DELETE("/asset-categories/:id", s.deleteAssetCategory)
-		api.GET("/asset-categories/:id/schema", s.getAssetCategorySchema)
-
-		// Crypto price endpoints
-		api.GET("/crypto/prices/:symbol", s.getCryptoPrice)
-		api.GET("/crypto/prices/history", s.getCryptoPriceHistory)
-		api.POST("/crypto/prices/refresh", s.refreshCryptoPrices)
-		api.POST("/crypto/prices/refresh/:symbol", s.refreshCryptoPrice)
-
-		// Plugin management endpoints
-		api.GET("/plugins", s.getPlugins)
-		api.GET("/plugins/:name/schema", s.getPluginSchema)
-		api.GET("/plugins/:name/schema/:category_id", s.getPluginSchemaForCategory)
-		api.POST("/plugins/:name/manual-entry", s.processManualEntry)
-		api.POST("/plugins/refresh", s.refreshPluginData)
-		api.GET("/plugins/health", s.getPluginHealth)
-
-		// Manual entry endpoints
-		api.GET("/manual-entries", s.getManualEntries)
-		api.POST("/manual-entries", s.createManualEntry)
-		api.PUT("/manual-entries/:id", s.updateManualEntry)
-		api.DELETE("/manual-entries/:id", s.deleteManualEntry)
-		api.GET("/manual-entries/schemas", s.getManualEntrySchemas)
-
-		// Price management endpoints
-		api.GET("/prices/refresh", s.refreshPrices)
-		api.POST("/prices/refresh", s.refreshPrices)
-		api.POST("/prices/refresh/:symbol", s.refreshSymbolPrice)
-		api.GET("/prices/status", s.getPricesStatus)
-		
-		// Market status endpoints
-		api.GET("/market/status", s.getMarketStatus)
-
-		// Property valuation endpoints
-		api.GET("/property-valuation", s.getPropertyValuation)
-		api.POST("/property-valuation/refresh", s.refreshPropertyValuation)
-		api.GET("/property-valuation/providers", s.getPropertyValuationProviders)
-
-		// Credential management endpoints
-		credentialHandler := handlers.NewCredentialHandler(s.credentialManager)
-		handlers.RegisterCredentialRoutes(api, credentialHandler)
-		
-		// OpenAPI spec download
-		// @Summary Download OpenAPI specification
-		// @Description Download the complete OpenAPI specification in JSON format
-		// @Tags system
-		// @Produce json
-		// @Success 200 {object} object "OpenAPI specification"
-		// @Router /swagger/spec [get]
-		api.GET("/swagger/spec", func(c *gin.Context) {
-			c.Header("Content-Type", "application/json")
-			c.File("docs/swagger.json")
-		})
-	}
+	// API routes, one group per version
+	s.mountAPIVersions()
+}
+
+// registerV1Routes registers the stable v1 API
+func (s *Server) registerV1Routes(api *gin.RouterGroup) {
+	// Routes that manage plugins, credentials and users
+	admin := api.Group("", s.requireRole(services.RoleAdmin))
+
+	// Sign-in endpoints
+	api.POST("/auth/login", s.login)
+	api.POST("/auth/logout", s.logout)
+	api.GET("/auth/me", s.getCurrentUser)
+
+	// User management endpoints
+	admin.GET("/users", s.getUsers)
+	admin.POST("/users", s.audited(services.AuditActionCreate, "user"), s.createUser)
+	admin.PUT("/users/:id", s.audited(services.AuditActionUpdate, "user"), s.updateUser)
+	admin.DELETE("/users/:id", s.audited(services.AuditActionDelete, "user"), s.deleteUser)
+
+	// API key endpoints
+	admin.GET("/api-keys", s.getAPIKeys)
+	admin.POST("/api-keys", s.audited(services.AuditActionCreate, "api_key"), s.createAPIKey)
+	admin.DELETE("/api-keys/:id", s.audited(services.AuditActionUpdate, "api_key"), s.revokeAPIKey)
+
+	// Database backup endpoints
+	admin.GET("/backups", s.getBackups)
+	admin.POST("/backups", s.createBackup)
+	admin.GET("/backups/:name/download", s.downloadBackup)
+	admin.POST("/backups/:name/restore", s.restoreBackup)
+	admin.DELETE("/backups/:name", s.deleteBackup)
+
+	// Demo data endpoints
+	admin.GET("/demo-data", s.getDemoData)
+	admin.POST("/demo-data", s.audited(services.AuditActionCreate, "demo_data"), s.seedDemoData)
+	admin.DELETE("/demo-data", s.audited(services.AuditActionDelete, "demo_data"), s.wipeDemoData)
+
+	// Price retention endpoints
+	admin.GET("/admin/retention", s.getPriceRetention)
+
+	// Runtime settings endpoints
+	admin.GET("/admin/settings", s.getRuntimeSettings)
+	admin.PUT("/admin/settings/:name", s.updateRuntimeSetting)
+	admin.DELETE("/admin/settings/:name", s.resetRuntimeSetting)
+
+	// Data integrity endpoints
+	admin.GET("/admin/integrity", s.getIntegrityReport)
+	admin.POST("/admin/integrity/check", s.checkIntegrity)
+
+	// Duplicate merge endpoints
+	admin.POST("/admin/merge/holdings", s.mergeHoldings)
+	admin.POST("/admin/merge/accounts", s.mergeAccounts)
+
+	// Net worth endpoints
+	api.GET("/net-worth", s.getNetWorth)
+	api.GET("/net-worth/history", s.getNetWorthHistory)
+	api.GET("/net-worth/breakdown", s.getNetWorthBreakdown)
+	api.GET("/net-worth/household", s.getHouseholdNetWorth)
+	api.GET("/net-worth/owners", s.getOwnerNetWorth)
+	api.GET("/passive-income", s.getPassiveIncome)
+
+	// Household endpoints
+	api.GET("/household", s.getHousehold)
+	api.PUT("/household", s.updateHousehold)
+	api.POST("/household/members", s.audited(services.AuditActionCreate, "household_member"), s.createHouseholdMember)
+	api.PUT("/household/members/:id", s.audited(services.AuditActionUpdate, "household_member"), s.updateHouseholdMember)
+	api.DELETE("/household/members/:id", s.audited(services.AuditActionDelete, "household_member"), s.deleteHouseholdMember)
+	api.GET("/household/ownership/:type/:holding_id", s.getHoldingOwnership)
+	api.PUT("/household/ownership/:type/:holding_id", s.setHoldingOwnership)
+
+	// Dashboard layout endpoints
+	api.GET("/dashboard/summary", s.getDashboardSummary)
+	api.GET("/dashboard/config", s.getDashboardConfig)
+	api.PUT("/dashboard/config", s.updateDashboardConfig)
+	api.DELETE("/dashboard/config", s.resetDashboardConfig)
+
+	// Saved view endpoints
+	api.GET("/views", s.getSavedViews)
+	api.GET("/views/:id", s.getSavedView)
+	api.POST("/views", s.audited(services.AuditActionCreate, "saved_view"), s.createSavedView)
+	api.PUT("/views/:id", s.audited(services.AuditActionUpdate, "saved_view"), s.updateSavedView)
+	api.DELETE("/views/:id", s.audited(services.AuditActionDelete, "saved_view"), s.deleteSavedView)
+
+	// Institution endpoints
+	api.GET("/institutions", s.getInstitutions)
+
+	// Account endpoints
+	api.GET("/accounts", s.getAccounts)
+	api.GET("/accounts/:id", s.getAccount)
+	api.POST("/accounts", s.audited(services.AuditActionCreate, "account"), s.createAccount)
+	api.PUT("/accounts/:id", s.audited(services.AuditActionUpdate, "account"), s.updateAccount)
+	api.DELETE("/accounts/:id", s.audited(services.AuditActionDelete, "account"), s.deleteAccount)
+	api.POST("/accounts/:id/holdings", s.linkAccountHoldings)
+
+	// Balance endpoints
+	api.GET("/balances", s.getBalances)
+	api.GET("/accounts/:id/balances", s.getAccountBalances)
+
+	// Stock holdings endpoints
+	api.GET("/stocks", s.getStockHoldings)
+	api.GET("/stocks/consolidated", s.getConsolidatedStocks)
+	api.GET("/stocks/:symbol/intraday", s.getIntradayPrices)
+	api.POST("/stocks", s.audited(services.AuditActionCreate, "stock_holding"), s.createStockHolding)
+	api.POST("/stocks/bulk", s.audited(services.AuditActionBulkCreate, "stock_holding"), s.bulkCreateStockHoldings)
+	api.POST("/stocks/bulk-delete", s.audited(services.AuditActionBulkDelete, "stock_holding"), s.bulkDeleteStockHoldings)
+	api.PUT("/stocks/:id", s.audited(services.AuditActionUpdate, "stock_holding"), s.updateStockHolding)
+	api.DELETE("/stocks/:id", s.audited(services.AuditActionDelete, "stock_holding"), s.deleteStockHolding)
+
+	// Equity compensation endpoints
+	api.GET("/equity", s.getEquityGrants)
+	api.GET("/equity/:id/vesting", s.getVestingSchedule)
+	api.GET("/equity/:id/value-history", s.getGrantValueHistory)
+	api.GET("/equity/trading-status", s.getTradingStatus)
+	api.GET("/equity/vest-withholding", s.getVestWithholding)
+	api.GET("/equity/trading-windows", s.getTradingWindows)
+	api.POST("/equity/trading-windows", s.audited(services.AuditActionCreate, "trading_window"), s.createTradingWindow)
+	api.PUT("/equity/trading-windows/:id", s.audited(services.AuditActionUpdate, "trading_window"), s.updateTradingWindow)
+	api.DELETE("/equity/trading-windows/:id", s.audited(services.AuditActionDelete, "trading_window"), s.deleteTradingWindow)
+	api.POST("/equity", s.audited(services.AuditActionCreate, "equity_grant"), s.createEquityGrant)
+	api.PUT("/equity/:id", s.audited(services.AuditActionUpdate, "equity_grant"), s.updateEquityGrant)
+	api.DELETE("/equity/:id", s.audited(services.AuditActionDelete, "equity_grant"), s.deleteEquityGrant)
+
+	// Real estate endpoints
+	api.GET("/real-estate", s.getRealEstate)
+	api.POST("/real-estate", s.audited(services.AuditActionCreate, "real_estate"), s.createRealEstate)
+	api.PUT("/real-estate/:id", s.audited(services.AuditActionUpdate, "real_estate"), s.updateRealEstate)
+	api.DELETE("/real-estate/:id", s.audited(services.AuditActionDelete, "real_estate"), s.deleteRealEstate)
+	api.GET("/real-estate/:id/ledger", s.getPropertyLedger)
+	api.GET("/real-estate/:id/cashflow", s.getPropertyCashFlow)
+	api.GET("/real-estate/:id/depreciation", s.getPropertyDepreciation)
+	api.GET("/real-estate/:id/valuations", s.getRealEstateValuations)
+	api.POST("/real-estate/:id/valuation/refresh", s.audited(services.AuditActionUpdate, "real_estate"), s.refreshRealEstateValuation)
+	api.GET("/real-estate/depreciation", s.getRealEstateDepreciation)
+	api.POST("/real-estate/ledger", s.audited(services.AuditActionCreate, "property_ledger_entry"), s.createPropertyLedgerEntry)
+	api.PUT("/real-estate/ledger/:id", s.audited(services.AuditActionUpdate, "property_ledger_entry"), s.updatePropertyLedgerEntry)
+	api.DELETE("/real-estate/ledger/:id", s.audited(services.AuditActionDelete, "property_ledger_entry"), s.deletePropertyLedgerEntry)
+
+	// Cash holdings endpoints
+	api.GET("/cash-holdings", s.getCashHoldings)
+	api.POST("/cash-holdings", s.audited(services.AuditActionCreate, "cash_holding"), s.createCashHolding)
+	api.POST("/cash-holdings/bulk", s.audited(services.AuditActionBulkCreate, "cash_holding"), s.bulkCreateCashHoldings)
+	api.POST("/cash-holdings/bulk-delete", s.audited(services.AuditActionBulkDelete, "cash_holding"), s.bulkDeleteCashHoldings)
+	api.PUT("/cash-holdings/bulk", s.audited(services.AuditActionBulkUpdate, "cash_holding"), s.bulkUpdateCashHoldings)
+	api.PUT("/cash-holdings/:id", s.audited(services.AuditActionUpdate, "cash_holding"), s.updateCashHolding)
+	api.DELETE("/cash-holdings/:id", s.audited(services.AuditActionDelete, "cash_holding"), s.deleteCashHolding)
+
+	// Crypto holdings endpoints
+	api.GET("/crypto-holdings", s.getCryptoHoldings)
+	api.POST("/crypto-holdings", s.audited(services.AuditActionCreate, "crypto_holding"), s.createCryptoHolding)
+	api.POST("/crypto-holdings/bulk", s.audited(services.AuditActionBulkCreate, "crypto_holding"), s.bulkCreateCryptoHoldings)
+	api.POST("/crypto-holdings/bulk-delete", s.audited(services.AuditActionBulkDelete, "crypto_holding"), s.bulkDeleteCryptoHoldings)
+	api.PUT("/crypto-holdings/:id", s.audited(services.AuditActionUpdate, "crypto_holding"), s.updateCryptoHolding)
+	api.DELETE("/crypto-holdings/:id", s.audited(services.AuditActionDelete, "crypto_holding"), s.deleteCryptoHolding)
+	api.GET("/crypto-holdings/:id/transactions", s.getCryptoTransactions)
+	api.POST("/crypto-holdings/transactions", s.audited(services.AuditActionCreate, "crypto_transaction"), s.createCryptoTransaction)
+	api.DELETE("/crypto-holdings/transactions/:id", s.audited(services.AuditActionDelete, "crypto_transaction"), s.deleteCryptoTransaction)
+
+	// Other assets endpoints
+	api.GET("/other-assets", s.getOtherAssets)
+	api.POST("/other-assets", s.audited(services.AuditActionCreate, "other_asset"), s.createOtherAsset)
+	api.POST("/other-assets/bulk", s.audited(services.AuditActionBulkCreate, "other_asset"), s.bulkCreateOtherAssets)
+	api.POST("/other-assets/bulk-delete", s.audited(services.AuditActionBulkDelete, "other_asset"), s.bulkDeleteOtherAssets)
+	api.PUT("/other-assets/:id", s.audited(services.AuditActionUpdate, "other_asset"), s.updateOtherAsset)
+	api.DELETE("/other-assets/:id", s.audited(services.AuditActionDelete, "other_asset"), s.deleteOtherAsset)
+	api.GET("/other-assets/valuation-providers", s.getAssetValuationProviders)
+	api.GET("/other-assets/:id/history", s.getOtherAssetHistory)
+	api.POST("/other-assets/:id/valuation/refresh", s.audited(services.AuditActionUpdate, "other_asset"), s.refreshOtherAssetValuation)
+	api.GET("/other-assets/:id/valuation/suggestion", s.getOtherAssetValuationSuggestion)
+	api.POST("/other-assets/:id/valuation/suggestion/confirm", s.audited(services.AuditActionUpdate, "other_asset"), s.confirmOtherAssetValuationSuggestion)
+	api.DELETE("/other-assets/:id/valuation/suggestion", s.dismissOtherAssetValuationSuggestion)
+
+	// Private investments endpoints
+	api.GET("/private-investments", s.getPrivateInvestments)
+	api.POST("/private-investments", s.audited(services.AuditActionCreate, "private_investment"), s.createPrivateInvestment)
+	api.GET("/private-investments/:id", s.getPrivateInvestment)
+	api.PUT("/private-investments/:id", s.audited(services.AuditActionUpdate, "private_investment"), s.updatePrivateInvestment)
+	api.DELETE("/private-investments/:id", s.audited(services.AuditActionDelete, "private_investment"), s.deletePrivateInvestment)
+	api.POST("/private-investments/:id/nav", s.audited(services.AuditActionUpdate, "private_investment"), s.updatePrivateInvestmentNAV)
+	api.POST("/private-investments/flows", s.audited(services.AuditActionCreate, "private_investment_flow"), s.createPrivateInvestmentFlow)
+	api.DELETE("/private-investments/flows/:id", s.audited(services.AuditActionDelete, "private_investment_flow"), s.deletePrivateInvestmentFlow)
+
+	// Bonds endpoints
+	api.GET("/bonds", s.getBonds)
+	api.POST("/bonds", s.audited(services.AuditActionCreate, "bond"), s.createBond)
+	api.GET("/bonds/ladder", s.getBondLadder)
+	api.GET("/bonds/:id", s.getBond)
+	api.PUT("/bonds/:id", s.audited(services.AuditActionUpdate, "bond"), s.updateBond)
+	api.DELETE("/bonds/:id", s.audited(services.AuditActionDelete, "bond"), s.deleteBond)
+
+	// I bond endpoints
+	api.GET("/i-bonds", s.getIBonds)
+	api.POST("/i-bonds", s.audited(services.AuditActionCreate, "i_bond"), s.createIBond)
+	api.GET("/i-bonds/rates", s.getIBondRates)
+	admin.PUT("/i-bonds/rates/:date", s.setIBondRate)
+	admin.DELETE("/i-bonds/rates/:date", s.deleteIBondRate)
+	api.GET("/i-bonds/:id", s.getIBond)
+	api.PUT("/i-bonds/:id", s.audited(services.AuditActionUpdate, "i_bond"), s.updateIBond)
+	api.DELETE("/i-bonds/:id", s.audited(services.AuditActionDelete, "i_bond"), s.deleteIBond)
+
+	// Pension endpoints
+	api.GET("/pensions", s.getPensions)
+	api.POST("/pensions", s.audited(services.AuditActionCreate, "pension"), s.createPension)
+	api.GET("/pensions/:id", s.getPension)
+	api.PUT("/pensions/:id", s.audited(services.AuditActionUpdate, "pension"), s.updatePension)
+	api.PUT("/pensions/:id/net-worth", s.audited(services.AuditActionUpdate, "pension"), s.setPensionIncluded)
+	api.DELETE("/pensions/:id", s.audited(services.AuditActionDelete, "pension"), s.deletePension)
+
+	// Insurance endpoints
+	api.GET("/insurance", s.getInsurancePolicies)
+	api.POST("/insurance", s.audited(services.AuditActionCreate, "insurance_policy"), s.createInsurancePolicy)
+	api.GET("/insurance/:id", s.getInsurancePolicy)
+	api.PUT("/insurance/:id", s.audited(services.AuditActionUpdate, "insurance_policy"), s.updateInsurancePolicy)
+	api.DELETE("/insurance/:id", s.audited(services.AuditActionDelete, "insurance_policy"), s.deleteInsurancePolicy)
+
+	// Liability endpoints
+	api.GET("/liabilities", s.getLiabilities)
+	api.POST("/liabilities", s.audited(services.AuditActionCreate, "liability"), s.createLiability)
+	api.GET("/liabilities/:id", s.getLiability)
+	api.PUT("/liabilities/:id", s.audited(services.AuditActionUpdate, "liability"), s.updateLiability)
+	api.DELETE("/liabilities/:id", s.audited(services.AuditActionDelete, "liability"), s.deleteLiability)
+	api.GET("/liabilities/:id/statements", s.getLiabilityStatements)
+	api.POST("/liabilities/:id/statements", s.importLiabilityStatement)
+	api.POST("/liabilities/:id/statements/:statement_id/apply", s.audited(services.AuditActionUpdate, "liability"), s.applyLiabilityStatement)
+	api.POST("/liabilities/:id/statements/:statement_id/reject", s.rejectLiabilityStatement)
+
+	// Asset categories endpoints
+	api.GET("/asset-categories", s.getAssetCategories)
+	api.POST("/asset-categories", s.audited(services.AuditActionCreate, "asset_category"), s.createAssetCategory)
+	api.POST("/asset-categories/validate-schema", s.validateAssetCategorySchema)
+	api.PUT("/asset-categories/:id", s.audited(services.AuditActionUpdate, "asset_category"), s.updateAssetCategory)
+	api.DELETE("/asset-categories/:id", s.audited(services.AuditActionDelete, "asset_category"), s.deleteAssetCategory)
+	api.GET("/asset-categories/:id/schema", s.getAssetCategorySchema)
+	api.GET("/asset-categories/:id/migration", s.getAssetCategoryMigration)
+	api.POST("/asset-categories/:id/migration", s.audited(services.AuditActionUpdate, "asset_category"), s.migrateAssetCategory)
+
+	// Crypto price endpoints
+	api.GET("/crypto/prices/:symbol", s.getCryptoPrice)
+	api.GET("/crypto/cost-basis", s.getCryptoCostBasis)
+	api.GET("/crypto/staking", s.getCryptoStaking)
+	api.GET("/crypto/prices/history", s.getCryptoPriceHistory)
+	api.POST("/crypto/prices/refresh", s.refreshCryptoPrices)
+	api.POST("/crypto/prices/refresh/:symbol", s.refreshCryptoPrice)
+
+	// Precious metal spot price endpoints
+	api.GET("/metals/prices", s.getMetalPrices)
+	api.GET("/metals/prices/:metal", s.getMetalPrice)
+	api.POST("/metals/prices/refresh", s.refreshMetalPrices)
+
+	// Plugin management endpoints
+	api.GET("/plugins", s.getPlugins)
+	api.GET("/plugins/:name/schema", s.getPluginSchema)
+	api.GET("/plugins/:name/schema/:category_id", s.getPluginSchemaForCategory)
+	api.POST("/plugins/:name/manual-entry", s.auditedPlugin(services.AuditActionCreate), s.processManualEntry)
+	api.POST("/plugins/refresh", s.refreshPluginData)
+	api.GET("/plugins/health", s.getPluginHealth)
+	admin.GET("/plugins/:name/config", s.getPluginConfig)
+	admin.PUT("/plugins/:name/config", s.updatePluginConfig)
+	admin.GET("/plugins/:name/credentials", s.getPluginCredentials)
+	admin.PUT("/plugins/:name/credentials", s.setPluginCredentials)
+	admin.DELETE("/plugins/:name/credentials", s.deletePluginCredentials)
+	admin.POST("/plugins/:name/credentials/test", s.testPluginCredentials)
+
+	// Manual entry endpoints
+	api.GET("/manual-entries", s.getManualEntries)
+	api.POST("/manual-entries", s.createManualEntry)
+	api.PUT("/manual-entries/:id", s.auditedPlugin(services.AuditActionUpdate), s.updateManualEntry)
+	api.DELETE("/manual-entries/:id", s.auditedPlugin(services.AuditActionDelete), s.deleteManualEntry)
+	api.GET("/manual-entries/schemas", s.getManualEntrySchemas)
+
+	// Price management endpoints
+	// Refreshes despite being a GET, so viewers may not call it
+	api.GET("/prices/refresh", s.requireRole(services.RoleEditor), s.refreshPrices)
+	api.POST("/prices/refresh", s.refreshPrices)
+	api.POST("/prices/refresh/:symbol", s.refreshSymbolPrice)
+	api.POST("/prices/history/backfill", s.backfillPriceHistory)
+	api.GET("/prices/status", s.getPricesStatus)
+	api.GET("/prices/symbols/health", s.getSymbolHealth)
+	api.POST("/prices/symbols/:type/:symbol/resume", s.resumeSymbol)
+	
+	// Market status endpoints
+	api.GET("/market/status", s.getMarketStatus)
+	api.GET("/market/holidays", s.getMarketHolidays)
+	api.POST("/market/holidays", s.audited(services.AuditActionCreate, "market_holiday"), s.createMarketHoliday)
+	api.DELETE("/market/holidays/:id", s.audited(services.AuditActionDelete, "market_holiday"), s.deleteMarketHoliday)
+
+	// Property valuation endpoints
+	api.GET("/property-valuation", s.getPropertyValuation)
+	api.POST("/property-valuation/refresh", s.refreshPropertyValuation)
+	api.GET("/property-valuation/providers", s.getPropertyValuationProviders)
+
+	// Onboarding setup endpoints
+	api.GET("/setup", s.getSetupState)
+	api.POST("/setup/steps/:step/complete", s.completeSetupStep)
+	api.POST("/setup/steps/:step/reset", s.resetSetupStep)
+	api.POST("/setup/seed-categories", s.seedExampleCategories)
+
+	// Audit log endpoints
+	api.GET("/audit", s.getAuditLog)
+
+	// Notification endpoints
+	api.GET("/notifications", s.getNotifications)
+	api.POST("/notifications/:id/read", s.markNotificationRead)
+	api.POST("/notifications/test", s.sendTestNotification)
+	api.GET("/notifications/channels", s.getNotificationChannels)
+	api.GET("/notifications/rules", s.getNotificationRules)
+	api.POST("/notifications/rules", s.audited(services.AuditActionCreate, "notification_rule"), s.createNotificationRule)
+	api.PUT("/notifications/rules/:id", s.audited(services.AuditActionUpdate, "notification_rule"), s.updateNotificationRule)
+	api.DELETE("/notifications/rules/:id", s.audited(services.AuditActionDelete, "notification_rule"), s.deleteNotificationRule)
+
+	// Savings goal endpoints
+	api.GET("/goals", s.getGoals)
+	api.GET("/goals/:id", s.getGoal)
+	api.POST("/goals", s.audited(services.AuditActionCreate, "goal"), s.createGoal)
+	api.PUT("/goals/:id", s.audited(services.AuditActionUpdate, "goal"), s.updateGoal)
+	api.DELETE("/goals/:id", s.audited(services.AuditActionDelete, "goal"), s.deleteGoal)
+
+	// Analytics endpoints
+	api.GET("/analytics/gains-history", s.getGainsHistory)
+	api.GET("/analytics/benchmark", s.getBenchmarkComparison)
+	api.GET("/analytics/exposure", s.getExposure)
+	api.POST("/analytics/what-if", s.postWhatIf)
+	api.GET("/analytics/projection", s.getContributionProjection)
+	api.GET("/analytics/fire", s.getFIRE)
+	api.POST("/analytics/withdrawal-simulation", s.postWithdrawalSimulation)
+	api.GET("/analytics/crypto-income", s.getCryptoIncome)
+
+	// Report endpoints
+	api.GET("/reports/monthly/:month", s.getMonthlyReport)
+	api.GET("/reports/capital-gains", s.getCapitalGainsReport)
+
+	// Security metadata endpoints
+	api.GET("/securities/search", s.searchSecurities)
+	api.GET("/securities/:symbol/lookup", s.lookupSecurity)
+	api.POST("/securities/lookup/backfill", s.backfillSecurityLookups)
+	api.GET("/securities/metadata", s.getSecurityMetadata)
+	api.POST("/securities/metadata/refresh", s.refreshSecurityMetadata)
+	api.PUT("/securities/:symbol/metadata", s.setSecurityMetadata)
+	api.DELETE("/securities/:symbol/metadata", s.deleteSecurityMetadata)
+	api.GET("/securities/:symbol/composition", s.getFundComposition)
+
+	// Attachment endpoints
+	api.GET("/attachments", s.getAttachments)
+	api.POST("/attachments", s.uploadAttachment)
+	api.POST("/attachments/notes", s.audited(services.AuditActionCreate, "attachment"), s.createAttachmentNote)
+	api.GET("/attachments/:id/download", s.downloadAttachment)
+	api.GET("/attachments/:id/url", s.getAttachmentURL)
+	api.PUT("/attachments/:id", s.audited(services.AuditActionUpdate, "attachment"), s.updateAttachmentNote)
+	api.DELETE("/attachments/:id", s.audited(services.AuditActionDelete, "attachment"), s.deleteAttachment)
+
+	// Share link endpoints; GET /share/:token works without signing in
+	api.GET("/share", s.getShareLinks)
+	api.POST("/share", s.audited(services.AuditActionCreate, "share_link"), s.createShareLink)
+	api.GET("/share/:token", s.getSharedSnapshot)
+	api.DELETE("/share/:id", s.audited(services.AuditActionUpdate, "share_link"), s.revokeShareLink)
+
+	// Search endpoints
+	api.GET("/search", s.search)
+
+	// Holding tag endpoints
+	api.GET("/tags", s.getTags)
+	api.POST("/tags", s.audited(services.AuditActionCreate, "tag"), s.createTag)
+	api.PUT("/tags/:id", s.audited(services.AuditActionUpdate, "tag"), s.updateTag)
+	api.DELETE("/tags/:id", s.audited(services.AuditActionDelete, "tag"), s.deleteTag)
+	api.GET("/tags/:id/holdings", s.getTagHoldings)
+	api.POST("/tags/:id/holdings", s.tagHoldings)
+	api.DELETE("/tags/:id/holdings/:type/:holding_id", s.untagHolding)
+
+	// Cash-flow endpoints
+	api.GET("/cash-flow/categories", s.getCashFlowCategories)
+	api.POST("/cash-flow/categories", s.audited(services.AuditActionCreate, "cash_flow_category"), s.createCashFlowCategory)
+	api.PUT("/cash-flow/categories/:id", s.audited(services.AuditActionUpdate, "cash_flow_category"), s.updateCashFlowCategory)
+	api.DELETE("/cash-flow/categories/:id", s.audited(services.AuditActionDelete, "cash_flow_category"), s.deleteCashFlowCategory)
+	api.GET("/cash-flow/transactions", s.getCashFlowTransactions)
+	api.POST("/cash-flow/transactions", s.audited(services.AuditActionCreate, "cash_flow_transaction"), s.createCashFlowTransaction)
+	api.POST("/cash-flow/transactions/import", s.audited(services.AuditActionBulkCreate, "cash_flow_transaction"), s.importCashFlowTransactions)
+	api.PUT("/cash-flow/transactions/:id", s.audited(services.AuditActionUpdate, "cash_flow_transaction"), s.updateCashFlowTransaction)
+	api.DELETE("/cash-flow/transactions/:id", s.audited(services.AuditActionDelete, "cash_flow_transaction"), s.deleteCashFlowTransaction)
+	api.GET("/cash-flow/summary", s.getCashFlowSummary)
+
+	// Recurring contribution endpoints
+	api.GET("/contributions", s.getRecurringContributions)
+	api.GET("/contributions/transactions", s.getContributionTransactions)
+	api.GET("/contributions/projection", s.getContributionProjection)
+	api.GET("/contributions/employer-match", s.getEmployerMatch)
+	api.POST("/contributions/employer-match", s.audited(services.AuditActionCreate, "employer_match_rule"), s.createEmployerMatchRule)
+	api.PUT("/contributions/employer-match/:id", s.audited(services.AuditActionUpdate, "employer_match_rule"), s.updateEmployerMatchRule)
+	api.DELETE("/contributions/employer-match/:id", s.audited(services.AuditActionDelete, "employer_match_rule"), s.deleteEmployerMatchRule)
+	api.POST("/contributions/run", s.runContributions)
+	api.PUT("/contributions/:id", s.audited(services.AuditActionUpdate, "recurring_contribution"), s.updateRecurringContribution)
+	api.POST("/contributions/transactions/:id/confirm", s.confirmContribution)
+	api.POST("/contributions/transactions/:id/skip", s.skipContribution)
+
+	// Credential management endpoints
+	credentialHandler := handlers.NewCredentialHandler(s.credentialManager)
+	handlers.RegisterCredentialRoutes(admin, credentialHandler)
+	
+	// OpenAPI spec download
+	// @Summary Download OpenAPI specification
+	// @Description Download the complete OpenAPI specification in JSON format
+	// @Tags system
+	// @Produce json
+	// @Success 200 {object} object "OpenAPI specification"
+	// @Router /swagger/spec [get]
+	api.GET("/swagger/spec", func(c *gin.Context) {
+		c.Header("Content-Type", "application/json")
+		c.File("docs/swagger.json")
+	})
 }
 
 func (s *Server) Start(addr string) error {
@@ -227,11 +672,131 @@ func (s *Server) Start(addr string) error {
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests to finish
+// or ctx to expire. Readiness fails from the moment shutdown begins so load
+// balancers stop routing new traffic here.
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Server shutting down...")
+	s.shuttingDown.Store(true)
+	if s.httpServer == nil {
+		return nil
+	}
 	return s.httpServer.Shutdown(ctx)
 }
 
+const (
+	// holdingSnapshotInterval is how often today's position snapshot is refreshed
+	holdingSnapshotInterval = time.Hour
+	// benchmarkPriceInterval is how often benchmark prices are recorded
+	benchmarkPriceInterval = 24 * time.Hour
+	// securityMetadataInterval is how often unclassified symbols are looked up
+	securityMetadataInterval = 24 * time.Hour
+	// symbolBackfillInterval is how often missing company names, exchanges and
+	// security types of stock holdings are filled in
+	symbolBackfillInterval = 24 * time.Hour
+	// concentrationCheckInterval is how often positions are checked against the
+	// concentration threshold
+	concentrationCheckInterval = time.Hour
+	// tradingWindowCheckInterval is how often employer stock trading windows
+	// are checked for opening or closing
+	tradingWindowCheckInterval = time.Hour
+	// marketHolidaySyncInterval is how often the holiday calendar is checked
+	// for a new year
+	marketHolidaySyncInterval = 24 * time.Hour
+	// stakingAccrualInterval is how often staked holdings are checked for a
+	// new day of rewards
+	stakingAccrualInterval = time.Hour
+	// employerMatchCheckInterval is how often retirement contributions are
+	// checked against the full employer match
+	employerMatchCheckInterval = 24 * time.Hour
+	// iBondRateCheckInterval is how often the I bond rate schedule is
+	// checked for a missing May or November announcement
+	iBondRateCheckInterval = 24 * time.Hour
+	// insuranceRenewalCheckInterval is how often insurance renewal dates are
+	// rolled forward and checked against their notice period
+	insuranceRenewalCheckInterval = 24 * time.Hour
+	// netWorthSnapshotInterval is how often today's net worth snapshot is refreshed
+	netWorthSnapshotInterval = time.Hour
+	// databasePoolCheckInterval is how often the connection pool is checked for
+	// queries that had to wait for a connection
+	databasePoolCheckInterval = time.Minute
+	// monthlyReportInterval is how often the previous month's report is checked
+	// for delivery
+	monthlyReportInterval = 6 * time.Hour
+	// emailQueueInterval is how often queued emails are retried; new ones are
+	// sent as soon as they are queued
+	emailQueueInterval = time.Minute
+	// pluginScheduleInterval is how often plugin refresh schedules are checked
+	pluginScheduleInterval = time.Minute
+	// assetValuationInterval is how often API-valued other assets are checked
+	// against their category's refresh interval
+	assetValuationInterval = 6 * time.Hour
+	// priceRetentionInterval is how often raw prices past the retention period
+	// are folded into daily prices
+	priceRetentionInterval = 24 * time.Hour
+	// integrityCheckInterval is how often holdings are checked for
+	// inconsistent values
+	integrityCheckInterval = 24 * time.Hour
+)
+
+// StartBackgroundJobs starts jobs that run until ctx is cancelled
+func (s *Server) StartBackgroundJobs(ctx context.Context) {
+	if s.config.Contributions.AutoApply {
+		log.Printf("INFO: Applying recurring contributions every %s", s.config.Contributions.CheckInterval)
+		go s.contributionService.Run(ctx, s.config.Contributions.CheckInterval, s.invalidateCache)
+	}
+
+	go s.gainsHistoryService.Run(ctx, holdingSnapshotInterval)
+	go s.netWorthHistoryService.Run(ctx, netWorthSnapshotInterval, s.repos.NetWorth.Breakdown)
+	go s.securityMetadataService.Run(ctx, securityMetadataInterval)
+	go s.symbolLookupService.Run(ctx, symbolBackfillInterval)
+	go s.pluginManager.RunScheduler(ctx, pluginScheduleInterval, s.invalidateCache)
+	// Metal holdings follow spot prices, which refresh with crypto prices
+	valuationInterval := assetValuationInterval
+	if spot := s.config.API.Current().CryptoCacheRefreshInterval; spot > 0 && spot < valuationInterval {
+		valuationInterval = spot
+	}
+	go s.assetValuationService.Run(ctx, valuationInterval, s.invalidateCache)
+	go s.tradingWindowService.Run(ctx, tradingWindowCheckInterval)
+	go s.marketHolidayService.Run(ctx, marketHolidaySyncInterval)
+	go s.cryptoStakingService.Run(ctx, stakingAccrualInterval, s.invalidateCache)
+	go s.employerMatchService.Run(ctx, employerMatchCheckInterval)
+	go s.iBondService.Run(ctx, iBondRateCheckInterval)
+	go s.insuranceService.Run(ctx, insuranceRenewalCheckInterval)
+	go s.integrityService.Run(ctx, integrityCheckInterval)
+	go database.MonitorPool(ctx, s.db, databasePoolCheckInterval)
+
+	if s.config.Risk.ConcentrationThresholdPercent > 0 {
+		log.Printf("INFO: Flagging symbols above %g%% of total assets", s.config.Risk.ConcentrationThresholdPercent)
+		go s.concentrationRiskService.Run(ctx, concentrationCheckInterval, s.repos.NetWorth.Breakdown)
+	}
+
+	if len(s.config.API.BenchmarkSymbols) > 0 {
+		log.Printf("INFO: Recording benchmark prices for %v daily", s.config.API.BenchmarkSymbols)
+		go s.benchmarkService.Run(ctx, benchmarkPriceInterval, s.config.API.BenchmarkSymbols)
+	}
+
+	if s.mailer.Configured() {
+		log.Printf("INFO: Sending email through %s:%d", s.config.SMTP.Host, s.config.SMTP.Port)
+		go s.mailer.Run(ctx, emailQueueInterval)
+	}
+
+	if s.config.Reports.MonthlyEnabled {
+		log.Printf("INFO: Delivering monthly reports")
+		go s.reportService.Run(ctx, monthlyReportInterval, s.repos.NetWorth.Breakdown)
+	}
+
+	if s.priceRetentionService.Enabled() {
+		log.Printf("INFO: Keeping raw prices for %d days", s.config.History.PriceRetentionDays)
+		go s.priceRetentionService.Run(ctx, priceRetentionInterval)
+	}
+
+	if s.config.Backup.ScheduleEnabled {
+		log.Printf("INFO: Backing up the database every %s", s.config.Backup.Interval)
+		go s.backupService.Run(ctx, s.config.Backup.Interval)
+	}
+}
+
 // Health check endpoint
 // @Summary Health check
 // @Description Get comprehensive system health status including database, plugins, and services
@@ -275,6 +840,8 @@ func (s *Server) healthCheck(c *gin.Context) {
 		"status":     "healthy",
 		"timestamp":  time.Now().Format(time.RFC3339),
 		"database":   dbStatus,
+		"database_pool": database.NewPoolStats(s.db),
+		"timescaledb":   database.TimescaleEnabled(s.db),
 		"plugins": gin.H{
 			"total_count": pluginCount,
 			"available":   pluginList,
@@ -291,11 +858,13 @@ func (s *Server) healthCheck(c *gin.Context) {
 			"is_open": marketOpen,
 		},
 		"crypto_service": gin.H{
+			"provider":        s.cryptoService.GetProviderName(),
 			"symbols_tracked": cryptoSymbolCount,
 		},
 		"property_service": gin.H{
 			"provider": propertyProvider,
 		},
+		"provider_circuits": httpclient.Breakers(),
 		"version": "1.0",
 	})
 }
\ No newline at end of file
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"networth-dashboard/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get onboarding setup state
+// @Description Retrieve first-run setup progress: which onboarding steps are complete, the next step and the base currency
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Success 200 {object} services.SetupState "Current setup state"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup [get]
+func (s *Server) getSetupState(c *gin.Context) {
+	state, err := s.setupService.GetState()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get setup state: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// @Summary Mark setup step complete
+// @Description Mark an onboarding step as complete. The base_currency step requires a "currency" field (USD, EUR, GBP or CAD)
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Param step path string true "Setup step (add_accounts, base_currency, configure_providers, first_refresh)"
+// @Param request body map[string]interface{} false "Optional step data"
+// @Success 200 {object} services.SetupState "Updated setup state"
+// @Failure 400 {object} map[string]interface{} "Unknown step or invalid step data"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup/steps/{step}/complete [post]
+func (s *Server) completeSetupStep(c *gin.Context) {
+	step := c.Param("step")
+	if !s.setupService.IsValidStep(step) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unknown setup step: %s", step),
+		})
+		return
+	}
+
+	var data map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&data); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+
+	if err := s.setupService.CompleteStep(step, data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s.getSetupState(c)
+}
+
+// @Summary Reset setup step
+// @Description Mark an onboarding step as incomplete so the wizard shows it again
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Param step path string true "Setup step (add_accounts, base_currency, configure_providers, first_refresh)"
+// @Success 200 {object} services.SetupState "Updated setup state"
+// @Failure 400 {object} map[string]interface{} "Unknown step"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup/steps/{step}/reset [post]
+func (s *Server) resetSetupStep(c *gin.Context) {
+	step := c.Param("step")
+	if !s.setupService.IsValidStep(step) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unknown setup step: %s", step),
+		})
+		return
+	}
+
+	if err := s.setupService.ResetStep(step); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s.getSetupState(c)
+}
+
+// @Summary Seed example asset categories
+// @Description Add the default example asset categories (vehicles, collectibles, art, etc.) that are missing
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Number of categories added"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup/seed-categories [post]
+func (s *Server) seedExampleCategories(c *gin.Context) {
+	added, err := database.SeedDefaultAssetCategories(s.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Example asset categories seeded",
+		"categories_added": added,
+	})
+}
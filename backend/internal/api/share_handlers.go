@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List share links
+// @Description Every share link with its value mode, expiry, revocation and view count, newest first. Tokens are only shown when a link is created.
+// @Tags share
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Share links"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /share [get]
+func (s *Server) getShareLinks(c *gin.Context) {
+	links, err := s.shareService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch share links"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"share_links": links, "count": len(links)})
+}
+
+// @Summary Create a share link
+// @Description Freeze the current net worth allocation into a read-only snapshot and return a link to it that works without signing in. value_mode controls dollar amounts: masked (default) leaves them out, scaled multiplies them so total assets come to scale_to (default 100000), exact shows them. include_holdings lists holdings by name under each asset class. Links expire after expires_in_days (default 30) unless no_expiry is set. The token is only returned here.
+// @Tags share
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} false "label, value_mode, scale_to, include_holdings, expires_in_days, no_expiry"
+// @Success 201 {object} map[string]interface{} "Share link, token, URL and snapshot"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /share [post]
+func (s *Server) createShareLink(c *gin.Context) {
+	var input services.ShareLinkInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+
+	breakdown, err := s.repos.NetWorth.Breakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth breakdown"})
+		return
+	}
+	tree, err := s.repos.NetWorth.Tree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth breakdown"})
+		return
+	}
+
+	link, snapshot, err := s.shareService.Create(input, breakdown, tree, requestActor(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	setAuditEntityID(c, link.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"share_link": link,
+		"url":        "/api/v1/share/" + link.Token,
+		"snapshot":   snapshot,
+	})
+}
+
+// @Summary View a shared snapshot
+// @Description The read-only allocation snapshot behind a share link token. Works without signing in; unknown, revoked and expired tokens all return 404.
+// @Tags share
+// @Produce json
+// @Param token path string true "Share link token"
+// @Success 200 {object} map[string]interface{} "Allocation snapshot"
+// @Failure 404 {object} map[string]interface{} "Share link not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /share/{token} [get]
+func (s *Server) getSharedSnapshot(c *gin.Context) {
+	snapshot, err := s.shareService.Open(c.Param("token"), time.Now())
+	if errors.Is(err, services.ErrShareLinkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shared snapshot"})
+		return
+	}
+
+	// Snapshots are personal even when anonymized; keep shared caches out
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// @Summary Revoke a share link
+// @Description Stop a share link from working. The link stays listed with its revocation time.
+// @Tags share
+// @Produce json
+// @Param id path int true "Share link ID"
+// @Success 200 {object} map[string]interface{} "Share link revoked"
+// @Failure 400 {object} map[string]interface{} "Invalid share link ID"
+// @Failure 404 {object} map[string]interface{} "Share link not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /share/{id} [delete]
+func (s *Server) revokeShareLink(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link ID"})
+		return
+	}
+
+	err = s.shareService.Revoke(id, time.Now())
+	if errors.Is(err, services.ErrShareLinkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get price symbol health
+// @Description List stock and crypto symbols with consecutive refresh failures, including symbols paused after reaching the failure threshold
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param paused query boolean false "Only return paused symbols"
+// @Success 200 {object} map[string]interface{} "Symbol health records and failure threshold"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /prices/symbols/health [get]
+func (s *Server) getSymbolHealth(c *gin.Context) {
+	pausedOnly := c.Query("paused") == "true"
+
+	records, err := s.symbolHealthService.List(pausedOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get symbol health: %v", err),
+		})
+		return
+	}
+
+	pausedCount := 0
+	for _, record := range records {
+		if record.Paused {
+			pausedCount++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbols":           records,
+		"paused_count":      pausedCount,
+		"failure_threshold": s.symbolHealthService.GetThreshold(),
+	})
+}
+
+// @Summary Re-enable paused symbol
+// @Description Resume price refresh for a symbol that was paused after repeated failures and reset its failure count
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param type path string true "Asset type (stock or crypto)"
+// @Param symbol path string true "Symbol (e.g., AAPL, BTC)"
+// @Success 200 {object} map[string]interface{} "Symbol re-enabled"
+// @Failure 400 {object} map[string]interface{} "Invalid asset type"
+// @Failure 404 {object} map[string]interface{} "Symbol not tracked"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /prices/symbols/{type}/{symbol}/resume [post]
+func (s *Server) resumeSymbol(c *gin.Context) {
+	assetType := strings.ToLower(c.Param("type"))
+	if !s.symbolHealthService.IsValidAssetType(assetType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid asset type: %s (expected stock or crypto)", assetType),
+		})
+		return
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+	if err := s.symbolHealthService.Resume(assetType, symbol); err != nil {
+		if errors.Is(err, services.ErrSymbolNotTracked) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("No refresh health record for %s %s", assetType, symbol),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("Price refresh re-enabled for %s", symbol),
+		"asset_type": assetType,
+		"symbol":     symbol,
+	})
+}
+
+// @Summary Get notifications
+// @Description List in-app notifications, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param unread query boolean false "Only return unread notifications"
+// @Param limit query int false "Maximum number of notifications (default 50)"
+// @Success 200 {object} map[string]interface{} "List of notifications"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications [get]
+func (s *Server) getNotifications(c *gin.Context) {
+	unreadOnly := c.Query("unread") == "true"
+
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	notifications, err := s.notificationService.List(unreadOnly, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to get notifications: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"count":         len(notifications),
+	})
+}
+
+// @Summary Mark notification read
+// @Description Mark an in-app notification as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} map[string]interface{} "Notification marked as read"
+// @Failure 400 {object} map[string]interface{} "Invalid notification ID"
+// @Failure 404 {object} map[string]interface{} "Notification not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/{id}/read [post]
+func (s *Server) markNotificationRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	if err := s.notificationService.MarkRead(id); err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notification not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification marked as read",
+	})
+}
+
+// @Summary Send a test notification
+// @Description Send a test message now through one channel to check its settings. The email channel bypasses the queue. Errors from the SMTP server, Telegram or Discord are returned as-is.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body map[string]interface{} false "Optional channel and email recipient: {\"channel\": \"email\", \"to\": \"me@example.com\"} (default channel email, default recipient NOTIFICATION_EMAIL_TO)"
+// @Success 200 {object} map[string]interface{} "Test notification sent"
+// @Failure 400 {object} map[string]interface{} "Channel not configured or no recipient"
+// @Failure 502 {object} map[string]interface{} "Delivery failed"
+// @Router /notifications/test [post]
+func (s *Server) sendTestNotification(c *gin.Context) {
+	var request struct {
+		Channel string `json:"channel"`
+		To      string `json:"to" binding:"omitempty,email"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+	}
+
+	if channel := strings.ToLower(strings.TrimSpace(request.Channel)); channel != "" && channel != services.NotificationChannelEmail {
+		err := s.notificationService.SendTest(channel)
+		if errors.Is(err, services.ErrUnknownNotificationChannel) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Channel %q is not configured", channel)})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Test notification sent", "channel": channel})
+		return
+	}
+
+	if !s.mailer.Configured() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrMailerNotConfigured.Error()})
+		return
+	}
+
+	email, err := s.mailer.Render(services.EmailTemplateTest, services.TestEmail{
+		Host:   s.config.SMTP.Host,
+		SentAt: time.Now().Format("2006-01-02 15:04:05 MST"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render test email"})
+		return
+	}
+	if to := strings.TrimSpace(request.To); to != "" {
+		email.To = []string{to}
+	}
+	if len(email.To) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrNoEmailRecipients.Error()})
+		return
+	}
+
+	if err := s.mailer.Send(email); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Test email sent",
+		"channel":    services.NotificationChannelEmail,
+		"recipients": email.To,
+	})
+}
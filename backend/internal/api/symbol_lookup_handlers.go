@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// enrichStockSymbol checks a stock holding's symbol with the symbol lookup
+// service and fills in company_name when it is blank, along with exchange and
+// security_type. Unknown symbols are rejected when symbol validation is on.
+// Lookups that fail let the write through, so a provider outage never blocks
+// data entry.
+func (s *Server) enrichStockSymbol(c *gin.Context, data map[string]interface{}) bool {
+	symbol, _ := data["symbol"].(string)
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		// The plugin reports the missing symbol
+		return true
+	}
+
+	match, err := s.symbolLookupService.Resolve(c.Request.Context(), symbol)
+	switch {
+	case errors.Is(err, services.ErrUnknownSymbol):
+		if !s.config.API.Current().SymbolValidationEnabled {
+			return true
+		}
+		respondValidationErrors(c, "Validation failed", []plugins.ValidationError{{
+			Field:   "symbol",
+			Message: fmt.Sprintf("%s is not a known ticker symbol", symbol),
+			Code:    "unknown_symbol",
+		}})
+		return false
+	case errors.Is(err, services.ErrSymbolLookupUnavailable):
+		return true
+	case err != nil:
+		log.Printf("WARNING: Could not look up symbol %s: %v", symbol, err)
+		return true
+	}
+
+	if name, _ := data["company_name"].(string); strings.TrimSpace(name) == "" && match.Name != "" {
+		data["company_name"] = match.Name
+	}
+	if match.Exchange != "" {
+		data["exchange"] = match.Exchange
+	}
+	if match.SecurityType != "" {
+		data["security_type"] = match.SecurityType
+	}
+	return true
+}
+
+// @Summary Search securities
+// @Description Search the price provider for securities by symbol or company name, for picking a symbol when adding a holding. Without a provider API key the built-in dataset of common stocks and funds is searched.
+// @Tags securities
+// @Produce json
+// @Param q query string true "Symbol or company name"
+// @Success 200 {object} map[string]interface{} "Matching securities"
+// @Failure 400 {object} map[string]interface{} "Missing query"
+// @Failure 502 {object} map[string]interface{} "Provider search failed"
+// @Router /securities/search [get]
+func (s *Server) searchSecurities(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	matches, err := s.symbolLookupService.Search(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Symbol search failed: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"matches": matches,
+		"count":   len(matches),
+	})
+}
+
+// @Summary Look up security
+// @Description Check that a ticker symbol exists and return its company name, exchange and security type. Lookups are cached for 30 days; unknown symbols for a day.
+// @Tags securities
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Success 200 {object} map[string]interface{} "Security"
+// @Failure 400 {object} map[string]interface{} "Invalid symbol"
+// @Failure 404 {object} map[string]interface{} "Unknown symbol"
+// @Failure 502 {object} map[string]interface{} "Provider lookup failed"
+// @Failure 503 {object} map[string]interface{} "No symbol lookup provider configured"
+// @Router /securities/{symbol}/lookup [get]
+func (s *Server) lookupSecurity(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+	if symbol == "" || len(symbol) > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid symbol"})
+		return
+	}
+
+	match, err := s.symbolLookupService.Resolve(c.Request.Context(), symbol)
+	switch {
+	case errors.Is(err, services.ErrUnknownSymbol):
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s is not a known ticker symbol", symbol)})
+		return
+	case errors.Is(err, services.ErrSymbolLookupUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No symbol lookup provider is configured"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Symbol lookup failed: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, match)
+}
+
+// @Summary Backfill security lookups
+// @Description Fill in missing company names, exchanges and security types of stock holdings now instead of waiting for the daily backfill. At most 5 symbols not looked up yet are sent to the provider per call.
+// @Tags securities
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Number of holdings updated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /securities/lookup/backfill [post]
+func (s *Server) backfillSecurityLookups(c *gin.Context) {
+	updated, err := s.symbolLookupService.Backfill(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill symbol details"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Filled in details for %d stock holdings", updated),
+		"updated": updated,
+	})
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTagHoldings bounds the holdings tagged in a single request
+const maxTagHoldings = 500
+
+// parseTagFilter reads the comma-separated tag and exclude_tag query parameters
+func parseTagFilter(c *gin.Context) repository.TagFilter {
+	split := func(value string) []string {
+		var names []string
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return repository.TagFilter{
+		Include: split(c.Query("tag")),
+		Exclude: split(c.Query("exclude_tag")),
+	}
+}
+
+// filterHoldings keeps the holdings that pass the request's tag filter and
+// saved view. The result is a new slice, so cached listings are never modified.
+func filterHoldings[T any](s *Server, c *gin.Context, holdingType string, holdings []T, id func(T) int) ([]T, bool) {
+	viewIDs, ok := s.savedViewMatches(c, holdingType)
+	if !ok {
+		return nil, false
+	}
+
+	filter := parseTagFilter(c)
+	if filter.IsEmpty() && viewIDs == nil {
+		return holdings, true
+	}
+
+	matcher, err := s.repos.Tags.HoldingMatcher(holdingType, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply tag filter"})
+		return nil, false
+	}
+
+	filtered := make([]T, 0, len(holdings))
+	for _, holding := range holdings {
+		holdingID := id(holding)
+		if matcher.MatchesID(holdingID) && (viewIDs == nil || viewIDs[holdingID]) {
+			filtered = append(filtered, holding)
+		}
+	}
+	return filtered, true
+}
+
+// symbolTagMatcher returns the matcher for the request's tag filter over stock
+// symbols; it is nil, and matches everything, when no filter was given
+func (s *Server) symbolTagMatcher(c *gin.Context) (*repository.TagMatcher, bool) {
+	matcher, err := s.repos.Tags.SymbolMatcher(parseTagFilter(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply tag filter"})
+		return nil, false
+	}
+	return matcher, true
+}
+
+// symbolFilter adapts a symbol matcher to the services' include function,
+// returning nil when there is no filter
+func symbolFilter(matcher *repository.TagMatcher) func(symbol string) bool {
+	if matcher == nil {
+		return nil
+	}
+	return matcher.Matches
+}
+
+// filterStocksByTags keeps the consolidated positions whose symbol passes the
+// matcher. The result is a new slice, so cached listings are never modified.
+func filterStocksByTags(stocks []models.StockConsolidation, matcher *repository.TagMatcher) []models.StockConsolidation {
+	if matcher == nil {
+		return stocks
+	}
+
+	filtered := make([]models.StockConsolidation, 0, len(stocks))
+	for _, stock := range stocks {
+		if matcher.Matches(strings.ToUpper(stock.Symbol)) {
+			filtered = append(filtered, stock)
+		}
+	}
+	return filtered
+}
+
+// respondTagError maps tag repository errors to HTTP responses
+func (s *Server) respondTagError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidHoldingType), errors.Is(err, repository.ErrUnknownHolding):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrDuplicateTag):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.respondRepositoryError(c, err, notFoundMsg, failureMsg)
+	}
+}
+
+// bindTag binds and validates a tag body
+func bindTag(c *gin.Context) (*models.TagInput, bool) {
+	var input models.TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return nil, false
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return nil, false
+	}
+	if len(input.Name) > 50 || strings.Contains(input.Name, ",") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 50 characters and contain no commas"})
+		return nil, false
+	}
+
+	return &input, true
+}
+
+// @Summary Get tags
+// @Description List user-defined holding tags with the number of holdings carrying each
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Tags"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags [get]
+func (s *Server) getTags(c *gin.Context) {
+	tags, err := s.repos.Tags.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags":  tags,
+		"count": len(tags),
+	})
+}
+
+// @Summary Create tag
+// @Description Create a holding tag such as ESG, speculative or employer. Names are unique ignoring case.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body map[string]interface{} true "Tag (name, color, description)"
+// @Success 201 {object} map[string]interface{} "Tag created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Tag already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags [post]
+func (s *Server) createTag(c *gin.Context) {
+	input, ok := bindTag(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.repos.Tags.Create(*input)
+	if err != nil {
+		s.respondTagError(c, err, "Tag not found", "Failed to create tag")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Tag created successfully",
+	})
+}
+
+// @Summary Update tag
+// @Description Rename a tag or change its color and description
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param tag body map[string]interface{} true "Tag (name, color, description)"
+// @Success 200 {object} map[string]interface{} "Tag updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Tag not found"
+// @Failure 409 {object} map[string]interface{} "Tag already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/{id} [put]
+func (s *Server) updateTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	input, ok := bindTag(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repos.Tags.Update(id, *input); err != nil {
+		s.respondTagError(c, err, "Tag not found", "Failed to update tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag updated successfully",
+	})
+}
+
+// @Summary Delete tag
+// @Description Delete a tag and remove it from every holding
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} map[string]interface{} "Tag deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid tag ID"
+// @Failure 404 {object} map[string]interface{} "Tag not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/{id} [delete]
+func (s *Server) deleteTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	if err := s.repos.Tags.Delete(id); err != nil {
+		s.respondTagError(c, err, "Tag not found", "Failed to delete tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// @Summary Get tagged holdings
+// @Description List the holdings carrying a tag by holding type and ID
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} map[string]interface{} "Tagged holdings"
+// @Failure 400 {object} map[string]interface{} "Invalid tag ID"
+// @Failure 404 {object} map[string]interface{} "Tag not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/{id}/holdings [get]
+func (s *Server) getTagHoldings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	holdings, err := s.repos.Tags.ListHoldings(id)
+	if err != nil {
+		s.respondTagError(c, err, "Tag not found", "Failed to fetch tagged holdings")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"holdings": holdings,
+		"count":    len(holdings),
+	})
+}
+
+// @Summary Tag holdings
+// @Description Attach a tag to holdings in one transaction. holding_type is stock_holding, equity_grant, real_estate, cash_holding, crypto_holding or other_asset. Holdings that already carry the tag are unchanged.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param request body map[string]interface{} true "Holdings to tag: {\"holdings\": [{\"holding_type\": \"stock_holding\", \"holding_id\": 1}]}"
+// @Success 200 {object} map[string]interface{} "Holdings tagged successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request or unknown holding"
+// @Failure 404 {object} map[string]interface{} "Tag not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/{id}/holdings [post]
+func (s *Server) tagHoldings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	var request struct {
+		Holdings []models.HoldingRef `json:"holdings" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+	if len(request.Holdings) == 0 || len(request.Holdings) > maxTagHoldings {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("holdings must list between 1 and %d holdings", maxTagHoldings)})
+		return
+	}
+
+	if err := s.repos.Tags.Attach(id, request.Holdings); err != nil {
+		s.respondTagError(c, err, "Tag not found", "Failed to tag holdings")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Holdings tagged successfully",
+		"count":   len(request.Holdings),
+	})
+}
+
+// @Summary Untag holding
+// @Description Remove a tag from one holding
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param type path string true "Holding type"
+// @Param holding_id path int true "Holding ID"
+// @Success 200 {object} map[string]interface{} "Holding untagged successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid parameters"
+// @Failure 404 {object} map[string]interface{} "Holding does not carry the tag"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/{id}/holdings/{type}/{holding_id} [delete]
+func (s *Server) untagHolding(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	holding := models.HoldingRef{HoldingType: c.Param("type")}
+	if !repository.IsHoldingType(holding.HoldingType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holding type"})
+		return
+	}
+	if holding.HoldingID, err = strconv.Atoi(c.Param("holding_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holding ID"})
+		return
+	}
+
+	if err := s.repos.Tags.Detach(id, holding); err != nil {
+		s.respondTagError(c, err, "Holding does not carry this tag", "Failed to untag holding")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Holding untagged successfully",
+	})
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondTradingWindowError maps trading window service errors to HTTP responses
+func respondTradingWindowError(c *gin.Context, err error, failureMsg string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTradingWindow):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTradingWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Trading window not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failureMsg})
+	}
+}
+
+// @Summary Get trading status
+// @Description Whether each company's stock can be traded today. A company with open windows can only be traded inside one; blackouts close trading even inside an open window; a company with no windows can always be traded. next_open_date or next_close_date says when that next changes.
+// @Tags equity
+// @Produce json
+// @Param symbol query string false "Comma-separated company symbols; defaults to every company with grants or windows"
+// @Success 200 {object} map[string]interface{} "Trading status by company symbol"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/trading-status [get]
+func (s *Server) getTradingStatus(c *gin.Context) {
+	var symbols []string
+	if param := c.Query("symbol"); param != "" {
+		for _, symbol := range strings.Split(param, ",") {
+			if symbol = strings.TrimSpace(symbol); symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	} else {
+		grants, err := s.repos.Equity.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch equity grants"})
+			return
+		}
+		for _, grant := range grants {
+			symbols = append(symbols, grant.CompanySymbol)
+		}
+	}
+
+	statuses, err := s.tradingWindowService.Statuses(symbols, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trading status"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trading_status": statuses})
+}
+
+// @Summary Get trading windows
+// @Description List open trading windows and blackout periods, by company and start date
+// @Tags equity
+// @Produce json
+// @Param symbol query string false "Only this company's windows"
+// @Success 200 {object} map[string]interface{} "Trading windows"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/trading-windows [get]
+func (s *Server) getTradingWindows(c *gin.Context) {
+	windows, err := s.tradingWindowService.List(c.Query("symbol"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trading windows"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trading_windows": windows, "count": len(windows)})
+}
+
+// @Summary Create trading window
+// @Description Add an open trading window or blackout period for a company's stock. Dates are inclusive.
+// @Tags equity
+// @Accept json
+// @Produce json
+// @Param window body map[string]interface{} true "Window: {\"company_symbol\": \"ACME\", \"window_type\": \"blackout\", \"start_date\": \"2025-03-15\", \"end_date\": \"2025-04-30\", \"note\": \"Q1 earnings\"}"
+// @Success 201 {object} map[string]interface{} "Trading window created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/trading-windows [post]
+func (s *Server) createTradingWindow(c *gin.Context) {
+	var input services.TradingWindowInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	id, err := s.tradingWindowService.Create(input)
+	if err != nil {
+		respondTradingWindowError(c, err, "Failed to create trading window")
+		return
+	}
+
+	setAuditEntityID(c, id)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "Trading window created successfully",
+	})
+}
+
+// @Summary Update trading window
+// @Description Replace a trading window's company, type, dates and note
+// @Tags equity
+// @Accept json
+// @Produce json
+// @Param id path int true "Trading window ID"
+// @Param window body map[string]interface{} true "Window (company_symbol, window_type, start_date, end_date, note)"
+// @Success 200 {object} map[string]interface{} "Trading window updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Trading window not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/trading-windows/{id} [put]
+func (s *Server) updateTradingWindow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trading window ID"})
+		return
+	}
+
+	var input services.TradingWindowInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindingError(c, err)
+		return
+	}
+
+	if err := s.tradingWindowService.Update(id, input); err != nil {
+		respondTradingWindowError(c, err, "Failed to update trading window")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trading window updated successfully"})
+}
+
+// @Summary Delete trading window
+// @Description Delete a trading window
+// @Tags equity
+// @Produce json
+// @Param id path int true "Trading window ID"
+// @Success 200 {object} map[string]interface{} "Trading window deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid trading window ID"
+// @Failure 404 {object} map[string]interface{} "Trading window not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/trading-windows/{id} [delete]
+func (s *Server) deleteTradingWindow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trading window ID"})
+		return
+	}
+
+	if err := s.tradingWindowService.Delete(id); err != nil {
+		respondTradingWindowError(c, err, "Failed to delete trading window")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trading window deleted successfully"})
+}
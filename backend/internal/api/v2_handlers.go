@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/cache"
+	"networth-dashboard/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerV2Routes registers the v2 API. v2 responds with typed bodies and
+// paginates every list; endpoints move here one at a time and the rest stay
+// on v1 until they do.
+func (s *Server) registerV2Routes(api *gin.RouterGroup) {
+	api.GET("/net-worth", s.getNetWorthV2)
+
+	api.GET("/stocks", listHoldingsV2(s, models.HoldingTypeStock, s.repos.Stocks.List,
+		func(h models.StockHolding) int { return h.ID }, "Failed to fetch stock holdings"))
+	api.GET("/cash-holdings", listHoldingsV2(s, models.HoldingTypeCash, s.repos.Cash.List,
+		func(h models.CashHolding) int { return h.ID }, "Failed to fetch cash holdings"))
+	api.GET("/crypto-holdings", listHoldingsV2(s, models.HoldingTypeCrypto, s.repos.Crypto.List,
+		func(h models.CryptoHolding) int { return h.ID }, "Failed to fetch crypto holdings"))
+
+	api.GET("/goals", s.getGoalsV2)
+}
+
+// getNetWorthV2 returns the net worth summary as a typed body, from the same
+// cache as v1. Like v1 it takes member and include_custodial parameters.
+func (s *Server) getNetWorthV2(c *gin.Context) {
+	if member := c.Query("member"); member != "" {
+		if excludeCustodial(c) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "member cannot be combined with include_custodial=false"})
+			return
+		}
+		s.respondMemberNetWorth(c, member)
+		return
+	}
+	if excludeCustodial(c) {
+		s.respondPersonalNetWorth(c)
+		return
+	}
+
+	summary, hit, err := cache.GetOrLoad(s.cache, cache.KeyNetWorth, s.config.Cache.TTL, s.calculateNetWorth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+
+	setCacheStatus(c, hit)
+	c.JSON(http.StatusOK, summary)
+}
+
+// listHoldingsV2 returns a handler serving one page of a holding list, with
+// the same tag, exclude_tag and view filters as v1
+func listHoldingsV2[T any](s *Server, holdingType string, load func() ([]T, error), id func(T) int, failure string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, ok := parsePageRequest(c)
+		if !ok {
+			return
+		}
+
+		holdings, err := load()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+			return
+		}
+
+		holdings, ok = filterHoldings(s, c, holdingType, holdings, id)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, paginate(holdings, request))
+	}
+}
+
+// getGoalsV2 returns one page of savings goals with their progress
+func (s *Server) getGoalsV2(c *gin.Context) {
+	request, ok := parsePageRequest(c)
+	if !ok {
+		return
+	}
+
+	goals, err := s.repos.Goals.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
+		return
+	}
+
+	page := paginate(goals, request)
+	if err := s.repos.Goals.AttachProgress(page.Items, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate goal progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
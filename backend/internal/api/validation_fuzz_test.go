@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/dbtest"
+	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/repository"
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// injected ends every string in a fuzzed body, and names an extra field, so
+// a test can tell input that reached the SQL text rather than a placeholder
+const injected = `'";--injected`
+
+// sqlRecorder is a database that answers every query with no rows and keeps
+// the SQL it was sent
+type sqlRecorder struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+// reset forgets the SQL seen so far
+func (r *sqlRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = nil
+}
+
+func (r *sqlRecorder) answer(query string) dbtest.Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, query)
+	return dbtest.Result{}
+}
+
+// checkNoInput fails t if any SQL the recorder saw carries fuzzed input
+func (r *sqlRecorder) checkNoInput(t *testing.T) {
+	t.Helper()
+	for _, query := range r.queries {
+		if strings.Contains(query, "injected") {
+			t.Fatalf("input reached the SQL text: %s", query)
+		}
+	}
+}
+
+// injectedBody decodes body as a JSON object and marks every string in it
+// and one extra field with injected. It returns body unchanged when it is
+// not an object, to exercise binding errors.
+func injectedBody(body string) string {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil || data == nil {
+		return body
+	}
+	var mark func(v interface{}) interface{}
+	mark = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return v + injected
+		case []interface{}:
+			for i := range v {
+				v[i] = mark(v[i])
+			}
+		case map[string]interface{}:
+			for k := range v {
+				v[k] = mark(v[k])
+			}
+		}
+		return v
+	}
+	mark(data)
+	data["column"+injected] = "value"
+	marked, _ := json.Marshal(data)
+	return string(marked)
+}
+
+// updateColumn matches one assignment in an UPDATE built by sqlbuilder
+var updateColumn = regexp.MustCompile(`^"([a-z_]+)" = \$\d+$`)
+
+func FuzzUpdateAssetCategory(f *testing.F) {
+	f.Add(`{"name": "Vehicles", "is_active": false, "sort_order": 3}`)
+	f.Add(`{"description": "x'); DROP TABLE asset_categories; --", "icon": "car", "color": "#fff"}`)
+	f.Add(`{"custom_schema": {"fields": [{"name": "vin", "type": "text", "label": "VIN"}]}}`)
+	f.Add(`{"valuation_api_config": {"provider": "depreciation"}}`)
+	f.Add(`{"name": "   "}`)
+	f.Add(`not json`)
+
+	// Columns an asset category update may set
+	allowed := map[string]bool{
+		"name": true, "description": true, "icon": true, "color": true, "custom_schema": true,
+		"valuation_api_config": true, "is_active": true, "sort_order": true, "updated_at": true,
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := &sqlRecorder{}
+	db := dbtest.Open(0, recorder.answer)
+	defer db.Close()
+	s := &Server{
+		db:                    db,
+		repos:                 repository.New(db, nil),
+		assetValuationService: services.NewAssetValuationService(db, &config.ApiConfig{}, nil),
+	}
+	r := gin.New()
+	r.PUT("/asset-categories/:id", s.updateAssetCategory)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		recorder.reset()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/asset-categories/1", strings.NewReader(injectedBody(body))))
+
+		// Every update affects no rows, so it is either refused or not found
+		if w.Code != http.StatusBadRequest && w.Code != http.StatusNotFound {
+			t.Fatalf("update = %d: %s", w.Code, w.Body)
+		}
+		recorder.checkNoInput(t)
+		for _, query := range recorder.queries {
+			set, ok := strings.CutPrefix(query, `UPDATE "asset_categories" SET `)
+			if !ok {
+				t.Fatalf("unexpected SQL: %s", query)
+			}
+			set, _, _ = strings.Cut(set, " WHERE ")
+			for _, assignment := range strings.Split(set, ", ") {
+				m := updateColumn.FindStringSubmatch(assignment)
+				if m == nil || !allowed[m[1]] {
+					t.Fatalf("update sets %q: %s", assignment, query)
+				}
+			}
+		}
+	})
+}
+
+func FuzzUpdateManualEntry(f *testing.F) {
+	f.Add("cash_holdings", `{"institution_name": "Chase", "account_name": "Checking", "account_type": "checking", "current_balance": 100}`)
+	f.Add("crypto_holdings", `{"institution_name": "Coinbase", "crypto_symbol": "BTC", "balance_tokens": 0.5}`)
+	f.Add("stock_holding", `{"symbol": "AAPL", "institution_name": "Fidelity", "shares_owned": 10}`)
+	f.Add("stock_holding", `{"symbol": "AAPL'; DROP TABLE stock_holdings; --", "institution_name": "Fidelity", "shares_owned": 10}`)
+	f.Add("real_estate", `{"property_name": "Home", "property_type": "single_family", "purchase_price": 1, "current_value": 2, "purchase_date": "2020-01-01"}`)
+	f.Add("other_assets", `{"asset_name": "Car", "asset_category_id": 1, "current_value": 5000, "custom_fields": {"vin": "1"}}`)
+	f.Add("private_investments", `{"investment_name": "Fund", "sponsor": "Acme Capital", "amount_invested": 10}`)
+	f.Add("morgan_stanley", `{"company_symbol": "ACME", "grant_type": "rsu", "total_shares": 100, "grant_date": "2024-01-01", "vest_start_date": "2024-01-01"}`)
+	f.Add("unknown", `{}`)
+	f.Add("cash_holdings", `[1, 2]`)
+
+	gin.SetMode(gin.TestMode)
+	recorder := &sqlRecorder{}
+	db := dbtest.Open(0, recorder.answer)
+	defer db.Close()
+	s := &Server{db: db, pluginManager: plugins.NewManager(db)}
+	r := gin.New()
+	r.PUT("/manual-entries/:id", s.updateManualEntry)
+
+	f.Fuzz(func(t *testing.T, entryType, body string) {
+		// As given, the body can pass validation and reach the database; once
+		// marked, strings carry SQL metacharacters
+		for _, body := range []string{body, injectedBody(body)} {
+			recorder.reset()
+			req := httptest.NewRequest(http.MethodPut, "/manual-entries/1", strings.NewReader(body))
+			req.URL.RawQuery = "type=" + url.QueryEscape(entryType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code >= http.StatusInternalServerError {
+				t.Fatalf("update = %d: %s", w.Code, w.Body)
+			}
+			recorder.checkNoInput(t)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"networth-dashboard/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxVestWithholdingMonths bounds how far ahead vests are estimated
+const maxVestWithholdingMonths = 120
+
+// parsePercentQuery reads a percentage between 0 and 100 from the named query
+// parameter, falling back to def when it is absent
+func parsePercentQuery(c *gin.Context, name string, def float64) (float64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return def, true
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a percentage between 0 and 100", name)})
+		return 0, false
+	}
+	return percent, true
+}
+
+// @Summary Estimate tax withholding at vest
+// @Description For RSU vests, and RSA vests without an 83(b) election, over the coming months: the tax withheld at the configured federal, state and FICA withholding rates, the whole shares withheld (or sold to cover) with the cash refunded for rounding up, and the net shares delivered with their value at the current price. Each rate can be overridden for the request. With expected_tax_percent, shortfall is the tax the vest income is expected to owe beyond what is withheld.
+// @Tags equity
+// @Produce json
+// @Param months query int false "Months ahead to include (default 12, max 120)"
+// @Param federal_percent query number false "Federal withholding rate (default RSU_FEDERAL_WITHHOLDING_PERCENT)"
+// @Param state_percent query number false "State withholding rate (default RSU_STATE_WITHHOLDING_PERCENT)"
+// @Param fica_percent query number false "Social Security and Medicare withholding rate (default RSU_FICA_WITHHOLDING_PERCENT)"
+// @Param expected_tax_percent query number false "Total rate the vest income is expected to be taxed at"
+// @Success 200 {object} map[string]interface{} "Withholding by vest with totals"
+// @Failure 400 {object} map[string]interface{} "Invalid months or rate"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /equity/vest-withholding [get]
+func (s *Server) getVestWithholding(c *gin.Context) {
+	months := 12
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > maxVestWithholdingMonths {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("months must be between 1 and %d", maxVestWithholdingMonths),
+			})
+			return
+		}
+		months = parsed
+	}
+
+	federal, ok := parsePercentQuery(c, "federal_percent", s.config.Tax.Current().VestFederalWithholdingPercent)
+	if !ok {
+		return
+	}
+	state, ok := parsePercentQuery(c, "state_percent", s.config.Tax.Current().VestStateWithholdingPercent)
+	if !ok {
+		return
+	}
+	fica, ok := parsePercentQuery(c, "fica_percent", s.config.Tax.Current().VestFICAWithholdingPercent)
+	if !ok {
+		return
+	}
+	var expectedTaxPercent *float64
+	if c.Query("expected_tax_percent") != "" {
+		expected, ok := parsePercentQuery(c, "expected_tax_percent", 0)
+		if !ok {
+			return
+		}
+		expectedTaxPercent = &expected
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	estimate, err := services.EstimateVestWithholding(s.db, from, from.AddDate(0, months, 0),
+		services.NewWithholdingRates(federal, state, fica), expectedTaxPercent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate vest withholding"})
+		return
+	}
+	c.JSON(http.StatusOK, estimate)
+}
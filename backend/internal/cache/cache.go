@@ -0,0 +1,96 @@
+// Package cache provides a read-through cache for expensive aggregate queries,
+// backed by process memory by default or Redis when configured.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"networth-dashboard/internal/config"
+)
+
+// Cache keys for cached API responses
+const (
+	KeyNetWorth           = "net_worth"
+	KeyNetWorthBreakdown  = "net_worth:breakdown"
+	KeyNetWorthTree       = "net_worth:tree"
+	KeyConsolidatedStocks = "stocks:consolidated"
+	KeyPricesStatus       = "prices:status"
+	KeyDashboardSummary   = "dashboard:summary"
+)
+
+// Cache stores JSON-encodable values with a time to live
+type Cache interface {
+	// Get decodes the cached value for key into dest, reporting whether it was found
+	Get(key string, dest interface{}) (bool, error)
+	// Set stores value under key for ttl
+	Set(key string, value interface{}, ttl time.Duration) error
+	// Invalidate removes the given keys, or every key when none are given
+	Invalidate(keys ...string) error
+	// Name identifies the backend ("memory", "redis" or "disabled")
+	Name() string
+}
+
+// New creates the cache selected by configuration. Redis is used when a URL is
+// configured and reachable; otherwise values are cached in memory.
+func New(cfg config.CacheConfig) Cache {
+	if !cfg.Enabled {
+		log.Printf("INFO: Response cache disabled")
+		return noopCache{}
+	}
+
+	if cfg.RedisURL != "" {
+		redisCache, err := newRedisCache(cfg.RedisURL, cfg.KeyPrefix)
+		if err == nil {
+			log.Printf("INFO: Response cache using Redis")
+			return redisCache
+		}
+		log.Printf("WARNING: Redis cache unavailable, falling back to in-memory cache: %v", err)
+	}
+
+	log.Printf("INFO: Response cache using in-memory store")
+	return newMemoryCache()
+}
+
+// GetOrLoad returns the cached value for key, or calls load, caches its result for
+// ttl and returns it. Cache errors are logged and never fail the request. The
+// boolean result reports whether the value came from the cache.
+func GetOrLoad[T any](c Cache, key string, ttl time.Duration, load func() (T, error)) (T, bool, error) {
+	var cached T
+	found, err := c.Get(key, &cached)
+	if err != nil {
+		log.Printf("WARNING: Cache read for %s failed: %v", key, err)
+	}
+	if found {
+		return cached, true, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, false, err
+	}
+
+	if err := c.Set(key, value, ttl); err != nil {
+		log.Printf("WARNING: Cache write for %s failed: %v", key, err)
+	}
+	return value, false, nil
+}
+
+// encode serializes a value for storage
+func encode(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache value: %w", err)
+	}
+	return data, nil
+}
+
+// noopCache never stores anything
+type noopCache struct{}
+
+func (noopCache) Get(key string, dest interface{}) (bool, error)             { return false, nil }
+func (noopCache) Set(key string, value interface{}, ttl time.Duration) error { return nil }
+func (noopCache) Invalidate(keys ...string) error                            { return nil }
+func (noopCache) Name() string                                               { return "disabled" }
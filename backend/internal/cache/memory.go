@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// memoryCache is a process-local cache. Values are stored JSON-encoded so callers
+// get an independent copy, matching the Redis backend.
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string]memoryEntry)}
+}
+
+func (m *memoryCache) Get(key string, dest interface{}) (bool, error) {
+	m.mu.RLock()
+	entry, ok := m.entries[key]
+	m.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(entry.data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *memoryCache) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.entries[key] = memoryEntry{data: data, expiresAt: time.Now().Add(ttl)}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memoryCache) Invalidate(keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(keys) == 0 {
+		m.entries = make(map[string]memoryEntry)
+		return nil
+	}
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *memoryCache) Name() string {
+	return "memory"
+}
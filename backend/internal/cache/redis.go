@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisTimeout bounds every Redis call so a slow cache never stalls a request
+const redisTimeout = 2 * time.Second
+
+// redisCache stores values in Redis under a key prefix so it can share a server
+type redisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisCache(url, prefix string) (*redisCache, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &redisCache{client: client, prefix: prefix}, nil
+}
+
+func (r *redisCache) Get(key string, dest interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	data, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *redisCache) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return r.client.Set(ctx, r.prefix+key, data, ttl).Err()
+}
+
+func (r *redisCache) Invalidate(keys ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if len(keys) > 0 {
+		prefixed := make([]string, len(keys))
+		for i, key := range keys {
+			prefixed[i] = r.prefix + key
+		}
+		return r.client.Del(ctx, prefixed...).Err()
+	}
+
+	// Remove everything under our prefix without blocking Redis with KEYS
+	iter := r.client.Scan(ctx, 0, r.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+func (r *redisCache) Name() string {
+	return "redis"
+}
@@ -4,24 +4,64 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Security SecurityConfig
-	API      ApiConfig
-	Market   MarketConfig
+	Database      DatabaseConfig
+	Server        ServerConfig
+	Security      SecurityConfig
+	API           ApiConfig
+	Market        MarketConfig
+	Cache         CacheConfig
+	Tracing       TracingConfig
+	Contributions ContributionsConfig
+	Risk          RiskConfig
+	Tax           TaxConfig
+	Reports       ReportsConfig
+	SMTP          SMTPConfig
+	Notifications NotificationsConfig
+	Auth          AuthConfig
+	History       HistoryConfig
+	Attachments   AttachmentsConfig
+	Storage       StorageConfig
+	Backup        BackupConfig
+	Demo          DemoConfig
+
+	// settings holds every setting's resolved value by name, so a reload can
+	// tell which ones changed
+	settings map[string]setting
+	// guard serializes reloads with reads of the settings they change
+	guard guard
 }
 
+// Database drivers DB_DRIVER selects
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 type DatabaseConfig struct {
+	// Driver is DriverPostgres, or DriverSQLite to keep everything in the
+	// single file at Path
+	Driver   string
+	Path     string
 	Host     string
 	Port     int
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+	// Connection pool limits; connections are recycled after ConnMaxLifetime,
+	// or after ConnMaxIdleTime unused, so the pool shrinks back after a burst
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// Timescale is "auto" to store time series as TimescaleDB hypertables
+	// when the extension is available, "on" to require it or "off"
+	Timescale string
 }
 
 type ServerConfig struct {
@@ -37,6 +77,8 @@ type SecurityConfig struct {
 	JWTSecret       string
 	EncryptionKey   string
 	CredentialKey   string
+	// Key for encrypting sensitive columns (wallet addresses, account numbers) at rest
+	FieldEncryptionKey string
 	RateLimitEnable bool
 	RateLimitRPS    int
 }
@@ -57,11 +99,240 @@ type ApiConfig struct {
 	FallbackPriceProvider  string
 	
 	CacheRefreshInterval   time.Duration
+
+	// Crypto price provider selection ("coingecko" or "coinmarketcap")
+	CryptoPriceProvider        string
+	CoinGeckoAPIKey            string
+	CoinGeckoDailyLimit        int
+	CoinGeckoRateLimit         int
+	CoinMarketCapAPIKey        string
+	CoinMarketCapDailyLimit    int
+	CoinMarketCapRateLimit     int
+	CryptoCacheRefreshInterval time.Duration
+
+	// Consecutive refresh failures before a symbol is paused (0 disables auto-pause)
+	SymbolFailureThreshold int
+
+	// Reject stock symbols the price provider's symbol search does not know
+	SymbolValidationEnabled bool
+
+	// Refresh the most valuable stock positions first and defer the rest once
+	// the provider rate limits
+	PrioritizeRefreshByValue bool
+
+	// Benchmark ETFs whose prices are recorded daily for performance comparison
+	BenchmarkSymbols []string
+
 	AttomDataAPIKey        string
 	AttomDataBaseURL       string
+	// Further property valuation providers, each used when its key is set
+	RentcastAPIKey         string
+	RentcastBaseURL        string
+	HouseCanaryAPIKey      string
+	HouseCanaryAPISecret   string
+	HouseCanaryBaseURL     string
+	// Vehicle valuation for other assets
+	MarketCheckAPIKey      string
+	MarketCheckBaseURL     string
+	// eBay sold listings for collectibles (Marketplace Insights application keys)
+	EbayClientID           string
+	EbayClientSecret       string
+	EbayBaseURL            string
+	// Precious metal spot prices, refreshed on the crypto price interval
+	MetalsPriceBaseURL     string
 	// Feature flags for property valuation
 	PropertyValuationEnabled bool
 	AttomDataEnabled         bool
+	// How long a property's or other asset's value counts as current before
+	// it should be revalued
+	PropertyValuationMaxAge   time.Duration
+	OtherAssetValuationMaxAge time.Duration
+
+	// Retries and circuit breaking for stock, crypto and property provider calls
+	HTTPRetry HTTPRetryConfig
+
+	// guard is shared with Config, see Current
+	guard guard
+}
+
+// HTTPRetryConfig controls how provider HTTP calls recover from transient failures
+type HTTPRetryConfig struct {
+	// Retries after the first attempt for network errors, 429 and 5xx responses
+	MaxRetries int
+	// Exponential backoff bounds; each delay is jittered
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Consecutive failures that open a host's circuit, and how long it stays open
+	// (0 disables circuit breaking)
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// AuthConfig controls sign-in and role-based access to the API
+type AuthConfig struct {
+	// Enabled requires a session token on API requests; when off every caller
+	// is treated as an admin
+	Enabled    bool
+	SessionTTL time.Duration
+	// Admin account created on startup when no users exist yet
+	BootstrapUsername string
+	BootstrapPassword string
+}
+
+type HistoryConfig struct {
+	// ChangeSnapshotThreshold records an extra net worth snapshot when a
+	// change moves net worth by more than this many dollars since the last
+	// snapshot (0 disables)
+	ChangeSnapshotThreshold float64
+	// PriceRetentionDays keeps every recorded stock and crypto price this many
+	// days; older ones are folded into one row per symbol and day (0 keeps
+	// them all)
+	PriceRetentionDays int
+
+	// guard is shared with Config, see Current
+	guard guard
+}
+
+// AttachmentsConfig controls files attached to holdings
+type AttachmentsConfig struct {
+	// MaxSizeBytes bounds a single uploaded file
+	MaxSizeBytes int64
+}
+
+// StorageConfig selects where attachment files and other documents are kept
+type StorageConfig struct {
+	// Backend is "local" (a directory on disk) or "s3" (S3 or an S3-compatible
+	// server such as MinIO)
+	Backend string
+	// LocalDir is the directory the local backend stores objects in
+	LocalDir string
+	S3       S3Config
+	// SignedURLTTL bounds how long a signed download URL stays valid
+	SignedURLTTL time.Duration
+}
+
+// BackupConfig controls database backups written to the storage backend
+type BackupConfig struct {
+	// ScheduleEnabled takes a backup every Interval
+	ScheduleEnabled bool
+	Interval        time.Duration
+	// RetentionCount keeps at most this many backups (0 keeps any number)
+	RetentionCount int
+	// RetentionDays removes backups older than this (0 keeps them regardless of age)
+	RetentionDays int
+	// PgDumpPath and PgRestorePath locate the PostgreSQL client tools
+	PgDumpPath    string
+	PgRestorePath string
+}
+
+// DemoConfig controls the sample portfolio used to evaluate the dashboard
+type DemoConfig struct {
+	// Enabled loads demo data at startup into a database without holdings
+	Enabled bool
+}
+
+// S3Config locates the bucket used by the s3 storage backend
+type S3Config struct {
+	// Endpoint is the server URL; empty uses AWS S3 in Region
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket in the URL path, as MinIO expects,
+	// rather than as a subdomain
+	PathStyle bool
+	// Prefix is prepended to every object key
+	Prefix string
+}
+type CacheConfig struct {
+	// Enabled turns the read-through response cache on or off
+	Enabled bool
+	// TTL bounds how stale a cached aggregate can be
+	TTL time.Duration
+	// RedisURL selects a shared Redis cache; empty uses an in-memory cache
+	RedisURL  string
+	KeyPrefix string
+}
+
+type TracingConfig struct {
+	// Enabled exports spans over OTLP/HTTP; the endpoint comes from OTEL_EXPORTER_OTLP_ENDPOINT
+	Enabled     bool
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded (0.0-1.0)
+	SampleRatio float64
+}
+
+type ContributionsConfig struct {
+	// AutoApply runs the background job that applies due monthly contributions
+	AutoApply bool
+	// CheckInterval is how often the job looks for due contributions
+	CheckInterval time.Duration
+	// RequireConfirmation makes new schedules wait for confirmation before
+	// changing a balance
+	RequireConfirmation bool
+}
+
+type RiskConfig struct {
+	// ConcentrationThresholdPercent flags a single symbol above this share of
+	// total assets (0 disables)
+	ConcentrationThresholdPercent float64
+	// StablecoinsAsCash reports crypto in StablecoinSymbols as cash rather
+	// than crypto in the allocation breakdown and concentration risk
+	StablecoinsAsCash bool
+	StablecoinSymbols []string
+}
+
+type TaxConfig struct {
+	// Capital gains tax rates, in percent, used to estimate the tax on
+	// hypothetical sales and on realized gains
+	ShortTermCapitalGainsPercent float64
+	LongTermCapitalGainsPercent  float64
+	// DepreciationRecapturePercent taxes the part of a property sale's gain
+	// that comes from depreciation taken (unrecaptured section 1250 gain)
+	DepreciationRecapturePercent float64
+	// Withholding rates, in percent, employers apply to RSU and RSA income
+	// at vest, used to estimate shares withheld for sell-to-cover
+	VestFederalWithholdingPercent float64
+	VestStateWithholdingPercent   float64
+	VestFICAWithholdingPercent    float64
+
+	// guard is shared with Config, see Current
+	guard guard
+}
+
+type ReportsConfig struct {
+	// MonthlyEnabled delivers each month's statement once the month has ended
+	MonthlyEnabled bool
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// TLSMode is "starttls" (upgrade when the server offers it), "tls"
+	// (implicit TLS, usually port 465) or "none"
+	TLSMode string
+	// To lists the addresses alerts and reports are emailed to
+	To []string
+	// MinSeverity is the lowest notification severity that is emailed
+	// ("info", "warning", "error", or "none" to email only types routed by
+	// notification rules)
+	MinSeverity string
+	// MaxAttempts bounds delivery attempts for a queued email
+	MaxAttempts int
+}
+
+type NotificationsConfig struct {
+	// Telegram bot that messages TelegramChatID (off unless both are set)
+	TelegramBotToken    string
+	TelegramChatID      string
+	TelegramMinSeverity string
+	// Discord webhook that notifications are posted to (off when empty)
+	DiscordWebhookURL  string
+	DiscordMinSeverity string
 }
 
 type MarketConfig struct {
@@ -69,11 +340,40 @@ type MarketConfig struct {
 	CloseTimeLocal string
 	Timezone       string
 	WeekendTrades  bool
+
+	// guard is shared with Config, see Current
+	guard guard
 }
 
-func Load() (*Config, error) {
+// load builds the configuration from the settings getEnvOrDefault resolves
+func load() (*Config, error) {
 	dbPort, _ := strconv.Atoi(getEnvOrDefault("DB_PORT", "5432"))
+	dbMaxOpenConns, err := strconv.Atoi(getEnvOrDefault("DB_MAX_OPEN_CONNS", "20"))
+	if err != nil || dbMaxOpenConns <= 0 {
+		dbMaxOpenConns = 20
+	}
+	dbMaxIdleConns, err := strconv.Atoi(getEnvOrDefault("DB_MAX_IDLE_CONNS", "10"))
+	if err != nil || dbMaxIdleConns < 0 || dbMaxIdleConns > dbMaxOpenConns {
+		dbMaxIdleConns = min(10, dbMaxOpenConns)
+	}
+	dbConnMaxLifetimeMinutes, err := strconv.Atoi(getEnvOrDefault("DB_CONN_MAX_LIFETIME_MINUTES", "30"))
+	if err != nil || dbConnMaxLifetimeMinutes < 0 {
+		dbConnMaxLifetimeMinutes = 30
+	}
+	dbConnMaxIdleTimeMinutes, err := strconv.Atoi(getEnvOrDefault("DB_CONN_MAX_IDLE_TIME_MINUTES", "5"))
+	if err != nil || dbConnMaxIdleTimeMinutes < 0 {
+		dbConnMaxIdleTimeMinutes = 5
+	}
+	dbDriver := strings.ToLower(getEnvOrDefault("DB_DRIVER", DriverPostgres))
+	if dbDriver != DriverSQLite {
+		dbDriver = DriverPostgres
+	}
+	dbTimescale := strings.ToLower(getEnvOrDefault("DB_TIMESCALE", "auto"))
+	if dbTimescale != "on" && dbTimescale != "off" {
+		dbTimescale = "auto"
+	}
 	rateLimitRPS, _ := strconv.Atoi(getEnvOrDefault("RATE_LIMIT_RPS", "100"))
+	shutdownTimeoutSeconds, _ := strconv.Atoi(getEnvOrDefault("SHUTDOWN_TIMEOUT_SECONDS", "10"))
 	
 	// Twelve Data configuration
 	twelveDataDailyLimit, _ := strconv.Atoi(getEnvOrDefault("TWELVE_DATA_DAILY_LIMIT", "800"))
@@ -84,10 +384,179 @@ func Load() (*Config, error) {
 	alphaVantageRateLimit, _ := strconv.Atoi(getEnvOrDefault("ALPHA_VANTAGE_RATE_LIMIT", "5"))
 	
 	cacheRefreshMinutes, _ := strconv.Atoi(getEnvOrDefault("CACHE_REFRESH_MINUTES", "15"))
+
+	// Crypto price provider configuration
+	coinGeckoDailyLimit, _ := strconv.Atoi(getEnvOrDefault("COINGECKO_DAILY_LIMIT", "300"))
+	coinGeckoRateLimit, _ := strconv.Atoi(getEnvOrDefault("COINGECKO_RATE_LIMIT", "10"))
+	coinMarketCapDailyLimit, _ := strconv.Atoi(getEnvOrDefault("COINMARKETCAP_DAILY_LIMIT", "300"))
+	coinMarketCapRateLimit, _ := strconv.Atoi(getEnvOrDefault("COINMARKETCAP_RATE_LIMIT", "30"))
+	cryptoCacheRefreshMinutes, _ := strconv.Atoi(getEnvOrDefault("CRYPTO_CACHE_REFRESH_MINUTES", "5"))
+	symbolFailureThreshold, _ := strconv.Atoi(getEnvOrDefault("SYMBOL_FAILURE_THRESHOLD", "5"))
+	symbolValidationEnabled, err := strconv.ParseBool(getEnvOrDefault("SYMBOL_VALIDATION_ENABLED", "true"))
+	if err != nil {
+		symbolValidationEnabled = true
+	}
+	prioritizeRefreshByValue, err := strconv.ParseBool(getEnvOrDefault("PRICE_REFRESH_PRIORITIZE", "true"))
+	if err != nil {
+		prioritizeRefreshByValue = true
+	}
+	var benchmarkSymbols []string
+	for _, symbol := range strings.Split(getEnvOrDefault("BENCHMARK_SYMBOLS", "SPY,QQQ,AGG"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			benchmarkSymbols = append(benchmarkSymbols, symbol)
+		}
+	}
+
+	// Provider HTTP retry and circuit breaker configuration
+	providerMaxRetries, err := strconv.Atoi(getEnvOrDefault("PROVIDER_HTTP_MAX_RETRIES", "2"))
+	if err != nil || providerMaxRetries < 0 {
+		providerMaxRetries = 2
+	}
+	providerRetryBaseMs, err := strconv.Atoi(getEnvOrDefault("PROVIDER_HTTP_RETRY_BASE_MS", "500"))
+	if err != nil || providerRetryBaseMs <= 0 {
+		providerRetryBaseMs = 500
+	}
+	providerRetryMaxMs, err := strconv.Atoi(getEnvOrDefault("PROVIDER_HTTP_RETRY_MAX_MS", "10000"))
+	if err != nil || providerRetryMaxMs < providerRetryBaseMs {
+		providerRetryMaxMs = max(10000, providerRetryBaseMs)
+	}
+	providerBreakerThreshold, err := strconv.Atoi(getEnvOrDefault("PROVIDER_CIRCUIT_BREAKER_THRESHOLD", "5"))
+	if err != nil || providerBreakerThreshold < 0 {
+		providerBreakerThreshold = 5
+	}
+	providerBreakerCooldownSeconds, err := strconv.Atoi(getEnvOrDefault("PROVIDER_CIRCUIT_BREAKER_COOLDOWN_SECONDS", "60"))
+	if err != nil || providerBreakerCooldownSeconds <= 0 {
+		providerBreakerCooldownSeconds = 60
+	}
+
+	// Read-through cache configuration
+	cacheEnabled, _ := strconv.ParseBool(getEnvOrDefault("CACHE_ENABLED", "true"))
+	cacheTTLSeconds, _ := strconv.Atoi(getEnvOrDefault("CACHE_TTL_SECONDS", "60"))
+
+	// Tracing configuration
+	tracingEnabled, _ := strconv.ParseBool(getEnvOrDefault("TRACING_ENABLED", "false"))
+	tracingSampleRatio, err := strconv.ParseFloat(getEnvOrDefault("TRACING_SAMPLE_RATIO", "1.0"), 64)
+	if err != nil {
+		tracingSampleRatio = 1.0
+	}
+
+	contributionsAutoApply, _ := strconv.ParseBool(getEnvOrDefault("CONTRIBUTIONS_AUTO_APPLY", "true"))
+	contributionsRequireConfirmation, _ := strconv.ParseBool(getEnvOrDefault("CONTRIBUTIONS_REQUIRE_CONFIRMATION", "false"))
+	contributionCheckMinutes, _ := strconv.Atoi(getEnvOrDefault("CONTRIBUTION_CHECK_INTERVAL_MINUTES", "60"))
+	if contributionCheckMinutes <= 0 {
+		contributionCheckMinutes = 60
+	}
+
+	changeSnapshotThreshold, err := strconv.ParseFloat(getEnvOrDefault("NET_WORTH_CHANGE_SNAPSHOT_THRESHOLD", "1000"), 64)
+	if err != nil || changeSnapshotThreshold < 0 {
+		changeSnapshotThreshold = 1000
+	}
+
+	priceRetentionDays, err := strconv.Atoi(getEnvOrDefault("PRICE_RETENTION_DAYS", "90"))
+	if err != nil || priceRetentionDays < 0 {
+		priceRetentionDays = 90
+	}
+
+	concentrationThresholdPercent, err := strconv.ParseFloat(getEnvOrDefault("CONCENTRATION_THRESHOLD_PERCENT", "20"), 64)
+	if err != nil || concentrationThresholdPercent < 0 {
+		concentrationThresholdPercent = 20
+	}
+	stablecoinsAsCash, err := strconv.ParseBool(getEnvOrDefault("STABLECOINS_AS_CASH", "true"))
+	if err != nil {
+		stablecoinsAsCash = true
+	}
+	var stablecoinSymbols []string
+	for _, symbol := range strings.Split(getEnvOrDefault("STABLECOIN_SYMBOLS", "USDC,USDT,DAI,BUSD,TUSD,USDP,PYUSD,GUSD,FDUSD,FRAX,LUSD"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			stablecoinSymbols = append(stablecoinSymbols, symbol)
+		}
+	}
+
+	shortTermCapitalGainsPercent, err := strconv.ParseFloat(getEnvOrDefault("SHORT_TERM_CAPITAL_GAINS_TAX_PERCENT", "24"), 64)
+	if err != nil || shortTermCapitalGainsPercent < 0 {
+		shortTermCapitalGainsPercent = 24
+	}
+	longTermCapitalGainsPercent, err := strconv.ParseFloat(getEnvOrDefault("LONG_TERM_CAPITAL_GAINS_TAX_PERCENT", "15"), 64)
+	if err != nil || longTermCapitalGainsPercent < 0 {
+		longTermCapitalGainsPercent = 15
+	}
+	depreciationRecapturePercent, err := strconv.ParseFloat(getEnvOrDefault("DEPRECIATION_RECAPTURE_TAX_PERCENT", "25"), 64)
+	if err != nil || depreciationRecapturePercent < 0 {
+		depreciationRecapturePercent = 25
+	}
+	vestFederalWithholdingPercent, err := strconv.ParseFloat(getEnvOrDefault("RSU_FEDERAL_WITHHOLDING_PERCENT", "22"), 64)
+	if err != nil || vestFederalWithholdingPercent < 0 {
+		vestFederalWithholdingPercent = 22
+	}
+	vestStateWithholdingPercent, err := strconv.ParseFloat(getEnvOrDefault("RSU_STATE_WITHHOLDING_PERCENT", "0"), 64)
+	if err != nil || vestStateWithholdingPercent < 0 {
+		vestStateWithholdingPercent = 0
+	}
+	vestFICAWithholdingPercent, err := strconv.ParseFloat(getEnvOrDefault("RSU_FICA_WITHHOLDING_PERCENT", "7.65"), 64)
+	if err != nil || vestFICAWithholdingPercent < 0 {
+		vestFICAWithholdingPercent = 7.65
+	}
+
+	monthlyReportsEnabled, _ := strconv.ParseBool(getEnvOrDefault("MONTHLY_REPORTS_ENABLED", "false"))
+
+	smtpPort, err := strconv.Atoi(getEnvOrDefault("SMTP_PORT", "587"))
+	if err != nil || smtpPort <= 0 {
+		smtpPort = 587
+	}
+	smtpMaxAttempts, err := strconv.Atoi(getEnvOrDefault("SMTP_MAX_ATTEMPTS", "5"))
+	if err != nil || smtpMaxAttempts <= 0 {
+		smtpMaxAttempts = 5
+	}
+	var emailRecipients []string
+	for _, address := range strings.Split(getEnvOrDefault("NOTIFICATION_EMAIL_TO", ""), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			emailRecipients = append(emailRecipients, address)
+		}
+	}
+
+	authEnabled, _ := strconv.ParseBool(getEnvOrDefault("AUTH_ENABLED", "false"))
+	authSessionTTLHours, err := strconv.Atoi(getEnvOrDefault("AUTH_SESSION_TTL_HOURS", "168"))
+	if err != nil || authSessionTTLHours <= 0 {
+		authSessionTTLHours = 168
+	}
+
+	attachmentMaxSizeMB, err := strconv.Atoi(getEnvOrDefault("ATTACHMENT_MAX_SIZE_MB", "25"))
+	if err != nil || attachmentMaxSizeMB <= 0 {
+		attachmentMaxSizeMB = 25
+	}
+	s3PathStyle, _ := strconv.ParseBool(getEnvOrDefault("S3_PATH_STYLE", "false"))
+	signedURLTTLMinutes, err := strconv.Atoi(getEnvOrDefault("STORAGE_SIGNED_URL_TTL_MINUTES", "15"))
+	if err != nil || signedURLTTLMinutes <= 0 {
+		signedURLTTLMinutes = 15
+	}
+
+	backupScheduleEnabled, _ := strconv.ParseBool(getEnvOrDefault("BACKUP_SCHEDULE_ENABLED", "false"))
+	backupIntervalHours, err := strconv.Atoi(getEnvOrDefault("BACKUP_INTERVAL_HOURS", "24"))
+	if err != nil || backupIntervalHours <= 0 {
+		backupIntervalHours = 24
+	}
+	backupRetentionCount, err := strconv.Atoi(getEnvOrDefault("BACKUP_RETENTION_COUNT", "7"))
+	if err != nil || backupRetentionCount < 0 {
+		backupRetentionCount = 7
+	}
+	backupRetentionDays, err := strconv.Atoi(getEnvOrDefault("BACKUP_RETENTION_DAYS", "30"))
+	if err != nil || backupRetentionDays < 0 {
+		backupRetentionDays = 30
+	}
+	demoMode, _ := strconv.ParseBool(getEnvOrDefault("DEMO_MODE", "false"))
+	encryptionKey := getEnvOrDefault("ENCRYPTION_KEY", "your-encryption-key-32-chars-long")
 	
 	// Parse feature flag boolean values (default to false for safety)
 	propertyValuationEnabled, _ := strconv.ParseBool(getEnvOrDefault("PROPERTY_VALUATION_ENABLED", "false"))
 	attomDataEnabled, _ := strconv.ParseBool(getEnvOrDefault("ATTOM_DATA_ENABLED", "false"))
+	propertyValuationMaxAgeDays, err := strconv.Atoi(getEnvOrDefault("PROPERTY_VALUATION_MAX_AGE_DAYS", "30"))
+	if err != nil || propertyValuationMaxAgeDays <= 0 {
+		propertyValuationMaxAgeDays = 30
+	}
+	otherAssetValuationMaxAgeDays, err := strconv.Atoi(getEnvOrDefault("OTHER_ASSET_VALUATION_MAX_AGE_DAYS", "90"))
+	if err != nil || otherAssetValuationMaxAgeDays <= 0 {
+		otherAssetValuationMaxAgeDays = 90
+	}
 
 	// Price provider configuration
 	primaryProvider := getEnvOrDefault("PRIMARY_PRICE_PROVIDER", "twelvedata")
@@ -111,25 +580,34 @@ func Load() (*Config, error) {
 
 	return &Config{
 		Database: DatabaseConfig{
+			Driver:   dbDriver,
+			Path:     getEnvOrDefault("DB_PATH", "data/networth.db"),
 			Host:     getEnvOrDefault("DB_HOST", "localhost"),
 			Port:     dbPort,
 			User:     getEnvOrDefault("DB_USER", "postgres"),
 			Password: getEnvOrDefault("DB_PASSWORD", "password"),
 			DBName:   getEnvOrDefault("DB_NAME", "networth_dashboard"),
 			SSLMode:  getEnvOrDefault("DB_SSLMODE", "disable"),
+
+			MaxOpenConns:    dbMaxOpenConns,
+			MaxIdleConns:    dbMaxIdleConns,
+			ConnMaxLifetime: time.Duration(dbConnMaxLifetimeMinutes) * time.Minute,
+			ConnMaxIdleTime: time.Duration(dbConnMaxIdleTimeMinutes) * time.Minute,
+			Timescale:       dbTimescale,
 		},
 		Server: ServerConfig{
 			Port:            getEnvOrDefault("PORT", "8080"),
 			ReadTimeout:     30 * time.Second,
 			WriteTimeout:    30 * time.Second,
-			ShutdownTimeout: 10 * time.Second,
+			ShutdownTimeout: time.Duration(shutdownTimeoutSeconds) * time.Second,
 			CORSEnabled:     true,
 			CORSOrigins:     []string{"http://localhost:3000", "http://localhost:5173"},
 		},
 		Security: SecurityConfig{
 			JWTSecret:       getEnvOrDefault("JWT_SECRET", "your-secret-key"),
-			EncryptionKey:   getEnvOrDefault("ENCRYPTION_KEY", "your-encryption-key-32-chars-long"),
+			EncryptionKey:   encryptionKey,
 			CredentialKey:   getEnvOrDefault("CREDENTIAL_KEY", "your-credential-encryption-key-32-chars"),
+			FieldEncryptionKey: loadFieldEncryptionKey(encryptionKey),
 			RateLimitEnable: true,
 			RateLimitRPS:    rateLimitRPS,
 		},
@@ -143,10 +621,42 @@ func Load() (*Config, error) {
 			PrimaryPriceProvider:     primaryProvider,
 			FallbackPriceProvider:    fallbackProvider,
 			CacheRefreshInterval:     time.Duration(cacheRefreshMinutes) * time.Minute,
+			CryptoPriceProvider:      getEnvOrDefault("CRYPTO_PRICE_PROVIDER", "coingecko"),
+			CoinGeckoAPIKey:          getEnvOrDefault("COINGECKO_API_KEY", ""),
+			CoinGeckoDailyLimit:      coinGeckoDailyLimit,
+			CoinGeckoRateLimit:       coinGeckoRateLimit,
+			CoinMarketCapAPIKey:      getEnvOrDefault("COINMARKETCAP_API_KEY", ""),
+			CoinMarketCapDailyLimit:  coinMarketCapDailyLimit,
+			CoinMarketCapRateLimit:   coinMarketCapRateLimit,
+			CryptoCacheRefreshInterval: time.Duration(cryptoCacheRefreshMinutes) * time.Minute,
+			SymbolFailureThreshold:   symbolFailureThreshold,
+			SymbolValidationEnabled:  symbolValidationEnabled,
+			PrioritizeRefreshByValue: prioritizeRefreshByValue,
+			BenchmarkSymbols:         benchmarkSymbols,
 			AttomDataAPIKey:          getEnvOrDefault("ATTOM_DATA_API_KEY", ""),
 			AttomDataBaseURL:         getEnvOrDefault("ATTOM_DATA_BASE_URL", "https://api.gateway.attomdata.com/propertyapi/v1.0.0"),
+			RentcastAPIKey:           getEnvOrDefault("RENTCAST_API_KEY", ""),
+			RentcastBaseURL:          getEnvOrDefault("RENTCAST_BASE_URL", "https://api.rentcast.io/v1"),
+			HouseCanaryAPIKey:        getEnvOrDefault("HOUSECANARY_API_KEY", ""),
+			HouseCanaryAPISecret:     getEnvOrDefault("HOUSECANARY_API_SECRET", ""),
+			HouseCanaryBaseURL:       getEnvOrDefault("HOUSECANARY_BASE_URL", "https://api.housecanary.com/v2"),
+			MarketCheckAPIKey:        getEnvOrDefault("MARKETCHECK_API_KEY", ""),
+			MarketCheckBaseURL:       getEnvOrDefault("MARKETCHECK_BASE_URL", "https://mc-api.marketcheck.com/v2"),
+			EbayClientID:             getEnvOrDefault("EBAY_CLIENT_ID", ""),
+			EbayClientSecret:         getEnvOrDefault("EBAY_CLIENT_SECRET", ""),
+			EbayBaseURL:              getEnvOrDefault("EBAY_BASE_URL", "https://api.ebay.com"),
+			MetalsPriceBaseURL:       getEnvOrDefault("METALS_PRICE_BASE_URL", "https://api.gold-api.com"),
 			PropertyValuationEnabled: propertyValuationEnabled,
 			AttomDataEnabled:         attomDataEnabled,
+			PropertyValuationMaxAge:   time.Duration(propertyValuationMaxAgeDays) * 24 * time.Hour,
+			OtherAssetValuationMaxAge: time.Duration(otherAssetValuationMaxAgeDays) * 24 * time.Hour,
+			HTTPRetry: HTTPRetryConfig{
+				MaxRetries:       providerMaxRetries,
+				BaseDelay:        time.Duration(providerRetryBaseMs) * time.Millisecond,
+				MaxDelay:         time.Duration(providerRetryMaxMs) * time.Millisecond,
+				BreakerThreshold: providerBreakerThreshold,
+				BreakerCooldown:  time.Duration(providerBreakerCooldownSeconds) * time.Second,
+			},
 		},
 		Market: MarketConfig{
 			OpenTimeLocal:  getEnvOrDefault("MARKET_OPEN_LOCAL", "09:30"),  // 9:30 AM ET
@@ -154,12 +664,133 @@ func Load() (*Config, error) {
 			Timezone:       getEnvOrDefault("MARKET_TIMEZONE", "America/New_York"),
 			WeekendTrades:  false,
 		},
+		Cache: CacheConfig{
+			Enabled:   cacheEnabled,
+			TTL:       time.Duration(cacheTTLSeconds) * time.Second,
+			RedisURL:  getEnvOrDefault("REDIS_URL", ""),
+			KeyPrefix: getEnvOrDefault("REDIS_KEY_PREFIX", "networth:"),
+		},
+		Tracing: TracingConfig{
+			Enabled:     tracingEnabled,
+			ServiceName: getEnvOrDefault("OTEL_SERVICE_NAME", "networth-dashboard-backend"),
+			SampleRatio: tracingSampleRatio,
+		},
+		Contributions: ContributionsConfig{
+			AutoApply:           contributionsAutoApply,
+			CheckInterval:       time.Duration(contributionCheckMinutes) * time.Minute,
+			RequireConfirmation: contributionsRequireConfirmation,
+		},
+		Risk: RiskConfig{
+			ConcentrationThresholdPercent: concentrationThresholdPercent,
+			StablecoinsAsCash:             stablecoinsAsCash,
+			StablecoinSymbols:             stablecoinSymbols,
+		},
+		Tax: TaxConfig{
+			ShortTermCapitalGainsPercent:  shortTermCapitalGainsPercent,
+			LongTermCapitalGainsPercent:   longTermCapitalGainsPercent,
+			DepreciationRecapturePercent:  depreciationRecapturePercent,
+			VestFederalWithholdingPercent: vestFederalWithholdingPercent,
+			VestStateWithholdingPercent:   vestStateWithholdingPercent,
+			VestFICAWithholdingPercent:    vestFICAWithholdingPercent,
+		},
+		Reports: ReportsConfig{
+			MonthlyEnabled: monthlyReportsEnabled,
+		},
+		SMTP: SMTPConfig{
+			Host:        getEnvOrDefault("SMTP_HOST", ""),
+			Port:        smtpPort,
+			Username:    getEnvOrDefault("SMTP_USERNAME", ""),
+			Password:    getEnvOrDefault("SMTP_PASSWORD", ""),
+			From:        getEnvOrDefault("SMTP_FROM", ""),
+			TLSMode:     strings.ToLower(getEnvOrDefault("SMTP_TLS_MODE", "starttls")),
+			To:          emailRecipients,
+			MinSeverity: strings.ToLower(getEnvOrDefault("EMAIL_NOTIFICATION_MIN_SEVERITY", "warning")),
+			MaxAttempts: smtpMaxAttempts,
+		},
+		Notifications: NotificationsConfig{
+			TelegramBotToken:    getEnvOrDefault("TELEGRAM_BOT_TOKEN", ""),
+			TelegramChatID:      getEnvOrDefault("TELEGRAM_CHAT_ID", ""),
+			TelegramMinSeverity: strings.ToLower(getEnvOrDefault("TELEGRAM_NOTIFICATION_MIN_SEVERITY", "warning")),
+			DiscordWebhookURL:   getEnvOrDefault("DISCORD_WEBHOOK_URL", ""),
+			DiscordMinSeverity:  strings.ToLower(getEnvOrDefault("DISCORD_NOTIFICATION_MIN_SEVERITY", "warning")),
+		},
+		Auth: AuthConfig{
+			Enabled:           authEnabled,
+			SessionTTL:        time.Duration(authSessionTTLHours) * time.Hour,
+			BootstrapUsername: getEnvOrDefault("AUTH_ADMIN_USERNAME", "admin"),
+			BootstrapPassword: getEnvOrDefault("AUTH_ADMIN_PASSWORD", ""),
+		},
+		History: HistoryConfig{
+			ChangeSnapshotThreshold: changeSnapshotThreshold,
+			PriceRetentionDays:      priceRetentionDays,
+		},
+		Attachments: AttachmentsConfig{
+			MaxSizeBytes: int64(attachmentMaxSizeMB) << 20,
+		},
+		Storage: StorageConfig{
+			Backend:  strings.ToLower(getEnvOrDefault("STORAGE_BACKEND", "local")),
+			LocalDir: getEnvOrDefault("STORAGE_LOCAL_DIR", "data"),
+			S3: S3Config{
+				Endpoint:        getEnvOrDefault("S3_ENDPOINT", ""),
+				Region:          getEnvOrDefault("S3_REGION", "us-east-1"),
+				Bucket:          getEnvOrDefault("S3_BUCKET", ""),
+				AccessKeyID:     getEnvOrDefault("S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnvOrDefault("S3_SECRET_ACCESS_KEY", ""),
+				PathStyle:       s3PathStyle,
+				Prefix:          getEnvOrDefault("S3_PREFIX", ""),
+			},
+			SignedURLTTL: time.Duration(signedURLTTLMinutes) * time.Minute,
+		},
+		Backup: BackupConfig{
+			ScheduleEnabled: backupScheduleEnabled,
+			Interval:        time.Duration(backupIntervalHours) * time.Hour,
+			RetentionCount:  backupRetentionCount,
+			RetentionDays:   backupRetentionDays,
+			PgDumpPath:      getEnvOrDefault("PG_DUMP_PATH", "pg_dump"),
+			PgRestorePath:   getEnvOrDefault("PG_RESTORE_PATH", "pg_restore"),
+		},
+		Demo: DemoConfig{
+			Enabled: demoMode,
+		},
 	}, nil
 }
 
+// loadFieldEncryptionKey reads the field encryption key from FIELD_ENCRYPTION_KEY,
+// or from the file named by FIELD_ENCRYPTION_KEY_FILE (e.g. a secret mounted by a
+// KMS or secrets manager), falling back to ENCRYPTION_KEY
+func loadFieldEncryptionKey(fallback string) string {
+	key := getEnvOrDefault("FIELD_ENCRYPTION_KEY", "")
+	path := getEnvOrDefault("FIELD_ENCRYPTION_KEY_FILE", "")
+	if key != "" {
+		return key
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("WARNING: Failed to read FIELD_ENCRYPTION_KEY_FILE %s: %v", path, err)
+		} else if key := strings.TrimSpace(string(data)); key != "" {
+			return key
+		}
+	}
+
+	return fallback
+}
+
+// getEnvOrDefault resolves a setting from the runtime overrides, then the
+// environment, then the configuration file, then defaultValue, and records
+// where it came from
 func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	resolved := setting{value: defaultValue}
+	if value, ok := overrideSettings[key]; ok && IsRuntimeSetting(key) {
+		resolved = setting{value: value, source: overrideSource}
+	} else if value := os.Getenv(key); value != "" {
+		resolved = setting{value: value, source: "environment"}
+	} else if value, ok := fileSettings[key]; ok && value != "" {
+		resolved = setting{value: value, source: fileSource}
+	}
+	if resolvedSettings != nil {
+		resolvedSettings[key] = resolved
 	}
-	return defaultValue
+	return resolved.value
 }
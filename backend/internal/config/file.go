@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a resolved setting value and where it came from: "settings"
+// when changed at runtime, "environment", the configuration file's path, or
+// empty for the default
+type setting struct {
+	value  string
+	source string
+}
+
+// overrideSource is the source of settings changed at runtime
+const overrideSource = "settings"
+
+// loadMu serializes Load, which resolves settings through the package state
+// below
+var (
+	loadMu           sync.Mutex
+	overrideSettings map[string]string
+	fileSettings     map[string]string
+	fileSource       string
+	resolvedSettings map[string]setting
+)
+
+// ValidationError lists every problem with a configuration, one per setting
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Load reads the configuration from the environment and, when CONFIG_FILE
+// names one, a YAML or TOML file. Environment variables take precedence over
+// the file, and the file over the defaults. Malformed values and settings the
+// file names that do not exist are reported together in a ValidationError.
+func Load() (*Config, error) {
+	return LoadWithOverrides(nil)
+}
+
+// LoadWithOverrides is Load with settings changed at runtime taking
+// precedence over every other source. Only runtime settings can be
+// overridden.
+func LoadWithOverrides(overrides map[string]string) (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	path := os.Getenv("CONFIG_FILE")
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	overrideSettings, fileSettings, fileSource, resolvedSettings = overrides, values, path, map[string]setting{}
+	defer func() {
+		overrideSettings, fileSettings, fileSource, resolvedSettings = nil, nil, "", nil
+	}()
+
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	cfg.settings = resolvedSettings
+	cfg.shareGuard()
+
+	problems := validateSettings(resolvedSettings)
+	for name := range overrides {
+		if !IsRuntimeSetting(name) {
+			problems = append(problems, fmt.Sprintf("%s: cannot be changed at runtime", name))
+		}
+	}
+	for _, name := range unknownSettings(values, resolvedSettings) {
+		problem := fmt.Sprintf("%s: unknown setting in %s", name, path)
+		if suggestion := closestSetting(name, resolvedSettings); suggestion != "" {
+			problem += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// readConfigFile reads the settings in a YAML (.yaml, .yml) or TOML (.toml)
+// file, keyed by their environment variable names. Nested sections are
+// joined with underscores, so
+//
+//	twelve_data:
+//	  daily_limit: 800
+//
+// sets TWELVE_DATA_DAILY_LIMIT, and lists become comma separated values. An
+// empty path reads nothing.
+func readConfigFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	document := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flattenSettings(values, "", document); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flattenSettings adds the settings in section to values, prefixing their
+// names with prefix
+func flattenSettings(values map[string]string, prefix string, section map[string]interface{}) error {
+	for key, value := range section {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flattenSettings(values, name, value); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				if _, ok := item.(map[string]interface{}); ok {
+					return fmt.Errorf("%s: lists may only hold plain values", name)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// unknownSettings lists the settings in the file that Load never read,
+// usually misspellings
+func unknownSettings(values map[string]string, resolved map[string]setting) []string {
+	var unknown []string
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// closestSetting suggests the known setting nearest to a misspelled name, if
+// one is close enough to be a likely typo
+func closestSetting(name string, resolved map[string]setting) string {
+	best, bestDistance := "", 4
+	for known := range resolved {
+		if distance := editDistance(name, known); distance < bestDistance || (distance == bestDistance && known < best) {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
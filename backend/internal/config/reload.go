@@ -0,0 +1,171 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// reloadable are the settings a running server picks up on reload, keyed by
+// name. Each is read from the shared configuration, through its section's
+// Current, whenever it is used, so copying the new value in place under the
+// guard is enough; the rest take effect on restart.
+var reloadable = map[string]func(dst, src *Config){
+	"CACHE_REFRESH_MINUTES":        func(dst, src *Config) { dst.API.CacheRefreshInterval = src.API.CacheRefreshInterval },
+	"CRYPTO_CACHE_REFRESH_MINUTES": func(dst, src *Config) { dst.API.CryptoCacheRefreshInterval = src.API.CryptoCacheRefreshInterval },
+	"TWELVE_DATA_DAILY_LIMIT":      func(dst, src *Config) { dst.API.TwelveDataDailyLimit = src.API.TwelveDataDailyLimit },
+	"TWELVE_DATA_RATE_LIMIT":       func(dst, src *Config) { dst.API.TwelveDataRateLimit = src.API.TwelveDataRateLimit },
+	"ALPHA_VANTAGE_DAILY_LIMIT":    func(dst, src *Config) { dst.API.AlphaVantageDailyLimit = src.API.AlphaVantageDailyLimit },
+	"ALPHA_VANTAGE_RATE_LIMIT":     func(dst, src *Config) { dst.API.AlphaVantageRateLimit = src.API.AlphaVantageRateLimit },
+	"COINGECKO_DAILY_LIMIT":        func(dst, src *Config) { dst.API.CoinGeckoDailyLimit = src.API.CoinGeckoDailyLimit },
+	"COINGECKO_RATE_LIMIT":         func(dst, src *Config) { dst.API.CoinGeckoRateLimit = src.API.CoinGeckoRateLimit },
+	"COINMARKETCAP_DAILY_LIMIT":    func(dst, src *Config) { dst.API.CoinMarketCapDailyLimit = src.API.CoinMarketCapDailyLimit },
+	"COINMARKETCAP_RATE_LIMIT":     func(dst, src *Config) { dst.API.CoinMarketCapRateLimit = src.API.CoinMarketCapRateLimit },
+	"SYMBOL_VALIDATION_ENABLED":    func(dst, src *Config) { dst.API.SymbolValidationEnabled = src.API.SymbolValidationEnabled },
+	"PRICE_REFRESH_PRIORITIZE":     func(dst, src *Config) { dst.API.PrioritizeRefreshByValue = src.API.PrioritizeRefreshByValue },
+	"MARKET_OPEN_LOCAL":            func(dst, src *Config) { dst.Market.OpenTimeLocal = src.Market.OpenTimeLocal },
+	"MARKET_CLOSE_LOCAL":           func(dst, src *Config) { dst.Market.CloseTimeLocal = src.Market.CloseTimeLocal },
+	"PROPERTY_VALUATION_MAX_AGE_DAYS": func(dst, src *Config) {
+		dst.API.PropertyValuationMaxAge = src.API.PropertyValuationMaxAge
+	},
+	"OTHER_ASSET_VALUATION_MAX_AGE_DAYS": func(dst, src *Config) {
+		dst.API.OtherAssetValuationMaxAge = src.API.OtherAssetValuationMaxAge
+	},
+	"NET_WORTH_CHANGE_SNAPSHOT_THRESHOLD": func(dst, src *Config) {
+		dst.History.ChangeSnapshotThreshold = src.History.ChangeSnapshotThreshold
+	},
+	"SHORT_TERM_CAPITAL_GAINS_TAX_PERCENT": func(dst, src *Config) {
+		dst.Tax.ShortTermCapitalGainsPercent = src.Tax.ShortTermCapitalGainsPercent
+	},
+	"LONG_TERM_CAPITAL_GAINS_TAX_PERCENT": func(dst, src *Config) {
+		dst.Tax.LongTermCapitalGainsPercent = src.Tax.LongTermCapitalGainsPercent
+	},
+	"DEPRECIATION_RECAPTURE_TAX_PERCENT": func(dst, src *Config) {
+		dst.Tax.DepreciationRecapturePercent = src.Tax.DepreciationRecapturePercent
+	},
+	"RSU_FEDERAL_WITHHOLDING_PERCENT": func(dst, src *Config) {
+		dst.Tax.VestFederalWithholdingPercent = src.Tax.VestFederalWithholdingPercent
+	},
+	"RSU_STATE_WITHHOLDING_PERCENT": func(dst, src *Config) {
+		dst.Tax.VestStateWithholdingPercent = src.Tax.VestStateWithholdingPercent
+	},
+	"RSU_FICA_WITHHOLDING_PERCENT": func(dst, src *Config) {
+		dst.Tax.VestFICAWithholdingPercent = src.Tax.VestFICAWithholdingPercent
+	},
+}
+
+// guard protects the settings a reload changes while requests and
+// background jobs read them. Config and each section holding runtime settings
+// share one lock, since services keep pointers to the sections rather than
+// to Config. A zero guard, as in a Config built by hand, does not lock.
+type guard struct {
+	mu *sync.RWMutex
+}
+
+func (g guard) rlock() (unlock func()) {
+	if g.mu == nil {
+		return func() {}
+	}
+	g.mu.RLock()
+	return g.mu.RUnlock
+}
+
+func (g guard) lock() (unlock func()) {
+	if g.mu == nil {
+		return func() {}
+	}
+	g.mu.Lock()
+	return g.mu.Unlock
+}
+
+// shareGuard gives c and its sections with runtime settings a common lock
+func (c *Config) shareGuard() {
+	c.guard = guard{mu: &sync.RWMutex{}}
+	c.API.guard = c.guard
+	c.Market.guard = c.guard
+	c.History.guard = c.guard
+	c.Tax.guard = c.guard
+}
+
+// Current returns a copy of the API settings that a concurrent reload cannot
+// change. Read runtime settings through it rather than the fields directly.
+func (a *ApiConfig) Current() ApiConfig {
+	defer a.guard.rlock()()
+	return *a
+}
+
+// Current returns a copy of the market settings that a concurrent reload
+// cannot change
+func (m *MarketConfig) Current() MarketConfig {
+	defer m.guard.rlock()()
+	return *m
+}
+
+// Current returns a copy of the history settings that a concurrent reload
+// cannot change
+func (h *HistoryConfig) Current() HistoryConfig {
+	defer h.guard.rlock()()
+	return *h
+}
+
+// Current returns a copy of the tax settings that a concurrent reload cannot
+// change
+func (t *TaxConfig) Current() TaxConfig {
+	defer t.guard.rlock()()
+	return *t
+}
+
+// IsRuntimeSetting reports whether a setting can change while the server runs
+func IsRuntimeSetting(name string) bool {
+	_, ok := reloadable[name]
+	return ok
+}
+
+// RuntimeSettings lists the settings that can change while the server runs
+func RuntimeSettings() []string {
+	names := make([]string, 0, len(reloadable))
+	for name := range reloadable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Setting returns a setting's value and where it came from: "settings" when
+// changed at runtime, "environment", the configuration file's path, or
+// "default"
+func (c *Config) Setting(name string) (value, source string) {
+	defer c.guard.rlock()()
+	resolved := c.settings[name]
+	if resolved.source == "" {
+		return resolved.value, "default"
+	}
+	return resolved.value, resolved.source
+}
+
+// Reload applies the reloadable settings that differ in next to c, returning
+// their names, and the names of changed settings that need a restart
+func (c *Config) Reload(next *Config) (applied, restartRequired []string) {
+	defer c.guard.lock()()
+	for name, value := range next.settings {
+		current := c.settings[name]
+		if current == value {
+			continue
+		}
+		apply, ok := reloadable[name]
+		if !ok {
+			if current.value != value.value {
+				restartRequired = append(restartRequired, name)
+			}
+			continue
+		}
+		// A value that moved to another source is recorded but not reapplied
+		if current.value != value.value {
+			apply(c, next)
+			applied = append(applied, name)
+		}
+		c.settings[name] = value
+	}
+	sort.Strings(applied)
+	sort.Strings(restartRequired)
+	return applied, restartRequired
+}
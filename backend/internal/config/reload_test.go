@@ -0,0 +1,95 @@
+package config
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloadAppliesChangedRuntimeSettings(t *testing.T) {
+	cfg, err := LoadWithOverrides(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	next, err := LoadWithOverrides(map[string]string{"CACHE_REFRESH_MINUTES": "42"})
+	if err != nil {
+		t.Fatalf("load with override: %v", err)
+	}
+
+	applied, restartRequired := cfg.Reload(next)
+	if len(applied) != 1 || applied[0] != "CACHE_REFRESH_MINUTES" {
+		t.Errorf("applied = %v, want [CACHE_REFRESH_MINUTES]", applied)
+	}
+	if len(restartRequired) != 0 {
+		t.Errorf("restartRequired = %v, want none", restartRequired)
+	}
+	if got := cfg.API.Current().CacheRefreshInterval; got != 42*time.Minute {
+		t.Errorf("CacheRefreshInterval = %v, want 42m", got)
+	}
+	if value, source := cfg.Setting("CACHE_REFRESH_MINUTES"); value != "42" || source != "settings" {
+		t.Errorf("Setting = %q from %q, want 42 from settings", value, source)
+	}
+}
+
+// TestReloadWithConcurrentReaders is meant for go test -race: reloads must
+// not race requests reading settings
+func TestReloadWithConcurrentReaders(t *testing.T) {
+	cfg, err := LoadWithOverrides(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var nexts []*Config
+	for _, minutes := range []string{"05", "10"} {
+		next, err := LoadWithOverrides(map[string]string{
+			"CACHE_REFRESH_MINUTES":               minutes,
+			"MARKET_OPEN_LOCAL":                   "09:" + minutes,
+			"LONG_TERM_CAPITAL_GAINS_TAX_PERCENT": minutes,
+			"NET_WORTH_CHANGE_SNAPSHOT_THRESHOLD": minutes,
+		})
+		if err != nil {
+			t.Fatalf("load with overrides: %v", err)
+		}
+		nexts = append(nexts, next)
+	}
+
+	read := func() {
+		for _, name := range RuntimeSettings() {
+			cfg.Setting(name)
+		}
+		_ = cfg.API.Current().CacheRefreshInterval
+		_ = cfg.Market.Current().OpenTimeLocal
+		_ = cfg.Tax.Current().LongTermCapitalGainsPercent
+		_ = cfg.History.Current().ChangeSnapshotThreshold
+	}
+
+	done := make(chan struct{})
+	var started, readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		started.Add(1)
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			read()
+			started.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					read()
+				}
+			}
+		}()
+	}
+
+	started.Wait()
+	for i := 0; i < 200; i++ {
+		cfg.Reload(nexts[i%len(nexts)])
+	}
+	close(done)
+	readers.Wait()
+
+	if got := cfg.API.Current().CacheRefreshInterval; got != 10*time.Minute {
+		t.Errorf("CacheRefreshInterval = %v after the last reload, want 10m", got)
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wholeNumberSettings are the integer settings with their smallest allowed
+// value
+var wholeNumberSettings = map[string]int{
+	"DB_PORT":                                   1,
+	"DB_MAX_OPEN_CONNS":                         1,
+	"DB_MAX_IDLE_CONNS":                         0,
+	"DB_CONN_MAX_LIFETIME_MINUTES":              0,
+	"DB_CONN_MAX_IDLE_TIME_MINUTES":             0,
+	"RATE_LIMIT_RPS":                            0,
+	"SHUTDOWN_TIMEOUT_SECONDS":                  0,
+	"TWELVE_DATA_DAILY_LIMIT":                   0,
+	"TWELVE_DATA_RATE_LIMIT":                    0,
+	"ALPHA_VANTAGE_DAILY_LIMIT":                 0,
+	"ALPHA_VANTAGE_RATE_LIMIT":                  0,
+	"CACHE_REFRESH_MINUTES":                     0,
+	"COINGECKO_DAILY_LIMIT":                     0,
+	"COINGECKO_RATE_LIMIT":                      0,
+	"COINMARKETCAP_DAILY_LIMIT":                 0,
+	"COINMARKETCAP_RATE_LIMIT":                  0,
+	"CRYPTO_CACHE_REFRESH_MINUTES":              0,
+	"SYMBOL_FAILURE_THRESHOLD":                  0,
+	"PROVIDER_HTTP_MAX_RETRIES":                 0,
+	"PROVIDER_HTTP_RETRY_BASE_MS":               1,
+	"PROVIDER_HTTP_RETRY_MAX_MS":                1,
+	"PROVIDER_CIRCUIT_BREAKER_THRESHOLD":        0,
+	"PROVIDER_CIRCUIT_BREAKER_COOLDOWN_SECONDS": 1,
+	"CACHE_TTL_SECONDS":                         0,
+	"CONTRIBUTION_CHECK_INTERVAL_MINUTES":       1,
+	"PRICE_RETENTION_DAYS":                      0,
+	"SMTP_PORT":                                 1,
+	"SMTP_MAX_ATTEMPTS":                         1,
+	"AUTH_SESSION_TTL_HOURS":                    1,
+	"ATTACHMENT_MAX_SIZE_MB":                    1,
+	"STORAGE_SIGNED_URL_TTL_MINUTES":            1,
+	"BACKUP_INTERVAL_HOURS":                     1,
+	"BACKUP_RETENTION_COUNT":                    0,
+	"BACKUP_RETENTION_DAYS":                     0,
+	"PROPERTY_VALUATION_MAX_AGE_DAYS":           1,
+	"OTHER_ASSET_VALUATION_MAX_AGE_DAYS":        1,
+}
+
+// numberSettings are the decimal settings, none of which may be negative
+var numberSettings = []string{
+	"TRACING_SAMPLE_RATIO",
+	"NET_WORTH_CHANGE_SNAPSHOT_THRESHOLD",
+	"CONCENTRATION_THRESHOLD_PERCENT",
+	"SHORT_TERM_CAPITAL_GAINS_TAX_PERCENT",
+	"LONG_TERM_CAPITAL_GAINS_TAX_PERCENT",
+	"DEPRECIATION_RECAPTURE_TAX_PERCENT",
+	"RSU_FEDERAL_WITHHOLDING_PERCENT",
+	"RSU_STATE_WITHHOLDING_PERCENT",
+	"RSU_FICA_WITHHOLDING_PERCENT",
+}
+
+// boolSettings are the on/off settings
+var boolSettings = []string{
+	"SYMBOL_VALIDATION_ENABLED",
+	"PRICE_REFRESH_PRIORITIZE",
+	"CACHE_ENABLED",
+	"TRACING_ENABLED",
+	"CONTRIBUTIONS_AUTO_APPLY",
+	"CONTRIBUTIONS_REQUIRE_CONFIRMATION",
+	"MONTHLY_REPORTS_ENABLED",
+	"AUTH_ENABLED",
+	"S3_PATH_STYLE",
+	"BACKUP_SCHEDULE_ENABLED",
+	"DEMO_MODE",
+	"PROPERTY_VALUATION_ENABLED",
+	"ATTOM_DATA_ENABLED",
+}
+
+// choiceSettings are the settings limited to a set of values, compared
+// without regard to case
+var choiceSettings = map[string][]string{
+	"DB_DRIVER":                          {"postgres", "sqlite"},
+	"DB_TIMESCALE":                       {"auto", "on", "off"},
+	"STORAGE_BACKEND":                    {"local", "s3"},
+	"SMTP_TLS_MODE":                      {"starttls", "tls", "none"},
+	"EMAIL_NOTIFICATION_MIN_SEVERITY":    {"info", "warning", "error", "none"},
+	"TELEGRAM_NOTIFICATION_MIN_SEVERITY": {"info", "warning", "error", "none"},
+	"DISCORD_NOTIFICATION_MIN_SEVERITY":  {"info", "warning", "error", "none"},
+	"PRIMARY_PRICE_PROVIDER":             {"twelvedata", "alphavantage"},
+	"FALLBACK_PRICE_PROVIDER":            {"twelvedata", "alphavantage"},
+	"CRYPTO_PRICE_PROVIDER":              {"coingecko", "coinmarketcap"},
+}
+
+// validateSettings checks the resolved settings, returning one problem per
+// invalid setting naming where its value came from
+func validateSettings(settings map[string]setting) []string {
+	var problems []string
+	invalid := func(name, format string, args ...interface{}) {
+		problem := name + ": " + fmt.Sprintf(format, args...)
+		if source := settings[name].source; source != "" {
+			problem += " (from " + source + ")"
+		}
+		problems = append(problems, problem)
+	}
+
+	for name, minimum := range wholeNumberSettings {
+		value := settings[name].value
+		number, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			invalid(name, "%q is not a whole number", value)
+		case number < minimum:
+			invalid(name, "%d is below the minimum of %d", number, minimum)
+		}
+	}
+	for _, name := range numberSettings {
+		value := settings[name].value
+		number, err := strconv.ParseFloat(value, 64)
+		switch {
+		case err != nil:
+			invalid(name, "%q is not a number", value)
+		case number < 0:
+			invalid(name, "%g must not be negative", number)
+		}
+	}
+	for _, name := range boolSettings {
+		if value := settings[name].value; value != "" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				invalid(name, "%q is not true or false", value)
+			}
+		}
+	}
+	for name, choices := range choiceSettings {
+		value := strings.ToLower(settings[name].value)
+		if value != "" && !slices.Contains(choices, value) {
+			invalid(name, "%q is not one of %s", settings[name].value, strings.Join(choices, ", "))
+		}
+	}
+
+	if ratio, err := strconv.ParseFloat(settings["TRACING_SAMPLE_RATIO"].value, 64); err == nil && ratio > 1 {
+		invalid("TRACING_SAMPLE_RATIO", "%g is above 1", ratio)
+	}
+	if strings.EqualFold(settings["STORAGE_BACKEND"].value, "s3") && settings["S3_BUCKET"].value == "" {
+		invalid("S3_BUCKET", "must be set when STORAGE_BACKEND is s3")
+	}
+	if strings.EqualFold(settings["DB_DRIVER"].value, "sqlite") && strings.EqualFold(settings["DB_TIMESCALE"].value, "on") {
+		invalid("DB_TIMESCALE", "can't be on when DB_DRIVER is sqlite")
+	}
+	if _, err := time.LoadLocation(settings["MARKET_TIMEZONE"].value); err != nil {
+		invalid("MARKET_TIMEZONE", "%q is not a known time zone", settings["MARKET_TIMEZONE"].value)
+	}
+	for _, name := range []string{"MARKET_OPEN_LOCAL", "MARKET_CLOSE_LOCAL"} {
+		if _, err := time.Parse("15:04", settings[name].value); err != nil {
+			invalid(name, "%q is not a time like 09:30", settings[name].value)
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
@@ -55,6 +55,27 @@ func (m *Manager) StoreBasicAuth(serviceType ServiceType, name, username, passwo
 	return m.store.Store(serviceType, CredentialTypeBasic, name, cred)
 }
 
+// SetAPIKey stores API key credentials for a service, rotating (replacing) the
+// existing key if there is one. Returns true when an existing key was rotated.
+func (m *Manager) SetAPIKey(serviceType ServiceType, name, key, secret, environment string) (*Credential, bool, error) {
+	_, err := m.store.GetByService(serviceType)
+	if err == ErrCredentialNotFound {
+		cred, err := m.StoreAPIKey(serviceType, name, key, secret, environment)
+		return cred, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	cred, err := m.UpdateAPIKey(serviceType, key, secret, environment)
+	return cred, true, err
+}
+
+// GetCredentialInfo returns credential metadata without decrypting it
+func (m *Manager) GetCredentialInfo(serviceType ServiceType) (*Credential, error) {
+	return m.store.GetByService(serviceType)
+}
+
 // GetCredential retrieves and decrypts credential data
 func (m *Manager) GetCredential(serviceType ServiceType) (CredentialData, error) {
 	return m.store.GetDecryptedData(serviceType)
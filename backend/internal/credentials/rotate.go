@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"database/sql"
+	"fmt"
+
+	"networth-dashboard/internal/database"
+)
+
+// RotateKey re-encrypts every stored credential encrypted with oldKey under
+// newKey in one transaction, returning how many were rewritten. A credential
+// oldKey cannot decrypt aborts the rotation, so a wrong old key changes
+// nothing.
+func RotateKey(db *sql.DB, oldKey, newKey string) (int, error) {
+	from, err := NewEncryptionService(oldKey)
+	if err != nil {
+		return 0, err
+	}
+	to, err := NewEncryptionService(newKey)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, encrypted_data FROM credentials` + database.DialectOf(db).ForUpdate())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	plaintexts := make(map[int][]byte)
+	for rows.Next() {
+		var id int
+		var encrypted string
+		if err := rows.Scan(&id, &encrypted); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan credential: %w", err)
+		}
+		plaintext, err := from.Decrypt(encrypted)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to decrypt credential %d with the current key: %w", id, err)
+		}
+		plaintexts[id] = plaintext
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for id, plaintext := range plaintexts {
+		encrypted, err := to.Encrypt(plaintext)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt credential %d: %w", id, err)
+		}
+		if _, err := tx.Exec(`UPDATE credentials SET encrypted_data = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, encrypted, id); err != nil {
+			return 0, fmt.Errorf("failed to update credential %d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit credentials: %w", err)
+	}
+	return len(plaintexts), nil
+}
@@ -181,7 +181,12 @@ func (s *Store) Update(serviceType ServiceType, data CredentialData) (*Credentia
 
 // Delete removes a credential
 func (s *Store) Delete(serviceType ServiceType) error {
-	query := `UPDATE credentials SET is_active = false WHERE service_type = $1`
+	// Only one inactive row per service is allowed, so drop any earlier one first
+	if _, err := s.db.Exec(`DELETE FROM credentials WHERE service_type = $1 AND is_active = false`, serviceType); err != nil {
+		return err
+	}
+
+	query := `UPDATE credentials SET is_active = false WHERE service_type = $1 AND is_active = true`
 	_, err := s.db.Exec(query, serviceType)
 	return err
 }
@@ -24,6 +24,15 @@ const (
 	ServiceTypeFidelity     ServiceType = "fidelity"
 	ServiceTypeMorganStanley ServiceType = "morgan_stanley"
 	ServiceTypeMarketData   ServiceType = "market_data"
+
+	// Data provider plugins whose API keys can be managed at runtime
+	ServiceTypeTwelveData    ServiceType = "twelvedata"
+	ServiceTypeAlphaVantage  ServiceType = "alphavantage"
+	ServiceTypeCoinGecko     ServiceType = "coingecko"
+	ServiceTypeCoinMarketCap ServiceType = "coinmarketcap"
+	ServiceTypeAttomData     ServiceType = "attomdata"
+	ServiceTypeRentcast      ServiceType = "rentcast"
+	ServiceTypeMarketCheck   ServiceType = "marketcheck"
 )
 
 // Credential represents a stored credential
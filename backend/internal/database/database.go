@@ -3,75 +3,257 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"networth-dashboard/internal/config"
 
-	_ "github.com/lib/pq"
+	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 type DB struct {
 	*sql.DB
+	// Dialect is the engine behind the database, PostgreSQL unless
+	// DB_DRIVER selects SQLite
+	Dialect Dialect
+	// Timescale is set when time series are stored in TimescaleDB
+	Timescale bool
 }
 
 func Initialize(cfg config.DatabaseConfig) (*DB, error) {
+	if cfg.Driver == config.DriverSQLite {
+		return initializeSQLite(cfg)
+	}
+
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
 
-	sqlDB, err := sql.Open("postgres", dsn)
+	pgxConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to parse database configuration: %w", err)
 	}
 
+	// Queries are traced as spans; row iteration and session resets are omitted to keep traces readable
+	sqlDB := otelsql.OpenDB(stdlib.GetConnector(*pgxConfig, stdlib.OptionAfterConnect(registerLenientText)),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	// Configure connection pool
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(25)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	db := &DB{sqlDB}
+	// Pool statistics are reported as db.sql.connection.* metrics to any
+	// OpenTelemetry meter provider installed
+	if err := otelsql.RegisterDBStatsMetrics(sqlDB, otelsql.WithAttributes(semconv.DBSystemPostgreSQL)); err != nil {
+		return nil, fmt.Errorf("failed to register database pool metrics: %w", err)
+	}
+
+	db := &DB{DB: sqlDB, Dialect: Postgres}
+	dialects.Store(sqlDB, Postgres)
 
 	// Run migrations
 	if err := db.runMigrations(); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	db.Timescale, err = db.setupTimescale(cfg.Timescale)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up TimescaleDB: %w", err)
+	}
+
 	return db, nil
 }
 
-func (db *DB) runMigrations() error {
-	// Create tables if they don't exist
-	migrations := []string{
-		createCredentialsTable,
-		createDataSourcesTable,
-		createAccountsTable,
-		createAccountBalancesTable,
-		createManualEntriesTable,
-		createManualEntryLogTable,
-		createStockHoldingsTable,
-		createStockPricesTable,
-		createEquityGrantsTable,
-		createVestingScheduleTable,
-		createRealEstatePropertiesTable,
-		createCashHoldingsTable,
-		createAssetCategoriesTable,
-		createMiscellaneousAssetsTable,
-		createNetWorthSnapshotsTable,
-		createCryptoHoldingsTable,
-		createCryptoPricesTable,
-		updateEquityGrantsTable,
-		updateRealEstateAddressFields,
-		updateStockHoldingsInstitution,
-		updateMiscellaneousAssetsTable,
-		updateStockHoldingsDividend,
-		updateStockHoldingsAdditionalFields,
-		updateCryptoHoldingsStaking,
-		updateStockHoldingsVestedSource,
-		createIndices,
-		seedAssetCategories,
+// initializeSQLite opens the SQLite database file at cfg.Path, creating it
+// and its directory when missing. TimescaleDB is PostgreSQL only.
+func initializeSQLite(cfg config.DatabaseConfig) (*DB, error) {
+	if cfg.Timescale == "on" {
+		return nil, fmt.Errorf("DB_TIMESCALE=on requires PostgreSQL")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	sqlDB := otelsql.OpenDB(sqliteConnector{dsn: sqliteDSN(cfg.Path)},
+		otelsql.WithAttributes(semconv.DBSystemSqlite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Only one connection writes at a time; the others wait for it up to
+	// the busy timeout
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := otelsql.RegisterDBStatsMetrics(sqlDB, otelsql.WithAttributes(semconv.DBSystemSqlite)); err != nil {
+		return nil, fmt.Errorf("failed to register database pool metrics: %w", err)
 	}
 
+	db := &DB{DB: sqlDB}
+	if err := db.alterSQLite(sqliteAlterations); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	if err := db.migrate(sqliteMigrations); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	// The dialect lists the columns of each table, so it is set up once
+	// the migrations have created them
+	dialect, err := newSQLiteDialect(sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	db.Dialect = dialect
+	dialects.Store(sqlDB, Dialect(dialect))
+
+	return db, nil
+}
+
+// postgresMigrations create the PostgreSQL schema when it doesn't exist and
+// bring an existing one up to date
+var postgresMigrations = []string{
+	createCredentialsTable,
+	createDataSourcesTable,
+	createAccountsTable,
+	createAccountBalancesTable,
+	createManualEntriesTable,
+	createManualEntryLogTable,
+	createStockHoldingsTable,
+	createStockPricesTable,
+	createEquityGrantsTable,
+	createVestingScheduleTable,
+	createRealEstatePropertiesTable,
+	createCashHoldingsTable,
+	createAssetCategoriesTable,
+	createMiscellaneousAssetsTable,
+	createNetWorthSnapshotsTable,
+	createCryptoHoldingsTable,
+	createCryptoPricesTable,
+	updateEquityGrantsTable,
+	updateRealEstateAddressFields,
+	updateStockHoldingsInstitution,
+	updateMiscellaneousAssetsTable,
+	updateStockHoldingsDividend,
+	updateStockHoldingsAdditionalFields,
+	updateCryptoHoldingsStaking,
+	updateStockHoldingsVestedSource,
+	updateCryptoPricesFetchedAt,
+	createSetupStateTable,
+	updateRealEstateOwnership,
+	createSymbolHealthTables,
+	createAuditLogTable,
+	updateEncryptedColumns,
+	createRecurringContributionsTables,
+	createGoalsTables,
+	createCashFlowTables,
+	createHoldingSnapshotsTable,
+	createSecurityMetadataTable,
+	createConcentrationAlertsTable,
+	createTagsTables,
+	updateNetWorthSnapshotComponents,
+	createMonthlyReportDeliveriesTable,
+	createEmailQueueTable,
+	createNotificationRulesTable,
+	createPluginConfigsTable,
+	addPluginConfigSchedule,
+	createUsersTables,
+	createHouseholdTables,
+	addNetWorthSnapshotTriggers,
+	createDashboardConfigsTable,
+	createSavedViewsTable,
+	createSymbolLookupsTable,
+	createPropertyLedgerTable,
+	addPropertyDepreciationColumns,
+	createPropertyValuationsTable,
+	createAssetValuationHistoryTable,
+	createMetalPricesTable,
+	createAssetValuationSuggestionsTable,
+	createSearchIndexes,
+	createAttachmentsTable,
+	createDemoRecordsTable,
+	createShareLinksTable,
+	createAPIKeysTable,
+	widenPriceColumns,
+	widenMoneyColumns,
+	createTradingWindowsTable,
+	addEquityGrantTypeFields,
+	createMarketHolidaysTable,
+	createIntradayPricesTables,
+	createCryptoTransactionsTable,
+	addCryptoStaking,
+	createPrivateInvestmentsTables,
+	createEmployerMatchRules,
+	createDailyPriceTables,
+	createNetWorthRollupViews,
+	createRuntimeSettingsTable,
+	createFundCompositionsTable,
+	createBondsTable,
+	createIBondsTable,
+	createPensionsTable,
+	createInsurancePoliciesTable,
+	addAccountOwner,
+	constrainAccountOwner,
+	generateDerivedColumns,
+	createLiabilitiesTable,
+	addLiabilityStatementAttachments,
+	createIndices,
+	seedAssetCategories,
+	configureVehicleValuation,
+	configureMetalValuation,
+	backfillAssetValuationHistory,
+}
+
+// sqliteMigrations create the SQLite schema (see migrations_sqlite.go) and
+// seed it as postgresMigrations do. schema_test.go checks the two schemas
+// have the same tables and columns.
+var sqliteMigrations = []string{
+	createSQLiteCoreTables,
+	createSQLiteHoldingTables,
+	createSQLitePriceTables,
+	createSQLiteNetWorthTables,
+	createSQLiteAppTables,
+	createSQLitePlanningTables,
+	createSQLiteValuationTables,
+	createSQLitePrivateInvestmentTables,
+	createSQLiteBondTables,
+	createSQLiteIBondTables,
+	createSQLitePensionsTable,
+	createSQLiteInsurancePoliciesTable,
+	createSQLiteLiabilityTables,
+	createSQLiteHoldingLinkTriggers,
+	createSQLiteChangeIndices,
+	seedAssetCategories,
+	configureVehicleValuation,
+	configureMetalValuation,
+	backfillAssetValuationHistory,
+}
+
+func (db *DB) runMigrations() error {
+	return db.migrate(postgresMigrations)
+}
+
+func (db *DB) migrate(migrations []string) error {
 	for _, migration := range migrations {
 		if _, err := db.Exec(migration); err != nil {
 			return fmt.Errorf("migration failed: %w", err)
@@ -79,4 +261,42 @@ func (db *DB) runMigrations() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// alterSQLite makes the alterations that are due, each in a transaction of
+// its own
+func (db *DB) alterSQLite(alterations []sqliteAlteration) error {
+	for _, alteration := range alterations {
+		var due bool
+		if err := db.QueryRow(alteration.due).Scan(&due); err != nil {
+			return fmt.Errorf("alteration check failed: %w", err)
+		}
+		if !due {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(alteration.change); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("alteration failed: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SeedDefaultAssetCategories inserts the default asset categories that are missing
+// and returns the number of categories added
+func SeedDefaultAssetCategories(db *sql.DB) (int64, error) {
+	result, err := db.Exec(seedAssetCategories)
+	if err != nil {
+		return 0, fmt.Errorf("failed to seed asset categories: %w", err)
+	}
+
+	return result.RowsAffected()
+}
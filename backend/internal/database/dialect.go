@@ -0,0 +1,246 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Dialect spells the parts of a query that differ between PostgreSQL and
+// SQLite. Everything else is written in the SQL both accept: CAST rather than
+// ::, window functions rather than DISTINCT ON, correlated subqueries rather
+// than LATERAL joins. Array parameters are passed as Go slices; SQLite
+// receives them as JSON arrays.
+type Dialect interface {
+	// Name is "postgres" or "sqlite"
+	Name() string
+	// ForUpdate is appended to a SELECT run in a transaction to keep the
+	// rows it reads from changing until the transaction ends
+	ForUpdate() string
+	// Date is the date of a timestamp expression
+	Date(expr string) string
+	// Month formats a date expression as YYYY-MM
+	Month(expr string) string
+	// AddDays is the date days after the date expression expr
+	AddDays(expr, days string) string
+	// InArray is a condition that expr equals an element of the array
+	// parameter param
+	InArray(expr, param string) string
+	// Unnest is a table named alias of the array parameters in columns,
+	// zipped into rows
+	Unnest(alias string, columns ...ArrayColumn) string
+	// ArrayAgg aggregates expr, which may end in ORDER BY, into an array read
+	// with Array. No rows give an empty array.
+	ArrayAgg(expr string) string
+	// FirstValue is the aggregate of expr on the first row of a group in
+	// orderBy order
+	FirstValue(expr, orderBy string) string
+	// JSONObject builds a JSON object from alternating keys and values.
+	// Values that are JSON documents are nested rather than quoted.
+	JSONObject(pairs ...string) string
+	// JSON reads a JSON column as a document, for nesting in JSONObject
+	JSON(expr string) string
+	// RowToJSON is the row of table, aliased alias, as a JSON object keyed
+	// by column
+	RowToJSON(table, alias string) string
+	// TextSearch is a condition that document contains every word of the
+	// parameter param as a word prefix, and an expression ranking the match.
+	// Bind param to TextSearchTerms.
+	TextSearch(document, param string) (match, rank string)
+	// TextSearchTerms is the parameter TextSearch matches words with
+	TextSearchTerms(words []string) any
+}
+
+// ArrayColumn is an array parameter of Unnest: its placeholder, the
+// PostgreSQL type of its elements and the column it becomes
+type ArrayColumn struct {
+	Param, Type, Name string
+}
+
+// Postgres is the PostgreSQL dialect
+var Postgres Dialect = postgresDialect{}
+
+// dialects records the dialect of each database Initialize opened
+var dialects sync.Map
+
+// DialectOf returns the dialect of db. Databases not opened by Initialize,
+// such as test doubles, are taken to be PostgreSQL.
+func DialectOf(db *sql.DB) Dialect {
+	if dialect, ok := dialects.Load(db); ok {
+		return dialect.(Dialect)
+	}
+	return Postgres
+}
+
+// IsSQLite reports whether db is a SQLite database
+func IsSQLite(db *sql.DB) bool {
+	_, ok := DialectOf(db).(*sqliteDialect)
+	return ok
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) ForUpdate() string { return " FOR UPDATE" }
+
+func (postgresDialect) Date(expr string) string { return "CAST(" + expr + " AS DATE)" }
+
+func (postgresDialect) Month(expr string) string { return "to_char(" + expr + ", 'YYYY-MM')" }
+
+func (postgresDialect) AddDays(expr, days string) string { return "(" + expr + " + " + days + ")" }
+
+func (postgresDialect) InArray(expr, param string) string { return expr + " = ANY(" + param + ")" }
+
+func (postgresDialect) Unnest(alias string, columns ...ArrayColumn) string {
+	arrays := make([]string, len(columns))
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		arrays[i] = "CAST(" + column.Param + " AS " + column.Type + "[])"
+		names[i] = column.Name
+	}
+	return fmt.Sprintf("unnest(%s) AS %s(%s)", strings.Join(arrays, ", "), alias, strings.Join(names, ", "))
+}
+
+func (postgresDialect) ArrayAgg(expr string) string { return "COALESCE(array_agg(" + expr + "), '{}')" }
+
+func (postgresDialect) FirstValue(expr, orderBy string) string {
+	return "(array_agg(" + expr + " ORDER BY " + orderBy + "))[1]"
+}
+
+func (postgresDialect) JSONObject(pairs ...string) string {
+	return "json_build_object(" + strings.Join(pairs, ", ") + ")"
+}
+
+func (postgresDialect) JSON(expr string) string { return expr }
+
+func (postgresDialect) RowToJSON(_, alias string) string { return "row_to_json(" + alias + ")" }
+
+func (postgresDialect) TextSearch(document, param string) (string, string) {
+	query := "to_tsquery('simple', " + param + ")"
+	vector := "to_tsvector('simple', " + document + ")"
+	return vector + " @@ " + query, "ts_rank(" + vector + ", " + query + ")"
+}
+
+// TextSearchTerms is a tsquery matching every word as a prefix
+func (postgresDialect) TextSearchTerms(words []string) any {
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// sqliteDialect is the SQLite dialect of a database. It keeps the columns of
+// each table, read once the migrations have run, for RowToJSON.
+type sqliteDialect struct {
+	columns map[string][]sqliteColumn
+}
+
+// sqliteColumn is a column of a table as SQLite declares it
+type sqliteColumn struct {
+	name, declType string
+}
+
+// newSQLiteDialect reads the columns of the tables of db
+func newSQLiteDialect(db *sql.DB) (*sqliteDialect, error) {
+	rows, err := db.Query(`
+		SELECT m.name, c.name, c.type
+		FROM sqlite_schema m, pragma_table_info(m.name) c
+		WHERE m.type = 'table'
+		ORDER BY m.name, c.cid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the SQLite schema: %w", err)
+	}
+	defer rows.Close()
+
+	d := &sqliteDialect{columns: map[string][]sqliteColumn{}}
+	for rows.Next() {
+		var table string
+		var column sqliteColumn
+		if err := rows.Scan(&table, &column.name, &column.declType); err != nil {
+			return nil, err
+		}
+		d.columns[table] = append(d.columns[table], column)
+	}
+	return d, rows.Err()
+}
+
+func (*sqliteDialect) Name() string { return "sqlite" }
+
+// ForUpdate is empty: SQLite locks the whole database rather than rows, and
+// transactions begin IMMEDIATE (see sqliteDSN), taking the write lock before
+// their first read, so nothing they read can change before they end
+func (*sqliteDialect) ForUpdate() string { return "" }
+
+func (*sqliteDialect) Date(expr string) string { return "date(" + expr + ")" }
+
+func (*sqliteDialect) Month(expr string) string { return "strftime('%Y-%m', " + expr + ")" }
+
+func (*sqliteDialect) AddDays(expr, days string) string {
+	return "date(" + expr + ", (" + days + ") || ' days')"
+}
+
+func (*sqliteDialect) InArray(expr, param string) string {
+	return expr + " IN (SELECT value FROM json_each(" + param + "))"
+}
+
+// Unnest zips the JSON arrays SQLite receives array parameters as by index
+func (*sqliteDialect) Unnest(alias string, columns ...ArrayColumn) string {
+	values := make([]string, len(columns))
+	joins := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = fmt.Sprintf("a%d.value AS %s", i, column.Name)
+		joins[i] = fmt.Sprintf("json_each(%s) a%d", column.Param, i)
+		if i > 0 {
+			joins[i] = fmt.Sprintf("JOIN %s ON a%d.key = a0.key", joins[i], i)
+		}
+	}
+	return fmt.Sprintf("(SELECT %s FROM %s) AS %s", strings.Join(values, ", "), strings.Join(joins, " "), alias)
+}
+
+func (*sqliteDialect) ArrayAgg(expr string) string { return "json_group_array(" + expr + ")" }
+
+func (*sqliteDialect) FirstValue(expr, orderBy string) string {
+	return "(json_group_array(" + expr + " ORDER BY " + orderBy + ") ->> 0)"
+}
+
+func (*sqliteDialect) JSONObject(pairs ...string) string {
+	return "json_object(" + strings.Join(pairs, ", ") + ")"
+}
+
+// JSON parses the text SQLite stores JSON columns as
+func (*sqliteDialect) JSON(expr string) string { return "json(" + expr + ")" }
+
+// RowToJSON lists the columns of table. They are left unqualified, which
+// RETURNING requires, so table must be the only table the statement reads.
+func (d *sqliteDialect) RowToJSON(table, _ string) string {
+	fields := make([]string, len(d.columns[table]))
+	for i, column := range d.columns[table] {
+		ref := `"` + column.name + `"`
+		switch strings.ToUpper(column.declType) {
+		case "JSON":
+			// Nested as documents rather than strings
+			ref = "json(" + ref + ")"
+		case "BOOLEAN":
+			ref = fmt.Sprintf("CASE WHEN %[1]s IS NULL THEN NULL WHEN %[1]s THEN json('true') ELSE json('false') END", ref)
+		}
+		fields[i] = "'" + column.name + "', " + ref
+	}
+	return "json_object(" + strings.Join(fields, ", ") + ")"
+}
+
+// TextSearch matches words at the start of a word of the document, or
+// after punctuation. SQLite has no ranking, so matches rank equally.
+func (*sqliteDialect) TextSearch(document, param string) (string, string) {
+	match := fmt.Sprintf(`NOT EXISTS (
+		SELECT 1 FROM json_each(%s) w
+		WHERE ' ' || LOWER(%s) NOT GLOB '*[^a-z0-9]' || LOWER(w.value) || '*')`, param, document)
+	return match, "0"
+}
+
+// TextSearchTerms is the words as a JSON array
+func (*sqliteDialect) TextSearchTerms(words []string) any {
+	return words
+}
@@ -372,6 +372,1295 @@ const (
 		CREATE INDEX IF NOT EXISTS idx_stock_holdings_vested ON stock_holdings(is_vested_equity) WHERE is_vested_equity = true;
 	`
 
+	// Schema update to track when crypto prices were fetched for provider rate limiting
+	updateCryptoPricesFetchedAt = `
+		-- Add fetched_at field to crypto_prices table (last_updated is the provider's quote time)
+		ALTER TABLE crypto_prices ADD COLUMN IF NOT EXISTS fetched_at TIMESTAMP;
+		
+		-- Backfill existing rows from last_updated
+		UPDATE crypto_prices SET fetched_at = last_updated WHERE fetched_at IS NULL;
+		
+		-- Add index for rate limit queries by provider
+		CREATE INDEX IF NOT EXISTS idx_crypto_prices_source_fetched ON crypto_prices(source, fetched_at);
+	`
+
+	// Schema update to support fractional ownership of real estate. Property
+	// figures stay stored at 100%; the percentage is applied when totals are computed.
+	updateRealEstateOwnership = `
+		ALTER TABLE real_estate_properties ADD COLUMN IF NOT EXISTS ownership_percentage DECIMAL(5,2) NOT NULL DEFAULT 100
+			CHECK (ownership_percentage > 0 AND ownership_percentage <= 100);
+	`
+
+	// Onboarding setup state and application settings
+	createSetupStateTable = `
+		CREATE TABLE IF NOT EXISTS setup_state (
+			step VARCHAR(50) PRIMARY KEY,
+			completed BOOLEAN DEFAULT false,
+			completed_at TIMESTAMP,
+			data JSONB,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS app_settings (
+			key VARCHAR(100) PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Price refresh health per symbol and in-app notifications
+	createSymbolHealthTables = `
+		CREATE TABLE IF NOT EXISTS price_symbol_health (
+			asset_type VARCHAR(10) NOT NULL, -- 'stock' or 'crypto'
+			symbol VARCHAR(20) NOT NULL,
+			consecutive_failures INTEGER DEFAULT 0,
+			last_error TEXT,
+			last_failure_at TIMESTAMP,
+			last_success_at TIMESTAMP,
+			paused BOOLEAN DEFAULT false,
+			paused_at TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (asset_type, symbol)
+		);
+
+		CREATE TABLE IF NOT EXISTS notifications (
+			id SERIAL PRIMARY KEY,
+			type VARCHAR(50) NOT NULL,
+			severity VARCHAR(20) DEFAULT 'info', -- 'info', 'warning', 'error'
+			title VARCHAR(200) NOT NULL,
+			message TEXT,
+			read BOOLEAN DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(read, created_at);
+	`
+
+	// Audit trail of data mutations made through the API
+	createAuditLogTable = `
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id SERIAL PRIMARY KEY,
+			action VARCHAR(20) NOT NULL, -- 'create', 'update', 'delete', 'bulk_update'
+			entity_type VARCHAR(50) NOT NULL,
+			entity_id INTEGER,
+			actor VARCHAR(100) NOT NULL,
+			ip_address VARCHAR(45),
+			old_values JSONB,
+			new_values JSONB,
+			changes JSONB, -- {"field": {"old": ..., "new": ...}}
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+	`
+
+	// Recurring monthly contributions to cash and brokerage accounts and the
+	// transactions they generate
+	createRecurringContributionsTables = `
+		CREATE TABLE IF NOT EXISTS recurring_contributions (
+			id SERIAL PRIMARY KEY,
+			cash_holding_id INTEGER NOT NULL UNIQUE REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			day_of_month INTEGER NOT NULL DEFAULT 1 CHECK (day_of_month BETWEEN 1 AND 28),
+			requires_confirmation BOOLEAN NOT NULL DEFAULT false,
+			active BOOLEAN NOT NULL DEFAULT true,
+			start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+			last_scheduled_date DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS contribution_transactions (
+			id SERIAL PRIMARY KEY,
+			recurring_contribution_id INTEGER NOT NULL REFERENCES recurring_contributions(id) ON DELETE CASCADE,
+			cash_holding_id INTEGER NOT NULL REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			scheduled_date DATE NOT NULL,
+			amount DECIMAL(10,2) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'applied', 'skipped'
+			balance_before DECIMAL(15,2),
+			balance_after DECIMAL(15,2),
+			applied_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(cash_holding_id, scheduled_date)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_contribution_transactions_status ON contribution_transactions(status, scheduled_date);
+	`
+
+	// Savings goals linked to asset classes and/or specific cash accounts
+	createGoalsTables = `
+		CREATE TABLE IF NOT EXISTS goals (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(200) NOT NULL,
+			description TEXT,
+			target_amount DECIMAL(15,2) NOT NULL CHECK (target_amount > 0),
+			target_date DATE,
+			asset_classes TEXT[] NOT NULL DEFAULT '{}', -- net worth component keys or 'net_worth'
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS goal_cash_holdings (
+			goal_id INTEGER NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+			cash_holding_id INTEGER NOT NULL REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			PRIMARY KEY (goal_id, cash_holding_id)
+		);
+	`
+
+	// Income and expense tracking for cash-flow summaries and savings rate
+	createCashFlowTables = `
+		CREATE TABLE IF NOT EXISTS cash_flow_categories (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			kind VARCHAR(10) NOT NULL CHECK (kind IN ('income', 'expense')),
+			color VARCHAR(7),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(name, kind)
+		);
+
+		CREATE TABLE IF NOT EXISTS cash_flow_transactions (
+			id SERIAL PRIMARY KEY,
+			category_id INTEGER NOT NULL REFERENCES cash_flow_categories(id) ON DELETE RESTRICT,
+			amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+			transaction_date DATE NOT NULL,
+			description TEXT,
+			cash_holding_id INTEGER REFERENCES cash_holdings(id) ON DELETE SET NULL,
+			source VARCHAR(20) NOT NULL DEFAULT 'manual', -- 'manual' or 'import'
+			import_key VARCHAR(64) UNIQUE, -- identifies imported rows so re-imports are skipped
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_cash_flow_transactions_date ON cash_flow_transactions(transaction_date);
+		CREATE INDEX IF NOT EXISTS idx_cash_flow_transactions_category ON cash_flow_transactions(category_id);
+
+		-- Seed default categories only on first run so deleted ones stay deleted
+		INSERT INTO cash_flow_categories (name, kind, color)
+		SELECT * FROM (VALUES
+			('Salary', 'income', '#10B981'),
+			('Other Income', 'income', '#34D399'),
+			('Housing', 'expense', '#EF4444'),
+			('Utilities', 'expense', '#F59E0B'),
+			('Groceries', 'expense', '#F97316'),
+			('Transportation', 'expense', '#3B82F6'),
+			('Dining', 'expense', '#EC4899'),
+			('Entertainment', 'expense', '#8B5CF6'),
+			('Healthcare', 'expense', '#14B8A6'),
+			('Other Expenses', 'expense', '#6B7280')
+		) AS defaults(name, kind, color)
+		WHERE NOT EXISTS (SELECT 1 FROM cash_flow_categories);
+	`
+
+	// Daily per-symbol position snapshots for gains history
+	createHoldingSnapshotsTable = `
+		CREATE TABLE IF NOT EXISTS holding_snapshots (
+			snapshot_date DATE NOT NULL,
+			symbol VARCHAR(10) NOT NULL,
+			shares_owned DECIMAL(15,6) NOT NULL,
+			cost_basis_total DECIMAL(15,2) NOT NULL,
+			market_value DECIMAL(15,2) NOT NULL,
+			recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (snapshot_date, symbol)
+		);
+	`
+
+	// Sector, industry and country of held securities for exposure analytics
+	createSecurityMetadataTable = `
+		CREATE TABLE IF NOT EXISTS security_metadata (
+			symbol VARCHAR(10) PRIMARY KEY,
+			name VARCHAR(200),
+			sector VARCHAR(100),
+			industry VARCHAR(150),
+			country VARCHAR(100),
+			asset_type VARCHAR(50),
+			source VARCHAR(20) NOT NULL, -- 'manual' or the provider it was fetched from
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Cached fund breakdowns for look-through exposure
+	createFundCompositionsTable = `
+		CREATE TABLE IF NOT EXISTS fund_compositions (
+			symbol VARCHAR(10) PRIMARY KEY,
+			sectors JSONB NOT NULL DEFAULT '{}', -- sector name to weight, as a fraction of the fund
+			top_holdings JSONB NOT NULL DEFAULT '[]',
+			source VARCHAR(20) NOT NULL, -- the provider it was fetched from
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Symbols already notified for exceeding the concentration threshold
+	createConcentrationAlertsTable = `
+		CREATE TABLE IF NOT EXISTS concentration_alerts (
+			symbol VARCHAR(10) PRIMARY KEY,
+			percent_of_assets DECIMAL(7,2) NOT NULL,
+			alerted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// User-defined tags and the holdings they are attached to. Holdings live in
+	// several tables, so a trigger on each removes the links of a deleted holding.
+	createTagsTables = `
+		CREATE TABLE IF NOT EXISTS tags (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(50) NOT NULL,
+			color VARCHAR(7),
+			description TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (LOWER(name));
+
+		CREATE TABLE IF NOT EXISTS holding_tags (
+			tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			holding_type VARCHAR(20) NOT NULL,
+			holding_id INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tag_id, holding_type, holding_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_holding_tags_holding ON holding_tags (holding_type, holding_id);
+
+		CREATE OR REPLACE FUNCTION delete_holding_tags() RETURNS trigger AS $$
+		BEGIN
+			DELETE FROM holding_tags WHERE holding_type = TG_ARGV[0] AND holding_id = OLD.id;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE TRIGGER stock_holdings_delete_tags AFTER DELETE ON stock_holdings
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('stock_holding');
+		CREATE OR REPLACE TRIGGER equity_grants_delete_tags AFTER DELETE ON equity_grants
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('equity_grant');
+		CREATE OR REPLACE TRIGGER real_estate_properties_delete_tags AFTER DELETE ON real_estate_properties
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('real_estate');
+		CREATE OR REPLACE TRIGGER cash_holdings_delete_tags AFTER DELETE ON cash_holdings
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('cash_holding');
+		CREATE OR REPLACE TRIGGER crypto_holdings_delete_tags AFTER DELETE ON crypto_holdings
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('crypto_holding');
+		CREATE OR REPLACE TRIGGER miscellaneous_assets_delete_tags AFTER DELETE ON miscellaneous_assets
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('other_asset');
+	`
+
+	// Record every asset class in net worth snapshots so monthly reports can
+	// compare allocation between two dates
+	updateNetWorthSnapshotComponents = `
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS cash_holdings_value DECIMAL(15,2);
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS crypto_holdings_value DECIMAL(15,2);
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS other_assets_value DECIMAL(15,2);
+	`
+
+	// Months whose scheduled report has been delivered, so each is sent once
+	createMonthlyReportDeliveriesTable = `
+		CREATE TABLE IF NOT EXISTS monthly_report_deliveries (
+			month CHAR(7) PRIMARY KEY,
+			delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Outgoing emails waiting to be sent or retried. Attachments are a JSON
+	// array with base64 content.
+	createEmailQueueTable = `
+		CREATE TABLE IF NOT EXISTS email_queue (
+			id SERIAL PRIMARY KEY,
+			recipients TEXT[] NOT NULL,
+			subject TEXT NOT NULL,
+			text_body TEXT NOT NULL,
+			html_body TEXT,
+			attachments JSONB,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			sent_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_email_queue_pending ON email_queue(next_attempt_at) WHERE status = 'pending';
+	`
+
+	// Per-type routing of notifications to delivery channels
+	createNotificationRulesTable = `
+		CREATE TABLE IF NOT EXISTS notification_rules (
+			id SERIAL PRIMARY KEY,
+			notification_type VARCHAR(50) NOT NULL UNIQUE,
+			channels TEXT[] NOT NULL DEFAULT '{}',
+			min_severity VARCHAR(20) NOT NULL DEFAULT 'info',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Plugin configurations saved through the API, applied at startup
+	createPluginConfigsTable = `
+		CREATE TABLE IF NOT EXISTS plugin_configs (
+			plugin_name VARCHAR(100) PRIMARY KEY,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			settings JSONB NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Cron schedule for refreshing each plugin's data
+	addPluginConfigSchedule = `
+		ALTER TABLE plugin_configs ADD COLUMN IF NOT EXISTS schedule VARCHAR(100) NOT NULL DEFAULT '';
+	`
+
+	// Users who sign in to the dashboard, and their session tokens (stored hashed)
+	createUsersTables = `
+		CREATE TABLE IF NOT EXISTS users (
+			id SERIAL PRIMARY KEY,
+			username VARCHAR(100) NOT NULL UNIQUE,
+			display_name VARCHAR(200),
+			role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'editor', 'viewer')),
+			password_hash TEXT NOT NULL,
+			last_login_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS user_sessions (
+			token_hash VARCHAR(64) PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);
+	`
+
+	// The household, its members and how much of each holding every member owns
+	createHouseholdTables = `
+		CREATE TABLE IF NOT EXISTS household (
+			id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+			name VARCHAR(100) NOT NULL DEFAULT 'Household',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		INSERT INTO household (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+		CREATE TABLE IF NOT EXISTS household_members (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_household_members_name ON household_members (LOWER(name));
+
+		CREATE TABLE IF NOT EXISTS holding_ownership (
+			holding_type VARCHAR(20) NOT NULL,
+			holding_id INTEGER NOT NULL,
+			member_id INTEGER NOT NULL REFERENCES household_members(id) ON DELETE CASCADE,
+			percentage DECIMAL(5,2) NOT NULL CHECK (percentage > 0 AND percentage <= 100),
+			PRIMARY KEY (holding_type, holding_id, member_id)
+		);
+
+		CREATE OR REPLACE FUNCTION delete_holding_ownership() RETURNS trigger AS $$
+		BEGIN
+			DELETE FROM holding_ownership WHERE holding_type = TG_ARGV[0] AND holding_id = OLD.id;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE TRIGGER stock_holdings_delete_ownership AFTER DELETE ON stock_holdings
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('stock_holding');
+		CREATE OR REPLACE TRIGGER equity_grants_delete_ownership AFTER DELETE ON equity_grants
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('equity_grant');
+		CREATE OR REPLACE TRIGGER real_estate_properties_delete_ownership AFTER DELETE ON real_estate_properties
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('real_estate');
+		CREATE OR REPLACE TRIGGER cash_holdings_delete_ownership AFTER DELETE ON cash_holdings
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('cash_holding');
+		CREATE OR REPLACE TRIGGER crypto_holdings_delete_ownership AFTER DELETE ON crypto_holdings
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('crypto_holding');
+		CREATE OR REPLACE TRIGGER miscellaneous_assets_delete_ownership AFTER DELETE ON miscellaneous_assets
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('other_asset');
+	`
+
+	// Snapshots recorded when a change moves net worth past the threshold, as
+	// well as the scheduled daily ones, with the request that triggered them
+	addNetWorthSnapshotTriggers = `
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS trigger_type VARCHAR(20) NOT NULL DEFAULT 'scheduled';
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS trigger_event VARCHAR(255);
+	`
+
+	// Dashboard widget layouts, one per user. The row without a user is the
+	// shared layout used while authentication is off.
+	createDashboardConfigsTable = `
+		CREATE TABLE IF NOT EXISTS dashboard_configs (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			config JSONB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_configs_user ON dashboard_configs (COALESCE(user_id, 0));
+	`
+
+	// Named filters over one holding list, applied with ?view={id}
+	createSavedViewsTable = `
+		CREATE TABLE IF NOT EXISTS saved_views (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			holding_type VARCHAR(20) NOT NULL,
+			filters JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_views_name ON saved_views (holding_type, LOWER(name));
+	`
+
+	// Ticker lookups against the provider's symbol search, kept so validating
+	// and enriching holdings does not spend quota on every write. Rows with
+	// found = false remember symbols the provider does not know.
+	createSymbolLookupsTable = `
+		CREATE TABLE IF NOT EXISTS symbol_lookups (
+			symbol VARCHAR(10) PRIMARY KEY,
+			found BOOLEAN NOT NULL,
+			name VARCHAR(200),
+			exchange VARCHAR(50),
+			security_type VARCHAR(50),
+			country VARCHAR(100),
+			currency VARCHAR(10),
+			source VARCHAR(20) NOT NULL,
+			looked_up_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		ALTER TABLE stock_holdings ADD COLUMN IF NOT EXISTS exchange VARCHAR(50);
+		ALTER TABLE stock_holdings ADD COLUMN IF NOT EXISTS security_type VARCHAR(50);
+	`
+
+	// Rent received and money spent on each property. Amounts are for the
+	// whole property, positive; the category says which way the money moved.
+	createPropertyLedgerTable = `
+		CREATE TABLE IF NOT EXISTS property_ledger_entries (
+			id SERIAL PRIMARY KEY,
+			property_id INTEGER NOT NULL REFERENCES real_estate_properties(id) ON DELETE CASCADE,
+			category VARCHAR(30) NOT NULL,
+			amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+			entry_date DATE NOT NULL,
+			description TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_property_ledger_entries_property ON property_ledger_entries(property_id, entry_date);
+	`
+
+	// Depreciation inputs for rental properties: the building's share of the
+	// purchase price (land does not depreciate) and when it was first rented
+	addPropertyDepreciationColumns = `
+		ALTER TABLE real_estate_properties ADD COLUMN IF NOT EXISTS improvement_value DECIMAL(15,2);
+		ALTER TABLE real_estate_properties ADD COLUMN IF NOT EXISTS placed_in_service_date DATE;
+	`
+
+	// Valuations fetched for each property, one row per provider that answered
+	// plus the consensus across them
+	createPropertyValuationsTable = `
+		CREATE TABLE IF NOT EXISTS property_valuations (
+			id SERIAL PRIMARY KEY,
+			property_id INTEGER NOT NULL REFERENCES real_estate_properties(id) ON DELETE CASCADE,
+			provider VARCHAR(50) NOT NULL, -- provider name, or 'consensus'
+			estimated_value DECIMAL(15,2) NOT NULL,
+			confidence_score DECIMAL(5,2),
+			valued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_property_valuations_property ON property_valuations(property_id, valued_at);
+	`
+
+	// Values an other asset has had, one row per valuation
+	createAssetValuationHistoryTable = `
+		CREATE TABLE IF NOT EXISTS asset_valuation_history (
+			id SERIAL PRIMARY KEY,
+			asset_id INTEGER NOT NULL REFERENCES miscellaneous_assets(id) ON DELETE CASCADE,
+			value DECIMAL(15,2) NOT NULL,
+			source VARCHAR(50) NOT NULL, -- valuation provider, or manual
+			valued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_asset_valuation_history_asset ON asset_valuation_history(asset_id, valued_at);
+	`
+
+	// Precious metal spot prices in USD per troy ounce, one row per fetch
+	createMetalPricesTable = `
+		CREATE TABLE IF NOT EXISTS metal_prices (
+			id SERIAL PRIMARY KEY,
+			metal VARCHAR(20) NOT NULL,
+			price_usd DECIMAL(15,4) NOT NULL,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			source VARCHAR(50)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_metal_prices_metal ON metal_prices(metal, last_updated);
+	`
+
+	// Values suggested for other assets that wait for confirmation, at most
+	// one per asset
+	createAssetValuationSuggestionsTable = `
+		CREATE TABLE IF NOT EXISTS asset_valuation_suggestions (
+			asset_id INTEGER PRIMARY KEY REFERENCES miscellaneous_assets(id) ON DELETE CASCADE,
+			value DECIMAL(15,2) NOT NULL,
+			provider VARCHAR(50) NOT NULL,
+			suggested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Full-text search indexes; the expressions must match the search documents
+	// in repository/search.go for the indexes to be used
+	createSearchIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_search ON stock_holdings USING GIN (
+			to_tsvector('simple', COALESCE(symbol, '') || ' ' || COALESCE(company_name, '')));
+		CREATE INDEX IF NOT EXISTS idx_equity_grants_search ON equity_grants USING GIN (
+			to_tsvector('simple', COALESCE(company_symbol, '') || ' ' || COALESCE(grant_type, '')));
+		CREATE INDEX IF NOT EXISTS idx_real_estate_search ON real_estate_properties USING GIN (
+			to_tsvector('simple', COALESCE(property_name, '') || ' ' || COALESCE(street_address, '') || ' ' || COALESCE(city, '') || ' ' || COALESCE(state, '') || ' ' || COALESCE(zip_code, '') || ' ' || COALESCE(notes, '')));
+		CREATE INDEX IF NOT EXISTS idx_cash_holdings_search ON cash_holdings USING GIN (
+			to_tsvector('simple', COALESCE(institution_name, '') || ' ' || COALESCE(account_name, '') || ' ' || COALESCE(account_type, '') || ' ' || COALESCE(notes, '')));
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_search ON crypto_holdings USING GIN (
+			to_tsvector('simple', COALESCE(crypto_symbol, '') || ' ' || COALESCE(institution_name, '') || ' ' || COALESCE(notes, '')));
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_search ON miscellaneous_assets USING GIN (
+			to_tsvector('simple', COALESCE(asset_name, '') || ' ' || COALESCE(description, '') || ' ' || COALESCE(notes, '')));
+	`
+
+	// Notes and files attached to any holding; entity_type is a holding type
+	// and entity_id the holding's ID
+	createAttachmentsTable = `
+		CREATE TABLE IF NOT EXISTS attachments (
+			id SERIAL PRIMARY KEY,
+			entity_type VARCHAR(30) NOT NULL,
+			entity_id INTEGER NOT NULL,
+			kind VARCHAR(10) NOT NULL CHECK (kind IN ('note', 'file')),
+			note TEXT,
+			file_name VARCHAR(255),
+			content_type VARCHAR(100),
+			size_bytes BIGINT,
+			storage_key VARCHAR(100),
+			created_by VARCHAR(100),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_attachments_entity ON attachments(entity_type, entity_id);
+	`
+
+	// Rows added by demo data, so they can be wiped without touching real data
+	createDemoRecordsTable = `
+		CREATE TABLE IF NOT EXISTS demo_records (
+			table_name VARCHAR(50) NOT NULL,
+			record_id INTEGER NOT NULL,
+			PRIMARY KEY (table_name, record_id)
+		);
+	`
+
+	// Read-only links to a frozen allocation snapshot; only the token's hash is stored
+	createShareLinksTable = `
+		CREATE TABLE IF NOT EXISTS share_links (
+			id SERIAL PRIMARY KEY,
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			label VARCHAR(200),
+			value_mode VARCHAR(10) NOT NULL CHECK (value_mode IN ('masked', 'scaled', 'exact')),
+			include_holdings BOOLEAN NOT NULL DEFAULT false,
+			snapshot JSONB NOT NULL,
+			created_by VARCHAR(100),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			revoked_at TIMESTAMP,
+			view_count INTEGER NOT NULL DEFAULT 0,
+			last_viewed_at TIMESTAMP
+		);
+	`
+
+	// Keys external tools authenticate with instead of a session; only the
+	// key's hash is stored. An empty asset_classes allows every asset class.
+	createAPIKeysTable = `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			key_prefix VARCHAR(20) NOT NULL,
+			key_hash VARCHAR(64) NOT NULL UNIQUE,
+			scope VARCHAR(10) NOT NULL CHECK (scope IN ('read', 'write')),
+			asset_classes TEXT[] NOT NULL DEFAULT '{}',
+			created_by VARCHAR(100),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		);
+	`
+
+	// Open trading windows and blackout periods for employer stock, and the
+	// last trading status notified per company
+	createTradingWindowsTable = `
+		CREATE TABLE IF NOT EXISTS trading_windows (
+			id SERIAL PRIMARY KEY,
+			company_symbol VARCHAR(10) NOT NULL,
+			window_type VARCHAR(10) NOT NULL CHECK (window_type IN ('open', 'blackout')),
+			start_date DATE NOT NULL,
+			end_date DATE NOT NULL,
+			note VARCHAR(255),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK (end_date >= start_date)
+		);
+		CREATE INDEX IF NOT EXISTS idx_trading_windows_symbol ON trading_windows(company_symbol, start_date);
+
+		CREATE TABLE IF NOT EXISTS trading_window_alerts (
+			company_symbol VARCHAR(10) PRIMARY KEY,
+			can_trade BOOLEAN NOT NULL,
+			changed_at TIMESTAMP NOT NULL
+		);
+	`
+
+	// Formalize equity grant types. grant_type was free text; existing values
+	// are lower-cased and the old stock_option type, which didn't say which
+	// kind of option, becomes nso. The check is NOT VALID so rows the update
+	// couldn't map don't stop startup.
+	addEquityGrantTypeFields = `
+		ALTER TABLE equity_grants ADD COLUMN IF NOT EXISTS expiration_date DATE;
+		ALTER TABLE equity_grants ADD COLUMN IF NOT EXISTS election_83b_filed_date DATE;
+		ALTER TABLE equity_grants ADD COLUMN IF NOT EXISTS amt_basis_per_share NUMERIC(18,6);
+
+		UPDATE equity_grants SET grant_type = LOWER(TRIM(grant_type))
+		WHERE grant_type <> LOWER(TRIM(grant_type));
+		UPDATE equity_grants SET grant_type = 'nso'
+		WHERE grant_type IN ('stock_option', 'stock_options');
+
+		DO $$
+		BEGIN
+		    IF NOT EXISTS (
+		        SELECT 1 FROM information_schema.table_constraints
+		        WHERE table_name = 'equity_grants' AND constraint_name = 'equity_grants_grant_type_check'
+		    ) THEN
+		        ALTER TABLE equity_grants ADD CONSTRAINT equity_grants_grant_type_check
+		            CHECK (grant_type IN ('iso', 'nso', 'rsu', 'espp', 'rsa')) NOT VALID;
+		    END IF;
+		END $$;
+	`
+
+	// Market holidays: the standard exchange calendar, filled a year ahead by
+	// a background job, plus closures added by hand. Removed holidays are
+	// kept so the job doesn't add them back.
+	createMarketHolidaysTable = `
+		CREATE TABLE IF NOT EXISTS market_holidays (
+			id SERIAL PRIMARY KEY,
+			holiday_date DATE NOT NULL UNIQUE,
+			name VARCHAR(100) NOT NULL,
+			source VARCHAR(20) NOT NULL DEFAULT 'manual',
+			removed BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Intraday 5-minute bars for sparklines, one trading day per symbol, and a
+	// log of intraday API calls counted against the provider's rate limits
+	createIntradayPricesTables = `
+		CREATE TABLE IF NOT EXISTS intraday_prices (
+			symbol VARCHAR(20) PRIMARY KEY,
+			trading_date DATE NOT NULL,
+			bars JSONB NOT NULL,
+			source VARCHAR(50) NOT NULL,
+			fetched_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS intraday_price_calls (
+			id SERIAL PRIMARY KEY,
+			symbol VARCHAR(20) NOT NULL,
+			source VARCHAR(50) NOT NULL,
+			called_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_intraday_price_calls_source ON intraday_price_calls(source, called_at);
+		DELETE FROM intraday_price_calls WHERE called_at < CURRENT_DATE - INTERVAL '7 days';
+	`
+
+	// Crypto purchases and sales. A holding's balance is kept in step with
+	// them, so the balance held before the first one stays an opening lot
+	// at the holding's purchase price.
+	createCryptoTransactionsTable = `
+		CREATE TABLE IF NOT EXISTS crypto_transactions (
+			id SERIAL PRIMARY KEY,
+			crypto_holding_id INTEGER NOT NULL REFERENCES crypto_holdings(id) ON DELETE CASCADE,
+			transaction_type VARCHAR(10) NOT NULL CHECK (transaction_type IN ('buy', 'sell')),
+			quantity DECIMAL(20,8) NOT NULL CHECK (quantity > 0),
+			price_usd DECIMAL(20,8) NOT NULL CHECK (price_usd >= 0),
+			fee_usd DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (fee_usd >= 0),
+			transaction_date DATE NOT NULL,
+			notes VARCHAR(255),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_crypto_transactions_holding ON crypto_transactions(crypto_holding_id, transaction_date);
+	`
+
+	// Split crypto balances into staked and liquid tokens and record staking
+	// rewards as transactions. Holdings already earning a staking rate were
+	// counted as fully staked, so start out that way.
+	addCryptoStaking = `
+		DO $$
+		BEGIN
+		    IF NOT EXISTS (
+		        SELECT 1 FROM information_schema.columns
+		        WHERE table_name = 'crypto_holdings' AND column_name = 'staked_tokens'
+		    ) THEN
+		        ALTER TABLE crypto_holdings ADD COLUMN staked_tokens DECIMAL(20,8) NOT NULL DEFAULT 0;
+		        UPDATE crypto_holdings SET staked_tokens = balance_tokens WHERE staking_annual_percentage > 0;
+		    END IF;
+
+		    IF NOT EXISTS (
+		        SELECT 1 FROM pg_constraint
+		        WHERE conname = 'crypto_transactions_transaction_type_check'
+		          AND pg_get_constraintdef(oid) LIKE '%reward%'
+		    ) THEN
+		        ALTER TABLE crypto_transactions DROP CONSTRAINT IF EXISTS crypto_transactions_transaction_type_check;
+		        ALTER TABLE crypto_transactions ADD CONSTRAINT crypto_transactions_transaction_type_check
+		            CHECK (transaction_type IN ('buy', 'sell', 'reward'));
+		    END IF;
+		END $$;
+
+		ALTER TABLE crypto_holdings ADD COLUMN IF NOT EXISTS staking_accrued_through DATE;
+	`
+
+	// Private investments such as angel rounds and private equity or venture
+	// funds. current_value is the last reported NAV plus capital called and
+	// less distributions paid since, kept up to date by triggers so every
+	// writer agrees with net worth.
+	createPrivateInvestmentsTables = `
+		CREATE TABLE IF NOT EXISTS private_investments (
+			id SERIAL PRIMARY KEY,
+			account_id INTEGER REFERENCES accounts(id),
+			investment_name VARCHAR(100) NOT NULL,
+			investment_type VARCHAR(30) NOT NULL DEFAULT 'private_equity', -- angel, private_equity, venture_capital, real_estate_fund, hedge_fund, other
+			sponsor VARCHAR(100) NOT NULL, -- fund manager, or the company for a direct investment
+			committed_capital DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (committed_capital >= 0),
+			current_nav DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (current_nav >= 0),
+			nav_date DATE NOT NULL DEFAULT CURRENT_DATE,
+			current_value DECIMAL(15,2) NOT NULL DEFAULT 0,
+			vintage_year INTEGER,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS private_investment_flows (
+			id SERIAL PRIMARY KEY,
+			private_investment_id INTEGER NOT NULL REFERENCES private_investments(id) ON DELETE CASCADE,
+			flow_type VARCHAR(20) NOT NULL CHECK (flow_type IN ('capital_call', 'distribution')),
+			amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+			flow_date DATE NOT NULL,
+			notes VARCHAR(255),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_private_investment_flows_investment ON private_investment_flows(private_investment_id, flow_date);
+
+		CREATE TABLE IF NOT EXISTS private_investment_navs (
+			id SERIAL PRIMARY KEY,
+			private_investment_id INTEGER NOT NULL REFERENCES private_investments(id) ON DELETE CASCADE,
+			nav DECIMAL(15,2) NOT NULL,
+			nav_date DATE NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_private_investment_navs_investment ON private_investment_navs(private_investment_id, nav_date);
+
+		CREATE OR REPLACE FUNCTION value_private_investment() RETURNS trigger AS $$
+		BEGIN
+			NEW.current_value := GREATEST(NEW.current_nav + COALESCE((
+				SELECT SUM(CASE WHEN f.flow_type = 'capital_call' THEN f.amount ELSE -f.amount END)
+				FROM private_investment_flows f
+				WHERE f.private_investment_id = NEW.id AND f.flow_date > NEW.nav_date
+			), 0), 0);
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE FUNCTION revalue_private_investment() RETURNS trigger AS $$
+		BEGIN
+			UPDATE private_investments SET current_value = current_value
+			WHERE id = CASE WHEN TG_OP = 'DELETE' THEN OLD.private_investment_id ELSE NEW.private_investment_id END;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE TRIGGER private_investments_value BEFORE INSERT OR UPDATE ON private_investments
+			FOR EACH ROW EXECUTE FUNCTION value_private_investment();
+		CREATE OR REPLACE TRIGGER private_investment_flows_revalue AFTER INSERT OR UPDATE OR DELETE ON private_investment_flows
+			FOR EACH ROW EXECUTE FUNCTION revalue_private_investment();
+		CREATE OR REPLACE TRIGGER private_investments_delete_tags AFTER DELETE ON private_investments
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('private_investment');
+		CREATE OR REPLACE TRIGGER private_investments_delete_ownership AFTER DELETE ON private_investments
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('private_investment');
+
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS private_investments_value DECIMAL(15,2);
+	`
+
+	// Employer match rules of 401(k), 403(b), 457(b) and HSA accounts tracked
+	// as cash holdings. The employer adds match_percent of the employee's
+	// contributions, counting contributions up to salary_limit_percent of
+	// annual_salary and paying at most annual_match_cap a year.
+	// employer_match_alerts remembers accounts already warned about leaving
+	// match unclaimed, so each is notified once until it is fixed.
+	createEmployerMatchRules = `
+		CREATE TABLE IF NOT EXISTS employer_match_rules (
+			id SERIAL PRIMARY KEY,
+			cash_holding_id INTEGER NOT NULL UNIQUE REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			plan_type VARCHAR(10) NOT NULL CHECK (plan_type IN ('401k', '403b', '457b', 'hsa')),
+			match_percent DECIMAL(7,2) NOT NULL CHECK (match_percent > 0),
+			salary_limit_percent DECIMAL(5,2) CHECK (salary_limit_percent > 0),
+			annual_salary DECIMAL(15,2) CHECK (annual_salary >= 0),
+			annual_match_cap DECIMAL(15,2) CHECK (annual_match_cap >= 0),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS employer_match_alerts (
+			cash_holding_id INTEGER PRIMARY KEY REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			missed_annual_match DECIMAL(15,2) NOT NULL,
+			alerted_at TIMESTAMP NOT NULL
+		);
+	`
+
+	// Daily prices folded from stock and crypto prices older than the
+	// retention period. The history views read both, so past prices survive
+	// pruning; a daily row whose close is still kept as a raw price is left
+	// out of the view.
+	createDailyPriceTables = `
+		CREATE TABLE IF NOT EXISTS stock_prices_daily (
+			symbol VARCHAR(20) NOT NULL,
+			price_date DATE NOT NULL,
+			open NUMERIC(18,6) NOT NULL,
+			high NUMERIC(18,6) NOT NULL,
+			low NUMERIC(18,6) NOT NULL,
+			close NUMERIC(18,6) NOT NULL,
+			opened_at TIMESTAMP NOT NULL,
+			closed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (symbol, price_date)
+		);
+
+		CREATE TABLE IF NOT EXISTS crypto_prices_daily (
+			symbol VARCHAR(20) NOT NULL,
+			price_date DATE NOT NULL,
+			open DECIMAL(15,8) NOT NULL,
+			high DECIMAL(15,8) NOT NULL,
+			low DECIMAL(15,8) NOT NULL,
+			close DECIMAL(15,8) NOT NULL,
+			close_btc DECIMAL(15,8),
+			opened_at TIMESTAMP NOT NULL,
+			closed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (symbol, price_date)
+		);
+
+		CREATE OR REPLACE VIEW stock_price_history AS
+			SELECT symbol, price, timestamp FROM stock_prices
+			UNION ALL
+			SELECT d.symbol, d.close, d.closed_at FROM stock_prices_daily d
+			WHERE NOT EXISTS (
+				SELECT 1 FROM stock_prices sp WHERE sp.symbol = d.symbol AND sp.timestamp = d.closed_at
+			);
+
+		CREATE OR REPLACE VIEW crypto_price_history AS
+			SELECT symbol, price_usd, price_btc, last_updated FROM crypto_prices
+			UNION ALL
+			SELECT d.symbol, d.close, d.close_btc, d.closed_at FROM crypto_prices_daily d
+			WHERE NOT EXISTS (
+				SELECT 1 FROM crypto_prices cp WHERE cp.symbol = d.symbol AND cp.last_updated = d.closed_at
+			);
+	`
+
+	// Daily and weekly net worth rollups: the last snapshot of each period
+	// with its low and high. With TimescaleDB they are replaced by continuous
+	// aggregates of the same shape (see timescale.go), so they are only
+	// created while missing.
+	createNetWorthRollupViews = `
+		DO $$
+		BEGIN
+		    IF to_regclass('net_worth_daily') IS NULL THEN
+		        CREATE VIEW net_worth_daily AS
+		            SELECT date_trunc('day', timestamp) AS bucket,
+		                   (array_agg(net_worth ORDER BY timestamp DESC))[1] AS net_worth,
+		                   MIN(net_worth) AS net_worth_low,
+		                   MAX(net_worth) AS net_worth_high,
+		                   (array_agg(total_assets ORDER BY timestamp DESC))[1] AS total_assets,
+		                   (array_agg(total_liabilities ORDER BY timestamp DESC))[1] AS total_liabilities,
+		                   COUNT(*) AS snapshots
+		            FROM net_worth_snapshots
+		            GROUP BY 1;
+		    END IF;
+		    IF to_regclass('net_worth_weekly') IS NULL THEN
+		        CREATE VIEW net_worth_weekly AS
+		            SELECT date_trunc('week', timestamp) AS bucket,
+		                   (array_agg(net_worth ORDER BY timestamp DESC))[1] AS net_worth,
+		                   MIN(net_worth) AS net_worth_low,
+		                   MAX(net_worth) AS net_worth_high,
+		                   (array_agg(total_assets ORDER BY timestamp DESC))[1] AS total_assets,
+		                   (array_agg(total_liabilities ORDER BY timestamp DESC))[1] AS total_liabilities,
+		                   COUNT(*) AS snapshots
+		            FROM net_worth_snapshots
+		            GROUP BY 1;
+		    END IF;
+		END $$;
+	`
+
+	// Settings changed at runtime through /admin/settings, overriding the
+	// environment and configuration file
+	createRuntimeSettingsTable = `
+		CREATE TABLE IF NOT EXISTS runtime_settings (
+			name VARCHAR(100) PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_by VARCHAR(100),
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Generate derived columns from the values they follow, so a write that
+	// misses one can't leave it stale: a grant's unvested shares from its
+	// total and vested shares, and a property's equity from its value and
+	// mortgage, as stock holdings' market_value already is
+	generateDerivedColumns = `
+		DO $$
+		BEGIN
+		    IF EXISTS (
+		        SELECT 1 FROM information_schema.columns
+		        WHERE table_name = 'equity_grants' AND column_name = 'unvested_shares' AND is_generated = 'NEVER'
+		    ) THEN
+		        ALTER TABLE equity_grants DROP COLUMN unvested_shares;
+		        ALTER TABLE equity_grants ADD COLUMN unvested_shares DECIMAL(15,6)
+		            GENERATED ALWAYS AS (total_shares - COALESCE(vested_shares, 0)) STORED;
+		    END IF;
+		    IF EXISTS (
+		        SELECT 1 FROM information_schema.columns
+		        WHERE table_name = 'real_estate_properties' AND column_name = 'equity' AND is_generated = 'NEVER'
+		    ) THEN
+		        ALTER TABLE real_estate_properties DROP COLUMN equity;
+		        ALTER TABLE real_estate_properties ADD COLUMN equity DECIMAL(15,2)
+		            GENERATED ALWAYS AS (current_value - COALESCE(outstanding_mortgage, 0)) STORED;
+		    END IF;
+		END $$;
+	`
+
+	// Bonds, treasuries and CDs, with prices as a percentage of face value.
+	// models.Bond.Value values each bond at its current price, or its
+	// purchase price until one is entered, plus the interest accrued since
+	// its last coupon, so the SQL function and view that once did are
+	// dropped. A matured bond counts at face value until it is removed.
+	createBondsTable = `
+		CREATE TABLE IF NOT EXISTS bonds (
+			id SERIAL PRIMARY KEY,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name VARCHAR(100) NOT NULL,
+			bond_name VARCHAR(100) NOT NULL,
+			bond_type VARCHAR(20) NOT NULL DEFAULT 'treasury', -- treasury, agency, municipal, corporate, cd, other
+			cusip VARCHAR(9),
+			face_value DECIMAL(15,2) NOT NULL CHECK (face_value > 0),
+			coupon_rate DECIMAL(7,4) NOT NULL DEFAULT 0 CHECK (coupon_rate >= 0), -- annual, as a percentage of face value
+			coupon_frequency INTEGER NOT NULL DEFAULT 2 CHECK (coupon_frequency IN (0, 1, 2, 4, 12)), -- payments a year, 0 for zero-coupon
+			issue_date DATE,
+			maturity_date DATE NOT NULL,
+			purchase_price DECIMAL(10,4) NOT NULL CHECK (purchase_price > 0),
+			purchase_date DATE,
+			current_price DECIMAL(10,4) CHECK (current_price > 0),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_bonds_maturity ON bonds(maturity_date);
+
+		DROP VIEW IF EXISTS bond_values;
+		DROP FUNCTION IF EXISTS bond_accrued_interest(DECIMAL, DECIMAL, INTEGER, DATE, DATE);
+
+		CREATE OR REPLACE TRIGGER bonds_delete_tags AFTER DELETE ON bonds
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('bond');
+		CREATE OR REPLACE TRIGGER bonds_delete_ownership AFTER DELETE ON bonds
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('bond');
+
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS fixed_income_value DECIMAL(15,2);
+	`
+
+	// i_bond_rates is the Series I savings bond rate schedule Treasury
+	// announces each May 1 and November 1: the fixed rate of bonds issued in
+	// the next six months and the semiannual inflation rate every bond earns
+	// for its six-month periods starting then. Rates are percentages.
+	// models.IBond.Value values I bonds from it, so the SQL functions and
+	// view that once did are dropped.
+	createIBondsTable = `
+		CREATE TABLE IF NOT EXISTS i_bond_rates (
+			effective_date DATE PRIMARY KEY
+				CHECK (EXTRACT(DAY FROM effective_date) = 1 AND EXTRACT(MONTH FROM effective_date) IN (5, 11)),
+			fixed_rate DECIMAL(6,4) NOT NULL CHECK (fixed_rate >= 0),
+			inflation_rate DECIMAL(6,4) NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		INSERT INTO i_bond_rates (effective_date, fixed_rate, inflation_rate) VALUES
+		('2017-05-01', 0.00, 0.98),
+		('2017-11-01', 0.10, 1.24),
+		('2018-05-01', 0.30, 1.11),
+		('2018-11-01', 0.50, 1.16),
+		('2019-05-01', 0.50, 0.70),
+		('2019-11-01', 0.20, 1.01),
+		('2020-05-01', 0.00, 0.53),
+		('2020-11-01', 0.00, 0.84),
+		('2021-05-01', 0.00, 1.77),
+		('2021-11-01', 0.00, 3.56),
+		('2022-05-01', 0.00, 4.81),
+		('2022-11-01', 0.40, 3.24),
+		('2023-05-01', 0.90, 1.69),
+		('2023-11-01', 1.30, 1.97),
+		('2024-05-01', 1.30, 1.48),
+		('2024-11-01', 1.20, 0.95),
+		('2025-05-01', 1.10, 1.43),
+		('2025-11-01', 0.90, 1.56)
+		ON CONFLICT (effective_date) DO NOTHING;
+
+		-- I bonds are issued on the first of the month they are bought
+		CREATE TABLE IF NOT EXISTS i_bonds (
+			id SERIAL PRIMARY KEY,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name VARCHAR(100) NOT NULL DEFAULT 'TreasuryDirect',
+			serial_number VARCHAR(20),
+			issue_date DATE NOT NULL CHECK (EXTRACT(DAY FROM issue_date) = 1),
+			purchase_amount DECIMAL(15,2) NOT NULL CHECK (purchase_amount >= 25),
+			fixed_rate DECIMAL(6,4) NOT NULL CHECK (fixed_rate >= 0),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_i_bonds_issue ON i_bonds(issue_date);
+
+		DROP VIEW IF EXISTS i_bond_values;
+		DROP FUNCTION IF EXISTS i_bond_value(DECIMAL, DATE, DECIMAL, DATE);
+		DROP FUNCTION IF EXISTS i_bond_inflation_rate(DATE);
+		DROP FUNCTION IF EXISTS i_bond_composite_rate(DECIMAL, DECIMAL);
+
+		CREATE OR REPLACE TRIGGER i_bonds_delete_tags AFTER DELETE ON i_bonds
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('i_bond');
+		CREATE OR REPLACE TRIGGER i_bonds_delete_ownership AFTER DELETE ON i_bonds
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('i_bond');
+
+		-- i_bond_rate_alerts remembers the announcement dates already notified
+		-- as missing from the schedule
+		CREATE TABLE IF NOT EXISTS i_bond_rate_alerts (
+			effective_date DATE PRIMARY KEY,
+			alerted_at TIMESTAMP NOT NULL
+		);
+	`
+
+	// Defined-benefit pensions and annuities are valued at the present value
+	// of their remaining payments, and only count toward net worth when
+	// include_in_net_worth is set, since they can't be sold or borrowed against.
+	// models.Pension.Value values them, so the SQL function and view that
+	// once did are dropped.
+	createPensionsTable = `
+		CREATE TABLE IF NOT EXISTS pensions (
+			id SERIAL PRIMARY KEY,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name VARCHAR(100) NOT NULL,
+			plan_name VARCHAR(100) NOT NULL,
+			pension_type VARCHAR(20) NOT NULL DEFAULT 'pension', -- pension, annuity, other
+			birth_date DATE NOT NULL,
+			monthly_benefit DECIMAL(15,2) NOT NULL CHECK (monthly_benefit > 0),
+			start_age DECIMAL(5,2) NOT NULL CHECK (start_age >= 0),
+			end_age DECIMAL(5,2) NOT NULL DEFAULT 90 CHECK (end_age > start_age AND end_age <= 120),
+			cola_rate DECIMAL(6,4) NOT NULL DEFAULT 0, -- annual, as a percentage
+			discount_rate DECIMAL(6,4) NOT NULL DEFAULT 4 CHECK (discount_rate > -100), -- annual, as a percentage
+			include_in_net_worth BOOLEAN NOT NULL DEFAULT false,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		DROP VIEW IF EXISTS pension_values;
+		DROP FUNCTION IF EXISTS pension_present_value(DECIMAL, DATE, DATE, DECIMAL, DECIMAL, DATE);
+
+		CREATE OR REPLACE TRIGGER pensions_delete_tags AFTER DELETE ON pensions
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('pension');
+		CREATE OR REPLACE TRIGGER pensions_delete_ownership AFTER DELETE ON pensions
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('pension');
+
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS pensions_value DECIMAL(15,2);
+	`
+
+	// insurance_policies tracks coverage and premiums. Only the cash value of
+	// whole, universal and variable life policies, less policy loans, counts
+	// toward net worth. A renewal date passed rolls forward by term_months.
+	// insurance_renewal_alerts remembers the renewals already notified.
+	createInsurancePoliciesTable = `
+		CREATE TABLE IF NOT EXISTS insurance_policies (
+			id SERIAL PRIMARY KEY,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name VARCHAR(100) NOT NULL,
+			policy_name VARCHAR(100) NOT NULL,
+			policy_type VARCHAR(20) NOT NULL, -- term_life, whole_life, universal_life, variable_life, umbrella, home, auto, health, disability, long_term_care, other
+			policy_number VARCHAR(50),
+			coverage_amount DECIMAL(15,2) CHECK (coverage_amount >= 0),
+			premium_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (premium_amount >= 0),
+			premium_frequency INTEGER NOT NULL DEFAULT 12 CHECK (premium_frequency IN (1, 2, 4, 12)), -- payments a year
+			cash_value DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (cash_value >= 0),
+			loan_balance DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (loan_balance >= 0),
+			effective_date DATE,
+			renewal_date DATE,
+			term_months INTEGER CHECK (term_months > 0),
+			renewal_notice_days INTEGER NOT NULL DEFAULT 30 CHECK (renewal_notice_days >= 0),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_insurance_policies_renewal ON insurance_policies(renewal_date);
+
+		CREATE TABLE IF NOT EXISTS insurance_renewal_alerts (
+			policy_id INTEGER NOT NULL REFERENCES insurance_policies(id) ON DELETE CASCADE,
+			renewal_date DATE NOT NULL,
+			alerted_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (policy_id, renewal_date)
+		);
+
+		CREATE OR REPLACE TRIGGER insurance_policies_delete_tags AFTER DELETE ON insurance_policies
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('insurance_policy');
+		CREATE OR REPLACE TRIGGER insurance_policies_delete_ownership AFTER DELETE ON insurance_policies
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('insurance_policy');
+
+		ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS insurance_cash_value DECIMAL(15,2);
+	`
+
+	// Who owns each account: self, spouse or a child by name. Accounts owned
+	// by anyone else, such as a child's UTMA or 529, are custodial and can be
+	// left out of net worth.
+	addAccountOwner = `
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS owner VARCHAR(100) NOT NULL DEFAULT 'self';
+		CREATE INDEX IF NOT EXISTS idx_accounts_owner ON accounts(owner);
+	`
+
+	// Constrain account owners to self, spouse or child, a child's account
+	// naming their household member, and make custodial an explicit flag.
+	// Children named in the free-text owner become household members, and
+	// their accounts stay custodial.
+	constrainAccountOwner = `
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS owner_member_id INTEGER REFERENCES household_members(id) ON DELETE SET NULL;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS custodial BOOLEAN NOT NULL DEFAULT false;
+
+		DO $$
+		BEGIN
+		    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_owner_check') THEN
+		        INSERT INTO household_members (name)
+		        SELECT DISTINCT ON (LOWER(TRIM(owner))) TRIM(owner)
+		        FROM accounts
+		        WHERE LOWER(TRIM(owner)) NOT IN ('', 'self', 'spouse')
+		        ON CONFLICT DO NOTHING;
+
+		        UPDATE accounts a
+		        SET owner = 'child', custodial = true,
+		            owner_member_id = (SELECT m.id FROM household_members m WHERE LOWER(m.name) = LOWER(TRIM(a.owner)))
+		        WHERE LOWER(TRIM(a.owner)) NOT IN ('', 'self', 'spouse');
+
+		        UPDATE accounts SET owner = COALESCE(NULLIF(LOWER(TRIM(owner)), ''), 'self') WHERE owner <> 'child';
+
+		        ALTER TABLE accounts ADD CONSTRAINT accounts_owner_check
+		            CHECK (owner IN ('self', 'spouse', 'child') AND (owner = 'child' OR owner_member_id IS NULL));
+		    END IF;
+		END $$;
+	`
+
+	// Widen stock and grant price columns, which topped out below $1M per
+	// share at four decimal places. market_value is generated from
+	// current_price, so it is recreated around the type change.
+	widenPriceColumns = `
+		DO $$
+		BEGIN
+		    IF EXISTS (
+		        SELECT 1 FROM information_schema.columns
+		        WHERE table_name = 'stock_holdings' AND column_name = 'current_price' AND numeric_precision < 18
+		    ) THEN
+		        ALTER TABLE stock_holdings DROP COLUMN market_value;
+		        ALTER TABLE stock_holdings
+		            ALTER COLUMN current_price TYPE NUMERIC(18,6),
+		            ALTER COLUMN cost_basis TYPE NUMERIC(18,6);
+		        ALTER TABLE stock_holdings ADD COLUMN market_value NUMERIC(18,2)
+		            GENERATED ALWAYS AS (shares_owned * COALESCE(current_price, 0)) STORED;
+		        ALTER TABLE stock_prices ALTER COLUMN price TYPE NUMERIC(18,6);
+		        ALTER TABLE equity_grants
+		            ALTER COLUMN strike_price TYPE NUMERIC(18,6),
+		            ALTER COLUMN current_price TYPE NUMERIC(18,6);
+		    END IF;
+		END $$;
+	`
+
+	// Widen the money columns narrower than the rest: monthly amounts and
+	// property tax topped out below $100M, dividends at four decimal places,
+	// and crypto purchase prices at whole cents, which rounded the price of
+	// sub-cent tokens to zero
+	widenMoneyColumns = `
+		DO $$
+		BEGIN
+		    IF EXISTS (
+		        SELECT 1 FROM information_schema.columns
+		        WHERE table_name = 'crypto_holdings' AND column_name = 'purchase_price_usd' AND numeric_scale < 8
+		    ) THEN
+		        ALTER TABLE real_estate_properties
+		            ALTER COLUMN rental_income_monthly TYPE NUMERIC(15,2),
+		            ALTER COLUMN property_tax_annual TYPE NUMERIC(15,2);
+		        ALTER TABLE cash_holdings ALTER COLUMN monthly_contribution TYPE NUMERIC(15,2);
+		        ALTER TABLE contribution_transactions ALTER COLUMN amount TYPE NUMERIC(15,2);
+		        ALTER TABLE stock_holdings ALTER COLUMN estimated_quarterly_dividend TYPE NUMERIC(18,6);
+		        ALTER TABLE crypto_holdings ALTER COLUMN purchase_price_usd TYPE NUMERIC(20,8);
+		    END IF;
+		END $$;
+	`
+
+	// The uploaded file an imported liability statement was read from
+	addLiabilityStatementAttachments = `
+		ALTER TABLE liability_statements ADD COLUMN IF NOT EXISTS attachment_id INTEGER REFERENCES attachments(id) ON DELETE SET NULL;
+	`
+
+	// Widen encrypted columns to hold AES-GCM ciphertext
+	updateEncryptedColumns = `
+		ALTER TABLE cash_holdings ALTER COLUMN account_number_last4 TYPE TEXT;
+		ALTER TABLE crypto_holdings ALTER COLUMN wallet_address TYPE TEXT;
+	`
+
+	// Credit cards and loans owed, counted in total_liabilities. Mortgages
+	// stay with their property, netted into real estate equity.
+	// liability_statements holds the fields read from an uploaded statement
+	// until they are reviewed: applying one copies the fields it found onto
+	// its liability, rejecting one leaves the liability as it was.
+	createLiabilitiesTable = `
+		CREATE TABLE IF NOT EXISTS liabilities (
+			id SERIAL PRIMARY KEY,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name VARCHAR(100) NOT NULL,
+			liability_name VARCHAR(100) NOT NULL,
+			liability_type VARCHAR(20) NOT NULL CHECK (liability_type IN ('credit_card', 'loan')),
+			current_balance DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (current_balance >= 0),
+			apr DECIMAL(7,4) CHECK (apr >= 0), -- annual, as a percentage
+			minimum_payment DECIMAL(15,2) CHECK (minimum_payment >= 0),
+			due_date DATE,
+			statement_date DATE,
+			account_number_last4 VARCHAR(4),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_liabilities_due ON liabilities(due_date);
+
+		CREATE TABLE IF NOT EXISTS liability_statements (
+			id SERIAL PRIMARY KEY,
+			liability_id INTEGER NOT NULL REFERENCES liabilities(id) ON DELETE CASCADE,
+			document_type VARCHAR(30) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'applied', 'rejected')),
+			balance DECIMAL(15,2),
+			apr DECIMAL(7,4),
+			minimum_payment DECIMAL(15,2),
+			due_date DATE,
+			statement_date DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			reviewed_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_liability_statements_liability ON liability_statements(liability_id, created_at DESC);
+
+		CREATE OR REPLACE TRIGGER liabilities_delete_tags AFTER DELETE ON liabilities
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_tags('liability');
+		CREATE OR REPLACE TRIGGER liabilities_delete_ownership AFTER DELETE ON liabilities
+			FOR EACH ROW EXECUTE FUNCTION delete_holding_ownership('liability');
+	`
+
 	createIndices = `
 		CREATE INDEX IF NOT EXISTS idx_accounts_data_source ON accounts(data_source_id);
 		CREATE INDEX IF NOT EXISTS idx_account_balances_account ON account_balances(account_id);
@@ -402,6 +1691,28 @@ const (
 		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_category ON miscellaneous_assets(asset_category_id);
 		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_type ON miscellaneous_assets(asset_type);
 		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_valuation ON miscellaneous_assets(valuation_method);
+
+		-- ETags read the latest change time of each table a response is built
+		-- from (see api/etag.go), which these keep to an index lookup
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_last_updated ON stock_holdings(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_equity_grants_last_updated ON equity_grants(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_cash_holdings_updated_at ON cash_holdings(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_updated_at ON crypto_holdings(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_last_updated ON real_estate_properties(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_last_updated ON miscellaneous_assets(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_private_investments_last_updated ON private_investments(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_bonds_last_updated ON bonds(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_i_bonds_last_updated ON i_bonds(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_i_bond_rates_updated_at ON i_bond_rates(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_pensions_last_updated ON pensions(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_insurance_policies_last_updated ON insurance_policies(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_liabilities_last_updated ON liabilities(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_stock_prices_timestamp ON stock_prices(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_symbol_lookups_looked_up_at ON symbol_lookups(looked_up_at);
+		CREATE INDEX IF NOT EXISTS idx_tags_created_at ON tags(created_at);
+		CREATE INDEX IF NOT EXISTS idx_holding_tags_created_at ON holding_tags(created_at);
+		CREATE INDEX IF NOT EXISTS idx_saved_views_updated_at ON saved_views(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_property_valuations_valued_at ON property_valuations(valued_at);
 	`
 
 	// Seed data for default asset categories
@@ -434,7 +1745,8 @@ const (
 		   {"name": "material", "type": "text", "label": "Material", "required": false},
 		   {"name": "appraised_value", "type": "number", "label": "Appraised Value", "required": false},
 		   {"name": "certificate_number", "type": "text", "label": "Certificate Number", "required": false},
-		   {"name": "appraisal_date", "type": "date", "label": "Appraisal Date", "required": false}
+		   {"name": "appraisal_date", "type": "date", "label": "Appraisal Date", "required": false},
+		   {"name": "ebay_search_query", "type": "text", "label": "eBay Search Query", "required": false}
 		 ]}', 2),
 		
 		('Art & Antiques', 'Paintings, sculptures, and antique items', 'palette', '#EF4444', 
@@ -475,6 +1787,30 @@ const (
 		   {"name": "jurisdiction", "type": "text", "label": "Jurisdiction", "required": false}
 		 ]}', 5),
 		
+		('Precious Metals', 'Gold, silver, platinum and palladium coins, bars and rounds', 'coins', '#EAB308', 
+		 '{"fields": [
+		   {"name": "metal", "type": "select", "label": "Metal", "required": true, "options": [
+		     {"value": "gold", "label": "Gold"},
+		     {"value": "silver", "label": "Silver"},
+		     {"value": "platinum", "label": "Platinum"},
+		     {"value": "palladium", "label": "Palladium"}
+		   ]},
+		   {"name": "quantity", "type": "number", "label": "Weight", "required": true, "validation": {"min": 0}},
+		   {"name": "weight_unit", "type": "select", "label": "Weight Unit", "required": false, "options": [
+		     {"value": "troy_oz", "label": "Troy Ounces"},
+		     {"value": "oz", "label": "Ounces"},
+		     {"value": "g", "label": "Grams"},
+		     {"value": "kg", "label": "Kilograms"}
+		   ]},
+		   {"name": "purity", "type": "number", "label": "Purity (e.g. 0.999)", "required": false, "validation": {"min": 0, "max": 100}},
+		   {"name": "form", "type": "select", "label": "Form", "required": false, "options": [
+		     {"value": "coin", "label": "Coin"},
+		     {"value": "bar", "label": "Bar"},
+		     {"value": "round", "label": "Round"},
+		     {"value": "jewelry", "label": "Jewelry"}
+		   ]}
+		 ]}', 6),
+		
 		('Other', 'Miscellaneous assets that do not fit other categories', 'more-horizontal', '#6B7280', 
 		 '{"fields": [
 		   {"name": "category", "type": "text", "label": "Category", "required": false},
@@ -488,4 +1824,28 @@ const (
 		 ]}', 99)
 		ON CONFLICT (name) DO NOTHING;
 	`
-)
\ No newline at end of file
+
+	// Depreciate vehicles by default once an asset opts into API valuation
+	configureVehicleValuation = `
+		UPDATE asset_categories
+		SET valuation_api_config = '{"provider": "depreciation", "annual_depreciation_percent": 15, "refresh_interval_days": 30}'
+		WHERE name = 'Vehicles' AND valuation_api_config IS NULL;
+	`
+
+	// Value precious metals at spot once an asset opts into API valuation
+	configureMetalValuation = `
+		UPDATE asset_categories
+		SET valuation_api_config = '{"provider": "metal_spot"}'
+		WHERE name = 'Precious Metals' AND valuation_api_config IS NULL;
+	`
+
+	// Start the valuation history of assets that have none at their current value
+	backfillAssetValuationHistory = `
+		INSERT INTO asset_valuation_history (asset_id, value, source, valued_at)
+		SELECT ma.id, ma.current_value,
+		       CASE WHEN ma.valuation_method = 'api' AND ma.api_provider IS NOT NULL THEN ma.api_provider ELSE 'manual' END,
+		       COALESCE(ma.last_updated, ma.created_at, CURRENT_TIMESTAMP)
+		FROM miscellaneous_assets ma
+		WHERE NOT EXISTS (SELECT 1 FROM asset_valuation_history h WHERE h.asset_id = ma.id);
+	`
+)
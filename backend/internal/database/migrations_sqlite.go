@@ -0,0 +1,1277 @@
+package database
+
+import "fmt"
+
+// The SQLite schema, the same tables as migrations.go leaves a PostgreSQL
+// database with. SQLite has no column types to alter, so each table is
+// created in its current shape rather than built up by ALTER statements;
+// changes to a table in migrations.go need a matching change here, which
+// schema_test.go checks, and changes to existing SQLite tables need an
+// entry in sqliteAlterations too.
+//
+// Decimals are stored as REAL: columns declared NUMERIC keep whole values as
+// integers, and SQLite divides integers without a remainder. Arrays are JSON
+// arrays in TEXT columns, and JSON documents are declared JSON so
+// Dialect.RowToJSON nests them.
+const (
+	createSQLiteCoreTables = `
+		CREATE TABLE IF NOT EXISTS credentials (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_type TEXT NOT NULL,
+			credential_type TEXT NOT NULL,
+			name TEXT NOT NULL,
+			encrypted_data TEXT NOT NULL,
+			is_active BOOLEAN DEFAULT true,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used TIMESTAMP,
+			UNIQUE(service_type, is_active)
+		);
+		CREATE INDEX IF NOT EXISTS idx_credentials_service_type ON credentials(service_type);
+		CREATE INDEX IF NOT EXISTS idx_credentials_active ON credentials(is_active);
+
+		CREATE TABLE IF NOT EXISTS data_sources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			type TEXT NOT NULL,
+			status TEXT DEFAULT 'inactive',
+			config_schema JSON,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS accounts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			data_source_id INTEGER REFERENCES data_sources(id),
+			external_account_id TEXT,
+			account_name TEXT NOT NULL,
+			account_type TEXT NOT NULL,
+			institution TEXT,
+			data_source_type TEXT DEFAULT 'api',
+			owner TEXT NOT NULL DEFAULT 'self' CHECK (owner IN ('self', 'spouse', 'child')),
+			owner_member_id INTEGER REFERENCES household_members(id) ON DELETE SET NULL,
+			custodial BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK (owner = 'child' OR owner_member_id IS NULL)
+		);
+		CREATE INDEX IF NOT EXISTS idx_accounts_data_source ON accounts(data_source_id);
+		CREATE INDEX IF NOT EXISTS idx_accounts_owner ON accounts(owner);
+
+		CREATE TABLE IF NOT EXISTS account_balances (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			balance REAL NOT NULL,
+			currency TEXT DEFAULT 'USD',
+			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			data_source TEXT DEFAULT 'api'
+		);
+		CREATE INDEX IF NOT EXISTS idx_account_balances_account ON account_balances(account_id);
+		CREATE INDEX IF NOT EXISTS idx_account_balances_timestamp ON account_balances(timestamp);
+
+		CREATE TABLE IF NOT EXISTS manual_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			entry_type TEXT NOT NULL,
+			data_json JSON NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS manual_entry_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			entry_type TEXT,
+			field_changed TEXT,
+			old_value TEXT,
+			new_value TEXT,
+			updated_by TEXT DEFAULT 'user',
+			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Generated columns round as the NUMERIC columns they replace do
+	createSQLiteHoldingTables = `
+		CREATE TABLE IF NOT EXISTS stock_holdings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			symbol TEXT NOT NULL,
+			company_name TEXT,
+			institution_name TEXT NOT NULL,
+			shares_owned REAL NOT NULL,
+			cost_basis REAL,
+			current_price REAL,
+			market_value REAL GENERATED ALWAYS AS (ROUND(shares_owned * COALESCE(current_price, 0), 2)) STORED,
+			estimated_quarterly_dividend REAL,
+			purchase_date DATE,
+			drip_enabled TEXT DEFAULT 'unknown',
+			last_manual_update TIMESTAMP,
+			is_vested_equity BOOLEAN DEFAULT false,
+			exchange TEXT,
+			security_type TEXT,
+			data_source TEXT DEFAULT 'manual',
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, symbol, institution_name)
+		);
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_symbol ON stock_holdings(symbol);
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_account ON stock_holdings(account_id);
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_institution ON stock_holdings(institution_name);
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_dividend ON stock_holdings(estimated_quarterly_dividend) WHERE estimated_quarterly_dividend IS NOT NULL AND estimated_quarterly_dividend > 0;
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_purchase_date ON stock_holdings(purchase_date) WHERE purchase_date IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_vested ON stock_holdings(is_vested_equity) WHERE is_vested_equity = true;
+
+		CREATE TABLE IF NOT EXISTS stock_prices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			price REAL NOT NULL,
+			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			source TEXT DEFAULT 'api',
+			UNIQUE(symbol, timestamp)
+		);
+		CREATE INDEX IF NOT EXISTS idx_stock_prices_symbol ON stock_prices(symbol);
+
+		CREATE TABLE IF NOT EXISTS equity_grants (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			grant_type TEXT NOT NULL CHECK (grant_type IN ('iso', 'nso', 'rsu', 'espp', 'rsa')),
+			company_symbol TEXT NOT NULL,
+			total_shares REAL NOT NULL,
+			vested_shares REAL DEFAULT 0,
+			unvested_shares REAL GENERATED ALWAYS AS (ROUND(total_shares - COALESCE(vested_shares, 0), 6)) VIRTUAL,
+			strike_price REAL,
+			current_price REAL DEFAULT 0,
+			grant_date DATE NOT NULL,
+			vest_start_date DATE NOT NULL,
+			expiration_date DATE,
+			election_83b_filed_date DATE,
+			amt_basis_per_share REAL,
+			data_source TEXT DEFAULT 'manual',
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, grant_type, company_symbol, grant_date)
+		);
+		CREATE INDEX IF NOT EXISTS idx_equity_grants_account ON equity_grants(account_id);
+		CREATE INDEX IF NOT EXISTS idx_equity_grants_symbol ON equity_grants(company_symbol);
+
+		CREATE TABLE IF NOT EXISTS vesting_schedule (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			grant_id INTEGER REFERENCES equity_grants(id),
+			vest_date DATE NOT NULL,
+			shares_vesting INTEGER NOT NULL,
+			cumulative_vested INTEGER NOT NULL,
+			is_future_vest BOOLEAN DEFAULT TRUE,
+			data_source TEXT DEFAULT 'manual',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_vesting_schedule_grant ON vesting_schedule(grant_id);
+		CREATE INDEX IF NOT EXISTS idx_vesting_schedule_date ON vesting_schedule(vest_date);
+
+		CREATE TABLE IF NOT EXISTS real_estate_properties (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			property_type TEXT NOT NULL,
+			property_name TEXT NOT NULL,
+			purchase_price REAL NOT NULL,
+			current_value REAL NOT NULL,
+			outstanding_mortgage REAL DEFAULT 0,
+			equity REAL GENERATED ALWAYS AS (ROUND(current_value - COALESCE(outstanding_mortgage, 0), 2)) VIRTUAL,
+			purchase_date DATE NOT NULL,
+			property_size_sqft REAL,
+			lot_size_acres REAL,
+			rental_income_monthly REAL,
+			property_tax_annual REAL,
+			notes TEXT,
+			street_address TEXT,
+			city TEXT,
+			state TEXT,
+			zip_code TEXT,
+			latitude REAL,
+			longitude REAL,
+			api_estimated_value REAL,
+			api_estimate_date TIMESTAMP,
+			api_provider TEXT,
+			ownership_percentage REAL NOT NULL DEFAULT 100
+				CHECK (ownership_percentage > 0 AND ownership_percentage <= 100),
+			improvement_value REAL,
+			placed_in_service_date DATE,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, property_name)
+		);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_account ON real_estate_properties(account_id);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_type ON real_estate_properties(property_type);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_location ON real_estate_properties(city, state);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_zip ON real_estate_properties(zip_code);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_coordinates ON real_estate_properties(latitude, longitude);
+
+		CREATE TABLE IF NOT EXISTS cash_holdings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL,
+			account_name TEXT NOT NULL,
+			account_type TEXT NOT NULL,
+			current_balance REAL NOT NULL,
+			interest_rate REAL,
+			monthly_contribution REAL,
+			account_number_last4 TEXT,
+			currency TEXT DEFAULT 'USD',
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, institution_name, account_name)
+		);
+		CREATE INDEX IF NOT EXISTS idx_cash_holdings_account ON cash_holdings(account_id);
+		CREATE INDEX IF NOT EXISTS idx_cash_holdings_type ON cash_holdings(account_type);
+		CREATE INDEX IF NOT EXISTS idx_cash_holdings_institution ON cash_holdings(institution_name);
+
+		CREATE TABLE IF NOT EXISTS asset_categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			icon TEXT,
+			color TEXT,
+			custom_schema JSON,
+			valuation_api_config JSON,
+			is_active BOOLEAN DEFAULT true,
+			sort_order INTEGER DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_asset_categories_active ON asset_categories(is_active);
+		CREATE INDEX IF NOT EXISTS idx_asset_categories_sort ON asset_categories(sort_order);
+
+		CREATE TABLE IF NOT EXISTS miscellaneous_assets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			asset_category_id INTEGER REFERENCES asset_categories(id),
+			asset_name TEXT NOT NULL,
+			asset_type TEXT,
+			current_value REAL NOT NULL,
+			purchase_price REAL,
+			amount_owed REAL DEFAULT 0,
+			purchase_date DATE,
+			description TEXT,
+			custom_fields JSON,
+			valuation_method TEXT DEFAULT 'manual',
+			last_valuation_date TIMESTAMP,
+			api_provider TEXT,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_account ON miscellaneous_assets(account_id);
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_category ON miscellaneous_assets(asset_category_id);
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_type ON miscellaneous_assets(asset_type);
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_valuation ON miscellaneous_assets(valuation_method);
+
+		CREATE TABLE IF NOT EXISTS crypto_holdings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL,
+			crypto_symbol TEXT NOT NULL,
+			balance_tokens REAL NOT NULL,
+			staked_tokens REAL NOT NULL DEFAULT 0,
+			staking_annual_percentage REAL DEFAULT 0,
+			staking_accrued_through DATE,
+			purchase_price_usd REAL,
+			purchase_date DATE,
+			wallet_address TEXT,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, institution_name, crypto_symbol)
+		);
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_account ON crypto_holdings(account_id);
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_symbol ON crypto_holdings(crypto_symbol);
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_institution ON crypto_holdings(institution_name);
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_staking ON crypto_holdings(staking_annual_percentage) WHERE staking_annual_percentage > 0;
+
+		CREATE TABLE IF NOT EXISTS crypto_transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			crypto_holding_id INTEGER NOT NULL REFERENCES crypto_holdings(id) ON DELETE CASCADE,
+			transaction_type TEXT NOT NULL CHECK (transaction_type IN ('buy', 'sell', 'reward')),
+			quantity REAL NOT NULL CHECK (quantity > 0),
+			price_usd REAL NOT NULL CHECK (price_usd >= 0),
+			fee_usd REAL NOT NULL DEFAULT 0 CHECK (fee_usd >= 0),
+			transaction_date DATE NOT NULL,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_crypto_transactions_holding ON crypto_transactions(crypto_holding_id, transaction_date);
+	`
+
+	// The unique index on crypto_prices is on the expression the upsert of
+	// crypto prices names, as ON CONFLICT only finds an index that way
+	createSQLitePriceTables = `
+		CREATE TABLE IF NOT EXISTS crypto_prices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			price_usd REAL NOT NULL,
+			price_btc REAL,
+			market_cap_usd REAL,
+			volume_24h_usd REAL,
+			price_change_24h REAL,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			fetched_at TIMESTAMP,
+			source TEXT DEFAULT 'coingecko'
+		);
+		CREATE INDEX IF NOT EXISTS idx_crypto_prices_symbol ON crypto_prices(symbol);
+		CREATE INDEX IF NOT EXISTS idx_crypto_prices_updated ON crypto_prices(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_crypto_prices_source_fetched ON crypto_prices(source, fetched_at);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_crypto_prices_symbol_minute ON crypto_prices (symbol, date_trunc('minute', last_updated));
+
+		CREATE TABLE IF NOT EXISTS stock_prices_daily (
+			symbol TEXT NOT NULL,
+			price_date DATE NOT NULL,
+			open REAL NOT NULL,
+			high REAL NOT NULL,
+			low REAL NOT NULL,
+			close REAL NOT NULL,
+			opened_at TIMESTAMP NOT NULL,
+			closed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (symbol, price_date)
+		);
+
+		CREATE TABLE IF NOT EXISTS crypto_prices_daily (
+			symbol TEXT NOT NULL,
+			price_date DATE NOT NULL,
+			open REAL NOT NULL,
+			high REAL NOT NULL,
+			low REAL NOT NULL,
+			close REAL NOT NULL,
+			close_btc REAL,
+			opened_at TIMESTAMP NOT NULL,
+			closed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (symbol, price_date)
+		);
+
+		CREATE VIEW IF NOT EXISTS stock_price_history AS
+			SELECT symbol, price, timestamp FROM stock_prices
+			UNION ALL
+			SELECT d.symbol, d.close, d.closed_at FROM stock_prices_daily d
+			WHERE NOT EXISTS (
+				SELECT 1 FROM stock_prices sp WHERE sp.symbol = d.symbol AND sp.timestamp = d.closed_at
+			);
+
+		CREATE VIEW IF NOT EXISTS crypto_price_history AS
+			SELECT symbol, price_usd, price_btc, last_updated FROM crypto_prices
+			UNION ALL
+			SELECT d.symbol, d.close, d.close_btc, d.closed_at FROM crypto_prices_daily d
+			WHERE NOT EXISTS (
+				SELECT 1 FROM crypto_prices cp WHERE cp.symbol = d.symbol AND cp.last_updated = d.closed_at
+			);
+
+		CREATE TABLE IF NOT EXISTS metal_prices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			metal TEXT NOT NULL,
+			price_usd REAL NOT NULL,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			source TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_metal_prices_metal ON metal_prices(metal, last_updated);
+
+		CREATE TABLE IF NOT EXISTS price_symbol_health (
+			asset_type TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			consecutive_failures INTEGER DEFAULT 0,
+			last_error TEXT,
+			last_failure_at TIMESTAMP,
+			last_success_at TIMESTAMP,
+			paused BOOLEAN DEFAULT false,
+			paused_at TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (asset_type, symbol)
+		);
+
+		CREATE TABLE IF NOT EXISTS security_metadata (
+			symbol TEXT PRIMARY KEY,
+			name TEXT,
+			sector TEXT,
+			industry TEXT,
+			country TEXT,
+			asset_type TEXT,
+			source TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS fund_compositions (
+			symbol TEXT PRIMARY KEY,
+			sectors JSON NOT NULL DEFAULT '{}',
+			top_holdings JSON NOT NULL DEFAULT '[]',
+			source TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS symbol_lookups (
+			symbol TEXT PRIMARY KEY,
+			found BOOLEAN NOT NULL,
+			name TEXT,
+			exchange TEXT,
+			security_type TEXT,
+			country TEXT,
+			currency TEXT,
+			source TEXT NOT NULL,
+			looked_up_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS market_holidays (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			holiday_date DATE NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			source TEXT NOT NULL DEFAULT 'manual',
+			removed BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS intraday_prices (
+			symbol TEXT PRIMARY KEY,
+			trading_date DATE NOT NULL,
+			bars JSON NOT NULL,
+			source TEXT NOT NULL,
+			fetched_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS intraday_price_calls (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			source TEXT NOT NULL,
+			called_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_intraday_price_calls_source ON intraday_price_calls(source, called_at);
+		DELETE FROM intraday_price_calls WHERE called_at < date('now', '-7 days');
+	`
+
+	// Daily and weekly net worth rollups: the last snapshot of each period
+	// with its low and high, as the PostgreSQL views in migrations.go
+	createSQLiteNetWorthTables = `
+		CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			total_assets REAL NOT NULL,
+			total_liabilities REAL NOT NULL,
+			net_worth REAL NOT NULL,
+			vested_equity_value REAL,
+			unvested_equity_value REAL,
+			stock_holdings_value REAL,
+			real_estate_equity REAL,
+			cash_holdings_value REAL,
+			crypto_holdings_value REAL,
+			other_assets_value REAL,
+			private_investments_value REAL,
+			fixed_income_value REAL,
+			pensions_value REAL,
+			insurance_cash_value REAL,
+			trigger_type TEXT NOT NULL DEFAULT 'scheduled',
+			trigger_event TEXT,
+			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_net_worth_snapshots_timestamp ON net_worth_snapshots(timestamp);
+
+		CREATE VIEW IF NOT EXISTS net_worth_daily AS
+			SELECT bucket, net_worth, net_worth_low, net_worth_high, total_assets, total_liabilities, snapshots
+			FROM (
+				SELECT date(timestamp) AS bucket, net_worth, total_assets, total_liabilities,
+				       MIN(net_worth) OVER bucket AS net_worth_low,
+				       MAX(net_worth) OVER bucket AS net_worth_high,
+				       COUNT(*) OVER bucket AS snapshots,
+				       ROW_NUMBER() OVER (bucket ORDER BY timestamp DESC) AS position
+				FROM net_worth_snapshots
+				WINDOW bucket AS (PARTITION BY date(timestamp))
+			)
+			WHERE position = 1;
+
+		CREATE VIEW IF NOT EXISTS net_worth_weekly AS
+			SELECT bucket, net_worth, net_worth_low, net_worth_high, total_assets, total_liabilities, snapshots
+			FROM (
+				SELECT date(timestamp, '-6 days', 'weekday 1') AS bucket, net_worth, total_assets, total_liabilities,
+				       MIN(net_worth) OVER bucket AS net_worth_low,
+				       MAX(net_worth) OVER bucket AS net_worth_high,
+				       COUNT(*) OVER bucket AS snapshots,
+				       ROW_NUMBER() OVER (bucket ORDER BY timestamp DESC) AS position
+				FROM net_worth_snapshots
+				WINDOW bucket AS (PARTITION BY date(timestamp, '-6 days', 'weekday 1'))
+			)
+			WHERE position = 1;
+
+		CREATE TABLE IF NOT EXISTS holding_snapshots (
+			snapshot_date DATE NOT NULL,
+			symbol TEXT NOT NULL,
+			shares_owned REAL NOT NULL,
+			cost_basis_total REAL NOT NULL,
+			market_value REAL NOT NULL,
+			recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (snapshot_date, symbol)
+		);
+
+		CREATE TABLE IF NOT EXISTS concentration_alerts (
+			symbol TEXT PRIMARY KEY,
+			percent_of_assets REAL NOT NULL,
+			alerted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	createSQLiteAppTables = `
+		CREATE TABLE IF NOT EXISTS setup_state (
+			step TEXT PRIMARY KEY,
+			completed BOOLEAN DEFAULT false,
+			completed_at TIMESTAMP,
+			data JSON,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS app_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS runtime_settings (
+			name TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			severity TEXT DEFAULT 'info',
+			title TEXT NOT NULL,
+			message TEXT,
+			read BOOLEAN DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(read, created_at);
+
+		CREATE TABLE IF NOT EXISTS notification_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			notification_type TEXT NOT NULL UNIQUE,
+			channels TEXT NOT NULL DEFAULT '[]',
+			min_severity TEXT NOT NULL DEFAULT 'info',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER,
+			actor TEXT NOT NULL,
+			ip_address TEXT,
+			old_values JSON,
+			new_values JSON,
+			changes JSON,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+
+		CREATE TABLE IF NOT EXISTS monthly_report_deliveries (
+			month TEXT PRIMARY KEY,
+			delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS email_queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			recipients TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			text_body TEXT NOT NULL,
+			html_body TEXT,
+			attachments JSON,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			sent_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_email_queue_pending ON email_queue(next_attempt_at) WHERE status = 'pending';
+
+		CREATE TABLE IF NOT EXISTS plugin_configs (
+			plugin_name TEXT PRIMARY KEY,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			settings JSON NOT NULL DEFAULT '{}',
+			schedule TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL UNIQUE,
+			display_name TEXT,
+			role TEXT NOT NULL CHECK (role IN ('admin', 'editor', 'viewer')),
+			password_hash TEXT NOT NULL,
+			last_login_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS user_sessions (
+			token_hash TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);
+
+		CREATE TABLE IF NOT EXISTS household (
+			id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+			name TEXT NOT NULL DEFAULT 'Household',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO household (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+		CREATE TABLE IF NOT EXISTS household_members (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_household_members_name ON household_members (LOWER(name));
+
+		CREATE TABLE IF NOT EXISTS holding_ownership (
+			holding_type TEXT NOT NULL,
+			holding_id INTEGER NOT NULL,
+			member_id INTEGER NOT NULL REFERENCES household_members(id) ON DELETE CASCADE,
+			percentage REAL NOT NULL CHECK (percentage > 0 AND percentage <= 100),
+			PRIMARY KEY (holding_type, holding_id, member_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			color TEXT,
+			description TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (LOWER(name));
+
+		CREATE TABLE IF NOT EXISTS holding_tags (
+			tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			holding_type TEXT NOT NULL,
+			holding_id INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tag_id, holding_type, holding_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_holding_tags_holding ON holding_tags (holding_type, holding_id);
+
+		CREATE TABLE IF NOT EXISTS dashboard_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			config JSON NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_configs_user ON dashboard_configs (COALESCE(user_id, 0));
+
+		CREATE TABLE IF NOT EXISTS saved_views (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			holding_type TEXT NOT NULL,
+			filters JSON NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_views_name ON saved_views (holding_type, LOWER(name));
+
+		CREATE TABLE IF NOT EXISTS attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			kind TEXT NOT NULL CHECK (kind IN ('note', 'file')),
+			note TEXT,
+			file_name TEXT,
+			content_type TEXT,
+			size_bytes INTEGER,
+			storage_key TEXT,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_attachments_entity ON attachments(entity_type, entity_id);
+
+		CREATE TABLE IF NOT EXISTS demo_records (
+			table_name TEXT NOT NULL,
+			record_id INTEGER NOT NULL,
+			PRIMARY KEY (table_name, record_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS share_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token_hash TEXT NOT NULL UNIQUE,
+			label TEXT,
+			value_mode TEXT NOT NULL CHECK (value_mode IN ('masked', 'scaled', 'exact')),
+			include_holdings BOOLEAN NOT NULL DEFAULT false,
+			snapshot JSON NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			revoked_at TIMESTAMP,
+			view_count INTEGER NOT NULL DEFAULT 0,
+			last_viewed_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			key_prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			scope TEXT NOT NULL CHECK (scope IN ('read', 'write')),
+			asset_classes TEXT NOT NULL DEFAULT '[]',
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		);
+	`
+
+	createSQLitePlanningTables = `
+		CREATE TABLE IF NOT EXISTS recurring_contributions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			cash_holding_id INTEGER NOT NULL UNIQUE REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			day_of_month INTEGER NOT NULL DEFAULT 1 CHECK (day_of_month BETWEEN 1 AND 28),
+			requires_confirmation BOOLEAN NOT NULL DEFAULT false,
+			active BOOLEAN NOT NULL DEFAULT true,
+			start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+			last_scheduled_date DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS contribution_transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			recurring_contribution_id INTEGER NOT NULL REFERENCES recurring_contributions(id) ON DELETE CASCADE,
+			cash_holding_id INTEGER NOT NULL REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			scheduled_date DATE NOT NULL,
+			amount REAL NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			balance_before REAL,
+			balance_after REAL,
+			applied_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(cash_holding_id, scheduled_date)
+		);
+		CREATE INDEX IF NOT EXISTS idx_contribution_transactions_status ON contribution_transactions(status, scheduled_date);
+
+		CREATE TABLE IF NOT EXISTS goals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			target_amount REAL NOT NULL CHECK (target_amount > 0),
+			target_date DATE,
+			asset_classes TEXT NOT NULL DEFAULT '[]',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS goal_cash_holdings (
+			goal_id INTEGER NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+			cash_holding_id INTEGER NOT NULL REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			PRIMARY KEY (goal_id, cash_holding_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS cash_flow_categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK (kind IN ('income', 'expense')),
+			color TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(name, kind)
+		);
+
+		CREATE TABLE IF NOT EXISTS cash_flow_transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			category_id INTEGER NOT NULL REFERENCES cash_flow_categories(id) ON DELETE RESTRICT,
+			amount REAL NOT NULL CHECK (amount > 0),
+			transaction_date DATE NOT NULL,
+			description TEXT,
+			cash_holding_id INTEGER REFERENCES cash_holdings(id) ON DELETE SET NULL,
+			source TEXT NOT NULL DEFAULT 'manual',
+			import_key TEXT UNIQUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_cash_flow_transactions_date ON cash_flow_transactions(transaction_date);
+		CREATE INDEX IF NOT EXISTS idx_cash_flow_transactions_category ON cash_flow_transactions(category_id);
+
+		-- Seed default categories only on first run so deleted ones stay deleted
+		INSERT INTO cash_flow_categories (name, kind, color)
+		SELECT column1, column2, column3 FROM (VALUES
+			('Salary', 'income', '#10B981'),
+			('Other Income', 'income', '#34D399'),
+			('Housing', 'expense', '#EF4444'),
+			('Utilities', 'expense', '#F59E0B'),
+			('Groceries', 'expense', '#F97316'),
+			('Transportation', 'expense', '#3B82F6'),
+			('Dining', 'expense', '#EC4899'),
+			('Entertainment', 'expense', '#8B5CF6'),
+			('Healthcare', 'expense', '#14B8A6'),
+			('Other Expenses', 'expense', '#6B7280')
+		)
+		WHERE NOT EXISTS (SELECT 1 FROM cash_flow_categories);
+
+		CREATE TABLE IF NOT EXISTS trading_windows (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			company_symbol TEXT NOT NULL,
+			window_type TEXT NOT NULL CHECK (window_type IN ('open', 'blackout')),
+			start_date DATE NOT NULL,
+			end_date DATE NOT NULL,
+			note TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK (end_date >= start_date)
+		);
+		CREATE INDEX IF NOT EXISTS idx_trading_windows_symbol ON trading_windows(company_symbol, start_date);
+
+		CREATE TABLE IF NOT EXISTS trading_window_alerts (
+			company_symbol TEXT PRIMARY KEY,
+			can_trade BOOLEAN NOT NULL,
+			changed_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS employer_match_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			cash_holding_id INTEGER NOT NULL UNIQUE REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			plan_type TEXT NOT NULL CHECK (plan_type IN ('401k', '403b', '457b', 'hsa')),
+			match_percent REAL NOT NULL CHECK (match_percent > 0),
+			salary_limit_percent REAL CHECK (salary_limit_percent > 0),
+			annual_salary REAL CHECK (annual_salary >= 0),
+			annual_match_cap REAL CHECK (annual_match_cap >= 0),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS employer_match_alerts (
+			cash_holding_id INTEGER PRIMARY KEY REFERENCES cash_holdings(id) ON DELETE CASCADE,
+			missed_annual_match REAL NOT NULL,
+			alerted_at TIMESTAMP NOT NULL
+		);
+	`
+
+	createSQLiteValuationTables = `
+		CREATE TABLE IF NOT EXISTS property_ledger_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			property_id INTEGER NOT NULL REFERENCES real_estate_properties(id) ON DELETE CASCADE,
+			category TEXT NOT NULL,
+			amount REAL NOT NULL CHECK (amount > 0),
+			entry_date DATE NOT NULL,
+			description TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_property_ledger_entries_property ON property_ledger_entries(property_id, entry_date);
+
+		CREATE TABLE IF NOT EXISTS property_valuations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			property_id INTEGER NOT NULL REFERENCES real_estate_properties(id) ON DELETE CASCADE,
+			provider TEXT NOT NULL,
+			estimated_value REAL NOT NULL,
+			confidence_score REAL,
+			valued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_property_valuations_property ON property_valuations(property_id, valued_at);
+
+		CREATE TABLE IF NOT EXISTS asset_valuation_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			asset_id INTEGER NOT NULL REFERENCES miscellaneous_assets(id) ON DELETE CASCADE,
+			value REAL NOT NULL,
+			source TEXT NOT NULL,
+			valued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_asset_valuation_history_asset ON asset_valuation_history(asset_id, valued_at);
+
+		CREATE TABLE IF NOT EXISTS asset_valuation_suggestions (
+			asset_id INTEGER PRIMARY KEY REFERENCES miscellaneous_assets(id) ON DELETE CASCADE,
+			value REAL NOT NULL,
+			provider TEXT NOT NULL,
+			suggested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+
+	// Private investments are valued by triggers, as in migrations.go. SQLite
+	// triggers can't change the row being written, so they update it after.
+	createSQLitePrivateInvestmentTables = `
+		CREATE TABLE IF NOT EXISTS private_investments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			investment_name TEXT NOT NULL,
+			investment_type TEXT NOT NULL DEFAULT 'private_equity',
+			sponsor TEXT NOT NULL,
+			committed_capital REAL NOT NULL DEFAULT 0 CHECK (committed_capital >= 0),
+			current_nav REAL NOT NULL DEFAULT 0 CHECK (current_nav >= 0),
+			nav_date DATE NOT NULL DEFAULT CURRENT_DATE,
+			current_value REAL NOT NULL DEFAULT 0,
+			vintage_year INTEGER,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS private_investment_flows (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			private_investment_id INTEGER NOT NULL REFERENCES private_investments(id) ON DELETE CASCADE,
+			flow_type TEXT NOT NULL CHECK (flow_type IN ('capital_call', 'distribution')),
+			amount REAL NOT NULL CHECK (amount > 0),
+			flow_date DATE NOT NULL,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_private_investment_flows_investment ON private_investment_flows(private_investment_id, flow_date);
+
+		CREATE TABLE IF NOT EXISTS private_investment_navs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			private_investment_id INTEGER NOT NULL REFERENCES private_investments(id) ON DELETE CASCADE,
+			nav REAL NOT NULL,
+			nav_date DATE NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_private_investment_navs_investment ON private_investment_navs(private_investment_id, nav_date);
+
+		CREATE TRIGGER IF NOT EXISTS private_investments_value_insert AFTER INSERT ON private_investments BEGIN
+			UPDATE private_investments SET current_value = MAX(current_nav + COALESCE((
+				SELECT SUM(CASE WHEN f.flow_type = 'capital_call' THEN f.amount ELSE -f.amount END)
+				FROM private_investment_flows f
+				WHERE f.private_investment_id = private_investments.id AND f.flow_date > private_investments.nav_date
+			), 0), 0)
+			WHERE id = NEW.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS private_investments_value_update AFTER UPDATE ON private_investments BEGIN
+			UPDATE private_investments SET current_value = MAX(current_nav + COALESCE((
+				SELECT SUM(CASE WHEN f.flow_type = 'capital_call' THEN f.amount ELSE -f.amount END)
+				FROM private_investment_flows f
+				WHERE f.private_investment_id = private_investments.id AND f.flow_date > private_investments.nav_date
+			), 0), 0)
+			WHERE id = NEW.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS private_investment_flows_revalue_insert AFTER INSERT ON private_investment_flows BEGIN
+			UPDATE private_investments SET current_value = current_value WHERE id = NEW.private_investment_id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS private_investment_flows_revalue_update AFTER UPDATE ON private_investment_flows BEGIN
+			UPDATE private_investments SET current_value = current_value WHERE id IN (OLD.private_investment_id, NEW.private_investment_id);
+		END;
+		CREATE TRIGGER IF NOT EXISTS private_investment_flows_revalue_delete AFTER DELETE ON private_investment_flows BEGIN
+			UPDATE private_investments SET current_value = current_value WHERE id = OLD.private_investment_id;
+		END;
+	`
+
+	// models.Bond.Value values bonds, as on PostgreSQL
+	createSQLiteBondTables = `
+		CREATE TABLE IF NOT EXISTS bonds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL,
+			bond_name TEXT NOT NULL,
+			bond_type TEXT NOT NULL DEFAULT 'treasury',
+			cusip TEXT,
+			face_value REAL NOT NULL CHECK (face_value > 0),
+			coupon_rate REAL NOT NULL DEFAULT 0 CHECK (coupon_rate >= 0),
+			coupon_frequency INTEGER NOT NULL DEFAULT 2 CHECK (coupon_frequency IN (0, 1, 2, 4, 12)),
+			issue_date DATE,
+			maturity_date DATE NOT NULL,
+			purchase_price REAL NOT NULL CHECK (purchase_price > 0),
+			purchase_date DATE,
+			current_price REAL CHECK (current_price > 0),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_bonds_maturity ON bonds(maturity_date);
+
+		DROP VIEW IF EXISTS bond_values;
+	`
+
+	// models.IBond.Value values I bonds, as on PostgreSQL
+	createSQLiteIBondTables = `
+		CREATE TABLE IF NOT EXISTS i_bond_rates (
+			effective_date DATE PRIMARY KEY
+				CHECK (strftime('%d', effective_date) = '01' AND strftime('%m', effective_date) IN ('05', '11')),
+			fixed_rate REAL NOT NULL CHECK (fixed_rate >= 0),
+			inflation_rate REAL NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		INSERT INTO i_bond_rates (effective_date, fixed_rate, inflation_rate) VALUES
+		('2017-05-01', 0.00, 0.98),
+		('2017-11-01', 0.10, 1.24),
+		('2018-05-01', 0.30, 1.11),
+		('2018-11-01', 0.50, 1.16),
+		('2019-05-01', 0.50, 0.70),
+		('2019-11-01', 0.20, 1.01),
+		('2020-05-01', 0.00, 0.53),
+		('2020-11-01', 0.00, 0.84),
+		('2021-05-01', 0.00, 1.77),
+		('2021-11-01', 0.00, 3.56),
+		('2022-05-01', 0.00, 4.81),
+		('2022-11-01', 0.40, 3.24),
+		('2023-05-01', 0.90, 1.69),
+		('2023-11-01', 1.30, 1.97),
+		('2024-05-01', 1.30, 1.48),
+		('2024-11-01', 1.20, 0.95),
+		('2025-05-01', 1.10, 1.43),
+		('2025-11-01', 0.90, 1.56)
+		ON CONFLICT (effective_date) DO NOTHING;
+
+		CREATE TABLE IF NOT EXISTS i_bonds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL DEFAULT 'TreasuryDirect',
+			serial_number TEXT,
+			issue_date DATE NOT NULL CHECK (strftime('%d', issue_date) = '01'),
+			purchase_amount REAL NOT NULL CHECK (purchase_amount >= 25),
+			fixed_rate REAL NOT NULL CHECK (fixed_rate >= 0),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_i_bonds_issue ON i_bonds(issue_date);
+
+		DROP VIEW IF EXISTS i_bond_values;
+
+		CREATE TABLE IF NOT EXISTS i_bond_rate_alerts (
+			effective_date DATE PRIMARY KEY,
+			alerted_at TIMESTAMP NOT NULL
+		);
+	`
+
+	// models.Pension.Value values pensions, as on PostgreSQL
+	createSQLitePensionsTable = `
+		CREATE TABLE IF NOT EXISTS pensions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL,
+			plan_name TEXT NOT NULL,
+			pension_type TEXT NOT NULL DEFAULT 'pension',
+			birth_date DATE NOT NULL,
+			monthly_benefit REAL NOT NULL CHECK (monthly_benefit > 0),
+			start_age REAL NOT NULL CHECK (start_age >= 0),
+			end_age REAL NOT NULL DEFAULT 90 CHECK (end_age > start_age AND end_age <= 120),
+			cola_rate REAL NOT NULL DEFAULT 0,
+			discount_rate REAL NOT NULL DEFAULT 4 CHECK (discount_rate > -100),
+			include_in_net_worth BOOLEAN NOT NULL DEFAULT false,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		DROP VIEW IF EXISTS pension_values;
+	`
+
+	createSQLiteInsurancePoliciesTable = `
+		CREATE TABLE IF NOT EXISTS insurance_policies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL,
+			policy_name TEXT NOT NULL,
+			policy_type TEXT NOT NULL,
+			policy_number TEXT,
+			coverage_amount REAL CHECK (coverage_amount >= 0),
+			premium_amount REAL NOT NULL DEFAULT 0 CHECK (premium_amount >= 0),
+			premium_frequency INTEGER NOT NULL DEFAULT 12 CHECK (premium_frequency IN (1, 2, 4, 12)),
+			cash_value REAL NOT NULL DEFAULT 0 CHECK (cash_value >= 0),
+			loan_balance REAL NOT NULL DEFAULT 0 CHECK (loan_balance >= 0),
+			effective_date DATE,
+			renewal_date DATE,
+			term_months INTEGER CHECK (term_months > 0),
+			renewal_notice_days INTEGER NOT NULL DEFAULT 30 CHECK (renewal_notice_days >= 0),
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_insurance_policies_renewal ON insurance_policies(renewal_date);
+
+		CREATE TABLE IF NOT EXISTS insurance_renewal_alerts (
+			policy_id INTEGER NOT NULL REFERENCES insurance_policies(id) ON DELETE CASCADE,
+			renewal_date DATE NOT NULL,
+			alerted_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (policy_id, renewal_date)
+		);
+	`
+
+	createSQLiteLiabilityTables = `
+		CREATE TABLE IF NOT EXISTS liabilities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER REFERENCES accounts(id),
+			institution_name TEXT NOT NULL,
+			liability_name TEXT NOT NULL,
+			liability_type TEXT NOT NULL CHECK (liability_type IN ('credit_card', 'loan')),
+			current_balance REAL NOT NULL DEFAULT 0 CHECK (current_balance >= 0),
+			apr REAL CHECK (apr >= 0),
+			minimum_payment REAL CHECK (minimum_payment >= 0),
+			due_date DATE,
+			statement_date DATE,
+			account_number_last4 TEXT,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_liabilities_due ON liabilities(due_date);
+
+		CREATE TABLE IF NOT EXISTS liability_statements (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			liability_id INTEGER NOT NULL REFERENCES liabilities(id) ON DELETE CASCADE,
+			attachment_id INTEGER REFERENCES attachments(id) ON DELETE SET NULL,
+			document_type TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'applied', 'rejected')),
+			balance REAL,
+			apr REAL,
+			minimum_payment REAL,
+			due_date DATE,
+			statement_date DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			reviewed_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_liability_statements_liability ON liability_statements(liability_id, created_at DESC);
+	`
+
+	// The change-time indexes of createIndices, which ETags read the latest
+	// change time of each table through
+	createSQLiteChangeIndices = `
+		CREATE INDEX IF NOT EXISTS idx_stock_holdings_last_updated ON stock_holdings(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_equity_grants_last_updated ON equity_grants(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_cash_holdings_updated_at ON cash_holdings(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_crypto_holdings_updated_at ON crypto_holdings(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_real_estate_last_updated ON real_estate_properties(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_miscellaneous_assets_last_updated ON miscellaneous_assets(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_private_investments_last_updated ON private_investments(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_bonds_last_updated ON bonds(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_i_bonds_last_updated ON i_bonds(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_i_bond_rates_updated_at ON i_bond_rates(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_pensions_last_updated ON pensions(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_insurance_policies_last_updated ON insurance_policies(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_liabilities_last_updated ON liabilities(last_updated);
+		CREATE INDEX IF NOT EXISTS idx_stock_prices_timestamp ON stock_prices(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_symbol_lookups_looked_up_at ON symbol_lookups(looked_up_at);
+		CREATE INDEX IF NOT EXISTS idx_tags_created_at ON tags(created_at);
+		CREATE INDEX IF NOT EXISTS idx_holding_tags_created_at ON holding_tags(created_at);
+		CREATE INDEX IF NOT EXISTS idx_saved_views_updated_at ON saved_views(updated_at);
+		CREATE INDEX IF NOT EXISTS idx_property_valuations_valued_at ON property_valuations(valued_at);
+	`
+
+	// Tags and owners of a holding go with it, as the delete_holding_tags
+	// and delete_holding_ownership triggers of migrations.go do
+	createSQLiteHoldingLinkTriggers = `
+		CREATE TRIGGER IF NOT EXISTS stock_holdings_delete_links AFTER DELETE ON stock_holdings BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'stock_holding' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'stock_holding' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS equity_grants_delete_links AFTER DELETE ON equity_grants BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'equity_grant' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'equity_grant' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS real_estate_properties_delete_links AFTER DELETE ON real_estate_properties BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'real_estate' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'real_estate' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS cash_holdings_delete_links AFTER DELETE ON cash_holdings BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'cash_holding' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'cash_holding' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS crypto_holdings_delete_links AFTER DELETE ON crypto_holdings BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'crypto_holding' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'crypto_holding' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS miscellaneous_assets_delete_links AFTER DELETE ON miscellaneous_assets BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'other_asset' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'other_asset' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS private_investments_delete_links AFTER DELETE ON private_investments BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'private_investment' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'private_investment' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS bonds_delete_links AFTER DELETE ON bonds BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'bond' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'bond' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS i_bonds_delete_links AFTER DELETE ON i_bonds BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'i_bond' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'i_bond' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS pensions_delete_links AFTER DELETE ON pensions BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'pension' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'pension' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS insurance_policies_delete_links AFTER DELETE ON insurance_policies BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'insurance_policy' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'insurance_policy' AND holding_id = OLD.id;
+		END;
+		CREATE TRIGGER IF NOT EXISTS liabilities_delete_links AFTER DELETE ON liabilities BEGIN
+			DELETE FROM holding_tags WHERE holding_type = 'liability' AND holding_id = OLD.id;
+			DELETE FROM holding_ownership WHERE holding_type = 'liability' AND holding_id = OLD.id;
+		END;
+	`
+)
+
+// sqliteAlteration brings a table of a SQLite database created by an earlier
+// version to its current shape. SQLite has no IF NOT EXISTS for columns and no
+// DO blocks, so due is a query reporting whether the change is still to make.
+// Alterations run before the migrations, so the indexes the migrations create
+// can be on the columns they add; a table that doesn't exist yet is left for
+// its migration to create.
+type sqliteAlteration struct {
+	due    string
+	change string
+}
+
+// addSQLiteColumn adds column, declared as definition, to a table that lacks it
+func addSQLiteColumn(table, column, definition string) sqliteAlteration {
+	return sqliteAlteration{
+		due: fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM pragma_table_xinfo('%[1]s'))
+			AND NOT EXISTS (SELECT 1 FROM pragma_table_xinfo('%[1]s') WHERE name = '%[2]s')`, table, column),
+		change: fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition),
+	}
+}
+
+// sqliteAlterations are the changes to existing SQLite tables, in order
+var sqliteAlterations = []sqliteAlteration{
+	{
+		// Unvested shares and equity are generated, as generateDerivedColumns
+		// makes them in PostgreSQL
+		due: `SELECT EXISTS (SELECT 1 FROM pragma_table_xinfo('equity_grants') WHERE name = 'unvested_shares' AND hidden = 0)`,
+		change: `
+			ALTER TABLE equity_grants DROP COLUMN unvested_shares;
+			ALTER TABLE equity_grants ADD COLUMN unvested_shares REAL
+				GENERATED ALWAYS AS (ROUND(total_shares - COALESCE(vested_shares, 0), 6)) VIRTUAL;
+		`,
+	},
+	{
+		due: `SELECT EXISTS (SELECT 1 FROM pragma_table_xinfo('real_estate_properties') WHERE name = 'equity' AND hidden = 0)`,
+		change: `
+			ALTER TABLE real_estate_properties DROP COLUMN equity;
+			ALTER TABLE real_estate_properties ADD COLUMN equity REAL
+				GENERATED ALWAYS AS (ROUND(current_value - COALESCE(outstanding_mortgage, 0), 2)) VIRTUAL;
+		`,
+	},
+	addSQLiteColumn("net_worth_snapshots", "fixed_income_value", "REAL"),
+	addSQLiteColumn("net_worth_snapshots", "pensions_value", "REAL"),
+	addSQLiteColumn("net_worth_snapshots", "insurance_cash_value", "REAL"),
+	addSQLiteColumn("accounts", "owner", "TEXT NOT NULL DEFAULT 'self'"),
+	addSQLiteColumn("accounts", "custodial", "BOOLEAN NOT NULL DEFAULT false"),
+	{
+		// Children named in the free-text owner become household members and
+		// their accounts stay custodial, as constrainAccountOwner does. SQLite
+		// can't add the owner check to an existing table, so the API's
+		// validation is what holds upgraded databases to it.
+		due: `SELECT EXISTS (SELECT 1 FROM pragma_table_xinfo('accounts'))
+			AND NOT EXISTS (SELECT 1 FROM pragma_table_xinfo('accounts') WHERE name = 'owner_member_id')`,
+		change: `
+			ALTER TABLE accounts ADD COLUMN owner_member_id INTEGER REFERENCES household_members(id) ON DELETE SET NULL;
+
+			INSERT INTO household_members (name)
+			SELECT MIN(TRIM(owner))
+			FROM accounts
+			WHERE LOWER(TRIM(owner)) NOT IN ('', 'self', 'spouse')
+			GROUP BY LOWER(TRIM(owner))
+			ON CONFLICT DO NOTHING;
+
+			UPDATE accounts
+			SET owner = 'child', custodial = true,
+			    owner_member_id = (SELECT m.id FROM household_members m WHERE LOWER(m.name) = LOWER(TRIM(accounts.owner)))
+			WHERE LOWER(TRIM(owner)) NOT IN ('', 'self', 'spouse');
+
+			UPDATE accounts SET owner = COALESCE(NULLIF(LOWER(TRIM(owner)), ''), 'self') WHERE owner <> 'child';
+		`,
+	},
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// registerLenientText lets numbers, booleans and times be bound to text
+// parameters, as lib/pq allowed by sending every parameter as text. pgx
+// encodes parameters as the type PostgreSQL infers for them, and its text
+// type only takes strings.
+func registerLenientText(_ context.Context, conn *pgx.Conn) error {
+	typeMap := conn.TypeMap()
+	typeMap.RegisterType(&pgtype.Type{Name: "text", OID: pgtype.TextOID, Codec: lenientTextCodec{}})
+	typeMap.RegisterType(&pgtype.Type{Name: "varchar", OID: pgtype.VarcharOID, Codec: lenientTextCodec{}})
+	return nil
+}
+
+// lenientTextCodec is pgx's text codec that also formats basic Go values
+type lenientTextCodec struct {
+	pgtype.TextCodec
+}
+
+func (c lenientTextCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if plan := c.TextCodec.PlanEncode(m, oid, format, value); plan != nil {
+		return plan
+	}
+	if _, ok := formatText(value); ok {
+		return encodePlanFormatText{}
+	}
+	return nil
+}
+
+// encodePlanFormatText encodes a value formatText accepts
+type encodePlanFormatText struct{}
+
+func (encodePlanFormatText) Encode(value any, buf []byte) ([]byte, error) {
+	text, _ := formatText(value)
+	return append(buf, text...), nil
+}
+
+// formatText formats integers, floats, booleans and times the way lib/pq did
+func formatText(value any) (string, bool) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999Z07:00"), true
+	}
+	return "", false
+}
+
+// Array scans a PostgreSQL array column, or the JSON array SQLite stores in
+// its place, into dest, a pointer to a slice
+func Array(dest any) sql.Scanner {
+	return arrayScanner{dest: dest}
+}
+
+type arrayScanner struct {
+	dest any
+}
+
+func (a arrayScanner) Scan(src any) error {
+	// PostgreSQL's text form of an array is {...}
+	if text, ok := src.(string); ok && strings.HasPrefix(text, "[") {
+		return json.Unmarshal([]byte(text), a.dest)
+	}
+	// A Map caches scan plans and is not safe for concurrent use
+	return pgtype.NewMap().SQLScanner(a.dest).Scan(src)
+}
+
+// WithConn runs fn on a pgx connection from db's pool, for batches and COPY,
+// which database/sql has no way to express
+func WithConn(ctx context.Context, db *sql.DB, fn func(*pgx.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		// Unwrap the tracing driver
+		if traced, ok := driverConn.(interface{ Raw() driver.Conn }); ok {
+			driverConn = traced.Raw()
+		}
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("database connection is %T, not pgx", driverConn)
+		}
+		return fn(pgxConn.Conn())
+	})
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// PoolStats is a snapshot of the connection pool. WaitCount and WaitDuration
+// grow whenever a query has to wait for a free connection, so a rising
+// WaitCount means MaxOpenConns is too low for the load.
+type PoolStats struct {
+	MaxOpenConns      int   `json:"max_open_conns"`
+	OpenConns         int   `json:"open_conns"`
+	InUse             int   `json:"in_use"`
+	Idle              int   `json:"idle"`
+	WaitCount         int64 `json:"wait_count"`
+	WaitDurationMs    int64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// NewPoolStats reads the pool's current statistics
+func NewPoolStats(db *sql.DB) PoolStats {
+	stats := db.Stats()
+	return PoolStats{
+		MaxOpenConns:      stats.MaxOpenConnections,
+		OpenConns:         stats.OpenConnections,
+		InUse:             stats.InUse,
+		Idle:              stats.Idle,
+		WaitCount:         stats.WaitCount,
+		WaitDurationMs:    stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     stats.MaxIdleClosed,
+		MaxIdleTimeClosed: stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed: stats.MaxLifetimeClosed,
+	}
+}
+
+// MonitorPool logs a warning every interval in which queries had to wait for
+// a connection, until ctx is cancelled
+func MonitorPool(ctx context.Context, db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := db.Stats()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := db.Stats()
+			if waits := stats.WaitCount - last.WaitCount; waits > 0 {
+				waited := stats.WaitDuration - last.WaitDuration
+				log.Printf("WARNING: %d database queries waited %s in total for a connection in the last %s (%d of %d connections in use); consider raising DB_MAX_OPEN_CONNS",
+					waits, waited.Round(time.Millisecond), interval, stats.InUse, stats.MaxOpenConnections)
+			}
+			last = stats
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// schemaStatement matches the statements of postgresMigrations that shape
+// tables, wherever they appear, including inside DO blocks
+var schemaStatement = regexp.MustCompile(`(?is)\b(?:CREATE\s+TABLE(?:\s+IF\s+NOT\s+EXISTS)?\s+(\w+)\s*\(|ALTER\s+TABLE(?:\s+IF\s+EXISTS)?(?:\s+ONLY)?\s+(\w+)\s+|DROP\s+TABLE(?:\s+IF\s+EXISTS)?\s+(\w+))`)
+
+// The actions of ALTER TABLE that change columns
+var (
+	addColumn    = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+	dropColumn   = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(\w+)`)
+	renameColumn = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?(\w+)\s+TO\s+(\w+)`)
+	renameTable  = regexp.MustCompile(`(?is)^RENAME\s+TO\s+(\w+)`)
+)
+
+// tableConstraints start the elements of a CREATE TABLE that aren't columns
+var tableConstraints = map[string]bool{"constraint": true, "primary": true, "unique": true, "foreign": true, "check": true, "exclude": true}
+
+// postgresSchema replays the table statements of postgresMigrations, returning
+// the columns of each table
+func postgresSchema(t *testing.T) map[string][]string {
+	t.Helper()
+	tables := map[string][]string{}
+	for _, migration := range postgresMigrations {
+		migration = stripSQLComments(migration)
+		for _, match := range schemaStatement.FindAllStringSubmatchIndex(migration, -1) {
+			switch {
+			case match[2] >= 0:
+				table := strings.ToLower(migration[match[2]:match[3]])
+				body, _ := parenthesized(migration[match[1]-1:])
+				if _, exists := tables[table]; exists {
+					// CREATE TABLE IF NOT EXISTS leaves it as it is
+					continue
+				}
+				tables[table] = []string{}
+				for _, element := range splitTopLevel(body) {
+					fields := strings.FieldsFunc(element, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+					if len(fields) == 0 || tableConstraints[strings.ToLower(fields[0])] {
+						continue
+					}
+					tables[table] = append(tables[table], strings.ToLower(strings.Trim(fields[0], `"`)))
+				}
+			case match[4] >= 0:
+				table := strings.ToLower(migration[match[4]:match[5]])
+				rest := migration[match[1]:]
+				if end := strings.IndexAny(rest, ";'"); end >= 0 {
+					rest = rest[:end]
+				}
+				for _, action := range splitTopLevel(rest) {
+					action = strings.TrimSpace(action)
+					switch {
+					case strings.HasPrefix(strings.ToUpper(action), "ADD CONSTRAINT"),
+						strings.HasPrefix(strings.ToUpper(action), "DROP CONSTRAINT"):
+					case addColumn.MatchString(action):
+						column := strings.ToLower(addColumn.FindStringSubmatch(action)[1])
+						if !contains(tables[table], column) {
+							tables[table] = append(tables[table], column)
+						}
+					case dropColumn.MatchString(action):
+						column := strings.ToLower(dropColumn.FindStringSubmatch(action)[1])
+						tables[table] = remove(tables[table], column)
+					case renameTable.MatchString(action):
+						tables[strings.ToLower(renameTable.FindStringSubmatch(action)[1])] = tables[table]
+						delete(tables, table)
+					case renameColumn.MatchString(action):
+						names := renameColumn.FindStringSubmatch(action)
+						for i, column := range tables[table] {
+							if column == strings.ToLower(names[1]) {
+								tables[table][i] = strings.ToLower(names[2])
+							}
+						}
+					}
+				}
+			case match[6] >= 0:
+				delete(tables, strings.ToLower(migration[match[6]:match[7]]))
+			}
+		}
+	}
+	return tables
+}
+
+func stripSQLComments(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, "--"); j >= 0 {
+			lines[i] = line[:j]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parenthesized returns the contents of the parentheses s starts with
+func parenthesized(s string) (string, bool) {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitTopLevel splits s at the commas outside parentheses
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(list []string, s string) []string {
+	kept := list[:0]
+	for _, x := range list {
+		if x != s {
+			kept = append(kept, x)
+		}
+	}
+	return kept
+}
+
+// TestSQLiteSchemaMatchesPostgres checks migrations_sqlite.go creates the
+// tables and columns postgresMigrations leave a PostgreSQL database with
+func TestSQLiteSchemaMatchesPostgres(t *testing.T) {
+	want := postgresSchema(t)
+	db := openSQLite(t, filepath.Join(t.TempDir(), "networth.db"))
+
+	rows, err := db.Query(`
+		SELECT m.name, c.name
+		FROM sqlite_schema m, pragma_table_xinfo(m.name) c
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := map[string][]string{}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			t.Fatal(err)
+		}
+		got[table] = append(got[table], column)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for table, columns := range want {
+		if _, ok := got[table]; !ok {
+			t.Errorf("table %s is missing from the SQLite schema", table)
+			continue
+		}
+		sort.Strings(columns)
+		sort.Strings(got[table])
+		if strings.Join(columns, ",") != strings.Join(got[table], ",") {
+			t.Errorf("columns of %s:\n postgres %v\n   sqlite %v", table, columns, got[table])
+		}
+	}
+	for table := range got {
+		if _, ok := want[table]; !ok {
+			t.Errorf("table %s is only in the SQLite schema", table)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeFormat is how times are stored: the wall clock without a zone,
+// as PostgreSQL's timestamp columns keep them and SQLite's date functions
+// read them. Times at midnight are stored as dates.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999"
+
+// sqliteBaseDriver is the modernc.org/sqlite driver as registered with
+// database/sql, which carries the functions in sqlite_functions.go
+var sqliteBaseDriver = func() driver.Driver {
+	db, err := sql.Open("sqlite", "")
+	if err != nil {
+		panic(fmt.Sprintf("sqlite driver not registered: %v", err))
+	}
+	defer db.Close()
+	return db.Driver()
+}()
+
+// sqliteDSN returns the data source name of the SQLite database at path.
+// Foreign keys are enforced as in PostgreSQL, the write-ahead log lets
+// readers run alongside a writer, and transactions take the write lock up
+// front so two of them can't deadlock upgrading a read lock.
+func sqliteDSN(path string) string {
+	params := url.Values{}
+	for _, pragma := range []string{"foreign_keys(1)", "journal_mode(WAL)", "busy_timeout(10000)", "synchronous(NORMAL)"} {
+		params.Add("_pragma", pragma)
+	}
+	params.Set("_txlock", "immediate")
+	return "file:" + path + "?" + params.Encode()
+}
+
+// sqliteConnector opens connections to a SQLite database that take and
+// return values as the PostgreSQL driver does
+type sqliteConnector struct {
+	dsn string
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := sqliteBaseDriver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{Conn: conn}, nil
+}
+
+func (c sqliteConnector) Driver() driver.Driver {
+	return sqliteDriver{}
+}
+
+type sqliteDriver struct{}
+
+func (sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	return sqliteConnector{dsn: dsn}.Connect(context.Background())
+}
+
+// sqliteConn converts arguments on their way to a SQLite connection, and
+// errors and dates on their way back. Queries are passed through as they are;
+// see Dialect for how they are written for both databases.
+type sqliteConn struct {
+	driver.Conn
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+	return &sqliteStmt{Stmt: stmt}, nil
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	return result, sqliteError(err)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+	return newSQLiteRows(rows), nil
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *sqliteConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *sqliteConn) IsValid() bool {
+	return c.Conn.(driver.Validator).IsValid()
+}
+
+func (c *sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := sqliteValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = value
+	return nil
+}
+
+type sqliteStmt struct {
+	driver.Stmt
+}
+
+func (s *sqliteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	result, err := s.Stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+	return result, sqliteError(err)
+}
+
+func (s *sqliteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, args)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+	return newSQLiteRows(rows), nil
+}
+
+func (s *sqliteStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := sqliteValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = value
+	return nil
+}
+
+// sqliteValue converts an argument to the value SQLite stores for it: times
+// as text, decimals as numbers, JSON documents as text and slices, which
+// PostgreSQL takes as arrays, as JSON arrays
+func sqliteValue(v any) (driver.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return formatSQLiteTime(v), nil
+	case []byte:
+		// Byte slices are JSON documents; SQLite would read a blob as JSONB
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	case decimal.Decimal:
+		return v.InexactFloat64(), nil
+	case decimal.NullDecimal:
+		if !v.Valid {
+			return nil, nil
+		}
+		return v.Decimal.InexactFloat64(), nil
+	case driver.Valuer:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil, nil
+		}
+		value, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+		return sqliteValue(value)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return sqliteValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes()), nil
+		}
+		elements := make([]any, rv.Len())
+		for i := range elements {
+			element, err := sqliteValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		data, err := json.Marshal(elements)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// parseSQLiteTime reads a date or time as formatSQLiteTime writes it, or as
+// SQLite's date functions return it
+func parseSQLiteTime(text string) (time.Time, error) {
+	layout := time.DateOnly
+	if len(text) > len(time.DateOnly) {
+		layout = sqliteTimeFormat[:min(len(text), len(sqliteTimeFormat))]
+		if len(text) > len("2006-01-02 15:04:05") {
+			layout = sqliteTimeFormat
+		}
+	}
+	return time.Parse(layout, text)
+}
+
+func formatSQLiteTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(sqliteTimeFormat)
+}
+
+// sqliteConstraintPattern picks the columns out of SQLite's constraint errors
+var sqliteConstraintPattern = regexp.MustCompile(`constraint failed: (\w+)\.(\w+(?:, \w+\.\w+)*)`)
+
+// sqliteError converts SQLite's constraint errors to the PostgreSQL errors
+// the repositories check for, with the constraint named as PostgreSQL names
+// it by default, as in accounts_name_key. Other errors are left alone.
+func sqliteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	codes := map[int]string{
+		sqlite3.SQLITE_CONSTRAINT_UNIQUE:     "23505",
+		sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY: "23505",
+		sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY: "23503",
+		sqlite3.SQLITE_CONSTRAINT_NOTNULL:    "23502",
+		sqlite3.SQLITE_CONSTRAINT_CHECK:      "23514",
+	}
+	code, ok := codes[sqliteErr.Code()]
+	if !ok {
+		return err
+	}
+	pgErr := &pgconn.PgError{Severity: "ERROR", Code: code, Message: sqliteErr.Error(), Detail: sqliteErr.Error()}
+	if match := sqliteConstraintPattern.FindStringSubmatch(sqliteErr.Error()); match != nil {
+		pgErr.TableName = match[1]
+		columns := strings.Split(match[2], ", ")
+		for i, column := range columns {
+			columns[i] = strings.TrimPrefix(column, match[1]+".")
+		}
+		pgErr.ColumnName = columns[0]
+		pgErr.ConstraintName = match[1] + "_" + strings.Join(columns, "_") + "_key"
+	}
+	return pgErr
+}
+
+// sqliteDatePattern matches the dates and times SQLite's date functions return
+var sqliteDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}( \d{2}:\d{2}(:\d{2}(\.\d+)?)?)?$`)
+
+// sqliteRows reads dates computed by expressions as times. The driver only
+// does so for columns declared as dates, so MAX(price_date) would otherwise
+// come back as text where PostgreSQL returns a date. Dates wanted as text are
+// formatted as something else, such as Dialect.Month's YYYY-MM.
+type sqliteRows struct {
+	driver.Rows
+	dateColumns []bool
+}
+
+func newSQLiteRows(rows driver.Rows) driver.Rows {
+	typed, ok := rows.(driver.RowsColumnTypeDatabaseTypeName)
+	if !ok {
+		return rows
+	}
+	columns := rows.Columns()
+	dateColumns := make([]bool, len(columns))
+	for i := range columns {
+		dateColumns[i] = typed.ColumnTypeDatabaseTypeName(i) == ""
+	}
+	return &sqliteRows{Rows: rows, dateColumns: dateColumns}
+}
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		// INSERT ... RETURNING fails on its first row
+		return sqliteError(err)
+	}
+	for i, value := range dest {
+		text, ok := value.(string)
+		if !ok || !r.dateColumns[i] || !sqliteDatePattern.MatchString(text) {
+			continue
+		}
+		if t, err := parseSQLiteTime(text); err == nil {
+			dest[i] = t
+		}
+	}
+	return nil
+}
+
+func (r *sqliteRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.Rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(index)
+}
+
+// SQLiteBackup writes a copy of the SQLite database at path to dest, which
+// must not exist
+func SQLiteBackup(ctx context.Context, path, dest string) error {
+	conn, err := sqliteBaseDriver.Open(sqliteDSN(path))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.(driver.ExecerContext).ExecContext(ctx, `VACUUM INTO ?`, []driver.NamedValue{{Ordinal: 1, Value: dest}})
+	return err
+}
+
+// SQLiteRestore replaces the contents of the SQLite database at path with
+// the SQLite database at src, such as one SQLiteBackup wrote. The pages are
+// copied in one write transaction, so open connections see the database
+// before or after, and a failed restore leaves it as it was.
+func SQLiteRestore(path, src string) error {
+	conn, err := sqliteBaseDriver.Open(sqliteDSN(path))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	restorer, ok := conn.(interface {
+		NewRestore(srcURI string) (*sqlite.Backup, error)
+	})
+	if !ok {
+		return fmt.Errorf("SQLite connection %T can't restore", conn)
+	}
+	restore, err := restorer.NewRestore("file:" + src + "?mode=ro")
+	if err != nil {
+		return err
+	}
+	if _, err := restore.Step(-1); err != nil {
+		restore.Finish()
+		return err
+	}
+	return restore.Finish()
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// PostgreSQL built-ins that queries and indexes use and SQLite lacks. They
+// are registered with the driver, so every connection has them.
+func init() {
+	functions := []struct {
+		name string
+		args int32
+		fn   func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error)
+	}{
+		{"greatest", -1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return extremum(args, 1), nil
+		}},
+		{"least", -1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return extremum(args, -1), nil
+		}},
+		{"date_trunc", 2, sqliteDateTrunc},
+	}
+	for _, f := range functions {
+		if err := sqlite.RegisterDeterministicScalarFunction(f.name, f.args, f.fn); err != nil {
+			panic(fmt.Sprintf("failed to register SQLite function %s: %v", f.name, err))
+		}
+	}
+}
+
+// extremum returns the largest of values when sign is 1 and the smallest when
+// it is -1, ignoring NULLs as GREATEST and LEAST do in PostgreSQL
+func extremum(values []driver.Value, sign int) driver.Value {
+	var result driver.Value
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if result == nil || compareValues(v, result)*sign > 0 {
+			result = v
+		}
+	}
+	return result
+}
+
+// compareValues orders numbers by value and anything else as text
+func compareValues(a, b driver.Value) int {
+	x, aNumber := number(a)
+	y, bNumber := number(b)
+	if aNumber && bNumber {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	s, t := fmt.Sprint(a), fmt.Sprint(b)
+	switch {
+	case s < t:
+		return -1
+	case s > t:
+		return 1
+	}
+	return 0
+}
+
+func number(v driver.Value) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// sqliteDateTrunc is date_trunc(unit, timestamp) for the units from minute to
+// year, on timestamps stored as sqliteValue stores them. It is deterministic,
+// so unique indexes can be built on it.
+func sqliteDateTrunc(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	unit, _ := args[0].(string)
+	text, ok := args[1].(string)
+	if args[1] == nil {
+		return nil, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("date_trunc: %v is not a timestamp", args[1])
+	}
+	t, err := parseSQLiteTime(text)
+	if err != nil {
+		return nil, fmt.Errorf("date_trunc: %v is not a timestamp", text)
+	}
+	switch unit {
+	case "minute":
+		t = t.Truncate(time.Minute)
+	case "hour":
+		t = t.Truncate(time.Hour)
+	case "day":
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "month":
+		t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		t = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nil, fmt.Errorf("date_trunc: unsupported unit %q", unit)
+	}
+	return t.Format("2006-01-02 15:04:05"), nil
+}
@@ -0,0 +1,361 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// openSQLite initializes a SQLite database in a temporary directory
+func openSQLite(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := Initialize(config.DatabaseConfig{Driver: config.DriverSQLite, Path: path, MaxOpenConns: 4})
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteInitialize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "networth.db")
+	db := openSQLite(t, path)
+	if !IsSQLite(db.DB) || db.Dialect.Name() != "sqlite" {
+		t.Errorf("dialect = %v, want sqlite", DialectOf(db.DB).Name())
+	}
+	if TimescaleEnabled(db.DB) {
+		t.Error("TimescaleEnabled on SQLite")
+	}
+	var categories int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM asset_categories`).Scan(&categories); err != nil {
+		t.Fatalf("asset_categories: %v", err)
+	}
+
+	// Migrations run again on every start and must leave the schema as is
+	db.Close()
+	db = openSQLite(t, path)
+	var again int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM asset_categories`).Scan(&again); err != nil {
+		t.Fatalf("asset_categories after restart: %v", err)
+	}
+	if again != categories {
+		t.Errorf("asset categories = %d after restart, want %d", again, categories)
+	}
+}
+
+func TestSQLiteAlterations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "networth.db")
+	db := openSQLite(t, path)
+
+	// A new database is created in the current shape
+	for i, alteration := range sqliteAlterations {
+		var due bool
+		if err := db.QueryRow(alteration.due).Scan(&due); err != nil {
+			t.Fatalf("alteration %d: %v", i, err)
+		}
+		if due {
+			t.Errorf("alteration %d is due on a new database", i)
+		}
+	}
+
+	// A property table from before equity was generated is altered, keeping
+	// its rows
+	_, err := db.Exec(`
+		ALTER TABLE real_estate_properties DROP COLUMN equity;
+		ALTER TABLE real_estate_properties ADD COLUMN equity REAL NOT NULL DEFAULT 0;
+		INSERT INTO real_estate_properties (property_type, property_name, purchase_price, current_value, outstanding_mortgage, purchase_date)
+		VALUES ('primary_residence', 'Home', 400000, 500000, 200000.5, '2020-01-01')`)
+	if err != nil {
+		t.Fatalf("old shape: %v", err)
+	}
+	if err := db.alterSQLite(sqliteAlterations); err != nil {
+		t.Fatalf("alterSQLite: %v", err)
+	}
+	var equity float64
+	if err := db.QueryRow(`SELECT equity FROM real_estate_properties`).Scan(&equity); err != nil {
+		t.Fatal(err)
+	}
+	if equity != 299999.5 {
+		t.Errorf("equity = %v, want 299999.5", equity)
+	}
+
+	// An accounts table from before owners is altered on start, before the
+	// migrations index the column
+	_, err = db.Exec(`
+		DROP TABLE accounts;
+		CREATE TABLE accounts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			data_source_id INTEGER REFERENCES data_sources(id),
+			external_account_id TEXT,
+			account_name TEXT NOT NULL,
+			account_type TEXT NOT NULL,
+			institution TEXT,
+			data_source_type TEXT DEFAULT 'api',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO accounts (account_name, account_type) VALUES ('Checking', 'checking')`)
+	if err != nil {
+		t.Fatalf("old shape: %v", err)
+	}
+	db.Close()
+	db = openSQLite(t, path)
+	var owner string
+	if err := db.QueryRow(`SELECT owner FROM accounts`).Scan(&owner); err != nil {
+		t.Fatal(err)
+	}
+	if owner != "self" {
+		t.Errorf("owner = %q, want self", owner)
+	}
+
+	// Free-text owners from before the owner check become a household child's
+	// custodial account
+	_, err = db.Exec(`
+		DROP INDEX idx_accounts_owner;
+		DROP TABLE accounts;
+		CREATE TABLE accounts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			data_source_id INTEGER REFERENCES data_sources(id),
+			external_account_id TEXT,
+			account_name TEXT NOT NULL,
+			account_type TEXT NOT NULL,
+			institution TEXT,
+			data_source_type TEXT DEFAULT 'api',
+			owner TEXT NOT NULL DEFAULT 'self',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO accounts (account_name, account_type, owner)
+		VALUES ('Joint', 'checking', 'Spouse'), ('UTMA', 'brokerage', ' Emma'), ('529', 'education', 'emma')`)
+	if err != nil {
+		t.Fatalf("free-text owners: %v", err)
+	}
+	db.Close()
+	db = openSQLite(t, path)
+	rows, err := db.Query(`
+		SELECT a.owner, a.custodial, COALESCE(m.name, '')
+		FROM accounts a LEFT JOIN household_members m ON m.id = a.owner_member_id
+		ORDER BY a.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var member string
+		var custodial bool
+		if err := rows.Scan(&owner, &custodial, &member); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s/%t/%s", owner, custodial, member))
+	}
+	if want := "spouse/false/,child/true/Emma,child/true/Emma"; strings.Join(got, ",") != want {
+		t.Errorf("owners = %v, want %s", got, want)
+	}
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "networth.db"))
+	_, err := db.Exec(`
+		CREATE TABLE quotes (
+			id INTEGER PRIMARY KEY,
+			symbol VARCHAR(20) NOT NULL,
+			price DECIMAL(15,2) NOT NULL,
+			quoted_on DATE NOT NULL,
+			live BOOLEAN NOT NULL DEFAULT TRUE,
+			UNIQUE (symbol, quoted_on)
+		)`)
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for _, q := range []struct {
+		symbol string
+		price  float64
+		day    string
+		live   bool
+	}{
+		{"AAPL", 190, "2026-01-02", true},
+		{"AAPL", 195.5, "2026-01-05", false},
+		{"MSFT", 410, "2026-01-02", false},
+	} {
+		day, _ := time.Parse(time.DateOnly, q.day)
+		if _, err := db.Exec(`INSERT INTO quotes (symbol, price, quoted_on, live) VALUES ($1, $2, $3, $4)`,
+			q.symbol, q.price, day, q.live); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	// RowToJSON reads the columns of tables as they were when the database
+	// was opened
+	dialect, err := newSQLiteDialect(db.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, _ := dialect.TextSearch("symbol || ' Apple-Inc'", "$1")
+
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		want  string
+	}{
+		{"cast", `SELECT CAST(CAST('12.50' AS NUMERIC) + 1 AS TEXT)`, nil, "13.5"},
+		{"month", `SELECT ` + dialect.Month("quoted_on") + ` FROM quotes WHERE id = 2`, nil, "2026-01"},
+		{"greatest ignores nulls", `SELECT CAST(GREATEST(1, NULL, 3) AS TEXT)`, nil, "3"},
+		{"in array", `SELECT CAST(COUNT(*) AS TEXT) FROM quotes WHERE ` + dialect.InArray("symbol", "$1"), []interface{}{[]string{"MSFT", "TSLA"}}, "1"},
+		{"first value", `SELECT ` + dialect.FirstValue("symbol", "price DESC") + ` FROM quotes`, nil, "MSFT"},
+		{"unnest", `SELECT string_agg(s || n, '|') FROM ` + dialect.Unnest("u", ArrayColumn{"$1", "text", "s"}, ArrayColumn{"$2", "int", "n"}),
+			[]interface{}{[]string{"a", "b"}, []int{1, 2}}, "a1|b2"},
+		{"row to json", `SELECT ` + dialect.RowToJSON("quotes", "q") + ` ->> 'live' FROM quotes q WHERE symbol = 'MSFT'`, nil, "0"},
+		{"json object", `SELECT ` + dialect.JSONObject("'symbol'", "symbol", "'n'", dialect.JSON("'[1]'")) + ` FROM quotes WHERE id = 3`, nil, `{"symbol":"MSFT","n":[1]}`},
+		{"text search", `SELECT CAST(COUNT(*) AS TEXT) FROM quotes WHERE ` + match, []interface{}{dialect.TextSearchTerms([]string{"inc", "aap"})}, "2"},
+		{"text search word start", `SELECT CAST(COUNT(*) AS TEXT) FROM quotes WHERE ` + match, []interface{}{dialect.TextSearchTerms([]string{"pple"})}, "0"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := db.QueryRow(tt.query, tt.args...).Scan(&got); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Dates computed by expressions are read as times, as from PostgreSQL
+	times := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"max", `SELECT MAX(quoted_on) FROM quotes WHERE symbol = 'AAPL'`, "2026-01-05 00:00:00"},
+		{"date", `SELECT ` + dialect.Date("'2026-01-31 10:30:00'"), "2026-01-31 00:00:00"},
+		{"add days", `SELECT ` + dialect.AddDays("quoted_on", "30") + ` FROM quotes WHERE id = 1`, "2026-02-01 00:00:00"},
+		{"date_trunc", `SELECT date_trunc('hour', '2026-01-31 10:30:00')`, "2026-01-31 10:00:00"},
+	}
+	for _, tt := range times {
+		var got time.Time
+		if err := db.QueryRow(tt.query).Scan(&got); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.Format(time.DateTime) != tt.want {
+			t.Errorf("%s = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	var ids []int64
+	if err := db.QueryRow(`SELECT ` + dialect.ArrayAgg("id ORDER BY id") + ` FROM quotes WHERE symbol = 'AAPL'`).Scan(Array(&ids)); err != nil {
+		t.Fatalf("scan array: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("ids = %v, want [1 2]", ids)
+	}
+
+	var upserted int
+	err = db.QueryRow(`
+		INSERT INTO quotes (symbol, price, quoted_on) VALUES ('MSFT', 415, '2026-01-02')
+		ON CONFLICT (symbol, quoted_on) DO UPDATE SET price = EXCLUDED.price
+		RETURNING id`).Scan(&upserted)
+	if err != nil || upserted != 3 {
+		t.Errorf("upsert = %d, %v; want 3", upserted, err)
+	}
+}
+
+// TestSQLiteTransactionsLock checks a transaction holds the write lock from
+// its first read, which is what ForUpdate relies on in SQLite
+func TestSQLiteTransactionsLock(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "networth.db"))
+	if _, err := db.Exec(`CREATE TABLE counters (id INTEGER PRIMARY KEY, n INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO counters (id, n) VALUES (1, 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := tx.QueryRow(`SELECT n FROM counters WHERE id = 1` + db.Dialect.ForUpdate()).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second writer waits for the first to commit, then sees its write
+	done := make(chan int)
+	go func() {
+		second, err := db.Begin()
+		if err != nil {
+			t.Error(err)
+			close(done)
+			return
+		}
+		defer second.Rollback()
+		var seen int
+		if err := second.QueryRow(`SELECT n FROM counters WHERE id = 1` + db.Dialect.ForUpdate()).Scan(&seen); err != nil {
+			t.Error(err)
+		}
+		done <- seen
+	}()
+	select {
+	case <-done:
+		t.Fatal("second transaction read while the first held the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := tx.Exec(`UPDATE counters SET n = $1 WHERE id = 1`, n+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if seen := <-done; seen != 1 {
+		t.Errorf("second transaction read %d, want 1", seen)
+	}
+}
+
+func TestSQLiteConstraintErrors(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "networth.db"))
+	if _, err := db.Exec(`CREATE TABLE parents (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE CHECK (name <> ''))`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents(id))`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO parents (name) VALUES ('a')`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		code  string
+	}{
+		{`INSERT INTO parents (name) VALUES ('a')`, "23505"},
+		{`INSERT INTO parents (name) VALUES (NULL)`, "23502"},
+		{`INSERT INTO parents (name) VALUES ('')`, "23514"},
+		{`INSERT INTO children (parent_id) VALUES (42)`, "23503"},
+	}
+	for _, tt := range tests {
+		_, err := db.Exec(tt.query)
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) {
+			t.Errorf("%s: error %v is not a PgError", tt.query, err)
+			continue
+		}
+		if pgErr.Code != tt.code {
+			t.Errorf("%s: code = %s, want %s", tt.query, pgErr.Code, tt.code)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO parents (name) VALUES ('a')`)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.TableName != "parents" || pgErr.ColumnName != "name") {
+		t.Errorf("unique violation on %s.%s, want parents.name", pgErr.TableName, pgErr.ColumnName)
+	}
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// hypertables are the time series tables converted to TimescaleDB
+// hypertables, with their time column, the primary key that must include it
+// and how much time each chunk holds. crypto_prices is left out: its one row
+// per symbol and minute is a unique index on an expression, which a
+// hypertable cannot enforce.
+var hypertables = []struct {
+	table, column, primaryKey, chunkInterval string
+}{
+	{"stock_prices", "timestamp", "id, timestamp", "30 days"},
+	{"net_worth_snapshots", "timestamp", "id, timestamp", "1 year"},
+	{"holding_snapshots", "snapshot_date", "", "1 year"},
+}
+
+// netWorthRollups are continuous aggregates replacing the plain net worth
+// rollup views, with their bucket width
+var netWorthRollups = []struct {
+	view, bucket string
+}{
+	{"net_worth_daily", "1 day"},
+	{"net_worth_weekly", "1 week"},
+}
+
+// setupTimescale stores the time series tables as TimescaleDB hypertables and
+// the net worth rollups as continuous aggregates when mode allows and the
+// extension is available. With mode "on" a missing extension is an error;
+// with "auto" the database is left as plain PostgreSQL. It reports whether
+// TimescaleDB is in use.
+func (db *DB) setupTimescale(mode string) (bool, error) {
+	if mode == "off" {
+		return false, nil
+	}
+
+	var available bool
+	if err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')
+	`).Scan(&available); err != nil {
+		return false, fmt.Errorf("failed to check for TimescaleDB: %w", err)
+	}
+	if !available {
+		if mode == "on" {
+			return false, fmt.Errorf("TimescaleDB is not installed on the database server")
+		}
+		return false, nil
+	}
+	// Creating the extension fails unless the server preloads timescaledb
+	if _, err := db.Exec(`CREATE EXTENSION IF NOT EXISTS timescaledb`); err != nil {
+		if mode == "on" {
+			return false, fmt.Errorf("failed to enable TimescaleDB: %w", err)
+		}
+		log.Printf("WARNING: TimescaleDB is installed but could not be enabled, using plain tables: %v", err)
+		return false, nil
+	}
+
+	for _, hypertable := range hypertables {
+		if err := db.createHypertable(hypertable.table, hypertable.column, hypertable.primaryKey, hypertable.chunkInterval); err != nil {
+			return false, err
+		}
+	}
+	for _, rollup := range netWorthRollups {
+		if err := db.createNetWorthAggregate(rollup.view, rollup.bucket); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// createHypertable converts table to a hypertable partitioned on column,
+// moving its rows into chunks. Every unique index of a hypertable must
+// include the time column, so a primary key on id alone is widened to
+// primaryKey first.
+func (db *DB) createHypertable(table, column, primaryKey, chunkInterval string) error {
+	var converted bool
+	if err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM timescaledb_information.hypertables WHERE hypertable_name = $1)
+	`, table).Scan(&converted); err != nil {
+		return fmt.Errorf("failed to check hypertable %s: %w", table, err)
+	}
+	if converted {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if primaryKey != "" {
+		// A row without a time has no chunk to go to
+		_, err := tx.Exec(fmt.Sprintf(`
+			DELETE FROM %[1]s WHERE %[2]s IS NULL;
+			ALTER TABLE %[1]s DROP CONSTRAINT IF EXISTS %[1]s_pkey;
+			ALTER TABLE %[1]s ADD PRIMARY KEY (%[3]s);
+		`, table, column, primaryKey))
+		if err != nil {
+			return fmt.Errorf("failed to widen the primary key of %s: %w", table, err)
+		}
+	}
+	_, err = tx.Exec(fmt.Sprintf(`
+		SELECT create_hypertable('%s', '%s', chunk_time_interval => INTERVAL '%s', migrate_data => true)
+	`, table, column, chunkInterval))
+	if err != nil {
+		return fmt.Errorf("failed to create hypertable %s: %w", table, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit hypertable %s: %w", table, err)
+	}
+	log.Printf("INFO: Converted %s to a TimescaleDB hypertable", table)
+	return nil
+}
+
+// createNetWorthAggregate replaces the plain rollup view with a continuous
+// aggregate of the same columns. It is materialized hourly up to the current
+// bucket; the newest snapshots are read from net_worth_snapshots at query
+// time, so the rollups are never behind.
+func (db *DB) createNetWorthAggregate(view, bucket string) error {
+	var continuous bool
+	if err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM timescaledb_information.continuous_aggregates WHERE view_name = $1)
+	`, view).Scan(&continuous); err != nil {
+		return fmt.Errorf("failed to check continuous aggregate %s: %w", view, err)
+	}
+	if continuous {
+		return nil
+	}
+
+	// Continuous aggregates cannot be created inside a transaction, so the
+	// plain view is briefly missing. Existing snapshots are materialized as
+	// the aggregate is created.
+	statements := []string{
+		fmt.Sprintf(`DROP VIEW IF EXISTS %s`, view),
+		fmt.Sprintf(`
+			CREATE MATERIALIZED VIEW %s
+			WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+			SELECT time_bucket(INTERVAL '%s', timestamp) AS bucket,
+			       last(net_worth, timestamp) AS net_worth,
+			       MIN(net_worth) AS net_worth_low,
+			       MAX(net_worth) AS net_worth_high,
+			       last(total_assets, timestamp) AS total_assets,
+			       last(total_liabilities, timestamp) AS total_liabilities,
+			       COUNT(*) AS snapshots
+			FROM net_worth_snapshots
+			GROUP BY 1
+		`, view, bucket),
+		fmt.Sprintf(`
+			SELECT add_continuous_aggregate_policy('%s',
+				start_offset => NULL, end_offset => INTERVAL '%s', schedule_interval => INTERVAL '1 hour')
+		`, view, bucket),
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create continuous aggregate %s: %w", view, err)
+		}
+	}
+	log.Printf("INFO: Created TimescaleDB continuous aggregate %s", view)
+	return nil
+}
+
+// TimescaleEnabled reports whether the database stores its time series in
+// TimescaleDB
+func TimescaleEnabled(db *sql.DB) bool {
+	if IsSQLite(db) {
+		return false
+	}
+	var enabled bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb')`).Scan(&enabled); err != nil {
+		return false
+	}
+	return enabled
+}
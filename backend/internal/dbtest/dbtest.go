@@ -0,0 +1,156 @@
+// Package dbtest provides a database/sql driver for tests and benchmarks. It
+// answers every query from canned results after a fixed round trip, so code
+// can be timed by how many trips to the database it makes without a server.
+// Server does the same over the PostgreSQL wire protocol, for timing what the
+// pgx driver itself sends.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"runtime"
+	"time"
+)
+
+// Result is the canned answer to a query
+type Result struct {
+	Columns []string
+	Rows    [][]driver.Value
+	// ParamOIDs are the types Server describes the query's parameters as;
+	// parameters past them are text
+	ParamOIDs []uint32
+}
+
+// Open returns a database whose queries are answered by answer and whose
+// statements affect no rows, each after waiting roundTrip. answer also sees
+// every statement, so tests can check the SQL that was run.
+func Open(roundTrip time.Duration, answer func(query string) Result) *sql.DB {
+	return sql.OpenDB(connector{roundTrip: roundTrip, answer: answer})
+}
+
+type connector struct {
+	roundTrip time.Duration
+	answer    func(query string) Result
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return conn(c), nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return dbDriver{}
+}
+
+type dbDriver struct{}
+
+func (dbDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("dbtest: open databases with dbtest.Open")
+}
+
+type conn connector
+
+func (c conn) QueryContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &rows{result: c.answer(query)}, nil
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	c.answer(query)
+	return driver.RowsAffected(0), nil
+}
+
+// CheckNamedValue accepts any argument, as the PostgreSQL driver does for arrays
+func (conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	return stmt{conn: c, query: query}, nil
+}
+
+func (conn) Close() error {
+	return nil
+}
+
+func (conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+// wait spends one round trip
+func (c conn) wait(ctx context.Context) error {
+	return spin(ctx, c.roundTrip)
+}
+
+// spin waits for d. It spins rather than sleeps, since timers can overshoot
+// a round trip of a few hundred microseconds several times over.
+func spin(ctx context.Context, d time.Duration) error {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		runtime.Gosched()
+	}
+	return ctx.Err()
+}
+
+type stmt struct {
+	conn  conn
+	query string
+}
+
+func (s stmt) Close() error {
+	return nil
+}
+
+func (stmt) NumInput() int {
+	return -1
+}
+
+func (s stmt) Exec([]driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, nil)
+}
+
+func (s stmt) Query([]driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, nil)
+}
+
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+type rows struct {
+	result Result
+	next   int
+}
+
+func (r *rows) Columns() []string {
+	return r.result.Columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.Rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,276 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Server speaks enough of the PostgreSQL wire protocol for the pgx driver to
+// run queries against it, so benchmarks can time what the driver sends as
+// well as how often. Every Sync and simple query costs one round trip, and
+// every statement parsed costs parseCost on top, standing in for the time
+// PostgreSQL spends parsing and planning it.
+type Server struct {
+	listener  net.Listener
+	roundTrip time.Duration
+	parseCost time.Duration
+	answer    func(query string) Result
+
+	mu     sync.Mutex
+	parses int
+	wg     sync.WaitGroup
+}
+
+// NewServer starts a server on a local port whose queries are answered by answer
+func NewServer(roundTrip, parseCost time.Duration, answer func(query string) Result) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("dbtest: failed to listen: %w", err)
+	}
+	s := &Server{listener: listener, roundTrip: roundTrip, parseCost: parseCost, answer: answer}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// URL is the connection string of the server
+func (s *Server) URL() string {
+	return fmt.Sprintf("postgres://bench@%s/bench?sslmode=disable", s.listener.Addr())
+}
+
+// Parses returns how many statements the server has parsed
+func (s *Server) Parses() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.parses
+}
+
+// Close stops the server once its connections are closed
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			_ = s.handle(conn)
+		}()
+	}
+}
+
+// parameterPattern finds the $n parameters of a query
+var parameterPattern = regexp.MustCompile(`\$(\d+)`)
+
+// statement is a parsed query with the types of its parameters
+type statement struct {
+	query  string
+	params []uint32
+	result Result
+}
+
+// portal is a statement bound to arguments, with the formats to send its columns in
+type portal struct {
+	statement *statement
+	formats   []int16
+}
+
+func (s *Server) handle(conn net.Conn) error {
+	backend := pgproto3.NewBackend(conn, conn)
+	if _, err := backend.ReceiveStartupMessage(); err != nil {
+		return err
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	for name, value := range map[string]string{
+		"server_version":              "16.0",
+		"client_encoding":             "UTF8",
+		"standard_conforming_strings": "on",
+		"DateStyle":                   "ISO, MDY",
+		"TimeZone":                    "UTC",
+	} {
+		backend.Send(&pgproto3.ParameterStatus{Name: name, Value: value})
+	}
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if err := backend.Flush(); err != nil {
+		return err
+	}
+
+	typeMap := pgtype.NewMap()
+	statements := map[string]*statement{}
+	portals := map[string]*portal{}
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return err
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.Parse:
+			statements[msg.Name] = s.parse(msg.Query, msg.ParameterOIDs)
+			backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			if msg.ObjectType == 'S' {
+				st := statements[msg.Name]
+				backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: st.params})
+				backend.Send(rowDescription(st.result, nil))
+			} else {
+				p := portals[msg.Name]
+				backend.Send(rowDescription(p.statement.result, p.formats))
+			}
+		case *pgproto3.Bind:
+			portals[msg.DestinationPortal] = &portal{
+				statement: statements[msg.PreparedStatement],
+				formats:   append([]int16(nil), msg.ResultFormatCodes...),
+			}
+			backend.Send(&pgproto3.BindComplete{})
+		case *pgproto3.Execute:
+			p := portals[msg.Portal]
+			if err := sendRows(backend, typeMap, p.statement.result, p.formats); err != nil {
+				return err
+			}
+		case *pgproto3.Close:
+			if msg.ObjectType == 'S' {
+				delete(statements, msg.Name)
+			} else {
+				delete(portals, msg.Name)
+			}
+			backend.Send(&pgproto3.CloseComplete{})
+		case *pgproto3.Sync:
+			s.wait()
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			if err := backend.Flush(); err != nil {
+				return err
+			}
+		case *pgproto3.Query:
+			result := s.parse(msg.String, nil).result
+			backend.Send(rowDescription(result, nil))
+			if err := sendRows(backend, typeMap, result, nil); err != nil {
+				return err
+			}
+			s.wait()
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			if err := backend.Flush(); err != nil {
+				return err
+			}
+		case *pgproto3.Terminate:
+			return nil
+		case *pgproto3.Flush:
+			if err := backend.Flush(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("dbtest: unexpected message %T", msg)
+		}
+	}
+}
+
+// parse spends parseCost on query and returns it with its answer. Parameters
+// without a type from the client take the result's, or text.
+func (s *Server) parse(query string, oids []uint32) *statement {
+	s.mu.Lock()
+	s.parses++
+	s.mu.Unlock()
+	_ = spin(context.Background(), s.parseCost)
+
+	result := s.answer(query)
+	count := 0
+	for _, m := range parameterPattern.FindAllStringSubmatch(query, -1) {
+		n, _ := strconv.Atoi(m[1])
+		count = max(count, n)
+	}
+	params := make([]uint32, count)
+	for i := range params {
+		switch {
+		case i < len(oids) && oids[i] != 0:
+			params[i] = oids[i]
+		case i < len(result.ParamOIDs):
+			params[i] = result.ParamOIDs[i]
+		default:
+			params[i] = pgtype.TextOID
+		}
+	}
+	return &statement{query: query, params: params, result: result}
+}
+
+func (s *Server) wait() {
+	_ = spin(context.Background(), s.roundTrip)
+}
+
+// rowDescription describes the columns of result, typed by its first row and
+// sent in formats
+func rowDescription(result Result, formats []int16) *pgproto3.RowDescription {
+	fields := make([]pgproto3.FieldDescription, len(result.Columns))
+	for i, column := range result.Columns {
+		oid := uint32(pgtype.TextOID)
+		if len(result.Rows) > 0 {
+			oid = valueOID(result.Rows[0][i])
+		}
+		fields[i] = pgproto3.FieldDescription{Name: []byte(column), DataTypeOID: oid, DataTypeSize: -1, TypeModifier: -1,
+			Format: formatOf(formats, i)}
+	}
+	return &pgproto3.RowDescription{Fields: fields}
+}
+
+// sendRows sends the rows of result in the formats the client asked for
+func sendRows(backend *pgproto3.Backend, typeMap *pgtype.Map, result Result, formats []int16) error {
+	for _, row := range result.Rows {
+		values := make([][]byte, len(row))
+		for i, value := range row {
+			encoded, err := typeMap.Encode(valueOID(value), formatOf(formats, i), value, nil)
+			if err != nil {
+				return fmt.Errorf("dbtest: failed to encode %v: %w", value, err)
+			}
+			values[i] = encoded
+		}
+		backend.Send(&pgproto3.DataRow{Values: values})
+	}
+	backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(fmt.Sprintf("SELECT %d", len(result.Rows)))})
+	return nil
+}
+
+// formatOf is the format of column i given the result formats of a Bind:
+// none for all text, one for all columns, or one per column
+func formatOf(formats []int16, i int) int16 {
+	switch {
+	case len(formats) == 1:
+		return formats[0]
+	case i < len(formats):
+		return formats[i]
+	}
+	return pgtype.TextFormatCode
+}
+
+// valueOID is the type a canned value is sent as
+func valueOID(value driver.Value) uint32 {
+	switch value.(type) {
+	case int64:
+		return pgtype.Int8OID
+	case float64:
+		return pgtype.Float8OID
+	case bool:
+		return pgtype.BoolOID
+	case time.Time:
+		return pgtype.TimestamptzOID
+	case []byte:
+		return pgtype.ByteaOID
+	}
+	return pgtype.TextOID
+}
@@ -0,0 +1,153 @@
+package encryption
+
+import (
+	"database/sql"
+	"fmt"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+// EncryptExistingData encrypts plaintext values left in the sensitive columns,
+// returning the number of values encrypted per "table.column". Already encrypted
+// values are skipped, so it is safe to run repeatedly.
+func EncryptExistingData(db *sql.DB, e *FieldEncryptor) (map[string]int, error) {
+	if e == nil {
+		return nil, fmt.Errorf("field encryption is not configured")
+	}
+
+	counts := make(map[string]int)
+	for _, col := range SensitiveColumns {
+		count, err := encryptColumn(db, e, col)
+		if err != nil {
+			return counts, err
+		}
+		counts[col.Table+"."+col.Column] = count
+	}
+
+	return counts, nil
+}
+
+func encryptColumn(db *sql.DB, e *FieldEncryptor, col SensitiveColumn) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Table and column names come from SensitiveColumns, never from input
+	selectQuery := fmt.Sprintf(`
+		SELECT id, %[2]s FROM %[1]s
+		WHERE %[2]s IS NOT NULL AND %[2]s <> '' AND %[2]s NOT LIKE '%[3]s%%'%[4]s
+	`, sqlbuilder.Ident(col.Table), sqlbuilder.Ident(col.Column), ciphertextPrefix, database.DialectOf(db).ForUpdate())
+
+	rows, err := tx.Query(selectQuery)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s.%s: %w", col.Table, col.Column, err)
+	}
+
+	plaintexts := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s.%s: %w", col.Table, col.Column, err)
+		}
+		plaintexts[id] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updateQuery := fmt.Sprintf("UPDATE %s SET %s = $1 WHERE id = $2", sqlbuilder.Ident(col.Table), sqlbuilder.Ident(col.Column))
+	for id, value := range plaintexts {
+		encrypted, err := e.Encrypt(value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt %s.%s for id %d: %w", col.Table, col.Column, id, err)
+		}
+		if _, err := tx.Exec(updateQuery, encrypted, id); err != nil {
+			return 0, fmt.Errorf("failed to update %s.%s for id %d: %w", col.Table, col.Column, id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit %s.%s: %w", col.Table, col.Column, err)
+	}
+
+	return len(plaintexts), nil
+}
+
+// RotateKey re-encrypts every sensitive value encrypted with from under to,
+// encrypting plaintext left in the columns as well, and returns the number of
+// values rewritten per "table.column". Each column is rewritten in one
+// transaction; a value that from cannot decrypt aborts its column, so a wrong
+// old key changes nothing.
+func RotateKey(db *sql.DB, from, to *FieldEncryptor) (map[string]int, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("both the current and the new field encryption key are required")
+	}
+
+	counts := make(map[string]int)
+	for _, col := range SensitiveColumns {
+		count, err := rotateColumn(db, from, to, col)
+		if err != nil {
+			return counts, err
+		}
+		counts[col.Table+"."+col.Column] = count
+	}
+	return counts, nil
+}
+
+func rotateColumn(db *sql.DB, from, to *FieldEncryptor, col SensitiveColumn) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT id, %[2]s FROM %[1]s
+		WHERE %[2]s IS NOT NULL AND %[2]s <> ''%[3]s
+	`, sqlbuilder.Ident(col.Table), sqlbuilder.Ident(col.Column), database.DialectOf(db).ForUpdate()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s.%s: %w", col.Table, col.Column, err)
+	}
+
+	plaintexts := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s.%s: %w", col.Table, col.Column, err)
+		}
+		plaintext, err := from.Decrypt(value)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to decrypt %s.%s for id %d with the current key: %w", col.Table, col.Column, id, err)
+		}
+		plaintexts[id] = plaintext
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updateQuery := fmt.Sprintf("UPDATE %s SET %s = $1 WHERE id = $2", sqlbuilder.Ident(col.Table), sqlbuilder.Ident(col.Column))
+	for id, value := range plaintexts {
+		encrypted, err := to.Encrypt(value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt %s.%s for id %d: %w", col.Table, col.Column, id, err)
+		}
+		if _, err := tx.Exec(updateQuery, encrypted, id); err != nil {
+			return 0, fmt.Errorf("failed to update %s.%s for id %d: %w", col.Table, col.Column, id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit %s.%s: %w", col.Table, col.Column, err)
+	}
+	return len(plaintexts), nil
+}
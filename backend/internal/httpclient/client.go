@@ -0,0 +1,225 @@
+// Package httpclient provides the HTTP client used for price and valuation
+// provider calls: traced, retried with jittered exponential backoff, and
+// guarded by a circuit breaker per provider host.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/telemetry"
+)
+
+// ErrCircuitOpen is returned without making a request while a host's circuit is open
+var ErrCircuitOpen = errors.New("circuit open")
+
+// New returns a traced HTTP client for a provider that retries transient
+// failures and stops calling a host that keeps failing
+func New(provider string, timeout time.Duration, cfg config.HTTPRetryConfig) *http.Client {
+	client := telemetry.HTTPClient(provider, timeout)
+	client.Transport = &retryTransport{
+		next:     client.Transport,
+		provider: provider,
+		config:   cfg,
+	}
+	return client
+}
+
+// retryTransport retries requests and records their outcome with the host's breaker
+type retryTransport struct {
+	next     http.RoundTripper
+	provider string
+	config   config.HTTPRetryConfig
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cb := breakerFor(req.URL.Host)
+	// A body that cannot be replayed is sent once
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if !cb.allow(time.Now(), t.config) {
+			return nil, fmt.Errorf("%s: %w for %s", t.provider, ErrCircuitOpen, req.URL.Host)
+		}
+
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		retryable := isRetryable(resp, err)
+		cb.record(time.Now(), !retryable, t.config)
+
+		if !retryable || !replayable || attempt >= t.config.MaxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay := backoff(attempt, t.config)
+		if resp != nil {
+			if after := retryAfter(resp); after > delay && after <= t.config.MaxDelay {
+				delay = after
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		fmt.Printf("WARNING: %s request failed (%s), retrying in %s (attempt %d of %d)\n",
+			t.provider, describeFailure(resp, err), delay.Round(time.Millisecond), attempt+2, t.config.MaxRetries+1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether a failure is likely transient: a network error,
+// a rate limit or a server error
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the delay before retry number attempt+1: the base delay
+// doubled per attempt, capped at the max delay, with "equal jitter" so
+// concurrent clients spread out
+func backoff(attempt int, cfg config.HTTPRetryConfig) time.Duration {
+	delay := cfg.BaseDelay << uint(attempt)
+	if delay > cfg.MaxDelay || delay <= 0 {
+		delay = cfg.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func describeFailure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
+
+// breaker is a circuit breaker for one host. It opens after a run of
+// consecutive failures, then after the cooldown lets a single trial request
+// through: success closes it, failure opens it again.
+type breaker struct {
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+var (
+	breakersMutex sync.Mutex
+	breakers      = make(map[string]*breaker)
+)
+
+// breakerFor returns the shared breaker of a host, so every client calling a
+// provider sees its state
+func breakerFor(host string) *breaker {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+
+	cb, ok := breakers[host]
+	if !ok {
+		cb = &breaker{}
+		breakers[host] = cb
+	}
+	return cb
+}
+
+func (cb *breaker) allow(now time.Time, cfg config.HTTPRetryConfig) bool {
+	if cfg.BreakerThreshold <= 0 {
+		return true
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.failures < cfg.BreakerThreshold {
+		return true
+	}
+	if now.Before(cb.openUntil) || cb.trial {
+		return false
+	}
+	cb.trial = true
+	return true
+}
+
+func (cb *breaker) record(now time.Time, success bool, cfg config.HTTPRetryConfig) {
+	if cfg.BreakerThreshold <= 0 {
+		return
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.trial = false
+	if success {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cfg.BreakerThreshold {
+		cb.openUntil = now.Add(cfg.BreakerCooldown)
+	}
+}
+
+// BreakerState reports a host's circuit
+type BreakerState struct {
+	Host      string     `json:"host"`
+	Open      bool       `json:"open"`
+	Failures  int        `json:"consecutive_failures"`
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+}
+
+// Breakers returns the circuit state of every host called so far
+func Breakers() []BreakerState {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+
+	now := time.Now()
+	states := make([]BreakerState, 0, len(breakers))
+	for host, cb := range breakers {
+		cb.mutex.Lock()
+		state := BreakerState{Host: host, Failures: cb.failures}
+		if now.Before(cb.openUntil) {
+			openUntil := cb.openUntil
+			state.Open = true
+			state.OpenUntil = &openUntil
+		}
+		cb.mutex.Unlock()
+		states = append(states, state)
+	}
+	return states
+}
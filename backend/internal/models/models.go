@@ -1,7 +1,13 @@
 package models
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Core data structures for the financial dashboard
@@ -24,17 +30,83 @@ type Account struct {
 	AccountType       string    `json:"account_type" db:"account_type"`
 	Institution       string    `json:"institution" db:"institution"`
 	DataSourceType    string    `json:"data_source_type" db:"data_source_type"`
+	Owner             string    `json:"owner" db:"owner"`
+	OwnerMemberID     *int      `json:"owner_member_id" db:"owner_member_id"`
+	Custodial         bool      `json:"custodial" db:"custodial"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Account data sources: manual entries are each filed under a "manual"
+// account of their own, and "institution" accounts are real accounts created
+// through the accounts API for holdings to be linked to
+const (
+	AccountSourceManual      = "manual"
+	AccountSourceInstitution = "institution"
+)
+
+// Account owners: the account holder, their spouse or a child, who is a
+// household member
+const (
+	AccountOwnerSelf   = "self"
+	AccountOwnerSpouse = "spouse"
+	AccountOwnerChild  = "child"
+)
+
+// AccountInput holds the writable fields of an account. Owner is self,
+// spouse or child, and defaults to self; a child's account names the
+// household member it is for. Custodial defaults to whether the owner is a
+// child.
+type AccountInput struct {
+	AccountName       string  `json:"account_name" binding:"required,max=200"`
+	AccountType       string  `json:"account_type" binding:"required,max=50"`
+	Institution       string  `json:"institution" binding:"required,max=100"`
+	ExternalAccountID *string `json:"external_account_id" binding:"omitempty,max=100"`
+	Owner             string  `json:"owner" binding:"omitempty,oneof=self spouse child"`
+	OwnerMemberID     *int    `json:"owner_member_id" binding:"required_if=Owner child,omitempty,gt=0"`
+	Custodial         *bool   `json:"custodial"`
+}
+
+// Ownership returns the owner, the member for a child's account and whether
+// the account is custodial, with the defaults applied
+func (in AccountInput) Ownership() (owner string, memberID *int, custodial bool) {
+	owner = in.Owner
+	if owner == "" {
+		owner = AccountOwnerSelf
+	}
+	if owner == AccountOwnerChild {
+		memberID = in.OwnerMemberID
+	}
+	custodial = owner == AccountOwnerChild
+	if in.Custodial != nil {
+		custodial = *in.Custodial
+	}
+	return owner, memberID, custodial
+}
+
+// AccountHolding is a holding filed under an account
+type AccountHolding struct {
+	HoldingRef
+	Name  string          `json:"name"`
+	Value decimal.Decimal `json:"value"`
+}
+
+// AccountDetail is an account with the holdings filed under it and their
+// value toward total assets
+type AccountDetail struct {
+	Account
+	HoldingCount int              `json:"holding_count"`
+	Value        decimal.Decimal  `json:"value"`
+	Holdings     []AccountHolding `json:"holdings,omitempty"`
+}
+
 type AccountBalance struct {
-	ID         int       `json:"id" db:"id"`
-	AccountID  int       `json:"account_id" db:"account_id"`
-	Balance    float64   `json:"balance" db:"balance"`
-	Currency   string    `json:"currency" db:"currency"`
-	Timestamp  time.Time `json:"timestamp" db:"timestamp"`
-	DataSource string    `json:"data_source" db:"data_source"`
+	ID         int             `json:"id" db:"id"`
+	AccountID  int             `json:"account_id" db:"account_id"`
+	Balance    decimal.Decimal `json:"balance" db:"balance"`
+	Currency   string          `json:"currency" db:"currency"`
+	Timestamp  time.Time       `json:"timestamp" db:"timestamp"`
+	DataSource string          `json:"data_source" db:"data_source"`
 }
 
 type ManualEntry struct {
@@ -58,43 +130,265 @@ type ManualEntryLog struct {
 }
 
 type StockHolding struct {
-	ID                int       `json:"id" db:"id"`
-	AccountID         int       `json:"account_id" db:"account_id"`
-	Symbol            string    `json:"symbol" db:"symbol"`
-	CompanyName       *string   `json:"company_name" db:"company_name"`
-	SharesOwned       float64   `json:"shares_owned" db:"shares_owned"`
-	CostBasis         *float64  `json:"cost_basis" db:"cost_basis"`
-	CurrentPrice      *float64  `json:"current_price" db:"current_price"`
-	MarketValue       *float64  `json:"market_value" db:"market_value"`
-	InstitutionName   string    `json:"institution_name" db:"institution_name"`
-	DataSource        string    `json:"data_source" db:"data_source"`
-	LastPriceUpdate   *time.Time `json:"last_price_update" db:"last_price_update"`
-	LastManualUpdate  *time.Time `json:"last_manual_update" db:"last_manual_update"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	ID                         int              `json:"id" db:"id"`
+	AccountID                  int              `json:"account_id" db:"account_id"`
+	Symbol                     string           `json:"symbol" db:"symbol"`
+	CompanyName                *string          `json:"company_name" db:"company_name"`
+	Exchange                   *string          `json:"exchange" db:"exchange"`
+	SecurityType               *string          `json:"security_type" db:"security_type"`
+	SharesOwned                float64          `json:"shares_owned" db:"shares_owned"`
+	CostBasis                  *decimal.Decimal `json:"cost_basis" db:"cost_basis"`
+	CurrentPrice               *decimal.Decimal `json:"current_price" db:"current_price"`
+	MarketValue                decimal.Decimal  `json:"market_value" db:"market_value"`
+	InstitutionName            string           `json:"institution_name" db:"institution_name"`
+	DataSource                 string           `json:"data_source" db:"data_source"`
+	EstimatedQuarterlyDividend *decimal.Decimal `json:"estimated_quarterly_dividend" db:"estimated_quarterly_dividend"`
+	PurchaseDate               *time.Time       `json:"purchase_date" db:"purchase_date"`
+	DripEnabled                *string          `json:"drip_enabled" db:"drip_enabled"`
+	LastManualUpdate           *time.Time       `json:"last_manual_update" db:"last_manual_update"`
+	IsVestedEquity             bool             `json:"is_vested_equity" db:"is_vested_equity"`
+	CreatedAt                  time.Time        `json:"created_at" db:"created_at"`
 }
 
 type StockPrice struct {
-	ID        int       `json:"id" db:"id"`
-	Symbol    string    `json:"symbol" db:"symbol"`
-	Price     float64   `json:"price" db:"price"`
-	Timestamp time.Time `json:"timestamp" db:"timestamp"`
-	Source    string    `json:"source" db:"source"`
+	ID        int             `json:"id" db:"id"`
+	Symbol    string          `json:"symbol" db:"symbol"`
+	Price     decimal.Decimal `json:"price" db:"price"`
+	Timestamp time.Time       `json:"timestamp" db:"timestamp"`
+	Source    string          `json:"source" db:"source"`
+}
+
+// SymbolPosition is the market value held in a symbol across stock holdings
+// and equity grants, and whether any of them has a price yet
+type SymbolPosition struct {
+	Value  float64
+	Priced bool
+}
+
+// SymbolPriceSnapshot is the price a symbol's holdings carry and when a price
+// for it was last cached
+type SymbolPriceSnapshot struct {
+	HoldingPrice *float64
+	CachedAt     *time.Time
+}
+
+// StockPriceCoverage counts the held symbols and those without a price, with
+// when any stock price was last cached
+type StockPriceCoverage struct {
+	Stale        int
+	Total        int
+	LastCachedAt time.Time
+}
+
+// CryptoPricePoint is one cached snapshot of a coin's price
+type CryptoPricePoint struct {
+	Symbol      string
+	PriceUSD    float64
+	PriceBTC    float64
+	LastUpdated time.Time
+}
+
+// PassiveIncome is the monthly passive income expected from each source
+type PassiveIncome struct {
+	CashInterest   float64
+	StockDividends float64
+	RentalIncome   float64
+	CryptoStaking  float64
+}
+
+// Equity grant types
+const (
+	GrantTypeISO  = "iso"  // incentive stock options
+	GrantTypeNSO  = "nso"  // non-qualified stock options
+	GrantTypeRSU  = "rsu"  // restricted stock units
+	GrantTypeESPP = "espp" // employee stock purchase plan shares
+	GrantTypeRSA  = "rsa"  // restricted stock awards
+)
+
+// EquityGrantTypes lists the grant types with their labels
+var EquityGrantTypes = []struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}{
+	{GrantTypeISO, "Incentive Stock Options (ISO)"},
+	{GrantTypeNSO, "Non-Qualified Stock Options (NSO)"},
+	{GrantTypeRSU, "Restricted Stock Units (RSU)"},
+	{GrantTypeESPP, "Employee Stock Purchase Plan (ESPP)"},
+	{GrantTypeRSA, "Restricted Stock Award (RSA)"},
+}
+
+// maxISOTermYears is the longest an ISO can be exercisable after its grant
+const maxISOTermYears = 10
+
+// election83bDeadlineDays is how long after a restricted stock award an 83(b)
+// election can be filed
+const election83bDeadlineDays = 30
+
+// NormalizeGrantType lower-cases a grant type. The old stock_option type,
+// which didn't say which kind of option, is read as NSO.
+func NormalizeGrantType(grantType string) string {
+	grantType = strings.ToLower(strings.TrimSpace(grantType))
+	switch grantType {
+	case "stock_option", "stock_options":
+		return GrantTypeNSO
+	}
+	return grantType
+}
+
+// IsOptionGrant reports whether a grant type is a stock option, with a strike
+// price paid on exercise
+func IsOptionGrant(grantType string) bool {
+	return grantType == GrantTypeISO || grantType == GrantTypeNSO
 }
 
 type EquityGrant struct {
-	ID             int       `json:"id" db:"id"`
-	AccountID      int       `json:"account_id" db:"account_id"`
-	GrantID        *string   `json:"grant_id" db:"grant_id"`
-	GrantType      string    `json:"grant_type" db:"grant_type"`
-	CompanySymbol  *string   `json:"company_symbol" db:"company_symbol"`
-	TotalShares    int       `json:"total_shares" db:"total_shares"`
-	VestedShares   int       `json:"vested_shares" db:"vested_shares"`
-	UnvestedShares int       `json:"unvested_shares" db:"unvested_shares"`
-	StrikePrice    *float64  `json:"strike_price" db:"strike_price"`
-	GrantDate      *time.Time `json:"grant_date" db:"grant_date"`
-	VestStartDate  *time.Time `json:"vest_start_date" db:"vest_start_date"`
-	DataSource     string    `json:"data_source" db:"data_source"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	ID             int              `json:"id" db:"id"`
+	AccountID      int              `json:"account_id" db:"account_id"`
+	GrantType      string           `json:"grant_type" db:"grant_type"`
+	CompanySymbol  string           `json:"company_symbol" db:"company_symbol"`
+	TotalShares    float64          `json:"total_shares" db:"total_shares"`
+	VestedShares   float64          `json:"vested_shares" db:"vested_shares"`
+	UnvestedShares float64          `json:"unvested_shares" db:"unvested_shares"`
+	StrikePrice    *decimal.Decimal `json:"strike_price" db:"strike_price"`
+	GrantDate      time.Time        `json:"grant_date" db:"grant_date"`
+	VestStartDate  time.Time        `json:"vest_start_date" db:"vest_start_date"`
+	CurrentPrice   *decimal.Decimal `json:"current_price" db:"current_price"`
+	DataSource     string           `json:"data_source" db:"data_source"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	// ExpirationDate is the last day an option can be exercised
+	ExpirationDate *time.Time `json:"expiration_date" db:"expiration_date"`
+	// Election83bFiledDate is when an 83(b) election was filed for an RSA or
+	// early-exercised options
+	Election83bFiledDate *time.Time `json:"election_83b_filed_date" db:"election_83b_filed_date"`
+	// AMTBasisPerShare is the fair market value per share at exercise of ISO
+	// shares, their basis for the alternative minimum tax
+	AMTBasisPerShare *float64 `json:"amt_basis_per_share" db:"amt_basis_per_share"`
+}
+
+// UpcomingVest is a scheduled vest of an equity grant valued at the current
+// price, or for options at their spread over the strike price
+type UpcomingVest struct {
+	GrantID   int     `json:"grant_id"`
+	Symbol    string  `json:"symbol"`
+	GrantType string  `json:"grant_type"`
+	VestDate  string  `json:"vest_date"`
+	Shares    float64 `json:"shares"`
+	Value     float64 `json:"value"`
+}
+
+// EquityGrantInput holds the writable fields of an equity grant. Dates are
+// YYYY-MM-DD. Which of the type-specific fields apply depends on GrantType;
+// see Validate.
+type EquityGrantInput struct {
+	AccountID            int      `json:"account_id" binding:"required,gt=0"`
+	GrantType            string   `json:"grant_type" binding:"required"`
+	CompanySymbol        string   `json:"company_symbol" binding:"required,max=10"`
+	TotalShares          float64  `json:"total_shares" binding:"required,gt=0"`
+	VestedShares         float64  `json:"vested_shares" binding:"gte=0"`
+	StrikePrice          float64  `json:"strike_price" binding:"gte=0"`
+	GrantDate            string   `json:"grant_date" binding:"required"`
+	VestStartDate        string   `json:"vest_start_date" binding:"required"`
+	ExpirationDate       *string  `json:"expiration_date"`
+	Election83bFiledDate *string  `json:"election_83b_filed_date"`
+	AMTBasisPerShare     *float64 `json:"amt_basis_per_share" binding:"omitempty,gte=0"`
+}
+
+// EquityGrantError is a field of an equity grant that is missing, malformed
+// or doesn't suit the grant type
+type EquityGrantError struct {
+	Field   string
+	Message string
+}
+
+func (e *EquityGrantError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Validate normalizes the grant type and checks the type-specific fields:
+//   - iso and nso need a strike price and may have an expiration date, which
+//     for an ISO is at most 10 years after the grant
+//   - rsu has no strike price
+//   - an 83(b) election applies to rsa, or to options exercised early, and is
+//     filed on or after the grant date; for an rsa within 30 days of it
+//   - an AMT basis applies to iso only
+func (input *EquityGrantInput) Validate() error {
+	input.GrantType = NormalizeGrantType(input.GrantType)
+	validType := false
+	for _, t := range EquityGrantTypes {
+		validType = validType || t.Value == input.GrantType
+	}
+	if !validType {
+		return &EquityGrantError{"grant_type", "must be one of iso, nso, rsu, espp or rsa"}
+	}
+
+	grantDate, err := time.Parse("2006-01-02", input.GrantDate)
+	if err != nil {
+		return &EquityGrantError{"grant_date", "must be YYYY-MM-DD"}
+	}
+	if _, err := time.Parse("2006-01-02", input.VestStartDate); err != nil {
+		return &EquityGrantError{"vest_start_date", "must be YYYY-MM-DD"}
+	}
+	if input.VestedShares > input.TotalShares {
+		return &EquityGrantError{"vested_shares", "cannot exceed total shares"}
+	}
+
+	option := IsOptionGrant(input.GrantType)
+	switch {
+	case option && input.StrikePrice <= 0:
+		return &EquityGrantError{"strike_price", "is required for stock options"}
+	case input.GrantType == GrantTypeRSU && input.StrikePrice > 0:
+		return &EquityGrantError{"strike_price", "does not apply to RSUs"}
+	}
+
+	if input.ExpirationDate != nil && *input.ExpirationDate != "" {
+		if !option {
+			return &EquityGrantError{"expiration_date", "only applies to stock options"}
+		}
+		expiration, err := time.Parse("2006-01-02", *input.ExpirationDate)
+		if err != nil {
+			return &EquityGrantError{"expiration_date", "must be YYYY-MM-DD"}
+		}
+		if !expiration.After(grantDate) {
+			return &EquityGrantError{"expiration_date", "must be after the grant date"}
+		}
+		if input.GrantType == GrantTypeISO && expiration.After(grantDate.AddDate(maxISOTermYears, 0, 0)) {
+			return &EquityGrantError{"expiration_date", "must be within 10 years of the grant date for an ISO"}
+		}
+	}
+
+	if input.Election83bFiledDate != nil && *input.Election83bFiledDate != "" {
+		if !option && input.GrantType != GrantTypeRSA {
+			return &EquityGrantError{"election_83b_filed_date", "only applies to RSAs and early-exercised options"}
+		}
+		filed, err := time.Parse("2006-01-02", *input.Election83bFiledDate)
+		if err != nil {
+			return &EquityGrantError{"election_83b_filed_date", "must be YYYY-MM-DD"}
+		}
+		if filed.Before(grantDate) {
+			return &EquityGrantError{"election_83b_filed_date", "cannot be before the grant date"}
+		}
+		if input.GrantType == GrantTypeRSA && filed.After(grantDate.AddDate(0, 0, election83bDeadlineDays)) {
+			return &EquityGrantError{"election_83b_filed_date", "must be within 30 days of the grant date for an RSA"}
+		}
+	}
+
+	if input.AMTBasisPerShare != nil && input.GrantType != GrantTypeISO {
+		return &EquityGrantError{"amt_basis_per_share", "only applies to ISOs"}
+	}
+	return nil
+}
+
+// OptionalDate returns the date in value, or nil when value is nil or empty.
+// It must already have passed validation.
+func OptionalDate(value *string) *time.Time {
+	if value == nil || *value == "" {
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil
+	}
+	return &parsed
 }
 
 type VestingSchedule struct {
@@ -109,64 +403,596 @@ type VestingSchedule struct {
 }
 
 type RealEstate struct {
-	ID                    int        `json:"id" db:"id"`
-	AccountID             int        `json:"account_id" db:"account_id"`
-	PropertyType          string     `json:"property_type" db:"property_type"`
-	PropertyName          string     `json:"property_name" db:"property_name"`
-	StreetAddress         *string    `json:"street_address" db:"street_address"`
-	City                  *string    `json:"city" db:"city"`
-	State                 *string    `json:"state" db:"state"`
-	ZipCode               *string    `json:"zip_code" db:"zip_code"`
-	Latitude              *float64   `json:"latitude" db:"latitude"`
-	Longitude             *float64   `json:"longitude" db:"longitude"`
-	PurchasePrice         float64    `json:"purchase_price" db:"purchase_price"`
-	CurrentValue          float64    `json:"current_value" db:"current_value"`
-	OutstandingMortgage   float64    `json:"outstanding_mortgage" db:"outstanding_mortgage"`
-	Equity                float64    `json:"equity" db:"equity"`
-	PurchaseDate          time.Time  `json:"purchase_date" db:"purchase_date"`
-	PropertySizeSqft      *float64   `json:"property_size_sqft" db:"property_size_sqft"`
-	LotSizeAcres          *float64   `json:"lot_size_acres" db:"lot_size_acres"`
-	RentalIncomeMonthly   *float64   `json:"rental_income_monthly" db:"rental_income_monthly"`
-	PropertyTaxAnnual     *float64   `json:"property_tax_annual" db:"property_tax_annual"`
-	Notes                 *string    `json:"notes" db:"notes"`
-	APIEstimatedValue     *float64   `json:"api_estimated_value" db:"api_estimated_value"`
-	APIEstimateDate       *time.Time `json:"api_estimate_date" db:"api_estimate_date"`
-	APIProvider           *string    `json:"api_provider" db:"api_provider"`
-	LastUpdated           time.Time  `json:"last_updated" db:"last_updated"`
-	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
+	ID                  int              `json:"id" db:"id"`
+	AccountID           int              `json:"account_id" db:"account_id"`
+	PropertyType        string           `json:"property_type" db:"property_type"`
+	PropertyName        string           `json:"property_name" db:"property_name"`
+	PurchasePrice       decimal.Decimal  `json:"purchase_price" db:"purchase_price"`
+	CurrentValue        decimal.Decimal  `json:"current_value" db:"current_value"`
+	OutstandingMortgage decimal.Decimal  `json:"outstanding_mortgage" db:"outstanding_mortgage"`
+	Equity              decimal.Decimal  `json:"equity" db:"equity"`
+	PurchaseDate        string           `json:"purchase_date" db:"purchase_date"` // YYYY-MM-DD
+	PropertySizeSqft    *float64         `json:"property_size_sqft" db:"property_size_sqft"`
+	LotSizeAcres        *float64         `json:"lot_size_acres" db:"lot_size_acres"`
+	RentalIncomeMonthly *decimal.Decimal `json:"rental_income_monthly" db:"rental_income_monthly"`
+	PropertyTaxAnnual   *decimal.Decimal `json:"property_tax_annual" db:"property_tax_annual"`
+	Notes               *string          `json:"notes" db:"notes"`
+	StreetAddress       *string          `json:"street_address" db:"street_address"`
+	City                *string          `json:"city" db:"city"`
+	State               *string          `json:"state" db:"state"`
+	ZipCode             *string          `json:"zip_code" db:"zip_code"`
+	Latitude            *float64         `json:"latitude" db:"latitude"`
+	Longitude           *float64         `json:"longitude" db:"longitude"`
+	APIEstimatedValue   *decimal.Decimal `json:"api_estimated_value" db:"api_estimated_value"`
+	APIEstimateDate     *time.Time       `json:"api_estimate_date" db:"api_estimate_date"`
+	APIProvider         *string          `json:"api_provider" db:"api_provider"`
+	OwnershipPercentage float64          `json:"ownership_percentage" db:"ownership_percentage"`
+	ImprovementValue    *decimal.Decimal `json:"improvement_value" db:"improvement_value"`           // depreciable building value, excluding land
+	PlacedInServiceDate *string          `json:"placed_in_service_date" db:"placed_in_service_date"` // YYYY-MM-DD, default purchase_date
+	CreatedAt           time.Time        `json:"created_at" db:"created_at"`
+	// Owner's share of the figures above, scaled by OwnershipPercentage
+	OwnedValue               decimal.Decimal  `json:"owned_value"`
+	OwnedMortgage            decimal.Decimal  `json:"owned_mortgage"`
+	OwnedEquity              decimal.Decimal  `json:"owned_equity"`
+	OwnedRentalIncomeMonthly *decimal.Decimal `json:"owned_rental_income_monthly"`
+	OwnedPropertyTaxAnnual   *decimal.Decimal `json:"owned_property_tax_annual"`
+}
+
+// ApplyOwnership fills in the owner's share of value, mortgage, equity, income and expenses
+func (r *RealEstate) ApplyOwnership() {
+	share := decimal.NewFromFloat(r.OwnershipPercentage).Div(decimal.NewFromInt(100))
+	r.OwnedValue = r.CurrentValue.Mul(share)
+	r.OwnedMortgage = r.OutstandingMortgage.Mul(share)
+	r.OwnedEquity = r.Equity.Mul(share)
+	if r.RentalIncomeMonthly != nil {
+		income := r.RentalIncomeMonthly.Mul(share)
+		r.OwnedRentalIncomeMonthly = &income
+	}
+	if r.PropertyTaxAnnual != nil {
+		tax := r.PropertyTaxAnnual.Mul(share)
+		r.OwnedPropertyTaxAnnual = &tax
+	}
+}
+
+// PropertyValuationConsensus is the provider recorded for the combined estimate
+const PropertyValuationConsensus = "consensus"
+
+// PropertyValuationRecord is one stored property valuation
+type PropertyValuationRecord struct {
+	ID              int       `json:"id"`
+	PropertyID      int       `json:"property_id"`
+	Provider        string    `json:"provider"`
+	EstimatedValue  float64   `json:"estimated_value"`
+	ConfidenceScore *float64  `json:"confidence_score"`
+	ValuedAt        time.Time `json:"valued_at"`
+}
+
+type CashHolding struct {
+	ID                  int              `json:"id" db:"id"`
+	AccountID           int              `json:"account_id" db:"account_id"`
+	InstitutionName     string           `json:"institution_name" db:"institution_name"`
+	AccountName         string           `json:"account_name" db:"account_name"`
+	AccountType         string           `json:"account_type" db:"account_type"`
+	CurrentBalance      decimal.Decimal  `json:"current_balance" db:"current_balance"`
+	InterestRate        *float64         `json:"interest_rate" db:"interest_rate"`
+	MonthlyContribution *decimal.Decimal `json:"monthly_contribution" db:"monthly_contribution"`
+	AccountNumberLast4  *string          `json:"account_number_last4" db:"account_number_last4"`
+	Currency            string           `json:"currency" db:"currency"`
+	Notes               *string          `json:"notes" db:"notes"`
+	CreatedAt           time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Computed from interest_rate for the requested compounding; nil without a rate
+	APY                     *float64         `json:"apy,omitempty"`
+	ProjectedAnnualInterest *decimal.Decimal `json:"projected_annual_interest,omitempty"`
+}
+
+type CryptoHolding struct {
+	ID                      int              `json:"id" db:"id"`
+	AccountID               int              `json:"account_id" db:"account_id"`
+	InstitutionName         string           `json:"institution_name" db:"institution_name"`
+	CryptoSymbol            string           `json:"crypto_symbol" db:"crypto_symbol"`
+	BalanceTokens           float64          `json:"balance_tokens" db:"balance_tokens"`
+	PurchasePriceUSD        *decimal.Decimal `json:"purchase_price_usd" db:"purchase_price_usd"`
+	PurchaseDate            *time.Time       `json:"purchase_date" db:"purchase_date"`
+	WalletAddress           *string          `json:"wallet_address" db:"wallet_address"`
+	Notes                   *string          `json:"notes" db:"notes"`
+	StakingAnnualPercentage *float64         `json:"staking_annual_percentage" db:"staking_annual_percentage"`
+	StakedTokens            float64          `json:"staked_tokens" db:"staked_tokens"`
+	LiquidTokens            float64          `json:"liquid_tokens"` // balance_tokens - staked_tokens
+	CreatedAt               time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt               time.Time        `json:"updated_at" db:"updated_at"`
+	// Latest cached price data
+	CurrentPriceUSD  *decimal.Decimal `json:"current_price_usd"`
+	CurrentPriceBTC  *float64         `json:"current_price_btc"`
+	CurrentValueUSD  *decimal.Decimal `json:"current_value_usd"`
+	PriceChange24h   *float64         `json:"price_change_24h"`
+	PriceLastUpdated *time.Time       `json:"price_last_updated"`
 }
 
 type MiscellaneousAsset struct {
-	ID           int       `json:"id" db:"id"`
-	AccountID    int       `json:"account_id" db:"account_id"`
-	AssetName    string    `json:"asset_name" db:"asset_name"`
-	AssetType    *string   `json:"asset_type" db:"asset_type"`
-	CurrentValue float64   `json:"current_value" db:"current_value"`
-	Description  *string   `json:"description" db:"description"`
-	LastUpdated  time.Time `json:"last_updated" db:"last_updated"`
+	ID                int                    `json:"id" db:"id"`
+	AssetName         string                 `json:"asset_name" db:"asset_name"`
+	CurrentValue      decimal.Decimal        `json:"current_value" db:"current_value"`
+	Equity            decimal.Decimal        `json:"equity"` // current_value - amount_owed
+	PurchasePrice     *decimal.Decimal       `json:"purchase_price,omitempty" db:"purchase_price"`
+	AmountOwed        *decimal.Decimal       `json:"amount_owed,omitempty" db:"amount_owed"`
+	PurchaseDate      *string                `json:"purchase_date,omitempty" db:"purchase_date"` // YYYY-MM-DD
+	Description       *string                `json:"description,omitempty" db:"description"`
+	CustomFields      map[string]interface{} `json:"custom_fields,omitempty" db:"custom_fields"`
+	ValuationMethod   string                 `json:"valuation_method" db:"valuation_method"`
+	LastValuationDate *time.Time             `json:"last_valuation_date,omitempty" db:"last_valuation_date"`
+	APIProvider       *string                `json:"api_provider,omitempty" db:"api_provider"`
+	Notes             *string                `json:"notes,omitempty" db:"notes"`
+	AssetCategoryID   int64                  `json:"asset_category_id" db:"asset_category_id"`
+	Category          *AssetCategorySummary  `json:"category,omitempty"`
+	CreatedAt         time.Time              `json:"created_at" db:"created_at"`
+	LastUpdated       time.Time              `json:"last_updated" db:"last_updated"`
+}
+
+// PrivateInvestment is an illiquid investment such as an angel round or a
+// private equity or venture fund. CurrentValue is the last reported NAV plus
+// capital called and less distributions paid since its date.
+type PrivateInvestment struct {
+	ID               int             `json:"id"`
+	AccountID        *int            `json:"account_id"`
+	InvestmentName   string          `json:"investment_name"`
+	InvestmentType   string          `json:"investment_type"`
+	Sponsor          string          `json:"sponsor"`
+	CommittedCapital decimal.Decimal `json:"committed_capital"`
+	CurrentNAV       decimal.Decimal `json:"current_nav"`
+	NAVDate          time.Time       `json:"nav_date"`
+	CurrentValue     decimal.Decimal `json:"current_value"`
+	VintageYear      *int            `json:"vintage_year"`
+	Notes            *string         `json:"notes"`
+	CreatedAt        time.Time       `json:"created_at"`
+	LastUpdated      time.Time       `json:"last_updated"`
+}
+
+// Bond is a bond, treasury or CD. Prices are percentages of face value;
+// CurrentPrice is nil until one is entered, and the bond is valued at its
+// purchase price until then. CurrentValue is the market value plus the
+// interest accrued since the last coupon, and the face value once matured.
+type Bond struct {
+	ID              int              `json:"id"`
+	AccountID       *int             `json:"account_id"`
+	InstitutionName string           `json:"institution_name"`
+	BondName        string           `json:"bond_name"`
+	BondType        string           `json:"bond_type"`
+	CUSIP           *string          `json:"cusip"`
+	FaceValue       decimal.Decimal  `json:"face_value"`
+	CouponRate      float64          `json:"coupon_rate"`
+	CouponFrequency int              `json:"coupon_frequency"`
+	IssueDate       *time.Time       `json:"issue_date"`
+	MaturityDate    time.Time        `json:"maturity_date"`
+	PurchasePrice   decimal.Decimal  `json:"purchase_price"`
+	PurchaseDate    *time.Time       `json:"purchase_date"`
+	CurrentPrice    *decimal.Decimal `json:"current_price"`
+	MarketValue     decimal.Decimal  `json:"market_value"`
+	AccruedInterest decimal.Decimal  `json:"accrued_interest"`
+	CurrentValue    decimal.Decimal  `json:"current_value"`
+	Notes           *string          `json:"notes"`
+	CreatedAt       time.Time        `json:"created_at"`
+	LastUpdated     time.Time        `json:"last_updated"`
+}
+
+// Value sets a bond's market value, the interest it has accrued and its
+// current value as of now. Every reader of bonds values them this way; there
+// is no SQL counterpart.
+func (b *Bond) Value(now time.Time) {
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !b.MaturityDate.After(asOf) {
+		b.MarketValue = b.FaceValue
+	} else {
+		price := b.PurchasePrice
+		if b.CurrentPrice != nil {
+			price = *b.CurrentPrice
+		}
+		b.MarketValue = b.FaceValue.Mul(price).Div(decimal.NewFromInt(100)).Round(2)
+	}
+	b.AccruedInterest = BondAccruedInterest(b.FaceValue, b.CouponRate, b.CouponFrequency, b.MaturityDate, asOf)
+	b.CurrentValue = b.MarketValue.Add(b.AccruedInterest)
+}
+
+// BondAccruedInterest is the interest a bond of face value paying couponRate
+// percent a year in frequency coupons has accrued since its last coupon as
+// of asOf. Coupon dates are counted back from maturity, and days actual/actual
+// within the coupon period.
+func BondAccruedInterest(face decimal.Decimal, couponRate float64, frequency int, maturity, asOf time.Time) decimal.Decimal {
+	if couponRate <= 0 || frequency <= 0 || !asOf.Before(maturity) {
+		return decimal.Zero
+	}
+	months := 12 / frequency
+	periods := 1
+	for addMonths(maturity, -months*periods).After(asOf) {
+		periods++
+	}
+	lastCoupon := addMonths(maturity, -months*periods)
+	nextCoupon := addMonths(maturity, -months*(periods-1))
+	coupon := face.Mul(decimal.NewFromFloat(couponRate)).Div(decimal.NewFromInt(int64(100 * frequency)))
+	return coupon.Mul(daysBetween(lastCoupon, asOf)).Div(daysBetween(lastCoupon, nextCoupon)).Round(2)
+}
+
+// daysBetween counts the days from one date to another
+func daysBetween(from, to time.Time) decimal.Decimal {
+	return decimal.NewFromFloat(math.Round(to.Sub(from).Hours() / 24))
+}
+
+// IBond is a US Series I savings bond. Its composite rate combines its fixed
+// rate with the inflation rate of each six-month period since issue.
+// RedemptionValue is what cashing it in would pay: the value three months
+// ago while held under five years, and nothing can be cashed in the first
+// year. Rates are percentages.
+type IBond struct {
+	ID              int             `json:"id"`
+	AccountID       *int            `json:"account_id"`
+	InstitutionName string          `json:"institution_name"`
+	SerialNumber    *string         `json:"serial_number"`
+	IssueDate       time.Time       `json:"issue_date"`
+	PurchaseAmount  decimal.Decimal `json:"purchase_amount"`
+	FixedRate       float64         `json:"fixed_rate"`
+	MonthsHeld      int             `json:"months_held"`
+	CompositeRate   float64         `json:"composite_rate"`
+	NextRateChange  *time.Time      `json:"next_rate_change"`
+	CurrentValue    decimal.Decimal `json:"current_value"`
+	RedemptionValue decimal.Decimal `json:"redemption_value"`
+	Penalty         decimal.Decimal `json:"penalty"`
+	Redeemable      bool            `json:"redeemable"`
+	Notes           *string         `json:"notes"`
+	CreatedAt       time.Time       `json:"created_at"`
+	LastUpdated     time.Time       `json:"last_updated"`
+}
+
+// IBondRate is a rate announcement in the I bond schedule
+type IBondRate struct {
+	EffectiveDate time.Time `json:"effective_date"`
+	FixedRate     float64   `json:"fixed_rate"`
+	InflationRate float64   `json:"inflation_rate"`
+	CompositeRate float64   `json:"composite_rate"`
+}
+
+const (
+	// iBondLockupMonths is how long an I bond must be held before it can be cashed in
+	iBondLockupMonths = 12
+	// iBondPenaltyMonths is how long an I bond must be held before cashing
+	// it in stops forfeiting the last three months of interest
+	iBondPenaltyMonths = 60
+	// iBondMaturityMonths is how long an I bond earns interest
+	iBondMaturityMonths = 360
+)
+
+// IBondRates is the I bond rate schedule, in any order
+type IBondRates []IBondRate
+
+// InflationRate is the semiannual inflation rate of the six-month period
+// starting on periodStart: that of the latest announcement on or before it,
+// or zero before the first
+func (rates IBondRates) InflationRate(periodStart time.Time) float64 {
+	var latest time.Time
+	rate := 0.0
+	for _, r := range rates {
+		if !r.EffectiveDate.After(periodStart) && r.EffectiveDate.After(latest) {
+			latest, rate = r.EffectiveDate, r.InflationRate
+		}
+	}
+	return rate
+}
+
+// Value sets an I bond's months held, composite rate, next rate change,
+// current and redemption values and whether it can be cashed in as of now,
+// with the inflation rates of the schedule. Every reader of I bonds values
+// them this way; there is no SQL counterpart.
+func (b *IBond) Value(now time.Time, rates IBondRates) {
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	b.MonthsHeld = max(0, monthsBetween(b.IssueDate, asOf))
+	b.CompositeRate, b.NextRateChange = 0, nil
+	if b.MonthsHeld < iBondMaturityMonths {
+		periodStart := addMonths(b.IssueDate, b.MonthsHeld/6*6)
+		b.CompositeRate = IBondCompositeRate(b.FixedRate, rates.InflationRate(periodStart))
+		next := addMonths(periodStart, 6)
+		b.NextRateChange = &next
+	}
+
+	// Cashing in within five years forfeits the last three months of interest
+	b.CurrentValue = IBondValue(b.PurchaseAmount, b.IssueDate, b.FixedRate, asOf, rates)
+	b.RedemptionValue = b.CurrentValue
+	if b.MonthsHeld < iBondPenaltyMonths {
+		b.RedemptionValue = IBondValue(b.PurchaseAmount, b.IssueDate, b.FixedRate, addMonths(asOf, -3), rates)
+	}
+	b.Penalty = b.CurrentValue.Sub(b.RedemptionValue)
+	b.Redeemable = b.MonthsHeld >= iBondLockupMonths
+}
+
+// IBondCompositeRate is the annual composite rate of a fixed rate and a
+// semiannual inflation rate, never below zero. Rates are percentages.
+func IBondCompositeRate(fixedRate, inflationRate float64) float64 {
+	return math.Max(0, math.Round((fixedRate+2*inflationRate+fixedRate*inflationRate/100)*100)/100)
+}
+
+// IBondValue is the value as of asOf of an I bond bought for amount on issue
+// with fixedRate, the way Treasury calculates it: interest is added on the
+// first of each month for up to 30 years, compounding every six months at
+// the composite rate of the period, on a $25 bond rounded to the cent and
+// scaled to the amount. I bonds are issued on the first of the month, so
+// the months held are calendar months.
+func IBondValue(amount decimal.Decimal, issue time.Time, fixedRate float64, asOf time.Time, rates IBondRates) decimal.Decimal {
+	months := min(monthsBetween(issue, asOf), iBondMaturityMonths)
+	unit := decimal.NewFromInt(25)
+	for periodStart := issue; months > 0; periodStart = addMonths(periodStart, 6) {
+		periodMonths := min(months, 6)
+		rate := IBondCompositeRate(fixedRate, rates.InflationRate(periodStart))
+		// The growth of a partial period is a fractional power, so it is
+		// float64 math; the value it scales stays in decimals
+		growth := decimal.NewFromFloat(math.Pow(1+rate/200, float64(periodMonths)/6))
+		unit = unit.Mul(growth).Round(2)
+		months -= periodMonths
+	}
+	return unit.Mul(amount).Div(decimal.NewFromInt(25)).Round(2)
+}
+
+// monthsBetween counts the calendar months from the month of from to the month of to
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// Pension is a defined-benefit pension or annuity paying MonthlyBenefit from
+// StartAge until EndAge of the beneficiary born on BirthDate, raised by
+// ColaRate each year. PresentValue discounts the payments still to come at
+// DiscountRate; it only counts toward net worth when IncludeInNetWorth is set.
+// Rates are percentages.
+type Pension struct {
+	ID                    int             `json:"id"`
+	AccountID             *int            `json:"account_id"`
+	InstitutionName       string          `json:"institution_name"`
+	PlanName              string          `json:"plan_name"`
+	PensionType           string          `json:"pension_type"`
+	BirthDate             time.Time       `json:"birth_date"`
+	MonthlyBenefit        decimal.Decimal `json:"monthly_benefit"`
+	StartAge              float64         `json:"start_age"`
+	EndAge                float64         `json:"end_age"`
+	ColaRate              float64         `json:"cola_rate"`
+	DiscountRate          float64         `json:"discount_rate"`
+	IncludeInNetWorth     bool            `json:"include_in_net_worth"`
+	StartDate             time.Time       `json:"start_date"`
+	EndDate               time.Time       `json:"end_date"`
+	InPayment             bool            `json:"in_payment"`
+	CurrentMonthlyBenefit decimal.Decimal `json:"current_monthly_benefit"`
+	PresentValue          decimal.Decimal `json:"present_value"`
+	Notes                 *string         `json:"notes"`
+	CreatedAt             time.Time       `json:"created_at"`
+	LastUpdated           time.Time       `json:"last_updated"`
+}
+
+// Value sets a pension's start and end dates, whether it is being paid as of
+// now, its current monthly benefit and its present value. Every reader of
+// pensions values them this way; there is no SQL counterpart.
+func (p *Pension) Value(now time.Time) {
+	p.StartDate = addMonths(p.BirthDate, int(math.Round(p.StartAge*12)))
+	p.EndDate = addMonths(p.BirthDate, int(math.Round(p.EndAge*12)))
+	p.InPayment = !now.Before(p.StartDate) && now.Before(p.EndDate)
+	// The discounting is float64 math, rounded to cents
+	benefit := p.MonthlyBenefit.InexactFloat64()
+	p.PresentValue = decimal.NewFromFloat(PensionPresentValue(benefit, p.StartDate, p.EndDate, p.ColaRate, p.DiscountRate, now))
+	raises := pensionPaymentsMade(p.StartDate, now) / 12
+	p.CurrentMonthlyBenefit = decimal.NewFromFloat(math.Round(benefit*math.Pow(1+p.ColaRate/100, float64(raises))*100) / 100)
+}
+
+// PensionPresentValue is the value as of asOf of the monthly payments of
+// benefit from start until end that are still to come, each discounted at
+// discountRate percent a year by the days until it is paid. The benefit
+// rises by colaRate percent after every 12 payments.
+func PensionPresentValue(benefit float64, start, end time.Time, colaRate, discountRate float64, asOf time.Time) float64 {
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	pv := 0.0
+	for paid := pensionPaymentsMade(start, asOf); ; paid++ {
+		payment := addMonths(start, paid)
+		if !payment.Before(end) {
+			break
+		}
+		days := math.Round(payment.Sub(asOf).Hours() / 24)
+		pv += benefit * math.Pow(1+colaRate/100, float64(paid/12)) / math.Pow(1+discountRate/100, days/365.25)
+	}
+	return math.Round(pv*100) / 100
+}
+
+// pensionPaymentsMade counts the monthly payments from start that fall
+// before now
+func pensionPaymentsMade(start, now time.Time) int {
+	if !start.Before(now) {
+		return 0
+	}
+	months := (now.Year()-start.Year())*12 + int(now.Month()-start.Month())
+	if now.Day() < start.Day() {
+		months--
+	}
+	if addMonths(start, months).Before(now) {
+		months++
+	}
+	return months
+}
+
+// addMonths moves a date by months, keeping its day of the month or, in
+// shorter months, moving to the last day, so payments on the 29th to 31st
+// fall on the last day of shorter months
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, date.Location())
+}
+
+// InsurancePolicy is a life, umbrella or other insurance policy. NetCashValue
+// is the cash value less policy loans, which only whole, universal and
+// variable life policies have; it counts toward net worth. PremiumFrequency
+// is payments a year.
+type InsurancePolicy struct {
+	ID                int              `json:"id"`
+	AccountID         *int             `json:"account_id"`
+	InstitutionName   string           `json:"institution_name"`
+	PolicyName        string           `json:"policy_name"`
+	PolicyType        string           `json:"policy_type"`
+	PolicyNumber      *string          `json:"policy_number"`
+	CoverageAmount    *decimal.Decimal `json:"coverage_amount"`
+	PremiumAmount     decimal.Decimal  `json:"premium_amount"`
+	PremiumFrequency  int              `json:"premium_frequency"`
+	AnnualPremium     decimal.Decimal  `json:"annual_premium"`
+	CashValue         decimal.Decimal  `json:"cash_value"`
+	LoanBalance       decimal.Decimal  `json:"loan_balance"`
+	NetCashValue      decimal.Decimal  `json:"net_cash_value"`
+	EffectiveDate     *time.Time       `json:"effective_date"`
+	RenewalDate       *time.Time       `json:"renewal_date"`
+	TermMonths        *int             `json:"term_months"`
+	RenewalNoticeDays int              `json:"renewal_notice_days"`
+	DaysUntilRenewal  *int             `json:"days_until_renewal"`
+	Notes             *string          `json:"notes"`
+	CreatedAt         time.Time        `json:"created_at"`
+	LastUpdated       time.Time        `json:"last_updated"`
+}
+
+// Liability types
+const (
+	LiabilityTypeCreditCard = "credit_card"
+	LiabilityTypeLoan       = "loan"
+)
+
+// Liability is a credit card or loan balance owed. APR is an annual
+// percentage. Mortgages are tracked with their property instead.
+type Liability struct {
+	ID                 int              `json:"id"`
+	AccountID          *int             `json:"account_id"`
+	InstitutionName    string           `json:"institution_name"`
+	LiabilityName      string           `json:"liability_name"`
+	LiabilityType      string           `json:"liability_type"`
+	CurrentBalance     decimal.Decimal  `json:"current_balance"`
+	APR                *float64         `json:"apr"`
+	MinimumPayment     *decimal.Decimal `json:"minimum_payment"`
+	DueDate            *time.Time       `json:"due_date"`
+	StatementDate      *time.Time       `json:"statement_date"`
+	AccountNumberLast4 *string          `json:"account_number_last4"`
+	Notes              *string          `json:"notes"`
+	CreatedAt          time.Time        `json:"created_at"`
+	LastUpdated        time.Time        `json:"last_updated"`
+}
+
+// LiabilityInput holds the writable fields of a liability. Dates are YYYY-MM-DD.
+type LiabilityInput struct {
+	AccountID          *int             `json:"account_id" binding:"omitempty,gt=0"`
+	InstitutionName    string           `json:"institution_name" binding:"required,max=100"`
+	LiabilityName      string           `json:"liability_name" binding:"required,max=100"`
+	LiabilityType      string           `json:"liability_type" binding:"required,oneof=credit_card loan"`
+	CurrentBalance     decimal.Decimal  `json:"current_balance" binding:"gte=0"`
+	APR                *float64         `json:"apr" binding:"omitempty,gte=0,lte=100"`
+	MinimumPayment     *decimal.Decimal `json:"minimum_payment" binding:"omitempty,gte=0"`
+	DueDate            *string          `json:"due_date" binding:"omitempty,datetime=2006-01-02"`
+	StatementDate      *string          `json:"statement_date" binding:"omitempty,datetime=2006-01-02"`
+	AccountNumberLast4 *string          `json:"account_number_last4" binding:"omitempty,len=4,numeric"`
+	Notes              *string          `json:"notes"`
+}
+
+// Liability statement statuses
+const (
+	StatementStatusPending  = "pending"
+	StatementStatusApplied  = "applied"
+	StatementStatusRejected = "rejected"
+)
+
+// LiabilityStatement is what was read from a statement uploaded for a
+// liability, held for review. Fields the statement didn't show are nil and
+// are left alone when it is applied.
+type LiabilityStatement struct {
+	ID             int              `json:"id"`
+	LiabilityID    int              `json:"liability_id"`
+	AttachmentID   *int             `json:"attachment_id"`
+	DocumentType   string           `json:"document_type"`
+	Status         string           `json:"status"`
+	Balance        *decimal.Decimal `json:"balance"`
+	APR            *float64         `json:"apr"`
+	MinimumPayment *decimal.Decimal `json:"minimum_payment"`
+	DueDate        *time.Time       `json:"due_date"`
+	StatementDate  *time.Time       `json:"statement_date"`
+	CreatedAt      time.Time        `json:"created_at"`
+	ReviewedAt     *time.Time       `json:"reviewed_at"`
+}
+
+// AssetCategorySummary is the category information embedded in asset listings
+type AssetCategorySummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Color       string `json:"color"`
+}
+
+// AssetCategory is a category of other assets with the schema of its custom
+// fields. The schema and valuation config are stored as JSON text.
+type AssetCategory struct {
+	ID                 int       `json:"id"`
+	Name               string    `json:"name"`
+	Description        *string   `json:"description"`
+	Icon               *string   `json:"icon"`
+	Color              *string   `json:"color"`
+	CustomSchema       *string   `json:"custom_schema"`
+	ValuationAPIConfig *string   `json:"valuation_api_config"`
+	IsActive           bool      `json:"is_active"`
+	SortOrder          int       `json:"sort_order"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// AssetCategoryInput holds the writable fields of an asset category. Nil
+// fields are left unchanged by an update and take their defaults on create.
+type AssetCategoryInput struct {
+	Name               *string
+	Description        *string
+	Icon               *string
+	Color              *string
+	CustomSchema       *string
+	ValuationAPIConfig *string
+	IsActive           *bool
+	SortOrder          *int
+}
+
+// ManualEntryRecord is one manually entered holding as listed across the
+// holding tables, its fields as JSON text
+type ManualEntryRecord struct {
+	EntryType   string  `json:"entry_type"`
+	ID          int     `json:"id"`
+	AccountID   int     `json:"account_id"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	DataJSON    string  `json:"data_json"`
+	AccountName *string `json:"account_name"`
+	Institution *string `json:"institution"`
+}
+
+// AssetValuationRecord is one recorded value of a miscellaneous asset
+type AssetValuationRecord struct {
+	ID       int             `json:"id"`
+	AssetID  int             `json:"asset_id"`
+	Value    decimal.Decimal `json:"value"`
+	Source   string          `json:"source"`
+	ValuedAt time.Time       `json:"valued_at"`
 }
 
 type NetWorthSnapshot struct {
-	ID                   int       `json:"id" db:"id"`
-	TotalAssets          float64   `json:"total_assets" db:"total_assets"`
-	TotalLiabilities     float64   `json:"total_liabilities" db:"total_liabilities"`
-	NetWorth             float64   `json:"net_worth" db:"net_worth"`
-	VestedEquityValue    *float64  `json:"vested_equity_value" db:"vested_equity_value"`
-	UnvestedEquityValue  *float64  `json:"unvested_equity_value" db:"unvested_equity_value"`
-	StockHoldingsValue   *float64  `json:"stock_holdings_value" db:"stock_holdings_value"`
-	RealEstateEquity     *float64  `json:"real_estate_equity" db:"real_estate_equity"`
-	Timestamp            time.Time `json:"timestamp" db:"timestamp"`
+	ID                  int       `json:"id" db:"id"`
+	TotalAssets         float64   `json:"total_assets" db:"total_assets"`
+	TotalLiabilities    float64   `json:"total_liabilities" db:"total_liabilities"`
+	NetWorth            float64   `json:"net_worth" db:"net_worth"`
+	VestedEquityValue   *float64  `json:"vested_equity_value" db:"vested_equity_value"`
+	UnvestedEquityValue *float64  `json:"unvested_equity_value" db:"unvested_equity_value"`
+	StockHoldingsValue  *float64  `json:"stock_holdings_value" db:"stock_holdings_value"`
+	RealEstateEquity    *float64  `json:"real_estate_equity" db:"real_estate_equity"`
+	Timestamp           time.Time `json:"timestamp" db:"timestamp"`
 }
 
 type Transaction struct {
-	ID          int       `json:"id" db:"id"`
-	AccountID   int       `json:"account_id" db:"account_id"`
-	Type        string    `json:"type" db:"type"`
-	Amount      float64   `json:"amount" db:"amount"`
-	Currency    string    `json:"currency" db:"currency"`
-	Description string    `json:"description" db:"description"`
-	Date        time.Time `json:"date" db:"date"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ID          int             `json:"id" db:"id"`
+	AccountID   int             `json:"account_id" db:"account_id"`
+	Type        string          `json:"type" db:"type"`
+	Amount      decimal.Decimal `json:"amount" db:"amount"`
+	Currency    string          `json:"currency" db:"currency"`
+	Description string          `json:"description" db:"description"`
+	Date        time.Time       `json:"date" db:"date"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
 }
 
 // Response DTOs
@@ -181,17 +1007,1040 @@ type NetWorthSummary struct {
 	LastUpdated         time.Time `json:"last_updated"`
 }
 
+// NetWorthBreakdown holds the value of each asset class and liabilities, computed
+// together so every widget reports the same numbers. Amounts are decimals so
+// summing thousands of holdings doesn't drift the way float64 does.
+// PensionsValue counts only the pensions included in net worth.
+type NetWorthBreakdown struct {
+	StockHoldingsValue      decimal.Decimal `json:"stock_holdings_value"`
+	VestedEquityValue       decimal.Decimal `json:"vested_equity_value"`
+	UnvestedEquityValue     decimal.Decimal `json:"unvested_equity_value"`
+	RealEstateEquity        decimal.Decimal `json:"real_estate_equity"`
+	CashHoldingsValue       decimal.Decimal `json:"cash_holdings_value"`
+	CryptoHoldingsValue     decimal.Decimal `json:"crypto_holdings_value"`
+	OtherAssetsValue        decimal.Decimal `json:"other_assets_value"`
+	PrivateInvestmentsValue decimal.Decimal `json:"private_investments_value"`
+	FixedIncomeValue        decimal.Decimal `json:"fixed_income_value"`
+	PensionsValue           decimal.Decimal `json:"pensions_value"`
+	InsuranceCashValue      decimal.Decimal `json:"insurance_cash_value"`
+	TotalLiabilities        decimal.Decimal `json:"total_liabilities"`
+	// StablecoinValue is the part of CryptoHoldingsValue held in stablecoins
+	StablecoinValue decimal.Decimal `json:"stablecoin_value"`
+}
+
+// TotalAssets sums vested and liquid assets; unvested equity is future value and excluded
+func (b NetWorthBreakdown) TotalAssets() decimal.Decimal {
+	return decimal.Sum(b.StockHoldingsValue, b.VestedEquityValue, b.RealEstateEquity,
+		b.CashHoldingsValue, b.CryptoHoldingsValue, b.OtherAssetsValue, b.PrivateInvestmentsValue,
+		b.FixedIncomeValue, b.PensionsValue, b.InsuranceCashValue)
+}
+
+// NetWorth is total assets minus liabilities
+func (b NetWorthBreakdown) NetWorth() decimal.Decimal {
+	return b.TotalAssets().Sub(b.TotalLiabilities)
+}
+
+// StablecoinsAsCash returns the breakdown with stablecoins counted as cash
+// rather than crypto. Totals are unchanged.
+func (b NetWorthBreakdown) StablecoinsAsCash() NetWorthBreakdown {
+	b.CryptoHoldingsValue = b.CryptoHoldingsValue.Sub(b.StablecoinValue)
+	b.CashHoldingsValue = b.CashHoldingsValue.Add(b.StablecoinValue)
+	return b
+}
+
+// Coin classes used to separate cash-like crypto from the rest in allocation
+// and risk analytics
+const (
+	CoinClassStablecoin = "stablecoin"
+	CoinClassVolatile   = "volatile"
+)
+
+// CoinClassification maps upper-case crypto symbols to their coin class.
+// Symbols not in the map are volatile.
+type CoinClassification map[string]string
+
+// NewCoinClassification classifies the given symbols as stablecoins
+func NewCoinClassification(stablecoins []string) CoinClassification {
+	coins := make(CoinClassification, len(stablecoins))
+	for _, symbol := range stablecoins {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			coins[symbol] = CoinClassStablecoin
+		}
+	}
+	return coins
+}
+
+// Class returns the coin class of symbol
+func (c CoinClassification) Class(symbol string) string {
+	if class, ok := c[strings.ToUpper(symbol)]; ok {
+		return class
+	}
+	return CoinClassVolatile
+}
+
+// Stablecoins lists the symbols classified as stablecoins, sorted
+func (c CoinClassification) Stablecoins() []string {
+	symbols := []string{}
+	for symbol, class := range c {
+		if class == CoinClassStablecoin {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// StablecoinsAsCash moves crypto holdings in stablecoins to the cash asset
+// class, leaving the values passed in unchanged
+func StablecoinsAsCash(values []HoldingValue, coins CoinClassification) []HoldingValue {
+	moved := make([]HoldingValue, len(values))
+	for i, v := range values {
+		if v.HoldingType == HoldingTypeCrypto && coins.Class(v.Name) == CoinClassStablecoin {
+			v.Component = "cash_holdings"
+		}
+		moved[i] = v
+	}
+	return moved
+}
+
+// NetWorthComponent is one asset class in the net worth breakdown
+type NetWorthComponent struct {
+	Key        string          `json:"key"`
+	Label      string          `json:"label"`
+	Value      decimal.Decimal `json:"value"`
+	Percentage float64         `json:"percentage"`
+}
+
+// PercentOf returns value as a percentage of total, or 0 when total is zero
+func PercentOf(value, total decimal.Decimal) float64 {
+	if total.IsZero() {
+		return 0
+	}
+	return value.Div(total).Mul(decimal.NewFromInt(100)).InexactFloat64()
+}
+
+// Components lists the asset classes counted in total assets with their share of it
+func (b NetWorthBreakdown) Components() []NetWorthComponent {
+	components := []NetWorthComponent{
+		{Key: "stock_holdings", Label: "Stocks", Value: b.StockHoldingsValue},
+		{Key: "vested_equity", Label: "Vested Equity", Value: b.VestedEquityValue},
+		{Key: "real_estate", Label: "Real Estate", Value: b.RealEstateEquity},
+		{Key: "cash_holdings", Label: "Cash", Value: b.CashHoldingsValue},
+		{Key: "crypto_holdings", Label: "Crypto", Value: b.CryptoHoldingsValue},
+		{Key: "other_assets", Label: "Other Assets", Value: b.OtherAssetsValue},
+		{Key: "private_investments", Label: "Private Investments", Value: b.PrivateInvestmentsValue},
+		{Key: "fixed_income", Label: "Fixed Income", Value: b.FixedIncomeValue},
+		{Key: "pensions", Label: "Pensions & Annuities", Value: b.PensionsValue},
+		{Key: "insurance", Label: "Insurance Cash Value", Value: b.InsuranceCashValue},
+	}
+
+	totalAssets := b.TotalAssets()
+	for i := range components {
+		components[i].Percentage = PercentOf(components[i].Value, totalAssets)
+	}
+	return components
+}
+
+// AddComponent adds value to the asset class named by key, one of the
+// Components keys or "unvested_equity", or to liabilities for "liabilities"
+func (b *NetWorthBreakdown) AddComponent(key string, value decimal.Decimal) {
+	switch key {
+	case "stock_holdings":
+		b.StockHoldingsValue = b.StockHoldingsValue.Add(value)
+	case "vested_equity":
+		b.VestedEquityValue = b.VestedEquityValue.Add(value)
+	case "unvested_equity":
+		b.UnvestedEquityValue = b.UnvestedEquityValue.Add(value)
+	case "real_estate":
+		b.RealEstateEquity = b.RealEstateEquity.Add(value)
+	case "cash_holdings":
+		b.CashHoldingsValue = b.CashHoldingsValue.Add(value)
+	case "crypto_holdings":
+		b.CryptoHoldingsValue = b.CryptoHoldingsValue.Add(value)
+	case "other_assets":
+		b.OtherAssetsValue = b.OtherAssetsValue.Add(value)
+	case "private_investments":
+		b.PrivateInvestmentsValue = b.PrivateInvestmentsValue.Add(value)
+	case "fixed_income":
+		b.FixedIncomeValue = b.FixedIncomeValue.Add(value)
+	case "pensions":
+		b.PensionsValue = b.PensionsValue.Add(value)
+	case "insurance":
+		b.InsuranceCashValue = b.InsuranceCashValue.Add(value)
+	case "liabilities":
+		b.TotalLiabilities = b.TotalLiabilities.Add(value)
+	}
+}
+
+// HoldingValue is what one holding contributes to one asset class, with the
+// institution and account it is held at, who owns that account (self, spouse
+// or a child by name) and whether it is custodial
+type HoldingValue struct {
+	HoldingRef
+	Component   string          `json:"component"`
+	Value       decimal.Decimal `json:"value"`
+	Institution string          `json:"institution"`
+	AccountID   *int            `json:"account_id"`
+	AccountName string          `json:"account_name"`
+	Owner       string          `json:"owner"`
+	Custodial   bool            `json:"custodial"`
+	Name        string          `json:"name"`
+	UpdatedAt   *time.Time      `json:"updated_at"`
+}
+
+// OwnerBreakdown is the net worth breakdown of one owner's custodial or
+// other accounts
+type OwnerBreakdown struct {
+	Owner     string
+	Custodial bool
+	Breakdown NetWorthBreakdown
+}
+
+// BreakdownHolding is one holding in the net worth breakdown tree
+type BreakdownHolding struct {
+	HoldingRef
+	Name       string          `json:"name"`
+	Value      decimal.Decimal `json:"value"`
+	Percentage float64         `json:"percentage"`
+}
+
+// BreakdownAccount groups the holdings of one account
+type BreakdownAccount struct {
+	AccountID  *int               `json:"account_id"`
+	Name       string             `json:"name"`
+	Value      decimal.Decimal    `json:"value"`
+	Percentage float64            `json:"percentage"`
+	Holdings   []BreakdownHolding `json:"holdings"`
+}
+
+// BreakdownInstitution groups the accounts held at one institution
+type BreakdownInstitution struct {
+	Name       string             `json:"name"`
+	Value      decimal.Decimal    `json:"value"`
+	Percentage float64            `json:"percentage"`
+	Accounts   []BreakdownAccount `json:"accounts"`
+}
+
+// BreakdownAssetClass is an asset class with the institutions holding it
+type BreakdownAssetClass struct {
+	NetWorthComponent
+	Institutions []BreakdownInstitution `json:"institutions"`
+}
+
+// UnspecifiedInstitution names the branch for holdings without an institution
+const UnspecifiedInstitution = "Unspecified"
+
+// NewBreakdownTree groups holding values by asset class, institution and
+// account. Asset classes come in Components order; everything below them is
+// largest first. Percentages are shares of total assets, so unvested equity
+// is left out as it is from the breakdown.
+func NewBreakdownTree(values []HoldingValue) []BreakdownAssetClass {
+	var breakdown NetWorthBreakdown
+	for _, v := range values {
+		breakdown.AddComponent(v.Component, v.Value)
+	}
+	totalAssets := breakdown.TotalAssets()
+	share := func(value decimal.Decimal) float64 {
+		return PercentOf(value, totalAssets)
+	}
+
+	components := breakdown.Components()
+	tree := make([]BreakdownAssetClass, len(components))
+	classIndex := make(map[string]int, len(components))
+	for i, component := range components {
+		tree[i] = BreakdownAssetClass{NetWorthComponent: component, Institutions: []BreakdownInstitution{}}
+		classIndex[component.Key] = i
+	}
+
+	for _, v := range values {
+		i, counted := classIndex[v.Component]
+		if !counted {
+			continue
+		}
+		name := v.Institution
+		if name == "" {
+			name = UnspecifiedInstitution
+		}
+
+		class := &tree[i]
+		institution := findInstitution(class, name)
+		institution.Value = institution.Value.Add(v.Value)
+		account := findAccount(institution, v.AccountID, v.AccountName)
+		account.Value = account.Value.Add(v.Value)
+		account.Holdings = append(account.Holdings, BreakdownHolding{
+			HoldingRef: v.HoldingRef, Name: v.Name, Value: v.Value, Percentage: share(v.Value),
+		})
+	}
+
+	for i := range tree {
+		institutions := tree[i].Institutions
+		sortByValue(institutions, func(n BreakdownInstitution) (decimal.Decimal, string) { return n.Value, n.Name })
+		for j := range institutions {
+			institutions[j].Percentage = share(institutions[j].Value)
+			accounts := institutions[j].Accounts
+			sortByValue(accounts, func(n BreakdownAccount) (decimal.Decimal, string) { return n.Value, n.Name })
+			for k := range accounts {
+				accounts[k].Percentage = share(accounts[k].Value)
+				sortByValue(accounts[k].Holdings, func(n BreakdownHolding) (decimal.Decimal, string) { return n.Value, n.Name })
+			}
+		}
+	}
+	return tree
+}
+
+func findInstitution(class *BreakdownAssetClass, name string) *BreakdownInstitution {
+	for i := range class.Institutions {
+		if class.Institutions[i].Name == name {
+			return &class.Institutions[i]
+		}
+	}
+	class.Institutions = append(class.Institutions, BreakdownInstitution{Name: name, Accounts: []BreakdownAccount{}})
+	return &class.Institutions[len(class.Institutions)-1]
+}
+
+func findAccount(institution *BreakdownInstitution, id *int, name string) *BreakdownAccount {
+	for i := range institution.Accounts {
+		account := &institution.Accounts[i]
+		if sameAccount(account.AccountID, id) {
+			return account
+		}
+	}
+	institution.Accounts = append(institution.Accounts, BreakdownAccount{AccountID: id, Name: name, Holdings: []BreakdownHolding{}})
+	return &institution.Accounts[len(institution.Accounts)-1]
+}
+
+func sameAccount(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// sortByValue orders nodes by value, largest first, then by name
+func sortByValue[T any](nodes []T, key func(T) (decimal.Decimal, string)) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		vi, ni := key(nodes[i])
+		vj, nj := key(nodes[j])
+		if !vi.Equal(vj) {
+			return vi.GreaterThan(vj)
+		}
+		return ni < nj
+	})
+}
+
+// InstitutionAccount is one account at an institution
+type InstitutionAccount struct {
+	AccountID    *int            `json:"account_id"`
+	Name         string          `json:"name"`
+	Value        decimal.Decimal `json:"value"`
+	HoldingCount int             `json:"holding_count"`
+	LastUpdated  *time.Time      `json:"last_updated"`
+}
+
+// InstitutionSummary totals the stocks, cash and crypto held at one institution
+type InstitutionSummary struct {
+	Name         string               `json:"name"`
+	TotalValue   decimal.Decimal      `json:"total_value"`
+	StocksValue  decimal.Decimal      `json:"stocks_value"`
+	CashValue    decimal.Decimal      `json:"cash_value"`
+	CryptoValue  decimal.Decimal      `json:"crypto_value"`
+	HoldingCount int                  `json:"holding_count"`
+	LastUpdated  *time.Time           `json:"last_updated"`
+	Accounts     []InstitutionAccount `json:"accounts"`
+}
+
+// NewInstitutionSummaries groups stock, cash and crypto holding values by
+// institution and account, largest first. Last updated is the most recent
+// change to any holding (or crypto price) under the node.
+func NewInstitutionSummaries(values []HoldingValue) []InstitutionSummary {
+	summaries := []InstitutionSummary{}
+	index := map[string]int{}
+
+	for _, v := range values {
+		switch v.HoldingType {
+		case HoldingTypeStock, HoldingTypeCash, HoldingTypeCrypto:
+		default:
+			continue
+		}
+		name := v.Institution
+		if name == "" {
+			name = UnspecifiedInstitution
+		}
+		i, found := index[name]
+		if !found {
+			i = len(summaries)
+			index[name] = i
+			summaries = append(summaries, InstitutionSummary{Name: name, Accounts: []InstitutionAccount{}})
+		}
+
+		summary := &summaries[i]
+		switch v.HoldingType {
+		case HoldingTypeStock:
+			summary.StocksValue = summary.StocksValue.Add(v.Value)
+		case HoldingTypeCash:
+			summary.CashValue = summary.CashValue.Add(v.Value)
+		case HoldingTypeCrypto:
+			summary.CryptoValue = summary.CryptoValue.Add(v.Value)
+		}
+		summary.TotalValue = summary.TotalValue.Add(v.Value)
+		summary.HoldingCount++
+		summary.LastUpdated = latest(summary.LastUpdated, v.UpdatedAt)
+
+		account := findInstitutionAccount(summary, v.AccountID, v.AccountName)
+		account.Value = account.Value.Add(v.Value)
+		account.HoldingCount++
+		account.LastUpdated = latest(account.LastUpdated, v.UpdatedAt)
+	}
+
+	sortByValue(summaries, func(n InstitutionSummary) (decimal.Decimal, string) { return n.TotalValue, n.Name })
+	for i := range summaries {
+		sortByValue(summaries[i].Accounts, func(n InstitutionAccount) (decimal.Decimal, string) { return n.Value, n.Name })
+	}
+	return summaries
+}
+
+func findInstitutionAccount(summary *InstitutionSummary, id *int, name string) *InstitutionAccount {
+	for i := range summary.Accounts {
+		if sameAccount(summary.Accounts[i].AccountID, id) {
+			return &summary.Accounts[i]
+		}
+	}
+	summary.Accounts = append(summary.Accounts, InstitutionAccount{AccountID: id, Name: name})
+	return &summary.Accounts[len(summary.Accounts)-1]
+}
+
+// latest returns the later of two optional times
+func latest(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// DashboardConfigVersion is the version of the dashboard layout schema
+const DashboardConfigVersion = 1
+
+// DashboardWidgetTypes lists the widgets a dashboard can show, each reporting
+// whether it takes a chart range
+var DashboardWidgetTypes = map[string]bool{
+	"net_worth":           false,
+	"total_assets":        false,
+	"stock_holdings":      false,
+	"vested_equity":       false,
+	"unvested_equity":     false,
+	"real_estate":         false,
+	"cash_holdings":       false,
+	"crypto_holdings":     false,
+	"other_assets":        false,
+	"private_investments": false,
+	"fixed_income":        false,
+	"pensions":            false,
+	"insurance":           false,
+	"net_worth_trend":     true,
+	"asset_allocation":    false,
+	"gains_history":       true,
+	"cash_flow":           true,
+	"passive_income":      false,
+	"goals":               false,
+	"concentration_risks": false,
+	"institutions":        false,
+	"recent_activity":     false,
+}
+
+// DashboardConfig is a dashboard layout: the widgets to show, in order
+type DashboardConfig struct {
+	Version int               `json:"version" binding:"required"`
+	Columns int               `json:"columns,omitempty" binding:"omitempty,min=1,max=4"`
+	Widgets []DashboardWidget `json:"widgets" binding:"required,max=50,dive"`
+}
+
+// DashboardWidget is one card or chart on the dashboard. Range applies to
+// chart widgets only.
+type DashboardWidget struct {
+	ID     string `json:"id" binding:"required,max=50"`
+	Type   string `json:"type" binding:"required"`
+	Size   string `json:"size,omitempty" binding:"omitempty,oneof=small medium large"`
+	Range  string `json:"range,omitempty" binding:"omitempty,oneof=1M 3M 6M 1Y YTD ALL"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// DefaultDashboardConfig is the layout shown until one is saved
+func DefaultDashboardConfig() DashboardConfig {
+	widgets := []DashboardWidget{}
+	for _, widgetType := range []string{
+		"total_assets", "vested_equity", "real_estate", "unvested_equity",
+		"cash_holdings", "crypto_holdings", "stock_holdings", "other_assets",
+	} {
+		widgets = append(widgets, DashboardWidget{ID: widgetType, Type: widgetType, Size: "small"})
+	}
+	widgets = append(widgets,
+		DashboardWidget{ID: "net_worth_trend", Type: "net_worth_trend", Size: "medium", Range: "1Y"},
+		DashboardWidget{ID: "asset_allocation", Type: "asset_allocation", Size: "medium"},
+		DashboardWidget{ID: "recent_activity", Type: "recent_activity", Size: "large"},
+	)
+	return DashboardConfig{Version: DashboardConfigVersion, Columns: 4, Widgets: widgets}
+}
+
+// SavedDashboardConfig is a stored layout; Default is set when none has been saved
+type SavedDashboardConfig struct {
+	Config    DashboardConfig `json:"config"`
+	Default   bool            `json:"default"`
+	UpdatedAt *time.Time      `json:"updated_at"`
+}
+
+// SavedViewFilters narrows a holding list. Every filter given must match.
+// Value is what the holding counts toward net worth; institution falls back
+// to the holding's account. Symbols apply to stocks, equity grants and
+// crypto, and sectors to stocks and equity grants.
+type SavedViewFilters struct {
+	Institution string   `json:"institution,omitempty" binding:"max=100"`
+	Symbols     []string `json:"symbols,omitempty" binding:"max=100,dive,required,max=20"`
+	Sectors     []string `json:"sectors,omitempty" binding:"max=50,dive,required,max=100"`
+	MinValue    *float64 `json:"min_value,omitempty"`
+	MaxValue    *float64 `json:"max_value,omitempty"`
+	Tags        []string `json:"tags,omitempty" binding:"max=50,dive,required,max=50"`
+	ExcludeTags []string `json:"exclude_tags,omitempty" binding:"max=50,dive,required,max=50"`
+}
+
+// SavedView is a named filter over one holding list
+type SavedView struct {
+	ID          int              `json:"id"`
+	Name        string           `json:"name"`
+	HoldingType string           `json:"holding_type"`
+	Filters     SavedViewFilters `json:"filters"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// SavedViewInput holds the writable fields of a saved view
+type SavedViewInput struct {
+	Name        string           `json:"name" binding:"required,max=100"`
+	HoldingType string           `json:"holding_type" binding:"required"`
+	Filters     SavedViewFilters `json:"filters"`
+}
+
+// GoalAssetClassNetWorth links a goal to total net worth rather than one asset class
+const GoalAssetClassNetWorth = "net_worth"
+
+// Goal progress statuses
+const (
+	GoalStatusAchieved     = "achieved"
+	GoalStatusOnTrack      = "on_track"
+	GoalStatusBehind       = "behind"
+	GoalStatusNoTargetDate = "no_target_date"
+)
+
+// Goal is a savings target tracked against linked asset classes and/or
+// specific cash and brokerage accounts
+type Goal struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	Description    *string         `json:"description"`
+	TargetAmount   decimal.Decimal `json:"target_amount"`
+	TargetDate     *time.Time      `json:"target_date"`
+	AssetClasses   []string        `json:"asset_classes"`
+	CashHoldingIDs []int           `json:"cash_holding_ids"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Progress       *GoalProgress   `json:"progress,omitempty"`
+}
+
+// GoalInput holds the writable fields of a goal. TargetDate is YYYY-MM-DD.
+type GoalInput struct {
+	Name           string          `json:"name" binding:"required,max=200"`
+	Description    *string         `json:"description"`
+	TargetAmount   decimal.Decimal `json:"target_amount" binding:"required,gt=0"`
+	TargetDate     *string         `json:"target_date"`
+	AssetClasses   []string        `json:"asset_classes"`
+	CashHoldingIDs []int           `json:"cash_holding_ids" binding:"dive,gt=0"`
+}
+
+// GoalProgress is how far a goal has come and whether recurring contributions
+// will reach it by the target date
+type GoalProgress struct {
+	CurrentAmount               decimal.Decimal  `json:"current_amount"`
+	MonthlyContribution         decimal.Decimal  `json:"monthly_contribution"`
+	PercentComplete             float64          `json:"percent_complete"`
+	RemainingAmount             decimal.Decimal  `json:"remaining_amount"`
+	Status                      string           `json:"status"`
+	MonthsRemaining             *int             `json:"months_remaining,omitempty"`
+	ProjectedAmount             *decimal.Decimal `json:"projected_amount,omitempty"`
+	RequiredMonthlyContribution *decimal.Decimal `json:"required_monthly_contribution,omitempty"`
+	ProjectedCompletionDate     *time.Time       `json:"projected_completion_date,omitempty"`
+}
+
+// ValidGoalAssetClass reports whether key is a net worth component key or net_worth
+func ValidGoalAssetClass(key string) bool {
+	if key == GoalAssetClassNetWorth {
+		return true
+	}
+	for _, component := range (NetWorthBreakdown{}).Components() {
+		if component.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// NewGoalProgress projects current plus monthly contributions (without growth)
+// to the target date. Without a target date only the completion date is estimated.
+func NewGoalProgress(target, current, monthly decimal.Decimal, targetDate *time.Time, now time.Time) GoalProgress {
+	p := GoalProgress{
+		CurrentAmount:       current,
+		MonthlyContribution: monthly,
+		RemainingAmount:     decimal.Max(target.Sub(current), decimal.Zero),
+	}
+	if target.IsPositive() {
+		p.PercentComplete = math.Min(PercentOf(current, target), 100)
+	}
+
+	if p.RemainingAmount.IsPositive() && monthly.IsPositive() {
+		completion := now.AddDate(0, int(p.RemainingAmount.Div(monthly).Ceil().IntPart()), 0)
+		completion = time.Date(completion.Year(), completion.Month(), completion.Day(), 0, 0, 0, 0, time.UTC)
+		p.ProjectedCompletionDate = &completion
+	}
+
+	if targetDate != nil {
+		months := (targetDate.Year()-now.Year())*12 + int(targetDate.Month()) - int(now.Month())
+		if targetDate.Day() < now.Day() {
+			months--
+		}
+		if months < 0 {
+			months = 0
+		}
+		projected := current.Add(monthly.Mul(decimal.NewFromInt(int64(months))))
+		required := p.RemainingAmount
+		if months > 0 {
+			// Round up to the cent so paying it every month reaches the target
+			required = p.RemainingAmount.Div(decimal.NewFromInt(int64(months))).RoundCeil(2)
+		}
+		p.MonthsRemaining = &months
+		p.ProjectedAmount = &projected
+		p.RequiredMonthlyContribution = &required
+	}
+
+	switch {
+	case p.RemainingAmount.IsZero():
+		p.Status = GoalStatusAchieved
+	case targetDate == nil:
+		p.Status = GoalStatusNoTargetDate
+	case p.ProjectedAmount.GreaterThanOrEqual(target):
+		p.Status = GoalStatusOnTrack
+	default:
+		p.Status = GoalStatusBehind
+	}
+	return p
+}
+
+// Cash-flow category kinds
+const (
+	CashFlowIncome  = "income"
+	CashFlowExpense = "expense"
+)
+
+// CashFlowCategory groups income or expense transactions
+type CashFlowCategory struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Color     *string   `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CashFlowCategoryInput holds the writable fields of a cash-flow category
+type CashFlowCategoryInput struct {
+	Name  string  `json:"name" binding:"required,max=100"`
+	Kind  string  `json:"kind" binding:"required,oneof=income expense"`
+	Color *string `json:"color"`
+}
+
+// CashFlowTransaction is a single income or expense. Amount is always positive;
+// the category's kind says which way the money moved.
+type CashFlowTransaction struct {
+	ID              int             `json:"id"`
+	CategoryID      int             `json:"category_id"`
+	CategoryName    string          `json:"category_name"`
+	Kind            string          `json:"kind"`
+	Amount          decimal.Decimal `json:"amount"`
+	TransactionDate time.Time       `json:"transaction_date"`
+	Description     *string         `json:"description"`
+	CashHoldingID   *int            `json:"cash_holding_id"`
+	Source          string          `json:"source"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// CashFlowTransactionInput holds the writable fields of a transaction.
+// TransactionDate is YYYY-MM-DD.
+type CashFlowTransactionInput struct {
+	CategoryID      int             `json:"category_id" binding:"required,gt=0"`
+	Amount          decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	TransactionDate string          `json:"transaction_date" binding:"required,datetime=2006-01-02"`
+	Description     *string         `json:"description"`
+	CashHoldingID   *int            `json:"cash_holding_id"`
+}
+
+// CashFlowImportRow is one parsed row of an imported statement
+type CashFlowImportRow struct {
+	Date         time.Time
+	Amount       decimal.Decimal
+	Kind         string
+	CategoryName string
+	Description  string
+	// ImportKey identifies the row so importing the same file twice adds nothing
+	ImportKey string
+}
+
+// CashFlowCategoryTotal is the amount spent or earned in one category
+type CashFlowCategoryTotal struct {
+	CategoryID   int             `json:"category_id"`
+	CategoryName string          `json:"category_name"`
+	Kind         string          `json:"kind"`
+	Amount       decimal.Decimal `json:"amount"`
+}
+
+// CashFlowMonth summarizes income and expenses for one calendar month
+type CashFlowMonth struct {
+	Month       string                  `json:"month"` // YYYY-MM
+	Income      decimal.Decimal         `json:"income"`
+	Expenses    decimal.Decimal         `json:"expenses"`
+	NetSavings  decimal.Decimal         `json:"net_savings"`
+	SavingsRate *float64                `json:"savings_rate"` // percent of income saved, nil without income
+	Categories  []CashFlowCategoryTotal `json:"categories"`
+}
+
+// CashFlowSummary summarizes income and expenses over a range of months
+type CashFlowSummary struct {
+	From                     string          `json:"from"`
+	To                       string          `json:"to"`
+	TotalIncome              decimal.Decimal `json:"total_income"`
+	TotalExpenses            decimal.Decimal `json:"total_expenses"`
+	NetSavings               decimal.Decimal `json:"net_savings"`
+	SavingsRate              *float64        `json:"savings_rate"`
+	AverageMonthlyNetSavings decimal.Decimal `json:"average_monthly_net_savings"`
+	Months                   []CashFlowMonth `json:"months"`
+}
+
+// SavingsRate returns the percentage of income saved, or nil without income
+func SavingsRate(income, expenses decimal.Decimal) *float64 {
+	if !income.IsPositive() {
+		return nil
+	}
+	rate := income.Sub(expenses).Div(income).Mul(decimal.NewFromInt(100)).InexactFloat64()
+	return &rate
+}
+
+// Property ledger entry kinds. Operating expenses count against net operating
+// income; debt service and money invested in the property do not.
+const (
+	PropertyLedgerIncome           = "income"
+	PropertyLedgerOperatingExpense = "operating_expense"
+	PropertyLedgerDebtService      = "debt_service"
+	PropertyLedgerInvestment       = "investment"
+)
+
+// PropertyLedgerCategories maps each property ledger category to its kind
+var PropertyLedgerCategories = map[string]string{
+	"rent":                PropertyLedgerIncome,
+	"other_income":        PropertyLedgerIncome,
+	"repairs":             PropertyLedgerOperatingExpense,
+	"maintenance":         PropertyLedgerOperatingExpense,
+	"insurance":           PropertyLedgerOperatingExpense,
+	"hoa":                 PropertyLedgerOperatingExpense,
+	"property_tax":        PropertyLedgerOperatingExpense,
+	"utilities":           PropertyLedgerOperatingExpense,
+	"management":          PropertyLedgerOperatingExpense,
+	"other_expense":       PropertyLedgerOperatingExpense,
+	"mortgage_payment":    PropertyLedgerDebtService,
+	"down_payment":        PropertyLedgerInvestment,
+	"closing_costs":       PropertyLedgerInvestment,
+	"capital_improvement": PropertyLedgerInvestment,
+}
+
+// PropertyLedgerEntry is rent received or money spent on a property. Amount is
+// always positive and for the whole property, regardless of ownership share.
+type PropertyLedgerEntry struct {
+	ID          int             `json:"id"`
+	PropertyID  int             `json:"property_id"`
+	Category    string          `json:"category"`
+	Kind        string          `json:"kind"`
+	Amount      decimal.Decimal `json:"amount"`
+	EntryDate   time.Time       `json:"entry_date"`
+	Description *string         `json:"description"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// PropertyLedgerEntryInput holds the writable fields of a ledger entry.
+// EntryDate is YYYY-MM-DD.
+type PropertyLedgerEntryInput struct {
+	PropertyID  int             `json:"property_id" binding:"required,gt=0"`
+	Category    string          `json:"category" binding:"required"`
+	Amount      decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	EntryDate   string          `json:"entry_date" binding:"required,datetime=2006-01-02"`
+	Description *string         `json:"description"`
+}
+
+// PropertyCashFlowMonth totals a property's ledger for one calendar month
+type PropertyCashFlowMonth struct {
+	Month              string          `json:"month"` // YYYY-MM
+	Income             decimal.Decimal `json:"income"`
+	OperatingExpenses  decimal.Decimal `json:"operating_expenses"`
+	NetOperatingIncome decimal.Decimal `json:"net_operating_income"`
+	DebtService        decimal.Decimal `json:"debt_service"`
+	CashFlow           decimal.Decimal `json:"cash_flow"`
+}
+
+// PropertyCashFlow summarizes a property's ledger over a date range. Returns
+// are annualized from the range, so a quarter of entries gives a yearly rate.
+type PropertyCashFlow struct {
+	PropertyID         int                        `json:"property_id"`
+	PropertyName       string                     `json:"property_name"`
+	From               string                     `json:"from"`
+	To                 string                     `json:"to"`
+	Income             decimal.Decimal            `json:"income"`
+	IncomeByCategory   map[string]decimal.Decimal `json:"income_by_category"`
+	OperatingExpenses  decimal.Decimal            `json:"operating_expenses"`
+	ExpensesByCategory map[string]decimal.Decimal `json:"expenses_by_category"`
+	NetOperatingIncome decimal.Decimal            `json:"net_operating_income"`
+	DebtService        decimal.Decimal            `json:"debt_service"`
+	CashFlow           decimal.Decimal            `json:"cash_flow"`
+	AnnualizedNOI      decimal.Decimal            `json:"annualized_noi"`
+	AnnualizedCashFlow decimal.Decimal            `json:"annualized_cash_flow"`
+	CurrentValue       decimal.Decimal            `json:"current_value"`
+	CapRate            *float64                   `json:"cap_rate"` // percent, nil without a current value
+	CashInvested       *decimal.Decimal           `json:"cash_invested"`
+	// CashInvestedEstimated is set when no down payment, closing cost or
+	// improvement entries exist and purchase price less mortgage stands in
+	CashInvestedEstimated bool     `json:"cash_invested_estimated"`
+	CashOnCashReturn      *float64 `json:"cash_on_cash_return"` // percent, nil without cash invested
+	OwnershipPercentage   float64  `json:"ownership_percentage"`
+	// Owner's share of the totals above, scaled by OwnershipPercentage. The
+	// returns are ratios, so they are the same for any share.
+	OwnedIncome             decimal.Decimal         `json:"owned_income"`
+	OwnedOperatingExpenses  decimal.Decimal         `json:"owned_operating_expenses"`
+	OwnedNetOperatingIncome decimal.Decimal         `json:"owned_net_operating_income"`
+	OwnedDebtService        decimal.Decimal         `json:"owned_debt_service"`
+	OwnedCashFlow           decimal.Decimal         `json:"owned_cash_flow"`
+	OwnedAnnualizedNOI      decimal.Decimal         `json:"owned_annualized_noi"`
+	OwnedAnnualizedCashFlow decimal.Decimal         `json:"owned_annualized_cash_flow"`
+	OwnedCashInvested       *decimal.Decimal        `json:"owned_cash_invested"`
+	Months                  []PropertyCashFlowMonth `json:"months"`
+}
+
+// ApplyOwnership fills in the owner's share of the cash flow totals, given
+// the property's ownership percentage
+func (f *PropertyCashFlow) ApplyOwnership(ownershipPercentage float64) {
+	share := decimal.NewFromFloat(ownershipPercentage).Div(decimal.NewFromInt(100))
+	f.OwnershipPercentage = ownershipPercentage
+	f.OwnedIncome = f.Income.Mul(share)
+	f.OwnedOperatingExpenses = f.OperatingExpenses.Mul(share)
+	f.OwnedNetOperatingIncome = f.NetOperatingIncome.Mul(share)
+	f.OwnedDebtService = f.DebtService.Mul(share)
+	f.OwnedCashFlow = f.CashFlow.Mul(share)
+	f.OwnedAnnualizedNOI = f.AnnualizedNOI.Mul(share)
+	f.OwnedAnnualizedCashFlow = f.AnnualizedCashFlow.Mul(share)
+	f.OwnedCashInvested = nil
+	if f.CashInvested != nil {
+		invested := f.CashInvested.Mul(share)
+		f.OwnedCashInvested = &invested
+	}
+}
+
+// SecurityMetadata classifies a symbol for exposure analytics. Source is
+// "manual", "static" (built-in dataset) or the provider it was fetched from.
+type SecurityMetadata struct {
+	Symbol    string     `json:"symbol"`
+	Name      *string    `json:"name"`
+	Sector    *string    `json:"sector"`
+	Industry  *string    `json:"industry"`
+	Country   *string    `json:"country"`
+	AssetType *string    `json:"asset_type"`
+	Source    string     `json:"source"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// SecurityMetadataInput holds the writable fields of a manual metadata override
+type SecurityMetadataInput struct {
+	Name      *string `json:"name" binding:"omitempty,max=200"`
+	Sector    *string `json:"sector" binding:"omitempty,max=100"`
+	Industry  *string `json:"industry" binding:"omitempty,max=150"`
+	Country   *string `json:"country" binding:"omitempty,max=100"`
+	AssetType *string `json:"asset_type" binding:"omitempty,max=50"`
+}
+
+// FundComposition breaks a fund down for look-through exposure. Weights are
+// fractions of the fund. A fund of funds, such as a target-date fund, lists
+// its underlying Funds instead of sectors and countries. Source is "static"
+// (built-in dataset) or the provider it was fetched from.
+type FundComposition struct {
+	Symbol      string             `json:"symbol"`
+	Sectors     map[string]float64 `json:"sectors,omitempty"`
+	Countries   map[string]float64 `json:"countries,omitempty"`
+	Funds       map[string]float64 `json:"funds,omitempty"`
+	TopHoldings []FundHolding      `json:"top_holdings,omitempty"`
+	Source      string             `json:"source"`
+	UpdatedAt   *time.Time         `json:"updated_at"`
+}
+
+// FundHolding is one of a fund's largest holdings
+type FundHolding struct {
+	Symbol string  `json:"symbol"`
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// Holding types that can be tagged, named like their audit entity types
+const (
+	HoldingTypeStock             = "stock_holding"
+	HoldingTypeEquityGrant       = "equity_grant"
+	HoldingTypeRealEstate        = "real_estate"
+	HoldingTypeCash              = "cash_holding"
+	HoldingTypeCrypto            = "crypto_holding"
+	HoldingTypeOtherAsset        = "other_asset"
+	HoldingTypePrivateInvestment = "private_investment"
+	HoldingTypeBond              = "bond"
+	HoldingTypeIBond             = "i_bond"
+	HoldingTypePension           = "pension"
+	HoldingTypeInsurancePolicy   = "insurance_policy"
+	HoldingTypeLiability         = "liability"
+)
+
+// Attachment kinds
+const (
+	AttachmentKindNote = "note"
+	AttachmentKindFile = "file"
+)
+
+// Attachment is a note or file attached to a holding, such as an appraisal
+// PDF on a property or a grant letter on an equity grant
+type Attachment struct {
+	ID          int       `json:"id"`
+	EntityType  string    `json:"entity_type"` // one of the HoldingType constants
+	EntityID    int       `json:"entity_id"`
+	Kind        string    `json:"kind"`
+	Note        *string   `json:"note"`
+	FileName    *string   `json:"file_name,omitempty"`
+	ContentType *string   `json:"content_type,omitempty"`
+	SizeBytes   *int64    `json:"size_bytes,omitempty"`
+	StorageKey  *string   `json:"-"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SearchResult is a holding matched by a full-text search
+type SearchResult struct {
+	Type     string  `json:"type"` // one of the HoldingType constants
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	Link     string  `json:"link"` // page showing the holding
+	Rank     float64 `json:"rank"`
+}
+
+// Tag is a user-defined label for holdings, such as "ESG" or "speculative"
+type Tag struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Color        *string   `json:"color"`
+	Description  *string   `json:"description"`
+	HoldingCount int       `json:"holding_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TagInput holds the writable fields of a tag
+type TagInput struct {
+	Name        string  `json:"name" binding:"required,max=50"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+// HoldingRef identifies a holding of any type
+type HoldingRef struct {
+	HoldingType string `json:"holding_type" binding:"required"`
+	HoldingID   int    `json:"holding_id" binding:"required,gt=0"`
+}
+
+// MergeInput names two duplicate records to merge: the source is folded into
+// the target and deleted. HoldingType is only used when merging holdings.
+type MergeInput struct {
+	HoldingType string `json:"holding_type"`
+	TargetID    int    `json:"target_id" binding:"required,gt=0"`
+	SourceID    int    `json:"source_id" binding:"required,gt=0"`
+}
+
+// HoldingTag is a tag attached to a holding
+type HoldingTag struct {
+	HoldingRef
+	TagID     int       `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Household is the people whose net worth the dashboard tracks
+type Household struct {
+	Name    string            `json:"name"`
+	Members []HouseholdMember `json:"members"`
+}
+
+// HouseholdMember is a person holdings are attributed to, optionally linked to
+// the user who signs in as them
+type HouseholdMember struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	UserID    *int      `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HouseholdMemberInput holds the writable fields of a household member
+type HouseholdMemberInput struct {
+	Name   string `json:"name" binding:"required,max=100"`
+	UserID *int   `json:"user_id" binding:"omitempty,gt=0"`
+}
+
+// OwnershipShare is the percentage of a holding one member owns
+type OwnershipShare struct {
+	MemberID   int     `json:"member_id" binding:"required,gt=0"`
+	Percentage float64 `json:"percentage" binding:"gt=0,lte=100"`
+}
+
+// HoldingOwnership is who owns a holding. Shares lists the percentages set
+// for it; Effective adds the unassigned remainder split equally between all
+// members, which is what net worth views use.
+type HoldingOwnership struct {
+	HoldingRef
+	Shares     []OwnershipShare `json:"shares"`
+	Effective  []OwnershipShare `json:"effective"`
+	Unassigned float64          `json:"unassigned_percentage"`
+}
+
 type AccountSummary struct {
 	Account Account        `json:"account"`
 	Balance AccountBalance `json:"balance"`
 }
 
 type StockConsolidation struct {
-	Symbol          string  `json:"symbol"`
-	CompanyName     string  `json:"company_name"`
-	TotalShares     float64 `json:"total_shares"`
-	TotalValue      float64 `json:"total_value"`
-	CurrentPrice    float64 `json:"current_price"`
-	UnrealizedGains float64 `json:"unrealized_gains"`
-	Sources         []StockHolding `json:"sources"`
-}
\ No newline at end of file
+	Symbol          string        `json:"symbol"`
+	CompanyName     string        `json:"company_name"`
+	TotalShares     float64       `json:"total_shares"`
+	TotalValue      float64       `json:"total_value"`
+	CurrentPrice    float64       `json:"current_price"`
+	UnrealizedGains float64       `json:"unrealized_gains"`
+	Sources         []StockSource `json:"sources"`
+}
+
+// StockSource is a single holding or vested grant contributing to a consolidated position
+type StockSource struct {
+	ID           int       `json:"id"`
+	AccountID    int       `json:"account_id"`
+	Symbol       string    `json:"symbol"`
+	CompanyName  string    `json:"company_name"`
+	SharesOwned  float64   `json:"shares_owned"`
+	CostBasis    *float64  `json:"cost_basis"`
+	CurrentPrice float64   `json:"current_price"`
+	MarketValue  float64   `json:"market_value"`
+	DataSource   string    `json:"data_source"`
+	SourceType   string    `json:"source_type"`
+	GrantType    *string   `json:"grant_type"`
+	CreatedAt    time.Time `json:"created_at"`
+}
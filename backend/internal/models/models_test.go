@@ -0,0 +1,447 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMain(m *testing.M) {
+	// As main does, so amounts encode as JSON numbers
+	decimal.MarshalJSONWithoutQuotes = true
+	os.Exit(m.Run())
+}
+
+// amount parses a decimal, failing t if it is malformed
+func amount(t *testing.T, value string) decimal.Decimal {
+	t.Helper()
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		t.Fatalf("bad amount %q: %v", value, err)
+	}
+	return d
+}
+
+func TestApplyOwnership(t *testing.T) {
+	income := amount(t, "2450.55")
+	tax := amount(t, "8123.41")
+	property := RealEstate{
+		CurrentValue:        amount(t, "1234567.89"),
+		OutstandingMortgage: amount(t, "456789.01"),
+		Equity:              amount(t, "777778.88"),
+		RentalIncomeMonthly: &income,
+		PropertyTaxAnnual:   &tax,
+		OwnershipPercentage: 33.33,
+	}
+	property.ApplyOwnership()
+
+	checks := []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		{"owned value", property.OwnedValue, "411481.477737"},
+		{"owned mortgage", property.OwnedMortgage, "152247.777033"},
+		{"owned equity", property.OwnedEquity, "259233.700704"},
+		{"owned rental income", *property.OwnedRentalIncomeMonthly, "816.768315"},
+		{"owned property tax", *property.OwnedPropertyTaxAnnual, "2707.532553"},
+	}
+	for _, c := range checks {
+		if !c.got.Equal(amount(t, c.want)) {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+	// Shares of value and mortgage still net to the share of equity
+	if !property.OwnedValue.Sub(property.OwnedMortgage).Equal(property.OwnedEquity) {
+		t.Errorf("owned value %s less owned mortgage %s is not owned equity %s",
+			property.OwnedValue, property.OwnedMortgage, property.OwnedEquity)
+	}
+}
+
+func TestMoneySumsExactly(t *testing.T) {
+	// A thousand 10 cent balances sum to exactly $100; as float64 they
+	// come to 99.9999999999986
+	var total decimal.Decimal
+	balance := amount(t, "0.10")
+	for range 1000 {
+		total = total.Add(CashHolding{CurrentBalance: balance}.CurrentBalance)
+	}
+	if !total.Equal(amount(t, "100")) {
+		t.Errorf("total = %s, want 100", total)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	created := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	contribution := amount(t, "250.00")
+	price := amount(t, "0.00001234")
+	costBasis := amount(t, "187.654321")
+	currentPrice := amount(t, "287.654320")
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name: "cash holding",
+			value: CashHolding{
+				ID: 1, AccountID: 2, InstitutionName: "Chase", AccountName: "Savings", AccountType: "savings",
+				CurrentBalance: amount(t, "12345678901.23"), MonthlyContribution: &contribution,
+				Currency: "USD", CreatedAt: created, UpdatedAt: created,
+			},
+			want: `{"id":1,"account_id":2,"institution_name":"Chase","account_name":"Savings","account_type":"savings",` +
+				`"current_balance":12345678901.23,"interest_rate":null,"monthly_contribution":250,"account_number_last4":null,` +
+				`"currency":"USD","notes":null,"created_at":"2026-10-16T12:00:00Z","updated_at":"2026-10-16T12:00:00Z"}`,
+		},
+		{
+			name: "crypto holding",
+			value: CryptoHolding{
+				ID: 3, AccountID: 2, InstitutionName: "Coinbase", CryptoSymbol: "SHIB", BalanceTokens: 1000000,
+				PurchasePriceUSD: &price, LiquidTokens: 1000000, CreatedAt: created, UpdatedAt: created,
+			},
+			want: `{"id":3,"account_id":2,"institution_name":"Coinbase","crypto_symbol":"SHIB","balance_tokens":1000000,` +
+				`"purchase_price_usd":0.00001234,"purchase_date":null,"wallet_address":null,"notes":null,` +
+				`"staking_annual_percentage":null,"staked_tokens":0,"liquid_tokens":1000000,` +
+				`"created_at":"2026-10-16T12:00:00Z","updated_at":"2026-10-16T12:00:00Z","current_price_usd":null,` +
+				`"current_price_btc":null,"current_value_usd":null,"price_change_24h":null,"price_last_updated":null}`,
+		},
+		{
+			name:  "cash-flow category total",
+			value: CashFlowCategoryTotal{CategoryID: 4, CategoryName: "Groceries", Kind: CashFlowExpense, Amount: amount(t, "0.30")},
+			want:  `{"category_id":4,"category_name":"Groceries","kind":"expense","amount":0.3}`,
+		},
+		{
+			name: "stock holding",
+			value: StockHolding{
+				ID: 5, AccountID: 2, Symbol: "VTI", SharesOwned: 10000, CostBasis: &costBasis, CurrentPrice: &currentPrice,
+				MarketValue: amount(t, "2876543.20"), InstitutionName: "Fidelity", DataSource: "manual", CreatedAt: created,
+			},
+			want: `{"id":5,"account_id":2,"symbol":"VTI","company_name":null,"exchange":null,"security_type":null,` +
+				`"shares_owned":10000,"cost_basis":187.654321,"current_price":287.65432,"market_value":2876543.2,` +
+				`"institution_name":"Fidelity","data_source":"manual","estimated_quarterly_dividend":null,"purchase_date":null,` +
+				`"drip_enabled":null,"last_manual_update":null,"is_vested_equity":false,"created_at":"2026-10-16T12:00:00Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.value)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s JSON =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+
+	// Amounts read back without loss, including from strings
+	var holding CashHolding
+	if err := json.Unmarshal([]byte(`{"current_balance": 0.1, "monthly_contribution": "0.2"}`), &holding); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if sum := holding.CurrentBalance.Add(*holding.MonthlyContribution); !sum.Equal(amount(t, "0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", sum)
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPensionPresentValue(t *testing.T) {
+	tests := []struct {
+		name                   string
+		benefit                float64
+		start, end, asOf       time.Time
+		colaRate, discountRate float64
+		want                   float64
+	}{
+		{
+			name:    "undiscounted year of payments",
+			benefit: 1000, start: date(2026, 1, 1), end: date(2027, 1, 1), asOf: date(2026, 1, 1),
+			want: 12000,
+		},
+		{
+			name:    "payment a year out is discounted by days over 365.25",
+			benefit: 1000, start: date(2027, 1, 1), end: date(2027, 2, 1), asOf: date(2026, 1, 1),
+			discountRate: 5,
+			want:         math.Round(1000/math.Pow(1.05, 365/365.25)*100) / 100,
+		},
+		{
+			name:    "cost of living raise after every 12 payments",
+			benefit: 1000, start: date(2026, 1, 1), end: date(2028, 1, 1), asOf: date(2026, 1, 1),
+			colaRate: 12,
+			want:     12*1000 + 12*1120,
+		},
+		{
+			name:    "future start is discounted and not yet raised",
+			benefit: 1000, start: date(2036, 1, 1), end: date(2036, 2, 1), asOf: date(2026, 1, 1),
+			colaRate: 3, discountRate: 4,
+			want: math.Round(1000/math.Pow(1.04, 3652/365.25)*100) / 100,
+		},
+		{
+			name:    "payments already made are skipped and count toward raises",
+			benefit: 1000, start: date(2020, 1, 15), end: date(2026, 4, 15), asOf: date(2026, 1, 20),
+			colaRate: 2,
+			// 73 payments made by Jan 20, 2026; Feb 15 and Mar 15 remain
+			want: math.Round(2*1000*math.Pow(1.02, 6)*100) / 100,
+		},
+		{
+			name:    "payment due today is still to come",
+			benefit: 500, start: date(2025, 6, 10), end: date(2026, 1, 1), asOf: date(2025, 12, 10),
+			want: 500,
+		},
+		{
+			name:    "end in the past",
+			benefit: 1000, start: date(2000, 1, 1), end: date(2020, 1, 1), asOf: date(2026, 1, 1),
+			colaRate: 2, discountRate: 4,
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PensionPresentValue(tt.benefit, tt.start, tt.end, tt.colaRate, tt.discountRate, tt.asOf)
+			if math.Abs(got-tt.want) > 0.005 {
+				t.Errorf("PensionPresentValue = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPensionPaymentsMade(t *testing.T) {
+	tests := []struct {
+		start, now time.Time
+		want       int
+	}{
+		{date(2026, 3, 1), date(2026, 1, 1), 0},
+		{date(2026, 1, 1), date(2026, 1, 1), 0},
+		{date(2026, 1, 1), date(2026, 1, 2), 1},
+		{date(2026, 1, 15), date(2026, 3, 15), 2},
+		{date(2026, 1, 15), date(2026, 3, 16), 3},
+		// The January 31 payment falls on February 28
+		{date(2026, 1, 31), date(2026, 2, 28), 1},
+		{date(2026, 1, 31), date(2026, 3, 1), 2},
+	}
+	for _, tt := range tests {
+		if got := pensionPaymentsMade(tt.start, tt.now); got != tt.want {
+			t.Errorf("pensionPaymentsMade(%s, %s) = %d, want %d", tt.start.Format("2006-01-02"), tt.now.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestPensionValue(t *testing.T) {
+	p := Pension{
+		BirthDate: date(1960, 1, 31), MonthlyBenefit: amount(t, "2000"),
+		StartAge: 65.5, EndAge: 90, ColaRate: 2, DiscountRate: 4,
+	}
+	now := date(2026, 9, 15)
+	p.Value(now)
+
+	// Born on the 31st, payments start on the last day of July
+	if !p.StartDate.Equal(date(2025, 7, 31)) || !p.EndDate.Equal(date(2050, 1, 31)) {
+		t.Errorf("dates = %s to %s, want 2025-07-31 to 2050-01-31", p.StartDate.Format("2006-01-02"), p.EndDate.Format("2006-01-02"))
+	}
+	if !p.InPayment {
+		t.Error("pension started in 2025 is not in payment")
+	}
+	// 14 payments made by September 15, 2026, so one raise
+	if want := amount(t, "2040"); !p.CurrentMonthlyBenefit.Equal(want) {
+		t.Errorf("current benefit = %s, want %s", p.CurrentMonthlyBenefit, want)
+	}
+	want := PensionPresentValue(2000, p.StartDate, p.EndDate, 2, 4, now)
+	if got := p.PresentValue.InexactFloat64(); got != want || want == 0 {
+		t.Errorf("present value = %v, want %v", got, want)
+	}
+}
+
+func TestBondAccruedInterest(t *testing.T) {
+	tests := []struct {
+		name           string
+		coupon         float64
+		frequency      int
+		maturity, asOf time.Time
+		want           string
+	}{
+		{
+			// 31 of the 181 days from Jan 15 to Jul 15 on a $212.50 coupon
+			name:   "semiannual coupon part way through a period",
+			coupon: 4.25, frequency: 2, maturity: date(2030, 7, 15), asOf: date(2026, 2, 15),
+			want: "36.40",
+		},
+		{
+			name:   "coupon paid today",
+			coupon: 4.25, frequency: 2, maturity: date(2030, 7, 15), asOf: date(2026, 7, 15),
+			want: "0",
+		},
+		{
+			// Counted back from January 31, coupons fall on February 28
+			// and March 31, so 14 of 31 days on a $50 coupon
+			name:   "monthly coupon from the end of a short month",
+			coupon: 6, frequency: 12, maturity: date(2027, 1, 31), asOf: date(2026, 3, 14),
+			want: "22.58",
+		},
+		{
+			name:   "zero coupon",
+			coupon: 0, frequency: 0, maturity: date(2030, 7, 15), asOf: date(2026, 2, 15),
+			want: "0",
+		},
+		{
+			name:   "matured",
+			coupon: 4.25, frequency: 2, maturity: date(2026, 1, 15), asOf: date(2026, 2, 15),
+			want: "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BondAccruedInterest(amount(t, "10000"), tt.coupon, tt.frequency, tt.maturity, tt.asOf)
+			if want := amount(t, tt.want); !got.Equal(want) {
+				t.Errorf("BondAccruedInterest = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestBondValue(t *testing.T) {
+	price := amount(t, "101.25")
+	b := Bond{
+		FaceValue: amount(t, "10000"), CouponRate: 4.25, CouponFrequency: 2,
+		MaturityDate: date(2030, 7, 15), PurchasePrice: amount(t, "98.5"), CurrentPrice: &price,
+	}
+	b.Value(time.Date(2026, 2, 15, 18, 30, 0, 0, time.UTC))
+	if want := amount(t, "10125"); !b.MarketValue.Equal(want) {
+		t.Errorf("market value = %s, want %s", b.MarketValue, want)
+	}
+	if want := amount(t, "10161.40"); !b.CurrentValue.Equal(want) {
+		t.Errorf("current value = %s, want %s", b.CurrentValue, want)
+	}
+
+	// Without a current price the purchase price is used, and a matured bond
+	// counts at face value
+	b.CurrentPrice = nil
+	b.Value(date(2026, 2, 15))
+	if want := amount(t, "9886.40"); !b.CurrentValue.Equal(want) {
+		t.Errorf("current value at purchase price = %s, want %s", b.CurrentValue, want)
+	}
+	b.Value(date(2030, 7, 15))
+	if !b.CurrentValue.Equal(b.FaceValue) || !b.AccruedInterest.IsZero() {
+		t.Errorf("matured bond = %s with %s accrued, want face value", b.CurrentValue, b.AccruedInterest)
+	}
+}
+
+func TestIBondCompositeRate(t *testing.T) {
+	tests := []struct {
+		fixed, inflation, want float64
+	}{
+		// November 2023: 1.30% fixed and 1.97% semiannual inflation
+		{1.3, 1.97, 5.27},
+		{0.5, 1.5, 3.51},
+		// Deflation can't take the composite rate below zero
+		{0, -1.5, 0},
+	}
+	for _, tt := range tests {
+		if got := IBondCompositeRate(tt.fixed, tt.inflation); got != tt.want {
+			t.Errorf("IBondCompositeRate(%v, %v) = %v, want %v", tt.fixed, tt.inflation, got, tt.want)
+		}
+	}
+}
+
+// iBondRates2024 are the inflation rates announced from November 2023 to May 2024
+var iBondRates2024 = IBondRates{
+	{EffectiveDate: date(2024, 5, 1), InflationRate: 1.48},
+	{EffectiveDate: date(2023, 11, 1), InflationRate: 1.97},
+}
+
+func TestIBondRatesInflationRate(t *testing.T) {
+	tests := []struct {
+		periodStart time.Time
+		want        float64
+	}{
+		{date(2023, 10, 1), 0},
+		{date(2023, 11, 1), 1.97},
+		{date(2024, 1, 1), 1.97},
+		{date(2024, 7, 1), 1.48},
+	}
+	for _, tt := range tests {
+		if got := iBondRates2024.InflationRate(tt.periodStart); got != tt.want {
+			t.Errorf("InflationRate(%s) = %v, want %v", tt.periodStart.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestIBondValue(t *testing.T) {
+	tests := []struct {
+		name string
+		asOf time.Time
+		want string
+	}{
+		{"not yet issued", date(2023, 12, 15), "10000"},
+		// A $25 bond earns half of 5.27% in its first six months, $25.66
+		{"first period", date(2024, 7, 1), "10264"},
+		// then a third of a period at 4.28% to $25.84
+		{"part of the second period", date(2024, 9, 15), "10336"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IBondValue(amount(t, "10000"), date(2024, 1, 1), 1.3, tt.asOf, iBondRates2024)
+			if want := amount(t, tt.want); !got.Equal(want) {
+				t.Errorf("IBondValue = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestIBondValueSetsRedemption(t *testing.T) {
+	b := IBond{IssueDate: date(2024, 1, 1), PurchaseAmount: amount(t, "10000"), FixedRate: 1.3}
+	b.Value(time.Date(2024, 9, 15, 18, 30, 0, 0, time.UTC), iBondRates2024)
+
+	if b.MonthsHeld != 8 || b.CompositeRate != 4.28 {
+		t.Errorf("months held %d at %v%%, want 8 at 4.28%%", b.MonthsHeld, b.CompositeRate)
+	}
+	if b.NextRateChange == nil || !b.NextRateChange.Equal(date(2025, 1, 1)) {
+		t.Errorf("next rate change = %v, want 2025-01-01", b.NextRateChange)
+	}
+	// Under five years, cashing in pays the value three months earlier
+	if want := amount(t, "10220"); !b.RedemptionValue.Equal(want) {
+		t.Errorf("redemption value = %s, want %s", b.RedemptionValue, want)
+	}
+	if want := amount(t, "116"); !b.Penalty.Equal(want) || b.Redeemable {
+		t.Errorf("penalty = %s (redeemable %v), want %s and not redeemable", b.Penalty, b.Redeemable, want)
+	}
+
+	// After 30 years the bond stops earning and has no next rate change
+	b.Value(date(2054, 3, 1), iBondRates2024)
+	if b.CompositeRate != 0 || b.NextRateChange != nil || !b.Penalty.IsZero() || !b.Redeemable {
+		t.Errorf("matured I bond = %+v", b)
+	}
+}
+
+func TestNewGoalProgress(t *testing.T) {
+	now := date(2026, 10, 17)
+	targetDate := date(2027, 6, 15)
+	p := NewGoalProgress(amount(t, "10000"), amount(t, "2500.10"), amount(t, "500"), &targetDate, now)
+
+	if p.MonthsRemaining == nil || *p.MonthsRemaining != 7 {
+		t.Fatalf("months remaining = %v, want 7", p.MonthsRemaining)
+	}
+	if want := amount(t, "7499.90"); !p.RemainingAmount.Equal(want) {
+		t.Errorf("remaining = %s, want %s", p.RemainingAmount, want)
+	}
+	if want := amount(t, "6000.10"); !p.ProjectedAmount.Equal(want) || p.Status != GoalStatusBehind {
+		t.Errorf("projected = %s (%s), want %s and behind", p.ProjectedAmount, p.Status, want)
+	}
+	// Rounded up so seven payments of it reach the target
+	if want := amount(t, "1071.42"); !p.RequiredMonthlyContribution.Equal(want) {
+		t.Errorf("required monthly = %s, want %s", p.RequiredMonthlyContribution, want)
+	}
+	if p.ProjectedCompletionDate == nil || !p.ProjectedCompletionDate.Equal(date(2028, 1, 17)) {
+		t.Errorf("projected completion = %v, want 2028-01-17", p.ProjectedCompletionDate)
+	}
+
+	p = NewGoalProgress(amount(t, "10000"), amount(t, "12000"), decimal.Zero, nil, now)
+	if !p.RemainingAmount.IsZero() || p.PercentComplete != 100 || p.Status != GoalStatusAchieved {
+		t.Errorf("achieved goal = %+v", p)
+	}
+}
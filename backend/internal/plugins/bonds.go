@@ -0,0 +1,527 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bond types
+var bondTypes = []FieldOption{
+	{Value: "treasury", Label: "US Treasury"},
+	{Value: "agency", Label: "Agency"},
+	{Value: "municipal", Label: "Municipal"},
+	{Value: "corporate", Label: "Corporate"},
+	{Value: "cd", Label: "Certificate of Deposit"},
+	{Value: "other", Label: "Other"},
+}
+
+// Coupon frequencies and the payments a year each makes
+var couponFrequencies = []FieldOption{
+	{Value: "semiannual", Label: "Semiannual"},
+	{Value: "annual", Label: "Annual"},
+	{Value: "quarterly", Label: "Quarterly"},
+	{Value: "monthly", Label: "Monthly"},
+	{Value: "zero_coupon", Label: "Zero Coupon / Discount"},
+}
+
+var couponPaymentsPerYear = map[string]int{
+	"annual":      1,
+	"semiannual":  2,
+	"quarterly":   4,
+	"monthly":     12,
+	"zero_coupon": 0,
+}
+
+// BondsPlugin handles manual entry for bonds, treasuries and CDs, identified
+// by CUSIP when one is given. Bonds count toward net worth as fixed income.
+type BondsPlugin struct {
+	db          *sql.DB
+	name        string
+	lastUpdated time.Time
+}
+
+// NewBondsPlugin creates a new Bonds plugin
+func NewBondsPlugin(db *sql.DB) *BondsPlugin {
+	return &BondsPlugin{
+		db:   db,
+		name: "bonds",
+	}
+}
+
+// GetName returns the plugin name
+func (p *BondsPlugin) GetName() string {
+	return p.name
+}
+
+// GetFriendlyName returns the user-friendly plugin name
+func (p *BondsPlugin) GetFriendlyName() string {
+	return "Bonds"
+}
+
+// GetType returns the plugin type
+func (p *BondsPlugin) GetType() PluginType {
+	return PluginTypeManual
+}
+
+// GetDataSource returns the data source type
+func (p *BondsPlugin) GetDataSource() DataSourceType {
+	return DataSourceManual
+}
+
+// GetVersion returns the plugin version
+func (p *BondsPlugin) GetVersion() string {
+	return "1.0.0"
+}
+
+// GetDescription returns the plugin description
+func (p *BondsPlugin) GetDescription() string {
+	return "Manual entry for treasuries, corporate and municipal bonds and CDs, valued at their price plus accrued interest"
+}
+
+// Initialize initializes the plugin. Each bond gets its own account, named
+// after its institution and bond, so there is nothing to set up.
+func (p *BondsPlugin) Initialize(config PluginConfig) error {
+	return nil
+}
+
+// Authenticate performs authentication (not needed for manual entry)
+func (p *BondsPlugin) Authenticate() error {
+	return nil
+}
+
+// Disconnect disconnects from the service (not needed for manual entry)
+func (p *BondsPlugin) Disconnect() error {
+	return nil
+}
+
+// IsHealthy returns the health status of the plugin
+func (p *BondsPlugin) IsHealthy() PluginHealth {
+	return PluginHealth{
+		Status:      PluginStatusActive,
+		LastChecked: time.Now(),
+		Metrics: PluginMetrics{
+			SuccessRate: 1.0,
+		},
+	}
+}
+
+// RefreshData refreshes plugin data (not applicable for manual entry)
+func (p *BondsPlugin) RefreshData() error {
+	p.lastUpdated = time.Now()
+	return nil
+}
+
+// GetLastUpdate returns the last update time
+func (p *BondsPlugin) GetLastUpdate() time.Time {
+	return p.lastUpdated
+}
+
+// GetAccounts returns the accounts bonds are filed under
+func (p *BondsPlugin) GetAccounts() ([]Account, error) {
+	rows, err := p.db.Query(`
+		SELECT a.id, a.account_name, a.institution, MAX(b.last_updated)
+		FROM bonds b
+		JOIN accounts a ON a.id = b.account_id
+		GROUP BY a.id, a.account_name, a.institution
+		ORDER BY a.account_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bond accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var id int
+		var account Account
+		if err := rows.Scan(&id, &account.Name, &account.Institution, &account.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan bond account: %w", err)
+		}
+		account.ID = fmt.Sprintf("%d", id)
+		account.Type = "bond"
+		account.DataSource = "manual"
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetBalances returns the current value of each bond account, valuing each
+// bond with Bond.Value
+func (p *BondsPlugin) GetBalances() ([]Balance, error) {
+	rows, err := p.db.Query(`
+		SELECT account_id, face_value, coupon_rate, coupon_frequency, maturity_date, purchase_price, current_price, last_updated
+		FROM bonds
+		WHERE account_id IS NOT NULL
+		ORDER BY account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate bond balances: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	balances := []Balance{}
+	var total decimal.Decimal
+	lastAccountID := 0
+	for rows.Next() {
+		var accountID int
+		var bond models.Bond
+		err := rows.Scan(&accountID, &bond.FaceValue, &bond.CouponRate, &bond.CouponFrequency, &bond.MaturityDate,
+			&bond.PurchasePrice, &bond.CurrentPrice, &bond.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bond balance: %w", err)
+		}
+		if accountID != lastAccountID {
+			total = decimal.Zero
+			lastAccountID = accountID
+			balances = append(balances, Balance{
+				AccountID:  fmt.Sprintf("%d", accountID),
+				Currency:   "USD",
+				DataSource: "manual",
+			})
+		}
+		bond.Value(now)
+		total = total.Add(bond.CurrentValue)
+		balance := &balances[len(balances)-1]
+		balance.Amount = total.InexactFloat64()
+		if bond.LastUpdated.After(balance.AsOfDate) {
+			balance.AsOfDate = bond.LastUpdated
+		}
+	}
+	return balances, rows.Err()
+}
+
+// GetTransactions returns transactions for this plugin (not applicable for bonds)
+func (p *BondsPlugin) GetTransactions(dateRange DateRange) ([]Transaction, error) {
+	return []Transaction{}, nil
+}
+
+// SupportsManualEntry returns true as this is a manual entry plugin
+func (p *BondsPlugin) SupportsManualEntry() bool {
+	return true
+}
+
+// GetManualEntrySchema returns the schema for manual data entry
+func (p *BondsPlugin) GetManualEntrySchema() ManualEntrySchema {
+	nameLength, notesLength := 100, 1000
+	zero, minFace, minPrice, maxRate, maxPrice := 0.0, 0.01, 0.0001, 100.0, 1000.0
+
+	return ManualEntrySchema{
+		Name:        "Bond",
+		Description: "Add a treasury, bond or CD held at a broker or bank",
+		Version:     "1.0.0",
+		Fields: []FieldSpec{
+			{
+				Name:        "institution_name",
+				Type:        "text",
+				Label:       "Institution",
+				Description: "Broker or bank holding the bond",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "Fidelity",
+			},
+			{
+				Name:        "bond_name",
+				Type:        "text",
+				Label:       "Bond Name",
+				Description: "Issuer and issue to identify this bond",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "US Treasury 4.25% 2034",
+			},
+			{
+				Name:         "bond_type",
+				Type:         "select",
+				Label:        "Bond Type",
+				Required:     true,
+				DefaultValue: "treasury",
+				Options:      bondTypes,
+			},
+			{
+				Name:        "cusip",
+				Type:        "text",
+				Label:       "CUSIP",
+				Description: "Nine-character CUSIP, such as 91282CJZ5",
+				Validation:  FieldValidation{Pattern: `^[0-9A-Za-z*@#]{9}$`},
+				Placeholder: "91282CJZ5",
+			},
+			{
+				Name:        "face_value",
+				Type:        "number",
+				Label:       "Face Value",
+				Description: "Par amount repaid at maturity",
+				Required:    true,
+				Validation:  FieldValidation{Min: &minFace},
+				Placeholder: "10000",
+			},
+			{
+				Name:         "coupon_rate",
+				Type:         "number",
+				Label:        "Coupon Rate (%)",
+				Description:  "Annual interest as a percentage of face value",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &zero, Max: &maxRate},
+				Placeholder:  "4.25",
+			},
+			{
+				Name:         "coupon_frequency",
+				Type:         "select",
+				Label:        "Coupon Frequency",
+				Required:     true,
+				DefaultValue: "semiannual",
+				Options:      couponFrequencies,
+			},
+			{
+				Name:  "issue_date",
+				Type:  "date",
+				Label: "Issue Date",
+			},
+			{
+				Name:     "maturity_date",
+				Type:     "date",
+				Label:    "Maturity Date",
+				Required: true,
+			},
+			{
+				Name:         "purchase_price",
+				Type:         "number",
+				Label:        "Purchase Price",
+				Description:  "Clean price paid, as a percentage of face value",
+				Required:     true,
+				DefaultValue: 100.0,
+				Validation:   FieldValidation{Min: &minPrice, Max: &maxPrice},
+				Placeholder:  "98.75",
+			},
+			{
+				Name:  "purchase_date",
+				Type:  "date",
+				Label: "Purchase Date",
+			},
+			{
+				Name:        "current_price",
+				Type:        "number",
+				Label:       "Current Price",
+				Description: "Current clean price as a percentage of face value; the purchase price is used until one is entered",
+				Validation:  FieldValidation{Min: &minPrice, Max: &maxPrice},
+				Placeholder: "99.10",
+			},
+			{
+				Name:        "notes",
+				Type:        "textarea",
+				Label:       "Notes",
+				Validation:  FieldValidation{MaxLength: &notesLength},
+				Placeholder: "Callable, held to maturity, etc.",
+			},
+		},
+	}
+}
+
+// ValidateManualEntry validates manual entry data. On success Data holds the
+// values to store, with the coupon frequency as payments a year and blank
+// optional fields as nil.
+func (p *BondsPlugin) ValidateManualEntry(data map[string]interface{}) ValidationResult {
+	result := ValidateSettings(p.GetManualEntrySchema(), data)
+	if !result.Valid {
+		return result
+	}
+
+	invalid := func(field, message, code string) ValidationResult {
+		return ValidationResult{Valid: false, Errors: []ValidationError{{Field: field, Message: message, Code: code}}}
+	}
+
+	if cusip, _ := result.Data["cusip"].(string); cusip != "" {
+		cusip = strings.ToUpper(cusip)
+		if !validCUSIP(cusip) {
+			return invalid("cusip", "CUSIP check digit does not match", "invalid_cusip")
+		}
+		result.Data["cusip"] = cusip
+	} else {
+		result.Data["cusip"] = nil
+	}
+
+	frequency := result.Data["coupon_frequency"].(string)
+	result.Data["coupon_frequency"] = couponPaymentsPerYear[frequency]
+	if frequency == "zero_coupon" && result.Data["coupon_rate"].(float64) > 0 {
+		return invalid("coupon_rate", "Coupon Rate must be 0 for a zero-coupon bond", "invalid_coupon")
+	}
+
+	maturity, _ := time.Parse("2006-01-02", result.Data["maturity_date"].(string))
+	for _, field := range []string{"issue_date", "purchase_date"} {
+		value, _ := result.Data[field].(string)
+		if value == "" {
+			result.Data[field] = nil
+			continue
+		}
+		date, _ := time.Parse("2006-01-02", value)
+		if !date.Before(maturity) {
+			return invalid(field, "Maturity Date must be after the issue and purchase dates", "invalid_date")
+		}
+	}
+	if result.Data["notes"] == "" {
+		result.Data["notes"] = nil
+	}
+	return result
+}
+
+// validCUSIP reports whether a CUSIP's ninth character is its check digit
+func validCUSIP(cusip string) bool {
+	sum := 0
+	for i := 0; i < 8; i++ {
+		var v int
+		switch c := cusip[i]; {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c >= 'A' && c <= 'Z':
+			v = int(c-'A') + 10
+		case c == '*':
+			v = 36
+		case c == '@':
+			v = 37
+		case c == '#':
+			v = 38
+		default:
+			return false
+		}
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+	return cusip[8] == byte('0'+(10-sum%10)%10)
+}
+
+// ProcessManualEntry processes and stores manual entry data
+func (p *BondsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := p.CreateManualEntryTx(tx, data); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateManualEntryTx inserts a bond using db, which may be a transaction
+func (p *BondsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+
+	institution := validation.Data["institution_name"].(string)
+	bondName := validation.Data["bond_name"].(string)
+	identifier := bondName
+	if cusip, ok := validation.Data["cusip"].(string); ok {
+		identifier = fmt.Sprintf("%s %s", bondName, cusip)
+	}
+	accountID, err := GetOrCreateUniquePluginAccount(
+		db,
+		"Bonds",
+		fmt.Sprintf("%s %s", institution, identifier),
+		"bond",
+		institution,
+		"manual",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create unique account for bond: %w", err)
+	}
+
+	now := time.Now()
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO bonds (
+			account_id, institution_name, bond_name, bond_type, cusip, face_value, coupon_rate,
+			coupon_frequency, issue_date, maturity_date, purchase_price, purchase_date,
+			current_price, notes, created_at, last_updated
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id
+	`,
+		accountID,
+		institution,
+		bondName,
+		validation.Data["bond_type"],
+		validation.Data["cusip"],
+		validation.Data["face_value"],
+		validation.Data["coupon_rate"],
+		validation.Data["coupon_frequency"],
+		validation.Data["issue_date"],
+		validation.Data["maturity_date"],
+		validation.Data["purchase_price"],
+		validation.Data["purchase_date"],
+		validation.Data["current_price"],
+		validation.Data["notes"],
+		now,
+		now,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert bond: %w", err)
+	}
+
+	p.lastUpdated = now
+	return id, nil
+}
+
+// UpdateManualEntry updates an existing bond
+func (p *BondsPlugin) UpdateManualEntry(id int, data map[string]interface{}) error {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return ValidationErrors(validation.Errors)
+	}
+
+	now := time.Now()
+	result, err := p.db.Exec(`
+		UPDATE bonds SET
+			institution_name = $2,
+			bond_name = $3,
+			bond_type = $4,
+			cusip = $5,
+			face_value = $6,
+			coupon_rate = $7,
+			coupon_frequency = $8,
+			issue_date = $9,
+			maturity_date = $10,
+			purchase_price = $11,
+			purchase_date = $12,
+			current_price = $13,
+			notes = $14,
+			last_updated = $15
+		WHERE id = $1
+	`,
+		id,
+		validation.Data["institution_name"],
+		validation.Data["bond_name"],
+		validation.Data["bond_type"],
+		validation.Data["cusip"],
+		validation.Data["face_value"],
+		validation.Data["coupon_rate"],
+		validation.Data["coupon_frequency"],
+		validation.Data["issue_date"],
+		validation.Data["maturity_date"],
+		validation.Data["purchase_price"],
+		validation.Data["purchase_date"],
+		validation.Data["current_price"],
+		validation.Data["notes"],
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update bond: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("no bond found with id %d", id)
+	}
+
+	p.lastUpdated = now
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"networth-dashboard/internal/encryption"
 )
 
 // CashHoldingsPlugin handles manual entry for cash holdings (checking, savings, etc.)
@@ -13,7 +15,9 @@ type CashHoldingsPlugin struct {
 	db          *sql.DB
 	name        string
 	accountID   int
+	accountName string
 	lastUpdated time.Time
+	encryptor   *encryption.FieldEncryptor
 }
 
 // NewCashHoldingsPlugin creates a new Cash Holdings plugin
@@ -24,6 +28,11 @@ func NewCashHoldingsPlugin(db *sql.DB) *CashHoldingsPlugin {
 	}
 }
 
+// SetFieldEncryptor sets the encryptor used for account numbers at rest
+func (p *CashHoldingsPlugin) SetFieldEncryptor(encryptor *encryption.FieldEncryptor) {
+	p.encryptor = encryptor
+}
+
 // GetName returns the plugin name
 func (p *CashHoldingsPlugin) GetName() string {
 	return p.name
@@ -56,22 +65,29 @@ func (p *CashHoldingsPlugin) GetDescription() string {
 
 // Initialize initializes the plugin with configuration
 func (p *CashHoldingsPlugin) Initialize(config PluginConfig) error {
-	// Get or create the plugin account
-	accountID, err := GetOrCreatePluginAccount(
+	// Get or create the plugin account, renaming it when the setting changed
+	accountID, accountName, err := configurePluginAccount(
 		p.db,
+		p.accountID,
+		config,
 		"Cash Holdings Portfolio",
 		"cash_holdings",
 		"Manual Entry",
-		"manual",
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Cash Holdings account: %w", err)
 	}
 
 	p.accountID = accountID
+	p.accountName = accountName
 	return nil
 }
 
+// GetConfigSchema returns the schema of the plugin's runtime settings
+func (p *CashHoldingsPlugin) GetConfigSchema() ManualEntrySchema {
+	return accountConfigSchema(p.GetFriendlyName(), "Cash Holdings Portfolio")
+}
+
 // Authenticate performs authentication (not needed for manual entry)
 func (p *CashHoldingsPlugin) Authenticate() error {
 	return nil
@@ -98,7 +114,7 @@ func (p *CashHoldingsPlugin) GetAccounts() ([]Account, error) {
 	return []Account{
 		{
 			ID:          fmt.Sprintf("%d", p.accountID),
-			Name:        "Cash Holdings Portfolio",
+			Name:        p.accountName,
 			Type:        "cash_holdings",
 			Institution: "Manual Entry",
 			DataSource:  "manual",
@@ -575,21 +591,81 @@ func (p *CashHoldingsPlugin) ValidateManualEntry(data map[string]interface{}) Va
 }
 
 
-// ProcessManualEntry processes and stores manual entry data
+// ProcessManualEntry processes and stores manual entry data. An existing account with
+// the same institution and account name is updated unless data["conflict_policy"] says otherwise.
 func (p *CashHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	policy, err := TakeConflictPolicy(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.UpsertManualEntry(data, policy)
+	return err
+}
+
+// UpsertManualEntry stores a cash holding, matching existing holdings on institution and account name
+func (p *CashHoldingsPlugin) UpsertManualEntry(data map[string]interface{}, policy ConflictPolicy) (*ManualEntryOutcome, error) {
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return nil, ValidationErrors(validation.Errors)
 	}
 
 	// Create unique account for this cash holding
 	institutionName := validation.Data["institution_name"].(string)
 	accountName := validation.Data["account_name"].(string)
+
+	// Look for an existing holding with the same natural key
+	if policy != ConflictPolicyDuplicate {
+		var existingID int
+		err := p.db.QueryRow(`
+			SELECT id FROM cash_holdings
+			WHERE LOWER(institution_name) = LOWER($1) AND LOWER(account_name) = LOWER($2)
+			ORDER BY id LIMIT 1
+		`, institutionName, accountName).Scan(&existingID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to check for existing cash holding: %w", err)
+		}
+		if err == nil {
+			switch policy {
+			case ConflictPolicySkip:
+				return &ManualEntryOutcome{Action: ManualEntrySkipped, ID: existingID}, nil
+			case ConflictPolicyReject:
+				return nil, duplicateEntry(p.db, "cash_holdings", existingID, p.encryptor,
+					fmt.Sprintf("a %s account at %s", accountName, institutionName))
+			}
+			previous := snapshotRow(p.db, "cash_holdings", existingID)
+			if err := p.UpdateManualEntry(existingID, data); err != nil {
+				return nil, err
+			}
+			return &ManualEntryOutcome{Action: ManualEntryUpdated, ID: existingID, Previous: previous}, nil
+		}
+	}
+
+	holdingID, err := p.insertHolding(p.db, validation.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &ManualEntryOutcome{Action: ManualEntryCreated, ID: holdingID}, nil
+}
+
+// CreateManualEntryTx inserts a cash holding using db, which may be a transaction
+func (p *CashHoldingsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+	return p.insertHolding(db, validation.Data)
+}
+
+// insertHolding inserts a new cash holding row for validated data
+func (p *CashHoldingsPlugin) insertHolding(db DBTX, data map[string]interface{}) (int, error) {
+	institutionName := data["institution_name"].(string)
+	accountName := data["account_name"].(string)
 	uniqueIdentifier := fmt.Sprintf("%s %s", institutionName, accountName)
 	
 	uniqueAccountID, err := GetOrCreateUniquePluginAccount(
-		p.db,
+		db,
 		"Cash Holdings",
 		uniqueIdentifier,
 		"cash",
@@ -597,7 +673,12 @@ func (p *CashHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) err
 		"manual",
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create unique account for cash holding: %w", err)
+		return 0, fmt.Errorf("failed to create unique account for cash holding: %w", err)
+	}
+
+	encryptedLast4, err := p.encryptor.EncryptValue(data["account_number_last4"])
+	if err != nil {
+		return 0, fmt.Errorf("failed to encrypt account number: %w", err)
 	}
 
 	// Insert the cash holding record
@@ -607,31 +688,33 @@ func (p *CashHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) err
 			current_balance, interest_rate, monthly_contribution,
 			account_number_last4, currency, notes, created_at, updated_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
 	`
 
 	now := time.Now()
-	_, err = p.db.Exec(
+	var holdingID int
+	err = db.QueryRow(
 		query,
 		uniqueAccountID,
-		validation.Data["institution_name"],
-		validation.Data["account_name"],
-		validation.Data["account_type"],
-		validation.Data["current_balance"],
-		validation.Data["interest_rate"],
-		validation.Data["monthly_contribution"],
-		validation.Data["account_number_last4"],
-		validation.Data["currency"],
-		validation.Data["notes"],
+		data["institution_name"],
+		data["account_name"],
+		data["account_type"],
+		data["current_balance"],
+		data["interest_rate"],
+		data["monthly_contribution"],
+		encryptedLast4,
+		data["currency"],
+		data["notes"],
 		now,
 		now,
-	)
+	).Scan(&holdingID)
 
 	if err != nil {
-		return fmt.Errorf("failed to insert cash holding: %w", err)
+		return 0, fmt.Errorf("failed to insert cash holding: %w", err)
 	}
 
 	p.lastUpdated = now
-	return nil
+	return holdingID, nil
 }
 
 // UpdateManualEntry updates an existing manual entry
@@ -639,7 +722,12 @@ func (p *CashHoldingsPlugin) UpdateManualEntry(id int, data map[string]interface
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
+	}
+
+	encryptedLast4, err := p.encryptor.EncryptValue(validation.Data["account_number_last4"])
+	if err != nil {
+		return fmt.Errorf("failed to encrypt account number: %w", err)
 	}
 
 	// Update the cash holding record
@@ -668,7 +756,7 @@ func (p *CashHoldingsPlugin) UpdateManualEntry(id int, data map[string]interface
 		validation.Data["current_balance"],
 		validation.Data["interest_rate"],
 		validation.Data["monthly_contribution"],
-		validation.Data["account_number_last4"],
+		encryptedLast4,
 		validation.Data["currency"],
 		validation.Data["notes"],
 		now,
@@ -753,6 +841,14 @@ func (p *CashHoldingsPlugin) BulkUpdateManualEntry(updates []BulkUpdateItem) err
 			existingData["monthly_contribution"] = *monthlyContribution
 		}
 		if accountNumberLast4 != nil {
+			if err := p.encryptor.DecryptPtr(accountNumberLast4); err != nil {
+				failedUpdates = append(failedUpdates, BulkUpdateError{
+					ID:     update.ID,
+					Error:  fmt.Sprintf("failed to decrypt account number: %v", err),
+					Fields: update.Data,
+				})
+				continue
+			}
 			existingData["account_number_last4"] = *accountNumberLast4
 		}
 		if notes != nil {
@@ -769,7 +865,17 @@ func (p *CashHoldingsPlugin) BulkUpdateManualEntry(updates []BulkUpdateItem) err
 		if !validation.Valid {
 			failedUpdates = append(failedUpdates, BulkUpdateError{
 				ID:     update.ID,
-				Error:  fmt.Sprintf("validation failed: %v", validation.Errors),
+				Error:  ValidationErrors(validation.Errors).Error(),
+				Fields: update.Data,
+			})
+			continue
+		}
+
+		encryptedLast4, err := p.encryptor.EncryptValue(validation.Data["account_number_last4"])
+		if err != nil {
+			failedUpdates = append(failedUpdates, BulkUpdateError{
+				ID:     update.ID,
+				Error:  fmt.Sprintf("failed to encrypt account number: %v", err),
 				Fields: update.Data,
 			})
 			continue
@@ -800,7 +906,7 @@ func (p *CashHoldingsPlugin) BulkUpdateManualEntry(updates []BulkUpdateItem) err
 			validation.Data["current_balance"],
 			validation.Data["interest_rate"],
 			validation.Data["monthly_contribution"],
-			validation.Data["account_number_last4"],
+			encryptedLast4,
 			validation.Data["currency"],
 			validation.Data["notes"],
 			now,
@@ -0,0 +1,200 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SettingAccountName names the account a built-in plugin files its entries under
+const SettingAccountName = "account_name"
+
+// ConfigurablePlugin is implemented by plugins with runtime settings. The
+// schema validates PluginConfig.Settings before the plugin is reinitialized.
+type ConfigurablePlugin interface {
+	GetConfigSchema() ManualEntrySchema
+}
+
+// accountConfigSchema is the settings schema of a built-in plugin whose only
+// setting is the name of its account
+func accountConfigSchema(friendlyName, defaultAccountName string) ManualEntrySchema {
+	maxLength := 200
+	return ManualEntrySchema{
+		Name:        friendlyName + " Settings",
+		Description: fmt.Sprintf("Runtime settings for the %s plugin", friendlyName),
+		Version:     "1.0.0",
+		Fields: []FieldSpec{
+			{
+				Name:         SettingAccountName,
+				Type:         "text",
+				Label:        "Account Name",
+				Description:  "Name of the account the plugin's entries are filed under",
+				Required:     true,
+				DefaultValue: defaultAccountName,
+				Validation:   FieldValidation{MaxLength: &maxLength},
+			},
+		},
+	}
+}
+
+// ValidateSettings checks plugin settings against a config schema. Unknown
+// settings are rejected and missing ones take the field default; on success
+// Data holds the complete settings.
+func ValidateSettings(schema ManualEntrySchema, settings map[string]interface{}) ValidationResult {
+	var errors []ValidationError
+	validated := make(map[string]interface{}, len(schema.Fields))
+
+	known := make(map[string]bool, len(schema.Fields))
+	for _, field := range schema.Fields {
+		known[field.Name] = true
+	}
+	for key := range settings {
+		if !known[key] {
+			errors = append(errors, ValidationError{
+				Field:   key,
+				Message: fmt.Sprintf("Unknown setting %s", key),
+				Code:    "unknown",
+			})
+		}
+	}
+
+	for _, field := range schema.Fields {
+		value, exists := settings[field.Name]
+		if !exists || value == nil {
+			value = field.DefaultValue
+		}
+		if value == nil {
+			if field.Required {
+				errors = append(errors, ValidationError{
+					Field:   field.Name,
+					Message: fmt.Sprintf("%s is required", field.Label),
+					Code:    "required",
+				})
+			}
+			continue
+		}
+
+		normalized, err := validateSetting(field, value)
+		if err != nil {
+			errors = append(errors, *err)
+			continue
+		}
+		validated[field.Name] = normalized
+	}
+
+	if len(errors) > 0 {
+		return ValidationResult{Valid: false, Errors: errors}
+	}
+	return ValidationResult{Valid: true, Data: validated}
+}
+
+// validateSetting checks one setting value against its field spec
+func validateSetting(field FieldSpec, value interface{}) (interface{}, *ValidationError) {
+	invalid := func(code, format string, args ...interface{}) *ValidationError {
+		return &ValidationError{Field: field.Name, Message: fmt.Sprintf(format, args...), Code: code}
+	}
+
+	switch field.Type {
+	case "number":
+		number, ok := value.(float64)
+		if !ok {
+			if i, isInt := value.(int); isInt {
+				number, ok = float64(i), true
+			}
+		}
+		if !ok {
+			return nil, invalid("invalid_type", "%s must be a number", field.Label)
+		}
+		if field.Validation.Min != nil && number < *field.Validation.Min {
+			return nil, invalid("min", "%s must be at least %g", field.Label, *field.Validation.Min)
+		}
+		if field.Validation.Max != nil && number > *field.Validation.Max {
+			return nil, invalid("max", "%s must be at most %g", field.Label, *field.Validation.Max)
+		}
+		return number, nil
+
+	case "boolean":
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, invalid("invalid_type", "%s must be true or false", field.Label)
+		}
+		return flag, nil
+
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return nil, invalid("invalid_type", "%s must be a string", field.Label)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			if field.Required {
+				return nil, invalid("required", "%s is required", field.Label)
+			}
+			return text, nil
+		}
+		if field.Validation.MinLength != nil && len(text) < *field.Validation.MinLength {
+			return nil, invalid("min_length", "%s must be at least %d characters", field.Label, *field.Validation.MinLength)
+		}
+		if field.Validation.MaxLength != nil && len(text) > *field.Validation.MaxLength {
+			return nil, invalid("max_length", "%s must be %d characters or less", field.Label, *field.Validation.MaxLength)
+		}
+		if field.Validation.Pattern != "" {
+			if matched, err := regexp.MatchString(field.Validation.Pattern, text); err != nil || !matched {
+				return nil, invalid("pattern", "%s has an invalid format", field.Label)
+			}
+		}
+		if field.Type == "date" {
+			if _, err := time.Parse("2006-01-02", text); err != nil {
+				return nil, invalid("invalid_date", "%s must be a date (YYYY-MM-DD)", field.Label)
+			}
+		}
+		if len(field.Options) > 0 {
+			allowed := false
+			for _, option := range field.Options {
+				if option.Value == text {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil, invalid("invalid_option", "%s must be one of the listed options", field.Label)
+			}
+		}
+		return text, nil
+	}
+}
+
+// settingString returns a string setting, or fallback when it is unset
+func settingString(config PluginConfig, key, fallback string) string {
+	if value, ok := config.Settings[key].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// configurePluginAccount returns the account a plugin files its entries under,
+// named from its settings. An account the plugin already holds is renamed in
+// place so existing entries follow a changed name.
+func configurePluginAccount(db *sql.DB, currentID int, config PluginConfig, defaultName, accountType, institution string) (int, string, error) {
+	accountName := settingString(config, SettingAccountName, defaultName)
+
+	if currentID != 0 {
+		_, err := db.Exec(`
+			UPDATE accounts
+			SET account_name = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND account_name <> $1
+		`, accountName, currentID)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to rename plugin account: %w", err)
+		}
+		return currentID, accountName, nil
+	}
+
+	accountID, err := GetOrCreatePluginAccount(db, accountName, accountType, institution, "manual")
+	if err != nil {
+		return 0, "", err
+	}
+	return accountID, accountName, nil
+}
@@ -0,0 +1,191 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors are the shorthand schedules accepted in place of five fields
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronSearchLimit bounds the search for the next run of a schedule that can
+// never fire, such as 30 February
+const cronSearchLimit = 5 * 365 * 24 * time.Hour
+
+// CronSchedule is a parsed cron expression: five fields (minute, hour, day of
+// month, month, day of week), a descriptor such as @daily, or @every <duration>
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a * field; when both day fields are
+	// restricted a day matching either one fires, as in standard cron
+	domAny, dowAny bool
+	every          time.Duration
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expression string) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if every < time.Minute {
+			return nil, fmt.Errorf("@every duration must be at least 1m")
+		}
+		return &CronSchedule{every: every}, nil
+	}
+	if descriptor, ok := cronDescriptors[strings.ToLower(expression)]; ok {
+		expression = descriptor
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	schedule := &CronSchedule{}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if schedule.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if schedule.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is an alias for Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	schedule.domAny = fields[2] == "*" || fields[2] == "?"
+	schedule.dowAny = fields[4] == "*" || fields[4] == "?"
+
+	return schedule, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+// into a bit set
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			rangePart = part[:slash]
+			n, err := strconv.Atoi(part[slash+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = min, max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(bounds[1], names); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if start, err = parseCronValue(rangePart, names); err != nil {
+				return 0, err
+			}
+			end = start
+			// "5/15" runs from 5 to the end of the range
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return n, nil
+}
+
+// Next returns the first run time after t, in t's location, or the zero time
+// when the schedule never fires
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	loc := t.Location()
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for next.Before(limit) {
+		switch {
+		case s.month&(1<<uint(next.Month())) == 0:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(next.Hour())) == 0:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(next.Minute())) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"networth-dashboard/internal/encryption"
 )
 
 // CryptoHoldingsPlugin handles manual entry for cryptocurrency holdings
@@ -13,7 +15,9 @@ type CryptoHoldingsPlugin struct {
 	db          *sql.DB
 	name        string
 	accountID   int
+	accountName string
 	lastUpdated time.Time
+	encryptor   *encryption.FieldEncryptor
 }
 
 // NewCryptoHoldingsPlugin creates a new Crypto Holdings plugin
@@ -24,6 +28,11 @@ func NewCryptoHoldingsPlugin(db *sql.DB) *CryptoHoldingsPlugin {
 	}
 }
 
+// SetFieldEncryptor sets the encryptor used for wallet addresses at rest
+func (p *CryptoHoldingsPlugin) SetFieldEncryptor(encryptor *encryption.FieldEncryptor) {
+	p.encryptor = encryptor
+}
+
 // GetName returns the plugin name
 func (p *CryptoHoldingsPlugin) GetName() string {
 	return p.name
@@ -56,22 +65,29 @@ func (p *CryptoHoldingsPlugin) GetDescription() string {
 
 // Initialize initializes the plugin with configuration
 func (p *CryptoHoldingsPlugin) Initialize(config PluginConfig) error {
-	// Get or create the plugin account
-	accountID, err := GetOrCreatePluginAccount(
+	// Get or create the plugin account, renaming it when the setting changed
+	accountID, accountName, err := configurePluginAccount(
 		p.db,
+		p.accountID,
+		config,
 		"Crypto Holdings Portfolio",
 		"crypto_holdings",
 		"Manual Entry",
-		"manual",
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Crypto Holdings account: %w", err)
 	}
 
 	p.accountID = accountID
+	p.accountName = accountName
 	return nil
 }
 
+// GetConfigSchema returns the schema of the plugin's runtime settings
+func (p *CryptoHoldingsPlugin) GetConfigSchema() ManualEntrySchema {
+	return accountConfigSchema(p.GetFriendlyName(), "Crypto Holdings Portfolio")
+}
+
 // Authenticate performs authentication (not needed for manual entry)
 func (p *CryptoHoldingsPlugin) Authenticate() error {
 	return nil
@@ -98,7 +114,7 @@ func (p *CryptoHoldingsPlugin) GetAccounts() ([]Account, error) {
 	return []Account{
 		{
 			ID:          fmt.Sprintf("%d", p.accountID),
-			Name:        "Crypto Holdings Portfolio",
+			Name:        p.accountName,
 			Type:        "crypto_holdings",
 			Institution: "Manual Entry",
 			DataSource:  "manual",
@@ -264,6 +280,17 @@ func (p *CryptoHoldingsPlugin) GetManualEntrySchema() ManualEntrySchema {
 				DefaultValue: 0,
 				Placeholder: "5.0",
 			},
+			{
+				Name:        "staked_tokens",
+				Type:        "number",
+				Label:       "Staked Balance",
+				Description: "Tokens staked and earning rewards; the rest of the balance is liquid (defaults to the whole balance when a staking percentage is set)",
+				Required:    false,
+				Validation: FieldValidation{
+					Min: func(f float64) *float64 { return &f }(0),
+				},
+				Placeholder: "1.0",
+			},
 			{
 				Name:        "notes",
 				Type:        "textarea",
@@ -529,6 +556,48 @@ func (p *CryptoHoldingsPlugin) ValidateManualEntry(data map[string]interface{})
 		validatedData["staking_annual_percentage"] = 0.0
 	}
 
+	// Validate optional staked_tokens. A holding with a staking percentage
+	// but no staked balance is taken to be fully staked.
+	balance, _ := validatedData["balance_tokens"].(float64)
+	stakedTokens := 0.0
+	if stakingPercentage, _ := validatedData["staking_annual_percentage"].(float64); stakingPercentage > 0 {
+		stakedTokens = balance
+	}
+	if stakedData, exists := data["staked_tokens"]; exists && stakedData != nil && stakedData != "" {
+		var err error
+		switch v := stakedData.(type) {
+		case string:
+			stakedTokens, err = strconv.ParseFloat(v, 64)
+		case float64:
+			stakedTokens = v
+		case int:
+			stakedTokens = float64(v)
+		default:
+			err = fmt.Errorf("unsupported type: %T", v)
+		}
+
+		if err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "staked_tokens",
+				Message: "Invalid staked balance",
+				Code:    "invalid",
+			})
+		} else if stakedTokens < 0 {
+			errors = append(errors, ValidationError{
+				Field:   "staked_tokens",
+				Message: "Staked balance cannot be negative",
+				Code:    "min",
+			})
+		} else if stakedTokens > balance {
+			errors = append(errors, ValidationError{
+				Field:   "staked_tokens",
+				Message: "Staked balance cannot exceed the balance",
+				Code:    "max",
+			})
+		}
+	}
+	validatedData["staked_tokens"] = stakedTokens
+
 	// Validate optional notes
 	if notesData, ok := data["notes"]; ok && notesData != nil {
 		if notesStr, ok := notesData.(string); ok {
@@ -552,12 +621,110 @@ func (p *CryptoHoldingsPlugin) ValidateManualEntry(data map[string]interface{})
 	}
 }
 
-// ProcessManualEntry processes and stores manual entry data
+// ProcessManualEntry processes and stores manual entry data. An existing holding
+// of the same coin in the same wallet is updated unless data["conflict_policy"]
+// says otherwise.
 func (p *CryptoHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	policy, err := TakeConflictPolicy(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.UpsertManualEntry(data, policy)
+	return err
+}
+
+// UpsertManualEntry stores a crypto holding, matching existing holdings of the
+// same coin in the same wallet
+func (p *CryptoHoldingsPlugin) UpsertManualEntry(data map[string]interface{}, policy ConflictPolicy) (*ManualEntryOutcome, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return nil, ValidationErrors(validation.Errors)
+	}
+
+	if policy != ConflictPolicyDuplicate {
+		existingID, err := p.findExistingHolding(validation.Data)
+		if err != nil {
+			return nil, err
+		}
+		if existingID != 0 {
+			switch policy {
+			case ConflictPolicySkip:
+				return &ManualEntryOutcome{Action: ManualEntrySkipped, ID: existingID}, nil
+			case ConflictPolicyReject:
+				return nil, duplicateEntry(p.db, "crypto_holdings", existingID, p.encryptor, cryptoEntry(validation.Data))
+			}
+			previous := snapshotRow(p.db, "crypto_holdings", existingID)
+			if err := p.UpdateManualEntry(existingID, data); err != nil {
+				return nil, err
+			}
+			return &ManualEntryOutcome{Action: ManualEntryUpdated, ID: existingID, Previous: previous}, nil
+		}
+	}
+
+	holdingID, err := p.CreateManualEntryTx(p.db, data)
+	if err != nil {
+		return nil, err
+	}
+	return &ManualEntryOutcome{Action: ManualEntryCreated, ID: holdingID}, nil
+}
+
+// findExistingHolding returns the ID of a holding of the same coin in the
+// same wallet as validated data, or 0. A holding with a wallet address is
+// matched on the address, which is encrypted at rest and so compared here;
+// one without is matched on its institution among holdings without one.
+func (p *CryptoHoldingsPlugin) findExistingHolding(data map[string]interface{}) (int, error) {
+	walletAddress, _ := data["wallet_address"].(string)
+	institutionName := data["institution_name"].(string)
+
+	rows, err := p.db.Query(`
+		SELECT id, institution_name, wallet_address FROM crypto_holdings
+		WHERE UPPER(crypto_symbol) = UPPER($1)
+		ORDER BY id
+	`, data["crypto_symbol"])
+	if err != nil {
+		return 0, fmt.Errorf("failed to check for existing crypto holding: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var institution string
+		var wallet sql.NullString
+		if err := rows.Scan(&id, &institution, &wallet); err != nil {
+			return 0, fmt.Errorf("failed to scan crypto holding: %w", err)
+		}
+		existingWallet := ""
+		if wallet.Valid {
+			if existingWallet, err = p.encryptor.Decrypt(wallet.String); err != nil {
+				continue
+			}
+		}
+		if walletAddress != "" {
+			if strings.EqualFold(strings.TrimSpace(existingWallet), walletAddress) {
+				return id, nil
+			}
+		} else if existingWallet == "" && strings.EqualFold(strings.TrimSpace(institution), institutionName) {
+			return id, nil
+		}
+	}
+	return 0, rows.Err()
+}
+
+// cryptoEntry describes the holding in validated data by its coin and wallet
+func cryptoEntry(data map[string]interface{}) string {
+	if walletAddress, _ := data["wallet_address"].(string); walletAddress != "" {
+		return fmt.Sprintf("a %s holding in wallet %s", data["crypto_symbol"], walletAddress)
+	}
+	return fmt.Sprintf("a %s holding at %s", data["crypto_symbol"], data["institution_name"])
+}
+
+// CreateManualEntryTx inserts a crypto holding using db, which may be a transaction
+func (p *CryptoHoldingsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return 0, ValidationErrors(validation.Errors)
 	}
 
 	// Create unique account for this crypto holding
@@ -566,7 +733,7 @@ func (p *CryptoHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) e
 	uniqueIdentifier := fmt.Sprintf("%s %s", institutionName, cryptoSymbol)
 	
 	uniqueAccountID, err := GetOrCreateUniquePluginAccount(
-		p.db,
+		db,
 		"Crypto Holdings",
 		uniqueIdentifier,
 		"crypto",
@@ -574,7 +741,12 @@ func (p *CryptoHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) e
 		"manual",
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create unique account for crypto holding: %w", err)
+		return 0, fmt.Errorf("failed to create unique account for crypto holding: %w", err)
+	}
+
+	encryptedWallet, err := p.encryptor.EncryptValue(validation.Data["wallet_address"])
+	if err != nil {
+		return 0, fmt.Errorf("failed to encrypt wallet address: %w", err)
 	}
 
 	// Insert the crypto holding record
@@ -582,12 +754,14 @@ func (p *CryptoHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) e
 		INSERT INTO crypto_holdings (
 			account_id, institution_name, crypto_symbol, balance_tokens,
 			purchase_price_usd, purchase_date, wallet_address, notes,
-			staking_annual_percentage, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			staking_annual_percentage, staked_tokens, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
 	`
 
 	now := time.Now()
-	_, err = p.db.Exec(
+	var holdingID int
+	err = db.QueryRow(
 		query,
 		uniqueAccountID,
 		validation.Data["institution_name"],
@@ -595,19 +769,20 @@ func (p *CryptoHoldingsPlugin) ProcessManualEntry(data map[string]interface{}) e
 		validation.Data["balance_tokens"],
 		validation.Data["purchase_price_usd"],
 		validation.Data["purchase_date"],
-		validation.Data["wallet_address"],
+		encryptedWallet,
 		validation.Data["notes"],
 		validation.Data["staking_annual_percentage"],
+		validation.Data["staked_tokens"],
 		now,
 		now,
-	)
+	).Scan(&holdingID)
 
 	if err != nil {
-		return fmt.Errorf("failed to insert crypto holding: %w", err)
+		return 0, fmt.Errorf("failed to insert crypto holding: %w", err)
 	}
 
 	p.lastUpdated = now
-	return nil
+	return holdingID, nil
 }
 
 // UpdateManualEntry updates an existing manual entry
@@ -615,7 +790,7 @@ func (p *CryptoHoldingsPlugin) UpdateManualEntry(id int, data map[string]interfa
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
 	}
 
 	// First, get the actual account ID for this crypto holding
@@ -626,6 +801,11 @@ func (p *CryptoHoldingsPlugin) UpdateManualEntry(id int, data map[string]interfa
 		return fmt.Errorf("failed to get crypto holding account ID: %w", err)
 	}
 
+	encryptedWallet, err := p.encryptor.EncryptValue(validation.Data["wallet_address"])
+	if err != nil {
+		return fmt.Errorf("failed to encrypt wallet address: %w", err)
+	}
+
 	// Update the crypto holding record
 	query := `
 		UPDATE crypto_holdings SET
@@ -637,7 +817,8 @@ func (p *CryptoHoldingsPlugin) UpdateManualEntry(id int, data map[string]interfa
 			wallet_address = $7,
 			notes = $8,
 			staking_annual_percentage = $9,
-			updated_at = $10
+			staked_tokens = $10,
+			updated_at = $11
 		WHERE id = $1
 	`
 
@@ -650,9 +831,10 @@ func (p *CryptoHoldingsPlugin) UpdateManualEntry(id int, data map[string]interfa
 		validation.Data["balance_tokens"],
 		validation.Data["purchase_price_usd"],
 		validation.Data["purchase_date"],
-		validation.Data["wallet_address"],
+		encryptedWallet,
 		validation.Data["notes"],
 		validation.Data["staking_annual_percentage"],
+		validation.Data["staked_tokens"],
 		now,
 	)
 
@@ -0,0 +1,367 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// customFieldTypes are the field types an asset category's custom schema may use
+var customFieldTypes = map[string]bool{
+	"text":     true,
+	"textarea": true,
+	"number":   true,
+	"date":     true,
+	"select":   true,
+}
+
+// customFieldNamePattern keeps custom field names usable as JSON keys and in
+// the custom_fields.<name> form fields
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ValidateCustomSchema checks an asset category's custom_schema: an object
+// with a fields list whose entries have unique snake_case names, a supported
+// type and a label. Select fields need options with unique values, and
+// validation rules must suit the field's type. A nil schema is valid.
+func ValidateCustomSchema(schema interface{}) []ValidationError {
+	if schema == nil {
+		return nil
+	}
+	object, ok := schema.(map[string]interface{})
+	if !ok {
+		return []ValidationError{{Field: "custom_schema", Message: "Custom schema must be an object", Code: "invalid_type"}}
+	}
+	rawFields, exists := object["fields"]
+	if !exists || rawFields == nil {
+		return nil
+	}
+	fields, ok := rawFields.([]interface{})
+	if !ok {
+		return []ValidationError{{Field: "custom_schema.fields", Message: "Fields must be a list", Code: "invalid_type"}}
+	}
+
+	var errs []ValidationError
+	names := make(map[string]int)
+	for i, rawField := range fields {
+		path := fmt.Sprintf("custom_schema.fields[%d]", i)
+		field, ok := rawField.(map[string]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{Field: path, Message: "Field must be an object", Code: "invalid_type"})
+			continue
+		}
+
+		name, _ := field["name"].(string)
+		switch {
+		case name == "":
+			errs = append(errs, ValidationError{Field: path + ".name", Message: "Field name is required", Code: "required"})
+		case !customFieldNamePattern.MatchString(name):
+			errs = append(errs, ValidationError{Field: path + ".name", Message: fmt.Sprintf("Field name %q must be lowercase letters, digits and underscores, starting with a letter", name), Code: "invalid_format"})
+		default:
+			if first, seen := names[name]; seen {
+				errs = append(errs, ValidationError{Field: path + ".name", Message: fmt.Sprintf("Field name %q is already used by field %d", name, first), Code: "duplicate"})
+			} else {
+				names[name] = i
+			}
+		}
+
+		if label, _ := field["label"].(string); strings.TrimSpace(label) == "" {
+			errs = append(errs, ValidationError{Field: path + ".label", Message: "Field label is required", Code: "required"})
+		}
+		if required, exists := field["required"]; exists {
+			if _, ok := required.(bool); !ok {
+				errs = append(errs, ValidationError{Field: path + ".required", Message: "Required must be true or false", Code: "invalid_type"})
+			}
+		}
+
+		fieldType, _ := field["type"].(string)
+		if !customFieldTypes[fieldType] {
+			errs = append(errs, ValidationError{Field: path + ".type", Message: "Field type must be text, textarea, number, date or select", Code: "invalid_option"})
+			continue
+		}
+
+		errs = append(errs, validateCustomFieldOptions(path, fieldType, field["options"])...)
+		errs = append(errs, validateCustomFieldRules(path, fieldType, field["validation"])...)
+	}
+	return errs
+}
+
+// validateCustomFieldOptions requires select fields, and only them, to list
+// options with a value and label, without repeating a value
+func validateCustomFieldOptions(path, fieldType string, rawOptions interface{}) []ValidationError {
+	if fieldType != "select" {
+		if rawOptions != nil {
+			return []ValidationError{{Field: path + ".options", Message: "Only select fields have options", Code: "invalid_option"}}
+		}
+		return nil
+	}
+
+	options, ok := rawOptions.([]interface{})
+	if !ok || len(options) == 0 {
+		return []ValidationError{{Field: path + ".options", Message: "Select fields need at least one option", Code: "required"}}
+	}
+
+	var errs []ValidationError
+	values := make(map[string]bool)
+	for j, rawOption := range options {
+		optionPath := fmt.Sprintf("%s.options[%d]", path, j)
+		option, ok := rawOption.(map[string]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{Field: optionPath, Message: "Option must be an object with a value and label", Code: "invalid_type"})
+			continue
+		}
+		value, _ := option["value"].(string)
+		if value == "" {
+			errs = append(errs, ValidationError{Field: optionPath + ".value", Message: "Option value is required", Code: "required"})
+		} else if values[value] {
+			errs = append(errs, ValidationError{Field: optionPath + ".value", Message: fmt.Sprintf("Option value %q is repeated", value), Code: "duplicate"})
+		}
+		values[value] = true
+		if label, _ := option["label"].(string); strings.TrimSpace(label) == "" {
+			errs = append(errs, ValidationError{Field: optionPath + ".label", Message: "Option label is required", Code: "required"})
+		}
+	}
+	return errs
+}
+
+// validateCustomFieldRules checks a field's validation rules: min and max
+// apply to numbers, lengths and pattern to text, and each lower bound must not
+// exceed its upper bound
+func validateCustomFieldRules(path, fieldType string, rawRules interface{}) []ValidationError {
+	if rawRules == nil {
+		return nil
+	}
+	rules, ok := rawRules.(map[string]interface{})
+	if !ok {
+		return []ValidationError{{Field: path + ".validation", Message: "Validation must be an object", Code: "invalid_type"}}
+	}
+
+	var errs []ValidationError
+	number := func(key string, applies bool, nonNegative bool) (float64, bool) {
+		raw, exists := rules[key]
+		if !exists || raw == nil {
+			return 0, false
+		}
+		rulePath := path + ".validation." + key
+		if !applies {
+			errs = append(errs, ValidationError{Field: rulePath, Message: fmt.Sprintf("%s does not apply to %s fields", key, fieldType), Code: "invalid_option"})
+			return 0, false
+		}
+		value, ok := raw.(float64)
+		if !ok {
+			errs = append(errs, ValidationError{Field: rulePath, Message: fmt.Sprintf("%s must be a number", key), Code: "invalid_number"})
+			return 0, false
+		}
+		if nonNegative && (value < 0 || value != float64(int(value))) {
+			errs = append(errs, ValidationError{Field: rulePath, Message: fmt.Sprintf("%s must be a whole number of at least 0", key), Code: "invalid_range"})
+			return 0, false
+		}
+		return value, true
+	}
+
+	isNumber := fieldType == "number"
+	isText := fieldType == "text" || fieldType == "textarea"
+	min, hasMin := number("min", isNumber, false)
+	max, hasMax := number("max", isNumber, false)
+	if hasMin && hasMax && min > max {
+		errs = append(errs, ValidationError{Field: path + ".validation.min", Message: "min must not exceed max", Code: "invalid_range"})
+	}
+	minLength, hasMinLength := number("min_length", isText, true)
+	maxLength, hasMaxLength := number("max_length", isText, true)
+	if hasMinLength && hasMaxLength && minLength > maxLength {
+		errs = append(errs, ValidationError{Field: path + ".validation.min_length", Message: "min_length must not exceed max_length", Code: "invalid_range"})
+	}
+
+	if raw, exists := rules["pattern"]; exists && raw != nil {
+		pattern, ok := raw.(string)
+		switch {
+		case !isText:
+			errs = append(errs, ValidationError{Field: path + ".validation.pattern", Message: fmt.Sprintf("pattern does not apply to %s fields", fieldType), Code: "invalid_option"})
+		case !ok:
+			errs = append(errs, ValidationError{Field: path + ".validation.pattern", Message: "pattern must be a string", Code: "invalid_type"})
+		default:
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, ValidationError{Field: path + ".validation.pattern", Message: fmt.Sprintf("pattern is not a valid regular expression: %v", err), Code: "invalid_format"})
+			}
+		}
+	}
+	return errs
+}
+
+// ParseCustomSchemaFields returns the fields of a stored custom_schema. An
+// empty schema has no fields.
+func ParseCustomSchemaFields(schemaJSON []byte) ([]FieldSpec, error) {
+	if len(schemaJSON) == 0 {
+		return nil, nil
+	}
+	var schema struct {
+		Fields []FieldSpec `json:"fields"`
+	}
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse custom schema: %w", err)
+	}
+	return schema.Fields, nil
+}
+
+// CustomFieldDrift is how an asset's custom_fields differ from its category's schema
+type CustomFieldDrift struct {
+	Missing         []string `json:"missing"`          // schema fields without a value
+	MissingRequired []string `json:"missing_required"` // the missing fields the schema requires
+	Extra           []string `json:"extra"`            // values for fields the schema no longer has
+}
+
+// HasDrift reports whether any field is missing or extra
+func (d CustomFieldDrift) HasDrift() bool {
+	return len(d.Missing) > 0 || len(d.Extra) > 0
+}
+
+// CompareCustomFields finds the schema fields an asset has no value for and
+// the values it keeps for fields that are not in the schema
+func CompareCustomFields(fields []FieldSpec, values map[string]interface{}) CustomFieldDrift {
+	drift := CustomFieldDrift{Missing: []string{}, MissingRequired: []string{}, Extra: []string{}}
+	inSchema := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		inSchema[field.Name] = true
+		if value, exists := values[field.Name]; !exists || value == nil || value == "" {
+			drift.Missing = append(drift.Missing, field.Name)
+			if field.Required {
+				drift.MissingRequired = append(drift.MissingRequired, field.Name)
+			}
+		}
+	}
+	for name := range values {
+		if !inSchema[name] {
+			drift.Extra = append(drift.Extra, name)
+		}
+	}
+	sort.Strings(drift.Extra)
+	return drift
+}
+
+// CustomFieldMigration brings assets' custom_fields in line with a changed
+// schema. Renames move a value from a field no longer in the schema to one
+// that is, defaults fill schema fields without a value, and RemoveExtra drops
+// values for fields the schema does not have.
+type CustomFieldMigration struct {
+	Renames     map[string]string      `json:"renames"`
+	Defaults    map[string]interface{} `json:"defaults"`
+	RemoveExtra bool                   `json:"remove_extra"`
+}
+
+// Validate checks the migration against the schema's fields: rename targets
+// and defaults must name schema fields, rename sources must not, and defaults
+// must suit their field's type
+func (m CustomFieldMigration) Validate(fields []FieldSpec) []ValidationError {
+	byName := make(map[string]FieldSpec, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	var errs []ValidationError
+	targets := make(map[string]string)
+	for from, to := range m.Renames {
+		path := "renames." + from
+		if _, exists := byName[from]; exists {
+			errs = append(errs, ValidationError{Field: path, Message: fmt.Sprintf("%q is still a schema field and cannot be renamed", from), Code: "invalid_option"})
+		}
+		if _, exists := byName[to]; !exists {
+			errs = append(errs, ValidationError{Field: path, Message: fmt.Sprintf("%q is not a schema field", to), Code: "invalid_option"})
+		} else if other, taken := targets[to]; taken {
+			errs = append(errs, ValidationError{Field: path, Message: fmt.Sprintf("%q is also renamed from %q", to, other), Code: "duplicate"})
+		}
+		targets[to] = from
+	}
+
+	for name, value := range m.Defaults {
+		path := "defaults." + name
+		field, exists := byName[name]
+		if !exists {
+			errs = append(errs, ValidationError{Field: path, Message: fmt.Sprintf("%q is not a schema field", name), Code: "invalid_option"})
+			continue
+		}
+		if message := defaultValueError(field, value); message != "" {
+			errs = append(errs, ValidationError{Field: path, Message: message, Code: "invalid_type"})
+		}
+	}
+	return errs
+}
+
+// defaultValueError describes why value cannot be stored in field, or is
+// empty if it can
+func defaultValueError(field FieldSpec, value interface{}) string {
+	switch field.Type {
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Sprintf("%s needs a number", field.Name)
+		}
+	case "date":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Sprintf("%s needs a YYYY-MM-DD date", field.Name)
+		}
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return fmt.Sprintf("%s needs a YYYY-MM-DD date", field.Name)
+		}
+	case "select":
+		text, _ := value.(string)
+		for _, option := range field.Options {
+			if option.Value == text {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s needs one of its option values", field.Name)
+	default:
+		if text, ok := value.(string); !ok || text == "" {
+			return fmt.Sprintf("%s needs text", field.Name)
+		}
+	}
+	return ""
+}
+
+// Apply returns values migrated to the schema and whether anything changed.
+// values itself is not modified. A rename is skipped when the target field
+// already has a value, leaving the old field as extra.
+func (m CustomFieldMigration) Apply(fields []FieldSpec, values map[string]interface{}) (map[string]interface{}, bool) {
+	migrated := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		migrated[name] = value
+	}
+	changed := false
+	empty := func(name string) bool {
+		value, exists := migrated[name]
+		return !exists || value == nil || value == ""
+	}
+
+	for from, to := range m.Renames {
+		value, exists := migrated[from]
+		if !exists || !empty(to) {
+			continue
+		}
+		migrated[to] = value
+		delete(migrated, from)
+		changed = true
+	}
+
+	for name, value := range m.Defaults {
+		if empty(name) {
+			migrated[name] = value
+			changed = true
+		}
+	}
+
+	if m.RemoveExtra {
+		inSchema := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			inSchema[field.Name] = true
+		}
+		for name := range migrated {
+			if !inSchema[name] {
+				delete(migrated, name)
+				changed = true
+			}
+		}
+	}
+	return migrated, changed
+}
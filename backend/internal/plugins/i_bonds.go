@@ -0,0 +1,418 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/services"
+
+	"github.com/shopspring/decimal"
+)
+
+// iBondsFirstIssue is the first month Series I savings bonds were sold
+var iBondsFirstIssue = time.Date(1998, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+// IBondsPlugin handles manual entry for US Series I savings bonds. A bond
+// is valued from its fixed rate and the inflation rates in the I bond rate
+// schedule, and counts toward net worth as fixed income.
+type IBondsPlugin struct {
+	db          *sql.DB
+	name        string
+	lastUpdated time.Time
+}
+
+// NewIBondsPlugin creates a new I Bonds plugin
+func NewIBondsPlugin(db *sql.DB) *IBondsPlugin {
+	return &IBondsPlugin{
+		db:   db,
+		name: "i_bonds",
+	}
+}
+
+// GetName returns the plugin name
+func (p *IBondsPlugin) GetName() string {
+	return p.name
+}
+
+// GetFriendlyName returns the user-friendly plugin name
+func (p *IBondsPlugin) GetFriendlyName() string {
+	return "I Bonds"
+}
+
+// GetType returns the plugin type
+func (p *IBondsPlugin) GetType() PluginType {
+	return PluginTypeManual
+}
+
+// GetDataSource returns the data source type
+func (p *IBondsPlugin) GetDataSource() DataSourceType {
+	return DataSourceManual
+}
+
+// GetVersion returns the plugin version
+func (p *IBondsPlugin) GetVersion() string {
+	return "1.0.0"
+}
+
+// GetDescription returns the plugin description
+func (p *IBondsPlugin) GetDescription() string {
+	return "Manual entry for Series I savings bonds, valued from the Treasury rate schedule with the early redemption penalty"
+}
+
+// Initialize initializes the plugin. Each bond gets its own account, named
+// after its institution and issue month, so there is nothing to set up.
+func (p *IBondsPlugin) Initialize(config PluginConfig) error {
+	return nil
+}
+
+// Authenticate performs authentication (not needed for manual entry)
+func (p *IBondsPlugin) Authenticate() error {
+	return nil
+}
+
+// Disconnect disconnects from the service (not needed for manual entry)
+func (p *IBondsPlugin) Disconnect() error {
+	return nil
+}
+
+// IsHealthy returns the health status of the plugin
+func (p *IBondsPlugin) IsHealthy() PluginHealth {
+	return PluginHealth{
+		Status:      PluginStatusActive,
+		LastChecked: time.Now(),
+		Metrics: PluginMetrics{
+			SuccessRate: 1.0,
+		},
+	}
+}
+
+// RefreshData refreshes plugin data (not applicable for manual entry)
+func (p *IBondsPlugin) RefreshData() error {
+	p.lastUpdated = time.Now()
+	return nil
+}
+
+// GetLastUpdate returns the last update time
+func (p *IBondsPlugin) GetLastUpdate() time.Time {
+	return p.lastUpdated
+}
+
+// GetAccounts returns the accounts I bonds are filed under
+func (p *IBondsPlugin) GetAccounts() ([]Account, error) {
+	rows, err := p.db.Query(`
+		SELECT a.id, a.account_name, a.institution, MAX(b.last_updated)
+		FROM i_bonds b
+		JOIN accounts a ON a.id = b.account_id
+		GROUP BY a.id, a.account_name, a.institution
+		ORDER BY a.account_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query I bond accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var id int
+		var account Account
+		if err := rows.Scan(&id, &account.Name, &account.Institution, &account.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan I bond account: %w", err)
+		}
+		account.ID = fmt.Sprintf("%d", id)
+		account.Type = "i_bond"
+		account.DataSource = "manual"
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetBalances returns the redemption value of each I bond account, valuing
+// each I bond with IBond.Value
+func (p *IBondsPlugin) GetBalances() ([]Balance, error) {
+	rates, err := services.NewIBondService(p.db, nil).Rates()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.Query(`
+		SELECT account_id, issue_date, purchase_amount, fixed_rate, last_updated
+		FROM i_bonds
+		WHERE account_id IS NOT NULL
+		ORDER BY account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate I bond balances: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	balances := []Balance{}
+	var total decimal.Decimal
+	lastAccountID := 0
+	for rows.Next() {
+		var accountID int
+		var bond models.IBond
+		if err := rows.Scan(&accountID, &bond.IssueDate, &bond.PurchaseAmount, &bond.FixedRate, &bond.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan I bond balance: %w", err)
+		}
+		if accountID != lastAccountID {
+			total = decimal.Zero
+			lastAccountID = accountID
+			balances = append(balances, Balance{
+				AccountID:  fmt.Sprintf("%d", accountID),
+				Currency:   "USD",
+				DataSource: "manual",
+			})
+		}
+		bond.Value(now, rates)
+		total = total.Add(bond.RedemptionValue)
+		balance := &balances[len(balances)-1]
+		balance.Amount = total.InexactFloat64()
+		if bond.LastUpdated.After(balance.AsOfDate) {
+			balance.AsOfDate = bond.LastUpdated
+		}
+	}
+	return balances, rows.Err()
+}
+
+// GetTransactions returns transactions for this plugin (not applicable for I bonds)
+func (p *IBondsPlugin) GetTransactions(dateRange DateRange) ([]Transaction, error) {
+	return []Transaction{}, nil
+}
+
+// SupportsManualEntry returns true as this is a manual entry plugin
+func (p *IBondsPlugin) SupportsManualEntry() bool {
+	return true
+}
+
+// GetManualEntrySchema returns the schema for manual data entry
+func (p *IBondsPlugin) GetManualEntrySchema() ManualEntrySchema {
+	nameLength, serialLength, notesLength := 100, 20, 1000
+	zero, minAmount, maxRate := 0.0, 25.0, 10.0
+
+	return ManualEntrySchema{
+		Name:        "I Bond",
+		Description: "Add a Series I savings bond held in TreasuryDirect or on paper",
+		Version:     "1.0.0",
+		Fields: []FieldSpec{
+			{
+				Name:         "institution_name",
+				Type:         "text",
+				Label:        "Held At",
+				Description:  "TreasuryDirect, or where paper bonds are kept",
+				Required:     true,
+				DefaultValue: "TreasuryDirect",
+				Validation:   FieldValidation{MaxLength: &nameLength},
+			},
+			{
+				Name:        "serial_number",
+				Type:        "text",
+				Label:       "Serial Number",
+				Description: "Paper bond serial number or TreasuryDirect confirmation number",
+				Validation:  FieldValidation{MaxLength: &serialLength},
+			},
+			{
+				Name:        "issue_date",
+				Type:        "date",
+				Label:       "Issue Date",
+				Description: "Month the bond was bought; I bonds are issued on the first of the month",
+				Required:    true,
+			},
+			{
+				Name:        "purchase_amount",
+				Type:        "number",
+				Label:       "Purchase Amount",
+				Description: "Amount paid, which is the bond's face value",
+				Required:    true,
+				Validation:  FieldValidation{Min: &minAmount},
+				Placeholder: "10000",
+			},
+			{
+				Name:        "fixed_rate",
+				Type:        "number",
+				Label:       "Fixed Rate (%)",
+				Description: "Fixed rate for the life of the bond; taken from the rate schedule for the issue date when left blank",
+				Validation:  FieldValidation{Min: &zero, Max: &maxRate},
+				Placeholder: "1.30",
+			},
+			{
+				Name:        "notes",
+				Type:        "textarea",
+				Label:       "Notes",
+				Validation:  FieldValidation{MaxLength: &notesLength},
+				Placeholder: "Gift, owner, beneficiary, etc.",
+			},
+		},
+	}
+}
+
+// ValidateManualEntry validates manual entry data. On success Data holds the
+// values to store, with the issue date moved to the first of its month and
+// blank optional fields as nil.
+func (p *IBondsPlugin) ValidateManualEntry(data map[string]interface{}) ValidationResult {
+	result := ValidateSettings(p.GetManualEntrySchema(), data)
+	if !result.Valid {
+		return result
+	}
+
+	issue, _ := time.Parse("2006-01-02", result.Data["issue_date"].(string))
+	issue = time.Date(issue.Year(), issue.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if issue.Before(iBondsFirstIssue) || issue.After(time.Now()) {
+		return ValidationResult{Valid: false, Errors: []ValidationError{{
+			Field:   "issue_date",
+			Message: "Issue Date must be between September 1998 and today",
+			Code:    "invalid_date",
+		}}}
+	}
+	result.Data["issue_date"] = issue.Format("2006-01-02")
+
+	for _, field := range []string{"serial_number", "notes"} {
+		if result.Data[field] == "" {
+			result.Data[field] = nil
+		}
+	}
+	return result
+}
+
+// fixedRate returns the fixed rate entered, or the one the rate schedule
+// gave bonds issued on the issue date
+func (p *IBondsPlugin) fixedRate(db DBTX, data map[string]interface{}) (float64, error) {
+	if rate, ok := data["fixed_rate"].(float64); ok {
+		return rate, nil
+	}
+
+	var rate float64
+	err := db.QueryRow(`
+		SELECT fixed_rate FROM i_bond_rates
+		WHERE effective_date <= $1
+		ORDER BY effective_date DESC LIMIT 1
+	`, data["issue_date"]).Scan(&rate)
+	if err == sql.ErrNoRows {
+		return 0, ValidationErrors([]ValidationError{{
+			Field:   "fixed_rate",
+			Message: "Fixed Rate is required for bonds issued before the rate schedule starts",
+			Code:    "required",
+		}})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up I bond fixed rate: %w", err)
+	}
+	return rate, nil
+}
+
+// ProcessManualEntry processes and stores manual entry data
+func (p *IBondsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := p.CreateManualEntryTx(tx, data); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateManualEntryTx inserts an I bond using db, which may be a transaction
+func (p *IBondsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+	fixedRate, err := p.fixedRate(db, validation.Data)
+	if err != nil {
+		return 0, err
+	}
+
+	institution := validation.Data["institution_name"].(string)
+	issue, _ := time.Parse("2006-01-02", validation.Data["issue_date"].(string))
+	identifier := issue.Format("Jan 2006")
+	if serial, ok := validation.Data["serial_number"].(string); ok {
+		identifier = fmt.Sprintf("%s %s", identifier, serial)
+	}
+	accountID, err := GetOrCreateUniquePluginAccount(
+		db,
+		"I Bonds",
+		fmt.Sprintf("%s %s", institution, identifier),
+		"i_bond",
+		institution,
+		"manual",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create unique account for I bond: %w", err)
+	}
+
+	now := time.Now()
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO i_bonds (
+			account_id, institution_name, serial_number, issue_date, purchase_amount,
+			fixed_rate, notes, created_at, last_updated
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`,
+		accountID,
+		institution,
+		validation.Data["serial_number"],
+		validation.Data["issue_date"],
+		validation.Data["purchase_amount"],
+		fixedRate,
+		validation.Data["notes"],
+		now,
+		now,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert I bond: %w", err)
+	}
+
+	p.lastUpdated = now
+	return id, nil
+}
+
+// UpdateManualEntry updates an existing I bond
+func (p *IBondsPlugin) UpdateManualEntry(id int, data map[string]interface{}) error {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return ValidationErrors(validation.Errors)
+	}
+	fixedRate, err := p.fixedRate(p.db, validation.Data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := p.db.Exec(`
+		UPDATE i_bonds SET
+			institution_name = $2,
+			serial_number = $3,
+			issue_date = $4,
+			purchase_amount = $5,
+			fixed_rate = $6,
+			notes = $7,
+			last_updated = $8
+		WHERE id = $1
+	`,
+		id,
+		validation.Data["institution_name"],
+		validation.Data["serial_number"],
+		validation.Data["issue_date"],
+		validation.Data["purchase_amount"],
+		fixedRate,
+		validation.Data["notes"],
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update I bond: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("no I bond found with id %d", id)
+	}
+
+	p.lastUpdated = now
+	return nil
+}
@@ -0,0 +1,499 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Insurance policy types
+var insurancePolicyTypes = []FieldOption{
+	{Value: "term_life", Label: "Term Life"},
+	{Value: "whole_life", Label: "Whole Life"},
+	{Value: "universal_life", Label: "Universal Life"},
+	{Value: "variable_life", Label: "Variable Life"},
+	{Value: "umbrella", Label: "Umbrella Liability"},
+	{Value: "home", Label: "Homeowners / Renters"},
+	{Value: "auto", Label: "Auto"},
+	{Value: "health", Label: "Health"},
+	{Value: "disability", Label: "Disability"},
+	{Value: "long_term_care", Label: "Long-Term Care"},
+	{Value: "other", Label: "Other"},
+}
+
+// cashValuePolicyTypes are the permanent life policies that build cash value
+var cashValuePolicyTypes = map[string]bool{
+	"whole_life":     true,
+	"universal_life": true,
+	"variable_life":  true,
+}
+
+var premiumFrequencies = []FieldOption{
+	{Value: "monthly", Label: "Monthly"},
+	{Value: "quarterly", Label: "Quarterly"},
+	{Value: "semiannual", Label: "Semiannual"},
+	{Value: "annual", Label: "Annual"},
+}
+
+var premiumPaymentsPerYear = map[string]int{
+	"annual":     1,
+	"semiannual": 2,
+	"quarterly":  4,
+	"monthly":    12,
+}
+
+// InsurancePlugin handles manual entry for insurance policies. The cash value
+// of whole, universal and variable life policies, less any policy loans,
+// counts toward net worth.
+type InsurancePlugin struct {
+	db          *sql.DB
+	name        string
+	lastUpdated time.Time
+}
+
+// NewInsurancePlugin creates a new Insurance plugin
+func NewInsurancePlugin(db *sql.DB) *InsurancePlugin {
+	return &InsurancePlugin{
+		db:   db,
+		name: "insurance",
+	}
+}
+
+// GetName returns the plugin name
+func (p *InsurancePlugin) GetName() string {
+	return p.name
+}
+
+// GetFriendlyName returns the user-friendly plugin name
+func (p *InsurancePlugin) GetFriendlyName() string {
+	return "Insurance"
+}
+
+// GetType returns the plugin type
+func (p *InsurancePlugin) GetType() PluginType {
+	return PluginTypeManual
+}
+
+// GetDataSource returns the data source type
+func (p *InsurancePlugin) GetDataSource() DataSourceType {
+	return DataSourceManual
+}
+
+// GetVersion returns the plugin version
+func (p *InsurancePlugin) GetVersion() string {
+	return "1.0.0"
+}
+
+// GetDescription returns the plugin description
+func (p *InsurancePlugin) GetDescription() string {
+	return "Manual entry for insurance policies, their coverage, premiums and renewal dates, and the cash value of permanent life insurance"
+}
+
+// Initialize initializes the plugin. Each policy gets its own account, named
+// after its insurer and policy, so there is nothing to set up.
+func (p *InsurancePlugin) Initialize(config PluginConfig) error {
+	return nil
+}
+
+// Authenticate performs authentication (not needed for manual entry)
+func (p *InsurancePlugin) Authenticate() error {
+	return nil
+}
+
+// Disconnect disconnects from the service (not needed for manual entry)
+func (p *InsurancePlugin) Disconnect() error {
+	return nil
+}
+
+// IsHealthy returns the health status of the plugin
+func (p *InsurancePlugin) IsHealthy() PluginHealth {
+	return PluginHealth{
+		Status:      PluginStatusActive,
+		LastChecked: time.Now(),
+		Metrics: PluginMetrics{
+			SuccessRate: 1.0,
+		},
+	}
+}
+
+// RefreshData refreshes plugin data (not applicable for manual entry)
+func (p *InsurancePlugin) RefreshData() error {
+	p.lastUpdated = time.Now()
+	return nil
+}
+
+// GetLastUpdate returns the last update time
+func (p *InsurancePlugin) GetLastUpdate() time.Time {
+	return p.lastUpdated
+}
+
+// GetAccounts returns the accounts insurance policies are filed under
+func (p *InsurancePlugin) GetAccounts() ([]Account, error) {
+	rows, err := p.db.Query(`
+		SELECT a.id, a.account_name, a.institution, MAX(ip.last_updated)
+		FROM insurance_policies ip
+		JOIN accounts a ON a.id = ip.account_id
+		GROUP BY a.id, a.account_name, a.institution
+		ORDER BY a.account_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insurance accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var id int
+		var account Account
+		if err := rows.Scan(&id, &account.Name, &account.Institution, &account.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan insurance account: %w", err)
+		}
+		account.ID = fmt.Sprintf("%d", id)
+		account.Type = "insurance"
+		account.DataSource = "manual"
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetBalances returns the net cash value of each insurance account
+func (p *InsurancePlugin) GetBalances() ([]Balance, error) {
+	rows, err := p.db.Query(`
+		SELECT account_id, SUM(GREATEST(cash_value - loan_balance, 0)), MAX(last_updated)
+		FROM insurance_policies
+		WHERE account_id IS NOT NULL AND cash_value > 0
+		GROUP BY account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate insurance balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := []Balance{}
+	for rows.Next() {
+		var accountID int
+		var balance Balance
+		if err := rows.Scan(&accountID, &balance.Amount, &balance.AsOfDate); err != nil {
+			return nil, fmt.Errorf("failed to scan insurance balance: %w", err)
+		}
+		balance.AccountID = fmt.Sprintf("%d", accountID)
+		balance.Currency = "USD"
+		balance.DataSource = "manual"
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// GetTransactions returns transactions for this plugin (not applicable for insurance)
+func (p *InsurancePlugin) GetTransactions(dateRange DateRange) ([]Transaction, error) {
+	return []Transaction{}, nil
+}
+
+// SupportsManualEntry returns true as this is a manual entry plugin
+func (p *InsurancePlugin) SupportsManualEntry() bool {
+	return true
+}
+
+// GetManualEntrySchema returns the schema for manual data entry
+func (p *InsurancePlugin) GetManualEntrySchema() ManualEntrySchema {
+	nameLength, numberLength, notesLength := 100, 50, 1000
+	zero, oneMonth, maxTerm, maxNotice := 0.0, 1.0, 600.0, 365.0
+
+	return ManualEntrySchema{
+		Name:        "Insurance Policy",
+		Description: "Add a life, umbrella, property or other insurance policy",
+		Version:     "1.0.0",
+		Fields: []FieldSpec{
+			{
+				Name:        "institution_name",
+				Type:        "text",
+				Label:       "Insurer",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "Northwestern Mutual",
+			},
+			{
+				Name:        "policy_name",
+				Type:        "text",
+				Label:       "Policy Name",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "Whole Life 65",
+			},
+			{
+				Name:     "policy_type",
+				Type:     "select",
+				Label:    "Policy Type",
+				Required: true,
+				Options:  insurancePolicyTypes,
+			},
+			{
+				Name:       "policy_number",
+				Type:       "text",
+				Label:      "Policy Number",
+				Validation: FieldValidation{MaxLength: &numberLength},
+			},
+			{
+				Name:        "coverage_amount",
+				Type:        "number",
+				Label:       "Coverage Amount",
+				Description: "Death benefit or liability limit",
+				Validation:  FieldValidation{Min: &zero},
+				Placeholder: "1000000",
+			},
+			{
+				Name:         "premium_amount",
+				Type:         "number",
+				Label:        "Premium",
+				Description:  "Amount of each premium payment",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &zero},
+				Placeholder:  "250",
+			},
+			{
+				Name:         "premium_frequency",
+				Type:         "select",
+				Label:        "Premium Frequency",
+				Required:     true,
+				DefaultValue: "monthly",
+				Options:      premiumFrequencies,
+			},
+			{
+				Name:         "cash_value",
+				Type:         "number",
+				Label:        "Cash Value",
+				Description:  "Current cash surrender value of a whole, universal or variable life policy",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &zero},
+			},
+			{
+				Name:         "loan_balance",
+				Type:         "number",
+				Label:        "Policy Loan Balance",
+				Description:  "Outstanding loans against the cash value",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &zero},
+			},
+			{
+				Name:  "effective_date",
+				Type:  "date",
+				Label: "Effective Date",
+			},
+			{
+				Name:        "renewal_date",
+				Type:        "date",
+				Label:       "Renewal Date",
+				Description: "Next renewal or premium due date to be reminded of",
+			},
+			{
+				Name:        "term_months",
+				Type:        "number",
+				Label:       "Renewal Term (months)",
+				Description: "Months between renewals; the renewal date moves forward by this once it passes",
+				Validation:  FieldValidation{Min: &oneMonth, Max: &maxTerm},
+				Placeholder: "12",
+			},
+			{
+				Name:         "renewal_notice_days",
+				Type:         "number",
+				Label:        "Renewal Notice (days)",
+				Description:  "Days before the renewal date to send an alert",
+				Required:     true,
+				DefaultValue: 30.0,
+				Validation:   FieldValidation{Min: &zero, Max: &maxNotice},
+			},
+			{
+				Name:        "notes",
+				Type:        "textarea",
+				Label:       "Notes",
+				Validation:  FieldValidation{MaxLength: &notesLength},
+				Placeholder: "Beneficiaries, riders, agent contact, etc.",
+			},
+		},
+	}
+}
+
+// ValidateManualEntry validates manual entry data. On success Data holds the
+// values to store, with the premium frequency as payments a year, whole
+// numbers of months and days as ints and blank optional fields as nil.
+func (p *InsurancePlugin) ValidateManualEntry(data map[string]interface{}) ValidationResult {
+	result := ValidateSettings(p.GetManualEntrySchema(), data)
+	if !result.Valid {
+		return result
+	}
+
+	invalid := func(field, message, code string) ValidationResult {
+		return ValidationResult{Valid: false, Errors: []ValidationError{{Field: field, Message: message, Code: code}}}
+	}
+
+	result.Data["premium_frequency"] = premiumPaymentsPerYear[result.Data["premium_frequency"].(string)]
+
+	cashValue := result.Data["cash_value"].(float64)
+	loanBalance := result.Data["loan_balance"].(float64)
+	if !cashValuePolicyTypes[result.Data["policy_type"].(string)] {
+		if cashValue > 0 {
+			return invalid("cash_value", "Only whole, universal and variable life policies have a cash value", "invalid_cash_value")
+		}
+		if loanBalance > 0 {
+			return invalid("loan_balance", "Only whole, universal and variable life policies can have a policy loan", "invalid_loan")
+		}
+	}
+	if loanBalance > cashValue {
+		return invalid("loan_balance", "Policy Loan Balance can't be more than the Cash Value", "invalid_loan")
+	}
+
+	for field, label := range map[string]string{"term_months": "Renewal Term", "renewal_notice_days": "Renewal Notice"} {
+		if value, ok := result.Data[field].(float64); ok {
+			if value != math.Trunc(value) {
+				return invalid(field, label+" must be a whole number", "invalid_type")
+			}
+			result.Data[field] = int(value)
+		}
+	}
+
+	effective, _ := result.Data["effective_date"].(string)
+	renewal, _ := result.Data["renewal_date"].(string)
+	if effective != "" && renewal != "" && renewal < effective {
+		return invalid("renewal_date", "Renewal Date can't be before the Effective Date", "invalid_date")
+	}
+	for _, field := range []string{"policy_number", "effective_date", "renewal_date", "notes"} {
+		if result.Data[field] == "" {
+			result.Data[field] = nil
+		}
+	}
+	return result
+}
+
+// ProcessManualEntry processes and stores manual entry data
+func (p *InsurancePlugin) ProcessManualEntry(data map[string]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := p.CreateManualEntryTx(tx, data); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateManualEntryTx inserts an insurance policy using db, which may be a
+// transaction
+func (p *InsurancePlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+
+	institution := validation.Data["institution_name"].(string)
+	policyName := validation.Data["policy_name"].(string)
+	accountID, err := GetOrCreateUniquePluginAccount(
+		db,
+		"Insurance",
+		fmt.Sprintf("%s %s", institution, policyName),
+		"insurance",
+		institution,
+		"manual",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create unique account for insurance policy: %w", err)
+	}
+
+	now := time.Now()
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO insurance_policies (
+			account_id, institution_name, policy_name, policy_type, policy_number,
+			coverage_amount, premium_amount, premium_frequency, cash_value, loan_balance,
+			effective_date, renewal_date, term_months, renewal_notice_days, notes,
+			created_at, last_updated
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id
+	`,
+		accountID,
+		institution,
+		policyName,
+		validation.Data["policy_type"],
+		validation.Data["policy_number"],
+		validation.Data["coverage_amount"],
+		validation.Data["premium_amount"],
+		validation.Data["premium_frequency"],
+		validation.Data["cash_value"],
+		validation.Data["loan_balance"],
+		validation.Data["effective_date"],
+		validation.Data["renewal_date"],
+		validation.Data["term_months"],
+		validation.Data["renewal_notice_days"],
+		validation.Data["notes"],
+		now,
+		now,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert insurance policy: %w", err)
+	}
+
+	p.lastUpdated = now
+	return id, nil
+}
+
+// UpdateManualEntry updates an existing insurance policy
+func (p *InsurancePlugin) UpdateManualEntry(id int, data map[string]interface{}) error {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return ValidationErrors(validation.Errors)
+	}
+
+	now := time.Now()
+	result, err := p.db.Exec(`
+		UPDATE insurance_policies SET
+			institution_name = $2,
+			policy_name = $3,
+			policy_type = $4,
+			policy_number = $5,
+			coverage_amount = $6,
+			premium_amount = $7,
+			premium_frequency = $8,
+			cash_value = $9,
+			loan_balance = $10,
+			effective_date = $11,
+			renewal_date = $12,
+			term_months = $13,
+			renewal_notice_days = $14,
+			notes = $15,
+			last_updated = $16
+		WHERE id = $1
+	`,
+		id,
+		validation.Data["institution_name"],
+		validation.Data["policy_name"],
+		validation.Data["policy_type"],
+		validation.Data["policy_number"],
+		validation.Data["coverage_amount"],
+		validation.Data["premium_amount"],
+		validation.Data["premium_frequency"],
+		validation.Data["cash_value"],
+		validation.Data["loan_balance"],
+		validation.Data["effective_date"],
+		validation.Data["renewal_date"],
+		validation.Data["term_months"],
+		validation.Data["renewal_notice_days"],
+		validation.Data["notes"],
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update insurance policy: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("no insurance policy found with id %d", id)
+	}
+
+	p.lastUpdated = now
+	return nil
+}
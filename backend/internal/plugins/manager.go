@@ -4,28 +4,40 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+
+	"networth-dashboard/internal/encryption"
 )
 
 // Manager handles plugin operations and data aggregation
 type Manager struct {
-	db       *sql.DB
-	registry *Registry
+	db          *sql.DB
+	registry    *Registry
+	scheduler   *pluginScheduler
+	initialized bool
 }
 
 // NewManager creates a new plugin manager
 func NewManager(db *sql.DB) *Manager {
 	manager := &Manager{
-		db:       db,
-		registry: NewRegistry(),
+		db:        db,
+		registry:  NewRegistry(),
+		scheduler: newPluginScheduler(),
 	}
 
 	// Register built-in plugins
 	manager.registerBuiltinPlugins()
+	manager.initialized = true
 
 	return manager
 }
 
+// Initialized reports whether built-in plugins have been registered
+func (m *Manager) Initialized() bool {
+	return m != nil && m.initialized
+}
+
 // registerBuiltinPlugins registers the built-in plugins
 func (m *Manager) registerBuiltinPlugins() {
 	// Register Stock Holding plugin
@@ -64,28 +76,202 @@ func (m *Manager) registerBuiltinPlugins() {
 		fmt.Printf("Failed to register Other Assets plugin: %v\n", err)
 	}
 
+	// Register Private Investments plugin
+	privateInvestmentsPlugin := NewPrivateInvestmentsPlugin(m.db)
+	if err := m.registry.Register(privateInvestmentsPlugin); err != nil {
+		fmt.Printf("Failed to register Private Investments plugin: %v\n", err)
+	}
+
+	// Register Bonds plugin
+	bondsPlugin := NewBondsPlugin(m.db)
+	if err := m.registry.Register(bondsPlugin); err != nil {
+		fmt.Printf("Failed to register Bonds plugin: %v\n", err)
+	}
+
+	// Register I Bonds plugin
+	iBondsPlugin := NewIBondsPlugin(m.db)
+	if err := m.registry.Register(iBondsPlugin); err != nil {
+		fmt.Printf("Failed to register I Bonds plugin: %v\n", err)
+	}
+
+	// Register Pensions plugin
+	pensionsPlugin := NewPensionsPlugin(m.db)
+	if err := m.registry.Register(pensionsPlugin); err != nil {
+		fmt.Printf("Failed to register Pensions plugin: %v\n", err)
+	}
+
+	// Register Insurance plugin
+	insurancePlugin := NewInsurancePlugin(m.db)
+	if err := m.registry.Register(insurancePlugin); err != nil {
+		fmt.Printf("Failed to register Insurance plugin: %v\n", err)
+	}
+
 	// Initialize with default configurations
 	m.initializeDefaultConfigs()
 }
 
-// initializeDefaultConfigs sets up default configurations for plugins
+// initializeDefaultConfigs sets up default configurations for plugins,
+// preferring configurations saved through the API
 func (m *Manager) initializeDefaultConfigs() {
-	defaultConfig := PluginConfig{
-		Enabled:  true,
-		Settings: make(map[string]interface{}),
+	saved, err := m.loadSavedConfigs()
+	if err != nil {
+		fmt.Printf("WARNING: Failed to load saved plugin configs, using defaults: %v\n", err)
 	}
 
-	plugins := []string{"stock_holding", "morgan_stanley", "real_estate", "cash_holdings", "crypto_holdings", "other_assets"}
+	plugins := []string{"stock_holding", "morgan_stanley", "real_estate", "cash_holdings", "crypto_holdings", "other_assets", "private_investments", "bonds", "i_bonds", "pensions", "insurance"}
 	for _, pluginName := range plugins {
-		if err := m.registry.Configure(pluginName, defaultConfig); err != nil {
+		config := PluginConfig{
+			Enabled:  true,
+			Settings: make(map[string]interface{}),
+		}
+		if savedConfig, ok := saved[pluginName]; ok {
+			// Settings saved under an older schema fall back to the defaults
+			if result := m.validateConfig(pluginName, savedConfig); result.Valid {
+				config = PluginConfig{Enabled: savedConfig.Enabled, Settings: result.Data, Schedule: savedConfig.Schedule}
+			} else {
+				fmt.Printf("WARNING: Saved config for plugin %s is no longer valid, using defaults\n", pluginName)
+			}
+		}
+
+		if err := m.registry.Configure(pluginName, config); err != nil {
 			fmt.Printf("Failed to configure plugin %s: %v\n", pluginName, err)
 		}
 	}
 }
 
-// ListPlugins returns all registered plugins
+// loadSavedConfigs reads the plugin configurations saved through the API
+func (m *Manager) loadSavedConfigs() (map[string]PluginConfig, error) {
+	rows, err := m.db.Query(`SELECT plugin_name, enabled, settings, schedule FROM plugin_configs`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plugin configs: %w", err)
+	}
+	defer rows.Close()
+
+	configs := make(map[string]PluginConfig)
+	for rows.Next() {
+		var name string
+		var settings []byte
+		config := PluginConfig{}
+		if err := rows.Scan(&name, &config.Enabled, &settings, &config.Schedule); err != nil {
+			return nil, fmt.Errorf("failed to scan plugin config: %w", err)
+		}
+		if err := json.Unmarshal(settings, &config.Settings); err != nil {
+			return nil, fmt.Errorf("failed to decode settings for plugin %s: %w", name, err)
+		}
+		configs[name] = config
+	}
+	return configs, rows.Err()
+}
+
+// GetConfigSchema returns the schema of a plugin's runtime settings. Plugins
+// without settings have an empty schema.
+func (m *Manager) GetConfigSchema(name string) (ManualEntrySchema, error) {
+	plugin, err := m.registry.Get(name)
+	if err != nil {
+		return ManualEntrySchema{}, err
+	}
+
+	if configurable, ok := plugin.(ConfigurablePlugin); ok {
+		return configurable.GetConfigSchema(), nil
+	}
+	return ManualEntrySchema{
+		Name:    plugin.GetFriendlyName() + " Settings",
+		Version: plugin.GetVersion(),
+		Fields:  []FieldSpec{},
+	}, nil
+}
+
+// validateConfig checks settings against a plugin's config schema and parses
+// its schedule
+func (m *Manager) validateConfig(name string, config PluginConfig) ValidationResult {
+	schema, err := m.GetConfigSchema(name)
+	if err != nil {
+		return ValidationResult{Valid: false, Errors: []ValidationError{{Field: "plugin", Message: err.Error(), Code: "not_found"}}}
+	}
+
+	result := ValidateSettings(schema, config.Settings)
+	if config.Schedule != "" {
+		schedule, err := ParseCron(config.Schedule)
+		if err == nil && schedule.Next(time.Now()).IsZero() {
+			err = fmt.Errorf("the schedule never runs")
+		}
+		if err != nil {
+			result.Valid = false
+			result.Data = nil
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "schedule",
+				Message: fmt.Sprintf("Invalid schedule: %v", err),
+				Code:    "invalid_schedule",
+			})
+		}
+	}
+	return result
+}
+
+// SaveConfig validates a plugin configuration, saves it and applies it to the
+// running plugin without a restart. Invalid settings are reported in the
+// result and leave the plugin unchanged.
+func (m *Manager) SaveConfig(name string, config PluginConfig) (ValidationResult, error) {
+	if _, err := m.registry.Get(name); err != nil {
+		return ValidationResult{}, err
+	}
+
+	config.Schedule = strings.TrimSpace(config.Schedule)
+	result := m.validateConfig(name, config)
+	if !result.Valid {
+		return result, nil
+	}
+	config.Settings = result.Data
+
+	settings, err := json.Marshal(config.Settings)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode plugin settings: %w", err)
+	}
+
+	previous, _ := m.registry.GetConfig(name)
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO plugin_configs (plugin_name, enabled, settings, schedule, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (plugin_name) DO UPDATE
+		SET enabled = EXCLUDED.enabled, settings = EXCLUDED.settings, schedule = EXCLUDED.schedule, updated_at = CURRENT_TIMESTAMP
+	`, name, config.Enabled, settings, config.Schedule)
+	if err != nil {
+		return result, fmt.Errorf("failed to save plugin config: %w", err)
+	}
+
+	// Apply before committing so a plugin that rejects the config is not saved
+	if err := m.registry.Configure(name, config); err != nil {
+		return result, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if restoreErr := m.registry.Configure(name, previous); restoreErr != nil {
+			fmt.Printf("WARNING: Failed to restore config for plugin %s: %v\n", name, restoreErr)
+		}
+		return result, fmt.Errorf("failed to commit plugin config: %w", err)
+	}
+
+	return result, nil
+}
+
+// ListPlugins returns all registered plugins with their refresh schedules
 func (m *Manager) ListPlugins() []PluginInfo {
-	return m.registry.List()
+	plugins := m.registry.List()
+	configs := m.registry.Configs()
+	now := time.Now()
+	for i := range plugins {
+		config := configs[plugins[i].Name]
+		plugins[i].Schedule = config.Schedule
+		plugins[i].ScheduleStatus = m.scheduler.status(plugins[i].Name, config, now)
+	}
+	return plugins
 }
 
 // GetPlugin retrieves a specific plugin
@@ -141,13 +327,128 @@ func (m *Manager) ProcessManualEntry(pluginName string, data map[string]interfac
 	// Validate the data first
 	validation := plugin.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
 	}
 
 	// Process the entry
 	return plugin.ProcessManualEntry(data)
 }
 
+// UpsertManualEntry processes manual data entry, applying the conflict policy in
+// data["conflict_policy"] for plugins that match entries on a natural key
+func (m *Manager) UpsertManualEntry(pluginName string, data map[string]interface{}) (*ManualEntryOutcome, error) {
+	plugin, err := m.registry.Get(pluginName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !plugin.SupportsManualEntry() {
+		return nil, fmt.Errorf("plugin %s does not support manual entry", pluginName)
+	}
+
+	policy, err := TakeConflictPolicy(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the data first
+	validation := plugin.ValidateManualEntry(data)
+	if !validation.Valid {
+		return nil, ValidationErrors(validation.Errors)
+	}
+
+	if upsertPlugin, ok := plugin.(UpsertManualEntryPlugin); ok {
+		return upsertPlugin.UpsertManualEntry(data, policy)
+	}
+
+	if err := plugin.ProcessManualEntry(data); err != nil {
+		return nil, err
+	}
+	return &ManualEntryOutcome{Action: ManualEntryCreated}, nil
+}
+
+// BulkCreateManualEntries creates many manual entries in one transaction. Each item
+// runs under its own savepoint so a failure is reported without aborting the rest;
+// when atomic is true any failure rolls back the whole batch.
+func (m *Manager) BulkCreateManualEntries(pluginName string, items []map[string]interface{}, atomic bool) (*BulkCreateResult, error) {
+	plugin, err := m.registry.Get(pluginName)
+	if err != nil {
+		return nil, err
+	}
+
+	txPlugin, ok := plugin.(TxManualEntryPlugin)
+	if !ok || !plugin.SupportsManualEntry() {
+		return nil, fmt.Errorf("plugin %s does not support bulk creation", pluginName)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &BulkCreateResult{Atomic: atomic, Results: make([]BulkCreateItemResult, 0, len(items))}
+	for i, data := range items {
+		item := BulkCreateItemResult{Index: i}
+
+		id, err := m.createInSavepoint(tx, plugin, txPlugin, data)
+		if err != nil {
+			item.Status = BulkItemFailed
+			item.Error = err.Error()
+			result.FailedCount++
+		} else {
+			item.Status = BulkItemCreated
+			item.ID = id
+			result.CreatedCount++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	if atomic && result.FailedCount > 0 {
+		for i := range result.Results {
+			if result.Results[i].Status == BulkItemCreated {
+				result.Results[i].Status = BulkItemRolledBack
+				result.Results[i].ID = 0
+			}
+		}
+		result.CreatedCount = 0
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk create: %w", err)
+	}
+	result.Committed = true
+	return result, nil
+}
+
+// createInSavepoint validates and creates one bulk item, undoing only that item on failure
+func (m *Manager) createInSavepoint(tx *sql.Tx, plugin FinancialDataPlugin, txPlugin TxManualEntryPlugin, data map[string]interface{}) (int, error) {
+	delete(data, ConflictPolicyField)
+
+	validation := plugin.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+
+	if _, err := tx.Exec("SAVEPOINT bulk_item"); err != nil {
+		return 0, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	id, err := txPlugin.CreateManualEntryTx(tx, data)
+	if err != nil {
+		if _, rbErr := tx.Exec("ROLLBACK TO SAVEPOINT bulk_item"); rbErr != nil {
+			return 0, fmt.Errorf("%v (and failed to roll back item: %v)", err, rbErr)
+		}
+		return 0, err
+	}
+
+	if _, err := tx.Exec("RELEASE SAVEPOINT bulk_item"); err != nil {
+		return 0, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return id, nil
+}
+
 // ValidateManualEntry validates manual entry data
 func (m *Manager) ValidateManualEntry(pluginName string, data map[string]interface{}) (ValidationResult, error) {
 	plugin, err := m.registry.Get(pluginName)
@@ -162,6 +463,17 @@ func (m *Manager) ValidateManualEntry(pluginName string, data map[string]interfa
 	return plugin.ValidateManualEntry(data), nil
 }
 
+// SetFieldEncryptor passes the field encryptor to plugins that store sensitive columns
+func (m *Manager) SetFieldEncryptor(encryptor *encryption.FieldEncryptor) {
+	for _, plugin := range m.registry.All() {
+		if p, ok := plugin.(interface {
+			SetFieldEncryptor(encryptor *encryption.FieldEncryptor)
+		}); ok {
+			p.SetFieldEncryptor(encryptor)
+		}
+	}
+}
+
 // GetAllAccounts aggregates accounts from all active plugins
 func (m *Manager) GetAllAccounts() ([]Account, error) {
 	var allAccounts []Account
@@ -2,11 +2,13 @@ package plugins
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"networth-dashboard/internal/models"
 	"networth-dashboard/internal/services"
 )
 
@@ -15,6 +17,7 @@ type MorganStanleyPlugin struct {
 	db          *sql.DB
 	name        string
 	accountID   int
+	accountName string
 	lastUpdated time.Time
 }
 
@@ -58,22 +61,29 @@ func (p *MorganStanleyPlugin) GetDescription() string {
 
 // Initialize initializes the plugin with configuration
 func (p *MorganStanleyPlugin) Initialize(config PluginConfig) error {
-	// Get or create the plugin account
-	accountID, err := GetOrCreatePluginAccount(
+	// Get or create the plugin account, renaming it when the setting changed
+	accountID, accountName, err := configurePluginAccount(
 		p.db,
+		p.accountID,
+		config,
 		"Morgan Stanley Equity Compensation",
 		"equity",
 		"Morgan Stanley",
-		"manual",
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Morgan Stanley account: %w", err)
 	}
 
 	p.accountID = accountID
+	p.accountName = accountName
 	return nil
 }
 
+// GetConfigSchema returns the schema of the plugin's runtime settings
+func (p *MorganStanleyPlugin) GetConfigSchema() ManualEntrySchema {
+	return accountConfigSchema(p.GetFriendlyName(), "Morgan Stanley Equity Compensation")
+}
+
 // Authenticate performs authentication (not needed for manual entry)
 func (p *MorganStanleyPlugin) Authenticate() error {
 	return nil
@@ -100,7 +110,7 @@ func (p *MorganStanleyPlugin) GetAccounts() ([]Account, error) {
 	return []Account{
 		{
 			ID:          fmt.Sprintf("%d", p.accountID),
-			Name:        "Morgan Stanley Equity Compensation",
+			Name:        p.accountName,
 			Type:        "equity",
 			Institution: "Morgan Stanley",
 			DataSource:  "manual",
@@ -150,7 +160,7 @@ func (p *MorganStanleyPlugin) SupportsManualEntry() bool {
 func (p *MorganStanleyPlugin) GetManualEntrySchema() ManualEntrySchema {
 	return ManualEntrySchema{
 		Name:        "Morgan Stanley Equity Grant",
-		Description: "Add or update equity compensation grants (RSUs, Stock Options, ESPP)",
+		Description: "Add or update equity compensation grants (ISOs, NSOs, RSUs, ESPP, RSAs)",
 		Version:     "1.0.0",
 		Fields: []FieldSpec{
 			{
@@ -160,9 +170,11 @@ func (p *MorganStanleyPlugin) GetManualEntrySchema() ManualEntrySchema {
 				Description: "Type of equity grant",
 				Required:    true,
 				Options: []FieldOption{
-					{Value: "rsu", Label: "Restricted Stock Units (RSU)"},
-					{Value: "stock_option", Label: "Stock Options"},
-					{Value: "espp", Label: "Employee Stock Purchase Plan (ESPP)"},
+					{Value: models.GrantTypeISO, Label: "Incentive Stock Options (ISO)"},
+					{Value: models.GrantTypeNSO, Label: "Non-Qualified Stock Options (NSO)"},
+					{Value: models.GrantTypeRSU, Label: "Restricted Stock Units (RSU)"},
+					{Value: models.GrantTypeESPP, Label: "Employee Stock Purchase Plan (ESPP)"},
+					{Value: models.GrantTypeRSA, Label: "Restricted Stock Award (RSA)"},
 				},
 			},
 			{
@@ -224,6 +236,31 @@ func (p *MorganStanleyPlugin) GetManualEntrySchema() ManualEntrySchema {
 				Description: "Date when vesting begins",
 				Required:    true,
 			},
+			{
+				Name:        "expiration_date",
+				Type:        "date",
+				Label:       "Expiration Date",
+				Description: "Last day options can be exercised (ISOs and NSOs only)",
+				Required:    false,
+			},
+			{
+				Name:        "election_83b_filed_date",
+				Type:        "date",
+				Label:       "83(b) Election Filed",
+				Description: "Date an 83(b) election was filed (RSAs and early-exercised options only)",
+				Required:    false,
+			},
+			{
+				Name:        "amt_basis_per_share",
+				Type:        "number",
+				Label:       "AMT Basis Per Share",
+				Description: "Fair market value per share when ISOs were exercised (ISOs only)",
+				Required:    false,
+				Validation: FieldValidation{
+					Min: func(f float64) *float64 { return &f }(0),
+				},
+				Placeholder: "150.00",
+			},
 			{
 				Name:        "vesting_schedule",
 				Type:        "select",
@@ -268,6 +305,9 @@ func (p *MorganStanleyPlugin) ValidateManualEntry(data map[string]interface{}) V
 			Message: "Grant type is required",
 			Code:    "required",
 		})
+	} else {
+		grantType = models.NormalizeGrantType(grantType)
+		data["grant_type"] = grantType
 	}
 
 	// Validate company symbol
@@ -333,7 +373,7 @@ func (p *MorganStanleyPlugin) ValidateManualEntry(data map[string]interface{}) V
 	data["unvested_shares"] = unvestedShares
 
 	// Validate strike price for options
-	if grantType == "stock_option" {
+	if models.IsOptionGrant(grantType) {
 		strikePrice, err := p.validateNumberField(data, "strike_price", true)
 		if err != nil {
 			result.Valid = false
@@ -390,10 +430,66 @@ func (p *MorganStanleyPlugin) ValidateManualEntry(data map[string]interface{}) V
 		})
 	}
 
+	// Validate AMT basis, which is optional
+	if value, present := data["amt_basis_per_share"]; present && value != nil && value != "" {
+		amtBasis, err := p.validateNumberField(data, "amt_basis_per_share", false)
+		if err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, *err)
+		} else if amtBasis < 0 {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "amt_basis_per_share",
+				Message: "AMT basis cannot be negative",
+				Code:    "invalid_range",
+			})
+		}
+	} else {
+		delete(data, "amt_basis_per_share")
+	}
+
+	// Validate the fields specific to the grant type
+	if result.Valid {
+		if err := p.grantInput(data).Validate(); err != nil {
+			var grantErr *models.EquityGrantError
+			if errors.As(err, &grantErr) {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   grantErr.Field,
+					Message: grantErr.Message,
+					Code:    "invalid_for_grant_type",
+				})
+			}
+		}
+	}
+
 	result.Data = data
 	return result
 }
 
+// grantInput collects validated entry data into an equity grant input, for
+// the checks specific to the grant type
+func (p *MorganStanleyPlugin) grantInput(data map[string]interface{}) *models.EquityGrantInput {
+	input := &models.EquityGrantInput{}
+	input.GrantType, _ = data["grant_type"].(string)
+	input.CompanySymbol, _ = data["company_symbol"].(string)
+	input.TotalShares, _ = data["total_shares"].(float64)
+	input.VestedShares, _ = data["vested_shares"].(float64)
+	input.StrikePrice, _ = data["strike_price"].(float64)
+	input.GrantDate, _ = data["grant_date"].(string)
+	input.VestStartDate, _ = data["vest_start_date"].(string)
+	if date, ok := data["expiration_date"].(string); ok && date != "" {
+		input.ExpirationDate = &date
+	}
+	if date, ok := data["election_83b_filed_date"].(string); ok && date != "" {
+		input.Election83bFiledDate = &date
+	}
+	if basis, ok := data["amt_basis_per_share"].(float64); ok {
+		input.AMTBasisPerShare = &basis
+	}
+	return input
+}
+
 // ProcessManualEntry processes the manual entry data
 func (p *MorganStanleyPlugin) ProcessManualEntry(data map[string]interface{}) error {
 	// Validate and extract all fields using helper methods
@@ -455,18 +551,21 @@ func (p *MorganStanleyPlugin) ProcessManualEntry(data map[string]interface{}) er
 		return fmt.Errorf("failed to create unique account for equity grant: %w", accountErr)
 	}
 
-	// Insert equity grant with current price
+	// Insert equity grant with current price; unvested shares are generated
+	// from the total and vested shares
 	query := `
 		INSERT INTO equity_grants (
 			account_id, grant_type, company_symbol, total_shares, vested_shares, 
-			unvested_shares, strike_price, current_price, grant_date, vest_start_date
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			strike_price, current_price, grant_date, vest_start_date,
+			expiration_date, election_83b_filed_date, amt_basis_per_share
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
-	unvestedShares := totalShares - vestedShares
+	grant := p.grantInput(data)
 	_, execErr := p.db.Exec(query,
 		uniqueAccountID, grantType, symbol, totalShares, vestedShares,
-		unvestedShares, strikePrice, currentPrice, grantDate, vestStartDate,
+		strikePrice, currentPrice, grantDate, vestStartDate,
+		models.OptionalDate(grant.ExpirationDate), models.OptionalDate(grant.Election83bFiledDate), grant.AMTBasisPerShare,
 	)
 
 	if execErr != nil {
@@ -482,7 +581,7 @@ func (p *MorganStanleyPlugin) UpdateManualEntry(id int, data map[string]interfac
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
 	}
 
 	// Extract and validate all fields using helper methods with proper error handling
@@ -521,9 +620,6 @@ func (p *MorganStanleyPlugin) UpdateManualEntry(id int, data map[string]interfac
 		return fmt.Errorf("vest_start_date validation failed: %s", validationErr.Message)
 	}
 
-	// Calculate unvested shares
-	unvestedShares := totalShares - vestedShares
-
 	// Get current market price from price service
 	priceService := services.NewPriceService()
 	currentPrice, priceErr := priceService.GetCurrentPrice(companySymbol)
@@ -537,19 +633,23 @@ func (p *MorganStanleyPlugin) UpdateManualEntry(id int, data map[string]interfac
 		currentPrice = existingPrice
 	}
 
-	// Update equity grant
+	// Update equity grant; unvested shares are generated from the total and
+	// vested shares
 	query := `
 		UPDATE equity_grants 
 		SET grant_type = $1, company_symbol = $2, total_shares = $3, vested_shares = $4, 
-		    unvested_shares = $5, strike_price = $6, current_price = $7, grant_date = $8, 
-		    vest_start_date = $9, last_updated = $10
-		WHERE id = $11
+		    strike_price = $5, current_price = $6, grant_date = $7, 
+		    vest_start_date = $8, last_updated = $9, expiration_date = $10,
+		    election_83b_filed_date = $11, amt_basis_per_share = $12
+		WHERE id = $13
 	`
 
+	grant := p.grantInput(data)
 	result, err := p.db.Exec(query,
 		grantType, companySymbol, totalShares, vestedShares,
-		unvestedShares, strikePrice, currentPrice, grantDate, vestStartDate,
-		time.Now(), id,
+		strikePrice, currentPrice, grantDate, vestStartDate,
+		time.Now(), models.OptionalDate(grant.ExpirationDate),
+		models.OptionalDate(grant.Election83bFiledDate), grant.AMTBasisPerShare, id,
 	)
 
 	if err != nil {
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"networth-dashboard/internal/database"
 )
 
 // OtherAssetsPlugin handles manual entry for miscellaneous assets
@@ -14,6 +16,7 @@ type OtherAssetsPlugin struct {
 	db          *sql.DB
 	name        string
 	accountID   int
+	accountName string
 	lastUpdated time.Time
 }
 
@@ -57,22 +60,29 @@ func (p *OtherAssetsPlugin) GetDescription() string {
 
 // Initialize initializes the plugin with configuration
 func (p *OtherAssetsPlugin) Initialize(config PluginConfig) error {
-	// Get or create the plugin account
-	accountID, err := GetOrCreatePluginAccount(
+	// Get or create the plugin account, renaming it when the setting changed
+	accountID, accountName, err := configurePluginAccount(
 		p.db,
+		p.accountID,
+		config,
 		"Other Assets Portfolio",
 		"other_assets",
 		"Manual Entry",
-		"manual",
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Other Assets account: %w", err)
 	}
 
 	p.accountID = accountID
+	p.accountName = accountName
 	return nil
 }
 
+// GetConfigSchema returns the schema of the plugin's runtime settings
+func (p *OtherAssetsPlugin) GetConfigSchema() ManualEntrySchema {
+	return accountConfigSchema(p.GetFriendlyName(), "Other Assets Portfolio")
+}
+
 // Authenticate performs authentication (not needed for manual entry)
 func (p *OtherAssetsPlugin) Authenticate() error {
 	return nil
@@ -99,7 +109,7 @@ func (p *OtherAssetsPlugin) GetAccounts() ([]Account, error) {
 	return []Account{
 		{
 			ID:          fmt.Sprintf("%d", p.accountID),
-			Name:        "Other Assets Portfolio",
+			Name:        p.accountName,
 			Type:        "other_assets",
 			Institution: "Manual Entry",
 			DataSource:  "manual",
@@ -582,6 +592,12 @@ func (p *OtherAssetsPlugin) validateCustomFields(data map[string]interface{}, ca
 
 // ProcessManualEntry processes the manual entry data
 func (p *OtherAssetsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	_, err := p.CreateManualEntryTx(p.db, data)
+	return err
+}
+
+// CreateManualEntryTx inserts a validated other asset using db, which may be a transaction
+func (p *OtherAssetsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
 	categoryID := data["asset_category_id"].(float64)
 	assetName := data["asset_name"].(string)
 	currentValue := data["current_value"].(float64)
@@ -623,7 +639,7 @@ func (p *OtherAssetsPlugin) ProcessManualEntry(data map[string]interface{}) erro
 	// Create unique account for this asset
 	uniqueIdentifier := fmt.Sprintf("%s_%d", strings.ReplaceAll(assetName, " ", "_"), time.Now().Unix())
 	uniqueAccountID, err := GetOrCreateUniquePluginAccount(
-		p.db,
+		db,
 		"Other Assets",
 		uniqueIdentifier,
 		"other_assets",
@@ -631,7 +647,7 @@ func (p *OtherAssetsPlugin) ProcessManualEntry(data map[string]interface{}) erro
 		"manual",
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create unique account for other asset: %w", err)
+		return 0, fmt.Errorf("failed to create unique account for other asset: %w", err)
 	}
 
 	// Insert other asset
@@ -641,20 +657,38 @@ func (p *OtherAssetsPlugin) ProcessManualEntry(data map[string]interface{}) erro
 			purchase_price, amount_owed, purchase_date, description, 
 			custom_fields, valuation_method, created_at, last_updated
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
 	`
 
 	now := time.Now()
-	_, err = p.db.Exec(query,
+	var assetID int
+	err = db.QueryRow(query,
 		uniqueAccountID, int(categoryID), assetName, currentValue,
 		purchasePrice, amountOwed, purchaseDate, description,
 		customFieldsJSON, "manual", now, now,
-	)
+	).Scan(&assetID)
 
 	if err != nil {
-		return fmt.Errorf("failed to save other asset: %w", err)
+		return 0, fmt.Errorf("failed to save other asset: %w", err)
+	}
+
+	if err := recordManualAssetValue(db, assetID, currentValue, now); err != nil {
+		return 0, err
 	}
 
 	p.lastUpdated = now
+	return assetID, nil
+}
+
+// recordManualAssetValue adds a value entered by hand to an asset's valuation history
+func recordManualAssetValue(db DBTX, assetID int, value float64, at time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO asset_valuation_history (asset_id, value, source, valued_at)
+		VALUES ($1, $2, 'manual', $3)
+	`, assetID, value, at)
+	if err != nil {
+		return fmt.Errorf("failed to record asset value: %w", err)
+	}
 	return nil
 }
 
@@ -663,7 +697,7 @@ func (p *OtherAssetsPlugin) UpdateManualEntry(id int, data map[string]interface{
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
 	}
 
 	categoryID := data["asset_category_id"].(float64)
@@ -704,35 +738,55 @@ func (p *OtherAssetsPlugin) UpdateManualEntry(id int, data map[string]interface{
 		}
 	}
 
-	// Update other asset
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the asset, noting whether the value entered differs from the stored one
+	var valueChanged bool
+	err = tx.QueryRow(`SELECT current_value <> $1 FROM miscellaneous_assets WHERE id = $2`+
+		database.DialectOf(p.db).ForUpdate(), currentValue, id).Scan(&valueChanged)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("other asset not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update other asset: %w", err)
+	}
+
+	// Update other asset; a value entered by hand stops automatic revaluation
+	// and, when it differs from the stored one, is added to the valuation history
 	query := `
-		UPDATE miscellaneous_assets 
+		UPDATE miscellaneous_assets
 		SET asset_category_id = $1, asset_name = $2, current_value = $3, 
 		    purchase_price = $4, amount_owed = $5, purchase_date = $6, 
-		    description = $7, custom_fields = $8, last_updated = $9
+		    description = $7, custom_fields = $8, last_updated = $9,
+		    valuation_method = CASE WHEN current_value = $3 THEN valuation_method ELSE 'manual' END
 		WHERE id = $10
 	`
 
-	result, err := p.db.Exec(query,
+	now := time.Now()
+	_, err = tx.Exec(query,
 		int(categoryID), assetName, currentValue,
 		purchasePrice, amountOwed, purchaseDate, description,
-		customFieldsJSON, time.Now(), id,
+		customFieldsJSON, now, id,
 	)
-
 	if err != nil {
 		return fmt.Errorf("failed to update other asset: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check update result: %w", err)
+	if valueChanged {
+		if err := recordManualAssetValue(tx, id, currentValue, now); err != nil {
+			return err
+		}
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("other asset not found")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit other asset update: %w", err)
 	}
 
-	p.lastUpdated = time.Now()
+	p.lastUpdated = now
 	return nil
 }
 
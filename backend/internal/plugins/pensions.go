@@ -0,0 +1,438 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Pension types
+var pensionTypes = []FieldOption{
+	{Value: "pension", Label: "Defined-Benefit Pension"},
+	{Value: "annuity", Label: "Annuity"},
+	{Value: "other", Label: "Other"},
+}
+
+// PensionsPlugin handles manual entry for defined-benefit pensions and
+// annuities, valued at the present value of their remaining payments
+type PensionsPlugin struct {
+	db          *sql.DB
+	name        string
+	lastUpdated time.Time
+}
+
+// NewPensionsPlugin creates a new Pensions plugin
+func NewPensionsPlugin(db *sql.DB) *PensionsPlugin {
+	return &PensionsPlugin{
+		db:   db,
+		name: "pensions",
+	}
+}
+
+// GetName returns the plugin name
+func (p *PensionsPlugin) GetName() string {
+	return p.name
+}
+
+// GetFriendlyName returns the user-friendly plugin name
+func (p *PensionsPlugin) GetFriendlyName() string {
+	return "Pensions & Annuities"
+}
+
+// GetType returns the plugin type
+func (p *PensionsPlugin) GetType() PluginType {
+	return PluginTypeManual
+}
+
+// GetDataSource returns the data source type
+func (p *PensionsPlugin) GetDataSource() DataSourceType {
+	return DataSourceManual
+}
+
+// GetVersion returns the plugin version
+func (p *PensionsPlugin) GetVersion() string {
+	return "1.0.0"
+}
+
+// GetDescription returns the plugin description
+func (p *PensionsPlugin) GetDescription() string {
+	return "Manual entry for defined-benefit pensions and annuities, valued at the present value of their expected payments"
+}
+
+// Initialize initializes the plugin. Each pension gets its own account, named
+// after its provider and plan, so there is nothing to set up.
+func (p *PensionsPlugin) Initialize(config PluginConfig) error {
+	return nil
+}
+
+// Authenticate performs authentication (not needed for manual entry)
+func (p *PensionsPlugin) Authenticate() error {
+	return nil
+}
+
+// Disconnect disconnects from the service (not needed for manual entry)
+func (p *PensionsPlugin) Disconnect() error {
+	return nil
+}
+
+// IsHealthy returns the health status of the plugin
+func (p *PensionsPlugin) IsHealthy() PluginHealth {
+	return PluginHealth{
+		Status:      PluginStatusActive,
+		LastChecked: time.Now(),
+		Metrics: PluginMetrics{
+			SuccessRate: 1.0,
+		},
+	}
+}
+
+// RefreshData refreshes plugin data (not applicable for manual entry)
+func (p *PensionsPlugin) RefreshData() error {
+	p.lastUpdated = time.Now()
+	return nil
+}
+
+// GetLastUpdate returns the last update time
+func (p *PensionsPlugin) GetLastUpdate() time.Time {
+	return p.lastUpdated
+}
+
+// GetAccounts returns the accounts pensions are filed under
+func (p *PensionsPlugin) GetAccounts() ([]Account, error) {
+	rows, err := p.db.Query(`
+		SELECT a.id, a.account_name, a.institution, MAX(pn.last_updated)
+		FROM pensions pn
+		JOIN accounts a ON a.id = pn.account_id
+		GROUP BY a.id, a.account_name, a.institution
+		ORDER BY a.account_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pension accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var id int
+		var account Account
+		if err := rows.Scan(&id, &account.Name, &account.Institution, &account.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan pension account: %w", err)
+		}
+		account.ID = fmt.Sprintf("%d", id)
+		account.Type = "pension"
+		account.DataSource = "manual"
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetBalances returns the present value of each pension account included in
+// net worth, valuing each pension with Pension.Value
+func (p *PensionsPlugin) GetBalances() ([]Balance, error) {
+	rows, err := p.db.Query(`
+		SELECT account_id, birth_date, monthly_benefit, start_age, end_age, cola_rate, discount_rate, last_updated
+		FROM pensions
+		WHERE account_id IS NOT NULL AND include_in_net_worth
+		ORDER BY account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate pension balances: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	balances := []Balance{}
+	var total decimal.Decimal
+	lastAccountID := 0
+	for rows.Next() {
+		var accountID int
+		var pension models.Pension
+		var updated time.Time
+		err := rows.Scan(&accountID, &pension.BirthDate, &pension.MonthlyBenefit, &pension.StartAge, &pension.EndAge,
+			&pension.ColaRate, &pension.DiscountRate, &updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pension balance: %w", err)
+		}
+		if accountID != lastAccountID {
+			total = decimal.Zero
+			lastAccountID = accountID
+			balances = append(balances, Balance{
+				AccountID:  fmt.Sprintf("%d", accountID),
+				Currency:   "USD",
+				DataSource: "manual",
+			})
+		}
+		pension.Value(now)
+		total = total.Add(pension.PresentValue)
+		balance := &balances[len(balances)-1]
+		balance.Amount = total.InexactFloat64()
+		if updated.After(balance.AsOfDate) {
+			balance.AsOfDate = updated
+		}
+	}
+	return balances, rows.Err()
+}
+
+// GetTransactions returns transactions for this plugin (not applicable for pensions)
+func (p *PensionsPlugin) GetTransactions(dateRange DateRange) ([]Transaction, error) {
+	return []Transaction{}, nil
+}
+
+// SupportsManualEntry returns true as this is a manual entry plugin
+func (p *PensionsPlugin) SupportsManualEntry() bool {
+	return true
+}
+
+// GetManualEntrySchema returns the schema for manual data entry
+func (p *PensionsPlugin) GetManualEntrySchema() ManualEntrySchema {
+	nameLength, notesLength := 100, 1000
+	zero, minBenefit, maxAge, minRate, maxRate := 0.0, 0.01, 120.0, -50.0, 50.0
+
+	return ManualEntrySchema{
+		Name:        "Pension or Annuity",
+		Description: "Add a defined-benefit pension or annuity paying a monthly benefit",
+		Version:     "1.0.0",
+		Fields: []FieldSpec{
+			{
+				Name:        "institution_name",
+				Type:        "text",
+				Label:       "Provider",
+				Description: "Employer plan or insurer paying the benefit",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "State Teachers' Retirement System",
+			},
+			{
+				Name:        "plan_name",
+				Type:        "text",
+				Label:       "Plan Name",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "Defined Benefit Plan",
+			},
+			{
+				Name:         "pension_type",
+				Type:         "select",
+				Label:        "Type",
+				Required:     true,
+				DefaultValue: "pension",
+				Options:      pensionTypes,
+			},
+			{
+				Name:        "birth_date",
+				Type:        "date",
+				Label:       "Beneficiary Birth Date",
+				Description: "Ages are counted from this date",
+				Required:    true,
+			},
+			{
+				Name:        "monthly_benefit",
+				Type:        "number",
+				Label:       "Monthly Benefit",
+				Description: "Expected monthly payment when benefits start",
+				Required:    true,
+				Validation:  FieldValidation{Min: &minBenefit},
+				Placeholder: "2500",
+			},
+			{
+				Name:        "start_age",
+				Type:        "number",
+				Label:       "Start Age",
+				Description: "Age payments start; a past age for a pension already being paid",
+				Required:    true,
+				Validation:  FieldValidation{Min: &zero, Max: &maxAge},
+				Placeholder: "65",
+			},
+			{
+				Name:         "end_age",
+				Type:         "number",
+				Label:        "End Age",
+				Description:  "Age payments are expected to stop, such as life expectancy or the end of a period-certain annuity",
+				Required:     true,
+				DefaultValue: 90.0,
+				Validation:   FieldValidation{Min: &zero, Max: &maxAge},
+			},
+			{
+				Name:         "cola_rate",
+				Type:         "number",
+				Label:        "COLA (%)",
+				Description:  "Annual cost-of-living adjustment once payments start",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &minRate, Max: &maxRate},
+				Placeholder:  "2",
+			},
+			{
+				Name:         "discount_rate",
+				Type:         "number",
+				Label:        "Discount Rate (%)",
+				Description:  "Annual rate future payments are discounted at",
+				Required:     true,
+				DefaultValue: 4.0,
+				Validation:   FieldValidation{Min: &minRate, Max: &maxRate},
+			},
+			{
+				Name:         "include_in_net_worth",
+				Type:         "boolean",
+				Label:        "Include in Net Worth",
+				Description:  "Count the present value toward net worth; off by default since a pension can't be sold",
+				DefaultValue: false,
+			},
+			{
+				Name:        "notes",
+				Type:        "textarea",
+				Label:       "Notes",
+				Validation:  FieldValidation{MaxLength: &notesLength},
+				Placeholder: "Survivor benefit, lump-sum option, etc.",
+			},
+		},
+	}
+}
+
+// ValidateManualEntry validates manual entry data. On success Data holds the
+// values to store, with blank notes as nil.
+func (p *PensionsPlugin) ValidateManualEntry(data map[string]interface{}) ValidationResult {
+	result := ValidateSettings(p.GetManualEntrySchema(), data)
+	if !result.Valid {
+		return result
+	}
+
+	invalid := func(field, message, code string) ValidationResult {
+		return ValidationResult{Valid: false, Errors: []ValidationError{{Field: field, Message: message, Code: code}}}
+	}
+
+	birth, _ := time.Parse("2006-01-02", result.Data["birth_date"].(string))
+	if birth.After(time.Now()) {
+		return invalid("birth_date", "Beneficiary Birth Date can't be in the future", "invalid_date")
+	}
+	if result.Data["end_age"].(float64) <= result.Data["start_age"].(float64) {
+		return invalid("end_age", "End Age must be after Start Age", "invalid_age")
+	}
+	if result.Data["notes"] == "" {
+		result.Data["notes"] = nil
+	}
+	return result
+}
+
+// ProcessManualEntry processes and stores manual entry data
+func (p *PensionsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := p.CreateManualEntryTx(tx, data); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateManualEntryTx inserts a pension using db, which may be a transaction
+func (p *PensionsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+
+	institution := validation.Data["institution_name"].(string)
+	planName := validation.Data["plan_name"].(string)
+	accountID, err := GetOrCreateUniquePluginAccount(
+		db,
+		"Pensions",
+		fmt.Sprintf("%s %s", institution, planName),
+		"pension",
+		institution,
+		"manual",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create unique account for pension: %w", err)
+	}
+
+	now := time.Now()
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO pensions (
+			account_id, institution_name, plan_name, pension_type, birth_date, monthly_benefit,
+			start_age, end_age, cola_rate, discount_rate, include_in_net_worth, notes,
+			created_at, last_updated
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`,
+		accountID,
+		institution,
+		planName,
+		validation.Data["pension_type"],
+		validation.Data["birth_date"],
+		validation.Data["monthly_benefit"],
+		validation.Data["start_age"],
+		validation.Data["end_age"],
+		validation.Data["cola_rate"],
+		validation.Data["discount_rate"],
+		validation.Data["include_in_net_worth"],
+		validation.Data["notes"],
+		now,
+		now,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert pension: %w", err)
+	}
+
+	p.lastUpdated = now
+	return id, nil
+}
+
+// UpdateManualEntry updates an existing pension
+func (p *PensionsPlugin) UpdateManualEntry(id int, data map[string]interface{}) error {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return ValidationErrors(validation.Errors)
+	}
+
+	now := time.Now()
+	result, err := p.db.Exec(`
+		UPDATE pensions SET
+			institution_name = $2,
+			plan_name = $3,
+			pension_type = $4,
+			birth_date = $5,
+			monthly_benefit = $6,
+			start_age = $7,
+			end_age = $8,
+			cola_rate = $9,
+			discount_rate = $10,
+			include_in_net_worth = $11,
+			notes = $12,
+			last_updated = $13
+		WHERE id = $1
+	`,
+		id,
+		validation.Data["institution_name"],
+		validation.Data["plan_name"],
+		validation.Data["pension_type"],
+		validation.Data["birth_date"],
+		validation.Data["monthly_benefit"],
+		validation.Data["start_age"],
+		validation.Data["end_age"],
+		validation.Data["cola_rate"],
+		validation.Data["discount_rate"],
+		validation.Data["include_in_net_worth"],
+		validation.Data["notes"],
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update pension: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("no pension found with id %d", id)
+	}
+
+	p.lastUpdated = now
+	return nil
+}
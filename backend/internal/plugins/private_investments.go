@@ -0,0 +1,431 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// Private investment types
+var privateInvestmentTypes = []FieldOption{
+	{Value: "angel", Label: "Angel / Direct Investment"},
+	{Value: "private_equity", Label: "Private Equity Fund"},
+	{Value: "venture_capital", Label: "Venture Capital Fund"},
+	{Value: "real_estate_fund", Label: "Real Estate Fund"},
+	{Value: "hedge_fund", Label: "Hedge Fund"},
+	{Value: "other", Label: "Other"},
+}
+
+// PrivateInvestmentsPlugin handles manual entry for illiquid private
+// investments. Capital calls, distributions and later NAV updates are
+// recorded through the private investments endpoints.
+type PrivateInvestmentsPlugin struct {
+	db          *sql.DB
+	name        string
+	lastUpdated time.Time
+}
+
+// NewPrivateInvestmentsPlugin creates a new Private Investments plugin
+func NewPrivateInvestmentsPlugin(db *sql.DB) *PrivateInvestmentsPlugin {
+	return &PrivateInvestmentsPlugin{
+		db:   db,
+		name: "private_investments",
+	}
+}
+
+// GetName returns the plugin name
+func (p *PrivateInvestmentsPlugin) GetName() string {
+	return p.name
+}
+
+// GetFriendlyName returns the user-friendly plugin name
+func (p *PrivateInvestmentsPlugin) GetFriendlyName() string {
+	return "Private Investments"
+}
+
+// GetType returns the plugin type
+func (p *PrivateInvestmentsPlugin) GetType() PluginType {
+	return PluginTypeManual
+}
+
+// GetDataSource returns the data source type
+func (p *PrivateInvestmentsPlugin) GetDataSource() DataSourceType {
+	return DataSourceManual
+}
+
+// GetVersion returns the plugin version
+func (p *PrivateInvestmentsPlugin) GetVersion() string {
+	return "1.0.0"
+}
+
+// GetDescription returns the plugin description
+func (p *PrivateInvestmentsPlugin) GetDescription() string {
+	return "Manual entry for angel investments and private equity, venture and other funds, valued at their last reported NAV"
+}
+
+// Initialize initializes the plugin. Each investment gets its own account,
+// named after its sponsor and investment, so there is nothing to set up.
+func (p *PrivateInvestmentsPlugin) Initialize(config PluginConfig) error {
+	return nil
+}
+
+// Authenticate performs authentication (not needed for manual entry)
+func (p *PrivateInvestmentsPlugin) Authenticate() error {
+	return nil
+}
+
+// Disconnect disconnects from the service (not needed for manual entry)
+func (p *PrivateInvestmentsPlugin) Disconnect() error {
+	return nil
+}
+
+// IsHealthy returns the health status of the plugin
+func (p *PrivateInvestmentsPlugin) IsHealthy() PluginHealth {
+	return PluginHealth{
+		Status:      PluginStatusActive,
+		LastChecked: time.Now(),
+		Metrics: PluginMetrics{
+			SuccessRate: 1.0,
+		},
+	}
+}
+
+// RefreshData refreshes plugin data (not applicable for manual entry)
+func (p *PrivateInvestmentsPlugin) RefreshData() error {
+	p.lastUpdated = time.Now()
+	return nil
+}
+
+// GetLastUpdate returns the last update time
+func (p *PrivateInvestmentsPlugin) GetLastUpdate() time.Time {
+	return p.lastUpdated
+}
+
+// GetAccounts returns the accounts private investments are filed under
+func (p *PrivateInvestmentsPlugin) GetAccounts() ([]Account, error) {
+	rows, err := p.db.Query(`
+		SELECT a.id, a.account_name, a.institution, MAX(pi.last_updated)
+		FROM private_investments pi
+		JOIN accounts a ON a.id = pi.account_id
+		GROUP BY a.id, a.account_name, a.institution
+		ORDER BY a.account_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query private investment accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var id int
+		var account Account
+		if err := rows.Scan(&id, &account.Name, &account.Institution, &account.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan private investment account: %w", err)
+		}
+		account.ID = fmt.Sprintf("%d", id)
+		account.Type = "private_investment"
+		account.DataSource = "manual"
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetBalances returns the current value of each private investment account
+func (p *PrivateInvestmentsPlugin) GetBalances() ([]Balance, error) {
+	rows, err := p.db.Query(`
+		SELECT account_id, SUM(current_value), MAX(last_updated)
+		FROM private_investments
+		WHERE account_id IS NOT NULL
+		GROUP BY account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate private investment balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := []Balance{}
+	for rows.Next() {
+		var accountID int
+		var balance Balance
+		if err := rows.Scan(&accountID, &balance.Amount, &balance.AsOfDate); err != nil {
+			return nil, fmt.Errorf("failed to scan private investment balance: %w", err)
+		}
+		balance.AccountID = fmt.Sprintf("%d", accountID)
+		balance.Currency = "USD"
+		balance.DataSource = "manual"
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// GetTransactions returns transactions for this plugin. Capital calls and
+// distributions are listed through the private investments endpoints.
+func (p *PrivateInvestmentsPlugin) GetTransactions(dateRange DateRange) ([]Transaction, error) {
+	return []Transaction{}, nil
+}
+
+// SupportsManualEntry returns true as this is a manual entry plugin
+func (p *PrivateInvestmentsPlugin) SupportsManualEntry() bool {
+	return true
+}
+
+// GetManualEntrySchema returns the schema for manual data entry
+func (p *PrivateInvestmentsPlugin) GetManualEntrySchema() ManualEntrySchema {
+	nameLength, notesLength := 100, 1000
+	zero := 0.0
+	minYear, maxYear := 1900.0, 2200.0
+
+	return ManualEntrySchema{
+		Name:        "Private Investment",
+		Description: "Add an angel investment or a commitment to a private fund",
+		Version:     "1.0.0",
+		Fields: []FieldSpec{
+			{
+				Name:        "sponsor",
+				Type:        "text",
+				Label:       "Sponsor",
+				Description: "Fund manager, or the company for a direct investment",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "Sequoia Capital",
+			},
+			{
+				Name:        "investment_name",
+				Type:        "text",
+				Label:       "Investment Name",
+				Description: "Fund or round to identify this investment",
+				Required:    true,
+				Validation:  FieldValidation{MaxLength: &nameLength},
+				Placeholder: "Fund XV, Series A",
+			},
+			{
+				Name:         "investment_type",
+				Type:         "select",
+				Label:        "Investment Type",
+				Required:     true,
+				DefaultValue: "private_equity",
+				Options:      privateInvestmentTypes,
+			},
+			{
+				Name:         "committed_capital",
+				Type:         "number",
+				Label:        "Committed Capital",
+				Description:  "Total capital committed, called or not",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &zero},
+				Placeholder:  "250000",
+			},
+			{
+				Name:         "current_nav",
+				Type:         "number",
+				Label:        "Net Asset Value",
+				Description:  "Value of your interest as last reported by the sponsor",
+				Required:     true,
+				DefaultValue: 0.0,
+				Validation:   FieldValidation{Min: &zero},
+				Placeholder:  "180000",
+			},
+			{
+				Name:        "nav_date",
+				Type:        "date",
+				Label:       "NAV Date",
+				Description: "Date of the reported NAV; defaults to today",
+			},
+			{
+				Name:        "vintage_year",
+				Type:        "number",
+				Label:       "Vintage Year",
+				Description: "Year of the fund's first capital call",
+				Validation:  FieldValidation{Min: &minYear, Max: &maxYear},
+				Placeholder: "2024",
+			},
+			{
+				Name:        "notes",
+				Type:        "textarea",
+				Label:       "Notes",
+				Validation:  FieldValidation{MaxLength: &notesLength},
+				Placeholder: "Side letter terms, carry, etc.",
+			},
+		},
+	}
+}
+
+// ValidateManualEntry validates manual entry data. On success Data holds the
+// values to store, with a blank NAV date meaning today.
+func (p *PrivateInvestmentsPlugin) ValidateManualEntry(data map[string]interface{}) ValidationResult {
+	result := ValidateSettings(p.GetManualEntrySchema(), data)
+	if !result.Valid {
+		return result
+	}
+
+	if result.Data["nav_date"] == nil || result.Data["nav_date"] == "" {
+		result.Data["nav_date"] = time.Now().Format("2006-01-02")
+	}
+	if year, ok := result.Data["vintage_year"].(float64); ok {
+		if year != float64(int(year)) {
+			return ValidationResult{Valid: false, Errors: []ValidationError{{
+				Field:   "vintage_year",
+				Message: "Vintage Year must be a whole year",
+				Code:    "invalid_type",
+			}}}
+		}
+		result.Data["vintage_year"] = int(year)
+	}
+	if result.Data["notes"] == "" {
+		result.Data["notes"] = nil
+	}
+	return result
+}
+
+// ProcessManualEntry processes and stores manual entry data
+func (p *PrivateInvestmentsPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := p.CreateManualEntryTx(tx, data); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateManualEntryTx inserts a private investment and its opening NAV using
+// db, which may be a transaction
+func (p *PrivateInvestmentsPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return 0, ValidationErrors(validation.Errors)
+	}
+
+	sponsor := validation.Data["sponsor"].(string)
+	investmentName := validation.Data["investment_name"].(string)
+	accountID, err := GetOrCreateUniquePluginAccount(
+		db,
+		"Private Investments",
+		fmt.Sprintf("%s %s", sponsor, investmentName),
+		"private_investment",
+		sponsor,
+		"manual",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create unique account for private investment: %w", err)
+	}
+
+	now := time.Now()
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO private_investments (
+			account_id, investment_name, investment_type, sponsor, committed_capital,
+			current_nav, nav_date, vintage_year, notes, created_at, last_updated
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`,
+		accountID,
+		investmentName,
+		validation.Data["investment_type"],
+		sponsor,
+		validation.Data["committed_capital"],
+		validation.Data["current_nav"],
+		validation.Data["nav_date"],
+		validation.Data["vintage_year"],
+		validation.Data["notes"],
+		now,
+		now,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert private investment: %w", err)
+	}
+
+	if err := recordPrivateInvestmentNAV(db, id, validation.Data["current_nav"], validation.Data["nav_date"]); err != nil {
+		return 0, err
+	}
+
+	p.lastUpdated = now
+	return id, nil
+}
+
+// UpdateManualEntry updates an existing private investment, recording its NAV
+// in the history when the NAV or its date changed
+func (p *PrivateInvestmentsPlugin) UpdateManualEntry(id int, data map[string]interface{}) error {
+	validation := p.ValidateManualEntry(data)
+	if !validation.Valid {
+		return ValidationErrors(validation.Errors)
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dialect := database.DialectOf(p.db)
+	var navChanged bool
+	err = tx.QueryRow(`
+		SELECT current_nav <> $2 OR `+dialect.Date("nav_date")+` <> `+dialect.Date("$3")+`
+		FROM private_investments
+		WHERE id = $1`+dialect.ForUpdate(), id, validation.Data["current_nav"], validation.Data["nav_date"]).Scan(&navChanged)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no private investment found with id %d", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch private investment: %w", err)
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		UPDATE private_investments SET
+			investment_name = $2,
+			investment_type = $3,
+			sponsor = $4,
+			committed_capital = $5,
+			current_nav = $6,
+			nav_date = $7,
+			vintage_year = $8,
+			notes = $9,
+			last_updated = $10
+		WHERE id = $1
+	`,
+		id,
+		validation.Data["investment_name"],
+		validation.Data["investment_type"],
+		validation.Data["sponsor"],
+		validation.Data["committed_capital"],
+		validation.Data["current_nav"],
+		validation.Data["nav_date"],
+		validation.Data["vintage_year"],
+		validation.Data["notes"],
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update private investment: %w", err)
+	}
+
+	if navChanged {
+		if err := recordPrivateInvestmentNAV(tx, id, validation.Data["current_nav"], validation.Data["nav_date"]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit private investment: %w", err)
+	}
+	p.lastUpdated = now
+	return nil
+}
+
+// recordPrivateInvestmentNAV adds a reported NAV to an investment's history
+func recordPrivateInvestmentNAV(db DBTX, id int, nav, navDate interface{}) error {
+	_, err := db.Exec(`
+		INSERT INTO private_investment_navs (private_investment_id, nav, nav_date)
+		VALUES ($1, $2, $3)
+	`, id, nav, navDate)
+	if err != nil {
+		return fmt.Errorf("failed to record private investment NAV: %w", err)
+	}
+	return nil
+}
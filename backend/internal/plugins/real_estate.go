@@ -13,6 +13,7 @@ type RealEstatePlugin struct {
 	db          *sql.DB
 	name        string
 	accountID   int
+	accountName string
 	lastUpdated time.Time
 }
 
@@ -56,22 +57,29 @@ func (p *RealEstatePlugin) GetDescription() string {
 
 // Initialize initializes the plugin with configuration
 func (p *RealEstatePlugin) Initialize(config PluginConfig) error {
-	// Get or create the plugin account
-	accountID, err := GetOrCreatePluginAccount(
+	// Get or create the plugin account, renaming it when the setting changed
+	accountID, accountName, err := configurePluginAccount(
 		p.db,
+		p.accountID,
+		config,
 		"Real Estate Portfolio",
 		"real_estate",
 		"Manual Entry",
-		"manual",
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Real Estate account: %w", err)
 	}
 
 	p.accountID = accountID
+	p.accountName = accountName
 	return nil
 }
 
+// GetConfigSchema returns the schema of the plugin's runtime settings
+func (p *RealEstatePlugin) GetConfigSchema() ManualEntrySchema {
+	return accountConfigSchema(p.GetFriendlyName(), "Real Estate Portfolio")
+}
+
 // Authenticate performs authentication (not needed for manual entry)
 func (p *RealEstatePlugin) Authenticate() error {
 	return nil
@@ -98,7 +106,7 @@ func (p *RealEstatePlugin) GetAccounts() ([]Account, error) {
 	return []Account{
 		{
 			ID:          fmt.Sprintf("%d", p.accountID),
-			Name:        "Real Estate Portfolio",
+			Name:        p.accountName,
 			Type:        "real_estate",
 			Institution: "Manual Entry",
 			DataSource:  "manual",
@@ -111,7 +119,7 @@ func (p *RealEstatePlugin) GetAccounts() ([]Account, error) {
 func (p *RealEstatePlugin) GetBalances() ([]Balance, error) {
 	// Calculate total property value
 	query := `
-		SELECT COALESCE(SUM(current_value), 0) as total_value
+		SELECT COALESCE(SUM(current_value * ownership_percentage / 100), 0) as total_value
 		FROM real_estate_properties 
 		WHERE account_id = $1
 	`
@@ -304,6 +312,19 @@ func (p *RealEstatePlugin) GetManualEntrySchema() ManualEntrySchema {
 				},
 				Placeholder: "200000",
 			},
+			{
+				Name:         "ownership_percentage",
+				Type:         "number",
+				Label:        "Ownership Percentage",
+				Description:  "Your share of the property. Enter full-property figures; this percentage is applied to value, mortgage, income and expenses",
+				Required:     false,
+				DefaultValue: 100,
+				Validation: FieldValidation{
+					Min: func(f float64) *float64 { return &f }(0.01),
+					Max: func(f float64) *float64 { return &f }(100),
+				},
+				Placeholder: "100",
+			},
 			{
 				Name:        "purchase_date",
 				Type:        "date",
@@ -355,6 +376,24 @@ func (p *RealEstatePlugin) GetManualEntrySchema() ManualEntrySchema {
 				},
 				Placeholder: "8000",
 			},
+			{
+				Name:        "improvement_value",
+				Type:        "number",
+				Label:       "Building Value",
+				Description: "Part of the purchase price paid for the building rather than the land. Investment properties depreciate it straight-line over 27.5 years (39 for commercial)",
+				Required:    false,
+				Validation: FieldValidation{
+					Min: func(f float64) *float64 { return &f }(0),
+				},
+				Placeholder: "280000",
+			},
+			{
+				Name:        "placed_in_service_date",
+				Type:        "date",
+				Label:       "Placed in Service",
+				Description: "Date the property was first available for rent (defaults to the purchase date)",
+				Required:    false,
+			},
 			{
 				Name:        "notes",
 				Type:        "textarea",
@@ -450,12 +489,61 @@ func (p *RealEstatePlugin) ValidateManualEntry(data map[string]interface{}) Vali
 		}
 	}
 
+	// Validate ownership percentage (optional, defaults to 100%)
+	ownership, err := p.validateNumberField(data, "ownership_percentage", false)
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, *err)
+	} else if data["ownership_percentage"] == nil {
+		data["ownership_percentage"] = 100.0
+	} else if ownership <= 0 || ownership > 100 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "ownership_percentage",
+			Message: "Ownership percentage must be greater than 0 and at most 100",
+			Code:    "invalid_range",
+		})
+	}
+
 	// Validate purchase date
-	if _, err := p.validateDateField(data, "purchase_date", true); err != nil {
+	purchaseDate, err := p.validateDateField(data, "purchase_date", true)
+	if err != nil {
 		result.Valid = false
 		result.Errors = append(result.Errors, *err)
 	}
 
+	// Validate depreciation inputs (optional)
+	if raw, exists := data["improvement_value"]; exists && raw != nil {
+		improvementValue, err := p.validateNumberField(data, "improvement_value", false)
+		if err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, *err)
+		} else if data["improvement_value"] != nil && (improvementValue < 0 || improvementValue > purchasePrice) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "improvement_value",
+				Message: "Building value must be between 0 and the purchase price",
+				Code:    "invalid_range",
+			})
+		}
+	}
+	if raw, exists := data["placed_in_service_date"]; exists && raw != nil && raw != "" {
+		inService, err := p.validateDateField(data, "placed_in_service_date", false)
+		if err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, *err)
+		} else if inService.Before(purchaseDate) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "placed_in_service_date",
+				Message: "Placed in service date cannot be before the purchase date",
+				Code:    "invalid_range",
+			})
+		}
+	} else {
+		data["placed_in_service_date"] = nil
+	}
+
 	// Validate optional numeric fields
 	optionalFields := []string{"property_size_sqft", "lot_size_acres", "rental_income_monthly", "property_tax_annual"}
 	for _, field := range optionalFields {
@@ -471,8 +559,82 @@ func (p *RealEstatePlugin) ValidateManualEntry(data map[string]interface{}) Vali
 	return result
 }
 
-// ProcessManualEntry processes the manual entry data
+// ProcessManualEntry processes the manual entry data. An existing property at the
+// same address is updated unless data["conflict_policy"] says otherwise.
 func (p *RealEstatePlugin) ProcessManualEntry(data map[string]interface{}) error {
+	policy, err := TakeConflictPolicy(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.UpsertManualEntry(data, policy)
+	return err
+}
+
+// findExistingProperty returns the ID of a property matching the entry's address,
+// or its name when no street address is given
+func (p *RealEstatePlugin) findExistingProperty(data map[string]interface{}) (int, error) {
+	streetAddress, _ := data["street_address"].(string)
+	streetAddress = strings.TrimSpace(streetAddress)
+
+	var existingID int
+	var err error
+	if streetAddress != "" {
+		city, _ := data["city"].(string)
+		state, _ := data["state"].(string)
+		zipCode, _ := data["zip_code"].(string)
+		err = p.db.QueryRow(`
+			SELECT id FROM real_estate_properties
+			WHERE LOWER(TRIM(street_address)) = LOWER($1)
+			  AND LOWER(COALESCE(city, '')) = LOWER($2)
+			  AND UPPER(COALESCE(state, '')) = UPPER($3)
+			  AND COALESCE(zip_code, '') = $4
+			ORDER BY id LIMIT 1
+		`, streetAddress, strings.TrimSpace(city), strings.TrimSpace(state), strings.TrimSpace(zipCode)).Scan(&existingID)
+	} else {
+		propertyName, _ := data["property_name"].(string)
+		err = p.db.QueryRow(`
+			SELECT id FROM real_estate_properties
+			WHERE LOWER(property_name) = LOWER($1) AND COALESCE(street_address, '') = ''
+			ORDER BY id LIMIT 1
+		`, propertyName).Scan(&existingID)
+	}
+
+	return existingID, err
+}
+
+// propertyEntry describes the property in manual entry data by its address,
+// or by its name when it has none
+func propertyEntry(data map[string]interface{}) string {
+	if streetAddress, _ := data["street_address"].(string); strings.TrimSpace(streetAddress) != "" {
+		return "a property at " + strings.TrimSpace(streetAddress)
+	}
+	propertyName, _ := data["property_name"].(string)
+	return fmt.Sprintf("a property named %s", propertyName)
+}
+
+// UpsertManualEntry stores a property, matching existing properties on address
+func (p *RealEstatePlugin) UpsertManualEntry(data map[string]interface{}, policy ConflictPolicy) (*ManualEntryOutcome, error) {
+	if policy != ConflictPolicyDuplicate {
+		existingID, err := p.findExistingProperty(data)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to check for existing property: %w", err)
+		}
+		if err == nil {
+			switch policy {
+			case ConflictPolicySkip:
+				return &ManualEntryOutcome{Action: ManualEntrySkipped, ID: existingID}, nil
+			case ConflictPolicyReject:
+				return nil, duplicateEntry(p.db, "real_estate_properties", existingID, nil, propertyEntry(data))
+			}
+			previous := snapshotRow(p.db, "real_estate_properties", existingID)
+			if err := p.UpdateManualEntry(existingID, data); err != nil {
+				return nil, err
+			}
+			return &ManualEntryOutcome{Action: ManualEntryUpdated, ID: existingID, Previous: previous}, nil
+		}
+	}
+
 	propertyType := data["property_type"].(string)
 	propertyName := data["property_name"].(string)
 	purchasePrice := data["purchase_price"].(float64)
@@ -483,6 +645,11 @@ func (p *RealEstatePlugin) ProcessManualEntry(data map[string]interface{}) error
 		outstandingMortgage = om.(float64)
 	}
 
+	ownershipPercentage := 100.0
+	if op, exists := data["ownership_percentage"]; exists && op != nil {
+		ownershipPercentage = op.(float64)
+	}
+
 	purchaseDate, _ := time.Parse("2006-01-02", data["purchase_date"].(string))
 
 	// Optional fields
@@ -505,8 +672,7 @@ func (p *RealEstatePlugin) ProcessManualEntry(data map[string]interface{}) error
 		notes = n.(string)
 	}
 
-	// Calculate equity
-	equity := currentValue - outstandingMortgage
+	improvementValue, placedInServiceDate := depreciationInputs(data)
 
 	// Extract address fields
 	var streetAddress, city, state, zipCode string
@@ -533,30 +699,34 @@ func (p *RealEstatePlugin) ProcessManualEntry(data map[string]interface{}) error
 		"manual",
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create unique account for property: %w", err)
+		return nil, fmt.Errorf("failed to create unique account for property: %w", err)
 	}
 
-	// Insert real estate property
+	// Insert real estate property; equity is generated from the value and mortgage
 	query := `
 		INSERT INTO real_estate_properties (
 			account_id, property_type, property_name, street_address, city, state, zip_code,
-			purchase_price, current_value, outstanding_mortgage, equity, purchase_date, 
-			property_size_sqft, lot_size_acres, rental_income_monthly, property_tax_annual, notes
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			purchase_price, current_value, outstanding_mortgage, purchase_date, 
+			property_size_sqft, lot_size_acres, rental_income_monthly, property_tax_annual, notes,
+			ownership_percentage, improvement_value, placed_in_service_date
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING id
 	`
 
-	_, err = p.db.Exec(query,
+	var propertyID int
+	err = p.db.QueryRow(query,
 		uniqueAccountID, propertyType, propertyName, streetAddress, city, state, zipCode,
-		purchasePrice, currentValue, outstandingMortgage, equity, purchaseDate, 
+		purchasePrice, currentValue, outstandingMortgage, purchaseDate, 
 		propertySizeSqft, lotSizeAcres, rentalIncomeMonthly, propertyTaxAnnual, notes,
-	)
+		ownershipPercentage, improvementValue, placedInServiceDate,
+	).Scan(&propertyID)
 
 	if err != nil {
-		return fmt.Errorf("failed to save real estate property: %w", err)
+		return nil, fmt.Errorf("failed to save real estate property: %w", err)
 	}
 
 	p.lastUpdated = time.Now()
-	return nil
+	return &ManualEntryOutcome{Action: ManualEntryCreated, ID: propertyID}, nil
 }
 
 // UpdateManualEntry updates an existing manual entry
@@ -564,15 +734,18 @@ func (p *RealEstatePlugin) UpdateManualEntry(id int, data map[string]interface{}
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
 	}
 
 	propertyType := data["property_type"].(string)
 	propertyName := data["property_name"].(string)
 	purchasePrice := data["purchase_price"].(float64)
 	currentValue := data["current_value"].(float64)
-	outstandingMortgage := data["outstanding_mortgage"].(float64)
-	equity := currentValue - outstandingMortgage
+	var outstandingMortgage float64
+	if om, exists := data["outstanding_mortgage"]; exists && om != nil {
+		outstandingMortgage = om.(float64)
+	}
+	ownershipPercentage := data["ownership_percentage"].(float64)
 
 	purchaseDate, _ := time.Parse("2006-01-02", data["purchase_date"].(string))
 
@@ -610,6 +783,8 @@ func (p *RealEstatePlugin) UpdateManualEntry(id int, data map[string]interface{}
 		}
 	}
 
+	improvementValue, placedInServiceDate := depreciationInputs(data)
+
 	// Extract address fields
 	var streetAddress, city, state, zipCode *string
 	if val, exists := data["street_address"]; exists && val != nil {
@@ -633,21 +808,22 @@ func (p *RealEstatePlugin) UpdateManualEntry(id int, data map[string]interface{}
 		}
 	}
 
-	// Update real estate property
+	// Update real estate property; equity is generated from the value and mortgage
 	query := `
 		UPDATE real_estate_properties 
 		SET property_type = $1, property_name = $2, street_address = $3, city = $4, state = $5, 
 		    zip_code = $6, purchase_price = $7, current_value = $8, outstanding_mortgage = $9, 
-		    equity = $10, purchase_date = $11, property_size_sqft = $12, lot_size_acres = $13, 
-		    rental_income_monthly = $14, property_tax_annual = $15, notes = $16, last_updated = $17
-		WHERE id = $18
+		    purchase_date = $10, property_size_sqft = $11, lot_size_acres = $12, 
+		    rental_income_monthly = $13, property_tax_annual = $14, notes = $15, last_updated = $16,
+		    ownership_percentage = $17, improvement_value = $18, placed_in_service_date = $19
+		WHERE id = $20
 	`
 
 	result, err := p.db.Exec(query,
 		propertyType, propertyName, streetAddress, city, state, zipCode,
-		purchasePrice, currentValue, outstandingMortgage, equity, purchaseDate, 
+		purchasePrice, currentValue, outstandingMortgage, purchaseDate, 
 		propertySizeSqft, lotSizeAcres, rentalIncomeMonthly, propertyTaxAnnual, notes,
-		time.Now(), id,
+		time.Now(), ownershipPercentage, improvementValue, placedInServiceDate, id,
 	)
 
 	if err != nil {
@@ -679,6 +855,22 @@ func (p *RealEstatePlugin) GetLastUpdate() time.Time {
 	return p.lastUpdated
 }
 
+// depreciationInputs returns the validated building value and placed in
+// service date, nil when not given
+func depreciationInputs(data map[string]interface{}) (*float64, *time.Time) {
+	var improvementValue *float64
+	if v, ok := data["improvement_value"].(float64); ok {
+		improvementValue = &v
+	}
+	var placedInServiceDate *time.Time
+	if v, ok := data["placed_in_service_date"].(string); ok {
+		if date, err := time.Parse("2006-01-02", v); err == nil {
+			placedInServiceDate = &date
+		}
+	}
+	return improvementValue, placedInServiceDate
+}
+
 // Helper methods for validation
 func (p *RealEstatePlugin) validateNumberField(data map[string]interface{}, field string, required bool) (float64, *ValidationError) {
 	value, exists := data[field]
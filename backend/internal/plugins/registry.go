@@ -191,6 +191,19 @@ func (r *Registry) GetActivePlugins() []FinancialDataPlugin {
 	return activePlugins
 }
 
+// All returns every registered plugin, enabled or not
+func (r *Registry) All() []FinancialDataPlugin {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	plugins := make([]FinancialDataPlugin, 0, len(r.plugins))
+	for _, plugin := range r.plugins {
+		plugins = append(plugins, plugin)
+	}
+
+	return plugins
+}
+
 // GetManualEntryPlugins returns all plugins that support manual entry
 func (r *Registry) GetManualEntryPlugins() []FinancialDataPlugin {
 	r.mutex.RLock()
@@ -223,6 +236,32 @@ func (r *Registry) HealthCheck() map[string]PluginHealth {
 	return healthStatus
 }
 
+// Refresh triggers a data refresh on one plugin
+func (r *Registry) Refresh(name string) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	plugin, exists := r.plugins[name]
+	if !exists {
+		return fmt.Errorf("plugin %s is not registered", name)
+	}
+
+	return plugin.RefreshData()
+}
+
+// Configs returns a copy of every plugin's configuration
+func (r *Registry) Configs() map[string]PluginConfig {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	configs := make(map[string]PluginConfig, len(r.configs))
+	for name, config := range r.configs {
+		configs[name] = config
+	}
+
+	return configs
+}
+
 // RefreshAll triggers data refresh on all active plugins
 func (r *Registry) RefreshAll() map[string]error {
 	r.mutex.RLock()
@@ -252,4 +291,6 @@ type PluginInfo struct {
 	Enabled      bool         `json:"enabled"`
 	Status       string       `json:"status"`
 	Health       PluginHealth `json:"health"`
+	Schedule     string       `json:"schedule,omitempty"`
+	ScheduleStatus
 }
\ No newline at end of file
@@ -0,0 +1,150 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ScheduleStatus reports a plugin's scheduled refreshes
+type ScheduleStatus struct {
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// scheduleState tracks one plugin's schedule between ticks
+type scheduleState struct {
+	expression string
+	schedule   *CronSchedule
+	lastRun    time.Time
+	nextRun    time.Time
+	lastError  string
+}
+
+// pluginScheduler runs each plugin's RefreshData on its cron schedule
+type pluginScheduler struct {
+	mutex  sync.Mutex
+	states map[string]*scheduleState
+}
+
+func newPluginScheduler() *pluginScheduler {
+	return &pluginScheduler{states: make(map[string]*scheduleState)}
+}
+
+// state returns the plugin's schedule state, starting over when the
+// expression changed since the last call. Callers hold the mutex.
+func (ps *pluginScheduler) state(name, expression string, now time.Time) *scheduleState {
+	state, ok := ps.states[name]
+	if ok && state.expression == expression {
+		return state
+	}
+	if !ok {
+		state = &scheduleState{}
+		ps.states[name] = state
+	}
+
+	state.expression = expression
+	state.schedule = nil
+	state.nextRun = time.Time{}
+	if expression == "" {
+		return state
+	}
+
+	schedule, err := ParseCron(expression)
+	if err != nil {
+		// Saved schedules are validated, so this only happens after a parser change
+		state.lastError = fmt.Sprintf("invalid schedule: %v", err)
+		return state
+	}
+	state.schedule = schedule
+	state.nextRun = schedule.Next(now)
+	return state
+}
+
+// status reports a plugin's last and next scheduled refresh
+func (ps *pluginScheduler) status(name string, config PluginConfig, now time.Time) ScheduleStatus {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	state := ps.state(name, config.Schedule, now)
+	status := ScheduleStatus{LastError: state.lastError}
+	if !state.lastRun.IsZero() {
+		lastRun := state.lastRun
+		status.LastRun = &lastRun
+	}
+	if config.Enabled && !state.nextRun.IsZero() {
+		nextRun := state.nextRun
+		status.NextRun = &nextRun
+	}
+	return status
+}
+
+// due returns the names of enabled plugins whose next run has come, advancing
+// their next run past now
+func (ps *pluginScheduler) due(configs map[string]PluginConfig, now time.Time) []string {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	var names []string
+	for name, config := range configs {
+		state := ps.state(name, config.Schedule, now)
+		if !config.Enabled || state.schedule == nil || state.nextRun.IsZero() || now.Before(state.nextRun) {
+			continue
+		}
+		state.nextRun = state.schedule.Next(now)
+		names = append(names, name)
+	}
+	return names
+}
+
+// record stores the outcome of a scheduled refresh
+func (ps *pluginScheduler) record(name string, at time.Time, err error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	state, ok := ps.states[name]
+	if !ok {
+		return
+	}
+	state.lastRun = at
+	state.lastError = ""
+	if err != nil {
+		state.lastError = err.Error()
+	}
+}
+
+// RunScheduler refreshes plugins on their configured schedules until ctx is
+// cancelled. Schedules are checked every interval, so runs fire up to one
+// interval late; a minute matches cron's resolution. onRefreshed, when not
+// nil, is called after each refresh that succeeds.
+func (m *Manager) RunScheduler(ctx context.Context, interval time.Duration, onRefreshed func()) {
+	m.runScheduledRefreshes(time.Now(), onRefreshed)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.runScheduledRefreshes(now, onRefreshed)
+		}
+	}
+}
+
+// runScheduledRefreshes refreshes every plugin that is due, calling
+// onRefreshed after each one that succeeds
+func (m *Manager) runScheduledRefreshes(now time.Time, onRefreshed func()) {
+	for _, name := range m.scheduler.due(m.registry.Configs(), now) {
+		err := m.registry.Refresh(name)
+		if err != nil {
+			fmt.Printf("WARNING: Scheduled refresh of plugin %s failed: %v\n", name, err)
+		} else if onRefreshed != nil {
+			onRefreshed()
+		}
+		m.scheduler.record(name, time.Now(), err)
+	}
+}
@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// refreshPlugin is a plugin whose refresh returns err
+type refreshPlugin struct {
+	FinancialDataPlugin
+	name      string
+	err       error
+	refreshes int
+}
+
+func (p *refreshPlugin) GetName() string { return p.name }
+
+func (p *refreshPlugin) RefreshData() error {
+	p.refreshes++
+	return p.err
+}
+
+func TestRunScheduledRefreshesCallsOnRefreshedAfterSuccess(t *testing.T) {
+	ok := &refreshPlugin{name: "ok"}
+	failing := &refreshPlugin{name: "failing", err: errors.New("provider down")}
+	idle := &refreshPlugin{name: "idle"}
+
+	m := &Manager{registry: NewRegistry(), scheduler: newPluginScheduler()}
+	for _, p := range []*refreshPlugin{ok, failing, idle} {
+		if err := m.registry.Register(p); err != nil {
+			t.Fatalf("register %s: %v", p.name, err)
+		}
+	}
+	m.registry.configs["ok"] = PluginConfig{Enabled: true, Schedule: "0 * * * *"}
+	m.registry.configs["failing"] = PluginConfig{Enabled: true, Schedule: "0 * * * *"}
+	m.registry.configs["idle"] = PluginConfig{Enabled: false, Schedule: "0 * * * *"}
+
+	start := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	refreshed := 0
+	onRefreshed := func() { refreshed++ }
+
+	// The first pass only schedules the next runs
+	m.runScheduledRefreshes(start, onRefreshed)
+	if refreshed != 0 || ok.refreshes != 0 {
+		t.Fatalf("refreshed before any run was due")
+	}
+
+	m.runScheduledRefreshes(start.Add(31*time.Minute), onRefreshed)
+	if ok.refreshes != 1 || failing.refreshes != 1 || idle.refreshes != 0 {
+		t.Errorf("refreshes = ok %d, failing %d, idle %d; want 1, 1, 0", ok.refreshes, failing.refreshes, idle.refreshes)
+	}
+	if refreshed != 1 {
+		t.Errorf("onRefreshed called %d times, want once for the successful refresh", refreshed)
+	}
+}
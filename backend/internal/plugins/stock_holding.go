@@ -15,6 +15,7 @@ type StockHoldingPlugin struct {
 	db          *sql.DB
 	name        string
 	accountID   int
+	accountName string
 	lastUpdated time.Time
 }
 
@@ -58,22 +59,29 @@ func (p *StockHoldingPlugin) GetDescription() string {
 
 // Initialize initializes the plugin with configuration
 func (p *StockHoldingPlugin) Initialize(config PluginConfig) error {
-	// Get or create the plugin account
-	accountID, err := GetOrCreatePluginAccount(
+	// Get or create the plugin account, renaming it when the setting changed
+	accountID, accountName, err := configurePluginAccount(
 		p.db,
+		p.accountID,
+		config,
 		"Stock Holdings",
 		"investment",
 		"Manual Entry",
-		"manual",
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize stock holdings account: %w", err)
 	}
 
 	p.accountID = accountID
+	p.accountName = accountName
 	return nil
 }
 
+// GetConfigSchema returns the schema of the plugin's runtime settings
+func (p *StockHoldingPlugin) GetConfigSchema() ManualEntrySchema {
+	return accountConfigSchema(p.GetFriendlyName(), "Stock Holdings")
+}
+
 // Authenticate performs authentication (not needed for manual entry)
 func (p *StockHoldingPlugin) Authenticate() error {
 	return nil
@@ -100,7 +108,7 @@ func (p *StockHoldingPlugin) GetAccounts() ([]Account, error) {
 	return []Account{
 		{
 			ID:          fmt.Sprintf("%d", p.accountID),
-			Name:        "Stock Holdings",
+			Name:        p.accountName,
 			Type:        "investment",
 			Institution: "Manual Entry",
 			DataSource:  "manual",
@@ -485,8 +493,98 @@ func (p *StockHoldingPlugin) ValidateManualEntry(data map[string]interface{}) Va
 	return result
 }
 
-// ProcessManualEntry processes the manual entry data
+// ProcessManualEntry processes the manual entry data. An existing holding of the
+// same symbol at the same institution is updated unless data["conflict_policy"] says otherwise.
 func (p *StockHoldingPlugin) ProcessManualEntry(data map[string]interface{}) error {
+	policy, err := TakeConflictPolicy(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.UpsertManualEntry(data, policy)
+	return err
+}
+
+// UpsertManualEntry stores a stock holding, matching existing holdings on symbol and
+// institution regardless of case
+func (p *StockHoldingPlugin) UpsertManualEntry(data map[string]interface{}, policy ConflictPolicy) (*ManualEntryOutcome, error) {
+	symbol := data["symbol"].(string)
+	institutionName := data["institution_name"].(string)
+
+	uniqueAccountID, err := p.holdingAccount(p.db, symbol, institutionName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look for an existing holding of the same symbol at the same institution,
+	// ignoring case, preferring one spelled exactly the same
+	var existingID int
+	var exact bool
+	err = p.db.QueryRow(`
+		SELECT id, account_id = $1 AND symbol = $2 AND institution_name = $3 AS exact
+		FROM stock_holdings
+		WHERE UPPER(symbol) = UPPER($2) AND LOWER(TRIM(institution_name)) = LOWER(TRIM($3))
+		ORDER BY exact DESC, id LIMIT 1
+	`, uniqueAccountID, symbol, institutionName).Scan(&existingID, &exact)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check for existing stock holding: %w", err)
+	}
+	if err == nil {
+		switch policy {
+		case ConflictPolicySkip:
+			return &ManualEntryOutcome{Action: ManualEntrySkipped, ID: existingID}, nil
+		case ConflictPolicyUpdate:
+			previous := snapshotRow(p.db, "stock_holdings", existingID)
+			if err := p.UpdateManualEntry(existingID, data); err != nil {
+				return nil, err
+			}
+			return &ManualEntryOutcome{Action: ManualEntryUpdated, ID: existingID, Previous: previous}, nil
+		case ConflictPolicyReject:
+			return nil, duplicateEntry(p.db, "stock_holdings", existingID, nil, fmt.Sprintf("a holding of %s at %s", symbol, institutionName))
+		default:
+			// The (account, symbol, institution) unique constraint does not allow
+			// exact duplicates
+			if exact {
+				return nil, fmt.Errorf("a holding of %s at %s already exists; stock holdings cannot be duplicated", symbol, institutionName)
+			}
+		}
+	}
+
+	holdingID, err := p.insertHolding(p.db, uniqueAccountID, data)
+	if err != nil {
+		return nil, err
+	}
+	return &ManualEntryOutcome{Action: ManualEntryCreated, ID: holdingID}, nil
+}
+
+// CreateManualEntryTx inserts a validated stock holding using db, which may be a transaction
+func (p *StockHoldingPlugin) CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error) {
+	uniqueAccountID, err := p.holdingAccount(db, data["symbol"].(string), data["institution_name"].(string))
+	if err != nil {
+		return 0, err
+	}
+	return p.insertHolding(db, uniqueAccountID, data)
+}
+
+// holdingAccount returns the unique account for a symbol held at an institution
+func (p *StockHoldingPlugin) holdingAccount(db DBTX, symbol, institutionName string) (int, error) {
+	uniqueIdentifier := fmt.Sprintf("%s at %s", symbol, institutionName)
+	uniqueAccountID, err := GetOrCreateUniquePluginAccount(
+		db,
+		"Stock Holdings",
+		uniqueIdentifier,
+		"stock",
+		institutionName,
+		"manual",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create unique account for stock holding: %w", err)
+	}
+	return uniqueAccountID, nil
+}
+
+// insertHolding inserts a new stock holding row for validated data
+func (p *StockHoldingPlugin) insertHolding(db DBTX, accountID int, data map[string]interface{}) (int, error) {
 	symbol := data["symbol"].(string)
 	institutionName := data["institution_name"].(string)
 	shares := data["shares_owned"].(float64)
@@ -531,20 +629,6 @@ func (p *StockHoldingPlugin) ProcessManualEntry(data map[string]interface{}) err
 		currentPrice = 0
 	}
 
-	// Create unique account for this stock holding
-	uniqueIdentifier := fmt.Sprintf("%s at %s", symbol, institutionName)
-	uniqueAccountID, err := GetOrCreateUniquePluginAccount(
-		p.db,
-		"Stock Holdings",
-		uniqueIdentifier,
-		"stock",
-		institutionName,
-		"manual",
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create unique account for stock holding: %w", err)
-	}
-
 	// Extract vested equity flag from validated data
 	isVestedEquity := data["is_vested_equity"].(bool)
 
@@ -553,22 +637,26 @@ func (p *StockHoldingPlugin) ProcessManualEntry(data map[string]interface{}) err
 		INSERT INTO stock_holdings (
 			account_id, symbol, company_name, shares_owned, cost_basis, 
 			current_price, institution_name, data_source, estimated_quarterly_dividend,
-			purchase_date, drip_enabled, last_manual_update, is_vested_equity
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			purchase_date, drip_enabled, last_manual_update, is_vested_equity,
+			exchange, security_type
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
 	`
 
-	_, execErr := p.db.Exec(query,
-		uniqueAccountID, symbol, companyName, shares, costBasis,
+	var holdingID int
+	execErr := db.QueryRow(query,
+		accountID, symbol, companyName, shares, costBasis,
 		currentPrice, institutionName, "stock_holding", estimatedQuarterlyDividend,
 		purchaseDate, dripEnabled, time.Now(), isVestedEquity,
-	)
+		symbolDetail(data, "exchange"), symbolDetail(data, "security_type"),
+	).Scan(&holdingID)
 
 	if execErr != nil {
-		return fmt.Errorf("failed to save stock holding: %w", execErr)
+		return 0, fmt.Errorf("failed to save stock holding: %w", execErr)
 	}
 
 	p.lastUpdated = time.Now()
-	return nil
+	return holdingID, nil
 }
 
 // UpdateManualEntry updates an existing manual entry
@@ -576,7 +664,7 @@ func (p *StockHoldingPlugin) UpdateManualEntry(id int, data map[string]interface
 	// Validate the data first
 	validation := p.ValidateManualEntry(data)
 	if !validation.Valid {
-		return fmt.Errorf("validation failed: %v", validation.Errors)
+		return ValidationErrors(validation.Errors)
 	}
 
 	symbol := data["symbol"].(string)
@@ -630,19 +718,23 @@ func (p *StockHoldingPlugin) UpdateManualEntry(id int, data map[string]interface
 		currentPrice = existingPrice
 	}
 
-	// Update stock holding
+	// Update stock holding. Exchange and security type are kept when the
+	// symbol is unchanged and no lookup result came with the update.
 	query := `
 		UPDATE stock_holdings 
 		SET symbol = $1, company_name = $2, shares_owned = $3, cost_basis = $4, 
 		    current_price = $5, institution_name = $6, last_updated = $7, estimated_quarterly_dividend = $8,
-		    purchase_date = $9, drip_enabled = $10, last_manual_update = $11, is_vested_equity = $12
-		WHERE id = $13 AND data_source = 'stock_holding'
+		    purchase_date = $9, drip_enabled = $10, last_manual_update = $11, is_vested_equity = $12,
+		    exchange = CASE WHEN symbol = $1 THEN COALESCE($13, exchange) ELSE $13 END,
+		    security_type = CASE WHEN symbol = $1 THEN COALESCE($14, security_type) ELSE $14 END
+		WHERE id = $15 AND data_source = 'stock_holding'
 	`
 
 	result, err := p.db.Exec(query,
 		symbol, companyName, shares, costBasis,
 		currentPrice, institutionName, time.Now(), estimatedQuarterlyDividend,
-		purchaseDate, dripEnabled, time.Now(), isVestedEquity, id,
+		purchaseDate, dripEnabled, time.Now(), isVestedEquity,
+		symbolDetail(data, "exchange"), symbolDetail(data, "security_type"), id,
 	)
 
 	if err != nil {
@@ -662,6 +754,15 @@ func (p *StockHoldingPlugin) UpdateManualEntry(id int, data map[string]interface
 	return nil
 }
 
+// symbolDetail returns an exchange or security type filled in from the
+// symbol lookup, or nil when the lookup did not provide one
+func symbolDetail(data map[string]interface{}, key string) *string {
+	if value, ok := data[key].(string); ok && value != "" {
+		return &value
+	}
+	return nil
+}
+
 // RefreshData refreshes data for this plugin
 func (p *StockHoldingPlugin) RefreshData() error {
 	// For manual entry, we could refresh market prices
@@ -2,8 +2,14 @@ package plugins
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+
+	"networth-dashboard/internal/encryption"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/sqlbuilder"
 )
 
 // Plugin types
@@ -39,6 +45,8 @@ const (
 type PluginConfig struct {
 	Enabled  bool                   `json:"enabled"`
 	Settings map[string]interface{} `json:"settings"`
+	// Schedule is a cron expression for refreshing the plugin's data; empty means never
+	Schedule string `json:"schedule,omitempty"`
 }
 
 // Plugin health status
@@ -139,6 +147,18 @@ type ValidationError struct {
 	Code    string `json:"code"`
 }
 
+// ValidationErrors is returned when data fails a plugin's validation, so
+// callers can report each field
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, validationErr := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", validationErr.Field, validationErr.Message))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
 // Validation result
 type ValidationResult struct {
 	Valid  bool                   `json:"valid"`
@@ -180,7 +200,7 @@ type FinancialDataPlugin interface {
 }
 
 // Helper function to get or create an account for a plugin
-func GetOrCreatePluginAccount(db *sql.DB, accountName, accountType, institution, dataSourceType string) (int, error) {
+func GetOrCreatePluginAccount(db DBTX, accountName, accountType, institution, dataSourceType string) (int, error) {
 	// First try to find existing account
 	var accountID int
 	query := `
@@ -216,13 +236,128 @@ func GetOrCreatePluginAccount(db *sql.DB, accountName, accountType, institution,
 }
 
 // Helper function to get or create a unique account for each manual entry
-func GetOrCreateUniquePluginAccount(db *sql.DB, baseAccountName, uniqueIdentifier, accountType, institution, dataSourceType string) (int, error) {
+func GetOrCreateUniquePluginAccount(db DBTX, baseAccountName, uniqueIdentifier, accountType, institution, dataSourceType string) (int, error) {
 	// Create unique account name by combining base name with identifier
 	accountName := fmt.Sprintf("%s - %s", baseAccountName, uniqueIdentifier)
 	
 	return GetOrCreatePluginAccount(db, accountName, accountType, institution, dataSourceType)
 }
 
+// ConflictPolicy controls how a manual entry is handled when an entry with the
+// same natural key already exists
+type ConflictPolicy string
+
+const (
+	ConflictPolicySkip      ConflictPolicy = "skip"      // Keep the existing entry unchanged
+	ConflictPolicyUpdate    ConflictPolicy = "update"    // Overwrite the existing entry
+	ConflictPolicyDuplicate ConflictPolicy = "duplicate" // Always insert a new entry
+	ConflictPolicyReject    ConflictPolicy = "reject"    // Refuse the entry, reporting the existing one
+)
+
+// ConflictPolicyField is the manual entry data key that selects the conflict policy
+const ConflictPolicyField = "conflict_policy"
+
+// Manual entry outcomes
+const (
+	ManualEntryCreated = "created"
+	ManualEntryUpdated = "updated"
+	ManualEntrySkipped = "skipped"
+)
+
+// ManualEntryOutcome describes what processing a manual entry did
+type ManualEntryOutcome struct {
+	Action   string                 `json:"action"`       // "created", "updated" or "skipped"
+	ID       int                    `json:"id,omitempty"` // ID of the created or matched entry
+	Previous map[string]interface{} `json:"-"`            // Stored row before an update, for auditing
+}
+
+// DuplicateEntryError is returned under ConflictPolicyReject when an entry
+// with the same natural key already exists
+type DuplicateEntryError struct {
+	Entry    string                 // What matched, e.g. "a holding of AAPL at Fidelity"
+	ID       int                    // ID of the existing entry
+	Existing map[string]interface{} // The existing entry's stored row
+}
+
+func (e *DuplicateEntryError) Error() string {
+	return fmt.Sprintf("%s already exists (id %d)", e.Entry, e.ID)
+}
+
+// duplicateEntry returns the DuplicateEntryError for an existing row, with
+// columns encrypted at rest decrypted, or left out when they can't be
+func duplicateEntry(db *sql.DB, table string, id int, encryptor *encryption.FieldEncryptor, entry string) error {
+	existing := snapshotRow(db, table, id)
+	for _, column := range encryption.SensitiveColumns {
+		value, ok := existing[column.Column].(string)
+		if column.Table != table || !ok {
+			continue
+		}
+		if plaintext, err := encryptor.Decrypt(value); err == nil {
+			existing[column.Column] = plaintext
+		} else {
+			delete(existing, column.Column)
+		}
+	}
+	return &DuplicateEntryError{Entry: entry, ID: id, Existing: existing}
+}
+
+// snapshotRow returns a table row as a map, or nil if it can't be read
+func snapshotRow(db *sql.DB, table string, id int) map[string]interface{} {
+	var raw []byte
+	query := fmt.Sprintf("SELECT %s FROM %s t WHERE id = $1", database.DialectOf(db).RowToJSON(table, "t"), sqlbuilder.Ident(table))
+	if err := db.QueryRow(query, id).Scan(&raw); err != nil {
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so entries can be written
+// directly or inside a caller-managed transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// TxManualEntryPlugin is implemented by plugins that can create validated manual
+// entries inside a transaction, which bulk creation requires
+type TxManualEntryPlugin interface {
+	CreateManualEntryTx(db DBTX, data map[string]interface{}) (int, error)
+}
+
+// UpsertManualEntryPlugin is implemented by plugins that match manual entries on a
+// natural key instead of always inserting
+type UpsertManualEntryPlugin interface {
+	UpsertManualEntry(data map[string]interface{}, policy ConflictPolicy) (*ManualEntryOutcome, error)
+}
+
+// TakeConflictPolicy reads and removes the conflict policy from manual entry data,
+// defaulting to update so that re-submitting a form is idempotent
+func TakeConflictPolicy(data map[string]interface{}) (ConflictPolicy, error) {
+	raw, exists := data[ConflictPolicyField]
+	delete(data, ConflictPolicyField)
+	if !exists || raw == nil || raw == "" {
+		return ConflictPolicyUpdate, nil
+	}
+
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", ConflictPolicyField)
+	}
+
+	switch policy := ConflictPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case ConflictPolicySkip, ConflictPolicyUpdate, ConflictPolicyDuplicate, ConflictPolicyReject:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q (expected skip, update, duplicate or reject)", ConflictPolicyField, value)
+	}
+}
+
 // Bulk update types
 type BulkUpdateItem struct {
 	ID   int                    `json:"id"`
@@ -241,6 +376,31 @@ type BulkUpdateResult struct {
 	Errors       []BulkUpdateError `json:"errors"`
 }
 
+// Bulk create item statuses
+const (
+	BulkItemCreated    = "created"
+	BulkItemFailed     = "failed"
+	BulkItemRolledBack = "rolled_back" // Valid, but discarded because another item failed
+)
+
+// BulkCreateItemResult reports what happened to one item of a bulk create
+type BulkCreateItemResult struct {
+	Index  int    `json:"index"`
+	ID     int    `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkCreateResult reports the outcome of a bulk create. When Atomic is set a
+// single failure rolls back every item and Committed is false.
+type BulkCreateResult struct {
+	Atomic       bool                   `json:"atomic"`
+	Committed    bool                   `json:"committed"`
+	CreatedCount int                    `json:"created_count"`
+	FailedCount  int                    `json:"failed_count"`
+	Results      []BulkCreateItemResult `json:"results"`
+}
+
 // Error method to implement the error interface for BulkUpdateResult
 func (r *BulkUpdateResult) Error() string {
 	return fmt.Sprintf("bulk update completed with %d successes and %d failures", r.SuccessCount, r.FailureCount)
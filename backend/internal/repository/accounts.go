@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrAccountInUse is returned when deleting an account that still has
+	// holdings, balances or manual entries
+	ErrAccountInUse = errors.New("account still has holdings")
+	// ErrInstitutionMismatch is returned when linking a holding to an account
+	// at another institution
+	ErrInstitutionMismatch = errors.New("holding is held at another institution")
+	// ErrDuplicateInAccount is returned when linking a holding to an account
+	// that already holds the same one
+	ErrDuplicateInAccount = errors.New("account already holds the same holding")
+)
+
+// iBondName names an I bond ib by its issue month, as in "I Bond Jan 2024",
+// from the ISO text of the date, which both databases spell the same
+const iBondName = `'I Bond ' || substr('JanFebMarAprMayJunJulAugSepOctNovDec', CAST(substr(CAST(ib.issue_date AS TEXT), 6, 2) AS INTEGER) * 3 - 2, 3)
+	       || ' ' || substr(CAST(ib.issue_date AS TEXT), 1, 4)`
+
+// accountHoldingsQuery lists every holding with the account it is filed under
+const accountHoldingsQuery = `
+	SELECT 'stock_holding', id, symbol, account_id FROM stock_holdings
+	UNION ALL
+	SELECT 'equity_grant', id, company_symbol || ' ' || grant_type, account_id FROM equity_grants
+	UNION ALL
+	SELECT 'real_estate', id, property_name, account_id FROM real_estate_properties
+	UNION ALL
+	SELECT 'cash_holding', id, account_name, account_id FROM cash_holdings
+	UNION ALL
+	SELECT 'crypto_holding', id, crypto_symbol, account_id FROM crypto_holdings
+	UNION ALL
+	SELECT 'other_asset', id, asset_name, account_id FROM miscellaneous_assets
+	UNION ALL
+	SELECT 'private_investment', id, investment_name, account_id FROM private_investments
+	UNION ALL
+	SELECT 'bond', id, bond_name, account_id FROM bonds
+	UNION ALL
+	SELECT 'i_bond', ib.id, ` + iBondName + `, ib.account_id FROM i_bonds ib
+	UNION ALL
+	SELECT 'pension', id, plan_name, account_id FROM pensions
+	UNION ALL
+	SELECT 'insurance_policy', id, policy_name, account_id FROM insurance_policies
+	ORDER BY 1, 2`
+
+// holdingInstitutionColumns name the columns of holding types tracked with
+// an institution of their own; the rest take their account's
+var holdingInstitutionColumns = map[string]string{
+	models.HoldingTypeStock:           "institution_name",
+	models.HoldingTypeCash:            "institution_name",
+	models.HoldingTypeCrypto:          "institution_name",
+	models.HoldingTypeBond:            "institution_name",
+	models.HoldingTypeIBond:           "institution_name",
+	models.HoldingTypePension:         "institution_name",
+	models.HoldingTypeInsurancePolicy: "institution_name",
+}
+
+// accountUnused matches an account a with nothing filed under it
+var accountUnused = func() string {
+	conditions := make([]string, len(accountTables))
+	for i, table := range accountTables {
+		conditions[i] = fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE account_id = a.id)", sqlbuilder.Ident(table))
+	}
+	return strings.Join(conditions, " AND ")
+}()
+
+// AccountRepository handles accounts and the holdings filed under them
+type AccountRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewAccountRepository creates a new account repository
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{db: db, dialect: database.DialectOf(db)}
+}
+
+const accountColumns = `
+	id, data_source_id, external_account_id, account_name, account_type,
+	COALESCE(institution, ''), COALESCE(data_source_type, ''), owner, owner_member_id, custodial,
+	created_at, updated_at`
+
+func scanAccount(row interface{ Scan(...interface{}) error }, a *models.Account) error {
+	err := row.Scan(&a.ID, &a.DataSourceID, &a.ExternalAccountID, &a.AccountName, &a.AccountType,
+		&a.Institution, &a.DataSourceType, &a.Owner, &a.OwnerMemberID, &a.Custodial, &a.CreatedAt, &a.UpdatedAt)
+	return err
+}
+
+// holdingsByAccount returns the holdings filed under each account with what
+// each counts toward total assets
+func (r *AccountRepository) holdingsByAccount() (map[int][]models.AccountHolding, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+	var breakdown models.NetWorthBreakdown
+	counted := map[string]bool{}
+	for _, component := range breakdown.Components() {
+		counted[component.Key] = true
+	}
+	worth := map[models.HoldingRef]decimal.Decimal{}
+	for _, v := range values {
+		if counted[v.Component] {
+			worth[v.HoldingRef] = worth[v.HoldingRef].Add(v.Value)
+		}
+	}
+
+	rows, err := r.db.Query(accountHoldingsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account holdings: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := map[int][]models.AccountHolding{}
+	for rows.Next() {
+		var h models.AccountHolding
+		var accountID sql.NullInt64
+		if err := rows.Scan(&h.HoldingType, &h.HoldingID, &h.Name, &accountID); err != nil {
+			return nil, fmt.Errorf("failed to scan account holding: %w", err)
+		}
+		if !accountID.Valid {
+			continue
+		}
+		h.Value = worth[h.HoldingRef]
+		holdings[int(accountID.Int64)] = append(holdings[int(accountID.Int64)], h)
+	}
+	return holdings, rows.Err()
+}
+
+// summarize totals an account's holdings
+func summarize(account models.Account, holdings []models.AccountHolding) models.AccountDetail {
+	summary := models.AccountDetail{Account: account, HoldingCount: len(holdings), Value: decimal.Zero}
+	for _, h := range holdings {
+		summary.Value = summary.Value.Add(h.Value)
+	}
+	return summary
+}
+
+// List returns every account with the number and value of its holdings,
+// ordered by institution and name
+func (r *AccountRepository) List() ([]models.AccountDetail, error) {
+	holdings, err := r.holdingsByAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT ` + accountColumns + ` FROM accounts ORDER BY institution, account_name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.AccountDetail{}
+	for rows.Next() {
+		var account models.Account
+		if err := scanAccount(rows, &account); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, summarize(account, holdings[account.ID]))
+	}
+	return accounts, rows.Err()
+}
+
+// Get returns an account with the holdings filed under it
+func (r *AccountRepository) Get(id int) (*models.AccountDetail, error) {
+	var account models.Account
+	err := scanAccount(r.db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE id = $1`, id), &account)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account: %w", err)
+	}
+
+	holdings, err := r.holdingsByAccount()
+	if err != nil {
+		return nil, err
+	}
+	summary := summarize(account, holdings[id])
+	summary.Holdings = holdings[id]
+	if summary.Holdings == nil {
+		summary.Holdings = []models.AccountHolding{}
+	}
+	return &summary, nil
+}
+
+// Create adds an institution account and returns its ID
+func (r *AccountRepository) Create(input models.AccountInput) (int, error) {
+	var id int
+	now := time.Now()
+	owner, memberID, custodial := input.Ownership()
+	err := r.db.QueryRow(`
+		INSERT INTO accounts (account_name, account_type, institution, external_account_id, data_source_type,
+		                      owner, owner_member_id, custodial, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id
+	`, input.AccountName, input.AccountType, input.Institution, input.ExternalAccountID,
+		models.AccountSourceInstitution, owner, memberID, custodial, now).Scan(&id)
+	if err != nil {
+		return 0, accountOwnerError(err, "failed to create account")
+	}
+	return id, nil
+}
+
+// Update replaces the writable fields of an account
+func (r *AccountRepository) Update(id int, input models.AccountInput) error {
+	owner, memberID, custodial := input.Ownership()
+	result, err := r.db.Exec(`
+		UPDATE accounts
+		SET account_name = $1, account_type = $2, institution = $3, external_account_id = $4, owner = $5,
+		    owner_member_id = $6, custodial = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
+	`, input.AccountName, input.AccountType, input.Institution, input.ExternalAccountID,
+		owner, memberID, custodial, id)
+	if err != nil {
+		return accountOwnerError(err, "failed to update account")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// accountOwnerError maps a child's account naming a household member that
+// does not exist to ErrUnknownMember
+func accountOwnerError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrUnknownMember
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// Delete deletes an account with nothing filed under it
+func (r *AccountRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM accounts AS a WHERE id = $1 AND `+accountUnused, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check account %d: %w", id, err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrAccountInUse
+}
+
+// Link files holdings under an account. Stock, cash and crypto holdings must
+// be held at the account's institution. The manual accounts the holdings were
+// created under are deleted once nothing is left in them; Link returns how
+// many were.
+func (r *AccountRepository) Link(accountID int, refs []models.HoldingRef) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var institution string
+	err = tx.QueryRow(`SELECT COALESCE(institution, '') FROM accounts WHERE id = $1`+r.dialect.ForUpdate(), accountID).Scan(&institution)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch account: %w", err)
+	}
+
+	previous := map[int]bool{}
+	for _, ref := range refs {
+		table, ok := holdingTables[ref.HoldingType]
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidHoldingType, ref.HoldingType)
+		}
+		column := "''"
+		if name, ok := holdingInstitutionColumns[ref.HoldingType]; ok {
+			column = fmt.Sprintf("COALESCE(%s, '')", sqlbuilder.Ident(name))
+		}
+
+		var currentID sql.NullInt64
+		var heldAt string
+		query := fmt.Sprintf("SELECT account_id, %s FROM %s WHERE id = $1%s", column, sqlbuilder.Ident(table), r.dialect.ForUpdate())
+		err := tx.QueryRow(query, ref.HoldingID).Scan(&currentID, &heldAt)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("%w: %s %d", ErrUnknownHolding, ref.HoldingType, ref.HoldingID)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to check %s %d: %w", ref.HoldingType, ref.HoldingID, err)
+		}
+		if heldAt != "" && institution != "" && !strings.EqualFold(strings.TrimSpace(heldAt), strings.TrimSpace(institution)) {
+			return 0, fmt.Errorf("%w: %s %d is held at %s, not %s", ErrInstitutionMismatch, ref.HoldingType, ref.HoldingID, heldAt, institution)
+		}
+		if currentID.Valid && int(currentID.Int64) == accountID {
+			continue
+		}
+		if currentID.Valid {
+			previous[int(currentID.Int64)] = true
+		}
+
+		update := fmt.Sprintf("UPDATE %s SET account_id = $1 WHERE id = $2", sqlbuilder.Ident(table))
+		if _, err := tx.Exec(update, accountID, ref.HoldingID); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return 0, fmt.Errorf("%w: %s %d", ErrDuplicateInAccount, ref.HoldingType, ref.HoldingID)
+			}
+			return 0, fmt.Errorf("failed to link %s %d: %w", ref.HoldingType, ref.HoldingID, err)
+		}
+	}
+
+	removed := 0
+	for id := range previous {
+		result, err := tx.Exec(`DELETE FROM accounts AS a WHERE id = $1 AND data_source_type = $2 AND `+accountUnused,
+			id, models.AccountSourceManual)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete emptied account %d: %w", id, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			removed += int(n)
+		}
+	}
+
+	return removed, tx.Commit()
+}
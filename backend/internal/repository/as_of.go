@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// auditDeltas sums, per entity, how much field changed in audited writes at
+// or after since. Subtracting the sum from the current value gives the value
+// just before since.
+func auditDeltas(db *sql.DB, entityType, field string, since time.Time) (map[int]decimal.Decimal, error) {
+	rows, err := db.Query(`
+		SELECT entity_id, SUM(CAST(new_values ->> CAST($3 AS TEXT) AS NUMERIC) - CAST(old_values ->> CAST($3 AS TEXT) AS NUMERIC))
+		FROM audit_log
+		WHERE entity_type = $1 AND created_at >= $2 AND entity_id IS NOT NULL
+		  AND old_values ->> CAST($3 AS TEXT) IS NOT NULL AND new_values ->> CAST($3 AS TEXT) IS NOT NULL
+		GROUP BY entity_id
+	`, entityType, since, field)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s changes: %w", entityType, err)
+	}
+	defer rows.Close()
+
+	deltas := make(map[int]decimal.Decimal)
+	for rows.Next() {
+		var id int
+		var delta decimal.NullDecimal
+		if err := rows.Scan(&id, &delta); err != nil {
+			return nil, fmt.Errorf("failed to scan %s change: %w", entityType, err)
+		}
+		deltas[id] = delta.Decimal
+	}
+	return deltas, rows.Err()
+}
+
+// endOfDay returns the start of the day after date, the cutoff for values as of date
+func endOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()).AddDate(0, 0, 1)
+}
+
+// ListAsOf returns the stock holdings as they stood at the end of date.
+// Shares are the current shares less audited changes since, and each
+// holding is priced from the stock price history, then the daily position
+// snapshots, then its current price. Holdings created after date are left
+// out; holdings deleted since are not restored.
+func (r *StockRepository) ListAsOf(date time.Time) ([]models.StockHolding, error) {
+	holdings, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := endOfDay(date)
+	deltas, err := auditDeltas(r.db, models.HoldingTypeStock, "shares_owned", cutoff)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := r.pricesAsOf(cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := make([]models.StockHolding, 0, len(holdings))
+	for _, h := range holdings {
+		if !h.CreatedAt.Before(cutoff) {
+			continue
+		}
+		h.SharesOwned -= deltas[h.ID].InexactFloat64()
+		if price, ok := prices[h.Symbol]; ok {
+			h.CurrentPrice = &price
+		}
+		h.MarketValue = decimal.Zero
+		if h.CurrentPrice != nil {
+			h.MarketValue = decimal.NewFromFloat(h.SharesOwned).Mul(*h.CurrentPrice)
+		}
+		asOf = append(asOf, h)
+	}
+	return asOf, nil
+}
+
+// pricesAsOf returns the last known price of each held symbol before cutoff
+func (r *StockRepository) pricesAsOf(cutoff time.Time) (map[string]decimal.Decimal, error) {
+	rows, err := r.db.Query(`
+		SELECT s.symbol, COALESCE(
+			(SELECT price FROM stock_price_history
+			 WHERE symbol = s.symbol AND timestamp < $1
+			 ORDER BY timestamp DESC
+			 LIMIT 1),
+			(SELECT market_value / NULLIF(shares_owned, 0) FROM holding_snapshots
+			 WHERE symbol = s.symbol AND snapshot_date < $1
+			 ORDER BY snapshot_date DESC
+			 LIMIT 1))
+		FROM (SELECT DISTINCT symbol FROM stock_holdings) s
+	`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var symbol string
+		var price decimal.NullDecimal
+		if err := rows.Scan(&symbol, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan historical price: %w", err)
+		}
+		if price.Valid && price.Decimal.IsPositive() {
+			prices[symbol] = price.Decimal
+		}
+	}
+	return prices, rows.Err()
+}
+
+// ListAsOf returns the cash holdings as they stood at the end of date: the
+// current balance less audited balance changes and recurring contributions
+// applied since. Holdings created after date are left out; holdings deleted
+// since are not restored.
+func (r *CashRepository) ListAsOf(date time.Time) ([]models.CashHolding, error) {
+	holdings, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := endOfDay(date)
+	deltas, err := auditDeltas(r.db, models.HoldingTypeCash, "current_balance", cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT cash_holding_id, SUM(balance_after - balance_before)
+		FROM contribution_transactions
+		WHERE status = 'applied' AND applied_at >= $1
+		GROUP BY cash_holding_id
+	`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch applied contributions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var amount decimal.NullDecimal
+		if err := rows.Scan(&id, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan applied contribution: %w", err)
+		}
+		deltas[id] = deltas[id].Add(amount.Decimal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch applied contributions: %w", err)
+	}
+
+	asOf := make([]models.CashHolding, 0, len(holdings))
+	for _, h := range holdings {
+		if !h.CreatedAt.Before(cutoff) {
+			continue
+		}
+		h.CurrentBalance = h.CurrentBalance.Sub(deltas[h.ID])
+		asOf = append(asOf, h)
+	}
+	return asOf, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+// ErrNoFieldsToUpdate is returned when an update sets nothing
+var ErrNoFieldsToUpdate = errors.New("no fields to update")
+
+// CategoryInUseError is returned when deleting an asset category that assets
+// are still filed under
+type CategoryInUseError struct {
+	Assets int
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("%d assets are using this category", e.Assets)
+}
+
+// assetCategoryColumns are the columns every category query selects
+const assetCategoryColumns = `
+	id, name, description, icon, color, custom_schema,
+	valuation_api_config, is_active, sort_order, created_at, updated_at`
+
+// AssetCategoryRepository provides access to the categories of other assets
+type AssetCategoryRepository struct {
+	db *sql.DB
+}
+
+// NewAssetCategoryRepository creates a new asset category repository
+func NewAssetCategoryRepository(db *sql.DB) *AssetCategoryRepository {
+	return &AssetCategoryRepository{db: db}
+}
+
+// List returns the categories in sort order, only the active ones when activeOnly is set
+func (r *AssetCategoryRepository) List(activeOnly bool) ([]models.AssetCategory, error) {
+	query := `SELECT ` + assetCategoryColumns + ` FROM asset_categories`
+	if activeOnly {
+		query += ` WHERE is_active = true`
+	}
+	query += ` ORDER BY sort_order, name`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.AssetCategory{}
+	for rows.Next() {
+		category, err := scanAssetCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan asset category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// Get returns a category by ID
+func (r *AssetCategoryRepository) Get(id int) (*models.AssetCategory, error) {
+	row := r.db.QueryRow(`SELECT `+assetCategoryColumns+` FROM asset_categories WHERE id = $1`, id)
+	category, err := scanAssetCategory(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset category %d: %w", id, err)
+	}
+	return &category, nil
+}
+
+// Create adds a category and returns its ID. Categories are active unless
+// input says otherwise and sort first by default.
+func (r *AssetCategoryRepository) Create(input models.AssetCategoryInput) (int, error) {
+	if input.Name == nil {
+		return 0, errors.New("asset category name is required")
+	}
+	isActive, sortOrder := true, 0
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+	if input.SortOrder != nil {
+		sortOrder = *input.SortOrder
+	}
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO asset_categories (name, description, icon, color, custom_schema,
+		                              valuation_api_config, is_active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, *input.Name, input.Description, input.Icon, input.Color, input.CustomSchema,
+		input.ValuationAPIConfig, isActive, sortOrder).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create asset category: %w", err)
+	}
+	return id, nil
+}
+
+// Update sets the fields of input that are not nil
+func (r *AssetCategoryRepository) Update(id int, input models.AssetCategoryInput) error {
+	update := sqlbuilder.NewUpdate("asset_categories")
+	setIf := func(column string, value *string) {
+		if value != nil {
+			update.Set(column, *value)
+		}
+	}
+	setIf("name", input.Name)
+	setIf("description", input.Description)
+	setIf("icon", input.Icon)
+	setIf("color", input.Color)
+	setIf("custom_schema", input.CustomSchema)
+	setIf("valuation_api_config", input.ValuationAPIConfig)
+	if input.IsActive != nil {
+		update.Set("is_active", *input.IsActive)
+	}
+	if input.SortOrder != nil {
+		update.Set("sort_order", *input.SortOrder)
+	}
+	if update.Empty() {
+		return ErrNoFieldsToUpdate
+	}
+
+	update.Set("updated_at", time.Now())
+	query, args := update.WhereID(id)
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update asset category: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete deletes a category no asset is filed under
+func (r *AssetCategoryRepository) Delete(id int) error {
+	var assets int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM miscellaneous_assets WHERE asset_category_id = $1`, id).Scan(&assets)
+	if err != nil {
+		return fmt.Errorf("failed to check category usage: %w", err)
+	}
+	if assets > 0 {
+		return &CategoryInUseError{Assets: assets}
+	}
+	return deleteByID(r.db, "asset_categories", id)
+}
+
+func scanAssetCategory(row interface{ Scan(...interface{}) error }) (models.AssetCategory, error) {
+	var c models.AssetCategory
+	err := row.Scan(&c.ID, &c.Name, &c.Description, &c.Icon, &c.Color, &c.CustomSchema,
+		&c.ValuationAPIConfig, &c.IsActive, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
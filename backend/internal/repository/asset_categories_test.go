@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var assetCategoryRow = []string{"id", "name", "description", "icon", "color", "custom_schema",
+	"valuation_api_config", "is_active", "sort_order", "created_at", "updated_at"}
+
+func TestAssetCategoryList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM asset_categories WHERE is_active = true ORDER BY sort_order, name`).
+		WillReturnRows(sqlmock.NewRows(assetCategoryRow).
+			AddRow(1, "Vehicles", "Cars", nil, nil, `{"fields":[]}`, nil, true, 1, created, created))
+
+	categories, err := NewAssetCategoryRepository(db).List(true)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(categories) != 1 {
+		t.Fatalf("List returned %d categories, want 1", len(categories))
+	}
+	c := categories[0]
+	if c.Name != "Vehicles" || c.Description == nil || *c.Description != "Cars" || c.Icon != nil {
+		t.Errorf("List = %+v", c)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAssetCategoryGetNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM asset_categories WHERE id = \$1`).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(assetCategoryRow))
+
+	if _, err := NewAssetCategoryRepository(db).Get(7); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAssetCategoryCreateDefaults(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	name := "Art"
+	mock.ExpectQuery(`INSERT INTO asset_categories`).
+		WithArgs("Art", nil, nil, nil, nil, nil, true, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+
+	repo := NewAssetCategoryRepository(db)
+	id, err := repo.Create(models.AssetCategoryInput{Name: &name})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if id != 12 {
+		t.Errorf("Create = %d, want 12", id)
+	}
+	if _, err := repo.Create(models.AssetCategoryInput{}); err == nil {
+		t.Error("Create without a name succeeded")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAssetCategoryUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewAssetCategoryRepository(db)
+
+	if err := repo.Update(3, models.AssetCategoryInput{}); !errors.Is(err, ErrNoFieldsToUpdate) {
+		t.Errorf("Update with no fields = %v, want ErrNoFieldsToUpdate", err)
+	}
+
+	active := false
+	mock.ExpectExec(`UPDATE "asset_categories" SET "is_active" = \$1, "updated_at" = \$2 WHERE id = \$3`).
+		WithArgs(false, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := repo.Update(3, models.AssetCategoryInput{IsActive: &active}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a missing category = %v, want ErrNotFound", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAssetCategoryDeleteInUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM miscellaneous_assets`).WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	err = NewAssetCategoryRepository(db).Delete(4)
+	var inUse *CategoryInUseError
+	if !errors.As(err, &inUse) || inUse.Assets != 2 {
+		t.Errorf("Delete = %v, want 2 assets in use", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+// AttachmentRepository provides access to notes and files attached to holdings
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+const attachmentSelectQuery = `
+		SELECT id, entity_type, entity_id, kind, note, file_name, content_type,
+		       size_bytes, storage_key, COALESCE(created_by, ''), created_at, updated_at
+		FROM attachments
+	`
+
+// HoldingExists reports whether a holding of the given type exists, returning
+// ErrInvalidHoldingType for types that cannot have attachments
+func (r *AttachmentRepository) HoldingExists(holdingType string, holdingID int) (bool, error) {
+	table, ok := holdingTables[holdingType]
+	if !ok {
+		return false, fmt.Errorf("%w: %q", ErrInvalidHoldingType, holdingType)
+	}
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", sqlbuilder.Ident(table))
+	if err := r.db.QueryRow(query, holdingID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check %s %d: %w", holdingType, holdingID, err)
+	}
+	return exists, nil
+}
+
+// List returns a holding's attachments, newest first
+func (r *AttachmentRepository) List(entityType string, entityID int) ([]models.Attachment, error) {
+	rows, err := r.db.Query(attachmentSelectQuery+`
+		WHERE entity_type = $1 AND entity_id = $2
+		ORDER BY created_at DESC, id DESC
+	`, entityType, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// Get returns one attachment
+func (r *AttachmentRepository) Get(id int) (*models.Attachment, error) {
+	a, err := scanAttachment(r.db.QueryRow(attachmentSelectQuery+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// Create stores an attachment and returns its ID
+func (r *AttachmentRepository) Create(a models.Attachment) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO attachments (entity_type, entity_id, kind, note, file_name,
+		                         content_type, size_bytes, storage_key, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, a.EntityType, a.EntityID, a.Kind, a.Note, a.FileName,
+		a.ContentType, a.SizeBytes, a.StorageKey, a.CreatedBy).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateNote replaces an attachment's note
+func (r *AttachmentRepository) UpdateNote(id int, note *string) error {
+	result, err := r.db.Exec(`
+		UPDATE attachments SET note = $1, updated_at = $2 WHERE id = $3
+	`, note, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update attachment: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// Delete removes an attachment record; its file is removed by the caller
+func (r *AttachmentRepository) Delete(id int) error {
+	return deleteByID(r.db, "attachments", id)
+}
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(
+		&a.ID, &a.EntityType, &a.EntityID, &a.Kind, &a.Note, &a.FileName, &a.ContentType,
+		&a.SizeBytes, &a.StorageKey, &a.CreatedBy, &a.CreatedAt, &a.UpdatedAt,
+	)
+	return a, err
+}
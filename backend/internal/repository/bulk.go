@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+// ErrInvalidFilter is returned when a bulk delete filter is empty or names a
+// column that cannot be filtered on
+var ErrInvalidFilter = errors.New("invalid bulk delete filter")
+
+// Bulk delete item statuses
+const (
+	BulkItemDeleted  = "deleted"
+	BulkItemNotFound = "not_found"
+)
+
+// BulkDeleteFilter selects the rows removed by a bulk delete. When IDs is set
+// only those rows are deleted and the other criteria are ignored; otherwise
+// rows must match every field and the created_at range.
+type BulkDeleteFilter struct {
+	IDs           []int
+	Fields        map[string]string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// BulkDeleteItemResult reports the outcome for one row
+type BulkDeleteItemResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+// BulkDeleteResult summarizes a bulk delete. Deleted holds each removed row as
+// it was before deletion, keyed by ID, for the audit log.
+type BulkDeleteResult struct {
+	DeletedCount  int                            `json:"deleted_count"`
+	NotFoundCount int                            `json:"not_found_count"`
+	Results       []BulkDeleteItemResult         `json:"results"`
+	Deleted       map[int]map[string]interface{} `json:"-"`
+}
+
+// Filterable columns per table. Values are compared case-insensitively.
+var (
+	stockBulkFilterColumns  = map[string]bool{"symbol": true, "institution_name": true, "data_source": true}
+	cashBulkFilterColumns   = map[string]bool{"institution_name": true, "account_name": true, "account_type": true}
+	cryptoBulkFilterColumns = map[string]bool{"crypto_symbol": true, "institution_name": true}
+	otherBulkFilterColumns  = map[string]bool{"asset_category_id": true, "asset_name": true}
+)
+
+// BulkDelete removes stock holdings matching filter in a single transaction
+func (r *StockRepository) BulkDelete(filter BulkDeleteFilter) (*BulkDeleteResult, error) {
+	return bulkDelete(r.db, "stock_holdings", stockBulkFilterColumns, filter)
+}
+
+// BulkDelete removes cash holdings matching filter in a single transaction
+func (r *CashRepository) BulkDelete(filter BulkDeleteFilter) (*BulkDeleteResult, error) {
+	return bulkDelete(r.db, "cash_holdings", cashBulkFilterColumns, filter)
+}
+
+// BulkDelete removes crypto holdings matching filter in a single transaction
+func (r *CryptoRepository) BulkDelete(filter BulkDeleteFilter) (*BulkDeleteResult, error) {
+	return bulkDelete(r.db, "crypto_holdings", cryptoBulkFilterColumns, filter)
+}
+
+// BulkDelete removes other assets matching filter in a single transaction
+func (r *OtherAssetRepository) BulkDelete(filter BulkDeleteFilter) (*BulkDeleteResult, error) {
+	return bulkDelete(r.db, "miscellaneous_assets", otherBulkFilterColumns, filter)
+}
+
+// bulkDelete deletes the rows selected by filter inside one transaction, so
+// either every matching row is removed or none is. The table name and column
+// allow-list always come from repository code.
+func bulkDelete(db *sql.DB, table string, columns map[string]bool, filter BulkDeleteFilter) (*BulkDeleteResult, error) {
+	var where sqlbuilder.Where
+	var args sqlbuilder.Args
+
+	if len(filter.IDs) == 0 {
+		fieldNames := make([]string, 0, len(filter.Fields))
+		for name := range filter.Fields {
+			if !columns[name] {
+				return nil, fmt.Errorf("%w: cannot filter %s by %q", ErrInvalidFilter, table, name)
+			}
+			fieldNames = append(fieldNames, name)
+		}
+		sort.Strings(fieldNames)
+
+		for _, name := range fieldNames {
+			where.Add(fmt.Sprintf("LOWER(CAST(%s AS TEXT)) = LOWER(%s)", sqlbuilder.Ident("t."+name), args.Bind(filter.Fields[name])))
+		}
+		if filter.CreatedAfter != nil {
+			where.Compare(&args, "t.created_at", ">=", *filter.CreatedAfter)
+		}
+		if filter.CreatedBefore != nil {
+			where.Compare(&args, "t.created_at", "<", *filter.CreatedBefore)
+		}
+
+		if len(where) == 0 {
+			return nil, fmt.Errorf("%w: ids or at least one criterion is required", ErrInvalidFilter)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &BulkDeleteResult{
+		Results: []BulkDeleteItemResult{},
+		Deleted: map[int]map[string]interface{}{},
+	}
+	rowToJSON := database.DialectOf(db).RowToJSON(table, "t")
+
+	if len(filter.IDs) > 0 {
+		query := fmt.Sprintf("DELETE FROM %s AS t WHERE t.id = $1 RETURNING %s", sqlbuilder.Ident(table), rowToJSON)
+		for _, id := range filter.IDs {
+			var raw []byte
+			err := tx.QueryRow(query, id).Scan(&raw)
+			if err == sql.ErrNoRows {
+				result.NotFoundCount++
+				result.Results = append(result.Results, BulkDeleteItemResult{ID: id, Status: BulkItemNotFound})
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete %d from %s: %w", id, table, err)
+			}
+			if err := result.recordDeleted(id, raw); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		query := fmt.Sprintf("DELETE FROM %s AS t WHERE %s RETURNING id, %s", sqlbuilder.Ident(table), where, rowToJSON)
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+		for rows.Next() {
+			var id int
+			var raw []byte
+			if err := rows.Scan(&id, &raw); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan deleted row: %w", err)
+			}
+			if err := result.recordDeleted(id, raw); err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk delete: %w", err)
+	}
+
+	return result, nil
+}
+
+// recordDeleted adds a removed row, given as JSON, to the result
+func (r *BulkDeleteResult) recordDeleted(id int, raw []byte) error {
+	var row map[string]interface{}
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return fmt.Errorf("failed to decode deleted row %d: %w", id, err)
+	}
+	r.Deleted[id] = row
+	r.DeletedCount++
+	r.Results = append(r.Results, BulkDeleteItemResult{ID: id, Status: BulkItemDeleted})
+	return nil
+}
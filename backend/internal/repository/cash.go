@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"networth-dashboard/internal/encryption"
+	"networth-dashboard/internal/models"
+)
+
+// CashRepository provides access to cash holdings
+type CashRepository struct {
+	db        *sql.DB
+	encryptor *encryption.FieldEncryptor
+}
+
+// NewCashRepository creates a new cash repository
+func NewCashRepository(db *sql.DB, encryptor *encryption.FieldEncryptor) *CashRepository {
+	return &CashRepository{db: db, encryptor: encryptor}
+}
+
+// List returns all cash holdings ordered by institution and account name
+func (r *CashRepository) List() ([]models.CashHolding, error) {
+	query := `
+		SELECT id, account_id, institution_name, account_name, account_type, 
+		       current_balance, interest_rate, monthly_contribution, 
+		       account_number_last4, currency, notes, created_at, updated_at
+		FROM cash_holdings
+		ORDER BY institution_name, account_name
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cash holdings: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := make([]models.CashHolding, 0)
+	for rows.Next() {
+		var h models.CashHolding
+		err := rows.Scan(
+			&h.ID, &h.AccountID, &h.InstitutionName, &h.AccountName,
+			&h.AccountType, &h.CurrentBalance, &h.InterestRate,
+			&h.MonthlyContribution, &h.AccountNumberLast4, &h.Currency,
+			&h.Notes, &h.CreatedAt, &h.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cash holding: %w", err)
+		}
+		if err := r.encryptor.DecryptPtr(h.AccountNumberLast4); err != nil {
+			return nil, fmt.Errorf("failed to decrypt account number for cash holding %d: %w", h.ID, err)
+		}
+		holdings = append(holdings, h)
+	}
+
+	return holdings, rows.Err()
+}
+
+// Delete removes a cash holding
+func (r *CashRepository) Delete(id int) error {
+	return deleteByID(r.db, "cash_holdings", id)
+}
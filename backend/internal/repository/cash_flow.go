@@ -0,0 +1,444 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrInvalidReference is returned when a cash-flow transaction or property
+	// ledger entry names a category, cash holding or property that does not exist
+	ErrInvalidReference = errors.New("referenced record does not exist")
+	// ErrCategoryInUse is returned when deleting a category that still has transactions
+	ErrCategoryInUse = errors.New("category has transactions")
+	// ErrDuplicateCategory is returned when a category name is already used for its kind
+	ErrDuplicateCategory = errors.New("category already exists")
+)
+
+// Cash-flow transaction sources
+const (
+	CashFlowSourceManual = "manual"
+	CashFlowSourceImport = "import"
+)
+
+// CashFlowFilter narrows a transaction listing; zero values are ignored
+type CashFlowFilter struct {
+	From       *time.Time
+	To         *time.Time
+	CategoryID int
+	Kind       string
+	Limit      int
+}
+
+// CashFlowImportResult reports the outcome of an import
+type CashFlowImportResult struct {
+	Imported   int   `json:"imported"`
+	Duplicates int   `json:"duplicates"`
+	IDs        []int `json:"ids"`
+}
+
+// CashFlowRepository provides access to income and expense tracking
+type CashFlowRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewCashFlowRepository creates a new cash-flow repository
+func NewCashFlowRepository(db *sql.DB) *CashFlowRepository {
+	return &CashFlowRepository{db: db, dialect: database.DialectOf(db)}
+}
+
+// ListCategories returns all categories, income first
+func (r *CashFlowRepository) ListCategories() ([]models.CashFlowCategory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, kind, color, created_at
+		FROM cash_flow_categories
+		ORDER BY kind DESC, name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cash-flow categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make([]models.CashFlowCategory, 0)
+	for rows.Next() {
+		var c models.CashFlowCategory
+		if err := rows.Scan(&c.ID, &c.Name, &c.Kind, &c.Color, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cash-flow category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+// CreateCategory inserts a category and returns its ID
+func (r *CashFlowRepository) CreateCategory(input models.CashFlowCategoryInput) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO cash_flow_categories (name, kind, color) VALUES ($1, $2, $3)
+		RETURNING id
+	`, input.Name, input.Kind, input.Color).Scan(&id)
+	if err != nil {
+		return 0, cashFlowError(err, "failed to create cash-flow category")
+	}
+	return id, nil
+}
+
+// UpdateCategory replaces the writable fields of a category
+func (r *CashFlowRepository) UpdateCategory(id int, input models.CashFlowCategoryInput) error {
+	result, err := r.db.Exec(`
+		UPDATE cash_flow_categories SET name = $1, kind = $2, color = $3 WHERE id = $4
+	`, input.Name, input.Kind, input.Color, id)
+	if err != nil {
+		return cashFlowError(err, "failed to update cash-flow category")
+	}
+	return requireAffected(result)
+}
+
+// DeleteCategory removes a category that has no transactions
+func (r *CashFlowRepository) DeleteCategory(id int) error {
+	err := deleteByID(r.db, "cash_flow_categories", id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrCategoryInUse
+	}
+	return err
+}
+
+// ListTransactions returns transactions matching filter, newest first
+func (r *CashFlowRepository) ListTransactions(filter CashFlowFilter) ([]models.CashFlowTransaction, error) {
+	var where sqlbuilder.Where
+	var args sqlbuilder.Args
+	if filter.From != nil {
+		where.Compare(&args, "t.transaction_date", ">=", *filter.From)
+	}
+	if filter.To != nil {
+		where.Compare(&args, "t.transaction_date", "<=", *filter.To)
+	}
+	if filter.CategoryID > 0 {
+		where.Compare(&args, "t.category_id", "=", filter.CategoryID)
+	}
+	if filter.Kind != "" {
+		where.Compare(&args, "c.kind", "=", filter.Kind)
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT t.id, t.category_id, c.name, c.kind, t.amount, t.transaction_date, t.description,
+		       t.cash_holding_id, t.source, t.created_at, t.updated_at
+		FROM cash_flow_transactions t
+		JOIN cash_flow_categories c ON c.id = t.category_id
+		WHERE %s
+		ORDER BY t.transaction_date DESC, t.id DESC
+		LIMIT %s
+	`, where, args.Bind(filter.Limit)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cash-flow transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]models.CashFlowTransaction, 0)
+	for rows.Next() {
+		var t models.CashFlowTransaction
+		err := rows.Scan(&t.ID, &t.CategoryID, &t.CategoryName, &t.Kind, &t.Amount, &t.TransactionDate,
+			&t.Description, &t.CashHoldingID, &t.Source, &t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cash-flow transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// CreateTransaction inserts a manually entered transaction and returns its ID
+func (r *CashFlowRepository) CreateTransaction(input models.CashFlowTransactionInput, date time.Time) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO cash_flow_transactions (category_id, amount, transaction_date, description, cash_holding_id, source)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, input.CategoryID, input.Amount, date, input.Description, input.CashHoldingID, CashFlowSourceManual).Scan(&id)
+	if err != nil {
+		return 0, cashFlowError(err, "failed to create cash-flow transaction")
+	}
+	return id, nil
+}
+
+// UpdateTransaction replaces the writable fields of a transaction
+func (r *CashFlowRepository) UpdateTransaction(id int, input models.CashFlowTransactionInput, date time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE cash_flow_transactions
+		SET category_id = $1, amount = $2, transaction_date = $3, description = $4,
+		    cash_holding_id = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`, input.CategoryID, input.Amount, date, input.Description, input.CashHoldingID, id)
+	if err != nil {
+		return cashFlowError(err, "failed to update cash-flow transaction")
+	}
+	return requireAffected(result)
+}
+
+// DeleteTransaction removes a transaction
+func (r *CashFlowRepository) DeleteTransaction(id int) error {
+	return deleteByID(r.db, "cash_flow_transactions", id)
+}
+
+// Import inserts parsed statement rows in one transaction. Rows are matched to
+// categories by name and kind, creating categories that do not exist yet, and
+// rows imported before are skipped. The rows are sent as a single batch
+// rather than one round trip each.
+func (r *CashFlowRepository) Import(rows []models.CashFlowImportRow) (*CashFlowImportResult, error) {
+	if database.IsSQLite(r.db) {
+		return r.importSQLite(rows)
+	}
+
+	ctx := context.Background()
+	var result *CashFlowImportResult
+	err := database.WithConn(ctx, r.db, func(conn *pgx.Conn) error {
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			var err error
+			result, err = importCashFlowRows(ctx, tx, rows)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importCashFlowRows resolves the rows' categories, then inserts the rows in
+// one batch
+func importCashFlowRows(ctx context.Context, tx pgx.Tx, rows []models.CashFlowImportRow) (*CashFlowImportResult, error) {
+	categoryIDs := make(map[string]int)
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		name := importCategoryName(row)
+		key := strings.ToLower(name) + "|" + row.Kind
+		categoryID, ok := categoryIDs[key]
+		if !ok {
+			err := tx.QueryRow(ctx, `
+				SELECT id FROM cash_flow_categories WHERE LOWER(name) = LOWER($1) AND kind = $2
+			`, name, row.Kind).Scan(&categoryID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = tx.QueryRow(ctx, `
+					INSERT INTO cash_flow_categories (name, kind) VALUES ($1, $2) RETURNING id
+				`, name, row.Kind).Scan(&categoryID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve category %q: %w", name, err)
+			}
+			categoryIDs[key] = categoryID
+		}
+
+		var description *string
+		if row.Description != "" {
+			description = &row.Description
+		}
+
+		batch.Queue(`
+			INSERT INTO cash_flow_transactions (category_id, amount, transaction_date, description, source, import_key)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (import_key) DO NOTHING
+			RETURNING id
+		`, categoryID, row.Amount, row.Date, description, CashFlowSourceImport, row.ImportKey)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	result := &CashFlowImportResult{IDs: []int{}}
+	for range rows {
+		var id int
+		err := results.QueryRow().Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.Duplicates++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import transaction: %w", err)
+		}
+		result.Imported++
+		result.IDs = append(result.IDs, id)
+	}
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("failed to import transactions: %w", err)
+	}
+	return result, nil
+}
+
+// importSQLite is Import for SQLite, which runs in process, so the rows are
+// inserted one at a time
+func (r *CashFlowRepository) importSQLite(rows []models.CashFlowImportRow) (*CashFlowImportResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	categoryIDs := make(map[string]int)
+	result := &CashFlowImportResult{IDs: []int{}}
+	for _, row := range rows {
+		name := importCategoryName(row)
+		key := strings.ToLower(name) + "|" + row.Kind
+		categoryID, ok := categoryIDs[key]
+		if !ok {
+			err := tx.QueryRow(`
+				SELECT id FROM cash_flow_categories WHERE LOWER(name) = LOWER($1) AND kind = $2
+			`, name, row.Kind).Scan(&categoryID)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = tx.QueryRow(`
+					INSERT INTO cash_flow_categories (name, kind) VALUES ($1, $2) RETURNING id
+				`, name, row.Kind).Scan(&categoryID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve category %q: %w", name, err)
+			}
+			categoryIDs[key] = categoryID
+		}
+
+		var description *string
+		if row.Description != "" {
+			description = &row.Description
+		}
+
+		var id int
+		err := tx.QueryRow(`
+			INSERT INTO cash_flow_transactions (category_id, amount, transaction_date, description, source, import_key)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (import_key) DO NOTHING
+			RETURNING id
+		`, categoryID, row.Amount, row.Date, description, CashFlowSourceImport, row.ImportKey).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			result.Duplicates++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import transaction: %w", err)
+		}
+		result.Imported++
+		result.IDs = append(result.IDs, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return result, nil
+}
+
+// importCategoryName is the category a row is imported into, Other Income or
+// Other Expenses when it names none
+func importCategoryName(row models.CashFlowImportRow) string {
+	if row.CategoryName != "" {
+		return row.CategoryName
+	}
+	if row.Kind == models.CashFlowIncome {
+		return "Other Income"
+	}
+	return "Other Expenses"
+}
+
+// Summary totals income and expenses per month and category for the months
+// starting at from and ending before to. Months without transactions are included.
+func (r *CashFlowRepository) Summary(from, to time.Time) (*models.CashFlowSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT `+r.dialect.Month("t.transaction_date")+`, c.id, c.name, c.kind, SUM(t.amount)
+		FROM cash_flow_transactions t
+		JOIN cash_flow_categories c ON c.id = t.category_id
+		WHERE t.transaction_date >= $1 AND t.transaction_date < $2
+		GROUP BY 1, c.id, c.name, c.kind
+		ORDER BY 1, c.kind DESC, SUM(t.amount) DESC
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize cash flow: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &models.CashFlowSummary{
+		From:   from.Format("2006-01-02"),
+		To:     to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Months: []models.CashFlowMonth{},
+	}
+	monthIndex := make(map[string]int)
+	for month := from; month.Before(to); month = month.AddDate(0, 1, 0) {
+		label := month.Format("2006-01")
+		monthIndex[label] = len(summary.Months)
+		summary.Months = append(summary.Months, models.CashFlowMonth{Month: label, Categories: []models.CashFlowCategoryTotal{}})
+	}
+
+	for rows.Next() {
+		var label string
+		var total models.CashFlowCategoryTotal
+		if err := rows.Scan(&label, &total.CategoryID, &total.CategoryName, &total.Kind, &total.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan cash-flow summary: %w", err)
+		}
+		i, ok := monthIndex[label]
+		if !ok {
+			continue
+		}
+		month := &summary.Months[i]
+		if total.Kind == models.CashFlowIncome {
+			month.Income = month.Income.Add(total.Amount)
+		} else {
+			month.Expenses = month.Expenses.Add(total.Amount)
+		}
+		month.Categories = append(month.Categories, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to summarize cash flow: %w", err)
+	}
+
+	for i := range summary.Months {
+		month := &summary.Months[i]
+		month.NetSavings = month.Income.Sub(month.Expenses)
+		month.SavingsRate = models.SavingsRate(month.Income, month.Expenses)
+		summary.TotalIncome = summary.TotalIncome.Add(month.Income)
+		summary.TotalExpenses = summary.TotalExpenses.Add(month.Expenses)
+	}
+	summary.NetSavings = summary.TotalIncome.Sub(summary.TotalExpenses)
+	summary.SavingsRate = models.SavingsRate(summary.TotalIncome, summary.TotalExpenses)
+	if len(summary.Months) > 0 {
+		summary.AverageMonthlyNetSavings = summary.NetSavings.Div(decimal.NewFromInt(int64(len(summary.Months)))).Round(2)
+	}
+
+	return summary, nil
+}
+
+// requireAffected returns ErrNotFound when an update matched no rows
+func requireAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// cashFlowError maps constraint violations to repository errors
+func cashFlowError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case foreignKeyViolation:
+			return fmt.Errorf("%w: %s", ErrInvalidReference, pgErr.Detail)
+		case uniqueViolation:
+			return ErrDuplicateCategory
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
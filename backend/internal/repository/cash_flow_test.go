@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+)
+
+func TestMain(m *testing.M) {
+	// As main does, so amounts encode as JSON numbers
+	decimal.MarshalJSONWithoutQuotes = true
+	os.Exit(m.Run())
+}
+
+func TestCashFlowSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	from := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// Cents that float64 can't hold exactly; September has no transactions
+	mock.ExpectQuery(`FROM cash_flow_transactions t`).WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"month", "id", "name", "kind", "sum"}).
+			AddRow("2026-07", 1, "Salary", "income", "10000.00").
+			AddRow("2026-07", 2, "Groceries", "expense", "600.20").
+			AddRow("2026-07", 3, "Dining", "expense", "0.10").
+			AddRow("2026-08", 1, "Salary", "income", "12500.00").
+			AddRow("2026-08", 2, "Groceries", "expense", "599.90"))
+
+	summary, err := NewCashFlowRepository(db).Summary(from, to)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+
+	got, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"from":"2026-07-01","to":"2026-09-30","total_income":22500,"total_expenses":1200.2,` +
+		`"net_savings":21299.8,"savings_rate":94.66577777777778,"average_monthly_net_savings":7099.93,"months":[` +
+		`{"month":"2026-07","income":10000,"expenses":600.3,"net_savings":9399.7,"savings_rate":93.997,"categories":[` +
+		`{"category_id":1,"category_name":"Salary","kind":"income","amount":10000},` +
+		`{"category_id":2,"category_name":"Groceries","kind":"expense","amount":600.2},` +
+		`{"category_id":3,"category_name":"Dining","kind":"expense","amount":0.1}]},` +
+		`{"month":"2026-08","income":12500,"expenses":599.9,"net_savings":11900.1,"savings_rate":95.2008,"categories":[` +
+		`{"category_id":1,"category_name":"Salary","kind":"income","amount":12500},` +
+		`{"category_id":2,"category_name":"Groceries","kind":"expense","amount":599.9}]},` +
+		`{"month":"2026-09","income":0,"expenses":0,"net_savings":0,"savings_rate":null,"categories":[]}]}`
+	if string(got) != want {
+		t.Errorf("Summary JSON =\n%s\nwant\n%s", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
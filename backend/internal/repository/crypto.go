@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/encryption"
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// CryptoRepository provides access to cryptocurrency holdings
+type CryptoRepository struct {
+	db        *sql.DB
+	encryptor *encryption.FieldEncryptor
+}
+
+// NewCryptoRepository creates a new crypto repository
+func NewCryptoRepository(db *sql.DB, encryptor *encryption.FieldEncryptor) *CryptoRepository {
+	return &CryptoRepository{db: db, encryptor: encryptor}
+}
+
+// List returns all crypto holdings joined with their latest cached price
+func (r *CryptoRepository) List() ([]models.CryptoHolding, error) {
+	query := `
+		SELECT ch.id, ch.account_id, ch.institution_name, ch.crypto_symbol, 
+		       ch.balance_tokens, ch.purchase_price_usd, ch.purchase_date,
+		       ch.wallet_address, ch.notes, ch.staking_annual_percentage, ch.staked_tokens, ch.created_at, ch.updated_at,
+		       cp.price_usd, cp.price_btc, cp.price_change_24h, cp.last_updated
+		FROM crypto_holdings ch
+		LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
+		AND cp.last_updated = (
+			SELECT MAX(last_updated)
+			FROM crypto_prices cp2
+			WHERE cp2.symbol = ch.crypto_symbol
+		)
+		ORDER BY ch.institution_name, ch.crypto_symbol
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto holdings: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := make([]models.CryptoHolding, 0)
+	for rows.Next() {
+		var h models.CryptoHolding
+		err := rows.Scan(
+			&h.ID, &h.AccountID, &h.InstitutionName, &h.CryptoSymbol,
+			&h.BalanceTokens, &h.PurchasePriceUSD, &h.PurchaseDate,
+			&h.WalletAddress, &h.Notes, &h.StakingAnnualPercentage, &h.StakedTokens, &h.CreatedAt, &h.UpdatedAt,
+			&h.CurrentPriceUSD, &h.CurrentPriceBTC, &h.PriceChange24h, &h.PriceLastUpdated,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan crypto holding: %w", err)
+		}
+		if err := r.encryptor.DecryptPtr(h.WalletAddress); err != nil {
+			return nil, fmt.Errorf("failed to decrypt wallet address for crypto holding %d: %w", h.ID, err)
+		}
+
+		h.LiquidTokens = h.BalanceTokens - h.StakedTokens
+
+		// Calculate current value in USD
+		if h.CurrentPriceUSD != nil {
+			value := decimal.NewFromFloat(h.BalanceTokens).Mul(*h.CurrentPriceUSD)
+			h.CurrentValueUSD = &value
+		}
+
+		holdings = append(holdings, h)
+	}
+
+	return holdings, rows.Err()
+}
+
+// Delete removes a crypto holding
+func (r *CryptoRepository) Delete(id int) error {
+	return deleteByID(r.db, "crypto_holdings", id)
+}
+
+// PriceUpdateTimes returns when each crypto symbol held was last priced, nil
+// when never. A coin held in several wallets counts once.
+func (r *CryptoRepository) PriceUpdateTimes() ([]*time.Time, error) {
+	return updateTimes(r.db, `
+		SELECT (SELECT MAX(cp.last_updated) FROM crypto_prices cp WHERE cp.symbol = held.crypto_symbol)
+		FROM (SELECT DISTINCT crypto_symbol FROM crypto_holdings) held
+	`, "crypto prices")
+}
+
+// PriceHistory returns the cached price snapshots taken since the given time,
+// by symbol and then oldest first
+func (r *CryptoRepository) PriceHistory(since time.Time) ([]models.CryptoPricePoint, error) {
+	rows, err := r.db.Query(`
+		SELECT symbol, price_usd, price_btc, last_updated
+		FROM crypto_price_history
+		WHERE last_updated >= $1
+		ORDER BY symbol, last_updated
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto price history: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.CryptoPricePoint{}
+	for rows.Next() {
+		var p models.CryptoPricePoint
+		if err := rows.Scan(&p.Symbol, &p.PriceUSD, &p.PriceBTC, &p.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan crypto price: %w", err)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+// DashboardRepository stores dashboard layouts
+type DashboardRepository struct {
+	db *sql.DB
+}
+
+// NewDashboardRepository creates a new dashboard repository
+func NewDashboardRepository(db *sql.DB) *DashboardRepository {
+	return &DashboardRepository{db: db}
+}
+
+// Get returns the layout saved for userID (nil for the shared layout), or the
+// default layout when none has been saved
+func (r *DashboardRepository) Get(userID *int) (*models.SavedDashboardConfig, error) {
+	var raw []byte
+	var updatedAt time.Time
+	err := r.db.QueryRow(`
+		SELECT config, updated_at FROM dashboard_configs WHERE COALESCE(user_id, 0) = COALESCE($1, 0)
+	`, userID).Scan(&raw, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.SavedDashboardConfig{Config: models.DefaultDashboardConfig(), Default: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dashboard config: %w", err)
+	}
+
+	saved := &models.SavedDashboardConfig{UpdatedAt: &updatedAt}
+	if err := json.Unmarshal(raw, &saved.Config); err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard config: %w", err)
+	}
+	return saved, nil
+}
+
+// Save stores the layout for userID (nil for the shared layout)
+func (r *DashboardRepository) Save(userID *int, config models.DashboardConfig) (*models.SavedDashboardConfig, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard config: %w", err)
+	}
+
+	var updatedAt time.Time
+	err = r.db.QueryRow(`
+		INSERT INTO dashboard_configs (user_id, config) VALUES ($1, $2)
+		ON CONFLICT ((COALESCE(user_id, 0))) DO UPDATE
+		SET config = EXCLUDED.config, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, userID, raw).Scan(&updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save dashboard config: %w", err)
+	}
+	return &models.SavedDashboardConfig{Config: config, UpdatedAt: &updatedAt}, nil
+}
+
+// Reset deletes the layout saved for userID so the default applies again
+func (r *DashboardRepository) Reset(userID *int) error {
+	_, err := r.db.Exec(`DELETE FROM dashboard_configs WHERE COALESCE(user_id, 0) = COALESCE($1, 0)`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset dashboard config: %w", err)
+	}
+	return nil
+}
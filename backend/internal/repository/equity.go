@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// EquityRepository provides access to equity compensation grants
+type EquityRepository struct {
+	db *sql.DB
+}
+
+// NewEquityRepository creates a new equity repository
+func NewEquityRepository(db *sql.DB) *EquityRepository {
+	return &EquityRepository{db: db}
+}
+
+// List returns all equity grants, newest grant first
+func (r *EquityRepository) List() ([]models.EquityGrant, error) {
+	query := `
+		SELECT id, account_id, grant_type, company_symbol, total_shares, 
+		       vested_shares, unvested_shares, strike_price, grant_date, 
+		       vest_start_date, current_price, data_source, created_at,
+		       expiration_date, election_83b_filed_date, amt_basis_per_share
+		FROM equity_grants
+		ORDER BY grant_date DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch equity grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := make([]models.EquityGrant, 0)
+	for rows.Next() {
+		var g models.EquityGrant
+		err := rows.Scan(
+			&g.ID, &g.AccountID, &g.GrantType, &g.CompanySymbol,
+			&g.TotalShares, &g.VestedShares, &g.UnvestedShares,
+			&g.StrikePrice, &g.GrantDate, &g.VestStartDate, &g.CurrentPrice, &g.DataSource, &g.CreatedAt,
+			&g.ExpirationDate, &g.Election83bFiledDate, &g.AMTBasisPerShare,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan equity grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+
+	return grants, rows.Err()
+}
+
+// UpcomingVests returns the next limit vests scheduled on or after from, soonest first
+func (r *EquityRepository) UpcomingVests(from time.Time, limit int) ([]models.UpcomingVest, error) {
+	rows, err := r.db.Query(`
+		SELECT g.id, UPPER(g.company_symbol), g.grant_type, vs.vest_date, vs.shares_vesting,
+		       vs.shares_vesting * CASE WHEN g.grant_type IN ('iso', 'nso')
+		            THEN GREATEST(COALESCE(g.current_price, 0) - COALESCE(g.strike_price, 0), 0)
+		            ELSE COALESCE(g.current_price, 0) END
+		FROM vesting_schedule vs
+		JOIN equity_grants g ON g.id = vs.grant_id
+		WHERE vs.vest_date >= $1
+		ORDER BY vs.vest_date, g.company_symbol, g.id
+		LIMIT $2
+	`, from.Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch upcoming vests: %w", err)
+	}
+	defer rows.Close()
+
+	vests := []models.UpcomingVest{}
+	for rows.Next() {
+		var v models.UpcomingVest
+		var date time.Time
+		if err := rows.Scan(&v.GrantID, &v.Symbol, &v.GrantType, &date, &v.Shares, &v.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan upcoming vest: %w", err)
+		}
+		v.VestDate = date.Format("2006-01-02")
+		v.Value = math.Round(v.Value*100) / 100
+		vests = append(vests, v)
+	}
+	return vests, rows.Err()
+}
+
+// Create inserts a manually entered equity grant and returns its ID. The
+// input must already have passed Validate. Unvested shares are generated
+// from the total and vested shares.
+func (r *EquityRepository) Create(input models.EquityGrantInput, currentPrice decimal.Decimal) (int, error) {
+	query := `
+		INSERT INTO equity_grants (
+			account_id, grant_type, company_symbol, total_shares, vested_shares, 
+			strike_price, grant_date, vest_start_date, 
+			current_price, data_source, created_at, expiration_date,
+			election_83b_filed_date, amt_basis_per_share
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`
+
+	var grantID int
+	err := r.db.QueryRow(
+		query,
+		input.AccountID, input.GrantType, input.CompanySymbol,
+		input.TotalShares, input.VestedShares,
+		input.StrikePrice, input.GrantDate, input.VestStartDate,
+		currentPrice, "manual", time.Now(), models.OptionalDate(input.ExpirationDate),
+		models.OptionalDate(input.Election83bFiledDate), input.AMTBasisPerShare,
+	).Scan(&grantID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create equity grant: %w", err)
+	}
+
+	return grantID, nil
+}
+
+// Update replaces the writable fields of an equity grant. The input must
+// already have passed Validate.
+func (r *EquityRepository) Update(id int, input models.EquityGrantInput, currentPrice decimal.Decimal) error {
+	query := `
+		UPDATE equity_grants 
+		SET account_id = $1, grant_type = $2, company_symbol = $3, total_shares = $4, 
+		    vested_shares = $5, strike_price = $6, current_price = $7, 
+		    grant_date = $8, vest_start_date = $9, last_updated = $10, expiration_date = $11,
+		    election_83b_filed_date = $12, amt_basis_per_share = $13
+		WHERE id = $14
+	`
+
+	result, err := r.db.Exec(
+		query,
+		input.AccountID, input.GrantType, input.CompanySymbol,
+		input.TotalShares, input.VestedShares,
+		input.StrikePrice, currentPrice, input.GrantDate, input.VestStartDate,
+		time.Now(), models.OptionalDate(input.ExpirationDate),
+		models.OptionalDate(input.Election83bFiledDate), input.AMTBasisPerShare, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update equity grant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetCurrentPrice returns the stored current price of a grant, or 0 if unknown
+func (r *EquityRepository) GetCurrentPrice(id int) decimal.Decimal {
+	var price decimal.Decimal
+	r.db.QueryRow("SELECT COALESCE(current_price, 0) FROM equity_grants WHERE id = $1", id).Scan(&price)
+	return price
+}
+
+// Delete removes an equity grant
+func (r *EquityRepository) Delete(id int) error {
+	return deleteByID(r.db, "equity_grants", id)
+}
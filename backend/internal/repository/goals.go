@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnknownCashHolding is returned when a goal links a cash holding that does not exist
+var ErrUnknownCashHolding = errors.New("linked cash holding does not exist")
+
+// goalSelectQuery selects goals with the IDs of their cash holdings, which
+// are aggregated with the expression formatted in
+const goalSelectQuery = `
+	SELECT g.id, g.name, g.description, g.target_amount, g.target_date, g.asset_classes,
+	       (SELECT %s FROM goal_cash_holdings gch WHERE gch.goal_id = g.id),
+	       g.created_at, g.updated_at
+	FROM goals g
+`
+
+// GoalRepository provides access to savings goals and their progress
+type GoalRepository struct {
+	db          *sql.DB
+	selectQuery string
+	netWorth    *NetWorthRepository
+}
+
+// NewGoalRepository creates a new goal repository
+func NewGoalRepository(db *sql.DB) *GoalRepository {
+	return &GoalRepository{
+		db:          db,
+		selectQuery: fmt.Sprintf(goalSelectQuery, database.DialectOf(db).ArrayAgg("gch.cash_holding_id ORDER BY gch.cash_holding_id")),
+		netWorth:    NewNetWorthRepository(db),
+	}
+}
+
+// List returns all goals, soonest target date first
+func (r *GoalRepository) List() ([]models.Goal, error) {
+	rows, err := r.db.Query(r.selectQuery + " ORDER BY g.target_date NULLS LAST, g.id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := make([]models.Goal, 0)
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+
+	return goals, rows.Err()
+}
+
+// Get returns a single goal
+func (r *GoalRepository) Get(id int) (*models.Goal, error) {
+	g, err := scanGoal(r.db.QueryRow(r.selectQuery+" WHERE g.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Create inserts a goal with its linked cash holdings and returns its ID
+func (r *GoalRepository) Create(input models.GoalInput, targetDate *time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO goals (name, description, target_amount, target_date, asset_classes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, input.Name, input.Description, input.TargetAmount, targetDate, input.AssetClasses).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	if err := insertGoalCashHoldings(tx, id, input.CashHoldingIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit goal: %w", err)
+	}
+	return id, nil
+}
+
+// Update replaces the writable fields and linked cash holdings of a goal
+func (r *GoalRepository) Update(id int, input models.GoalInput, targetDate *time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE goals
+		SET name = $1, description = $2, target_amount = $3, target_date = $4,
+		    asset_classes = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`, input.Name, input.Description, input.TargetAmount, targetDate, input.AssetClasses, id)
+	if err != nil {
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM goal_cash_holdings WHERE goal_id = $1", id); err != nil {
+		return fmt.Errorf("failed to update goal accounts: %w", err)
+	}
+	if err := insertGoalCashHoldings(tx, id, input.CashHoldingIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit goal: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a goal
+func (r *GoalRepository) Delete(id int) error {
+	return deleteByID(r.db, "goals", id)
+}
+
+// AttachProgress fills in the progress of each goal from current balances and
+// active recurring contributions. A linked cash holding already counted by a
+// linked asset class is not counted twice.
+func (r *GoalRepository) AttachProgress(goals []models.Goal, now time.Time) error {
+	breakdown, err := r.netWorth.Breakdown()
+	if err != nil {
+		return err
+	}
+
+	classValues := map[string]decimal.Decimal{models.GoalAssetClassNetWorth: breakdown.NetWorth()}
+	for _, component := range breakdown.Components() {
+		classValues[component.Key] = component.Value
+	}
+
+	rows, err := r.db.Query(`
+		SELECT ch.id, ch.account_type, ch.current_balance,
+		       CASE WHEN rc.id IS NULL OR rc.active THEN COALESCE(ch.monthly_contribution, 0) ELSE 0 END
+		FROM cash_holdings ch
+		LEFT JOIN recurring_contributions rc ON rc.cash_holding_id = ch.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to fetch cash holdings: %w", err)
+	}
+	defer rows.Close()
+
+	type cashAccount struct {
+		class                 string
+		balance, contribution decimal.Decimal
+	}
+	accounts := make(map[int]cashAccount)
+	classContributions := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var id int
+		var accountType string
+		var a cashAccount
+		if err := rows.Scan(&id, &accountType, &a.balance, &a.contribution); err != nil {
+			return fmt.Errorf("failed to scan cash holding: %w", err)
+		}
+		// Brokerage balances count toward stocks in the net worth breakdown
+		a.class = "cash_holdings"
+		if accountType == "brokerage" {
+			a.class = "stock_holdings"
+		}
+		accounts[id] = a
+		classContributions[a.class] = classContributions[a.class].Add(a.contribution)
+		classContributions[models.GoalAssetClassNetWorth] = classContributions[models.GoalAssetClassNetWorth].Add(a.contribution)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch cash holdings: %w", err)
+	}
+
+	for i := range goals {
+		g := &goals[i]
+		linked := make(map[string]bool, len(g.AssetClasses))
+		var current, monthly decimal.Decimal
+		for _, class := range g.AssetClasses {
+			linked[class] = true
+			current = current.Add(classValues[class])
+			monthly = monthly.Add(classContributions[class])
+		}
+		for _, id := range g.CashHoldingIDs {
+			a, ok := accounts[id]
+			if !ok || linked[a.class] || linked[models.GoalAssetClassNetWorth] {
+				continue
+			}
+			current = current.Add(a.balance)
+			monthly = monthly.Add(a.contribution)
+		}
+
+		progress := models.NewGoalProgress(g.TargetAmount, current, monthly, g.TargetDate, now)
+		g.Progress = &progress
+	}
+	return nil
+}
+
+// insertGoalCashHoldings links cash holdings to a goal
+func insertGoalCashHoldings(tx *sql.Tx, goalID int, cashHoldingIDs []int) error {
+	for _, cashHoldingID := range cashHoldingIDs {
+		_, err := tx.Exec(`
+			INSERT INTO goal_cash_holdings (goal_id, cash_holding_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, goalID, cashHoldingID)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+				return fmt.Errorf("%w: %d", ErrUnknownCashHolding, cashHoldingID)
+			}
+			return fmt.Errorf("failed to link cash holding %d: %w", cashHoldingID, err)
+		}
+	}
+	return nil
+}
+
+// scanGoal scans a row of goalSelectQuery
+func scanGoal(row interface{ Scan(...interface{}) error }) (models.Goal, error) {
+	var g models.Goal
+	var targetDate sql.NullTime
+	var cashHoldingIDs []int64
+	err := row.Scan(&g.ID, &g.Name, &g.Description, &g.TargetAmount, &targetDate,
+		database.Array(&g.AssetClasses), database.Array(&cashHoldingIDs), &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return g, err
+		}
+		return g, fmt.Errorf("failed to scan goal: %w", err)
+	}
+
+	if targetDate.Valid {
+		g.TargetDate = &targetDate.Time
+	}
+	g.CashHoldingIDs = make([]int, len(cashHoldingIDs))
+	for i, id := range cashHoldingIDs {
+		g.CashHoldingIDs[i] = int(id)
+	}
+	return g, nil
+}
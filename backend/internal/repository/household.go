@@ -0,0 +1,364 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrDuplicateMember is returned when a member name is already used, ignoring case
+	ErrDuplicateMember = errors.New("household member already exists")
+	// ErrUserAlreadyMember is returned when linking a user that another member is linked to
+	ErrUserAlreadyMember = errors.New("user is already linked to a household member")
+	// ErrUnknownUser is returned when linking a member to a user that does not exist
+	ErrUnknownUser = errors.New("user does not exist")
+	// ErrUnknownMember is returned when attributing a holding to a member that does not exist
+	ErrUnknownMember = errors.New("household member does not exist")
+	// ErrOwnershipOverAllocated is returned when a holding's shares add up to more than 100%
+	ErrOwnershipOverAllocated = errors.New("ownership percentages add up to more than 100")
+)
+
+// HouseholdRepository handles the household, its members and who owns each holding
+type HouseholdRepository struct {
+	db *sql.DB
+}
+
+// NewHouseholdRepository creates a new household repository
+func NewHouseholdRepository(db *sql.DB) *HouseholdRepository {
+	return &HouseholdRepository{db: db}
+}
+
+// Get returns the household with its members
+func (r *HouseholdRepository) Get() (*models.Household, error) {
+	household := &models.Household{Name: "Household"}
+	err := r.db.QueryRow(`SELECT name FROM household WHERE id = 1`).Scan(&household.Name)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to fetch household: %w", err)
+	}
+
+	household.Members, err = r.ListMembers()
+	if err != nil {
+		return nil, err
+	}
+	return household, nil
+}
+
+// Rename changes the household name
+func (r *HouseholdRepository) Rename(name string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO household (id, name) VALUES (1, $1)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_at = CURRENT_TIMESTAMP
+	`, name)
+	if err != nil {
+		return fmt.Errorf("failed to rename household: %w", err)
+	}
+	return nil
+}
+
+// ListMembers returns the household members in the order they were added
+func (r *HouseholdRepository) ListMembers() ([]models.HouseholdMember, error) {
+	rows, err := r.db.Query(`SELECT id, name, user_id, created_at FROM household_members ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch household members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.HouseholdMember{}
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// GetMember returns one household member
+func (r *HouseholdRepository) GetMember(id int) (*models.HouseholdMember, error) {
+	member, err := scanMember(r.db.QueryRow(`SELECT id, name, user_id, created_at FROM household_members WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// MemberForUser returns the member a user is linked to
+func (r *HouseholdRepository) MemberForUser(userID int) (*models.HouseholdMember, error) {
+	member, err := scanMember(r.db.QueryRow(`SELECT id, name, user_id, created_at FROM household_members WHERE user_id = $1`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// CreateMember adds a household member
+func (r *HouseholdRepository) CreateMember(input models.HouseholdMemberInput) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO household_members (name, user_id) VALUES ($1, $2) RETURNING id
+	`, strings.TrimSpace(input.Name), input.UserID).Scan(&id)
+	if err != nil {
+		return 0, memberError(err, "failed to create household member")
+	}
+	return id, nil
+}
+
+// UpdateMember renames a member or changes the user linked to them
+func (r *HouseholdRepository) UpdateMember(id int, input models.HouseholdMemberInput) error {
+	result, err := r.db.Exec(`
+		UPDATE household_members SET name = $1, user_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3
+	`, strings.TrimSpace(input.Name), input.UserID, id)
+	if err != nil {
+		return memberError(err, "failed to update household member")
+	}
+	return requireAffected(result)
+}
+
+// DeleteMember removes a member. Their shares are dropped, so holdings they
+// owned part of fall back to being split between the remaining members.
+func (r *HouseholdRepository) DeleteMember(id int) error {
+	return deleteByID(r.db, "household_members", id)
+}
+
+// Ownership returns who owns a holding
+func (r *HouseholdRepository) Ownership(ref models.HoldingRef) (*models.HoldingOwnership, error) {
+	if _, ok := holdingTables[ref.HoldingType]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHoldingType, ref.HoldingType)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT member_id, percentage FROM holding_ownership
+		WHERE holding_type = $1 AND holding_id = $2
+		ORDER BY member_id
+	`, ref.HoldingType, ref.HoldingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ownership: %w", err)
+	}
+	defer rows.Close()
+
+	ownership := &models.HoldingOwnership{HoldingRef: ref, Shares: []models.OwnershipShare{}}
+	for rows.Next() {
+		var share models.OwnershipShare
+		if err := rows.Scan(&share.MemberID, &share.Percentage); err != nil {
+			return nil, fmt.Errorf("failed to scan ownership: %w", err)
+		}
+		ownership.Shares = append(ownership.Shares, share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch ownership: %w", err)
+	}
+
+	members, err := r.ListMembers()
+	if err != nil {
+		return nil, err
+	}
+	memberIDs := make([]int, len(members))
+	for i, member := range members {
+		memberIDs[i] = member.ID
+	}
+
+	fractions := effectiveShares(ownership.Shares, memberIDs)
+	ownership.Effective = make([]models.OwnershipShare, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		ownership.Effective = append(ownership.Effective, models.OwnershipShare{
+			MemberID:   memberID,
+			Percentage: math.Round(fractions[memberID]*10000) / 100,
+		})
+	}
+	ownership.Unassigned = 100 - sumShares(ownership.Shares)
+	return ownership, nil
+}
+
+// SetOwnership replaces the shares of a holding. An empty list splits it
+// equally between all members.
+func (r *HouseholdRepository) SetOwnership(ref models.HoldingRef, shares []models.OwnershipShare) error {
+	table, ok := holdingTables[ref.HoldingType]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidHoldingType, ref.HoldingType)
+	}
+	if sumShares(shares) > 100.005 {
+		return ErrOwnershipOverAllocated
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", sqlbuilder.Ident(table))
+	if err := tx.QueryRow(query, ref.HoldingID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check %s %d: %w", ref.HoldingType, ref.HoldingID, err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s %d", ErrUnknownHolding, ref.HoldingType, ref.HoldingID)
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM holding_ownership WHERE holding_type = $1 AND holding_id = $2
+	`, ref.HoldingType, ref.HoldingID); err != nil {
+		return fmt.Errorf("failed to clear ownership: %w", err)
+	}
+
+	for _, share := range shares {
+		_, err := tx.Exec(`
+			INSERT INTO holding_ownership (holding_type, holding_id, member_id, percentage)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (holding_type, holding_id, member_id) DO UPDATE
+			SET percentage = holding_ownership.percentage + EXCLUDED.percentage
+		`, ref.HoldingType, ref.HoldingID, share.MemberID, share.Percentage)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+				return fmt.Errorf("%w: %d", ErrUnknownMember, share.MemberID)
+			}
+			return fmt.Errorf("failed to save ownership: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MemberBreakdowns splits the net worth breakdown between household members
+// by their effective share of each holding. Together the breakdowns add up to
+// the household's; with no members the result is empty.
+func (r *HouseholdRepository) MemberBreakdowns() (map[int]models.NetWorthBreakdown, error) {
+	members, err := r.ListMembers()
+	if err != nil {
+		return nil, err
+	}
+	breakdowns := make(map[int]models.NetWorthBreakdown, len(members))
+	if len(members) == 0 {
+		return breakdowns, nil
+	}
+	memberIDs := make([]int, len(members))
+	for i, member := range members {
+		memberIDs[i] = member.ID
+		breakdowns[member.ID] = models.NetWorthBreakdown{}
+	}
+
+	explicit, err := r.allShares()
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, holding := range values {
+		for memberID, fraction := range effectiveShares(explicit[holding.HoldingRef], memberIDs) {
+			breakdown := breakdowns[memberID]
+			breakdown.AddComponent(holding.Component, holding.Value.Mul(decimal.NewFromFloat(fraction)))
+			breakdowns[memberID] = breakdown
+		}
+	}
+	return breakdowns, nil
+}
+
+// allShares returns the shares set for every holding
+func (r *HouseholdRepository) allShares() (map[models.HoldingRef][]models.OwnershipShare, error) {
+	rows, err := r.db.Query(`SELECT holding_type, holding_id, member_id, percentage FROM holding_ownership`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ownership: %w", err)
+	}
+	defer rows.Close()
+
+	shares := make(map[models.HoldingRef][]models.OwnershipShare)
+	for rows.Next() {
+		var ref models.HoldingRef
+		var share models.OwnershipShare
+		if err := rows.Scan(&ref.HoldingType, &ref.HoldingID, &share.MemberID, &share.Percentage); err != nil {
+			return nil, fmt.Errorf("failed to scan ownership: %w", err)
+		}
+		shares[ref] = append(shares[ref], share)
+	}
+	return shares, rows.Err()
+}
+
+// effectiveShares returns each member's fraction of a holding: their own
+// share plus an equal part of whatever no one was assigned
+func effectiveShares(shares []models.OwnershipShare, memberIDs []int) map[int]float64 {
+	fractions := make(map[int]float64, len(memberIDs))
+	if len(memberIDs) == 0 {
+		return fractions
+	}
+
+	known := make(map[int]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		known[id] = true
+	}
+
+	assigned := 0.0
+	for _, share := range shares {
+		if known[share.MemberID] {
+			fractions[share.MemberID] += share.Percentage / 100
+			assigned += share.Percentage / 100
+		}
+	}
+
+	if remainder := 1 - assigned; remainder > 1e-9 {
+		each := remainder / float64(len(memberIDs))
+		for _, id := range memberIDs {
+			fractions[id] += each
+		}
+	}
+	return fractions
+}
+
+func sumShares(shares []models.OwnershipShare) float64 {
+	total := 0.0
+	for _, share := range shares {
+		total += share.Percentage
+	}
+	return total
+}
+
+func scanMember(row interface{ Scan(...interface{}) error }) (models.HouseholdMember, error) {
+	var member models.HouseholdMember
+	var userID sql.NullInt64
+	if err := row.Scan(&member.ID, &member.Name, &userID, &member.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return member, err
+		}
+		return member, fmt.Errorf("failed to scan household member: %w", err)
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		member.UserID = &id
+	}
+	return member, nil
+}
+
+// memberError maps constraint violations on household_members to repository errors
+func memberError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation && strings.Contains(pgErr.ConstraintName, "user_id"):
+			return ErrUserAlreadyMember
+		case pgErr.Code == uniqueViolation:
+			return ErrDuplicateMember
+		case pgErr.Code == foreignKeyViolation:
+			return ErrUnknownUser
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
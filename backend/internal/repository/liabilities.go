@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrStatementReviewed is returned when applying or rejecting a statement
+// that was already applied or rejected
+var ErrStatementReviewed = errors.New("statement was already reviewed")
+
+const liabilitySelectQuery = `
+	SELECT id, account_id, institution_name, liability_name, liability_type, current_balance,
+	       apr, minimum_payment, due_date, statement_date, account_number_last4, notes,
+	       created_at, last_updated
+	FROM liabilities
+`
+
+const statementSelectQuery = `
+	SELECT id, liability_id, attachment_id, document_type, status, balance, apr,
+	       minimum_payment, due_date, statement_date, created_at, reviewed_at
+	FROM liability_statements
+`
+
+// LiabilityRepository provides access to credit cards and loans and the
+// statements imported for them
+type LiabilityRepository struct {
+	db *sql.DB
+}
+
+// NewLiabilityRepository creates a new liability repository
+func NewLiabilityRepository(db *sql.DB) *LiabilityRepository {
+	return &LiabilityRepository{db: db}
+}
+
+// List returns every liability, soonest due first
+func (r *LiabilityRepository) List() ([]models.Liability, error) {
+	rows, err := r.db.Query(liabilitySelectQuery + " ORDER BY due_date NULLS LAST, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch liabilities: %w", err)
+	}
+	defer rows.Close()
+
+	liabilities := []models.Liability{}
+	for rows.Next() {
+		l, err := scanLiability(rows)
+		if err != nil {
+			return nil, err
+		}
+		liabilities = append(liabilities, l)
+	}
+	return liabilities, rows.Err()
+}
+
+// Get returns a single liability
+func (r *LiabilityRepository) Get(id int) (*models.Liability, error) {
+	l, err := scanLiability(r.db.QueryRow(liabilitySelectQuery+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Create inserts a liability and returns its ID
+func (r *LiabilityRepository) Create(input models.LiabilityInput) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO liabilities (account_id, institution_name, liability_name, liability_type, current_balance,
+			apr, minimum_payment, due_date, statement_date, account_number_last4, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`, input.AccountID, input.InstitutionName, input.LiabilityName, input.LiabilityType, input.CurrentBalance,
+		input.APR, input.MinimumPayment, input.DueDate, input.StatementDate, input.AccountNumberLast4, input.Notes).Scan(&id)
+	if err != nil {
+		return 0, liabilityError(err, "failed to create liability")
+	}
+	return id, nil
+}
+
+// Update replaces the writable fields of a liability
+func (r *LiabilityRepository) Update(id int, input models.LiabilityInput) error {
+	result, err := r.db.Exec(`
+		UPDATE liabilities
+		SET account_id = $1, institution_name = $2, liability_name = $3, liability_type = $4, current_balance = $5,
+		    apr = $6, minimum_payment = $7, due_date = $8, statement_date = $9, account_number_last4 = $10,
+		    notes = $11, last_updated = CURRENT_TIMESTAMP
+		WHERE id = $12
+	`, input.AccountID, input.InstitutionName, input.LiabilityName, input.LiabilityType, input.CurrentBalance,
+		input.APR, input.MinimumPayment, input.DueDate, input.StatementDate, input.AccountNumberLast4, input.Notes, id)
+	if err != nil {
+		return liabilityError(err, "failed to update liability")
+	}
+	return requireAffected(result)
+}
+
+// Delete removes a liability with its imported statements
+func (r *LiabilityRepository) Delete(id int) error {
+	return deleteByID(r.db, "liabilities", id)
+}
+
+// ListStatements returns the statements imported for a liability, newest first
+func (r *LiabilityRepository) ListStatements(liabilityID int) ([]models.LiabilityStatement, error) {
+	rows, err := r.db.Query(statementSelectQuery+" WHERE liability_id = $1 ORDER BY created_at DESC, id DESC", liabilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch liability statements: %w", err)
+	}
+	defer rows.Close()
+
+	statements := []models.LiabilityStatement{}
+	for rows.Next() {
+		s, err := scanStatement(rows)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+	}
+	return statements, rows.Err()
+}
+
+// GetStatement returns a single imported statement
+func (r *LiabilityRepository) GetStatement(id int) (*models.LiabilityStatement, error) {
+	s, err := scanStatement(r.db.QueryRow(statementSelectQuery+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateStatement records the fields read from a statement, pending review,
+// and returns its ID
+func (r *LiabilityRepository) CreateStatement(s models.LiabilityStatement) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO liability_statements (liability_id, attachment_id, document_type, balance, apr,
+			minimum_payment, due_date, statement_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, s.LiabilityID, s.AttachmentID, s.DocumentType, s.Balance, s.APR,
+		s.MinimumPayment, s.DueDate, s.StatementDate).Scan(&id)
+	if err != nil {
+		return 0, liabilityError(err, "failed to record liability statement")
+	}
+	return id, nil
+}
+
+// ApplyStatement copies the fields a pending statement found onto its
+// liability, keeping the liability's own values for the rest
+func (r *LiabilityRepository) ApplyStatement(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reviewStatement(tx, id, models.StatementStatusApplied); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE liabilities AS l
+		SET current_balance = COALESCE(s.balance, l.current_balance),
+		    apr = COALESCE(s.apr, l.apr),
+		    minimum_payment = COALESCE(s.minimum_payment, l.minimum_payment),
+		    due_date = COALESCE(s.due_date, l.due_date),
+		    statement_date = COALESCE(s.statement_date, l.statement_date),
+		    last_updated = CURRENT_TIMESTAMP
+		FROM liability_statements s
+		WHERE s.id = $1 AND l.id = s.liability_id
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to apply liability statement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit liability statement: %w", err)
+	}
+	return nil
+}
+
+// RejectStatement marks a pending statement rejected, leaving its liability alone
+func (r *LiabilityRepository) RejectStatement(id int) error {
+	return reviewStatement(r.db, id, models.StatementStatusRejected)
+}
+
+// reviewStatement moves a pending statement to status, returning
+// ErrStatementReviewed when it isn't pending and ErrNotFound when it doesn't exist
+func reviewStatement(db interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, id int, status string) error {
+	// The update only claims a pending statement, so of two reviews at once
+	// only one succeeds; the other then finds out why
+	var reviewed int
+	err := db.QueryRow(`
+		UPDATE liability_statements SET status = $2, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING id
+	`, id, status).Scan(&reviewed)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to review liability statement: %w", err)
+	}
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM liability_statements WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to review liability statement: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrStatementReviewed
+}
+
+// liabilityError maps an account or attachment that does not exist to ErrInvalidReference
+func liabilityError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return fmt.Errorf("%w: %s", ErrInvalidReference, pgErr.Detail)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func scanLiability(row interface{ Scan(...interface{}) error }) (models.Liability, error) {
+	var l models.Liability
+	err := row.Scan(&l.ID, &l.AccountID, &l.InstitutionName, &l.LiabilityName, &l.LiabilityType, &l.CurrentBalance,
+		&l.APR, &l.MinimumPayment, &l.DueDate, &l.StatementDate, &l.AccountNumberLast4, &l.Notes,
+		&l.CreatedAt, &l.LastUpdated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return l, fmt.Errorf("failed to scan liability: %w", err)
+	}
+	return l, err
+}
+
+func scanStatement(row interface{ Scan(...interface{}) error }) (models.LiabilityStatement, error) {
+	var s models.LiabilityStatement
+	err := row.Scan(&s.ID, &s.LiabilityID, &s.AttachmentID, &s.DocumentType, &s.Status, &s.Balance, &s.APR,
+		&s.MinimumPayment, &s.DueDate, &s.StatementDate, &s.CreatedAt, &s.ReviewedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return s, fmt.Errorf("failed to scan liability statement: %w", err)
+	}
+	return s, err
+}
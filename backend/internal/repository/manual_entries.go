@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+)
+
+// ErrInvalidEntryType is returned for a manual entry type that has no table
+var ErrInvalidEntryType = errors.New("invalid entry type")
+
+// manualEntriesQuery lists the manual entries of every holding table, each
+// with its fields as a JSON object and the account it is filed under
+func manualEntriesQuery(dialect database.Dialect) string {
+	return `
+		SELECT 'computershare' as entry_type,
+		       sh.id, sh.account_id, sh.created_at, sh.created_at as updated_at,
+		       ` + dialect.JSONObject(
+		"'symbol'", "sh.symbol",
+		"'company_name'", "sh.company_name",
+		"'shares_owned'", "sh.shares_owned",
+		"'cost_basis'", "sh.cost_basis",
+		"'current_price'", "sh.current_price",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM stock_holdings sh
+		LEFT JOIN accounts a ON sh.account_id = a.id
+		WHERE sh.data_source = 'computershare'
+
+		UNION ALL
+
+		SELECT 'stock_holding' as entry_type,
+		       sh.id, sh.account_id, sh.created_at, sh.created_at as updated_at,
+		       ` + dialect.JSONObject(
+		"'symbol'", "sh.symbol",
+		"'company_name'", "sh.company_name",
+		"'shares_owned'", "sh.shares_owned",
+		"'cost_basis'", "sh.cost_basis",
+		"'current_price'", "sh.current_price",
+		"'institution_name'", "sh.institution_name",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM stock_holdings sh
+		LEFT JOIN accounts a ON sh.account_id = a.id
+		WHERE sh.data_source IN ('manual', 'stock_holding') OR (sh.data_source IS NULL AND sh.created_at IS NOT NULL)
+
+		UNION ALL
+
+		SELECT 'morgan_stanley' as entry_type,
+		       eg.id, eg.account_id, eg.created_at, eg.created_at as updated_at,
+		       ` + dialect.JSONObject(
+		"'grant_type'", "eg.grant_type",
+		"'company_symbol'", "eg.company_symbol",
+		"'total_shares'", "eg.total_shares",
+		"'vested_shares'", "eg.vested_shares",
+		"'unvested_shares'", "eg.unvested_shares",
+		"'strike_price'", "eg.strike_price",
+		"'grant_date'", dialect.Date("eg.grant_date"),
+		"'vest_start_date'", dialect.Date("eg.vest_start_date"),
+		"'current_price'", "eg.current_price",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM equity_grants eg
+		LEFT JOIN accounts a ON eg.account_id = a.id
+		WHERE eg.created_at IS NOT NULL
+
+		UNION ALL
+
+		SELECT 'real_estate' as entry_type,
+		       re.id, re.account_id, re.created_at, re.created_at as updated_at,
+		       ` + dialect.JSONObject(
+		"'property_type'", "re.property_type",
+		"'property_name'", "re.property_name",
+		"'street_address'", "re.street_address",
+		"'city'", "re.city",
+		"'state'", "re.state",
+		"'zip_code'", "re.zip_code",
+		"'purchase_price'", "re.purchase_price",
+		"'current_value'", "re.current_value",
+		"'outstanding_mortgage'", "re.outstanding_mortgage",
+		"'equity'", "re.equity",
+		"'purchase_date'", dialect.Date("re.purchase_date"),
+		"'property_size_sqft'", "re.property_size_sqft",
+		"'lot_size_acres'", "re.lot_size_acres",
+		"'rental_income_monthly'", "re.rental_income_monthly",
+		"'property_tax_annual'", "re.property_tax_annual",
+		"'ownership_percentage'", "re.ownership_percentage",
+		"'notes'", "re.notes",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM real_estate_properties re
+		LEFT JOIN accounts a ON re.account_id = a.id
+		WHERE re.created_at IS NOT NULL
+
+		UNION ALL
+
+		SELECT 'cash_holdings' as entry_type,
+		       ch.id, ch.account_id, ch.created_at, ch.updated_at,
+		       ` + dialect.JSONObject(
+		"'institution_name'", "ch.institution_name",
+		"'account_name'", "ch.account_name",
+		"'account_type'", "ch.account_type",
+		"'current_balance'", "ch.current_balance",
+		"'interest_rate'", "ch.interest_rate",
+		"'monthly_contribution'", "ch.monthly_contribution",
+		"'account_number_last4'", "ch.account_number_last4",
+		"'currency'", "ch.currency",
+		"'notes'", "ch.notes",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM cash_holdings ch
+		LEFT JOIN accounts a ON ch.account_id = a.id
+		WHERE ch.created_at IS NOT NULL
+
+		UNION ALL
+
+		SELECT 'crypto_holdings' as entry_type,
+		       cry.id, cry.account_id, cry.created_at, cry.updated_at,
+		       ` + dialect.JSONObject(
+		"'institution_name'", "cry.institution_name",
+		"'crypto_symbol'", "cry.crypto_symbol",
+		"'balance_tokens'", "cry.balance_tokens",
+		"'purchase_price_usd'", "cry.purchase_price_usd",
+		"'purchase_date'", dialect.Date("cry.purchase_date"),
+		"'wallet_address'", "cry.wallet_address",
+		"'notes'", "cry.notes",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM crypto_holdings cry
+		LEFT JOIN accounts a ON cry.account_id = a.id
+		WHERE cry.created_at IS NOT NULL
+
+		UNION ALL
+
+		SELECT 'other_assets' as entry_type,
+		       ma.id, ma.account_id, ma.created_at, ma.last_updated as updated_at,
+		       ` + dialect.JSONObject(
+		"'asset_category_id'", "ma.asset_category_id",
+		"'asset_name'", "ma.asset_name",
+		"'current_value'", "ma.current_value",
+		"'purchase_price'", "ma.purchase_price",
+		"'amount_owed'", "ma.amount_owed",
+		"'purchase_date'", dialect.Date("ma.purchase_date"),
+		"'description'", "ma.description",
+		"'custom_fields'", dialect.JSON("ma.custom_fields"),
+		"'valuation_method'", "ma.valuation_method",
+		"'last_valuation_date'", "ma.last_valuation_date",
+		"'notes'", "ma.notes",
+		"'category_name'", "ac.name",
+		"'category_description'", "ac.description",
+		"'category_icon'", "ac.icon",
+		"'category_color'", "ac.color",
+	) + ` as data_json,
+		       a.account_name, a.institution
+		FROM miscellaneous_assets ma
+		LEFT JOIN accounts a ON ma.account_id = a.id
+		LEFT JOIN asset_categories ac ON ma.asset_category_id = ac.id
+		WHERE ma.created_at IS NOT NULL
+	`
+}
+
+// manualEntryDeletes deletes a manual entry of each type by ID
+var manualEntryDeletes = map[string]string{
+	"stock_holding":   "DELETE FROM stock_holdings WHERE id = $1 AND data_source = 'stock_holding'",
+	"morgan_stanley":  "DELETE FROM equity_grants WHERE id = $1",
+	"real_estate":     "DELETE FROM real_estate_properties WHERE id = $1",
+	"cash_holdings":   "DELETE FROM cash_holdings WHERE id = $1",
+	"crypto_holdings": "DELETE FROM crypto_holdings WHERE id = $1",
+}
+
+// ManualEntryRepository lists and deletes manual entries across the holding tables
+type ManualEntryRepository struct {
+	db *sql.DB
+}
+
+// NewManualEntryRepository creates a new manual entry repository
+func NewManualEntryRepository(db *sql.DB) *ManualEntryRepository {
+	return &ManualEntryRepository{db: db}
+}
+
+// List returns the manual entries newest first, only those of entryType
+// unless it is empty. Encrypted fields in data_json are left encrypted.
+func (r *ManualEntryRepository) List(entryType string) ([]models.ManualEntryRecord, error) {
+	query := `SELECT * FROM (` + manualEntriesQuery(database.DialectOf(r.db)) + `) AS all_entries`
+	var args []interface{}
+	if entryType != "" {
+		query += ` WHERE entry_type = $1`
+		args = append(args, entryType)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manual entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.ManualEntryRecord{}
+	for rows.Next() {
+		var e models.ManualEntryRecord
+		err := rows.Scan(&e.EntryType, &e.ID, &e.AccountID, &e.CreatedAt, &e.UpdatedAt,
+			&e.DataJSON, &e.AccountName, &e.Institution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan manual entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Delete deletes the manual entry of entryType with the given ID
+func (r *ManualEntryRepository) Delete(entryType string, id int) error {
+	query, ok := manualEntryDeletes[entryType]
+	if !ok {
+		return ErrInvalidEntryType
+	}
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete manual entry: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
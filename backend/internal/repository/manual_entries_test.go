@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestManualEntryList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`\) AS all_entries WHERE entry_type = \$1 ORDER BY created_at DESC`).
+		WithArgs("cash_holdings").
+		WillReturnRows(sqlmock.NewRows([]string{"entry_type", "id", "account_id", "created_at",
+			"updated_at", "data_json", "account_name", "institution"}).
+			AddRow("cash_holdings", 5, 2, "2026-10-01", "2026-10-02", `{"current_balance":100}`, "Checking", nil))
+
+	entries, err := NewManualEntryRepository(db).List("cash_holdings")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("List returned %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.ID != 5 || e.AccountID != 2 || e.AccountName == nil || *e.AccountName != "Checking" || e.Institution != nil {
+		t.Errorf("List = %+v", e)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestManualEntryDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewManualEntryRepository(db)
+
+	if err := repo.Delete("accounts", 1); !errors.Is(err, ErrInvalidEntryType) {
+		t.Errorf("Delete of an unknown type = %v, want ErrInvalidEntryType", err)
+	}
+
+	mock.ExpectExec(`DELETE FROM real_estate_properties WHERE id = \$1`).WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := repo.Delete("real_estate", 9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a missing entry = %v, want ErrNotFound", err)
+	}
+
+	mock.ExpectExec(`DELETE FROM crypto_holdings WHERE id = \$1`).WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.Delete("crypto_holdings", 3); err != nil {
+		t.Errorf("Delete = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
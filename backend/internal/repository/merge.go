@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrInvalidMerge is returned for records that cannot be merged, such as
+	// holdings of different symbols
+	ErrInvalidMerge = errors.New("records cannot be merged")
+	// ErrMergeConflict is returned when moving an account's holdings would
+	// duplicate holdings the target account already has
+	ErrMergeConflict = errors.New("merge would duplicate existing records")
+)
+
+// holdingMerge describes how two holdings of one type are combined
+type holdingMerge struct {
+	// match is an expression both holdings must agree on, named matchName
+	match     string
+	matchName string
+	// combine sets the target's columns from the target t and the source s
+	combine string
+	// moves re-point the source's child rows at the target, $1 being the
+	// target and $2 the source. Rows the target already has equivalents of
+	// are left to be deleted with the source.
+	moves []string
+}
+
+// weightedAverage averages a per-unit column of the target t and source s,
+// weighted by their quantities
+func weightedAverage(column, quantity string) string {
+	return fmt.Sprintf(`CASE
+			WHEN t.%[1]s IS NULL THEN s.%[1]s
+			WHEN s.%[1]s IS NULL OR t.%[2]s + s.%[2]s = 0 THEN t.%[1]s
+			ELSE (t.%[1]s * t.%[2]s + s.%[1]s * s.%[2]s) / (t.%[2]s + s.%[2]s)
+		END`, column, quantity)
+}
+
+// holdingMerges are the holding types that can be merged. Quantities are
+// summed, per-unit costs averaged, the earliest purchase date kept, and the
+// target's other details kept unless it has none.
+var holdingMerges = map[string]holdingMerge{
+	models.HoldingTypeStock: {
+		match:     "UPPER(symbol)",
+		matchName: "symbol",
+		combine: `
+			shares_owned = t.shares_owned + s.shares_owned,
+			cost_basis = ` + weightedAverage("cost_basis", "shares_owned") + `,
+			current_price = COALESCE(t.current_price, s.current_price),
+			purchase_date = LEAST(t.purchase_date, s.purchase_date),
+			estimated_quarterly_dividend = COALESCE(t.estimated_quarterly_dividend, s.estimated_quarterly_dividend),
+			last_updated = CURRENT_TIMESTAMP`,
+	},
+	models.HoldingTypeCash: {
+		match:     "UPPER(COALESCE(currency, 'USD'))",
+		matchName: "currency",
+		combine: `
+			current_balance = t.current_balance + s.current_balance,
+			interest_rate = COALESCE(t.interest_rate, s.interest_rate),
+			monthly_contribution = COALESCE(t.monthly_contribution, s.monthly_contribution),
+			account_number_last4 = COALESCE(t.account_number_last4, s.account_number_last4),
+			notes = COALESCE(t.notes, s.notes),
+			updated_at = CURRENT_TIMESTAMP`,
+		moves: []string{
+			`UPDATE recurring_contributions SET cash_holding_id = $1
+			 WHERE cash_holding_id = $2 AND NOT EXISTS (SELECT 1 FROM recurring_contributions WHERE cash_holding_id = $1)`,
+			`UPDATE contribution_transactions AS ct
+			 SET cash_holding_id = $1,
+			     recurring_contribution_id = COALESCE((SELECT id FROM recurring_contributions WHERE cash_holding_id = $1), ct.recurring_contribution_id)
+			 WHERE ct.cash_holding_id = $2 AND NOT EXISTS (
+			     SELECT 1 FROM contribution_transactions t WHERE t.cash_holding_id = $1 AND t.scheduled_date = ct.scheduled_date)`,
+			`INSERT INTO goal_cash_holdings (goal_id, cash_holding_id)
+			 SELECT goal_id, $1 FROM goal_cash_holdings WHERE cash_holding_id = $2
+			 ON CONFLICT DO NOTHING`,
+			`UPDATE cash_flow_transactions SET cash_holding_id = $1 WHERE cash_holding_id = $2`,
+			`UPDATE employer_match_rules SET cash_holding_id = $1
+			 WHERE cash_holding_id = $2 AND NOT EXISTS (SELECT 1 FROM employer_match_rules WHERE cash_holding_id = $1)`,
+		},
+	},
+	models.HoldingTypeCrypto: {
+		match:     "UPPER(crypto_symbol)",
+		matchName: "symbol",
+		// Staking rewards are accrued from the later date, so neither
+		// holding's rewards are counted twice
+		combine: `
+			balance_tokens = t.balance_tokens + s.balance_tokens,
+			staked_tokens = t.staked_tokens + s.staked_tokens,
+			purchase_price_usd = ` + weightedAverage("purchase_price_usd", "balance_tokens") + `,
+			purchase_date = LEAST(t.purchase_date, s.purchase_date),
+			wallet_address = COALESCE(t.wallet_address, s.wallet_address),
+			staking_annual_percentage = CASE WHEN t.staking_annual_percentage > 0 THEN t.staking_annual_percentage ELSE s.staking_annual_percentage END,
+			staking_accrued_through = GREATEST(t.staking_accrued_through, s.staking_accrued_through),
+			notes = COALESCE(t.notes, s.notes),
+			updated_at = CURRENT_TIMESTAMP`,
+		moves: []string{
+			`UPDATE crypto_transactions SET crypto_holding_id = $1 WHERE crypto_holding_id = $2`,
+		},
+	},
+}
+
+// holdingReferenceMoves re-point the tags, ownership and attachments of any
+// holding type, $1 being the target, $2 the source and $3 the holding type.
+// Ownership is only copied to a target without any.
+var holdingReferenceMoves = []string{
+	`INSERT INTO holding_tags (tag_id, holding_type, holding_id)
+	 SELECT tag_id, holding_type, $1 FROM holding_tags WHERE holding_type = $3 AND holding_id = $2
+	 ON CONFLICT DO NOTHING`,
+	`INSERT INTO holding_ownership (holding_type, holding_id, member_id, percentage)
+	 SELECT holding_type, $1, member_id, percentage FROM holding_ownership
+	 WHERE holding_type = $3 AND holding_id = $2
+	   AND NOT EXISTS (SELECT 1 FROM holding_ownership WHERE holding_type = $3 AND holding_id = $1)`,
+	`UPDATE attachments SET entity_id = $1 WHERE entity_type = $3 AND entity_id = $2`,
+}
+
+// accountTables are the tables whose rows belong to an account
+var accountTables = []string{
+	"account_balances",
+	"manual_entries",
+	"manual_entry_log",
+	"stock_holdings",
+	"equity_grants",
+	"real_estate_properties",
+	"cash_holdings",
+	"crypto_holdings",
+	"miscellaneous_assets",
+	"private_investments",
+	"bonds",
+	"i_bonds",
+	"pensions",
+	"insurance_policies",
+	"liabilities",
+}
+
+// MergeRepository combines duplicate holdings and accounts
+type MergeRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewMergeRepository creates a new merge repository
+func NewMergeRepository(db *sql.DB) *MergeRepository {
+	return &MergeRepository{db: db, dialect: database.DialectOf(db)}
+}
+
+// IsMergeableHoldingType reports whether holdings of holdingType can be merged
+func IsMergeableHoldingType(holdingType string) bool {
+	_, ok := holdingMerges[holdingType]
+	return ok
+}
+
+// lockPair locks the target and source rows of table, returning the value of
+// match for each by ID, or ErrNotFound when either does not exist
+func (r *MergeRepository) lockPair(tx *sql.Tx, table, match string, targetID, sourceID int) (map[int]string, error) {
+	if targetID == sourceID {
+		return nil, fmt.Errorf("%w: a record cannot be merged into itself", ErrInvalidMerge)
+	}
+
+	query := fmt.Sprintf("SELECT id, %s FROM %s WHERE id IN ($1, $2)%s", match, sqlbuilder.Ident(table), r.dialect.ForUpdate())
+	rows, err := tx.Query(query, targetID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	values := make(map[int]string, 2)
+	for rows.Next() {
+		var id int
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		values[id] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range []int{targetID, sourceID} {
+		if _, ok := values[id]; !ok {
+			return nil, fmt.Errorf("%w: %s %d", ErrNotFound, table, id)
+		}
+	}
+	return values, nil
+}
+
+// MergeHoldings folds the source holding into the target and deletes it. The
+// source's transactions, contributions, tags and attachments move to the
+// target; both must be the same symbol, or for cash, currency.
+func (r *MergeRepository) MergeHoldings(holdingType string, targetID, sourceID int) error {
+	merge, ok := holdingMerges[holdingType]
+	if !ok {
+		return fmt.Errorf("%w: %q holdings cannot be merged", ErrInvalidMerge, holdingType)
+	}
+	table := holdingTables[holdingType]
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	values, err := r.lockPair(tx, table, merge.match, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+	if values[targetID] != values[sourceID] {
+		return fmt.Errorf("%w: the %s differs (%s and %s)", ErrInvalidMerge, merge.matchName, values[targetID], values[sourceID])
+	}
+
+	combine := fmt.Sprintf("UPDATE %[1]s AS t SET %[2]s FROM %[1]s AS s WHERE t.id = $1 AND s.id = $2", sqlbuilder.Ident(table), merge.combine)
+	if _, err := tx.Exec(combine, targetID, sourceID); err != nil {
+		return fmt.Errorf("failed to combine %s: %w", table, err)
+	}
+	for _, move := range merge.moves {
+		if _, err := tx.Exec(move, targetID, sourceID); err != nil {
+			return fmt.Errorf("failed to move %s references: %w", table, err)
+		}
+	}
+	for _, move := range holdingReferenceMoves {
+		if _, err := tx.Exec(move, targetID, sourceID, holdingType); err != nil {
+			return fmt.Errorf("failed to move %s references: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = $1", sqlbuilder.Ident(table)), sourceID); err != nil {
+		return fmt.Errorf("failed to delete merged %s: %w", table, err)
+	}
+	return tx.Commit()
+}
+
+// MergeAccounts moves everything belonging to the source account to the
+// target and deletes it. When both accounts hold the same holding it returns
+// ErrMergeConflict, and those holdings need merging first.
+func (r *MergeRepository) MergeAccounts(targetID, sourceID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := r.lockPair(tx, "accounts", "account_type", targetID, sourceID); err != nil {
+		return err
+	}
+
+	for _, table := range accountTables {
+		query := fmt.Sprintf("UPDATE %s SET account_id = $1 WHERE account_id = $2", sqlbuilder.Ident(table))
+		if _, err := tx.Exec(query, targetID, sourceID); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return fmt.Errorf("%w: both accounts hold the same %s", ErrMergeConflict, table)
+			}
+			return fmt.Errorf("failed to move %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM accounts WHERE id = $1`, sourceID); err != nil {
+		return fmt.Errorf("failed to delete merged account: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+// NetWorthRepository computes net worth aggregates across all asset domains
+type NetWorthRepository struct {
+	db    *sql.DB
+	coins models.CoinClassification
+}
+
+// NewNetWorthRepository creates a new net worth repository
+func NewNetWorthRepository(db *sql.DB) *NetWorthRepository {
+	return &NetWorthRepository{db: db, coins: models.CoinClassification{}}
+}
+
+// SetCoinClassification sets which crypto holdings are stablecoins
+func (r *NetWorthRepository) SetCoinClassification(coins models.CoinClassification) {
+	r.coins = coins
+}
+
+// Breakdown returns the current value of each asset class and liabilities.
+// Liabilities are credit cards and loans; mortgages are already subtracted from
+// real estate equity. Stablecoins count as crypto,
+// with their value also reported as StablecoinValue. It is the sum of
+// holdingValues, so it always agrees with the trees, owner breakdowns and top
+// holdings built from them.
+func (r *NetWorthRepository) Breakdown() (models.NetWorthBreakdown, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return models.NetWorthBreakdown{}, err
+	}
+
+	var b models.NetWorthBreakdown
+	for _, v := range values {
+		r.addHoldingValue(&b, v)
+	}
+	return b, nil
+}
+
+// accountOwnersQuery is each account with its owner, a child owner named by
+// their household member
+const accountOwnersQuery = `
+	SELECT a.id, a.institution, a.account_name, a.custodial,
+	       CASE WHEN a.owner = 'child' THEN COALESCE(m.name, a.owner) ELSE a.owner END AS owner
+	FROM accounts a
+	LEFT JOIN household_members m ON m.id = a.owner_member_id
+`
+
+// holdingValuesQuery values each holding, one row per holding and asset
+// class, with the institution and account it sits under, the account's owner
+// and whether it is custodial, and when its data last changed. These are the
+// only net worth valuation rules in SQL: brokerage cash balances count toward
+// stocks rather than cash, vested equity includes stock holdings flagged as
+// vested grants, real estate is the owner's share of equity (already net of
+// mortgages) and liabilities are the credit card and loan balances. A child
+// owner is named by their household member. Holdings tracked without an
+// institution of their own (equity grants, real estate, other assets, private
+// investments) take their account's, and holdings without an account belong
+// to self. Pensions, bonds and I bonds are valued in Go by pensionValues,
+// bondValues and iBondValues, a round trip each.
+const holdingValuesQuery = `
+	WITH latest_crypto_prices AS (
+		SELECT symbol, price_usd, last_updated
+		FROM (
+			SELECT symbol, price_usd, last_updated, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY last_updated DESC) AS position
+			FROM crypto_prices
+		) ranked
+		WHERE position = 1
+	), account_owners AS (` + accountOwnersQuery + `)
+	SELECT 'stock_holding', sh.id,
+	       CASE WHEN COALESCE(sh.is_vested_equity, false) THEN 'vested_equity' ELSE 'stock_holdings' END,
+	       sh.shares_owned * sh.current_price,
+	       COALESCE(sh.institution_name, a.institution, ''), sh.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), sh.symbol, sh.last_updated
+	FROM stock_holdings sh
+	LEFT JOIN account_owners a ON a.id = sh.account_id
+	WHERE sh.current_price > 0
+	UNION ALL
+	SELECT 'cash_holding', ch.id,
+	       CASE WHEN ch.account_type = 'brokerage' THEN 'stock_holdings' ELSE 'cash_holdings' END,
+	       ch.current_balance,
+	       ch.institution_name, ch.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), ch.account_name, ch.updated_at
+	FROM cash_holdings ch
+	LEFT JOIN account_owners a ON a.id = ch.account_id
+	UNION ALL
+	SELECT 'equity_grant', eg.id, 'vested_equity', eg.vested_shares * eg.current_price,
+	       COALESCE(a.institution, ''), eg.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), eg.company_symbol || ' ' || eg.grant_type, eg.last_updated
+	FROM equity_grants eg
+	LEFT JOIN account_owners a ON a.id = eg.account_id
+	WHERE eg.current_price > 0 AND eg.vested_shares > 0
+	UNION ALL
+	SELECT 'equity_grant', eg.id, 'unvested_equity', eg.unvested_shares * eg.current_price,
+	       COALESCE(a.institution, ''), eg.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), eg.company_symbol || ' ' || eg.grant_type, eg.last_updated
+	FROM equity_grants eg
+	LEFT JOIN account_owners a ON a.id = eg.account_id
+	WHERE eg.current_price > 0 AND eg.unvested_shares > 0
+	UNION ALL
+	SELECT 'real_estate', re.id, 'real_estate', re.equity * re.ownership_percentage / 100,
+	       COALESCE(a.institution, ''), re.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), re.property_name, re.last_updated
+	FROM real_estate_properties re
+	LEFT JOIN account_owners a ON a.id = re.account_id
+	UNION ALL
+	SELECT 'crypto_holding', ch.id, 'crypto_holdings', ch.balance_tokens * COALESCE(lp.price_usd, 0),
+	       ch.institution_name, ch.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), ch.crypto_symbol, GREATEST(ch.updated_at, lp.last_updated)
+	FROM crypto_holdings ch
+	LEFT JOIN latest_crypto_prices lp ON lp.symbol = ch.crypto_symbol
+	LEFT JOIN account_owners a ON a.id = ch.account_id
+	UNION ALL
+	SELECT 'other_asset', ma.id, 'other_assets', ma.current_value - COALESCE(ma.amount_owed, 0),
+	       COALESCE(a.institution, ''), ma.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), ma.asset_name, ma.last_updated
+	FROM miscellaneous_assets ma
+	LEFT JOIN account_owners a ON a.id = ma.account_id
+	UNION ALL
+	SELECT 'private_investment', pi.id, 'private_investments', pi.current_value,
+	       COALESCE(a.institution, pi.sponsor), pi.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), pi.investment_name, pi.last_updated
+	FROM private_investments pi
+	LEFT JOIN account_owners a ON a.id = pi.account_id
+	UNION ALL
+	SELECT 'insurance_policy', ip.id, 'insurance', GREATEST(ip.cash_value - ip.loan_balance, 0),
+	       ip.institution_name, ip.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), ip.policy_name, ip.last_updated
+	FROM insurance_policies ip
+	LEFT JOIN account_owners a ON a.id = ip.account_id
+	WHERE ip.cash_value > 0
+	UNION ALL
+	SELECT 'liability', l.id, 'liabilities', l.current_balance,
+	       l.institution_name, l.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), l.liability_name, l.last_updated
+	FROM liabilities l
+	LEFT JOIN account_owners a ON a.id = l.account_id
+`
+
+// holdingValues returns the value of every holding in each asset class it counts toward
+func holdingValues(db *sql.DB) ([]models.HoldingValue, error) {
+	rows, err := db.Query(holdingValuesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to value holdings: %w", err)
+	}
+	defer rows.Close()
+
+	values := []models.HoldingValue{}
+	for rows.Next() {
+		var v models.HoldingValue
+		err := rows.Scan(&v.HoldingType, &v.HoldingID, &v.Component, &v.Value,
+			&v.Institution, &v.AccountID, &v.AccountName, &v.Owner, &v.Custodial, &v.Name, &v.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan holding value: %w", err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to value holdings: %w", err)
+	}
+
+	now := time.Now()
+	pensions, err := pensionValues(db, now)
+	if err != nil {
+		return nil, err
+	}
+	bonds, err := bondValues(db, now)
+	if err != nil {
+		return nil, err
+	}
+	iBonds, err := iBondValues(db, now)
+	if err != nil {
+		return nil, err
+	}
+	values = append(values, pensions...)
+	values = append(values, bonds...)
+	return append(values, iBonds...), nil
+}
+
+// pensionValues values the pensions included in net worth as of now, with
+// Pension.Value, as holdingValuesQuery values other holdings
+func pensionValues(db *sql.DB, now time.Time) ([]models.HoldingValue, error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.birth_date, p.monthly_benefit, p.start_age, p.end_age, p.cola_rate, p.discount_rate,
+		       p.institution_name, p.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), p.plan_name, p.last_updated
+		FROM pensions p
+		LEFT JOIN (` + accountOwnersQuery + `) a ON a.id = p.account_id
+		WHERE p.include_in_net_worth
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to value pensions: %w", err)
+	}
+	defer rows.Close()
+
+	values := []models.HoldingValue{}
+	for rows.Next() {
+		var p models.Pension
+		v := models.HoldingValue{HoldingRef: models.HoldingRef{HoldingType: models.HoldingTypePension}, Component: "pensions"}
+		err := rows.Scan(&v.HoldingID, &p.BirthDate, &p.MonthlyBenefit, &p.StartAge, &p.EndAge, &p.ColaRate, &p.DiscountRate,
+			&v.Institution, &v.AccountID, &v.AccountName, &v.Owner, &v.Custodial, &v.Name, &v.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pension value: %w", err)
+		}
+		p.Value(now)
+		v.Value = p.PresentValue
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to value pensions: %w", err)
+	}
+	return values, nil
+}
+
+// bondValues values every bond as of now, with Bond.Value, as
+// holdingValuesQuery values other holdings
+func bondValues(db *sql.DB, now time.Time) ([]models.HoldingValue, error) {
+	rows, err := db.Query(`
+		SELECT b.id, b.face_value, b.coupon_rate, b.coupon_frequency, b.maturity_date, b.purchase_price, b.current_price,
+		       b.institution_name, b.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false), b.bond_name, b.last_updated
+		FROM bonds b
+		LEFT JOIN (` + accountOwnersQuery + `) a ON a.id = b.account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to value bonds: %w", err)
+	}
+	defer rows.Close()
+
+	values := []models.HoldingValue{}
+	for rows.Next() {
+		var b models.Bond
+		v := models.HoldingValue{HoldingRef: models.HoldingRef{HoldingType: models.HoldingTypeBond}, Component: "fixed_income"}
+		err := rows.Scan(&v.HoldingID, &b.FaceValue, &b.CouponRate, &b.CouponFrequency, &b.MaturityDate, &b.PurchasePrice, &b.CurrentPrice,
+			&v.Institution, &v.AccountID, &v.AccountName, &v.Owner, &v.Custodial, &v.Name, &v.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bond value: %w", err)
+		}
+		b.Value(now)
+		v.Value = b.CurrentValue
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to value bonds: %w", err)
+	}
+	return values, nil
+}
+
+// iBondValues values every I bond at what cashing it in as of now would pay,
+// with IBond.Value and the schedule's inflation rates, as holdingValuesQuery
+// values other holdings
+func iBondValues(db *sql.DB, now time.Time) ([]models.HoldingValue, error) {
+	rates, err := iBondRates(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT ib.id, ib.issue_date, ib.purchase_amount, ib.fixed_rate,
+		       ib.institution_name, ib.account_id, COALESCE(a.account_name, ''), COALESCE(a.owner, 'self'), COALESCE(a.custodial, false),
+		       ` + iBondName + `, ib.last_updated
+		FROM i_bonds ib
+		LEFT JOIN (` + accountOwnersQuery + `) a ON a.id = ib.account_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to value I bonds: %w", err)
+	}
+	defer rows.Close()
+
+	values := []models.HoldingValue{}
+	for rows.Next() {
+		var b models.IBond
+		v := models.HoldingValue{HoldingRef: models.HoldingRef{HoldingType: models.HoldingTypeIBond}, Component: "fixed_income"}
+		err := rows.Scan(&v.HoldingID, &b.IssueDate, &b.PurchaseAmount, &b.FixedRate,
+			&v.Institution, &v.AccountID, &v.AccountName, &v.Owner, &v.Custodial, &v.Name, &v.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan I bond value: %w", err)
+		}
+		b.Value(now, rates)
+		v.Value = b.RedemptionValue
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to value I bonds: %w", err)
+	}
+	return values, nil
+}
+
+// iBondRates returns the inflation rates of the I bond rate schedule
+func iBondRates(db *sql.DB) (models.IBondRates, error) {
+	rows, err := db.Query(`SELECT effective_date, inflation_rate FROM i_bond_rates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch I bond rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := models.IBondRates{}
+	for rows.Next() {
+		var r models.IBondRate
+		if err := rows.Scan(&r.EffectiveDate, &r.InflationRate); err != nil {
+			return nil, fmt.Errorf("failed to scan I bond rate: %w", err)
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// TopHoldings returns the limit most valuable holdings counted in total
+// assets, largest first. Unvested equity is left out.
+func (r *NetWorthRepository) TopHoldings(limit int) ([]models.HoldingValue, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var breakdown models.NetWorthBreakdown
+	counted := map[string]bool{}
+	for _, component := range breakdown.Components() {
+		counted[component.Key] = true
+	}
+	top := make([]models.HoldingValue, 0, len(values))
+	for _, v := range values {
+		if counted[v.Component] {
+			top = append(top, v)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Value.GreaterThan(top[j].Value) })
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+// Tree returns the asset classes counted in total assets, each split by
+// institution, account and holding
+func (r *NetWorthRepository) Tree() ([]models.BreakdownAssetClass, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewBreakdownTree(values), nil
+}
+
+// CashEquivalentTree is Tree with stablecoin holdings under cash rather than crypto
+func (r *NetWorthRepository) CashEquivalentTree() ([]models.BreakdownAssetClass, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewBreakdownTree(models.StablecoinsAsCash(values, r.coins)), nil
+}
+
+// Institutions returns each institution holding stocks, cash or crypto with
+// its accounts and totals
+func (r *NetWorthRepository) Institutions() ([]models.InstitutionSummary, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewInstitutionSummaries(values), nil
+}
+
+// OwnerBreakdowns splits the net worth breakdown by the owner of the account
+// each holding is filed under, with an owner's custodial accounts apart from
+// the rest. Self comes first, then spouse, then children by name. Together
+// the breakdowns add up to Breakdown's.
+func (r *NetWorthRepository) OwnerBreakdowns() ([]models.OwnerBreakdown, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	type ownerKey struct {
+		owner     string
+		custodial bool
+	}
+	index := map[ownerKey]int{}
+	breakdowns := []models.OwnerBreakdown{}
+	for _, v := range values {
+		key := ownerKey{v.Owner, v.Custodial}
+		i, ok := index[key]
+		if !ok {
+			i = len(breakdowns)
+			index[key] = i
+			breakdowns = append(breakdowns, models.OwnerBreakdown{Owner: v.Owner, Custodial: v.Custodial})
+		}
+		r.addHoldingValue(&breakdowns[i].Breakdown, v)
+	}
+
+	rank := func(owner string) int {
+		switch owner {
+		case models.AccountOwnerSelf:
+			return 0
+		case models.AccountOwnerSpouse:
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(breakdowns, func(i, j int) bool {
+		a, b := breakdowns[i], breakdowns[j]
+		if rank(a.Owner) != rank(b.Owner) {
+			return rank(a.Owner) < rank(b.Owner)
+		}
+		if !strings.EqualFold(a.Owner, b.Owner) {
+			return strings.ToLower(a.Owner) < strings.ToLower(b.Owner)
+		}
+		return !a.Custodial && b.Custodial
+	})
+	return breakdowns, nil
+}
+
+// PersonalBreakdown is Breakdown without the holdings in custodial accounts
+func (r *NetWorthRepository) PersonalBreakdown() (models.NetWorthBreakdown, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return models.NetWorthBreakdown{}, err
+	}
+
+	var b models.NetWorthBreakdown
+	for _, v := range values {
+		if !v.Custodial {
+			r.addHoldingValue(&b, v)
+		}
+	}
+	return b, nil
+}
+
+// PersonalTree is Tree without the holdings in custodial accounts, with
+// stablecoins under cash when cashEquivalent is set
+func (r *NetWorthRepository) PersonalTree(cashEquivalent bool) ([]models.BreakdownAssetClass, error) {
+	values, err := holdingValues(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	personal := make([]models.HoldingValue, 0, len(values))
+	for _, v := range values {
+		if !v.Custodial {
+			personal = append(personal, v)
+		}
+	}
+	if cashEquivalent {
+		personal = models.StablecoinsAsCash(personal, r.coins)
+	}
+	return models.NewBreakdownTree(personal), nil
+}
+
+// addHoldingValue counts a holding value toward its asset class in b, and
+// toward StablecoinValue when it is crypto in a stablecoin
+func (r *NetWorthRepository) addHoldingValue(b *models.NetWorthBreakdown, v models.HoldingValue) {
+	b.AddComponent(v.Component, v.Value)
+	if r.isStablecoin(v) {
+		b.StablecoinValue = b.StablecoinValue.Add(v.Value)
+	}
+}
+
+// isStablecoin reports whether a holding value is crypto in a stablecoin
+func (r *NetWorthRepository) isStablecoin(v models.HoldingValue) bool {
+	return v.HoldingType == models.HoldingTypeCrypto && r.coins.Class(v.Name) == models.CoinClassStablecoin
+}
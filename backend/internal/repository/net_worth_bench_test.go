@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/dbtest"
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// benchRoundTrip is what each query costs the benchmarks, about a trip to a
+// database on the local network
+const benchRoundTrip = 200 * time.Microsecond
+
+// benchHoldings is how many holdings the breakdown benchmarks value
+const benchHoldings = 200
+
+// perClassBreakdownQueries are the queries net worth took before it was summed
+// from holdingValuesQuery, one per asset class and adjustment
+var perClassBreakdownQueries = []string{
+	`SELECT COALESCE(SUM(shares_owned * current_price), 0) FROM stock_holdings WHERE current_price > 0 AND COALESCE(is_vested_equity, false) = false`,
+	`SELECT COALESCE(SUM(current_balance), 0) FROM cash_holdings WHERE account_type = 'brokerage'`,
+	`SELECT COALESCE(SUM(vested_shares * current_price), 0) FROM equity_grants WHERE current_price > 0 AND vested_shares > 0`,
+	`SELECT COALESCE(SUM(shares_owned * current_price), 0) FROM stock_holdings WHERE current_price > 0 AND COALESCE(is_vested_equity, false) = true`,
+	`SELECT COALESCE(SUM(unvested_shares * current_price), 0) FROM equity_grants WHERE current_price > 0 AND unvested_shares > 0`,
+	`SELECT COALESCE(SUM(equity * ownership_percentage / 100), 0) FROM real_estate_properties`,
+	`SELECT COALESCE(SUM(current_balance), 0) FROM cash_holdings WHERE account_type != 'brokerage'`,
+	`SELECT COALESCE(SUM(ch.balance_tokens * COALESCE(cp.price_usd, 0)), 0) FROM crypto_holdings ch
+	 LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
+	 AND cp.last_updated = (SELECT MAX(last_updated) FROM crypto_prices cp2 WHERE cp2.symbol = ch.crypto_symbol)`,
+	`SELECT COALESCE(SUM(current_value - COALESCE(amount_owed, 0)), 0) FROM miscellaneous_assets`,
+	`SELECT COALESCE(SUM(current_value), 0) FROM private_investments`,
+	`SELECT COALESCE(SUM(current_value), 0) FROM bond_values`,
+	`SELECT COALESCE(SUM(redemption_value), 0) FROM i_bond_values`,
+	`SELECT COALESCE(SUM(pv.present_value), 0) FROM pensions p JOIN pension_values pv ON pv.id = p.id WHERE p.include_in_net_worth`,
+	`SELECT COALESCE(SUM(GREATEST(cash_value - loan_balance, 0)), 0) FROM insurance_policies`,
+	`SELECT COALESCE(SUM(ch.balance_tokens * COALESCE(cp.price_usd, 0)), 0) FROM crypto_holdings ch
+	 LEFT JOIN crypto_prices cp ON ch.crypto_symbol = cp.symbol
+	 AND cp.last_updated = (SELECT MAX(last_updated) FROM crypto_prices cp2 WHERE cp2.symbol = ch.crypto_symbol)
+	 WHERE UPPER(ch.crypto_symbol) = ANY($1)`,
+}
+
+// perClassBreakdown computes the breakdown the way it was before holding
+// values, one round trip per query
+func perClassBreakdown(db *sql.DB, stablecoins []string) (models.NetWorthBreakdown, error) {
+	v := make([]decimal.Decimal, len(perClassBreakdownQueries))
+	for i, query := range perClassBreakdownQueries {
+		var args []any
+		if strings.Contains(query, "$1") {
+			args = append(args, stablecoins)
+		}
+		if err := db.QueryRow(query, args...).Scan(&v[i]); err != nil {
+			return models.NetWorthBreakdown{}, err
+		}
+	}
+	return models.NetWorthBreakdown{
+		StockHoldingsValue:      v[0].Add(v[1]),
+		VestedEquityValue:       v[2].Add(v[3]),
+		UnvestedEquityValue:     v[4],
+		RealEstateEquity:        v[5],
+		CashHoldingsValue:       v[6],
+		CryptoHoldingsValue:     v[7],
+		OtherAssetsValue:        v[8],
+		PrivateInvestmentsValue: v[9],
+		FixedIncomeValue:        v[10].Add(v[11]),
+		PensionsValue:           v[12],
+		InsuranceCashValue:      v[13],
+		StablecoinValue:         v[14],
+	}, nil
+}
+
+// sumsResult answers a query with one row of n sums
+func sumsResult(n int) dbtest.Result {
+	result := dbtest.Result{Rows: [][]driver.Value{make([]driver.Value, n)}}
+	for i := range n {
+		result.Columns = append(result.Columns, fmt.Sprintf("sum%d", i))
+		result.Rows[0][i] = "12345.67"
+	}
+	return result
+}
+
+// holdingRowsResult answers holdingValuesQuery with n holdings spread across
+// the asset classes
+func holdingRowsResult(n int) dbtest.Result {
+	components := []string{"stock_holdings", "vested_equity", "cash_holdings", "crypto_holdings", "real_estate", "other_assets"}
+	result := dbtest.Result{Columns: []string{"holding_type", "id", "component", "value",
+		"institution", "account_id", "account_name", "owner", "custodial", "name", "updated_at"}}
+	updated := time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC)
+	for i := range n {
+		result.Rows = append(result.Rows, []driver.Value{
+			"stock_holding", int64(i + 1), components[i%len(components)], "12345.67",
+			fmt.Sprintf("Institution %d", i%5), int64(i%10 + 1), fmt.Sprintf("Account %d", i%10), "self", false,
+			fmt.Sprintf("HOLD%d", i), updated,
+		})
+	}
+	return result
+}
+
+// goValuationQueries mark the queries of pensionValues, bondValues and
+// iBondValues
+var goValuationQueries = []string{"FROM pensions p", "FROM bonds b", "FROM i_bond_rates", "FROM i_bonds ib"}
+
+// goValuationResult answers the holdings valued in Go with nothing to value,
+// and reports whether query is one of theirs
+func goValuationResult(query string) (dbtest.Result, bool) {
+	for _, marker := range goValuationQueries {
+		if strings.Contains(query, marker) {
+			return dbtest.Result{Columns: []string{"id"}}, true
+		}
+	}
+	return dbtest.Result{}, false
+}
+
+func BenchmarkNetWorthBreakdown(b *testing.B) {
+	b.Run("holding_values", func(b *testing.B) {
+		db := dbtest.Open(benchRoundTrip, func(query string) dbtest.Result {
+			if result, ok := goValuationResult(query); ok {
+				return result
+			}
+			return holdingRowsResult(benchHoldings)
+		})
+		defer db.Close()
+		repo := NewNetWorthRepository(db)
+
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := repo.Breakdown(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("per_class", func(b *testing.B) {
+		db := dbtest.Open(benchRoundTrip, func(string) dbtest.Result { return sumsResult(1) })
+		defer db.Close()
+
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := perClassBreakdown(db, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+)
+
+var holdingValueColumns = []string{"holding_type", "id", "component", "value", "institution",
+	"account_id", "account_name", "owner", "custodial", "name", "updated_at"}
+
+// ownedHoldingRows are holdings in self's, spouse's and two children's
+// accounts, one child with an account that is not custodial
+func ownedHoldingRows() *sqlmock.Rows {
+	updated := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	return sqlmock.NewRows(holdingValueColumns).
+		AddRow("stock_holding", 1, "stock_holdings", "1000.00", "Fidelity", 1, "Brokerage", "self", false, "VTI", updated).
+		AddRow("cash_holding", 2, "cash_holdings", "500.00", "Chase", 2, "Checking", "spouse", false, "Checking", updated).
+		AddRow("stock_holding", 3, "stock_holdings", "250.00", "Fidelity", 3, "UTMA", "Noah", true, "VTI", updated).
+		AddRow("crypto_holding", 4, "crypto_holdings", "40.00", "Coinbase", 3, "UTMA", "Noah", true, "USDC", updated).
+		AddRow("cash_holding", 5, "cash_holdings", "75.00", "Chase", 4, "Savings", "emma", false, "Savings", updated).
+		AddRow("stock_holding", 6, "stock_holdings", "300.00", "Vanguard", 5, "529", "emma", true, "VFIAX", updated).
+		AddRow("equity_grant", 7, "unvested_equity", "900.00", "", nil, "", "self", false, "ACME RSU", updated)
+}
+
+// noRows answers pensionValues, bondValues and iBondValues with nothing to value
+func noRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id"})
+}
+
+// expectGoValuations expects the pensions, bonds and I bonds valued in Go,
+// with none
+func expectGoValuations(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM pensions p`).WillReturnRows(noRows())
+	mock.ExpectQuery(`FROM bonds b`).WillReturnRows(noRows())
+	mock.ExpectQuery(`FROM i_bond_rates`).WillReturnRows(noRows())
+	mock.ExpectQuery(`FROM i_bonds ib`).WillReturnRows(noRows())
+}
+
+func TestOwnerBreakdowns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewNetWorthRepository(db)
+	repo.SetCoinClassification(models.NewCoinClassification([]string{"USDC"}))
+
+	mock.ExpectQuery(`account_owners AS`).WillReturnRows(ownedHoldingRows())
+	expectGoValuations(mock)
+
+	breakdowns, err := repo.OwnerBreakdowns()
+	if err != nil {
+		t.Fatalf("OwnerBreakdowns: %v", err)
+	}
+
+	want := []struct {
+		owner       string
+		custodial   bool
+		totalAssets string
+		unvested    string
+		stablecoins string
+	}{
+		{"self", false, "1000", "900", "0"},
+		{"spouse", false, "500", "0", "0"},
+		{"emma", false, "75", "0", "0"},
+		{"emma", true, "300", "0", "0"},
+		{"Noah", true, "290", "0", "40"},
+	}
+	if len(breakdowns) != len(want) {
+		t.Fatalf("OwnerBreakdowns returned %d owners, want %d: %+v", len(breakdowns), len(want), breakdowns)
+	}
+	for i, w := range want {
+		got := breakdowns[i]
+		if got.Owner != w.owner || got.Custodial != w.custodial {
+			t.Errorf("owner %d = %s (custodial %v), want %s (custodial %v)", i, got.Owner, got.Custodial, w.owner, w.custodial)
+			continue
+		}
+		if !got.Breakdown.TotalAssets().Equal(decimal.RequireFromString(w.totalAssets)) {
+			t.Errorf("%s total assets = %s, want %s", w.owner, got.Breakdown.TotalAssets(), w.totalAssets)
+		}
+		if !got.Breakdown.UnvestedEquityValue.Equal(decimal.RequireFromString(w.unvested)) {
+			t.Errorf("%s unvested = %s, want %s", w.owner, got.Breakdown.UnvestedEquityValue, w.unvested)
+		}
+		if !got.Breakdown.StablecoinValue.Equal(decimal.RequireFromString(w.stablecoins)) {
+			t.Errorf("%s stablecoins = %s, want %s", w.owner, got.Breakdown.StablecoinValue, w.stablecoins)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewNetWorthRepository(db)
+	repo.SetCoinClassification(models.NewCoinClassification([]string{"USDC"}))
+
+	mock.ExpectQuery(`account_owners AS`).WillReturnRows(ownedHoldingRows())
+	expectGoValuations(mock)
+
+	b, err := repo.Breakdown()
+	if err != nil {
+		t.Fatalf("Breakdown: %v", err)
+	}
+
+	// Every owner's holdings, custodial or not
+	checks := []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		{"stock holdings", b.StockHoldingsValue, "1550"},
+		{"cash", b.CashHoldingsValue, "575"},
+		{"crypto", b.CryptoHoldingsValue, "40"},
+		{"stablecoins", b.StablecoinValue, "40"},
+		{"unvested equity", b.UnvestedEquityValue, "900"},
+		{"total assets", b.TotalAssets(), "2165"},
+	}
+	for _, c := range checks {
+		if !c.got.Equal(decimal.RequireFromString(c.want)) {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPersonalBreakdown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewNetWorthRepository(db)
+	repo.SetCoinClassification(models.NewCoinClassification([]string{"USDC"}))
+
+	mock.ExpectQuery(`account_owners AS`).WillReturnRows(ownedHoldingRows())
+	expectGoValuations(mock)
+
+	b, err := repo.PersonalBreakdown()
+	if err != nil {
+		t.Fatalf("PersonalBreakdown: %v", err)
+	}
+
+	// Noah's UTMA and emma's 529 are left out; emma's savings account is not custodial
+	checks := []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		{"stock holdings", b.StockHoldingsValue, "1000"},
+		{"cash", b.CashHoldingsValue, "575"},
+		{"crypto", b.CryptoHoldingsValue, "0"},
+		{"stablecoins", b.StablecoinValue, "0"},
+		{"unvested equity", b.UnvestedEquityValue, "900"},
+		{"total assets", b.TotalAssets(), "1575"},
+	}
+	for _, c := range checks {
+		if !c.got.Equal(decimal.RequireFromString(c.want)) {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
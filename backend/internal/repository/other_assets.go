@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+// OtherAssetRepository provides access to miscellaneous assets
+type OtherAssetRepository struct {
+	db *sql.DB
+}
+
+// NewOtherAssetRepository creates a new other assets repository
+func NewOtherAssetRepository(db *sql.DB) *OtherAssetRepository {
+	return &OtherAssetRepository{db: db}
+}
+
+const otherAssetSelectQuery = `
+		SELECT ma.id, ma.asset_name, ma.current_value, ma.purchase_price, 
+		       ma.amount_owed, ma.purchase_date, ma.description, ma.custom_fields,
+		       ma.valuation_method, ma.last_valuation_date, ma.api_provider,
+		       ma.notes, ma.created_at, ma.last_updated,
+		       ac.name as category_name, ac.description as category_description,
+		       ac.icon as category_icon, ac.color as category_color,
+		       ma.asset_category_id
+		FROM miscellaneous_assets ma
+		LEFT JOIN asset_categories ac ON ma.asset_category_id = ac.id
+	`
+
+// List returns miscellaneous assets with category information, most recently
+// updated first. A non-nil categoryID restricts results to that category.
+func (r *OtherAssetRepository) List(categoryID *int) ([]models.MiscellaneousAsset, error) {
+	query := otherAssetSelectQuery
+
+	args := []interface{}{}
+	if categoryID != nil {
+		query += " WHERE ma.asset_category_id = $1"
+		args = append(args, *categoryID)
+	}
+	query += " ORDER BY ma.last_updated DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch other assets: %w", err)
+	}
+	defer rows.Close()
+
+	assets := make([]models.MiscellaneousAsset, 0)
+	for rows.Next() {
+		a, err := scanOtherAsset(rows)
+		if err != nil {
+			continue
+		}
+		assets = append(assets, a)
+	}
+
+	return assets, rows.Err()
+}
+
+// Get returns one miscellaneous asset with its category information
+func (r *OtherAssetRepository) Get(id int) (*models.MiscellaneousAsset, error) {
+	a, err := scanOtherAsset(r.db.QueryRow(otherAssetSelectQuery+" WHERE ma.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch other asset: %w", err)
+	}
+	return &a, nil
+}
+
+// ValuationHistory returns an asset's values recorded from from up to but
+// not including to, oldest first
+func (r *OtherAssetRepository) ValuationHistory(assetID int, from, to time.Time) ([]models.AssetValuationRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT id, asset_id, value, source, valued_at
+		FROM asset_valuation_history
+		WHERE asset_id = $1 AND valued_at >= $2 AND valued_at < $3
+		ORDER BY valued_at, id
+	`, assetID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset valuation history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]models.AssetValuationRecord, 0)
+	for rows.Next() {
+		var v models.AssetValuationRecord
+		if err := rows.Scan(&v.ID, &v.AssetID, &v.Value, &v.Source, &v.ValuedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan asset valuation: %w", err)
+		}
+		history = append(history, v)
+	}
+	return history, rows.Err()
+}
+
+// ValuationBefore returns the last value recorded for an asset before at, or
+// nil if there is none
+func (r *OtherAssetRepository) ValuationBefore(assetID int, at time.Time) (*models.AssetValuationRecord, error) {
+	var v models.AssetValuationRecord
+	err := r.db.QueryRow(`
+		SELECT id, asset_id, value, source, valued_at
+		FROM asset_valuation_history
+		WHERE asset_id = $1 AND valued_at < $2
+		ORDER BY valued_at DESC, id DESC
+		LIMIT 1
+	`, assetID, at).Scan(&v.ID, &v.AssetID, &v.Value, &v.Source, &v.ValuedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset valuation: %w", err)
+	}
+	return &v, nil
+}
+
+// CategorySchema returns an asset category's stored custom_schema, which is
+// empty if the category has none
+func (r *OtherAssetRepository) CategorySchema(categoryID int) ([]byte, error) {
+	var schema sql.NullString
+	err := r.db.QueryRow("SELECT custom_schema FROM asset_categories WHERE id = $1", categoryID).Scan(&schema)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch category schema: %w", err)
+	}
+	if !schema.Valid || schema.String == "null" {
+		return nil, nil
+	}
+	return []byte(schema.String), nil
+}
+
+// ReplaceCustomFields stores new custom_fields for several assets in one
+// transaction, keyed by asset ID
+func (r *OtherAssetRepository) ReplaceCustomFields(customFields map[int]map[string]interface{}) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for id, fields := range customFields {
+		fieldsJSON, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("failed to encode custom fields of asset %d: %w", id, err)
+		}
+		_, err = tx.Exec(`
+			UPDATE miscellaneous_assets SET custom_fields = $1, last_updated = $2 WHERE id = $3
+		`, fieldsJSON, now, id)
+		if err != nil {
+			return fmt.Errorf("failed to update custom fields of asset %d: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ValuationUpdateTimes returns when each other asset was last edited or
+// valued, nil when never
+func (r *OtherAssetRepository) ValuationUpdateTimes() ([]*time.Time, error) {
+	return updateTimes(r.db, `
+		SELECT GREATEST(last_valuation_date, last_updated)
+		FROM miscellaneous_assets
+	`, "other asset valuations")
+}
+
+// Delete removes a miscellaneous asset
+func (r *OtherAssetRepository) Delete(id int) error {
+	return deleteByID(r.db, "miscellaneous_assets", id)
+}
+
+func scanOtherAsset(row interface{ Scan(...interface{}) error }) (models.MiscellaneousAsset, error) {
+	var a models.MiscellaneousAsset
+	var customFields sql.NullString
+	var categoryName, categoryDescription, categoryIcon, categoryColor sql.NullString
+	var assetCategoryID sql.NullInt64
+	var purchaseDate sql.NullTime
+
+	err := row.Scan(
+		&a.ID, &a.AssetName, &a.CurrentValue, &a.PurchasePrice,
+		&a.AmountOwed, &purchaseDate, &a.Description, &customFields,
+		&a.ValuationMethod, &a.LastValuationDate, &a.APIProvider,
+		&a.Notes, &a.CreatedAt, &a.LastUpdated,
+		&categoryName, &categoryDescription, &categoryIcon,
+		&categoryColor, &assetCategoryID,
+	)
+	if err != nil {
+		return a, err
+	}
+
+	a.PurchaseDate = dateText(purchaseDate)
+
+	// Calculate equity (value - amount owed)
+	a.Equity = a.CurrentValue
+	if a.AmountOwed != nil {
+		a.Equity = a.Equity.Sub(*a.AmountOwed)
+	}
+
+	if customFields.Valid && customFields.String != "" {
+		json.Unmarshal([]byte(customFields.String), &a.CustomFields)
+	}
+
+	a.AssetCategoryID = assetCategoryID.Int64
+	if categoryName.Valid {
+		a.Category = &models.AssetCategorySummary{
+			Name:        categoryName.String,
+			Description: categoryDescription.String,
+			Icon:        categoryIcon.String,
+			Color:       categoryColor.String,
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"fmt"
+
+	"networth-dashboard/internal/models"
+)
+
+// passiveIncomeQuery sums the monthly income of each source in one round
+// trip: cash interest outside brokerage accounts, a third of the estimated
+// quarterly dividends, the owned share of rent and staking yield at the
+// latest crypto price
+const passiveIncomeQuery = `
+	WITH latest_crypto_prices AS (
+		SELECT symbol, price_usd
+		FROM (
+			SELECT symbol, price_usd, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY last_updated DESC) AS position
+			FROM crypto_prices
+		) ranked
+		WHERE position = 1
+	)
+	SELECT
+		(SELECT COALESCE(SUM(current_balance * COALESCE(interest_rate, 0) / 100 / 12), 0)
+		 FROM cash_holdings
+		 WHERE account_type != 'brokerage' AND interest_rate > 0),
+		(SELECT COALESCE(SUM(shares_owned * COALESCE(estimated_quarterly_dividend, 0) / 3), 0)
+		 FROM stock_holdings
+		 WHERE estimated_quarterly_dividend > 0),
+		(SELECT COALESCE(SUM(rental_income_monthly * ownership_percentage / 100), 0)
+		 FROM real_estate_properties
+		 WHERE rental_income_monthly > 0),
+		(SELECT COALESCE(SUM(ch.staked_tokens * COALESCE(lp.price_usd, 0) * ch.staking_annual_percentage / 100 / 12), 0)
+		 FROM crypto_holdings ch
+		 LEFT JOIN latest_crypto_prices lp ON lp.symbol = ch.crypto_symbol
+		 WHERE ch.staking_annual_percentage > 0 AND ch.staked_tokens > 0)
+`
+
+// PassiveIncome returns the monthly passive income expected from each source
+func (r *NetWorthRepository) PassiveIncome() (models.PassiveIncome, error) {
+	var income models.PassiveIncome
+	err := r.db.QueryRow(passiveIncomeQuery).Scan(
+		&income.CashInterest, &income.StockDividends, &income.RentalIncome, &income.CryptoStaking,
+	)
+	if err != nil {
+		return models.PassiveIncome{}, fmt.Errorf("failed to calculate passive income: %w", err)
+	}
+	return income, nil
+}
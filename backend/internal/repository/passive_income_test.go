@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPassiveIncome(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewNetWorthRepository(db)
+
+	mock.ExpectQuery(`WITH latest_crypto_prices`).
+		WillReturnRows(sqlmock.NewRows([]string{"cash", "dividends", "rental", "staking"}).
+			AddRow(41.5, 12.25, 1800.0, 3.1))
+	income, err := repo.PassiveIncome()
+	if err != nil {
+		t.Fatalf("PassiveIncome: %v", err)
+	}
+	want := models.PassiveIncome{CashInterest: 41.5, StockDividends: 12.25, RentalIncome: 1800, CryptoStaking: 3.1}
+	if income != want {
+		t.Errorf("PassiveIncome = %+v, want %+v", income, want)
+	}
+
+	mock.ExpectQuery(`WITH latest_crypto_prices`).WillReturnError(errors.New("connection reset"))
+	if _, err := repo.PassiveIncome(); err == nil {
+		t.Error("PassiveIncome hid a database error")
+	}
+}
+
+func TestCryptoPriceHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	since := time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM crypto_price_history`).WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "price_usd", "price_btc", "last_updated"}).
+			AddRow("BTC", 65000.0, 1.0, since).
+			AddRow("ETH", 2500.0, 0.038, since.Add(time.Hour)))
+
+	points, err := NewCryptoRepository(db, nil).PriceHistory(since)
+	if err != nil {
+		t.Fatalf("PriceHistory: %v", err)
+	}
+	if len(points) != 2 || points[1].Symbol != "ETH" || points[1].PriceBTC != 0.038 {
+		t.Errorf("PriceHistory = %+v", points)
+	}
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// PropertyLedgerRepository provides access to property income and expense entries
+type PropertyLedgerRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewPropertyLedgerRepository creates a new property ledger repository
+func NewPropertyLedgerRepository(db *sql.DB) *PropertyLedgerRepository {
+	return &PropertyLedgerRepository{db: db, dialect: database.DialectOf(db)}
+}
+
+// List returns a property's entries dated from through to, newest first
+func (r *PropertyLedgerRepository) List(propertyID int, from, to time.Time) ([]models.PropertyLedgerEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, property_id, category, amount, entry_date, description, created_at, updated_at
+		FROM property_ledger_entries
+		WHERE property_id = $1 AND entry_date >= $2 AND entry_date <= $3
+		ORDER BY entry_date DESC, id DESC
+	`, propertyID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch property ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.PropertyLedgerEntry, 0)
+	for rows.Next() {
+		var e models.PropertyLedgerEntry
+		err := rows.Scan(&e.ID, &e.PropertyID, &e.Category, &e.Amount, &e.EntryDate,
+			&e.Description, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan property ledger entry: %w", err)
+		}
+		e.Kind = models.PropertyLedgerCategories[e.Category]
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Create inserts a ledger entry and returns its ID
+func (r *PropertyLedgerRepository) Create(input models.PropertyLedgerEntryInput, date time.Time) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO property_ledger_entries (property_id, category, amount, entry_date, description)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, input.PropertyID, input.Category, input.Amount, date, input.Description).Scan(&id)
+	if err != nil {
+		return 0, cashFlowError(err, "failed to create property ledger entry")
+	}
+	return id, nil
+}
+
+// Update replaces the writable fields of a ledger entry
+func (r *PropertyLedgerRepository) Update(id int, input models.PropertyLedgerEntryInput, date time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE property_ledger_entries
+		SET property_id = $1, category = $2, amount = $3, entry_date = $4, description = $5,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`, input.PropertyID, input.Category, input.Amount, date, input.Description, id)
+	if err != nil {
+		return cashFlowError(err, "failed to update property ledger entry")
+	}
+	return requireAffected(result)
+}
+
+// Delete removes a ledger entry
+func (r *PropertyLedgerRepository) Delete(id int) error {
+	return deleteByID(r.db, "property_ledger_entries", id)
+}
+
+// CashFlow totals a property's ledger per month and category for the entries
+// dated from through to, and works out its returns. Net operating income is
+// income less operating expenses; cap rate is annualized NOI over the current
+// value and cash-on-cash return is annualized cash flow after debt service over
+// the cash invested up to to. The totals are for the whole property, with the
+// owner's share of each alongside.
+func (r *PropertyLedgerRepository) CashFlow(property models.RealEstate, from, to time.Time) (*models.PropertyCashFlow, error) {
+	rows, err := r.db.Query(`
+		SELECT `+r.dialect.Month("entry_date")+`, category, SUM(amount)
+		FROM property_ledger_entries
+		WHERE property_id = $1 AND entry_date >= $2 AND entry_date <= $3
+		GROUP BY 1, category
+	`, property.ID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize property cash flow: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &models.PropertyCashFlow{
+		PropertyID:         property.ID,
+		PropertyName:       property.PropertyName,
+		From:               from.Format("2006-01-02"),
+		To:                 to.Format("2006-01-02"),
+		IncomeByCategory:   map[string]decimal.Decimal{},
+		ExpensesByCategory: map[string]decimal.Decimal{},
+		CurrentValue:       property.CurrentValue,
+		Months:             []models.PropertyCashFlowMonth{},
+	}
+	monthIndex := make(map[string]int)
+	end := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(end); month = month.AddDate(0, 1, 0) {
+		label := month.Format("2006-01")
+		monthIndex[label] = len(summary.Months)
+		summary.Months = append(summary.Months, models.PropertyCashFlowMonth{Month: label})
+	}
+
+	for rows.Next() {
+		var label, category string
+		var amount decimal.Decimal
+		if err := rows.Scan(&label, &category, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan property cash flow: %w", err)
+		}
+		i, ok := monthIndex[label]
+		if !ok {
+			continue
+		}
+		month := &summary.Months[i]
+		switch models.PropertyLedgerCategories[category] {
+		case models.PropertyLedgerIncome:
+			month.Income = month.Income.Add(amount)
+			summary.IncomeByCategory[category] = summary.IncomeByCategory[category].Add(amount)
+		case models.PropertyLedgerOperatingExpense:
+			month.OperatingExpenses = month.OperatingExpenses.Add(amount)
+			summary.ExpensesByCategory[category] = summary.ExpensesByCategory[category].Add(amount)
+		case models.PropertyLedgerDebtService:
+			month.DebtService = month.DebtService.Add(amount)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to summarize property cash flow: %w", err)
+	}
+
+	for i := range summary.Months {
+		month := &summary.Months[i]
+		month.NetOperatingIncome = month.Income.Sub(month.OperatingExpenses)
+		month.CashFlow = month.NetOperatingIncome.Sub(month.DebtService)
+		summary.Income = summary.Income.Add(month.Income)
+		summary.OperatingExpenses = summary.OperatingExpenses.Add(month.OperatingExpenses)
+		summary.DebtService = summary.DebtService.Add(month.DebtService)
+	}
+	summary.NetOperatingIncome = summary.Income.Sub(summary.OperatingExpenses)
+	summary.CashFlow = summary.NetOperatingIncome.Sub(summary.DebtService)
+
+	// The range is inclusive of both ends
+	days := decimal.NewFromInt(int64(to.Sub(from).Hours()/24) + 1)
+	perYear := decimal.NewFromInt(365).Div(days)
+	summary.AnnualizedNOI = summary.NetOperatingIncome.Mul(perYear).Round(2)
+	summary.AnnualizedCashFlow = summary.CashFlow.Mul(perYear).Round(2)
+	if summary.CurrentValue.IsPositive() {
+		capRate := models.PercentOf(summary.AnnualizedNOI, summary.CurrentValue)
+		summary.CapRate = &capRate
+	}
+
+	invested, err := r.cashInvested(property.ID, to)
+	if err != nil {
+		return nil, err
+	}
+	if invested.IsZero() {
+		invested = property.PurchasePrice.Sub(property.OutstandingMortgage)
+		summary.CashInvestedEstimated = true
+	}
+	if invested.IsPositive() {
+		summary.CashInvested = &invested
+		cashOnCash := models.PercentOf(summary.AnnualizedCashFlow, invested)
+		summary.CashOnCashReturn = &cashOnCash
+	}
+	summary.ApplyOwnership(property.OwnershipPercentage)
+
+	return summary, nil
+}
+
+// BasisAdditions totals each property's closing cost and capital improvement
+// entries dated up to asOf, which add to its cost basis
+func (r *PropertyLedgerRepository) BasisAdditions(asOf time.Time) (map[int]float64, error) {
+	rows, err := r.db.Query(`
+		SELECT property_id, SUM(amount)
+		FROM property_ledger_entries
+		WHERE entry_date <= $1 AND category IN ('closing_costs', 'capital_improvement')
+		GROUP BY property_id
+	`, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total property basis additions: %w", err)
+	}
+	defer rows.Close()
+
+	additions := make(map[int]float64)
+	for rows.Next() {
+		var propertyID int
+		var amount float64
+		if err := rows.Scan(&propertyID, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan property basis additions: %w", err)
+		}
+		additions[propertyID] = amount
+	}
+	return additions, rows.Err()
+}
+
+// cashInvested totals down payment, closing cost and improvement entries up to to
+func (r *PropertyLedgerRepository) cashInvested(propertyID int, to time.Time) (decimal.Decimal, error) {
+	var categories []string
+	for category, kind := range models.PropertyLedgerCategories {
+		if kind == models.PropertyLedgerInvestment {
+			categories = append(categories, category)
+		}
+	}
+
+	var invested decimal.Decimal
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0)
+		FROM property_ledger_entries
+		WHERE property_id = $1 AND entry_date <= $2 AND `+r.dialect.InArray("category", "$3")+`
+	`, propertyID, to, categories).Scan(&invested)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to total cash invested: %w", err)
+	}
+	return invested, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+const realEstateSelectQuery = `
+		SELECT id, account_id, property_type, property_name, purchase_price, 
+		       current_value, outstanding_mortgage, equity, 
+		       purchase_date, 
+		       property_size_sqft, lot_size_acres, rental_income_monthly, 
+		       property_tax_annual, notes, street_address, city, state, zip_code,
+		       latitude, longitude, api_estimated_value, api_estimate_date, 
+		       api_provider, ownership_percentage, improvement_value,
+		       placed_in_service_date, created_at
+		FROM real_estate_properties`
+
+// RealEstateRepository provides access to real estate properties
+type RealEstateRepository struct {
+	db *sql.DB
+}
+
+// NewRealEstateRepository creates a new real estate repository
+func NewRealEstateRepository(db *sql.DB) *RealEstateRepository {
+	return &RealEstateRepository{db: db}
+}
+
+// List returns all properties ordered by name
+func (r *RealEstateRepository) List() ([]models.RealEstate, error) {
+	rows, err := r.db.Query(realEstateSelectQuery + `
+		ORDER BY property_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch real estate properties: %w", err)
+	}
+	defer rows.Close()
+
+	properties := make([]models.RealEstate, 0)
+	for rows.Next() {
+		p, err := scanRealEstate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan real estate property: %w", err)
+		}
+		properties = append(properties, p)
+	}
+
+	return properties, rows.Err()
+}
+
+// Get returns one property
+func (r *RealEstateRepository) Get(id int) (*models.RealEstate, error) {
+	p, err := scanRealEstate(r.db.QueryRow(realEstateSelectQuery+` WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch real estate property: %w", err)
+	}
+	return &p, nil
+}
+
+// RecordValuation stores a consensus valuation with the provider values
+// behind it and sets it as the property's API estimate
+func (r *RealEstateRepository) RecordValuation(propertyID int, source string, consensus models.PropertyValuationRecord, providers []models.PropertyValuationRecord) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE real_estate_properties
+		SET api_estimated_value = $1, api_estimate_date = CURRENT_TIMESTAMP, api_provider = $2
+		WHERE id = $3
+	`, consensus.EstimatedValue, source, propertyID)
+	if err != nil {
+		return fmt.Errorf("failed to update property estimate: %w", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	consensus.Provider = models.PropertyValuationConsensus
+	for _, v := range append(providers, consensus) {
+		_, err := tx.Exec(`
+			INSERT INTO property_valuations (property_id, provider, estimated_value, confidence_score)
+			VALUES ($1, $2, $3, $4)
+		`, propertyID, v.Provider, v.EstimatedValue, v.ConfidenceScore)
+		if err != nil {
+			return fmt.Errorf("failed to record property valuation: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListValuations returns a property's stored valuations, newest first
+func (r *RealEstateRepository) ListValuations(propertyID, limit int) ([]models.PropertyValuationRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT id, property_id, provider, estimated_value, confidence_score, valued_at
+		FROM property_valuations
+		WHERE property_id = $1
+		ORDER BY valued_at DESC, id DESC
+		LIMIT $2
+	`, propertyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch property valuations: %w", err)
+	}
+	defer rows.Close()
+
+	valuations := make([]models.PropertyValuationRecord, 0)
+	for rows.Next() {
+		var v models.PropertyValuationRecord
+		if err := rows.Scan(&v.ID, &v.PropertyID, &v.Provider, &v.EstimatedValue, &v.ConfidenceScore, &v.ValuedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan property valuation: %w", err)
+		}
+		valuations = append(valuations, v)
+	}
+	return valuations, rows.Err()
+}
+
+// ValuationUpdateTimes returns when each property was last edited or valued,
+// nil when never
+func (r *RealEstateRepository) ValuationUpdateTimes() ([]*time.Time, error) {
+	return updateTimes(r.db, `
+		SELECT GREATEST(re.last_updated, (SELECT MAX(pv.valued_at) FROM property_valuations pv WHERE pv.property_id = re.id))
+		FROM real_estate_properties re
+	`, "property valuations")
+}
+
+// Delete removes a property
+func (r *RealEstateRepository) Delete(id int) error {
+	return deleteByID(r.db, "real_estate_properties", id)
+}
+
+func scanRealEstate(row interface{ Scan(...interface{}) error }) (models.RealEstate, error) {
+	var p models.RealEstate
+	var purchaseDate time.Time
+	var placedInService sql.NullTime
+	err := row.Scan(
+		&p.ID, &p.AccountID, &p.PropertyType, &p.PropertyName,
+		&p.PurchasePrice, &p.CurrentValue, &p.OutstandingMortgage,
+		&p.Equity, &purchaseDate, &p.PropertySizeSqft,
+		&p.LotSizeAcres, &p.RentalIncomeMonthly, &p.PropertyTaxAnnual,
+		&p.Notes, &p.StreetAddress, &p.City, &p.State,
+		&p.ZipCode, &p.Latitude, &p.Longitude,
+		&p.APIEstimatedValue, &p.APIEstimateDate, &p.APIProvider,
+		&p.OwnershipPercentage, &p.ImprovementValue, &placedInService,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return p, err
+	}
+	p.PurchaseDate = purchaseDate.Format("2006-01-02")
+	p.PlacedInServiceDate = dateText(placedInService)
+	p.ApplyOwnership()
+	return p, nil
+}
@@ -0,0 +1,128 @@
+// Package repository provides typed data access for each asset domain so that
+// SQL and row scanning live in one place instead of in HTTP handlers.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/encryption"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+// ErrNotFound is returned when a record with the requested ID does not exist
+var ErrNotFound = errors.New("record not found")
+
+// PostgreSQL error codes for constraint violations
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// Repositories groups the per-domain repositories
+type Repositories struct {
+	Accounts        *AccountRepository
+	Stocks          *StockRepository
+	Equity          *EquityRepository
+	RealEstate      *RealEstateRepository
+	Cash            *CashRepository
+	Crypto          *CryptoRepository
+	OtherAssets     *OtherAssetRepository
+	NetWorth        *NetWorthRepository
+	Goals           *GoalRepository
+	CashFlow        *CashFlowRepository
+	Tags            *TagRepository
+	Household       *HouseholdRepository
+	Dashboard       *DashboardRepository
+	SavedViews      *SavedViewRepository
+	PropertyLedger  *PropertyLedgerRepository
+	Search          *SearchRepository
+	Attachments     *AttachmentRepository
+	Merges          *MergeRepository
+	AssetCategories *AssetCategoryRepository
+	ManualEntries   *ManualEntryRepository
+	Liabilities     *LiabilityRepository
+}
+
+// New creates all repositories backed by the given database. Sensitive columns
+// are decrypted with fieldEncryptor.
+func New(db *sql.DB, fieldEncryptor *encryption.FieldEncryptor) *Repositories {
+	return &Repositories{
+		Accounts:        NewAccountRepository(db),
+		Stocks:          NewStockRepository(db),
+		Equity:          NewEquityRepository(db),
+		RealEstate:      NewRealEstateRepository(db),
+		Cash:            NewCashRepository(db, fieldEncryptor),
+		Crypto:          NewCryptoRepository(db, fieldEncryptor),
+		OtherAssets:     NewOtherAssetRepository(db),
+		NetWorth:        NewNetWorthRepository(db),
+		Goals:           NewGoalRepository(db),
+		CashFlow:        NewCashFlowRepository(db),
+		Tags:            NewTagRepository(db),
+		Household:       NewHouseholdRepository(db),
+		Dashboard:       NewDashboardRepository(db),
+		SavedViews:      NewSavedViewRepository(db),
+		PropertyLedger:  NewPropertyLedgerRepository(db),
+		Search:          NewSearchRepository(db),
+		Attachments:     NewAttachmentRepository(db),
+		Merges:          NewMergeRepository(db),
+		AssetCategories: NewAssetCategoryRepository(db),
+		ManualEntries:   NewManualEntryRepository(db),
+		Liabilities:     NewLiabilityRepository(db),
+	}
+}
+
+// deleteByID deletes a single row by primary key, returning ErrNotFound when
+// nothing was deleted. The table name always comes from repository code.
+func deleteByID(db *sql.DB, table string, id int) error {
+	result, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = $1", sqlbuilder.Ident(table)), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete from %s: %w", table, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// dateText formats a nullable date column as YYYY-MM-DD
+func dateText(date sql.NullTime) *string {
+	if !date.Valid {
+		return nil
+	}
+	text := date.Time.Format("2006-01-02")
+	return &text
+}
+
+// updateTimes runs a query of one nullable timestamp per row, such as when
+// each holding was last priced, with what naming them in errors
+func updateTimes(db *sql.DB, query, what string) ([]*time.Time, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s update times: %w", what, err)
+	}
+	defer rows.Close()
+
+	times := []*time.Time{}
+	for rows.Next() {
+		var updated sql.NullTime
+		if err := rows.Scan(&updated); err != nil {
+			return nil, fmt.Errorf("failed to scan %s update time: %w", what, err)
+		}
+		if updated.Valid {
+			times = append(times, &updated.Time)
+		} else {
+			times = append(times, nil)
+		}
+	}
+	return times, rows.Err()
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDuplicateView is returned when a holding list already has a view with the name, ignoring case
+var ErrDuplicateView = errors.New("saved view already exists")
+
+const savedViewSelectQuery = `SELECT id, name, holding_type, filters, created_at, updated_at FROM saved_views`
+
+// viewSubjectQueries select, per holding type, what saved view filters look
+// at: id, institution, symbol, sector and value. Values follow the net worth
+// breakdown, so a view over stocks sees the same market value as /stocks.
+var viewSubjectQueries = map[string]string{
+	models.HoldingTypeStock: `
+		SELECT h.id AS id, h.institution_name AS institution, h.symbol AS symbol, sm.sector AS sector,
+		       h.shares_owned * COALESCE(h.current_price, 0) AS value
+		FROM stock_holdings h
+		LEFT JOIN security_metadata sm ON sm.symbol = UPPER(h.symbol)`,
+	models.HoldingTypeEquityGrant: `
+		SELECT g.id AS id, a.institution AS institution, g.company_symbol AS symbol, sm.sector AS sector,
+		       g.vested_shares * COALESCE(g.current_price, 0) AS value
+		FROM equity_grants g
+		LEFT JOIN accounts a ON a.id = g.account_id
+		LEFT JOIN security_metadata sm ON sm.symbol = UPPER(g.company_symbol)`,
+	models.HoldingTypeRealEstate: `
+		SELECT p.id AS id, a.institution AS institution, NULL AS symbol, NULL AS sector,
+		       p.equity * p.ownership_percentage / 100 AS value
+		FROM real_estate_properties p
+		LEFT JOIN accounts a ON a.id = p.account_id`,
+	models.HoldingTypeCash: `
+		SELECT h.id AS id, h.institution_name AS institution, NULL AS symbol, NULL AS sector,
+		       h.current_balance AS value
+		FROM cash_holdings h`,
+	models.HoldingTypeCrypto: `
+		SELECT h.id AS id, h.institution_name AS institution, h.crypto_symbol AS symbol, NULL AS sector,
+		       h.balance_tokens * COALESCE((
+		           SELECT price_usd FROM crypto_prices
+		           WHERE symbol = h.crypto_symbol
+		           ORDER BY last_updated DESC
+		           LIMIT 1
+		       ), 0) AS value
+		FROM crypto_holdings h`,
+	models.HoldingTypeOtherAsset: `
+		SELECT m.id AS id, a.institution AS institution, NULL AS symbol, NULL AS sector,
+		       m.current_value - COALESCE(m.amount_owed, 0) AS value
+		FROM miscellaneous_assets m
+		LEFT JOIN accounts a ON a.id = m.account_id`,
+}
+
+// SavedViewRepository stores saved views and applies their filters
+type SavedViewRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+	tags    *TagRepository
+}
+
+// NewSavedViewRepository creates a new saved view repository
+func NewSavedViewRepository(db *sql.DB) *SavedViewRepository {
+	return &SavedViewRepository{db: db, dialect: database.DialectOf(db), tags: NewTagRepository(db)}
+}
+
+// List returns the saved views, optionally only those over holdingType, by name
+func (r *SavedViewRepository) List(holdingType string) ([]models.SavedView, error) {
+	rows, err := r.db.Query(savedViewSelectQuery+`
+		WHERE $1 = '' OR holding_type = $1
+		ORDER BY holding_type, LOWER(name)
+	`, holdingType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch saved views: %w", err)
+	}
+	defer rows.Close()
+
+	views := []models.SavedView{}
+	for rows.Next() {
+		view, err := scanSavedView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	return views, rows.Err()
+}
+
+// Get returns one saved view
+func (r *SavedViewRepository) Get(id int) (*models.SavedView, error) {
+	view, err := scanSavedView(r.db.QueryRow(savedViewSelectQuery+` WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// Create inserts a saved view and returns it
+func (r *SavedViewRepository) Create(input models.SavedViewInput) (*models.SavedView, error) {
+	filters, err := json.Marshal(input.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode view filters: %w", err)
+	}
+
+	var id int
+	err = r.db.QueryRow(`
+		INSERT INTO saved_views (name, holding_type, filters) VALUES ($1, $2, $3) RETURNING id
+	`, input.Name, input.HoldingType, filters).Scan(&id)
+	if err != nil {
+		return nil, savedViewError(err, "create")
+	}
+	return r.Get(id)
+}
+
+// Update replaces the name, holding type and filters of a saved view
+func (r *SavedViewRepository) Update(id int, input models.SavedViewInput) (*models.SavedView, error) {
+	filters, err := json.Marshal(input.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode view filters: %w", err)
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE saved_views
+		SET name = $1, holding_type = $2, filters = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, input.Name, input.HoldingType, filters, id)
+	if err != nil {
+		return nil, savedViewError(err, "update")
+	}
+	if err := requireAffected(result); err != nil {
+		return nil, err
+	}
+	return r.Get(id)
+}
+
+// Delete removes a saved view
+func (r *SavedViewRepository) Delete(id int) error {
+	return deleteByID(r.db, "saved_views", id)
+}
+
+// MatchingIDs returns the IDs of the holdings that pass the view's filters
+func (r *SavedViewRepository) MatchingIDs(view models.SavedView) (map[int]bool, error) {
+	subjects, ok := viewSubjectQueries[view.HoldingType]
+	if !ok {
+		return nil, fmt.Errorf("saved views do not support %s", view.HoldingType)
+	}
+
+	f := view.Filters
+	var symbols, sectors []string
+	for _, symbol := range f.Symbols {
+		symbols = append(symbols, strings.ToUpper(strings.TrimSpace(symbol)))
+	}
+	for _, sector := range f.Sectors {
+		sectors = append(sectors, strings.ToLower(strings.TrimSpace(sector)))
+	}
+
+	// Empty symbol and sector lists match everything, flagged by $2 and $4
+	rows, err := r.db.Query(`
+		SELECT id FROM (`+subjects+`
+		) AS subject
+		WHERE ($1 = '' OR LOWER(institution) = LOWER($1))
+		  AND (NOT $2 OR `+r.dialect.InArray("UPPER(symbol)", "$3")+`)
+		  AND (NOT $4 OR `+r.dialect.InArray("LOWER(sector)", "$5")+`)
+		  AND (CAST($6 AS NUMERIC) IS NULL OR value >= $6)
+		  AND (CAST($7 AS NUMERIC) IS NULL OR value <= $7)
+	`, strings.TrimSpace(f.Institution), len(symbols) > 0, symbols, len(sectors) > 0, sectors, f.MinValue, f.MaxValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply saved view: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan saved view match: %w", err)
+		}
+		ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to apply saved view: %w", err)
+	}
+
+	// Tags go through the same matcher as the tag and exclude_tag parameters
+	matcher, err := r.tags.HoldingMatcher(view.HoldingType, TagFilter{Include: f.Tags, Exclude: f.ExcludeTags})
+	if err != nil {
+		return nil, err
+	}
+	for id := range ids {
+		if !matcher.MatchesID(id) {
+			delete(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func scanSavedView(row interface{ Scan(...interface{}) error }) (models.SavedView, error) {
+	var view models.SavedView
+	var filters []byte
+	err := row.Scan(&view.ID, &view.Name, &view.HoldingType, &filters, &view.CreatedAt, &view.UpdatedAt)
+	if err != nil {
+		return view, err
+	}
+	if err := json.Unmarshal(filters, &view.Filters); err != nil {
+		return view, fmt.Errorf("failed to decode view filters: %w", err)
+	}
+	return view, nil
+}
+
+// savedViewError maps constraint violations to ErrDuplicateView
+func savedViewError(err error, action string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicateView
+	}
+	return fmt.Errorf("failed to %s saved view: %w", action, err)
+}
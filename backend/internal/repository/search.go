@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+)
+
+// searchSource describes how one holding table is searched. document must
+// match the expression of the table's GIN index in createSearchIndexes.
+type searchSource struct {
+	holdingType string
+	table       string
+	document    string
+	title       string
+	subtitle    string
+	page        string
+}
+
+// searchSources are the holding tables covered by Search, in result order for
+// equal ranks
+var searchSources = []searchSource{
+	{
+		holdingType: models.HoldingTypeStock,
+		table:       "stock_holdings",
+		document:    "COALESCE(symbol, '') || ' ' || COALESCE(company_name, '')",
+		title:       "symbol",
+		subtitle:    "COALESCE(company_name, '')",
+		page:        "/stocks",
+	},
+	{
+		holdingType: models.HoldingTypeEquityGrant,
+		table:       "equity_grants",
+		document:    "COALESCE(company_symbol, '') || ' ' || COALESCE(grant_type, '')",
+		title:       "company_symbol",
+		subtitle:    "UPPER(grant_type)",
+		page:        "/equity",
+	},
+	{
+		holdingType: models.HoldingTypeRealEstate,
+		table:       "real_estate_properties",
+		document:    "COALESCE(property_name, '') || ' ' || COALESCE(street_address, '') || ' ' || COALESCE(city, '') || ' ' || COALESCE(state, '') || ' ' || COALESCE(zip_code, '') || ' ' || COALESCE(notes, '')",
+		title:       "property_name",
+		subtitle:    "CONCAT_WS(', ', NULLIF(street_address, ''), NULLIF(city, ''), NULLIF(state, ''))",
+		page:        "/real-estate",
+	},
+	{
+		holdingType: models.HoldingTypeCash,
+		table:       "cash_holdings",
+		document:    "COALESCE(institution_name, '') || ' ' || COALESCE(account_name, '') || ' ' || COALESCE(account_type, '') || ' ' || COALESCE(notes, '')",
+		title:       "account_name",
+		subtitle:    "institution_name",
+		page:        "/cash-holdings",
+	},
+	{
+		holdingType: models.HoldingTypeCrypto,
+		table:       "crypto_holdings",
+		document:    "COALESCE(crypto_symbol, '') || ' ' || COALESCE(institution_name, '') || ' ' || COALESCE(notes, '')",
+		title:       "crypto_symbol",
+		subtitle:    "institution_name",
+		page:        "/crypto-holdings",
+	},
+	{
+		holdingType: models.HoldingTypeOtherAsset,
+		table:       "miscellaneous_assets",
+		document:    "COALESCE(asset_name, '') || ' ' || COALESCE(description, '') || ' ' || COALESCE(notes, '')",
+		title:       "asset_name",
+		subtitle:    "COALESCE(description, '')",
+		page:        "/other-assets",
+	},
+}
+
+// searchTermPattern splits a query into the words the simple text search
+// configuration indexes
+var searchTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// IsSearchType reports whether holdingType is covered by Search
+func IsSearchType(holdingType string) bool {
+	for _, source := range searchSources {
+		if source.holdingType == holdingType {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchRepository provides full-text search across holdings
+type SearchRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewSearchRepository creates a new search repository
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db, dialect: database.DialectOf(db)}
+}
+
+// searchWords splits free text into the words to search for. Each matches as
+// a prefix, so results appear while a word is still being typed.
+func searchWords(text string) []string {
+	return searchTermPattern.FindAllString(strings.ToLower(text), -1)
+}
+
+// Search returns the holdings whose names, symbols, addresses, institutions,
+// descriptions or notes contain every word of text, best matches first. A
+// non-empty types restricts results to those holding types.
+func (r *SearchRepository) Search(text string, types []string, limit int) ([]models.SearchResult, error) {
+	words := searchWords(text)
+	if len(words) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	var parts []string
+	for _, source := range searchSources {
+		if len(wanted) > 0 && !wanted[source.holdingType] {
+			continue
+		}
+		match, rank := r.dialect.TextSearch(source.document, "$1")
+		parts = append(parts, fmt.Sprintf(`
+			SELECT '%s' AS type, id, %s AS title, %s AS subtitle, %s AS rank
+			FROM %s
+			WHERE %s
+		`, source.holdingType, source.title, source.subtitle, rank, source.table, match))
+	}
+	if len(parts) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	rows, err := r.db.Query(strings.Join(parts, " UNION ALL ")+" ORDER BY rank DESC, title LIMIT $2", r.dialect.TextSearchTerms(words), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search holdings: %w", err)
+	}
+	defer rows.Close()
+
+	pages := make(map[string]string, len(searchSources))
+	for _, source := range searchSources {
+		pages[source.holdingType] = source.page
+	}
+
+	results := make([]models.SearchResult, 0)
+	for rows.Next() {
+		var result models.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.Title, &result.Subtitle, &result.Rank); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Link = fmt.Sprintf("%s?id=%d", pages[result.Type], result.ID)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
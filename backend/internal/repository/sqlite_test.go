@@ -0,0 +1,700 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/encryption"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/services"
+
+	"github.com/shopspring/decimal"
+)
+
+// openSQLite migrates a SQLite database in a temporary directory and seeds it
+// with a holding of each kind
+func openSQLite(t *testing.T) (*database.DB, *Repositories) {
+	t.Helper()
+	db, err := database.Initialize(config.DatabaseConfig{
+		Driver:       config.DriverSQLite,
+		Path:         filepath.Join(t.TempDir(), "networth.db"),
+		MaxOpenConns: 4,
+	})
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	encryptor, err := encryption.NewFieldEncryptor("sqlite-repository-test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, seed := range []string{
+		`INSERT INTO accounts (account_name, account_type, institution) VALUES ('Brokerage', 'brokerage', 'Fidelity')`,
+		`INSERT INTO stock_holdings (account_id, symbol, company_name, institution_name, shares_owned, cost_basis, current_price, created_at)
+		 VALUES (1, 'AAPL', 'Apple Inc.', 'Fidelity', 10, 1500, 200, '2026-01-05 09:00:00'),
+		        (1, 'MSFT', 'Microsoft', 'Fidelity', 5, 1800, 400, '2026-02-05 09:00:00'),
+		        (1, 'VTI', 'Vanguard Total Market', 'Vanguard', 20, 4000, 250, '2026-03-05 09:00:00')`,
+		`INSERT INTO stock_prices (symbol, price, timestamp) VALUES ('AAPL', 180, '2026-01-10 16:00:00'), ('AAPL', 200, '2026-03-10 16:00:00')`,
+		`INSERT INTO cash_holdings (account_id, institution_name, account_name, account_type, current_balance)
+		 VALUES (1, 'Fidelity', 'Settlement', 'brokerage', 500), (1, 'Chase', 'Checking', 'checking', 3000)`,
+		`INSERT INTO crypto_holdings (account_id, institution_name, crypto_symbol, balance_tokens, updated_at)
+		 VALUES (1, 'Coinbase', 'BTC', 0.5, '2026-01-01 00:00:00'), (1, 'Coinbase', 'USDC', 1000, '2026-01-01 00:00:00')`,
+		`INSERT INTO crypto_prices (symbol, price_usd, last_updated)
+		 VALUES ('BTC', 50000, '2026-03-01 00:00:00'), ('BTC', 60000, '2026-03-02 00:00:00'), ('USDC', 1, '2026-03-02 00:00:00')`,
+		`INSERT INTO real_estate_properties (account_id, property_type, property_name, purchase_price, current_value, outstanding_mortgage, purchase_date, ownership_percentage)
+		 VALUES (1, 'single_family', 'Lake House', 300000, 400000, 100000, '2020-06-01', 50)`,
+		`INSERT INTO miscellaneous_assets (account_id, asset_name, current_value, amount_owed) VALUES (1, 'Car', 20000, 5000)`,
+	} {
+		if _, err := db.Exec(seed); err != nil {
+			t.Fatalf("seed: %v\n%s", err, seed)
+		}
+	}
+
+	repos := New(db.DB, encryptor)
+	repos.NetWorth.SetCoinClassification(models.NewCoinClassification([]string{"USDC"}))
+	return db, repos
+}
+
+func TestSQLiteNetWorth(t *testing.T) {
+	_, repos := openSQLite(t)
+	if _, err := repos.Liabilities.Create(models.LiabilityInput{
+		InstitutionName: "Chase", LiabilityName: "Sapphire", LiabilityType: "credit_card", CurrentBalance: decimal.NewFromInt(1200),
+	}); err != nil {
+		t.Fatalf("create liability: %v", err)
+	}
+
+	b, err := repos.NetWorth.Breakdown()
+	if err != nil {
+		t.Fatalf("Breakdown: %v", err)
+	}
+	for name, tt := range map[string]struct {
+		got  float64
+		want float64
+	}{
+		"stocks":      {b.StockHoldingsValue.InexactFloat64(), 2000 + 2000 + 5000 + 500},
+		"cash":        {b.CashHoldingsValue.InexactFloat64(), 3000},
+		"crypto":      {b.CryptoHoldingsValue.InexactFloat64(), 30000 + 1000},
+		"stablecoins": {b.StablecoinValue.InexactFloat64(), 1000},
+		"real estate": {b.RealEstateEquity.InexactFloat64(), 150000},
+		"other":       {b.OtherAssetsValue.InexactFloat64(), 15000},
+		"liabilities": {b.TotalLiabilities.InexactFloat64(), 1200},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+
+	top, err := repos.NetWorth.TopHoldings(2)
+	if err != nil {
+		t.Fatalf("TopHoldings: %v", err)
+	}
+	if len(top) != 2 || top[0].HoldingType != models.HoldingTypeRealEstate || top[1].Name != "BTC" {
+		t.Errorf("TopHoldings = %+v, want the lake house and BTC", top)
+	}
+	if top[1].UpdatedAt.Format(time.DateOnly) != "2026-03-02" {
+		t.Errorf("BTC updated at %v, want its latest price", top[1].UpdatedAt)
+	}
+
+	if _, err := repos.NetWorth.Tree(); err != nil {
+		t.Errorf("Tree: %v", err)
+	}
+	institutions, err := repos.NetWorth.Institutions()
+	if err != nil {
+		t.Fatalf("Institutions: %v", err)
+	}
+	if len(institutions) == 0 {
+		t.Error("Institutions returned nothing")
+	}
+}
+
+func TestSQLiteHoldings(t *testing.T) {
+	_, repos := openSQLite(t)
+
+	stocks, err := repos.Stocks.List()
+	if err != nil || len(stocks) != 3 {
+		t.Fatalf("Stocks.List = %d holdings, %v; want 3", len(stocks), err)
+	}
+	if _, err := repos.Cash.List(); err != nil {
+		t.Errorf("Cash.List: %v", err)
+	}
+	if _, err := repos.Crypto.List(); err != nil {
+		t.Errorf("Crypto.List: %v", err)
+	}
+	if _, err := repos.OtherAssets.List(nil); err != nil {
+		t.Errorf("OtherAssets.List: %v", err)
+	}
+
+	// In February only AAPL was held, at the January price
+	asOf, err := repos.Stocks.ListAsOf(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ListAsOf: %v", err)
+	}
+	if len(asOf) != 1 || asOf[0].Symbol != "AAPL" || asOf[0].CurrentPrice == nil || !asOf[0].CurrentPrice.Equal(decimal.NewFromInt(180)) {
+		t.Errorf("ListAsOf = %+v, want AAPL at 180", asOf)
+	}
+	if _, err := repos.Cash.ListAsOf(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("Cash.ListAsOf: %v", err)
+	}
+
+	result, err := repos.Stocks.BulkDelete(BulkDeleteFilter{IDs: []int{1, 99}, Fields: map[string]string{"institution_name": "fidelity"}})
+	if err != nil {
+		t.Fatalf("BulkDelete: %v", err)
+	}
+	if result.DeletedCount != 1 || result.NotFoundCount != 1 {
+		t.Errorf("BulkDelete deleted %d, missed %d; want 1 and 1", result.DeletedCount, result.NotFoundCount)
+	}
+	if deleted := result.Deleted[1]; deleted["symbol"] != "AAPL" || deleted["is_vested_equity"] != false {
+		t.Errorf("deleted row = %v, want AAPL as JSON", deleted)
+	}
+}
+
+func TestSQLiteLiabilityStatements(t *testing.T) {
+	_, repos := openSQLite(t)
+	id, err := repos.Liabilities.Create(models.LiabilityInput{
+		InstitutionName: "Chase", LiabilityName: "Sapphire", LiabilityType: "credit_card", CurrentBalance: decimal.NewFromInt(1200),
+	})
+	if err != nil {
+		t.Fatalf("create liability: %v", err)
+	}
+
+	balance, due := decimal.RequireFromString("950.25"), time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC)
+	applied, err := repos.Liabilities.CreateStatement(models.LiabilityStatement{LiabilityID: id, DocumentType: "pdf", Balance: &balance, DueDate: &due})
+	if err != nil {
+		t.Fatalf("CreateStatement: %v", err)
+	}
+	if err := repos.Liabilities.ApplyStatement(applied); err != nil {
+		t.Fatalf("ApplyStatement: %v", err)
+	}
+	if err := repos.Liabilities.ApplyStatement(applied); !errors.Is(err, ErrStatementReviewed) {
+		t.Errorf("second ApplyStatement = %v, want ErrStatementReviewed", err)
+	}
+	if err := repos.Liabilities.RejectStatement(99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RejectStatement(99) = %v, want ErrNotFound", err)
+	}
+
+	liability, err := repos.Liabilities.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !liability.CurrentBalance.Equal(balance) || liability.DueDate == nil || !liability.DueDate.Equal(due) {
+		t.Errorf("liability = %+v, want the statement's balance and due date", liability)
+	}
+
+	statements, err := repos.Liabilities.ListStatements(id)
+	if err != nil || len(statements) != 1 || statements[0].Status != models.StatementStatusApplied {
+		t.Errorf("ListStatements = %+v, %v; want one applied statement", statements, err)
+	}
+}
+
+func TestSQLiteTagsAndViews(t *testing.T) {
+	_, repos := openSQLite(t)
+	tagID, err := repos.Tags.Create(models.TagInput{Name: "Core"})
+	if err != nil {
+		t.Fatalf("create tag: %v", err)
+	}
+	refs := []models.HoldingRef{{HoldingType: models.HoldingTypeStock, HoldingID: 1}, {HoldingType: models.HoldingTypeStock, HoldingID: 3}}
+	if err := repos.Tags.Attach(tagID, refs); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	// Tagging again leaves the tags as they are
+	if err := repos.Tags.Attach(tagID, refs[:1]); err != nil {
+		t.Fatalf("Attach again: %v", err)
+	}
+
+	matcher, err := repos.Tags.HoldingMatcher(models.HoldingTypeStock, TagFilter{Include: []string{"core"}})
+	if err != nil {
+		t.Fatalf("HoldingMatcher: %v", err)
+	}
+	if !matcher.MatchesID(1) || matcher.MatchesID(2) || !matcher.MatchesID(3) {
+		t.Error("HoldingMatcher does not match the tagged holdings")
+	}
+
+	minValue := 1000.0
+	ids, err := repos.SavedViews.MatchingIDs(models.SavedView{
+		HoldingType: models.HoldingTypeStock,
+		Filters:     models.SavedViewFilters{Institution: "fidelity", Symbols: []string{"aapl", "vti"}, MinValue: &minValue, Tags: []string{"Core"}},
+	})
+	if err != nil {
+		t.Fatalf("MatchingIDs: %v", err)
+	}
+	if len(ids) != 1 || !ids[1] {
+		t.Errorf("MatchingIDs = %v, want holding 1", ids)
+	}
+
+	// Deleting a holding takes its tags with it
+	if err := repos.Stocks.Delete(3); err != nil {
+		t.Fatalf("delete stock: %v", err)
+	}
+	holdings, err := repos.Tags.ListHoldings(tagID)
+	if err != nil || len(holdings) != 1 {
+		t.Errorf("ListHoldings = %+v, %v; want one holding", holdings, err)
+	}
+}
+
+func TestSQLiteGoalsAndSearch(t *testing.T) {
+	_, repos := openSQLite(t)
+	goalID, err := repos.Goals.Create(models.GoalInput{Name: "Emergency fund", TargetAmount: decimal.NewFromInt(10000), CashHoldingIDs: []int{2, 1}}, nil)
+	if err != nil {
+		t.Fatalf("create goal: %v", err)
+	}
+	goal, err := repos.Goals.Get(goalID)
+	if err != nil {
+		t.Fatalf("get goal: %v", err)
+	}
+	if len(goal.CashHoldingIDs) != 2 || goal.CashHoldingIDs[0] != 1 {
+		t.Errorf("CashHoldingIDs = %v, want [1 2]", goal.CashHoldingIDs)
+	}
+
+	results, err := repos.Search.Search("micro", nil, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Type != models.HoldingTypeStock || results[0].ID != 2 {
+		t.Errorf("Search(micro) = %+v, want MSFT", results)
+	}
+	if results, err := repos.Search.Search("lake hou", nil, 10); err != nil || len(results) != 1 {
+		t.Errorf("Search(lake hou) = %+v, %v; want the lake house", results, err)
+	}
+}
+
+func TestSQLiteCashFlow(t *testing.T) {
+	_, repos := openSQLite(t)
+	day := func(month, d int) time.Time { return time.Date(2026, time.Month(month), d, 0, 0, 0, 0, time.UTC) }
+	rows := []models.CashFlowImportRow{
+		{Date: day(1, 15), Amount: decimal.NewFromInt(5000), Kind: "income", CategoryName: "Salary", ImportKey: "a"},
+		{Date: day(1, 20), Amount: decimal.NewFromInt(1500), Kind: "expense", CategoryName: "Rent", ImportKey: "b"},
+		{Date: day(2, 15), Amount: decimal.NewFromInt(5000), Kind: "income", CategoryName: "Salary", ImportKey: "c"},
+	}
+	result, err := repos.CashFlow.Import(rows)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Imported != 3 {
+		t.Errorf("imported %d rows, want 3", result.Imported)
+	}
+	// Importing the same file again adds nothing
+	if result, err := repos.CashFlow.Import(rows); err != nil || result.Duplicates != 3 {
+		t.Errorf("second Import = %+v, %v; want 3 duplicates", result, err)
+	}
+
+	summary, err := repos.CashFlow.Summary(day(1, 1), day(3, 1))
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if !summary.TotalIncome.Equal(decimal.NewFromInt(10000)) || !summary.TotalExpenses.Equal(decimal.NewFromInt(1500)) ||
+		len(summary.Months) != 2 || !summary.Months[1].Income.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("Summary = %+v, want 10000 income over two months", summary)
+	}
+
+	property, err := repos.RealEstate.Get(1)
+	if err != nil {
+		t.Fatalf("get property: %v", err)
+	}
+	if property.PurchaseDate != "2020-06-01" {
+		t.Errorf("PurchaseDate = %q, want 2020-06-01", property.PurchaseDate)
+	}
+	for _, entry := range []models.PropertyLedgerEntryInput{
+		{PropertyID: 1, Category: "rent", Amount: decimal.NewFromInt(2000)},
+		{PropertyID: 1, Category: "repairs", Amount: decimal.NewFromInt(300)},
+	} {
+		if _, err := repos.PropertyLedger.Create(entry, day(2, 1)); err != nil {
+			t.Fatalf("create ledger entry: %v", err)
+		}
+	}
+	flow, err := repos.PropertyLedger.CashFlow(*property, day(1, 1), day(2, 28))
+	if err != nil {
+		t.Fatalf("CashFlow: %v", err)
+	}
+	if len(flow.Months) != 2 || !flow.Months[1].Income.Equal(decimal.NewFromInt(2000)) || !flow.Months[1].OperatingExpenses.Equal(decimal.NewFromInt(300)) {
+		t.Errorf("CashFlow months = %+v, want February's rent and repairs", flow.Months)
+	}
+	// The lake house is half owned
+	if !flow.CashFlow.Equal(decimal.NewFromInt(1700)) || !flow.OwnedCashFlow.Equal(decimal.NewFromInt(850)) ||
+		flow.OwnedCashInvested == nil || !flow.OwnedCashInvested.Equal(decimal.NewFromInt(100000)) {
+		t.Errorf("CashFlow = %s, owned %s of %v invested; want 1700, owned 850 of 100000", flow.CashFlow, flow.OwnedCashFlow, flow.OwnedCashInvested)
+	}
+}
+
+func TestSQLiteMerge(t *testing.T) {
+	db, repos := openSQLite(t)
+	if _, err := db.Exec(`
+		INSERT INTO accounts (account_name, account_type, institution) VALUES ('Old Brokerage', 'brokerage', 'Schwab');
+		INSERT INTO stock_holdings (account_id, symbol, institution_name, shares_owned, cost_basis, current_price)
+		VALUES (1, 'AAPL', 'Schwab', 5, 120, 200);
+		INSERT INTO liabilities (account_id, institution_name, liability_name, liability_type, current_balance)
+		VALUES ((SELECT id FROM accounts WHERE account_name = 'Old Brokerage'), 'Schwab', 'Margin Loan', 'loan', 2500)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repos.Merges.MergeHoldings(models.HoldingTypeStock, 1, 4); err != nil {
+		t.Fatalf("MergeHoldings: %v", err)
+	}
+	var shares float64
+	if err := db.QueryRow(`SELECT shares_owned FROM stock_holdings WHERE id = 1`).Scan(&shares); err != nil {
+		t.Fatal(err)
+	}
+	if shares != 15 {
+		t.Errorf("merged shares = %v, want 15", shares)
+	}
+	if err := repos.Merges.MergeHoldings(models.HoldingTypeStock, 1, 2); !errors.Is(err, ErrInvalidMerge) {
+		t.Errorf("merging AAPL and MSFT = %v, want ErrInvalidMerge", err)
+	}
+
+	var oldAccount int
+	if err := db.QueryRow(`SELECT id FROM accounts WHERE account_name = 'Old Brokerage'`).Scan(&oldAccount); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Merges.MergeAccounts(1, oldAccount); err != nil {
+		t.Fatalf("MergeAccounts: %v", err)
+	}
+	var accounts int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM accounts WHERE id = $1`, oldAccount).Scan(&accounts); err != nil || accounts != 0 {
+		t.Errorf("merged account left behind: %d, %v", accounts, err)
+	}
+	var liabilityAccount int
+	if err := db.QueryRow(`SELECT account_id FROM liabilities WHERE liability_name = 'Margin Loan'`).Scan(&liabilityAccount); err != nil || liabilityAccount != 1 {
+		t.Errorf("merged liability is in account %d, want 1 (%v)", liabilityAccount, err)
+	}
+}
+
+func TestSQLiteAccountTables(t *testing.T) {
+	db, _ := openSQLite(t)
+
+	// Every table referencing accounts is moved by merges and counted as a use
+	rows, err := db.Query(`
+		SELECT m.name FROM sqlite_schema m, pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND f."table" = 'accounts'
+		ORDER BY m.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(accountTables, table) {
+			t.Errorf("accountTables is missing %s", table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteAccounts(t *testing.T) {
+	db, repos := openSQLite(t)
+
+	id, err := repos.Accounts.Create(models.AccountInput{AccountName: "Joint", AccountType: "brokerage", Institution: "Fidelity"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	refs := []models.HoldingRef{{HoldingType: models.HoldingTypeStock, HoldingID: 1}, {HoldingType: models.HoldingTypeStock, HoldingID: 2}}
+	if _, err := repos.Accounts.Link(id, refs); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	vanguard := []models.HoldingRef{{HoldingType: models.HoldingTypeStock, HoldingID: 3}}
+	if _, err := repos.Accounts.Link(id, vanguard); !errors.Is(err, ErrInstitutionMismatch) {
+		t.Errorf("linking a Vanguard holding = %v, want ErrInstitutionMismatch", err)
+	}
+
+	account, err := repos.Accounts.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if account.HoldingCount != 2 {
+		t.Errorf("holdings = %d, want 2", account.HoldingCount)
+	}
+	if err := repos.Accounts.Delete(id); !errors.Is(err, ErrAccountInUse) {
+		t.Errorf("deleting an account with holdings = %v, want ErrAccountInUse", err)
+	}
+
+	// A child's account names their household member and is custodial
+	missing := 99
+	child := models.AccountInput{AccountName: "UTMA", AccountType: "brokerage", Institution: "Vanguard", Owner: models.AccountOwnerChild, OwnerMemberID: &missing}
+	if _, err := repos.Accounts.Create(child); !errors.Is(err, ErrUnknownMember) {
+		t.Errorf("creating an account for an unknown member = %v, want ErrUnknownMember", err)
+	}
+	var member int
+	if err := db.QueryRow(`INSERT INTO household_members (name) VALUES ('Emma') RETURNING id`).Scan(&member); err != nil {
+		t.Fatal(err)
+	}
+	child.OwnerMemberID = &member
+	utma, err := repos.Accounts.Create(child)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repos.Accounts.Link(utma, vanguard); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	owners, err := repos.NetWorth.OwnerBreakdowns()
+	if err != nil {
+		t.Fatalf("OwnerBreakdowns: %v", err)
+	}
+	last := owners[len(owners)-1]
+	if last.Owner != "Emma" || !last.Custodial || !last.Breakdown.StockHoldingsValue.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("last owner = %s (custodial %v) with stocks %s, want Emma's custodial 5000", last.Owner, last.Custodial, last.Breakdown.StockHoldingsValue)
+	}
+}
+
+func TestSQLiteLinkKeepsAccountWithLiability(t *testing.T) {
+	db, repos := openSQLite(t)
+	var manual int
+	if err := db.QueryRow(`
+		INSERT INTO accounts (account_name, account_type, institution, data_source_type)
+		VALUES ('Stock Holdings - NVDA at Fidelity', 'manual', 'Fidelity', 'manual')
+		RETURNING id`).Scan(&manual); err != nil {
+		t.Fatal(err)
+	}
+	var holding int
+	if err := db.QueryRow(`
+		INSERT INTO stock_holdings (account_id, symbol, institution_name, shares_owned, cost_basis, current_price)
+		VALUES ($1, 'NVDA', 'Fidelity', 4, 400, 120)
+		RETURNING id`, manual).Scan(&holding); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO liabilities (account_id, institution_name, liability_name, liability_type, current_balance)
+		VALUES ($1, 'Fidelity', 'Margin Loan', 'loan', 800)`, manual); err != nil {
+		t.Fatal(err)
+	}
+
+	// The manual account keeps its liability, so linking its last holding
+	// elsewhere leaves it in place rather than failing to delete it
+	target, err := repos.Accounts.Create(models.AccountInput{AccountName: "Joint", AccountType: "brokerage", Institution: "Fidelity"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	removed, err := repos.Accounts.Link(target, []models.HoldingRef{{HoldingType: models.HoldingTypeStock, HoldingID: holding}})
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("removed %d accounts, want 0", removed)
+	}
+	var accountID int
+	if err := db.QueryRow(`SELECT account_id FROM stock_holdings WHERE id = $1`, holding).Scan(&accountID); err != nil || accountID != target {
+		t.Errorf("NVDA is in account %d, want %d (%v)", accountID, target, err)
+	}
+	if _, err := repos.Accounts.Get(manual); err != nil {
+		t.Errorf("Get(%d) after linking its last holding: %v", manual, err)
+	}
+}
+
+func TestSQLiteManualEntriesAndPrices(t *testing.T) {
+	db, repos := openSQLite(t)
+	if _, err := db.Exec(`UPDATE crypto_holdings SET staked_tokens = 0.1, staking_annual_percentage = 12 WHERE crypto_symbol = 'BTC'`); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := repos.ManualEntries.List("real_estate")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0].DataJSON, `"purchase_date":"2020-06-01"`) {
+		t.Errorf("real estate entries = %+v", entries)
+	}
+	if all, err := repos.ManualEntries.List(""); err != nil || len(all) < 7 {
+		t.Errorf("List all = %d entries, %v", len(all), err)
+	}
+
+	positions, err := repos.Stocks.Positions(context.Background(), []string{"AAPL", "TSLA"})
+	if err != nil {
+		t.Fatalf("Positions: %v", err)
+	}
+	if p, ok := positions["AAPL"]; !ok || p.Value != 2000 || !p.Priced || len(positions) != 1 {
+		t.Errorf("positions = %+v", positions)
+	}
+
+	cachedAt, err := repos.Stocks.LatestPriceTimes(context.Background(), []string{"AAPL", "MSFT"})
+	if err != nil {
+		t.Fatalf("LatestPriceTimes: %v", err)
+	}
+	if want := time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC); !cachedAt["AAPL"].Equal(want) || len(cachedAt) != 1 {
+		t.Errorf("cached at = %v, want AAPL at %v", cachedAt, want)
+	}
+
+	income, err := repos.NetWorth.PassiveIncome()
+	if err != nil {
+		t.Fatalf("PassiveIncome: %v", err)
+	}
+	// Staking is valued at the latest BTC price
+	if income.CryptoStaking != 60 {
+		t.Errorf("passive income = %+v", income)
+	}
+}
+
+func TestSQLiteBondValues(t *testing.T) {
+	db, repos := openSQLite(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	maturity := today.AddDate(5, 1, 0)
+	if _, err := db.Exec(`
+		INSERT INTO bonds (institution_name, bond_name, face_value, coupon_rate, coupon_frequency, maturity_date, purchase_price)
+		VALUES ('Fidelity', 'Treasury', 10000, 4.25, 2, $1, 98.5)`, maturity); err != nil {
+		t.Fatal(err)
+	}
+
+	// Breakdown values the bond with Bond.Value
+	b, err := repos.NetWorth.Breakdown()
+	if err != nil {
+		t.Fatalf("Breakdown: %v", err)
+	}
+	interest := models.BondAccruedInterest(decimal.NewFromInt(10000), 4.25, 2, maturity, today)
+	want := decimal.NewFromInt(9850).Add(interest)
+	if got := b.FixedIncomeValue; !got.Equal(want) || interest.IsZero() {
+		t.Errorf("fixed income = %v, want %v", got, want)
+	}
+}
+
+func TestSQLiteIBondValues(t *testing.T) {
+	db, repos := openSQLite(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	issue := time.Date(today.Year()-3, today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, stmt := range []string{
+		`DELETE FROM i_bond_rates`,
+		`INSERT INTO i_bond_rates (effective_date, fixed_rate, inflation_rate) VALUES ('2017-05-01', 0.5, 1.5)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`
+		INSERT INTO i_bonds (issue_date, purchase_amount, fixed_rate) VALUES ($1, 10000, 0.5)`, issue); err != nil {
+		t.Fatal(err)
+	}
+
+	// Held under five years, the bond counts at its value three months ago
+	b, err := repos.NetWorth.Breakdown()
+	if err != nil {
+		t.Fatalf("Breakdown: %v", err)
+	}
+	threeMonthsAgo := time.Date(today.Year(), today.Month()-3, 1, 0, 0, 0, 0, time.UTC)
+	rates := models.IBondRates{{EffectiveDate: time.Date(2017, time.May, 1, 0, 0, 0, 0, time.UTC), InflationRate: 1.5}}
+	want := models.IBondValue(decimal.NewFromInt(10000), issue, 0.5, threeMonthsAgo, rates)
+	if got := b.FixedIncomeValue; !got.Equal(want) || want.LessThanOrEqual(decimal.NewFromInt(10000)) {
+		t.Errorf("fixed income = %v, want %v", got, want)
+	}
+
+	top, err := repos.NetWorth.TopHoldings(20)
+	if err != nil {
+		t.Fatalf("TopHoldings: %v", err)
+	}
+	name := "I Bond " + issue.Format("Jan 2006")
+	found := false
+	for _, v := range top {
+		found = found || (v.HoldingType == models.HoldingTypeIBond && v.Name == name)
+	}
+	if !found {
+		t.Errorf("TopHoldings has no %q: %+v", name, top)
+	}
+}
+
+func TestSQLitePensionValues(t *testing.T) {
+	db, repos := openSQLite(t)
+	if _, err := db.Exec(`
+		INSERT INTO pensions (institution_name, plan_name, birth_date, monthly_benefit, start_age, end_age, cola_rate, include_in_net_worth)
+		VALUES ('CalPERS', 'Pension', '1960-01-31', 2000, 65.5, 90, 2, true),
+		       ('Insurer', 'Annuity', '1980-05-15', 500, 70, 85, 0, false)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the included pension counts, at its present value of
+	// payments starting on the last day of July 2025
+	b, err := repos.NetWorth.Breakdown()
+	if err != nil {
+		t.Fatalf("Breakdown: %v", err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := time.Date(2025, time.July, 31, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2050, time.January, 31, 0, 0, 0, 0, time.UTC)
+	want := models.PensionPresentValue(2000, start, end, 2, 4, today)
+	if got := b.PensionsValue.InexactFloat64(); got != want || want == 0 {
+		t.Errorf("pensions = %v, want %v", got, want)
+	}
+}
+
+func TestSQLiteInsuranceRenewals(t *testing.T) {
+	db, repos := openSQLite(t)
+	if _, err := db.Exec(`
+		INSERT INTO insurance_policies (institution_name, policy_name, policy_type, premium_amount, cash_value, loan_balance, renewal_date, term_months)
+		VALUES ('Northwestern Mutual', 'Whole Life', 'whole_life', 450, 42000, 2000, '2026-03-10', 12),
+		       ('Geico', 'Auto', 'auto', 120, 0, 0, '2026-06-01', 6)`); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := repos.NetWorth.Breakdown()
+	if err != nil {
+		t.Fatalf("Breakdown: %v", err)
+	}
+	if got := b.InsuranceCashValue.InexactFloat64(); got != 40000 {
+		t.Errorf("insurance cash value = %v, want 40000", got)
+	}
+
+	// Only the policy renewing within its notice period is alerted on, once
+	notifications := services.NewNotificationService(db.DB)
+	insurance := services.NewInsuranceService(db.DB, notifications)
+	now := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := insurance.Check(now); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	var alerts, notified int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM insurance_renewal_alerts`).Scan(&alerts); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE type = 'insurance_renewal'`).Scan(&notified); err != nil {
+		t.Fatal(err)
+	}
+	if alerts != 1 || notified != 1 {
+		t.Errorf("alerts = %d, notifications = %d, want 1 and 1", alerts, notified)
+	}
+}
+
+func TestSQLiteUpdateTimes(t *testing.T) {
+	db, repos := openSQLite(t)
+	for name, updateTimes := range map[string]func() ([]*time.Time, error){
+		"properties":   repos.RealEstate.ValuationUpdateTimes,
+		"other assets": repos.OtherAssets.ValuationUpdateTimes,
+	} {
+		times, err := updateTimes()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// The fixture's one property and one car were just inserted
+		if len(times) != 1 || times[0] == nil {
+			t.Errorf("%s update times = %v, want one", name, times)
+		}
+	}
+
+	// BTC in a second wallet counts once; ETH has never been priced
+	if _, err := db.Exec(`INSERT INTO crypto_holdings (account_id, institution_name, crypto_symbol, balance_tokens)
+		VALUES (1, 'Ledger', 'BTC', 0.1), (1, 'Ledger', 'ETH', 2)`); err != nil {
+		t.Fatal(err)
+	}
+	times, err := repos.Crypto.PriceUpdateTimes()
+	if err != nil {
+		t.Fatalf("crypto: %v", err)
+	}
+	var priced, never int
+	for _, updated := range times {
+		if updated == nil {
+			never++
+		} else if updated.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+			priced++
+		}
+	}
+	if len(times) != 3 || priced != 2 || never != 1 {
+		t.Errorf("crypto update times = %v, want BTC and USDC on March 2 and ETH never", times)
+	}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/dbtest"
+	"networth-dashboard/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// benchParseCost stands in for PostgreSQL parsing and planning a statement.
+// Check it against the planning time EXPLAIN (ANALYZE, SUMMARY) reports for
+// the query on a real database before reading much into the results.
+const benchParseCost = 100 * time.Microsecond
+
+// cachedPriceQuery is the lookup the price service makes before asking a provider
+const cachedPriceQuery = `
+	SELECT price, timestamp
+	FROM stock_prices
+	WHERE symbol = $1
+	ORDER BY timestamp DESC
+	LIMIT 1
+`
+
+// statementCacheResult answers the cached price lookup with one price, the
+// valuations made in Go with none and the holding values with benchHoldings holdings
+func statementCacheResult(query string) dbtest.Result {
+	if result, ok := goValuationResult(query); ok {
+		return result
+	}
+	if strings.Contains(query, "FROM stock_prices") {
+		return dbtest.Result{
+			Columns: []string{"price", "timestamp"},
+			Rows:    [][]driver.Value{{187.42, time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC)}},
+		}
+	}
+	return holdingRowsResult(benchHoldings)
+}
+
+// BenchmarkStatementCache runs the hot queries through pgx with its statement
+// cache, which parses each query once per connection, and with the modes that
+// parse it on every call: describe_exec describes it first, an extra round
+// trip, and exec sends it with its arguments in one.
+func BenchmarkStatementCache(b *testing.B) {
+	server, err := dbtest.NewServer(benchRoundTrip, benchParseCost, statementCacheResult)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer server.Close()
+
+	modes := []struct {
+		name string
+		mode pgx.QueryExecMode
+	}{
+		{"cache_statement", pgx.QueryExecModeCacheStatement},
+		{"describe_exec", pgx.QueryExecModeDescribeExec},
+		{"exec", pgx.QueryExecModeExec},
+	}
+	for _, m := range modes {
+		config, err := pgx.ParseConfig(server.URL())
+		if err != nil {
+			b.Fatal(err)
+		}
+		config.DefaultQueryExecMode = m.mode
+		db := stdlib.OpenDB(*config)
+		db.SetMaxOpenConns(1)
+		if err := db.Ping(); err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run("price/"+m.name, func(b *testing.B) {
+			benchmarkParses(b, server, func() error {
+				var price float64
+				var timestamp time.Time
+				return db.QueryRow(cachedPriceQuery, "AAPL").Scan(&price, &timestamp)
+			})
+		})
+
+		repo := NewNetWorthRepository(db)
+		repo.SetCoinClassification(models.NewCoinClassification([]string{"USDC"}))
+		b.Run("net_worth/"+m.name, func(b *testing.B) {
+			benchmarkParses(b, server, func() error {
+				_, err := repo.Breakdown()
+				return err
+			})
+		})
+		db.Close()
+	}
+}
+
+// benchmarkParses times query and reports how many statements the server
+// parsed for each run
+func benchmarkParses(b *testing.B, server *dbtest.Server, query func() error) {
+	b.ReportAllocs()
+	parses := server.Parses()
+	for range b.N {
+		if err := query(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(server.Parses()-parses)/float64(b.N), "parses/op")
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+)
+
+// ActiveSymbols returns every symbol held in stock holdings or equity grants,
+// upper-cased and in alphabetical order
+func (r *StockRepository) ActiveSymbols() ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT UPPER(TRIM(symbol)) FROM stock_holdings WHERE TRIM(COALESCE(symbol, '')) != ''
+		UNION
+		SELECT UPPER(TRIM(company_symbol)) FROM equity_grants WHERE TRIM(COALESCE(company_symbol, '')) != ''
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active symbols: %w", err)
+	}
+	defer rows.Close()
+
+	symbols := []string{}
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+// Positions returns the market value held in each of symbols across stock
+// holdings and vested and unvested grants. Symbols held nowhere are left out.
+func (r *StockRepository) Positions(ctx context.Context, symbols []string) (map[string]models.SymbolPosition, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT symbol, SUM(value), MAX(CASE WHEN priced THEN 1 ELSE 0 END) = 1
+		FROM (
+			SELECT UPPER(symbol) AS symbol, shares_owned * COALESCE(current_price, 0) AS value,
+			       COALESCE(current_price, 0) > 0 AS priced
+			FROM stock_holdings
+
+			UNION ALL
+
+			SELECT UPPER(company_symbol),
+			       (COALESCE(vested_shares, 0) + COALESCE(unvested_shares, 0)) * COALESCE(current_price, 0),
+			       COALESCE(current_price, 0) > 0
+			FROM equity_grants
+		) positions
+		WHERE `+database.DialectOf(r.db).InArray("symbol", "$1")+`
+		GROUP BY symbol
+	`, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to value positions: %w", err)
+	}
+	defer rows.Close()
+
+	positions := make(map[string]models.SymbolPosition, len(symbols))
+	for rows.Next() {
+		var symbol string
+		var p models.SymbolPosition
+		if err := rows.Scan(&symbol, &p.Value, &p.Priced); err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		positions[symbol] = p
+	}
+	return positions, rows.Err()
+}
+
+// LatestPriceTimes returns when a price was last cached for each of symbols.
+// Symbols never priced are left out.
+func (r *StockRepository) LatestPriceTimes(ctx context.Context, symbols []string) (map[string]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT symbol, MAX(timestamp)
+		FROM stock_prices
+		WHERE `+database.DialectOf(r.db).InArray("symbol", "$1")+`
+		GROUP BY symbol
+	`, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cached prices: %w", err)
+	}
+	defer rows.Close()
+
+	cachedAt := make(map[string]time.Time, len(symbols))
+	for rows.Next() {
+		var symbol string
+		var timestamp time.Time
+		if err := rows.Scan(&symbol, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan cached price: %w", err)
+		}
+		cachedAt[symbol] = timestamp
+	}
+	return cachedAt, rows.Err()
+}
+
+// PriceSnapshot returns the price symbol's stock holdings carry and when a
+// price for it was last cached. Both are nil for a symbol no stock holding has.
+func (r *StockRepository) PriceSnapshot(ctx context.Context, symbol string) (models.SymbolPriceSnapshot, error) {
+	var snapshot models.SymbolPriceSnapshot
+	err := r.db.QueryRowContext(ctx, `
+		SELECT h.current_price, sp.timestamp
+		FROM stock_holdings h
+		LEFT JOIN (
+			SELECT symbol, timestamp
+			FROM stock_prices
+			WHERE symbol = $1
+			ORDER BY timestamp DESC
+			LIMIT 1
+		) sp ON sp.symbol = h.symbol
+		WHERE h.symbol = $1
+		LIMIT 1
+	`, symbol).Scan(&snapshot.HoldingPrice, &snapshot.CachedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.SymbolPriceSnapshot{}, fmt.Errorf("failed to get the price of %s: %w", symbol, err)
+	}
+	return snapshot, nil
+}
+
+// ApplyPrice sets price on every stock holding and equity grant in symbol in
+// one transaction and returns how many were updated. Nothing is committed
+// when none were.
+func (r *StockRepository) ApplyPrice(symbol string, price float64) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var updated int64
+	for _, query := range []string{
+		`UPDATE stock_holdings SET current_price = $1, last_updated = $2 WHERE symbol = $3`,
+		`UPDATE equity_grants SET current_price = $1, last_updated = $2 WHERE company_symbol = $3`,
+	} {
+		result, err := tx.Exec(query, price, now, symbol)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update the price of %s: %w", symbol, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to check affected rows: %w", err)
+		}
+		updated += rows
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+// PriceCoverage counts the held symbols and those without a price, and finds
+// when any stock price was last cached (zero when none was)
+func (r *StockRepository) PriceCoverage() (models.StockPriceCoverage, error) {
+	var coverage models.StockPriceCoverage
+	var lastCached sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(DISTINCT symbol) FROM (
+			    SELECT symbol FROM stock_holdings
+			    WHERE current_price = 0 OR current_price IS NULL
+			    UNION
+			    SELECT company_symbol FROM equity_grants
+			    WHERE current_price = 0 OR current_price IS NULL
+			) AS stale_symbols),
+			(SELECT COUNT(DISTINCT symbol) FROM (
+			    SELECT symbol FROM stock_holdings
+			    UNION
+			    SELECT company_symbol FROM equity_grants
+			) AS all_symbols),
+			(SELECT MAX(timestamp) FROM stock_prices)
+	`).Scan(&coverage.Stale, &coverage.Total, &lastCached)
+	if err != nil {
+		return models.StockPriceCoverage{}, fmt.Errorf("failed to check stock price coverage: %w", err)
+	}
+	if lastCached.Valid {
+		coverage.LastCachedAt = lastCached.Time
+	}
+	return coverage, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// arrayConverter passes slices through to sqlmock the way pgx takes them as arrays
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	switch v.(type) {
+	case []string, []float64, []time.Time:
+		return v, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestStockActiveSymbols(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT UPPER\(TRIM\(symbol\)\) FROM stock_holdings`).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL").AddRow("MSFT"))
+
+	symbols, err := NewStockRepository(db).ActiveSymbols()
+	if err != nil {
+		t.Fatalf("ActiveSymbols: %v", err)
+	}
+	if want := []string{"AAPL", "MSFT"}; !reflect.DeepEqual(symbols, want) {
+		t.Errorf("ActiveSymbols = %v, want %v", symbols, want)
+	}
+}
+
+func TestStockPositions(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	symbols := []string{"AAPL", "NEW"}
+	mock.ExpectQuery(`WHERE symbol = ANY\(\$1\)`).WithArgs(symbols).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "value", "priced"}).AddRow("AAPL", 1905.0, true))
+
+	positions, err := NewStockRepository(db).Positions(context.Background(), symbols)
+	if err != nil {
+		t.Fatalf("Positions: %v", err)
+	}
+	if p, ok := positions["AAPL"]; !ok || p.Value != 1905 || !p.Priced {
+		t.Errorf("Positions[AAPL] = %+v, %v", p, ok)
+	}
+	if _, ok := positions["NEW"]; ok {
+		t.Error("Positions has a symbol held nowhere")
+	}
+}
+
+func TestStockPriceSnapshotNoHolding(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM stock_holdings h`).WithArgs("GOOG").
+		WillReturnRows(sqlmock.NewRows([]string{"current_price", "timestamp"}))
+
+	snapshot, err := NewStockRepository(db).PriceSnapshot(context.Background(), "GOOG")
+	if err != nil {
+		t.Fatalf("PriceSnapshot: %v", err)
+	}
+	if snapshot.HoldingPrice != nil || snapshot.CachedAt != nil {
+		t.Errorf("PriceSnapshot = %+v, want empty", snapshot)
+	}
+}
+
+func TestStockApplyPrice(t *testing.T) {
+	tests := []struct {
+		name           string
+		holdings       int64
+		grants         int64
+		grantErr       error
+		want           int64
+		wantErr        bool
+		wantCommit     bool
+		wantGrantsExec bool
+	}{
+		{name: "updates both", holdings: 2, grants: 1, want: 3, wantCommit: true, wantGrantsExec: true},
+		{name: "nothing held", wantGrantsExec: true},
+		{name: "grants fail", holdings: 2, grantErr: errors.New("boom"), wantErr: true, wantGrantsExec: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE stock_holdings SET current_price = \$1`).
+				WithArgs(190.5, sqlmock.AnyArg(), "AAPL").
+				WillReturnResult(sqlmock.NewResult(0, tt.holdings))
+			grants := mock.ExpectExec(`UPDATE equity_grants SET current_price = \$1`).
+				WithArgs(190.5, sqlmock.AnyArg(), "AAPL")
+			if tt.grantErr != nil {
+				grants.WillReturnError(tt.grantErr)
+			} else {
+				grants.WillReturnResult(sqlmock.NewResult(0, tt.grants))
+			}
+			if tt.wantCommit {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			updated, err := NewStockRepository(db).ApplyPrice("AAPL", 190.5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyPrice error = %v, want error %v", err, tt.wantErr)
+			}
+			if updated != tt.want {
+				t.Errorf("ApplyPrice = %d, want %d", updated, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestStockPriceCoverageWithoutPrices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT MAX\(timestamp\) FROM stock_prices`).
+		WillReturnRows(sqlmock.NewRows([]string{"stale", "total", "last"}).AddRow(2, 5, nil))
+
+	coverage, err := NewStockRepository(db).PriceCoverage()
+	if err != nil {
+		t.Fatalf("PriceCoverage: %v", err)
+	}
+	if coverage.Stale != 2 || coverage.Total != 5 || !coverage.LastCachedAt.IsZero() {
+		t.Errorf("PriceCoverage = %+v, want 2 of 5 stale and no cache time", coverage)
+	}
+}
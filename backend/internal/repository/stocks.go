@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"networth-dashboard/internal/models"
+)
+
+// StockRepository provides access to stock holdings
+type StockRepository struct {
+	db *sql.DB
+}
+
+// NewStockRepository creates a new stock repository
+func NewStockRepository(db *sql.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// List returns all stock holdings ordered by institution and symbol
+func (r *StockRepository) List() ([]models.StockHolding, error) {
+	query := `
+		SELECT h.id, h.account_id, h.symbol, h.company_name, h.shares_owned, 
+		       h.cost_basis, h.current_price, h.institution_name, h.data_source, h.created_at,
+		       COALESCE(h.shares_owned * h.current_price, 0) as market_value,
+		       h.estimated_quarterly_dividend, h.purchase_date, h.drip_enabled, h.last_manual_update,
+		       COALESCE(h.is_vested_equity, false) as is_vested_equity,
+		       h.exchange, h.security_type
+		FROM stock_holdings h
+		ORDER BY h.institution_name, h.symbol
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stock holdings: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := make([]models.StockHolding, 0)
+	for rows.Next() {
+		var h models.StockHolding
+		err := rows.Scan(
+			&h.ID, &h.AccountID, &h.Symbol, &h.CompanyName,
+			&h.SharesOwned, &h.CostBasis, &h.CurrentPrice,
+			&h.InstitutionName, &h.DataSource, &h.CreatedAt, &h.MarketValue,
+			&h.EstimatedQuarterlyDividend, &h.PurchaseDate, &h.DripEnabled, &h.LastManualUpdate,
+			&h.IsVestedEquity, &h.Exchange, &h.SecurityType,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock holding: %w", err)
+		}
+		holdings = append(holdings, h)
+	}
+
+	return holdings, rows.Err()
+}
+
+// ListConsolidated returns positions per symbol combining direct holdings and
+// vested equity grants, each with its contributing sources
+func (r *StockRepository) ListConsolidated() ([]models.StockConsolidation, error) {
+	query := `
+		WITH combined_holdings AS (
+			-- Direct stock holdings
+			SELECT symbol, 
+			       company_name,
+			       shares_owned, 
+			       cost_basis, 
+			       current_price, 
+			       'direct_stock' as source_type,
+			       data_source
+			FROM stock_holdings 
+			WHERE shares_owned > 0
+			
+			UNION ALL
+			
+			-- Vested equity compensation
+			SELECT company_symbol as symbol,
+			       NULL as company_name,  -- Named from the symbol lookup below
+			       vested_shares as shares_owned,
+			       CASE 
+			           WHEN grant_type IN ('iso', 'nso') THEN COALESCE(strike_price, 0)
+			           ELSE COALESCE(current_price, 0) -- For RSUs/ESPP, cost basis is current price at vest
+			       END as cost_basis,
+			       current_price,
+			       CONCAT('equity_', grant_type) as source_type,
+			       data_source
+			FROM equity_grants 
+			WHERE vested_shares > 0
+		)
+		SELECT combined_holdings.symbol, 
+		       COALESCE(MAX(NULLIF(company_name, '')), MAX(sl.name), combined_holdings.symbol) as company_name,
+		       SUM(shares_owned) as total_shares,
+		       COALESCE(AVG(NULLIF(current_price, 0)), 0) as current_price,
+		       SUM(shares_owned * COALESCE(current_price, 0)) as total_value,
+		       COALESCE(
+		           SUM(shares_owned * COALESCE(current_price, 0)) - 
+		           SUM(shares_owned * COALESCE(cost_basis, 0)), 
+		           0
+		       ) as unrealized_gains
+		FROM combined_holdings
+		LEFT JOIN symbol_lookups sl ON sl.symbol = UPPER(combined_holdings.symbol) AND sl.found
+		GROUP BY combined_holdings.symbol
+		ORDER BY total_value DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch consolidated stocks: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := make([]models.StockConsolidation, 0)
+	for rows.Next() {
+		var stock models.StockConsolidation
+		err := rows.Scan(
+			&stock.Symbol, &stock.CompanyName, &stock.TotalShares,
+			&stock.CurrentPrice, &stock.TotalValue, &stock.UnrealizedGains,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan consolidated stock: %w", err)
+		}
+		stocks = append(stocks, stock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range stocks {
+		sources, err := r.listSources(stocks[i])
+		if err != nil {
+			// Skip sources on error but keep the consolidated data
+			sources = make([]models.StockSource, 0)
+		}
+		stocks[i].Sources = sources
+	}
+
+	return stocks, nil
+}
+
+// listSources returns the stock holdings and vested equity grants for a consolidated symbol
+func (r *StockRepository) listSources(stock models.StockConsolidation) ([]models.StockSource, error) {
+	query := `
+		SELECT id, account_id, shares_owned, cost_basis, data_source, created_at, 'direct_stock' as source_type, NULL as grant_type
+		FROM stock_holdings 
+		WHERE symbol = $1 AND shares_owned > 0
+		
+		UNION ALL
+		
+		SELECT id, account_id, vested_shares as shares_owned, 
+		       CASE 
+		           WHEN grant_type IN ('iso', 'nso') THEN COALESCE(strike_price, 0)
+		           ELSE COALESCE(current_price, 0) 
+		       END as cost_basis,
+		       data_source, created_at, 'equity_compensation' as source_type, grant_type
+		FROM equity_grants 
+		WHERE company_symbol = $1 AND vested_shares > 0
+		
+		ORDER BY data_source, source_type
+	`
+
+	rows, err := r.db.Query(query, stock.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make([]models.StockSource, 0)
+	for rows.Next() {
+		var source models.StockSource
+		err := rows.Scan(
+			&source.ID, &source.AccountID, &source.SharesOwned,
+			&source.CostBasis, &source.DataSource, &source.CreatedAt,
+			&source.SourceType, &source.GrantType,
+		)
+		if err != nil {
+			continue
+		}
+
+		// Build source display name
+		if source.SourceType == "equity_compensation" && source.GrantType != nil {
+			source.DataSource = fmt.Sprintf("%s (%s)", source.DataSource, *source.GrantType)
+		}
+
+		source.Symbol = stock.Symbol
+		source.CompanyName = stock.CompanyName
+		source.CurrentPrice = stock.CurrentPrice
+		source.MarketValue = source.SharesOwned * stock.CurrentPrice
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// Delete removes a stock holding
+func (r *StockRepository) Delete(id int) error {
+	return deleteByID(r.db, "stock_holdings", id)
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrDuplicateTag is returned when a tag name is already used, ignoring case
+	ErrDuplicateTag = errors.New("tag already exists")
+	// ErrUnknownHolding is returned when tagging a holding that does not exist
+	ErrUnknownHolding = errors.New("holding does not exist")
+	// ErrInvalidHoldingType is returned for a holding type that cannot be tagged
+	ErrInvalidHoldingType = errors.New("invalid holding type")
+)
+
+// holdingTables maps taggable holding types to the table holding them
+var holdingTables = map[string]string{
+	models.HoldingTypeStock:             "stock_holdings",
+	models.HoldingTypeEquityGrant:       "equity_grants",
+	models.HoldingTypeRealEstate:        "real_estate_properties",
+	models.HoldingTypeCash:              "cash_holdings",
+	models.HoldingTypeCrypto:            "crypto_holdings",
+	models.HoldingTypeOtherAsset:        "miscellaneous_assets",
+	models.HoldingTypePrivateInvestment: "private_investments",
+	models.HoldingTypeBond:              "bonds",
+	models.HoldingTypeIBond:             "i_bonds",
+	models.HoldingTypePension:           "pensions",
+	models.HoldingTypeInsurancePolicy:   "insurance_policies",
+	models.HoldingTypeLiability:         "liabilities",
+}
+
+// IsHoldingType reports whether holdingType can be tagged
+func IsHoldingType(holdingType string) bool {
+	_, ok := holdingTables[holdingType]
+	return ok
+}
+
+// TagFilter selects holdings by tag name, ignoring case. A holding passes when
+// it has any Include tag (or Include is empty) and none of the Exclude tags.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// IsEmpty reports whether the filter lets every holding through
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// TagMatcher reports whether a holding ID or symbol passes a TagFilter. A nil
+// matcher passes everything.
+type TagMatcher struct {
+	include map[string]bool // nil when the filter has no Include tags
+	exclude map[string]bool
+}
+
+// Matches reports whether key, a holding ID or a symbol, passes the filter
+func (m *TagMatcher) Matches(key string) bool {
+	if m == nil {
+		return true
+	}
+	if m.exclude[key] {
+		return false
+	}
+	return m.include == nil || m.include[key]
+}
+
+// MatchesID reports whether a holding ID passes the filter
+func (m *TagMatcher) MatchesID(id int) bool {
+	return m.Matches(strconv.Itoa(id))
+}
+
+// TagRepository provides access to tags and their holdings
+type TagRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db, dialect: database.DialectOf(db)}
+}
+
+// List returns all tags by name with the number of holdings carrying each
+func (r *TagRepository) List() ([]models.Tag, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.name, t.color, t.description,
+		       (SELECT COUNT(*) FROM holding_tags ht WHERE ht.tag_id = t.id),
+		       t.created_at
+		FROM tags t
+		ORDER BY LOWER(t.name)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.Description, &t.HoldingCount, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+// Create inserts a tag and returns its ID
+func (r *TagRepository) Create(input models.TagInput) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO tags (name, color, description) VALUES ($1, $2, $3)
+		RETURNING id
+	`, input.Name, input.Color, input.Description).Scan(&id)
+	if err != nil {
+		return 0, tagError(err, "failed to create tag")
+	}
+	return id, nil
+}
+
+// Update replaces the writable fields of a tag
+func (r *TagRepository) Update(id int, input models.TagInput) error {
+	result, err := r.db.Exec(`
+		UPDATE tags SET name = $1, color = $2, description = $3 WHERE id = $4
+	`, input.Name, input.Color, input.Description, id)
+	if err != nil {
+		return tagError(err, "failed to update tag")
+	}
+	return requireAffected(result)
+}
+
+// Delete removes a tag and detaches it from every holding
+func (r *TagRepository) Delete(id int) error {
+	return deleteByID(r.db, "tags", id)
+}
+
+// ListHoldings returns the holdings carrying a tag
+func (r *TagRepository) ListHoldings(tagID int) ([]models.HoldingTag, error) {
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tags WHERE id = $1)", tagID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to fetch tag: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := r.db.Query(`
+		SELECT holding_type, holding_id, tag_id, created_at
+		FROM holding_tags
+		WHERE tag_id = $1
+		ORDER BY holding_type, holding_id
+	`, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tagged holdings: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := make([]models.HoldingTag, 0)
+	for rows.Next() {
+		var h models.HoldingTag
+		if err := rows.Scan(&h.HoldingType, &h.HoldingID, &h.TagID, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tagged holding: %w", err)
+		}
+		holdings = append(holdings, h)
+	}
+
+	return holdings, rows.Err()
+}
+
+// Attach tags each holding in one transaction. Holdings that already carry
+// the tag are left as they are.
+func (r *TagRepository) Attach(tagID int, holdings []models.HoldingRef) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, holding := range holdings {
+		table, ok := holdingTables[holding.HoldingType]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrInvalidHoldingType, holding.HoldingType)
+		}
+
+		var exists bool
+		query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", sqlbuilder.Ident(table))
+		if err := tx.QueryRow(query, holding.HoldingID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check %s %d: %w", holding.HoldingType, holding.HoldingID, err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s %d", ErrUnknownHolding, holding.HoldingType, holding.HoldingID)
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO holding_tags (tag_id, holding_type, holding_id) VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, tagID, holding.HoldingType, holding.HoldingID)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to tag %s %d: %w", holding.HoldingType, holding.HoldingID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return nil
+}
+
+// Detach removes a tag from a holding
+func (r *TagRepository) Detach(tagID int, holding models.HoldingRef) error {
+	result, err := r.db.Exec(`
+		DELETE FROM holding_tags WHERE tag_id = $1 AND holding_type = $2 AND holding_id = $3
+	`, tagID, holding.HoldingType, holding.HoldingID)
+	if err != nil {
+		return fmt.Errorf("failed to untag holding: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// HoldingMatcher returns a matcher over the IDs of one holding type, or nil
+// when the filter is empty
+func (r *TagRepository) HoldingMatcher(holdingType string, filter TagFilter) (*TagMatcher, error) {
+	query := `
+		SELECT DISTINCT CAST(ht.holding_id AS TEXT)
+		FROM holding_tags ht
+		JOIN tags t ON t.id = ht.tag_id
+		WHERE ht.holding_type = $1 AND ` + r.dialect.InArray("LOWER(t.name)", "$2")
+	return r.matcher(filter, func(names []string) (*sql.Rows, error) {
+		return r.db.Query(query, holdingType, names)
+	})
+}
+
+// SymbolMatcher returns a matcher over stock symbols, or nil when the filter
+// is empty. A symbol carries a tag when any stock holding or equity grant in
+// it does.
+func (r *TagRepository) SymbolMatcher(filter TagFilter) (*TagMatcher, error) {
+	query := `
+		SELECT UPPER(h.symbol)
+		FROM holding_tags ht
+		JOIN tags t ON t.id = ht.tag_id
+		JOIN stock_holdings h ON h.id = ht.holding_id
+		WHERE ht.holding_type = 'stock_holding' AND ` + r.dialect.InArray("LOWER(t.name)", "$1") + `
+
+		UNION
+
+		SELECT UPPER(g.company_symbol)
+		FROM holding_tags ht
+		JOIN tags t ON t.id = ht.tag_id
+		JOIN equity_grants g ON g.id = ht.holding_id
+		WHERE ht.holding_type = 'equity_grant' AND ` + r.dialect.InArray("LOWER(t.name)", "$1")
+	return r.matcher(filter, func(names []string) (*sql.Rows, error) {
+		return r.db.Query(query, names)
+	})
+}
+
+// matcher builds a TagMatcher from a query returning the keys tagged with any
+// of the given lower-case names
+func (r *TagRepository) matcher(filter TagFilter, query func(names []string) (*sql.Rows, error)) (*TagMatcher, error) {
+	if filter.IsEmpty() {
+		return nil, nil
+	}
+
+	keys := func(names []string) (map[string]bool, error) {
+		lower := make([]string, len(names))
+		for i, name := range names {
+			lower[i] = strings.ToLower(name)
+		}
+
+		rows, err := query(lower)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tagged holdings: %w", err)
+		}
+		defer rows.Close()
+
+		set := make(map[string]bool)
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				return nil, fmt.Errorf("failed to scan tagged holding: %w", err)
+			}
+			set[key] = true
+		}
+		return set, rows.Err()
+	}
+
+	m := &TagMatcher{}
+	var err error
+	if len(filter.Include) > 0 {
+		if m.include, err = keys(filter.Include); err != nil {
+			return nil, err
+		}
+	}
+	if len(filter.Exclude) > 0 {
+		if m.exclude, err = keys(filter.Exclude); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// tagError maps constraint violations to repository errors
+func tagError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicateTag
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
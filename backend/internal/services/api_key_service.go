@@ -0,0 +1,188 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// API key scopes
+const (
+	// APIKeyScopeRead allows reads, like the viewer role
+	APIKeyScopeRead = "read"
+	// APIKeyScopeWrite also allows changing data, like the editor role
+	APIKeyScopeWrite = "write"
+)
+
+// APIKeyPrefix starts every API key, telling keys apart from session tokens
+const APIKeyPrefix = "nwk_"
+
+// APIKeyAssetClasses are the asset classes a key can be limited to
+var APIKeyAssetClasses = []string{"stocks", "equity", "real_estate", "cash", "crypto", "other_assets", "private_investments", "fixed_income", "pensions", "insurance", "liabilities"}
+
+var (
+	// ErrAPIKeyNotFound is returned when an API key does not exist
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrInvalidAPIKey is returned for an unknown, revoked or expired key
+	ErrInvalidAPIKey = errors.New("invalid, revoked or expired API key")
+)
+
+// APIKeyInput creates an API key
+type APIKeyInput struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Scope string `json:"scope" binding:"required,oneof=read write"`
+	// AssetClasses limits the key to these asset classes' endpoints; empty
+	// allows every endpoint its scope does
+	AssetClasses  []string `json:"asset_classes" binding:"omitempty,dive,oneof=stocks equity real_estate cash crypto other_assets private_investments fixed_income pensions insurance liabilities"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// APIKey lets an external tool call the API without a session. The key
+// itself is only known when it is created.
+type APIKey struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Key          string     `json:"key,omitempty"`
+	Prefix       string     `json:"prefix"`
+	Scope        string     `json:"scope"`
+	AssetClasses []string   `json:"asset_classes"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+}
+
+// Role is the user role the key acts with: viewer for read keys and editor
+// for write keys. Keys never act as admins.
+func (k *APIKey) Role() string {
+	if k.Scope == APIKeyScopeWrite {
+		return RoleEditor
+	}
+	return RoleViewer
+}
+
+// AllowsAssetClass reports whether the key may reach endpoints of class
+func (k *APIKey) AllowsAssetClass(class string) bool {
+	if len(k.AssetClasses) == 0 {
+		return true
+	}
+	for _, allowed := range k.AssetClasses {
+		if allowed == class {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyService creates, authenticates and revokes API keys
+type APIKeyService struct {
+	db *sql.DB
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(db *sql.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+const apiKeyColumns = `
+	id, name, key_prefix, scope, asset_classes, COALESCE(created_by, ''),
+	created_at, expires_at, last_used_at, revoked_at
+`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (APIKey, error) {
+	var key APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Scope, database.Array(&key.AssetClasses), &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	if key.AssetClasses == nil {
+		key.AssetClasses = []string{}
+	}
+	return key, err
+}
+
+// List returns every API key, newest first
+func (ks *APIKeyService) List() ([]APIKey, error) {
+	rows, err := ks.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Create generates a new key and returns it with the key itself
+func (ks *APIKeyService) Create(input APIKeyInput, createdBy string, now time.Time) (*APIKey, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	secret := APIKeyPrefix + token
+
+	var expiresAt *time.Time
+	if input.ExpiresInDays > 0 {
+		at := now.AddDate(0, 0, input.ExpiresInDays)
+		expiresAt = &at
+	}
+	classes := input.AssetClasses
+	if classes == nil {
+		classes = []string{}
+	}
+
+	key, err := scanAPIKey(ks.db.QueryRow(`
+		INSERT INTO api_keys (name, key_prefix, key_hash, scope, asset_classes, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING `+apiKeyColumns,
+		strings.TrimSpace(input.Name), secret[:len(APIKeyPrefix)+8], hashSessionToken(secret), input.Scope,
+		classes, createdBy, now, expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	key.Key = secret
+	return &key, nil
+}
+
+// Authenticate returns the key secret belongs to and records its use
+func (ks *APIKeyService) Authenticate(secret string, now time.Time) (*APIKey, error) {
+	if !strings.HasPrefix(secret, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := scanAPIKey(ks.db.QueryRow(`
+		UPDATE api_keys SET last_used_at = $2
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING `+apiKeyColumns, hashSessionToken(secret), now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check API key: %w", err)
+	}
+	return &key, nil
+}
+
+// Revoke stops a key from working. Revoked keys stay listed.
+func (ks *APIKeyService) Revoke(id int, now time.Time) error {
+	result, err := ks.db.Exec(`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/models"
+)
+
+// defaultAssetRefreshDays is how often API-valued assets are revalued when
+// the category does not say
+const defaultAssetRefreshDays = 30
+
+// Errors returned when an asset cannot be valued through its category
+var (
+	ErrNoAssetValuationConfig      = errors.New("asset category has no valuation_api_config provider")
+	ErrUnknownAssetValuationSource = errors.New("unknown asset valuation provider")
+	ErrAssetValuationUnavailable   = errors.New("asset valuation provider is not configured")
+	ErrNoAssetValuationSuggestion  = errors.New("no valuation suggestion for asset")
+)
+
+// AssetValuationConfig is a category's valuation_api_config. Provider names
+// an AssetValuationProvider; the remaining fields are provider options.
+type AssetValuationConfig struct {
+	Provider            string `json:"provider"`
+	RefreshIntervalDays int    `json:"refresh_interval_days,omitempty"`
+	// Depreciation: yearly decline from the purchase price (default 15%)
+	AnnualDepreciationPercent *float64 `json:"annual_depreciation_percent,omitempty"`
+	// MarketCheck: ZIP code that local market prices are taken from
+	ZipCode string `json:"zip_code,omitempty"`
+	// Metal spot: defaults for holdings without the custom field
+	Metal      string   `json:"metal,omitempty"`
+	WeightUnit string   `json:"weight_unit,omitempty"`
+	Purity     *float64 `json:"purity,omitempty"`
+	// eBay sold listings: sales window (default 90 days) and minimum sales (default 3)
+	LookbackDays int `json:"lookback_days,omitempty"`
+	MinSales     int `json:"min_sales,omitempty"`
+}
+
+// scheduledAssetValuationProvider is a provider that follows a market price
+// and revalues on that price's refresh interval unless the category sets one
+type scheduledAssetValuationProvider interface {
+	RefreshInterval() time.Duration
+}
+
+// confirmedAssetValuationProvider is a provider whose values are suggestions
+// that replace current_value only once confirmed
+type confirmedAssetValuationProvider interface {
+	RequiresConfirmation() bool
+}
+
+// refreshInterval is how long an API-valued asset keeps its value
+func (s *AssetValuationService) refreshInterval(cfg AssetValuationConfig) time.Duration {
+	if cfg.RefreshIntervalDays > 0 {
+		return time.Duration(cfg.RefreshIntervalDays) * 24 * time.Hour
+	}
+	if scheduled, ok := s.providers[cfg.Provider].(scheduledAssetValuationProvider); ok {
+		return scheduled.RefreshInterval()
+	}
+	return defaultAssetRefreshDays * 24 * time.Hour
+}
+
+// AssetValuationProvider values a miscellaneous asset from its fields
+type AssetValuationProvider interface {
+	// Name is the provider's key in valuation_api_config
+	Name() string
+	GetProviderName() string
+	Description() string
+	IsAvailable() bool
+	Valuate(asset models.MiscellaneousAsset, cfg AssetValuationConfig) (float64, error)
+}
+
+// AssetValuation is the result of valuing an asset. Pending valuations are
+// suggestions waiting to be confirmed; PreviousValue is then the value they
+// would replace.
+type AssetValuation struct {
+	AssetID       int       `json:"asset_id"`
+	Provider      string    `json:"provider"`
+	PreviousValue float64   `json:"previous_value"`
+	Value         float64   `json:"value"`
+	ValuedAt      time.Time `json:"valued_at"`
+	Pending       bool      `json:"pending,omitempty"`
+}
+
+// AssetValuationService values miscellaneous assets through the provider
+// their category's valuation_api_config names, and keeps API-valued assets
+// current
+type AssetValuationService struct {
+	db          *sql.DB
+	marketCheck *MarketCheckValuationProvider
+	providers   map[string]AssetValuationProvider
+}
+
+// NewAssetValuationService creates an asset valuation service with the
+// built-in providers
+func NewAssetValuationService(db *sql.DB, cfg *config.ApiConfig, metals *MetalsPriceService) *AssetValuationService {
+	marketCheck := NewMarketCheckValuationProvider(cfg)
+	s := &AssetValuationService{
+		db:          db,
+		marketCheck: marketCheck,
+		providers:   make(map[string]AssetValuationProvider),
+	}
+	providers := []AssetValuationProvider{
+		&DepreciationValuationProvider{},
+		marketCheck,
+		NewMetalSpotValuationProvider(metals),
+		NewEbaySoldListingsProvider(cfg),
+	}
+	for _, provider := range providers {
+		s.providers[provider.Name()] = provider
+	}
+	return s
+}
+
+// SetMarketCheckAPIKey replaces the MarketCheck API key after it changes
+func (s *AssetValuationService) SetMarketCheckAPIKey(apiKey string) {
+	s.marketCheck.SetAPIKey(apiKey)
+}
+
+// Providers returns the asset valuation providers ordered by name
+func (s *AssetValuationService) Providers() []AssetValuationProvider {
+	providers := make([]AssetValuationProvider, 0, len(s.providers))
+	for _, provider := range s.providers {
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name() < providers[j].Name() })
+	return providers
+}
+
+// ValidateConfig checks a valuation_api_config before it is stored. A config
+// without a provider is allowed and turns API valuation off for the category.
+func (s *AssetValuationService) ValidateConfig(raw interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("valuation_api_config is not valid JSON: %w", err)
+	}
+	var cfg AssetValuationConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("valuation_api_config is invalid: %w", err)
+	}
+	if cfg.Provider == "" {
+		return nil
+	}
+	if _, ok := s.providers[cfg.Provider]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAssetValuationSource, cfg.Provider)
+	}
+	if cfg.RefreshIntervalDays < 0 {
+		return fmt.Errorf("refresh_interval_days cannot be negative")
+	}
+	if p := cfg.AnnualDepreciationPercent; p != nil && (*p < 0 || *p >= 100) {
+		return fmt.Errorf("annual_depreciation_percent must be at least 0 and below 100")
+	}
+	if cfg.Metal != "" {
+		if _, ok := metalSymbols[strings.ToLower(cfg.Metal)]; !ok {
+			return ErrUnknownMetal
+		}
+	}
+	if _, ok := troyOuncesPer[cfg.WeightUnit]; cfg.WeightUnit != "" && !ok {
+		return fmt.Errorf("weight_unit must be troy_oz, oz, g or kg")
+	}
+	if p := cfg.Purity; p != nil && (*p <= 0 || *p > 100) {
+		return fmt.Errorf("purity must be a fraction up to 1 or a percentage up to 100")
+	}
+	if cfg.LookbackDays < 0 || cfg.MinSales < 0 {
+		return fmt.Errorf("lookback_days and min_sales cannot be negative")
+	}
+	return nil
+}
+
+// categoryConfig returns the valuation config of an asset category
+func (s *AssetValuationService) categoryConfig(categoryID int64) (AssetValuationConfig, error) {
+	var cfg AssetValuationConfig
+	var raw sql.NullString
+	err := s.db.QueryRow("SELECT valuation_api_config FROM asset_categories WHERE id = $1", categoryID).Scan(&raw)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return cfg, fmt.Errorf("failed to fetch asset category: %w", err)
+	}
+	if raw.Valid && raw.String != "" {
+		if err := json.Unmarshal([]byte(raw.String), &cfg); err != nil {
+			return cfg, fmt.Errorf("asset category valuation_api_config is invalid: %w", err)
+		}
+	}
+	if cfg.Provider == "" {
+		return cfg, ErrNoAssetValuationConfig
+	}
+	return cfg, nil
+}
+
+// Refresh values an asset with its category's provider, stores the value in
+// current_value and the valuation history, and marks the asset as API-valued
+// so it is kept current from then on. A provider that requires confirmation
+// leaves the value as a pending suggestion instead.
+func (s *AssetValuationService) Refresh(asset models.MiscellaneousAsset) (*AssetValuation, error) {
+	cfg, err := s.categoryConfig(asset.AssetCategoryID)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := s.providers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAssetValuationSource, cfg.Provider)
+	}
+	if !provider.IsAvailable() {
+		return nil, fmt.Errorf("%w: %s", ErrAssetValuationUnavailable, provider.GetProviderName())
+	}
+
+	value, err := provider.Valuate(asset, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &AssetValuation{
+		AssetID:       asset.ID,
+		Provider:      provider.Name(),
+		PreviousValue: asset.CurrentValue.InexactFloat64(),
+		Value:         roundCents(value),
+		ValuedAt:      time.Now(),
+	}
+	if confirmed, ok := provider.(confirmedAssetValuationProvider); ok && confirmed.RequiresConfirmation() {
+		valuation.Pending = true
+		_, err = s.db.Exec(`
+			INSERT INTO asset_valuation_suggestions (asset_id, value, provider, suggested_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (asset_id) DO UPDATE
+			SET value = EXCLUDED.value, provider = EXCLUDED.provider, suggested_at = EXCLUDED.suggested_at
+		`, valuation.AssetID, valuation.Value, valuation.Provider, valuation.ValuedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store valuation suggestion: %w", err)
+		}
+		return valuation, nil
+	}
+
+	if err := s.record(valuation); err != nil {
+		return nil, err
+	}
+	return valuation, nil
+}
+
+// Suggestion returns an asset's pending valuation suggestion
+func (s *AssetValuationService) Suggestion(assetID int) (*AssetValuation, error) {
+	v := AssetValuation{AssetID: assetID, Pending: true}
+	err := s.db.QueryRow(`
+		SELECT s.value, s.provider, s.suggested_at, ma.current_value
+		FROM asset_valuation_suggestions s
+		JOIN miscellaneous_assets ma ON ma.id = s.asset_id
+		WHERE s.asset_id = $1
+	`, assetID).Scan(&v.Value, &v.Provider, &v.ValuedAt, &v.PreviousValue)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoAssetValuationSuggestion
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch valuation suggestion: %w", err)
+	}
+	return &v, nil
+}
+
+// ConfirmSuggestion applies an asset's pending suggestion as its current
+// value, like a Refresh with a provider that needs no confirmation
+func (s *AssetValuationService) ConfirmSuggestion(assetID int) (*AssetValuation, error) {
+	v, err := s.Suggestion(assetID)
+	if err != nil {
+		return nil, err
+	}
+	v.Pending = false
+	v.ValuedAt = time.Now()
+	if err := s.record(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DismissSuggestion discards an asset's pending suggestion
+func (s *AssetValuationService) DismissSuggestion(assetID int) error {
+	result, err := s.db.Exec("DELETE FROM asset_valuation_suggestions WHERE asset_id = $1", assetID)
+	if err != nil {
+		return fmt.Errorf("failed to dismiss valuation suggestion: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNoAssetValuationSuggestion
+	}
+	return nil
+}
+
+// record stores a valuation on the asset and in its history, superseding any
+// pending suggestion
+func (s *AssetValuationService) record(v *AssetValuation) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE miscellaneous_assets
+		SET current_value = $1, valuation_method = 'api', api_provider = $2,
+		    last_valuation_date = $3, last_updated = $3
+		WHERE id = $4
+	`, v.Value, v.Provider, v.ValuedAt, v.AssetID)
+	if err != nil {
+		return fmt.Errorf("failed to update asset value: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO asset_valuation_history (asset_id, value, source, valued_at)
+		VALUES ($1, $2, $3, $4)
+	`, v.AssetID, v.Value, v.Provider, v.ValuedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record asset valuation: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM asset_valuation_suggestions WHERE asset_id = $1", v.AssetID)
+	if err != nil {
+		return fmt.Errorf("failed to clear valuation suggestion: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RefreshDue revalues API-valued assets whose refresh interval has passed
+// since their last valuation or suggestion, and returns how many were revalued. Assets
+// whose provider fails keep their value and are retried next run.
+func (s *AssetValuationService) RefreshDue(ctx context.Context) (int, error) {
+	return s.refreshAPIValued(ctx, func(asset models.MiscellaneousAsset, cfg AssetValuationConfig) bool {
+		return asset.LastValuationDate == nil || time.Since(*asset.LastValuationDate) >= s.refreshInterval(cfg)
+	})
+}
+
+// RefreshProvider revalues every API-valued asset whose category uses the
+// named provider, due or not, and returns how many were revalued
+func (s *AssetValuationService) RefreshProvider(ctx context.Context, provider string) (int, error) {
+	return s.refreshAPIValued(ctx, func(_ models.MiscellaneousAsset, cfg AssetValuationConfig) bool {
+		return cfg.Provider == provider
+	})
+}
+
+// refreshAPIValued revalues the API-valued assets selected by include
+func (s *AssetValuationService) refreshAPIValued(ctx context.Context, include func(models.MiscellaneousAsset, AssetValuationConfig) bool) (int, error) {
+	rows, err := s.db.Query(`
+		SELECT ma.id, ma.asset_name, ma.current_value, ma.purchase_price,
+		       ma.purchase_date, ma.custom_fields,
+		       GREATEST(ma.last_valuation_date, s.suggested_at), ma.asset_category_id
+		FROM miscellaneous_assets ma
+		JOIN asset_categories ac ON ma.asset_category_id = ac.id
+		LEFT JOIN asset_valuation_suggestions s ON s.asset_id = ma.id
+		WHERE ma.valuation_method = 'api' AND ac.valuation_api_config IS NOT NULL
+		ORDER BY ma.last_valuation_date NULLS FIRST
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch API-valued assets: %w", err)
+	}
+
+	var assets []models.MiscellaneousAsset
+	for rows.Next() {
+		var a models.MiscellaneousAsset
+		var customFields sql.NullString
+		var categoryID sql.NullInt64
+		var purchaseDate sql.NullTime
+		if err := rows.Scan(&a.ID, &a.AssetName, &a.CurrentValue, &a.PurchasePrice,
+			&purchaseDate, &customFields, &a.LastValuationDate, &categoryID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan asset: %w", err)
+		}
+		if purchaseDate.Valid {
+			day := purchaseDate.Time.Format("2006-01-02")
+			a.PurchaseDate = &day
+		}
+		if customFields.Valid && customFields.String != "" {
+			json.Unmarshal([]byte(customFields.String), &a.CustomFields)
+		}
+		a.AssetCategoryID = categoryID.Int64
+		assets = append(assets, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, asset := range assets {
+		if ctx.Err() != nil {
+			break
+		}
+		cfg, err := s.categoryConfig(asset.AssetCategoryID)
+		if err != nil || !include(asset, cfg) {
+			continue
+		}
+		if _, err := s.Refresh(asset); err != nil {
+			fmt.Printf("WARNING: Failed to revalue asset %d (%s): %v\n", asset.ID, asset.AssetName, err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+// Run revalues due assets now and then every interval until ctx is done.
+// onChange is called after a run that changed any value.
+func (s *AssetValuationService) Run(ctx context.Context, interval time.Duration, onChange func()) {
+	refresh := func() {
+		count, err := s.RefreshDue(ctx)
+		if err != nil {
+			fmt.Printf("WARNING: Asset revaluation failed: %v\n", err)
+			return
+		}
+		if count > 0 && onChange != nil {
+			onChange()
+		}
+	}
+
+	refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
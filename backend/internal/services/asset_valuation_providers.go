@@ -0,0 +1,275 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+	"networth-dashboard/internal/models"
+)
+
+// defaultAnnualDepreciationPercent is how much value an asset loses a year
+// when its category does not say; typical for a car after its first year
+const defaultAnnualDepreciationPercent = 15.0
+
+// customFieldString returns a custom field as trimmed text
+func customFieldString(asset models.MiscellaneousAsset, name string) string {
+	switch v := asset.CustomFields[name].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// customFieldNumber returns a numeric custom field, which may be stored as a
+// number or as text
+func customFieldNumber(asset models.MiscellaneousAsset, name string) (float64, bool) {
+	switch v := asset.CustomFields[name].(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// DepreciationValuationProvider values an asset by declining its purchase
+// price at a fixed yearly rate since the purchase date
+type DepreciationValuationProvider struct{}
+
+// Name is the provider's key in valuation_api_config
+func (p *DepreciationValuationProvider) Name() string {
+	return "depreciation"
+}
+
+// GetProviderName returns the provider name
+func (p *DepreciationValuationProvider) GetProviderName() string {
+	return "Depreciation Schedule"
+}
+
+// Description says what the provider offers
+func (p *DepreciationValuationProvider) Description() string {
+	return "Declines the purchase price by annual_depreciation_percent a year since the purchase date (no API key required)"
+}
+
+// IsAvailable reports that the schedule needs no configuration
+func (p *DepreciationValuationProvider) IsAvailable() bool {
+	return true
+}
+
+// Valuate returns purchase_price × (1 − rate)^years since purchase_date
+func (p *DepreciationValuationProvider) Valuate(asset models.MiscellaneousAsset, cfg AssetValuationConfig) (float64, error) {
+	if asset.PurchasePrice == nil || !asset.PurchasePrice.IsPositive() {
+		return 0, fmt.Errorf("purchase_price is required for depreciation")
+	}
+	if asset.PurchaseDate == nil {
+		return 0, fmt.Errorf("purchase_date is required for depreciation")
+	}
+	purchased, err := time.Parse("2006-01-02", *asset.PurchaseDate)
+	if err != nil {
+		return 0, fmt.Errorf("purchase_date is invalid: %w", err)
+	}
+
+	rate := defaultAnnualDepreciationPercent
+	if cfg.AnnualDepreciationPercent != nil {
+		rate = *cfg.AnnualDepreciationPercent
+	}
+	years := math.Max(0, time.Since(purchased).Hours()/24/365.25)
+	return asset.PurchasePrice.InexactFloat64() * math.Pow(1-rate/100, years), nil
+}
+
+// MarketCheckValuationProvider values vehicles by VIN and mileage with the
+// MarketCheck price prediction API
+type MarketCheckValuationProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewMarketCheckValuationProvider creates a MarketCheck provider
+func NewMarketCheckValuationProvider(cfg *config.ApiConfig) *MarketCheckValuationProvider {
+	return &MarketCheckValuationProvider{
+		apiKey:     cfg.MarketCheckAPIKey,
+		baseURL:    strings.TrimRight(cfg.MarketCheckBaseURL, "/"),
+		httpClient: httpclient.New("marketcheck", 30*time.Second, cfg.HTTPRetry),
+	}
+}
+
+// Name is the provider's key in valuation_api_config
+func (p *MarketCheckValuationProvider) Name() string {
+	return "marketcheck"
+}
+
+// GetProviderName returns the provider name
+func (p *MarketCheckValuationProvider) GetProviderName() string {
+	return "MarketCheck"
+}
+
+// Description says what the provider offers
+func (p *MarketCheckValuationProvider) Description() string {
+	return "Vehicle market value from the vin and mileage custom fields near zip_code (API key required)"
+}
+
+// IsAvailable reports whether an API key is configured
+func (p *MarketCheckValuationProvider) IsAvailable() bool {
+	return p.apiKey != ""
+}
+
+// SetAPIKey replaces the API key after it changes
+func (p *MarketCheckValuationProvider) SetAPIKey(apiKey string) {
+	p.apiKey = apiKey
+}
+
+// Valuate fetches the predicted private-party price of the asset's vehicle
+func (p *MarketCheckValuationProvider) Valuate(asset models.MiscellaneousAsset, cfg AssetValuationConfig) (float64, error) {
+	vin := strings.ToUpper(customFieldString(asset, "vin"))
+	if len(vin) != 17 {
+		return 0, fmt.Errorf("a 17-character vin custom field is required for MarketCheck")
+	}
+	miles, ok := customFieldNumber(asset, "mileage")
+	if !ok || miles < 0 {
+		return 0, fmt.Errorf("a mileage custom field is required for MarketCheck")
+	}
+	if cfg.ZipCode == "" {
+		return 0, fmt.Errorf("zip_code is required in the category valuation_api_config for MarketCheck")
+	}
+
+	params := url.Values{}
+	params.Set("api_key", p.apiKey)
+	params.Set("vin", vin)
+	params.Set("miles", strconv.Itoa(int(miles)))
+	params.Set("zip", cfg.ZipCode)
+	params.Set("dealer_type", "independent")
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/predict/car/us/marketcheck_price?"+params.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, readProviderError("MarketCheck", resp)
+	}
+
+	var body struct {
+		MarketCheckPrice float64 `json:"marketcheck_price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode API response: %w", err)
+	}
+	if body.MarketCheckPrice <= 0 {
+		return 0, fmt.Errorf("no market price available for VIN %s", vin)
+	}
+	return body.MarketCheckPrice, nil
+}
+
+// troyOuncesPer converts a weight unit to troy ounces
+var troyOuncesPer = map[string]float64{
+	"troy_oz": 1,
+	"oz":      28.349523125 / 31.1034768,
+	"g":       1 / 31.1034768,
+	"kg":      1000 / 31.1034768,
+}
+
+// MetalSpotProvider is the metal spot provider's key in valuation_api_config
+const MetalSpotProvider = "metal_spot"
+
+// MetalSpotValuationProvider values precious metal holdings as quantity ×
+// purity × spot price
+type MetalSpotValuationProvider struct {
+	metals *MetalsPriceService
+}
+
+// NewMetalSpotValuationProvider creates a provider valuing metals at spot
+func NewMetalSpotValuationProvider(metals *MetalsPriceService) *MetalSpotValuationProvider {
+	return &MetalSpotValuationProvider{metals: metals}
+}
+
+// Name is the provider's key in valuation_api_config
+func (p *MetalSpotValuationProvider) Name() string {
+	return MetalSpotProvider
+}
+
+// GetProviderName returns the provider name
+func (p *MetalSpotValuationProvider) GetProviderName() string {
+	return "Metal Spot Price"
+}
+
+// Description says what the provider offers
+func (p *MetalSpotValuationProvider) Description() string {
+	return "Quantity × purity × spot price from the metal, quantity, weight_unit and purity custom fields (no API key required)"
+}
+
+// IsAvailable reports that spot prices need no configuration
+func (p *MetalSpotValuationProvider) IsAvailable() bool {
+	return true
+}
+
+// RefreshInterval revalues metal holdings whenever spot prices are refetched
+func (p *MetalSpotValuationProvider) RefreshInterval() time.Duration {
+	return p.metals.RefreshInterval()
+}
+
+// Valuate returns the holding's melt value. The metal, weight unit and purity
+// custom fields fall back to the category's config; purity above 1 is a
+// percentage and defaults to pure.
+func (p *MetalSpotValuationProvider) Valuate(asset models.MiscellaneousAsset, cfg AssetValuationConfig) (float64, error) {
+	metal := customFieldString(asset, "metal")
+	if metal == "" {
+		metal = cfg.Metal
+	}
+	if metal == "" {
+		return 0, fmt.Errorf("a metal custom field or config metal is required for spot valuation")
+	}
+
+	quantity, ok := customFieldNumber(asset, "quantity")
+	if !ok || quantity <= 0 {
+		return 0, fmt.Errorf("a positive quantity custom field is required for spot valuation")
+	}
+
+	unit := strings.ToLower(customFieldString(asset, "weight_unit"))
+	if unit == "" {
+		unit = cfg.WeightUnit
+	}
+	if unit == "" {
+		unit = "troy_oz"
+	}
+	perUnit, ok := troyOuncesPer[unit]
+	if !ok {
+		return 0, fmt.Errorf("weight_unit must be troy_oz, oz, g or kg")
+	}
+
+	purity := 1.0
+	if cfg.Purity != nil {
+		purity = *cfg.Purity
+	}
+	if value, ok := customFieldNumber(asset, "purity"); ok {
+		purity = value
+	}
+	if purity > 1 {
+		purity /= 100
+	}
+	if purity <= 0 || purity > 1 {
+		return 0, fmt.Errorf("purity must be a fraction up to 1 or a percentage up to 100")
+	}
+
+	spot, err := p.metals.GetSpotPrice(metal, false)
+	if err != nil {
+		return 0, err
+	}
+	return quantity * perUnit * purity * spot.PriceUSD, nil
+}
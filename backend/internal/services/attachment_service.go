@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/storage"
+)
+
+// ErrAttachmentTooLarge is returned for files over the configured size limit
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+// attachmentKeyPattern matches the keys Save generates, so a key read back from
+// the database always names an object under attachmentKeyPrefix
+var attachmentKeyPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// attachmentKeyPrefix groups attachment files in the document store
+const attachmentKeyPrefix = "attachments/"
+
+// AttachmentService stores the files attached to holdings in the document
+// store under random keys; their metadata is kept in the attachments table
+type AttachmentService struct {
+	store        storage.Store
+	maxSize      int64
+	signedURLTTL time.Duration
+}
+
+// NewAttachmentService creates an attachment service storing files in store
+func NewAttachmentService(store storage.Store, cfg config.AttachmentsConfig, signedURLTTL time.Duration) *AttachmentService {
+	return &AttachmentService{store: store, maxSize: cfg.MaxSizeBytes, signedURLTTL: signedURLTTL}
+}
+
+// MaxSize is the largest file Save accepts, in bytes
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save stores size bytes read from r and returns the new file's key
+func (s *AttachmentService) Save(ctx context.Context, r io.Reader, size int64, contentType string) (string, error) {
+	if size > s.maxSize {
+		return "", ErrAttachmentTooLarge
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate attachment key: %w", err)
+	}
+	key := hex.EncodeToString(raw)
+
+	if err := s.store.Put(ctx, attachmentKeyPrefix+key, io.LimitReader(r, size), size, contentType); err != nil {
+		return "", fmt.Errorf("failed to store attachment file: %w", err)
+	}
+	return key, nil
+}
+
+// Open returns the stored file for key, or storage.ErrNotFound
+func (s *AttachmentService) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !attachmentKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("invalid attachment key %q", key)
+	}
+	return s.store.Get(ctx, attachmentKeyPrefix+key)
+}
+
+// SignedURL returns a link that downloads the file for key as fileName without
+// going through the API, with its expiry, or storage.ErrSignedURLsUnsupported
+func (s *AttachmentService) SignedURL(key, fileName string) (string, time.Time, error) {
+	if !attachmentKeyPattern.MatchString(key) {
+		return "", time.Time{}, fmt.Errorf("invalid attachment key %q", key)
+	}
+	expiresAt := time.Now().Add(s.signedURLTTL)
+	url, err := s.store.SignedURL(attachmentKeyPrefix+key, fileName, s.signedURLTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return url, expiresAt, nil
+}
+
+// Remove deletes the stored file for key; a file already gone is not an error
+func (s *AttachmentService) Remove(ctx context.Context, key string) error {
+	if !attachmentKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid attachment key %q", key)
+	}
+	return s.store.Delete(ctx, attachmentKeyPrefix+key)
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+// Audited actions
+const (
+	AuditActionCreate     = "create"
+	AuditActionUpdate     = "update"
+	AuditActionDelete     = "delete"
+	AuditActionBulkUpdate = "bulk_update"
+	AuditActionBulkCreate = "bulk_create"
+	AuditActionBulkDelete = "bulk_delete"
+	AuditActionMerge      = "merge"
+)
+
+// auditTables maps audited entity types to the table holding them
+var auditTables = map[string]string{
+	"account":                 "accounts",
+	"stock_holding":           "stock_holdings",
+	"equity_grant":            "equity_grants",
+	"real_estate":             "real_estate_properties",
+	"cash_holding":            "cash_holdings",
+	"crypto_holding":          "crypto_holdings",
+	"other_asset":             "miscellaneous_assets",
+	"private_investment":      "private_investments",
+	"private_investment_flow": "private_investment_flows",
+	"bond":                    "bonds",
+	"i_bond":                  "i_bonds",
+	"pension":                 "pensions",
+	"insurance_policy":        "insurance_policies",
+	"liability":               "liabilities",
+	"asset_category":          "asset_categories",
+	"recurring_contribution":  "recurring_contributions",
+	"employer_match_rule":     "employer_match_rules",
+	"goal":                    "goals",
+	"cash_flow_category":      "cash_flow_categories",
+	"cash_flow_transaction":   "cash_flow_transactions",
+	"tag":                     "tags",
+	"notification_rule":       "notification_rules",
+	"user":                    "users",
+	"household_member":        "household_members",
+	"saved_view":              "saved_views",
+	"property_ledger_entry":   "property_ledger_entries",
+	"attachment":              "attachments",
+	"trading_window":          "trading_windows",
+	"market_holiday":          "market_holidays",
+	"crypto_transaction":      "crypto_transactions",
+}
+
+// auditRedactedColumns are left out of snapshots so secrets never reach the audit log
+var auditRedactedColumns = []string{"password_hash"}
+
+// FieldChange is the old and new value of a single changed field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEntry is a single recorded data mutation
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   *int                   `json:"entity_id,omitempty"`
+	Actor      string                 `json:"actor"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	OldValues  map[string]interface{} `json:"old_values,omitempty"`
+	NewValues  map[string]interface{} `json:"new_values,omitempty"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditFilter narrows the audit entries returned by List
+type AuditFilter struct {
+	EntityType string
+	EntityID   *int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// AuditService records create/update/delete operations with before and after values
+type AuditService struct {
+	db *sql.DB
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Snapshot returns the current row for an entity as a map, or nil if it doesn't exist
+func (as *AuditService) Snapshot(entityType string, id int) map[string]interface{} {
+	table, ok := auditTables[entityType]
+	if !ok {
+		return nil
+	}
+
+	var raw []byte
+	query := fmt.Sprintf("SELECT %s FROM %s t WHERE id = $1", database.DialectOf(as.db).RowToJSON(table, "t"), sqlbuilder.Ident(table))
+	if err := as.db.QueryRow(query, id).Scan(&raw); err != nil {
+		if err != sql.ErrNoRows {
+			fmt.Printf("WARNING: Failed to snapshot %s %d for audit: %v\n", entityType, id, err)
+		}
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		fmt.Printf("WARNING: Failed to decode %s %d snapshot for audit: %v\n", entityType, id, err)
+		return nil
+	}
+	for _, column := range auditRedactedColumns {
+		delete(values, column)
+	}
+	return values
+}
+
+// Record stores an audit entry, computing the field-level diff between the old and new values
+func (as *AuditService) Record(action, entityType string, entityID *int, actor, ipAddress string, oldValues, newValues map[string]interface{}) error {
+	changes := DiffValues(oldValues, newValues)
+	if action == AuditActionUpdate && len(changes) == 0 && oldValues != nil && newValues != nil {
+		// Nothing actually changed
+		return nil
+	}
+
+	oldJSON, err := marshalNullableJSON(oldValues)
+	if err != nil {
+		return fmt.Errorf("failed to encode old values: %w", err)
+	}
+	newJSON, err := marshalNullableJSON(newValues)
+	if err != nil {
+		return fmt.Errorf("failed to encode new values: %w", err)
+	}
+	changesJSON, err := marshalNullableJSON(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (action, entity_type, entity_id, actor, ip_address, old_values, new_values, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err = as.db.Exec(query, action, entityType, entityID, actor, ipAddress, oldJSON, newJSON, changesJSON, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// List returns audit entries matching the filter, newest first
+func (as *AuditService) List(filter AuditFilter) ([]AuditEntry, error) {
+	query := `
+		SELECT id, action, entity_type, entity_id, actor, COALESCE(ip_address, ''),
+		       old_values, new_values, changes, created_at
+		FROM audit_log
+	`
+	var where sqlbuilder.Where
+	var args sqlbuilder.Args
+
+	if filter.EntityType != "" {
+		where.Compare(&args, "entity_type", "=", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		where.Compare(&args, "entity_id", "=", *filter.EntityID)
+	}
+	if filter.From != nil {
+		where.Compare(&args, "created_at", ">=", *filter.From)
+	}
+	if filter.To != nil {
+		where.Compare(&args, "created_at", "<", *filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += " WHERE " + where.String() + " ORDER BY created_at DESC, id DESC LIMIT " + args.Bind(limit)
+
+	rows, err := as.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var e AuditEntry
+		var entityID sql.NullInt64
+		var oldJSON, newJSON, changesJSON []byte
+		err := rows.Scan(&e.ID, &e.Action, &e.EntityType, &entityID, &e.Actor, &e.IPAddress,
+			&oldJSON, &newJSON, &changesJSON, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if entityID.Valid {
+			id := int(entityID.Int64)
+			e.EntityID = &id
+		}
+		if len(oldJSON) > 0 {
+			json.Unmarshal(oldJSON, &e.OldValues)
+		}
+		if len(newJSON) > 0 {
+			json.Unmarshal(newJSON, &e.NewValues)
+		}
+		if len(changesJSON) > 0 {
+			json.Unmarshal(changesJSON, &e.Changes)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// DiffValues returns the fields whose values differ between old and new. A nil
+// map on either side is treated as empty, so creates and deletes list every field.
+func DiffValues(oldValues, newValues map[string]interface{}) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+
+	for key, oldValue := range oldValues {
+		newValue, exists := newValues[key]
+		if newValues != nil && !exists {
+			// Field not present in the new values (e.g. a partial update body)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+
+	for key, newValue := range newValues {
+		if _, exists := oldValues[key]; exists {
+			continue
+		}
+		changes[key] = FieldChange{Old: nil, New: newValue}
+	}
+
+	return changes
+}
+
+// marshalNullableJSON encodes a value as JSON, returning nil for empty maps
+func marshalNullableJSON(value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || (v.Kind() == reflect.Map && v.Len() == 0) {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
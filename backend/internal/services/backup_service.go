@@ -0,0 +1,380 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/storage"
+)
+
+var (
+	// ErrBackupNotFound is returned for a backup name that is not stored
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupInProgress is returned while another backup or restore is running
+	ErrBackupInProgress = errors.New("a backup or restore is already running")
+)
+
+// backupKeyPrefix groups database backups in the document store
+const backupKeyPrefix = "backups/"
+
+// backupTimeFormat names backups by the UTC time they were taken
+const backupTimeFormat = "20060102T150405Z"
+
+// backupNamePattern matches the names Create gives backups
+var backupNamePattern = regexp.MustCompile(`^networth-(\d{8}T\d{6}Z)\.dump$`)
+
+// Backup is a pg_dump archive of the database kept in the document store, or
+// a copy of the database file under SQLite
+type Backup struct {
+	Name      string    `json:"name"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupService dumps the database with pg_dump into the document store,
+// restores it with pg_restore and prunes old backups. SQLite databases are
+// copied instead.
+type BackupService struct {
+	store               storage.Store
+	database            config.DatabaseConfig
+	config              config.BackupConfig
+	notificationService *NotificationService
+	// running serializes backups and restores
+	running sync.Mutex
+}
+
+// NewBackupService creates a new backup service
+func NewBackupService(store storage.Store, database config.DatabaseConfig, cfg config.BackupConfig, notificationService *NotificationService) *BackupService {
+	return &BackupService{
+		store:               store,
+		database:            database,
+		config:              cfg,
+		notificationService: notificationService,
+	}
+}
+
+// List returns the stored backups, newest first
+func (bs *BackupService) List(ctx context.Context) ([]Backup, error) {
+	objects, err := bs.store.List(ctx, backupKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := make([]Backup, 0, len(objects))
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, backupKeyPrefix)
+		match := backupNamePattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimeFormat, match[1])
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: name, SizeBytes: object.Size, CreatedAt: createdAt})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Create dumps the database and stores the archive as a new backup
+func (bs *BackupService) Create(ctx context.Context) (*Backup, error) {
+	if !bs.running.TryLock() {
+		return nil, ErrBackupInProgress
+	}
+	defer bs.running.Unlock()
+	return bs.create(ctx)
+}
+
+func (bs *BackupService) create(ctx context.Context) (*Backup, error) {
+	temp, err := os.CreateTemp("", "networth-backup-*.dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	if err := bs.dump(ctx, path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	backup := Backup{
+		Name:      "networth-" + createdAt.Format(backupTimeFormat) + ".dump",
+		SizeBytes: info.Size(),
+		CreatedAt: createdAt,
+	}
+	if err := bs.store.Put(ctx, backupKeyPrefix+backup.Name, file, info.Size(), "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("failed to store backup: %w", err)
+	}
+	fmt.Printf("INFO: Database backed up to %s (%d bytes)\n", backup.Name, backup.SizeBytes)
+	return &backup, nil
+}
+
+// dump writes a pg_dump archive of the database to path, or a copy of a
+// SQLite database
+func (bs *BackupService) dump(ctx context.Context, path string) error {
+	if bs.database.Driver == config.DriverSQLite {
+		// The copy is written to a new file
+		os.Remove(path)
+		if err := database.SQLiteBackup(ctx, bs.database.Path, path); err != nil {
+			return fmt.Errorf("failed to copy the database: %w", err)
+		}
+		return nil
+	}
+
+	err := bs.run(ctx, bs.config.PgDumpPath,
+		"--format=custom", "--no-owner", "--no-privileges", "--file="+path)
+	if err != nil {
+		return fmt.Errorf("pg_dump failed: %w", err)
+	}
+	return nil
+}
+
+// Export dumps the database to w without storing it as a backup, returning
+// the archive's size
+func (bs *BackupService) Export(ctx context.Context, w io.Writer) (int64, error) {
+	if !bs.running.TryLock() {
+		return 0, ErrBackupInProgress
+	}
+	defer bs.running.Unlock()
+
+	temp, err := os.CreateTemp("", "networth-export-*.dump")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+
+	if err := bs.dump(ctx, path); err != nil {
+		return 0, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read export file: %w", err)
+	}
+	defer file.Close()
+	size, err := io.Copy(w, file)
+	if err != nil {
+		return size, fmt.Errorf("failed to write export: %w", err)
+	}
+	return size, nil
+}
+
+// Open returns a stored backup's archive
+func (bs *BackupService) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	if !backupNamePattern.MatchString(name) {
+		return nil, ErrBackupNotFound
+	}
+	archive, err := bs.store.Get(ctx, backupKeyPrefix+name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrBackupNotFound
+	}
+	return archive, err
+}
+
+// Restore replaces the database's contents with a stored backup. The current
+// database is backed up first, so a restore can itself be undone; the name of
+// that backup is returned.
+func (bs *BackupService) Restore(ctx context.Context, name string) (*Backup, error) {
+	if !bs.running.TryLock() {
+		return nil, ErrBackupInProgress
+	}
+	defer bs.running.Unlock()
+
+	archive, err := bs.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return bs.restore(ctx, archive, name)
+}
+
+// Import replaces the database's contents with a pg_dump archive read from r,
+// such as one written by Export. Like Restore, it backs up the current
+// database first and returns that backup.
+func (bs *BackupService) Import(ctx context.Context, r io.Reader, source string) (*Backup, error) {
+	if !bs.running.TryLock() {
+		return nil, ErrBackupInProgress
+	}
+	defer bs.running.Unlock()
+	return bs.restore(ctx, r, source)
+}
+
+// restore copies archive to a file and restores the database from it after
+// backing up the current database
+func (bs *BackupService) restore(ctx context.Context, archive io.Reader, source string) (*Backup, error) {
+	file, err := os.CreateTemp("", "networth-restore-*.dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = io.Copy(file, archive)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	safety, err := bs.create(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up the current database before restoring: %w", err)
+	}
+
+	if bs.database.Driver == config.DriverSQLite {
+		if err := database.SQLiteRestore(bs.database.Path, file.Name()); err != nil {
+			return safety, fmt.Errorf("failed to restore the database: %w", err)
+		}
+		fmt.Printf("INFO: Database restored from %s\n", source)
+		return safety, nil
+	}
+
+	// --clean drops each object before recreating it, all in one transaction,
+	// so a failed restore leaves the database as it was
+	err = bs.run(ctx, bs.config.PgRestorePath,
+		"--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction",
+		"--dbname="+bs.database.DBName, file.Name())
+	if err != nil {
+		return safety, fmt.Errorf("pg_restore failed: %w", err)
+	}
+	fmt.Printf("INFO: Database restored from %s\n", source)
+	return safety, nil
+}
+
+// Delete removes a stored backup
+func (bs *BackupService) Delete(ctx context.Context, name string) error {
+	if !backupNamePattern.MatchString(name) {
+		return ErrBackupNotFound
+	}
+	if err := bs.store.Delete(ctx, backupKeyPrefix+name); err != nil {
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes backups beyond the retention count or older than the
+// retention period, always keeping the newest one, and returns their names
+func (bs *BackupService) Prune(ctx context.Context, now time.Time) ([]string, error) {
+	backups, err := bs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]string, 0)
+	cutoff := now.AddDate(0, 0, -bs.config.RetentionDays)
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := bs.config.RetentionCount > 0 && i >= bs.config.RetentionCount
+		tooOld := bs.config.RetentionDays > 0 && backup.CreatedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := bs.Delete(ctx, backup.Name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup.Name)
+	}
+	return removed, nil
+}
+
+// Run takes a backup whenever the newest one is older than interval, checking
+// now and then hourly until ctx is done, and prunes after each backup.
+// Checking the newest backup keeps restarts from taking extra backups.
+func (bs *BackupService) Run(ctx context.Context, interval time.Duration) {
+	check := func() {
+		backups, err := bs.List(ctx)
+		if err != nil {
+			fmt.Printf("WARNING: Scheduled backup check failed: %v\n", err)
+			return
+		}
+		if len(backups) > 0 && time.Since(backups[0].CreatedAt) < interval {
+			return
+		}
+
+		if _, err := bs.Create(ctx); err != nil {
+			if errors.Is(err, ErrBackupInProgress) {
+				return
+			}
+			fmt.Printf("WARNING: Scheduled backup failed: %v\n", err)
+			if notifyErr := bs.notificationService.Create("backup_failed", NotificationSeverityError,
+				"Database backup failed", err.Error()); notifyErr != nil {
+				fmt.Printf("WARNING: Failed to create backup notification: %v\n", notifyErr)
+			}
+			return
+		}
+		removed, err := bs.Prune(ctx, time.Now())
+		if err != nil {
+			fmt.Printf("WARNING: Pruning backups failed: %v\n", err)
+		}
+		if len(removed) > 0 {
+			fmt.Printf("INFO: Pruned %d old backups\n", len(removed))
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// run executes a PostgreSQL client tool against the configured database,
+// passing the connection through the libpq environment variables
+func (bs *BackupService) run(ctx context.Context, tool string, args ...string) error {
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Env = append(os.Environ(),
+		"PGHOST="+bs.database.Host,
+		"PGPORT="+strconv.Itoa(bs.database.Port),
+		"PGUSER="+bs.database.User,
+		"PGPASSWORD="+bs.database.Password,
+		"PGDATABASE="+bs.database.DBName,
+		"PGSSLMODE="+bs.database.SSLMode,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			if len(message) > 500 {
+				message = message[:500]
+			}
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// BenchmarkSixtyForty is the preset blend of 60% US stocks and 40% US bonds
+const BenchmarkSixtyForty = "60/40"
+
+// benchmarkPresets are named blends accepted in place of a symbol
+var benchmarkPresets = map[string]map[string]float64{
+	BenchmarkSixtyForty: {"SPY": 0.6, "AGG": 0.4},
+}
+
+// Benchmark is a single ETF or a blend of ETFs rebalanced every interval
+type Benchmark struct {
+	Name    string             `json:"name"`
+	Weights map[string]float64 `json:"weights"` // fractions summing to 1
+}
+
+// ParseBenchmark parses a symbol ("SPY"), a preset ("60/40") or a weighted
+// blend ("VTI:70+BND:30"). Blend weights are normalized to sum to 1.
+func ParseBenchmark(spec string) (Benchmark, error) {
+	spec = strings.ToUpper(strings.TrimSpace(spec))
+	if spec == "" {
+		return Benchmark{}, fmt.Errorf("empty benchmark")
+	}
+	if weights, ok := benchmarkPresets[spec]; ok {
+		return Benchmark{Name: spec, Weights: weights}, nil
+	}
+	if !strings.Contains(spec, ":") {
+		return Benchmark{Name: spec, Weights: map[string]float64{spec: 1}}, nil
+	}
+
+	weights := make(map[string]float64)
+	var total float64
+	// An unescaped "+" in a query string arrives as a space
+	parts := strings.FieldsFunc(spec, func(r rune) bool { return r == '+' || r == ' ' })
+	for _, part := range parts {
+		symbol, weightText, ok := strings.Cut(part, ":")
+		weight, err := strconv.ParseFloat(weightText, 64)
+		if !ok || symbol == "" || err != nil || weight <= 0 {
+			return Benchmark{}, fmt.Errorf("invalid benchmark %q: use SYMBOL or SYMBOL:weight+SYMBOL:weight", spec)
+		}
+		weights[symbol] += weight
+		total += weight
+	}
+	for symbol := range weights {
+		weights[symbol] /= total
+	}
+	return Benchmark{Name: spec, Weights: weights}, nil
+}
+
+// ReturnPoint is the cumulative return in percent from the start of the range
+type ReturnPoint struct {
+	Date             string  `json:"date"` // YYYY-MM-DD
+	CumulativeReturn float64 `json:"cumulative_return"`
+}
+
+// RelativeReturnPoint is a benchmark's cumulative return and the portfolio's
+// lead over it in percentage points
+type RelativeReturnPoint struct {
+	ReturnPoint
+	Relative float64 `json:"relative"`
+}
+
+// BenchmarkResult compares the portfolio with one benchmark. It is unavailable
+// when a component has no recorded price at the start of the range.
+type BenchmarkResult struct {
+	Benchmark
+	Available      bool                  `json:"available"`
+	MissingSymbols []string              `json:"missing_symbols,omitempty"`
+	Return         *float64              `json:"return"`
+	RelativeReturn *float64              `json:"relative_return"` // portfolio minus benchmark, in percentage points
+	Outperforming  *bool                 `json:"outperforming"`
+	Points         []RelativeReturnPoint `json:"points"`
+}
+
+// BenchmarkComparison compares the portfolio's time-weighted return with benchmarks
+type BenchmarkComparison struct {
+	From            string            `json:"from"`
+	To              string            `json:"to"`
+	Interval        string            `json:"interval"`
+	PortfolioReturn float64           `json:"portfolio_return"`
+	Portfolio       []ReturnPoint     `json:"portfolio"`
+	Benchmarks      []BenchmarkResult `json:"benchmarks"`
+}
+
+// BenchmarkService records benchmark prices and compares portfolio performance
+// against them
+type BenchmarkService struct {
+	db           *sql.DB
+	priceService *PriceService
+	gains        *GainsHistoryService
+}
+
+// NewBenchmarkService creates a benchmark service
+func NewBenchmarkService(db *sql.DB, priceService *PriceService, gains *GainsHistoryService) *BenchmarkService {
+	return &BenchmarkService{db: db, priceService: priceService, gains: gains}
+}
+
+// Run fetches the price of each symbol now and then every interval until ctx
+// is done. Fetched prices are stored in the stock price history.
+func (bs *BenchmarkService) Run(ctx context.Context, interval time.Duration, symbols []string) {
+	record := func() {
+		for _, symbol := range symbols {
+			if _, err := bs.priceService.GetCurrentPriceWithForceContext(ctx, symbol, false); err != nil {
+				fmt.Printf("WARNING: Failed to record benchmark price for %s: %v\n", symbol, err)
+			}
+		}
+	}
+
+	record()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
+
+// Compare compares the portfolio's time-weighted return between from and to
+// with each benchmark over the same snapshot dates. Portfolio returns exclude
+// contributions, so buying more shares does not count as performance. A
+// non-nil include limits the portfolio to the symbols it accepts.
+func (bs *BenchmarkService) Compare(from, to time.Time, interval string, benchmarks []Benchmark, include func(symbol string) bool) (*BenchmarkComparison, error) {
+	history, err := bs.gains.History(from, to, interval, "", include)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &BenchmarkComparison{
+		From:       history.From,
+		To:         history.To,
+		Interval:   interval,
+		Portfolio:  make([]ReturnPoint, 0, len(history.Total)),
+		Benchmarks: make([]BenchmarkResult, 0, len(benchmarks)),
+	}
+
+	dates := make([]string, len(history.Total))
+	growth := 1.0
+	for i, point := range history.Total {
+		dates[i] = point.Date
+		if i > 0 && history.Total[i-1].MarketValue > 0 {
+			growth *= 1 + point.MarketChange/history.Total[i-1].MarketValue
+		}
+		comparison.Portfolio = append(comparison.Portfolio, ReturnPoint{Date: point.Date, CumulativeReturn: roundCents((growth - 1) * 100)})
+	}
+	comparison.PortfolioReturn = roundCents((growth - 1) * 100)
+
+	for _, benchmark := range benchmarks {
+		result, err := bs.compareBenchmark(benchmark, dates, comparison.Portfolio)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Benchmarks = append(comparison.Benchmarks, *result)
+	}
+	return comparison, nil
+}
+
+// compareBenchmark computes a benchmark's cumulative return on each date
+func (bs *BenchmarkService) compareBenchmark(benchmark Benchmark, dates []string, portfolio []ReturnPoint) (*BenchmarkResult, error) {
+	result := &BenchmarkResult{Benchmark: benchmark, Points: []RelativeReturnPoint{}}
+	if len(dates) == 0 {
+		return result, nil
+	}
+
+	symbols := make([]string, 0, len(benchmark.Weights))
+	for symbol := range benchmark.Weights {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	prices := make(map[string][]float64, len(symbols))
+	for _, symbol := range symbols {
+		series, err := pricesOn(bs.db, symbol, dates)
+		if err != nil {
+			return nil, err
+		}
+		if series[0] <= 0 {
+			result.MissingSymbols = append(result.MissingSymbols, symbol)
+		}
+		prices[symbol] = series
+	}
+	if len(result.MissingSymbols) > 0 {
+		return result, nil
+	}
+
+	result.Available = true
+	growth := 1.0
+	for i, date := range dates {
+		if i > 0 {
+			var periodReturn float64
+			for _, symbol := range symbols {
+				periodReturn += benchmark.Weights[symbol] * (prices[symbol][i]/prices[symbol][i-1] - 1)
+			}
+			growth *= 1 + periodReturn
+		}
+		cumulative := (growth - 1) * 100
+		result.Points = append(result.Points, RelativeReturnPoint{
+			ReturnPoint: ReturnPoint{Date: date, CumulativeReturn: roundCents(cumulative)},
+			Relative:    roundCents(portfolio[i].CumulativeReturn - cumulative),
+		})
+	}
+
+	last := result.Points[len(result.Points)-1]
+	benchmarkReturn := last.CumulativeReturn
+	relative := last.Relative
+	outperforming := relative > 0
+	result.Return = &benchmarkReturn
+	result.RelativeReturn = &relative
+	result.Outperforming = &outperforming
+	return result, nil
+}
+
+// pricesOn returns the last recorded price of symbol by the end of each date,
+// or 0 for dates before its first recorded price
+func pricesOn(db *sql.DB, symbol string, dates []string) ([]float64, error) {
+	positions := make([]int, len(dates))
+	for i := range dates {
+		positions[i] = i
+	}
+	dialect := database.DialectOf(db)
+	rows, err := db.Query(`
+		SELECT COALESCE((
+			SELECT sp.price FROM stock_price_history sp
+			WHERE sp.symbol = $1 AND sp.timestamp < `+dialect.AddDays("d.day", "1")+`
+			ORDER BY sp.timestamp DESC
+			LIMIT 1
+		), 0)
+		FROM `+dialect.Unnest("d",
+		database.ArrayColumn{Param: "$2", Type: "date", Name: "day"},
+		database.ArrayColumn{Param: "$3", Type: "int", Name: "n"})+`
+		ORDER BY d.n
+	`, symbol, dates, positions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices for %s: %w", symbol, err)
+	}
+	defer rows.Close()
+
+	prices := make([]float64, 0, len(dates))
+	for rows.Next() {
+		var price float64
+		if err := rows.Scan(&price); err != nil {
+			return nil, fmt.Errorf("failed to scan price for %s: %w", symbol, err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
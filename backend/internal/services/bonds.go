@@ -0,0 +1,192 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrBondNotFound is returned when a bond does not exist
+var ErrBondNotFound = errors.New("bond not found")
+
+// addMonths moves a date by months, keeping its day of the month or, in
+// shorter months, moving to the last day as PostgreSQL date arithmetic does
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, date.Location())
+}
+
+// BondPortfolio is every bond, soonest maturity first, with their totals.
+// AnnualCouponIncome is the coupon income of the bonds not yet matured.
+type BondPortfolio struct {
+	Bonds                []models.Bond   `json:"bonds"`
+	TotalFaceValue       decimal.Decimal `json:"total_face_value"`
+	TotalMarketValue     decimal.Decimal `json:"total_market_value"`
+	TotalAccruedInterest decimal.Decimal `json:"total_accrued_interest"`
+	TotalValue           decimal.Decimal `json:"total_value"`
+	AnnualCouponIncome   decimal.Decimal `json:"annual_coupon_income"`
+}
+
+// BondLadderRung is the bonds maturing in one year
+type BondLadderRung struct {
+	Year         int             `json:"year"`
+	Count        int             `json:"count"`
+	FaceValue    decimal.Decimal `json:"face_value"`
+	CurrentValue decimal.Decimal `json:"current_value"`
+	Bonds        []models.Bond   `json:"bonds"`
+}
+
+// BondLadder lays bonds out by the year they mature, with every year from the
+// first maturity to the last so gaps in the ladder show. Averages are
+// weighted by face value and cover the bonds not yet matured.
+type BondLadder struct {
+	Rungs                  []BondLadderRung `json:"rungs"`
+	TotalFaceValue         decimal.Decimal  `json:"total_face_value"`
+	AverageYearsToMaturity float64          `json:"average_years_to_maturity"`
+	AverageCouponRate      float64          `json:"average_coupon_rate"`
+	MaturedCount           int              `json:"matured_count"`
+}
+
+// BondService lists bonds and lays out their maturities
+type BondService struct {
+	db *sql.DB
+}
+
+// NewBondService creates a bond service
+func NewBondService(db *sql.DB) *BondService {
+	return &BondService{db: db}
+}
+
+// List returns every bond, soonest maturity first, with their totals as of now
+func (bs *BondService) List(now time.Time) (*BondPortfolio, error) {
+	bonds, err := bs.bonds(0, now)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &BondPortfolio{Bonds: bonds}
+	for _, b := range bonds {
+		portfolio.TotalFaceValue = portfolio.TotalFaceValue.Add(b.FaceValue)
+		portfolio.TotalMarketValue = portfolio.TotalMarketValue.Add(b.MarketValue)
+		portfolio.TotalAccruedInterest = portfolio.TotalAccruedInterest.Add(b.AccruedInterest)
+		portfolio.TotalValue = portfolio.TotalValue.Add(b.CurrentValue)
+		if b.MaturityDate.After(now) {
+			portfolio.AnnualCouponIncome = portfolio.AnnualCouponIncome.Add(b.FaceValue.Mul(decimal.NewFromFloat(b.CouponRate)).Div(decimal.NewFromInt(100)))
+		}
+	}
+	portfolio.AnnualCouponIncome = portfolio.AnnualCouponIncome.Round(2)
+	return portfolio, nil
+}
+
+// Get returns a bond valued as of now
+func (bs *BondService) Get(id int, now time.Time) (*models.Bond, error) {
+	bonds, err := bs.bonds(id, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(bonds) == 0 {
+		return nil, ErrBondNotFound
+	}
+	return &bonds[0], nil
+}
+
+// Delete removes a bond
+func (bs *BondService) Delete(id int) error {
+	result, err := bs.db.Exec(`DELETE FROM bonds WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete bond: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrBondNotFound
+	}
+	return nil
+}
+
+// Ladder groups bonds by maturity year as of now
+func (bs *BondService) Ladder(now time.Time) (*BondLadder, error) {
+	bonds, err := bs.bonds(0, now)
+	if err != nil {
+		return nil, err
+	}
+	return buildBondLadder(bonds, now), nil
+}
+
+// buildBondLadder lays out bonds, ordered by maturity, by the year they mature
+func buildBondLadder(bonds []models.Bond, now time.Time) *BondLadder {
+	ladder := &BondLadder{Rungs: []BondLadderRung{}}
+	if len(bonds) == 0 {
+		return ladder
+	}
+
+	first, last := bonds[0].MaturityDate.Year(), bonds[len(bonds)-1].MaturityDate.Year()
+	for year := first; year <= last; year++ {
+		ladder.Rungs = append(ladder.Rungs, BondLadderRung{Year: year, Bonds: []models.Bond{}})
+	}
+
+	// The averages are weights rather than amounts, so they are kept in float64
+	var outstanding, yearsWeighted, couponWeighted float64
+	for _, b := range bonds {
+		rung := &ladder.Rungs[b.MaturityDate.Year()-first]
+		rung.Count++
+		rung.FaceValue = rung.FaceValue.Add(b.FaceValue)
+		rung.CurrentValue = rung.CurrentValue.Add(b.CurrentValue)
+		rung.Bonds = append(rung.Bonds, b)
+		ladder.TotalFaceValue = ladder.TotalFaceValue.Add(b.FaceValue)
+
+		if !b.MaturityDate.After(now) {
+			ladder.MaturedCount++
+			continue
+		}
+		face := b.FaceValue.InexactFloat64()
+		outstanding += face
+		yearsWeighted += face * b.MaturityDate.Sub(now).Hours() / 24 / 365.25
+		couponWeighted += face * b.CouponRate
+	}
+
+	if outstanding > 0 {
+		ladder.AverageYearsToMaturity = roundCents(yearsWeighted / outstanding)
+		ladder.AverageCouponRate = roundCents(couponWeighted / outstanding)
+	}
+	return ladder
+}
+
+// bonds returns the bond with id, or every bond when id is 0, soonest
+// maturity first, each valued with Bond.Value as of now
+func (bs *BondService) bonds(id int, now time.Time) ([]models.Bond, error) {
+	rows, err := bs.db.Query(`
+		SELECT b.id, b.account_id, b.institution_name, b.bond_name, b.bond_type, b.cusip,
+		       b.face_value, b.coupon_rate, b.coupon_frequency, b.issue_date, b.maturity_date,
+		       b.purchase_price, b.purchase_date, b.current_price,
+		       b.notes, b.created_at, b.last_updated
+		FROM bonds b
+		WHERE $1 = 0 OR b.id = $1
+		ORDER BY b.maturity_date, b.id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bonds: %w", err)
+	}
+	defer rows.Close()
+
+	bonds := []models.Bond{}
+	for rows.Next() {
+		var b models.Bond
+		err := rows.Scan(&b.ID, &b.AccountID, &b.InstitutionName, &b.BondName, &b.BondType, &b.CUSIP,
+			&b.FaceValue, &b.CouponRate, &b.CouponFrequency, &b.IssueDate, &b.MaturityDate,
+			&b.PurchasePrice, &b.PurchaseDate, &b.CurrentPrice,
+			&b.Notes, &b.CreatedAt, &b.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bond: %w", err)
+		}
+		b.Value(now)
+		bonds = append(bonds, b)
+	}
+	return bonds, rows.Err()
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidTaxYear is returned for a tax year that isn't a four-digit year
+var ErrInvalidTaxYear = errors.New("year must be a four-digit year")
+
+// CapitalGainsReport is the realized gains of one tax year, split into
+// short-term and long-term, with the tax they'd owe at the configured rates
+type CapitalGainsReport struct {
+	Year                    int            `json:"year"`
+	Sales                   []RealizedGain `json:"sales"`
+	Proceeds                float64        `json:"proceeds"`
+	CostBasis               float64        `json:"cost_basis"`
+	ShortTermGain           float64        `json:"short_term_gain"`
+	LongTermGain            float64        `json:"long_term_gain"`
+	TotalGain               float64        `json:"total_gain"`
+	EstimatedTax            float64        `json:"estimated_tax"`
+	ShortTermTaxRatePercent float64        `json:"short_term_tax_rate_percent"`
+	LongTermTaxRatePercent  float64        `json:"long_term_tax_rate_percent"`
+	Warnings                []string       `json:"warnings"`
+}
+
+// ParseTaxYear parses a YYYY year, defaulting to now's year when value is empty
+func ParseTaxYear(value string, now time.Time) (int, error) {
+	if value == "" {
+		return now.Year(), nil
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1000 || year > 9999 {
+		return 0, ErrInvalidTaxYear
+	}
+	return year, nil
+}
+
+// TaxYearBounds returns the first and last day of year
+func TaxYearBounds(year int) (time.Time, time.Time) {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+}
+
+// NewCapitalGainsReport totals the sales of year. Realized crypto sales are
+// the only sales recorded so far.
+func NewCapitalGainsReport(year int, sales []RealizedGain, warnings []string, shortRatePercent, longRatePercent float64) *CapitalGainsReport {
+	report := &CapitalGainsReport{
+		Year:                    year,
+		Sales:                   sales,
+		ShortTermTaxRatePercent: shortRatePercent,
+		LongTermTaxRatePercent:  longRatePercent,
+		Warnings:                warnings,
+	}
+	if report.Sales == nil {
+		report.Sales = []RealizedGain{}
+	}
+	if report.Warnings == nil {
+		report.Warnings = []string{}
+	}
+
+	for _, sale := range sales {
+		report.Proceeds += sale.Proceeds
+		report.CostBasis += sale.CostBasis
+		if sale.Term == GainTermLong {
+			report.LongTermGain += sale.Gain
+		} else {
+			report.ShortTermGain += sale.Gain
+		}
+	}
+	report.TotalGain = report.ShortTermGain + report.LongTermGain
+	report.EstimatedTax = capitalGainsTax(decimal.NewFromFloat(report.ShortTermGain), decimal.NewFromFloat(report.LongTermGain),
+		shortRatePercent, longRatePercent).InexactFloat64()
+
+	report.Proceeds = roundCents(report.Proceeds)
+	report.CostBasis = roundCents(report.CostBasis)
+	report.ShortTermGain = roundCents(report.ShortTermGain)
+	report.LongTermGain = roundCents(report.LongTermGain)
+	report.TotalGain = roundCents(report.TotalGain)
+	report.EstimatedTax = roundCents(report.EstimatedTax)
+	return report
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxCashFlowImportRows bounds the size of a single statement import
+const maxCashFlowImportRows = 5000
+
+// cashFlowDateLayouts are the date formats accepted in imported statements
+var cashFlowDateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "2006/01/02"}
+
+// ParseCashFlowCSV parses a bank statement export with a header row. Required
+// columns are date and either amount (negative for expenses) or debit/credit.
+// Optional columns are description, category and type (income or expense),
+// which overrides the sign of amount.
+func ParseCashFlowCSV(r io.Reader) ([]models.CashFlowImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, errors.New("missing date column")
+	}
+	_, hasAmount := columns["amount"]
+	_, hasDebit := columns["debit"]
+	_, hasCredit := columns["credit"]
+	if !hasAmount && !hasDebit && !hasCredit {
+		return nil, errors.New("missing amount column (or debit/credit columns)")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []models.CashFlowImportRow
+	occurrences := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(rows) >= maxCashFlowImportRows {
+			return nil, fmt.Errorf("at most %d rows can be imported at once", maxCashFlowImportRows)
+		}
+
+		date, err := parseCashFlowDate(field(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var amount decimal.Decimal
+		if hasAmount && field(record, "amount") != "" {
+			amount, err = parseCashFlowAmount(field(record, "amount"))
+		} else {
+			var debit, credit decimal.Decimal
+			if debit, err = parseOptionalAmount(field(record, "debit")); err == nil {
+				credit, err = parseOptionalAmount(field(record, "credit"))
+			}
+			amount = credit.Sub(debit.Abs())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		kind := models.CashFlowIncome
+		if amount.IsNegative() {
+			kind = models.CashFlowExpense
+		}
+		switch strings.ToLower(field(record, "type")) {
+		case "":
+		case models.CashFlowIncome, "credit":
+			kind = models.CashFlowIncome
+		case models.CashFlowExpense, "debit":
+			kind = models.CashFlowExpense
+		default:
+			return nil, fmt.Errorf("line %d: type must be income or expense", line)
+		}
+
+		row := models.CashFlowImportRow{
+			Date:         date,
+			Amount:       amount.Abs().Round(2),
+			Kind:         kind,
+			CategoryName: field(record, "category"),
+			Description:  field(record, "description"),
+		}
+
+		// Identical rows in one file are distinct transactions (two coffees on the
+		// same day), so the occurrence count is part of the key
+		identity := fmt.Sprintf("%s|%s|%s|%s", row.Date.Format("2006-01-02"), row.Kind, row.Amount.StringFixed(2), strings.ToLower(row.Description))
+		occurrences[identity]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", identity, occurrences[identity])))
+		row.ImportKey = hex.EncodeToString(sum[:])
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("no transactions found")
+	}
+	return rows, nil
+}
+
+func parseCashFlowDate(value string) (time.Time, error) {
+	for _, layout := range cashFlowDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseCashFlowAmount accepts currency symbols, thousands separators and
+// accounting-style parentheses for negatives
+func parseCashFlowAmount(value string) (decimal.Decimal, error) {
+	cleaned := strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+	negative := strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")")
+	cleaned = strings.Trim(cleaned, "()")
+
+	amount, err := decimal.NewFromString(cleaned)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return amount, nil
+}
+
+func parseOptionalAmount(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	return parseCashFlowAmount(value)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"math"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// How often a cash account's interest_rate compounds. The default, monthly,
+// is what balance projections have always assumed; annually treats the rate
+// as an APY already.
+const (
+	CompoundingDaily     = "daily"
+	CompoundingMonthly   = "monthly"
+	CompoundingQuarterly = "quarterly"
+	CompoundingAnnually  = "annually"
+)
+
+// compoundingPeriods is the number of compounding periods per year
+var compoundingPeriods = map[string]float64{
+	CompoundingDaily:     365,
+	CompoundingMonthly:   12,
+	CompoundingQuarterly: 4,
+	CompoundingAnnually:  1,
+}
+
+// IsCompounding reports whether compounding is a supported compounding frequency
+func IsCompounding(compounding string) bool {
+	_, ok := compoundingPeriods[compounding]
+	return ok
+}
+
+// AnnualPercentageYield returns the APY, in percent, of an annual rate in
+// percent compounded at the given frequency
+func AnnualPercentageYield(ratePercent float64, compounding string) float64 {
+	n := compoundingPeriods[compounding]
+	return (math.Pow(1+ratePercent/100/n, n) - 1) * 100
+}
+
+// monthlyGrowthRate is the growth per month equivalent to an APY in percent
+func monthlyGrowthRate(apyPercent float64) float64 {
+	return math.Pow(1+apyPercent/100, 1.0/12) - 1
+}
+
+// CashInterestSummary is the interest cash accounts are projected to earn over the next year
+type CashInterestSummary struct {
+	Compounding             string  `json:"compounding"`
+	TotalBalance            float64 `json:"total_balance"`
+	InterestBearingBalance  float64 `json:"interest_bearing_balance"`
+	ProjectedAnnualInterest float64 `json:"projected_annual_interest"`
+	// YieldOnCash is projected interest as a percent of all cash, including
+	// accounts that pay nothing
+	YieldOnCash float64 `json:"yield_on_cash"`
+}
+
+// ProjectCashInterest returns the holdings with their APY and projected
+// annual interest filled in, and the totals across them. Interest is on the
+// current balance, without future contributions. The holdings passed in are
+// not modified.
+func ProjectCashInterest(holdings []models.CashHolding, compounding string) ([]models.CashHolding, CashInterestSummary) {
+	summary := CashInterestSummary{Compounding: compounding}
+	projected := make([]models.CashHolding, len(holdings))
+	for i, h := range holdings {
+		rate := 0.0
+		if h.InterestRate != nil {
+			rate = *h.InterestRate
+		}
+		if apy, interest := summary.add(h.CurrentBalance.InexactFloat64(), rate); apy > 0 {
+			apy = roundPercent(apy)
+			interest := decimal.NewFromFloat(interest).Round(2)
+			h.APY = &apy
+			h.ProjectedAnnualInterest = &interest
+		}
+		projected[i] = h
+	}
+	return projected, summary.rounded()
+}
+
+// add counts an account's balance and returns its APY and projected annual interest
+func (s *CashInterestSummary) add(balance, ratePercent float64) (apy, interest float64) {
+	s.TotalBalance += balance
+	if ratePercent <= 0 {
+		return 0, 0
+	}
+	apy = AnnualPercentageYield(ratePercent, s.Compounding)
+	interest = balance * apy / 100
+	s.InterestBearingBalance += balance
+	s.ProjectedAnnualInterest += interest
+	return apy, interest
+}
+
+// rounded returns the summary with its yield computed and amounts rounded to cents
+func (s CashInterestSummary) rounded() CashInterestSummary {
+	if s.TotalBalance > 0 {
+		s.YieldOnCash = roundPercent(s.ProjectedAnnualInterest / s.TotalBalance * 100)
+	}
+	s.TotalBalance = roundCents(s.TotalBalance)
+	s.InterestBearingBalance = roundCents(s.InterestBearingBalance)
+	s.ProjectedAnnualInterest = roundCents(s.ProjectedAnnualInterest)
+	return s
+}
+
+// roundPercent rounds a percentage to four decimals, enough for rates quoted in basis points
+func roundPercent(percent float64) float64 {
+	return math.Round(percent*10000) / 10000
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+)
+
+// ConcentrationRisk is a symbol whose combined value exceeds the concentration
+// threshold. Unvested equity is counted because it is exposure to the same
+// company even though it is excluded from net worth. Crypto is counted by coin
+// across wallets and exchanges.
+type ConcentrationRisk struct {
+	Symbol              string  `json:"symbol"`
+	HoldingsValue       float64 `json:"holdings_value"`
+	CryptoValue         float64 `json:"crypto_value"`
+	VestedEquityValue   float64 `json:"vested_equity_value"`
+	UnvestedEquityValue float64 `json:"unvested_equity_value"`
+	TotalValue          float64 `json:"total_value"`
+	PercentOfAssets     float64 `json:"percent_of_assets"`
+	ThresholdPercent    float64 `json:"threshold_percent"`
+}
+
+// ConcentrationRiskService flags single symbols that make up too much of total
+// assets and notifies once when a symbol crosses the threshold
+type ConcentrationRiskService struct {
+	db               *sql.DB
+	thresholdPercent float64
+	cashEquivalents  []string
+	notifications    *NotificationService
+}
+
+// NewConcentrationRiskService creates a concentration risk service. A threshold
+// of 0 disables the checks. Coins in cashEquivalents, such as stablecoins
+// counted as cash, are never flagged.
+func NewConcentrationRiskService(db *sql.DB, thresholdPercent float64, cashEquivalents []string, notifications *NotificationService) *ConcentrationRiskService {
+	if cashEquivalents == nil {
+		cashEquivalents = []string{}
+	}
+	return &ConcentrationRiskService{
+		db:               db,
+		thresholdPercent: thresholdPercent,
+		cashEquivalents:  cashEquivalents,
+		notifications:    notifications,
+	}
+}
+
+// Assess returns the symbols above the threshold, largest first. Percentages
+// are of total assets plus unvested equity, so the unvested shares counted for
+// a symbol are also counted in the whole.
+func (crs *ConcentrationRiskService) Assess(breakdown models.NetWorthBreakdown) ([]ConcentrationRisk, error) {
+	risks := []ConcentrationRisk{}
+	total := breakdown.TotalAssets().Add(breakdown.UnvestedEquityValue).InexactFloat64()
+	if crs.thresholdPercent <= 0 || total <= 0 {
+		return risks, nil
+	}
+
+	rows, err := crs.db.Query(`
+		WITH latest_crypto_prices AS (
+			SELECT symbol, price_usd
+			FROM (
+				SELECT symbol, price_usd, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY last_updated DESC) AS position
+				FROM crypto_prices
+			) ranked
+			WHERE position = 1
+		)
+		SELECT symbol, SUM(holdings_value), SUM(crypto_value), SUM(vested_value), SUM(unvested_value)
+		FROM (
+			SELECT UPPER(symbol) AS symbol, shares_owned * current_price AS holdings_value,
+			       0 AS crypto_value, 0 AS vested_value, 0 AS unvested_value
+			FROM stock_holdings
+			WHERE current_price > 0 AND shares_owned > 0
+
+			UNION ALL
+
+			SELECT UPPER(ch.crypto_symbol), 0, ch.balance_tokens * lp.price_usd, 0, 0
+			FROM crypto_holdings ch
+			JOIN latest_crypto_prices lp ON lp.symbol = ch.crypto_symbol
+			WHERE ch.balance_tokens > 0 AND NOT `+database.DialectOf(crs.db).InArray("UPPER(ch.crypto_symbol)", "$1")+`
+
+			UNION ALL
+
+			SELECT UPPER(company_symbol), 0, 0,
+			       COALESCE(vested_shares, 0) * current_price, COALESCE(unvested_shares, 0) * current_price
+			FROM equity_grants
+			WHERE current_price > 0
+		) positions
+		GROUP BY symbol
+	`, crs.cashEquivalents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch positions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var risk ConcentrationRisk
+		if err := rows.Scan(&risk.Symbol, &risk.HoldingsValue, &risk.CryptoValue, &risk.VestedEquityValue, &risk.UnvestedEquityValue); err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		risk.TotalValue = risk.HoldingsValue + risk.CryptoValue + risk.VestedEquityValue + risk.UnvestedEquityValue
+		risk.PercentOfAssets = risk.TotalValue / total * 100
+		if risk.PercentOfAssets <= crs.thresholdPercent {
+			continue
+		}
+
+		risk.HoldingsValue = roundCents(risk.HoldingsValue)
+		risk.CryptoValue = roundCents(risk.CryptoValue)
+		risk.VestedEquityValue = roundCents(risk.VestedEquityValue)
+		risk.UnvestedEquityValue = roundCents(risk.UnvestedEquityValue)
+		risk.TotalValue = roundCents(risk.TotalValue)
+		risk.PercentOfAssets = roundCents(risk.PercentOfAssets)
+		risk.ThresholdPercent = crs.thresholdPercent
+		risks = append(risks, risk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch positions: %w", err)
+	}
+
+	sort.Slice(risks, func(i, j int) bool { return risks[i].PercentOfAssets > risks[j].PercentOfAssets })
+	return risks, nil
+}
+
+// Run checks concentration now and then every interval until ctx is done
+func (crs *ConcentrationRiskService) Run(ctx context.Context, interval time.Duration, breakdown func() (models.NetWorthBreakdown, error)) {
+	check := func() {
+		b, err := breakdown()
+		if err != nil {
+			fmt.Printf("WARNING: Concentration check failed: %v\n", err)
+			return
+		}
+		if err := crs.Check(b); err != nil {
+			fmt.Printf("WARNING: Concentration check failed: %v\n", err)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// Check creates a notification for each symbol that has crossed the threshold
+// since the last check. A symbol that drops back below the threshold is
+// cleared, so it is notified again if it crosses a second time.
+func (crs *ConcentrationRiskService) Check(breakdown models.NetWorthBreakdown) error {
+	risks, err := crs.Assess(breakdown)
+	if err != nil {
+		return err
+	}
+
+	symbols := make([]string, 0, len(risks))
+	for _, risk := range risks {
+		symbols = append(symbols, risk.Symbol)
+	}
+	if _, err := crs.db.Exec(`DELETE FROM concentration_alerts WHERE NOT `+database.DialectOf(crs.db).InArray("symbol", "$1"), symbols); err != nil {
+		return fmt.Errorf("failed to clear concentration alerts: %w", err)
+	}
+
+	for _, risk := range risks {
+		result, err := crs.db.Exec(`
+			INSERT INTO concentration_alerts (symbol, percent_of_assets, alerted_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (symbol) DO NOTHING
+		`, risk.Symbol, risk.PercentOfAssets, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to record concentration alert for %s: %w", risk.Symbol, err)
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 0 || crs.notifications == nil {
+			continue
+		}
+
+		crs.notifications.Create(
+			"concentration_risk",
+			NotificationSeverityWarning,
+			fmt.Sprintf("%s is %.1f%% of your assets", risk.Symbol, risk.PercentOfAssets),
+			fmt.Sprintf("%s positions worth $%.2f (holdings $%.2f, crypto $%.2f, vested equity $%.2f, unvested equity $%.2f) exceed the %g%% concentration threshold. Consider diversifying.",
+				risk.Symbol, risk.TotalValue, risk.HoldingsValue, risk.CryptoValue, risk.VestedEquityValue, risk.UnvestedEquityValue, risk.ThresholdPercent),
+		)
+	}
+	return nil
+}
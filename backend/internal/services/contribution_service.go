@@ -0,0 +1,601 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/sqlbuilder"
+
+	"github.com/shopspring/decimal"
+)
+
+// Contribution transaction statuses
+const (
+	ContributionPending = "pending"
+	ContributionApplied = "applied"
+	ContributionSkipped = "skipped"
+)
+
+// maxContributionCatchUp bounds how many missed months one run schedules for a
+// single account, so a long outage does not apply years of contributions at once
+const maxContributionCatchUp = 12
+
+var (
+	// ErrContributionNotFound is returned when a schedule or transaction does not exist
+	ErrContributionNotFound = errors.New("contribution not found")
+	// ErrContributionNotPending is returned when confirming or skipping a
+	// transaction that was already applied or skipped
+	ErrContributionNotPending = errors.New("contribution is not pending")
+)
+
+// RecurringContribution is the monthly contribution schedule of a cash or
+// brokerage account. The amount is the account's monthly_contribution.
+type RecurringContribution struct {
+	ID                   int        `json:"id"`
+	CashHoldingID        int        `json:"cash_holding_id"`
+	InstitutionName      string     `json:"institution_name"`
+	AccountName          string     `json:"account_name"`
+	AccountType          string     `json:"account_type"`
+	Amount               float64    `json:"amount"`
+	DayOfMonth           int        `json:"day_of_month"`
+	RequiresConfirmation bool       `json:"requires_confirmation"`
+	Active               bool       `json:"active"`
+	StartDate            time.Time  `json:"start_date"`
+	LastScheduledDate    *time.Time `json:"last_scheduled_date"`
+	NextDueDate          *time.Time `json:"next_due_date"`
+}
+
+// RecurringContributionUpdate changes a schedule; nil fields are left as they are
+type RecurringContributionUpdate struct {
+	DayOfMonth           *int  `json:"day_of_month" binding:"omitempty,min=1,max=28"`
+	RequiresConfirmation *bool `json:"requires_confirmation"`
+	Active               *bool `json:"active"`
+}
+
+// ContributionTransaction is one scheduled contribution and what happened to it
+type ContributionTransaction struct {
+	ID                      int        `json:"id"`
+	RecurringContributionID int        `json:"recurring_contribution_id"`
+	CashHoldingID           int        `json:"cash_holding_id"`
+	InstitutionName         string     `json:"institution_name"`
+	AccountName             string     `json:"account_name"`
+	ScheduledDate           time.Time  `json:"scheduled_date"`
+	Amount                  float64    `json:"amount"`
+	Status                  string     `json:"status"`
+	BalanceBefore           *float64   `json:"balance_before"`
+	BalanceAfter            *float64   `json:"balance_after"`
+	AppliedAt               *time.Time `json:"applied_at"`
+	CreatedAt               time.Time  `json:"created_at"`
+}
+
+// ContributionRunResult counts what a run of the contribution job did
+type ContributionRunResult struct {
+	Applied int `json:"applied"`
+	Pending int `json:"pending"`
+}
+
+// ContributionProjectionPoint is the projected cash and brokerage total at the end of a month
+type ContributionProjectionPoint struct {
+	Month                   string  `json:"month"` // YYYY-MM
+	Contributions           float64 `json:"contributions"`
+	CumulativeContributions float64 `json:"cumulative_contributions"`
+	Growth                  float64 `json:"growth"`
+	Balance                 float64 `json:"balance"`
+}
+
+// ContributionProjection projects cash and brokerage balances forward using
+// each account's interest rate and active recurring contribution
+type ContributionProjection struct {
+	Months                 int                           `json:"months"`
+	Interest               CashInterestSummary           `json:"interest"`
+	StartingBalance        float64                       `json:"starting_balance"`
+	MonthlyContributions   float64                       `json:"monthly_contributions"`
+	MonthlyCashFlowSavings float64                       `json:"monthly_cash_flow_savings"` // included in MonthlyContributions
+	MonthlyEmployerMatch   float64                       `json:"monthly_employer_match"`    // included in MonthlyContributions
+	AnnualEmployerMatch    float64                       `json:"annual_employer_match"`
+	EmployerMatch          []EmployerMatchProjection     `json:"employer_match"`
+	TotalContributions     float64                       `json:"total_contributions"`
+	TotalGrowth            float64                       `json:"total_growth"`
+	ProjectedBalance       float64                       `json:"projected_balance"`
+	Points                 []ContributionProjectionPoint `json:"points"`
+}
+
+// ContributionService schedules monthly contributions from cash_holdings and
+// applies them to account balances, directly or after confirmation
+type ContributionService struct {
+	db                  *sql.DB
+	notifications       *NotificationService
+	requireConfirmation bool
+}
+
+// NewContributionService creates a contribution service. requireConfirmation is
+// the default for schedules created for new accounts.
+func NewContributionService(db *sql.DB, notifications *NotificationService, requireConfirmation bool) *ContributionService {
+	return &ContributionService{db: db, notifications: notifications, requireConfirmation: requireConfirmation}
+}
+
+// Run applies due contributions now and then every interval until ctx is done.
+// onApplied is called after a run that changed any balance.
+func (cs *ContributionService) Run(ctx context.Context, interval time.Duration, onApplied func()) {
+	run := func() {
+		result, err := cs.ProcessDue(time.Now())
+		if err != nil {
+			fmt.Printf("WARNING: Recurring contribution run failed: %v\n", err)
+			return
+		}
+		if result.Applied > 0 && onApplied != nil {
+			onApplied()
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// ProcessDue creates a transaction for every contribution due on or before
+// asOf. Schedules that require confirmation leave them pending; the rest are
+// added to the account balance immediately.
+func (cs *ContributionService) ProcessDue(asOf time.Time) (*ContributionRunResult, error) {
+	if err := cs.ensureSchedules(); err != nil {
+		return nil, err
+	}
+
+	today := dateOnly(asOf)
+
+	// Paused schedules and accounts without a contribution skip the months they
+	// sit out rather than catching up when resumed
+	if _, err := cs.db.Exec(`
+		UPDATE recurring_contributions AS rc SET last_scheduled_date = $1
+		FROM cash_holdings ch
+		WHERE ch.id = rc.cash_holding_id
+		  AND (NOT rc.active OR COALESCE(ch.monthly_contribution, 0) <= 0)
+	`, today); err != nil {
+		return nil, fmt.Errorf("failed to advance inactive schedules: %w", err)
+	}
+
+	schedules, err := cs.listSchedules("rc.active AND ch.monthly_contribution > 0")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContributionRunResult{}
+	for _, schedule := range schedules {
+		dates := contributionDueDates(schedule.StartDate, schedule.LastScheduledDate, schedule.DayOfMonth, today)
+		if len(dates) == 0 {
+			continue
+		}
+
+		applied, pending, err := cs.scheduleContributions(schedule, dates)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to schedule contributions for cash holding %d: %v\n", schedule.CashHoldingID, err)
+			continue
+		}
+		result.Applied += applied
+		result.Pending += pending
+	}
+
+	if result.Applied > 0 || result.Pending > 0 {
+		fmt.Printf("INFO: Recurring contributions: %d applied, %d awaiting confirmation\n", result.Applied, result.Pending)
+	}
+	if result.Pending > 0 && cs.notifications != nil {
+		cs.notifications.Create(
+			"contribution_pending",
+			NotificationSeverityInfo,
+			fmt.Sprintf("%d contribution(s) awaiting confirmation", result.Pending),
+			"Scheduled monthly contributions are due. Confirm them to add them to your balances, or skip them if they did not happen.",
+		)
+	}
+
+	return result, nil
+}
+
+// scheduleContributions records the due dates of one schedule in a single
+// transaction, applying them unless the schedule requires confirmation
+func (cs *ContributionService) scheduleContributions(schedule RecurringContribution, dates []time.Time) (applied, pending int, err error) {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, date := range dates {
+		var id int
+		err := tx.QueryRow(`
+			INSERT INTO contribution_transactions (recurring_contribution_id, cash_holding_id, scheduled_date, amount)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (cash_holding_id, scheduled_date) DO NOTHING
+			RETURNING id
+		`, schedule.ID, schedule.CashHoldingID, date, schedule.Amount).Scan(&id)
+		if err == sql.ErrNoRows {
+			// Already scheduled by a concurrent run
+			continue
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to record contribution: %w", err)
+		}
+
+		if schedule.RequiresConfirmation {
+			pending++
+			continue
+		}
+		if err := applyContribution(tx, id, schedule.CashHoldingID, schedule.Amount); err != nil {
+			return 0, 0, err
+		}
+		applied++
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE recurring_contributions SET last_scheduled_date = $1 WHERE id = $2
+	`, dates[len(dates)-1], schedule.ID); err != nil {
+		return 0, 0, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit contributions: %w", err)
+	}
+	return applied, pending, nil
+}
+
+// applyContribution adds a contribution to its account balance and marks it applied
+func applyContribution(tx *sql.Tx, id, cashHoldingID int, amount float64) error {
+	var balanceAfter float64
+	err := tx.QueryRow(`
+		UPDATE cash_holdings SET current_balance = current_balance + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING current_balance
+	`, amount, cashHoldingID).Scan(&balanceAfter)
+	if err != nil {
+		return fmt.Errorf("failed to update balance of cash holding %d: %w", cashHoldingID, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE contribution_transactions
+		SET status = $1, balance_before = $2, balance_after = $3, applied_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, ContributionApplied, balanceAfter-amount, balanceAfter, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark contribution %d applied: %w", id, err)
+	}
+	return nil
+}
+
+// Confirm applies a pending contribution to its account balance
+func (cs *ContributionService) Confirm(id int) (*ContributionTransaction, error) {
+	return cs.resolvePending(id, true)
+}
+
+// Skip marks a pending contribution as not made, leaving the balance unchanged
+func (cs *ContributionService) Skip(id int) (*ContributionTransaction, error) {
+	return cs.resolvePending(id, false)
+}
+
+func (cs *ContributionService) resolvePending(id int, apply bool) (*ContributionTransaction, error) {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var cashHoldingID int
+	var amount float64
+	var status string
+	err = tx.QueryRow(`
+		SELECT cash_holding_id, amount, status FROM contribution_transactions WHERE id = $1
+	`+database.DialectOf(cs.db).ForUpdate(), id).Scan(&cashHoldingID, &amount, &status)
+	if err == sql.ErrNoRows {
+		return nil, ErrContributionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load contribution: %w", err)
+	}
+	if status != ContributionPending {
+		return nil, ErrContributionNotPending
+	}
+
+	if apply {
+		err = applyContribution(tx, id, cashHoldingID, amount)
+	} else {
+		_, err = tx.Exec(`UPDATE contribution_transactions SET status = $1 WHERE id = $2`, ContributionSkipped, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit contribution: %w", err)
+	}
+
+	transactions, err := cs.listTransactions("ct.id = $1", []interface{}{id}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, ErrContributionNotFound
+	}
+	return &transactions[0], nil
+}
+
+// ListSchedules returns the contribution schedule of every account that has one,
+// creating schedules for accounts that gained a monthly contribution
+func (cs *ContributionService) ListSchedules() ([]RecurringContribution, error) {
+	if err := cs.ensureSchedules(); err != nil {
+		return nil, err
+	}
+	return cs.listSchedules("true")
+}
+
+// UpdateSchedule changes the day, confirmation requirement or active state of a schedule
+func (cs *ContributionService) UpdateSchedule(id int, update RecurringContributionUpdate) (*RecurringContribution, error) {
+	result, err := cs.db.Exec(`
+		UPDATE recurring_contributions SET
+			day_of_month = COALESCE($1, day_of_month),
+			requires_confirmation = COALESCE($2, requires_confirmation),
+			active = COALESCE($3, active),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, update.DayOfMonth, update.RequiresConfirmation, update.Active, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update contribution schedule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrContributionNotFound
+	}
+
+	schedules, err := cs.listSchedules(fmt.Sprintf("rc.id = %d", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrContributionNotFound
+	}
+	return &schedules[0], nil
+}
+
+// ListTransactions returns contribution transactions, newest first, optionally
+// filtered by status and account
+func (cs *ContributionService) ListTransactions(status string, cashHoldingID int, limit int) ([]ContributionTransaction, error) {
+	var where sqlbuilder.Where
+	var args sqlbuilder.Args
+	if status != "" {
+		where.Compare(&args, "ct.status", "=", status)
+	}
+	if cashHoldingID > 0 {
+		where.Compare(&args, "ct.cash_holding_id", "=", cashHoldingID)
+	}
+	return cs.listTransactions(where.String(), args, limit)
+}
+
+// Project projects cash and brokerage balances months ahead, growing each
+// account by its interest rate compounded at the given frequency plus its
+// active contribution. cashFlowSavings is added each month as uninvested
+// savings on top of the account contributions.
+func (cs *ContributionService) Project(months int, from time.Time, cashFlowSavings float64, compounding string) (*ContributionProjection, error) {
+	matches, err := employerMatchProjections(cs.db)
+	if err != nil {
+		return nil, err
+	}
+	matchByAccount := make(map[int]float64, len(matches))
+	for _, m := range matches {
+		matchByAccount[m.CashHoldingID] = m.ProjectedAnnualMatch / 12
+	}
+
+	rows, err := cs.db.Query(`
+		SELECT ch.id, ch.current_balance,
+		       COALESCE(ch.interest_rate, 0),
+		       CASE WHEN rc.id IS NULL OR rc.active THEN COALESCE(ch.monthly_contribution, 0) ELSE 0 END
+		FROM cash_holdings ch
+		LEFT JOIN recurring_contributions rc ON rc.cash_holding_id = ch.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cash holdings: %w", err)
+	}
+	defer rows.Close()
+
+	type account struct {
+		balance, monthlyRate, contribution float64
+	}
+	var accounts []account
+	projection := &ContributionProjection{Months: months, EmployerMatch: matches, Points: make([]ContributionProjectionPoint, 0, months)}
+	projection.Interest.Compounding = compounding
+	for rows.Next() {
+		var a account
+		var id int
+		var annualRate float64
+		if err := rows.Scan(&id, &a.balance, &annualRate, &a.contribution); err != nil {
+			return nil, fmt.Errorf("failed to scan cash holding: %w", err)
+		}
+		apy, _ := projection.Interest.add(a.balance, annualRate)
+		a.monthlyRate = monthlyGrowthRate(apy)
+		// The employer's match lands in the account alongside each contribution
+		a.contribution += matchByAccount[id]
+		projection.MonthlyEmployerMatch += matchByAccount[id]
+		accounts = append(accounts, a)
+		projection.StartingBalance += a.balance
+		projection.MonthlyContributions += a.contribution
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load cash holdings: %w", err)
+	}
+	if cashFlowSavings != 0 {
+		accounts = append(accounts, account{contribution: cashFlowSavings})
+		projection.MonthlyContributions += cashFlowSavings
+		projection.MonthlyCashFlowSavings = roundCents(cashFlowSavings)
+	}
+
+	firstOfMonth := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for m := 1; m <= months; m++ {
+		point := ContributionProjectionPoint{Month: firstOfMonth.AddDate(0, m, 0).Format("2006-01")}
+		for i := range accounts {
+			growth := accounts[i].balance * accounts[i].monthlyRate
+			accounts[i].balance += growth + accounts[i].contribution
+			point.Growth += growth
+			point.Contributions += accounts[i].contribution
+			point.Balance += accounts[i].balance
+		}
+		projection.TotalContributions += point.Contributions
+		projection.TotalGrowth += point.Growth
+		point.CumulativeContributions = roundCents(projection.TotalContributions)
+		point.Contributions = roundCents(point.Contributions)
+		point.Growth = roundCents(point.Growth)
+		point.Balance = roundCents(point.Balance)
+		projection.Points = append(projection.Points, point)
+	}
+
+	projection.Interest = projection.Interest.rounded()
+	projection.AnnualEmployerMatch = roundCents(projection.MonthlyEmployerMatch * 12)
+	projection.MonthlyEmployerMatch = roundCents(projection.MonthlyEmployerMatch)
+	projection.ProjectedBalance = projection.StartingBalance + projection.TotalContributions + projection.TotalGrowth
+	projection.ProjectedBalance = roundCents(projection.ProjectedBalance)
+	projection.TotalContributions = roundCents(projection.TotalContributions)
+	projection.TotalGrowth = roundCents(projection.TotalGrowth)
+	return projection, nil
+}
+
+// ensureSchedules creates a default schedule for each account that has a
+// monthly contribution but no schedule yet. Schedules start today, so existing
+// contributions are never backfilled.
+func (cs *ContributionService) ensureSchedules() error {
+	_, err := cs.db.Exec(`
+		INSERT INTO recurring_contributions (cash_holding_id, requires_confirmation)
+		SELECT id, $1 FROM cash_holdings WHERE monthly_contribution > 0
+		ON CONFLICT (cash_holding_id) DO NOTHING
+	`, cs.requireConfirmation)
+	if err != nil {
+		return fmt.Errorf("failed to create contribution schedules: %w", err)
+	}
+	return nil
+}
+
+// listSchedules loads schedules matching condition, which always comes from service code
+func (cs *ContributionService) listSchedules(condition string) ([]RecurringContribution, error) {
+	rows, err := cs.db.Query(fmt.Sprintf(`
+		SELECT rc.id, rc.cash_holding_id, ch.institution_name, ch.account_name, ch.account_type,
+		       COALESCE(ch.monthly_contribution, 0), rc.day_of_month, rc.requires_confirmation,
+		       rc.active, rc.start_date, rc.last_scheduled_date
+		FROM recurring_contributions rc
+		JOIN cash_holdings ch ON ch.id = rc.cash_holding_id
+		WHERE %s
+		ORDER BY ch.institution_name, ch.account_name
+	`, condition))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contribution schedules: %w", err)
+	}
+	defer rows.Close()
+
+	today := dateOnly(time.Now())
+	schedules := []RecurringContribution{}
+	for rows.Next() {
+		var s RecurringContribution
+		var last sql.NullTime
+		if err := rows.Scan(&s.ID, &s.CashHoldingID, &s.InstitutionName, &s.AccountName, &s.AccountType,
+			&s.Amount, &s.DayOfMonth, &s.RequiresConfirmation, &s.Active, &s.StartDate, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan contribution schedule: %w", err)
+		}
+		if last.Valid {
+			s.LastScheduledDate = &last.Time
+		}
+		if s.Active && s.Amount > 0 {
+			next := nextContributionDate(s.StartDate, s.LastScheduledDate, s.DayOfMonth)
+			if next.Before(today) {
+				next = today
+			}
+			s.NextDueDate = &next
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// listTransactions loads transactions matching condition, which always comes from service code
+func (cs *ContributionService) listTransactions(condition string, args sqlbuilder.Args, limit int) ([]ContributionTransaction, error) {
+	limitPlaceholder := args.Bind(limit)
+	rows, err := cs.db.Query(fmt.Sprintf(`
+		SELECT ct.id, ct.recurring_contribution_id, ct.cash_holding_id, ch.institution_name, ch.account_name,
+		       ct.scheduled_date, ct.amount, ct.status, ct.balance_before, ct.balance_after,
+		       ct.applied_at, ct.created_at
+		FROM contribution_transactions ct
+		JOIN cash_holdings ch ON ch.id = ct.cash_holding_id
+		WHERE %s
+		ORDER BY ct.scheduled_date DESC, ct.id DESC
+		LIMIT %s
+	`, condition, limitPlaceholder), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contribution transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []ContributionTransaction{}
+	for rows.Next() {
+		var t ContributionTransaction
+		var before, after sql.NullFloat64
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&t.ID, &t.RecurringContributionID, &t.CashHoldingID, &t.InstitutionName, &t.AccountName,
+			&t.ScheduledDate, &t.Amount, &t.Status, &before, &after, &appliedAt, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan contribution transaction: %w", err)
+		}
+		if before.Valid {
+			t.BalanceBefore = &before.Float64
+		}
+		if after.Valid {
+			t.BalanceAfter = &after.Float64
+		}
+		if appliedAt.Valid {
+			t.AppliedAt = &appliedAt.Time
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// nextContributionDate returns the first day-of-month date on or after the
+// schedule start and after the last scheduled date
+func nextContributionDate(start time.Time, last *time.Time, day int) time.Time {
+	from := dateOnly(start)
+	if last != nil && !last.Before(from) {
+		from = dateOnly(*last).AddDate(0, 0, 1)
+	}
+	next := time.Date(from.Year(), from.Month(), day, 0, 0, 0, 0, time.UTC)
+	if next.Before(from) {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next
+}
+
+// contributionDueDates lists the unscheduled contribution dates up to asOf,
+// oldest first and at most maxContributionCatchUp of them
+func contributionDueDates(start time.Time, last *time.Time, day int, asOf time.Time) []time.Time {
+	var dates []time.Time
+	for due := nextContributionDate(start, last, day); !due.After(asOf) && len(dates) < maxContributionCatchUp; due = due.AddDate(0, 1, 0) {
+		dates = append(dates, due)
+	}
+	return dates
+}
+
+// dateOnly truncates t to midnight UTC on its calendar date
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// centsOf rounds a decimal amount to whole cents for a float64 field
+func centsOf(amount decimal.Decimal) float64 {
+	return amount.Round(2).InexactFloat64()
+}
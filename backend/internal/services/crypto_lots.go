@@ -0,0 +1,703 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// Crypto transaction types
+const (
+	CryptoTransactionBuy  = "buy"
+	CryptoTransactionSell = "sell"
+	// CryptoTransactionReward is a staking reward. It is income at its price
+	// when received, which becomes the cost basis of the tokens.
+	CryptoTransactionReward = "reward"
+)
+
+// Holding periods of a realized gain. Lots held more than a year are long-term.
+const (
+	GainTermShort = "short"
+	GainTermLong  = "long"
+)
+
+// tokenEpsilon absorbs rounding in token quantities, which are stored to
+// eight decimal places
+const tokenEpsilon = 1e-9
+
+var (
+	// ErrCryptoHoldingNotFound is returned when a crypto holding does not exist
+	ErrCryptoHoldingNotFound = errors.New("crypto holding not found")
+	// ErrCryptoTransactionNotFound is returned when a crypto transaction does not exist
+	ErrCryptoTransactionNotFound = errors.New("crypto transaction not found")
+	// ErrInvalidCryptoTransaction is returned for an unparseable date or a
+	// sale of more tokens than are held
+	ErrInvalidCryptoTransaction = errors.New("invalid crypto transaction")
+)
+
+// CryptoTransaction is a purchase, sale or staking reward of tokens in a
+// crypto holding
+type CryptoTransaction struct {
+	ID              int       `json:"id"`
+	HoldingID       int       `json:"crypto_holding_id"`
+	CryptoSymbol    string    `json:"crypto_symbol"`
+	TransactionType string    `json:"transaction_type"`
+	Quantity        float64   `json:"quantity"`
+	PriceUSD        float64   `json:"price_usd"`
+	FeeUSD          float64   `json:"fee_usd"`
+	TransactionDate time.Time `json:"transaction_date"`
+	Notes           *string   `json:"notes"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CryptoTransactionInput records a purchase, sale or reward. PriceUSD is per
+// token; a purchase's fee adds to its cost basis and a sale's comes off its
+// proceeds. TransactionDate is YYYY-MM-DD.
+type CryptoTransactionInput struct {
+	HoldingID       int     `json:"crypto_holding_id" binding:"required"`
+	TransactionType string  `json:"transaction_type" binding:"required,oneof=buy sell reward"`
+	Quantity        float64 `json:"quantity" binding:"required,gt=0"`
+	PriceUSD        float64 `json:"price_usd" binding:"gte=0"`
+	FeeUSD          float64 `json:"fee_usd" binding:"gte=0"`
+	TransactionDate string  `json:"transaction_date" binding:"required"`
+	Notes           *string `json:"notes" binding:"omitempty,max=255"`
+}
+
+// CryptoLot is the part of a purchase or reward still held. The opening lot is the
+// balance a holding had before its first recorded transaction, at the
+// holding's purchase price and date.
+type CryptoLot struct {
+	HoldingID     int        `json:"crypto_holding_id"`
+	TransactionID *int       `json:"transaction_id"`
+	Opening       bool       `json:"opening"`
+	AcquiredDate  *time.Time `json:"acquired_date"`
+	Quantity      float64    `json:"quantity"`
+	CostPerToken  float64    `json:"cost_per_token"`
+	CostBasis     float64    `json:"cost_basis"`
+}
+
+// RealizedGain is the part of a sale matched to one lot. A sale spanning
+// several lots has one per lot.
+type RealizedGain struct {
+	AssetClass    string     `json:"asset_class"`
+	Symbol        string     `json:"symbol"`
+	HoldingID     int        `json:"holding_id"`
+	TransactionID int        `json:"transaction_id"`
+	AcquiredDate  *time.Time `json:"acquired_date"`
+	SoldDate      time.Time  `json:"sold_date"`
+	Quantity      float64    `json:"quantity"`
+	Proceeds      float64    `json:"proceeds"`
+	CostBasis     float64    `json:"cost_basis"`
+	Gain          float64    `json:"gain"`
+	Term          string     `json:"term"`
+
+	// opening marks a gain matched to a holding's opening lot
+	opening bool
+}
+
+// CryptoCostBasis is the FIFO cost basis and gains of one coin across every
+// holding of it
+type CryptoCostBasis struct {
+	Symbol                string      `json:"symbol"`
+	Tokens                float64     `json:"tokens"`
+	CostBasis             float64     `json:"cost_basis"`
+	AverageCost           *float64    `json:"average_cost"`
+	CurrentPriceUSD       *float64    `json:"current_price_usd"`
+	MarketValue           *float64    `json:"market_value"`
+	UnrealizedGain        *float64    `json:"unrealized_gain"`
+	UnrealizedGainPercent *float64    `json:"unrealized_gain_percent"`
+	RealizedGain          float64     `json:"realized_gain"`
+	ShortTermRealizedGain float64     `json:"short_term_realized_gain"`
+	LongTermRealizedGain  float64     `json:"long_term_realized_gain"`
+	Lots                  []CryptoLot `json:"lots"`
+	Warnings              []string    `json:"warnings,omitempty"`
+}
+
+// cryptoLedger is a holding with its transactions, from which its lots and
+// realized gains are worked out
+type cryptoLedger struct {
+	holdingID     int
+	symbol        string
+	balance       float64
+	purchasePrice *float64
+	purchaseDate  *time.Time
+	currentPrice  *float64
+	transactions  []CryptoTransaction
+}
+
+// cryptoLedgerResult is a ledger's lots and gains. Shortfall is tokens sold
+// beyond those held, which happens when a holding's balance is edited below
+// what its transactions leave.
+type cryptoLedgerResult struct {
+	lots      []CryptoLot
+	realized  []RealizedGain
+	shortfall float64
+	// missingBasis is opening tokens without a purchase price
+	missingBasis float64
+	// unknownDate is tokens sold without a purchase date, from an opening lot
+	// without one or beyond those held
+	unknownDate float64
+}
+
+// openingQuantity is the balance held before the first transaction
+func (l cryptoLedger) openingQuantity() float64 {
+	quantity := l.balance
+	for _, t := range l.transactions {
+		if t.TransactionType == CryptoTransactionSell {
+			quantity += t.Quantity
+		} else {
+			quantity -= t.Quantity
+		}
+	}
+	if quantity < tokenEpsilon {
+		return 0
+	}
+	return quantity
+}
+
+// fifo matches sales to the oldest lots first. The opening lot is always
+// the oldest; on the same day purchases and rewards come before sales.
+func (l cryptoLedger) fifo() cryptoLedgerResult {
+	var result cryptoLedgerResult
+	var lots []CryptoLot
+	if opening := l.openingQuantity(); opening > 0 {
+		var cost float64
+		if l.purchasePrice != nil {
+			cost = *l.purchasePrice
+		} else {
+			result.missingBasis = opening
+		}
+		lots = append(lots, CryptoLot{HoldingID: l.holdingID, Opening: true, AcquiredDate: l.purchaseDate, Quantity: opening, CostPerToken: cost})
+	}
+
+	transactions := append([]CryptoTransaction(nil), l.transactions...)
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.TransactionDate.Equal(b.TransactionDate) {
+			return a.TransactionDate.Before(b.TransactionDate)
+		}
+		if aSell, bSell := a.TransactionType == CryptoTransactionSell, b.TransactionType == CryptoTransactionSell; aSell != bSell {
+			return bSell
+		}
+		return a.ID < b.ID
+	})
+
+	for _, t := range transactions {
+		if t.TransactionType != CryptoTransactionSell {
+			id, acquired := t.ID, t.TransactionDate
+			lots = append(lots, CryptoLot{
+				HoldingID: l.holdingID, TransactionID: &id, AcquiredDate: &acquired,
+				Quantity: t.Quantity, CostPerToken: t.PriceUSD + t.FeeUSD/t.Quantity,
+			})
+			continue
+		}
+
+		remaining := t.Quantity
+		for i := range lots {
+			if remaining <= tokenEpsilon {
+				break
+			}
+			lot := &lots[i]
+			if lot.Quantity <= tokenEpsilon {
+				continue
+			}
+			quantity := lot.Quantity
+			if quantity > remaining {
+				quantity = remaining
+			}
+			lot.Quantity -= quantity
+			remaining -= quantity
+
+			gain := RealizedGain{
+				AssetClass:    "crypto",
+				Symbol:        l.symbol,
+				HoldingID:     l.holdingID,
+				TransactionID: t.ID,
+				AcquiredDate:  lot.AcquiredDate,
+				SoldDate:      t.TransactionDate,
+				Quantity:      quantity,
+				Proceeds:      quantity*t.PriceUSD - t.FeeUSD*quantity/t.Quantity,
+				CostBasis:     quantity * lot.CostPerToken,
+				Term:          GainTermShort,
+				opening:       lot.Opening,
+			}
+			gain.Gain = gain.Proceeds - gain.CostBasis
+			switch {
+			case lot.AcquiredDate == nil:
+				result.unknownDate += quantity
+			case t.TransactionDate.After(lot.AcquiredDate.AddDate(1, 0, 0)):
+				gain.Term = GainTermLong
+			}
+			result.realized = append(result.realized, gain)
+		}
+		// Tokens sold beyond those held have no lot, so are all gain
+		if remaining > tokenEpsilon {
+			result.shortfall += remaining
+			result.unknownDate += remaining
+			result.realized = append(result.realized, RealizedGain{
+				AssetClass:    "crypto",
+				Symbol:        l.symbol,
+				HoldingID:     l.holdingID,
+				TransactionID: t.ID,
+				SoldDate:      t.TransactionDate,
+				Quantity:      remaining,
+				Proceeds:      remaining*t.PriceUSD - t.FeeUSD*remaining/t.Quantity,
+				Gain:          remaining*t.PriceUSD - t.FeeUSD*remaining/t.Quantity,
+				Term:          GainTermShort,
+			})
+		}
+	}
+
+	for _, lot := range lots {
+		if lot.Quantity > tokenEpsilon {
+			lot.CostBasis = lot.Quantity * lot.CostPerToken
+			result.lots = append(result.lots, lot)
+		}
+	}
+	return result
+}
+
+// CryptoLotService records crypto purchases and sales and works out FIFO
+// cost basis and realized gains from them
+type CryptoLotService struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewCryptoLotService creates a new crypto lot service
+func NewCryptoLotService(db *sql.DB) *CryptoLotService {
+	return &CryptoLotService{db: db, dialect: database.DialectOf(db)}
+}
+
+// Transactions returns a holding's purchases and sales by date
+func (cls *CryptoLotService) Transactions(holdingID int) ([]CryptoTransaction, error) {
+	var exists bool
+	if err := cls.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM crypto_holdings WHERE id = $1)`, holdingID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto holding: %w", err)
+	}
+	if !exists {
+		return nil, ErrCryptoHoldingNotFound
+	}
+
+	ledgers, err := loadCryptoLedgers(cls.db, holdingID, false)
+	if err != nil {
+		return nil, err
+	}
+	transactions := []CryptoTransaction{}
+	for _, ledger := range ledgers {
+		transactions = append(transactions, ledger.transactions...)
+	}
+	return transactions, nil
+}
+
+// CreateTransaction records a purchase, sale or reward, moving the holding's
+// balance by its quantity, and returns its ID. Rewards are added to the
+// staked balance. A sale of more tokens than the holding had on its date is
+// rejected.
+func (cls *CryptoLotService) CreateTransaction(input CryptoTransactionInput) (int, error) {
+	date, err := time.Parse("2006-01-02", input.TransactionDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: transaction_date must be YYYY-MM-DD", ErrInvalidCryptoTransaction)
+	}
+	transactionType := strings.ToLower(input.TransactionType)
+	if transactionType != CryptoTransactionBuy && transactionType != CryptoTransactionSell && transactionType != CryptoTransactionReward {
+		return 0, fmt.Errorf("%w: transaction_type must be buy, sell or reward", ErrInvalidCryptoTransaction)
+	}
+	if input.Quantity <= 0 {
+		return 0, fmt.Errorf("%w: quantity must be positive", ErrInvalidCryptoTransaction)
+	}
+
+	tx, err := cls.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ledger, err := cls.lockCryptoLedger(tx, input.HoldingID)
+	if err != nil {
+		return 0, err
+	}
+
+	change, stakedChange := input.Quantity, 0.0
+	if transactionType == CryptoTransactionReward {
+		stakedChange = input.Quantity
+	}
+	if transactionType == CryptoTransactionSell {
+		change = -input.Quantity
+		held := ledger.heldOn(date)
+		if held+tokenEpsilon < input.Quantity {
+			return 0, fmt.Errorf("%w: only %g %s were held on %s", ErrInvalidCryptoTransaction,
+				held, ledger.symbol, input.TransactionDate)
+		}
+
+		after := ledger
+		after.balance += change
+		after.transactions = append(append([]CryptoTransaction(nil), ledger.transactions...), CryptoTransaction{
+			TransactionType: transactionType, Quantity: input.Quantity,
+			PriceUSD: input.PriceUSD, FeeUSD: input.FeeUSD, TransactionDate: date,
+		})
+		if after.fifo().shortfall > ledger.fifo().shortfall+tokenEpsilon {
+			return 0, fmt.Errorf("%w: later sales would be left without tokens", ErrInvalidCryptoTransaction)
+		}
+	}
+
+	id, err := insertCryptoTransaction(tx, input.HoldingID, transactionType, input.Quantity, input.PriceUSD, input.FeeUSD, date, input.Notes)
+	if err != nil {
+		return 0, err
+	}
+	if err := moveCryptoBalance(tx, input.HoldingID, change, stakedChange); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit crypto transaction: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteTransaction removes a purchase, sale or reward and undoes its change
+// to the holding's balance. A purchase or reward that later sales were
+// matched to can't be removed while they would be left without tokens.
+func (cls *CryptoLotService) DeleteTransaction(id int) error {
+	tx, err := cls.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var holdingID int
+	err = tx.QueryRow(`SELECT crypto_holding_id FROM crypto_transactions WHERE id = $1`, id).Scan(&holdingID)
+	if err == sql.ErrNoRows {
+		return ErrCryptoTransactionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch crypto transaction: %w", err)
+	}
+
+	ledger, err := cls.lockCryptoLedger(tx, holdingID)
+	if err != nil {
+		return err
+	}
+
+	var removed *CryptoTransaction
+	kept := make([]CryptoTransaction, 0, len(ledger.transactions))
+	for i, t := range ledger.transactions {
+		if t.ID == id {
+			removed = &ledger.transactions[i]
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if removed == nil {
+		return ErrCryptoTransactionNotFound
+	}
+
+	change, stakedChange := removed.Quantity, 0.0
+	if removed.TransactionType == CryptoTransactionReward {
+		stakedChange = -removed.Quantity
+	}
+	if removed.TransactionType != CryptoTransactionSell {
+		change = -removed.Quantity
+		after := ledger
+		after.transactions = kept
+		after.balance += change
+		if after.fifo().shortfall > ledger.fifo().shortfall+tokenEpsilon {
+			return fmt.Errorf("%w: later sales depend on this purchase", ErrInvalidCryptoTransaction)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM crypto_transactions WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete crypto transaction: %w", err)
+	}
+	if err := moveCryptoBalance(tx, holdingID, change, stakedChange); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit crypto transaction: %w", err)
+	}
+	return nil
+}
+
+// CostBasis returns the FIFO cost basis, open lots and gains of each coin,
+// by symbol. Unrealized gains use the latest cached price.
+func (cls *CryptoLotService) CostBasis() ([]CryptoCostBasis, error) {
+	ledgers, err := loadCryptoLedgers(cls.db, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := make(map[string]*CryptoCostBasis)
+	var symbols []string
+	missingBasis, unknownDate, shortfall := map[string]float64{}, map[string]float64{}, map[string]float64{}
+	for _, ledger := range ledgers {
+		basis, ok := bySymbol[ledger.symbol]
+		if !ok {
+			basis = &CryptoCostBasis{Symbol: ledger.symbol, CurrentPriceUSD: ledger.currentPrice, Lots: []CryptoLot{}}
+			bySymbol[ledger.symbol] = basis
+			symbols = append(symbols, ledger.symbol)
+		}
+
+		result := ledger.fifo()
+		for _, lot := range result.lots {
+			basis.Tokens += lot.Quantity
+			basis.CostBasis += lot.CostBasis
+			basis.Lots = append(basis.Lots, roundCryptoLot(lot))
+		}
+		for _, gain := range result.realized {
+			basis.RealizedGain += gain.Gain
+			if gain.Term == GainTermLong {
+				basis.LongTermRealizedGain += gain.Gain
+			} else {
+				basis.ShortTermRealizedGain += gain.Gain
+			}
+		}
+		missingBasis[ledger.symbol] += result.missingBasis
+		unknownDate[ledger.symbol] += result.unknownDate
+		shortfall[ledger.symbol] += result.shortfall
+	}
+
+	sort.Strings(symbols)
+	costBases := make([]CryptoCostBasis, 0, len(symbols))
+	for _, symbol := range symbols {
+		basis := bySymbol[symbol]
+		if basis.Tokens > 0 {
+			average := basis.CostBasis / basis.Tokens
+			basis.AverageCost = &average
+		}
+		if basis.CurrentPriceUSD != nil {
+			value := roundCents(basis.Tokens * *basis.CurrentPriceUSD)
+			gain := roundCents(value - basis.CostBasis)
+			basis.MarketValue, basis.UnrealizedGain = &value, &gain
+			if basis.CostBasis > 0 {
+				percent := roundCents(gain / basis.CostBasis * 100)
+				basis.UnrealizedGainPercent = &percent
+			}
+		}
+		basis.CostBasis = roundCents(basis.CostBasis)
+		basis.RealizedGain = roundCents(basis.RealizedGain)
+		basis.ShortTermRealizedGain = roundCents(basis.ShortTermRealizedGain)
+		basis.LongTermRealizedGain = roundCents(basis.LongTermRealizedGain)
+		basis.Warnings = cryptoLedgerWarnings(symbol, missingBasis[symbol], unknownDate[symbol], shortfall[symbol])
+		costBases = append(costBases, *basis)
+	}
+	return costBases, nil
+}
+
+// RealizedGains returns the gains of crypto sales between from and to
+// (inclusive dates), by sale date, with warnings about sales whose basis or
+// holding period had to be assumed
+func (cls *CryptoLotService) RealizedGains(from, to time.Time) ([]RealizedGain, []string, error) {
+	ledgers, err := loadCryptoLedgers(cls.db, 0, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gains := []RealizedGain{}
+	var warnings []string
+	from, to = dateOnly(from), dateOnly(to)
+	for _, ledger := range ledgers {
+		var missingBasis, unknownDate float64
+		for _, gain := range ledger.fifo().realized {
+			if gain.SoldDate.Before(from) || gain.SoldDate.After(to) {
+				continue
+			}
+			if gain.AcquiredDate == nil {
+				unknownDate += gain.Quantity
+			}
+			if gain.opening && ledger.purchasePrice == nil {
+				missingBasis += gain.Quantity
+			}
+			gains = append(gains, roundRealizedGain(gain))
+		}
+		if missingBasis > 0 {
+			warnings = append(warnings, fmt.Sprintf("%g %s sold from crypto holding %d have no purchase price and are counted at zero cost", missingBasis, ledger.symbol, ledger.holdingID))
+		}
+		if unknownDate > 0 {
+			warnings = append(warnings, fmt.Sprintf("%g %s sold from crypto holding %d have no purchase date and are treated as short-term", unknownDate, ledger.symbol, ledger.holdingID))
+		}
+	}
+
+	sort.SliceStable(gains, func(i, j int) bool { return gains[i].SoldDate.Before(gains[j].SoldDate) })
+	return gains, warnings, nil
+}
+
+// cryptoLedgerWarnings explains the assumptions behind a coin's gains
+func cryptoLedgerWarnings(symbol string, missingBasis, unknownDate, shortfall float64) []string {
+	var warnings []string
+	if missingBasis > 0 {
+		warnings = append(warnings, fmt.Sprintf("%g %s held before the first recorded transaction have no purchase price and are counted at zero cost", missingBasis, symbol))
+	}
+	if unknownDate > 0 {
+		warnings = append(warnings, fmt.Sprintf("%g %s sold have no purchase date and are treated as short-term", unknownDate, symbol))
+	}
+	if shortfall > 0 {
+		warnings = append(warnings, fmt.Sprintf("sales exceed the %s held by %g; the balance was edited below what the transactions leave", symbol, shortfall))
+	}
+	return warnings
+}
+
+// heldOn returns the tokens held at the end of day
+func (l cryptoLedger) heldOn(day time.Time) float64 {
+	held := l.openingQuantity()
+	for _, t := range l.transactions {
+		if t.TransactionDate.After(day) {
+			continue
+		}
+		if t.TransactionType == CryptoTransactionSell {
+			held -= t.Quantity
+		} else {
+			held += t.Quantity
+		}
+	}
+	if held < 0 {
+		return 0
+	}
+	return held
+}
+
+// lockCryptoLedger loads one holding and its transactions, locking the
+// holding until tx ends
+func (cls *CryptoLotService) lockCryptoLedger(tx *sql.Tx, holdingID int) (cryptoLedger, error) {
+	ledger := cryptoLedger{holdingID: holdingID}
+	err := tx.QueryRow(`
+		SELECT crypto_symbol, balance_tokens, purchase_price_usd, purchase_date
+		FROM crypto_holdings WHERE id = $1
+	`+cls.dialect.ForUpdate(), holdingID).Scan(&ledger.symbol, &ledger.balance, &ledger.purchasePrice, &ledger.purchaseDate)
+	if err == sql.ErrNoRows {
+		return ledger, ErrCryptoHoldingNotFound
+	}
+	if err != nil {
+		return ledger, fmt.Errorf("failed to fetch crypto holding: %w", err)
+	}
+
+	ledgers, err := loadCryptoLedgers(tx, holdingID, false)
+	if err != nil {
+		return ledger, err
+	}
+	if len(ledgers) > 0 {
+		ledger.transactions = ledgers[0].transactions
+	}
+	return ledger, nil
+}
+
+// insertCryptoTransaction stores a transaction and returns its ID
+func insertCryptoTransaction(tx *sql.Tx, holdingID int, transactionType string, quantity, priceUSD, feeUSD float64, date time.Time, notes *string) (int, error) {
+	var id int
+	err := tx.QueryRow(`
+		INSERT INTO crypto_transactions (crypto_holding_id, transaction_type, quantity, price_usd, fee_usd, transaction_date, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, holdingID, transactionType, quantity, priceUSD, feeUSD, date, notes).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create crypto transaction: %w", err)
+	}
+	return id, nil
+}
+
+// moveCryptoBalance adds change to a holding's token balance and
+// stakedChange to its staked balance. Sales come out of the liquid balance
+// first, so the staked balance only shrinks once it is more than the
+// balance.
+func moveCryptoBalance(tx *sql.Tx, holdingID int, change, stakedChange float64) error {
+	_, err := tx.Exec(`
+		UPDATE crypto_holdings SET
+			balance_tokens = GREATEST(balance_tokens + $2, 0),
+			staked_tokens = LEAST(GREATEST(staked_tokens + $3, 0), GREATEST(balance_tokens + $2, 0)),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, holdingID, change, stakedChange)
+	if err != nil {
+		return fmt.Errorf("failed to update crypto balance: %w", err)
+	}
+	return nil
+}
+
+// loadCryptoLedgers loads the holdings with their transactions by date, one
+// holding when holdingID is set. withPrices adds each coin's latest cached
+// price.
+func loadCryptoLedgers(db interface {
+	Query(string, ...interface{}) (*sql.Rows, error)
+}, holdingID int, withPrices bool) ([]cryptoLedger, error) {
+	priceColumn := "CAST(NULL AS NUMERIC)"
+	if withPrices {
+		priceColumn = `(
+			SELECT cp.price_usd FROM crypto_prices cp
+			WHERE cp.symbol = ch.crypto_symbol
+			ORDER BY cp.last_updated DESC
+			LIMIT 1
+		)`
+	}
+
+	rows, err := db.Query(`
+		SELECT ch.id, UPPER(ch.crypto_symbol), ch.balance_tokens, ch.purchase_price_usd, ch.purchase_date, `+priceColumn+`
+		FROM crypto_holdings ch
+		WHERE $1 = 0 OR ch.id = $1
+		ORDER BY ch.id
+	`, holdingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto holdings: %w", err)
+	}
+	defer rows.Close()
+
+	var ledgers []cryptoLedger
+	index := make(map[int]int)
+	for rows.Next() {
+		var l cryptoLedger
+		if err := rows.Scan(&l.holdingID, &l.symbol, &l.balance, &l.purchasePrice, &l.purchaseDate, &l.currentPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan crypto holding: %w", err)
+		}
+		index[l.holdingID] = len(ledgers)
+		ledgers = append(ledgers, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto holdings: %w", err)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT id, crypto_holding_id, transaction_type, quantity, price_usd, fee_usd, transaction_date, notes, created_at
+		FROM crypto_transactions
+		WHERE $1 = 0 OR crypto_holding_id = $1
+		ORDER BY transaction_date, id
+	`, holdingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t CryptoTransaction
+		err := rows.Scan(&t.ID, &t.HoldingID, &t.TransactionType, &t.Quantity, &t.PriceUSD, &t.FeeUSD, &t.TransactionDate, &t.Notes, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan crypto transaction: %w", err)
+		}
+		i, ok := index[t.HoldingID]
+		if !ok {
+			continue
+		}
+		t.CryptoSymbol = ledgers[i].symbol
+		ledgers[i].transactions = append(ledgers[i].transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch crypto transactions: %w", err)
+	}
+	return ledgers, nil
+}
+
+func roundCryptoLot(lot CryptoLot) CryptoLot {
+	lot.CostBasis = roundCents(lot.CostBasis)
+	return lot
+}
+
+func roundRealizedGain(gain RealizedGain) RealizedGain {
+	gain.Proceeds = roundCents(gain.Proceeds)
+	gain.CostBasis = roundCents(gain.CostBasis)
+	gain.Gain = roundCents(gain.Gain)
+	return gain
+}
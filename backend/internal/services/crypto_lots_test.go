@@ -0,0 +1,154 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func cryptoTx(id int, transactionType string, quantity, price, fee float64, on time.Time) CryptoTransaction {
+	return CryptoTransaction{ID: id, TransactionType: transactionType, Quantity: quantity, PriceUSD: price, FeeUSD: fee, TransactionDate: on}
+}
+
+func TestCryptoLedgerFIFO(t *testing.T) {
+	type wantGain struct {
+		quantity, proceeds, costBasis float64
+		term                          string
+	}
+	type wantLot struct {
+		quantity, costPerToken float64
+	}
+	tests := []struct {
+		name         string
+		ledger       cryptoLedger
+		gains        []wantGain
+		lots         []wantLot
+		shortfall    float64
+		unknownDate  float64
+		missingBasis float64
+	}{
+		{
+			name: "sale spans the opening lot and a purchase",
+			ledger: cryptoLedger{
+				balance: 0.5, purchasePrice: floatPtr(10000), purchaseDate: timePtr(date(2023, 1, 1)),
+				transactions: []CryptoTransaction{
+					cryptoTx(1, CryptoTransactionBuy, 1, 20000, 100, date(2024, 6, 1)),
+					cryptoTx(2, CryptoTransactionSell, 1.5, 30000, 150, date(2025, 3, 1)),
+				},
+			},
+			gains: []wantGain{
+				{1, 29900, 10000, GainTermLong},
+				{0.5, 14950, 10050, GainTermShort},
+			},
+			lots: []wantLot{{0.5, 20100}},
+		},
+		{
+			name: "exactly a year is still short-term",
+			ledger: cryptoLedger{
+				balance: 1,
+				transactions: []CryptoTransaction{
+					cryptoTx(1, CryptoTransactionBuy, 1, 100, 0, date(2024, 3, 1)),
+					cryptoTx(2, CryptoTransactionBuy, 1, 100, 0, date(2024, 3, 1)),
+					cryptoTx(3, CryptoTransactionSell, 1, 150, 0, date(2025, 3, 1)),
+				},
+			},
+			gains: []wantGain{{1, 150, 100, GainTermShort}},
+			lots:  []wantLot{{1, 100}},
+		},
+		{
+			name: "a year and a day is long-term",
+			ledger: cryptoLedger{
+				transactions: []CryptoTransaction{
+					cryptoTx(1, CryptoTransactionBuy, 1, 100, 0, date(2024, 3, 1)),
+					cryptoTx(2, CryptoTransactionSell, 1, 150, 0, date(2025, 3, 2)),
+				},
+			},
+			gains: []wantGain{{1, 150, 100, GainTermLong}},
+		},
+		{
+			name: "same-day purchase is sold before older tokens run out",
+			ledger: cryptoLedger{
+				transactions: []CryptoTransaction{
+					// Recorded sale first, but purchases on the same day come first
+					cryptoTx(2, CryptoTransactionSell, 2, 50, 0, date(2025, 5, 1)),
+					cryptoTx(1, CryptoTransactionBuy, 2, 40, 0, date(2025, 5, 1)),
+				},
+			},
+			gains: []wantGain{{2, 100, 80, GainTermShort}},
+		},
+		{
+			name: "rewards are lots at their price when received",
+			ledger: cryptoLedger{
+				balance: 0.5,
+				transactions: []CryptoTransaction{
+					cryptoTx(1, CryptoTransactionReward, 1, 2000, 0, date(2025, 1, 10)),
+					cryptoTx(2, CryptoTransactionSell, 0.5, 3000, 0, date(2025, 2, 10)),
+				},
+			},
+			gains: []wantGain{{0.5, 1500, 1000, GainTermShort}},
+			lots:  []wantLot{{0.5, 2000}},
+		},
+		{
+			name: "tokens sold before any are held are all gain",
+			ledger: cryptoLedger{
+				transactions: []CryptoTransaction{
+					cryptoTx(1, CryptoTransactionSell, 1, 200, 0, date(2025, 1, 1)),
+					cryptoTx(2, CryptoTransactionBuy, 1, 100, 0, date(2025, 2, 1)),
+				},
+			},
+			gains:       []wantGain{{1, 200, 0, GainTermShort}},
+			lots:        []wantLot{{1, 100}},
+			shortfall:   1,
+			unknownDate: 1,
+		},
+		{
+			name: "opening lot without a price or date",
+			ledger: cryptoLedger{
+				balance: 1,
+				transactions: []CryptoTransaction{
+					cryptoTx(1, CryptoTransactionSell, 1, 500, 0, date(2025, 2, 1)),
+				},
+			},
+			gains:        []wantGain{{1, 500, 0, GainTermShort}},
+			lots:         []wantLot{{1, 0}},
+			unknownDate:  1,
+			missingBasis: 2,
+		},
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.ledger.fifo()
+			if len(result.realized) != len(tt.gains) {
+				t.Fatalf("got %d realized gains, want %d: %+v", len(result.realized), len(tt.gains), result.realized)
+			}
+			for i, want := range tt.gains {
+				got := result.realized[i]
+				if !near(got.Quantity, want.quantity) || !near(got.Proceeds, want.proceeds) ||
+					!near(got.CostBasis, want.costBasis) || !near(got.Gain, want.proceeds-want.costBasis) || got.Term != want.term {
+					t.Errorf("gain %d = %v tokens, proceeds %v, basis %v, gain %v, %s; want %+v",
+						i, got.Quantity, got.Proceeds, got.CostBasis, got.Gain, got.Term, want)
+				}
+			}
+			if len(result.lots) != len(tt.lots) {
+				t.Fatalf("got %d open lots, want %d: %+v", len(result.lots), len(tt.lots), result.lots)
+			}
+			for i, want := range tt.lots {
+				got := result.lots[i]
+				if !near(got.Quantity, want.quantity) || !near(got.CostPerToken, want.costPerToken) ||
+					!near(got.CostBasis, want.quantity*want.costPerToken) {
+					t.Errorf("lot %d = %v tokens at %v (basis %v), want %+v", i, got.Quantity, got.CostPerToken, got.CostBasis, want)
+				}
+			}
+			if !near(result.shortfall, tt.shortfall) || !near(result.unknownDate, tt.unknownDate) || !near(result.missingBasis, tt.missingBasis) {
+				t.Errorf("shortfall %v, unknown date %v, missing basis %v; want %v, %v, %v",
+					result.shortfall, result.unknownDate, result.missingBasis, tt.shortfall, tt.unknownDate, tt.missingBasis)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+)
+
+// CryptoPriceProvider interface allows easy swapping of crypto price data sources
+type CryptoPriceProvider interface {
+	GetMultiplePrices(symbols []string) (map[string]*CryptoPriceData, error)
+	GetProviderName() string
+	// GetSourceName returns the identifier stored in crypto_prices.source
+	GetSourceName() string
+	GetDailyLimit() int
+	GetRateLimit() int
+}
+
+// CoinGeckoPriceProvider provides crypto prices from the CoinGecko API
+type CoinGeckoPriceProvider struct {
+	apiKey  string
+	client  *http.Client
+	config  *config.ApiConfig
+	baseURL string
+}
+
+// CoinMarketCapPriceProvider provides crypto prices from the CoinMarketCap API
+type CoinMarketCapPriceProvider struct {
+	apiKey  string
+	client  *http.Client
+	config  *config.ApiConfig
+	baseURL string
+}
+
+// CoinGeckoResponse represents the response from CoinGecko API
+type CoinGeckoResponse struct {
+	ID                string  `json:"id"`
+	Symbol            string  `json:"symbol"`
+	Name              string  `json:"name"`
+	CurrentPrice      float64 `json:"current_price"`
+	MarketCap         float64 `json:"market_cap"`
+	TotalVolume       float64 `json:"total_volume"`
+	PriceChange24h    float64 `json:"price_change_24h"`
+	PriceChangePct24h float64 `json:"price_change_percentage_24h"`
+	LastUpdated       string  `json:"last_updated"`
+}
+
+// CoinMarketCapQuoteResponse represents the response from the CoinMarketCap quotes endpoint
+type CoinMarketCapQuoteResponse struct {
+	Status struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+	Data map[string]struct {
+		Symbol string `json:"symbol"`
+		Quote  map[string]struct {
+			Price            float64 `json:"price"`
+			Volume24h        float64 `json:"volume_24h"`
+			PercentChange24h float64 `json:"percent_change_24h"`
+			MarketCap        float64 `json:"market_cap"`
+			LastUpdated      string  `json:"last_updated"`
+		} `json:"quote"`
+	} `json:"data"`
+}
+
+// NewCryptoPriceProvider selects a crypto price provider based on configuration
+func NewCryptoPriceProvider(cfg *config.ApiConfig) CryptoPriceProvider {
+	if cfg.CryptoPriceProvider == "coinmarketcap" {
+		if cfg.CoinMarketCapAPIKey != "" {
+			fmt.Printf("INFO: Initializing CoinMarketCap as crypto price provider (API key: %d chars)\n", len(cfg.CoinMarketCapAPIKey))
+			return NewCoinMarketCapPriceProvider(cfg.CoinMarketCapAPIKey, cfg)
+		}
+		fmt.Printf("WARNING: CoinMarketCap selected but COINMARKETCAP_API_KEY is not set - falling back to CoinGecko\n")
+	}
+
+	fmt.Printf("INFO: Initializing CoinGecko as crypto price provider\n")
+	return NewCoinGeckoPriceProvider(cfg.CoinGeckoAPIKey, cfg)
+}
+
+// NewCoinGeckoPriceProvider creates a new CoinGecko price provider
+func NewCoinGeckoPriceProvider(apiKey string, cfg *config.ApiConfig) *CoinGeckoPriceProvider {
+	return &CoinGeckoPriceProvider{
+		apiKey:  apiKey,
+		client:  httpclient.New("coingecko", 30*time.Second, cfg.HTTPRetry),
+		config:  cfg,
+		baseURL: "https://api.coingecko.com/api/v3",
+	}
+}
+
+// NewCoinMarketCapPriceProvider creates a new CoinMarketCap price provider
+func NewCoinMarketCapPriceProvider(apiKey string, cfg *config.ApiConfig) *CoinMarketCapPriceProvider {
+	return &CoinMarketCapPriceProvider{
+		apiKey:  apiKey,
+		client:  httpclient.New("coinmarketcap", 30*time.Second, cfg.HTTPRetry),
+		config:  cfg,
+		baseURL: "https://pro-api.coinmarketcap.com/v1",
+	}
+}
+
+// GetMultiplePrices fetches prices for multiple cryptocurrencies in a single CoinGecko call
+func (cg *CoinGeckoPriceProvider) GetMultiplePrices(symbols []string) (map[string]*CryptoPriceData, error) {
+	results := make(map[string]*CryptoPriceData)
+	if len(symbols) == 0 {
+		return results, nil
+	}
+
+	// Convert symbols to coin IDs and prepare request
+	coinIDs := make([]string, 0, len(symbols))
+	idToSymbol := make(map[string]string)
+
+	for _, symbol := range symbols {
+		coinID := cg.symbolToID(symbol)
+		coinIDs = append(coinIDs, coinID)
+		idToSymbol[coinID] = strings.ToUpper(symbol)
+	}
+
+	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd,btc&include_market_cap=true&include_24hr_vol=true&include_24hr_change=true&include_last_updated_at=true",
+		cg.baseURL, strings.Join(coinIDs, ","))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CoinGecko request: %w", err)
+	}
+	if cg.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", cg.apiKey)
+	}
+
+	resp, err := cg.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices from CoinGecko: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CoinGecko API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// CoinGecko returns a map with coin ID as key
+	var response map[string]map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse CoinGecko response: %w", err)
+	}
+
+	for coinID, priceData := range response {
+		symbol, exists := idToSymbol[coinID]
+		if !exists {
+			continue
+		}
+
+		priceUSD, _ := priceData["usd"].(float64)
+		priceBTC, _ := priceData["btc"].(float64)
+		marketCapUSD, _ := priceData["usd_market_cap"].(float64)
+		volume24hUSD, _ := priceData["usd_24h_vol"].(float64)
+		priceChange24h, _ := priceData["usd_24h_change"].(float64)
+		lastUpdatedUnix, _ := priceData["last_updated_at"].(float64)
+
+		results[symbol] = &CryptoPriceData{
+			Symbol:         symbol,
+			PriceUSD:       priceUSD,
+			PriceBTC:       priceBTC,
+			MarketCapUSD:   marketCapUSD,
+			Volume24hUSD:   volume24hUSD,
+			PriceChange24h: priceChange24h,
+			LastUpdated:    time.Unix(int64(lastUpdatedUnix), 0),
+		}
+	}
+
+	return results, nil
+}
+
+// GetProviderName returns the provider name
+func (cg *CoinGeckoPriceProvider) GetProviderName() string {
+	return "CoinGecko"
+}
+
+// GetSourceName returns the source identifier used when caching prices
+func (cg *CoinGeckoPriceProvider) GetSourceName() string {
+	return "coingecko"
+}
+
+// GetDailyLimit returns the configured daily API call limit
+func (cg *CoinGeckoPriceProvider) GetDailyLimit() int {
+	return cg.config.Current().CoinGeckoDailyLimit
+}
+
+// GetRateLimit returns the configured per-minute API call limit
+func (cg *CoinGeckoPriceProvider) GetRateLimit() int {
+	return cg.config.Current().CoinGeckoRateLimit
+}
+
+// symbolToID converts crypto symbol to CoinGecko coin ID
+// This is a simplified mapping - in production, you might want to maintain a more comprehensive mapping
+func (cg *CoinGeckoPriceProvider) symbolToID(symbol string) string {
+	symbolMap := map[string]string{
+		"btc":   "bitcoin",
+		"eth":   "ethereum",
+		"ada":   "cardano",
+		"dot":   "polkadot",
+		"sol":   "solana",
+		"matic": "polygon",
+		"avax":  "avalanche-2",
+		"link":  "chainlink",
+		"uni":   "uniswap",
+		"ltc":   "litecoin",
+		"bch":   "bitcoin-cash",
+		"xlm":   "stellar",
+		"xrp":   "ripple",
+		"doge":  "dogecoin",
+		"shib":  "shiba-inu",
+		"bnb":   "binancecoin",
+		"usdc":  "usd-coin",
+		"usdt":  "tether",
+		"busd":  "binance-usd",
+		"dai":   "dai",
+	}
+
+	symbol = strings.ToLower(symbol)
+	if coinID, exists := symbolMap[symbol]; exists {
+		return coinID
+	}
+
+	// Fallback: assume symbol is the same as coin ID
+	return symbol
+}
+
+// GetMultiplePrices fetches prices for multiple cryptocurrencies in a single CoinMarketCap call
+func (cmc *CoinMarketCapPriceProvider) GetMultiplePrices(symbols []string) (map[string]*CryptoPriceData, error) {
+	results := make(map[string]*CryptoPriceData)
+	if len(symbols) == 0 {
+		return results, nil
+	}
+
+	// The free plan only allows a single convert currency, so BTC is always
+	// requested alongside the other symbols to derive BTC-denominated prices
+	requested := []string{"BTC"}
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if symbol != "BTC" {
+			requested = append(requested, symbol)
+		}
+	}
+
+	url := fmt.Sprintf("%s/cryptocurrency/quotes/latest?symbol=%s&convert=USD", cmc.baseURL, strings.Join(requested, ","))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CoinMarketCap request: %w", err)
+	}
+	req.Header.Set("X-CMC_PRO_API_KEY", cmc.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := cmc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices from CoinMarketCap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response CoinMarketCapQuoteResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse CoinMarketCap response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || response.Status.ErrorCode != 0 {
+		return nil, fmt.Errorf("CoinMarketCap API returned status %d: %s", resp.StatusCode, response.Status.ErrorMessage)
+	}
+
+	var btcUSD float64
+	if btc, exists := response.Data["BTC"]; exists {
+		btcUSD = btc.Quote["USD"].Price
+	}
+
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		coin, exists := response.Data[symbol]
+		if !exists {
+			continue
+		}
+
+		quote, exists := coin.Quote["USD"]
+		if !exists {
+			continue
+		}
+
+		var priceBTC float64
+		if btcUSD > 0 {
+			priceBTC = quote.Price / btcUSD
+		}
+
+		lastUpdated, err := time.Parse(time.RFC3339, quote.LastUpdated)
+		if err != nil {
+			lastUpdated = time.Now()
+		}
+
+		results[symbol] = &CryptoPriceData{
+			Symbol:         symbol,
+			PriceUSD:       quote.Price,
+			PriceBTC:       priceBTC,
+			MarketCapUSD:   quote.MarketCap,
+			Volume24hUSD:   quote.Volume24h,
+			PriceChange24h: quote.PercentChange24h,
+			LastUpdated:    lastUpdated,
+		}
+	}
+
+	return results, nil
+}
+
+// GetProviderName returns the provider name
+func (cmc *CoinMarketCapPriceProvider) GetProviderName() string {
+	return "CoinMarketCap"
+}
+
+// GetSourceName returns the source identifier used when caching prices
+func (cmc *CoinMarketCapPriceProvider) GetSourceName() string {
+	return "coinmarketcap"
+}
+
+// GetDailyLimit returns the configured daily API call limit
+func (cmc *CoinMarketCapPriceProvider) GetDailyLimit() int {
+	return cmc.config.Current().CoinMarketCapDailyLimit
+}
+
+// GetRateLimit returns the configured per-minute API call limit
+func (cmc *CoinMarketCapPriceProvider) GetRateLimit() int {
+	return cmc.config.Current().CoinMarketCapRateLimit
+}
@@ -2,32 +2,24 @@ package services
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"networth-dashboard/internal/config"
 )
 
-// CryptoService handles cryptocurrency price data from CoinGecko
+// CryptoService handles cryptocurrency price data with caching and rate limiting
+// on top of the configured CryptoPriceProvider
 type CryptoService struct {
-	db      *sql.DB
-	client  *http.Client
-	baseURL string
-}
+	db        *sql.DB
+	provider  CryptoPriceProvider
+	config    *config.ApiConfig
+	mu        sync.Mutex      // Protects against concurrent price updates for the same symbol
+	updateMap map[string]bool // Tracks which symbols are currently being updated
 
-// CoinGeckoResponse represents the response from CoinGecko API
-type CoinGeckoResponse struct {
-	ID                string  `json:"id"`
-	Symbol            string  `json:"symbol"`
-	Name              string  `json:"name"`
-	CurrentPrice      float64 `json:"current_price"`
-	MarketCap         float64 `json:"market_cap"`
-	TotalVolume       float64 `json:"total_volume"`
-	PriceChange24h    float64 `json:"price_change_24h"`
-	PriceChangePct24h float64 `json:"price_change_percentage_24h"`
-	LastUpdated       string  `json:"last_updated"`
+	symbolHealth *SymbolHealthService // Optional; pauses symbols that keep failing
 }
 
 // CryptoPriceData represents crypto price information
@@ -39,6 +31,7 @@ type CryptoPriceData struct {
 	Volume24hUSD   float64   `json:"volume_24h_usd"`
 	PriceChange24h float64   `json:"price_change_24h"`
 	LastUpdated    time.Time `json:"last_updated"`
+	fetchedAt      time.Time // When the price was retrieved from the provider
 }
 
 // CryptoPriceUpdateResult represents the result of a crypto price update operation
@@ -67,83 +60,104 @@ type CryptoPriceRefreshSummary struct {
 	ProviderName   string                    `json:"provider_name"`
 	Timestamp      time.Time                 `json:"timestamp"`
 	DurationMs     int64                     `json:"duration_ms"`
+	PausedSymbols  []string                  `json:"paused_symbols,omitempty"` // Skipped after repeated failures
 }
 
-// NewCryptoService creates a new cryptocurrency service
-func NewCryptoService(db *sql.DB) *CryptoService {
+// NewCryptoService creates a new cryptocurrency service using the configured price provider
+func NewCryptoService(db *sql.DB, cfg *config.ApiConfig) *CryptoService {
 	return &CryptoService{
-		db:      db,
-		client:  &http.Client{Timeout: 30 * time.Second},
-		baseURL: "https://api.coingecko.com/api/v3",
+		db:        db,
+		provider:  NewCryptoPriceProvider(cfg),
+		config:    cfg,
+		updateMap: make(map[string]bool),
 	}
 }
 
+// SetSymbolHealthService enables failure tracking and auto-pausing of symbols during bulk refresh
+func (cs *CryptoService) SetSymbolHealthService(symbolHealth *SymbolHealthService) {
+	cs.symbolHealth = symbolHealth
+}
+
+// ReloadProvider re-creates the crypto price provider after API keys change
+func (cs *CryptoService) ReloadProvider() {
+	provider := NewCryptoPriceProvider(cs.config)
+
+	cs.mu.Lock()
+	cs.provider = provider
+	cs.mu.Unlock()
+}
+
+// GetProviderName returns the name of the active crypto price provider
+func (cs *CryptoService) GetProviderName() string {
+	return cs.provider.GetProviderName()
+}
+
 // GetPrice fetches current price for a single cryptocurrency
 func (cs *CryptoService) GetPrice(symbol string) (*CryptoPriceData, error) {
-	symbol = strings.ToLower(symbol)
-	
-	// Check if we have recent cached data (within 5 minutes)
-	cached, err := cs.getCachedPrice(symbol)
-	if err == nil && cached != nil && time.Since(cached.LastUpdated) < 5*time.Minute {
-		return cached, nil
-	}
-
-	// Fetch from CoinGecko
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd,btc&include_market_cap=true&include_24hr_vol=true&include_24hr_change=true&include_last_updated_at=true", 
-		cs.baseURL, cs.symbolToID(symbol))
+	return cs.GetPriceWithForce(symbol, false)
+}
 
-	resp, err := cs.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch price from CoinGecko: %w", err)
+// GetPriceWithForce fetches current price for a single cryptocurrency with optional force refresh
+func (cs *CryptoService) GetPriceWithForce(symbol string, forceRefresh bool) (*CryptoPriceData, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol cannot be empty")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CoinGecko API returned status %d", resp.StatusCode)
+	// Prevent concurrent updates for the same symbol
+	cs.mu.Lock()
+	if cs.updateMap[symbol] {
+		cs.mu.Unlock()
+		// If another goroutine is already updating this symbol, just get cached price
+		time.Sleep(100 * time.Millisecond)
+		cached, err := cs.getCachedPrice(symbol)
+		if err == nil && cached != nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("price update already in progress for %s", symbol)
+	}
+	cs.updateMap[symbol] = true
+	cs.mu.Unlock()
+
+	// Ensure cleanup on exit
+	defer func() {
+		cs.mu.Lock()
+		delete(cs.updateMap, symbol)
+		cs.mu.Unlock()
+	}()
+
+	// Check if we have recent cached data. Crypto trades around the clock, so a
+	// fixed refresh interval is used instead of market-hours aware caching.
+	cached, err := cs.getCachedPrice(symbol)
+	hasCache := err == nil && cached != nil
+	if hasCache && !forceRefresh && time.Since(cached.fetchedAt) < cs.config.Current().CryptoCacheRefreshInterval {
+		return cached, nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// Check rate limiting
+	if !cs.canMakeAPICall() {
+		if hasCache {
+			fmt.Printf("DEBUG: %s rate limited for %s, using cached price\n", cs.provider.GetProviderName(), symbol)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("rate limit exceeded and no cached price available for %s", symbol)
 	}
 
-	// Parse the response - CoinGecko returns a map with coin ID as key
-	var response map[string]map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse CoinGecko response: %w", err)
+	prices, err := cs.fetchAndCache([]string{symbol})
+	if err != nil {
+		if hasCache {
+			fmt.Printf("WARNING: %s request failed for %s, using cached price: %v\n", cs.provider.GetProviderName(), symbol, err)
+			return cached, nil
+		}
+		return nil, err
 	}
 
-	coinID := cs.symbolToID(symbol)
-	priceData, exists := response[coinID]
+	price, exists := prices[symbol]
 	if !exists {
 		return nil, fmt.Errorf("price data not found for symbol %s", symbol)
 	}
 
-	// Extract price information
-	priceUSD, _ := priceData["usd"].(float64)
-	priceBTC, _ := priceData["btc"].(float64)
-	marketCapUSD, _ := priceData["usd_market_cap"].(float64)
-	volume24hUSD, _ := priceData["usd_24h_vol"].(float64)
-	priceChange24h, _ := priceData["usd_24h_change"].(float64)
-	lastUpdatedUnix, _ := priceData["last_updated_at"].(float64)
-
-	cryptoPrice := &CryptoPriceData{
-		Symbol:         strings.ToUpper(symbol),
-		PriceUSD:       priceUSD,
-		PriceBTC:       priceBTC,
-		MarketCapUSD:   marketCapUSD,
-		Volume24hUSD:   volume24hUSD,
-		PriceChange24h: priceChange24h,
-		LastUpdated:    time.Unix(int64(lastUpdatedUnix), 0),
-	}
-
-	// Cache the result
-	if err := cs.cachePrice(cryptoPrice); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Failed to cache price for %s: %v\n", symbol, err)
-	}
-
-	return cryptoPrice, nil
+	return price, nil
 }
 
 // GetMultiplePrices fetches prices for multiple cryptocurrencies
@@ -152,66 +166,32 @@ func (cs *CryptoService) GetMultiplePrices(symbols []string) (map[string]*Crypto
 		return make(map[string]*CryptoPriceData), nil
 	}
 
-	// Convert symbols to coin IDs and prepare request
-	coinIDs := make([]string, 0, len(symbols))
-	symbolToID := make(map[string]string)
-	
-	for _, symbol := range symbols {
-		symbol = strings.ToLower(symbol)
-		coinID := cs.symbolToID(symbol)
-		coinIDs = append(coinIDs, coinID)
-		symbolToID[coinID] = strings.ToUpper(symbol)
+	if !cs.canMakeAPICall() {
+		return nil, fmt.Errorf("%s rate limit exceeded", cs.provider.GetProviderName())
 	}
 
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd,btc&include_market_cap=true&include_24hr_vol=true&include_24hr_change=true&include_last_updated_at=true", 
-		cs.baseURL, strings.Join(coinIDs, ","))
-
-	resp, err := cs.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch prices from CoinGecko: %w", err)
-	}
-	defer resp.Body.Close()
+	return cs.fetchAndCache(symbols)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CoinGecko API returned status %d", resp.StatusCode)
+// fetchAndCache fetches prices from the provider and caches them. All rows from
+// one provider call share a fetched_at timestamp so they count as a single API call.
+func (cs *CryptoService) fetchAndCache(symbols []string) (map[string]*CryptoPriceData, error) {
+	normalized := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		normalized = append(normalized, strings.ToUpper(strings.TrimSpace(symbol)))
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	fmt.Printf("INFO: Making %s API call for %d symbols\n", cs.provider.GetProviderName(), len(normalized))
+	results, err := cs.provider.GetMultiplePrices(normalized)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var response map[string]map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse CoinGecko response: %w", err)
+		return nil, err
 	}
 
-	results := make(map[string]*CryptoPriceData)
-	
-	for coinID, priceData := range response {
-		symbol := symbolToID[coinID]
-		
-		priceUSD, _ := priceData["usd"].(float64)
-		priceBTC, _ := priceData["btc"].(float64)
-		marketCapUSD, _ := priceData["usd_market_cap"].(float64)
-		volume24hUSD, _ := priceData["usd_24h_vol"].(float64)
-		priceChange24h, _ := priceData["usd_24h_change"].(float64)
-		lastUpdatedUnix, _ := priceData["last_updated_at"].(float64)
-
-		cryptoPrice := &CryptoPriceData{
-			Symbol:         symbol,
-			PriceUSD:       priceUSD,
-			PriceBTC:       priceBTC,
-			MarketCapUSD:   marketCapUSD,
-			Volume24hUSD:   volume24hUSD,
-			PriceChange24h: priceChange24h,
-			LastUpdated:    time.Unix(int64(lastUpdatedUnix), 0),
-		}
-
-		results[symbol] = cryptoPrice
-
-		// Cache the result
-		if err := cs.cachePrice(cryptoPrice); err != nil {
+	fetchedAt := time.Now()
+	for symbol, price := range results {
+		price.fetchedAt = fetchedAt
+		if err := cs.cachePrice(price); err != nil {
+			// Log error but don't fail the request
 			fmt.Printf("Failed to cache price for %s: %v\n", symbol, err)
 		}
 	}
@@ -237,7 +217,22 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 		if err := rows.Scan(&symbol); err != nil {
 			continue
 		}
-		symbols = append(symbols, symbol)
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+
+	// Skip symbols paused after repeated failures
+	var pausedSymbols []string
+	if cs.symbolHealth != nil {
+		paused := cs.symbolHealth.GetPausedSymbols(SymbolAssetTypeCrypto)
+		active := make([]string, 0, len(symbols))
+		for _, symbol := range symbols {
+			if paused[symbol] {
+				pausedSymbols = append(pausedSymbols, symbol)
+			} else {
+				active = append(active, symbol)
+			}
+		}
+		symbols = active
 	}
 
 	if len(symbols) == 0 {
@@ -246,9 +241,10 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 			UpdatedSymbols: 0,
 			FailedSymbols:  0,
 			Results:        []CryptoPriceUpdateResult{},
-			ProviderName:   "CoinGecko",
+			ProviderName:   cs.provider.GetProviderName(),
 			Timestamp:      time.Now(),
 			DurationMs:     time.Since(startTime).Milliseconds(),
+			PausedSymbols:  pausedSymbols,
 		}, nil
 	}
 
@@ -260,9 +256,15 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 		}
 	}
 
-	// Fetch new prices for all symbols
-	newPrices, err := cs.GetMultiplePrices(symbols)
-	
+	// Fetch new prices for all symbols, unless the provider's rate limits are exhausted
+	rateLimited := !cs.canMakeAPICall()
+	var newPrices map[string]*CryptoPriceData
+	if rateLimited {
+		err = fmt.Errorf("%s rate limit exceeded", cs.provider.GetProviderName())
+	} else {
+		newPrices, err = cs.fetchAndCache(symbols)
+	}
+
 	// Build results
 	results := make([]CryptoPriceUpdateResult, 0, len(symbols))
 	updatedCount := 0
@@ -279,7 +281,7 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 		if oldPrice, exists := oldPrices[symbol]; exists {
 			result.OldPriceUSD = oldPrice.PriceUSD
 			result.OldPriceBTC = oldPrice.PriceBTC
-			result.CacheAge = fmt.Sprintf("%.0fm", time.Since(oldPrice.LastUpdated).Minutes())
+			result.CacheAge = fmt.Sprintf("%.0fm", time.Since(oldPrice.fetchedAt).Minutes())
 		}
 
 		// Check if we got new price
@@ -297,8 +299,16 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 		} else {
 			result.Updated = false
 			failedCount++
+			// The provider answered but returned nothing for this symbol
 			result.Error = "Failed to fetch price"
-			result.ErrorType = "api_error"
+			result.ErrorType = "invalid_symbol"
+
+			// Report the cached price when one is available
+			if oldPrice, exists := oldPrices[symbol]; exists {
+				result.NewPriceUSD = oldPrice.PriceUSD
+				result.NewPriceBTC = oldPrice.PriceBTC
+				result.Source = "cache"
+			}
 		}
 
 		results = append(results, result)
@@ -309,7 +319,23 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 		for i := range results {
 			if !results[i].Updated {
 				results[i].Error = err.Error()
-				results[i].ErrorType = "api_error"
+				if rateLimited {
+					results[i].ErrorType = "rate_limited"
+				} else {
+					results[i].ErrorType = "api_error"
+				}
+			}
+		}
+	}
+
+	// Track per-symbol health. Provider-wide failures are not the symbol's fault,
+	// so only count symbols the provider answered without.
+	if cs.symbolHealth != nil {
+		for _, result := range results {
+			if result.Updated {
+				cs.symbolHealth.RecordSuccess(SymbolAssetTypeCrypto, result.Symbol)
+			} else if err == nil {
+				cs.symbolHealth.RecordFailure(SymbolAssetTypeCrypto, result.Symbol, result.Error)
 			}
 		}
 	}
@@ -319,27 +345,29 @@ func (cs *CryptoService) RefreshAllCryptoPrices() (*CryptoPriceRefreshSummary, e
 		UpdatedSymbols: updatedCount,
 		FailedSymbols:  failedCount,
 		Results:        results,
-		ProviderName:   "CoinGecko",
+		ProviderName:   cs.provider.GetProviderName(),
 		Timestamp:      time.Now(),
 		DurationMs:     time.Since(startTime).Milliseconds(),
+		PausedSymbols:  pausedSymbols,
 	}, nil
 }
 
 // getCachedPrice retrieves cached price data from database
 func (cs *CryptoService) getCachedPrice(symbol string) (*CryptoPriceData, error) {
 	query := `
-		SELECT symbol, price_usd, price_btc, market_cap_usd, volume_24h_usd, 
-		       price_change_24h, last_updated
+		SELECT symbol, price_usd, COALESCE(price_btc, 0), COALESCE(market_cap_usd, 0),
+		       COALESCE(volume_24h_usd, 0), COALESCE(price_change_24h, 0), last_updated,
+		       COALESCE(fetched_at, last_updated)
 		FROM crypto_prices 
 		WHERE symbol = $1 
-		ORDER BY last_updated DESC 
+		ORDER BY COALESCE(fetched_at, last_updated) DESC 
 		LIMIT 1
 	`
 
 	var price CryptoPriceData
 	err := cs.db.QueryRow(query, strings.ToUpper(symbol)).Scan(
 		&price.Symbol, &price.PriceUSD, &price.PriceBTC, &price.MarketCapUSD,
-		&price.Volume24hUSD, &price.PriceChange24h, &price.LastUpdated,
+		&price.Volume24hUSD, &price.PriceChange24h, &price.LastUpdated, &price.fetchedAt,
 	)
 	
 	if err == sql.ErrNoRows {
@@ -352,12 +380,21 @@ func (cs *CryptoService) getCachedPrice(symbol string) (*CryptoPriceData, error)
 	return &price, nil
 }
 
-// cachePrice stores price data in the database
+// cachePrice stores price data in the database. A provider may report the same
+// last_updated time on consecutive calls, so the row for that minute is refreshed instead.
 func (cs *CryptoService) cachePrice(price *CryptoPriceData) error {
 	query := `
 		INSERT INTO crypto_prices (symbol, price_usd, price_btc, market_cap_usd, 
-		                          volume_24h_usd, price_change_24h, last_updated, source)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		                          volume_24h_usd, price_change_24h, last_updated, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (symbol, (date_trunc('minute', last_updated))) DO UPDATE SET
+			price_usd = EXCLUDED.price_usd,
+			price_btc = EXCLUDED.price_btc,
+			market_cap_usd = EXCLUDED.market_cap_usd,
+			volume_24h_usd = EXCLUDED.volume_24h_usd,
+			price_change_24h = EXCLUDED.price_change_24h,
+			source = EXCLUDED.source,
+			fetched_at = EXCLUDED.fetched_at
 	`
 
 	_, err := cs.db.Exec(
@@ -369,43 +406,65 @@ func (cs *CryptoService) cachePrice(price *CryptoPriceData) error {
 		price.Volume24hUSD,
 		price.PriceChange24h,
 		price.LastUpdated,
-		"coingecko",
+		cs.provider.GetSourceName(),
+		price.fetchedAt,
 	)
 
 	return err
 }
 
-// symbolToID converts crypto symbol to CoinGecko coin ID
-// This is a simplified mapping - in production, you might want to maintain a more comprehensive mapping
-func (cs *CryptoService) symbolToID(symbol string) string {
-	symbolMap := map[string]string{
-		"btc":  "bitcoin",
-		"eth":  "ethereum",
-		"ada":  "cardano",
-		"dot":  "polkadot",
-		"sol":  "solana",
-		"matic": "polygon",
-		"avax": "avalanche-2",
-		"link": "chainlink",
-		"uni":  "uniswap",
-		"ltc":  "litecoin",
-		"bch":  "bitcoin-cash",
-		"xlm":  "stellar",
-		"xrp":  "ripple",
-		"doge": "dogecoin",
-		"shib": "shiba-inu",
-		"bnb":  "binancecoin",
-		"usdc": "usd-coin",
-		"usdt": "tether",
-		"busd": "binance-usd",
-		"dai":  "dai",
+// canMakeAPICall checks if we can make an API call based on the provider's rate limits
+func (cs *CryptoService) canMakeAPICall() bool {
+	providerName := cs.provider.GetProviderName()
+
+	// Check daily limit
+	today := time.Now().Format("2006-01-02")
+	dailyCount := cs.getAPICallCount(today)
+	if dailyCount >= cs.provider.GetDailyLimit() {
+		fmt.Printf("DEBUG: %s daily limit exceeded: %d >= %d\n", providerName, dailyCount, cs.provider.GetDailyLimit())
+		return false
 	}
 
-	symbol = strings.ToLower(symbol)
-	if coinID, exists := symbolMap[symbol]; exists {
-		return coinID
+	// Check per-minute rate limit
+	lastMinute := time.Now().Add(-1 * time.Minute)
+	recentCount := cs.getAPICallCountSince(lastMinute)
+
+	canMake := recentCount < cs.provider.GetRateLimit()
+	fmt.Printf("DEBUG: %s rate check: %d < %d = %t\n", providerName, recentCount, cs.provider.GetRateLimit(), canMake)
+	return canMake
+}
+
+// getAPICallCount gets the number of API calls made on a given date. Each
+// provider call stores its rows with a shared fetched_at, so distinct values are counted.
+func (cs *CryptoService) getAPICallCount(date string) int {
+	query := `
+		SELECT COUNT(DISTINCT fetched_at) 
+		FROM crypto_prices 
+		WHERE source = $1 
+		AND DATE(fetched_at) = $2
+	`
+
+	var count int
+	err := cs.db.QueryRow(query, cs.provider.GetSourceName(), date).Scan(&count)
+	if err != nil {
+		return 0
 	}
+	return count
+}
 
-	// Fallback: assume symbol is the same as coin ID
-	return symbol
-}
\ No newline at end of file
+// getAPICallCountSince gets the number of API calls made since a specific time
+func (cs *CryptoService) getAPICallCountSince(since time.Time) int {
+	query := `
+		SELECT COUNT(DISTINCT fetched_at) 
+		FROM crypto_prices 
+		WHERE source = $1 
+		AND fetched_at > $2
+	`
+
+	var count int
+	err := cs.db.QueryRow(query, cs.provider.GetSourceName(), since).Scan(&count)
+	if err != nil {
+		return 0
+	}
+	return count
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// StakingPosition is a crypto holding's staked and liquid balance with the
+// rewards it has earned
+type StakingPosition struct {
+	HoldingID       int        `json:"crypto_holding_id"`
+	InstitutionName string     `json:"institution_name"`
+	Symbol          string     `json:"crypto_symbol"`
+	BalanceTokens   float64    `json:"balance_tokens"`
+	StakedTokens    float64    `json:"staked_tokens"`
+	LiquidTokens    float64    `json:"liquid_tokens"`
+	APRPercent      float64    `json:"apr_percent"`
+	AccruedThrough  *time.Time `json:"accrued_through"`
+	// PendingRewardTokens have accrued since AccruedThrough but aren't
+	// recorded yet
+	PendingRewardTokens float64 `json:"pending_reward_tokens"`
+	// RewardTokens and RewardIncomeUSD total the recorded rewards, valued
+	// when they were received
+	RewardTokens                float64  `json:"reward_tokens"`
+	RewardIncomeUSD             float64  `json:"reward_income_usd"`
+	ProjectedAnnualRewardTokens float64  `json:"projected_annual_reward_tokens"`
+	CurrentPriceUSD             *float64 `json:"current_price_usd"`
+	ProjectedAnnualIncomeUSD    *float64 `json:"projected_annual_income_usd"`
+}
+
+// CryptoIncomeMonth is the staking reward income of one month
+type CryptoIncomeMonth struct {
+	Month     string             `json:"month"` // YYYY-MM
+	IncomeUSD float64            `json:"income_usd"`
+	BySymbol  map[string]float64 `json:"by_symbol"`
+}
+
+// CryptoIncome is staking reward income by month, valued when received
+type CryptoIncome struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	TotalUSD float64             `json:"total_usd"`
+	BySymbol map[string]float64  `json:"by_symbol"`
+	Months   []CryptoIncomeMonth `json:"months"`
+}
+
+// CryptoStakingService accrues staking rewards on staked balances and
+// reports the income they bring in
+type CryptoStakingService struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewCryptoStakingService creates a new crypto staking service
+func NewCryptoStakingService(db *sql.DB) *CryptoStakingService {
+	return &CryptoStakingService{db: db, dialect: database.DialectOf(db)}
+}
+
+// stakingReward is the reward on staked tokens over days at a simple annual rate
+func stakingReward(staked, aprPercent float64, days int) float64 {
+	return staked * aprPercent / 100 * float64(days) / 365
+}
+
+// Positions returns every holding that is staked or has earned rewards, by
+// institution and symbol
+func (css *CryptoStakingService) Positions(now time.Time) ([]StakingPosition, error) {
+	rows, err := css.db.Query(`
+		SELECT ch.id, ch.institution_name, UPPER(ch.crypto_symbol), ch.balance_tokens, ch.staked_tokens,
+		       COALESCE(ch.staking_annual_percentage, 0), ch.staking_accrued_through,
+		       COALESCE(r.tokens, 0), COALESCE(r.income, 0), (
+		           SELECT cp.price_usd FROM crypto_prices cp
+		           WHERE cp.symbol = ch.crypto_symbol
+		           ORDER BY cp.last_updated DESC
+		           LIMIT 1
+		       )
+		FROM crypto_holdings ch
+		LEFT JOIN (
+			SELECT crypto_holding_id, SUM(quantity) AS tokens, SUM(quantity * price_usd) AS income
+			FROM crypto_transactions
+			WHERE transaction_type = 'reward'
+			GROUP BY crypto_holding_id
+		) r ON r.crypto_holding_id = ch.id
+		WHERE ch.staked_tokens > 0 OR r.tokens > 0
+		ORDER BY ch.institution_name, ch.crypto_symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch staking positions: %w", err)
+	}
+	defer rows.Close()
+
+	today := dateOnly(now)
+	positions := []StakingPosition{}
+	for rows.Next() {
+		var p StakingPosition
+		err := rows.Scan(&p.HoldingID, &p.InstitutionName, &p.Symbol, &p.BalanceTokens, &p.StakedTokens,
+			&p.APRPercent, &p.AccruedThrough, &p.RewardTokens, &p.RewardIncomeUSD, &p.CurrentPriceUSD)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan staking position: %w", err)
+		}
+		p.LiquidTokens = p.BalanceTokens - p.StakedTokens
+		if p.AccruedThrough != nil && today.After(*p.AccruedThrough) {
+			days := int(today.Sub(*p.AccruedThrough).Hours() / 24)
+			p.PendingRewardTokens = stakingReward(p.StakedTokens, p.APRPercent, days)
+		}
+		p.ProjectedAnnualRewardTokens = stakingReward(p.StakedTokens, p.APRPercent, 365)
+		if p.CurrentPriceUSD != nil {
+			income := roundCents(p.ProjectedAnnualRewardTokens * *p.CurrentPriceUSD)
+			p.ProjectedAnnualIncomeUSD = &income
+		}
+		p.RewardIncomeUSD = roundCents(p.RewardIncomeUSD)
+		positions = append(positions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch staking positions: %w", err)
+	}
+	return positions, nil
+}
+
+// Accrue records the rewards each staked holding has earned since it was
+// last accrued as a reward transaction dated today, valued at the latest
+// cached price, and adds them to its staked balance. A holding that just
+// started staking begins accruing today. It returns how many rewards were
+// recorded.
+func (css *CryptoStakingService) Accrue(now time.Time) (int, error) {
+	today := dateOnly(now)
+	_, err := css.db.Exec(`
+		UPDATE crypto_holdings SET staking_accrued_through = NULL
+		WHERE staking_accrued_through IS NOT NULL
+		  AND NOT (staked_tokens > 0 AND staking_annual_percentage > 0)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stop staking accrual: %w", err)
+	}
+	_, err = css.db.Exec(`
+		UPDATE crypto_holdings SET staking_accrued_through = $1
+		WHERE staking_accrued_through IS NULL AND staked_tokens > 0 AND staking_annual_percentage > 0
+	`, today)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start staking accrual: %w", err)
+	}
+
+	rows, err := css.db.Query(`
+		SELECT id FROM crypto_holdings
+		WHERE staking_accrued_through < $1 AND staked_tokens > 0 AND staking_annual_percentage > 0
+		ORDER BY id
+	`, today)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch staked holdings: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan staked holding: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to fetch staked holdings: %w", err)
+	}
+
+	recorded := 0
+	for _, id := range ids {
+		ok, err := css.accrueHolding(id, today)
+		if err != nil {
+			fmt.Printf("WARNING: Staking accrual for crypto holding %d failed: %v\n", id, err)
+			continue
+		}
+		if ok {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// accrueHolding records one holding's rewards through today. Rewards too
+// small to store keep accruing until they aren't.
+func (css *CryptoStakingService) accrueHolding(id int, today time.Time) (bool, error) {
+	tx, err := css.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var symbol string
+	var staked, apr float64
+	var through time.Time
+	err = tx.QueryRow(`
+		SELECT crypto_symbol, staked_tokens, staking_annual_percentage, staking_accrued_through
+		FROM crypto_holdings
+		WHERE id = $1 AND staking_accrued_through < $2 AND staked_tokens > 0 AND staking_annual_percentage > 0
+	`+css.dialect.ForUpdate(), id, today).Scan(&symbol, &staked, &apr, &through)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch crypto holding: %w", err)
+	}
+
+	days := int(today.Sub(dateOnly(through)).Hours() / 24)
+	reward := math.Floor(stakingReward(staked, apr, days)*1e8) / 1e8
+	if reward <= 0 {
+		return false, nil
+	}
+
+	var price float64
+	err = tx.QueryRow(`
+		SELECT price_usd FROM crypto_prices WHERE symbol = $1 ORDER BY last_updated DESC LIMIT 1
+	`, symbol).Scan(&price)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to fetch %s price: %w", symbol, err)
+	}
+	if err == sql.ErrNoRows {
+		fmt.Printf("WARNING: No %s price for staking rewards; recording them at $0\n", symbol)
+	}
+
+	notes := fmt.Sprintf("Staking rewards for %d days at %g%% APR", days, apr)
+	if _, err := insertCryptoTransaction(tx, id, CryptoTransactionReward, reward, price, 0, today, &notes); err != nil {
+		return false, err
+	}
+	if err := moveCryptoBalance(tx, id, reward, reward); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE crypto_holdings SET staking_accrued_through = $2 WHERE id = $1`, id, today); err != nil {
+		return false, fmt.Errorf("failed to update staking accrual: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit staking rewards: %w", err)
+	}
+	return true, nil
+}
+
+// Income returns reward income by month between from and to (inclusive
+// dates), including months without any
+func (css *CryptoStakingService) Income(from, to time.Time) (*CryptoIncome, error) {
+	from, to = dateOnly(from), dateOnly(to)
+	rows, err := css.db.Query(`
+		SELECT `+css.dialect.Month("ct.transaction_date")+`, UPPER(ch.crypto_symbol), SUM(ct.quantity * ct.price_usd)
+		FROM crypto_transactions ct
+		JOIN crypto_holdings ch ON ch.id = ct.crypto_holding_id
+		WHERE ct.transaction_type = 'reward' AND ct.transaction_date >= $1 AND ct.transaction_date <= $2
+		GROUP BY 1, 2
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch staking income: %w", err)
+	}
+	defer rows.Close()
+
+	income := &CryptoIncome{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		BySymbol: map[string]float64{},
+		Months:   []CryptoIncomeMonth{},
+	}
+	byMonth := map[string]map[string]float64{}
+	for rows.Next() {
+		var month, symbol string
+		var amount float64
+		if err := rows.Scan(&month, &symbol, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan staking income: %w", err)
+		}
+		if byMonth[month] == nil {
+			byMonth[month] = map[string]float64{}
+		}
+		byMonth[month][symbol] += amount
+		income.BySymbol[symbol] += amount
+		income.TotalUSD += amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch staking income: %w", err)
+	}
+
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(to); month = month.AddDate(0, 1, 0) {
+		label := month.Format("2006-01")
+		entry := CryptoIncomeMonth{Month: label, BySymbol: map[string]float64{}}
+		for symbol, amount := range byMonth[label] {
+			entry.BySymbol[symbol] = roundCents(amount)
+			entry.IncomeUSD += amount
+		}
+		entry.IncomeUSD = roundCents(entry.IncomeUSD)
+		income.Months = append(income.Months, entry)
+	}
+
+	for symbol, amount := range income.BySymbol {
+		income.BySymbol[symbol] = roundCents(amount)
+	}
+	income.TotalUSD = roundCents(income.TotalUSD)
+	return income, nil
+}
+
+// Run accrues rewards now and then every interval until ctx is done, calling
+// onAccrued after rewards are recorded
+func (css *CryptoStakingService) Run(ctx context.Context, interval time.Duration, onAccrued func()) {
+	run := func() {
+		recorded, err := css.Accrue(time.Now())
+		if err != nil {
+			fmt.Printf("WARNING: Staking accrual failed: %v\n", err)
+			return
+		}
+		if recorded > 0 && onAccrued != nil {
+			onAccrued()
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
@@ -0,0 +1,814 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+	"networth-dashboard/internal/sqlbuilder"
+)
+
+var (
+	// ErrDemoDataLoaded is returned when seeding while demo data is present
+	ErrDemoDataLoaded = errors.New("demo data is already loaded")
+	// ErrDatabaseNotEmpty is returned when seeding a database that has holdings,
+	// so demo data never mixes with real data
+	ErrDatabaseNotEmpty = errors.New("demo data can only be loaded into a database without holdings")
+	// ErrNoDemoData is returned when wiping without demo data loaded
+	ErrNoDemoData = errors.New("no demo data is loaded")
+)
+
+const (
+	// demoSeededAtSetting records in app_settings when demo data was loaded
+	demoSeededAtSetting = "demo_data_seeded_at"
+	// demoHistoryDays is how much price and net worth history is generated
+	demoHistoryDays = 365
+	// demoSource marks generated prices
+	demoSource = "demo"
+)
+
+// demoTables lists the tables demo data is written to, in the order rows are
+// removed so nothing is deleted while another row still references it
+var demoTables = []string{
+	"vesting_schedule",
+	"asset_valuation_history",
+	"equity_grants",
+	"stock_holdings",
+	"real_estate_properties",
+	"cash_holdings",
+	"crypto_holdings",
+	"miscellaneous_assets",
+	"stock_prices",
+	"crypto_prices",
+	"net_worth_snapshots",
+	"accounts",
+}
+
+// DemoDataStatus reports whether demo data is loaded and how many rows of each
+// table it added
+type DemoDataStatus struct {
+	Loaded   bool           `json:"loaded"`
+	SeededAt *time.Time     `json:"seeded_at,omitempty"`
+	Records  map[string]int `json:"records"`
+}
+
+// DemoDataService loads a realistic fake portfolio with a year of price and net
+// worth history, so the dashboard can be evaluated without real data, and
+// removes it again. Every row it adds is recorded in demo_records.
+type DemoDataService struct {
+	db *sql.DB
+}
+
+// NewDemoDataService creates a new demo data service
+func NewDemoDataService(db *sql.DB) *DemoDataService {
+	return &DemoDataService{db: db}
+}
+
+type demoStock struct {
+	symbol      string
+	company     string
+	institution string
+	shares      float64
+	costBasis   float64
+	price       float64
+	volatility  float64
+	purchased   string
+}
+
+type demoCrypto struct {
+	symbol        string
+	institution   string
+	tokens        float64
+	purchasePrice float64
+	price         float64
+	volatility    float64
+	purchased     string
+}
+
+type demoCash struct {
+	institution  string
+	name         string
+	accountType  string
+	balance      float64
+	interestRate float64
+	contribution float64
+}
+
+type demoProperty struct {
+	propertyType  string
+	name          string
+	street        string
+	city          string
+	state         string
+	zip           string
+	purchasePrice float64
+	value         float64
+	mortgage      float64
+	purchased     string
+	sqft          float64
+	rent          float64
+	propertyTax   float64
+	// appreciation and principal are the annual value growth rate and
+	// monthly mortgage principal paid, used to generate history
+	appreciation float64
+	principal    float64
+}
+
+type demoGrant struct {
+	grantType   string
+	shares      int
+	strikePrice float64
+	// yearsAgo is when the grant was made; it vests quarterly over four years
+	yearsAgo int
+}
+
+type demoAsset struct {
+	category     string
+	name         string
+	description  string
+	value        float64
+	purchase     float64
+	owed         float64
+	purchased    string
+	customFields map[string]interface{}
+	// annualChange is the yearly value change (negative for depreciation)
+	// and monthlyPayment the loan principal paid each month
+	annualChange   float64
+	monthlyPayment float64
+}
+
+// demoEmployer is the company whose equity grants the demo portfolio holds
+var demoEmployer = demoStock{symbol: "CRM", company: "Salesforce, Inc.", price: 265, volatility: 0.019}
+
+var demoStocks = []demoStock{
+	{"VTI", "Vanguard Total Stock Market ETF", "Vanguard", 420, 185.40, 298.50, 0.010, "2019-03-14"},
+	{"VXUS", "Vanguard Total International Stock ETF", "Vanguard", 380, 52.10, 66.20, 0.009, "2020-06-02"},
+	{"BND", "Vanguard Total Bond Market ETF", "Vanguard", 300, 78.90, 73.10, 0.003, "2021-01-11"},
+	{"AAPL", "Apple Inc.", "Fidelity", 120, 131.25, 232.80, 0.015, "2020-11-20"},
+	{"MSFT", "Microsoft Corporation", "Fidelity", 75, 242.60, 428.40, 0.014, "2021-04-07"},
+	{"NVDA", "NVIDIA Corporation", "Fidelity", 210, 38.75, 178.90, 0.028, "2022-10-18"},
+	{"GOOGL", "Alphabet Inc.", "Charles Schwab", 90, 104.30, 192.10, 0.017, "2022-12-05"},
+	{"AMZN", "Amazon.com, Inc.", "Charles Schwab", 65, 118.90, 221.30, 0.018, "2023-02-21"},
+}
+
+var demoCryptos = []demoCrypto{
+	{"BTC", "Coinbase", 0.85, 28400, 64800, 0.030, "2023-03-09"},
+	{"ETH", "Coinbase", 6.5, 1850, 3150, 0.036, "2023-05-16"},
+	{"SOL", "Kraken", 40, 21.50, 148.60, 0.045, "2023-09-27"},
+}
+
+var demoCashAccounts = []demoCash{
+	{"Ally Bank", "High Yield Savings", "high_yield_savings", 42500, 4.20, 1000},
+	{"Chase", "Everyday Checking", "checking", 8750, 0.01, 0},
+	{"Fidelity", "Brokerage Cash", "brokerage", 3200, 3.90, 0},
+}
+
+var demoProperties = []demoProperty{
+	{
+		propertyType: "primary_residence", name: "Maple Street Home",
+		street: "1428 Maple Street", city: "Austin", state: "TX", zip: "78704",
+		purchasePrice: 455000, value: 642000, mortgage: 308000, purchased: "2018-07-20",
+		sqft: 2150, propertyTax: 11800, appreciation: 0.045, principal: 780,
+	},
+	{
+		propertyType: "investment_property", name: "Lakeview Condo",
+		street: "77 Shoreline Drive, Unit 4B", city: "Round Rock", state: "TX", zip: "78681",
+		purchasePrice: 284000, value: 318500, mortgage: 204000, purchased: "2021-09-03",
+		sqft: 980, rent: 2150, propertyTax: 5400, appreciation: 0.03, principal: 410,
+	},
+}
+
+var demoGrants = []demoGrant{
+	{grantType: "rsu", shares: 1600, yearsAgo: 2},
+	{grantType: "iso", shares: 4000, strikePrice: 182.50, yearsAgo: 3},
+}
+
+var demoAssets = []demoAsset{
+	{
+		category: "Vehicles", name: "2021 Toyota RAV4 Hybrid", description: "Daily driver",
+		value: 24800, purchase: 34500, owed: 7900, purchased: "2021-05-14",
+		customFields: map[string]interface{}{"make": "Toyota", "model": "RAV4 Hybrid", "year": 2021, "mileage": 48200, "condition": "good"},
+		annualChange: -0.12, monthlyPayment: 430,
+	},
+	{
+		category: "Jewelry & Collectibles", name: "Omega Speedmaster", description: "Moonwatch, box and papers",
+		value: 6400, purchase: 5600, purchased: "2019-12-24",
+		customFields: map[string]interface{}{"type": "watch", "material": "Stainless steel"},
+		annualChange: 0.04,
+	},
+}
+
+// Status reports whether demo data is loaded
+func (ds *DemoDataService) Status() (DemoDataStatus, error) {
+	status := DemoDataStatus{Records: make(map[string]int)}
+
+	var seededAt string
+	err := ds.db.QueryRow("SELECT value FROM app_settings WHERE key = $1", demoSeededAtSetting).Scan(&seededAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("failed to check demo data: %w", err)
+	}
+	if at, err := time.Parse(time.RFC3339, seededAt); err == nil {
+		status.SeededAt = &at
+	}
+	status.Loaded = true
+
+	rows, err := ds.db.Query("SELECT table_name, COUNT(*) FROM demo_records GROUP BY table_name")
+	if err != nil {
+		return status, fmt.Errorf("failed to count demo data: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		var count int
+		if err := rows.Scan(&table, &count); err != nil {
+			return status, fmt.Errorf("failed to count demo data: %w", err)
+		}
+		status.Records[table] = count
+	}
+	return status, rows.Err()
+}
+
+// Seed loads the demo portfolio, valued as of now, into a database without
+// holdings
+func (ds *DemoDataService) Seed(now time.Time) (DemoDataStatus, error) {
+	status, err := ds.Status()
+	if err != nil {
+		return status, err
+	}
+	if status.Loaded {
+		return status, ErrDemoDataLoaded
+	}
+
+	var hasHoldings bool
+	err = ds.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM stock_holdings) OR EXISTS(SELECT 1 FROM equity_grants)
+		    OR EXISTS(SELECT 1 FROM real_estate_properties) OR EXISTS(SELECT 1 FROM cash_holdings)
+		    OR EXISTS(SELECT 1 FROM crypto_holdings) OR EXISTS(SELECT 1 FROM miscellaneous_assets)
+	`).Scan(&hasHoldings)
+	if err != nil {
+		return status, fmt.Errorf("failed to check for holdings: %w", err)
+	}
+	if hasHoldings {
+		return status, ErrDatabaseNotEmpty
+	}
+
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return status, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	seeder := newDemoSeeder(tx, database.DialectOf(ds.db), now)
+	if err := seeder.seed(); err != nil {
+		return status, err
+	}
+	if err := seeder.saveRecords(); err != nil {
+		return status, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO app_settings (key, value, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at
+	`, demoSeededAtSetting, now.UTC().Format(time.RFC3339), now)
+	if err != nil {
+		return status, fmt.Errorf("failed to record demo data: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return status, fmt.Errorf("failed to commit demo data: %w", err)
+	}
+
+	fmt.Printf("INFO: Loaded demo data with %d days of history\n", demoHistoryDays)
+	return ds.Status()
+}
+
+// Wipe removes every row demo data added, along with the net worth and
+// holding snapshots recorded since it was loaded, which were computed from it.
+// It returns the number of rows removed per table.
+func (ds *DemoDataService) Wipe() (map[string]int64, error) {
+	status, err := ds.Status()
+	if err != nil {
+		return nil, err
+	}
+	if !status.Loaded {
+		return nil, ErrNoDemoData
+	}
+
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed := make(map[string]int64)
+	if status.SeededAt != nil {
+		// Demo prices older than the price retention period were folded into
+		// the daily tables, so the daily prices of demo holdings up to the
+		// seeding date go too
+		for _, daily := range []struct{ table, holdings, column string }{
+			{"stock_prices_daily", "stock_holdings", "symbol"},
+			{"crypto_prices_daily", "crypto_holdings", "crypto_symbol"},
+		} {
+			result, err := tx.Exec(fmt.Sprintf(`
+				DELETE FROM %[1]s WHERE price_date <= %[4]s AND symbol IN (
+					SELECT %[3]s FROM %[2]s
+					WHERE id IN (SELECT record_id FROM demo_records WHERE table_name = $2)
+				)
+			`, daily.table, daily.holdings, daily.column, database.DialectOf(ds.db).Date("$1")), *status.SeededAt, daily.holdings)
+			if err != nil {
+				return nil, fmt.Errorf("failed to remove demo %s: %w", daily.table, err)
+			}
+			removed[daily.table], _ = result.RowsAffected()
+		}
+	}
+	for _, table := range demoTables {
+		result, err := tx.Exec(fmt.Sprintf(`
+			DELETE FROM %s WHERE id IN (SELECT record_id FROM demo_records WHERE table_name = $1)
+		`, sqlbuilder.Ident(table)), table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove demo %s: %w", table, err)
+		}
+		removed[table], _ = result.RowsAffected()
+	}
+
+	if status.SeededAt != nil {
+		result, err := tx.Exec("DELETE FROM net_worth_snapshots WHERE timestamp >= $1", *status.SeededAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove net worth snapshots: %w", err)
+		}
+		count, _ := result.RowsAffected()
+		removed["net_worth_snapshots"] += count
+
+		result, err = tx.Exec("DELETE FROM holding_snapshots WHERE recorded_at >= $1", *status.SeededAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove holding snapshots: %w", err)
+		}
+		removed["holding_snapshots"], _ = result.RowsAffected()
+	}
+
+	if _, err := tx.Exec("DELETE FROM demo_records"); err != nil {
+		return nil, fmt.Errorf("failed to clear demo records: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM app_settings WHERE key = $1", demoSeededAtSetting); err != nil {
+		return nil, fmt.Errorf("failed to clear demo data setting: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit demo data removal: %w", err)
+	}
+
+	fmt.Printf("INFO: Removed demo data\n")
+	return removed, nil
+}
+
+// demoSeeder writes the demo portfolio in one transaction, remembering the ID
+// of every row it inserts
+type demoSeeder struct {
+	tx      *sql.Tx
+	dialect database.Dialect
+	now     time.Time
+	today   time.Time
+	records map[string][]int64
+	// prices holds each symbol's generated daily prices, oldest first and
+	// ending today
+	prices map[string][]float64
+}
+
+func newDemoSeeder(tx *sql.Tx, dialect database.Dialect, now time.Time) *demoSeeder {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return &demoSeeder{
+		tx:      tx,
+		dialect: dialect,
+		now:     now,
+		today:   today,
+		records: make(map[string][]int64),
+		prices:  make(map[string][]float64),
+	}
+}
+
+// day returns the date i days into the generated history; day demoHistoryDays is today
+func (s *demoSeeder) day(i int) time.Time {
+	return s.today.AddDate(0, 0, i-demoHistoryDays)
+}
+
+// insert runs an INSERT ... RETURNING id and records the new row
+func (s *demoSeeder) insert(table, query string, args ...interface{}) (int64, error) {
+	var id int64
+	if err := s.tx.QueryRow(query, args...).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to seed demo %s: %w", table, err)
+	}
+	s.records[table] = append(s.records[table], id)
+	return id, nil
+}
+
+// insertMany runs an INSERT ... RETURNING id that adds several rows and
+// records them all
+func (s *demoSeeder) insertMany(table, query string, args ...interface{}) error {
+	rows, err := s.tx.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to seed demo %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to seed demo %s: %w", table, err)
+		}
+		s.records[table] = append(s.records[table], id)
+	}
+	return rows.Err()
+}
+
+func (s *demoSeeder) account(name, accountType, institution string) (int64, error) {
+	return s.insert("accounts", `
+		INSERT INTO accounts (account_name, account_type, institution, data_source_type, created_at, updated_at)
+		VALUES ($1, $2, $3, 'demo', $4, $4)
+		RETURNING id
+	`, name, accountType, institution, s.now)
+}
+
+func (s *demoSeeder) saveRecords() error {
+	for table, ids := range s.records {
+		_, err := s.tx.Exec(`
+			INSERT INTO demo_records (table_name, record_id)
+			SELECT $1, r.id FROM `+s.dialect.Unnest("r", database.ArrayColumn{Param: "$2", Type: "bigint", Name: "id"})+`
+		`, table, ids)
+		if err != nil {
+			return fmt.Errorf("failed to record demo %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (s *demoSeeder) seed() error {
+	steps := []func() error{
+		s.seedStocks,
+		s.seedCrypto,
+		s.seedCash,
+		s.seedRealEstate,
+		s.seedEquityGrants,
+		s.seedOtherAssets,
+		s.seedNetWorthHistory,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pricePath generates a daily random walk for symbol that ends at current.
+// Walks are seeded by symbol, so every load of demo data looks the same.
+func pricePath(symbol string, current, volatility float64) []float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(symbol))
+	rng := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	path := make([]float64, demoHistoryDays+1)
+	path[demoHistoryDays] = current
+	// Walk backwards from today with a small upward drift
+	for i := demoHistoryDays; i > 0; i-- {
+		change := 0.0004 + volatility*rng.NormFloat64()
+		path[i-1] = path[i] / math.Exp(change)
+	}
+	for i := range path {
+		path[i] = math.Round(path[i]*100) / 100
+	}
+	return path
+}
+
+// historyDates returns the dates of the generated history, oldest first
+func (s *demoSeeder) historyDates() []time.Time {
+	dates := make([]time.Time, demoHistoryDays+1)
+	for i := range dates {
+		dates[i] = s.day(i)
+	}
+	return dates
+}
+
+// pricePoints is a table p of the prices in $2 paired with the times in $3
+func (s *demoSeeder) pricePoints() string {
+	return s.dialect.Unnest("p",
+		database.ArrayColumn{Param: "$2", Type: "numeric", Name: "price"},
+		database.ArrayColumn{Param: "$3", Type: "timestamp", Name: "at"})
+}
+
+func (s *demoSeeder) seedStockPrices(symbol string, current, volatility float64) error {
+	path := pricePath(symbol, current, volatility)
+	s.prices[symbol] = path
+	return s.insertMany("stock_prices", `
+		INSERT INTO stock_prices (symbol, price, timestamp, source)
+		SELECT $1, price, at, $4 FROM `+s.pricePoints()+`
+		RETURNING id
+	`, symbol, path, s.historyDates(), demoSource)
+}
+
+func (s *demoSeeder) seedStocks() error {
+	for _, stock := range demoStocks {
+		accountID, err := s.account(fmt.Sprintf("Stock Holdings - %s at %s", stock.symbol, stock.institution), "stock", stock.institution)
+		if err != nil {
+			return err
+		}
+		_, err = s.insert("stock_holdings", `
+			INSERT INTO stock_holdings (
+				account_id, symbol, company_name, shares_owned, cost_basis, current_price,
+				institution_name, data_source, purchase_date, last_manual_update
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, 'stock_holding', $8, $9)
+			RETURNING id
+		`, accountID, stock.symbol, stock.company, stock.shares, stock.costBasis, stock.price,
+			stock.institution, stock.purchased, s.now)
+		if err != nil {
+			return err
+		}
+		if err := s.seedStockPrices(stock.symbol, stock.price, stock.volatility); err != nil {
+			return err
+		}
+
+		// Daily holding snapshots feed the unrealized gains history
+		path := s.prices[stock.symbol]
+		values := make([]float64, len(path))
+		for i, price := range path {
+			values[i] = math.Round(stock.shares*price*100) / 100
+		}
+		// WHERE true keeps SQLite from reading ON CONFLICT as a join constraint
+		_, err = s.tx.Exec(`
+			INSERT INTO holding_snapshots (snapshot_date, symbol, shares_owned, cost_basis_total, market_value, recorded_at)
+			SELECT `+s.dialect.Date("h.at")+`, $1, $2, $3, value, $5 FROM `+s.dialect.Unnest("h",
+			database.ArrayColumn{Param: "$4", Type: "numeric", Name: "value"},
+			database.ArrayColumn{Param: "$6", Type: "timestamp", Name: "at"})+`
+			WHERE true
+			ON CONFLICT (snapshot_date, symbol) DO NOTHING
+		`, stock.symbol, stock.shares, stock.shares*stock.costBasis, values, s.now, s.historyDates())
+		if err != nil {
+			return fmt.Errorf("failed to seed demo holding snapshots: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *demoSeeder) seedCrypto() error {
+	for _, coin := range demoCryptos {
+		accountID, err := s.account(fmt.Sprintf("Crypto Holdings - %s %s", coin.institution, coin.symbol), "crypto", coin.institution)
+		if err != nil {
+			return err
+		}
+		_, err = s.insert("crypto_holdings", `
+			INSERT INTO crypto_holdings (
+				account_id, institution_name, crypto_symbol, balance_tokens,
+				purchase_price_usd, purchase_date, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+			RETURNING id
+		`, accountID, coin.institution, coin.symbol, coin.tokens, coin.purchasePrice, coin.purchased, s.now)
+		if err != nil {
+			return err
+		}
+
+		path := pricePath(coin.symbol, coin.price, coin.volatility)
+		s.prices[coin.symbol] = path
+		err = s.insertMany("crypto_prices", `
+			INSERT INTO crypto_prices (symbol, price_usd, last_updated, source, fetched_at)
+			SELECT $1, price, at, $4, at FROM `+s.pricePoints()+`
+			RETURNING id
+		`, coin.symbol, path, s.historyDates(), demoSource)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *demoSeeder) seedCash() error {
+	for _, cash := range demoCashAccounts {
+		accountID, err := s.account(fmt.Sprintf("Cash Holdings - %s %s", cash.institution, cash.name), "cash", cash.institution)
+		if err != nil {
+			return err
+		}
+		_, err = s.insert("cash_holdings", `
+			INSERT INTO cash_holdings (
+				account_id, institution_name, account_name, account_type,
+				current_balance, interest_rate, monthly_contribution, currency, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, 'USD', $8, $8)
+			RETURNING id
+		`, accountID, cash.institution, cash.name, cash.accountType, cash.balance, cash.interestRate,
+			nullIfZero(cash.contribution), s.now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *demoSeeder) seedRealEstate() error {
+	for _, property := range demoProperties {
+		accountID, err := s.account("Real Estate - "+property.name, "real_estate", "Manual Entry")
+		if err != nil {
+			return err
+		}
+		_, err = s.insert("real_estate_properties", `
+			INSERT INTO real_estate_properties (
+				account_id, property_type, property_name, street_address, city, state, zip_code,
+				purchase_price, current_value, outstanding_mortgage, purchase_date,
+				property_size_sqft, rental_income_monthly, property_tax_annual
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING id
+		`, accountID, property.propertyType, property.name, property.street, property.city, property.state, property.zip,
+			property.purchasePrice, property.value, property.mortgage, property.purchased,
+			property.sqft, nullIfZero(property.rent), property.propertyTax)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grantVests returns the quarterly vest dates of a grant made yearsAgo, over four years
+func (s *demoSeeder) grantVests(grant demoGrant) (time.Time, []time.Time) {
+	grantDate := s.today.AddDate(-grant.yearsAgo, 0, 0)
+	vests := make([]time.Time, 16)
+	for i := range vests {
+		vests[i] = grantDate.AddDate(0, 3*(i+1), 0)
+	}
+	return grantDate, vests
+}
+
+// vestedShares counts the shares of grant vested by date
+func (s *demoSeeder) vestedShares(grant demoGrant, date time.Time) int {
+	_, vests := s.grantVests(grant)
+	vested := 0
+	for _, vest := range vests {
+		if !vest.After(date) {
+			vested += grant.shares / len(vests)
+		}
+	}
+	return vested
+}
+
+func (s *demoSeeder) seedEquityGrants() error {
+	employer := demoEmployer
+	if err := s.seedStockPrices(employer.symbol, employer.price, employer.volatility); err != nil {
+		return err
+	}
+
+	for _, grant := range demoGrants {
+		accountID, err := s.account(fmt.Sprintf("Morgan Stanley - %s %s", employer.symbol, grant.grantType), "equity", "Morgan Stanley")
+		if err != nil {
+			return err
+		}
+		grantDate, vests := s.grantVests(grant)
+		vested := s.vestedShares(grant, s.today)
+		var expiration *time.Time
+		if models.IsOptionGrant(grant.grantType) {
+			expires := grantDate.AddDate(10, 0, 0)
+			expiration = &expires
+		}
+		grantID, err := s.insert("equity_grants", `
+			INSERT INTO equity_grants (
+				account_id, grant_type, company_symbol, total_shares, vested_shares,
+				strike_price, current_price, grant_date, vest_start_date,
+				expiration_date, data_source
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9, 'manual')
+			RETURNING id
+		`, accountID, grant.grantType, employer.symbol, grant.shares, vested,
+			nullIfZero(grant.strikePrice), employer.price, grantDate, expiration)
+		if err != nil {
+			return err
+		}
+
+		perVest := grant.shares / len(vests)
+		for i, vest := range vests {
+			_, err := s.insert("vesting_schedule", `
+				INSERT INTO vesting_schedule (grant_id, vest_date, shares_vesting, cumulative_vested, is_future_vest, data_source)
+				VALUES ($1, $2, $3, $4, $5, 'manual')
+				RETURNING id
+			`, grantID, vest, perVest, perVest*(i+1), vest.After(s.today))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *demoSeeder) seedOtherAssets() error {
+	for _, asset := range demoAssets {
+		accountID, err := s.account("Other Assets - "+asset.name, "other_assets", "Manual Entry")
+		if err != nil {
+			return err
+		}
+		var categoryID sql.NullInt64
+		err = s.tx.QueryRow("SELECT id FROM asset_categories WHERE name = $1", asset.category).Scan(&categoryID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to find asset category %s: %w", asset.category, err)
+		}
+		customFields, err := json.Marshal(asset.customFields)
+		if err != nil {
+			return fmt.Errorf("failed to encode demo custom fields: %w", err)
+		}
+
+		assetID, err := s.insert("miscellaneous_assets", `
+			INSERT INTO miscellaneous_assets (
+				account_id, asset_category_id, asset_name, current_value, purchase_price, amount_owed,
+				purchase_date, description, custom_fields, valuation_method, created_at, last_updated
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'manual', $10, $10)
+			RETURNING id
+		`, accountID, categoryID, asset.name, asset.value, asset.purchase, asset.owed,
+			asset.purchased, asset.description, customFields, s.now)
+		if err != nil {
+			return err
+		}
+
+		// A manual revaluation each month
+		for months := 12; months >= 0; months-- {
+			at := s.today.AddDate(0, -months, 0)
+			_, err := s.insert("asset_valuation_history", `
+				INSERT INTO asset_valuation_history (asset_id, value, source, valued_at)
+				VALUES ($1, $2, 'manual', $3)
+				RETURNING id
+			`, assetID, asset.valueAt(float64(months)/12), at)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// valueAt is the asset's value the given number of years ago
+func (a demoAsset) valueAt(yearsAgo float64) float64 {
+	return math.Round(a.value/math.Pow(1+a.annualChange, yearsAgo)*100) / 100
+}
+
+// seedNetWorthHistory records a daily snapshot valuing the demo holdings at
+// each day's generated prices, with cash savings, mortgage principal and
+// property values rolled back to that day
+func (s *demoSeeder) seedNetWorthHistory() error {
+	for i := 0; i <= demoHistoryDays; i++ {
+		date := s.day(i)
+		daysAgo := float64(demoHistoryDays - i)
+		monthsAgo := daysAgo / 30.44
+		var b struct {
+			stocks, vested, unvested, realEstate, cash, crypto, other float64
+		}
+
+		for _, stock := range demoStocks {
+			b.stocks += stock.shares * s.prices[stock.symbol][i]
+		}
+		for _, cash := range demoCashAccounts {
+			balance := math.Max(cash.balance-cash.contribution*math.Floor(monthsAgo), 0)
+			if cash.accountType == "brokerage" {
+				b.stocks += balance
+			} else {
+				b.cash += balance
+			}
+		}
+		for _, grant := range demoGrants {
+			vested := float64(s.vestedShares(grant, date))
+			price := s.prices[demoEmployer.symbol][i]
+			b.vested += vested * price
+			b.unvested += (float64(grant.shares) - vested) * price
+		}
+		for _, property := range demoProperties {
+			value := property.value / math.Pow(1+property.appreciation, daysAgo/365)
+			mortgage := property.mortgage + property.principal*monthsAgo
+			b.realEstate += value - mortgage
+		}
+		for _, coin := range demoCryptos {
+			b.crypto += coin.tokens * s.prices[coin.symbol][i]
+		}
+		for _, asset := range demoAssets {
+			b.other += asset.valueAt(daysAgo/365) - (asset.owed + asset.monthlyPayment*monthsAgo)
+		}
+
+		total := b.stocks + b.vested + b.realEstate + b.cash + b.crypto + b.other
+		_, err := s.insert("net_worth_snapshots", `
+			INSERT INTO net_worth_snapshots (
+				total_assets, total_liabilities, net_worth, vested_equity_value, unvested_equity_value,
+				stock_holdings_value, real_estate_equity, cash_holdings_value, crypto_holdings_value,
+				other_assets_value, timestamp, trigger_type
+			) VALUES ($1, 0, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id
+		`, roundCents(total), roundCents(b.vested), roundCents(b.unvested), roundCents(b.stocks),
+			roundCents(b.realEstate), roundCents(b.cash), roundCents(b.crypto), roundCents(b.other),
+			date, SnapshotTriggerScheduled)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullIfZero stores optional amounts that do not apply as NULL
+func nullIfZero(value float64) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
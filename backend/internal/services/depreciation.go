@@ -0,0 +1,121 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+// depreciationRecoveryYears is the straight-line recovery period per property
+// type. Residential rentals use 27.5 years and commercial buildings 39; homes,
+// vacation homes and land are not depreciated.
+var depreciationRecoveryYears = map[string]float64{
+	"investment_property": 27.5,
+	"commercial":          39,
+}
+
+// DepreciationYear is one calendar year of a depreciation schedule
+type DepreciationYear struct {
+	Year                    int     `json:"year"`
+	Depreciation            float64 `json:"depreciation"`
+	AccumulatedDepreciation float64 `json:"accumulated_depreciation"`
+	AdjustedBasis           float64 `json:"adjusted_basis"`
+}
+
+// PropertyDepreciation is a property's straight-line depreciation on its
+// building value as of a date. Figures are for the whole property. CostBasis
+// is the purchase price plus closing costs and capital improvements from the
+// property ledger; AdjustedBasis is cost basis less accumulated depreciation.
+type PropertyDepreciation struct {
+	PropertyID              int                `json:"property_id"`
+	PropertyName            string             `json:"property_name"`
+	PropertyType            string             `json:"property_type"`
+	Depreciable             bool               `json:"depreciable"`
+	Reason                  string             `json:"reason,omitempty"` // why a property is not depreciated
+	AsOf                    string             `json:"as_of"`
+	RecoveryYears           float64            `json:"recovery_years,omitempty"`
+	DepreciableBasis        float64            `json:"depreciable_basis"`
+	PlacedInServiceDate     string             `json:"placed_in_service_date,omitempty"`
+	AnnualDepreciation      float64            `json:"annual_depreciation"`
+	AccumulatedDepreciation float64            `json:"accumulated_depreciation"`
+	FullyDepreciatedYear    int                `json:"fully_depreciated_year,omitempty"`
+	CostBasis               float64            `json:"cost_basis"`
+	AdjustedBasis           float64            `json:"adjusted_basis"`
+	Schedule                []DepreciationYear `json:"schedule"`
+}
+
+// DepreciateProperty works out a property's depreciation as of asOf under the
+// mid-month convention: the month placed in service and the month of asOf
+// each count as half a month. basisAdditions is the closing costs and capital
+// improvements recorded for the property, which add to its cost basis but are
+// not depreciated separately.
+func DepreciateProperty(property models.RealEstate, basisAdditions float64, asOf time.Time) PropertyDepreciation {
+	d := PropertyDepreciation{
+		PropertyID:   property.ID,
+		PropertyName: property.PropertyName,
+		PropertyType: property.PropertyType,
+		AsOf:         asOf.Format("2006-01-02"),
+		CostBasis:    roundCents(property.PurchasePrice.InexactFloat64() + basisAdditions),
+		Schedule:     []DepreciationYear{},
+	}
+	d.AdjustedBasis = d.CostBasis
+
+	years, ok := depreciationRecoveryYears[property.PropertyType]
+	switch {
+	case !ok:
+		d.Reason = "only investment and commercial properties are depreciated"
+		return d
+	case property.ImprovementValue == nil || !property.ImprovementValue.IsPositive():
+		d.Reason = "improvement_value (the building's share of the purchase price) is not set"
+		return d
+	}
+
+	inService, err := time.Parse("2006-01-02", property.PurchaseDate)
+	if property.PlacedInServiceDate != nil {
+		inService, err = time.Parse("2006-01-02", *property.PlacedInServiceDate)
+	}
+	if err != nil {
+		d.Reason = "the placed in service date is missing"
+		return d
+	}
+
+	d.Depreciable = true
+	d.RecoveryYears = years
+	d.DepreciableBasis = property.ImprovementValue.InexactFloat64()
+	d.PlacedInServiceDate = inService.Format("2006-01-02")
+	d.AnnualDepreciation = roundCents(d.DepreciableBasis / years)
+
+	monthly := d.DepreciableBasis / (years * 12)
+	// accumulated returns depreciation taken by the middle of the month that
+	// is months after the one placed in service
+	accumulated := func(months float64) float64 {
+		return math.Max(0, math.Min(d.DepreciableBasis, monthly*months))
+	}
+
+	if !asOf.Before(inService) {
+		d.AccumulatedDepreciation = roundCents(accumulated(float64(monthsBetween(inService, asOf))))
+	}
+	d.AdjustedBasis = roundCents(d.CostBasis - d.AccumulatedDepreciation)
+
+	// A full calendar year takes twelve months; the first takes the rest of
+	// the year from the middle of the month placed in service
+	previous := 0.0
+	for year := inService.Year(); previous < d.DepreciableBasis; year++ {
+		total := accumulated(float64(monthsBetween(inService, time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC))) + 0.5)
+		d.Schedule = append(d.Schedule, DepreciationYear{
+			Year:                    year,
+			Depreciation:            roundCents(total - previous),
+			AccumulatedDepreciation: roundCents(total),
+			AdjustedBasis:           roundCents(d.CostBasis - total),
+		})
+		previous = total
+		d.FullyDepreciatedYear = year
+	}
+	return d
+}
+
+// monthsBetween counts the calendar months from the month of from to the month of to
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+	"networth-dashboard/internal/models"
+)
+
+// eBay sold-listing search defaults
+const (
+	ebaySearchQueryField    = "ebay_search_query"
+	defaultEbayLookbackDays = 90
+	defaultEbayMinSales     = 3
+	ebayInsightsScope       = "https://api.ebay.com/oauth/api_scope/buy.marketplace.insights"
+)
+
+// EbaySoldListingsProvider suggests a collectible's value as the median
+// price of recent eBay sales matching a search query kept in the asset's
+// ebay_search_query custom field. Its values are suggestions that must be
+// confirmed before they replace current_value.
+type EbaySoldListingsProvider struct {
+	clientID     string
+	clientSecret string
+	baseURL      string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewEbaySoldListingsProvider creates an eBay sold listings provider
+func NewEbaySoldListingsProvider(cfg *config.ApiConfig) *EbaySoldListingsProvider {
+	return &EbaySoldListingsProvider{
+		clientID:     cfg.EbayClientID,
+		clientSecret: cfg.EbayClientSecret,
+		baseURL:      strings.TrimRight(cfg.EbayBaseURL, "/"),
+		httpClient:   httpclient.New("ebay", 30*time.Second, cfg.HTTPRetry),
+	}
+}
+
+// Name is the provider's key in valuation_api_config
+func (p *EbaySoldListingsProvider) Name() string {
+	return "ebay_sold"
+}
+
+// GetProviderName returns the provider name
+func (p *EbaySoldListingsProvider) GetProviderName() string {
+	return "eBay Sold Listings"
+}
+
+// Description says what the provider offers
+func (p *EbaySoldListingsProvider) Description() string {
+	return "Median price of recent eBay sales matching the ebay_search_query custom field, suggested for confirmation (client ID and secret required)"
+}
+
+// IsAvailable reports whether eBay application credentials are configured
+func (p *EbaySoldListingsProvider) IsAvailable() bool {
+	return p.clientID != "" && p.clientSecret != ""
+}
+
+// RequiresConfirmation marks eBay values as suggestions: sold listings match
+// a free-text query, so a person checks the result before it is applied
+func (p *EbaySoldListingsProvider) RequiresConfirmation() bool {
+	return true
+}
+
+// Valuate returns the median sold price of matching listings over the
+// category's lookback_days (default 90), requiring min_sales (default 3)
+func (p *EbaySoldListingsProvider) Valuate(asset models.MiscellaneousAsset, cfg AssetValuationConfig) (float64, error) {
+	query := customFieldString(asset, ebaySearchQueryField)
+	if query == "" {
+		return 0, fmt.Errorf("an %s custom field is required for eBay valuation", ebaySearchQueryField)
+	}
+	lookback := cfg.LookbackDays
+	if lookback <= 0 {
+		lookback = defaultEbayLookbackDays
+	}
+	minSales := cfg.MinSales
+	if minSales <= 0 {
+		minSales = defaultEbayMinSales
+	}
+
+	token, err := p.accessToken()
+	if err != nil {
+		return 0, err
+	}
+
+	since := time.Now().AddDate(0, 0, -lookback).UTC().Format("2006-01-02T15:04:05Z")
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", "200")
+	params.Set("filter", "lastSoldDate:["+since+"..],priceCurrency:USD")
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/buy/marketplace_insights/v1_beta/item_sales/search?"+params.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-EBAY-C-MARKETPLACE-ID", "EBAY_US")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, readProviderError("eBay", resp)
+	}
+
+	var body struct {
+		ItemSales []struct {
+			LastSoldPrice struct {
+				Value    string `json:"value"`
+				Currency string `json:"currency"`
+			} `json:"lastSoldPrice"`
+		} `json:"itemSales"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode API response: %w", err)
+	}
+
+	prices := make([]float64, 0, len(body.ItemSales))
+	for _, sale := range body.ItemSales {
+		price, err := strconv.ParseFloat(sale.LastSoldPrice.Value, 64)
+		if err == nil && price > 0 && sale.LastSoldPrice.Currency == "USD" {
+			prices = append(prices, price)
+		}
+	}
+	if len(prices) < minSales {
+		return 0, fmt.Errorf("only %d eBay sales matched %q in the last %d days; at least %d are needed", len(prices), query, lookback, minSales)
+	}
+	return median(prices), nil
+}
+
+// accessToken returns an application token for the Marketplace Insights API,
+// requesting a new one shortly before the current one expires
+func (p *EbaySoldListingsProvider) accessToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", ebayInsightsScope)
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+"/identity/v1/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.clientID, p.clientSecret)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request eBay token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", readProviderError("eBay token", resp)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode eBay token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("eBay returned no access token")
+	}
+
+	p.token = body.AccessToken
+	p.tokenExpiry = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return p.token, nil
+}
+
+// median returns the middle value of prices, averaging the two middle
+// values of an even count. prices is sorted in place.
+func median(prices []float64) float64 {
+	sort.Float64s(prices)
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return (prices[mid-1] + prices[mid]) / 2
+	}
+	return prices[mid]
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// Email template names
+const (
+	EmailTemplateTest          = "test"
+	EmailTemplateNotification  = "notification"
+	EmailTemplateMonthlyReport = "monthly_report"
+)
+
+// emailTemplate renders the subject, plain text body and HTML body of an email
+// from the same data
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// TestEmail is the data of the test email
+type TestEmail struct {
+	Host   string
+	SentAt string
+}
+
+var emailFuncs = map[string]interface{}{
+	"currency":         formatCurrency,
+	"signedCurrency":   formatSignedCurrency,
+	"optionalCurrency": formatOptionalCurrency,
+	"optionalPercent":  formatOptionalPercent,
+}
+
+// emailLayout wraps every HTML body
+const emailLayout = `{{define "layout"}}<!DOCTYPE html>
+<html><body style="font-family: -apple-system, 'Segoe UI', Helvetica, Arial, sans-serif; color: #1f2937; max-width: 600px;">
+{{template "content" .}}
+<p style="color: #9ca3af; font-size: 12px; margin-top: 32px;">Sent by your Net Worth Dashboard</p>
+</body></html>{{end}}`
+
+func newEmailTemplate(name, subject, text, html string) emailTemplate {
+	return emailTemplate{
+		subject: texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(subject)),
+		text:    texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(text)),
+		html:    htmltemplate.Must(htmltemplate.Must(htmltemplate.New(name).Funcs(emailFuncs).Parse(emailLayout)).Parse(`{{template "layout" .}}` + html)),
+	}
+}
+
+var emailTemplates = map[string]emailTemplate{
+	EmailTemplateTest: newEmailTemplate(EmailTemplateTest,
+		`Net Worth Dashboard test email`,
+		`This is a test email from your Net Worth Dashboard, sent through {{.Host}} at {{.SentAt}}.
+
+If you can read this, email delivery is configured correctly.
+`,
+		`{{define "content"}}<h2>Email delivery works</h2>
+<p>This is a test email from your Net Worth Dashboard, sent through <strong>{{.Host}}</strong> at {{.SentAt}}.</p>{{end}}`),
+
+	EmailTemplateNotification: newEmailTemplate(EmailTemplateNotification,
+		`[{{.Severity}}] {{.Title}}`,
+		`{{.Title}}
+
+{{.Message}}
+
+Severity: {{.Severity}}
+Time: {{.CreatedAt.Format "2006-01-02 15:04"}}
+`,
+		`{{define "content"}}<h2>{{.Title}}</h2>
+<p>{{.Message}}</p>
+<p style="color: #6b7280;">Severity: {{.Severity}} &middot; {{.CreatedAt.Format "2006-01-02 15:04"}}</p>{{end}}`),
+
+	EmailTemplateMonthlyReport: newEmailTemplate(EmailTemplateMonthlyReport,
+		`Your {{.Title}} net worth statement`,
+		`Net worth statement for {{.Title}} ({{.From}} to {{.To}})
+
+Opening: {{optionalCurrency .NetWorth.Opening}}
+Closing: {{optionalCurrency .NetWorth.Closing}}
+Change:  {{with .NetWorth.Change}}{{signedCurrency .}}{{else}}-{{end}} ({{optionalPercent .NetWorth.ChangePercent}})
+{{with .TopGainers}}
+Top gainers:
+{{range .}}  {{.Symbol}} {{signedCurrency .MarketChange}}
+{{end}}{{end}}{{with .TopLosers}}
+Top losers:
+{{range .}}  {{.Symbol}} {{signedCurrency .MarketChange}}
+{{end}}{{end}}{{with .VestingEvents}}
+Vesting events:
+{{range .}}  {{.Date}} {{.Symbol}} {{.Shares}} shares ({{currency .Value}})
+{{end}}{{end}}
+The full statement is attached as a PDF.
+`,
+		`{{define "content"}}<h2>Net worth statement for {{.Title}}</h2>
+<p style="color: #6b7280;">{{.From}} to {{.To}}</p>
+<table cellpadding="4">
+<tr><td>Opening</td><td align="right">{{optionalCurrency .NetWorth.Opening}}</td></tr>
+<tr><td>Closing</td><td align="right">{{optionalCurrency .NetWorth.Closing}}</td></tr>
+<tr><td>Change</td><td align="right">{{with .NetWorth.Change}}{{signedCurrency .}}{{else}}-{{end}} ({{optionalPercent .NetWorth.ChangePercent}})</td></tr>
+</table>
+{{with .TopGainers}}<h3>Top gainers</h3>
+<ul>{{range .}}<li>{{.Symbol}} {{signedCurrency .MarketChange}}</li>{{end}}</ul>{{end}}
+{{with .TopLosers}}<h3>Top losers</h3>
+<ul>{{range .}}<li>{{.Symbol}} {{signedCurrency .MarketChange}}</li>{{end}}</ul>{{end}}
+{{with .VestingEvents}}<h3>Vesting events</h3>
+<ul>{{range .}}<li>{{.Date}} {{.Symbol}} {{.Shares}} shares ({{currency .Value}})</li>{{end}}</ul>{{end}}
+<p>The full statement is attached as a PDF.</p>{{end}}`),
+}
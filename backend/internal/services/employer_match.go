@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+var (
+	// ErrEmployerMatchRuleNotFound is returned when a cash holding has no employer match rule
+	ErrEmployerMatchRuleNotFound = errors.New("employer match rule not found")
+	// ErrEmployerMatchRuleExists is returned when a cash holding already has an employer match rule
+	ErrEmployerMatchRuleExists = errors.New("cash holding already has an employer match rule")
+	// ErrInvalidEmployerMatchRule is returned for a salary limit without a salary
+	ErrInvalidEmployerMatchRule = errors.New("invalid employer match rule")
+	// ErrMatchCashHoldingNotFound is returned when adding a rule to a cash holding that does not exist
+	ErrMatchCashHoldingNotFound = errors.New("cash holding not found")
+)
+
+// EmployerMatchRule is how an employer matches contributions to a retirement
+// account. The employer adds MatchPercent of the employee's contributions,
+// counting contributions up to SalaryLimitPercent of AnnualSalary, and pays at
+// most AnnualMatchCap a year. Without a limit or cap every dollar is matched.
+type EmployerMatchRule struct {
+	ID                 int       `json:"id"`
+	CashHoldingID      int       `json:"cash_holding_id"`
+	PlanType           string    `json:"plan_type"`
+	MatchPercent       float64   `json:"match_percent"`
+	SalaryLimitPercent *float64  `json:"salary_limit_percent"`
+	AnnualSalary       *float64  `json:"annual_salary"`
+	AnnualMatchCap     *float64  `json:"annual_match_cap"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// EmployerMatchRuleInput creates or updates a cash holding's employer match
+// rule, e.g. 50% of contributions up to 6% of a $120,000 salary:
+// {"cash_holding_id": 3, "plan_type": "401k", "match_percent": 50, "salary_limit_percent": 6, "annual_salary": 120000}
+// CashHoldingID is ignored on update.
+type EmployerMatchRuleInput struct {
+	CashHoldingID      int      `json:"cash_holding_id" binding:"omitempty,gt=0"`
+	PlanType           string   `json:"plan_type" binding:"required,oneof=401k 403b 457b hsa"`
+	MatchPercent       float64  `json:"match_percent" binding:"required,gt=0,max=1000"`
+	SalaryLimitPercent *float64 `json:"salary_limit_percent" binding:"omitempty,gt=0,max=100"`
+	AnnualSalary       *float64 `json:"annual_salary" binding:"omitempty,gte=0"`
+	AnnualMatchCap     *float64 `json:"annual_match_cap" binding:"omitempty,gte=0"`
+}
+
+// EmployerMatchProjection is the match a retirement account's current
+// monthly contribution earns over a year. When the match is limited,
+// ContributionForFullMatch is the yearly contribution that earns all of it
+// and MissedAnnualMatch what the current contribution leaves unclaimed.
+type EmployerMatchProjection struct {
+	EmployerMatchRule
+	InstitutionName          string   `json:"institution_name"`
+	AccountName              string   `json:"account_name"`
+	AnnualContribution       float64  `json:"annual_contribution"`
+	ProjectedAnnualMatch     float64  `json:"projected_annual_match"`
+	MaxAnnualMatch           *float64 `json:"max_annual_match"`
+	ContributionForFullMatch *float64 `json:"contribution_for_full_match"`
+	MissedAnnualMatch        float64  `json:"missed_annual_match"`
+	Warning                  string   `json:"warning,omitempty"`
+}
+
+// EmployerMatchService stores employer match rules, projects the match each
+// account earns and warns when contributions fall short of the full match
+type EmployerMatchService struct {
+	db            *sql.DB
+	notifications *NotificationService
+}
+
+// NewEmployerMatchService creates an employer match service
+func NewEmployerMatchService(db *sql.DB, notifications *NotificationService) *EmployerMatchService {
+	return &EmployerMatchService{db: db, notifications: notifications}
+}
+
+// Run checks for under-contribution now and then every interval until ctx is done
+func (ems *EmployerMatchService) Run(ctx context.Context, interval time.Duration) {
+	check := func() {
+		if err := ems.Check(); err != nil {
+			fmt.Printf("WARNING: Employer match check failed: %v\n", err)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// CreateRule adds an employer match rule to a cash holding and returns its ID
+func (ems *EmployerMatchService) CreateRule(input EmployerMatchRuleInput) (int, error) {
+	if err := input.validate(); err != nil {
+		return 0, err
+	}
+	if input.CashHoldingID == 0 {
+		return 0, fmt.Errorf("%w: cash_holding_id is required", ErrInvalidEmployerMatchRule)
+	}
+
+	var exists bool
+	err := ems.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM cash_holdings WHERE id = $1)`, input.CashHoldingID).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch cash holding: %w", err)
+	}
+	if !exists {
+		return 0, ErrMatchCashHoldingNotFound
+	}
+
+	var id int
+	err = ems.db.QueryRow(`
+		INSERT INTO employer_match_rules (
+			cash_holding_id, plan_type, match_percent, salary_limit_percent, annual_salary, annual_match_cap
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (cash_holding_id) DO NOTHING
+		RETURNING id
+	`, input.CashHoldingID, input.PlanType, input.MatchPercent, input.SalaryLimitPercent, input.AnnualSalary, input.AnnualMatchCap).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrEmployerMatchRuleExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create employer match rule: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateRule replaces an employer match rule's terms. The cash holding it
+// belongs to does not change.
+func (ems *EmployerMatchService) UpdateRule(id int, input EmployerMatchRuleInput) error {
+	if err := input.validate(); err != nil {
+		return err
+	}
+
+	result, err := ems.db.Exec(`
+		UPDATE employer_match_rules
+		SET plan_type = $2, match_percent = $3, salary_limit_percent = $4, annual_salary = $5,
+		    annual_match_cap = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, input.PlanType, input.MatchPercent, input.SalaryLimitPercent, input.AnnualSalary, input.AnnualMatchCap)
+	if err != nil {
+		return fmt.Errorf("failed to update employer match rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrEmployerMatchRuleNotFound
+	}
+	return nil
+}
+
+// DeleteRule removes an employer match rule and any under-contribution alert
+// for its account
+func (ems *EmployerMatchService) DeleteRule(id int) error {
+	var cashHoldingID int
+	err := ems.db.QueryRow(`DELETE FROM employer_match_rules WHERE id = $1 RETURNING cash_holding_id`, id).Scan(&cashHoldingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEmployerMatchRuleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete employer match rule: %w", err)
+	}
+	if _, err := ems.db.Exec(`DELETE FROM employer_match_alerts WHERE cash_holding_id = $1`, cashHoldingID); err != nil {
+		return fmt.Errorf("failed to clear employer match alert: %w", err)
+	}
+	return nil
+}
+
+// validate rejects a salary limit that has no salary to apply to
+func (input EmployerMatchRuleInput) validate() error {
+	if input.SalaryLimitPercent != nil && input.AnnualSalary == nil {
+		return fmt.Errorf("%w: salary_limit_percent needs annual_salary", ErrInvalidEmployerMatchRule)
+	}
+	return nil
+}
+
+// Projections returns the match every account with a rule earns at its
+// current monthly contribution, by institution and account
+func (ems *EmployerMatchService) Projections() ([]EmployerMatchProjection, error) {
+	return employerMatchProjections(ems.db)
+}
+
+// Check creates an employer_match notification for each account whose
+// contributions have started to leave match unclaimed. An account that
+// reaches the full match is cleared, so it is notified again if it falls
+// short a second time.
+func (ems *EmployerMatchService) Check() error {
+	projections, err := ems.Projections()
+	if err != nil {
+		return err
+	}
+
+	short := make([]int64, 0, len(projections))
+	for _, p := range projections {
+		if p.MissedAnnualMatch > 0 {
+			short = append(short, int64(p.CashHoldingID))
+		}
+	}
+	if _, err := ems.db.Exec(`DELETE FROM employer_match_alerts WHERE NOT `+database.DialectOf(ems.db).InArray("cash_holding_id", "$1"), short); err != nil {
+		return fmt.Errorf("failed to clear employer match alerts: %w", err)
+	}
+
+	for _, p := range projections {
+		if p.MissedAnnualMatch <= 0 {
+			continue
+		}
+		result, err := ems.db.Exec(`
+			INSERT INTO employer_match_alerts (cash_holding_id, missed_annual_match, alerted_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (cash_holding_id) DO NOTHING
+		`, p.CashHoldingID, p.MissedAnnualMatch, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to record employer match alert for %s: %w", p.AccountName, err)
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 0 || ems.notifications == nil {
+			continue
+		}
+
+		err = ems.notifications.Create(
+			"employer_match",
+			NotificationSeverityWarning,
+			fmt.Sprintf("%s is missing $%.2f of employer match a year", p.AccountName, p.MissedAnnualMatch),
+			p.Warning,
+		)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to notify employer match for %s: %v\n", p.AccountName, err)
+		}
+	}
+	return nil
+}
+
+// employerMatchProjections projects every rule at its account's monthly
+// contribution, counted only while the account's contribution schedule is
+// active as in Project
+func employerMatchProjections(db *sql.DB) ([]EmployerMatchProjection, error) {
+	rows, err := db.Query(`
+		SELECT emr.id, emr.cash_holding_id, emr.plan_type, emr.match_percent, emr.salary_limit_percent,
+		       emr.annual_salary, emr.annual_match_cap, emr.created_at, emr.updated_at,
+		       ch.institution_name, ch.account_name,
+		       CASE WHEN rc.id IS NULL OR rc.active THEN COALESCE(ch.monthly_contribution, 0) ELSE 0 END
+		FROM employer_match_rules emr
+		JOIN cash_holdings ch ON ch.id = emr.cash_holding_id
+		LEFT JOIN recurring_contributions rc ON rc.cash_holding_id = ch.id
+		ORDER BY ch.institution_name, ch.account_name, emr.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employer match rules: %w", err)
+	}
+	defer rows.Close()
+
+	projections := []EmployerMatchProjection{}
+	for rows.Next() {
+		var rule EmployerMatchRule
+		var institution, account string
+		var monthly float64
+		err := rows.Scan(&rule.ID, &rule.CashHoldingID, &rule.PlanType, &rule.MatchPercent, &rule.SalaryLimitPercent,
+			&rule.AnnualSalary, &rule.AnnualMatchCap, &rule.CreatedAt, &rule.UpdatedAt,
+			&institution, &account, &monthly)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan employer match rule: %w", err)
+		}
+		p := rule.Project(monthly * 12)
+		p.InstitutionName, p.AccountName = institution, account
+		projections = append(projections, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch employer match rules: %w", err)
+	}
+	return projections, nil
+}
+
+// Project works out the match a year's contributions earn under the rule
+func (r EmployerMatchRule) Project(annualContribution float64) EmployerMatchProjection {
+	rate := r.MatchPercent / 100
+	p := EmployerMatchProjection{
+		EmployerMatchRule:  r,
+		AnnualContribution: roundCents(annualContribution),
+	}
+
+	maxMatch := math.Inf(1)
+	if r.SalaryLimitPercent != nil && r.AnnualSalary != nil {
+		maxMatch = *r.AnnualSalary * *r.SalaryLimitPercent / 100 * rate
+	}
+	if r.AnnualMatchCap != nil {
+		maxMatch = math.Min(maxMatch, *r.AnnualMatchCap)
+	}
+
+	match := math.Min(annualContribution*rate, maxMatch)
+	p.ProjectedAnnualMatch = roundCents(match)
+	if math.IsInf(maxMatch, 1) || rate <= 0 {
+		return p
+	}
+
+	full, needed := roundCents(maxMatch), roundCents(maxMatch/rate)
+	p.MaxAnnualMatch = &full
+	p.ContributionForFullMatch = &needed
+	p.MissedAnnualMatch = roundCents(maxMatch - match)
+	if p.MissedAnnualMatch > 0 {
+		p.Warning = fmt.Sprintf("Contributing $%.2f a year earns $%.2f of a possible $%.2f employer match. Contribute $%.2f a year ($%.2f a month) to receive the full match.",
+			p.AnnualContribution, p.ProjectedAnnualMatch, full, needed, needed/12)
+	}
+	return p
+}
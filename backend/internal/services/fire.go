@@ -0,0 +1,131 @@
+package services
+
+import (
+	"math"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Default FIRE assumptions
+const (
+	// DefaultWithdrawalRate is the safe withdrawal rate, in percent a year,
+	// the FI number is based on
+	DefaultWithdrawalRate = 4.0
+	// DefaultFIREReturnRate is the annual return, in percent, assumed for
+	// invested assets other than cash holdings
+	DefaultFIREReturnRate = 5.0
+)
+
+// FIREInput holds the assumptions of a FIRE calculation. Rates are percents a
+// year. AnnualIncome is nil when no income is known.
+type FIREInput struct {
+	WithdrawalRate float64
+	ReturnRate     float64
+	AnnualExpenses float64
+	AnnualIncome   *float64
+}
+
+// FIREPoint is projected invested assets at the end of a month
+type FIREPoint struct {
+	Month          string  `json:"month"` // YYYY-MM
+	InvestedAssets float64 `json:"invested_assets"`
+	Progress       float64 `json:"progress"`
+}
+
+// FIREMetrics measures progress toward financial independence: invested
+// assets large enough that withdrawing WithdrawalRate percent a year covers
+// annual expenses
+type FIREMetrics struct {
+	WithdrawalRate float64  `json:"withdrawal_rate"`
+	ReturnRate     float64  `json:"return_rate"`
+	AnnualExpenses float64  `json:"annual_expenses"`
+	AnnualIncome   *float64 `json:"annual_income"`
+	AnnualSavings  *float64 `json:"annual_savings"`
+	// SavingsRate is the percentage of income saved, or nil without income
+	SavingsRate            *float64 `json:"savings_rate"`
+	FINumber               float64  `json:"fi_number"`
+	InvestedAssets         float64  `json:"invested_assets"`
+	Progress               float64  `json:"progress"`
+	FinanciallyIndependent bool     `json:"financially_independent"`
+	MonthlyContributions   float64  `json:"monthly_contributions"`
+	// ProjectedFIDate is the month invested assets first reach the FI number,
+	// or nil when they already have or don't within the projection
+	ProjectedFIDate *string     `json:"projected_fi_date"`
+	MonthsToFI      *int        `json:"months_to_fi"`
+	YearsToFI       *float64    `json:"years_to_fi"`
+	Points          []FIREPoint `json:"points"`
+}
+
+// InvestedAssets is the part of net worth that can fund withdrawals: stocks,
+// vested equity, cash, crypto, private investments and fixed income. Home
+// and property equity, other assets, pensions and insurance cash value are
+// left out.
+func InvestedAssets(b models.NetWorthBreakdown) float64 {
+	return b.StockHoldingsValue.Add(b.VestedEquityValue).Add(b.CashHoldingsValue).
+		Add(b.CryptoHoldingsValue).Add(b.PrivateInvestmentsValue).Add(b.FixedIncomeValue).
+		InexactFloat64()
+}
+
+// ProjectFIRE computes FIRE metrics from invested assets and a balance
+// projection. Cash holdings follow the projection, with their interest,
+// recurring contributions and employer match; the rest of the invested assets
+// grow at the input's return rate.
+func ProjectFIRE(input FIREInput, invested float64, cash *ContributionProjection) FIREMetrics {
+	metrics := FIREMetrics{
+		WithdrawalRate:       input.WithdrawalRate,
+		ReturnRate:           input.ReturnRate,
+		AnnualExpenses:       roundCents(input.AnnualExpenses),
+		InvestedAssets:       roundCents(invested),
+		MonthlyContributions: cash.MonthlyContributions,
+		Points:               make([]FIREPoint, 0, len(cash.Points)),
+	}
+	if input.AnnualIncome != nil {
+		income := roundCents(*input.AnnualIncome)
+		savings := roundCents(*input.AnnualIncome - input.AnnualExpenses)
+		metrics.AnnualIncome = &income
+		metrics.AnnualSavings = &savings
+		if rate := models.SavingsRate(decimal.NewFromFloat(*input.AnnualIncome), decimal.NewFromFloat(input.AnnualExpenses)); rate != nil {
+			rounded := roundPercent(*rate)
+			metrics.SavingsRate = &rounded
+		}
+	}
+
+	metrics.FINumber = roundCents(input.AnnualExpenses / (input.WithdrawalRate / 100))
+	metrics.Progress = fireProgress(invested, metrics.FINumber)
+	metrics.FinanciallyIndependent = invested >= metrics.FINumber
+	if metrics.FinanciallyIndependent {
+		months, years := 0, 0.0
+		metrics.MonthsToFI, metrics.YearsToFI = &months, &years
+	}
+
+	// Cash holdings, brokerage cash included, are projected with their accounts
+	other := math.Max(invested-cash.StartingBalance, 0)
+	monthlyGrowth := monthlyGrowthRate(input.ReturnRate)
+	for i, point := range cash.Points {
+		other *= 1 + monthlyGrowth
+		assets := roundCents(point.Balance + other)
+		metrics.Points = append(metrics.Points, FIREPoint{
+			Month:          point.Month,
+			InvestedAssets: assets,
+			Progress:       fireProgress(assets, metrics.FINumber),
+		})
+		if metrics.MonthsToFI == nil && assets >= metrics.FINumber {
+			months := i + 1
+			years := math.Round(float64(months)/12*10) / 10
+			date := point.Month
+			metrics.MonthsToFI, metrics.YearsToFI, metrics.ProjectedFIDate = &months, &years, &date
+		}
+	}
+	return metrics
+}
+
+// fireProgress is assets as a percentage of the FI number, 100 when there
+// are no expenses to cover
+func fireProgress(assets, fiNumber float64) float64 {
+	if fiNumber <= 0 {
+		return 100
+	}
+	return roundPercent(assets / fiNumber * 100)
+}
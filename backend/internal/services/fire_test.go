@@ -0,0 +1,142 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// flatCashProjection projects cash of start growing by monthly each month
+func flatCashProjection(start, monthly float64, months int) *ContributionProjection {
+	projection := &ContributionProjection{Months: months, StartingBalance: start, MonthlyContributions: monthly}
+	balance := start
+	for i := 1; i <= months; i++ {
+		balance += monthly
+		projection.Points = append(projection.Points, ContributionProjectionPoint{
+			Month:   fmt.Sprintf("2027-%02d", i),
+			Balance: balance,
+		})
+	}
+	return projection
+}
+
+func TestProjectFIRE(t *testing.T) {
+	income := 100000.0
+	tests := []struct {
+		name          string
+		input         FIREInput
+		invested      float64
+		cash          *ContributionProjection
+		fiNumber      float64
+		progress      float64
+		independent   bool
+		monthsToFI    *int
+		yearsToFI     *float64
+		projectedDate *string
+	}{
+		{
+			name:     "FI number from the withdrawal rate",
+			input:    FIREInput{WithdrawalRate: 4, AnnualExpenses: 40000},
+			invested: 250000,
+			cash:     flatCashProjection(0, 0, 12),
+			fiNumber: 1000000, progress: 25,
+		},
+		{
+			name:     "contributions reach the FI number",
+			input:    FIREInput{WithdrawalRate: 4, AnnualExpenses: 40000},
+			invested: 950000,
+			cash:     flatCashProjection(0, 10000, 12),
+			fiNumber: 1000000, progress: 95,
+			monthsToFI: intPtr(5), yearsToFI: floatPtr(0.4), projectedDate: stringPtr("2027-05"),
+		},
+		{
+			name:     "already independent",
+			input:    FIREInput{WithdrawalRate: 5, AnnualExpenses: 50000},
+			invested: 1200000,
+			cash:     flatCashProjection(200000, 0, 12),
+			fiNumber: 1000000, progress: 120, independent: true,
+			monthsToFI: intPtr(0), yearsToFI: floatPtr(0),
+		},
+		{
+			name:     "not reached within the projection",
+			input:    FIREInput{WithdrawalRate: 4, AnnualExpenses: 80000, AnnualIncome: &income},
+			invested: 100000,
+			cash:     flatCashProjection(0, 1000, 24),
+			fiNumber: 2000000, progress: 5,
+		},
+		{
+			name:     "invested assets other than cash grow at the return rate",
+			input:    FIREInput{WithdrawalRate: 4, ReturnRate: 12, AnnualExpenses: 4480},
+			invested: 100000,
+			cash:     flatCashProjection(0, 0, 12),
+			fiNumber: 112000, progress: 89.2857,
+			monthsToFI: intPtr(12), yearsToFI: floatPtr(1), projectedDate: stringPtr("2027-12"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ProjectFIRE(tt.input, tt.invested, tt.cash)
+			if m.FINumber != tt.fiNumber || m.Progress != tt.progress || m.FinanciallyIndependent != tt.independent {
+				t.Errorf("FI number %v, progress %v, independent %t; want %v, %v, %t",
+					m.FINumber, m.Progress, m.FinanciallyIndependent, tt.fiNumber, tt.progress, tt.independent)
+			}
+			if !equalPtr(m.MonthsToFI, tt.monthsToFI) || !equalPtr(m.YearsToFI, tt.yearsToFI) || !equalPtr(m.ProjectedFIDate, tt.projectedDate) {
+				t.Errorf("months %v, years %v, date %v; want %v, %v, %v",
+					deref(m.MonthsToFI), deref(m.YearsToFI), deref(m.ProjectedFIDate),
+					deref(tt.monthsToFI), deref(tt.yearsToFI), deref(tt.projectedDate))
+			}
+			if len(m.Points) != len(tt.cash.Points) {
+				t.Errorf("got %d points, want %d", len(m.Points), len(tt.cash.Points))
+			}
+		})
+	}
+}
+
+func TestProjectFIRESavingsRate(t *testing.T) {
+	income := 100000.0
+	m := ProjectFIRE(FIREInput{WithdrawalRate: 4, AnnualExpenses: 40000, AnnualIncome: &income}, 0, flatCashProjection(0, 0, 1))
+	if m.AnnualSavings == nil || *m.AnnualSavings != 60000 || m.SavingsRate == nil || *m.SavingsRate != 60 {
+		t.Errorf("savings %v at rate %v, want 60000 at 60", deref(m.AnnualSavings), deref(m.SavingsRate))
+	}
+	if math.IsInf(m.Progress, 0) || m.Progress != 0 {
+		t.Errorf("progress with no assets = %v, want 0", m.Progress)
+	}
+}
+
+func TestInvestedAssets(t *testing.T) {
+	b := models.NetWorthBreakdown{
+		StockHoldingsValue:      decimal.NewFromInt(100),
+		VestedEquityValue:       decimal.NewFromInt(20),
+		CashHoldingsValue:       decimal.NewFromInt(30),
+		CryptoHoldingsValue:     decimal.NewFromInt(5),
+		PrivateInvestmentsValue: decimal.NewFromInt(40),
+		FixedIncomeValue:        decimal.NewFromInt(50),
+		RealEstateEquity:        decimal.NewFromInt(1000),
+		OtherAssetsValue:        decimal.NewFromInt(7),
+	}
+	if got := InvestedAssets(b); got != 245 {
+		t.Errorf("InvestedAssets = %v, want 245 without home equity or other assets", got)
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func stringPtr(v string) *string { return &v }
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func deref[T any](p *T) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+)
+
+const (
+	// fundCompositionMaxAge is how long a provider breakdown is used before
+	// it is looked up again
+	fundCompositionMaxAge = 90 * 24 * time.Hour
+	// fundTopHoldings bounds the largest holdings stored per fund
+	fundTopHoldings = 25
+	// maxFundDepth bounds how deep funds of funds are looked through
+	maxFundDepth = 3
+)
+
+// alphaVantageETFProfile is the part of the Alpha Vantage ETF_PROFILE response used
+type alphaVantageETFProfile struct {
+	Sectors []struct {
+		Sector string `json:"sector"`
+		Weight string `json:"weight"`
+	} `json:"sectors"`
+	Holdings []struct {
+		Symbol      string `json:"symbol"`
+		Description string `json:"description"`
+		Weight      string `json:"weight"`
+	} `json:"holdings"`
+}
+
+// FundExposure is how a fund's value divides across sectors and countries,
+// as fractions that add up to one
+type FundExposure struct {
+	Sectors   map[string]float64
+	Countries map[string]float64
+}
+
+// isFund reports whether metadata describes an ETF or mutual fund
+func isFund(m models.SecurityMetadata) bool {
+	if m.AssetType == nil {
+		return false
+	}
+	return strings.EqualFold(*m.AssetType, assetTypeETF) || strings.EqualFold(*m.AssetType, assetTypeMutualFund)
+}
+
+// Compositions returns the breakdown of each symbol that has one. Provider
+// breakdowns stored earlier win over the built-in dataset.
+func (ms *SecurityMetadataService) Compositions(symbols []string) (map[string]models.FundComposition, error) {
+	rows, err := ms.db.Query(`
+		SELECT symbol, sectors, top_holdings, source, updated_at
+		FROM fund_compositions
+		WHERE `+database.DialectOf(ms.db).InArray("symbol", "$1")+`
+	`, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fund compositions: %w", err)
+	}
+	defer rows.Close()
+
+	compositions := make(map[string]models.FundComposition, len(symbols))
+	for rows.Next() {
+		var c models.FundComposition
+		var sectors, holdings []byte
+		var updatedAt time.Time
+		if err := rows.Scan(&c.Symbol, &sectors, &holdings, &c.Source, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan fund composition: %w", err)
+		}
+		if err := json.Unmarshal(sectors, &c.Sectors); err != nil {
+			return nil, fmt.Errorf("failed to parse sectors of %s: %w", c.Symbol, err)
+		}
+		if err := json.Unmarshal(holdings, &c.TopHoldings); err != nil {
+			return nil, fmt.Errorf("failed to parse top holdings of %s: %w", c.Symbol, err)
+		}
+		c.UpdatedAt = &updatedAt
+		compositions[c.Symbol] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch fund compositions: %w", err)
+	}
+
+	for _, symbol := range symbols {
+		if _, ok := compositions[symbol]; ok {
+			continue
+		}
+		if f, ok := staticFunds[symbol]; ok {
+			compositions[symbol] = models.FundComposition{
+				Symbol:    symbol,
+				Sectors:   f.sectors,
+				Countries: f.countries,
+				Funds:     f.funds,
+				Source:    SecurityMetadataStatic,
+			}
+		}
+	}
+	return compositions, nil
+}
+
+// LookThrough resolves the sectors and countries each fund among symbols
+// invests in, following funds of funds to the funds they hold. Symbols
+// without a breakdown are left out.
+func (ms *SecurityMetadataService) LookThrough(symbols []string) (map[string]FundExposure, error) {
+	compositions := map[string]models.FundComposition{}
+	pending := symbols
+	for depth := 0; depth <= maxFundDepth && len(pending) > 0; depth++ {
+		found, err := ms.Compositions(pending)
+		if err != nil {
+			return nil, err
+		}
+		pending = nil
+		for symbol, c := range found {
+			compositions[symbol] = c
+			for underlying := range c.Funds {
+				if _, ok := compositions[underlying]; !ok {
+					pending = append(pending, underlying)
+				}
+			}
+		}
+	}
+
+	all := make([]string, 0, len(compositions))
+	for symbol, c := range compositions {
+		all = append(all, symbol)
+		for underlying := range c.Funds {
+			all = append(all, underlying)
+		}
+	}
+	metadata, err := ms.Lookup(all)
+	if err != nil {
+		return nil, err
+	}
+
+	exposures := make(map[string]FundExposure)
+	for _, symbol := range symbols {
+		if _, ok := compositions[symbol]; !ok {
+			continue
+		}
+		exposure := FundExposure{Sectors: map[string]float64{}, Countries: map[string]float64{}}
+		exposure.add(symbol, 1, compositions, metadata, 0)
+		exposure.prune()
+		exposures[symbol] = exposure
+	}
+	return exposures, nil
+}
+
+// add spreads weight of symbol across sectors and countries. What a breakdown
+// leaves out, and funds without one, count under the symbol's own metadata.
+func (e FundExposure) add(symbol string, weight float64, compositions map[string]models.FundComposition,
+	metadata map[string]models.SecurityMetadata, depth int) {
+	m := metadata[symbol]
+	c, ok := compositions[symbol]
+	if !ok || depth > maxFundDepth {
+		e.Sectors[valueOr(m.Sector, UnclassifiedExposure)] += weight
+		e.Countries[valueOr(m.Country, UnclassifiedExposure)] += weight
+		return
+	}
+
+	if len(c.Funds) > 0 {
+		allocated := spread(c.Funds, func(underlying string, w float64) {
+			e.add(underlying, weight*w, compositions, metadata, depth+1)
+		})
+		e.Sectors[valueOr(m.Sector, UnclassifiedExposure)] += weight * (1 - allocated)
+		e.Countries[valueOr(m.Country, UnclassifiedExposure)] += weight * (1 - allocated)
+		return
+	}
+
+	allocated := spread(c.Sectors, func(sector string, w float64) { e.Sectors[sector] += weight * w })
+	e.Sectors[valueOr(m.Sector, UnclassifiedExposure)] += weight * (1 - allocated)
+	allocated = spread(c.Countries, func(country string, w float64) { e.Countries[country] += weight * w })
+	e.Countries[valueOr(m.Country, UnclassifiedExposure)] += weight * (1 - allocated)
+}
+
+// prune drops the rounding leftovers of fully allocated breakdowns
+func (e FundExposure) prune() {
+	for _, weights := range []map[string]float64{e.Sectors, e.Countries} {
+		for name, w := range weights {
+			if w < 1e-9 {
+				delete(weights, name)
+			}
+		}
+	}
+}
+
+// spread calls apply for each weight, scaled down when they add up to more
+// than one, and returns the fraction allocated
+func spread(weights map[string]float64, apply func(name string, weight float64)) float64 {
+	var total float64
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+	scale := 1.0
+	if total > 1 {
+		scale = 1 / total
+	}
+	for name, w := range weights {
+		if w > 0 {
+			apply(name, w*scale)
+		}
+	}
+	return total * scale
+}
+
+// refreshCompositions looks up held funds without a breakdown, or whose
+// provider breakdown is older than fundCompositionMaxAge, making at most
+// budget provider calls, and returns how many were stored
+func (ms *SecurityMetadataService) refreshCompositions(ctx context.Context, symbols []string, budget int) (int, error) {
+	if budget <= 0 {
+		return 0, nil
+	}
+	metadata, err := ms.Lookup(symbols)
+	if err != nil {
+		return 0, err
+	}
+	compositions, err := ms.Compositions(symbols)
+	if err != nil {
+		return 0, err
+	}
+
+	var stored, lookups int
+	for _, symbol := range symbols {
+		if !isFund(metadata[symbol]) {
+			continue
+		}
+		if c, ok := compositions[symbol]; ok {
+			if c.UpdatedAt == nil || time.Since(*c.UpdatedAt) < fundCompositionMaxAge {
+				continue
+			}
+		}
+		if lookups == budget {
+			break
+		}
+		lookups++
+
+		profile, err := ms.fetchETFProfile(ctx, symbol)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to look up fund composition for %s: %v\n", symbol, err)
+			continue
+		}
+		if err := ms.storeComposition(symbol, profile); err != nil {
+			return stored, err
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+// fetchETFProfile calls the Alpha Vantage ETF_PROFILE endpoint for a symbol
+func (ms *SecurityMetadataService) fetchETFProfile(ctx context.Context, symbol string) (*alphaVantageETFProfile, error) {
+	query := url.Values{"function": {"ETF_PROFILE"}, "symbol": {symbol}, "apikey": {ms.config.AlphaVantageAPIKey}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ms.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ms.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Alpha Vantage returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile alphaVantageETFProfile
+	if err := json.Unmarshal(body, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse ETF profile: %w", err)
+	}
+	// Unknown symbols, rate limits and errors all come back without sectors
+	if len(profile.Sectors) == 0 {
+		return nil, fmt.Errorf("no ETF profile available")
+	}
+	return &profile, nil
+}
+
+// storeComposition saves a provider breakdown of a fund
+func (ms *SecurityMetadataService) storeComposition(symbol string, profile *alphaVantageETFProfile) error {
+	sectors := make(map[string]float64, len(profile.Sectors))
+	for _, s := range profile.Sectors {
+		weight, err := strconv.ParseFloat(s.Weight, 64)
+		if err != nil || weight <= 0 || s.Sector == "" {
+			continue
+		}
+		sectors[titleCase(s.Sector)] += weight
+	}
+	holdings := make([]models.FundHolding, 0, fundTopHoldings)
+	for _, h := range profile.Holdings {
+		if len(holdings) == fundTopHoldings {
+			break
+		}
+		weight, err := strconv.ParseFloat(h.Weight, 64)
+		if err != nil {
+			continue
+		}
+		holdings = append(holdings, models.FundHolding{Symbol: h.Symbol, Name: h.Description, Weight: weight})
+	}
+
+	sectorsJSON, err := json.Marshal(sectors)
+	if err != nil {
+		return err
+	}
+	holdingsJSON, err := json.Marshal(holdings)
+	if err != nil {
+		return err
+	}
+	_, err = ms.db.Exec(`
+		INSERT INTO fund_compositions (symbol, sectors, top_holdings, source, updated_at)
+		VALUES ($1, $2, $3, 'alphavantage', CURRENT_TIMESTAMP)
+		ON CONFLICT (symbol) DO UPDATE SET
+			sectors = EXCLUDED.sectors, top_holdings = EXCLUDED.top_holdings,
+			source = EXCLUDED.source, updated_at = EXCLUDED.updated_at
+	`, symbol, sectorsJSON, holdingsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to save fund composition for %s: %w", symbol, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+// staticFund is a built-in breakdown of a fund. Weights are fractions of the
+// fund and approximate, as of mid-2024; whatever they leave out is counted
+// under the fund's own sector and country.
+type staticFund struct {
+	sectors, countries map[string]float64
+	// funds are the underlying funds of a fund of funds
+	funds map[string]float64
+}
+
+var (
+	sp500Sectors = map[string]float64{
+		sectorTechnology: 0.31, sectorFinancials: 0.13, sectorHealthCare: 0.11, sectorConsumerDiscretionary: 0.11,
+		sectorCommunication: 0.09, sectorIndustrials: 0.09, sectorConsumerStaples: 0.06, sectorEnergy: 0.04,
+		sectorUtilities: 0.02, sectorRealEstate: 0.02, sectorMaterials: 0.02,
+	}
+	usTotalMarketSectors = map[string]float64{
+		sectorTechnology: 0.30, sectorFinancials: 0.13, sectorHealthCare: 0.11, sectorConsumerDiscretionary: 0.11,
+		sectorIndustrials: 0.10, sectorCommunication: 0.08, sectorConsumerStaples: 0.05, sectorEnergy: 0.04,
+		sectorRealEstate: 0.03, sectorUtilities: 0.03, sectorMaterials: 0.02,
+	}
+	nasdaq100Sectors = map[string]float64{
+		sectorTechnology: 0.50, sectorCommunication: 0.16, sectorConsumerDiscretionary: 0.14, sectorHealthCare: 0.06,
+		sectorConsumerStaples: 0.05, sectorIndustrials: 0.05, sectorUtilities: 0.01, sectorMaterials: 0.01,
+		sectorEnergy: 0.01, sectorFinancials: 0.01,
+	}
+	internationalSectors = map[string]float64{
+		sectorFinancials: 0.22, sectorIndustrials: 0.15, sectorTechnology: 0.13, sectorConsumerDiscretionary: 0.11,
+		sectorHealthCare: 0.09, sectorConsumerStaples: 0.07, sectorMaterials: 0.07, sectorCommunication: 0.06,
+		sectorEnergy: 0.05, sectorUtilities: 0.03, sectorRealEstate: 0.02,
+	}
+	internationalCountries = map[string]float64{
+		"Japan": 0.15, "United Kingdom": 0.09, "China": 0.08, "Canada": 0.08, "Taiwan": 0.06, "India": 0.06,
+		"France": 0.06, "Switzerland": 0.06, "Germany": 0.05, "Australia": 0.05, "South Korea": 0.03, "Netherlands": 0.03,
+	}
+	usOnly = map[string]float64{countryUS: 1}
+)
+
+// targetDate splits a target-date fund between US and international stocks
+// and bonds
+func targetDate(usStocks, internationalStocks, usBonds, internationalBonds float64) staticFund {
+	return staticFund{funds: map[string]float64{
+		"VTI": usStocks, "VXUS": internationalStocks, "BND": usBonds, "BNDX": internationalBonds,
+	}}
+}
+
+// staticFunds breaks down common index and target-date funds without a
+// provider call
+var staticFunds = map[string]staticFund{
+	"SPY":   {sectors: sp500Sectors, countries: usOnly},
+	"VOO":   {sectors: sp500Sectors, countries: usOnly},
+	"IVV":   {sectors: sp500Sectors, countries: usOnly},
+	"VFIAX": {sectors: sp500Sectors, countries: usOnly},
+	"FXAIX": {sectors: sp500Sectors, countries: usOnly},
+	"VTI":   {sectors: usTotalMarketSectors, countries: usOnly},
+	"VTSAX": {sectors: usTotalMarketSectors, countries: usOnly},
+	"QQQ":   {sectors: nasdaq100Sectors, countries: usOnly},
+	"VXUS":  {sectors: internationalSectors, countries: internationalCountries},
+	"VTIAX": {sectors: internationalSectors, countries: internationalCountries},
+	"VT":    {funds: map[string]float64{"VTI": 0.62, "VXUS": 0.38}},
+
+	// Vanguard Target Retirement glide path
+	"VTTVX": targetDate(0.32, 0.21, 0.35, 0.12),
+	"VTHRX": targetDate(0.38, 0.25, 0.26, 0.11),
+	"VTTHX": targetDate(0.43, 0.28, 0.20, 0.09),
+	"VFORX": targetDate(0.47, 0.32, 0.15, 0.06),
+	"VTIVX": targetDate(0.52, 0.34, 0.10, 0.04),
+	"VFIFX": targetDate(0.54, 0.36, 0.07, 0.03),
+	"VFFVX": targetDate(0.54, 0.36, 0.07, 0.03),
+	"VTTSX": targetDate(0.54, 0.36, 0.07, 0.03),
+	"VLXVX": targetDate(0.54, 0.36, 0.07, 0.03),
+}
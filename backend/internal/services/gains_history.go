@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// Gains history intervals
+const (
+	GainsIntervalDay   = "day"
+	GainsIntervalWeek  = "week"
+	GainsIntervalMonth = "month"
+)
+
+// ErrInvalidGainsInterval is returned for an interval other than day, week or month
+var ErrInvalidGainsInterval = errors.New("interval must be day, week or month")
+
+// GainsPoint is the cost basis and value of a position (or the portfolio) on
+// one date. Contributions and MarketChange split the change in market value
+// since the previous point into money moved in or out and price movement.
+type GainsPoint struct {
+	Date                  string   `json:"date"` // YYYY-MM-DD
+	CostBasis             float64  `json:"cost_basis"`
+	MarketValue           float64  `json:"market_value"`
+	UnrealizedGain        float64  `json:"unrealized_gain"`
+	UnrealizedGainPercent *float64 `json:"unrealized_gain_percent"`
+	Contributions         float64  `json:"contributions"`
+	MarketChange          float64  `json:"market_change"`
+}
+
+// SymbolGainsPoint is a GainsPoint for a single symbol
+type SymbolGainsPoint struct {
+	GainsPoint
+	Shares float64 `json:"shares"`
+	Price  float64 `json:"price"`
+}
+
+// SymbolGainsHistory is the gains history of one symbol
+type SymbolGainsHistory struct {
+	Symbol string             `json:"symbol"`
+	Points []SymbolGainsPoint `json:"points"`
+}
+
+// GainsHistory is unrealized gains over time for the portfolio and per symbol
+type GainsHistory struct {
+	From               string               `json:"from"`
+	To                 string               `json:"to"`
+	Interval           string               `json:"interval"`
+	TotalContributions float64              `json:"total_contributions"`
+	TotalMarketChange  float64              `json:"total_market_change"`
+	Total              []GainsPoint         `json:"total"`
+	Symbols            []SymbolGainsHistory `json:"symbols"`
+}
+
+// GainsHistoryService records daily position snapshots and derives unrealized
+// gains history from them and the stock price history
+type GainsHistoryService struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewGainsHistoryService creates a gains history service
+func NewGainsHistoryService(db *sql.DB) *GainsHistoryService {
+	return &GainsHistoryService{db: db, dialect: database.DialectOf(db)}
+}
+
+// Run records a snapshot now and then every interval until ctx is done
+func (gs *GainsHistoryService) Run(ctx context.Context, interval time.Duration) {
+	record := func() {
+		if err := gs.RecordSnapshot(time.Now()); err != nil {
+			fmt.Printf("WARNING: Holding snapshot failed: %v\n", err)
+		}
+	}
+
+	record()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
+
+// RecordSnapshot replaces the snapshot for the day of asOf with the current
+// positions, combining direct holdings and vested equity like the consolidated
+// stock view
+func (gs *GainsHistoryService) RecordSnapshot(asOf time.Time) error {
+	tx, err := gs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	day := dateOnly(asOf)
+	if _, err := tx.Exec("DELETE FROM holding_snapshots WHERE snapshot_date = $1", day); err != nil {
+		return fmt.Errorf("failed to clear holding snapshot: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO holding_snapshots (snapshot_date, symbol, shares_owned, cost_basis_total, market_value)
+		SELECT $1, symbol, SUM(shares_owned), SUM(shares_owned * COALESCE(cost_basis, 0)),
+		       SUM(shares_owned * COALESCE(current_price, 0))
+		FROM (
+			SELECT symbol, shares_owned, cost_basis, current_price
+			FROM stock_holdings
+			WHERE shares_owned > 0
+
+			UNION ALL
+
+			SELECT company_symbol, vested_shares,
+			       CASE WHEN grant_type IN ('iso', 'nso') THEN COALESCE(strike_price, 0) ELSE COALESCE(current_price, 0) END,
+			       current_price
+			FROM equity_grants
+			WHERE vested_shares > 0
+		) positions
+		GROUP BY symbol
+	`, day)
+	if err != nil {
+		return fmt.Errorf("failed to record holding snapshot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit holding snapshot: %w", err)
+	}
+	return nil
+}
+
+// History returns gains between from and to (inclusive dates) sampled at the
+// last snapshot of each interval. Each position is valued at the latest stock
+// price recorded by the end of the snapshot day, falling back to the price at
+// snapshot time. A non-empty symbol limits the per-symbol series; a non-nil
+// include limits both the totals and the series to the symbols it accepts.
+func (gs *GainsHistoryService) History(from, to time.Time, interval, symbol string, include func(symbol string) bool) (*GainsHistory, error) {
+	bucket, ok := gainsBuckets[interval]
+	if !ok {
+		return nil, ErrInvalidGainsInterval
+	}
+
+	rows, err := gs.db.Query(`
+		SELECT hs.snapshot_date, hs.symbol, hs.shares_owned, hs.cost_basis_total, hs.market_value, (
+			SELECT sp.price FROM stock_price_history sp
+			WHERE sp.symbol = hs.symbol AND sp.timestamp < `+gs.dialect.AddDays("hs.snapshot_date", "1")+`
+			ORDER BY sp.timestamp DESC
+			LIMIT 1
+		)
+		FROM holding_snapshots hs
+		WHERE hs.snapshot_date >= $1 AND hs.snapshot_date <= $2
+		ORDER BY hs.snapshot_date, hs.symbol
+	`, dateOnly(from), dateOnly(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch holding snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	type position struct {
+		shares, costBasis, price float64
+	}
+	snapshots := make(map[string]map[string]position)
+	var dates []string
+	symbols := make(map[string]bool)
+	for rows.Next() {
+		var date time.Time
+		var sym string
+		var p position
+		var snapshotValue float64
+		var price sql.NullFloat64
+		if err := rows.Scan(&date, &sym, &p.shares, &p.costBasis, &snapshotValue, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan holding snapshot: %w", err)
+		}
+		if include != nil && !include(sym) {
+			continue
+		}
+		switch {
+		case price.Valid && price.Float64 > 0:
+			p.price = price.Float64
+		case p.shares > 0:
+			p.price = snapshotValue / p.shares
+		}
+
+		label := date.Format("2006-01-02")
+		if _, ok := snapshots[label]; !ok {
+			snapshots[label] = make(map[string]position)
+			dates = append(dates, label)
+		}
+		snapshots[label][sym] = p
+		symbols[sym] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch holding snapshots: %w", err)
+	}
+
+	// Keep the last snapshot of each interval
+	var sampled []string
+	for i, date := range dates {
+		if i+1 < len(dates) && bucket(dates[i+1]) == bucket(date) {
+			continue
+		}
+		sampled = append(sampled, date)
+	}
+
+	allSymbols := make([]string, 0, len(symbols))
+	for sym := range symbols {
+		allSymbols = append(allSymbols, sym)
+	}
+	sort.Strings(allSymbols)
+
+	history := &GainsHistory{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Interval: interval,
+		Total:    make([]GainsPoint, 0, len(sampled)),
+		Symbols:  []SymbolGainsHistory{},
+	}
+	for _, sym := range allSymbols {
+		if symbol == "" || sym == symbol {
+			history.Symbols = append(history.Symbols, SymbolGainsHistory{Symbol: sym, Points: []SymbolGainsPoint{}})
+		}
+	}
+	perSymbol := make(map[string]*SymbolGainsHistory, len(history.Symbols))
+	for i := range history.Symbols {
+		perSymbol[history.Symbols[i].Symbol] = &history.Symbols[i]
+	}
+
+	previous := make(map[string]position)
+	for i, date := range sampled {
+		current := snapshots[date]
+		total := GainsPoint{Date: date}
+		for _, sym := range allSymbols {
+			now, prev := current[sym], previous[sym]
+			if now.shares == 0 && prev.shares == 0 {
+				continue
+			}
+			// A sold position has no price of its own; carry the last one
+			if now.shares == 0 {
+				now.price = prev.price
+			}
+
+			point := SymbolGainsPoint{
+				GainsPoint: newGainsPoint(date, now.costBasis, now.shares*now.price),
+				Shares:     now.shares,
+				Price:      now.price,
+			}
+			if i > 0 {
+				point.MarketChange = prev.shares * (now.price - prev.price)
+				point.Contributions = point.MarketValue - prev.shares*prev.price - point.MarketChange
+			}
+
+			total.CostBasis += point.CostBasis
+			total.MarketValue += point.MarketValue
+			total.Contributions += point.Contributions
+			total.MarketChange += point.MarketChange
+
+			if series, ok := perSymbol[sym]; ok {
+				series.Points = append(series.Points, roundSymbolGainsPoint(point))
+			}
+		}
+
+		history.TotalContributions += total.Contributions
+		history.TotalMarketChange += total.MarketChange
+		point := newGainsPoint(date, total.CostBasis, total.MarketValue)
+		point.Contributions = total.Contributions
+		point.MarketChange = total.MarketChange
+		history.Total = append(history.Total, roundGainsPoint(point))
+		previous = current
+	}
+
+	history.TotalContributions = roundCents(history.TotalContributions)
+	history.TotalMarketChange = roundCents(history.TotalMarketChange)
+	return history, nil
+}
+
+// gainsBuckets map a YYYY-MM-DD date to the interval it falls in
+var gainsBuckets = map[string]func(date string) string{
+	GainsIntervalDay: func(date string) string { return date },
+	GainsIntervalWeek: func(date string) string {
+		t, _ := time.Parse("2006-01-02", date)
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	},
+	GainsIntervalMonth: func(date string) string { return date[:7] },
+}
+
+func newGainsPoint(date string, costBasis, marketValue float64) GainsPoint {
+	point := GainsPoint{
+		Date:           date,
+		CostBasis:      costBasis,
+		MarketValue:    marketValue,
+		UnrealizedGain: marketValue - costBasis,
+	}
+	if costBasis > 0 {
+		percent := point.UnrealizedGain / costBasis * 100
+		point.UnrealizedGainPercent = &percent
+	}
+	return point
+}
+
+func roundGainsPoint(point GainsPoint) GainsPoint {
+	point.CostBasis = roundCents(point.CostBasis)
+	point.MarketValue = roundCents(point.MarketValue)
+	point.UnrealizedGain = roundCents(point.UnrealizedGain)
+	point.Contributions = roundCents(point.Contributions)
+	point.MarketChange = roundCents(point.MarketChange)
+	if point.UnrealizedGainPercent != nil {
+		percent := roundCents(*point.UnrealizedGainPercent)
+		point.UnrealizedGainPercent = &percent
+	}
+	return point
+}
+
+func roundSymbolGainsPoint(point SymbolGainsPoint) SymbolGainsPoint {
+	point.GainsPoint = roundGainsPoint(point.GainsPoint)
+	return point
+}
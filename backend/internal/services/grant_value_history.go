@@ -0,0 +1,197 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrEquityGrantNotFound is returned when an equity grant does not exist
+var ErrEquityGrantNotFound = errors.New("equity grant not found")
+
+// GrantValuePoint is an equity grant's value at the end of a day. For options
+// the value of a share is its spread over the strike price, never negative.
+type GrantValuePoint struct {
+	Date           string   `json:"date"`
+	Price          float64  `json:"price"`
+	SpreadPerShare *float64 `json:"spread_per_share,omitempty"`
+	VestedShares   float64  `json:"vested_shares"`
+	UnvestedShares float64  `json:"unvested_shares"`
+	VestedValue    float64  `json:"vested_value"`
+	UnvestedValue  float64  `json:"unvested_value"`
+	TotalValue     float64  `json:"total_value"`
+}
+
+// GrantValueHistory is how an equity grant's value evolved as it vested and
+// its stock price moved
+type GrantValueHistory struct {
+	GrantID       int               `json:"grant_id"`
+	Symbol        string            `json:"symbol"`
+	GrantType     string            `json:"grant_type"`
+	TotalShares   float64           `json:"total_shares"`
+	StrikePrice   *float64          `json:"strike_price"`
+	GrantDate     string            `json:"grant_date"`
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	Interval      string            `json:"interval"`
+	Points        []GrantValuePoint `json:"points"`
+	VestingEvents []GrantVestEvent  `json:"vesting_events"`
+	Warnings      []string          `json:"warnings"`
+}
+
+// GrantVestEvent is one vest of a grant's vesting schedule
+type GrantVestEvent struct {
+	Date   string  `json:"date"`
+	Shares float64 `json:"shares"`
+}
+
+// NewGrantValueHistory values grant grantID at the end of each interval
+// between from and to (inclusive dates), and on each vest date, using the
+// recorded stock price history. A zero from starts at the grant date. Shares
+// count as vested from their vest date in the vesting schedule; a grant
+// without a schedule shows its current vested shares throughout. Days before
+// the first recorded price are left out.
+func NewGrantValueHistory(db *sql.DB, grantID int, from, to time.Time, interval string) (*GrantValueHistory, error) {
+	bucket, ok := gainsBuckets[interval]
+	if !ok {
+		return nil, ErrInvalidGainsInterval
+	}
+
+	history := &GrantValueHistory{
+		GrantID:       grantID,
+		Interval:      interval,
+		Points:        []GrantValuePoint{},
+		VestingEvents: []GrantVestEvent{},
+		Warnings:      []string{},
+	}
+	var grantDate time.Time
+	var vestedShares float64
+	err := db.QueryRow(`
+		SELECT UPPER(company_symbol), grant_type, total_shares, COALESCE(vested_shares, 0), strike_price, grant_date
+		FROM equity_grants
+		WHERE id = $1
+	`, grantID).Scan(&history.Symbol, &history.GrantType, &history.TotalShares, &vestedShares, &history.StrikePrice, &grantDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEquityGrantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch equity grant: %w", err)
+	}
+	history.GrantDate = grantDate.Format("2006-01-02")
+
+	if from.Before(grantDate) {
+		from = grantDate
+	}
+	from, to = dateOnly(from), dateOnly(to)
+	history.From, history.To = from.Format("2006-01-02"), to.Format("2006-01-02")
+	if from.After(to) {
+		return history, nil
+	}
+
+	vests, err := grantVests(db, grantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(vests) == 0 {
+		history.Warnings = append(history.Warnings,
+			"The grant has no vesting schedule, so its current vested shares are shown throughout")
+	}
+
+	// The last day of each interval, plus vest days so each vest shows as a step
+	samples := map[string]bool{}
+	var last string
+	for day := to; !day.Before(from); day = day.AddDate(0, 0, -1) {
+		date := day.Format("2006-01-02")
+		if key := bucket(date); key != last {
+			samples[date] = true
+			last = key
+		}
+	}
+	for _, vest := range vests {
+		if vest.Date >= history.From && vest.Date <= history.To {
+			samples[vest.Date] = true
+		}
+		history.VestingEvents = append(history.VestingEvents, vest)
+	}
+	dates := make([]string, 0, len(samples))
+	for date := range samples {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	prices, err := pricesOn(db, history.Symbol, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	isOption := history.GrantType == "iso" || history.GrantType == "nso"
+	var unpriced int
+	for i, date := range dates {
+		if prices[i] <= 0 {
+			unpriced++
+			continue
+		}
+
+		point := GrantValuePoint{Date: date, Price: prices[i], VestedShares: vestedShares}
+		if len(vests) > 0 {
+			point.VestedShares = 0
+			for _, vest := range vests {
+				if vest.Date <= date {
+					point.VestedShares += vest.Shares
+				}
+			}
+			point.VestedShares = math.Min(point.VestedShares, history.TotalShares)
+		}
+		point.UnvestedShares = history.TotalShares - point.VestedShares
+
+		shareValue := point.Price
+		if isOption {
+			var strike float64
+			if history.StrikePrice != nil {
+				strike = *history.StrikePrice
+			}
+			shareValue = math.Max(point.Price-strike, 0)
+			spread := roundCents(shareValue)
+			point.SpreadPerShare = &spread
+		}
+		point.VestedValue = roundCents(point.VestedShares * shareValue)
+		point.UnvestedValue = roundCents(point.UnvestedShares * shareValue)
+		point.TotalValue = roundCents(point.VestedValue + point.UnvestedValue)
+		history.Points = append(history.Points, point)
+	}
+	if unpriced > 0 {
+		history.Warnings = append(history.Warnings,
+			fmt.Sprintf("%d of %d dates are before the first recorded %s price and are left out", unpriced, len(dates), history.Symbol))
+	}
+	return history, nil
+}
+
+// grantVests returns a grant's vesting schedule by date
+func grantVests(db *sql.DB, grantID int) ([]GrantVestEvent, error) {
+	rows, err := db.Query(`
+		SELECT vest_date, SUM(shares_vesting)
+		FROM vesting_schedule
+		WHERE grant_id = $1
+		GROUP BY vest_date
+		ORDER BY vest_date
+	`, grantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vesting schedule: %w", err)
+	}
+	defer rows.Close()
+
+	var vests []GrantVestEvent
+	for rows.Next() {
+		var date time.Time
+		var vest GrantVestEvent
+		if err := rows.Scan(&date, &vest.Shares); err != nil {
+			return nil, fmt.Errorf("failed to scan vesting schedule: %w", err)
+		}
+		vest.Date = date.Format("2006-01-02")
+		vests = append(vests, vest)
+	}
+	return vests, rows.Err()
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxIBondRate bounds the fixed and semiannual inflation rates accepted
+const maxIBondRate = 20
+
+var (
+	// ErrIBondNotFound is returned when an I bond does not exist
+	ErrIBondNotFound = errors.New("I bond not found")
+	// ErrIBondRateNotFound is returned when the schedule has no rate for a date
+	ErrIBondRateNotFound = errors.New("I bond rate not found")
+	// ErrInvalidIBondRate is returned for a rate not dated May 1 or November 1, or out of range
+	ErrInvalidIBondRate = errors.New("invalid I bond rate")
+)
+
+// IBondPortfolio is every I bond, oldest first, with their totals.
+// TotalPenalty is the interest cashing them all in today would forfeit.
+type IBondPortfolio struct {
+	Bonds                []models.IBond  `json:"bonds"`
+	TotalPurchaseAmount  decimal.Decimal `json:"total_purchase_amount"`
+	TotalCurrentValue    decimal.Decimal `json:"total_current_value"`
+	TotalRedemptionValue decimal.Decimal `json:"total_redemption_value"`
+	TotalPenalty         decimal.Decimal `json:"total_penalty"`
+	TotalInterest        decimal.Decimal `json:"total_interest"`
+	// LatestRateDate is the most recent announcement in the rate schedule
+	LatestRateDate *time.Time `json:"latest_rate_date"`
+}
+
+// IBondRateInput is the writable part of a rate announcement
+type IBondRateInput struct {
+	FixedRate     *float64 `json:"fixed_rate" binding:"required"`
+	InflationRate *float64 `json:"inflation_rate" binding:"required"`
+}
+
+// IBondService lists I bonds, keeps the I bond rate schedule and reminds
+// when a rate announcement is missing from it
+type IBondService struct {
+	db            *sql.DB
+	notifications *NotificationService
+}
+
+// NewIBondService creates an I bond service
+func NewIBondService(db *sql.DB, notifications *NotificationService) *IBondService {
+	return &IBondService{db: db, notifications: notifications}
+}
+
+// List returns every I bond, oldest first, with their totals as of now
+func (ibs *IBondService) List(now time.Time) (*IBondPortfolio, error) {
+	bonds, err := ibs.bonds(0, now)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &IBondPortfolio{Bonds: bonds}
+	for _, b := range bonds {
+		portfolio.TotalPurchaseAmount = portfolio.TotalPurchaseAmount.Add(b.PurchaseAmount)
+		portfolio.TotalCurrentValue = portfolio.TotalCurrentValue.Add(b.CurrentValue)
+		portfolio.TotalRedemptionValue = portfolio.TotalRedemptionValue.Add(b.RedemptionValue)
+		portfolio.TotalPenalty = portfolio.TotalPenalty.Add(b.Penalty)
+	}
+	portfolio.TotalInterest = portfolio.TotalCurrentValue.Sub(portfolio.TotalPurchaseAmount)
+
+	var latest sql.NullTime
+	if err := ibs.db.QueryRow(`SELECT MAX(effective_date) FROM i_bond_rates`).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to fetch latest I bond rate: %w", err)
+	}
+	if latest.Valid {
+		portfolio.LatestRateDate = &latest.Time
+	}
+	return portfolio, nil
+}
+
+// Get returns an I bond valued as of now
+func (ibs *IBondService) Get(id int, now time.Time) (*models.IBond, error) {
+	bonds, err := ibs.bonds(id, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(bonds) == 0 {
+		return nil, ErrIBondNotFound
+	}
+	return &bonds[0], nil
+}
+
+// Delete removes an I bond
+func (ibs *IBondService) Delete(id int) error {
+	result, err := ibs.db.Exec(`DELETE FROM i_bonds WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete I bond: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrIBondNotFound
+	}
+	return nil
+}
+
+// bonds returns the I bond with id, or every I bond when id is 0, oldest
+// first, each valued with IBond.Value as of now
+func (ibs *IBondService) bonds(id int, now time.Time) ([]models.IBond, error) {
+	rates, err := ibs.Rates()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ibs.db.Query(`
+		SELECT id, account_id, institution_name, serial_number, issue_date,
+		       purchase_amount, fixed_rate, notes, created_at, last_updated
+		FROM i_bonds
+		WHERE $1 = 0 OR id = $1
+		ORDER BY issue_date, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch I bonds: %w", err)
+	}
+	defer rows.Close()
+
+	bonds := []models.IBond{}
+	for rows.Next() {
+		var b models.IBond
+		err := rows.Scan(&b.ID, &b.AccountID, &b.InstitutionName, &b.SerialNumber, &b.IssueDate,
+			&b.PurchaseAmount, &b.FixedRate, &b.Notes, &b.CreatedAt, &b.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan I bond: %w", err)
+		}
+		b.Value(now, rates)
+		bonds = append(bonds, b)
+	}
+	return bonds, rows.Err()
+}
+
+// Rates returns the rate schedule, newest first
+func (ibs *IBondService) Rates() (models.IBondRates, error) {
+	rows, err := ibs.db.Query(`
+		SELECT effective_date, fixed_rate, inflation_rate
+		FROM i_bond_rates
+		ORDER BY effective_date DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch I bond rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := models.IBondRates{}
+	for rows.Next() {
+		var r models.IBondRate
+		if err := rows.Scan(&r.EffectiveDate, &r.FixedRate, &r.InflationRate); err != nil {
+			return nil, fmt.Errorf("failed to scan I bond rate: %w", err)
+		}
+		r.CompositeRate = models.IBondCompositeRate(r.FixedRate, r.InflationRate)
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// SetRate records the rates Treasury announced for a May 1 or November 1
+// date (YYYY-MM-DD), replacing any already recorded for it
+func (ibs *IBondService) SetRate(date string, input IBondRateInput) (*models.IBondRate, error) {
+	effective, err := parseIBondRateDate(date)
+	if err != nil {
+		return nil, err
+	}
+	if *input.FixedRate < 0 || *input.FixedRate > maxIBondRate ||
+		*input.InflationRate < -maxIBondRate || *input.InflationRate > maxIBondRate {
+		return nil, fmt.Errorf("%w: rates must be percentages, with a fixed rate of at least 0", ErrInvalidIBondRate)
+	}
+
+	rate := models.IBondRate{EffectiveDate: effective}
+	err = ibs.db.QueryRow(`
+		INSERT INTO i_bond_rates (effective_date, fixed_rate, inflation_rate, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (effective_date) DO UPDATE SET
+			fixed_rate = EXCLUDED.fixed_rate, inflation_rate = EXCLUDED.inflation_rate,
+			updated_at = EXCLUDED.updated_at
+		RETURNING fixed_rate, inflation_rate
+	`, effective, *input.FixedRate, *input.InflationRate).Scan(&rate.FixedRate, &rate.InflationRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save I bond rate: %w", err)
+	}
+	rate.CompositeRate = models.IBondCompositeRate(rate.FixedRate, rate.InflationRate)
+	return &rate, nil
+}
+
+// DeleteRate removes the rates recorded for a date (YYYY-MM-DD)
+func (ibs *IBondService) DeleteRate(date string) error {
+	effective, err := parseIBondRateDate(date)
+	if err != nil {
+		return err
+	}
+	result, err := ibs.db.Exec(`DELETE FROM i_bond_rates WHERE effective_date = $1`, effective)
+	if err != nil {
+		return fmt.Errorf("failed to delete I bond rate: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrIBondRateNotFound
+	}
+	return nil
+}
+
+// parseIBondRateDate parses a YYYY-MM-DD announcement date, which must be May 1 or November 1
+func parseIBondRateDate(date string) (time.Time, error) {
+	effective, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidIBondRate)
+	}
+	if effective.Day() != 1 || (effective.Month() != time.May && effective.Month() != time.November) {
+		return time.Time{}, fmt.Errorf("%w: rates take effect on May 1 or November 1", ErrInvalidIBondRate)
+	}
+	return effective, nil
+}
+
+// latestIBondAnnouncement is the most recent May 1 or November 1 on or before now
+func latestIBondAnnouncement(now time.Time) time.Time {
+	year := now.Year()
+	switch {
+	case now.Month() >= time.November:
+		return time.Date(year, time.November, 1, 0, 0, 0, 0, time.UTC)
+	case now.Month() >= time.May:
+		return time.Date(year, time.May, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year-1, time.November, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Run checks the rate schedule now and then every interval until ctx is done
+func (ibs *IBondService) Run(ctx context.Context, interval time.Duration) {
+	check := func() {
+		if err := ibs.Check(time.Now()); err != nil {
+			fmt.Printf("WARNING: I bond rate check failed: %v\n", err)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// Check creates an i_bond_rate notification, once per announcement, when I
+// bonds are held and the schedule is missing the rates Treasury announced on
+// the latest May 1 or November 1. Until they are recorded, bonds whose
+// six-month period starts after then carry on at the previous inflation rate.
+func (ibs *IBondService) Check(now time.Time) error {
+	announcement := latestIBondAnnouncement(now)
+
+	var held, recorded bool
+	err := ibs.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM i_bonds),
+		       EXISTS (SELECT 1 FROM i_bond_rates WHERE effective_date = $1)
+	`, announcement).Scan(&held, &recorded)
+	if err != nil {
+		return fmt.Errorf("failed to check I bond rates: %w", err)
+	}
+	if !held || recorded {
+		return nil
+	}
+
+	result, err := ibs.db.Exec(`
+		INSERT INTO i_bond_rate_alerts (effective_date, alerted_at)
+		VALUES ($1, $2)
+		ON CONFLICT (effective_date) DO NOTHING
+	`, announcement, now)
+	if err != nil {
+		return fmt.Errorf("failed to record I bond rate alert: %w", err)
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 0 || ibs.notifications == nil {
+		return nil
+	}
+
+	err = ibs.notifications.Create(
+		"i_bond_rate",
+		NotificationSeverityInfo,
+		fmt.Sprintf("I bond rates for %s are missing", announcement.Format("January 2006")),
+		fmt.Sprintf("Treasury announces new I bond rates on %s. Record the fixed and semiannual inflation rates with PUT /api/v1/i-bonds/rates/%s; until then your I bonds are valued at the previous inflation rate.",
+			announcement.Format("January 2, 2006"), announcement.Format("2006-01-02")),
+	)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to notify missing I bond rates: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInsurancePolicyNotFound is returned when an insurance policy does not exist
+var ErrInsurancePolicyNotFound = errors.New("insurance policy not found")
+
+// InsurancePortfolio is every insurance policy, soonest renewal first, with
+// coverage by policy type, what the premiums cost a year and the net cash
+// value counted in net worth
+type InsurancePortfolio struct {
+	Policies       []models.InsurancePolicy   `json:"policies"`
+	CoverageByType map[string]decimal.Decimal `json:"coverage_by_type"`
+	AnnualPremiums decimal.Decimal            `json:"annual_premiums"`
+	NetCashValue   decimal.Decimal            `json:"net_cash_value"`
+	// UpcomingRenewals counts the policies renewing within their notice period
+	UpcomingRenewals int `json:"upcoming_renewals"`
+}
+
+// InsuranceService lists insurance policies and alerts on upcoming renewals
+type InsuranceService struct {
+	db            *sql.DB
+	notifications *NotificationService
+}
+
+// NewInsuranceService creates an insurance service. notifications may be nil,
+// in which case renewals are tracked without sending alerts.
+func NewInsuranceService(db *sql.DB, notifications *NotificationService) *InsuranceService {
+	return &InsuranceService{db: db, notifications: notifications}
+}
+
+// List returns every policy, soonest renewal first, with their totals as of now
+func (is *InsuranceService) List(now time.Time) (*InsurancePortfolio, error) {
+	policies, err := is.policies(0, now)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &InsurancePortfolio{Policies: policies, CoverageByType: map[string]decimal.Decimal{}}
+	for _, p := range policies {
+		if p.CoverageAmount != nil {
+			portfolio.CoverageByType[p.PolicyType] = portfolio.CoverageByType[p.PolicyType].Add(*p.CoverageAmount)
+		}
+		portfolio.AnnualPremiums = portfolio.AnnualPremiums.Add(p.AnnualPremium)
+		portfolio.NetCashValue = portfolio.NetCashValue.Add(p.NetCashValue)
+		if p.DaysUntilRenewal != nil && *p.DaysUntilRenewal <= p.RenewalNoticeDays {
+			portfolio.UpcomingRenewals++
+		}
+	}
+	return portfolio, nil
+}
+
+// Get returns an insurance policy as of now
+func (is *InsuranceService) Get(id int, now time.Time) (*models.InsurancePolicy, error) {
+	policies, err := is.policies(id, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, ErrInsurancePolicyNotFound
+	}
+	return &policies[0], nil
+}
+
+// Delete removes an insurance policy
+func (is *InsuranceService) Delete(id int) error {
+	result, err := is.db.Exec(`DELETE FROM insurance_policies WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete insurance policy: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrInsurancePolicyNotFound
+	}
+	return nil
+}
+
+// policies returns the policy with id, or every policy when id is 0, soonest
+// renewal first and those without a renewal date last
+func (is *InsuranceService) policies(id int, now time.Time) ([]models.InsurancePolicy, error) {
+	rows, err := is.db.Query(`
+		SELECT id, account_id, institution_name, policy_name, policy_type, policy_number,
+		       coverage_amount, premium_amount, premium_frequency, cash_value, loan_balance,
+		       effective_date, renewal_date, term_months, renewal_notice_days,
+		       notes, created_at, last_updated
+		FROM insurance_policies
+		WHERE $1 = 0 OR id = $1
+		ORDER BY renewal_date NULLS LAST, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch insurance policies: %w", err)
+	}
+	defer rows.Close()
+
+	today := dateOnly(now)
+	policies := []models.InsurancePolicy{}
+	for rows.Next() {
+		var p models.InsurancePolicy
+		err := rows.Scan(&p.ID, &p.AccountID, &p.InstitutionName, &p.PolicyName, &p.PolicyType, &p.PolicyNumber,
+			&p.CoverageAmount, &p.PremiumAmount, &p.PremiumFrequency, &p.CashValue, &p.LoanBalance,
+			&p.EffectiveDate, &p.RenewalDate, &p.TermMonths, &p.RenewalNoticeDays,
+			&p.Notes, &p.CreatedAt, &p.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan insurance policy: %w", err)
+		}
+		p.AnnualPremium = p.PremiumAmount.Mul(decimal.NewFromInt(int64(p.PremiumFrequency)))
+		if p.CashValue.GreaterThan(p.LoanBalance) {
+			p.NetCashValue = p.CashValue.Sub(p.LoanBalance)
+		}
+		if p.RenewalDate != nil {
+			days := int(dateOnly(*p.RenewalDate).Sub(today).Hours() / 24)
+			p.DaysUntilRenewal = &days
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// Run checks renewal dates now and then every interval until ctx is done
+func (is *InsuranceService) Run(ctx context.Context, interval time.Duration) {
+	check := func() {
+		if err := is.Check(time.Now()); err != nil {
+			fmt.Printf("WARNING: Insurance renewal check failed: %v\n", err)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// Check moves renewal dates that have passed forward by their policy's term,
+// then creates an insurance_renewal notification, once per policy and
+// renewal date, for each policy renewing within its notice period.
+func (is *InsuranceService) Check(now time.Time) error {
+	today := dateOnly(now)
+	if err := is.rollRenewalDates(today); err != nil {
+		return err
+	}
+
+	// The alerts are recorded first, so only the renewals not yet alerted on
+	// come back
+	dialect := database.DialectOf(is.db)
+	rows, err := is.db.Query(`
+		INSERT INTO insurance_renewal_alerts (policy_id, renewal_date, alerted_at)
+		SELECT id, renewal_date, $2
+		FROM insurance_policies
+		WHERE renewal_date >= $1 AND renewal_date <= `+dialect.AddDays(dialect.Date("$1"), "renewal_notice_days")+`
+		ON CONFLICT (policy_id, renewal_date) DO NOTHING
+		RETURNING policy_id
+	`, today, now)
+	if err != nil {
+		return fmt.Errorf("failed to record insurance renewal alerts: %w", err)
+	}
+	var due []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan insurance renewal: %w", err)
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to record insurance renewal alerts: %w", err)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	rows, err = is.db.Query(`
+		SELECT institution_name, policy_name, renewal_date, premium_amount
+		FROM insurance_policies
+		WHERE `+dialect.InArray("id", "$1")+`
+		ORDER BY renewal_date, id
+	`, due)
+	if err != nil {
+		return fmt.Errorf("failed to fetch insurance renewals: %w", err)
+	}
+	defer rows.Close()
+
+	type renewal struct {
+		institution, policy string
+		date                time.Time
+		premium             decimal.Decimal
+	}
+	var renewals []renewal
+	for rows.Next() {
+		var r renewal
+		if err := rows.Scan(&r.institution, &r.policy, &r.date, &r.premium); err != nil {
+			return fmt.Errorf("failed to scan insurance renewal: %w", err)
+		}
+		renewals = append(renewals, r)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read insurance renewals: %w", err)
+	}
+	if is.notifications == nil {
+		return nil
+	}
+
+	for _, r := range renewals {
+		days := int(dateOnly(r.date).Sub(today).Hours() / 24)
+		when := fmt.Sprintf("in %d days", days)
+		switch days {
+		case 0:
+			when = "today"
+		case 1:
+			when = "tomorrow"
+		}
+		err := is.notifications.Create(
+			"insurance_renewal",
+			NotificationSeverityWarning,
+			fmt.Sprintf("%s renews %s", r.policy, when),
+			fmt.Sprintf("Your %s policy with %s renews on %s with a premium of $%s. Review the coverage and premium before then.",
+				r.policy, r.institution, r.date.Format("January 2, 2006"), r.premium.StringFixed(2)),
+		)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to notify insurance renewal of %s: %v\n", r.policy, err)
+		}
+	}
+	return nil
+}
+
+// rollRenewalDates moves renewal dates before today forward by whole terms
+// until they are today or later. Policies without a term keep their date.
+func (is *InsuranceService) rollRenewalDates(today time.Time) error {
+	rows, err := is.db.Query(`
+		SELECT id, renewal_date, term_months
+		FROM insurance_policies
+		WHERE renewal_date < $1 AND term_months IS NOT NULL
+	`, today)
+	if err != nil {
+		return fmt.Errorf("failed to fetch lapsed renewal dates: %w", err)
+	}
+	defer rows.Close()
+
+	rolled := map[int]time.Time{}
+	for rows.Next() {
+		var id, term int
+		var renewal time.Time
+		if err := rows.Scan(&id, &renewal, &term); err != nil {
+			return fmt.Errorf("failed to scan renewal date: %w", err)
+		}
+		rolled[id] = nextRenewal(dateOnly(renewal), term, today)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read renewal dates: %w", err)
+	}
+
+	for id, renewal := range rolled {
+		_, err := is.db.Exec(`
+			UPDATE insurance_policies SET renewal_date = $2, last_updated = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, id, renewal)
+		if err != nil {
+			return fmt.Errorf("failed to update renewal date: %w", err)
+		}
+	}
+	return nil
+}
+
+// nextRenewal returns the first date on or after today that is a whole number
+// of terms after renewal. Terms are counted from renewal rather than stepped
+// so a renewal on the 31st stays at the end of shorter months.
+func nextRenewal(renewal time.Time, termMonths int, today time.Time) time.Time {
+	next := renewal
+	for terms := 1; next.Before(today); terms++ {
+		next = addMonths(renewal, terms*termMonths)
+	}
+	return next
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Integrity checks
+const (
+	IntegrityGrantShares     = "grant_shares"
+	IntegrityMissingAccount  = "missing_account"
+	IntegrityNegativeBalance = "negative_balance"
+)
+
+// integrityCheck finds one kind of inconsistency. Its query returns the
+// entity type, id, name and a description of each affected row.
+type integrityCheck struct {
+	name  string
+	query string
+}
+
+// Values derived from others, such as a grant's unvested shares and a
+// property's equity, are generated columns and can't drift, so the checks
+// look for stored values that contradict each other or can't be right
+var integrityChecks = []integrityCheck{
+	{
+		// Unvested shares are the total less vested shares, so vested shares
+		// outside the grant leave them negative or above the total
+		name: IntegrityGrantShares,
+		query: `
+			SELECT 'equity_grant', id, company_symbol,
+				format('vested %s of %s total shares', COALESCE(vested_shares, 0), total_shares)
+			FROM equity_grants
+			WHERE COALESCE(vested_shares, 0) NOT BETWEEN 0 AND total_shares
+			ORDER BY 1, 2`,
+	},
+	{
+		// The foreign keys rule out a dangling account, so only a missing one
+		// is left; which account a holding belongs to can't be guessed
+		name: IntegrityMissingAccount,
+		query: `
+			SELECT 'stock_holding', id, symbol, 'no account' FROM stock_holdings WHERE account_id IS NULL
+			UNION ALL
+			SELECT 'equity_grant', id, company_symbol, 'no account' FROM equity_grants WHERE account_id IS NULL
+			UNION ALL
+			SELECT 'real_estate', id, property_name, 'no account' FROM real_estate_properties WHERE account_id IS NULL
+			UNION ALL
+			SELECT 'cash_holding', id, account_name, 'no account' FROM cash_holdings WHERE account_id IS NULL
+			UNION ALL
+			SELECT 'crypto_holding', id, crypto_symbol, 'no account' FROM crypto_holdings WHERE account_id IS NULL
+			UNION ALL
+			SELECT 'other_asset', id, asset_name, 'no account' FROM miscellaneous_assets WHERE account_id IS NULL
+			ORDER BY 1, 2`,
+	},
+	{
+		// Liabilities are tracked as amounts owed, so a negative balance is a
+		// data entry mistake whose intended value is unknown
+		name: IntegrityNegativeBalance,
+		query: `
+			SELECT 'stock_holding', id, symbol, format('%s shares owned', shares_owned) FROM stock_holdings WHERE shares_owned < 0
+			UNION ALL
+			SELECT 'cash_holding', id, account_name, format('balance %s', current_balance) FROM cash_holdings WHERE current_balance < 0
+			UNION ALL
+			SELECT 'crypto_holding', id, crypto_symbol, format('%s tokens held', balance_tokens) FROM crypto_holdings WHERE balance_tokens < 0
+			UNION ALL
+			SELECT 'real_estate', id, property_name, format('value %s', current_value) FROM real_estate_properties WHERE current_value < 0
+			UNION ALL
+			SELECT 'real_estate', id, property_name, format('mortgage %s', outstanding_mortgage) FROM real_estate_properties WHERE outstanding_mortgage < 0
+			UNION ALL
+			SELECT 'other_asset', id, asset_name, format('value %s', current_value) FROM miscellaneous_assets WHERE current_value < 0
+			UNION ALL
+			SELECT 'other_asset', id, asset_name, format('amount owed %s', amount_owed) FROM miscellaneous_assets WHERE amount_owed < 0
+			ORDER BY 1, 2`,
+	},
+}
+
+// IntegrityIssue is one inconsistent row
+type IntegrityIssue struct {
+	Check      string `json:"check"`
+	EntityType string `json:"entity_type"`
+	EntityID   int    `json:"entity_id"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+}
+
+// IntegrityReport is the result of one integrity check
+type IntegrityReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// Counts are the issues found by each check
+	Counts map[string]int   `json:"counts"`
+	Issues []IntegrityIssue `json:"issues"`
+}
+
+// IntegrityService finds holdings whose stored values contradict each other
+// or can't be right, such as grants with more vested shares than the total,
+// for someone to correct
+type IntegrityService struct {
+	db *sql.DB
+
+	mu         sync.Mutex
+	lastReport *IntegrityReport
+}
+
+// NewIntegrityService creates a new integrity service
+func NewIntegrityService(db *sql.DB) *IntegrityService {
+	return &IntegrityService{db: db}
+}
+
+// Check runs every integrity check
+func (is *IntegrityService) Check(ctx context.Context) (*IntegrityReport, error) {
+	report := &IntegrityReport{
+		CheckedAt: time.Now(),
+		Counts:    map[string]int{},
+		Issues:    []IntegrityIssue{},
+	}
+
+	for _, check := range integrityChecks {
+		issues, err := is.find(ctx, check)
+		if err != nil {
+			return nil, err
+		}
+		report.Counts[check.name] = len(issues)
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	is.mu.Lock()
+	is.lastReport = report
+	is.mu.Unlock()
+	return report, nil
+}
+
+// find returns the rows failing a check
+func (is *IntegrityService) find(ctx context.Context, check integrityCheck) ([]IntegrityIssue, error) {
+	rows, err := is.db.QueryContext(ctx, check.query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s check: %w", check.name, err)
+	}
+	defer rows.Close()
+
+	var issues []IntegrityIssue
+	for rows.Next() {
+		issue := IntegrityIssue{Check: check.name}
+		if err := rows.Scan(&issue.EntityType, &issue.EntityID, &issue.Name, &issue.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan %s issue: %w", check.name, err)
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// LastReport returns the report of the last check since the server started,
+// or nil
+func (is *IntegrityService) LastReport() *IntegrityReport {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	return is.lastReport
+}
+
+// Run checks integrity now and every interval until ctx is cancelled
+func (is *IntegrityService) Run(ctx context.Context, interval time.Duration) {
+	check := func() {
+		report, err := is.Check(ctx)
+		if err != nil {
+			fmt.Printf("WARNING: Integrity check failed: %v\n", err)
+			return
+		}
+		if len(report.Issues) > 0 {
+			fmt.Printf("WARNING: Integrity check found %d data inconsistencies; see /api/v1/admin/integrity\n", len(report.Issues))
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// IntradayInterval is the length of each intraday bar
+const IntradayInterval = 5 * time.Minute
+
+var (
+	// ErrIntradayUnsupported is returned when the price provider has no
+	// intraday data
+	ErrIntradayUnsupported = errors.New("the price provider does not support intraday prices")
+	// ErrIntradayRateLimited is returned when the provider's rate limit is
+	// spent and no bars are cached
+	ErrIntradayRateLimited = errors.New("intraday rate limit exceeded and no cached bars available")
+)
+
+// PriceBar is one interval's open, high, low and close
+type PriceBar struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume int64     `json:"volume"`
+}
+
+// IntradayProvider is a price provider with 5-minute bars for the latest
+// trading day
+type IntradayProvider interface {
+	GetIntradayBars(symbol string) ([]PriceBar, error)
+}
+
+// GetIntradayBars returns the provider's 5-minute bars for symbol's latest
+// trading day
+func (ps *PriceService) GetIntradayBars(symbol string) ([]PriceBar, error) {
+	provider, ok := ps.provider.(IntradayProvider)
+	if !ok {
+		return nil, ErrIntradayUnsupported
+	}
+	return provider.GetIntradayBars(symbol)
+}
+
+// IntradaySeries is a symbol's 5-minute bars for one trading day
+type IntradaySeries struct {
+	Symbol        string     `json:"symbol"`
+	Date          string     `json:"date"`
+	Interval      string     `json:"interval"`
+	Bars          []PriceBar `json:"bars"`
+	Open          float64    `json:"open"`
+	Last          float64    `json:"last"`
+	Change        float64    `json:"change"`
+	ChangePercent float64    `json:"change_percent"`
+	Source        string     `json:"source"`
+	FetchedAt     time.Time  `json:"fetched_at"`
+	// Stale is set when the bars are older than an interval but the
+	// provider's rate limit is spent
+	Stale bool `json:"stale,omitempty"`
+}
+
+// IntradayService caches each symbol's intraday bars, fetching them from the
+// price provider at most once per interval while the market is open
+type IntradayService struct {
+	db     *sql.DB
+	prices *PriceService
+	market *MarketHoursService
+	group  singleflight.Group
+}
+
+// NewIntradayService creates a new intraday service
+func NewIntradayService(db *sql.DB, prices *PriceService, market *MarketHoursService) *IntradayService {
+	return &IntradayService{db: db, prices: prices, market: market}
+}
+
+// Series returns symbol's bars for the latest trading day, from the cache
+// unless it is older than an interval during market hours. Concurrent
+// requests for a symbol share one fetch.
+func (is *IntradayService) Series(symbol string) (*IntradaySeries, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol cannot be empty")
+	}
+
+	result, err, _ := is.group.Do(symbol, func() (interface{}, error) {
+		return is.series(symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*IntradaySeries), nil
+}
+
+func (is *IntradayService) series(symbol string) (*IntradaySeries, error) {
+	cached, err := is.cached(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && !is.market.ShouldRefreshPrices(cached.FetchedAt, IntradayInterval) {
+		return cached, nil
+	}
+
+	bars, err := is.prices.GetIntradayBars(symbol)
+	if err != nil {
+		if cached != nil && !errors.Is(err, ErrIntradayUnsupported) {
+			fmt.Printf("WARNING: Intraday fetch for %s failed, using cached bars: %v\n", symbol, err)
+			cached.Stale = true
+			return cached, nil
+		}
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no intraday bars returned for %s", symbol)
+	}
+
+	series := newIntradaySeries(symbol, bars, is.prices.GetProviderName(), time.Now())
+	encoded, err := json.Marshal(series.Bars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode intraday bars: %w", err)
+	}
+	_, err = is.db.Exec(`
+		INSERT INTO intraday_prices (symbol, trading_date, bars, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (symbol) DO UPDATE SET trading_date = EXCLUDED.trading_date, bars = EXCLUDED.bars,
+		    source = EXCLUDED.source, fetched_at = EXCLUDED.fetched_at
+	`, symbol, series.Date, encoded, series.Source, series.FetchedAt)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to cache intraday bars for %s: %v\n", symbol, err)
+	}
+	return series, nil
+}
+
+// cached returns the stored series for symbol, or nil when there is none
+func (is *IntradayService) cached(symbol string) (*IntradaySeries, error) {
+	var encoded []byte
+	var source string
+	var fetchedAt time.Time
+	err := is.db.QueryRow(`
+		SELECT bars, source, fetched_at FROM intraday_prices WHERE symbol = $1
+	`, symbol).Scan(&encoded, &source, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached intraday bars: %w", err)
+	}
+
+	var bars []PriceBar
+	if err := json.Unmarshal(encoded, &bars); err != nil || len(bars) == 0 {
+		return nil, nil
+	}
+	return newIntradaySeries(symbol, bars, source, fetchedAt), nil
+}
+
+// newIntradaySeries orders bars by time and summarizes the day's move
+func newIntradaySeries(symbol string, bars []PriceBar, source string, fetchedAt time.Time) *IntradaySeries {
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	series := &IntradaySeries{
+		Symbol:    symbol,
+		Date:      bars[0].Time.Format("2006-01-02"),
+		Interval:  "5min",
+		Bars:      bars,
+		Open:      bars[0].Open,
+		Last:      bars[len(bars)-1].Close,
+		Source:    source,
+		FetchedAt: fetchedAt,
+	}
+	series.Change = roundCents(series.Last - series.Open)
+	if series.Open > 0 {
+		series.ChangePercent = roundPercent((series.Last - series.Open) / series.Open * 100)
+	}
+	return series
+}
+
+// latestDayBars keeps the bars of the latest date among them
+func latestDayBars(bars []PriceBar) []PriceBar {
+	var latest string
+	for _, bar := range bars {
+		if day := bar.Time.Format("2006-01-02"); day > latest {
+			latest = day
+		}
+	}
+	kept := make([]PriceBar, 0, len(bars))
+	for _, bar := range bars {
+		if bar.Time.Format("2006-01-02") == latest {
+			kept = append(kept, bar)
+		}
+	}
+	return kept
+}
+
+// recordIntradayCall logs an intraday API call so it counts against the
+// provider's rate limits alongside price lookups
+func recordIntradayCall(db *sql.DB, symbol, source string) {
+	_, err := db.Exec(`INSERT INTO intraday_price_calls (symbol, source) VALUES ($1, $2)`, symbol, source)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to record intraday call for %s: %v\n", symbol, err)
+	}
+}
+
+// parseBar builds a bar from a provider's string fields
+func parseBar(at time.Time, open, high, low, close, volume string) (PriceBar, bool) {
+	bar := PriceBar{Time: at}
+	var err error
+	for _, field := range []struct {
+		value string
+		dest  *float64
+	}{{open, &bar.Open}, {high, &bar.High}, {low, &bar.Low}, {close, &bar.Close}} {
+		if *field.dest, err = strconv.ParseFloat(field.value, 64); err != nil {
+			return bar, false
+		}
+	}
+	bar.Volume, _ = strconv.ParseInt(volume, 10, 64)
+	return bar, bar.Close > 0
+}
+
+// intradayLocation loads a provider's exchange timezone, falling back to the
+// market's
+func intradayLocation(name string, market *MarketHoursService) *time.Location {
+	if location, err := time.LoadLocation(name); err == nil && name != "" {
+		return location
+	}
+	return market.GetMarketTimeZone()
+}
+
+// GetIntradayBars fetches the latest trading day's 5-minute bars from the
+// Twelve Data time_series endpoint
+func (td *TwelveDataPriceProvider) GetIntradayBars(symbol string) ([]PriceBar, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !td.canMakeAPICall() {
+		return nil, ErrIntradayRateLimited
+	}
+
+	// A trading day has 78 five-minute bars
+	url := fmt.Sprintf("%s/time_series?symbol=%s&interval=5min&outputsize=100&apikey=%s", td.baseURL, symbol, td.apiKey)
+	resp, err := td.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Twelve Data intraday request failed for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+	recordIntradayCall(td.db, symbol, "twelvedata")
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Twelve Data intraday API returned status %d for %s", resp.StatusCode, symbol)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Twelve Data intraday response for %s: %w", symbol, err)
+	}
+
+	var response struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+		Meta    struct {
+			ExchangeTimezone string `json:"exchange_timezone"`
+		} `json:"meta"`
+		Values []struct {
+			Datetime string `json:"datetime"`
+			Open     string `json:"open"`
+			High     string `json:"high"`
+			Low      string `json:"low"`
+			Close    string `json:"close"`
+			Volume   string `json:"volume"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Twelve Data intraday response for %s: %w", symbol, err)
+	}
+	if response.Code == http.StatusTooManyRequests {
+		return nil, ErrIntradayRateLimited
+	}
+	if response.Status == "error" {
+		return nil, fmt.Errorf("Twelve Data intraday error for %s: %s", symbol, response.Message)
+	}
+
+	location := intradayLocation(response.Meta.ExchangeTimezone, td.marketService)
+	bars := make([]PriceBar, 0, len(response.Values))
+	for _, value := range response.Values {
+		at, err := time.ParseInLocation("2006-01-02 15:04:05", value.Datetime, location)
+		if err != nil {
+			continue
+		}
+		if bar, ok := parseBar(at, value.Open, value.High, value.Low, value.Close, value.Volume); ok {
+			bars = append(bars, bar)
+		}
+	}
+	return latestDayBars(bars), nil
+}
+
+// GetIntradayBars fetches the latest trading day's 5-minute bars from the
+// Alpha Vantage TIME_SERIES_INTRADAY endpoint
+func (av *AlphaVantagePriceProvider) GetIntradayBars(symbol string) ([]PriceBar, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !av.canMakeAPICall() {
+		return nil, ErrIntradayRateLimited
+	}
+
+	url := fmt.Sprintf("%s?function=TIME_SERIES_INTRADAY&symbol=%s&interval=5min&apikey=%s", av.baseURL, symbol, av.apiKey)
+	resp, err := av.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Alpha Vantage intraday request failed for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+	recordIntradayCall(av.db, symbol, "alphavantage")
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Alpha Vantage intraday API returned status %d for %s", resp.StatusCode, symbol)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Alpha Vantage intraday response for %s: %w", symbol, err)
+	}
+
+	var response struct {
+		MetaData   map[string]string `json:"Meta Data"`
+		Note       string            `json:"Note"`
+		Info       string            `json:"Information"`
+		Error      string            `json:"Error Message"`
+		TimeSeries map[string]struct {
+			Open   string `json:"1. open"`
+			High   string `json:"2. high"`
+			Low    string `json:"3. low"`
+			Close  string `json:"4. close"`
+			Volume string `json:"5. volume"`
+		} `json:"Time Series (5min)"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Alpha Vantage intraday response for %s: %w", symbol, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("Alpha Vantage intraday error for %s: %s", symbol, response.Error)
+	}
+	if len(response.TimeSeries) == 0 && (response.Note != "" || response.Info != "") {
+		return nil, ErrIntradayRateLimited
+	}
+
+	location := intradayLocation(response.MetaData["6. Time Zone"], av.marketService)
+	bars := make([]PriceBar, 0, len(response.TimeSeries))
+	for timestamp, value := range response.TimeSeries {
+		at, err := time.ParseInLocation("2006-01-02 15:04:05", timestamp, location)
+		if err != nil {
+			continue
+		}
+		if bar, ok := parseBar(at, value.Open, value.High, value.Low, value.Close, value.Volume); ok {
+			bars = append(bars, bar)
+		}
+	}
+	return latestDayBars(bars), nil
+}
+
+// GetIntradayBars simulates a random walk of 5-minute bars through today's
+// session, in UTC
+func (m *MockPriceProvider) GetIntradayBars(symbol string) ([]PriceBar, error) {
+	price, err := m.GetCurrentPrice(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	open := time.Date(now.Year(), now.Month(), now.Day(), 14, 30, 0, 0, time.UTC)
+	bars := []PriceBar{}
+	for at := open; at.Before(open.Add(390*time.Minute)) && !at.After(now); at = at.Add(IntradayInterval) {
+		move := price * (m.rand.Float64() - 0.5) * 0.004
+		high := math.Max(price, price+move) * (1 + m.rand.Float64()*0.001)
+		low := math.Min(price, price+move) * (1 - m.rand.Float64()*0.001)
+		bars = append(bars, PriceBar{
+			Time:   at,
+			Open:   roundCents(price),
+			High:   roundCents(high),
+			Low:    roundCents(low),
+			Close:  roundCents(price + move),
+			Volume: int64(1000 + m.rand.Intn(9000)),
+		})
+		price += move
+	}
+	if len(bars) == 0 {
+		// Before today's open, show a single bar at the current price
+		bars = append(bars, PriceBar{Time: now.Truncate(IntradayInterval), Open: price, High: price, Low: price, Close: price})
+	}
+	return bars, nil
+}
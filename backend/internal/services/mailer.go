@@ -0,0 +1,410 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/database"
+)
+
+// Email queue statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+)
+
+const (
+	// smtpTimeout bounds a whole SMTP conversation
+	smtpTimeout = 30 * time.Second
+	// emailBatchSize is how many queued emails one pass sends
+	emailBatchSize = 20
+	// maxEmailBackoff caps the wait between delivery attempts
+	maxEmailBackoff = time.Hour
+)
+
+var (
+	// ErrMailerNotConfigured is returned when SMTP_HOST or SMTP_FROM is not set
+	ErrMailerNotConfigured = errors.New("SMTP is not configured; set SMTP_HOST and SMTP_FROM")
+	// ErrNoEmailRecipients is returned for an email with nowhere to go
+	ErrNoEmailRecipients = errors.New("no email recipients; set NOTIFICATION_EMAIL_TO")
+)
+
+// EmailAttachment is a file attached to an email
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// Email is a message with a plain text body and an optional HTML alternative
+type Email struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []EmailAttachment
+}
+
+// Mailer renders templated emails and sends them over SMTP, either directly or
+// through a queue in the database that retries failed deliveries with backoff
+type Mailer struct {
+	db     *sql.DB
+	config *config.SMTPConfig
+	wake   chan struct{}
+}
+
+// NewMailer creates a mailer
+func NewMailer(db *sql.DB, cfg *config.SMTPConfig) *Mailer {
+	return &Mailer{
+		db:     db,
+		config: cfg,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Configured reports whether SMTP settings are present
+func (m *Mailer) Configured() bool {
+	return m.config.Host != "" && m.config.From != ""
+}
+
+// Recipients returns the configured alert and report recipients
+func (m *Mailer) Recipients() []string {
+	return m.config.To
+}
+
+// Render builds an email from a named template. Recipients default to the
+// configured ones.
+func (m *Mailer) Render(name string, data interface{}) (Email, error) {
+	tmpl, ok := emailTemplates[name]
+	if !ok {
+		return Email{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return Email{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Email{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return Email{}, fmt.Errorf("failed to render %s HTML: %w", name, err)
+	}
+
+	return Email{
+		To:      m.config.To,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Enqueue stores an email for the queue worker to send
+func (m *Mailer) Enqueue(email Email) error {
+	if !m.Configured() {
+		return ErrMailerNotConfigured
+	}
+	if len(email.To) == 0 {
+		return ErrNoEmailRecipients
+	}
+
+	var attachments []byte
+	if len(email.Attachments) > 0 {
+		var err error
+		if attachments, err = json.Marshal(email.Attachments); err != nil {
+			return fmt.Errorf("failed to encode attachments: %w", err)
+		}
+	}
+
+	_, err := m.db.Exec(`
+		INSERT INTO email_queue (recipients, subject, text_body, html_body, attachments)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`, email.To, email.Subject, email.Text, email.HTML, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run sends queued emails now and then every interval, or as soon as one is
+// queued, until ctx is done
+func (m *Mailer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.SendQueued(time.Now()); err != nil {
+			fmt.Printf("WARNING: Email queue failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+	}
+}
+
+// SendQueued sends the pending emails that are due. A failed email is retried
+// with exponential backoff until it has been attempted MaxAttempts times.
+func (m *Mailer) SendQueued(now time.Time) error {
+	rows, err := m.db.Query(`
+		SELECT id, recipients, subject, text_body, COALESCE(html_body, ''), attachments, attempts
+		FROM email_queue
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY id
+		LIMIT $3
+	`, EmailStatusPending, now, emailBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch queued emails: %w", err)
+	}
+
+	type queued struct {
+		id       int
+		email    Email
+		attempts int
+	}
+	var batch []queued
+	for rows.Next() {
+		var q queued
+		var attachments []byte
+		if err := rows.Scan(&q.id, database.Array(&q.email.To), &q.email.Subject, &q.email.Text, &q.email.HTML, &attachments, &q.attempts); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan queued email: %w", err)
+		}
+		if len(attachments) > 0 {
+			if err := json.Unmarshal(attachments, &q.email.Attachments); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode attachments of email %d: %w", q.id, err)
+			}
+		}
+		batch = append(batch, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch queued emails: %w", err)
+	}
+
+	for _, q := range batch {
+		sendErr := m.Send(q.email)
+		if sendErr == nil {
+			_, err = m.db.Exec(`
+				UPDATE email_queue SET status = $1, attempts = attempts + 1, sent_at = $2, last_error = NULL
+				WHERE id = $3
+			`, EmailStatusSent, time.Now(), q.id)
+		} else {
+			attempts := q.attempts + 1
+			status := EmailStatusPending
+			if attempts >= m.config.MaxAttempts {
+				status = EmailStatusFailed
+				fmt.Printf("WARNING: Giving up on email %d (%q) after %d attempts: %v\n", q.id, q.email.Subject, attempts, sendErr)
+			}
+			_, err = m.db.Exec(`
+				UPDATE email_queue SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4
+				WHERE id = $5
+			`, status, attempts, now.Add(emailBackoff(attempts)), sendErr.Error(), q.id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update queued email %d: %w", q.id, err)
+		}
+	}
+	return nil
+}
+
+// emailBackoff is the wait after a failed attempt: a minute, doubling with
+// each attempt up to maxEmailBackoff
+func emailBackoff(attempts int) time.Duration {
+	backoff := time.Minute
+	for i := 1; i < attempts && backoff < maxEmailBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxEmailBackoff {
+		return maxEmailBackoff
+	}
+	return backoff
+}
+
+// Send delivers an email now over SMTP
+func (m *Mailer) Send(email Email) error {
+	if !m.Configured() {
+		return ErrMailerNotConfigured
+	}
+	if len(email.To) == 0 {
+		return ErrNoEmailRecipients
+	}
+
+	message, err := m.buildMessage(email)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	tlsConfig := &tls.Config{ServerName: m.config.Host}
+
+	var conn net.Conn
+	if m.config.TLSMode == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if m.config.TLSMode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("failed to start TLS: %w", err)
+			}
+		}
+	}
+	if m.config.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection
+		// except to localhost
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.config.From); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range email.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage encodes an email as MIME: the text and HTML bodies as
+// alternatives, wrapped with any attachments
+func (m *Mailer) buildMessage(email Email) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	alternative, err := alternativeBody(email)
+	if err != nil {
+		return nil, err
+	}
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.boundary},
+	})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(alternative.body)
+
+	for _, attachment := range email.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", m.config.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "Message-ID: <%s@%s>\r\n", randomToken(), m.config.Host)
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+type mimeBody struct {
+	boundary string
+	body     []byte
+}
+
+// alternativeBody encodes the text body, and the HTML body when there is one,
+// as quoted-printable parts
+func alternativeBody(email Email) (mimeBody, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	bodies := []struct{ contentType, content string }{{"text/plain; charset=utf-8", email.Text}}
+	if email.HTML != "" {
+		bodies = append(bodies, struct{ contentType, content string }{"text/html; charset=utf-8", email.HTML})
+	}
+	for _, b := range bodies {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {b.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return mimeBody{}, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		qp.Write([]byte(b.content))
+		qp.Close()
+	}
+	if err := writer.Close(); err != nil {
+		return mimeBody{}, err
+	}
+	return mimeBody{boundary: writer.Boundary(), body: body.Bytes()}, nil
+}
+
+func randomToken() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Market holiday sources
+const (
+	// MarketHolidaySourceCalendar is a holiday from the standard exchange calendar
+	MarketHolidaySourceCalendar = "calendar"
+	// MarketHolidaySourceManual is a closure entered by hand, such as a
+	// national day of mourning
+	MarketHolidaySourceManual = "manual"
+)
+
+var (
+	// ErrMarketHolidayNotFound is returned when a market holiday does not exist
+	ErrMarketHolidayNotFound = errors.New("market holiday not found")
+	// ErrInvalidMarketHoliday is returned for an unparseable date or empty name
+	ErrInvalidMarketHoliday = errors.New("invalid market holiday")
+)
+
+// MarketHoliday is a weekday the stock market is closed
+type MarketHoliday struct {
+	ID        int       `json:"id"`
+	Date      time.Time `json:"date"`
+	Name      string    `json:"name"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MarketHolidayInput adds a closure. Date is YYYY-MM-DD.
+type MarketHolidayInput struct {
+	Date string `json:"date" binding:"required"`
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// StandardMarketHolidays returns the NYSE holidays of year, moved to the
+// nearest weekday when they fall on a weekend. New Year's Day falling on a
+// Saturday is not observed, since the exchange doesn't close in the prior
+// year. Juneteenth is observed from 2022.
+func StandardMarketHolidays(year int) []MarketHoliday {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	holidays := []MarketHoliday{}
+	add := func(name string, day time.Time) {
+		holidays = append(holidays, MarketHoliday{Date: day, Name: name, Source: MarketHolidaySourceCalendar})
+	}
+
+	if newYear := date(time.January, 1); newYear.Weekday() != time.Saturday {
+		add("New Year's Day", observedHoliday(newYear))
+	}
+	add("Martin Luther King Jr. Day", nthWeekday(year, time.January, time.Monday, 3))
+	add("Washington's Birthday", nthWeekday(year, time.February, time.Monday, 3))
+	add("Good Friday", easterSunday(year).AddDate(0, 0, -2))
+	add("Memorial Day", lastWeekday(year, time.May, time.Monday))
+	if year >= 2022 {
+		add("Juneteenth National Independence Day", observedHoliday(date(time.June, 19)))
+	}
+	add("Independence Day", observedHoliday(date(time.July, 4)))
+	add("Labor Day", nthWeekday(year, time.September, time.Monday, 1))
+	add("Thanksgiving Day", nthWeekday(year, time.November, time.Thursday, 4))
+	add("Christmas Day", observedHoliday(date(time.December, 25)))
+	return holidays
+}
+
+// observedHoliday moves a Saturday holiday to Friday and a Sunday one to Monday
+func observedHoliday(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// nthWeekday returns the nth weekday of a month, e.g. the third Monday
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last weekday of a month, e.g. the last Monday
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easterSunday returns the date of Easter in the Gregorian calendar
+// (anonymous Gregorian algorithm)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// MarketHolidayService keeps the market holiday table filled from the
+// standard calendar and loads it into the market hours service
+type MarketHolidayService struct {
+	db     *sql.DB
+	market *MarketHoursService
+}
+
+// NewMarketHolidayService creates a new market holiday service
+func NewMarketHolidayService(db *sql.DB, market *MarketHoursService) *MarketHolidayService {
+	return &MarketHolidayService{db: db, market: market}
+}
+
+// List returns the holidays of year, or every holiday when year is 0, by date
+func (mhs *MarketHolidayService) List(year int) ([]MarketHoliday, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := mhs.db.Query(`
+		SELECT id, holiday_date, name, source, created_at
+		FROM market_holidays
+		WHERE NOT removed AND ($1 = 0 OR (holiday_date >= $2 AND holiday_date < $3))
+		ORDER BY holiday_date
+	`, year, start, start.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch market holidays: %w", err)
+	}
+	defer rows.Close()
+
+	holidays := []MarketHoliday{}
+	for rows.Next() {
+		var h MarketHoliday
+		if err := rows.Scan(&h.ID, &h.Date, &h.Name, &h.Source, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan market holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch market holidays: %w", err)
+	}
+	return holidays, nil
+}
+
+// Create adds a manual closure, replacing any holiday on that date, and
+// returns its ID
+func (mhs *MarketHolidayService) Create(input MarketHolidayInput) (int, error) {
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		return 0, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidMarketHoliday)
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return 0, fmt.Errorf("%w: name is required", ErrInvalidMarketHoliday)
+	}
+
+	var id int
+	err = mhs.db.QueryRow(`
+		INSERT INTO market_holidays (holiday_date, name, source)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (holiday_date) DO UPDATE SET name = EXCLUDED.name, source = EXCLUDED.source, removed = false
+		RETURNING id
+	`, date, name, MarketHolidaySourceManual).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create market holiday: %w", err)
+	}
+	return id, mhs.Load()
+}
+
+// Delete removes a holiday. A calendar holiday removed this way is not added
+// back, so an exchange that stays open on a usual holiday can be recorded.
+func (mhs *MarketHolidayService) Delete(id int) error {
+	result, err := mhs.db.Exec(`UPDATE market_holidays SET removed = true WHERE id = $1 AND NOT removed`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete market holiday: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMarketHolidayNotFound
+	}
+	return mhs.Load()
+}
+
+// Sync adds the standard calendar holidays of now's year and the next one,
+// keeping holidays already stored, and loads the table into the market
+// hours service
+func (mhs *MarketHolidayService) Sync(now time.Time) error {
+	for year := now.Year(); year <= now.Year()+1; year++ {
+		for _, holiday := range StandardMarketHolidays(year) {
+			_, err := mhs.db.Exec(`
+				INSERT INTO market_holidays (holiday_date, name, source)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (holiday_date) DO NOTHING
+			`, holiday.Date, holiday.Name, holiday.Source)
+			if err != nil {
+				return fmt.Errorf("failed to add market holiday %s: %w", holiday.Date.Format("2006-01-02"), err)
+			}
+		}
+	}
+	return mhs.Load()
+}
+
+// Load replaces the market hours service's holidays with the stored ones
+func (mhs *MarketHolidayService) Load() error {
+	rows, err := mhs.db.Query(`SELECT holiday_date, name FROM market_holidays WHERE NOT removed`)
+	if err != nil {
+		return fmt.Errorf("failed to load market holidays: %w", err)
+	}
+	defer rows.Close()
+
+	holidays := map[string]string{}
+	for rows.Next() {
+		var date time.Time
+		var name string
+		if err := rows.Scan(&date, &name); err != nil {
+			return fmt.Errorf("failed to scan market holiday: %w", err)
+		}
+		holidays[date.Format("2006-01-02")] = name
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load market holidays: %w", err)
+	}
+	mhs.market.SetHolidays(holidays)
+	return nil
+}
+
+// Run syncs the holiday calendar now and then every interval until ctx is
+// done, so the next year's holidays are in place before it starts
+func (mhs *MarketHolidayService) Run(ctx context.Context, interval time.Duration) {
+	sync := func() {
+		if err := mhs.Sync(time.Now()); err != nil {
+			fmt.Printf("WARNING: Market holiday sync failed: %v\n", err)
+		}
+	}
+
+	sync()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sync()
+		}
+	}
+}
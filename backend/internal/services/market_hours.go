@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"sync"
 	"time"
 	"networth-dashboard/internal/config"
 )
@@ -10,6 +11,9 @@ import (
 type MarketHoursService struct {
 	config *config.MarketConfig
 	location *time.Location
+
+	mu       sync.RWMutex
+	holidays map[string]string // holiday name by market date (YYYY-MM-DD)
 }
 
 // MarketStatus represents the current market status
@@ -21,6 +25,7 @@ type MarketStatus struct {
 	NextClose     time.Time `json:"next_close"`
 	TimeToNext    string    `json:"time_to_next"`
 	Status        string    `json:"status"` // "open", "closed", "pre_market", "after_hours"
+	Holiday       string    `json:"holiday,omitempty"` // the holiday the market is closed for today
 }
 
 // NewMarketHoursService creates a new market hours service
@@ -31,23 +36,49 @@ func NewMarketHoursService(cfg *config.MarketConfig) (*MarketHoursService, error
 		location = time.UTC
 	}
 
+	// Start from the standard calendar until the stored holidays are loaded
+	holidays := make(map[string]string)
+	year := time.Now().Year()
+	for y := year - 1; y <= year+1; y++ {
+		for _, holiday := range StandardMarketHolidays(y) {
+			holidays[holiday.Date.Format("2006-01-02")] = holiday.Name
+		}
+	}
+
 	return &MarketHoursService{
 		config:   cfg,
 		location: location,
+		holidays: holidays,
 	}, nil
 }
 
+// SetHolidays replaces the market holidays, keyed by date (YYYY-MM-DD)
+func (mhs *MarketHoursService) SetHolidays(holidays map[string]string) {
+	mhs.mu.Lock()
+	defer mhs.mu.Unlock()
+	mhs.holidays = holidays
+}
+
+// Holiday returns the name of the holiday the market is closed for on t's
+// date in the market timezone, if any
+func (mhs *MarketHoursService) Holiday(t time.Time) (string, bool) {
+	mhs.mu.RLock()
+	defer mhs.mu.RUnlock()
+	name, ok := mhs.holidays[t.In(mhs.location).Format("2006-01-02")]
+	return name, ok
+}
+
 // IsMarketOpen returns true if the market is currently open
 func (mhs *MarketHoursService) IsMarketOpen() bool {
 	now := time.Now().In(mhs.location)
 	
-	// Check if it's a weekend
-	if !mhs.config.WeekendTrades && (now.Weekday() == time.Saturday || now.Weekday() == time.Sunday) {
+	// Check if it's a weekend or holiday
+	if !mhs.IsBusinessDay(now) {
 		return false
 	}
 
-	openTime := mhs.getTodayTime(mhs.config.OpenTimeLocal)
-	closeTime := mhs.getTodayTime(mhs.config.CloseTimeLocal)
+	openTime := mhs.getTodayTime(mhs.config.Current().OpenTimeLocal)
+	closeTime := mhs.getTodayTime(mhs.config.Current().CloseTimeLocal)
 
 	return now.After(openTime) && now.Before(closeTime)
 }
@@ -56,10 +87,11 @@ func (mhs *MarketHoursService) IsMarketOpen() bool {
 func (mhs *MarketHoursService) GetMarketStatus() *MarketStatus {
 	now := time.Now().In(mhs.location)
 	
-	openTime := mhs.getTodayTime(mhs.config.OpenTimeLocal)
-	closeTime := mhs.getTodayTime(mhs.config.CloseTimeLocal)
+	openTime := mhs.getTodayTime(mhs.config.Current().OpenTimeLocal)
+	closeTime := mhs.getTodayTime(mhs.config.Current().CloseTimeLocal)
 	
 	isOpen := mhs.IsMarketOpen()
+	holiday, _ := mhs.Holiday(now)
 	
 	var nextOpen, nextClose time.Time
 	var status string
@@ -69,7 +101,11 @@ func (mhs *MarketHoursService) GetMarketStatus() *MarketStatus {
 		nextClose = closeTime
 		nextOpen = mhs.getNextBusinessDay(openTime)
 	} else {
-		if now.Before(openTime) {
+		if !mhs.IsBusinessDay(now) {
+			status = "closed"
+			nextOpen = mhs.getNextBusinessDay(openTime)
+			nextClose = mhs.getNextBusinessDay(closeTime)
+		} else if now.Before(openTime) {
 			status = "pre_market"
 			nextOpen = openTime
 			nextClose = closeTime
@@ -98,6 +134,7 @@ func (mhs *MarketHoursService) GetMarketStatus() *MarketStatus {
 		NextClose:  nextClose,
 		TimeToNext: timeToNext,
 		Status:     status,
+		Holiday:    holiday,
 	}
 }
 
@@ -121,9 +158,11 @@ func (mhs *MarketHoursService) ShouldRefreshPricesWithForce(lastUpdate time.Time
 	
 	cacheAge := now.Sub(lastUpdate)
 	
-	// If market is closed, refresh if cache is very stale (more than 12 hours) OR no cache exists
+	// If market is closed, refresh if cache is very stale (more than 12 hours)
+	// and older than the last session's close, so weekends and holidays
+	// don't make the closing prices stale
 	if !mhs.IsMarketOpen() {
-		return cacheAge > 12*time.Hour
+		return cacheAge > 12*time.Hour && lastUpdate.Before(mhs.lastClose(now))
 	}
 	
 	// If market is open, refresh based on configured interval
@@ -148,33 +187,44 @@ func (mhs *MarketHoursService) GetSecondsUntilNextRefresh(lastUpdate time.Time,
 
 // getTodayTime parses time string (HH:MM) as UTC time and returns today's time
 func (mhs *MarketHoursService) getTodayTime(timeStr string) time.Time {
-	now := time.Now()
-	
+	return mhs.getDayTime(time.Now(), timeStr)
+}
+
+// getDayTime returns the time (HH:MM, UTC) on day's date
+func (mhs *MarketHoursService) getDayTime(day time.Time, timeStr string) time.Time {
 	// Parse the time string
 	t, err := time.Parse("15:04", timeStr)
 	if err != nil {
-		// Fallback to current time if parsing fails
-		return now
+		// Fallback to the given time if parsing fails
+		return day
 	}
 	
-	// Create UTC time for today with the parsed hour and minute
-	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	// Create UTC time for the day with the parsed hour and minute
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
 }
 
 // getNextBusinessDay returns the next business day's time
 func (mhs *MarketHoursService) getNextBusinessDay(baseTime time.Time) time.Time {
 	nextDay := baseTime.AddDate(0, 0, 1)
 	
-	// Skip weekends if weekend trading is disabled
-	if !mhs.config.WeekendTrades {
-		for nextDay.Weekday() == time.Saturday || nextDay.Weekday() == time.Sunday {
-			nextDay = nextDay.AddDate(0, 0, 1)
-		}
+	// Skip weekends and holidays
+	for !mhs.IsBusinessDay(nextDay) {
+		nextDay = nextDay.AddDate(0, 0, 1)
 	}
 	
 	return nextDay
 }
 
+// lastClose returns the close of the most recent session ended by now
+func (mhs *MarketHoursService) lastClose(now time.Time) time.Time {
+	closeTime := mhs.getDayTime(now, mhs.config.Current().CloseTimeLocal)
+	// A year without a business day only happens with a broken calendar
+	for i := 0; i < 366 && (closeTime.After(now) || !mhs.IsBusinessDay(closeTime)); i++ {
+		closeTime = closeTime.AddDate(0, 0, -1)
+	}
+	return closeTime
+}
+
 // formatDuration formats a duration into a human-readable string
 func (mhs *MarketHoursService) formatDuration(d time.Duration) string {
 	if d < 0 {
@@ -190,8 +240,12 @@ func (mhs *MarketHoursService) formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dm", minutes)
 }
 
-// IsBusinessDay returns true if the given time is a business day
+// IsBusinessDay returns true if the given time is a business day: not a
+// market holiday, nor a weekend unless weekend trading is enabled
 func (mhs *MarketHoursService) IsBusinessDay(t time.Time) bool {
+	if _, holiday := mhs.Holiday(t); holiday {
+		return false
+	}
 	if mhs.config.WeekendTrades {
 		return true
 	}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+)
+
+// metalSymbols maps supported metals to their ISO 4217 codes
+var metalSymbols = map[string]string{
+	"gold":      "XAU",
+	"silver":    "XAG",
+	"platinum":  "XPT",
+	"palladium": "XPD",
+}
+
+// ErrUnknownMetal is returned for metals without a spot price
+var ErrUnknownMetal = errors.New("unknown metal; use gold, silver, platinum or palladium")
+
+// MetalPrice is a metal's spot price in USD per troy ounce
+type MetalPrice struct {
+	Metal       string    `json:"metal"`
+	Symbol      string    `json:"symbol"`
+	PriceUSD    float64   `json:"price_usd"` // per troy ounce
+	LastUpdated time.Time `json:"last_updated"`
+	Source      string    `json:"source"`
+	Stale       bool      `json:"stale,omitempty"` // the provider failed and a stored price was used
+}
+
+// MetalsPriceService provides precious metal spot prices. Prices are stored
+// and refetched once they are older than the crypto price refresh interval,
+// since metals, like crypto, trade around the clock.
+type MetalsPriceService struct {
+	db         *sql.DB
+	config     *config.ApiConfig
+	baseURL    string
+	httpClient *http.Client
+	mu         sync.Mutex // serializes refreshes so concurrent valuations share one fetch
+}
+
+// NewMetalsPriceService creates a metals price service
+func NewMetalsPriceService(db *sql.DB, cfg *config.ApiConfig) *MetalsPriceService {
+	return &MetalsPriceService{
+		db:         db,
+		config:     cfg,
+		baseURL:    strings.TrimRight(cfg.MetalsPriceBaseURL, "/"),
+		httpClient: httpclient.New("goldapi", 30*time.Second, cfg.HTTPRetry),
+	}
+}
+
+// GetProviderName returns the name of the spot price provider
+func (ms *MetalsPriceService) GetProviderName() string {
+	return "Gold API"
+}
+
+// RefreshInterval is how long a stored spot price is used before refetching
+func (ms *MetalsPriceService) RefreshInterval() time.Duration {
+	return ms.config.Current().CryptoCacheRefreshInterval
+}
+
+// Metals returns the supported metal names in order
+func (ms *MetalsPriceService) Metals() []string {
+	metals := make([]string, 0, len(metalSymbols))
+	for metal := range metalSymbols {
+		metals = append(metals, metal)
+	}
+	sort.Strings(metals)
+	return metals
+}
+
+// GetSpotPrice returns a metal's spot price, fetching it when the stored
+// price is older than the refresh interval or forceRefresh is set. When the
+// provider fails a stored price is returned, marked stale.
+func (ms *MetalsPriceService) GetSpotPrice(metal string, forceRefresh bool) (*MetalPrice, error) {
+	metal = strings.ToLower(strings.TrimSpace(metal))
+	symbol, ok := metalSymbols[metal]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMetal, metal)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored, err := ms.storedPrice(metal)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil && !forceRefresh && time.Since(stored.LastUpdated) < ms.RefreshInterval() {
+		return stored, nil
+	}
+
+	price, err := ms.fetch(metal, symbol)
+	if err != nil {
+		if stored != nil {
+			fmt.Printf("WARNING: %s request failed for %s, using stored price: %v\n", ms.GetProviderName(), metal, err)
+			stored.Stale = true
+			return stored, nil
+		}
+		return nil, err
+	}
+
+	_, err = ms.db.Exec(`
+		INSERT INTO metal_prices (metal, price_usd, last_updated, source)
+		VALUES ($1, $2, $3, $4)
+	`, price.Metal, price.PriceUSD, price.LastUpdated, price.Source)
+	if err != nil {
+		// Log error but don't fail the request
+		fmt.Printf("Failed to store %s spot price: %v\n", metal, err)
+	}
+	return price, nil
+}
+
+// GetSpotPrices returns the spot price of every supported metal. Metals
+// whose price cannot be had are left out and reported in the error map.
+func (ms *MetalsPriceService) GetSpotPrices(forceRefresh bool) ([]MetalPrice, map[string]string) {
+	prices := make([]MetalPrice, 0, len(metalSymbols))
+	failures := make(map[string]string)
+	for _, metal := range ms.Metals() {
+		price, err := ms.GetSpotPrice(metal, forceRefresh)
+		if err != nil {
+			failures[metal] = err.Error()
+			continue
+		}
+		prices = append(prices, *price)
+	}
+	return prices, failures
+}
+
+// storedPrice returns the latest stored price of a metal, or nil if there is none
+func (ms *MetalsPriceService) storedPrice(metal string) (*MetalPrice, error) {
+	price := MetalPrice{Metal: metal, Symbol: metalSymbols[metal]}
+	err := ms.db.QueryRow(`
+		SELECT price_usd, last_updated, source
+		FROM metal_prices
+		WHERE metal = $1
+		ORDER BY last_updated DESC
+		LIMIT 1
+	`, metal).Scan(&price.PriceUSD, &price.LastUpdated, &price.Source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stored %s price: %w", metal, err)
+	}
+	return &price, nil
+}
+
+// fetch requests a metal's spot price from the provider
+func (ms *MetalsPriceService) fetch(metal, symbol string) (*MetalPrice, error) {
+	req, err := http.NewRequest(http.MethodGet, ms.baseURL+"/price/"+symbol, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := ms.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readProviderError(ms.GetProviderName(), resp)
+	}
+
+	var body struct {
+		Price float64 `json:"price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode API response: %w", err)
+	}
+	if body.Price <= 0 {
+		return nil, fmt.Errorf("no spot price returned for %s", metal)
+	}
+
+	return &MetalPrice{
+		Metal:       metal,
+		Symbol:      symbol,
+		PriceUSD:    body.Price,
+		LastUpdated: time.Now(),
+		Source:      ms.GetProviderName(),
+	}, nil
+}
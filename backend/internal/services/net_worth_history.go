@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Snapshot triggers
+const (
+	// SnapshotTriggerScheduled is the daily snapshot, replaced through the day
+	SnapshotTriggerScheduled = "scheduled"
+	// SnapshotTriggerChange is recorded when a change moves net worth past the threshold
+	SnapshotTriggerChange = "change"
+)
+
+// maxTriggerEventLength bounds the stored description of a triggering event
+const maxTriggerEventLength = 255
+
+// NetWorthSnapshot is the net worth breakdown recorded at one time
+type NetWorthSnapshot struct {
+	Timestamp    time.Time       `json:"timestamp"`
+	Trigger      string          `json:"trigger"`
+	TriggerEvent *string         `json:"trigger_event"`
+	NetWorth     decimal.Decimal `json:"net_worth"`
+	TotalAssets  decimal.Decimal `json:"total_assets"`
+	models.NetWorthBreakdown
+}
+
+// NetWorthRollup is the last net worth of one day or week, with its low and
+// high over the period
+type NetWorthRollup struct {
+	Period           time.Time       `json:"period"`
+	NetWorth         decimal.Decimal `json:"net_worth"`
+	NetWorthLow      decimal.Decimal `json:"net_worth_low"`
+	NetWorthHigh     decimal.Decimal `json:"net_worth_high"`
+	TotalAssets      decimal.Decimal `json:"total_assets"`
+	TotalLiabilities decimal.Decimal `json:"total_liabilities"`
+	Snapshots        int             `json:"snapshots"`
+}
+
+// netWorthRollupViews are the rollup views by interval; weeks start on Monday
+var netWorthRollupViews = map[string]string{
+	"day":  "net_worth_daily",
+	"week": "net_worth_weekly",
+}
+
+// ErrInvalidRollupInterval is returned for a rollup interval other than day or week
+var ErrInvalidRollupInterval = errors.New("interval must be day or week")
+
+// NetWorthHistoryService records daily net worth snapshots, and snapshots
+// between them when net worth changes enough, and looks them up
+type NetWorthHistoryService struct {
+	db *sql.DB
+	// mu keeps concurrent changes from comparing against the same snapshot
+	mu sync.Mutex
+}
+
+// NewNetWorthHistoryService creates a net worth history service
+func NewNetWorthHistoryService(db *sql.DB) *NetWorthHistoryService {
+	return &NetWorthHistoryService{db: db}
+}
+
+// Run records a snapshot now and then every interval until ctx is done
+func (nhs *NetWorthHistoryService) Run(ctx context.Context, interval time.Duration, breakdown func() (models.NetWorthBreakdown, error)) {
+	record := func() {
+		b, err := breakdown()
+		if err == nil {
+			err = nhs.RecordSnapshot(b, time.Now())
+		}
+		if err != nil {
+			fmt.Printf("WARNING: Net worth snapshot failed: %v\n", err)
+		}
+	}
+
+	record()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
+
+// RecordSnapshot replaces the scheduled snapshot for the day of at with
+// breakdown. Snapshots recorded on change are kept.
+func (nhs *NetWorthHistoryService) RecordSnapshot(b models.NetWorthBreakdown, at time.Time) error {
+	tx, err := nhs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	day := dateOnly(at)
+	_, err = tx.Exec(`
+		DELETE FROM net_worth_snapshots
+		WHERE timestamp >= $1 AND timestamp < $2 AND trigger_type = $3
+	`, day, day.AddDate(0, 0, 1), SnapshotTriggerScheduled)
+	if err != nil {
+		return fmt.Errorf("failed to clear net worth snapshot: %w", err)
+	}
+
+	if err := insertSnapshot(tx, b, at, SnapshotTriggerScheduled, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit net worth snapshot: %w", err)
+	}
+	return nil
+}
+
+// RecordChange records a snapshot annotated with event when breakdown's net
+// worth differs from the latest snapshot by more than threshold, reporting
+// whether it did. With no earlier snapshot it always records.
+func (nhs *NetWorthHistoryService) RecordChange(b models.NetWorthBreakdown, event string, at time.Time, threshold float64) (bool, error) {
+	nhs.mu.Lock()
+	defer nhs.mu.Unlock()
+
+	latest, err := nhs.LatestBefore(at)
+	if err != nil {
+		return false, err
+	}
+	if latest != nil && b.NetWorth().Sub(latest.NetWorth).Abs().LessThanOrEqual(decimal.NewFromFloat(threshold)) {
+		return false, nil
+	}
+
+	if len(event) > maxTriggerEventLength {
+		event = event[:maxTriggerEventLength]
+	}
+	if err := insertSnapshot(nhs.db, b, at, SnapshotTriggerChange, &event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// insertSnapshot stores one snapshot of breakdown
+func insertSnapshot(db interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, b models.NetWorthBreakdown, at time.Time, trigger string, event *string) error {
+	_, err := db.Exec(`
+		INSERT INTO net_worth_snapshots (
+			total_assets, total_liabilities, net_worth, vested_equity_value, unvested_equity_value,
+			stock_holdings_value, real_estate_equity, cash_holdings_value, crypto_holdings_value,
+			other_assets_value, private_investments_value, fixed_income_value, pensions_value,
+			insurance_cash_value, timestamp, trigger_type, trigger_event
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`, b.TotalAssets(), b.TotalLiabilities, b.NetWorth(), b.VestedEquityValue, b.UnvestedEquityValue,
+		b.StockHoldingsValue, b.RealEstateEquity, b.CashHoldingsValue, b.CryptoHoldingsValue,
+		b.OtherAssetsValue, b.PrivateInvestmentsValue, b.FixedIncomeValue, b.PensionsValue, b.InsuranceCashValue, at, trigger, event)
+	if err != nil {
+		return fmt.Errorf("failed to record net worth snapshot: %w", err)
+	}
+	return nil
+}
+
+// List returns the snapshots taken from from up to (not including) to, oldest
+// first, optionally only those recorded by trigger
+func (nhs *NetWorthHistoryService) List(from, to time.Time, trigger string) ([]NetWorthSnapshot, error) {
+	rows, err := nhs.db.Query(snapshotColumns+`
+		WHERE timestamp >= $1 AND timestamp < $2 AND ($3 = '' OR trigger_type = $3)
+		ORDER BY timestamp
+	`, from, to, trigger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch net worth history: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []NetWorthSnapshot{}
+	for rows.Next() {
+		s, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan net worth snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch net worth history: %w", err)
+	}
+	return snapshots, nil
+}
+
+// Rollups returns the daily or weekly rollups of the periods starting from
+// from up to (not including) to, oldest first. With TimescaleDB the rollups
+// are continuous aggregates, so long histories are not regrouped on every call.
+func (nhs *NetWorthHistoryService) Rollups(from, to time.Time, interval string) ([]NetWorthRollup, error) {
+	view, ok := netWorthRollupViews[interval]
+	if !ok {
+		return nil, ErrInvalidRollupInterval
+	}
+
+	rows, err := nhs.db.Query(fmt.Sprintf(`
+		SELECT bucket, net_worth, net_worth_low, net_worth_high, total_assets, total_liabilities, snapshots
+		FROM %s
+		WHERE bucket >= $1 AND bucket < $2
+		ORDER BY bucket
+	`, view), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch net worth rollups: %w", err)
+	}
+	defer rows.Close()
+
+	rollups := []NetWorthRollup{}
+	for rows.Next() {
+		var r NetWorthRollup
+		if err := rows.Scan(&r.Period, &r.NetWorth, &r.NetWorthLow, &r.NetWorthHigh,
+			&r.TotalAssets, &r.TotalLiabilities, &r.Snapshots); err != nil {
+			return nil, fmt.Errorf("failed to scan net worth rollup: %w", err)
+		}
+		rollups = append(rollups, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch net worth rollups: %w", err)
+	}
+	return rollups, nil
+}
+
+// LatestBefore returns the last snapshot taken before t, or nil when there is none
+func (nhs *NetWorthHistoryService) LatestBefore(t time.Time) (*NetWorthSnapshot, error) {
+	return nhs.snapshot(`WHERE timestamp < $1 ORDER BY timestamp DESC`, t)
+}
+
+// EarliestFrom returns the first snapshot taken at or after t, or nil when there is none
+func (nhs *NetWorthHistoryService) EarliestFrom(t time.Time) (*NetWorthSnapshot, error) {
+	return nhs.snapshot(`WHERE timestamp >= $1 ORDER BY timestamp`, t)
+}
+
+// snapshotColumns selects the columns scanSnapshot reads. Snapshots taken
+// before cash, crypto, other assets, private investments, fixed income,
+// pensions and insurance were recorded read them as zero.
+const snapshotColumns = `
+	SELECT timestamp, trigger_type, trigger_event, net_worth, total_assets, total_liabilities,
+	       COALESCE(vested_equity_value, 0), COALESCE(unvested_equity_value, 0),
+	       COALESCE(stock_holdings_value, 0), COALESCE(real_estate_equity, 0),
+	       COALESCE(cash_holdings_value, 0), COALESCE(crypto_holdings_value, 0),
+	       COALESCE(other_assets_value, 0), COALESCE(private_investments_value, 0),
+	       COALESCE(fixed_income_value, 0), COALESCE(pensions_value, 0),
+	       COALESCE(insurance_cash_value, 0)
+	FROM net_worth_snapshots
+`
+
+func scanSnapshot(row interface{ Scan(...interface{}) error }) (NetWorthSnapshot, error) {
+	var s NetWorthSnapshot
+	err := row.Scan(&s.Timestamp, &s.Trigger, &s.TriggerEvent, &s.NetWorth, &s.TotalAssets,
+		&s.TotalLiabilities, &s.VestedEquityValue, &s.UnvestedEquityValue,
+		&s.StockHoldingsValue, &s.RealEstateEquity, &s.CashHoldingsValue, &s.CryptoHoldingsValue,
+		&s.OtherAssetsValue, &s.PrivateInvestmentsValue, &s.FixedIncomeValue, &s.PensionsValue,
+		&s.InsuranceCashValue)
+	return s, err
+}
+
+// snapshot returns the first snapshot selected by clause
+func (nhs *NetWorthHistoryService) snapshot(clause string, t time.Time) (*NetWorthSnapshot, error) {
+	s, err := scanSnapshot(nhs.db.QueryRow(snapshotColumns+clause+`
+		LIMIT 1
+	`, t))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch net worth snapshot: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Notification delivery channels. In-app notifications are always stored;
+// channels deliver them elsewhere.
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelTelegram = "telegram"
+	NotificationChannelDiscord  = "discord"
+)
+
+const (
+	// channelTimeout bounds a single chat delivery request
+	channelTimeout = 15 * time.Second
+	// channelAttempts is how many times a chat delivery is tried
+	channelAttempts = 3
+	// discordMaxContent is Discord's message length limit
+	discordMaxContent = 2000
+)
+
+// notificationSeverityEmoji prefixes chat messages so severity shows in
+// push notifications
+var notificationSeverityEmoji = map[string]string{
+	NotificationSeverityInfo:    "ℹ️",
+	NotificationSeverityWarning: "⚠️",
+	NotificationSeverityError:   "🚨",
+}
+
+// NotificationChannel delivers notifications outside the app
+type NotificationChannel interface {
+	Name() string
+	// Send delivers a notification. It may block on network I/O.
+	Send(n Notification) error
+}
+
+// emailChannel queues an email for each notification
+type emailChannel struct {
+	mailer *Mailer
+}
+
+// NewEmailChannel delivers notifications through the mailer's send queue
+func NewEmailChannel(mailer *Mailer) NotificationChannel {
+	return &emailChannel{mailer: mailer}
+}
+
+func (ec *emailChannel) Name() string { return NotificationChannelEmail }
+
+func (ec *emailChannel) Send(n Notification) error {
+	email, err := ec.mailer.Render(EmailTemplateNotification, n)
+	if err != nil {
+		return err
+	}
+	return ec.mailer.Enqueue(email)
+}
+
+// TelegramChannel sends notifications as messages from a Telegram bot
+type TelegramChannel struct {
+	botToken string
+	chatID   string
+	baseURL  string
+	client   *http.Client
+}
+
+// NewTelegramChannel creates a Telegram channel. The chat ID is the user,
+// group or channel the bot posts to.
+func NewTelegramChannel(botToken, chatID string) *TelegramChannel {
+	return &TelegramChannel{
+		botToken: botToken,
+		chatID:   chatID,
+		baseURL:  "https://api.telegram.org",
+		client:   &http.Client{Timeout: channelTimeout},
+	}
+}
+
+func (tc *TelegramChannel) Name() string { return NotificationChannelTelegram }
+
+func (tc *TelegramChannel) Send(n Notification) error {
+	payload := map[string]interface{}{
+		"chat_id": tc.chatID,
+		"text":    fmt.Sprintf("%s %s\n\n%s", notificationSeverityEmoji[n.Severity], n.Title, n.Message),
+	}
+	url := fmt.Sprintf("%s/bot%s/sendMessage", tc.baseURL, tc.botToken)
+	// The URL carries the bot token, so errors name the channel instead
+	return postChannelJSON(tc.client, url, payload, "Telegram")
+}
+
+// DiscordChannel posts notifications to a Discord webhook
+type DiscordChannel struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewDiscordChannel creates a Discord channel for a webhook URL
+func NewDiscordChannel(webhookURL string) *DiscordChannel {
+	return &DiscordChannel{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: channelTimeout},
+	}
+}
+
+func (dc *DiscordChannel) Name() string { return NotificationChannelDiscord }
+
+func (dc *DiscordChannel) Send(n Notification) error {
+	content := fmt.Sprintf("%s **%s**\n%s", notificationSeverityEmoji[n.Severity], n.Title, n.Message)
+	if runes := []rune(content); len(runes) > discordMaxContent {
+		content = string(runes[:discordMaxContent-1]) + "…"
+	}
+	return postChannelJSON(dc.client, dc.webhookURL, map[string]interface{}{"content": content}, "Discord")
+}
+
+// postChannelJSON posts a JSON payload, retrying server errors, rate limits and
+// network failures with a short backoff
+func postChannelJSON(client *http.Client, url string, payload interface{}, service string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", service, err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= channelAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(attempt-1) * 2 * time.Second)
+		}
+
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// The error wraps the URL, which may hold a secret
+			lastErr = fmt.Errorf("%s request failed", service)
+			continue
+		}
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("%s returned %d: %s", service, resp.StatusCode, bytes.TrimSpace(detail))
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return lastErr
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNotificationRuleNotFound is returned when a rule does not exist
+	ErrNotificationRuleNotFound = errors.New("notification rule not found")
+	// ErrDuplicateNotificationRule is returned when a notification type already has a rule
+	ErrDuplicateNotificationRule = errors.New("notification type already has a rule")
+	// ErrInvalidNotificationRule is returned for an unknown channel or severity
+	ErrInvalidNotificationRule = errors.New("invalid notification rule")
+)
+
+// NotificationTypes lists the notification types the backend creates
+var NotificationTypes = []string{
+	"backup_failed",
+	"concentration_risk",
+	"contribution_pending",
+	"employer_match",
+	"i_bond_rate",
+	"insurance_renewal",
+	"monthly_report",
+	"symbol_paused",
+	"trading_window",
+}
+
+// notificationChannelNames are the channels a rule can name
+var notificationChannelNames = map[string]bool{
+	NotificationChannelEmail:    true,
+	NotificationChannelTelegram: true,
+	NotificationChannelDiscord:  true,
+}
+
+// NotificationRule routes one notification type to chosen channels, replacing
+// the channels' default severity thresholds. A rule with no channels keeps the
+// type in-app only.
+type NotificationRule struct {
+	ID               int       `json:"id"`
+	NotificationType string    `json:"notification_type"`
+	Channels         []string  `json:"channels"`
+	MinSeverity      string    `json:"min_severity"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// NotificationRuleInput is the writable part of a notification rule
+type NotificationRuleInput struct {
+	NotificationType string   `json:"notification_type" binding:"required,max=50"`
+	Channels         []string `json:"channels"`
+	MinSeverity      string   `json:"min_severity"` // default info
+}
+
+// normalize validates the input, lower-casing channels and dropping duplicates
+func (input *NotificationRuleInput) normalize() error {
+	input.NotificationType = strings.TrimSpace(input.NotificationType)
+	if input.NotificationType == "" {
+		return fmt.Errorf("%w: notification_type is required", ErrInvalidNotificationRule)
+	}
+
+	if input.MinSeverity == "" {
+		input.MinSeverity = NotificationSeverityInfo
+	}
+	if _, ok := notificationSeverityRank[input.MinSeverity]; !ok {
+		return fmt.Errorf("%w: min_severity must be info, warning or error", ErrInvalidNotificationRule)
+	}
+
+	seen := make(map[string]bool)
+	channels := make([]string, 0, len(input.Channels))
+	for _, channel := range input.Channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if !notificationChannelNames[channel] {
+			return fmt.Errorf("%w: unknown channel %q (use email, telegram or discord)", ErrInvalidNotificationRule, channel)
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	input.Channels = channels
+	return nil
+}
+
+// ListRules returns all notification rules by type
+func (ns *NotificationService) ListRules() ([]NotificationRule, error) {
+	rows, err := ns.db.Query(`
+		SELECT id, notification_type, channels, min_severity, created_at, updated_at
+		FROM notification_rules
+		ORDER BY notification_type
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]NotificationRule, 0)
+	for rows.Next() {
+		var rule NotificationRule
+		if err := rows.Scan(&rule.ID, &rule.NotificationType, database.Array(&rule.Channels), &rule.MinSeverity, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// CreateRule adds a rule for a notification type and returns its ID
+func (ns *NotificationService) CreateRule(input NotificationRuleInput) (int, error) {
+	if err := input.normalize(); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := ns.db.QueryRow(`
+		INSERT INTO notification_rules (notification_type, channels, min_severity)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, input.NotificationType, input.Channels, input.MinSeverity).Scan(&id)
+	if err != nil {
+		return 0, notificationRuleError(err, "failed to create notification rule")
+	}
+	return id, nil
+}
+
+// UpdateRule replaces a rule
+func (ns *NotificationService) UpdateRule(id int, input NotificationRuleInput) error {
+	if err := input.normalize(); err != nil {
+		return err
+	}
+
+	result, err := ns.db.Exec(`
+		UPDATE notification_rules
+		SET notification_type = $1, channels = $2, min_severity = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, input.NotificationType, input.Channels, input.MinSeverity, id)
+	if err != nil {
+		return notificationRuleError(err, "failed to update notification rule")
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotificationRuleNotFound
+	}
+	return nil
+}
+
+// DeleteRule removes a rule, returning its type to the default routing
+func (ns *NotificationService) DeleteRule(id int) error {
+	result, err := ns.db.Exec(`DELETE FROM notification_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification rule: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotificationRuleNotFound
+	}
+	return nil
+}
+
+// ruleForType returns the rule for a notification type, or nil when it has none
+func (ns *NotificationService) ruleForType(notificationType string) (*NotificationRule, error) {
+	var rule NotificationRule
+	err := ns.db.QueryRow(`
+		SELECT id, notification_type, channels, min_severity, created_at, updated_at
+		FROM notification_rules
+		WHERE notification_type = $1
+	`, notificationType).Scan(&rule.ID, &rule.NotificationType, database.Array(&rule.Channels), &rule.MinSeverity, &rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// notificationRuleError maps a duplicate notification type to ErrDuplicateNotificationRule
+func notificationRuleError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicateNotificationRule
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
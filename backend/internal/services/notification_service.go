@@ -0,0 +1,228 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Notification severities
+const (
+	NotificationSeverityInfo    = "info"
+	NotificationSeverityWarning = "warning"
+	NotificationSeverityError   = "error"
+)
+
+// ErrNotificationNotFound is returned when a notification does not exist
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Notification is an in-app message about something that needs the user's attention
+type Notification struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	Severity  string    `json:"severity"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// notificationSeverityRank orders severities for channel thresholds
+var notificationSeverityRank = map[string]int{
+	NotificationSeverityInfo:    1,
+	NotificationSeverityWarning: 2,
+	NotificationSeverityError:   3,
+}
+
+// notificationRoute is a channel and the lowest severity it receives by default
+type notificationRoute struct {
+	channel     NotificationChannel
+	minSeverity string
+}
+
+// NotificationService stores and lists in-app notifications and delivers them
+// through the configured channels
+type NotificationService struct {
+	db       *sql.DB
+	channels map[string]notificationRoute
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(db *sql.DB) *NotificationService {
+	return &NotificationService{db: db, channels: make(map[string]notificationRoute)}
+}
+
+// NotificationSeverityNone as a channel threshold delivers only the
+// notification types that a rule routes to the channel
+const NotificationSeverityNone = "none"
+
+// AddChannel delivers new notifications of minSeverity or higher through
+// channel, unless a rule routes their type differently. An unknown severity
+// delivers warnings and errors.
+func (ns *NotificationService) AddChannel(channel NotificationChannel, minSeverity string) {
+	if _, ok := notificationSeverityRank[minSeverity]; !ok && minSeverity != NotificationSeverityNone {
+		minSeverity = NotificationSeverityWarning
+	}
+	ns.channels[channel.Name()] = notificationRoute{channel: channel, minSeverity: minSeverity}
+}
+
+// NotificationChannelStatus is a configured channel and its default threshold
+type NotificationChannelStatus struct {
+	Name        string `json:"name"`
+	MinSeverity string `json:"min_severity"`
+}
+
+// Channels lists the configured channels by name
+func (ns *NotificationService) Channels() []NotificationChannelStatus {
+	channels := make([]NotificationChannelStatus, 0, len(ns.channels))
+	for name, route := range ns.channels {
+		channels = append(channels, NotificationChannelStatus{Name: name, MinSeverity: route.minSeverity})
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels
+}
+
+// Create stores a new unread notification and delivers it in the background
+// through the channels its type is routed to. Delivery failures are logged and
+// do not fail the notification.
+func (ns *NotificationService) Create(notificationType, severity, title, message string) error {
+	notification, err := ns.store(notificationType, severity, title, message)
+	if err != nil {
+		return err
+	}
+
+	channels, err := ns.route(notification)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to route notification %q: %v\n", title, err)
+		return nil
+	}
+	for _, channel := range channels {
+		go func(channel NotificationChannel) {
+			if err := channel.Send(notification); err != nil {
+				fmt.Printf("WARNING: Failed to send notification %q to %s: %v\n", title, channel.Name(), err)
+			}
+		}(channel)
+	}
+	return nil
+}
+
+// ErrUnknownNotificationChannel is returned for a channel that is not configured
+var ErrUnknownNotificationChannel = errors.New("notification channel is not configured")
+
+// SendTest sends a test notification through a channel now and returns its error
+func (ns *NotificationService) SendTest(name string) error {
+	route, ok := ns.channels[name]
+	if !ok {
+		return ErrUnknownNotificationChannel
+	}
+	return route.channel.Send(Notification{
+		Type:      "test",
+		Severity:  NotificationSeverityInfo,
+		Title:     "Net Worth Dashboard test notification",
+		Message:   "If you can read this, the " + name + " channel is configured correctly.",
+		CreatedAt: time.Now(),
+	})
+}
+
+// route returns the channels a notification goes to: those named by the rule
+// for its type when there is one, otherwise every channel whose default
+// threshold it meets
+func (ns *NotificationService) route(n Notification) ([]NotificationChannel, error) {
+	if len(ns.channels) == 0 {
+		return nil, nil
+	}
+
+	rule, err := ns.ruleForType(n.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []NotificationChannel
+	if rule != nil {
+		if notificationSeverityRank[n.Severity] < notificationSeverityRank[rule.MinSeverity] {
+			return nil, nil
+		}
+		for _, name := range rule.Channels {
+			if route, ok := ns.channels[name]; ok {
+				channels = append(channels, route.channel)
+			}
+		}
+		return channels, nil
+	}
+
+	for _, route := range ns.channels {
+		if route.minSeverity == NotificationSeverityNone {
+			continue
+		}
+		if notificationSeverityRank[n.Severity] >= notificationSeverityRank[route.minSeverity] {
+			channels = append(channels, route.channel)
+		}
+	}
+	return channels, nil
+}
+
+// store saves a notification without delivering it
+func (ns *NotificationService) store(notificationType, severity, title, message string) (Notification, error) {
+	query := `
+		INSERT INTO notifications (type, severity, title, message, read, created_at)
+		VALUES ($1, $2, $3, $4, false, $5)
+		RETURNING id
+	`
+
+	n := Notification{Type: notificationType, Severity: severity, Title: title, Message: message, CreatedAt: time.Now()}
+	if err := ns.db.QueryRow(query, notificationType, severity, title, message, n.CreatedAt).Scan(&n.ID); err != nil {
+		return Notification{}, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	fmt.Printf("INFO: Notification created [%s] %s: %s\n", severity, title, message)
+	return n, nil
+}
+
+// List returns notifications, newest first
+func (ns *NotificationService) List(unreadOnly bool, limit int) ([]Notification, error) {
+	query := `
+		SELECT id, type, COALESCE(severity, 'info'), title, COALESCE(message, ''), read, created_at
+		FROM notifications
+	`
+	if unreadOnly {
+		query += " WHERE read = false"
+	}
+	query += " ORDER BY created_at DESC LIMIT $1"
+
+	rows, err := ns.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(&n.ID, &n.Type, &n.Severity, &n.Title, &n.Message, &n.Read, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkRead marks a notification as read
+func (ns *NotificationService) MarkRead(id int) error {
+	result, err := ns.db.Exec(`UPDATE notifications SET read = true WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
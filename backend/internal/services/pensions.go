@@ -0,0 +1,135 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrPensionNotFound is returned when a pension does not exist
+var ErrPensionNotFound = errors.New("pension not found")
+
+// PensionPortfolio is every pension and annuity, soonest start first, with
+// the present value of all of them and of those included in net worth
+type PensionPortfolio struct {
+	Pensions             []models.Pension `json:"pensions"`
+	TotalPresentValue    decimal.Decimal  `json:"total_present_value"`
+	IncludedPresentValue decimal.Decimal  `json:"included_present_value"`
+	// MonthlyIncome is the current benefit of the pensions being paid now
+	MonthlyIncome decimal.Decimal `json:"monthly_income"`
+}
+
+// PensionService lists pensions and annuities with their present values
+type PensionService struct {
+	db *sql.DB
+}
+
+// NewPensionService creates a pension service
+func NewPensionService(db *sql.DB) *PensionService {
+	return &PensionService{db: db}
+}
+
+// List returns every pension, soonest start first, with their totals as of now
+func (ps *PensionService) List(now time.Time) (*PensionPortfolio, error) {
+	pensions, err := ps.pensions(0, now)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &PensionPortfolio{Pensions: pensions}
+	for _, p := range pensions {
+		portfolio.TotalPresentValue = portfolio.TotalPresentValue.Add(p.PresentValue)
+		if p.IncludeInNetWorth {
+			portfolio.IncludedPresentValue = portfolio.IncludedPresentValue.Add(p.PresentValue)
+		}
+		if p.InPayment {
+			portfolio.MonthlyIncome = portfolio.MonthlyIncome.Add(p.CurrentMonthlyBenefit)
+		}
+	}
+	return portfolio, nil
+}
+
+// Get returns a pension as of now
+func (ps *PensionService) Get(id int, now time.Time) (*models.Pension, error) {
+	pensions, err := ps.pensions(id, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(pensions) == 0 {
+		return nil, ErrPensionNotFound
+	}
+	return &pensions[0], nil
+}
+
+// SetIncluded sets whether a pension counts toward net worth
+func (ps *PensionService) SetIncluded(id int, include bool) error {
+	result, err := ps.db.Exec(`
+		UPDATE pensions SET include_in_net_worth = $2, last_updated = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, include)
+	if err != nil {
+		return fmt.Errorf("failed to update pension: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	} else if n == 0 {
+		return ErrPensionNotFound
+	}
+	return nil
+}
+
+// Delete removes a pension
+func (ps *PensionService) Delete(id int) error {
+	result, err := ps.db.Exec(`DELETE FROM pensions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pension: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrPensionNotFound
+	}
+	return nil
+}
+
+// pensions returns the pension with id, or every pension when id is 0,
+// soonest start first
+func (ps *PensionService) pensions(id int, now time.Time) ([]models.Pension, error) {
+	rows, err := ps.db.Query(`
+		SELECT id, account_id, institution_name, plan_name, pension_type, birth_date,
+		       monthly_benefit, start_age, end_age, cola_rate, discount_rate,
+		       include_in_net_worth, notes, created_at, last_updated
+		FROM pensions
+		WHERE $1 = 0 OR id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pensions: %w", err)
+	}
+	defer rows.Close()
+
+	pensions := []models.Pension{}
+	for rows.Next() {
+		var p models.Pension
+		err := rows.Scan(&p.ID, &p.AccountID, &p.InstitutionName, &p.PlanName, &p.PensionType, &p.BirthDate,
+			&p.MonthlyBenefit, &p.StartAge, &p.EndAge, &p.ColaRate, &p.DiscountRate,
+			&p.IncludeInNetWorth, &p.Notes, &p.CreatedAt, &p.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pension: %w", err)
+		}
+		p.Value(now)
+		pensions = append(pensions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Pensions are ordered by id, so ties in start date stay in id order
+	sort.SliceStable(pensions, func(i, j int) bool { return pensions[i].StartDate.Before(pensions[j].StartDate) })
+	return pensions, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/telemetry"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// twelveDataBatchSize is the most symbols Twelve Data accepts in one quote request
+const twelveDataBatchSize = 120
+
+// BatchQuote is the outcome of one symbol in a batch price lookup
+type BatchQuote struct {
+	Price float64
+	Err   error
+}
+
+// BatchPriceProvider is a price provider that quotes many symbols in one request
+type BatchPriceProvider interface {
+	GetBatchPrices(symbols []string, forceRefresh bool) map[string]BatchQuote
+}
+
+// GetBatchPricesContext quotes symbols through the provider's batch API,
+// traced as a span under ctx. It returns false when the provider has no batch
+// quotes, so callers fall back to fetching one symbol at a time.
+func (ps *PriceService) GetBatchPricesContext(ctx context.Context, symbols []string, forceRefresh bool) (map[string]BatchQuote, bool) {
+	provider, ok := ps.provider.(BatchPriceProvider)
+	if !ok {
+		return nil, false
+	}
+	_, span := telemetry.StartSpan(ctx, "price.get_batch",
+		telemetry.ProviderAttribute.String(ps.GetProviderName()),
+		attribute.Int("symbols", len(symbols)),
+		attribute.Bool("force_refresh", forceRefresh),
+	)
+	quotes := provider.GetBatchPrices(symbols, forceRefresh)
+	telemetry.EndSpan(span, nil)
+	return quotes, true
+}
+
+// GetBatchPrices quotes symbols from the Twelve Data quote endpoint, as many
+// per request as the rate limit has credits left. Fresh cached prices are
+// used without a call and every fetched price is cached in one batched write.
+// Twelve Data charges a credit per symbol, so the one cached row per symbol
+// keeps the rate limit count in step. Symbols past the rate limit get their
+// cached price, or a rate limit error without one.
+func (td *TwelveDataPriceProvider) GetBatchPrices(symbols []string, forceRefresh bool) map[string]BatchQuote {
+	quotes := make(map[string]BatchQuote, len(symbols))
+	cached, err := td.getCachedPrices(symbols)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to load cached prices for batch quote: %v\n", err)
+		cached = map[string]StockPrice{}
+	}
+
+	// fallback answers a symbol with its cached price when the API can't
+	fallback := func(symbol string, err error) {
+		if price, ok := cached[symbol]; ok && price.Price.IsPositive() {
+			quotes[symbol] = BatchQuote{Price: price.Price.InexactFloat64()}
+			return
+		}
+		quotes[symbol] = BatchQuote{Err: err}
+	}
+
+	var due []string
+	for _, symbol := range symbols {
+		price, ok := cached[symbol]
+		if ok && !forceRefresh && !td.marketService.ShouldRefreshPrices(price.Timestamp, td.config.Current().CacheRefreshInterval) {
+			quotes[symbol] = BatchQuote{Price: price.Price.InexactFloat64()}
+			continue
+		}
+		due = append(due, symbol)
+	}
+
+	// Only as many symbols as there are credits left this minute and today go out
+	cfg := td.config.Current()
+	credits := min(cfg.TwelveDataDailyLimit-td.getAPICallCount(time.Now().Format("2006-01-02")),
+		cfg.TwelveDataRateLimit-td.getAPICallCountSince(time.Now().Add(-time.Minute)))
+	credits = max(credits, 0)
+	for _, symbol := range due[min(credits, len(due)):] {
+		fallback(symbol, fmt.Errorf("rate limit exceeded and no cached price available for %s", symbol))
+	}
+	due = due[:min(credits, len(due))]
+
+	var fetched []StockPrice
+	for start := 0; start < len(due); start += twelveDataBatchSize {
+		batch := due[start:min(start+twelveDataBatchSize, len(due))]
+		prices, errs, err := td.fetchQuotes(batch)
+		if err != nil {
+			fmt.Printf("ERROR: Twelve Data batch quote failed for %d symbols: %v\n", len(batch), err)
+		}
+		now := time.Now()
+		for _, symbol := range batch {
+			if price, ok := prices[symbol]; ok {
+				quotes[symbol] = BatchQuote{Price: price}
+				fetched = append(fetched, StockPrice{Symbol: symbol, Price: decimal.NewFromFloat(price), Timestamp: now, Source: "twelvedata"})
+				continue
+			}
+			switch {
+			case errs[symbol] != nil:
+				quotes[symbol] = BatchQuote{Err: errs[symbol]}
+			case err != nil:
+				fallback(symbol, fmt.Errorf("failed to fetch price from Twelve Data and no cached price available for %s: %w", symbol, err))
+			default:
+				fallback(symbol, fmt.Errorf("no price data found for symbol %s and no cached price available", symbol))
+			}
+		}
+	}
+
+	if _, err := InsertStockPrices(td.db, fetched); err != nil {
+		fmt.Printf("ERROR: Failed to cache %d batch quoted prices: %v\n", len(fetched), err)
+	}
+	return quotes
+}
+
+// fetchQuotes calls the Twelve Data quote endpoint for symbols at once. It
+// returns the prices found and the symbols Twelve Data rejected; err means
+// the whole request failed.
+func (td *TwelveDataPriceProvider) fetchQuotes(symbols []string) (map[string]float64, map[string]error, error) {
+	url := fmt.Sprintf("%s/quote?symbol=%s&apikey=%s", td.baseURL, strings.Join(symbols, ","), td.apiKey)
+	fmt.Printf("INFO: Making Twelve Data batch quote call for %d symbols\n", len(symbols))
+	resp, err := td.client.Get(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("Twelve Data API returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var status twelveDataStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse Twelve Data response: %w", err)
+	}
+	if err := status.err(); err != nil {
+		return nil, nil, err
+	}
+
+	// One symbol comes back as a bare quote, several keyed by symbol
+	raw := map[string]json.RawMessage{symbols[0]: body}
+	if len(symbols) > 1 {
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse Twelve Data response: %w", err)
+		}
+	}
+
+	prices := make(map[string]float64, len(symbols))
+	errs := make(map[string]error)
+	for symbol, message := range raw {
+		symbol = strings.ToUpper(symbol)
+		var quote struct {
+			twelveDataStatus
+			TwelveDataQuoteResponse
+		}
+		if err := json.Unmarshal(message, &quote); err != nil {
+			errs[symbol] = fmt.Errorf("failed to parse Twelve Data response for %s: %w", symbol, err)
+			continue
+		}
+		if err := quote.err(); err != nil {
+			errs[symbol] = fmt.Errorf("Twelve Data error for %s: %w", symbol, err)
+			continue
+		}
+		price := 0.0
+		if _, err := fmt.Sscanf(quote.Close, "%f", &price); err != nil || price <= 0 {
+			errs[symbol] = fmt.Errorf("failed to parse price %q for symbol %s", quote.Close, symbol)
+			continue
+		}
+		prices[symbol] = price
+	}
+	return prices, errs, nil
+}
+
+// twelveDataStatus is the error envelope of a Twelve Data response
+type twelveDataStatus struct {
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s twelveDataStatus) err() error {
+	if s.Status != "error" {
+		return nil
+	}
+	if s.Code == http.StatusTooManyRequests {
+		return fmt.Errorf("rate limit exceeded: %s", s.Message)
+	}
+	return fmt.Errorf("%s (code %d)", s.Message, s.Code)
+}
+
+// getCachedPrices returns the latest cached price of each symbol in one query
+func (td *TwelveDataPriceProvider) getCachedPrices(symbols []string) (map[string]StockPrice, error) {
+	rows, err := td.db.Query(`
+		SELECT symbol, price, timestamp
+		FROM (
+			SELECT symbol, price, timestamp, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY timestamp DESC) AS position
+			FROM stock_prices
+			WHERE `+database.DialectOf(td.db).InArray("symbol", "$1")+`
+		) ranked
+		WHERE position = 1
+	`, symbols)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cached := make(map[string]StockPrice, len(symbols))
+	for rows.Next() {
+		var price StockPrice
+		if err := rows.Scan(&price.Symbol, &price.Price, &price.Timestamp); err != nil {
+			return nil, err
+		}
+		cached[price.Symbol] = price
+	}
+	return cached, rows.Err()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"networth-dashboard/internal/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// arrayConverter passes slices through to sqlmock the way pgx takes them as arrays
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	switch v.(type) {
+	case []string, []float64, []time.Time:
+		return v, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestTwelveDataGetBatchPrices(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("symbol"))
+		fmt.Fprint(w, `{
+			"AAPL": {"symbol": "AAPL", "close": "190.50"},
+			"MSFT": {"symbol": "MSFT", "close": "410.25"},
+			"BAD": {"code": 404, "message": "symbol not found", "status": "error"}
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewTwelveDataPriceProvider("key", db, nil, &config.ApiConfig{
+		TwelveDataDailyLimit: 800,
+		TwelveDataRateLimit:  3,
+	})
+	provider.baseURL = server.URL
+
+	symbols := []string{"AAPL", "MSFT", "BAD", "TSLA", "NVDA"}
+	mock.ExpectQuery(`SELECT symbol, price, timestamp`).
+		WithArgs(symbols).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "price", "timestamp"}).
+			AddRow("NVDA", 120.0, time.Now().Add(-time.Hour)))
+	mock.ExpectQuery(`DATE\(timestamp\) = \$1`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`timestamp > \$1`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO stock_prices`).
+		WithArgs([]string{"AAPL", "MSFT"}, []float64{190.50, 410.25}, sqlmock.AnyArg(), []string{"twelvedata", "twelvedata"}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	quotes := provider.GetBatchPrices(symbols, true)
+
+	if len(requests) != 1 || requests[0] != "AAPL,MSFT,BAD" {
+		t.Errorf("quote requests = %q, want one request for the 3 symbols the rate limit allows", requests)
+	}
+	for symbol, want := range map[string]float64{"AAPL": 190.50, "MSFT": 410.25, "NVDA": 120} {
+		if got := quotes[symbol]; got.Err != nil || got.Price != want {
+			t.Errorf("%s = %+v, want price %.2f", symbol, got, want)
+		}
+	}
+	if err := quotes["BAD"].Err; err == nil || !strings.Contains(err.Error(), "symbol not found") {
+		t.Errorf("BAD error = %v, want the Twelve Data rejection", err)
+	}
+	if err := quotes["TSLA"].Err; err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("TSLA error = %v, want a rate limit error past the credits", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTwelveDataGetBatchPricesSingleSymbol(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// One symbol comes back as a bare quote rather than keyed by symbol
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"symbol": "AAPL", "close": "190.50"}`)
+	}))
+	defer server.Close()
+
+	provider := NewTwelveDataPriceProvider("key", db, nil, &config.ApiConfig{
+		TwelveDataDailyLimit: 800,
+		TwelveDataRateLimit:  8,
+	})
+	provider.baseURL = server.URL
+
+	mock.ExpectQuery(`SELECT symbol, price, timestamp`).WillReturnRows(sqlmock.NewRows([]string{"symbol", "price", "timestamp"}))
+	mock.ExpectQuery(`DATE\(timestamp\) = \$1`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`timestamp > \$1`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO stock_prices`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	quotes := provider.GetBatchPrices([]string{"AAPL"}, true)
+	if got := quotes["AAPL"]; got.Err != nil || got.Price != 190.50 {
+		t.Errorf("AAPL = %+v, want 190.50", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPriceServiceBatchFallsBackWithoutBatchSupport(t *testing.T) {
+	if _, ok := NewPriceService().GetBatchPricesContext(context.Background(), []string{"AAPL"}, false); ok {
+		t.Error("the mock provider has no batch quotes, want single fetches")
+	}
+}
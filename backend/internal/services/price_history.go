@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+// stockPriceBatchSize is how many prices InsertStockPrices writes per statement
+const stockPriceBatchSize = 1000
+
+// dailyCloseSource marks stock prices recorded from a provider's daily history
+// rather than a live quote
+const dailyCloseSource = "daily_close"
+
+var (
+	// ErrDailyHistoryUnsupported is returned when the price provider has no
+	// daily price history
+	ErrDailyHistoryUnsupported = errors.New("the price provider does not support daily price history")
+	// ErrDailyHistoryRateLimited is returned when the provider's rate limit is
+	// spent
+	ErrDailyHistoryRateLimited = errors.New("daily history rate limit exceeded")
+)
+
+// StockPrice is one recorded price of a symbol
+type StockPrice struct {
+	Symbol    string
+	Price     decimal.Decimal
+	Timestamp time.Time
+	Source    string
+}
+
+// InsertStockPrices records prices in batches of stockPriceBatchSize, one
+// statement per batch rather than one per price. Prices already recorded for
+// a symbol at the same timestamp are skipped. It returns how many were
+// inserted.
+func InsertStockPrices(db *sql.DB, prices []StockPrice) (int, error) {
+	// WHERE true keeps SQLite from reading ON CONFLICT as a join constraint
+	query := `
+		INSERT INTO stock_prices (symbol, price, timestamp, source)
+		SELECT symbol, price, timestamp, source FROM ` + database.DialectOf(db).Unnest("p",
+		database.ArrayColumn{Param: "$1", Type: "text", Name: "symbol"},
+		database.ArrayColumn{Param: "$2", Type: "numeric", Name: "price"},
+		database.ArrayColumn{Param: "$3", Type: "timestamp", Name: "timestamp"},
+		database.ArrayColumn{Param: "$4", Type: "text", Name: "source"}) + `
+		WHERE true
+		ON CONFLICT (symbol, timestamp) DO NOTHING
+	`
+	inserted := 0
+	for start := 0; start < len(prices); start += stockPriceBatchSize {
+		batch := prices[start:min(start+stockPriceBatchSize, len(prices))]
+		symbols := make([]string, len(batch))
+		values := make([]float64, len(batch))
+		timestamps := make([]time.Time, len(batch))
+		sources := make([]string, len(batch))
+		for i, price := range batch {
+			// Prices are quoted as floats, so converting back is exact
+			symbols[i], values[i], timestamps[i], sources[i] = price.Symbol, price.Price.InexactFloat64(), price.Timestamp, price.Source
+		}
+
+		result, err := db.Exec(query, symbols, values, timestamps, sources)
+		if err != nil {
+			return inserted, fmt.Errorf("failed to insert stock prices: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return inserted, fmt.Errorf("failed to check inserted stock prices: %w", err)
+		}
+		inserted += int(rows)
+	}
+	return inserted, nil
+}
+
+// DailyHistoryProvider is a price provider with daily closing prices
+type DailyHistoryProvider interface {
+	GetDailyCloses(symbol string, from time.Time) ([]PriceBar, error)
+}
+
+// GetDailyCloses returns the provider's daily bars for symbol from the given
+// date, oldest first
+func (ps *PriceService) GetDailyCloses(symbol string, from time.Time) ([]PriceBar, error) {
+	provider, ok := ps.provider.(DailyHistoryProvider)
+	if !ok {
+		return nil, ErrDailyHistoryUnsupported
+	}
+	bars, err := provider.GetDailyCloses(symbol, from)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, nil
+}
+
+// PriceBackfillResult reports a price history backfill
+type PriceBackfillResult struct {
+	From     string            `json:"from"`
+	Symbols  int               `json:"symbols"`
+	Fetched  int               `json:"fetched"`
+	Inserted int               `json:"inserted"`
+	Failed   map[string]string `json:"failed"`
+	// Deferred symbols were not fetched because the rate limit ran out
+	Deferred []string `json:"deferred"`
+}
+
+// PriceHistoryService backfills the stock price history from the price
+// provider's daily closes, so charts and returns reach back before prices
+// were first refreshed
+type PriceHistoryService struct {
+	db     *sql.DB
+	prices *PriceService
+}
+
+// NewPriceHistoryService creates a new price history service
+func NewPriceHistoryService(db *sql.DB, prices *PriceService) *PriceHistoryService {
+	return &PriceHistoryService{db: db, prices: prices}
+}
+
+// Backfill fetches the daily closes of each symbol since from and records
+// the ones before today in one batched write. Each close is recorded at 4pm
+// on its day. Symbols the provider fails on are reported and skipped; once
+// the rate limit runs out the rest are deferred.
+func (phs *PriceHistoryService) Backfill(ctx context.Context, symbols []string, from time.Time) (*PriceBackfillResult, error) {
+	result := &PriceBackfillResult{
+		From:     dateOnly(from).Format("2006-01-02"),
+		Symbols:  len(symbols),
+		Failed:   map[string]string{},
+		Deferred: []string{},
+	}
+	first, today := result.From, time.Now().Format("2006-01-02")
+
+	var prices []StockPrice
+	for i, symbol := range symbols {
+		if ctx.Err() != nil {
+			result.Deferred = append(result.Deferred, symbols[i:]...)
+			break
+		}
+
+		bars, err := phs.prices.GetDailyCloses(symbol, from)
+		if errors.Is(err, ErrDailyHistoryUnsupported) {
+			return nil, err
+		}
+		if errors.Is(err, ErrDailyHistoryRateLimited) {
+			result.Deferred = append(result.Deferred, symbols[i:]...)
+			break
+		}
+		if err != nil {
+			result.Failed[symbol] = err.Error()
+			continue
+		}
+
+		for _, bar := range bars {
+			if day := bar.Time.Format("2006-01-02"); day < first || day >= today {
+				continue
+			}
+			prices = append(prices, StockPrice{
+				Symbol:    strings.ToUpper(symbol),
+				Price:     decimal.NewFromFloat(bar.Close),
+				Timestamp: time.Date(bar.Time.Year(), bar.Time.Month(), bar.Time.Day(), 16, 0, 0, 0, time.UTC),
+				Source:    dailyCloseSource,
+			})
+		}
+	}
+
+	result.Fetched = len(prices)
+	inserted, err := InsertStockPrices(phs.db, prices)
+	result.Inserted = inserted
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// GetDailyCloses fetches daily bars since from from the Twelve Data
+// time_series endpoint
+func (td *TwelveDataPriceProvider) GetDailyCloses(symbol string, from time.Time) ([]PriceBar, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !td.canMakeAPICall() {
+		return nil, ErrDailyHistoryRateLimited
+	}
+
+	url := fmt.Sprintf("%s/time_series?symbol=%s&interval=1day&start_date=%s&outputsize=5000&apikey=%s",
+		td.baseURL, symbol, from.Format("2006-01-02"), td.apiKey)
+	resp, err := td.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Twelve Data daily history request failed for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+	recordIntradayCall(td.db, symbol, "twelvedata")
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Twelve Data daily history API returned status %d for %s", resp.StatusCode, symbol)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Twelve Data daily history response for %s: %w", symbol, err)
+	}
+
+	var response struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+		Values  []struct {
+			Datetime string `json:"datetime"`
+			Open     string `json:"open"`
+			High     string `json:"high"`
+			Low      string `json:"low"`
+			Close    string `json:"close"`
+			Volume   string `json:"volume"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Twelve Data daily history response for %s: %w", symbol, err)
+	}
+	if response.Code == http.StatusTooManyRequests {
+		return nil, ErrDailyHistoryRateLimited
+	}
+	if response.Status == "error" {
+		return nil, fmt.Errorf("Twelve Data daily history error for %s: %s", symbol, response.Message)
+	}
+
+	bars := make([]PriceBar, 0, len(response.Values))
+	for _, value := range response.Values {
+		day, err := time.Parse("2006-01-02", value.Datetime)
+		if err != nil {
+			continue
+		}
+		if bar, ok := parseBar(day, value.Open, value.High, value.Low, value.Close, value.Volume); ok {
+			bars = append(bars, bar)
+		}
+	}
+	return bars, nil
+}
+
+// GetDailyCloses fetches daily bars from the Alpha Vantage TIME_SERIES_DAILY
+// endpoint. The free tier only returns the last 100 trading days, so older
+// dates are left out.
+func (av *AlphaVantagePriceProvider) GetDailyCloses(symbol string, from time.Time) ([]PriceBar, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !av.canMakeAPICall() {
+		return nil, ErrDailyHistoryRateLimited
+	}
+
+	url := fmt.Sprintf("%s?function=TIME_SERIES_DAILY&symbol=%s&outputsize=compact&apikey=%s", av.baseURL, symbol, av.apiKey)
+	resp, err := av.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Alpha Vantage daily history request failed for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+	recordIntradayCall(av.db, symbol, "alphavantage")
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Alpha Vantage daily history API returned status %d for %s", resp.StatusCode, symbol)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Alpha Vantage daily history response for %s: %w", symbol, err)
+	}
+
+	var response struct {
+		Note       string `json:"Note"`
+		Info       string `json:"Information"`
+		Error      string `json:"Error Message"`
+		TimeSeries map[string]struct {
+			Open   string `json:"1. open"`
+			High   string `json:"2. high"`
+			Low    string `json:"3. low"`
+			Close  string `json:"4. close"`
+			Volume string `json:"5. volume"`
+		} `json:"Time Series (Daily)"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Alpha Vantage daily history response for %s: %w", symbol, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("Alpha Vantage daily history error for %s: %s", symbol, response.Error)
+	}
+	if len(response.TimeSeries) == 0 && (response.Note != "" || response.Info != "") {
+		return nil, ErrDailyHistoryRateLimited
+	}
+
+	bars := make([]PriceBar, 0, len(response.TimeSeries))
+	for date, value := range response.TimeSeries {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil || date < from.Format("2006-01-02") {
+			continue
+		}
+		if bar, ok := parseBar(day, value.Open, value.High, value.Low, value.Close, value.Volume); ok {
+			bars = append(bars, bar)
+		}
+	}
+	return bars, nil
+}
+
+// GetDailyCloses simulates a random walk of weekday closes from from up to
+// yesterday, ending near the current price
+func (m *MockPriceProvider) GetDailyCloses(symbol string, from time.Time) ([]PriceBar, error) {
+	price, err := m.GetCurrentPrice(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	bars := []PriceBar{}
+	start := dateOnly(from)
+	for day := dateOnly(time.Now()).AddDate(0, 0, -1); !day.Before(start); day = day.AddDate(0, 0, -1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		price *= 1 + (m.rand.Float64()-0.5)*0.03
+		value := roundCents(price)
+		bars = append(bars, PriceBar{Time: day, Open: value, High: value, Low: value, Close: value})
+	}
+	return bars, nil
+}
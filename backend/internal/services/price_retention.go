@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/database"
+)
+
+// foldStockPrices folds stock prices before $1 into stock_prices_daily,
+// leaving each symbol's latest price for the price cache; its day is folded
+// once a newer price arrives
+func foldStockPrices(dialect database.Dialect) string {
+	return fmt.Sprintf(`
+		INSERT INTO stock_prices_daily AS d (symbol, price_date, open, high, low, close, opened_at, closed_at)
+		SELECT symbol, %[1]s, %[2]s, MAX(price), MIN(price), %[3]s, MIN(timestamp), MAX(timestamp)
+		FROM stock_prices sp
+		WHERE timestamp < $1
+		GROUP BY symbol, %[1]s
+		HAVING MIN(timestamp) < (SELECT MAX(l.timestamp) FROM stock_prices l WHERE l.symbol = sp.symbol)
+		ON CONFLICT (symbol, price_date) DO UPDATE SET
+			open = CASE WHEN EXCLUDED.opened_at < d.opened_at THEN EXCLUDED.open ELSE d.open END,
+			high = GREATEST(d.high, EXCLUDED.high),
+			low = LEAST(d.low, EXCLUDED.low),
+			close = CASE WHEN EXCLUDED.closed_at > d.closed_at THEN EXCLUDED.close ELSE d.close END,
+			opened_at = LEAST(d.opened_at, EXCLUDED.opened_at),
+			closed_at = GREATEST(d.closed_at, EXCLUDED.closed_at)
+	`, dialect.Date("timestamp"), dialect.FirstValue("price", "timestamp"), dialect.FirstValue("price", "timestamp DESC"))
+}
+
+// foldCryptoPrices is foldStockPrices for crypto_prices
+func foldCryptoPrices(dialect database.Dialect) string {
+	return fmt.Sprintf(`
+		INSERT INTO crypto_prices_daily AS d (symbol, price_date, open, high, low, close, close_btc, opened_at, closed_at)
+		SELECT symbol, %[1]s, %[2]s, MAX(price_usd), MIN(price_usd), %[3]s, %[4]s,
+			MIN(last_updated), MAX(last_updated)
+		FROM crypto_prices cp
+		WHERE last_updated < $1
+		GROUP BY symbol, %[1]s
+		HAVING MIN(last_updated) < (SELECT MAX(l.last_updated) FROM crypto_prices l WHERE l.symbol = cp.symbol)
+		ON CONFLICT (symbol, price_date) DO UPDATE SET
+			open = CASE WHEN EXCLUDED.opened_at < d.opened_at THEN EXCLUDED.open ELSE d.open END,
+			high = GREATEST(d.high, EXCLUDED.high),
+			low = LEAST(d.low, EXCLUDED.low),
+			close = CASE WHEN EXCLUDED.closed_at > d.closed_at THEN EXCLUDED.close ELSE d.close END,
+			close_btc = CASE WHEN EXCLUDED.closed_at > d.closed_at THEN EXCLUDED.close_btc ELSE d.close_btc END,
+			opened_at = LEAST(d.opened_at, EXCLUDED.opened_at),
+			closed_at = GREATEST(d.closed_at, EXCLUDED.closed_at)
+	`, dialect.Date("last_updated"), dialect.FirstValue("price_usd", "last_updated"),
+		dialect.FirstValue("price_usd", "last_updated DESC"), dialect.FirstValue("price_btc", "last_updated DESC"))
+}
+
+// priceTable is a raw price table under retention, with its daily table,
+// timestamp column and the statement folding its prices
+type priceTable struct {
+	raw, daily, column string
+	fold               func(database.Dialect) string
+}
+
+// prunable is the condition on the rows of the raw table t that a pruning run
+// before $1 deletes: all but each symbol's latest price
+func (table priceTable) prunable() string {
+	return fmt.Sprintf("t.%[2]s < $1 AND t.%[2]s < (SELECT MAX(l.%[2]s) FROM %[1]s l WHERE l.symbol = t.symbol)",
+		table.raw, table.column)
+}
+
+// priceTables are the raw price tables under retention
+var priceTables = []priceTable{
+	{"stock_prices", "stock_prices_daily", "timestamp", foldStockPrices},
+	{"crypto_prices", "crypto_prices_daily", "last_updated", foldCryptoPrices},
+}
+
+// PriceTableRetention describes one raw price table and its daily table
+type PriceTableRetention struct {
+	Table       string     `json:"table"`
+	RawRows     int        `json:"raw_rows"`
+	OldestRaw   *time.Time `json:"oldest_raw"`
+	DailyTable  string     `json:"daily_table"`
+	DailyRows   int        `json:"daily_rows"`
+	OldestDaily *string    `json:"oldest_daily"`
+	// PrunableRows are raw prices older than the cutoff that the next run
+	// will fold and delete
+	PrunableRows int `json:"prunable_rows"`
+}
+
+// PricePruneResult reports one pruning run
+type PricePruneResult struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Cutoff     string    `json:"cutoff"`
+	// DaysFolded counts the symbol days written to the daily tables
+	DaysFolded map[string]int `json:"days_folded"`
+	Pruned     map[string]int `json:"pruned"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// PriceRetentionStatus reports the retention policy and the price tables
+type PriceRetentionStatus struct {
+	Enabled       bool                  `json:"enabled"`
+	RetentionDays int                   `json:"retention_days"`
+	Cutoff        *string               `json:"cutoff"`
+	Tables        []PriceTableRetention `json:"tables"`
+	// LastRun is the last pruning run since the server started
+	LastRun *PricePruneResult `json:"last_run"`
+}
+
+// PriceRetentionService keeps raw stock and crypto prices for a retention
+// period and folds older ones into daily open, high, low and close rows, so
+// the price tables stop growing with every refresh while price history is
+// kept at daily resolution
+type PriceRetentionService struct {
+	db            *sql.DB
+	retentionDays int
+
+	mu      sync.Mutex
+	lastRun *PricePruneResult
+}
+
+// NewPriceRetentionService creates a new price retention service; a
+// retentionDays of 0 keeps every raw price
+func NewPriceRetentionService(db *sql.DB, retentionDays int) *PriceRetentionService {
+	return &PriceRetentionService{db: db, retentionDays: retentionDays}
+}
+
+// Enabled reports whether raw prices are pruned
+func (prs *PriceRetentionService) Enabled() bool {
+	return prs.retentionDays > 0
+}
+
+// cutoff is the start of the oldest day whose raw prices are kept, so only
+// whole days are folded
+func (prs *PriceRetentionService) cutoff(now time.Time) time.Time {
+	return dateOnly(now).AddDate(0, 0, -prs.retentionDays)
+}
+
+// Prune folds raw prices before the cutoff into the daily tables and deletes
+// them, keeping each symbol's latest price
+func (prs *PriceRetentionService) Prune(ctx context.Context, now time.Time) (*PricePruneResult, error) {
+	cutoff := prs.cutoff(now)
+	result := &PricePruneResult{
+		StartedAt:  now,
+		Cutoff:     cutoff.Format("2006-01-02"),
+		DaysFolded: map[string]int{},
+		Pruned:     map[string]int{},
+	}
+
+	var err error
+	for _, table := range priceTables {
+		var folded, pruned int
+		if folded, pruned, err = prs.prune(ctx, table, cutoff); err != nil {
+			err = fmt.Errorf("failed to prune %s: %w", table.raw, err)
+			break
+		}
+		result.DaysFolded[table.raw] = folded
+		result.Pruned[table.raw] = pruned
+	}
+
+	result.FinishedAt = time.Now()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	prs.mu.Lock()
+	prs.lastRun = result
+	prs.mu.Unlock()
+	return result, err
+}
+
+// prune folds and deletes the prices of table before cutoff, returning how
+// many days were folded and prices deleted. Both steps run in one repeatable
+// read transaction, and so see the same prices: a price recorded while they
+// run is neither folded nor deleted.
+func (prs *PriceRetentionService) prune(ctx context.Context, table priceTable, cutoff time.Time) (int, int, error) {
+	tx, err := prs.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	var counts [2]int
+	statements := []string{
+		table.fold(database.DialectOf(prs.db)),
+		fmt.Sprintf("DELETE FROM %s AS t WHERE %s", table.raw, table.prunable()),
+	}
+	for i, statement := range statements {
+		result, err := tx.ExecContext(ctx, statement, cutoff)
+		if err != nil {
+			return 0, 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, err
+		}
+		counts[i] = int(affected)
+	}
+	return counts[0], counts[1], tx.Commit()
+}
+
+// Status reports the retention policy, the size and age of each price table
+// and the last pruning run
+func (prs *PriceRetentionService) Status(ctx context.Context) (*PriceRetentionStatus, error) {
+	status := &PriceRetentionStatus{
+		Enabled:       prs.Enabled(),
+		RetentionDays: prs.retentionDays,
+		Tables:        make([]PriceTableRetention, 0, len(priceTables)),
+	}
+	// With retention off nothing is prunable, which a cutoff before every
+	// price expresses
+	cutoff := time.Time{}
+	if prs.Enabled() {
+		cutoff = prs.cutoff(time.Now())
+		day := cutoff.Format("2006-01-02")
+		status.Cutoff = &day
+	}
+
+	for _, table := range priceTables {
+		info := PriceTableRetention{Table: table.raw, DailyTable: table.daily}
+		var oldestRaw, oldestDaily sql.NullTime
+		err := prs.db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT
+				(SELECT COUNT(*) FROM %[1]s),
+				(SELECT MIN(%[2]s) FROM %[1]s),
+				(SELECT COUNT(*) FROM %[1]s t WHERE %[4]s),
+				(SELECT COUNT(*) FROM %[3]s),
+				(SELECT MIN(price_date) FROM %[3]s)
+		`, table.raw, table.column, table.daily, table.prunable()), cutoff).Scan(
+			&info.RawRows, &oldestRaw, &info.PrunableRows, &info.DailyRows, &oldestDaily)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", table.raw, err)
+		}
+		if oldestRaw.Valid {
+			info.OldestRaw = &oldestRaw.Time
+		}
+		if oldestDaily.Valid {
+			day := oldestDaily.Time.Format("2006-01-02")
+			info.OldestDaily = &day
+		}
+		status.Tables = append(status.Tables, info)
+	}
+
+	prs.mu.Lock()
+	status.LastRun = prs.lastRun
+	prs.mu.Unlock()
+	return status, nil
+}
+
+// Run prunes raw prices now and every interval until ctx is cancelled
+func (prs *PriceRetentionService) Run(ctx context.Context, interval time.Duration) {
+	prune := func() {
+		result, err := prs.Prune(ctx, time.Now())
+		if err != nil {
+			fmt.Printf("WARNING: Price retention failed: %v\n", err)
+			return
+		}
+		for _, table := range priceTables {
+			if pruned := result.Pruned[table.raw]; pruned > 0 {
+				fmt.Printf("INFO: Folded %d %s older than %s into %d daily prices\n",
+					pruned, table.raw, result.Cutoff, result.DaysFolded[table.raw])
+			}
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -8,9 +9,12 @@ import (
 	"math/rand"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+	"networth-dashboard/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // PriceProvider interface allows easy swapping of price data sources
@@ -194,8 +198,7 @@ type TwelveDataPriceProvider struct {
 	marketService *MarketHoursService
 	config        *config.ApiConfig
 	baseURL       string
-	mu            sync.Mutex // Protects against concurrent price updates for the same symbol
-	updateMap     map[string]bool // Tracks which symbols are currently being updated
+	dedup         *SymbolDedup
 }
 
 // AlphaVantagePriceProvider provides real stock prices from Alpha Vantage API
@@ -206,20 +209,19 @@ type AlphaVantagePriceProvider struct {
 	marketService *MarketHoursService
 	config        *config.ApiConfig
 	baseURL       string
-	mu            sync.Mutex // Protects against concurrent price updates for the same symbol
-	updateMap     map[string]bool // Tracks which symbols are currently being updated
+	dedup         *SymbolDedup
 }
 
 // NewTwelveDataPriceProvider creates a new Twelve Data price provider
 func NewTwelveDataPriceProvider(apiKey string, db *sql.DB, marketService *MarketHoursService, cfg *config.ApiConfig) *TwelveDataPriceProvider {
 	return &TwelveDataPriceProvider{
 		apiKey:        apiKey,
-		client:        &http.Client{Timeout: 30 * time.Second},
+		client:        httpclient.New("twelvedata", 30*time.Second, cfg.HTTPRetry),
 		db:            db,
 		marketService: marketService,
 		config:        cfg,
 		baseURL:       "https://api.twelvedata.com",
-		updateMap:     make(map[string]bool),
+		dedup:         stockPriceDedup,
 	}
 }
 
@@ -227,12 +229,12 @@ func NewTwelveDataPriceProvider(apiKey string, db *sql.DB, marketService *Market
 func NewAlphaVantagePriceProvider(apiKey string, db *sql.DB, marketService *MarketHoursService, cfg *config.ApiConfig) *AlphaVantagePriceProvider {
 	return &AlphaVantagePriceProvider{
 		apiKey:        apiKey,
-		client:        &http.Client{Timeout: 30 * time.Second},
+		client:        httpclient.New("alphavantage", 30*time.Second, cfg.HTTPRetry),
 		db:            db,
 		marketService: marketService,
 		config:        cfg,
 		baseURL:       "https://www.alphavantage.co/query",
-		updateMap:     make(map[string]bool),
+		dedup:         stockPriceDedup,
 	}
 }
 
@@ -249,30 +251,15 @@ func (av *AlphaVantagePriceProvider) GetCurrentPriceWithForce(symbol string, for
 		return 0, fmt.Errorf("symbol cannot be empty")
 	}
 
-	// Prevent concurrent updates for the same symbol
-	av.mu.Lock()
-	if av.updateMap[symbol] {
-		av.mu.Unlock()
-		// If another goroutine is already updating this symbol, just get cached price
-		cachedPrice, _, err := av.getCachedPrice(symbol)
-		if err == nil {
-			fmt.Printf("DEBUG: Concurrent update detected for %s, returning cached price %.2f\n", symbol, cachedPrice)
-			return cachedPrice, nil
-		}
-		// If no cache, wait a bit and try again
-		time.Sleep(100 * time.Millisecond)
-		return av.getCachedPriceWithFallback(symbol)
-	}
-	av.updateMap[symbol] = true
-	av.mu.Unlock()
-
-	// Ensure cleanup on exit
-	defer func() {
-		av.mu.Lock()
-		delete(av.updateMap, symbol)
-		av.mu.Unlock()
-	}()
+	// Concurrent requests for the same symbol share a single lookup
+	price, _, err := av.dedup.Do(symbol, forceRefresh, func() (float64, error) {
+		return av.fetchPrice(symbol, forceRefresh)
+	})
+	return price, err
+}
 
+// fetchPrice returns the cached price or calls the Alpha Vantage API, honouring rate limits
+func (av *AlphaVantagePriceProvider) fetchPrice(symbol string, forceRefresh bool) (float64, error) {
 	fmt.Printf("DEBUG: Alpha Vantage GetCurrentPriceWithForce called for %s, force: %t\n", symbol, forceRefresh)
 
 	// Check cached price first
@@ -283,7 +270,7 @@ func (av *AlphaVantagePriceProvider) GetCurrentPriceWithForce(symbol string, for
 	
 	if hasCache && !forceRefresh {
 		// Use market-aware caching logic for regular refresh (not force)
-		shouldRefresh := av.marketService.ShouldRefreshPrices(lastUpdate, av.config.CacheRefreshInterval)
+		shouldRefresh := av.marketService.ShouldRefreshPrices(lastUpdate, av.config.Current().CacheRefreshInterval)
 		fmt.Printf("DEBUG: Cache decision for %s - shouldRefresh: %t, cacheAge: %v\n", symbol, shouldRefresh, time.Since(lastUpdate))
 		
 		if !shouldRefresh {
@@ -658,18 +645,6 @@ func (av *AlphaVantagePriceProvider) getCachedPrice(symbol string) (float64, tim
 	return price, timestamp, nil
 }
 
-// getCachedPriceWithFallback attempts to get cached price with retry logic
-func (av *AlphaVantagePriceProvider) getCachedPriceWithFallback(symbol string) (float64, error) {
-	for i := 0; i < 3; i++ {
-		cachedPrice, _, err := av.getCachedPrice(symbol)
-		if err == nil {
-			return cachedPrice, nil
-		}
-		time.Sleep(50 * time.Millisecond)
-	}
-	return 0, fmt.Errorf("no cached price available for %s after concurrent update", symbol)
-}
-
 // cachePrice stores price in database with comprehensive error handling
 func (av *AlphaVantagePriceProvider) cachePrice(symbol string, price float64) error {
 	if price <= 0 {
@@ -705,7 +680,7 @@ func (av *AlphaVantagePriceProvider) canMakeAPICall() bool {
 	today := time.Now().Format("2006-01-02")
 	dailyCount := av.getAPICallCount(today)
 	
-	if dailyCount >= av.config.AlphaVantageDailyLimit {
+	if dailyCount >= av.config.Current().AlphaVantageDailyLimit {
 		return false
 	}
 
@@ -713,7 +688,7 @@ func (av *AlphaVantagePriceProvider) canMakeAPICall() bool {
 	lastMinute := time.Now().Add(-1 * time.Minute)
 	recentCount := av.getAPICallCountSince(lastMinute)
 	
-	return recentCount < av.config.AlphaVantageRateLimit
+	return recentCount < av.config.Current().AlphaVantageRateLimit
 }
 
 // canMakeForceRefreshAPICall checks if we can make a force refresh API call
@@ -722,7 +697,7 @@ func (av *AlphaVantagePriceProvider) canMakeForceRefreshAPICall() bool {
 	// Check daily limit - force refresh gets 50% more calls
 	today := time.Now().Format("2006-01-02")
 	dailyCount := av.getAPICallCount(today)
-	forceRefreshDailyLimit := int(float64(av.config.AlphaVantageDailyLimit) * 1.5)
+	forceRefreshDailyLimit := int(float64(av.config.Current().AlphaVantageDailyLimit) * 1.5)
 	
 	if dailyCount >= forceRefreshDailyLimit {
 		fmt.Printf("DEBUG: Force refresh daily limit exceeded: %d >= %d\n", dailyCount, forceRefreshDailyLimit)
@@ -732,7 +707,7 @@ func (av *AlphaVantagePriceProvider) canMakeForceRefreshAPICall() bool {
 	// Check rate limit - force refresh gets double the per-minute limit
 	lastMinute := time.Now().Add(-1 * time.Minute)
 	recentCount := av.getAPICallCountSince(lastMinute)
-	forceRefreshRateLimit := av.config.AlphaVantageRateLimit * 2
+	forceRefreshRateLimit := av.config.Current().AlphaVantageRateLimit * 2
 	
 	canMake := recentCount < forceRefreshRateLimit
 	fmt.Printf("DEBUG: Force refresh rate check: %d < %d = %t\n", recentCount, forceRefreshRateLimit, canMake)
@@ -742,10 +717,12 @@ func (av *AlphaVantagePriceProvider) canMakeForceRefreshAPICall() bool {
 // getAPICallCount gets the number of API calls made today
 func (av *AlphaVantagePriceProvider) getAPICallCount(date string) int {
 	query := `
-		SELECT COUNT(*) 
-		FROM stock_prices 
-		WHERE source = 'alphavantage' 
-		AND DATE(timestamp) = $1
+		SELECT (SELECT COUNT(*) 
+		        FROM stock_prices 
+		        WHERE source = 'alphavantage' 
+		        AND DATE(timestamp) = $1) +
+		       (SELECT COUNT(*) FROM intraday_price_calls
+		        WHERE source = 'alphavantage' AND DATE(called_at) = $1)
 	`
 
 	var count int
@@ -759,10 +736,12 @@ func (av *AlphaVantagePriceProvider) getAPICallCount(date string) int {
 // getAPICallCountSince gets the number of API calls made since a specific time
 func (av *AlphaVantagePriceProvider) getAPICallCountSince(since time.Time) int {
 	query := `
-		SELECT COUNT(*) 
-		FROM stock_prices 
-		WHERE source = 'alphavantage' 
-		AND timestamp > $1
+		SELECT (SELECT COUNT(*) 
+		        FROM stock_prices 
+		        WHERE source = 'alphavantage' 
+		        AND timestamp > $1) +
+		       (SELECT COUNT(*) FROM intraday_price_calls
+		        WHERE source = 'alphavantage' AND called_at > $1)
 	`
 
 	var count int
@@ -794,30 +773,15 @@ func (td *TwelveDataPriceProvider) GetCurrentPriceWithForce(symbol string, force
 		return 0, fmt.Errorf("symbol cannot be empty")
 	}
 
-	// Prevent concurrent updates for the same symbol
-	td.mu.Lock()
-	if td.updateMap[symbol] {
-		td.mu.Unlock()
-		// If another goroutine is already updating this symbol, just get cached price
-		cachedPrice, _, err := td.getCachedPrice(symbol)
-		if err == nil {
-			fmt.Printf("DEBUG: Concurrent update detected for %s, returning cached price %.2f\n", symbol, cachedPrice)
-			return cachedPrice, nil
-		}
-		// If no cache, wait a bit and try again
-		time.Sleep(100 * time.Millisecond)
-		return td.getCachedPriceWithFallback(symbol)
-	}
-	td.updateMap[symbol] = true
-	td.mu.Unlock()
-
-	// Ensure cleanup on exit
-	defer func() {
-		td.mu.Lock()
-		delete(td.updateMap, symbol)
-		td.mu.Unlock()
-	}()
+	// Concurrent requests for the same symbol share a single lookup
+	price, _, err := td.dedup.Do(symbol, forceRefresh, func() (float64, error) {
+		return td.fetchPrice(symbol, forceRefresh)
+	})
+	return price, err
+}
 
+// fetchPrice returns the cached price or calls the Twelve Data API, honouring rate limits
+func (td *TwelveDataPriceProvider) fetchPrice(symbol string, forceRefresh bool) (float64, error) {
 	fmt.Printf("DEBUG: Twelve Data GetCurrentPriceWithForce called for %s, force: %t\n", symbol, forceRefresh)
 
 	// Check cached price first
@@ -828,7 +792,7 @@ func (td *TwelveDataPriceProvider) GetCurrentPriceWithForce(symbol string, force
 	
 	if hasCache && !forceRefresh {
 		// Use market-aware caching logic for regular refresh (not force)
-		shouldRefresh := td.marketService.ShouldRefreshPrices(lastUpdate, td.config.CacheRefreshInterval)
+		shouldRefresh := td.marketService.ShouldRefreshPrices(lastUpdate, td.config.Current().CacheRefreshInterval)
 		fmt.Printf("DEBUG: Cache decision for %s - shouldRefresh: %t, cacheAge: %v\n", symbol, shouldRefresh, time.Since(lastUpdate))
 		
 		if !shouldRefresh {
@@ -1069,18 +1033,6 @@ func (td *TwelveDataPriceProvider) getCachedPrice(symbol string) (float64, time.
 	return price, timestamp, nil
 }
 
-// getCachedPriceWithFallback attempts to get cached price with retry logic
-func (td *TwelveDataPriceProvider) getCachedPriceWithFallback(symbol string) (float64, error) {
-	for i := 0; i < 3; i++ {
-		cachedPrice, _, err := td.getCachedPrice(symbol)
-		if err == nil {
-			return cachedPrice, nil
-		}
-		time.Sleep(50 * time.Millisecond)
-	}
-	return 0, fmt.Errorf("no cached price available for %s after concurrent update", symbol)
-}
-
 // cachePrice stores price in database with comprehensive error handling
 func (td *TwelveDataPriceProvider) cachePrice(symbol string, price float64) error {
 	if price <= 0 {
@@ -1116,8 +1068,8 @@ func (td *TwelveDataPriceProvider) canMakeAPICall() bool {
 	today := time.Now().Format("2006-01-02")
 	dailyCount := td.getAPICallCount(today)
 	
-	if dailyCount >= td.config.TwelveDataDailyLimit {
-		fmt.Printf("DEBUG: Twelve Data daily limit exceeded: %d >= %d\n", dailyCount, td.config.TwelveDataDailyLimit)
+	if dailyCount >= td.config.Current().TwelveDataDailyLimit {
+		fmt.Printf("DEBUG: Twelve Data daily limit exceeded: %d >= %d\n", dailyCount, td.config.Current().TwelveDataDailyLimit)
 		return false
 	}
 
@@ -1125,18 +1077,20 @@ func (td *TwelveDataPriceProvider) canMakeAPICall() bool {
 	lastMinute := time.Now().Add(-1 * time.Minute)
 	recentCount := td.getAPICallCountSince(lastMinute)
 	
-	canMake := recentCount < td.config.TwelveDataRateLimit
-	fmt.Printf("DEBUG: Twelve Data rate check: %d < %d = %t\n", recentCount, td.config.TwelveDataRateLimit, canMake)
+	canMake := recentCount < td.config.Current().TwelveDataRateLimit
+	fmt.Printf("DEBUG: Twelve Data rate check: %d < %d = %t\n", recentCount, td.config.Current().TwelveDataRateLimit, canMake)
 	return canMake
 }
 
 // getAPICallCount gets the number of API calls made today
 func (td *TwelveDataPriceProvider) getAPICallCount(date string) int {
 	query := `
-		SELECT COUNT(*) 
-		FROM stock_prices 
-		WHERE source = 'twelvedata' 
-		AND DATE(timestamp) = $1
+		SELECT (SELECT COUNT(*) 
+		        FROM stock_prices 
+		        WHERE source = 'twelvedata' 
+		        AND DATE(timestamp) = $1) +
+		       (SELECT COUNT(*) FROM intraday_price_calls
+		        WHERE source = 'twelvedata' AND DATE(called_at) = $1)
 	`
 
 	var count int
@@ -1150,10 +1104,12 @@ func (td *TwelveDataPriceProvider) getAPICallCount(date string) int {
 // getAPICallCountSince gets the number of API calls made since a specific time
 func (td *TwelveDataPriceProvider) getAPICallCountSince(since time.Time) int {
 	query := `
-		SELECT COUNT(*) 
-		FROM stock_prices 
-		WHERE source = 'twelvedata' 
-		AND timestamp > $1
+		SELECT (SELECT COUNT(*) 
+		        FROM stock_prices 
+		        WHERE source = 'twelvedata' 
+		        AND timestamp > $1) +
+		       (SELECT COUNT(*) FROM intraday_price_calls
+		        WHERE source = 'twelvedata' AND called_at > $1)
 	`
 
 	var count int
@@ -1255,6 +1211,11 @@ func (ps *PriceService) SetProvider(provider PriceProvider) {
 	ps.provider = provider
 }
 
+// ReloadProviders re-selects the price provider after API keys change
+func (ps *PriceService) ReloadProviders(db *sql.DB, marketService *MarketHoursService, cfg *config.ApiConfig) {
+	ps.SetProvider(NewPriceServiceWithProviders(db, marketService, cfg).provider)
+}
+
 // GetCurrentPrice gets the current price for a symbol
 func (ps *PriceService) GetCurrentPrice(symbol string) (float64, error) {
 	return ps.provider.GetCurrentPrice(symbol)
@@ -1272,6 +1233,19 @@ func (ps *PriceService) GetCurrentPriceWithForce(symbol string, forceRefresh boo
 	return ps.provider.GetCurrentPrice(symbol)
 }
 
+// GetCurrentPriceWithForceContext is GetCurrentPriceWithForce traced as a span under ctx,
+// tagged with the provider so slow refreshes can be attributed to it
+func (ps *PriceService) GetCurrentPriceWithForceContext(ctx context.Context, symbol string, forceRefresh bool) (float64, error) {
+	_, span := telemetry.StartSpan(ctx, "price.get_current",
+		telemetry.ProviderAttribute.String(ps.GetProviderName()),
+		attribute.String("symbol", symbol),
+		attribute.Bool("force_refresh", forceRefresh),
+	)
+	price, err := ps.GetCurrentPriceWithForce(symbol, forceRefresh)
+	telemetry.EndSpan(span, err)
+	return price, err
+}
+
 // GetMultiplePrices gets prices for multiple symbols
 func (ps *PriceService) GetMultiplePrices(symbols []string) (map[string]float64, error) {
 	return ps.provider.GetMultiplePrices(symbols)
@@ -1299,11 +1273,14 @@ type PriceUpdateResult struct {
 
 // PriceRefreshSummary summarizes a bulk price refresh operation
 type PriceRefreshSummary struct {
-	TotalSymbols   int                 `json:"total_symbols"`
-	UpdatedSymbols int                 `json:"updated_symbols"`
-	FailedSymbols  int                 `json:"failed_symbols"`
-	Results        []PriceUpdateResult `json:"results"`
-	ProviderName   string              `json:"provider_name"`
-	Timestamp      time.Time           `json:"timestamp"`
-	DurationMs     int64               `json:"duration_ms"`
+	TotalSymbols    int                 `json:"total_symbols"`
+	UpdatedSymbols  int                 `json:"updated_symbols"`
+	FailedSymbols   int                 `json:"failed_symbols"`
+	Results         []PriceUpdateResult `json:"results"`
+	ProviderName    string              `json:"provider_name"`
+	Timestamp       time.Time           `json:"timestamp"`
+	DurationMs      int64               `json:"duration_ms"`
+	PausedSymbols   []string            `json:"paused_symbols,omitempty"`   // Skipped after repeated failures
+	SkippedSymbols  []string            `json:"skipped_symbols,omitempty"`  // Cached price still fresh
+	DeferredSymbols []string            `json:"deferred_symbols,omitempty"` // Not tried after the provider rate limited
 }
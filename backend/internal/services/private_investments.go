@@ -0,0 +1,512 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Private investment flow types. Capital calls are money paid in against the
+// commitment; distributions are money paid back out.
+const (
+	PrivateInvestmentCapitalCall  = "capital_call"
+	PrivateInvestmentDistribution = "distribution"
+)
+
+var (
+	// ErrPrivateInvestmentNotFound is returned when a private investment does not exist
+	ErrPrivateInvestmentNotFound = errors.New("private investment not found")
+	// ErrPrivateInvestmentFlowNotFound is returned when a capital call or distribution does not exist
+	ErrPrivateInvestmentFlowNotFound = errors.New("private investment flow not found")
+	// ErrInvalidPrivateInvestmentFlow is returned for an unparseable or future date
+	ErrInvalidPrivateInvestmentFlow = errors.New("invalid private investment flow")
+	// ErrInvalidNAVUpdate is returned for an unparseable or future NAV date
+	ErrInvalidNAVUpdate = errors.New("invalid NAV update")
+)
+
+// PrivateInvestmentFlow is a capital call or distribution of a private investment
+type PrivateInvestmentFlow struct {
+	ID                  int             `json:"id"`
+	PrivateInvestmentID int             `json:"private_investment_id"`
+	FlowType            string          `json:"flow_type"`
+	Amount              decimal.Decimal `json:"amount"`
+	FlowDate            time.Time       `json:"flow_date"`
+	Notes               *string         `json:"notes"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
+
+// PrivateInvestmentFlowInput records a capital call or distribution. FlowDate
+// is YYYY-MM-DD.
+type PrivateInvestmentFlowInput struct {
+	PrivateInvestmentID int     `json:"private_investment_id" binding:"required"`
+	FlowType            string  `json:"flow_type" binding:"required,oneof=capital_call distribution"`
+	Amount              float64 `json:"amount" binding:"required,gt=0"`
+	FlowDate            string  `json:"flow_date" binding:"required"`
+	Notes               *string `json:"notes" binding:"omitempty,max=255"`
+}
+
+// NAVUpdate is a NAV reported by an investment's sponsor. NAVDate is
+// YYYY-MM-DD and defaults to today.
+type NAVUpdate struct {
+	NAV     float64 `json:"nav" binding:"gte=0"`
+	NAVDate string  `json:"nav_date"`
+}
+
+// NAVRecord is one reported NAV of a private investment
+type NAVRecord struct {
+	NAV       decimal.Decimal `json:"nav"`
+	NAVDate   time.Time       `json:"nav_date"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PrivateInvestmentPerformance is a private investment with its paid-in
+// capital and returns. DPI is distributions over called capital and TVPI adds
+// the current value; both are nil until capital has been called. IRR is the
+// annualized return, as a percentage, of the calls and distributions with the
+// current value as a final distribution today, nil when it can't be solved.
+type PrivateInvestmentPerformance struct {
+	models.PrivateInvestment
+	CalledCapital   decimal.Decimal `json:"called_capital"`
+	UncalledCapital decimal.Decimal `json:"uncalled_capital"`
+	PercentCalled   float64         `json:"percent_called"`
+	Distributions   decimal.Decimal `json:"distributions"`
+	NetGain         decimal.Decimal `json:"net_gain"`
+	DPI             *float64        `json:"dpi"`
+	TVPI            *float64        `json:"tvpi"`
+	IRR             *float64        `json:"irr"`
+}
+
+// PrivateInvestmentDetail is a private investment with its flows and NAV history
+type PrivateInvestmentDetail struct {
+	PrivateInvestmentPerformance
+	Flows      []PrivateInvestmentFlow `json:"flows"`
+	NAVHistory []NAVRecord             `json:"nav_history"`
+}
+
+// PrivateInvestmentPortfolio totals every private investment. IRR treats all
+// their flows as one portfolio.
+type PrivateInvestmentPortfolio struct {
+	Investments        []PrivateInvestmentPerformance `json:"investments"`
+	TotalCommitted     decimal.Decimal                `json:"total_committed"`
+	TotalCalled        decimal.Decimal                `json:"total_called"`
+	TotalUncalled      decimal.Decimal                `json:"total_uncalled"`
+	TotalDistributions decimal.Decimal                `json:"total_distributions"`
+	TotalValue         decimal.Decimal                `json:"total_value"`
+	DPI                *float64                       `json:"dpi"`
+	TVPI               *float64                       `json:"tvpi"`
+	IRR                *float64                       `json:"irr"`
+}
+
+// PrivateInvestmentService tracks the capital calls, distributions and NAVs
+// of private investments and measures their returns
+type PrivateInvestmentService struct {
+	db *sql.DB
+}
+
+// NewPrivateInvestmentService creates a private investment service
+func NewPrivateInvestmentService(db *sql.DB) *PrivateInvestmentService {
+	return &PrivateInvestmentService{db: db}
+}
+
+// List returns every private investment with its returns as of now, largest
+// current value first, and the portfolio totals
+func (pis *PrivateInvestmentService) List(now time.Time) (*PrivateInvestmentPortfolio, error) {
+	investments, err := pis.investments(0)
+	if err != nil {
+		return nil, err
+	}
+	flows, err := pis.flows(0)
+	if err != nil {
+		return nil, err
+	}
+
+	byInvestment := make(map[int][]PrivateInvestmentFlow)
+	for _, f := range flows {
+		byInvestment[f.PrivateInvestmentID] = append(byInvestment[f.PrivateInvestmentID], f)
+	}
+
+	portfolio := &PrivateInvestmentPortfolio{Investments: []PrivateInvestmentPerformance{}}
+	for _, investment := range investments {
+		p := privateInvestmentPerformance(investment, byInvestment[investment.ID], now)
+		portfolio.Investments = append(portfolio.Investments, p)
+		portfolio.TotalCommitted = portfolio.TotalCommitted.Add(p.CommittedCapital)
+		portfolio.TotalCalled = portfolio.TotalCalled.Add(p.CalledCapital)
+		portfolio.TotalUncalled = portfolio.TotalUncalled.Add(p.UncalledCapital)
+		portfolio.TotalDistributions = portfolio.TotalDistributions.Add(p.Distributions)
+		portfolio.TotalValue = portfolio.TotalValue.Add(p.CurrentValue)
+	}
+
+	portfolio.DPI, portfolio.TVPI = multiples(portfolio.TotalCalled, portfolio.TotalDistributions, portfolio.TotalValue)
+	portfolio.IRR = privateInvestmentIRR(flows, portfolio.TotalValue, now)
+	return portfolio, nil
+}
+
+// Get returns a private investment with its returns as of now, its flows and
+// its NAV history
+func (pis *PrivateInvestmentService) Get(id int, now time.Time) (*PrivateInvestmentDetail, error) {
+	investments, err := pis.investments(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(investments) == 0 {
+		return nil, ErrPrivateInvestmentNotFound
+	}
+	flows, err := pis.flows(id)
+	if err != nil {
+		return nil, err
+	}
+	history, err := pis.navHistory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrivateInvestmentDetail{
+		PrivateInvestmentPerformance: privateInvestmentPerformance(investments[0], flows, now),
+		Flows:                        flows,
+		NAVHistory:                   history,
+	}, nil
+}
+
+// Delete removes a private investment with its flows and NAV history
+func (pis *PrivateInvestmentService) Delete(id int) error {
+	result, err := pis.db.Exec(`DELETE FROM private_investments WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete private investment: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrPrivateInvestmentNotFound
+	}
+	return nil
+}
+
+// CreateFlow records a capital call or distribution and returns its ID. Calls
+// and distributions after the NAV date move the investment's current value.
+func (pis *PrivateInvestmentService) CreateFlow(input PrivateInvestmentFlowInput, now time.Time) (int, error) {
+	date, err := parseReportDate(input.FlowDate, now)
+	if err != nil {
+		return 0, fmt.Errorf("%w: flow_date %v", ErrInvalidPrivateInvestmentFlow, err)
+	}
+	if input.FlowType != PrivateInvestmentCapitalCall && input.FlowType != PrivateInvestmentDistribution {
+		return 0, fmt.Errorf("%w: flow_type must be capital_call or distribution", ErrInvalidPrivateInvestmentFlow)
+	}
+	if input.Amount <= 0 {
+		return 0, fmt.Errorf("%w: amount must be positive", ErrInvalidPrivateInvestmentFlow)
+	}
+	if err := pis.requireInvestment(input.PrivateInvestmentID); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = pis.db.QueryRow(`
+		INSERT INTO private_investment_flows (private_investment_id, flow_type, amount, flow_date, notes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, input.PrivateInvestmentID, input.FlowType, input.Amount, date, input.Notes).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record private investment flow: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteFlow removes a capital call or distribution
+func (pis *PrivateInvestmentService) DeleteFlow(id int) error {
+	result, err := pis.db.Exec(`DELETE FROM private_investment_flows WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete private investment flow: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	} else if n == 0 {
+		return ErrPrivateInvestmentFlowNotFound
+	}
+	return nil
+}
+
+// UpdateNAV records a NAV reported by the sponsor. It becomes the current NAV
+// unless a later one is already recorded, in which case it is only added to
+// the history.
+func (pis *PrivateInvestmentService) UpdateNAV(id int, update NAVUpdate, now time.Time) error {
+	date := now.Truncate(24 * time.Hour)
+	if update.NAVDate != "" {
+		var err error
+		if date, err = parseReportDate(update.NAVDate, now); err != nil {
+			return fmt.Errorf("%w: nav_date %v", ErrInvalidNAVUpdate, err)
+		}
+	}
+	if update.NAV < 0 {
+		return fmt.Errorf("%w: nav cannot be negative", ErrInvalidNAVUpdate)
+	}
+
+	tx, err := pis.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var currentDate time.Time
+	err = tx.QueryRow(`SELECT nav_date FROM private_investments WHERE id = $1`+database.DialectOf(pis.db).ForUpdate(), id).Scan(&currentDate)
+	if err == sql.ErrNoRows {
+		return ErrPrivateInvestmentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch private investment: %w", err)
+	}
+
+	if !date.Before(currentDate) {
+		_, err = tx.Exec(`
+			UPDATE private_investments
+			SET current_nav = $2, nav_date = $3, last_updated = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, id, update.NAV, date)
+		if err != nil {
+			return fmt.Errorf("failed to update private investment NAV: %w", err)
+		}
+	}
+	_, err = tx.Exec(`
+		INSERT INTO private_investment_navs (private_investment_id, nav, nav_date)
+		VALUES ($1, $2, $3)
+	`, id, update.NAV, date)
+	if err != nil {
+		return fmt.Errorf("failed to record private investment NAV: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit private investment NAV: %w", err)
+	}
+	return nil
+}
+
+// parseReportDate parses a YYYY-MM-DD date no later than now
+func parseReportDate(value string, now time.Time) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be YYYY-MM-DD")
+	}
+	if date.After(now) {
+		return time.Time{}, fmt.Errorf("cannot be in the future")
+	}
+	return date, nil
+}
+
+func (pis *PrivateInvestmentService) requireInvestment(id int) error {
+	var exists bool
+	err := pis.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM private_investments WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to fetch private investment: %w", err)
+	}
+	if !exists {
+		return ErrPrivateInvestmentNotFound
+	}
+	return nil
+}
+
+// investments loads one private investment, or all of them when id is 0,
+// largest current value first
+func (pis *PrivateInvestmentService) investments(id int) ([]models.PrivateInvestment, error) {
+	rows, err := pis.db.Query(`
+		SELECT id, account_id, investment_name, investment_type, sponsor, committed_capital,
+		       current_nav, nav_date, current_value, vintage_year, notes, created_at, last_updated
+		FROM private_investments
+		WHERE $1 = 0 OR id = $1
+		ORDER BY current_value DESC, investment_name
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch private investments: %w", err)
+	}
+	defer rows.Close()
+
+	investments := []models.PrivateInvestment{}
+	for rows.Next() {
+		var pi models.PrivateInvestment
+		err := rows.Scan(&pi.ID, &pi.AccountID, &pi.InvestmentName, &pi.InvestmentType, &pi.Sponsor,
+			&pi.CommittedCapital, &pi.CurrentNAV, &pi.NAVDate, &pi.CurrentValue, &pi.VintageYear,
+			&pi.Notes, &pi.CreatedAt, &pi.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan private investment: %w", err)
+		}
+		investments = append(investments, pi)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch private investments: %w", err)
+	}
+	return investments, nil
+}
+
+// flows loads the capital calls and distributions of one private investment,
+// or of all of them when id is 0, by date
+func (pis *PrivateInvestmentService) flows(id int) ([]PrivateInvestmentFlow, error) {
+	rows, err := pis.db.Query(`
+		SELECT id, private_investment_id, flow_type, amount, flow_date, notes, created_at
+		FROM private_investment_flows
+		WHERE $1 = 0 OR private_investment_id = $1
+		ORDER BY flow_date, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch private investment flows: %w", err)
+	}
+	defer rows.Close()
+
+	flows := []PrivateInvestmentFlow{}
+	for rows.Next() {
+		var f PrivateInvestmentFlow
+		if err := rows.Scan(&f.ID, &f.PrivateInvestmentID, &f.FlowType, &f.Amount, &f.FlowDate, &f.Notes, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan private investment flow: %w", err)
+		}
+		flows = append(flows, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch private investment flows: %w", err)
+	}
+	return flows, nil
+}
+
+// navHistory loads the NAVs reported for a private investment, latest first
+func (pis *PrivateInvestmentService) navHistory(id int) ([]NAVRecord, error) {
+	rows, err := pis.db.Query(`
+		SELECT nav, nav_date, created_at
+		FROM private_investment_navs
+		WHERE private_investment_id = $1
+		ORDER BY nav_date DESC, id DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch NAV history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []NAVRecord{}
+	for rows.Next() {
+		var r NAVRecord
+		if err := rows.Scan(&r.NAV, &r.NAVDate, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan NAV record: %w", err)
+		}
+		history = append(history, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch NAV history: %w", err)
+	}
+	return history, nil
+}
+
+// privateInvestmentPerformance works out an investment's paid-in capital and
+// returns from its flows
+func privateInvestmentPerformance(investment models.PrivateInvestment, flows []PrivateInvestmentFlow, now time.Time) PrivateInvestmentPerformance {
+	p := PrivateInvestmentPerformance{PrivateInvestment: investment}
+	for _, f := range flows {
+		if f.FlowType == PrivateInvestmentCapitalCall {
+			p.CalledCapital = p.CalledCapital.Add(f.Amount)
+		} else {
+			p.Distributions = p.Distributions.Add(f.Amount)
+		}
+	}
+
+	p.UncalledCapital = decimal.Max(p.CommittedCapital.Sub(p.CalledCapital), decimal.Zero)
+	if p.CommittedCapital.IsPositive() {
+		p.PercentCalled = p.CalledCapital.Div(p.CommittedCapital).Mul(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+	}
+	p.NetGain = p.CurrentValue.Add(p.Distributions).Sub(p.CalledCapital)
+	p.DPI, p.TVPI = multiples(p.CalledCapital, p.Distributions, p.CurrentValue)
+	p.IRR = privateInvestmentIRR(flows, p.CurrentValue, now)
+	return p
+}
+
+// multiples returns DPI and TVPI, rounded to two places, or nil when no
+// capital has been called
+func multiples(called, distributions, value decimal.Decimal) (*float64, *float64) {
+	if !called.IsPositive() {
+		return nil, nil
+	}
+	dpi := distributions.Div(called).Round(2).InexactFloat64()
+	tvpi := distributions.Add(value).Div(called).Round(2).InexactFloat64()
+	return &dpi, &tvpi
+}
+
+// privateInvestmentIRR is the annualized return, as a percentage rounded to
+// two places, of capital calls paid in and distributions paid out, with value
+// received today
+func privateInvestmentIRR(flows []PrivateInvestmentFlow, value decimal.Decimal, now time.Time) *float64 {
+	var dated []datedCashFlow
+	for _, f := range flows {
+		amount := f.Amount.InexactFloat64()
+		if f.FlowType == PrivateInvestmentCapitalCall {
+			amount = -amount
+		}
+		dated = append(dated, datedCashFlow{date: f.FlowDate, amount: amount})
+	}
+	if value.IsPositive() {
+		dated = append(dated, datedCashFlow{date: now, amount: value.InexactFloat64()})
+	}
+
+	rate, ok := xirr(dated)
+	if !ok {
+		return nil
+	}
+	irr := math.Round(rate*10000) / 100
+	return &irr
+}
+
+// datedCashFlow is an amount received (positive) or paid (negative) on a date
+type datedCashFlow struct {
+	date   time.Time
+	amount float64
+}
+
+// xirr solves for the annual rate at which the cash flows' net present value
+// is zero, compounding over 365-day years. It needs money both paid and
+// received over more than a day, and reports false when there is no rate
+// between -99.99% and 1,000,000%.
+func xirr(flows []datedCashFlow) (float64, bool) {
+	if len(flows) < 2 {
+		return 0, false
+	}
+	first, last := flows[0].date, flows[0].date
+	var paid, received bool
+	for _, f := range flows {
+		if f.date.Before(first) {
+			first = f.date
+		}
+		if f.date.After(last) {
+			last = f.date
+		}
+		paid = paid || f.amount < 0
+		received = received || f.amount > 0
+	}
+	if !paid || !received || last.Sub(first) < 24*time.Hour {
+		return 0, false
+	}
+
+	npv := func(rate float64) float64 {
+		total := 0.0
+		for _, f := range flows {
+			years := f.date.Sub(first).Hours() / 24 / 365
+			total += f.amount / math.Pow(1+rate, years)
+		}
+		return total
+	}
+
+	// Bisect, since NPV can have a flat or turning slope that sends Newton's
+	// method astray
+	low, high := -0.9999, 10000.0
+	fLow, fHigh := npv(low), npv(high)
+	if math.IsNaN(fLow) || math.IsNaN(fHigh) || (fLow > 0) == (fHigh > 0) {
+		return 0, false
+	}
+	for i := 0; i < 200 && high-low > 1e-9; i++ {
+		mid := (low + high) / 2
+		fMid := npv(mid)
+		if (fMid > 0) == (fLow > 0) {
+			low, fLow = mid, fMid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2, true
+}
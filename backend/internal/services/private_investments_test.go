@@ -0,0 +1,92 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestXIRR(t *testing.T) {
+	tests := []struct {
+		name  string
+		flows []datedCashFlow
+		want  float64
+		ok    bool
+	}{
+		{
+			name: "one year at 10%",
+			flows: []datedCashFlow{
+				{date(2025, 1, 1), -1000},
+				{date(2026, 1, 1), 1100},
+			},
+			want: 0.10, ok: true,
+		},
+		{
+			name: "two calls and a distribution",
+			flows: []datedCashFlow{
+				{date(2025, 1, 1), -1000},
+				{date(2026, 1, 1), -1000},
+				{date(2027, 1, 1), 2310},
+			},
+			want: 0.10, ok: true,
+		},
+		{
+			name: "flows out of order",
+			flows: []datedCashFlow{
+				{date(2027, 1, 1), 2310},
+				{date(2026, 1, 1), -1000},
+				{date(2025, 1, 1), -1000},
+			},
+			want: 0.10, ok: true,
+		},
+		{
+			name: "a loss",
+			flows: []datedCashFlow{
+				{date(2025, 1, 1), -1000},
+				{date(2026, 1, 1), 500},
+			},
+			want: -0.50, ok: true,
+		},
+		{
+			name:  "only payments",
+			flows: []datedCashFlow{{date(2025, 1, 1), -1000}, {date(2026, 1, 1), -500}},
+		},
+		{
+			name:  "only receipts",
+			flows: []datedCashFlow{{date(2025, 1, 1), 1000}, {date(2026, 1, 1), 500}},
+		},
+		{
+			name:  "same day",
+			flows: []datedCashFlow{{date(2025, 1, 1), -1000}, {date(2025, 1, 1), 1100}},
+		},
+		{
+			name:  "single flow",
+			flows: []datedCashFlow{{date(2025, 1, 1), -1000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, ok := xirr(tt.flows)
+			if ok != tt.ok {
+				t.Fatalf("xirr ok = %t, want %t", ok, tt.ok)
+			}
+			if ok && math.Abs(rate-tt.want) > 1e-6 {
+				t.Errorf("xirr = %.8f, want %.8f", rate, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivateInvestmentIRR(t *testing.T) {
+	flows := []PrivateInvestmentFlow{
+		{FlowType: PrivateInvestmentCapitalCall, Amount: decimal.NewFromInt(1000), FlowDate: date(2025, 1, 1)},
+	}
+	irr := privateInvestmentIRR(flows, decimal.NewFromInt(1210), date(2027, 1, 1))
+	if irr == nil || *irr != 10 {
+		t.Errorf("privateInvestmentIRR = %v, want 10", irr)
+	}
+	if irr := privateInvestmentIRR(flows, decimal.Zero, date(2027, 1, 1)); irr != nil {
+		t.Errorf("privateInvestmentIRR without value or distributions = %v, want nil", *irr)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
 )
 
 // PropertyValuation represents a property valuation result
@@ -18,6 +20,8 @@ type PropertyValuation struct {
 	Source             string                 `json:"source"`
 	ComparableProperties []*ComparableProperty `json:"comparable_properties,omitempty"`
 	PropertyDetails    *PropertyDetails       `json:"property_details,omitempty"`
+	// Providers lists each provider's value behind a consensus valuation
+	Providers []ProviderValuation `json:"providers,omitempty"`
 }
 
 // ComparableProperty represents a comparable property
@@ -111,47 +115,110 @@ type AttomDataResponse struct {
 	} `json:"property"`
 }
 
-// PropertyValuationService handles property valuation API calls
+// AttomDataValuationProvider values properties from ATTOM Data property details
+type AttomDataValuationProvider struct {
+	apiKey     string
+	baseURL    string
+	enabled    bool
+	httpClient *http.Client
+}
+
+// NewAttomDataValuationProvider creates an ATTOM Data provider
+func NewAttomDataValuationProvider(cfg *config.ApiConfig) *AttomDataValuationProvider {
+	return &AttomDataValuationProvider{
+		apiKey:     cfg.AttomDataAPIKey,
+		baseURL:    cfg.AttomDataBaseURL,
+		enabled:    cfg.AttomDataEnabled,
+		httpClient: httpclient.New("attomdata", 30*time.Second, cfg.HTTPRetry),
+	}
+}
+
+// GetProviderName returns the provider name
+func (p *AttomDataValuationProvider) GetProviderName() string {
+	return "ATTOM Data API"
+}
+
+// Description says what the provider offers
+func (p *AttomDataValuationProvider) Description() string {
+	return "Professional property data and valuation from ATTOM Data (API key and ATTOM_DATA_ENABLED required)"
+}
+
+// IsAvailable checks if ATTOM Data API is enabled and has a key
+func (p *AttomDataValuationProvider) IsAvailable() bool {
+	return p.enabled && p.apiKey != "" && p.apiKey != "your_attom_data_api_key_here"
+}
+
+// PropertyValuationService values properties with every configured provider
+// and combines their estimates into a consensus
 type PropertyValuationService struct {
-	attomAPIKey              string
-	attomBaseURL             string
-	httpClient               *http.Client
 	propertyValuationEnabled bool
-	attomDataEnabled         bool
+	attom                    *AttomDataValuationProvider
+	rentcast                 *RentcastValuationProvider
+	providers                []PropertyValuationProvider
 }
 
 // NewPropertyValuationService creates a new property valuation service
 func NewPropertyValuationService(cfg *config.ApiConfig) *PropertyValuationService {
+	attom := NewAttomDataValuationProvider(cfg)
+	rentcast := NewRentcastValuationProvider(cfg)
 	return &PropertyValuationService{
-		attomAPIKey:              cfg.AttomDataAPIKey,
-		attomBaseURL:             cfg.AttomDataBaseURL,
 		propertyValuationEnabled: cfg.PropertyValuationEnabled,
-		attomDataEnabled:         cfg.AttomDataEnabled,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		attom:                    attom,
+		rentcast:                 rentcast,
+		providers:                []PropertyValuationProvider{attom, rentcast, NewHouseCanaryValuationProvider(cfg)},
 	}
 }
 
+// SetAttomAPIKey replaces the ATTOM Data API key after it changes
+func (pvs *PropertyValuationService) SetAttomAPIKey(apiKey string) {
+	pvs.attom.apiKey = apiKey
+}
+
+// SetRentcastAPIKey replaces the Rentcast API key after it changes
+func (pvs *PropertyValuationService) SetRentcastAPIKey(apiKey string) {
+	pvs.rentcast.SetAPIKey(apiKey)
+}
+
 // IsPropertyValuationEnabled checks if property valuation feature is enabled
 func (pvs *PropertyValuationService) IsPropertyValuationEnabled() bool {
 	return pvs.propertyValuationEnabled
 }
 
-// IsAttomDataAvailable checks if ATTOM Data API is available
-func (pvs *PropertyValuationService) IsAttomDataAvailable() bool {
-	return pvs.attomDataEnabled && pvs.attomAPIKey != "" && pvs.attomAPIKey != "your_attom_data_api_key_here"
+// Providers returns every valuation provider, configured or not
+func (pvs *PropertyValuationService) Providers() []PropertyValuationProvider {
+	return pvs.providers
 }
 
-// GetProviderName returns the name of the active provider
+// availableProviders returns the providers with credentials configured
+func (pvs *PropertyValuationService) availableProviders() []PropertyValuationProvider {
+	var available []PropertyValuationProvider
+	for _, provider := range pvs.providers {
+		if provider.IsAvailable() {
+			available = append(available, provider)
+		}
+	}
+	return available
+}
+
+// HasAvailableProvider reports whether any provider has credentials configured
+func (pvs *PropertyValuationService) HasAvailableProvider() bool {
+	return len(pvs.availableProviders()) > 0
+}
+
+// GetProviderName returns the names of the providers in use
 func (pvs *PropertyValuationService) GetProviderName() string {
-	if pvs.IsAttomDataAvailable() {
-		return "ATTOM Data API"
+	var names []string
+	for _, provider := range pvs.availableProviders() {
+		names = append(names, provider.GetProviderName())
 	}
-	return "Manual Entry"
+	if len(names) == 0 {
+		return "Manual Entry"
+	}
+	return strings.Join(names, ", ")
 }
 
-// GetPropertyValuation gets property valuation using the best available provider
+// GetPropertyValuation values a property with every available provider. With
+// more than one answering, the result is their consensus.
 func (pvs *PropertyValuationService) GetPropertyValuation(address, city, state, zipCode string) (*PropertyValuation, error) {
 	// Check if property valuation feature is enabled
 	if !pvs.propertyValuationEnabled {
@@ -162,22 +229,23 @@ func (pvs *PropertyValuationService) GetPropertyValuation(address, city, state,
 			Source:          "Manual Entry (Property valuation disabled)",
 		}, nil
 	}
-	
-	if pvs.IsAttomDataAvailable() {
-		return pvs.getAttomDataValuation(address, city, state, zipCode)
+
+	providers := pvs.availableProviders()
+	if len(providers) == 0 {
+		// Fallback to manual entry (no API call needed)
+		return &PropertyValuation{
+			EstimatedValue:  0,
+			ConfidenceScore: nil,
+			LastUpdated:     time.Now(),
+			Source:          "Manual Entry",
+		}, nil
 	}
-	
-	// Fallback to manual entry (no API call needed)
-	return &PropertyValuation{
-		EstimatedValue:  0,
-		ConfidenceScore: nil,
-		LastUpdated:     time.Now(),
-		Source:          "Manual Entry",
-	}, nil
+
+	return consensusValuation(providers, address, city, state, zipCode)
 }
 
-// getAttomDataValuation calls ATTOM Data API for property valuation
-func (pvs *PropertyValuationService) getAttomDataValuation(address, city, state, zipCode string) (*PropertyValuation, error) {
+// GetValuation calls ATTOM Data API for property valuation
+func (p *AttomDataValuationProvider) GetValuation(address, city, state, zipCode string) (*PropertyValuation, error) {
 	// Build query parameters using correct ATTOM Data API parameter names
 	params := url.Values{}
 	
@@ -202,7 +270,7 @@ func (pvs *PropertyValuationService) getAttomDataValuation(address, city, state,
 	}
 	
 	// Build request URL
-	requestURL := fmt.Sprintf("%s/property/detail?%s", pvs.attomBaseURL, params.Encode())
+	requestURL := fmt.Sprintf("%s/property/detail?%s", p.baseURL, params.Encode())
 	
 	// Create request
 	req, err := http.NewRequest("GET", requestURL, nil)
@@ -212,14 +280,14 @@ func (pvs *PropertyValuationService) getAttomDataValuation(address, city, state,
 	
 	// Set headers - ATTOM Data API uses 'apikey' header
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("apikey", pvs.attomAPIKey)
+	req.Header.Set("apikey", p.apiKey)
 	
 	// Log the request for debugging
 	fmt.Printf("ATTOM Data API Request - URL: %s, API Key: %s...%s\n", 
-		requestURL, pvs.attomAPIKey[:8], pvs.attomAPIKey[len(pvs.attomAPIKey)-4:])
+		requestURL, p.apiKey[:8], p.apiKey[len(p.apiKey)-4:])
 	
 	// Make request
-	resp, err := pvs.httpClient.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
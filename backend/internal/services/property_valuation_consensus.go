@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultProviderConfidence weighs providers that report no confidence
+const defaultProviderConfidence = 50
+
+// consensusValuation asks every provider for a valuation at once. One answer
+// is returned as is; several are combined into a value weighted by each
+// provider's confidence. The consensus confidence is the weighted mean
+// confidence less twice the providers' disagreement: the coefficient of
+// variation of their values, in percent. An error is returned only when every
+// provider fails.
+func consensusValuation(providers []PropertyValuationProvider, address, city, state, zipCode string) (*PropertyValuation, error) {
+	valuations := make([]*PropertyValuation, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider PropertyValuationProvider) {
+			defer wg.Done()
+			valuations[i], errs[i] = provider.GetValuation(address, city, state, zipCode)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var succeeded []*PropertyValuation
+	var failures []string
+	perProvider := make([]ProviderValuation, len(providers))
+	for i, provider := range providers {
+		perProvider[i].Provider = provider.GetProviderName()
+		if errs[i] != nil {
+			perProvider[i].Error = errs[i].Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.GetProviderName(), errs[i]))
+			continue
+		}
+		value := valuations[i].EstimatedValue
+		perProvider[i].EstimatedValue = &value
+		perProvider[i].ConfidenceScore = valuations[i].ConfidenceScore
+		succeeded = append(succeeded, valuations[i])
+	}
+
+	switch len(succeeded) {
+	case 0:
+		return nil, fmt.Errorf("all valuation providers failed: %s", strings.Join(failures, "; "))
+	case 1:
+		valuation := *succeeded[0]
+		valuation.Providers = perProvider
+		return &valuation, nil
+	}
+
+	var weightSum, weightedValue, weightedConfidence float64
+	for _, v := range succeeded {
+		weight := float64(defaultProviderConfidence)
+		if v.ConfidenceScore != nil && *v.ConfidenceScore > 0 {
+			weight = *v.ConfidenceScore
+		}
+		weightSum += weight
+		weightedValue += weight * v.EstimatedValue
+		weightedConfidence += weight * weight
+	}
+	value := weightedValue / weightSum
+
+	var variance float64
+	for _, v := range succeeded {
+		weight := float64(defaultProviderConfidence)
+		if v.ConfidenceScore != nil && *v.ConfidenceScore > 0 {
+			weight = *v.ConfidenceScore
+		}
+		variance += weight * (v.EstimatedValue - value) * (v.EstimatedValue - value)
+	}
+	spread := math.Sqrt(variance/weightSum) / value * 100
+	confidence := roundCents(clampPercent(weightedConfidence/weightSum - 2*spread))
+
+	consensus := &PropertyValuation{
+		EstimatedValue:  roundCents(value),
+		ConfidenceScore: &confidence,
+		LastUpdated:     time.Now(),
+		Source:          fmt.Sprintf("Consensus of %d providers", len(succeeded)),
+		Providers:       perProvider,
+	}
+	for _, v := range succeeded {
+		if v.PropertyDetails != nil {
+			consensus.PropertyDetails = v.PropertyDetails
+			break
+		}
+	}
+	return consensus, nil
+}
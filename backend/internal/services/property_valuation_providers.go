@@ -0,0 +1,251 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+)
+
+// PropertyValuationProvider is an automated valuation model queried by address
+type PropertyValuationProvider interface {
+	GetProviderName() string
+	// Description says what the provider offers, for the providers listing
+	Description() string
+	IsAvailable() bool
+	GetValuation(address, city, state, zipCode string) (*PropertyValuation, error)
+}
+
+// ProviderValuation is one provider's part in a consensus valuation. Error is
+// set instead of the value when the provider failed.
+type ProviderValuation struct {
+	Provider        string   `json:"provider"`
+	EstimatedValue  *float64 `json:"estimated_value,omitempty"`
+	ConfidenceScore *float64 `json:"confidence_score,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// rangeConfidence turns a provider's value range into a 0-100 confidence: a
+// range of ±10% of the value gives 90
+func rangeConfidence(value, low, high float64) *float64 {
+	if value <= 0 || high < low {
+		return nil
+	}
+	confidence := clampPercent(100 * (1 - (high-low)/(2*value)))
+	return &confidence
+}
+
+func clampPercent(percent float64) float64 {
+	if percent < 0 {
+		return 0
+	}
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// readProviderError returns an error for a non-200 provider response
+func readProviderError(provider string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s request failed with status %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// joinAddress formats address components as one line, skipping blanks
+func joinAddress(address, city, state, zipCode string) string {
+	var parts []string
+	for _, part := range []string{address, city, strings.TrimSpace(state + " " + zipCode)} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RentcastValuationProvider values properties with the Rentcast AVM
+type RentcastValuationProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewRentcastValuationProvider creates a Rentcast provider
+func NewRentcastValuationProvider(cfg *config.ApiConfig) *RentcastValuationProvider {
+	return &RentcastValuationProvider{
+		apiKey:     cfg.RentcastAPIKey,
+		baseURL:    strings.TrimRight(cfg.RentcastBaseURL, "/"),
+		httpClient: httpclient.New("rentcast", 30*time.Second, cfg.HTTPRetry),
+	}
+}
+
+// GetProviderName returns the provider name
+func (p *RentcastValuationProvider) GetProviderName() string {
+	return "Rentcast"
+}
+
+// Description says what the provider offers
+func (p *RentcastValuationProvider) Description() string {
+	return "Automated valuation with a value range from Rentcast (API key required)"
+}
+
+// IsAvailable reports whether an API key is configured
+func (p *RentcastValuationProvider) IsAvailable() bool {
+	return p.apiKey != ""
+}
+
+// GetValuation fetches the Rentcast value estimate for an address
+func (p *RentcastValuationProvider) GetValuation(address, city, state, zipCode string) (*PropertyValuation, error) {
+	if strings.TrimSpace(address) == "" {
+		return nil, fmt.Errorf("a street address is required for Rentcast")
+	}
+
+	params := url.Values{}
+	params.Set("address", joinAddress(address, city, state, zipCode))
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/avm/value?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Api-Key", p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readProviderError("Rentcast", resp)
+	}
+
+	var body struct {
+		Price          float64 `json:"price"`
+		PriceRangeLow  float64 `json:"priceRangeLow"`
+		PriceRangeHigh float64 `json:"priceRangeHigh"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode API response: %w", err)
+	}
+	if body.Price <= 0 {
+		return nil, fmt.Errorf("no valuation data available for this property")
+	}
+
+	return &PropertyValuation{
+		EstimatedValue:  body.Price,
+		ConfidenceScore: rangeConfidence(body.Price, body.PriceRangeLow, body.PriceRangeHigh),
+		LastUpdated:     time.Now(),
+		Source:          p.GetProviderName(),
+	}, nil
+}
+
+// SetAPIKey replaces the API key after it changes
+func (p *RentcastValuationProvider) SetAPIKey(apiKey string) {
+	p.apiKey = apiKey
+}
+
+// HouseCanaryValuationProvider values properties with the HouseCanary property value API
+type HouseCanaryValuationProvider struct {
+	apiKey     string
+	apiSecret  string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHouseCanaryValuationProvider creates a HouseCanary provider
+func NewHouseCanaryValuationProvider(cfg *config.ApiConfig) *HouseCanaryValuationProvider {
+	return &HouseCanaryValuationProvider{
+		apiKey:     cfg.HouseCanaryAPIKey,
+		apiSecret:  cfg.HouseCanaryAPISecret,
+		baseURL:    strings.TrimRight(cfg.HouseCanaryBaseURL, "/"),
+		httpClient: httpclient.New("housecanary", 30*time.Second, cfg.HTTPRetry),
+	}
+}
+
+// GetProviderName returns the provider name
+func (p *HouseCanaryValuationProvider) GetProviderName() string {
+	return "HouseCanary"
+}
+
+// Description says what the provider offers
+func (p *HouseCanaryValuationProvider) Description() string {
+	return "Automated valuation with forecast standard deviation from HouseCanary (API key and secret required)"
+}
+
+// IsAvailable reports whether an API key and secret are configured
+func (p *HouseCanaryValuationProvider) IsAvailable() bool {
+	return p.apiKey != "" && p.apiSecret != ""
+}
+
+// GetValuation fetches the HouseCanary value estimate for an address
+func (p *HouseCanaryValuationProvider) GetValuation(address, city, state, zipCode string) (*PropertyValuation, error) {
+	address = strings.TrimSpace(address)
+	params := url.Values{}
+	params.Set("address", address)
+	switch {
+	case address == "":
+		return nil, fmt.Errorf("a street address is required for HouseCanary")
+	case zipCode != "":
+		params.Set("zipcode", zipCode)
+	case city != "" && state != "":
+		params.Set("city", city)
+		params.Set("state", state)
+	default:
+		return nil, fmt.Errorf("a ZIP code or city and state is required for HouseCanary")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/property/value?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(p.apiKey, p.apiSecret)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readProviderError("HouseCanary", resp)
+	}
+
+	var body []struct {
+		Value struct {
+			APICode            int    `json:"api_code"`
+			APICodeDescription string `json:"api_code_description"`
+			Result             struct {
+				Value struct {
+					PriceMean float64 `json:"price_mean"`
+					FSD       float64 `json:"fsd"` // forecast standard deviation, as a fraction of the value
+				} `json:"value"`
+			} `json:"result"`
+		} `json:"property/value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode API response: %w", err)
+	}
+	if len(body) == 0 {
+		return nil, fmt.Errorf("no property data found for the given address")
+	}
+	result := body[0].Value
+	if result.APICode != 0 {
+		return nil, fmt.Errorf("API returned error: %s", result.APICodeDescription)
+	}
+	value := result.Result.Value
+	if value.PriceMean <= 0 {
+		return nil, fmt.Errorf("no valuation data available for this property")
+	}
+
+	confidence := clampPercent(100 * (1 - value.FSD))
+	return &PropertyValuation{
+		EstimatedValue:  value.PriceMean,
+		ConfidenceScore: &confidence,
+		LastUpdated:     time.Now(),
+		Source:          p.GetProviderName(),
+	}, nil
+}
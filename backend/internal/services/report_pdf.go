@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDF page layout in points (US Letter)
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+	pdfMargin     = 54.0
+)
+
+// PDF fonts, all standard Type 1 fonts every reader provides
+const (
+	pdfFontRegular = "F1" // Helvetica
+	pdfFontBold    = "F2" // Helvetica-Bold
+	pdfFontMono    = "F3" // Courier, for aligned table rows
+)
+
+// pdfDocument lays out lines of text top to bottom, starting a new page when
+// one is full. It covers the text-only statements the reports need without a
+// PDF dependency.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// line writes text in font at size, indented from the left margin
+func (d *pdfDocument) line(font string, size, indent float64, text string) {
+	height := size * 1.4
+	if len(d.pages) == 0 || d.y-height < pdfMargin {
+		d.pages = append(d.pages, &bytes.Buffer{})
+		d.y = pdfPageHeight - pdfMargin
+	}
+	d.y -= height
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		font, size, pdfMargin+indent, d.y, pdfEscape(text))
+}
+
+// gap leaves vertical space before the next line
+func (d *pdfDocument) gap(points float64) {
+	d.y -= points
+}
+
+// bytes serializes the document
+func (d *pdfDocument) bytes() []byte {
+	if len(d.pages) == 0 {
+		d.pages = append(d.pages, &bytes.Buffer{})
+	}
+
+	// Objects 1-5 are the catalog, page tree and fonts; each page adds a page
+	// object followed by its content stream
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // page tree, filled in once page objects are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, 0, len(d.pages))
+	for _, content := range d.pages {
+		pageNumber := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNumber))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+				"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageNumber+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfEscape escapes a string literal, replacing characters outside printable
+// ASCII since the standard fonts are not embedded
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
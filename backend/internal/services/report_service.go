@@ -0,0 +1,618 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+// ErrInvalidReportMonth is returned for a month that is malformed or has not started
+var ErrInvalidReportMonth = errors.New("month must be YYYY-MM and not in the future")
+
+// reportTopMovers is how many gainers and losers a monthly report lists
+const reportTopMovers = 5
+
+// ReportNetWorth is net worth at the start and end of a report period. Values
+// are nil when no snapshot covers that end of the period.
+type ReportNetWorth struct {
+	OpeningDate   string   `json:"opening_date,omitempty"`
+	Opening       *float64 `json:"opening"`
+	ClosingDate   string   `json:"closing_date,omitempty"`
+	Closing       *float64 `json:"closing"`
+	Change        *float64 `json:"change"`
+	ChangePercent *float64 `json:"change_percent"`
+}
+
+// ReportMover is a symbol's change in value over a report period. Contributions
+// are shares bought or sold; MarketChange is price movement on shares held.
+type ReportMover struct {
+	Symbol              string   `json:"symbol"`
+	OpeningValue        float64  `json:"opening_value"`
+	ClosingValue        float64  `json:"closing_value"`
+	Contributions       float64  `json:"contributions"`
+	MarketChange        float64  `json:"market_change"`
+	MarketChangePercent *float64 `json:"market_change_percent"`
+}
+
+// ReportVestingEvent is an equity grant vest in a report period, valued at the
+// grant's current price (less the strike price for options)
+type ReportVestingEvent struct {
+	Date      string  `json:"date"` // YYYY-MM-DD
+	GrantID   int     `json:"grant_id"`
+	Symbol    string  `json:"symbol"`
+	GrantType string  `json:"grant_type"`
+	Shares    int     `json:"shares"`
+	Value     float64 `json:"value"`
+}
+
+// ReportAllocation is an asset class's share of total assets at the start and
+// end of a report period. Drift is the change in percentage points.
+type ReportAllocation struct {
+	Key            string  `json:"key"`
+	Label          string  `json:"label"`
+	OpeningValue   float64 `json:"opening_value"`
+	OpeningPercent float64 `json:"opening_percent"`
+	ClosingValue   float64 `json:"closing_value"`
+	ClosingPercent float64 `json:"closing_percent"`
+	DriftPercent   float64 `json:"drift_percent"`
+}
+
+// MonthlyReport is the statement for one calendar month. A month in progress
+// runs to today and closes with current values.
+type MonthlyReport struct {
+	Month         string               `json:"month"` // YYYY-MM
+	Title         string               `json:"title"`
+	From          string               `json:"from"`
+	To            string               `json:"to"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+	NetWorth      ReportNetWorth       `json:"net_worth"`
+	TopGainers    []ReportMover        `json:"top_gainers"`
+	TopLosers     []ReportMover        `json:"top_losers"`
+	VestingEvents []ReportVestingEvent `json:"vesting_events"`
+	Allocation    []ReportAllocation   `json:"allocation"`
+	Notes         []string             `json:"notes"`
+}
+
+// ReportService builds monthly statements from net worth snapshots, position
+// snapshots and vesting schedules, and delivers them on a schedule
+type ReportService struct {
+	db              *sql.DB
+	netWorthHistory *NetWorthHistoryService
+	gainsHistory    *GainsHistoryService
+	notifications   *NotificationService
+	mailer          *Mailer
+}
+
+// NewReportService creates a report service. Scheduled reports are emailed
+// when the mailer is configured.
+func NewReportService(db *sql.DB, netWorthHistory *NetWorthHistoryService, gainsHistory *GainsHistoryService, notifications *NotificationService, mailer *Mailer) *ReportService {
+	return &ReportService{
+		db:              db,
+		netWorthHistory: netWorthHistory,
+		gainsHistory:    gainsHistory,
+		notifications:   notifications,
+		mailer:          mailer,
+	}
+}
+
+// ParseReportMonth parses a YYYY-MM month that has started by now
+func ParseReportMonth(value string, now time.Time) (time.Time, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil || month.After(dateOnly(now)) {
+		return time.Time{}, ErrInvalidReportMonth
+	}
+	return month, nil
+}
+
+// Monthly builds the report for the month starting at month. breakdown supplies
+// current values when the month is still in progress.
+func (rs *ReportService) Monthly(month, now time.Time, breakdown func() (models.NetWorthBreakdown, error)) (*MonthlyReport, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	next := start.AddDate(0, 1, 0)
+	today := dateOnly(now)
+	if start.After(today) {
+		return nil, ErrInvalidReportMonth
+	}
+	end := next.AddDate(0, 0, -1)
+	inProgress := today.Before(next)
+	if inProgress {
+		end = today
+	}
+
+	report := &MonthlyReport{
+		Month:         start.Format("2006-01"),
+		Title:         start.Format("January 2006"),
+		From:          start.Format("2006-01-02"),
+		To:            end.Format("2006-01-02"),
+		GeneratedAt:   now,
+		TopGainers:    []ReportMover{},
+		TopLosers:     []ReportMover{},
+		VestingEvents: []ReportVestingEvent{},
+		Allocation:    []ReportAllocation{},
+		Notes:         []string{},
+	}
+
+	opening, closing, err := rs.netWorthBounds(report, start, next, inProgress, breakdown)
+	if err != nil {
+		return nil, err
+	}
+	if opening != nil && closing != nil {
+		report.Allocation = allocationDrift(*opening, *closing)
+	}
+
+	if err := rs.addMovers(report, start, end); err != nil {
+		return nil, err
+	}
+	if err := rs.addVestingEvents(report, start, next); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// netWorthBounds fills in the report's net worth from the last snapshot before
+// the month (or the first one in it) and the last snapshot in it (or current
+// values), and returns both breakdowns
+func (rs *ReportService) netWorthBounds(report *MonthlyReport, start, next time.Time, inProgress bool, breakdown func() (models.NetWorthBreakdown, error)) (*models.NetWorthBreakdown, *models.NetWorthBreakdown, error) {
+	var opening, closing *models.NetWorthBreakdown
+
+	snapshot, err := rs.netWorthHistory.LatestBefore(start)
+	if err != nil {
+		return nil, nil, err
+	}
+	if snapshot == nil {
+		if snapshot, err = rs.netWorthHistory.EarliestFrom(start); err != nil {
+			return nil, nil, err
+		}
+		if snapshot != nil && !snapshot.Timestamp.Before(next) {
+			snapshot = nil
+		}
+		if snapshot != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("No net worth history before %s; opening values are from %s.",
+				report.From, snapshot.Timestamp.Format("2006-01-02")))
+		}
+	}
+	if snapshot != nil {
+		opening = &snapshot.NetWorthBreakdown
+		report.NetWorth.OpeningDate = snapshot.Timestamp.Format("2006-01-02")
+	}
+
+	if inProgress {
+		current, err := breakdown()
+		if err != nil {
+			return nil, nil, err
+		}
+		closing = &current
+		report.NetWorth.ClosingDate = report.To
+		report.Notes = append(report.Notes, "The month is in progress; closing values are current.")
+	} else {
+		snapshot, err := rs.netWorthHistory.LatestBefore(next)
+		if err != nil {
+			return nil, nil, err
+		}
+		if snapshot != nil && !snapshot.Timestamp.Before(start) {
+			closing = &snapshot.NetWorthBreakdown
+			report.NetWorth.ClosingDate = snapshot.Timestamp.Format("2006-01-02")
+		}
+	}
+
+	if opening == nil && closing == nil {
+		report.Notes = append(report.Notes, "No net worth snapshots were recorded for this month.")
+	}
+	if opening != nil {
+		value := centsOf(opening.NetWorth())
+		report.NetWorth.Opening = &value
+	}
+	if closing != nil {
+		value := centsOf(closing.NetWorth())
+		report.NetWorth.Closing = &value
+	}
+	if opening != nil && closing != nil {
+		change := closing.NetWorth().Sub(opening.NetWorth())
+		changeCents := centsOf(change)
+		report.NetWorth.Change = &changeCents
+		if !opening.NetWorth().IsZero() {
+			percent := roundCents(models.PercentOf(change, opening.NetWorth().Abs()))
+			report.NetWorth.ChangePercent = &percent
+		}
+	}
+	return opening, closing, nil
+}
+
+// allocationDrift compares each asset class's share of total assets
+func allocationDrift(opening, closing models.NetWorthBreakdown) []ReportAllocation {
+	before := opening.Components()
+	after := closing.Components()
+	allocation := make([]ReportAllocation, 0, len(after))
+	for i, component := range after {
+		allocation = append(allocation, ReportAllocation{
+			Key:            component.Key,
+			Label:          component.Label,
+			OpeningValue:   centsOf(before[i].Value),
+			OpeningPercent: roundCents(before[i].Percentage),
+			ClosingValue:   centsOf(component.Value),
+			ClosingPercent: roundCents(component.Percentage),
+			DriftPercent:   roundCents(component.Percentage - before[i].Percentage),
+		})
+	}
+	return allocation
+}
+
+// addMovers lists the symbols with the largest market change over the month,
+// measured from the last position snapshot of the previous month
+func (rs *ReportService) addMovers(report *MonthlyReport, start, end time.Time) error {
+	history, err := rs.gainsHistory.History(start.AddDate(0, -1, 0), end, GainsIntervalMonth, "", nil)
+	if err != nil {
+		return err
+	}
+	if len(history.Total) < 2 || !strings.HasPrefix(history.Total[len(history.Total)-1].Date, report.Month) {
+		report.Notes = append(report.Notes, "Top movers need position snapshots from both this month and the one before.")
+		return nil
+	}
+
+	var movers []ReportMover
+	for _, series := range history.Symbols {
+		last := series.Points[len(series.Points)-1]
+		if !strings.HasPrefix(last.Date, report.Month) || last.MarketChange == 0 {
+			continue
+		}
+		mover := ReportMover{
+			Symbol:        series.Symbol,
+			OpeningValue:  roundCents(last.MarketValue - last.Contributions - last.MarketChange),
+			ClosingValue:  last.MarketValue,
+			Contributions: last.Contributions,
+			MarketChange:  last.MarketChange,
+		}
+		if mover.OpeningValue > 0 {
+			percent := roundCents(mover.MarketChange / mover.OpeningValue * 100)
+			mover.MarketChangePercent = &percent
+		}
+		movers = append(movers, mover)
+	}
+
+	sort.Slice(movers, func(i, j int) bool { return movers[i].MarketChange > movers[j].MarketChange })
+	for _, mover := range movers {
+		if mover.MarketChange > 0 && len(report.TopGainers) < reportTopMovers {
+			report.TopGainers = append(report.TopGainers, mover)
+		}
+	}
+	for i := len(movers) - 1; i >= 0; i-- {
+		if movers[i].MarketChange < 0 && len(report.TopLosers) < reportTopMovers {
+			report.TopLosers = append(report.TopLosers, movers[i])
+		}
+	}
+	return nil
+}
+
+// addVestingEvents lists the vests scheduled between start and next
+func (rs *ReportService) addVestingEvents(report *MonthlyReport, start, next time.Time) error {
+	rows, err := rs.db.Query(`
+		SELECT vs.vest_date, g.id, UPPER(g.company_symbol), g.grant_type, vs.shares_vesting,
+		       CASE WHEN g.grant_type IN ('iso', 'nso')
+		            THEN GREATEST(COALESCE(g.current_price, 0) - COALESCE(g.strike_price, 0), 0)
+		            ELSE COALESCE(g.current_price, 0) END
+		FROM vesting_schedule vs
+		JOIN equity_grants g ON g.id = vs.grant_id
+		WHERE vs.vest_date >= $1 AND vs.vest_date < $2
+		ORDER BY vs.vest_date, g.company_symbol, g.id
+	`, start, next)
+	if err != nil {
+		return fmt.Errorf("failed to fetch vesting events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event ReportVestingEvent
+		var date time.Time
+		var price float64
+		if err := rows.Scan(&date, &event.GrantID, &event.Symbol, &event.GrantType, &event.Shares, &price); err != nil {
+			return fmt.Errorf("failed to scan vesting event: %w", err)
+		}
+		event.Date = date.Format("2006-01-02")
+		event.Value = roundCents(float64(event.Shares) * price)
+		report.VestingEvents = append(report.VestingEvents, event)
+	}
+	return rows.Err()
+}
+
+// Run delivers the report for the previous month once, checking now and then
+// every interval until ctx is done
+func (rs *ReportService) Run(ctx context.Context, interval time.Duration, breakdown func() (models.NetWorthBreakdown, error)) {
+	deliver := func() {
+		if err := rs.DeliverPrevious(time.Now(), breakdown); err != nil {
+			fmt.Printf("WARNING: Monthly report delivery failed: %v\n", err)
+		}
+	}
+
+	deliver()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deliver()
+		}
+	}
+}
+
+// DeliverPrevious delivers the report for the month before now unless it has
+// already been delivered. A month without net worth history is recorded as
+// delivered without sending anything.
+func (rs *ReportService) DeliverPrevious(now time.Time, breakdown func() (models.NetWorthBreakdown, error)) error {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	label := month.Format("2006-01")
+
+	var delivered bool
+	err := rs.db.QueryRow("SELECT EXISTS(SELECT 1 FROM monthly_report_deliveries WHERE month = $1)", label).Scan(&delivered)
+	if err != nil {
+		return fmt.Errorf("failed to check report delivery: %w", err)
+	}
+	if delivered {
+		return nil
+	}
+
+	report, err := rs.Monthly(month, now, breakdown)
+	if err != nil {
+		return err
+	}
+	if report.NetWorth.Closing != nil {
+		if err := rs.deliver(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("INFO: Skipping monthly report for %s: no net worth history\n", label)
+	}
+
+	_, err = rs.db.Exec(`
+		INSERT INTO monthly_report_deliveries (month, delivered_at) VALUES ($1, $2)
+		ON CONFLICT (month) DO NOTHING
+	`, label, now)
+	if err != nil {
+		return fmt.Errorf("failed to record report delivery: %w", err)
+	}
+	return nil
+}
+
+// deliver emails a finished report with the PDF attached, when email is
+// configured, and announces it with a notification linking to it. The
+// notification is stored without going through the notification channels, so
+// the report is not emailed twice.
+func (rs *ReportService) deliver(report *MonthlyReport) error {
+	emailed := false
+	if rs.mailer != nil && rs.mailer.Configured() && len(rs.mailer.Recipients()) > 0 {
+		email, err := rs.mailer.Render(EmailTemplateMonthlyReport, report)
+		if err != nil {
+			return err
+		}
+		email.Attachments = []EmailAttachment{{
+			Filename:    fmt.Sprintf("networth-report-%s.pdf", report.Month),
+			ContentType: "application/pdf",
+			Content:     RenderMonthlyReportPDF(report),
+		}}
+		if err := rs.mailer.Enqueue(email); err != nil {
+			return err
+		}
+		emailed = true
+	}
+
+	if rs.notifications == nil {
+		return nil
+	}
+	summary := fmt.Sprintf("Net worth closed at %s.", formatCurrency(*report.NetWorth.Closing))
+	if report.NetWorth.Change != nil {
+		summary = fmt.Sprintf("Net worth changed by %s to %s.",
+			formatSignedCurrency(*report.NetWorth.Change), formatCurrency(*report.NetWorth.Closing))
+	}
+	if emailed {
+		summary += " The statement has been emailed."
+	}
+	_, err := rs.notifications.store(
+		"monthly_report",
+		NotificationSeverityInfo,
+		fmt.Sprintf("Your %s statement is ready", report.Title),
+		fmt.Sprintf("%s Download it from /api/v1/reports/monthly/%s?format=pdf", summary, report.Month),
+	)
+	return err
+}
+
+// RenderMonthlyReportHTML renders a report as a standalone HTML page
+func RenderMonthlyReportHTML(report *MonthlyReport) ([]byte, error) {
+	var out bytes.Buffer
+	if err := monthlyReportTemplate.Execute(&out, report); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// RenderMonthlyReportPDF renders a report as a text PDF
+func RenderMonthlyReportPDF(report *MonthlyReport) []byte {
+	doc := &pdfDocument{}
+	doc.line(pdfFontBold, 18, 0, "Net Worth Statement - "+report.Title)
+	doc.line(pdfFontRegular, 10, 0, fmt.Sprintf("%s to %s, generated %s",
+		report.From, report.To, report.GeneratedAt.Format("2006-01-02 15:04")))
+
+	section := func(title string) {
+		doc.gap(12)
+		doc.line(pdfFontBold, 13, 0, title)
+		doc.gap(2)
+	}
+	row := func(format string, args ...interface{}) {
+		doc.line(pdfFontMono, 9, 0, fmt.Sprintf(format, args...))
+	}
+
+	section("Net Worth")
+	row("%-12s %-12s %18s", "Opening", report.NetWorth.OpeningDate, formatOptionalCurrency(report.NetWorth.Opening))
+	row("%-12s %-12s %18s", "Closing", report.NetWorth.ClosingDate, formatOptionalCurrency(report.NetWorth.Closing))
+	change := "-"
+	if report.NetWorth.Change != nil {
+		change = formatSignedCurrency(*report.NetWorth.Change)
+	}
+	row("%-12s %-12s %18s %10s", "Change", "", change, formatOptionalPercent(report.NetWorth.ChangePercent))
+
+	movers := func(title string, list []ReportMover) {
+		section(title)
+		if len(list) == 0 {
+			doc.line(pdfFontRegular, 10, 0, "None")
+			return
+		}
+		row("%-10s %16s %16s %16s %9s", "Symbol", "Opening", "Closing", "Market change", "%")
+		for _, m := range list {
+			row("%-10s %16s %16s %16s %9s", m.Symbol, formatCurrency(m.OpeningValue), formatCurrency(m.ClosingValue),
+				formatSignedCurrency(m.MarketChange), formatOptionalPercent(m.MarketChangePercent))
+		}
+	}
+	movers("Top Gainers", report.TopGainers)
+	movers("Top Losers", report.TopLosers)
+
+	section("Vesting Events")
+	if len(report.VestingEvents) == 0 {
+		doc.line(pdfFontRegular, 10, 0, "None")
+	} else {
+		row("%-10s %-10s %-14s %10s %16s", "Date", "Symbol", "Grant type", "Shares", "Value")
+		for _, e := range report.VestingEvents {
+			row("%-10s %-10s %-14s %10d %16s", e.Date, e.Symbol, e.GrantType, e.Shares, formatCurrency(e.Value))
+		}
+	}
+
+	section("Allocation Drift")
+	if len(report.Allocation) == 0 {
+		doc.line(pdfFontRegular, 10, 0, "Not available")
+	} else {
+		row("%-14s %16s %9s %16s %9s %9s", "Asset class", "Opening", "%", "Closing", "%", "Drift")
+		for _, a := range report.Allocation {
+			row("%-14s %16s %8.2f%% %16s %8.2f%% %9s", a.Label, formatCurrency(a.OpeningValue), a.OpeningPercent,
+				formatCurrency(a.ClosingValue), a.ClosingPercent, formatSignedPercent(a.DriftPercent))
+		}
+	}
+
+	if len(report.Notes) > 0 {
+		section("Notes")
+		for _, note := range report.Notes {
+			doc.line(pdfFontRegular, 10, 0, note)
+		}
+	}
+	return doc.bytes()
+}
+
+// formatCurrency formats an amount as dollars with thousands separators
+func formatCurrency(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := fmt.Sprintf("%.2f", amount)
+	whole, fraction := cents[:len(cents)-3], cents[len(cents)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + "$" + whole + fraction
+}
+
+// formatSignedCurrency formats an amount with an explicit + for gains
+func formatSignedCurrency(amount float64) string {
+	if amount > 0 {
+		return "+" + formatCurrency(amount)
+	}
+	return formatCurrency(amount)
+}
+
+func formatOptionalCurrency(amount *float64) string {
+	if amount == nil {
+		return "-"
+	}
+	return formatCurrency(*amount)
+}
+
+func formatSignedPercent(percent float64) string {
+	return fmt.Sprintf("%+.2f%%", percent)
+}
+
+func formatOptionalPercent(percent *float64) string {
+	if percent == nil {
+		return "-"
+	}
+	return formatSignedPercent(*percent)
+}
+
+var monthlyReportTemplate = template.Must(template.New("monthly_report").Funcs(template.FuncMap{
+	"currency":         formatCurrency,
+	"signedCurrency":   formatSignedCurrency,
+	"optionalCurrency": formatOptionalCurrency,
+	"signedPercent":    formatSignedPercent,
+	"optionalPercent":  formatOptionalPercent,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Net Worth Statement - {{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2937; max-width: 860px; margin: 2rem auto; padding: 0 1rem; }
+h1 { margin-bottom: 0.25rem; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #e5e7eb; padding-bottom: 0.25rem; }
+.period { color: #6b7280; }
+table { width: 100%; border-collapse: collapse; }
+th, td { padding: 0.4rem 0.5rem; text-align: right; border-bottom: 1px solid #f3f4f6; }
+th:first-child, td:first-child { text-align: left; }
+.gain { color: #059669; }
+.loss { color: #dc2626; }
+</style>
+</head>
+<body>
+<h1>Net Worth Statement - {{.Title}}</h1>
+<p class="period">{{.From}} to {{.To}}, generated {{.GeneratedAt.Format "2006-01-02 15:04"}}</p>
+
+<h2>Net Worth</h2>
+<table>
+<tr><td>Opening{{with .NetWorth.OpeningDate}} ({{.}}){{end}}</td><td>{{optionalCurrency .NetWorth.Opening}}</td></tr>
+<tr><td>Closing{{with .NetWorth.ClosingDate}} ({{.}}){{end}}</td><td>{{optionalCurrency .NetWorth.Closing}}</td></tr>
+<tr><td>Change</td><td>{{with .NetWorth.Change}}{{signedCurrency .}}{{else}}-{{end}} ({{optionalPercent .NetWorth.ChangePercent}})</td></tr>
+</table>
+
+{{define "movers"}}
+{{if .}}
+<table>
+<tr><th>Symbol</th><th>Opening</th><th>Closing</th><th>Contributions</th><th>Market change</th><th>%</th></tr>
+{{range .}}<tr><td>{{.Symbol}}</td><td>{{currency .OpeningValue}}</td><td>{{currency .ClosingValue}}</td><td>{{signedCurrency .Contributions}}</td><td class="{{if gt .MarketChange 0.0}}gain{{else}}loss{{end}}">{{signedCurrency .MarketChange}}</td><td>{{optionalPercent .MarketChangePercent}}</td></tr>
+{{end}}</table>
+{{else}}<p>None</p>{{end}}
+{{end}}
+<h2>Top Gainers</h2>
+{{template "movers" .TopGainers}}
+<h2>Top Losers</h2>
+{{template "movers" .TopLosers}}
+
+<h2>Vesting Events</h2>
+{{if .VestingEvents}}
+<table>
+<tr><th>Date</th><th>Symbol</th><th>Grant type</th><th>Shares</th><th>Value</th></tr>
+{{range .VestingEvents}}<tr><td>{{.Date}}</td><td>{{.Symbol}}</td><td>{{.GrantType}}</td><td>{{.Shares}}</td><td>{{currency .Value}}</td></tr>
+{{end}}</table>
+{{else}}<p>None</p>{{end}}
+
+<h2>Allocation Drift</h2>
+{{if .Allocation}}
+<table>
+<tr><th>Asset class</th><th>Opening</th><th>%</th><th>Closing</th><th>%</th><th>Drift</th></tr>
+{{range .Allocation}}<tr><td>{{.Label}}</td><td>{{currency .OpeningValue}}</td><td>{{printf "%.2f%%" .OpeningPercent}}</td><td>{{currency .ClosingValue}}</td><td>{{printf "%.2f%%" .ClosingPercent}}</td><td>{{signedPercent .DriftPercent}}</td></tr>
+{{end}}</table>
+{{else}}<p>Not available</p>{{end}}
+
+{{if .Notes}}
+<h2>Notes</h2>
+<ul>
+{{range .Notes}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/config"
+)
+
+// ErrNotRuntimeSetting is returned for a setting that cannot change while
+// the server runs
+var ErrNotRuntimeSetting = errors.New("setting cannot be changed at runtime")
+
+// ErrInvalidSettingValue is returned for a value the setting does not accept
+var ErrInvalidSettingValue = errors.New("invalid setting value")
+
+// RuntimeSetting is a setting that can change while the server runs, with
+// its current value
+type RuntimeSetting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Source is "settings" when changed through the API, "environment", the
+	// configuration file's path, or "default"
+	Source    string     `json:"source"`
+	UpdatedBy *string    `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// RuntimeSettingsService stores settings changed at runtime, which override
+// the environment and configuration file, and applies them to the running
+// configuration
+type RuntimeSettingsService struct {
+	db  *sql.DB
+	cfg *config.Config
+
+	// mu serializes changes, so concurrent updates cannot apply each other's
+	// overrides out of order
+	mu sync.Mutex
+}
+
+// NewRuntimeSettingsService creates a new runtime settings service
+func NewRuntimeSettingsService(db *sql.DB, cfg *config.Config) *RuntimeSettingsService {
+	return &RuntimeSettingsService{db: db, cfg: cfg}
+}
+
+// overrides returns the stored settings by name
+func (rs *RuntimeSettingsService) overrides() (map[string]string, error) {
+	rows, err := rs.db.Query(`SELECT name, value FROM runtime_settings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load runtime settings: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan runtime setting: %w", err)
+		}
+		// A setting no longer changeable at runtime is left to the configuration
+		if config.IsRuntimeSetting(name) {
+			overrides[name] = value
+		}
+	}
+	return overrides, rows.Err()
+}
+
+// Apply applies the stored settings to the configuration loaded at startup,
+// returning how many there are
+func (rs *RuntimeSettingsService) Apply() (int, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	overrides, err := rs.overrides()
+	if err != nil || len(overrides) == 0 {
+		return 0, err
+	}
+	next, err := config.LoadWithOverrides(overrides)
+	if err != nil {
+		return 0, err
+	}
+	rs.cfg.Reload(next)
+	return len(overrides), nil
+}
+
+// Reload re-reads the environment, configuration file and stored settings,
+// applying the runtime settings that changed. It returns their names and the
+// names of changed settings that take effect after a restart; an invalid
+// configuration leaves the running one unchanged.
+func (rs *RuntimeSettingsService) Reload() (applied, restartRequired []string, err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.reload()
+}
+
+// reload is Reload for callers holding mu
+func (rs *RuntimeSettingsService) reload() (applied, restartRequired []string, err error) {
+	overrides, err := rs.overrides()
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err := config.LoadWithOverrides(overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, restartRequired = rs.cfg.Reload(next)
+	return applied, restartRequired, nil
+}
+
+// List returns every runtime setting with its current value
+func (rs *RuntimeSettingsService) List() ([]RuntimeSetting, error) {
+	rows, err := rs.db.Query(`SELECT name, updated_by, updated_at FROM runtime_settings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load runtime settings: %w", err)
+	}
+	defer rows.Close()
+
+	type change struct {
+		by *string
+		at *time.Time
+	}
+	changes := map[string]change{}
+	for rows.Next() {
+		var name string
+		var updatedBy sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&name, &updatedBy, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan runtime setting: %w", err)
+		}
+		var c change
+		if updatedBy.Valid {
+			c.by = &updatedBy.String
+		}
+		if updatedAt.Valid {
+			c.at = &updatedAt.Time
+		}
+		changes[name] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Holding mu keeps a concurrent change from landing partway through the list
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	settings := make([]RuntimeSetting, 0, len(config.RuntimeSettings()))
+	for _, name := range config.RuntimeSettings() {
+		setting := RuntimeSetting{Name: name}
+		setting.Value, setting.Source = rs.cfg.Setting(name)
+		if c, ok := changes[name]; ok {
+			setting.UpdatedBy, setting.UpdatedAt = c.by, c.at
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// Set stores a runtime setting and applies it. The value is validated as if
+// it came from the environment before it is stored.
+func (rs *RuntimeSettingsService) Set(name, value, actor string) (*RuntimeSetting, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	value = strings.TrimSpace(value)
+	if !config.IsRuntimeSetting(name) {
+		return nil, ErrNotRuntimeSetting
+	}
+	if value == "" {
+		return nil, fmt.Errorf("%w: %s: a value is required", ErrInvalidSettingValue, name)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	overrides, err := rs.overrides()
+	if err != nil {
+		return nil, err
+	}
+	overrides[name] = value
+	next, err := config.LoadWithOverrides(overrides)
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		for _, problem := range invalid.Problems {
+			if strings.HasPrefix(problem, name+":") {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidSettingValue, strings.TrimSuffix(problem, " (from settings)"))
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := time.Now()
+	_, err = rs.db.Exec(`
+		INSERT INTO runtime_settings (name, value, updated_by, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+	`, name, value, actor, updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save runtime setting: %w", err)
+	}
+	rs.cfg.Reload(next)
+
+	setting := &RuntimeSetting{Name: name, UpdatedBy: &actor, UpdatedAt: &updatedAt}
+	setting.Value, setting.Source = rs.cfg.Setting(name)
+	return setting, nil
+}
+
+// Reset removes a stored runtime setting, so the environment, configuration
+// file or default applies again
+func (rs *RuntimeSettingsService) Reset(name string) (*RuntimeSetting, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !config.IsRuntimeSetting(name) {
+		return nil, ErrNotRuntimeSetting
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if _, err := rs.db.Exec(`DELETE FROM runtime_settings WHERE name = $1`, name); err != nil {
+		return nil, fmt.Errorf("failed to reset runtime setting: %w", err)
+	}
+	if _, _, err := rs.reload(); err != nil {
+		return nil, err
+	}
+
+	setting := &RuntimeSetting{Name: name}
+	setting.Value, setting.Source = rs.cfg.Setting(name)
+	return setting, nil
+}
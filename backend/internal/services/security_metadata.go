@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/database"
+	"networth-dashboard/internal/httpclient"
+	"networth-dashboard/internal/models"
+)
+
+// Security metadata sources besides the provider name
+const (
+	SecurityMetadataManual  = "manual"
+	SecurityMetadataStatic  = "static"
+	SecurityMetadataUnknown = "unknown"
+
+	// UnclassifiedExposure labels holdings without a sector, country or region
+	UnclassifiedExposure = "Unknown"
+)
+
+// metadataLookupsPerRun bounds provider calls per refresh so metadata does not
+// use up the daily price quota
+const metadataLookupsPerRun = 5
+
+// ErrSecurityMetadataNotFound is returned when removing an override that does not exist
+var ErrSecurityMetadataNotFound = errors.New("no stored metadata for symbol")
+
+// alphaVantageOverview is the part of the Alpha Vantage OVERVIEW response used
+type alphaVantageOverview struct {
+	Symbol    string `json:"Symbol"`
+	AssetType string `json:"AssetType"`
+	Name      string `json:"Name"`
+	Country   string `json:"Country"`
+	Sector    string `json:"Sector"`
+	Industry  string `json:"Industry"`
+}
+
+// SecurityMetadataService resolves sector, industry and country for symbols
+// from manual overrides, cached provider lookups and a built-in dataset
+type SecurityMetadataService struct {
+	db      *sql.DB
+	config  *config.ApiConfig
+	client  *http.Client
+	baseURL string
+}
+
+// NewSecurityMetadataService creates a security metadata service. Lookups use
+// the Alpha Vantage key in cfg when one is set.
+func NewSecurityMetadataService(db *sql.DB, cfg *config.ApiConfig) *SecurityMetadataService {
+	return &SecurityMetadataService{
+		db:      db,
+		config:  cfg,
+		client:  httpclient.New("alphavantage", 30*time.Second, cfg.HTTPRetry),
+		baseURL: "https://www.alphavantage.co/query",
+	}
+}
+
+// HeldSymbols returns every symbol held directly or through vested equity
+func (ms *SecurityMetadataService) HeldSymbols() ([]string, error) {
+	rows, err := ms.db.Query(`
+		SELECT symbol FROM stock_holdings WHERE shares_owned > 0
+		UNION
+		SELECT company_symbol FROM equity_grants WHERE vested_shares > 0
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch held symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+	return symbols, rows.Err()
+}
+
+// Lookup returns metadata for each symbol. Stored metadata wins over the
+// built-in dataset; symbols found in neither have source "unknown".
+func (ms *SecurityMetadataService) Lookup(symbols []string) (map[string]models.SecurityMetadata, error) {
+	rows, err := ms.db.Query(`
+		SELECT symbol, name, sector, industry, country, asset_type, source, updated_at
+		FROM security_metadata
+		WHERE `+database.DialectOf(ms.db).InArray("symbol", "$1"), symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch security metadata: %w", err)
+	}
+	defer rows.Close()
+
+	metadata := make(map[string]models.SecurityMetadata, len(symbols))
+	for rows.Next() {
+		var m models.SecurityMetadata
+		var updatedAt time.Time
+		err := rows.Scan(&m.Symbol, &m.Name, &m.Sector, &m.Industry, &m.Country, &m.AssetType, &m.Source, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan security metadata: %w", err)
+		}
+		m.UpdatedAt = &updatedAt
+		metadata[m.Symbol] = m
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch security metadata: %w", err)
+	}
+
+	for _, symbol := range symbols {
+		if _, ok := metadata[symbol]; ok {
+			continue
+		}
+		if s, ok := staticSecurities[symbol]; ok {
+			metadata[symbol] = models.SecurityMetadata{
+				Symbol:    symbol,
+				Name:      &s.name,
+				Sector:    &s.sector,
+				Industry:  &s.industry,
+				Country:   &s.country,
+				AssetType: &s.assetType,
+				Source:    SecurityMetadataStatic,
+			}
+			continue
+		}
+		metadata[symbol] = models.SecurityMetadata{Symbol: symbol, Source: SecurityMetadataUnknown}
+	}
+	return metadata, nil
+}
+
+// SetOverride stores manual metadata for a symbol, which provider refreshes never replace
+func (ms *SecurityMetadataService) SetOverride(symbol string, input models.SecurityMetadataInput) error {
+	_, err := ms.db.Exec(`
+		INSERT INTO security_metadata (symbol, name, sector, industry, country, asset_type, source, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+		ON CONFLICT (symbol) DO UPDATE SET
+			name = EXCLUDED.name, sector = EXCLUDED.sector, industry = EXCLUDED.industry,
+			country = EXCLUDED.country, asset_type = EXCLUDED.asset_type,
+			source = EXCLUDED.source, updated_at = EXCLUDED.updated_at
+	`, symbol, input.Name, input.Sector, input.Industry, input.Country, input.AssetType, SecurityMetadataManual)
+	if err != nil {
+		return fmt.Errorf("failed to save security metadata: %w", err)
+	}
+	return nil
+}
+
+// DeleteStored removes stored metadata for a symbol so it is resolved again
+func (ms *SecurityMetadataService) DeleteStored(symbol string) error {
+	result, err := ms.db.Exec("DELETE FROM security_metadata WHERE symbol = $1", symbol)
+	if err != nil {
+		return fmt.Errorf("failed to delete security metadata: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deletion result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSecurityMetadataNotFound
+	}
+	return nil
+}
+
+// Run refreshes metadata for held symbols now and then every interval until ctx is done
+func (ms *SecurityMetadataService) Run(ctx context.Context, interval time.Duration) {
+	refresh := func() {
+		if _, err := ms.Refresh(ctx); err != nil {
+			fmt.Printf("WARNING: Security metadata refresh failed: %v\n", err)
+		}
+	}
+
+	refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// Refresh looks up held symbols that are neither stored nor in the built-in
+// dataset, then the composition of held funds, at most metadataLookupsPerRun
+// per call, and returns how many were stored. Without an Alpha Vantage key
+// nothing is looked up.
+func (ms *SecurityMetadataService) Refresh(ctx context.Context) (int, error) {
+	if ms.config.AlphaVantageAPIKey == "" {
+		return 0, nil
+	}
+
+	symbols, err := ms.HeldSymbols()
+	if err != nil {
+		return 0, err
+	}
+	metadata, err := ms.Lookup(symbols)
+	if err != nil {
+		return 0, err
+	}
+
+	var stored, lookups int
+	for _, symbol := range symbols {
+		if metadata[symbol].Source != SecurityMetadataUnknown {
+			continue
+		}
+		if lookups == metadataLookupsPerRun {
+			break
+		}
+		lookups++
+
+		overview, err := ms.fetchOverview(ctx, symbol)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to look up metadata for %s: %v\n", symbol, err)
+			continue
+		}
+		if err := ms.store(symbol, overview); err != nil {
+			return stored, err
+		}
+		stored++
+	}
+
+	if stored > 0 {
+		fmt.Printf("INFO: Stored security metadata for %d symbols\n", stored)
+	}
+
+	// Funds are broken down with what is left of the budget, once they are
+	// known to be funds
+	funds, err := ms.refreshCompositions(ctx, symbols, metadataLookupsPerRun-lookups)
+	if funds > 0 {
+		fmt.Printf("INFO: Stored fund compositions for %d symbols\n", funds)
+	}
+	return stored + funds, err
+}
+
+// fetchOverview calls the Alpha Vantage OVERVIEW endpoint for a symbol
+func (ms *SecurityMetadataService) fetchOverview(ctx context.Context, symbol string) (*alphaVantageOverview, error) {
+	query := url.Values{"function": {"OVERVIEW"}, "symbol": {symbol}, "apikey": {ms.config.AlphaVantageAPIKey}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ms.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ms.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Alpha Vantage returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var overview alphaVantageOverview
+	if err := json.Unmarshal(body, &overview); err != nil {
+		return nil, fmt.Errorf("failed to parse overview: %w", err)
+	}
+	// Unknown symbols, rate limits and errors all come back without a symbol
+	if overview.Symbol == "" {
+		return nil, fmt.Errorf("no overview available")
+	}
+	return &overview, nil
+}
+
+// store saves provider metadata without replacing a manual override
+func (ms *SecurityMetadataService) store(symbol string, overview *alphaVantageOverview) error {
+	_, err := ms.db.Exec(`
+		INSERT INTO security_metadata (symbol, name, sector, industry, country, asset_type, source, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'alphavantage', CURRENT_TIMESTAMP)
+		ON CONFLICT (symbol) DO UPDATE SET
+			name = EXCLUDED.name, sector = EXCLUDED.sector, industry = EXCLUDED.industry,
+			country = EXCLUDED.country, asset_type = EXCLUDED.asset_type,
+			source = EXCLUDED.source, updated_at = EXCLUDED.updated_at
+		WHERE security_metadata.source <> 'manual'
+	`, symbol, nullIfEmpty(overview.Name), nullIfEmpty(titleCase(overview.Sector)),
+		nullIfEmpty(titleCase(overview.Industry)), nullIfEmpty(overview.Country), nullIfEmpty(overview.AssetType))
+	if err != nil {
+		return fmt.Errorf("failed to save security metadata for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// ExposurePosition is one holding's share of the stock portfolio and of total assets
+type ExposurePosition struct {
+	Symbol               string  `json:"symbol"`
+	Name                 string  `json:"name"`
+	Value                float64 `json:"value"`
+	PercentOfPortfolio   float64 `json:"percent_of_portfolio"`
+	PercentOfTotalAssets float64 `json:"percent_of_total_assets"`
+	Sector               string  `json:"sector"`
+	Industry             string  `json:"industry"`
+	Country              string  `json:"country"`
+	Region               string  `json:"region"`
+}
+
+// ExposureBucket is the combined value of the holdings in one sector, country or region
+type ExposureBucket struct {
+	Name    string   `json:"name"`
+	Value   float64  `json:"value"`
+	Percent float64  `json:"percent"`
+	Symbols []string `json:"symbols"`
+}
+
+// ExposureReport breaks the stock portfolio down by sector, country and region
+// and measures how concentrated it is
+type ExposureReport struct {
+	PortfolioValue float64 `json:"portfolio_value"`
+	TotalAssets    float64 `json:"total_assets"`
+	// TopPositionPercent and TopFivePercent are shares of the stock portfolio
+	TopPositionPercent float64 `json:"top_position_percent"`
+	TopFivePercent     float64 `json:"top_five_percent"`
+	// HerfindahlIndex is the sum of squared position weights (1/N when
+	// equally weighted, 1 for a single stock)
+	HerfindahlIndex     float64            `json:"herfindahl_index"`
+	Sectors             []ExposureBucket   `json:"sectors"`
+	Countries           []ExposureBucket   `json:"countries"`
+	Regions             []ExposureBucket   `json:"regions"`
+	Positions           []ExposurePosition `json:"positions"`
+	UnclassifiedSymbols []string           `json:"unclassified_symbols"`
+	// LookThrough is set when funds were split across what they hold, and
+	// LookedThroughSymbols lists the funds that were
+	LookThrough          bool     `json:"look_through"`
+	LookedThroughSymbols []string `json:"looked_through_symbols,omitempty"`
+}
+
+// BuildExposure computes exposure for consolidated stock positions. Positions
+// are expected largest first, as returned by the consolidated stock view.
+// Funds in lookThrough count toward the sectors and countries they invest in;
+// with a nil lookThrough every position counts as a whole.
+func BuildExposure(stocks []models.StockConsolidation, metadata map[string]models.SecurityMetadata,
+	lookThrough map[string]FundExposure, totalAssets float64) *ExposureReport {
+	report := &ExposureReport{
+		TotalAssets:         roundCents(totalAssets),
+		Positions:           make([]ExposurePosition, 0, len(stocks)),
+		UnclassifiedSymbols: []string{},
+		LookThrough:         lookThrough != nil,
+	}
+	for _, stock := range stocks {
+		report.PortfolioValue += stock.TotalValue
+	}
+
+	sectors := make(exposureBuckets)
+	countries := make(exposureBuckets)
+	regions := make(exposureBuckets)
+	for i, stock := range stocks {
+		m := metadata[strings.ToUpper(stock.Symbol)]
+		position := ExposurePosition{
+			Symbol:   stock.Symbol,
+			Name:     stock.CompanyName,
+			Value:    roundCents(stock.TotalValue),
+			Sector:   valueOr(m.Sector, UnclassifiedExposure),
+			Industry: valueOr(m.Industry, UnclassifiedExposure),
+			Country:  valueOr(m.Country, UnclassifiedExposure),
+		}
+		if m.Name != nil && *m.Name != "" {
+			position.Name = *m.Name
+		}
+		position.Region = regionOf(position.Country)
+		if m.Source == SecurityMetadataUnknown {
+			report.UnclassifiedSymbols = append(report.UnclassifiedSymbols, stock.Symbol)
+		}
+
+		weight := percentOf(stock.TotalValue, report.PortfolioValue)
+		position.PercentOfPortfolio = roundCents(weight)
+		position.PercentOfTotalAssets = roundCents(percentOf(stock.TotalValue, totalAssets))
+		report.HerfindahlIndex += (weight / 100) * (weight / 100)
+		if i == 0 {
+			report.TopPositionPercent = position.PercentOfPortfolio
+		}
+		if i < 5 {
+			report.TopFivePercent += weight
+		}
+
+		report.Positions = append(report.Positions, position)
+		if exposure, ok := lookThrough[strings.ToUpper(stock.Symbol)]; ok {
+			report.LookedThroughSymbols = append(report.LookedThroughSymbols, stock.Symbol)
+			for sector, w := range exposure.Sectors {
+				sectors.add(sector, stock.Symbol, stock.TotalValue*w)
+			}
+			for country, w := range exposure.Countries {
+				countries.add(country, stock.Symbol, stock.TotalValue*w)
+				regions.add(regionOf(country), stock.Symbol, stock.TotalValue*w)
+			}
+			continue
+		}
+		sectors.add(position.Sector, stock.Symbol, stock.TotalValue)
+		countries.add(position.Country, stock.Symbol, stock.TotalValue)
+		regions.add(position.Region, stock.Symbol, stock.TotalValue)
+	}
+
+	report.Sectors = sectors.list(report.PortfolioValue)
+	report.Countries = countries.list(report.PortfolioValue)
+	report.Regions = regions.list(report.PortfolioValue)
+	report.PortfolioValue = roundCents(report.PortfolioValue)
+	report.TopFivePercent = roundCents(report.TopFivePercent)
+	report.HerfindahlIndex = math.Round(report.HerfindahlIndex*10000) / 10000
+	return report
+}
+
+// exposureBuckets accumulates holdings by name
+type exposureBuckets map[string]*ExposureBucket
+
+func (b exposureBuckets) add(name, symbol string, value float64) {
+	bucket, ok := b[name]
+	if !ok {
+		bucket = &ExposureBucket{Name: name, Symbols: []string{}}
+		b[name] = bucket
+	}
+	bucket.Value += value
+	// A fund's countries can share a region
+	if n := len(bucket.Symbols); n > 0 && bucket.Symbols[n-1] == symbol {
+		return
+	}
+	bucket.Symbols = append(bucket.Symbols, symbol)
+}
+
+// list returns the buckets largest first with their share of total
+func (b exposureBuckets) list(total float64) []ExposureBucket {
+	buckets := make([]ExposureBucket, 0, len(b))
+	for _, bucket := range b {
+		bucket.Percent = roundCents(percentOf(bucket.Value, total))
+		bucket.Value = roundCents(bucket.Value)
+		buckets = append(buckets, *bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Value != buckets[j].Value {
+			return buckets[i].Value > buckets[j].Value
+		}
+		return buckets[i].Name < buckets[j].Name
+	})
+	return buckets
+}
+
+// regionOf maps a country to its region
+func regionOf(country string) string {
+	if region, ok := countryRegions[strings.ToUpper(country)]; ok {
+		return region
+	}
+	if country == UnclassifiedExposure {
+		return UnclassifiedExposure
+	}
+	return "Other"
+}
+
+func percentOf(value, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return value / total * 100
+}
+
+func valueOr(value *string, fallback string) string {
+	if value == nil || *value == "" {
+		return fallback
+	}
+	return *value
+}
+
+// nullIfEmpty treats empty values and Alpha Vantage's "None" as missing
+func nullIfEmpty(value string) *string {
+	if value == "" || value == "None" {
+		return nil
+	}
+	return &value
+}
+
+// titleCase turns provider values such as "ELECTRONIC COMPUTERS" into "Electronic Computers"
+func titleCase(value string) string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
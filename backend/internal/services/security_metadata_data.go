@@ -0,0 +1,146 @@
+package services
+
+// staticSecurity is a built-in classification for a widely held symbol
+type staticSecurity struct {
+	name, sector, industry, country, assetType string
+}
+
+// Sector names follow GICS. Broad funds use the "Diversified" sector and the
+// country or region they invest in.
+const (
+	sectorTechnology            = "Information Technology"
+	sectorCommunication         = "Communication Services"
+	sectorConsumerDiscretionary = "Consumer Discretionary"
+	sectorConsumerStaples       = "Consumer Staples"
+	sectorHealthCare            = "Health Care"
+	sectorFinancials            = "Financials"
+	sectorEnergy                = "Energy"
+	sectorIndustrials           = "Industrials"
+	sectorMaterials             = "Materials"
+	sectorUtilities             = "Utilities"
+	sectorFixedIncome           = "Fixed Income"
+	sectorRealEstate            = "Real Estate"
+	sectorDiversified           = "Diversified"
+
+	assetTypeStock      = "Common Stock"
+	assetTypeETF        = "ETF"
+	assetTypeMutualFund = "Mutual Fund"
+
+	countryUS            = "United States"
+	countryGlobal        = "Global"
+	countryInternational = "International"
+)
+
+// staticSecurities classifies common stocks and funds without a provider call
+var staticSecurities = map[string]staticSecurity{
+	// Large-cap US stocks
+	"AAPL":  {"Apple Inc.", sectorTechnology, "Technology Hardware", countryUS, assetTypeStock},
+	"MSFT":  {"Microsoft Corporation", sectorTechnology, "Software", countryUS, assetTypeStock},
+	"NVDA":  {"NVIDIA Corporation", sectorTechnology, "Semiconductors", countryUS, assetTypeStock},
+	"AVGO":  {"Broadcom Inc.", sectorTechnology, "Semiconductors", countryUS, assetTypeStock},
+	"AMD":   {"Advanced Micro Devices, Inc.", sectorTechnology, "Semiconductors", countryUS, assetTypeStock},
+	"INTC":  {"Intel Corporation", sectorTechnology, "Semiconductors", countryUS, assetTypeStock},
+	"ORCL":  {"Oracle Corporation", sectorTechnology, "Software", countryUS, assetTypeStock},
+	"CRM":   {"Salesforce, Inc.", sectorTechnology, "Software", countryUS, assetTypeStock},
+	"ADBE":  {"Adobe Inc.", sectorTechnology, "Software", countryUS, assetTypeStock},
+	"CSCO":  {"Cisco Systems, Inc.", sectorTechnology, "Communications Equipment", countryUS, assetTypeStock},
+	"IBM":   {"International Business Machines Corporation", sectorTechnology, "IT Services", countryUS, assetTypeStock},
+	"GOOGL": {"Alphabet Inc. Class A", sectorCommunication, "Interactive Media & Services", countryUS, assetTypeStock},
+	"GOOG":  {"Alphabet Inc. Class C", sectorCommunication, "Interactive Media & Services", countryUS, assetTypeStock},
+	"META":  {"Meta Platforms, Inc.", sectorCommunication, "Interactive Media & Services", countryUS, assetTypeStock},
+	"NFLX":  {"Netflix, Inc.", sectorCommunication, "Entertainment", countryUS, assetTypeStock},
+	"DIS":   {"The Walt Disney Company", sectorCommunication, "Entertainment", countryUS, assetTypeStock},
+	"AMZN":  {"Amazon.com, Inc.", sectorConsumerDiscretionary, "Broadline Retail", countryUS, assetTypeStock},
+	"TSLA":  {"Tesla, Inc.", sectorConsumerDiscretionary, "Automobiles", countryUS, assetTypeStock},
+	"HD":    {"The Home Depot, Inc.", sectorConsumerDiscretionary, "Specialty Retail", countryUS, assetTypeStock},
+	"NKE":   {"NIKE, Inc.", sectorConsumerDiscretionary, "Textiles, Apparel & Luxury Goods", countryUS, assetTypeStock},
+	"MCD":   {"McDonald's Corporation", sectorConsumerDiscretionary, "Hotels, Restaurants & Leisure", countryUS, assetTypeStock},
+	"SBUX":  {"Starbucks Corporation", sectorConsumerDiscretionary, "Hotels, Restaurants & Leisure", countryUS, assetTypeStock},
+	"WMT":   {"Walmart Inc.", sectorConsumerStaples, "Consumer Staples Distribution", countryUS, assetTypeStock},
+	"COST":  {"Costco Wholesale Corporation", sectorConsumerStaples, "Consumer Staples Distribution", countryUS, assetTypeStock},
+	"PG":    {"The Procter & Gamble Company", sectorConsumerStaples, "Household Products", countryUS, assetTypeStock},
+	"KO":    {"The Coca-Cola Company", sectorConsumerStaples, "Beverages", countryUS, assetTypeStock},
+	"PEP":   {"PepsiCo, Inc.", sectorConsumerStaples, "Beverages", countryUS, assetTypeStock},
+	"JNJ":   {"Johnson & Johnson", sectorHealthCare, "Pharmaceuticals", countryUS, assetTypeStock},
+	"LLY":   {"Eli Lilly and Company", sectorHealthCare, "Pharmaceuticals", countryUS, assetTypeStock},
+	"PFE":   {"Pfizer Inc.", sectorHealthCare, "Pharmaceuticals", countryUS, assetTypeStock},
+	"MRK":   {"Merck & Co., Inc.", sectorHealthCare, "Pharmaceuticals", countryUS, assetTypeStock},
+	"ABBV":  {"AbbVie Inc.", sectorHealthCare, "Biotechnology", countryUS, assetTypeStock},
+	"UNH":   {"UnitedHealth Group Incorporated", sectorHealthCare, "Health Care Providers & Services", countryUS, assetTypeStock},
+	"JPM":   {"JPMorgan Chase & Co.", sectorFinancials, "Banks", countryUS, assetTypeStock},
+	"BAC":   {"Bank of America Corporation", sectorFinancials, "Banks", countryUS, assetTypeStock},
+	"WFC":   {"Wells Fargo & Company", sectorFinancials, "Banks", countryUS, assetTypeStock},
+	"GS":    {"The Goldman Sachs Group, Inc.", sectorFinancials, "Capital Markets", countryUS, assetTypeStock},
+	"V":     {"Visa Inc.", sectorFinancials, "Financial Services", countryUS, assetTypeStock},
+	"MA":    {"Mastercard Incorporated", sectorFinancials, "Financial Services", countryUS, assetTypeStock},
+	"BRK.B": {"Berkshire Hathaway Inc. Class B", sectorFinancials, "Financial Services", countryUS, assetTypeStock},
+	"XOM":   {"Exxon Mobil Corporation", sectorEnergy, "Oil, Gas & Consumable Fuels", countryUS, assetTypeStock},
+	"CVX":   {"Chevron Corporation", sectorEnergy, "Oil, Gas & Consumable Fuels", countryUS, assetTypeStock},
+	"BA":    {"The Boeing Company", sectorIndustrials, "Aerospace & Defense", countryUS, assetTypeStock},
+	"CAT":   {"Caterpillar Inc.", sectorIndustrials, "Machinery", countryUS, assetTypeStock},
+	"GE":    {"GE Aerospace", sectorIndustrials, "Aerospace & Defense", countryUS, assetTypeStock},
+	"UPS":   {"United Parcel Service, Inc.", sectorIndustrials, "Air Freight & Logistics", countryUS, assetTypeStock},
+
+	// Large-cap international stocks
+	"TSM":  {"Taiwan Semiconductor Manufacturing Company Limited", sectorTechnology, "Semiconductors", "Taiwan", assetTypeStock},
+	"ASML": {"ASML Holding N.V.", sectorTechnology, "Semiconductor Equipment", "Netherlands", assetTypeStock},
+	"SAP":  {"SAP SE", sectorTechnology, "Software", "Germany", assetTypeStock},
+	"NVO":  {"Novo Nordisk A/S", sectorHealthCare, "Pharmaceuticals", "Denmark", assetTypeStock},
+	"TM":   {"Toyota Motor Corporation", sectorConsumerDiscretionary, "Automobiles", "Japan", assetTypeStock},
+	"BABA": {"Alibaba Group Holding Limited", sectorConsumerDiscretionary, "Broadline Retail", "China", assetTypeStock},
+	"SHOP": {"Shopify Inc.", sectorTechnology, "IT Services", "Canada", assetTypeStock},
+
+	// Funds
+	"SPY":  {"SPDR S&P 500 ETF Trust", sectorDiversified, "US Large Cap", countryUS, assetTypeETF},
+	"VOO":  {"Vanguard S&P 500 ETF", sectorDiversified, "US Large Cap", countryUS, assetTypeETF},
+	"IVV":  {"iShares Core S&P 500 ETF", sectorDiversified, "US Large Cap", countryUS, assetTypeETF},
+	"VTI":  {"Vanguard Total Stock Market ETF", sectorDiversified, "US Total Market", countryUS, assetTypeETF},
+	"QQQ":  {"Invesco QQQ Trust", sectorTechnology, "US Large Cap Growth", countryUS, assetTypeETF},
+	"DIA":  {"SPDR Dow Jones Industrial Average ETF Trust", sectorDiversified, "US Large Cap", countryUS, assetTypeETF},
+	"IWM":  {"iShares Russell 2000 ETF", sectorDiversified, "US Small Cap", countryUS, assetTypeETF},
+	"SCHD": {"Schwab U.S. Dividend Equity ETF", sectorDiversified, "US Dividend", countryUS, assetTypeETF},
+	"VT":   {"Vanguard Total World Stock ETF", sectorDiversified, "Global Total Market", countryGlobal, assetTypeETF},
+	"VXUS": {"Vanguard Total International Stock ETF", sectorDiversified, "International Total Market", countryInternational, assetTypeETF},
+	"VEA":  {"Vanguard FTSE Developed Markets ETF", sectorDiversified, "International Developed", "International", assetTypeETF},
+	"VWO":  {"Vanguard FTSE Emerging Markets ETF", sectorDiversified, "Emerging Markets", "Emerging Markets", assetTypeETF},
+	"EFA":  {"iShares MSCI EAFE ETF", sectorDiversified, "International Developed", "International", assetTypeETF},
+	"AGG":  {"iShares Core U.S. Aggregate Bond ETF", sectorFixedIncome, "US Aggregate Bonds", countryUS, assetTypeETF},
+	"BND":  {"Vanguard Total Bond Market ETF", sectorFixedIncome, "US Aggregate Bonds", countryUS, assetTypeETF},
+	"TLT":  {"iShares 20+ Year Treasury Bond ETF", sectorFixedIncome, "US Treasuries", countryUS, assetTypeETF},
+	"VNQ":  {"Vanguard Real Estate ETF", sectorRealEstate, "US REITs", countryUS, assetTypeETF},
+	"GLD":  {"SPDR Gold Shares", "Commodities", "Gold", countryGlobal, assetTypeETF},
+	"BNDX": {"Vanguard Total International Bond ETF", sectorFixedIncome, "International Bonds", countryInternational, assetTypeETF},
+
+	// Index mutual funds
+	"VTSAX": {"Vanguard Total Stock Market Index Fund Admiral Shares", sectorDiversified, "US Total Market", countryUS, assetTypeMutualFund},
+	"VFIAX": {"Vanguard 500 Index Fund Admiral Shares", sectorDiversified, "US Large Cap", countryUS, assetTypeMutualFund},
+	"FXAIX": {"Fidelity 500 Index Fund", sectorDiversified, "US Large Cap", countryUS, assetTypeMutualFund},
+	"VTIAX": {"Vanguard Total International Stock Index Fund Admiral Shares", sectorDiversified, "International Total Market", countryInternational, assetTypeMutualFund},
+	"VBTLX": {"Vanguard Total Bond Market Index Fund Admiral Shares", sectorFixedIncome, "US Aggregate Bonds", countryUS, assetTypeMutualFund},
+
+	// Target-date funds
+	"VTTVX": {"Vanguard Target Retirement 2025 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VTHRX": {"Vanguard Target Retirement 2030 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VTTHX": {"Vanguard Target Retirement 2035 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VFORX": {"Vanguard Target Retirement 2040 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VTIVX": {"Vanguard Target Retirement 2045 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VFIFX": {"Vanguard Target Retirement 2050 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VFFVX": {"Vanguard Target Retirement 2055 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VTTSX": {"Vanguard Target Retirement 2060 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+	"VLXVX": {"Vanguard Target Retirement 2065 Fund", sectorDiversified, "Target Date", countryGlobal, assetTypeMutualFund},
+}
+
+// countryRegions maps countries, as returned by providers or entered by hand,
+// to a region
+var countryRegions = map[string]string{
+	"UNITED STATES": "North America", "USA": "North America", "US": "North America", "CANADA": "North America",
+	"UNITED KINGDOM": "Europe", "UK": "Europe", "GERMANY": "Europe", "FRANCE": "Europe", "NETHERLANDS": "Europe",
+	"SWITZERLAND": "Europe", "IRELAND": "Europe", "DENMARK": "Europe", "SWEDEN": "Europe", "NORWAY": "Europe",
+	"FINLAND": "Europe", "SPAIN": "Europe", "ITALY": "Europe", "BELGIUM": "Europe", "LUXEMBOURG": "Europe",
+	"JAPAN": "Asia Pacific", "CHINA": "Asia Pacific", "HONG KONG": "Asia Pacific", "TAIWAN": "Asia Pacific",
+	"SOUTH KOREA": "Asia Pacific", "KOREA": "Asia Pacific", "INDIA": "Asia Pacific", "SINGAPORE": "Asia Pacific",
+	"AUSTRALIA": "Asia Pacific", "NEW ZEALAND": "Asia Pacific",
+	"BRAZIL": "Latin America", "MEXICO": "Latin America", "ARGENTINA": "Latin America", "CHILE": "Latin America",
+	"ISRAEL": "Middle East & Africa", "SOUTH AFRICA": "Middle East & Africa",
+	"GLOBAL": "Global", "INTERNATIONAL": "International ex-US", "EMERGING MARKETS": "Emerging Markets",
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+)
+
+// Onboarding setup steps, in the order the first-run wizard presents them
+const (
+	SetupStepAddAccounts        = "add_accounts"
+	SetupStepBaseCurrency       = "base_currency"
+	SetupStepConfigureProviders = "configure_providers"
+	SetupStepFirstRefresh       = "first_refresh"
+)
+
+// SupportedBaseCurrencies lists the currencies that can be selected as base currency
+var SupportedBaseCurrencies = []string{"USD", "EUR", "GBP", "CAD"}
+
+// SetupStepDefinition describes a single onboarding step
+type SetupStepDefinition struct {
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var setupSteps = []SetupStepDefinition{
+	{Key: SetupStepAddAccounts, Title: "Add accounts", Description: "Add at least one holding or account to start tracking net worth"},
+	{Key: SetupStepBaseCurrency, Title: "Set base currency", Description: "Choose the currency used for totals and reports"},
+	{Key: SetupStepConfigureProviders, Title: "Configure providers", Description: "Set up stock and crypto price provider API keys"},
+	{Key: SetupStepFirstRefresh, Title: "First refresh", Description: "Fetch current prices for your holdings"},
+}
+
+// SetupStepStatus represents the completion state of an onboarding step
+type SetupStepStatus struct {
+	SetupStepDefinition
+	Completed   bool                   `json:"completed"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	Detected    bool                   `json:"detected"` // Completion inferred from existing data
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+// SetupState summarizes onboarding progress
+type SetupState struct {
+	Steps          []SetupStepStatus `json:"steps"`
+	CompletedSteps int               `json:"completed_steps"`
+	TotalSteps     int               `json:"total_steps"`
+	IsComplete     bool              `json:"is_complete"`
+	NextStep       string            `json:"next_step,omitempty"`
+	BaseCurrency   string            `json:"base_currency"`
+}
+
+// SetupService tracks progress through the first-run onboarding flow
+type SetupService struct {
+	db     *sql.DB
+	config *config.ApiConfig
+}
+
+// NewSetupService creates a new setup service
+func NewSetupService(db *sql.DB, cfg *config.ApiConfig) *SetupService {
+	return &SetupService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// IsValidStep reports whether the given key is a known onboarding step
+func (ss *SetupService) IsValidStep(step string) bool {
+	for _, def := range setupSteps {
+		if def.Key == step {
+			return true
+		}
+	}
+	return false
+}
+
+// GetState returns the current onboarding state, combining explicitly marked
+// steps with completion detected from existing data
+func (ss *SetupService) GetState() (*SetupState, error) {
+	marked, err := ss.getMarkedSteps()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &SetupState{
+		Steps:        make([]SetupStepStatus, 0, len(setupSteps)),
+		TotalSteps:   len(setupSteps),
+		BaseCurrency: ss.GetBaseCurrency(),
+	}
+
+	for _, def := range setupSteps {
+		status := SetupStepStatus{SetupStepDefinition: def}
+		if m, exists := marked[def.Key]; exists {
+			status.Completed = m.Completed
+			status.CompletedAt = m.CompletedAt
+			status.Data = m.Data
+		}
+		if !status.Completed {
+			status.Detected = ss.detectStep(def.Key)
+			status.Completed = status.Detected
+		}
+
+		if status.Completed {
+			state.CompletedSteps++
+		} else if state.NextStep == "" {
+			state.NextStep = def.Key
+		}
+		state.Steps = append(state.Steps, status)
+	}
+
+	state.IsComplete = state.CompletedSteps == state.TotalSteps
+	return state, nil
+}
+
+// CompleteStep marks an onboarding step as complete with optional step data
+func (ss *SetupService) CompleteStep(step string, data map[string]interface{}) error {
+	if !ss.IsValidStep(step) {
+		return fmt.Errorf("unknown setup step: %s", step)
+	}
+
+	if step == SetupStepBaseCurrency {
+		currency, _ := data["currency"].(string)
+		if err := ss.SetBaseCurrency(currency); err != nil {
+			return err
+		}
+	}
+
+	var dataJSON sql.NullString
+	if len(data) > 0 {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode step data: %w", err)
+		}
+		dataJSON = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	query := `
+		INSERT INTO setup_state (step, completed, completed_at, data, updated_at)
+		VALUES ($1, true, $2, $3, $2)
+		ON CONFLICT (step) DO UPDATE SET
+			completed = true,
+			completed_at = EXCLUDED.completed_at,
+			data = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := ss.db.Exec(query, step, time.Now(), dataJSON); err != nil {
+		return fmt.Errorf("failed to mark setup step %s complete: %w", step, err)
+	}
+
+	return nil
+}
+
+// ResetStep marks an onboarding step as incomplete
+func (ss *SetupService) ResetStep(step string) error {
+	if !ss.IsValidStep(step) {
+		return fmt.Errorf("unknown setup step: %s", step)
+	}
+
+	query := `
+		INSERT INTO setup_state (step, completed, completed_at, data, updated_at)
+		VALUES ($1, false, NULL, NULL, $2)
+		ON CONFLICT (step) DO UPDATE SET
+			completed = false,
+			completed_at = NULL,
+			data = NULL,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := ss.db.Exec(query, step, time.Now()); err != nil {
+		return fmt.Errorf("failed to reset setup step %s: %w", step, err)
+	}
+
+	return nil
+}
+
+// GetBaseCurrency returns the configured base currency, defaulting to USD
+func (ss *SetupService) GetBaseCurrency() string {
+	var currency string
+	err := ss.db.QueryRow(`SELECT value FROM app_settings WHERE key = 'base_currency'`).Scan(&currency)
+	if err != nil || currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// SetBaseCurrency stores the base currency setting
+func (ss *SetupService) SetBaseCurrency(currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	supported := false
+	for _, c := range SupportedBaseCurrencies {
+		if c == currency {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported base currency %q (supported: %s)", currency, strings.Join(SupportedBaseCurrencies, ", "))
+	}
+
+	query := `
+		INSERT INTO app_settings (key, value, updated_at)
+		VALUES ('base_currency', $1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := ss.db.Exec(query, currency, time.Now()); err != nil {
+		return fmt.Errorf("failed to save base currency: %w", err)
+	}
+
+	return nil
+}
+
+// markedStep holds a persisted setup_state row
+type markedStep struct {
+	Completed   bool
+	CompletedAt *time.Time
+	Data        map[string]interface{}
+}
+
+// getMarkedSteps loads explicitly marked steps from the database
+func (ss *SetupService) getMarkedSteps() (map[string]markedStep, error) {
+	rows, err := ss.db.Query(`SELECT step, completed, completed_at, data FROM setup_state`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query setup state: %w", err)
+	}
+	defer rows.Close()
+
+	marked := make(map[string]markedStep)
+	for rows.Next() {
+		var step string
+		var completed bool
+		var completedAt sql.NullTime
+		var data sql.NullString
+
+		if err := rows.Scan(&step, &completed, &completedAt, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan setup state: %w", err)
+		}
+
+		m := markedStep{Completed: completed}
+		if completedAt.Valid {
+			m.CompletedAt = &completedAt.Time
+		}
+		if data.Valid {
+			json.Unmarshal([]byte(data.String), &m.Data)
+		}
+		marked[step] = m
+	}
+
+	return marked, nil
+}
+
+// detectStep infers step completion from existing data so that installations
+// with data entered before onboarding existed are not asked to repeat steps
+func (ss *SetupService) detectStep(step string) bool {
+	var query string
+
+	switch step {
+	case SetupStepAddAccounts:
+		query = `
+			SELECT (SELECT COUNT(*) FROM stock_holdings) +
+			       (SELECT COUNT(*) FROM equity_grants) +
+			       (SELECT COUNT(*) FROM real_estate_properties) +
+			       (SELECT COUNT(*) FROM cash_holdings) +
+			       (SELECT COUNT(*) FROM crypto_holdings) +
+			       (SELECT COUNT(*) FROM miscellaneous_assets)
+		`
+	case SetupStepConfigureProviders:
+		if ss.config.TwelveDataAPIKey != "" || ss.config.AlphaVantageAPIKey != "" || ss.config.CoinMarketCapAPIKey != "" {
+			return true
+		}
+		query = `SELECT COUNT(*) FROM credentials`
+	case SetupStepFirstRefresh:
+		query = `SELECT (SELECT COUNT(*) FROM stock_prices) + (SELECT COUNT(*) FROM crypto_prices)`
+	default:
+		return false
+	}
+
+	var count int
+	if err := ss.db.QueryRow(query).Scan(&count); err != nil {
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Share link value modes: how absolute dollar amounts appear in a snapshot
+const (
+	// ShareValuesMasked leaves dollar amounts out, sharing only percentages
+	ShareValuesMasked = "masked"
+	// ShareValuesScaled multiplies every amount by one factor so total assets
+	// come to a chosen figure, keeping proportions but hiding the real ones
+	ShareValuesScaled = "scaled"
+	// ShareValuesExact shares the real amounts
+	ShareValuesExact = "exact"
+)
+
+const (
+	// defaultShareScaleTo is what scaled snapshots set total assets to
+	defaultShareScaleTo = 100000
+	// defaultShareExpiryDays is how long a link works when no expiry is given
+	defaultShareExpiryDays = 30
+)
+
+var (
+	// ErrShareLinkNotFound is returned for an unknown share link, or a token
+	// that is unknown, revoked or expired
+	ErrShareLinkNotFound = errors.New("share link not found")
+)
+
+// ShareLinkInput describes a share link to create
+type ShareLinkInput struct {
+	Label     string `json:"label" binding:"max=200"`
+	ValueMode string `json:"value_mode" binding:"omitempty,oneof=masked scaled exact"`
+	// ScaleTo is the total assets figure of a scaled snapshot
+	ScaleTo float64 `json:"scale_to" binding:"omitempty,gt=0"`
+	// IncludeHoldings lists holdings by name under each asset class
+	IncludeHoldings bool `json:"include_holdings"`
+	// ExpiresInDays defaults to 30; NoExpiry keeps the link until revoked
+	ExpiresInDays int  `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	NoExpiry      bool `json:"no_expiry"`
+}
+
+// ShareLink is a read-only link to an allocation snapshot. The token is only
+// known when the link is created.
+type ShareLink struct {
+	ID              int        `json:"id"`
+	Token           string     `json:"token,omitempty"`
+	Label           string     `json:"label"`
+	ValueMode       string     `json:"value_mode"`
+	IncludeHoldings bool       `json:"include_holdings"`
+	CreatedBy       string     `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	ViewCount       int        `json:"view_count"`
+	LastViewedAt    *time.Time `json:"last_viewed_at"`
+}
+
+// ShareSnapshot is the net worth allocation a share link shows, frozen when
+// the link was created. Amounts are nil when masked.
+type ShareSnapshot struct {
+	Label       string            `json:"label,omitempty"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	ValueMode   string            `json:"value_mode"`
+	NetWorth    *float64          `json:"net_worth"`
+	TotalAssets *float64          `json:"total_assets"`
+	Allocation  []ShareAssetClass `json:"allocation"`
+	// UnvestedEquityPercentage is unvested equity as a share of total assets;
+	// like the dashboard, it is not counted in them
+	UnvestedEquityPercentage float64       `json:"unvested_equity_percentage"`
+	Changes                  []ShareChange `json:"changes"`
+}
+
+// ShareAssetClass is one asset class's share of total assets
+type ShareAssetClass struct {
+	Key        string         `json:"key"`
+	Label      string         `json:"label"`
+	Percentage float64        `json:"percentage"`
+	Value      *float64       `json:"value"`
+	Holdings   []ShareHolding `json:"holdings,omitempty"`
+}
+
+// ShareHolding is one holding's share of total assets
+type ShareHolding struct {
+	Name       string   `json:"name"`
+	Percentage float64  `json:"percentage"`
+	Value      *float64 `json:"value"`
+}
+
+// ShareChange is how much net worth moved over a period, nil without history
+// that far back
+type ShareChange struct {
+	Period     string   `json:"period"`
+	Percentage *float64 `json:"percentage"`
+}
+
+// ShareService creates read-only share links to allocation snapshots,
+// resolves their tokens and revokes them
+type ShareService struct {
+	db      *sql.DB
+	history *NetWorthHistoryService
+}
+
+// NewShareService creates a new share service
+func NewShareService(db *sql.DB, history *NetWorthHistoryService) *ShareService {
+	return &ShareService{db: db, history: history}
+}
+
+const shareLinkColumns = `
+	SELECT id, COALESCE(label, ''), value_mode, include_holdings, COALESCE(created_by, ''),
+	       created_at, expires_at, revoked_at, view_count, last_viewed_at
+	FROM share_links
+`
+
+func scanShareLink(row interface{ Scan(...interface{}) error }) (ShareLink, error) {
+	var link ShareLink
+	err := row.Scan(&link.ID, &link.Label, &link.ValueMode, &link.IncludeHoldings, &link.CreatedBy,
+		&link.CreatedAt, &link.ExpiresAt, &link.RevokedAt, &link.ViewCount, &link.LastViewedAt)
+	return link, err
+}
+
+// List returns every share link, newest first
+func (ss *ShareService) List() ([]ShareLink, error) {
+	rows, err := ss.db.Query(shareLinkColumns + `ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []ShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch share links: %w", err)
+	}
+	return links, nil
+}
+
+// Create freezes the current allocation into a snapshot and returns a link to
+// it, with the token that opens it
+func (ss *ShareService) Create(input ShareLinkInput, breakdown models.NetWorthBreakdown, tree []models.BreakdownAssetClass, createdBy string, now time.Time) (*ShareLink, *ShareSnapshot, error) {
+	if input.ValueMode == "" {
+		input.ValueMode = ShareValuesMasked
+	}
+	snapshot, err := ss.newSnapshot(input, breakdown, tree, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode share snapshot: %w", err)
+	}
+
+	var expiresAt *time.Time
+	if !input.NoExpiry {
+		days := input.ExpiresInDays
+		if days == 0 {
+			days = defaultShareExpiryDays
+		}
+		at := now.AddDate(0, 0, days)
+		expiresAt = &at
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	link, err := scanShareLink(ss.db.QueryRow(`
+		INSERT INTO share_links (token_hash, label, value_mode, include_holdings, snapshot, created_by, created_at, expires_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id, COALESCE(label, ''), value_mode, include_holdings, COALESCE(created_by, ''),
+		          created_at, expires_at, revoked_at, view_count, last_viewed_at
+	`, hashSessionToken(token), input.Label, input.ValueMode, input.IncludeHoldings, raw, createdBy, now, expiresAt))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	link.Token = token
+	return &link, snapshot, nil
+}
+
+// Open returns the snapshot behind a token and counts the view. Revoked and
+// expired links are reported as not found, like unknown tokens.
+func (ss *ShareService) Open(token string, now time.Time) (*ShareSnapshot, error) {
+	if token == "" {
+		return nil, ErrShareLinkNotFound
+	}
+	var raw []byte
+	err := ss.db.QueryRow(`
+		UPDATE share_links SET view_count = view_count + 1, last_viewed_at = $2
+		WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING snapshot
+	`, hashSessionToken(token), now).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open share link: %w", err)
+	}
+
+	var snapshot ShareSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode share snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Revoke stops a share link from working. Revoked links stay listed.
+func (ss *ShareService) Revoke(id int, now time.Time) error {
+	result, err := ss.db.Exec(`
+		UPDATE share_links SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1
+	`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrShareLinkNotFound
+	}
+	return nil
+}
+
+// shareChangePeriods are the periods net worth changes are reported over
+var shareChangePeriods = []struct {
+	period string
+	start  func(now time.Time) time.Time
+}{
+	{"1M", func(now time.Time) time.Time { return now.AddDate(0, -1, 0) }},
+	{"3M", func(now time.Time) time.Time { return now.AddDate(0, -3, 0) }},
+	{"YTD", func(now time.Time) time.Time { return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()) }},
+	{"1Y", func(now time.Time) time.Time { return now.AddDate(-1, 0, 0) }},
+}
+
+func (ss *ShareService) newSnapshot(input ShareLinkInput, breakdown models.NetWorthBreakdown, tree []models.BreakdownAssetClass, now time.Time) (*ShareSnapshot, error) {
+	totalAssets := breakdown.TotalAssets()
+
+	// amount shows a dollar amount the way the value mode asks
+	factor := decimal.NewFromInt(1)
+	if input.ValueMode == ShareValuesScaled && !totalAssets.IsZero() {
+		scaleTo := input.ScaleTo
+		if scaleTo == 0 {
+			scaleTo = defaultShareScaleTo
+		}
+		factor = decimal.NewFromFloat(scaleTo).Div(totalAssets)
+	}
+	amount := func(value decimal.Decimal) *float64 {
+		if input.ValueMode == ShareValuesMasked {
+			return nil
+		}
+		scaled := centsOf(value.Mul(factor))
+		return &scaled
+	}
+
+	snapshot := &ShareSnapshot{
+		Label:       input.Label,
+		GeneratedAt: now,
+		ValueMode:   input.ValueMode,
+		NetWorth:    amount(breakdown.NetWorth()),
+		TotalAssets: amount(totalAssets),
+		Allocation:  make([]ShareAssetClass, 0, len(tree)),
+		Changes:     make([]ShareChange, 0, len(shareChangePeriods)),
+	}
+	snapshot.UnvestedEquityPercentage = roundPercent(models.PercentOf(breakdown.UnvestedEquityValue, totalAssets))
+
+	for _, class := range tree {
+		shared := ShareAssetClass{
+			Key:        class.Key,
+			Label:      class.Label,
+			Percentage: roundPercent(class.Percentage),
+			Value:      amount(class.Value),
+		}
+		if input.IncludeHoldings {
+			for _, holding := range shareHoldings(class, totalAssets) {
+				holding.Value = amount(holding.value)
+				shared.Holdings = append(shared.Holdings, holding.ShareHolding)
+			}
+		}
+		snapshot.Allocation = append(snapshot.Allocation, shared)
+	}
+
+	netWorth := breakdown.NetWorth()
+	for _, period := range shareChangePeriods {
+		change := ShareChange{Period: period.period}
+		past, err := ss.history.LatestBefore(period.start(now))
+		if err != nil {
+			return nil, err
+		}
+		if past != nil && !past.NetWorth.IsZero() {
+			percentage := roundPercent(models.PercentOf(netWorth.Sub(past.NetWorth), past.NetWorth.Abs()))
+			change.Percentage = &percentage
+		}
+		snapshot.Changes = append(snapshot.Changes, change)
+	}
+	return snapshot, nil
+}
+
+type shareHolding struct {
+	ShareHolding
+	value decimal.Decimal
+}
+
+// shareHoldings combines an asset class's holdings by name across
+// institutions and accounts, largest first, since a snapshot names neither
+func shareHoldings(class models.BreakdownAssetClass, totalAssets decimal.Decimal) []shareHolding {
+	index := map[string]int{}
+	holdings := []shareHolding{}
+	for _, institution := range class.Institutions {
+		for _, account := range institution.Accounts {
+			for _, holding := range account.Holdings {
+				i, seen := index[holding.Name]
+				if !seen {
+					i = len(holdings)
+					index[holding.Name] = i
+					holdings = append(holdings, shareHolding{ShareHolding: ShareHolding{Name: holding.Name}})
+				}
+				holdings[i].value = holdings[i].value.Add(holding.Value)
+			}
+		}
+	}
+
+	sort.SliceStable(holdings, func(i, j int) bool { return holdings[i].value.GreaterThan(holdings[j].value) })
+	for i := range holdings {
+		holdings[i].Percentage = roundPercent(models.PercentOf(holdings[i].value, totalAssets))
+	}
+	return holdings
+}
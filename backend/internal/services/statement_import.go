@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/shopspring/decimal"
+)
+
+// Document types statements are imported as
+const (
+	DocumentTypeCreditCardStatement = "credit_card_statement"
+	DocumentTypeLoanStatement       = "loan_statement"
+)
+
+var (
+	// ErrUnsupportedDocument is returned for files that aren't PDFs or plain text
+	ErrUnsupportedDocument = errors.New("statements must be PDF or plain text files")
+	// ErrUnknownDocumentType is returned for a document type no extractor reads
+	ErrUnknownDocumentType = errors.New("unknown document type")
+	// ErrNothingExtracted is returned when a statement shows none of the fields read from it
+	ErrNothingExtracted = errors.New("no balance, APR, minimum payment or dates found in the statement")
+)
+
+// StatementFields are what an extractor read from a statement. Fields it
+// didn't find are nil.
+type StatementFields struct {
+	Balance        *decimal.Decimal
+	APR            *float64
+	MinimumPayment *decimal.Decimal
+	DueDate        *time.Time
+	StatementDate  *time.Time
+}
+
+// empty reports whether no field was found
+func (f StatementFields) empty() bool {
+	return f.Balance == nil && f.APR == nil && f.MinimumPayment == nil && f.DueDate == nil && f.StatementDate == nil
+}
+
+// StatementExtractor reads liability fields from the text of one type of statement
+type StatementExtractor interface {
+	// DocumentType names the statements the extractor reads
+	DocumentType() string
+	// Extract returns the fields found in text
+	Extract(text string) StatementFields
+}
+
+// StatementImportService turns uploaded statements into proposed liability
+// updates: it extracts the document's text, then has the extractor
+// registered for the document type read the fields from it
+type StatementImportService struct {
+	extractors map[string]StatementExtractor
+}
+
+// NewStatementImportService creates a statement import service with the
+// credit card and loan statement extractors
+func NewStatementImportService() *StatementImportService {
+	s := &StatementImportService{extractors: map[string]StatementExtractor{}}
+	s.Register(creditCardStatementExtractor)
+	s.Register(loanStatementExtractor)
+	return s
+}
+
+// Register adds an extractor, replacing any for the same document type
+func (s *StatementImportService) Register(extractor StatementExtractor) {
+	s.extractors[extractor.DocumentType()] = extractor
+}
+
+// DocumentTypeFor is the document type statements for a liability type are read as
+func DocumentTypeFor(liabilityType string) string {
+	if liabilityType == models.LiabilityTypeCreditCard {
+		return DocumentTypeCreditCardStatement
+	}
+	return DocumentTypeLoanStatement
+}
+
+// Read extracts the fields of a statement of documentType from a PDF or
+// plain text file
+func (s *StatementImportService) Read(documentType, contentType string, data []byte) (StatementFields, error) {
+	extractor, ok := s.extractors[documentType]
+	if !ok {
+		return StatementFields{}, fmt.Errorf("%w: %q", ErrUnknownDocumentType, documentType)
+	}
+	text, err := documentText(contentType, data)
+	if err != nil {
+		return StatementFields{}, err
+	}
+	fields := extractor.Extract(text)
+	if fields.empty() {
+		return StatementFields{}, ErrNothingExtracted
+	}
+	return fields, nil
+}
+
+// documentText returns the text of a PDF or plain text file. PDFs are
+// recognized by their header whatever content type they were uploaded with.
+func documentText(contentType string, data []byte) (text string, err error) {
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		// The PDF reader panics on some malformed files
+		defer func() {
+			if r := recover(); r != nil {
+				text, err = "", fmt.Errorf("failed to read PDF: %v", r)
+			}
+		}()
+		reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return "", fmt.Errorf("failed to read PDF: %w", err)
+		}
+		plain, err := reader.GetPlainText()
+		if err != nil {
+			return "", fmt.Errorf("failed to read PDF text: %w", err)
+		}
+		content, err := io.ReadAll(plain)
+		if err != nil {
+			return "", fmt.Errorf("failed to read PDF text: %w", err)
+		}
+		return string(content), nil
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if strings.HasPrefix(strings.TrimSpace(mediaType), "text/") {
+		return string(data), nil
+	}
+	return "", ErrUnsupportedDocument
+}
+
+// labelExtractor reads each field as the first value of its kind following
+// one of the field's labels, trying labels in order. Labels match ignoring
+// case and spacing, so the same statement reads alike from a PDF's text
+// runs and from a text export.
+type labelExtractor struct {
+	documentType   string
+	balance        []string
+	apr            []string
+	minimumPayment []string
+	dueDate        []string
+	statementDate  []string
+}
+
+// labelWindow is how far past its label a value may be
+const labelWindow = 80
+
+var (
+	amountPattern  = regexp.MustCompile(`\$?\s*((?:\d{1,3}(?:,\d{3})+|\d+)\.\d{2})\b`)
+	percentPattern = regexp.MustCompile(`(\d{1,2}(?:\.\d{1,4})?)\s*%`)
+	// A date, or a range of dates such as an opening/closing period whose
+	// second date is the one wanted
+	datePattern = regexp.MustCompile(`(?i)(\d{1,2}/\d{1,2}/\d{2,4}|\d{4}-\d{2}-\d{2}|[a-z]{3,9}\.? \d{1,2},? \d{4})` +
+		`(?:\s*(?:-|–|to)\s*(\d{1,2}/\d{1,2}/\d{2,4}|\d{4}-\d{2}-\d{2}|[a-z]{3,9}\.? \d{1,2},? \d{4}))?`)
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+var dateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02", "January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006"}
+
+var creditCardStatementExtractor = &labelExtractor{
+	documentType:   DocumentTypeCreditCardStatement,
+	balance:        []string{"new balance", "statement balance", "current balance"},
+	apr:            []string{"purchase apr", "annual percentage rate", "purchases", "apr"},
+	minimumPayment: []string{"minimum payment due", "minimum amount due", "minimum payment"},
+	dueDate:        []string{"payment due date", "due date"},
+	statementDate:  []string{"statement closing date", "opening/closing date", "closing date", "statement date"},
+}
+
+var loanStatementExtractor = &labelExtractor{
+	documentType: DocumentTypeLoanStatement,
+	balance: []string{"unpaid principal balance", "outstanding principal", "principal balance",
+		"current balance", "outstanding balance", "loan balance"},
+	apr:            []string{"interest rate", "annual percentage rate", "apr"},
+	minimumPayment: []string{"total amount due", "amount due", "regular monthly payment", "monthly payment", "payment amount"},
+	dueDate:        []string{"payment due date", "due date"},
+	statementDate:  []string{"statement date"},
+}
+
+func (e *labelExtractor) DocumentType() string {
+	return e.documentType
+}
+
+func (e *labelExtractor) Extract(text string) StatementFields {
+	text = spacePattern.ReplaceAllString(text, " ")
+	// Lowercased byte for byte so offsets into it are offsets into text
+	folded := []byte(text)
+	for i, b := range folded {
+		if 'A' <= b && b <= 'Z' {
+			folded[i] = b + 'a' - 'A'
+		}
+	}
+	lower := string(folded)
+
+	var fields StatementFields
+	if m := findAfter(text, lower, e.balance, amountPattern); m != nil {
+		if v, err := decimal.NewFromString(strings.ReplaceAll(m[1], ",", "")); err == nil {
+			fields.Balance = &v
+		}
+	}
+	if m := findAfter(text, lower, e.apr, percentPattern); m != nil {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			fields.APR = &v
+		}
+	}
+	if m := findAfter(text, lower, e.minimumPayment, amountPattern); m != nil {
+		if v, err := decimal.NewFromString(strings.ReplaceAll(m[1], ",", "")); err == nil {
+			fields.MinimumPayment = &v
+		}
+	}
+	fields.DueDate = findDate(text, lower, e.dueDate)
+	fields.StatementDate = findDate(text, lower, e.statementDate)
+	return fields
+}
+
+// findAfter returns the submatches of the first value matching pattern
+// within labelWindow of one of labels, trying labels in order. Labels match
+// whole words, so "apr" doesn't match "April".
+func findAfter(text, lower string, labels []string, pattern *regexp.Regexp) []string {
+	for _, label := range labels {
+		for offset := 0; ; {
+			i := strings.Index(lower[offset:], label)
+			if i < 0 {
+				break
+			}
+			begin, start := offset+i, offset+i+len(label)
+			offset = start
+			if (begin > 0 && isLetter(lower[begin-1])) || (start < len(lower) && isLetter(lower[start])) {
+				continue
+			}
+			end := min(start+labelWindow, len(text))
+			if m := pattern.FindStringSubmatch(text[start:end]); m != nil {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+func isLetter(b byte) bool {
+	return 'a' <= b && b <= 'z'
+}
+
+// findDate returns the first date following one of labels, or the end of a
+// range of dates
+func findDate(text, lower string, labels []string) *time.Time {
+	m := findAfter(text, lower, labels, datePattern)
+	if m == nil {
+		return nil
+	}
+	value := m[1]
+	if m[2] != "" {
+		value = m[2]
+	}
+	value = strings.Replace(value, ".", "", 1)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
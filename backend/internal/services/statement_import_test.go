@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const creditCardStatementText = `
+ACCOUNT SUMMARY
+Previous Balance                 $1,102.17
+Payment, Credits                 -$1,102.17
+Purchases                        +$1,284.56
+New Balance                      $1,284.56
+Opening/Closing Date  09/21/26 - 10/20/26
+
+PAYMENT INFORMATION
+New Balance $1,284.56
+Minimum Payment Due $35.00
+Payment Due Date 11/15/26
+Late Payment Warning: If we do not receive your minimum payment by April 15...
+
+INTEREST CHARGES
+Type of Balance   Annual Percentage Rate (APR)   Balance Subject to Interest Rate
+Purchases         24.99% (v)                      $0.00
+Cash Advances     29.99% (v)                      $0.00
+`
+
+const loanStatementText = `
+Auto Loan Statement
+Statement Date: October 5, 2026
+Payment Due Date: November 1, 2026
+Total Amount Due: $412.38
+Regular Monthly Payment $412.38
+Interest Rate 6.49%
+Unpaid Principal Balance $18,204.11
+`
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStatementExtractors(t *testing.T) {
+	tests := []struct {
+		name           string
+		extractor      StatementExtractor
+		text           string
+		balance        string
+		apr            float64
+		minimumPayment string
+		dueDate        time.Time
+		statementDate  time.Time
+	}{
+		{
+			name:      "credit card",
+			extractor: creditCardStatementExtractor, text: creditCardStatementText,
+			balance: "1284.56", apr: 24.99, minimumPayment: "35",
+			// The closing date ends the opening/closing range
+			dueDate: date(2026, 11, 15), statementDate: date(2026, 10, 20),
+		},
+		{
+			name:      "loan",
+			extractor: loanStatementExtractor, text: loanStatementText,
+			balance: "18204.11", apr: 6.49, minimumPayment: "412.38",
+			dueDate: date(2026, 11, 1), statementDate: date(2026, 10, 5),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.extractor.Extract(tt.text)
+			if f.Balance == nil || !f.Balance.Equal(decimal.RequireFromString(tt.balance)) {
+				t.Errorf("balance = %v, want %v", f.Balance, tt.balance)
+			}
+			if f.APR == nil || *f.APR != tt.apr {
+				t.Errorf("apr = %v, want %v", f.APR, tt.apr)
+			}
+			if f.MinimumPayment == nil || !f.MinimumPayment.Equal(decimal.RequireFromString(tt.minimumPayment)) {
+				t.Errorf("minimum payment = %v, want %v", f.MinimumPayment, tt.minimumPayment)
+			}
+			if f.DueDate == nil || !f.DueDate.Equal(tt.dueDate) {
+				t.Errorf("due date = %v, want %s", f.DueDate, tt.dueDate.Format("2006-01-02"))
+			}
+			if f.StatementDate == nil || !f.StatementDate.Equal(tt.statementDate) {
+				t.Errorf("statement date = %v, want %s", f.StatementDate, tt.statementDate.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestStatementExtractorPartial(t *testing.T) {
+	// "APR" in "April" is not a label, and fields not shown stay nil
+	f := creditCardStatementExtractor.Extract("Payments are due by April 15, 20% off. New Balance: $12.00")
+	if f.APR != nil {
+		t.Errorf("apr = %v, want nil", *f.APR)
+	}
+	if f.Balance == nil || !f.Balance.Equal(decimal.NewFromInt(12)) {
+		t.Errorf("balance = %v, want 12", f.Balance)
+	}
+	if f.MinimumPayment != nil || f.DueDate != nil || f.StatementDate != nil {
+		t.Errorf("unexpected fields: %+v", f)
+	}
+}
+
+func TestStatementImportRead(t *testing.T) {
+	s := NewStatementImportService()
+
+	if _, err := s.Read(DocumentTypeLoanStatement, "image/png", []byte("\x89PNG")); !errors.Is(err, ErrUnsupportedDocument) {
+		t.Errorf("PNG: err = %v, want ErrUnsupportedDocument", err)
+	}
+	if _, err := s.Read("tax_return", "text/plain", []byte(loanStatementText)); !errors.Is(err, ErrUnknownDocumentType) {
+		t.Errorf("unknown type: err = %v, want ErrUnknownDocumentType", err)
+	}
+	if _, err := s.Read(DocumentTypeLoanStatement, "text/plain", []byte("Thank you for your business")); !errors.Is(err, ErrNothingExtracted) {
+		t.Errorf("no fields: err = %v, want ErrNothingExtracted", err)
+	}
+	if _, err := s.Read(DocumentTypeLoanStatement, "application/pdf", []byte("%PDF-1.4 truncated")); err == nil {
+		t.Error("malformed PDF: err = nil")
+	}
+
+	f, err := s.Read(DocumentTypeFor("loan"), "text/plain; charset=utf-8", []byte(loanStatementText))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if f.Balance == nil || !f.Balance.Equal(decimal.RequireFromString("18204.11")) {
+		t.Errorf("balance = %v, want 18204.11", f.Balance)
+	}
+}
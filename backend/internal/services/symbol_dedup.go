@@ -0,0 +1,38 @@
+package services
+
+import (
+	"golang.org/x/sync/singleflight"
+)
+
+// SymbolDedup coalesces concurrent price lookups for the same symbol so only
+// one caller hits the provider API and the rest share its result.
+type SymbolDedup struct {
+	group singleflight.Group
+}
+
+// NewSymbolDedup creates an empty dedup group
+func NewSymbolDedup() *SymbolDedup {
+	return &SymbolDedup{}
+}
+
+// Do runs fetch for symbol unless a fetch for it is already in flight, in which
+// case it waits for and returns that result. shared reports whether the result
+// was shared with other callers. Forced refreshes only share with each other,
+// so a force never settles for a lookup that may answer from the cache.
+func (d *SymbolDedup) Do(symbol string, force bool, fetch func() (float64, error)) (price float64, shared bool, err error) {
+	key := symbol
+	if force {
+		key += "|force"
+	}
+	result, err, shared := d.group.Do(key, func() (interface{}, error) {
+		return fetch()
+	})
+	if err != nil {
+		return 0, shared, err
+	}
+	return result.(float64), shared, nil
+}
+
+// stockPriceDedup is shared by every stock price provider, so a symbol is fetched
+// once even when requests race across providers or a provider reload
+var stockPriceDedup = NewSymbolDedup()
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSymbolDedupForceDoesNotJoinCachedLookup(t *testing.T) {
+	d := NewSymbolDedup()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.Do("AAPL", false, func() (float64, error) {
+			close(started)
+			<-release
+			return 100, nil
+		})
+	}()
+	<-started
+
+	price, shared, err := d.Do("AAPL", true, func() (float64, error) {
+		return 105, nil
+	})
+	close(release)
+	wg.Wait()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shared || price != 105 {
+		t.Errorf("forced lookup = %v (shared %t), want its own fetch of 105", price, shared)
+	}
+}
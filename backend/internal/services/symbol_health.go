@@ -0,0 +1,234 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Asset types tracked by the symbol health service
+const (
+	SymbolAssetTypeStock  = "stock"
+	SymbolAssetTypeCrypto = "crypto"
+)
+
+// ErrSymbolNotTracked is returned when no health record exists for a symbol
+var ErrSymbolNotTracked = errors.New("symbol has no refresh health record")
+
+// SymbolHealth is the refresh health of a single price symbol
+type SymbolHealth struct {
+	AssetType           string     `json:"asset_type"`
+	Symbol              string     `json:"symbol"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	Paused              bool       `json:"paused"`
+	PausedAt            *time.Time `json:"paused_at,omitempty"`
+}
+
+// SymbolHealthService tracks consecutive refresh failures per symbol and pauses
+// symbols that keep failing (typos, delisted tickers) so they stop consuming quota
+type SymbolHealthService struct {
+	db            *sql.DB
+	threshold     int
+	notifications *NotificationService
+}
+
+// NewSymbolHealthService creates a new symbol health service. A threshold of 0
+// disables auto-pausing while still tracking failures.
+func NewSymbolHealthService(db *sql.DB, threshold int, notifications *NotificationService) *SymbolHealthService {
+	return &SymbolHealthService{
+		db:            db,
+		threshold:     threshold,
+		notifications: notifications,
+	}
+}
+
+// GetThreshold returns the number of consecutive failures that pauses a symbol
+func (shs *SymbolHealthService) GetThreshold() int {
+	return shs.threshold
+}
+
+// RecordSuccess resets the failure count for a symbol after a successful refresh
+func (shs *SymbolHealthService) RecordSuccess(assetType, symbol string) {
+	query := `
+		INSERT INTO price_symbol_health (asset_type, symbol, consecutive_failures, last_success_at, updated_at)
+		VALUES ($1, $2, 0, $3, $3)
+		ON CONFLICT (asset_type, symbol) DO UPDATE SET
+			consecutive_failures = 0,
+			last_success_at = EXCLUDED.last_success_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := shs.db.Exec(query, assetType, strings.ToUpper(symbol), time.Now()); err != nil {
+		fmt.Printf("WARNING: Failed to record refresh success for %s %s: %v\n", assetType, symbol, err)
+	}
+}
+
+// RecordFailure increments the consecutive failure count for a symbol and pauses
+// it once the threshold is reached. Returns true if this failure paused the symbol.
+func (shs *SymbolHealthService) RecordFailure(assetType, symbol, errMsg string) bool {
+	symbol = strings.ToUpper(symbol)
+	now := time.Now()
+
+	query := `
+		INSERT INTO price_symbol_health (asset_type, symbol, consecutive_failures, last_error, last_failure_at, updated_at)
+		VALUES ($1, $2, 1, $3, $4, $4)
+		ON CONFLICT (asset_type, symbol) DO UPDATE SET
+			consecutive_failures = price_symbol_health.consecutive_failures + 1,
+			last_error = EXCLUDED.last_error,
+			last_failure_at = EXCLUDED.last_failure_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING consecutive_failures, paused
+	`
+
+	var failures int
+	var paused bool
+	if err := shs.db.QueryRow(query, assetType, symbol, errMsg, now).Scan(&failures, &paused); err != nil {
+		fmt.Printf("WARNING: Failed to record refresh failure for %s %s: %v\n", assetType, symbol, err)
+		return false
+	}
+
+	if paused || shs.threshold <= 0 || failures < shs.threshold {
+		return false
+	}
+
+	_, err := shs.db.Exec(`
+		UPDATE price_symbol_health SET paused = true, paused_at = $3, updated_at = $3
+		WHERE asset_type = $1 AND symbol = $2
+	`, assetType, symbol, now)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to pause %s %s: %v\n", assetType, symbol, err)
+		return false
+	}
+
+	fmt.Printf("WARNING: Paused price refresh for %s %s after %d consecutive failures\n", assetType, symbol, failures)
+	if shs.notifications != nil {
+		shs.notifications.Create(
+			"symbol_paused",
+			NotificationSeverityWarning,
+			fmt.Sprintf("Price refresh paused for %s", symbol),
+			fmt.Sprintf("The %s price refresh for %s failed %d times in a row and has been paused (last error: %s). Check the symbol and re-enable it once fixed.",
+				assetType, symbol, failures, errMsg),
+		)
+	}
+
+	return true
+}
+
+// GetPausedSymbols returns the set of paused symbols for an asset type
+func (shs *SymbolHealthService) GetPausedSymbols(assetType string) map[string]bool {
+	paused := make(map[string]bool)
+
+	rows, err := shs.db.Query(`SELECT symbol FROM price_symbol_health WHERE asset_type = $1 AND paused = true`, assetType)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to load paused %s symbols: %v\n", assetType, err)
+		return paused
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbol string
+		if rows.Scan(&symbol) == nil {
+			paused[symbol] = true
+		}
+	}
+
+	return paused
+}
+
+// List returns symbol health records, optionally only paused or failing symbols
+func (shs *SymbolHealthService) List(pausedOnly bool) ([]SymbolHealth, error) {
+	query := `
+		SELECT asset_type, symbol, consecutive_failures, COALESCE(last_error, ''),
+		       last_failure_at, last_success_at, paused, paused_at
+		FROM price_symbol_health
+	`
+	if pausedOnly {
+		query += " WHERE paused = true"
+	} else {
+		query += " WHERE paused = true OR consecutive_failures > 0"
+	}
+	query += " ORDER BY paused DESC, consecutive_failures DESC, asset_type, symbol"
+
+	rows, err := shs.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol health: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]SymbolHealth, 0)
+	for rows.Next() {
+		var h SymbolHealth
+		var lastFailureAt, lastSuccessAt, pausedAt sql.NullTime
+		err := rows.Scan(&h.AssetType, &h.Symbol, &h.ConsecutiveFailures, &h.LastError,
+			&lastFailureAt, &lastSuccessAt, &h.Paused, &pausedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan symbol health: %w", err)
+		}
+		if lastFailureAt.Valid {
+			h.LastFailureAt = &lastFailureAt.Time
+		}
+		if lastSuccessAt.Valid {
+			h.LastSuccessAt = &lastSuccessAt.Time
+		}
+		if pausedAt.Valid {
+			h.PausedAt = &pausedAt.Time
+		}
+		records = append(records, h)
+	}
+
+	return records, rows.Err()
+}
+
+// Resume re-enables refresh for a paused symbol and clears its failure count
+func (shs *SymbolHealthService) Resume(assetType, symbol string) error {
+	result, err := shs.db.Exec(`
+		UPDATE price_symbol_health
+		SET paused = false, paused_at = NULL, consecutive_failures = 0, updated_at = $3
+		WHERE asset_type = $1 AND symbol = $2
+	`, assetType, strings.ToUpper(symbol), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to resume %s %s: %w", assetType, symbol, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSymbolNotTracked
+	}
+
+	fmt.Printf("INFO: Resumed price refresh for %s %s\n", assetType, strings.ToUpper(symbol))
+	return nil
+}
+
+// IsValidAssetType reports whether the asset type is tracked
+func (shs *SymbolHealthService) IsValidAssetType(assetType string) bool {
+	return assetType == SymbolAssetTypeStock || assetType == SymbolAssetTypeCrypto
+}
+
+// countsAsSymbolFailure reports whether a refresh error type reflects a problem
+// with the symbol itself rather than quota or infrastructure
+func countsAsSymbolFailure(errorType string) bool {
+	switch errorType {
+	case "rate_limited", "cache_error", "database_error":
+		return false
+	}
+	return true
+}
+
+// RecordResult records the outcome of a refresh given its error type
+func (shs *SymbolHealthService) RecordResult(assetType, symbol string, updated bool, errorType, errMsg string) {
+	if updated {
+		shs.RecordSuccess(assetType, symbol)
+		return
+	}
+	if countsAsSymbolFailure(errorType) {
+		shs.RecordFailure(assetType, symbol, errMsg)
+	}
+}
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/httpclient"
+)
+
+const (
+	// symbolLookupTTL is how long a found symbol is trusted before it is looked up again
+	symbolLookupTTL = 30 * 24 * time.Hour
+	// unknownSymbolTTL is how long a symbol the provider did not know is
+	// rejected without asking again, so a new listing is picked up the next day
+	unknownSymbolTTL = 24 * time.Hour
+	// symbolBackfillLookupsPerRun bounds provider calls per backfill so it does
+	// not use up the daily price quota
+	symbolBackfillLookupsPerRun = 5
+	// symbolSearchLimit caps the matches returned by Search
+	symbolSearchLimit = 10
+)
+
+var (
+	// ErrUnknownSymbol is returned when the provider has no security with the symbol
+	ErrUnknownSymbol = errors.New("unknown ticker symbol")
+	// ErrSymbolLookupUnavailable is returned when no provider is configured and
+	// the symbol is not in the built-in dataset
+	ErrSymbolLookupUnavailable = errors.New("no symbol lookup provider configured")
+)
+
+// SymbolMatch is a security returned by a symbol search
+type SymbolMatch struct {
+	Symbol       string `json:"symbol"`
+	Name         string `json:"name"`
+	Exchange     string `json:"exchange,omitempty"`
+	SecurityType string `json:"security_type,omitempty"`
+	Country      string `json:"country,omitempty"`
+	Currency     string `json:"currency,omitempty"`
+	Source       string `json:"source"`
+}
+
+// twelveDataSymbolSearch is the Twelve Data symbol_search response
+type twelveDataSymbolSearch struct {
+	Data []struct {
+		Symbol         string `json:"symbol"`
+		InstrumentName string `json:"instrument_name"`
+		Exchange       string `json:"exchange"`
+		InstrumentType string `json:"instrument_type"`
+		Country        string `json:"country"`
+		Currency       string `json:"currency"`
+	} `json:"data"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// alphaVantageSymbolSearch is the Alpha Vantage SYMBOL_SEARCH response. Rate
+// limits come back as a Note or Information message instead of matches.
+type alphaVantageSymbolSearch struct {
+	BestMatches []struct {
+		Symbol   string `json:"1. symbol"`
+		Name     string `json:"2. name"`
+		Type     string `json:"3. type"`
+		Region   string `json:"4. region"`
+		Currency string `json:"8. currency"`
+	} `json:"bestMatches"`
+	Note        string `json:"Note"`
+	Information string `json:"Information"`
+}
+
+// SymbolLookupService validates ticker symbols and resolves their company
+// name, exchange and security type through the price providers' symbol search
+type SymbolLookupService struct {
+	db                 *sql.DB
+	config             *config.ApiConfig
+	twelveDataClient   *http.Client
+	alphaVantageClient *http.Client
+	twelveDataURL      string
+	alphaVantageURL    string
+}
+
+// NewSymbolLookupService creates a symbol lookup service. Searches use the
+// primary price provider, then the fallback, of those with a key in cfg.
+func NewSymbolLookupService(db *sql.DB, cfg *config.ApiConfig) *SymbolLookupService {
+	return &SymbolLookupService{
+		db:                 db,
+		config:             cfg,
+		twelveDataClient:   httpclient.New("twelvedata", 15*time.Second, cfg.HTTPRetry),
+		alphaVantageClient: httpclient.New("alphavantage", 15*time.Second, cfg.HTTPRetry),
+		twelveDataURL:      "https://api.twelvedata.com",
+		alphaVantageURL:    "https://www.alphavantage.co/query",
+	}
+}
+
+// providers returns the configured providers, primary first
+func (sl *SymbolLookupService) providers() []string {
+	order := []string{sl.config.PrimaryPriceProvider, sl.config.FallbackPriceProvider, "twelvedata", "alphavantage"}
+	var providers []string
+	seen := make(map[string]bool)
+	for _, provider := range order {
+		if seen[provider] {
+			continue
+		}
+		seen[provider] = true
+		if (provider == "twelvedata" && sl.config.TwelveDataAPIKey != "") ||
+			(provider == "alphavantage" && sl.config.AlphaVantageAPIKey != "") {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// Search returns securities whose symbol or name matches query. Without a
+// provider the built-in dataset is searched instead.
+func (sl *SymbolLookupService) Search(ctx context.Context, query string) ([]SymbolMatch, error) {
+	query = strings.TrimSpace(query)
+	matches, err := sl.search(ctx, query)
+	if errors.Is(err, ErrSymbolLookupUnavailable) {
+		return searchStaticSecurities(query), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > symbolSearchLimit {
+		matches = matches[:symbolSearchLimit]
+	}
+	return matches, nil
+}
+
+// search asks each configured provider in turn until one answers
+func (sl *SymbolLookupService) search(ctx context.Context, query string) ([]SymbolMatch, error) {
+	providers := sl.providers()
+	if len(providers) == 0 {
+		return nil, ErrSymbolLookupUnavailable
+	}
+
+	var lastErr error
+	for _, provider := range providers {
+		var matches []SymbolMatch
+		var err error
+		switch provider {
+		case "twelvedata":
+			matches, err = sl.searchTwelveData(ctx, query)
+		case "alphavantage":
+			matches, err = sl.searchAlphaVantage(ctx, query)
+		}
+		if err == nil {
+			return matches, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", provider, err)
+	}
+	return nil, lastErr
+}
+
+// Resolve returns the security listed under symbol. Lookups are cached in
+// symbol_lookups; when no provider is configured or every provider fails, the
+// built-in dataset is used. Returns ErrUnknownSymbol when the provider has no
+// exact match.
+func (sl *SymbolLookupService) Resolve(ctx context.Context, symbol string) (*SymbolMatch, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	cached, unknown, err := sl.cached(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		return cached, nil
+	}
+	if unknown {
+		return nil, ErrUnknownSymbol
+	}
+
+	matches, err := sl.search(ctx, symbol)
+	if err != nil {
+		if s, ok := staticSecurities[symbol]; ok {
+			return &SymbolMatch{Symbol: symbol, Name: s.name, SecurityType: s.assetType, Country: s.country, Source: SecurityMetadataStatic}, nil
+		}
+		return nil, err
+	}
+
+	match := exactSymbolMatch(matches, symbol)
+	if err := sl.store(symbol, match); err != nil {
+		return nil, err
+	}
+	if match == nil {
+		return nil, ErrUnknownSymbol
+	}
+	return match, nil
+}
+
+// cached returns a fresh stored lookup for symbol. A fresh lookup that found
+// nothing returns a nil match with unknown set.
+func (sl *SymbolLookupService) cached(symbol string) (*SymbolMatch, bool, error) {
+	var match SymbolMatch
+	var found bool
+	var name, exchange, securityType, country, currency sql.NullString
+	var lookedUpAt time.Time
+	err := sl.db.QueryRow(`
+		SELECT found, name, exchange, security_type, country, currency, source, looked_up_at
+		FROM symbol_lookups WHERE symbol = $1
+	`, symbol).Scan(&found, &name, &exchange, &securityType, &country, &currency, &match.Source, &lookedUpAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch symbol lookup: %w", err)
+	}
+
+	if !found {
+		return nil, time.Since(lookedUpAt) < unknownSymbolTTL, nil
+	}
+	if time.Since(lookedUpAt) >= symbolLookupTTL {
+		return nil, false, nil
+	}
+	match.Symbol = symbol
+	match.Name = name.String
+	match.Exchange = exchange.String
+	match.SecurityType = securityType.String
+	match.Country = country.String
+	match.Currency = currency.String
+	return &match, false, nil
+}
+
+// store records a lookup; a nil match records that the symbol was not found
+func (sl *SymbolLookupService) store(symbol string, match *SymbolMatch) error {
+	var err error
+	if match == nil {
+		_, err = sl.db.Exec(`
+			INSERT INTO symbol_lookups (symbol, found, source, looked_up_at)
+			VALUES ($1, false, $2, CURRENT_TIMESTAMP)
+			ON CONFLICT (symbol) DO UPDATE SET
+				found = false, name = NULL, exchange = NULL, security_type = NULL, country = NULL,
+				currency = NULL, source = EXCLUDED.source, looked_up_at = EXCLUDED.looked_up_at
+		`, symbol, sl.providers()[0])
+	} else {
+		_, err = sl.db.Exec(`
+			INSERT INTO symbol_lookups (symbol, found, name, exchange, security_type, country, currency, source, looked_up_at)
+			VALUES ($1, true, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+			ON CONFLICT (symbol) DO UPDATE SET
+				found = true, name = EXCLUDED.name, exchange = EXCLUDED.exchange,
+				security_type = EXCLUDED.security_type, country = EXCLUDED.country,
+				currency = EXCLUDED.currency, source = EXCLUDED.source, looked_up_at = EXCLUDED.looked_up_at
+		`, symbol, nullIfEmpty(match.Name), nullIfEmpty(match.Exchange), nullIfEmpty(match.SecurityType),
+			nullIfEmpty(match.Country), nullIfEmpty(match.Currency), match.Source)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save symbol lookup for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// Backfill fills in missing company names, exchanges and security types of
+// stock holdings from stored lookups, looking up at most
+// symbolBackfillLookupsPerRun symbols not yet resolved. Symbols of equity
+// grants are looked up too, so consolidated positions show company names.
+// Returns how many holdings were updated.
+func (sl *SymbolLookupService) Backfill(ctx context.Context) (int, error) {
+	if len(sl.providers()) > 0 {
+		rows, err := sl.db.Query(`
+			SELECT symbol FROM (
+				SELECT UPPER(symbol) AS symbol FROM stock_holdings
+				WHERE NULLIF(company_name, '') IS NULL OR exchange IS NULL OR security_type IS NULL
+				UNION
+				SELECT UPPER(company_symbol) FROM equity_grants
+			) missing
+			WHERE NOT EXISTS (
+				SELECT 1 FROM symbol_lookups sl
+				WHERE sl.symbol = missing.symbol
+				  AND ((sl.found AND sl.looked_up_at > $1) OR (NOT sl.found AND sl.looked_up_at > $2))
+			)
+			ORDER BY symbol
+			LIMIT $3
+		`, time.Now().Add(-symbolLookupTTL), time.Now().Add(-unknownSymbolTTL), symbolBackfillLookupsPerRun)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch symbols to look up: %w", err)
+		}
+		var symbols []string
+		for rows.Next() {
+			var symbol string
+			if err := rows.Scan(&symbol); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to scan symbol: %w", err)
+			}
+			symbols = append(symbols, symbol)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("failed to fetch symbols to look up: %w", err)
+		}
+
+		for _, symbol := range symbols {
+			_, err := sl.Resolve(ctx, symbol)
+			if err != nil && !errors.Is(err, ErrUnknownSymbol) {
+				fmt.Printf("WARNING: Failed to look up symbol %s: %v\n", symbol, err)
+			}
+		}
+	}
+
+	// Only fields still empty are filled, so names typed in by hand are kept
+	result, err := sl.db.Exec(`
+		UPDATE stock_holdings AS h SET
+			company_name = COALESCE(NULLIF(h.company_name, ''), sl.name),
+			exchange = COALESCE(h.exchange, sl.exchange),
+			security_type = COALESCE(h.security_type, sl.security_type)
+		FROM symbol_lookups sl
+		WHERE sl.symbol = UPPER(h.symbol) AND sl.found
+		  AND ((NULLIF(h.company_name, '') IS NULL AND sl.name IS NOT NULL)
+		    OR (h.exchange IS NULL AND sl.exchange IS NOT NULL)
+		    OR (h.security_type IS NULL AND sl.security_type IS NOT NULL))
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill stock holdings: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check backfill result: %w", err)
+	}
+	if updated > 0 {
+		fmt.Printf("INFO: Filled in symbol details for %d stock holdings\n", updated)
+	}
+	return int(updated), nil
+}
+
+// Run backfills symbol details now and then every interval until ctx is done
+func (sl *SymbolLookupService) Run(ctx context.Context, interval time.Duration) {
+	backfill := func() {
+		if _, err := sl.Backfill(ctx); err != nil {
+			fmt.Printf("WARNING: Symbol backfill failed: %v\n", err)
+		}
+	}
+
+	backfill()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			backfill()
+		}
+	}
+}
+
+// searchTwelveData calls the Twelve Data symbol_search endpoint
+func (sl *SymbolLookupService) searchTwelveData(ctx context.Context, query string) ([]SymbolMatch, error) {
+	params := url.Values{"symbol": {query}, "outputsize": {fmt.Sprint(symbolSearchLimit)}, "apikey": {sl.config.TwelveDataAPIKey}}
+	var response twelveDataSymbolSearch
+	if err := sl.getJSON(ctx, sl.twelveDataClient, sl.twelveDataURL+"/symbol_search?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	if response.Status != "" && response.Status != "ok" {
+		return nil, fmt.Errorf("symbol search failed: %s", response.Message)
+	}
+
+	matches := make([]SymbolMatch, 0, len(response.Data))
+	for _, d := range response.Data {
+		matches = append(matches, SymbolMatch{
+			Symbol:       strings.ToUpper(d.Symbol),
+			Name:         d.InstrumentName,
+			Exchange:     d.Exchange,
+			SecurityType: d.InstrumentType,
+			Country:      d.Country,
+			Currency:     d.Currency,
+			Source:       "twelvedata",
+		})
+	}
+	return matches, nil
+}
+
+// searchAlphaVantage calls the Alpha Vantage SYMBOL_SEARCH endpoint, which
+// does not report exchanges
+func (sl *SymbolLookupService) searchAlphaVantage(ctx context.Context, query string) ([]SymbolMatch, error) {
+	params := url.Values{"function": {"SYMBOL_SEARCH"}, "keywords": {query}, "apikey": {sl.config.AlphaVantageAPIKey}}
+	var response alphaVantageSymbolSearch
+	if err := sl.getJSON(ctx, sl.alphaVantageClient, sl.alphaVantageURL+"?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	if response.BestMatches == nil {
+		if response.Note != "" {
+			return nil, fmt.Errorf("symbol search failed: %s", response.Note)
+		}
+		if response.Information != "" {
+			return nil, fmt.Errorf("symbol search failed: %s", response.Information)
+		}
+	}
+
+	matches := make([]SymbolMatch, 0, len(response.BestMatches))
+	for _, m := range response.BestMatches {
+		matches = append(matches, SymbolMatch{
+			Symbol:       strings.ToUpper(m.Symbol),
+			Name:         m.Name,
+			SecurityType: m.Type,
+			Country:      m.Region,
+			Currency:     m.Currency,
+			Source:       "alphavantage",
+		})
+	}
+	return matches, nil
+}
+
+// getJSON fetches rawURL and decodes the JSON response into v
+func (sl *SymbolLookupService) getJSON(ctx context.Context, client *http.Client, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("provider returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse symbol search: %w", err)
+	}
+	return nil
+}
+
+// exactSymbolMatch picks the match listed under symbol, preferring a US
+// listing when the symbol trades on several exchanges
+func exactSymbolMatch(matches []SymbolMatch, symbol string) *SymbolMatch {
+	var exact *SymbolMatch
+	for i := range matches {
+		if matches[i].Symbol != symbol {
+			continue
+		}
+		if matches[i].Country == "United States" {
+			return &matches[i]
+		}
+		if exact == nil {
+			exact = &matches[i]
+		}
+	}
+	return exact
+}
+
+// searchStaticSecurities matches query against symbols and names in the built-in dataset
+func searchStaticSecurities(query string) []SymbolMatch {
+	upper := strings.ToUpper(query)
+	matches := []SymbolMatch{}
+	if upper == "" {
+		return matches
+	}
+	for symbol, s := range staticSecurities {
+		if strings.HasPrefix(symbol, upper) || strings.Contains(strings.ToUpper(s.name), upper) {
+			matches = append(matches, SymbolMatch{
+				Symbol:       symbol,
+				Name:         s.name,
+				SecurityType: s.assetType,
+				Country:      s.country,
+				Source:       SecurityMetadataStatic,
+			})
+		}
+	}
+	sortSymbolMatches(matches, upper)
+	if len(matches) > symbolSearchLimit {
+		matches = matches[:symbolSearchLimit]
+	}
+	return matches
+}
+
+// sortSymbolMatches puts an exact symbol first, then the rest by symbol
+func sortSymbolMatches(matches []SymbolMatch, symbol string) {
+	sort.Slice(matches, func(i, j int) bool {
+		if (matches[i].Symbol == symbol) != (matches[j].Symbol == symbol) {
+			return matches[i].Symbol == symbol
+		}
+		return matches[i].Symbol < matches[j].Symbol
+	})
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Trading window types
+const (
+	// TradingWindowOpen is a period employees may trade the company's stock.
+	// Once a company has any, trading outside them is closed.
+	TradingWindowOpen = "open"
+	// TradingWindowBlackout is a period trading is closed, even inside an
+	// open window
+	TradingWindowBlackout = "blackout"
+)
+
+// Reasons a company's stock can or can't be traded today
+const (
+	TradingReasonNotConfigured = "not_configured"
+	TradingReasonOpenWindow    = "open_window"
+	TradingReasonOutsideWindow = "outside_window"
+	TradingReasonBlackout      = "blackout"
+	TradingReasonNoBlackout    = "no_blackout"
+)
+
+var (
+	// ErrTradingWindowNotFound is returned when a trading window does not exist
+	ErrTradingWindowNotFound = errors.New("trading window not found")
+	// ErrInvalidTradingWindow is returned for unparseable or reversed dates
+	ErrInvalidTradingWindow = errors.New("invalid trading window")
+)
+
+// TradingWindow is an open window or blackout period for one company's stock.
+// Both dates are inclusive.
+type TradingWindow struct {
+	ID            int       `json:"id"`
+	CompanySymbol string    `json:"company_symbol"`
+	WindowType    string    `json:"window_type"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	Note          *string   `json:"note"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TradingWindowInput is the writable part of a trading window. Dates are YYYY-MM-DD.
+type TradingWindowInput struct {
+	CompanySymbol string  `json:"company_symbol" binding:"required,max=10"`
+	WindowType    string  `json:"window_type" binding:"required,oneof=open blackout"`
+	StartDate     string  `json:"start_date" binding:"required"`
+	EndDate       string  `json:"end_date" binding:"required"`
+	Note          *string `json:"note" binding:"omitempty,max=255"`
+
+	start, end time.Time
+}
+
+// normalize upper-cases the symbol and parses the dates
+func (input *TradingWindowInput) normalize() error {
+	input.CompanySymbol = strings.ToUpper(strings.TrimSpace(input.CompanySymbol))
+	if input.CompanySymbol == "" {
+		return fmt.Errorf("%w: company_symbol is required", ErrInvalidTradingWindow)
+	}
+
+	var err error
+	if input.start, err = time.Parse("2006-01-02", input.StartDate); err != nil {
+		return fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidTradingWindow)
+	}
+	if input.end, err = time.Parse("2006-01-02", input.EndDate); err != nil {
+		return fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidTradingWindow)
+	}
+	if input.end.Before(input.start) {
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidTradingWindow)
+	}
+	return nil
+}
+
+// covers reports whether day falls inside the window
+func (w TradingWindow) covers(day time.Time) bool {
+	return !day.Before(w.StartDate) && !day.After(w.EndDate)
+}
+
+// TradingStatus says whether a company's stock can be traded today and when
+// that next changes. Without any windows configured it can always be traded.
+type TradingStatus struct {
+	CompanySymbol string         `json:"company_symbol"`
+	Configured    bool           `json:"configured"`
+	CanTrade      bool           `json:"can_trade"`
+	Reason        string         `json:"reason"`
+	CurrentWindow *TradingWindow `json:"current_window,omitempty"`
+	// NextOpenDate is the next day trading opens, when it is closed today
+	NextOpenDate *string `json:"next_open_date,omitempty"`
+	// NextCloseDate is the next day trading closes, when it is open today
+	NextCloseDate *string `json:"next_close_date,omitempty"`
+}
+
+// TradingWindowService stores trading windows for employer stock, reports
+// whether each company can be traded and notifies when that changes
+type TradingWindowService struct {
+	db            *sql.DB
+	notifications *NotificationService
+}
+
+// NewTradingWindowService creates a new trading window service
+func NewTradingWindowService(db *sql.DB, notifications *NotificationService) *TradingWindowService {
+	return &TradingWindowService{db: db, notifications: notifications}
+}
+
+// List returns the trading windows for symbol, or for every company when
+// symbol is empty, by company and start date
+func (tws *TradingWindowService) List(symbol string) ([]TradingWindow, error) {
+	rows, err := tws.db.Query(`
+		SELECT id, company_symbol, window_type, start_date, end_date, note, created_at, updated_at
+		FROM trading_windows
+		WHERE $1 = '' OR company_symbol = $1
+		ORDER BY company_symbol, start_date, id
+	`, strings.ToUpper(strings.TrimSpace(symbol)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trading windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := []TradingWindow{}
+	for rows.Next() {
+		var w TradingWindow
+		err := rows.Scan(&w.ID, &w.CompanySymbol, &w.WindowType, &w.StartDate, &w.EndDate, &w.Note, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trading window: %w", err)
+		}
+		windows = append(windows, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch trading windows: %w", err)
+	}
+	return windows, nil
+}
+
+// Create adds a trading window and returns its ID
+func (tws *TradingWindowService) Create(input TradingWindowInput) (int, error) {
+	if err := input.normalize(); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := tws.db.QueryRow(`
+		INSERT INTO trading_windows (company_symbol, window_type, start_date, end_date, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, input.CompanySymbol, input.WindowType, input.start, input.end, input.Note).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create trading window: %w", err)
+	}
+	return id, nil
+}
+
+// Update replaces a trading window
+func (tws *TradingWindowService) Update(id int, input TradingWindowInput) error {
+	if err := input.normalize(); err != nil {
+		return err
+	}
+
+	result, err := tws.db.Exec(`
+		UPDATE trading_windows
+		SET company_symbol = $1, window_type = $2, start_date = $3, end_date = $4, note = $5,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`, input.CompanySymbol, input.WindowType, input.start, input.end, input.Note, id)
+	if err != nil {
+		return fmt.Errorf("failed to update trading window: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTradingWindowNotFound
+	}
+	return nil
+}
+
+// Delete removes a trading window
+func (tws *TradingWindowService) Delete(id int) error {
+	result, err := tws.db.Exec(`DELETE FROM trading_windows WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete trading window: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTradingWindowNotFound
+	}
+	return nil
+}
+
+// Statuses returns the trading status of each of symbols, and of every
+// company with windows configured, on now's date
+func (tws *TradingWindowService) Statuses(symbols []string, now time.Time) (map[string]TradingStatus, error) {
+	windows, err := tws.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := map[string][]TradingWindow{}
+	for _, symbol := range symbols {
+		bySymbol[strings.ToUpper(symbol)] = nil
+	}
+	for _, w := range windows {
+		bySymbol[w.CompanySymbol] = append(bySymbol[w.CompanySymbol], w)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	statuses := make(map[string]TradingStatus, len(bySymbol))
+	for symbol, companyWindows := range bySymbol {
+		statuses[symbol] = tradingStatus(symbol, companyWindows, today)
+	}
+	return statuses, nil
+}
+
+// tradingStatus works out one company's status on today
+func tradingStatus(symbol string, windows []TradingWindow, today time.Time) TradingStatus {
+	status := TradingStatus{CompanySymbol: symbol, Configured: len(windows) > 0}
+	if !status.Configured {
+		status.CanTrade = true
+		status.Reason = TradingReasonNotConfigured
+		return status
+	}
+
+	var current *TradingWindow
+	status.CanTrade, status.Reason, current = tradingAllowed(windows, today)
+	if current != nil {
+		window := *current
+		status.CurrentWindow = &window
+	}
+
+	// Trading can only open on the first day of an open window or the day
+	// after a blackout, and only close on the first day of a blackout or the
+	// day after an open window
+	var candidates []time.Time
+	for _, w := range windows {
+		switch {
+		case w.WindowType == TradingWindowOpen && status.CanTrade,
+			w.WindowType == TradingWindowBlackout && !status.CanTrade:
+			candidates = append(candidates, w.EndDate.AddDate(0, 0, 1))
+		default:
+			candidates = append(candidates, w.StartDate)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	for _, day := range candidates {
+		if !day.After(today) {
+			continue
+		}
+		if allowed, _, _ := tradingAllowed(windows, day); allowed != status.CanTrade {
+			date := day.Format("2006-01-02")
+			if status.CanTrade {
+				status.NextCloseDate = &date
+			} else {
+				status.NextOpenDate = &date
+			}
+			break
+		}
+	}
+	return status
+}
+
+// tradingAllowed reports whether windows allow trading on day, why, and the
+// window deciding it
+func tradingAllowed(windows []TradingWindow, day time.Time) (bool, string, *TradingWindow) {
+	hasOpenWindows := false
+	var open *TradingWindow
+	for i, w := range windows {
+		switch w.WindowType {
+		case TradingWindowBlackout:
+			if w.covers(day) {
+				return false, TradingReasonBlackout, &windows[i]
+			}
+		case TradingWindowOpen:
+			hasOpenWindows = true
+			if open == nil && w.covers(day) {
+				open = &windows[i]
+			}
+		}
+	}
+
+	switch {
+	case open != nil:
+		return true, TradingReasonOpenWindow, open
+	case hasOpenWindows:
+		return false, TradingReasonOutsideWindow, nil
+	default:
+		return true, TradingReasonNoBlackout, nil
+	}
+}
+
+// Run checks trading windows now and then every interval until ctx is done
+func (tws *TradingWindowService) Run(ctx context.Context, interval time.Duration) {
+	check := func() {
+		if err := tws.Check(time.Now()); err != nil {
+			fmt.Printf("WARNING: Trading window check failed: %v\n", err)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// Check creates a trading_window notification for each company with windows
+// whose stock has become tradable or untradable since the last check. The
+// first check of a company only records its status.
+func (tws *TradingWindowService) Check(now time.Time) error {
+	statuses, err := tws.Statuses(nil, now)
+	if err != nil {
+		return err
+	}
+
+	symbols := make([]string, 0, len(statuses))
+	for symbol := range statuses {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		status := statuses[symbol]
+		var previous bool
+		err := tws.db.QueryRow(`
+			SELECT can_trade FROM trading_window_alerts WHERE company_symbol = $1
+		`, symbol).Scan(&previous)
+		seen := err == nil
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to read trading status of %s: %w", symbol, err)
+		}
+		if seen && previous == status.CanTrade {
+			continue
+		}
+
+		_, err = tws.db.Exec(`
+			INSERT INTO trading_window_alerts (company_symbol, can_trade, changed_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (company_symbol) DO UPDATE SET can_trade = EXCLUDED.can_trade, changed_at = EXCLUDED.changed_at
+		`, symbol, status.CanTrade, now)
+		if err != nil {
+			return fmt.Errorf("failed to record trading status of %s: %w", symbol, err)
+		}
+		if !seen || tws.notifications == nil {
+			continue
+		}
+
+		title, message := tradingWindowNotification(status)
+		tws.notifications.Create("trading_window", NotificationSeverityInfo, title, message)
+	}
+
+	_, err = tws.db.Exec(`DELETE FROM trading_window_alerts WHERE company_symbol NOT IN (SELECT company_symbol FROM trading_windows)`)
+	if err != nil {
+		return fmt.Errorf("failed to clear trading window alerts: %w", err)
+	}
+	return nil
+}
+
+// tradingWindowNotification describes a change in status
+func tradingWindowNotification(status TradingStatus) (string, string) {
+	if status.CanTrade {
+		title := fmt.Sprintf("%s trading window is open", status.CompanySymbol)
+		message := fmt.Sprintf("You can trade %s now.", status.CompanySymbol)
+		if status.NextCloseDate != nil {
+			message = fmt.Sprintf("You can trade %s until trading closes on %s.", status.CompanySymbol, *status.NextCloseDate)
+		}
+		return title, message
+	}
+
+	title := fmt.Sprintf("%s trading window is closed", status.CompanySymbol)
+	if status.Reason == TradingReasonBlackout {
+		title = fmt.Sprintf("%s blackout period has started", status.CompanySymbol)
+	}
+	message := fmt.Sprintf("You can't trade %s now.", status.CompanySymbol)
+	if status.NextOpenDate != nil {
+		message = fmt.Sprintf("You can't trade %s until %s.", status.CompanySymbol, *status.NextOpenDate)
+	}
+	return title, message
+}
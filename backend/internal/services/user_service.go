@@ -0,0 +1,405 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"networth-dashboard/internal/database"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User roles, from least to most privileged
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// roleRanks orders roles so a role satisfies any requirement at or below it
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// minPasswordLength is the shortest password accepted for a user
+const minPasswordLength = 8
+
+var (
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken is returned when creating a user whose username exists
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrInvalidCredentials is returned when a username and password do not match
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidSession is returned for a missing, unknown or expired session token
+	ErrInvalidSession = errors.New("invalid or expired session")
+	// ErrLastAdmin is returned when a change would leave no admin
+	ErrLastAdmin = errors.New("at least one admin is required")
+	// ErrInvalidUser is returned for an unknown role or a password that is too short
+	ErrInvalidUser = errors.New("invalid user")
+)
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAllows reports whether a user with role may do what required needs
+func RoleAllows(role, required string) bool {
+	return roleRanks[role] >= roleRanks[required] && roleRanks[required] > 0
+}
+
+// User is someone who can sign in to the dashboard
+type User struct {
+	ID          int        `json:"id"`
+	Username    string     `json:"username"`
+	DisplayName string     `json:"display_name,omitempty"`
+	Role        string     `json:"role"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// UserInput creates a user
+type UserInput struct {
+	Username    string `json:"username" binding:"required,max=100"`
+	DisplayName string `json:"display_name" binding:"max=200"`
+	Role        string `json:"role" binding:"required,oneof=admin editor viewer"`
+	Password    string `json:"password" binding:"required,min=8"`
+}
+
+// UserUpdate changes a user; nil fields are left as they are
+type UserUpdate struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=200"`
+	Role        *string `json:"role" binding:"omitempty,oneof=admin editor viewer"`
+	Password    *string `json:"password" binding:"omitempty,min=8"`
+}
+
+// Session is a signed-in user's bearer token
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+// UserService manages users, their passwords and their sessions
+type UserService struct {
+	db         *sql.DB
+	dialect    database.Dialect
+	sessionTTL time.Duration
+}
+
+func NewUserService(db *sql.DB, sessionTTL time.Duration) *UserService {
+	return &UserService{db: db, dialect: database.DialectOf(db), sessionTTL: sessionTTL}
+}
+
+const userColumns = `id, username, COALESCE(display_name, ''), role, last_login_at, created_at, updated_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.Username, &user.DisplayName, &user.Role, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+// List returns every user
+func (us *UserService) List() ([]User, error) {
+	rows, err := us.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Get returns one user
+func (us *UserService) Get(id int) (*User, error) {
+	user, err := scanUser(us.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return &user, nil
+}
+
+// Create adds a user
+func (us *UserService) Create(input UserInput) (*User, error) {
+	username := strings.ToLower(strings.TrimSpace(input.Username))
+	if username == "" || !ValidRole(input.Role) || len(input.Password) < minPasswordLength {
+		return nil, ErrInvalidUser
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := scanUser(us.db.QueryRow(`
+		INSERT INTO users (username, display_name, role, password_hash)
+		VALUES ($1, NULLIF($2, ''), $3, $4)
+		RETURNING `+userColumns,
+		username, strings.TrimSpace(input.DisplayName), input.Role, string(hash)))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return &user, nil
+}
+
+// Update changes a user's display name, role or password. Changing the
+// password signs the user out everywhere.
+func (us *UserService) Update(id int, update UserUpdate) (*User, error) {
+	if update.Role != nil && !ValidRole(*update.Role) {
+		return nil, ErrInvalidUser
+	}
+	if update.Password != nil && len(*update.Password) < minPasswordLength {
+		return nil, ErrInvalidUser
+	}
+
+	tx, err := us.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var currentRole string
+	err = tx.QueryRow(`SELECT role FROM users WHERE id = $1`+us.dialect.ForUpdate(), id).Scan(&currentRole)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	if update.Role != nil && currentRole == RoleAdmin && *update.Role != RoleAdmin {
+		if err := us.requireOtherAdmin(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var passwordHash *string
+	if update.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*update.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		hashed := string(hash)
+		passwordHash = &hashed
+	}
+
+	var displayName *string
+	if update.DisplayName != nil {
+		trimmed := strings.TrimSpace(*update.DisplayName)
+		displayName = &trimmed
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET display_name = CASE WHEN CAST($2 AS TEXT) IS NULL THEN display_name ELSE NULLIF($2, '') END,
+		    role = COALESCE($3, role),
+		    password_hash = COALESCE($4, password_hash),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, displayName, update.Role, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if passwordHash != nil {
+		if _, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = $1`, id); err != nil {
+			return nil, fmt.Errorf("failed to end sessions: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return us.Get(id)
+}
+
+// Delete removes a user and their sessions
+func (us *UserService) Delete(id int) error {
+	tx, err := us.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var role string
+	err = tx.QueryRow(`SELECT role FROM users WHERE id = $1`+us.dialect.ForUpdate(), id).Scan(&role)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if role == RoleAdmin {
+		if err := us.requireOtherAdmin(tx, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return tx.Commit()
+}
+
+// requireOtherAdmin returns ErrLastAdmin unless an admin other than id exists.
+// The admin rows are locked so concurrent demotions cannot both succeed.
+func (us *UserService) requireOtherAdmin(tx *sql.Tx, id int) error {
+	rows, err := tx.Query(`SELECT id FROM users WHERE role = $1 AND id <> $2`+us.dialect.ForUpdate(), RoleAdmin, id)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return nil
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	return ErrLastAdmin
+}
+
+// Login checks a username and password and starts a session
+func (us *UserService) Login(username, password string) (*Session, error) {
+	var id int
+	var passwordHash string
+	err := us.db.QueryRow(`SELECT id, password_hash FROM users WHERE username = $1`,
+		strings.ToLower(strings.TrimSpace(username))).Scan(&id, &passwordHash)
+	if err == sql.ErrNoRows {
+		// Compare anyway so unknown usernames take as long as wrong passwords
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(us.sessionTTL)
+
+	// Expired sessions are cleared as new ones start
+	if _, err := us.db.Exec(`DELETE FROM user_sessions WHERE expires_at <= CURRENT_TIMESTAMP`); err != nil {
+		fmt.Printf("WARNING: Failed to delete expired sessions: %v\n", err)
+	}
+	if _, err := us.db.Exec(`
+		INSERT INTO user_sessions (token_hash, user_id, expires_at) VALUES ($1, $2, $3)
+	`, hashSessionToken(token), id, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	if _, err := us.db.Exec(`UPDATE users SET last_login_at = CURRENT_TIMESTAMP WHERE id = $1`, id); err != nil {
+		fmt.Printf("WARNING: Failed to record login of user %d: %v\n", id, err)
+	}
+
+	user, err := us.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{Token: token, ExpiresAt: expiresAt, User: *user}, nil
+}
+
+// Authenticate returns the user a session token belongs to
+func (us *UserService) Authenticate(token string) (*User, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	user, err := scanUser(us.db.QueryRow(`
+		SELECT u.id, u.username, COALESCE(u.display_name, ''), u.role, u.last_login_at, u.created_at, u.updated_at
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = $1 AND s.expires_at > CURRENT_TIMESTAMP
+	`, hashSessionToken(token)))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	return &user, nil
+}
+
+// Logout ends a session
+func (us *UserService) Logout(token string) error {
+	if _, err := us.db.Exec(`DELETE FROM user_sessions WHERE token_hash = $1`, hashSessionToken(token)); err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	return nil
+}
+
+// EnsureAdmin creates the first admin when there are no users yet. Without a
+// password nobody could sign in, so it only warns.
+func (us *UserService) EnsureAdmin(username, password string) error {
+	var count int
+	if err := us.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if password == "" {
+		fmt.Printf("WARNING: Authentication is enabled but no users exist; set AUTH_ADMIN_PASSWORD to create the first admin\n")
+		return nil
+	}
+
+	if _, err := us.Create(UserInput{Username: username, Role: RoleAdmin, Password: password}); err != nil {
+		return fmt.Errorf("failed to create admin %s: %w", username, err)
+	}
+	fmt.Printf("INFO: Created admin user %s\n", username)
+	return nil
+}
+
+// dummyPasswordHash is compared against when a username does not exist
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// newSessionToken returns a random bearer token
+func newSessionToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// hashSessionToken returns the stored form of a token, so a database dump
+// does not hand out working sessions
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// WithholdingRates are the tax rates, in percent, withheld from stock income
+// at vest
+type WithholdingRates struct {
+	FederalPercent float64 `json:"federal_percent"`
+	StatePercent   float64 `json:"state_percent"`
+	FICAPercent    float64 `json:"fica_percent"`
+	TotalPercent   float64 `json:"total_percent"`
+}
+
+// NewWithholdingRates totals the federal, state and FICA rates
+func NewWithholdingRates(federalPercent, statePercent, ficaPercent float64) WithholdingRates {
+	return WithholdingRates{
+		FederalPercent: federalPercent,
+		StatePercent:   statePercent,
+		FICAPercent:    ficaPercent,
+		TotalPercent:   federalPercent + statePercent + ficaPercent,
+	}
+}
+
+// VestWithholding is the estimated tax withholding on one vest. Employers
+// withhold whole shares, rounding up, and refund the excess over the tax in
+// cash, so SharesWithheld is also the number to sell when selling to cover.
+type VestWithholding struct {
+	VestDate       string  `json:"vest_date"`
+	GrantID        int     `json:"grant_id"`
+	Symbol         string  `json:"symbol"`
+	GrantType      string  `json:"grant_type"`
+	SharesVesting  float64 `json:"shares_vesting"`
+	Price          float64 `json:"price"`
+	GrossValue     float64 `json:"gross_value"`
+	TaxWithheld    float64 `json:"tax_withheld"`
+	SharesWithheld float64 `json:"shares_withheld"`
+	CashRefund     float64 `json:"cash_refund"`
+	NetShares      float64 `json:"net_shares"`
+	NetValue       float64 `json:"net_value"`
+	// Shortfall is the tax expected on the vest beyond what is withheld,
+	// only when an expected tax rate is given
+	Shortfall *float64 `json:"shortfall,omitempty"`
+}
+
+// VestWithholdingEstimate is the withholding on the RSU and RSA vests between
+// From and To at the current price, with totals
+type VestWithholdingEstimate struct {
+	From               string            `json:"from"`
+	To                 string            `json:"to"`
+	Rates              WithholdingRates  `json:"rates"`
+	ExpectedTaxPercent *float64          `json:"expected_tax_percent,omitempty"`
+	Vests              []VestWithholding `json:"vests"`
+	SharesVesting      float64           `json:"shares_vesting"`
+	GrossValue         float64           `json:"gross_value"`
+	TaxWithheld        float64           `json:"tax_withheld"`
+	SharesWithheld     float64           `json:"shares_withheld"`
+	CashRefund         float64           `json:"cash_refund"`
+	NetShares          float64           `json:"net_shares"`
+	NetValue           float64           `json:"net_value"`
+	Shortfall          *float64          `json:"shortfall,omitempty"`
+	Warnings           []string          `json:"warnings"`
+}
+
+// EstimateVestWithholding estimates the withholding on RSU vests, and RSA
+// vests without an 83(b) election, dated on or after from and before to.
+// Vests are valued at the grant's current price. expectedTaxPercent, when
+// given, is the rate the vest income is expected to be taxed at in total,
+// to show what the withholding leaves owing.
+func EstimateVestWithholding(db *sql.DB, from, to time.Time, rates WithholdingRates, expectedTaxPercent *float64) (*VestWithholdingEstimate, error) {
+	rows, err := db.Query(`
+		SELECT vs.vest_date, g.id, UPPER(g.company_symbol), g.grant_type, vs.shares_vesting, COALESCE(g.current_price, 0)
+		FROM vesting_schedule vs
+		JOIN equity_grants g ON g.id = vs.grant_id
+		WHERE vs.vest_date >= $1 AND vs.vest_date < $2
+		  AND (g.grant_type = 'rsu' OR (g.grant_type = 'rsa' AND g.election_83b_filed_date IS NULL))
+		ORDER BY vs.vest_date, g.company_symbol, g.id
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vesting events: %w", err)
+	}
+	defer rows.Close()
+
+	estimate := &VestWithholdingEstimate{
+		From:               from.Format("2006-01-02"),
+		To:                 to.Format("2006-01-02"),
+		Rates:              rates,
+		ExpectedTaxPercent: expectedTaxPercent,
+		Vests:              []VestWithholding{},
+		Warnings:           []string{},
+	}
+	unpriced := map[string]bool{}
+	for rows.Next() {
+		var vest VestWithholding
+		var date time.Time
+		if err := rows.Scan(&date, &vest.GrantID, &vest.Symbol, &vest.GrantType, &vest.SharesVesting, &vest.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan vesting event: %w", err)
+		}
+		vest.VestDate = date.Format("2006-01-02")
+		if vest.Price <= 0 && !unpriced[vest.Symbol] {
+			unpriced[vest.Symbol] = true
+			estimate.Warnings = append(estimate.Warnings,
+				fmt.Sprintf("%s has no current price, so its vests are valued at $0", vest.Symbol))
+		}
+		vest.estimate(rates, expectedTaxPercent)
+
+		estimate.SharesVesting += vest.SharesVesting
+		estimate.GrossValue += vest.GrossValue
+		estimate.TaxWithheld += vest.TaxWithheld
+		estimate.SharesWithheld += vest.SharesWithheld
+		estimate.CashRefund += vest.CashRefund
+		estimate.NetShares += vest.NetShares
+		estimate.NetValue += vest.NetValue
+		if vest.Shortfall != nil {
+			shortfall := *vest.Shortfall
+			if estimate.Shortfall != nil {
+				shortfall += *estimate.Shortfall
+			}
+			estimate.Shortfall = &shortfall
+		}
+		estimate.Vests = append(estimate.Vests, vest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch vesting events: %w", err)
+	}
+
+	estimate.GrossValue = roundCents(estimate.GrossValue)
+	estimate.TaxWithheld = roundCents(estimate.TaxWithheld)
+	estimate.CashRefund = roundCents(estimate.CashRefund)
+	estimate.NetValue = roundCents(estimate.NetValue)
+	if estimate.Shortfall != nil {
+		shortfall := roundCents(*estimate.Shortfall)
+		estimate.Shortfall = &shortfall
+	}
+	return estimate, nil
+}
+
+// estimate fills in the withholding on the vest's shares at its price
+func (v *VestWithholding) estimate(rates WithholdingRates, expectedTaxPercent *float64) {
+	rate := math.Min(rates.TotalPercent/100, 1)
+	gross := v.SharesVesting * v.Price
+	tax := gross * rate
+
+	v.SharesWithheld = math.Min(math.Ceil(v.SharesVesting*rate), v.SharesVesting)
+	v.NetShares = v.SharesVesting - v.SharesWithheld
+	v.GrossValue = roundCents(gross)
+	v.TaxWithheld = roundCents(tax)
+	v.CashRefund = roundCents(v.SharesWithheld*v.Price - tax)
+	v.NetValue = roundCents(v.NetShares * v.Price)
+	if expectedTaxPercent != nil {
+		shortfall := roundCents(gross*(*expectedTaxPercent)/100 - tax)
+		v.Shortfall = &shortfall
+	}
+}
@@ -0,0 +1,582 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// What-if action types
+const (
+	WhatIfSellStock       = "sell_stock"
+	WhatIfPayOffMortgage  = "pay_off_mortgage"
+	WhatIfBuyProperty     = "buy_property"
+	WhatIfSellProperty    = "sell_property"
+	WhatIfExerciseOptions = "exercise_options"
+)
+
+// ErrInvalidWhatIf is returned for an action that cannot be applied to the
+// current holdings
+var ErrInvalidWhatIf = errors.New("invalid what-if action")
+
+// WhatIfAction is one hypothetical change. Which fields apply depends on Type:
+//   - sell_stock: Symbol, Shares and optionally Price per share (default current price)
+//   - pay_off_mortgage: PropertyID
+//   - buy_property: Price, DownPayment and optionally ClosingCosts
+//   - sell_property: PropertyID and optionally Price (default current value)
+//     and ClosingCosts, the selling costs; both for the whole property
+//   - exercise_options: GrantID, Shares, optionally Price, the fair market
+//     value per share (default current price), and Sell to sell the shares
+//     the same day
+type WhatIfAction struct {
+	Type         string           `json:"type" binding:"required,oneof=sell_stock pay_off_mortgage buy_property sell_property exercise_options"`
+	Symbol       string           `json:"symbol,omitempty"`
+	Shares       float64          `json:"shares,omitempty" binding:"gte=0"`
+	Price        *decimal.Decimal `json:"price,omitempty" binding:"omitempty,gte=0"`
+	PropertyID   int              `json:"property_id,omitempty" binding:"gte=0"`
+	DownPayment  decimal.Decimal  `json:"down_payment" binding:"gte=0"`
+	ClosingCosts decimal.Decimal  `json:"closing_costs" binding:"gte=0"`
+	GrantID      int              `json:"grant_id,omitempty" binding:"gte=0"`
+	Sell         bool             `json:"sell,omitempty"`
+}
+
+// WhatIfSnapshot is net worth and allocation before or after a scenario
+type WhatIfSnapshot struct {
+	NetWorth    decimal.Decimal            `json:"net_worth"`
+	TotalAssets decimal.Decimal            `json:"total_assets"`
+	Allocation  []models.NetWorthComponent `json:"allocation"`
+}
+
+// WhatIfOutcome is the effect of one action
+type WhatIfOutcome struct {
+	WhatIfAction
+	Description    string          `json:"description"`
+	CashChange     decimal.Decimal `json:"cash_change"`
+	NetWorthChange decimal.Decimal `json:"net_worth_change"`
+	ShortTermGain  decimal.Decimal `json:"short_term_gain"`
+	LongTermGain   decimal.Decimal `json:"long_term_gain"`
+	// DepreciationRecapture is the part of a property sale's gain from
+	// depreciation taken, taxed at the recapture rate
+	DepreciationRecapture decimal.Decimal `json:"depreciation_recapture"`
+	// OrdinaryIncome is the spread of an NSO exercise, or of ISO shares sold
+	// the same day, taxed at the short-term rate
+	OrdinaryIncome decimal.Decimal `json:"ordinary_income"`
+	// AMTPreference is the spread of ISO shares exercised and held, counted
+	// for the alternative minimum tax but not regular income tax
+	AMTPreference decimal.Decimal `json:"amt_preference"`
+	EstimatedTax  decimal.Decimal `json:"estimated_tax"`
+	Warnings      []string        `json:"warnings,omitempty"`
+}
+
+// WhatIfResult compares current net worth with a hypothetical scenario
+type WhatIfResult struct {
+	Current                 WhatIfSnapshot  `json:"current"`
+	Projected               WhatIfSnapshot  `json:"projected"`
+	NetWorthChange          decimal.Decimal `json:"net_worth_change"`
+	EstimatedTax            decimal.Decimal `json:"estimated_tax"`
+	ShortTermTaxRatePercent float64         `json:"short_term_tax_rate_percent"`
+	LongTermTaxRatePercent  float64         `json:"long_term_tax_rate_percent"`
+	RecaptureTaxRatePercent float64         `json:"recapture_tax_rate_percent"`
+	Actions                 []WhatIfOutcome `json:"actions"`
+}
+
+// WhatIfScenario applies hypothetical actions to the current holdings without
+// persisting anything. Sales are taxed at the configured capital gains rates
+// and the after-tax proceeds are added to cash.
+type WhatIfScenario struct {
+	breakdown      models.NetWorthBreakdown
+	stocks         []models.StockHolding
+	properties     []models.RealEstate
+	grants         []models.EquityGrant
+	shortRate      float64 // percent
+	longRate       float64 // percent
+	recaptureRate  float64 // percent
+	basisAdditions map[int]float64
+	now            time.Time
+	sold           map[int]float64 // shares sold so far per stock holding ID
+	soldProperties map[int]bool
+	exercised      map[int]float64 // options exercised so far per grant ID
+}
+
+// NewWhatIfScenario starts a scenario from the current breakdown, stock
+// holdings and properties
+func NewWhatIfScenario(breakdown models.NetWorthBreakdown, stocks []models.StockHolding, properties []models.RealEstate, shortTermRatePercent, longTermRatePercent float64, now time.Time) *WhatIfScenario {
+	return &WhatIfScenario{
+		breakdown:      breakdown,
+		stocks:         stocks,
+		properties:     properties,
+		shortRate:      shortTermRatePercent,
+		longRate:       longTermRatePercent,
+		now:            now,
+		sold:           make(map[int]float64),
+		soldProperties: make(map[int]bool),
+		exercised:      make(map[int]float64),
+	}
+}
+
+// WithGrants sets the equity grants options can be exercised from
+func (ws *WhatIfScenario) WithGrants(grants []models.EquityGrant) *WhatIfScenario {
+	ws.grants = grants
+	return ws
+}
+
+// WithDepreciation sets what property sales need beyond the capital gains
+// rates: the closing costs and capital improvements added to each property's
+// basis, by property ID, and the rate in percent that taxes depreciation
+// recapture
+func (ws *WhatIfScenario) WithDepreciation(basisAdditions map[int]float64, recaptureRatePercent float64) *WhatIfScenario {
+	ws.basisAdditions = basisAdditions
+	ws.recaptureRate = recaptureRatePercent
+	return ws
+}
+
+// Run applies the actions in order and compares the result with the starting
+// breakdown. Each action sees the effect of the ones before it.
+func (ws *WhatIfScenario) Run(actions []WhatIfAction) (*WhatIfResult, error) {
+	result := &WhatIfResult{
+		Current:                 newWhatIfSnapshot(ws.breakdown),
+		ShortTermTaxRatePercent: ws.shortRate,
+		LongTermTaxRatePercent:  ws.longRate,
+		RecaptureTaxRatePercent: ws.recaptureRate,
+		Actions:                 make([]WhatIfOutcome, 0, len(actions)),
+	}
+
+	for i, action := range actions {
+		var outcome *WhatIfOutcome
+		var err error
+		switch action.Type {
+		case WhatIfSellStock:
+			outcome, err = ws.sellStock(action)
+		case WhatIfPayOffMortgage:
+			outcome, err = ws.payOffMortgage(action)
+		case WhatIfBuyProperty:
+			outcome, err = ws.buyProperty(action)
+		case WhatIfSellProperty:
+			outcome, err = ws.sellProperty(action)
+		case WhatIfExerciseOptions:
+			outcome, err = ws.exerciseOptions(action)
+		default:
+			err = fmt.Errorf("%w: type must be %s, %s, %s, %s or %s", ErrInvalidWhatIf,
+				WhatIfSellStock, WhatIfPayOffMortgage, WhatIfBuyProperty, WhatIfSellProperty, WhatIfExerciseOptions)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+
+		if ws.breakdown.CashHoldingsValue.IsNegative() {
+			outcome.Warnings = append(outcome.Warnings, "cash holdings would be negative; the shortfall would have to come from other assets or new debt")
+		}
+		result.EstimatedTax = result.EstimatedTax.Add(outcome.EstimatedTax)
+		result.Actions = append(result.Actions, roundWhatIfOutcome(*outcome))
+	}
+
+	result.Projected = newWhatIfSnapshot(ws.breakdown)
+	result.NetWorthChange = result.Projected.NetWorth.Sub(result.Current.NetWorth)
+	result.EstimatedTax = result.EstimatedTax.Round(2)
+	return result, nil
+}
+
+// sellStock sells shares of a symbol from its stock holdings, oldest purchase
+// first, and adds the after-tax proceeds to cash
+func (ws *WhatIfScenario) sellStock(action WhatIfAction) (*WhatIfOutcome, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(action.Symbol))
+	if symbol == "" || action.Shares <= 0 {
+		return nil, fmt.Errorf("%w: sell_stock needs a symbol and shares greater than 0", ErrInvalidWhatIf)
+	}
+	if action.Price != nil && !action.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be greater than 0", ErrInvalidWhatIf)
+	}
+
+	var lots []models.StockHolding
+	var available float64
+	for _, lot := range ws.stocks {
+		if strings.ToUpper(lot.Symbol) == symbol && lot.SharesOwned-ws.sold[lot.ID] > 0 {
+			lots = append(lots, lot)
+			available += lot.SharesOwned - ws.sold[lot.ID]
+		}
+	}
+	if available < action.Shares {
+		return nil, fmt.Errorf("%w: only %g shares of %s are held", ErrInvalidWhatIf, available, symbol)
+	}
+
+	// Oldest lots first; lots without a purchase date last
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i].PurchaseDate, lots[j].PurchaseDate
+		return a != nil && (b == nil || a.Before(*b))
+	})
+
+	outcome := &WhatIfOutcome{WhatIfAction: action}
+	outcome.Symbol = symbol
+	longTermBefore := ws.now.AddDate(-1, 0, 0)
+	var proceeds, marketValue decimal.Decimal
+	var missingBasis, unknownDate float64
+	remaining := action.Shares
+	for _, lot := range lots {
+		if remaining <= 0 {
+			break
+		}
+		shares := lot.SharesOwned - ws.sold[lot.ID]
+		if shares > remaining {
+			shares = remaining
+		}
+		remaining -= shares
+		ws.sold[lot.ID] += shares
+
+		var currentPrice decimal.Decimal
+		if lot.CurrentPrice != nil {
+			currentPrice = *lot.CurrentPrice
+		}
+		salePrice := currentPrice
+		if action.Price != nil {
+			salePrice = *action.Price
+		}
+		if !salePrice.IsPositive() {
+			return nil, fmt.Errorf("%w: %s has no current price; pass a price", ErrInvalidWhatIf, symbol)
+		}
+
+		// The breakdown values holdings at the current price
+		quantity := decimal.NewFromFloat(shares)
+		value := quantity.Mul(currentPrice)
+		marketValue = marketValue.Add(value)
+		if lot.IsVestedEquity {
+			ws.breakdown.AddComponent("vested_equity", value.Neg())
+		} else {
+			ws.breakdown.AddComponent("stock_holdings", value.Neg())
+		}
+		proceeds = proceeds.Add(quantity.Mul(salePrice))
+
+		var costBasis decimal.Decimal
+		if lot.CostBasis != nil {
+			costBasis = *lot.CostBasis
+		} else {
+			missingBasis += shares
+		}
+		gain := quantity.Mul(salePrice.Sub(costBasis))
+		switch {
+		case lot.PurchaseDate == nil:
+			unknownDate += shares
+			outcome.ShortTermGain = outcome.ShortTermGain.Add(gain)
+		case lot.PurchaseDate.Before(longTermBefore):
+			outcome.LongTermGain = outcome.LongTermGain.Add(gain)
+		default:
+			outcome.ShortTermGain = outcome.ShortTermGain.Add(gain)
+		}
+	}
+
+	outcome.EstimatedTax = ws.capitalGainsTax(outcome.ShortTermGain, outcome.LongTermGain)
+	outcome.CashChange = proceeds.Sub(outcome.EstimatedTax)
+	outcome.NetWorthChange = outcome.CashChange.Sub(marketValue)
+	ws.breakdown.AddComponent("cash_holdings", outcome.CashChange)
+	outcome.Description = fmt.Sprintf("Sell %g shares of %s for $%s", action.Shares, symbol, proceeds.StringFixed(2))
+
+	if missingBasis > 0 {
+		outcome.Warnings = append(outcome.Warnings, fmt.Sprintf("%g shares have no cost basis and are treated as all gain", missingBasis))
+	}
+	if unknownDate > 0 {
+		outcome.Warnings = append(outcome.Warnings, fmt.Sprintf("%g shares have no purchase date and are treated as short-term", unknownDate))
+	}
+	if outcome.ShortTermGain.Add(outcome.LongTermGain).IsNegative() {
+		outcome.Warnings = append(outcome.Warnings, "the sale realizes a loss, which may offset other gains; no tax benefit is counted")
+	}
+	return outcome, nil
+}
+
+// capitalGainsTax taxes net short-term and long-term gains at their rates,
+// counting no benefit for losses beyond offsetting gains of the same sale
+func (ws *WhatIfScenario) capitalGainsTax(shortTerm, longTerm decimal.Decimal) decimal.Decimal {
+	return capitalGainsTax(shortTerm, longTerm, ws.shortRate, ws.longRate)
+}
+
+// capitalGainsTax taxes net short-term and long-term gains at rates given in
+// percent. A net loss in one term offsets gains in the other; no benefit is
+// counted for what remains.
+func capitalGainsTax(shortTerm, longTerm decimal.Decimal, shortRatePercent, longRatePercent float64) decimal.Decimal {
+	switch {
+	case shortTerm.IsNegative() && longTerm.IsPositive():
+		longTerm = longTerm.Add(shortTerm)
+		shortTerm = decimal.Zero
+	case longTerm.IsNegative() && shortTerm.IsPositive():
+		shortTerm = shortTerm.Add(longTerm)
+		longTerm = decimal.Zero
+	}
+
+	var tax decimal.Decimal
+	if shortTerm.IsPositive() {
+		tax = tax.Add(atRate(shortTerm, shortRatePercent))
+	}
+	if longTerm.IsPositive() {
+		tax = tax.Add(atRate(longTerm, longRatePercent))
+	}
+	return tax
+}
+
+// atRate returns ratePercent percent of amount
+func atRate(amount decimal.Decimal, ratePercent float64) decimal.Decimal {
+	return amount.Mul(decimal.NewFromFloat(ratePercent)).Div(decimal.NewFromInt(100))
+}
+
+// payOffMortgage pays the owner's share of a property's mortgage from cash,
+// moving value from cash into real estate equity
+func (ws *WhatIfScenario) payOffMortgage(action WhatIfAction) (*WhatIfOutcome, error) {
+	property, err := ws.property(action.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &WhatIfOutcome{WhatIfAction: action}
+	payoff := property.OwnedMortgage
+	if !payoff.IsPositive() {
+		outcome.Description = fmt.Sprintf("%s has no outstanding mortgage", property.PropertyName)
+		return outcome, nil
+	}
+
+	// Zero the mortgage so a second payoff of the same property does nothing
+	property.OwnedMortgage = decimal.Zero
+	ws.breakdown.AddComponent("cash_holdings", payoff.Neg())
+	ws.breakdown.AddComponent("real_estate", payoff)
+	outcome.CashChange = payoff.Neg()
+	outcome.Description = fmt.Sprintf("Pay off the $%s mortgage on %s", payoff.StringFixed(2), property.PropertyName)
+	outcome.Warnings = append(outcome.Warnings, "mortgage interest deductions and investment returns on the cash are not modeled")
+	return outcome, nil
+}
+
+// property returns a property still held in the scenario
+func (ws *WhatIfScenario) property(id int) (*models.RealEstate, error) {
+	if ws.soldProperties[id] {
+		return nil, fmt.Errorf("%w: property %d is already sold in this scenario", ErrInvalidWhatIf, id)
+	}
+	for i := range ws.properties {
+		if ws.properties[i].ID == id {
+			return &ws.properties[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: property %d does not exist", ErrInvalidWhatIf, id)
+}
+
+// sellProperty sells the owner's share of a property, pays off its mortgage
+// and adds the after-tax proceeds to cash. The gain is over the adjusted
+// basis; the part of it from depreciation taken is taxed at the recapture
+// rate and the rest as a capital gain.
+func (ws *WhatIfScenario) sellProperty(action WhatIfAction) (*WhatIfOutcome, error) {
+	property, err := ws.property(action.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+	price := property.CurrentValue
+	if action.Price != nil {
+		price = *action.Price
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be greater than 0", ErrInvalidWhatIf)
+	}
+	if action.ClosingCosts.IsNegative() {
+		return nil, fmt.Errorf("%w: closing_costs must not be negative", ErrInvalidWhatIf)
+	}
+
+	share := decimal.NewFromFloat(property.OwnershipPercentage).Div(decimal.NewFromInt(100))
+	depreciation := DepreciateProperty(*property, ws.basisAdditions[property.ID], ws.now)
+	netPrice := price.Sub(action.ClosingCosts)
+	gain := share.Mul(netPrice.Sub(decimal.NewFromFloat(depreciation.AdjustedBasis)))
+
+	outcome := &WhatIfOutcome{WhatIfAction: action}
+	capitalGain := gain
+	if gain.IsPositive() {
+		outcome.DepreciationRecapture = decimal.Min(gain, share.Mul(decimal.NewFromFloat(depreciation.AccumulatedDepreciation)))
+		capitalGain = capitalGain.Sub(outcome.DepreciationRecapture)
+	}
+	purchased, err := time.Parse("2006-01-02", property.PurchaseDate)
+	if err == nil && purchased.Before(ws.now.AddDate(-1, 0, 0)) {
+		outcome.LongTermGain = capitalGain
+	} else {
+		outcome.ShortTermGain = capitalGain
+	}
+	outcome.EstimatedTax = ws.capitalGainsTax(outcome.ShortTermGain, outcome.LongTermGain).
+		Add(atRate(outcome.DepreciationRecapture, ws.recaptureRate))
+
+	// property.OwnedMortgage reflects any payoff earlier in the scenario
+	equity := property.OwnedValue.Sub(property.OwnedMortgage)
+	outcome.CashChange = share.Mul(netPrice).Sub(property.OwnedMortgage).Sub(outcome.EstimatedTax)
+	outcome.NetWorthChange = outcome.CashChange.Sub(equity)
+	ws.breakdown.AddComponent("real_estate", equity.Neg())
+	ws.breakdown.AddComponent("cash_holdings", outcome.CashChange)
+	ws.soldProperties[property.ID] = true
+	outcome.Description = fmt.Sprintf("Sell %s for $%s", property.PropertyName, price.StringFixed(2))
+
+	if property.PropertyType == "primary_residence" {
+		outcome.Warnings = append(outcome.Warnings, "the home sale exclusion for a primary residence is not applied")
+	}
+	if gain.IsNegative() {
+		outcome.Warnings = append(outcome.Warnings, "the sale realizes a loss, which may offset other gains; no tax benefit is counted")
+	}
+	return outcome, nil
+}
+
+// buyProperty pays the down payment and closing costs from cash and adds the
+// property's equity; the rest of the price is a new mortgage
+func (ws *WhatIfScenario) buyProperty(action WhatIfAction) (*WhatIfOutcome, error) {
+	if action.Price == nil || !action.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: buy_property needs a price greater than 0", ErrInvalidWhatIf)
+	}
+	price := *action.Price
+	if action.DownPayment.IsNegative() || action.DownPayment.GreaterThan(price) {
+		return nil, fmt.Errorf("%w: down_payment must be between 0 and the price", ErrInvalidWhatIf)
+	}
+	if action.ClosingCosts.IsNegative() {
+		return nil, fmt.Errorf("%w: closing_costs must not be negative", ErrInvalidWhatIf)
+	}
+
+	outcome := &WhatIfOutcome{WhatIfAction: action}
+	outcome.CashChange = action.DownPayment.Add(action.ClosingCosts).Neg()
+	outcome.NetWorthChange = action.ClosingCosts.Neg()
+	ws.breakdown.AddComponent("cash_holdings", outcome.CashChange)
+	ws.breakdown.AddComponent("real_estate", action.DownPayment)
+	outcome.Description = fmt.Sprintf("Buy a $%s property with $%s down and a $%s mortgage",
+		price.StringFixed(2), action.DownPayment.StringFixed(2), price.Sub(action.DownPayment).StringFixed(2))
+	return outcome, nil
+}
+
+// exerciseOptions exercises options of an ISO or NSO grant, paying the strike
+// price from cash. The spread over the strike price is taxed by grant type:
+//   - nso: ordinary income, at the short-term rate
+//   - iso held: an AMT preference with no regular tax
+//   - iso sold the same day: a disqualifying disposition, so ordinary income
+//
+// Held shares move from equity to stock holdings; sold shares become cash.
+// Unvested options can only be exercised early on a grant with an 83(b)
+// election.
+func (ws *WhatIfScenario) exerciseOptions(action WhatIfAction) (*WhatIfOutcome, error) {
+	if action.Shares <= 0 {
+		return nil, fmt.Errorf("%w: exercise_options needs shares greater than 0", ErrInvalidWhatIf)
+	}
+	if action.Price != nil && !action.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be greater than 0", ErrInvalidWhatIf)
+	}
+
+	var grant *models.EquityGrant
+	for i := range ws.grants {
+		if ws.grants[i].ID == action.GrantID {
+			grant = &ws.grants[i]
+		}
+	}
+	if grant == nil {
+		return nil, fmt.Errorf("%w: equity grant %d does not exist", ErrInvalidWhatIf, action.GrantID)
+	}
+	grantType := models.NormalizeGrantType(grant.GrantType)
+	if !models.IsOptionGrant(grantType) {
+		return nil, fmt.Errorf("%w: grant %d is an %s grant, which has no options to exercise; sell its shares with %s",
+			ErrInvalidWhatIf, grant.ID, strings.ToUpper(grantType), WhatIfSellStock)
+	}
+	if grant.ExpirationDate != nil && grant.ExpirationDate.Before(ws.now) {
+		return nil, fmt.Errorf("%w: grant %d expired on %s", ErrInvalidWhatIf, grant.ID, grant.ExpirationDate.Format("2006-01-02"))
+	}
+
+	// Vested options are exercised first
+	exercised := ws.exercised[grant.ID]
+	vested := math.Max(grant.VestedShares-exercised, 0)
+	unvested := grant.UnvestedShares - math.Max(exercised-grant.VestedShares, 0)
+	if action.Shares > vested+unvested {
+		return nil, fmt.Errorf("%w: only %g options of grant %d are left to exercise", ErrInvalidWhatIf, vested+unvested, grant.ID)
+	}
+	early := math.Max(action.Shares-vested, 0)
+	if early > 0 && grant.Election83bFiledDate == nil {
+		return nil, fmt.Errorf("%w: only %g options of grant %d are vested; exercising early needs an 83(b) election on the grant",
+			ErrInvalidWhatIf, vested, grant.ID)
+	}
+	ws.exercised[grant.ID] += action.Shares
+
+	var currentPrice, strike decimal.Decimal
+	if grant.CurrentPrice != nil {
+		currentPrice = *grant.CurrentPrice
+	}
+	if grant.StrikePrice != nil {
+		strike = *grant.StrikePrice
+	}
+	price := currentPrice
+	if action.Price != nil {
+		price = *action.Price
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("%w: %s has no current price; pass a price", ErrInvalidWhatIf, grant.CompanySymbol)
+	}
+
+	outcome := &WhatIfOutcome{WhatIfAction: action}
+	outcome.Symbol = strings.ToUpper(grant.CompanySymbol)
+	shares := decimal.NewFromFloat(action.Shares)
+	spread := decimal.Max(shares.Mul(price.Sub(strike)), decimal.Zero)
+	if grantType == models.GrantTypeISO && !action.Sell {
+		outcome.AMTPreference = spread
+	} else {
+		outcome.OrdinaryIncome = spread
+		outcome.EstimatedTax = atRate(spread, ws.shortRate)
+	}
+
+	// The breakdown values options at the current price, counting only vested
+	// ones in net worth
+	equityValue := decimal.NewFromFloat(action.Shares - early).Mul(currentPrice)
+	ws.breakdown.AddComponent("vested_equity", equityValue.Neg())
+	ws.breakdown.AddComponent("unvested_equity", decimal.NewFromFloat(early).Mul(currentPrice).Neg())
+	cost := shares.Mul(strike)
+	value := shares.Mul(price)
+	outcome.CashChange = cost.Neg().Sub(outcome.EstimatedTax)
+	if action.Sell {
+		outcome.CashChange = outcome.CashChange.Add(value)
+		outcome.NetWorthChange = outcome.CashChange.Sub(equityValue)
+		outcome.Description = fmt.Sprintf("Exercise and sell %g %s options of %s at $%s",
+			action.Shares, strings.ToUpper(grantType), outcome.Symbol, price.StringFixed(2))
+	} else {
+		ws.breakdown.AddComponent("stock_holdings", value)
+		outcome.NetWorthChange = outcome.CashChange.Add(value).Sub(equityValue)
+		outcome.Description = fmt.Sprintf("Exercise %g %s options of %s for $%s",
+			action.Shares, strings.ToUpper(grantType), outcome.Symbol, cost.StringFixed(2))
+	}
+	ws.breakdown.AddComponent("cash_holdings", outcome.CashChange)
+
+	switch {
+	case grantType == models.GrantTypeISO && action.Sell:
+		outcome.Warnings = append(outcome.Warnings, "selling ISO shares the same day is a disqualifying disposition; the spread is taxed as ordinary income")
+	case grantType == models.GrantTypeISO:
+		outcome.Warnings = append(outcome.Warnings, "the spread is an AMT preference item; alternative minimum tax is not estimated")
+		if grant.AMTBasisPerShare != nil {
+			outcome.Warnings = append(outcome.Warnings, "the grant already records an AMT basis from an earlier exercise")
+		}
+	}
+	if early > 0 {
+		outcome.Warnings = append(outcome.Warnings, fmt.Sprintf("%g options are exercised before vesting under the grant's 83(b) election", early))
+	}
+	if !price.GreaterThan(strike) {
+		outcome.Warnings = append(outcome.Warnings, "the options are underwater; exercising them costs more than the shares are worth")
+	}
+	return outcome, nil
+}
+
+func newWhatIfSnapshot(b models.NetWorthBreakdown) WhatIfSnapshot {
+	components := b.Components()
+	for i := range components {
+		components[i].Value = components[i].Value.Round(2)
+		components[i].Percentage = roundCents(components[i].Percentage)
+	}
+	return WhatIfSnapshot{
+		NetWorth:    b.NetWorth().Round(2),
+		TotalAssets: b.TotalAssets().Round(2),
+		Allocation:  components,
+	}
+}
+
+func roundWhatIfOutcome(outcome WhatIfOutcome) WhatIfOutcome {
+	outcome.CashChange = outcome.CashChange.Round(2)
+	outcome.NetWorthChange = outcome.NetWorthChange.Round(2)
+	outcome.ShortTermGain = outcome.ShortTermGain.Round(2)
+	outcome.LongTermGain = outcome.LongTermGain.Round(2)
+	outcome.DepreciationRecapture = outcome.DepreciationRecapture.Round(2)
+	outcome.OrdinaryIncome = outcome.OrdinaryIncome.Round(2)
+	outcome.AMTPreference = outcome.AMTPreference.Round(2)
+	outcome.EstimatedTax = outcome.EstimatedTax.Round(2)
+	return outcome
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"networth-dashboard/internal/models"
+)
+
+// Retirement withdrawal strategies
+const (
+	// WithdrawalStrategyFixed withdraws the same inflation-adjusted amount
+	// every year, 4% of the starting balance in the classic rule
+	WithdrawalStrategyFixed = "fixed"
+	// WithdrawalStrategyGuardrails starts like fixed, then cuts spending 10%
+	// when the withdrawal rate drifts 20% above the starting rate and raises
+	// it 10% when the rate drifts 20% below
+	WithdrawalStrategyGuardrails = "guardrails"
+	// WithdrawalStrategyBucket spends from cash, refilling it to two years of
+	// spending from stocks after years they rose and from bonds after years
+	// they fell, without otherwise rebalancing
+	WithdrawalStrategyBucket = "bucket"
+)
+
+// WithdrawalStrategies lists every strategy in the order they are reported
+var WithdrawalStrategies = []string{WithdrawalStrategyFixed, WithdrawalStrategyGuardrails, WithdrawalStrategyBucket}
+
+// ErrInvalidWithdrawalSimulation is returned for a simulation that cannot be run
+var ErrInvalidWithdrawalSimulation = errors.New("invalid withdrawal simulation")
+
+// Withdrawal simulation defaults and strategy parameters
+const (
+	DefaultSimulationYears = 30
+	DefaultSimulationCount = 1000
+	guardrailBand          = 0.20
+	guardrailAdjustment    = 0.10
+	bucketCashYears        = 2
+)
+
+// AssetClassAssumption is the real (after inflation) annual return and
+// volatility, in percent, simulated for an asset class, and how closely it
+// moves with the stock market from 0 to 1
+type AssetClassAssumption struct {
+	Return            float64 `json:"return"`
+	Volatility        float64 `json:"volatility"`
+	MarketCorrelation float64 `json:"market_correlation"`
+}
+
+// withdrawalAssetClasses are the net worth components withdrawals are funded
+// from, in the order they are reported, with their simulated returns. A
+// single employer's stock and crypto are more volatile than the market.
+var withdrawalAssetClasses = []struct {
+	key        string
+	assumption AssetClassAssumption
+}{
+	{"stock_holdings", AssetClassAssumption{Return: 6.5, Volatility: 17, MarketCorrelation: 1}},
+	{"vested_equity", AssetClassAssumption{Return: 6.5, Volatility: 30, MarketCorrelation: 0.7}},
+	{"crypto_holdings", AssetClassAssumption{Return: 8, Volatility: 65, MarketCorrelation: 0.4}},
+	{"private_investments", AssetClassAssumption{Return: 7, Volatility: 25, MarketCorrelation: 0.6}},
+	{"fixed_income", AssetClassAssumption{Return: 1.5, Volatility: 6, MarketCorrelation: 0}},
+	{"cash_holdings", AssetClassAssumption{Return: 0.5, Volatility: 1, MarketCorrelation: 0}},
+}
+
+// WithdrawalSimulationInput configures a withdrawal simulation. Spending is
+// AnnualSpending when given, otherwise WithdrawalRate percent of the
+// starting balance. Without Seed each run draws different returns.
+type WithdrawalSimulationInput struct {
+	Years          int      `json:"years" binding:"omitempty,min=1,max=60"`
+	Simulations    int      `json:"simulations" binding:"omitempty,min=100,max=10000"`
+	WithdrawalRate float64  `json:"withdrawal_rate" binding:"omitempty,gt=0,lte=20"`
+	AnnualSpending *float64 `json:"annual_spending" binding:"omitempty,gt=0"`
+	Strategies     []string `json:"strategies" binding:"omitempty,dive,oneof=fixed guardrails bucket"`
+	Seed           *int64   `json:"seed"`
+}
+
+// WithdrawalAllocation is one asset class of the simulated portfolio
+type WithdrawalAllocation struct {
+	AssetClass string  `json:"asset_class"`
+	Value      float64 `json:"value"`
+	Percentage float64 `json:"percentage"`
+	AssetClassAssumption
+}
+
+// WithdrawalStrategyResult is how a strategy fared across every simulation.
+// Balances and spending are in today's dollars; a simulation fails when the
+// portfolio can't cover a year's spending.
+type WithdrawalStrategyResult struct {
+	Strategy            string  `json:"strategy"`
+	FailureRate         float64 `json:"failure_rate"`
+	MedianEndingBalance float64 `json:"median_ending_balance"`
+	EndingBalanceP10    float64 `json:"ending_balance_p10"`
+	EndingBalanceP90    float64 `json:"ending_balance_p90"`
+	// MedianAnnualSpending is the median of each simulation's average yearly
+	// spending, and MedianLowestSpending of its lowest year's
+	MedianAnnualSpending float64 `json:"median_annual_spending"`
+	MedianLowestSpending float64 `json:"median_lowest_spending"`
+}
+
+// WithdrawalSimulation compares withdrawal strategies on the same simulated
+// returns of the current allocation
+type WithdrawalSimulation struct {
+	StartingBalance float64                    `json:"starting_balance"`
+	AnnualSpending  float64                    `json:"annual_spending"`
+	WithdrawalRate  float64                    `json:"withdrawal_rate"`
+	Years           int                        `json:"years"`
+	Simulations     int                        `json:"simulations"`
+	Seed            int64                      `json:"seed"`
+	Allocation      []WithdrawalAllocation     `json:"allocation"`
+	Strategies      []WithdrawalStrategyResult `json:"strategies"`
+}
+
+// withdrawalPath is the outcome of one strategy in one simulation
+type withdrawalPath struct {
+	ending, spent, lowest float64
+	failed                bool
+}
+
+// SimulateWithdrawals runs a Monte Carlo simulation of retirement withdrawals
+// from the invested assets of b. Each simulated year draws a real return for
+// every asset class around its assumed return, moving with a shared stock
+// market draw by its correlation. Every strategy is run against the same
+// draws. Spending is withdrawn at the start of each year.
+func SimulateWithdrawals(b models.NetWorthBreakdown, input WithdrawalSimulationInput) (*WithdrawalSimulation, error) {
+	values := map[string]float64{}
+	for _, component := range b.Components() {
+		values[component.Key] = component.Value.InexactFloat64()
+	}
+
+	sim := &WithdrawalSimulation{Years: input.Years, Simulations: input.Simulations}
+	if sim.Years == 0 {
+		sim.Years = DefaultSimulationYears
+	}
+	if sim.Simulations == 0 {
+		sim.Simulations = DefaultSimulationCount
+	}
+	sim.Seed = time.Now().UnixNano()
+	if input.Seed != nil {
+		sim.Seed = *input.Seed
+	}
+
+	weights := make([]float64, len(withdrawalAssetClasses))
+	for i, class := range withdrawalAssetClasses {
+		if value := values[class.key]; value > 0 {
+			weights[i] = value
+			sim.StartingBalance += value
+		}
+	}
+	if sim.StartingBalance <= 0 {
+		return nil, fmt.Errorf("%w: no invested assets to withdraw from", ErrInvalidWithdrawalSimulation)
+	}
+	for i, class := range withdrawalAssetClasses {
+		sim.Allocation = append(sim.Allocation, WithdrawalAllocation{
+			AssetClass:           class.key,
+			Value:                roundCents(weights[i]),
+			Percentage:           roundPercent(weights[i] / sim.StartingBalance * 100),
+			AssetClassAssumption: class.assumption,
+		})
+		weights[i] /= sim.StartingBalance
+	}
+
+	switch {
+	case input.AnnualSpending != nil:
+		sim.AnnualSpending = *input.AnnualSpending
+	case input.WithdrawalRate > 0:
+		sim.AnnualSpending = sim.StartingBalance * input.WithdrawalRate / 100
+	default:
+		sim.AnnualSpending = sim.StartingBalance * DefaultWithdrawalRate / 100
+	}
+	sim.WithdrawalRate = roundPercent(sim.AnnualSpending / sim.StartingBalance * 100)
+
+	strategies := input.Strategies
+	if len(strategies) == 0 {
+		strategies = WithdrawalStrategies
+	}
+	paths := make(map[string][]withdrawalPath, len(strategies))
+
+	rng := rand.New(rand.NewSource(sim.Seed))
+	returns := make([][]float64, sim.Years)
+	for i := range returns {
+		returns[i] = make([]float64, len(withdrawalAssetClasses))
+	}
+	for n := 0; n < sim.Simulations; n++ {
+		for year := range returns {
+			market := rng.NormFloat64()
+			for i, class := range withdrawalAssetClasses {
+				a := class.assumption
+				z := a.MarketCorrelation*market + math.Sqrt(1-a.MarketCorrelation*a.MarketCorrelation)*rng.NormFloat64()
+				returns[year][i] = math.Max((a.Return+a.Volatility*z)/100, -1)
+			}
+		}
+		for _, strategy := range strategies {
+			var path withdrawalPath
+			switch strategy {
+			case WithdrawalStrategyFixed:
+				path = withdrawRebalanced(sim.StartingBalance, sim.AnnualSpending, weights, returns, false)
+			case WithdrawalStrategyGuardrails:
+				path = withdrawRebalanced(sim.StartingBalance, sim.AnnualSpending, weights, returns, true)
+			case WithdrawalStrategyBucket:
+				path = withdrawFromBuckets(sim.StartingBalance, sim.AnnualSpending, weights, returns)
+			default:
+				return nil, fmt.Errorf("%w: strategy must be fixed, guardrails or bucket", ErrInvalidWithdrawalSimulation)
+			}
+			paths[strategy] = append(paths[strategy], path)
+		}
+	}
+
+	for _, strategy := range strategies {
+		sim.Strategies = append(sim.Strategies, summarizeWithdrawals(strategy, paths[strategy], sim.Years))
+	}
+	sim.StartingBalance = roundCents(sim.StartingBalance)
+	sim.AnnualSpending = roundCents(sim.AnnualSpending)
+	return sim, nil
+}
+
+// withdrawRebalanced withdraws spending from a portfolio rebalanced to
+// weights every year. With guardrails, spending is cut or raised when the
+// withdrawal rate leaves the band around the starting rate.
+func withdrawRebalanced(balance, spending float64, weights []float64, returns [][]float64, guardrails bool) withdrawalPath {
+	initialRate := spending / balance
+	path := withdrawalPath{lowest: spending}
+	for _, yearReturns := range returns {
+		if guardrails && balance > 0 {
+			switch rate := spending / balance; {
+			case rate > initialRate*(1+guardrailBand):
+				spending *= 1 - guardrailAdjustment
+			case rate < initialRate*(1-guardrailBand):
+				spending *= 1 + guardrailAdjustment
+			}
+		}
+		path.lowest = math.Min(path.lowest, spending)
+		if balance < spending {
+			path.spent += balance
+			path.failed = true
+			return path
+		}
+		balance -= spending
+		path.spent += spending
+
+		growth := 0.0
+		for i, weight := range weights {
+			growth += weight * yearReturns[i]
+		}
+		balance *= 1 + growth
+	}
+	path.ending = balance
+	return path
+}
+
+// withdrawFromBuckets spends from cash, then bonds, then the rest. After each
+// year cash is refilled to bucketCashYears of spending from the growth assets
+// when they rose, or from bonds when they fell.
+func withdrawFromBuckets(balance, spending float64, weights []float64, returns [][]float64) withdrawalPath {
+	var cash, bonds float64
+	growth := make([]float64, len(weights))
+	for i, class := range withdrawalAssetClasses {
+		switch class.key {
+		case "cash_holdings":
+			cash = balance * weights[i]
+		case "fixed_income":
+			bonds = balance * weights[i]
+		default:
+			growth[i] = balance * weights[i]
+		}
+	}
+	sum := func(values []float64) float64 {
+		total := 0.0
+		for _, v := range values {
+			total += v
+		}
+		return total
+	}
+	// take removes up to amount from the growth assets in proportion to
+	// their values, and returns how much it took
+	take := func(amount float64) float64 {
+		total := sum(growth)
+		if total <= 0 {
+			return 0
+		}
+		amount = math.Min(amount, total)
+		for i := range growth {
+			growth[i] -= amount * growth[i] / total
+		}
+		return amount
+	}
+
+	path := withdrawalPath{lowest: spending}
+	for _, yearReturns := range returns {
+		if cash+bonds+sum(growth) < spending {
+			path.spent += cash + bonds + sum(growth)
+			path.failed = true
+			return path
+		}
+		need := spending
+		fromCash := math.Min(cash, need)
+		cash -= fromCash
+		need -= fromCash
+		fromBonds := math.Min(bonds, need)
+		bonds -= fromBonds
+		need -= fromBonds
+		take(need)
+		path.spent += spending
+
+		before := sum(growth)
+		for i, class := range withdrawalAssetClasses {
+			switch class.key {
+			case "cash_holdings":
+				cash *= 1 + yearReturns[i]
+			case "fixed_income":
+				bonds *= 1 + yearReturns[i]
+			default:
+				growth[i] *= 1 + yearReturns[i]
+			}
+		}
+
+		shortfall := bucketCashYears*spending - cash
+		if shortfall <= 0 {
+			continue
+		}
+		if sum(growth) >= before {
+			cash += take(shortfall)
+		} else {
+			fromBonds := math.Min(bonds, shortfall)
+			bonds -= fromBonds
+			cash += fromBonds + take(shortfall-fromBonds)
+		}
+	}
+	path.ending = cash + bonds + sum(growth)
+	return path
+}
+
+// summarizeWithdrawals reduces one strategy's simulations to its failure
+// rate and the spread of ending balances and spending
+func summarizeWithdrawals(strategy string, paths []withdrawalPath, years int) WithdrawalStrategyResult {
+	endings := make([]float64, len(paths))
+	spending := make([]float64, len(paths))
+	lowest := make([]float64, len(paths))
+	failures := 0
+	for i, path := range paths {
+		endings[i] = path.ending
+		spending[i] = path.spent / float64(years)
+		lowest[i] = path.lowest
+		if path.failed {
+			failures++
+		}
+	}
+	sort.Float64s(endings)
+
+	return WithdrawalStrategyResult{
+		Strategy:             strategy,
+		FailureRate:          roundPercent(float64(failures) / float64(len(paths)) * 100),
+		MedianEndingBalance:  roundCents(median(endings)),
+		EndingBalanceP10:     roundCents(percentileOf(endings, 10)),
+		EndingBalanceP90:     roundCents(percentileOf(endings, 90)),
+		MedianAnnualSpending: roundCents(median(spending)),
+		MedianLowestSpending: roundCents(median(lowest)),
+	}
+}
+
+// percentileOf returns the pth percentile of sorted values, interpolating
+// between the two nearest ranks
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
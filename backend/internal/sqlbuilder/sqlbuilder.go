@@ -0,0 +1,95 @@
+// Package sqlbuilder assembles queries with dynamic SET and WHERE clauses.
+// Values are only ever bound as numbered placeholders, and column and table
+// names must be plain identifiers and are quoted, so input never becomes part
+// of the SQL.
+package sqlbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// identifierPattern matches a lowercase name, optionally qualified by a
+// table or alias, e.g. sort_order or t.created_at
+var identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// operators are the comparisons Where.Compare accepts
+var operators = map[string]bool{
+	"=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// Ident returns name double-quoted after checking it is a plain identifier,
+// e.g. "t"."created_at". Identifiers come from code or an allow-list, so
+// anything else is a programming error and panics.
+func Ident(name string) string {
+	if !identifierPattern.MatchString(name) {
+		panic(fmt.Sprintf("sqlbuilder: invalid identifier %q", name))
+	}
+	return `"` + strings.ReplaceAll(name, ".", `"."`) + `"`
+}
+
+// Args collects query arguments, numbering them as PostgreSQL placeholders
+type Args []interface{}
+
+// Bind adds value and returns its placeholder, e.g. $3
+func (a *Args) Bind(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// Where is a list of conditions joined with AND
+type Where []string
+
+// Add appends a condition written in code. Values in it must come from Bind.
+func (w *Where) Add(condition string) {
+	*w = append(*w, condition)
+}
+
+// Compare appends "column op value", binding value to args
+func (w *Where) Compare(args *Args, column, op string, value interface{}) {
+	if !operators[op] {
+		panic(fmt.Sprintf("sqlbuilder: invalid operator %q", op))
+	}
+	w.Add(Ident(column) + " " + op + " " + args.Bind(value))
+}
+
+// String joins the conditions with AND, or returns "true" when there are none
+func (w Where) String() string {
+	if len(w) == 0 {
+		return "true"
+	}
+	return strings.Join(w, " AND ")
+}
+
+// Update builds an UPDATE of one row by ID, setting only the columns given
+type Update struct {
+	table string
+	sets  []string
+	args  Args
+}
+
+// NewUpdate starts an update of table
+func NewUpdate(table string) *Update {
+	return &Update{table: Ident(table)}
+}
+
+// Set sets column to value
+func (u *Update) Set(column string, value interface{}) *Update {
+	u.sets = append(u.sets, Ident(column)+" = "+u.args.Bind(value))
+	return u
+}
+
+// Empty reports whether no column has been set
+func (u *Update) Empty() bool {
+	return len(u.sets) == 0
+}
+
+// WhereID returns the query updating the row with id, and its arguments
+func (u *Update) WhereID(id interface{}) (string, []interface{}) {
+	args := append(Args{}, u.args...)
+	placeholder := args.Bind(id)
+	query := "UPDATE " + u.table + " SET " + strings.Join(u.sets, ", ") + " WHERE id = " + placeholder
+	return query, args
+}
@@ -0,0 +1,145 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// quotedIdentifier matches what Ident returns: a plain identifier, each part
+// double-quoted
+var quotedIdentifier = regexp.MustCompile(`^"[a-z_][a-z0-9_]*"(\."[a-z_][a-z0-9_]*")?$`)
+
+// panics reports whether f panics
+func panics(f func()) (panicked bool) {
+	defer func() {
+		if recover() != nil {
+			panicked = true
+		}
+	}()
+	f()
+	return false
+}
+
+func TestIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"sort_order", `"sort_order"`},
+		{"t.created_at", `"t"."created_at"`},
+		{"_x9", `"_x9"`},
+	}
+	for _, tt := range tests {
+		if got := Ident(tt.name); got != tt.want {
+			t.Errorf("Ident(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"", "Name", "1st", "a.b.c", `name"`, "name; DROP TABLE accounts", "a b", "t."} {
+		if !panics(func() { Ident(name) }) {
+			t.Errorf("Ident(%q) did not panic", name)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	update := NewUpdate("asset_categories")
+	if !update.Empty() {
+		t.Error("new update is not empty")
+	}
+	update.Set("name", "Art").Set("sort_order", 2)
+	query, args := update.WhereID(7)
+
+	want := `UPDATE "asset_categories" SET "name" = $1, "sort_order" = $2 WHERE id = $3`
+	if query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Art", 2, 7}) {
+		t.Errorf("args = %v", args)
+	}
+
+	// WhereID leaves the update as it was, so it can be built again
+	if again, _ := update.WhereID(8); again != want {
+		t.Errorf("second WhereID = %s, want %s", again, want)
+	}
+}
+
+func FuzzIdent(f *testing.F) {
+	for _, seed := range []string{"name", "t.created_at", "", `a"; DROP TABLE x; --`, "Name", "a.b.c", "é"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		var quoted string
+		if panics(func() { quoted = Ident(name) }) {
+			if identifierPattern.MatchString(name) {
+				t.Fatalf("Ident(%q) panicked on a plain identifier", name)
+			}
+			return
+		}
+		if !identifierPattern.MatchString(name) {
+			t.Fatalf("Ident(%q) = %s, want a panic", name, quoted)
+		}
+		if !quotedIdentifier.MatchString(quoted) {
+			t.Fatalf("Ident(%q) = %s, not a quoted identifier", name, quoted)
+		}
+		if unquoted := strings.ReplaceAll(quoted, `"`, ""); unquoted != name {
+			t.Fatalf("Ident(%q) = %s names %s", name, quoted, unquoted)
+		}
+	})
+}
+
+func FuzzWhere(f *testing.F) {
+	f.Add("amount", ">=", "100", "t.date", "2026-01-01")
+	f.Add("name", "=", "x' OR '1'='1", "id", "1; DROP TABLE accounts")
+	f.Add("Name", "LIKE", "%", "a b", "")
+	f.Fuzz(func(t *testing.T, column1, op, value1, column2, value2 string) {
+		var args Args
+		var where Where
+		if panics(func() {
+			where.Compare(&args, column1, op, value1)
+			where.Compare(&args, column2, "=", value2)
+		}) {
+			if identifierPattern.MatchString(column1) && identifierPattern.MatchString(column2) && operators[op] {
+				t.Fatalf("Compare panicked on %q %q and %q", column1, op, column2)
+			}
+			return
+		}
+
+		want := fmt.Sprintf("%s %s $1 AND %s = $2", Ident(column1), op, Ident(column2))
+		if got := where.String(); got != want {
+			t.Fatalf("Where = %s, want %s", got, want)
+		}
+		if !reflect.DeepEqual(args, Args{value1, value2}) {
+			t.Fatalf("args = %v, want the values bound in order", args)
+		}
+	})
+}
+
+func FuzzUpdate(f *testing.F) {
+	f.Add("asset_categories", "name", "Art", "color", "#fff", 7)
+	f.Add("accounts", "owner", "self'; DROP TABLE accounts; --", "custodial", "true", -1)
+	f.Add("Accounts", "a.b.c", "", "x y", "", 0)
+	f.Fuzz(func(t *testing.T, table, column1, value1, column2, value2 string, id int) {
+		var query string
+		var args []interface{}
+		if panics(func() {
+			query, args = NewUpdate(table).Set(column1, value1).Set(column2, value2).WhereID(id)
+		}) {
+			if identifierPattern.MatchString(table) && identifierPattern.MatchString(column1) && identifierPattern.MatchString(column2) {
+				t.Fatalf("update of %q setting %q and %q panicked", table, column1, column2)
+			}
+			return
+		}
+
+		want := fmt.Sprintf("UPDATE %s SET %s = $1, %s = $2 WHERE id = $3", Ident(table), Ident(column1), Ident(column2))
+		if query != want {
+			t.Fatalf("query = %s, want %s", query, want)
+		}
+		if !reflect.DeepEqual(args, []interface{}{value1, value2, id}) {
+			t.Fatalf("args = %v, want the values and ID bound in order", args)
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+	"networth-dashboard/internal/telemetry"
+)
+
+const (
+	// unsignedPayload skips hashing request bodies, which S3 and MinIO accept
+	// for both signed requests and presigned URLs
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// maxPresignTTL is the longest expiry S3 allows on a presigned URL
+	maxPresignTTL = 7 * 24 * time.Hour
+)
+
+// s3Store keeps objects in an S3 bucket, or a bucket on an S3-compatible
+// server such as MinIO, signing requests with AWS Signature Version 4
+type s3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	prefix    string
+	client    *http.Client
+}
+
+func newS3Store(cfg config.S3Config) (*s3Store, error) {
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("the s3 storage backend needs S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", cfg.Endpoint)
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		if err := validateKey(prefix); err != nil {
+			return nil, fmt.Errorf("invalid S3_PREFIX: %w", err)
+		}
+		prefix += "/"
+	}
+
+	return &s3Store{
+		endpoint:  u,
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		pathStyle: cfg.PathStyle,
+		prefix:    prefix,
+		client:    telemetry.HTTPClient("s3", 0),
+	}, nil
+}
+
+// bucketURL addresses the root of the bucket
+func (s *s3Store) bucketURL() *url.URL {
+	u := *s.endpoint
+	base := strings.TrimRight(u.Path, "/")
+	if s.pathStyle {
+		u.Path = base + "/" + s.bucket + "/"
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = base + "/"
+	}
+	u.RawQuery = ""
+	return &u
+}
+
+// objectURL addresses key in the bucket. Keys and the prefix only hold
+// characters that need no escaping, so the path is already canonical.
+func (s *s3Store) objectURL(key string) (*url.URL, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	u := s.bucketURL()
+	u.Path += s.prefix + key
+	return u, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to upload %s: %w", key, s3Error(resp))
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %w", key, s3Error(resp))
+	}
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("failed to remove %s: %w", key, s3Error(resp))
+	}
+}
+
+// listBucketResult is the part of a ListObjectsV2 response List reads
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2 results under prefix
+func (s *s3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	if err := validatePrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	objects := make([]Object, 0)
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.prefix+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u := s.bucketURL()
+		u.RawQuery = canonicalQueryString(query)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		var result listBucketResult
+		if resp.StatusCode != http.StatusOK {
+			err = s3Error(resp)
+		} else {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{
+				Key:          strings.TrimPrefix(content.Key, s.prefix),
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// SignedURL presigns a GET of key that asks S3 to serve it as an attachment
+// named fileName
+func (s *s3Store) SignedURL(key, fileName string, ttl time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	if ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+	return s.presign(u, fileName, ttl, time.Now().UTC()), nil
+}
+
+// presign adds the query parameters and signature of a presigned GET to u
+func (s *s3Store) presign(u *url.URL, fileName string, ttl time.Duration, now time.Time) string {
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if fileName != "" {
+		query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+func (s *s3Store) Name() string { return "s3" }
+
+// do signs req with an Authorization header and sends it
+func (s *s3Store) do(req *http.Request) (*http.Response, error) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + unsignedPayload + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+	return s.client.Do(req)
+}
+
+// scope is the credential scope of a request signed at now
+func (s *s3Store) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request with a key derived from the secret
+// key, date, region and service
+func (s *s3Store) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQueryString sorts and percent-encodes parameters as SigV4
+// requires, with spaces as %20 rather than +
+func canonicalQueryString(values url.Values) string {
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
+
+// s3Error describes a failed response from the error document S3 returns
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if len(body) == 0 {
+		return fmt.Errorf("S3 returned %s", resp.Status)
+	}
+	return fmt.Errorf("S3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,86 @@
+// Package storage keeps attachment files and other documents as objects
+// addressed by key, on the local filesystem or in an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"networth-dashboard/internal/config"
+)
+
+var (
+	// ErrNotFound is returned when no object is stored under a key
+	ErrNotFound = errors.New("object not found")
+	// ErrSignedURLsUnsupported is returned by backends that can only serve
+	// objects through the API
+	ErrSignedURLsUnsupported = errors.New("storage backend does not support signed URLs")
+)
+
+// keyPattern matches the keys a store accepts: slash-separated segments that
+// cannot start with a dot, so a key never names a path outside the store
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// Object describes a stored object
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Store keeps objects under keys such as "attachments/3f9c..."
+type Store interface {
+	// Put stores size bytes read from body under key, replacing any object there
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns the object stored under key, or ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key; a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose keys start with prefix, such as "backups/"
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL returns a URL that downloads the object under key as fileName
+	// without credentials until ttl passes, or ErrSignedURLsUnsupported
+	SignedURL(key, fileName string, ttl time.Duration) (string, error)
+	// Name identifies the backend ("local" or "s3")
+	Name() string
+}
+
+// New creates the store selected by configuration
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "local":
+		log.Printf("INFO: Document storage using local directory %s", cfg.LocalDir)
+		return newLocalStore(cfg.LocalDir), nil
+	case "s3":
+		store, err := newS3Store(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("INFO: Document storage using S3 bucket %s", cfg.S3.Bucket)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (use local or s3)", cfg.Backend)
+	}
+}
+
+// validateKey rejects keys that could escape the store
+func validateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
+
+// validatePrefix rejects list prefixes other than a key followed by a slash
+func validatePrefix(prefix string) error {
+	if !strings.HasSuffix(prefix, "/") || validateKey(strings.TrimSuffix(prefix, "/")) != nil {
+		return fmt.Errorf("invalid storage prefix %q", prefix)
+	}
+	return nil
+}
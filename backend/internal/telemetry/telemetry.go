@@ -0,0 +1,103 @@
+// Package telemetry configures OpenTelemetry tracing and provides helpers for
+// instrumenting handlers, SQL and outbound provider calls.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"networth-dashboard/internal/config"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans created by this application
+const instrumentationName = "networth-dashboard"
+
+// ProviderAttribute names the external data provider behind a span
+const ProviderAttribute = attribute.Key("provider.name")
+
+// Setup installs the global tracer provider and W3C trace context propagation.
+// When tracing is disabled spans are no-ops. The returned function flushes
+// pending spans and must be called on shutdown.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// The exporter reads the standard OTEL_EXPORTER_OTLP_* variables for its
+	// endpoint, headers and TLS settings (default http://localhost:4318)
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	log.Printf("INFO: Tracing enabled for service %s (sample ratio %.2f)", cfg.ServiceName, cfg.SampleRatio)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the application tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartSpan starts an internal span as a child of any span in ctx
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// HTTPClient returns an HTTP client whose requests are traced as client spans
+// named after the provider, so slow calls can be attributed to it
+func HTTPClient(provider string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: otelhttp.NewTransport(http.DefaultTransport,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return provider + " " + r.Method
+			}),
+			otelhttp.WithSpanOptions(trace.WithAttributes(ProviderAttribute.String(provider))),
+		),
+	}
+}
@@ -9,44 +9,90 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "networth-dashboard/docs" // Import generated swagger docs
-	"networth-dashboard/internal/api"
-	"networth-dashboard/internal/config"
-	"networth-dashboard/internal/database"
-	"networth-dashboard/internal/plugins"
+	"networth-dashboard/internal/services"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
 )
 
 func main() {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
-	}
+	// Keep amounts JSON numbers, as they were when money was float64
+	decimal.MarshalJSONWithoutQuotes = true
 
-	// Initialize database
-	db, err := database.Initialize(cfg.Database)
-	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
+	if err := newRootCommand().Execute(); err != nil {
+		log.Fatal(err)
 	}
-	defer db.Close()
+}
 
-	// Initialize plugin manager
-	pluginManager := plugins.NewManager(db.DB)
+// serve runs the API server until SIGINT or SIGTERM
+func serve(a *app, _ *cobra.Command, _ []string) error {
+	if a.cfg.Demo.Enabled {
+		seedDemoMode(services.NewDemoDataService(a.db.DB))
+	}
 
 	// Initialize API server
-	server := api.NewServer(cfg, db.DB, pluginManager)
+	server, err := a.server()
+	if err != nil {
+		return err
+	}
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	port := a.cfg.Server.Port
+
+	// Stop on SIGINT/SIGTERM (e.g. Kubernetes pod termination)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server.StartBackgroundJobs(ctx)
+
+	// Re-read the configuration on SIGHUP
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				server.ReloadConfig()
+			}
+		}
+	}()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on port %s", port)
+		serverErr <- server.Start(":" + port)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		stop()
 	}
 
-	log.Printf("Starting server on port %s", port)
-	if err := server.Start(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// Drain in-flight requests before exiting
+	log.Printf("Shutdown signal received, draining requests (timeout %s)", a.cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown did not complete: %v", err)
 	}
+	log.Println("Server stopped")
+	return nil
 }
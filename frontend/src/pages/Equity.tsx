@@ -150,7 +150,7 @@ function EquityGrantCard({
       </div>
 
       {/* Strike Price for Options */}
-      {grant.grant_type === 'stock_options' && grant.strike_price && (
+      {(grant.grant_type === 'iso' || grant.grant_type === 'nso') && grant.strike_price && (
         <div className="mt-4 pt-4 border-t border-gray-200 dark:border-gray-600">
           <div className="flex justify-between items-center">
             <span className="text-sm text-gray-500 dark:text-gray-400">Strike Price:</span>
@@ -224,7 +224,7 @@ function Equity() {
         let unvested_value = 0
         
         if (current_price > 0) {
-          if (grant.grant_type === 'stock_options' && grant.strike_price) {
+          if ((grant.grant_type === 'iso' || grant.grant_type === 'nso') && grant.strike_price) {
             // For options, value is (current_price - strike_price) * shares
             const optionValue = Math.max(0, current_price - grant.strike_price)
             vested_value = grant.vested_shares * optionValue
@@ -574,7 +574,7 @@ function Equity() {
                     <p className="text-gray-900 dark:text-white">${selectedGrant.current_price.toFixed(2)}</p>
                   </div>
                 )}
-                {selectedGrant.grant_type === 'stock_options' && selectedGrant.strike_price && (
+                {(selectedGrant.grant_type === 'iso' || selectedGrant.grant_type === 'nso') && selectedGrant.strike_price && (
                   <div>
                     <h4 className="text-sm font-medium text-gray-500 dark:text-gray-400 mb-1">Strike Price</h4>
                     <p className="text-gray-900 dark:text-white">${selectedGrant.strike_price.toFixed(2)}</p>
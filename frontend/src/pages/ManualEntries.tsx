@@ -135,7 +135,7 @@ const getEntryValue = (entry: ManualEntry) => {
       return 'N/A'
     case 'morgan_stanley':
       if (data.vested_shares && data.current_price) {
-        if (data.grant_type === 'stock_option' && data.strike_price) {
+        if ((data.grant_type === 'iso' || data.grant_type === 'nso') && data.strike_price) {
           const intrinsicValue = Math.max(0, data.current_price - data.strike_price)
           const totalValue = data.vested_shares * intrinsicValue
           return totalValue > 0 ? `$${totalValue.toLocaleString()}` : '$0'
@@ -205,7 +205,7 @@ class SmartDataService {
       }
 
       // Grant type specific validation
-      if (grant_type === 'stock_option' && !strike_price) {
+      if ((grant_type === 'iso' || grant_type === 'nso') && !strike_price) {
         warnings.push('Strike price is required for stock options')
       }
       if (grant_type === 'rsu' && strike_price) {
@@ -52,6 +52,9 @@ export interface EquityGrant {
   current_price?: number
   data_source: string
   created_at: string
+  expiration_date?: string
+  election_83b_filed_date?: string
+  amt_basis_per_share?: number
 }
 
 export interface VestingSchedule {